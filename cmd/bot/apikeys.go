@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"discord-tars/internal/config"
+	"discord-tars/internal/repository"
+	"discord-tars/internal/repository/postgres"
+	apikeysService "discord-tars/internal/services/apikeys"
+)
+
+const apiKeysUsage = `usage: bot api-keys <list|create|rotate|revoke|usage> [flags]
+
+Manages the keys integrators call the HTTP API with, straight in the database;
+a running bot also serves /admin/api-keys. Scopes are search (GET /api/search),
+ask (POST /api/ask) and admin (the /admin endpoints, and all the others).
+
+  list                               Keys with their requests over the last 30 days
+  create -name NAME -scopes a,b      Create a key and print its secret, once
+  rotate [-grace 24h] ID             Replace a key; the old one works for the grace period
+  revoke ID                          Stop a key from working at once
+  usage [-days 30] ID                A key's requests per day and scope
+`
+
+func runAPIKeys(args []string) error {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "-help" || args[0] == "help" {
+		fmt.Fprint(os.Stderr, apiKeysUsage)
+		if len(args) == 0 {
+			return fmt.Errorf("a subcommand is required")
+		}
+		return flag.ErrHelp
+	}
+	sub, args := args[0], args[1:]
+	fs := flag.NewFlagSet("api-keys "+sub, flag.ContinueOnError)
+	fs.Usage = func() { fmt.Fprint(fs.Output(), apiKeysUsage) }
+	name := fs.String("name", "", "name of the key, e.g. the integration using it (create)")
+	scopes := fs.String("scopes", "", "comma-separated scopes: search, ask, admin (create)")
+	grace := fs.Duration("grace", apikeysService.DefaultGrace, "how long the old key keeps working (rotate)")
+	days := fs.Int("days", apikeysService.UsageDays, "days of usage to show (usage)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadToolConfig()
+	if err != nil {
+		return err
+	}
+	db, err := postgres.NewGormConnection(cfg.Database)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	keys := apikeysService.NewService(repository.NewAPIKeyRepository(db))
+	ctx := context.Background()
+
+	switch sub {
+	case "list":
+		return listAPIKeys(ctx, keys)
+	case "create":
+		key, secret, err := keys.Create(ctx, *name, splitScopes(*scopes))
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Created key %d (%s) with scopes %s. Its secret, shown only now:\n\n%s\n", key.ID, key.Name, strings.Join(key.Scopes, ", "), secret)
+		return nil
+	}
+
+	id, err := keyIDArg(fs)
+	if err != nil {
+		return err
+	}
+	switch sub {
+	case "rotate":
+		key, secret, err := keys.Rotate(ctx, id, *grace)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Key %d replaces key %d, which works until %s. Its secret, shown only now:\n\n%s\n", key.ID, id, time.Now().Add(*grace).Format(time.RFC1123), secret)
+		return nil
+	case "revoke":
+		if err := keys.Revoke(ctx, id); err != nil {
+			return err
+		}
+		fmt.Printf("Revoked key %d.\n", id)
+		return nil
+	case "usage":
+		usage, err := keys.Usage(ctx, id, *days)
+		if err != nil {
+			return err
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "DAY\tSCOPE\tREQUESTS\tFAILURES")
+		for _, u := range usage {
+			fmt.Fprintf(tw, "%s\t%s\t%d\t%d\n", u.Day.Format("2006-01-02"), u.Scope, u.Requests, u.Failures)
+		}
+		return tw.Flush()
+	}
+	return fmt.Errorf("unknown api-keys subcommand %q", sub)
+}
+
+func listAPIKeys(ctx context.Context, keys *apikeysService.Service) error {
+	infos, err := keys.List(ctx)
+	if err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "ID\tNAME\tPREFIX\tSCOPES\tSTATUS\tLAST USED\tREQUESTS (%dd)\tFAILURES\n", apikeysService.UsageDays)
+	for _, info := range infos {
+		status := "active"
+		switch {
+		case info.RevokedAt != nil:
+			status = "revoked"
+		case !info.Active:
+			status = "expired"
+		case info.ExpiresAt != nil:
+			status = "expires " + info.ExpiresAt.Format("2006-01-02 15:04")
+		}
+		lastUsed := "never"
+		if info.LastUsedAt != nil {
+			lastUsed = info.LastUsedAt.Format("2006-01-02 15:04")
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s…\t%s\t%s\t%s\t%d\t%d\n", info.ID, info.Name, info.Prefix, strings.Join(info.Scopes, ","), status, lastUsed, info.Requests, info.Failures)
+	}
+	return tw.Flush()
+}
+
+func splitScopes(list string) []string {
+	var scopes []string
+	for _, scope := range strings.Split(list, ",") {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes
+}
+
+func keyIDArg(fs *flag.FlagSet) (int64, error) {
+	if fs.NArg() != 1 {
+		return 0, fmt.Errorf("a key ID is required, from bot api-keys list")
+	}
+	id, err := strconv.ParseInt(fs.Arg(0), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid key ID %q", fs.Arg(0))
+	}
+	return id, nil
+}
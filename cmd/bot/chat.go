@@ -0,0 +1,286 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+
+	"discord-tars/internal/config"
+	"discord-tars/internal/models"
+	"discord-tars/internal/repository"
+	"discord-tars/internal/repository/postgres"
+	calendarService "discord-tars/internal/services/calendar"
+	discordService "discord-tars/internal/services/discord"
+	openaiService "discord-tars/internal/services/openai"
+	personaService "discord-tars/internal/services/persona"
+	ragService "discord-tars/internal/services/rag"
+	rolloutService "discord-tars/internal/services/rollout"
+	"discord-tars/internal/vcr"
+	"discord-tars/internal/vectorstore"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const (
+	// chatMaxTurns is how many earlier exchanges follow-up questions see
+	chatMaxTurns = 5
+	// chatExcerptChars shortens retrieved context in the display
+	chatExcerptChars = 120
+)
+
+const chatHelp = `Ask a question, or:
+  /context  show or hide the retrieved context
+  /prompt   show or hide the full prompt sent to the model
+  /reset    forget the conversation
+  /quit     leave (or Ctrl-D)
+`
+
+func runChat(args []string) error {
+	fs := flag.NewFlagSet("chat", flag.ContinueOnError)
+	guildID := fs.Int64("guild", 0, "answer as in this server, searching its history and documents")
+	channelID := fs.Int64("channel", 0, "answer as in this channel, whose recent messages fill in when nothing matches")
+	username := fs.String("user", "developer", "name the question is asked as")
+	showContext := fs.Bool("context", true, "show the retrieved context before each answer")
+	showPrompt := fs.Bool("prompt", false, "show the full prompt sent to the model")
+	verbose := fs.Bool("v", false, "show the bot's logs and SQL")
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), `usage: bot chat [flags]
+
+Answers questions typed on stdin through the bot's answer pipeline, without
+connecting to Discord, streaming each answer as it is generated. An answer the
+bot would replace after checking it, e.g. for want of support in the context,
+is shown again as the bot would send it. OFFLINE and AI_RECORD apply, so
+fixtures can stand in for the API.
+
+`)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	cfg, err := config.LoadToolConfig()
+	if err != nil {
+		return err
+	}
+	if cfg.OpenAI.APIKey == "" && !cfg.OpenAI.Offline {
+		return fmt.Errorf("OPENAI_API_KEY is required, or OFFLINE=true to replay fixtures")
+	}
+	if !*verbose {
+		log.SetOutput(io.Discard)
+	}
+
+	db, err := postgres.NewGormConnection(cfg.Database)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	if !*verbose {
+		db.DB = db.Session(&gorm.Session{Logger: logger.Default.LogMode(logger.Silent)})
+	}
+	pipeline, err := newChatPipeline(cfg, db)
+	if err != nil {
+		return err
+	}
+	guild, channel := snowflake(*guildID), snowflake(*channelID)
+
+	fmt.Print(chatHelp)
+	var turns []discordService.ConversationTurn
+	input := bufio.NewScanner(os.Stdin)
+	input.Buffer(make([]byte, 64*1024), 1024*1024)
+	for {
+		fmt.Print("\n> ")
+		if !input.Scan() {
+			fmt.Println()
+			return input.Err()
+		}
+		question := strings.TrimSpace(input.Text())
+		switch question {
+		case "":
+			continue
+		case "/quit", "/exit":
+			return nil
+		case "/reset":
+			turns = nil
+			fmt.Println("Conversation forgotten.")
+			continue
+		case "/context":
+			*showContext = !*showContext
+			fmt.Printf("Context display %s.\n", onOff(*showContext))
+			continue
+		case "/prompt":
+			*showPrompt = !*showPrompt
+			fmt.Printf("Prompt display %s.\n", onOff(*showPrompt))
+			continue
+		case "/help":
+			fmt.Print(chatHelp)
+			continue
+		}
+
+		ctx, draft, err := pipeline.Prepare(context.Background(), question, *username, guild, channel, discordService.Conversation{Turns: turns})
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			continue
+		}
+		if *showContext {
+			printRetrieved(draft.Retrieved)
+		}
+		if *showPrompt {
+			fmt.Printf("── prompt ──\n%s\n────────────\n", draft.Prompt)
+		}
+
+		// Ctrl-C stops the answer rather than the session
+		answerCtx, cancel := signal.NotifyContext(ctx, os.Interrupt)
+		var streamed strings.Builder
+		answer, err := pipeline.Generate(answerCtx, draft, func(text string) {
+			streamed.WriteString(text)
+			fmt.Print(text)
+		})
+		stopped := answerCtx.Err() != nil
+		cancel()
+		fmt.Println()
+		if stopped {
+			fmt.Println("(stopped)")
+			continue
+		}
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			continue
+		}
+		if answer.Text != streamed.String() {
+			fmt.Printf("── the bot answers ──\n%s\n─────────────────────\n", answer.Text)
+		}
+		turns = append(turns, discordService.ConversationTurn{Question: question, Answer: answer.Text})
+		if len(turns) > chatMaxTurns {
+			turns = turns[len(turns)-chatMaxTurns:]
+		}
+	}
+}
+
+// newChatPipeline sets up the answer pipeline the way the bot does, without a
+// Discord session, so author roles and custom emojis are left out
+func newChatPipeline(cfg *config.Config, db *postgres.GormDB) (*discordService.Pipeline, error) {
+	msgRepo := repository.NewMessageRepository(db)
+	msgRepo.SetDiversity(cfg.RAG.DuplicateThreshold, cfg.RAG.MMRLambda)
+	msgRepo.SetReactionBoost(cfg.RAG.ReactionBoost)
+	msgRepo.SetSolvedBoost(cfg.RAG.SolvedBoost)
+	priorityRepo := repository.NewPriorityRepository(db)
+	summaryRepo := repository.NewSummaryRepository(db)
+	attachmentRepo := repository.NewAttachmentRepository(db)
+	if cfg.Security.EncryptMessages {
+		cipher, err := loadCipher(cfg)
+		if err != nil {
+			return nil, err
+		}
+		msgRepo.SetCipher(cipher)
+		priorityRepo.SetCipher(cipher)
+		summaryRepo.SetCipher(cipher)
+		attachmentRepo.SetCipher(cipher)
+	}
+	store, err := vectorstore.New(vectorStoreConfig(cfg))
+	if err != nil {
+		return nil, err
+	}
+	if store != nil {
+		msgRepo.SetVectorStore(store)
+	}
+
+	aiSvc := openaiService.NewService(openaiService.Config{
+		APIKey:         cfg.OpenAI.APIKey,
+		Model:          cfg.OpenAI.Model,
+		EmbeddingModel: cfg.OpenAI.EmbeddingModel,
+		HTTPClient:     vcr.NewClient(vcr.Config{Mode: vcr.ModeFor(cfg.OpenAI.Offline, cfg.OpenAI.Record), Dir: cfg.OpenAI.FixturesDir}),
+	})
+	ragSvc := ragService.NewService(aiSvc, msgRepo, priorityRepo, repository.NewKnowledgeRepository(db), nil)
+	ragSvc.SetQueryRewriting(cfg.RAG.QueryRewrite)
+	if cfg.RAG.ChannelSummaries {
+		ragSvc.SetSummaryRepository(summaryRepo)
+	}
+	if cfg.RAG.FileSearch {
+		ragSvc.SetAttachmentRepository(attachmentRepo)
+	}
+	if cfg.RAG.MessageLanguages {
+		ragSvc.SetLanguageRepository(repository.NewLanguageRepository(db))
+		ragSvc.SetQueryTranslation(cfg.RAG.QueryTranslation)
+	}
+
+	pipeline := discordService.NewPipeline(aiSvc, ragSvc, cfg.RAG.ConfidenceThreshold)
+	personaSvc := personaService.NewService(repository.NewPersonaRepository(db))
+	if err := personaSvc.RefreshModes(context.Background()); err != nil {
+		log.Printf("⚠️ Failed to load persona modes: %v", err)
+	}
+	pipeline.SetPersonaService(personaSvc)
+	// Quality signals of the chat don't count toward rollouts: only the
+	// rollout's settings are applied
+	rolloutSvc := rolloutService.NewService(repository.NewRolloutRepository(db), rolloutService.Config{})
+	if _, err := rolloutSvc.Active(context.Background()); err != nil {
+		log.Printf("⚠️ Failed to load the active rollout: %v", err)
+	}
+	pipeline.SetRolloutService(rolloutSvc)
+	pipeline.SetCalendarService(calendarService.NewService(repository.NewCalendarRepository(db), nil))
+	pipeline.SetInjectionScreen()
+	return pipeline, nil
+}
+
+// snowflake formats a Discord ID flag, leaving it empty when unset
+func snowflake(id int64) string {
+	if id == 0 {
+		return ""
+	}
+	return strconv.FormatInt(id, 10)
+}
+
+// printRetrieved lists what retrieval found, most similar first within each kind
+func printRetrieved(rc *ragService.RetrievedContext) {
+	if rc == nil {
+		fmt.Println("(retrieval failed; -v shows why)")
+		return
+	}
+	fmt.Println("── context ──")
+	for _, r := range rc.Priority {
+		fmt.Printf("📌 %.2f #%s: %s\n", r.Similarity, r.Document.ChannelName, excerpt(r.Document.Content))
+	}
+	for _, r := range rc.Documents {
+		fmt.Printf("📚 %.2f %s: %s\n", r.Similarity, r.Title, excerpt(r.Chunk.Content))
+	}
+	for _, r := range rc.Summaries {
+		fmt.Printf("🗓️ %.2f #%s %s: %s\n", r.Similarity, r.Summary.ChannelName, r.Summary.Day.Format("2006-01-02"), excerpt(r.Summary.Content))
+	}
+	for _, r := range rc.Screenshots {
+		fmt.Printf("🖼️ %.2f %s: %s\n", r.Similarity, r.Attachment.Filename, excerpt(r.Attachment.Content))
+	}
+	for _, r := range rc.Messages {
+		fmt.Printf("💬 %.2f %s: %s\n", r.Similarity, messageSource(r), excerpt(r.Message.Content))
+	}
+	if len(rc.Priority)+len(rc.Documents)+len(rc.Summaries)+len(rc.Screenshots)+len(rc.Messages) == 0 {
+		fmt.Println("(nothing found)")
+	}
+	fmt.Println("─────────────")
+}
+
+func messageSource(r models.SearchResult) string {
+	return fmt.Sprintf("%s in #%s, %s", r.User.Username, r.Channel.Name, r.Message.Timestamp.Format("2006-01-02"))
+}
+
+// excerpt shortens text to a line of the context display
+func excerpt(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if runes := []rune(text); len(runes) > chatExcerptChars {
+		return string(runes[:chatExcerptChars]) + "…"
+	}
+	return text
+}
+
+func onOff(on bool) string {
+	if on {
+		return "on"
+	}
+	return "off"
+}
@@ -0,0 +1,521 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"discord-tars/internal/backup"
+	"discord-tars/internal/config"
+	"discord-tars/internal/export"
+	"discord-tars/internal/importer"
+	"discord-tars/internal/repository"
+	"discord-tars/internal/repository/postgres"
+	"discord-tars/internal/secrets"
+	openaiService "discord-tars/internal/services/openai"
+	ragService "discord-tars/internal/services/rag"
+	"discord-tars/internal/storage"
+	"discord-tars/internal/vectorstore"
+)
+
+const commandUsage = `usage: bot [command] [flags]
+
+Without a command the bot starts normally. Commands:
+  chat      Ask questions from the terminal, without connecting to Discord
+  dashboard Watch a running bot's throughput, queues, token spend and errors
+  backup    Dump messages, embeddings, documents and settings to an archive
+  restore   Load an archive written by backup
+  partition-messages
+            Partition the messages table by guild (stop the bot and worker first)
+  sync-vectors
+            Copy the stored embeddings to the configured VECTOR_STORE
+  import    Index server history from DiscordChatExporter JSON or a Discord data package
+  export-index
+            Write messages with their embeddings to JSON Lines
+  api-keys  Create, rotate, revoke and meter the keys of the HTTP API
+
+Run "bot <command> -h" for its flags.
+`
+
+// runCommand runs a maintenance subcommand and returns the exit code
+func runCommand(name string, args []string) int {
+	var err error
+	switch name {
+	case "chat":
+		err = runChat(args)
+	case "dashboard":
+		err = runDashboard(args)
+	case "backup":
+		err = runBackup(args)
+	case "restore":
+		err = runRestore(args)
+	case "partition-messages":
+		err = runPartitionMessages(args)
+	case "sync-vectors":
+		err = runSyncVectors(args)
+	case "import":
+		err = runImport(args)
+	case "export-index":
+		err = runExportIndex(args)
+	case "api-keys":
+		err = runAPIKeys(args)
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(os.Stderr, commandUsage)
+		return 0
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", name, commandUsage)
+		return 2
+	}
+
+	if errors.Is(err, flag.ErrHelp) {
+		return 0
+	}
+	if err != nil {
+		log.Printf("❌ %s failed: %v", name, err)
+		return 1
+	}
+	return 0
+}
+
+func runBackup(args []string) error {
+	fs := flag.NewFlagSet("backup", flag.ContinueOnError)
+	output := fs.String("o", "", `write the archive to this file ("-" for stdout) instead of backup storage`)
+	only := fs.String("only", "", "comma-separated groups to include: "+strings.Join(backup.Groups, ", "))
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	groups, err := backup.ParseGroups(*only)
+	if err != nil {
+		return err
+	}
+
+	cfg, svc, err := openBackupService()
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	if *output == "" {
+		store, err := openBackupStore(cfg, nil)
+		if err != nil {
+			return err
+		}
+		key, _, err := backup.NewArchiver(svc, store, 0).Upload(ctx, groups)
+		if err != nil {
+			return err
+		}
+		log.Printf("✅ Backup stored as %s", key)
+		return nil
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "-" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", *output, err)
+		}
+		defer f.Close()
+		w = f
+	}
+	if _, err := svc.Dump(ctx, w, groups); err != nil {
+		return err
+	}
+	log.Printf("✅ Backup written to %s", *output)
+	return nil
+}
+
+func runRestore(args []string) error {
+	fs := flag.NewFlagSet("restore", flag.ContinueOnError)
+	input := fs.String("i", "", `read the archive from this file ("-" for stdin)`)
+	key := fs.String("key", "", "read the archive from backup storage")
+	latest := fs.Bool("latest", false, "restore the newest archive in backup storage")
+	only := fs.String("only", "", "comma-separated groups to restore: "+strings.Join(backup.Groups, ", "))
+	replace := fs.Bool("replace", false, "empty the restored tables first instead of merging into them")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	sources := 0
+	for _, set := range []bool{*input != "", *key != "", *latest} {
+		if set {
+			sources++
+		}
+	}
+	if sources != 1 {
+		return fmt.Errorf("choose exactly one of -i, -key or -latest")
+	}
+
+	var groups []string
+	if *only != "" {
+		var err error
+		if groups, err = backup.ParseGroups(*only); err != nil {
+			return err
+		}
+	}
+
+	cfg, svc, err := openBackupService()
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	var r io.Reader = os.Stdin
+	switch {
+	case *input != "" && *input != "-":
+		f, err := os.Open(*input)
+		if err != nil {
+			return fmt.Errorf("failed to open %s: %w", *input, err)
+		}
+		defer f.Close()
+		r = f
+	case *key != "" || *latest:
+		store, err := openBackupStore(cfg, nil)
+		if err != nil {
+			return err
+		}
+		archiver := backup.NewArchiver(svc, store, 0)
+		if *latest {
+			if *key, err = archiver.Latest(ctx); err != nil {
+				return fmt.Errorf("failed to find the latest backup: %w", err)
+			}
+		}
+		body, err := archiver.Open(ctx, *key)
+		if err != nil {
+			return fmt.Errorf("failed to open backup %s: %w", *key, err)
+		}
+		defer body.Close()
+		r = body
+		log.Printf("📥 Restoring %s", *key)
+	}
+
+	manifest, err := svc.Restore(ctx, r, backup.RestoreOptions{Groups: groups, Replace: *replace})
+	if err != nil {
+		return err
+	}
+	log.Printf("✅ Restored backup from %s", manifest.CreatedAt.Format("2006-01-02 15:04 MST"))
+	return nil
+}
+
+func runPartitionMessages(args []string) error {
+	fs := flag.NewFlagSet("partition-messages", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), `usage: bot partition-messages
+
+Converts the messages table into one partitioned by guild, with a partition for
+each guild and a default one for the rest. Foreign keys referencing messages
+are dropped. Messages are copied in one transaction, so stop the bot and the
+worker first and leave room for a second copy of the table.
+`)
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	cfg, err := config.LoadToolConfig()
+	if err != nil {
+		return err
+	}
+	db, err := postgres.NewGormConnection(cfg.Database)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	guilds, err := repository.NewPartitionRepository(db).PartitionMessages(context.Background())
+	if err != nil {
+		return err
+	}
+	log.Printf("✅ Partitioned messages across %d guilds", guilds)
+	return nil
+}
+
+func runSyncVectors(args []string) error {
+	fs := flag.NewFlagSet("sync-vectors", flag.ContinueOnError)
+	batch := fs.Int("batch", 500, "embeddings copied per request")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	cfg, err := config.LoadToolConfig()
+	if err != nil {
+		return err
+	}
+	store, err := vectorstore.New(vectorStoreConfig(cfg))
+	if err != nil {
+		return err
+	}
+	if store == nil {
+		return fmt.Errorf("VECTOR_STORE is %s, which searches the embeddings already in Postgres", cfg.VectorStore.Backend)
+	}
+	db, err := postgres.NewGormConnection(cfg.Database)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	msgRepo := repository.NewMessageRepository(db)
+	msgRepo.SetVectorStore(store)
+	copied, err := msgRepo.SyncVectorStore(context.Background(), *batch, func(copied int) {
+		log.Printf("📤 Copied %d embeddings", copied)
+	})
+	if err != nil {
+		return err
+	}
+	log.Printf("✅ Copied %d embeddings to %s", copied, cfg.VectorStore.Backend)
+	return nil
+}
+
+func runImport(args []string) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	guildID := fs.Int64("guild", 0, "only import messages of this server")
+	noEmbed := fs.Bool("no-embed", false, "store the messages without embedding them; /rag reindex the channels later")
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), `usage: bot import [flags] <export>...
+
+Stores and embeds the messages of DiscordChatExporter JSON exports (a file or a
+directory of them) or of a Discord data package (extracted or as its zip),
+which holds only its owner's messages. Messages already stored are skipped, as
+are direct messages and those of bots.
+
+`)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return fmt.Errorf("no export given")
+	}
+	cfg, err := config.LoadToolConfig()
+	if err != nil {
+		return err
+	}
+	if !*noEmbed && cfg.OpenAI.APIKey == "" {
+		return fmt.Errorf("OPENAI_API_KEY is required to embed imported messages (or use -no-embed)")
+	}
+	db, err := postgres.NewGormConnection(cfg.Database)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	msgRepo := repository.NewMessageRepository(db)
+	if cfg.Security.EncryptMessages {
+		cipher, err := loadCipher(cfg)
+		if err != nil {
+			return err
+		}
+		msgRepo.SetCipher(cipher)
+	}
+	store, err := vectorstore.New(vectorStoreConfig(cfg))
+	if err != nil {
+		return err
+	}
+	if store != nil {
+		msgRepo.SetVectorStore(store)
+	}
+
+	aiSvc := openaiService.NewService(openaiService.Config{
+		APIKey:         cfg.OpenAI.APIKey,
+		Model:          cfg.OpenAI.Model,
+		EmbeddingModel: cfg.OpenAI.EmbeddingModel,
+	})
+	ragSvc := ragService.NewService(aiSvc, msgRepo, nil, nil, nil)
+	ragSvc.SetIndexRepository(repository.NewIndexRepository(db))
+	ragSvc.SetEmbeddingModel(cfg.OpenAI.EmbeddingModel)
+	if cfg.RAG.CodeSearch {
+		ragSvc.SetCodeRepository(repository.NewCodeRepository(db))
+	}
+	ingestFilter, err := ragService.NewIngestFilter(ragService.FilterConfig{
+		MinLength:       cfg.RAG.IngestMinLength,
+		Blocklist:       cfg.RAG.IngestBlocklist,
+		SkipLinkOnly:    cfg.RAG.IngestSkipLinkOnly,
+		CommandPrefixes: cfg.RAG.IngestCommandPrefixes,
+	})
+	if err != nil {
+		return fmt.Errorf("invalid INGEST_BLOCKLIST: %w", err)
+	}
+	ingestFilter.SetClassifier(ragService.ClassifierConfig{
+		SkipBelow:  cfg.RAG.IngestSkipBelow,
+		EmbedAbove: cfg.RAG.IngestEmbedAbove,
+		Model:      cfg.RAG.IngestClassifierModel,
+	})
+	ragSvc.SetIngestFilter(ingestFilter)
+
+	ctx := context.Background()
+	var progress ragService.ImportProgress
+	for _, export := range fs.Args() {
+		log.Printf("📥 Importing %s", export)
+		err := importer.Read(export, func(channel importer.Channel, messages []importer.Message) error {
+			if *guildID != 0 && channel.GuildID != *guildID {
+				return nil
+			}
+			if err := ragSvc.ImportMessages(ctx, channel, messages, !*noEmbed, &progress); err != nil {
+				return fmt.Errorf("failed to import #%s: %w", channel.Name, err)
+			}
+			log.Printf("📥 #%s: %d imported, %d embedded, %d already stored", channel.Name, progress.Imported, progress.Embedded, progress.Existing)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	log.Printf("✅ Imported %d messages (%d embedded, %d failed to embed); skipped %d already stored and %d from bots",
+		progress.Imported, progress.Embedded, progress.Failed, progress.Existing, progress.Skipped)
+	return nil
+}
+
+func runExportIndex(args []string) error {
+	fs := flag.NewFlagSet("export-index", flag.ContinueOnError)
+	output := fs.String("o", "", `write the export to this file ("-" for stdout) instead of the file store`)
+	guilds := fs.String("guild", "", "comma-separated IDs of the servers to export; every server when empty")
+	embedded := fs.Bool("embedded", false, "only export messages with an embedding")
+	compress := fs.Bool("gzip", true, "gzip the export")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	opts := export.Options{EmbeddedOnly: *embedded, Gzip: *compress}
+	for _, field := range strings.Split(*guilds, ",") {
+		if field = strings.TrimSpace(field); field == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(field, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid server ID %q", field)
+		}
+		opts.GuildIDs = append(opts.GuildIDs, id)
+	}
+
+	cfg, err := config.LoadToolConfig()
+	if err != nil {
+		return err
+	}
+	db, err := postgres.NewGormConnection(cfg.Database)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	msgRepo := repository.NewMessageRepository(db)
+	if cfg.Security.EncryptMessages {
+		cipher, err := loadCipher(cfg)
+		if err != nil {
+			return err
+		}
+		msgRepo.SetCipher(cipher)
+	}
+	exporter := export.NewExporter(msgRepo)
+	ctx := context.Background()
+	progress := func(written int) {
+		log.Printf("📤 Exported %d messages", written)
+	}
+
+	if *output == "" {
+		store, err := storage.New(storageConfig(cfg))
+		if err != nil {
+			return err
+		}
+		key, written, err := exporter.Upload(ctx, store, opts, progress)
+		if err != nil {
+			return err
+		}
+		log.Printf("✅ Exported %d messages as %s", written, key)
+		return nil
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "-" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", *output, err)
+		}
+		defer f.Close()
+		w = f
+	}
+	written, err := exporter.Write(ctx, w, opts, progress)
+	if err != nil {
+		return err
+	}
+	log.Printf("✅ Exported %d messages to %s", written, *output)
+	return nil
+}
+
+func openBackupService() (*config.Config, *backup.Service, error) {
+	cfg, err := config.LoadToolConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+	db, err := postgres.NewGormConnection(cfg.Database)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cfg, backup.NewService(db.DB), nil
+}
+
+// openBackupStore returns the dedicated backup bucket when one is configured,
+// otherwise the file store (opened from config when fileStore is nil)
+func openBackupStore(cfg *config.Config, fileStore storage.Store) (storage.Store, error) {
+	if cfg.Backup.S3Bucket != "" {
+		storeCfg := storageConfig(cfg)
+		storeCfg.Backend = "s3"
+		storeCfg.S3Bucket = cfg.Backup.S3Bucket
+		return storage.New(storeCfg)
+	}
+	if fileStore != nil {
+		return fileStore, nil
+	}
+	return storage.New(storageConfig(cfg))
+}
+
+func storageConfig(cfg *config.Config) storage.Config {
+	return storage.Config{
+		Backend:     cfg.Storage.Backend,
+		LocalPath:   cfg.Storage.LocalPath,
+		PublicURL:   cfg.Storage.PublicURL,
+		SigningKey:  cfg.Storage.SigningKey,
+		S3Endpoint:  cfg.Storage.S3Endpoint,
+		S3Region:    cfg.Storage.S3Region,
+		S3Bucket:    cfg.Storage.S3Bucket,
+		S3AccessKey: cfg.Storage.S3AccessKey,
+		S3SecretKey: cfg.Storage.S3SecretKey,
+		S3PathStyle: cfg.Storage.S3PathStyle,
+	}
+}
+
+func loadCipher(cfg *config.Config) (*secrets.Cipher, error) {
+	if !cfg.Security.HasEncryptionKey() {
+		return nil, fmt.Errorf("ENCRYPT_MESSAGES is set but no encryption key is configured")
+	}
+	key, err := secrets.LoadKey(context.Background(), secrets.KeySource{
+		Key:           cfg.Security.EncryptionKey,
+		File:          cfg.Security.EncryptionKeyFile,
+		KMSCiphertext: cfg.Security.KMSEncryptedKey,
+		KMS: secrets.KMSConfig{
+			Region:       cfg.Security.KMSRegion,
+			Endpoint:     cfg.Security.KMSEndpoint,
+			AccessKey:    cfg.Security.AWSAccessKey,
+			SecretKey:    cfg.Security.AWSSecretKey,
+			SessionToken: cfg.Security.AWSSessionToken,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load encryption key: %w", err)
+	}
+	cipher, err := secrets.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid encryption key: %w", err)
+	}
+	return cipher, nil
+}
+
+func vectorStoreConfig(cfg *config.Config) vectorstore.Config {
+	return vectorstore.Config{
+		Backend:          cfg.VectorStore.Backend,
+		QdrantURL:        cfg.VectorStore.QdrantURL,
+		QdrantAPIKey:     cfg.VectorStore.QdrantAPIKey,
+		QdrantCollection: cfg.VectorStore.QdrantCollection,
+	}
+}
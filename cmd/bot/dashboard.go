@@ -0,0 +1,218 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"discord-tars/internal/config"
+	overviewService "discord-tars/internal/services/overview"
+)
+
+const (
+	// dashboardRows caps the commands, guilds and errors shown
+	dashboardRows = 8
+	// dashboardWidth is where long lines are cut
+	dashboardWidth = 110
+)
+
+// clearScreen moves the cursor home and clears the terminal. Redrawing the
+// whole screen each refresh is all a read-only view needs, without a TUI
+// framework.
+const clearScreen = "\033[H\033[2J"
+
+func runDashboard(args []string) error {
+	cfg := config.LoadClientConfig()
+	fs := flag.NewFlagSet("dashboard", flag.ContinueOnError)
+	baseURL := fs.String("url", fmt.Sprintf("http://localhost:%d", cfg.App.HTTPPort), "address of the bot's HTTP server")
+	token := fs.String("token", cfg.App.AdminToken, "admin API token (ADMIN_API_TOKEN)")
+	interval := fs.Duration("interval", 2*time.Second, "how often to refresh")
+	once := fs.Bool("once", false, "print one snapshot and exit, without clearing the screen")
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), `usage: bot dashboard [flags]
+
+Shows a running bot's command throughput, queue depths, token spend, recent
+errors and activity per guild, refreshed until Ctrl-C. It reads the
+/admin/overview endpoint, so the bot needs ADMIN_API_TOKEN set.
+
+`)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *token == "" {
+		return fmt.Errorf("an admin token is required: set ADMIN_API_TOKEN or pass -token")
+	}
+	if *interval < time.Second {
+		return fmt.Errorf("-interval must be at least 1s")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	url := strings.TrimRight(*baseURL, "/") + "/admin/overview"
+	client := &http.Client{Timeout: 5 * time.Second}
+
+	if *once {
+		o, err := fetchOverview(ctx, client, url, *token)
+		if err != nil {
+			return err
+		}
+		renderDashboard(os.Stdout, url, o, nil)
+		return nil
+	}
+
+	var last *overviewService.Overview
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+	for {
+		o, err := fetchOverview(ctx, client, url, *token)
+		if ctx.Err() != nil {
+			fmt.Println()
+			return nil
+		}
+		if err == nil {
+			last = o
+		}
+		fmt.Print(clearScreen)
+		renderDashboard(os.Stdout, url, last, err)
+
+		select {
+		case <-ctx.Done():
+			fmt.Println()
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func fetchOverview(ctx context.Context, client *http.Client, url, token string) (*overviewService.Overview, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		var body struct {
+			Error string `json:"error"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&body)
+		if body.Error == "" {
+			body.Error = resp.Status
+		}
+		return nil, fmt.Errorf("%s: %s", url, body.Error)
+	}
+	var o overviewService.Overview
+	if err := json.NewDecoder(resp.Body).Decode(&o); err != nil {
+		return nil, fmt.Errorf("invalid overview: %w", err)
+	}
+	return &o, nil
+}
+
+// renderDashboard writes a snapshot; when the latest refresh failed, the
+// error shows above the last good one
+func renderDashboard(w io.Writer, url string, o *overviewService.Overview, fetchErr error) {
+	if fetchErr != nil {
+		fmt.Fprintf(w, "⚠️ %s\n\n", clip(fetchErr.Error()))
+	}
+	if o == nil {
+		fmt.Fprintf(w, "Waiting for %s…\n", url)
+		return
+	}
+	fmt.Fprintf(w, "T.A.R.S  %s  up %s  %s\n\n", url, time.Duration(o.Uptime)*time.Second, o.Time.Local().Format("15:04:05"))
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "COMMANDS\t%d/min\t%d/h\tp95\n", o.Commands.LastMinute, o.Commands.LastHour)
+	for n, c := range o.Commands.ByCommand {
+		if n == dashboardRows {
+			fmt.Fprintf(tw, "  … %d more\t\t\t\n", len(o.Commands.ByCommand)-n)
+			break
+		}
+		fmt.Fprintf(tw, "  %s\t%d\t%d\t%s\n", c.Command, c.LastMinute, c.LastHour, time.Duration(c.P95)*time.Millisecond)
+	}
+	tw.Flush()
+
+	fmt.Fprintf(w, "\nQUEUES    embeddings %d   jobs %s   outbox %s\n",
+		o.Queues.Embeddings, statusCounts(o.Queues.Jobs, "queued", "running", "failed"), statusCounts(o.Queues.Outbox, "pending", "failed"))
+	if o.Ingest != nil {
+		fmt.Fprintf(w, "INGEST    embedded %d   skipped %.0f%%\n", o.Ingest.Embedded, o.Ingest.SkipRate*100)
+	}
+
+	t := o.Tokens
+	fmt.Fprintf(w, "TOKENS    prompt %s   completion %s   embeddings %s   in %d requests\n",
+		humanCount(t.PromptTokens), humanCount(t.CompletionTokens), humanCount(t.EmbeddingTokens), t.Requests)
+	models := make([]string, 0, len(t.ByModel))
+	for model := range t.ByModel {
+		models = append(models, model)
+	}
+	sort.Slice(models, func(a, b int) bool { return t.ByModel[models[a]] > t.ByModel[models[b]] })
+	for _, model := range models {
+		fmt.Fprintf(w, "  %s %s\n", model, humanCount(t.ByModel[model]))
+	}
+
+	fmt.Fprintln(w)
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "GUILDS\tcmds/min\tcmds/h\ttokens")
+	for n, g := range o.Guilds {
+		if n == dashboardRows {
+			fmt.Fprintf(tw, "  … %d more\t\t\t\n", len(o.Guilds)-n)
+			break
+		}
+		name := g.Name
+		if name == "" {
+			name = fmt.Sprint(g.GuildID)
+		}
+		fmt.Fprintf(tw, "  %s\t%d\t%d\t%s\n", name, g.LastMinute, g.LastHour, humanCount(g.Tokens))
+	}
+	tw.Flush()
+
+	fmt.Fprintf(w, "\nERRORS    %d since start\n", o.Errors.Total)
+	for n, e := range o.Errors.Recent {
+		if n == dashboardRows {
+			break
+		}
+		fmt.Fprintf(w, "  %s\n", clip(e.Time.Local().Format("15:04:05")+" "+e.Message))
+	}
+}
+
+// statusCounts lists the counts of some statuses, e.g. "queued 3, running 1"
+func statusCounts(counts map[string]int64, statuses ...string) string {
+	parts := make([]string, len(statuses))
+	for n, status := range statuses {
+		parts[n] = fmt.Sprintf("%s %d", status, counts[status])
+	}
+	return strings.Join(parts, ", ")
+}
+
+// humanCount shortens large counts, e.g. 1.2M
+func humanCount(n int64) string {
+	switch {
+	case n >= 1_000_000:
+		return fmt.Sprintf("%.1fM", float64(n)/1_000_000)
+	case n >= 1_000:
+		return fmt.Sprintf("%.1fk", float64(n)/1_000)
+	}
+	return fmt.Sprint(n)
+}
+
+// clip cuts a line to the dashboard's width
+func clip(line string) string {
+	if runes := []rune(line); len(runes) > dashboardWidth {
+		return string(runes[:dashboardWidth-1]) + "…"
+	}
+	return line
+}
@@ -1,21 +1,92 @@
 package main
 
 import (
+	"context"
+	"fmt"
+	"io"
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
+	"time"
 
+	"discord-tars/internal/backup"
 	"discord-tars/internal/config"
+	"discord-tars/internal/events"
+	"discord-tars/internal/export"
+	"discord-tars/internal/leader"
+	"discord-tars/internal/mentions"
+	"discord-tars/internal/models"
 	"discord-tars/internal/repository"
 	"discord-tars/internal/repository/postgres"
+	"discord-tars/internal/secrets"
+	"discord-tars/internal/server"
+	agentService "discord-tars/internal/services/agent"
+	announceService "discord-tars/internal/services/announce"
+	apiService "discord-tars/internal/services/api"
+	apikeysService "discord-tars/internal/services/apikeys"
+	auditService "discord-tars/internal/services/audit"
+	bookmarksService "discord-tars/internal/services/bookmarks"
+	calendarService "discord-tars/internal/services/calendar"
+	credentialsService "discord-tars/internal/services/credentials"
+	dashboardService "discord-tars/internal/services/dashboard"
+	debuglogService "discord-tars/internal/services/debuglog"
+	digestService "discord-tars/internal/services/digest"
 	discordService "discord-tars/internal/services/discord"
+	duplicatesService "discord-tars/internal/services/duplicates"
+	feedsService "discord-tars/internal/services/feeds"
+	forumsService "discord-tars/internal/services/forums"
+	githubService "discord-tars/internal/services/github"
+	helpdeskService "discord-tars/internal/services/helpdesk"
+	highlightsService "discord-tars/internal/services/highlights"
+	incidentService "discord-tars/internal/services/incident"
+	"discord-tars/internal/services/jobs"
+	knowledgeService "discord-tars/internal/services/knowledge"
+	loreService "discord-tars/internal/services/lore"
+	maintenanceService "discord-tars/internal/services/maintenance"
+	memoryService "discord-tars/internal/services/memory"
+	moodService "discord-tars/internal/services/mood"
+	notesService "discord-tars/internal/services/notes"
+	ocrService "discord-tars/internal/services/ocr"
+	onboardingService "discord-tars/internal/services/onboarding"
 	openaiService "discord-tars/internal/services/openai"
+	outboxService "discord-tars/internal/services/outbox"
+	overviewService "discord-tars/internal/services/overview"
+	partitionsService "discord-tars/internal/services/partitions"
+	personaService "discord-tars/internal/services/persona"
+	pollService "discord-tars/internal/services/poll"
+	quizService "discord-tars/internal/services/quiz"
 	ragService "discord-tars/internal/services/rag"
+	remindersService "discord-tars/internal/services/reminders"
+	rolloutService "discord-tars/internal/services/rollout"
+	sandboxService "discord-tars/internal/services/sandbox"
+	"discord-tars/internal/services/scheduler"
+	"discord-tars/internal/services/slo"
+	standupService "discord-tars/internal/services/standup"
+	startersService "discord-tars/internal/services/starters"
+	suggestService "discord-tars/internal/services/suggest"
+	summarizeService "discord-tars/internal/services/summarize"
+	timezoneService "discord-tars/internal/services/timezone"
+	trackerService "discord-tars/internal/services/tracker"
 	voiceService "discord-tars/internal/services/voice"
+	xpService "discord-tars/internal/services/xp"
+	"discord-tars/internal/storage"
+	"discord-tars/internal/vcr"
+	"discord-tars/internal/vectorstore"
 )
 
 func main() {
+	// Maintenance subcommands run instead of the bot
+	if len(os.Args) > 1 {
+		os.Exit(runCommand(os.Args[1], os.Args[2:]))
+	}
+
+	// Keep the latest errors for the admin overview
+	errorLog := server.NewErrorLog(20)
+	log.SetOutput(io.MultiWriter(os.Stderr, errorLog))
+
 	log.Println("🚀 Starting Discord T.A.R.S...")
 
 	// Load configuration
@@ -40,40 +111,644 @@ func main() {
 	}
 	log.Println("✅ pgvector extension verified")
 
+	// Load the encryption key used for per-guild secrets and message content
+	var cipher *secrets.Cipher
+	if cfg.Security.HasEncryptionKey() {
+		key, err := secrets.LoadKey(context.Background(), secrets.KeySource{
+			Key:           cfg.Security.EncryptionKey,
+			File:          cfg.Security.EncryptionKeyFile,
+			KMSCiphertext: cfg.Security.KMSEncryptedKey,
+			KMS: secrets.KMSConfig{
+				Region:       cfg.Security.KMSRegion,
+				Endpoint:     cfg.Security.KMSEndpoint,
+				AccessKey:    cfg.Security.AWSAccessKey,
+				SecretKey:    cfg.Security.AWSSecretKey,
+				SessionToken: cfg.Security.AWSSessionToken,
+			},
+		})
+		if err != nil {
+			log.Fatalf("❌ Failed to load encryption key: %v", err)
+		}
+		if cipher, err = secrets.NewCipher(key); err != nil {
+			log.Fatalf("❌ Invalid encryption key: %v", err)
+		}
+	} else {
+		log.Printf("⚠️ No encryption key configured; per-server API keys are disabled")
+	}
+
 	// Initialize repositories
 	msgRepo := repository.NewMessageRepository(db)
+	msgRepo.SetDiversity(cfg.RAG.DuplicateThreshold, cfg.RAG.MMRLambda)
+	msgRepo.SetReactionBoost(cfg.RAG.ReactionBoost)
+	msgRepo.SetSolvedBoost(cfg.RAG.SolvedBoost)
+	vectorStore, err := vectorstore.New(vectorStoreConfig(cfg))
+	if err != nil {
+		log.Fatalf("❌ Failed to initialize the vector store: %v", err)
+	}
+	if vectorStore != nil {
+		msgRepo.SetVectorStore(vectorStore)
+	}
+	priorityRepo := repository.NewPriorityRepository(db)
+	memoryRepo := repository.NewMemoryRepository(db)
+	questionRepo := repository.NewQuestionRepository(db)
+	summaryRepo := repository.NewSummaryRepository(db)
+	codeRepo := repository.NewCodeRepository(db)
+	attachmentRepo := repository.NewAttachmentRepository(db)
+	languageRepo := repository.NewLanguageRepository(db)
+	forumRepo := repository.NewForumRepository(db)
+	helpRepo := repository.NewHelpRepository(db)
+	sandboxRepo := repository.NewSandboxRepository(db)
+	timezoneRepo := repository.NewTimezoneRepository(db)
+	rolloutRepo := repository.NewRolloutRepository(db)
+	duplicateRepo := repository.NewDuplicateRepository(db)
+	highlightRepo := repository.NewHighlightRepository(db)
+	jobRepo := repository.NewJobRepository(db)
+	outboxRepo := repository.NewOutboxRepository(db)
+	auditRepo := repository.NewAuditRepository(db)
+	noteRepo := repository.NewNoteRepository(db)
+	bookmarkRepo := repository.NewBookmarkRepository(db)
+	answerLogRepo := repository.NewAnswerLogRepository(db)
+	apiKeyRepo := repository.NewAPIKeyRepository(db)
+	if cfg.Security.EncryptMessages {
+		msgRepo.SetCipher(cipher)
+		priorityRepo.SetCipher(cipher)
+		memoryRepo.SetCipher(cipher)
+		questionRepo.SetCipher(cipher)
+		summaryRepo.SetCipher(cipher)
+		codeRepo.SetCipher(cipher)
+		attachmentRepo.SetCipher(cipher)
+		forumRepo.SetCipher(cipher)
+		duplicateRepo.SetCipher(cipher)
+		highlightRepo.SetCipher(cipher)
+		jobRepo.SetCipher(cipher)
+		outboxRepo.SetCipher(cipher)
+		auditRepo.SetCipher(cipher)
+		noteRepo.SetCipher(cipher)
+		bookmarkRepo.SetCipher(cipher)
+		answerLogRepo.SetCipher(cipher)
+		log.Printf("🔒 Message content is encrypted at rest")
+	}
+	digestRepo := repository.NewDigestRepository(db)
+	standupRepo := repository.NewStandupRepository(db)
+	pollRepo := repository.NewPollRepository(db)
+	quizRepo := repository.NewQuizRepository(db)
+	xpRepo := repository.NewXPRepository(db)
+	starterRepo := repository.NewStarterRepository(db)
+	incidentRepo := repository.NewIncidentRepository(db)
+	announcementRepo := repository.NewAnnouncementRepository(db)
+	personaRepo := repository.NewPersonaRepository(db)
+	moodRepo := repository.NewMoodRepository(db)
+	onboardingRepo := repository.NewOnboardingRepository(db)
+	githubRepo := repository.NewGitHubRepository(db)
+	feedRepo := repository.NewFeedRepository(db)
+	calendarRepo := repository.NewCalendarRepository(db)
+	trackerRepo := repository.NewTrackerRepository(db)
+	knowledgeRepo := repository.NewKnowledgeRepository(db)
+	credentialRepo := repository.NewCredentialRepository(db)
+	indexRepo := repository.NewIndexRepository(db)
+
+	// Initialize file storage
+	fileStore, err := storage.New(storageConfig(cfg))
+	if err != nil {
+		log.Fatalf("❌ Failed to initialize storage: %v", err)
+	}
+	log.Printf("✅ File storage ready (%s)", cfg.Storage.Backend)
+
+	// Record or replay AI requests in local development
+	aiHTTP := vcr.NewClient(vcr.Config{Mode: vcr.ModeFor(cfg.OpenAI.Offline, cfg.OpenAI.Record), Dir: cfg.OpenAI.FixturesDir})
+	if cfg.OpenAI.Offline {
+		log.Printf("📼 Offline: replaying AI responses from %s", cfg.OpenAI.FixturesDir)
+	} else if cfg.OpenAI.Record {
+		log.Printf("📼 Recording AI responses to %s", cfg.OpenAI.FixturesDir)
+	}
 
 	// Initialize AI service
-	aiSvc := openaiService.NewService(openaiService.Config{
-		APIKey: cfg.OpenAI.APIKey,
-		Model:  cfg.OpenAI.Model,
+	openaiSvc := openaiService.NewService(openaiService.Config{
+		APIKey:         cfg.OpenAI.APIKey,
+		Model:          cfg.OpenAI.Model,
+		EmbeddingModel: cfg.OpenAI.EmbeddingModel,
+		HTTPClient:     aiHTTP,
+		ModelOverrides: true, // For rollouts trying another model
+	})
+
+	// Route AI requests to each guild's own key when one is configured
+	aiSvc := credentialsService.NewService(credentialRepo, cipher, openaiSvc, credentialsService.Config{
+		DefaultOpenAIModel: cfg.OpenAI.Model,
+		RequireOwnKey:      cfg.OpenAI.RequireGuildKey,
+		GlobalConcurrency:  cfg.OpenAI.GlobalConcurrency,
+		GuildConcurrency:   cfg.OpenAI.GuildConcurrency,
+		QueueTimeout:       cfg.OpenAI.QueueTimeout,
+		HTTPClient:         aiHTTP,
 	})
 
 	// Initialize voice service
 	voiceSvc := voiceService.NewService(voiceService.Config{
 		OpenAIAPIKey: cfg.OpenAI.APIKey,
 		TTSModel:     cfg.OpenAI.TTSModel,
+		HTTPClient:   aiHTTP,
 	})
+	voiceSvc.SetRecordingStore(fileStore)
+	voiceSvc.SetClientResolver(aiSvc.OpenAIClient)
 
 	// Initialize Discord bot
+	mentionPolicy, err := mentions.ParsePolicy(cfg.Discord.AllowedMentions)
+	if err != nil {
+		log.Fatalf("❌ Invalid ALLOWED_MENTIONS: %v", err)
+	}
+	log.Printf("✅ Mention policy: %s", mentionPolicy)
 	bot, err := discordService.NewBot(discordService.BotConfig{
-		Token:   cfg.Discord.Token,
-		GuildID: cfg.Discord.GuildID,
+		Token:               cfg.Discord.Token,
+		GuildID:             cfg.Discord.GuildID,
+		ConfidenceThreshold: cfg.RAG.ConfidenceThreshold,
+		PresenceTemplates:   cfg.Discord.PresenceTemplates,
+		PresenceInterval:    cfg.Discord.PresenceInterval,
+
+		ResponseCacheTTL:        cfg.RAG.ResponseCacheTTL,
+		ResponseCacheSimilarity: cfg.RAG.ResponseCacheSimilarity,
+
+		ExternalIndexing: cfg.Worker.Enabled && cfg.Events.Backend == "redis",
+		AllowedMentions:  mentionPolicy,
+		ForumSuggestions: cfg.RAG.ForumSuggestions,
+
+		StageTranscription: cfg.Discord.StageTranscription,
 	}, aiSvc, nil, voiceSvc)
 	if err != nil {
 		log.Fatalf("❌ Failed to create bot: %v", err)
 	}
 
+	// Initialize the event bus the Discord handlers publish to
+	bus, err := events.New(events.Config{
+		Backend:       cfg.Events.Backend,
+		QueueSize:     cfg.Events.QueueSize,
+		RedisAddr:     fmt.Sprintf("%s:%d", cfg.Redis.Host, cfg.Redis.Port),
+		RedisPassword: cfg.Redis.Password,
+		RedisDB:       cfg.Redis.DB,
+		StreamMaxLen:  cfg.Events.StreamMaxLen,
+	})
+	if err != nil {
+		log.Fatalf("❌ Failed to initialize event bus: %v", err)
+	}
+	bot.SetEventBus(bus)
+	log.Printf("✅ Event bus ready (%s)", cfg.Events.Backend)
+
 	// Initialize RAG service with bot session
-	ragSvc := ragService.NewService(aiSvc, msgRepo, bot.GetSession())
+	ragSvc := ragService.NewService(aiSvc, msgRepo, priorityRepo, knowledgeRepo, bot.GetSession())
+	ragSvc.SetQueryRewriting(cfg.RAG.QueryRewrite)
+	ragSvc.SetIndexRepository(indexRepo)
+	ragSvc.SetEmbeddingModel(cfg.OpenAI.EmbeddingModel)
+	if cfg.RAG.ChannelSummaries {
+		ragSvc.SetSummaryRepository(summaryRepo)
+	}
+	if cfg.RAG.CodeSearch {
+		ragSvc.SetCodeRepository(codeRepo)
+	}
+	if cfg.RAG.FileSearch {
+		ragSvc.SetAttachmentRepository(attachmentRepo)
+		ocrEngine, err := ocrService.New(ocrService.Config{
+			Engine:        cfg.RAG.OCREngine,
+			APIKey:        cfg.OpenAI.APIKey,
+			Model:         cfg.RAG.OCRModel,
+			HTTPClient:    aiHTTP,
+			TesseractPath: cfg.RAG.TesseractPath,
+			Languages:     cfg.RAG.OCRLanguages,
+		})
+		if err != nil {
+			log.Fatalf("❌ Failed to initialize OCR: %v", err)
+		}
+		if ocrEngine != nil {
+			ragSvc.SetOCR(ocrEngine)
+			log.Printf("🖼️ Reading the text of shared images with %s", cfg.RAG.OCREngine)
+		}
+	}
+	ingestFilter, err := ragService.NewIngestFilter(ragService.FilterConfig{
+		MinLength:       cfg.RAG.IngestMinLength,
+		Blocklist:       cfg.RAG.IngestBlocklist,
+		SkipLinkOnly:    cfg.RAG.IngestSkipLinkOnly,
+		CommandPrefixes: cfg.RAG.IngestCommandPrefixes,
+		SpamThreshold:   cfg.RAG.IngestSpamThreshold,
+		SpamWindow:      cfg.RAG.IngestSpamWindow,
+	})
+	if err != nil {
+		log.Fatalf("❌ Invalid INGEST_BLOCKLIST: %v", err)
+	}
+	ingestFilter.SetClassifier(ragService.ClassifierConfig{
+		SkipBelow:  cfg.RAG.IngestSkipBelow,
+		EmbedAbove: cfg.RAG.IngestEmbedAbove,
+		Model:      cfg.RAG.IngestClassifierModel,
+	})
+	ragSvc.SetIngestFilter(ingestFilter)
+	if cfg.RAG.MessageLanguages {
+		ragSvc.SetLanguageRepository(languageRepo)
+		ragSvc.SetQueryTranslation(cfg.RAG.QueryTranslation)
+	}
 	bot.SetRAGService(ragSvc)
 
+	// Track reindexes, backfills and digests as jobs admins can manage with /jobs
+	host, err := os.Hostname()
+	if err != nil {
+		host = "bot"
+	}
+	jobRunner := jobs.NewRunner(jobRepo, fmt.Sprintf("bot-%s-%d", host, os.Getpid()), cfg.Worker.PollInterval)
+	ragSvc.RegisterJobs(jobRunner)
+	bot.SetJobRunner(jobRunner)
+
+	// Initialize timezones, which times given to /remind and new schedules are read in
+	bot.SetTimezoneService(timezoneService.NewService(timezoneRepo))
+	reminderSvc := remindersService.NewService(jobRunner, bot.GetSession(), remindersService.Config{
+		MaxAhead: cfg.Scheduler.ReminderMaxAhead,
+	})
+	bot.SetReminderService(reminderSvc)
+	auditSvc := auditService.NewService(auditRepo, cfg.Security.AuditRetention)
+	bot.SetAuditLog(auditSvc)
+	bot.SetInjectionGuard(cfg.Security.InjectionAlerts)
+	bot.SetCredentialService(aiSvc)
+	if cfg.Memory.Enabled {
+		bot.SetMemoryService(memoryService.NewService(aiSvc, memoryRepo, memoryService.Config{
+			TokenBudget: cfg.Memory.TokenBudget,
+			KeepTurns:   cfg.Memory.KeepTurns,
+			TTL:         cfg.Memory.TTL,
+		}))
+	}
+	bot.SetSuggestService(suggestService.NewService(questionRepo, priorityRepo))
+	var duplicateSvc *duplicatesService.Service
+	if cfg.RAG.DuplicateQuestions {
+		duplicateSvc = duplicatesService.NewService(aiSvc, duplicateRepo, priorityRepo, duplicatesService.Config{
+			AnswerSimilarity: cfg.RAG.DuplicateAnswerSimilarity,
+			FAQSimilarity:    cfg.RAG.DuplicateFAQSimilarity,
+			MaxAge:           cfg.RAG.DuplicateAnswerMaxAge,
+			Cooldown:         cfg.RAG.DuplicateCooldown,
+		})
+		bot.SetDuplicateService(duplicateSvc)
+	}
+
+	// Initialize latency SLO tracking and slow search alerts
+	latencyTracker := slo.NewTracker(slo.Config{
+		Targets:       cfg.Monitoring.CommandSLOs,
+		DefaultTarget: cfg.Monitoring.CommandSLO,
+		WebhookURL:    cfg.Monitoring.AlertWebhookURL,
+		Cooldown:      cfg.Monitoring.AlertCooldown,
+	})
+	bot.SetLatencyTracker(latencyTracker)
+	db.SetSlowQueryHook(cfg.Monitoring.SlowSearchThreshold, latencyTracker.ReportSlowQuery)
+
+	// Initialize canary rollouts of answer settings, alerting operators on rollbacks
+	rolloutSvc := rolloutService.NewService(rolloutRepo, rolloutService.Config{
+		MinSamples:           cfg.Rollout.MinSamples,
+		MaxErrorIncrease:     cfg.Rollout.MaxErrorIncrease,
+		MaxUncertainIncrease: cfg.Rollout.MaxUncertainIncrease,
+		MaxNegativeIncrease:  cfg.Rollout.MaxNegativeIncrease,
+		Alert:                latencyTracker.Alert,
+	})
+	if _, err := rolloutSvc.Active(context.Background()); err != nil {
+		log.Printf("⚠️ Failed to load the active rollout: %v", err)
+	}
+	bot.SetRolloutService(rolloutSvc)
+
+	// Initialize the multi-step research agent
+	agentTools := agentService.MathTools()
+	if !cfg.Agent.DisableWeb {
+		agentTools = append(agentTools, agentService.WebTools(cfg.Agent.WebSearchAPIKey)...)
+	}
+	bot.SetAgentService(agentService.NewService(aiSvc, agentService.Config{
+		MaxSteps:   cfg.Agent.MaxSteps,
+		TimeBudget: cfg.Agent.TimeBudget,
+	}, agentTools...))
+	if cfg.Storage.ArchiveAttachments {
+		ragSvc.SetAttachmentStore(fileStore, cfg.Storage.MaxAttachmentBytes)
+		bot.SetFileStore(fileStore)
+	}
+
+	// Deliver digests, reminders and alerts through the outbox, so a failed
+	// send is retried rather than lost
+	outboxSvc := outboxService.NewService(outboxRepo, bot.GetSession(), outboxService.Config{
+		MaxAttempts: cfg.Outbox.MaxAttempts,
+		Retention:   cfg.Outbox.Retention,
+	})
+	reminderSvc.SetOutbox(outboxSvc)
+
+	// Initialize summarization and digest delivery
+	summarizeSvc := summarizeService.NewService(aiSvc, msgRepo)
+	bot.SetSummarizeService(summarizeSvc)
+	digestSvc := digestService.NewService(digestRepo, summarizeSvc, bot.GetSession())
+	digestSvc.SetOutbox(outboxSvc)
+	if !cfg.Worker.Enabled {
+		digestSvc.SetJobRunner(jobRunner, jobs.Options{MaxAttempts: cfg.Worker.MaxAttempts})
+	}
+	bot.SetDigestService(digestSvc)
+
+	// Initialize standup assistant
+	standupSvc := standupService.NewService(aiSvc, standupRepo, bot.GetSession())
+	standupSvc.SetOutbox(outboxSvc)
+	bot.SetStandupService(standupSvc)
+
+	// Initialize polls
+	pollSvc := pollService.NewService(aiSvc, pollRepo, msgRepo, bot.GetSession())
+	bot.SetPollService(pollSvc)
+
+	// Initialize quizzes
+	quizSvc := quizService.NewService(aiSvc, quizRepo, bot.GetSession())
+	bot.SetQuizService(quizSvc)
+
+	// Initialize activity XP, leaderboards and level-up announcements
+	bot.SetXPService(xpService.NewService(aiSvc, xpRepo, bot.GetSession()))
+
+	// Initialize scheduled conversation starters
+	starterSvc := startersService.NewService(aiSvc, starterRepo, bot.GetSession())
+	starterSvc.SetOutbox(outboxSvc)
+	bot.SetStarterService(starterSvc)
+
+	// Initialize incident mode, drafting postmortems with the summarizer
+	bot.SetIncidentService(incidentService.NewService(incidentRepo, summarizeSvc, bot.GetSession()))
+
+	// Initialize personal and channel notebooks
+	bot.SetNoteService(notesService.NewService(aiSvc, noteRepo))
+
+	// Initialize private message bookmarks
+	bot.SetBookmarkService(bookmarksService.NewService(aiSvc, bookmarkRepo))
+
+	// Initialize forum post tracking, solved answers and similar post suggestions
+	bot.SetForumService(forumsService.NewService(aiSvc, forumRepo, bot.GetSession()))
+
+	// Initialize answer suggestions for new posts in help channels
+	if cfg.RAG.HelpAnswers {
+		bot.SetHelpdeskService(helpdeskService.NewService(aiSvc, msgRepo, helpRepo, helpdeskService.Config{
+			MinSimilarity: cfg.RAG.HelpAnswerSimilarity,
+			Cooldown:      cfg.RAG.HelpAnswerCooldown,
+		}))
+	}
+
+	// Initialize sandbox mode, which holds back replies to try changes on live traffic
+	bot.SetSandboxService(sandboxService.NewService(sandboxRepo, sandboxService.Config{
+		All:       cfg.Discord.Sandbox,
+		ChannelID: cfg.Discord.SandboxChannelID,
+	}))
+	if cfg.Discord.Sandbox {
+		log.Println("🧪 Sandbox mode: replies are logged instead of sent")
+	}
+
+	// Initialize log analysis, which cites threads where an error was solved
+	bot.SetDebugLogService(debuglogService.NewService(aiSvc, msgRepo))
+
+	// Initialize announcement drafting
+	bot.SetAnnounceService(announceService.NewService(aiSvc, announcementRepo, priorityRepo, msgRepo, bot.GetSession()))
+
+	// Initialize the lore keeper, which answers from session notes channels
+	bot.SetLoreService(loreService.NewService(aiSvc, priorityRepo))
+
+	// Initialize per-guild personas and their scheduled modes
+	personaSvc := personaService.NewService(personaRepo)
+	if err := personaSvc.RefreshModes(context.Background()); err != nil {
+		log.Printf("⚠️ Failed to load persona modes: %v", err)
+	}
+	bot.SetPersonaService(personaSvc)
+
+	// Initialize mood tracking
+	var moodSvc *moodService.Service
+	if cfg.RAG.MoodTracking {
+		moodSvc = moodService.NewService(aiSvc, moodRepo, msgRepo, bot.GetSession(), cfg.RAG.MoodMinMessages)
+		bot.SetMoodService(moodSvc)
+	}
+	if cfg.RAG.ToxicityWarnings {
+		toxicityWatcher := moodService.NewWatcher(aiSvc, moodRepo, bot.GetSession(), cfg.RAG.ToxicityCoolOff)
+		toxicityWatcher.SetOutbox(outboxSvc)
+		bot.SetToxicityWatcher(toxicityWatcher)
+	}
+
+	// Initialize the highlights channel
+	highlightSvc := highlightsService.NewService(aiSvc, highlightRepo, bot.GetSession())
+	highlightSvc.SetOutbox(outboxSvc)
+	bot.SetHighlightService(highlightSvc)
+
+	// Initialize new member onboarding
+	onboardingSvc := onboardingService.NewService(aiSvc, ragSvc, onboardingRepo, bot.GetSession())
+	bot.SetOnboardingService(onboardingSvc)
+
+	// Initialize per-guild message partitions, once messages is partitioned
+	partitionRepo := repository.NewPartitionRepository(db)
+	if vectorStore != nil {
+		partitionRepo.SetVectorStore(vectorStore)
+	}
+	partitionSvc := partitionsService.NewService(partitionRepo, cfg.Database.PartitionRetention)
+	bot.SetPartitionService(partitionSvc)
+
+	// Initialize vector index maintenance
+	location, _ := time.LoadLocation(cfg.Maintenance.Timezone)
+	maintenanceSvc, err := maintenanceService.NewService(repository.NewMaintenanceRepository(db), maintenanceService.Config{
+		Interval:       cfg.Maintenance.Interval,
+		Window:         cfg.Maintenance.Window,
+		Location:       location,
+		BloatThreshold: cfg.Maintenance.BloatThreshold,
+	})
+	if err != nil {
+		log.Fatalf("❌ Invalid MAINTENANCE_WINDOW: %v", err)
+	}
+
+	// Initialize HTTP server for webhooks and health checks
+	httpServer := server.NewServer(cfg.App.HTTPPort)
+	if local, ok := fileStore.(*storage.LocalStore); ok {
+		httpServer.Handle("GET "+storage.LocalFilesPath, local.Handler())
+	}
+	// Admin endpoints answer ADMIN_API_TOKEN and API keys with the admin scope
+	apiKeySvc := apikeysService.NewService(apiKeyRepo)
+	adminOnly := func(next http.HandlerFunc) http.HandlerFunc {
+		return apiKeySvc.Require(models.ScopeAdmin, cfg.App.AdminToken, next)
+	}
+	overviewSvc := overviewService.NewService(overviewService.Sources{
+		Latency: latencyTracker,
+		RAG:     ragSvc,
+		Jobs:    jobRepo,
+		Outbox:  outboxRepo,
+		Errors:  errorLog,
+		GuildName: func(guildID int64) string {
+			if guild, err := bot.GetSession().State.Guild(strconv.FormatInt(guildID, 10)); err == nil {
+				return guild.Name
+			}
+			return ""
+		},
+	})
+	httpServer.HandleFunc("GET /admin/overview", adminOnly(overviewSvc.HandleStats))
+	httpServer.HandleFunc("GET /admin/rag/status", adminOnly(ragSvc.HandleStatus))
+	httpServer.HandleFunc("GET /admin/latency", adminOnly(latencyTracker.HandleStats))
+	httpServer.HandleFunc("GET /admin/jobs", adminOnly(jobRunner.HandleStats))
+	httpServer.HandleFunc("GET /admin/outbox", adminOnly(outboxSvc.HandleList))
+	httpServer.HandleFunc("POST /admin/outbox/{id}/retry", adminOnly(outboxSvc.HandleRetry))
+	httpServer.HandleFunc("GET /admin/rollouts", adminOnly(rolloutSvc.HandleList))
+	httpServer.HandleFunc("POST /admin/rollouts", adminOnly(rolloutSvc.HandleStart))
+	httpServer.HandleFunc("POST /admin/rollouts/active/percent", adminOnly(rolloutSvc.HandlePercent))
+	httpServer.HandleFunc("POST /admin/rollouts/active/promote", adminOnly(rolloutSvc.HandlePromote))
+	httpServer.HandleFunc("POST /admin/rollouts/active/stop", adminOnly(rolloutSvc.HandleStop))
+	httpServer.HandleFunc("GET /admin/maintenance", adminOnly(maintenanceSvc.HandleStatus))
+	httpServer.HandleFunc("POST /admin/maintenance/run", adminOnly(maintenanceSvc.HandleRun))
+	httpServer.HandleFunc("GET /admin/api-keys", adminOnly(apiKeySvc.HandleList))
+	httpServer.HandleFunc("POST /admin/api-keys", adminOnly(apiKeySvc.HandleCreate))
+	httpServer.HandleFunc("POST /admin/api-keys/{id}/rotate", adminOnly(apiKeySvc.HandleRotate))
+	httpServer.HandleFunc("POST /admin/api-keys/{id}/revoke", adminOnly(apiKeySvc.HandleRevoke))
+	httpServer.HandleFunc("GET /admin/api-keys/{id}/usage", adminOnly(apiKeySvc.HandleUsage))
+
+	// Initialize the API integrators ask questions and search with, using API
+	// keys or API_TOKEN
+	apiSvc := apiService.NewService(apiService.Sources{Ask: bot.Ask, Search: bot.Search})
+	apiSvc.SetKeyService(apiKeySvc)
+	apiSvc.Register(httpServer, cfg.App.APIToken)
+
+	// Initialize the web dashboard for guild admins
+	var dashboardSvc *dashboardService.Service
+	if cfg.Dashboard.Enabled() {
+		dashboardSvc, err = dashboardService.NewService(dashboardService.Config{
+			ClientID:        cfg.Dashboard.ClientID,
+			ClientSecret:    cfg.Dashboard.ClientSecret,
+			PublicURL:       cfg.Dashboard.PublicURL,
+			SessionSecret:   cfg.Dashboard.SessionSecret,
+			SessionTTL:      cfg.Dashboard.SessionTTL,
+			AnswerRetention: cfg.Dashboard.AnswerRetention,
+		}, bot.GetSession(), answerLogRepo, priorityRepo, personaSvc)
+		if err != nil {
+			log.Fatalf("❌ Failed to initialize the dashboard: %v", err)
+		}
+		if duplicateSvc != nil {
+			dashboardSvc.SetDuplicateService(duplicateSvc)
+		}
+		dashboardSvc.SetAuditLog(auditSvc)
+		dashboardSvc.SetLatencyTracker(latencyTracker)
+		dashboardSvc.Register(httpServer)
+		bot.SetDashboardService(dashboardSvc)
+		log.Printf("🖥️ Dashboard enabled at %s/dashboard/", cfg.Dashboard.PublicURL)
+	}
+
+	// Initialize GitHub integration
+	githubSvc := githubService.NewService(githubService.Config{
+		Token:         cfg.GitHub.Token,
+		WebhookSecret: cfg.GitHub.WebhookSecret,
+	}, aiSvc, githubRepo, bot.GetSession())
+	bot.SetGitHubService(githubSvc)
+	httpServer.HandleFunc("POST /webhooks/github", githubSvc.HandleWebhook)
+
+	// Initialize feed watcher
+	feedSvc := feedsService.NewService(aiSvc, feedRepo, bot.GetSession())
+	bot.SetFeedService(feedSvc)
+
+	// Initialize calendars
+	calendarSvc := calendarService.NewService(calendarRepo, bot.GetSession())
+	calendarSvc.SetOutbox(outboxSvc)
+	bot.SetCalendarService(calendarSvc)
+
+	// Initialize issue tracker lookups
+	bot.SetTrackerService(trackerService.NewService(trackerRepo))
+
+	// Initialize documentation sync
+	knowledgeSvc := knowledgeService.NewService(aiSvc, knowledgeRepo)
+	knowledgeSvc.SetSnapshotStore(fileStore)
+	bot.SetKnowledgeService(knowledgeSvc)
+
+	// Initialize scheduler for background jobs
+	sched := scheduler.NewScheduler()
+	var elector *leader.Elector
+	if cfg.Scheduler.LeaderElection {
+		sqlDB, err := db.DB.DB()
+		if err != nil {
+			log.Fatalf("❌ Failed to get database connection: %v", err)
+		}
+		elector = leader.New(sqlDB, "bot", cfg.Scheduler.LeaderCheckInterval)
+		sched.SetLeaderCheck(elector.IsLeader)
+		bot.SetLeaderElector(elector)
+	}
+	sched.Register("standup-reminders", cfg.Scheduler.StandupInterval, standupSvc.ProcessDue)
+	sched.Register("poll-closing", cfg.Scheduler.PollInterval, pollSvc.CloseExpired)
+	sched.Register("quiz-closing", cfg.Scheduler.PollInterval, quizSvc.CloseExpired)
+	sched.Register("calendar-sync", cfg.Scheduler.CalendarSyncInterval, calendarSvc.SyncAll)
+	sched.Register("calendar-reminders", cfg.Scheduler.CalendarReminderInterval, calendarSvc.SendReminders)
+	sched.Register("knowledge-sync", cfg.Scheduler.KnowledgeSyncInterval, knowledgeSvc.SyncAll)
+	sched.RegisterLocal("persona-modes", cfg.Scheduler.PersonaModeInterval, personaSvc.RefreshModes)
+	// Each replica judges the answers it gave, and picks up rollouts changed by others
+	sched.RegisterLocal("rollout-evaluation", cfg.Rollout.Interval, rolloutSvc.Evaluate)
+	sched.Register("outbox-dispatch", cfg.Outbox.Interval, outboxSvc.Dispatch)
+	sched.Register("outbox-pruning", time.Hour, outboxSvc.Prune)
+	sched.Register("audit-pruning", time.Hour, auditSvc.Prune)
+	if dashboardSvc != nil {
+		sched.Register("answer-log-pruning", time.Hour, dashboardSvc.Prune)
+	}
+	if moodSvc != nil {
+		sched.Register("mood-scoring", cfg.Scheduler.MoodScoringInterval, moodSvc.ScoreDays)
+	}
+	// The worker runs these when it is deployed, keeping the bot latency-focused
+	if !cfg.Worker.Enabled {
+		sched.Register("digest-delivery", cfg.Scheduler.DigestInterval, digestSvc.DeliverDue)
+		sched.Register("feed-polling", cfg.Scheduler.FeedInterval, feedSvc.PollDue)
+		sched.Register("highlight-digests", cfg.Scheduler.HighlightDigestInterval, highlightSvc.PostDigests)
+		sched.Register("starter-posts", cfg.Scheduler.StarterInterval, starterSvc.PostDue)
+		if cfg.RAG.ChannelSummaries {
+			dailyIndexer := summarizeService.NewDailyIndexer(summarizeSvc, summaryRepo, cfg.RAG.SummaryMinMessages)
+			sched.Register("channel-summaries", cfg.Scheduler.ChannelSummaryInterval, dailyIndexer.IndexDays)
+		}
+		if duplicateSvc != nil {
+			sched.Register("duplicate-pruning", cfg.Scheduler.DuplicatePruneInterval, duplicateSvc.Prune)
+		}
+		janitor := storage.NewJanitor(fileStore,
+			storage.Rule{Prefix: storage.PrefixAttachments, MaxAge: cfg.Storage.AttachmentRetention},
+			storage.Rule{Prefix: storage.PrefixRecordings, MaxAge: cfg.Storage.RecordingRetention},
+			storage.Rule{Prefix: storage.PrefixImages, MaxAge: cfg.Storage.ImageRetention},
+			storage.Rule{Prefix: storage.PrefixDocuments, MaxAge: cfg.Storage.DocumentRetention},
+		)
+		sched.Register("storage-cleanup", cfg.Scheduler.StorageCleanupInterval, janitor.Cleanup)
+		if cfg.Maintenance.Interval > 0 {
+			sched.Register("index-maintenance", maintenanceService.CheckInterval, maintenanceSvc.Run)
+		}
+		sched.Register("partition-pruning", time.Hour, partitionSvc.Prune)
+	}
+	if cfg.Backup.Interval > 0 {
+		backupStore, err := openBackupStore(cfg, fileStore)
+		if err != nil {
+			log.Fatalf("❌ Failed to initialize backup storage: %v", err)
+		}
+		archiver := backup.NewArchiver(backup.NewService(db.DB), backupStore, cfg.Backup.Retention)
+		sched.Register("backup", cfg.Backup.Interval, archiver.Run)
+	}
+	if cfg.Export.Interval > 0 {
+		opts := export.Options{GuildIDs: cfg.Export.GuildIDs, EmbeddedOnly: cfg.Export.EmbeddedOnly, Gzip: true}
+		sched.Register("index-export", cfg.Export.Interval, export.NewExporter(msgRepo).Job(fileStore, opts, cfg.Export.Retention))
+	}
+
+	// Campaign before connecting, so the leader registers commands on its first READY
+	if elector != nil {
+		elector.Start()
+		defer elector.Stop()
+	}
+
 	// Start bot
 	if err := bot.Start(); err != nil {
 		log.Fatalf("❌ Failed to start bot: %v", err)
 	}
 	defer bot.Stop()
 
+	jobRunner.Start()
+	defer jobRunner.Stop()
+
+	sched.Start()
+	defer sched.Stop()
+
+	httpServer.Start()
+	defer httpServer.Stop()
+
+	// Runtime diagnostics for operators
+	if cfg.Monitoring.DebugAddr != "" {
+		server.PublishRuntimeVars()
+		server.PublishDebugVar("embedding_queue_depth", func() interface{} {
+			return ragSvc.EmbeddingQueueDepth()
+		})
+		server.PublishDebugVar("ingest", func() interface{} {
+			return ragSvc.IngestStats()
+		})
+		server.PublishDebugVar("voice", func() interface{} {
+			return voiceSvc.Stats()
+		})
+		server.PublishDebugVar("gateway", func() interface{} {
+			return bot.GatewayStats()
+		})
+		debugServer := server.NewDebugServer(cfg.Monitoring.DebugAddr, cfg.Monitoring.DebugToken)
+		debugServer.Start()
+		defer debugServer.Stop()
+	}
+
 	log.Println("🤖 T.A.R.S is now online with RAG and voice capabilities!")
 
 	// Wait for interrupt signal
@@ -0,0 +1,393 @@
+// Command openapi-client generates the types and methods of pkg/client from
+// the API's OpenAPI document. It understands the part of OpenAPI the API
+// uses: object schemas, JSON request and response bodies and query
+// parameters. Run it after changing the document, or with -check in CI to
+// fail when the client is out of date.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"sort"
+	"strings"
+)
+
+func main() {
+	specPath := flag.String("spec", "internal/services/api/openapi.json", "OpenAPI document to generate from")
+	out := flag.String("out", "pkg/client/generated.go", "Go file to write")
+	pkg := flag.String("package", "client", "Package of the generated file")
+	check := flag.Bool("check", false, "Fail if the generated file is out of date instead of writing it")
+	flag.Parse()
+
+	data, err := os.ReadFile(*specPath)
+	if err != nil {
+		log.Fatalf("❌ Failed to read the OpenAPI document: %v", err)
+	}
+	var doc document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		log.Fatalf("❌ Failed to parse the OpenAPI document: %v", err)
+	}
+	code, err := generate(&doc, *pkg, *specPath)
+	if err != nil {
+		log.Fatalf("❌ Failed to generate the client: %v", err)
+	}
+
+	if *check {
+		current, err := os.ReadFile(*out)
+		if err != nil || !bytes.Equal(current, code) {
+			log.Fatalf("❌ %s is out of date with %s; run make api-client", *out, *specPath)
+		}
+		return
+	}
+	if err := os.WriteFile(*out, code, 0o644); err != nil {
+		log.Fatalf("❌ Failed to write the client: %v", err)
+	}
+	log.Printf("✅ Generated %s from %s", *out, *specPath)
+}
+
+// document is the part of an OpenAPI 3 document the generator reads
+type document struct {
+	Paths      map[string]map[string]*operation `json:"paths"`
+	Components struct {
+		Schemas map[string]*schema `json:"schemas"`
+	} `json:"components"`
+}
+
+type operation struct {
+	OperationID string      `json:"operationId"`
+	Summary     string      `json:"summary"`
+	Description string      `json:"description"`
+	Parameters  []parameter `json:"parameters"`
+	RequestBody *struct {
+		Content map[string]struct {
+			Schema *schema `json:"schema"`
+		} `json:"content"`
+	} `json:"requestBody"`
+	Responses map[string]*struct {
+		Content map[string]struct {
+			Schema *schema `json:"schema"`
+		} `json:"content"`
+	} `json:"responses"`
+}
+
+type parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Required    bool    `json:"required"`
+	Description string  `json:"description"`
+	Schema      *schema `json:"schema"`
+}
+
+type schema struct {
+	Ref         string     `json:"$ref"`
+	Type        string     `json:"type"`
+	Format      string     `json:"format"`
+	Description string     `json:"description"`
+	Items       *schema    `json:"items"`
+	Properties  properties `json:"properties"`
+	Required    []string   `json:"required"`
+}
+
+// properties keeps an object's properties in the document's order, which
+// the generated struct fields follow
+type properties []property
+
+type property struct {
+	Name   string
+	Schema *schema
+}
+
+func (p *properties) UnmarshalJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if _, err := dec.Token(); err != nil {
+		return err
+	}
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		var s schema
+		if err := dec.Decode(&s); err != nil {
+			return err
+		}
+		*p = append(*p, property{Name: key.(string), Schema: &s})
+	}
+	return nil
+}
+
+var methods = []string{"get", "post", "put", "patch", "delete"}
+
+func generate(doc *document, pkg, specPath string) ([]byte, error) {
+	var b bytes.Buffer
+	names := make([]string, 0, len(doc.Components.Schemas))
+	for name := range doc.Components.Schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := writeStruct(&b, name, doc.Components.Schemas[name]); err != nil {
+			return nil, err
+		}
+	}
+
+	paths := make([]string, 0, len(doc.Paths))
+	for path := range doc.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		for _, method := range methods {
+			if op := doc.Paths[path][method]; op != nil {
+				if err := writeOperation(&b, strings.ToUpper(method), path, op); err != nil {
+					return nil, fmt.Errorf("%s %s: %w", method, path, err)
+				}
+			}
+		}
+	}
+
+	var file bytes.Buffer
+	fmt.Fprintf(&file, "// Code generated by cmd/openapi-client from %s; DO NOT EDIT.\n\n", specPath)
+	fmt.Fprintf(&file, "package %s\n\n", pkg)
+	file.WriteString("import (\n")
+	for _, path := range []string{"context", "net/url", "strconv", "time"} {
+		if uses(b.Bytes(), path) {
+			fmt.Fprintf(&file, "%q\n", path)
+		}
+	}
+	file.WriteString(")\n\n")
+	file.Write(b.Bytes())
+
+	code, err := format.Source(file.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to format the generated code: %w", err)
+	}
+	return code, nil
+}
+
+func writeStruct(b *bytes.Buffer, name string, s *schema) error {
+	if s.Type != "object" {
+		return fmt.Errorf("schema %s: only object schemas are supported", name)
+	}
+	description := "is generated from the OpenAPI schema of the same name"
+	if s.Description != "" {
+		description = "is " + lowerFirst(s.Description)
+	}
+	writeComment(b, name+" "+description)
+	fmt.Fprintf(b, "type %s struct {\n", name)
+	for _, prop := range s.Properties {
+		typ, err := goType(prop.Schema)
+		if err != nil {
+			return fmt.Errorf("schema %s, property %s: %w", name, prop.Name, err)
+		}
+		tag := prop.Name
+		if !contains(s.Required, prop.Name) {
+			tag += ",omitempty"
+		}
+		fmt.Fprintf(b, "%s %s `json:%q`", goName(prop.Name), typ, tag)
+		if prop.Schema.Description != "" {
+			fmt.Fprintf(b, " // %s", prop.Schema.Description)
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("}\n\n")
+	return nil
+}
+
+func writeOperation(b *bytes.Buffer, method, path string, op *operation) error {
+	if op.OperationID == "" {
+		return fmt.Errorf("an operationId is required")
+	}
+	result, err := responseType(op)
+	if err != nil {
+		return err
+	}
+
+	args := "ctx context.Context"
+	query := "nil"
+	body := "nil"
+	if len(op.Parameters) > 0 {
+		paramsType := op.OperationID + "Params"
+		if err := writeParams(b, paramsType, op); err != nil {
+			return err
+		}
+		args += ", params " + paramsType
+		query = "params.values()"
+	}
+	if op.RequestBody != nil {
+		content, ok := op.RequestBody.Content["application/json"]
+		if !ok || content.Schema == nil {
+			return fmt.Errorf("only JSON request bodies are supported")
+		}
+		typ, err := goType(content.Schema)
+		if err != nil {
+			return err
+		}
+		args += ", body " + typ
+		body = "body"
+	}
+
+	comment := fmt.Sprintf("%s calls %s %s", op.OperationID, method, path)
+	if op.Summary != "" {
+		comment += ", to " + lowerFirst(op.Summary)
+	}
+	writeComment(b, comment)
+	if op.Description != "" {
+		b.WriteString("//\n")
+		writeComment(b, op.Description)
+	}
+	fmt.Fprintf(b, "func (c *Client) %s(%s) (*%s, error) {\n", op.OperationID, args, result)
+	fmt.Fprintf(b, "var out %s\n", result)
+	fmt.Fprintf(b, "if err := c.do(ctx, %q, %q, %s, %s, &out); err != nil {\nreturn nil, err\n}\n", method, path, query, body)
+	b.WriteString("return &out, nil\n}\n\n")
+	return nil
+}
+
+// writeParams writes the struct holding an operation's query parameters and
+// its encoding, leaving out optional parameters left at their zero value
+func writeParams(b *bytes.Buffer, name string, op *operation) error {
+	var fields, encode strings.Builder
+	for _, param := range op.Parameters {
+		if param.In != "query" {
+			return fmt.Errorf("parameter %s: only query parameters are supported", param.Name)
+		}
+		field := goName(param.Name)
+		typ, err := goType(param.Schema)
+		if err != nil {
+			return fmt.Errorf("parameter %s: %w", param.Name, err)
+		}
+		var value, zero string
+		switch typ {
+		case "string":
+			value, zero = "p."+field, `""`
+		case "int":
+			value, zero = "strconv.Itoa(p."+field+")", "0"
+		default:
+			return fmt.Errorf("parameter %s: only string and integer parameters are supported", param.Name)
+		}
+
+		fmt.Fprintf(&fields, "%s %s", field, typ)
+		if description := paramDescription(param); description != "" {
+			fmt.Fprintf(&fields, " // %s", description)
+		}
+		fields.WriteString("\n")
+		if param.Required {
+			fmt.Fprintf(&encode, "v.Set(%q, %s)\n", param.Name, value)
+		} else {
+			fmt.Fprintf(&encode, "if p.%s != %s {\nv.Set(%q, %s)\n}\n", field, zero, param.Name, value)
+		}
+	}
+	writeComment(b, name+" are the query parameters of "+op.OperationID)
+	fmt.Fprintf(b, "type %s struct {\n%s}\n\n", name, fields.String())
+	fmt.Fprintf(b, "func (p %s) values() url.Values {\nv := url.Values{}\n%sreturn v\n}\n\n", name, encode.String())
+	return nil
+}
+
+func paramDescription(param parameter) string {
+	description := param.Description
+	if param.Required {
+		description += " (required)"
+	}
+	return strings.TrimSpace(description)
+}
+
+// responseType is the type of an operation's successful JSON response
+func responseType(op *operation) (string, error) {
+	response, ok := op.Responses["200"]
+	if !ok || response == nil {
+		return "", fmt.Errorf("a 200 response is required")
+	}
+	content, ok := response.Content["application/json"]
+	if !ok || content.Schema == nil || content.Schema.Ref == "" {
+		return "", fmt.Errorf("the 200 response must be a JSON schema reference")
+	}
+	return goType(content.Schema)
+}
+
+func goType(s *schema) (string, error) {
+	if s == nil {
+		return "", fmt.Errorf("missing schema")
+	}
+	if s.Ref != "" {
+		const prefix = "#/components/schemas/"
+		if !strings.HasPrefix(s.Ref, prefix) {
+			return "", fmt.Errorf("unsupported reference %s", s.Ref)
+		}
+		return strings.TrimPrefix(s.Ref, prefix), nil
+	}
+	switch s.Type {
+	case "string":
+		if s.Format == "date-time" {
+			return "time.Time", nil
+		}
+		return "string", nil
+	case "integer":
+		if s.Format == "int64" {
+			return "int64", nil
+		}
+		return "int", nil
+	case "number":
+		return "float64", nil
+	case "boolean":
+		return "bool", nil
+	case "array":
+		item, err := goType(s.Items)
+		if err != nil {
+			return "", err
+		}
+		return "[]" + item, nil
+	}
+	return "", fmt.Errorf("unsupported type %q", s.Type)
+}
+
+// initialisms are written in capitals in Go names
+var initialisms = map[string]string{"id": "ID", "url": "URL", "api": "API", "http": "HTTP"}
+
+// goName turns a snake_case name into a Go one, e.g. guild_id into GuildID
+func goName(name string) string {
+	var sb strings.Builder
+	for _, part := range strings.Split(name, "_") {
+		if part == "" {
+			continue
+		}
+		if upper, ok := initialisms[part]; ok {
+			sb.WriteString(upper)
+			continue
+		}
+		sb.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return sb.String()
+}
+
+func writeComment(b *bytes.Buffer, text string) {
+	for _, line := range strings.Split(text, "\n") {
+		fmt.Fprintf(b, "// %s\n", line)
+	}
+}
+
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToLower(s[:1]) + s[1:]
+}
+
+// uses reports whether generated code refers to an imported package
+func uses(code []byte, path string) bool {
+	name := path[strings.LastIndex(path, "/")+1:]
+	return bytes.Contains(code, []byte(name+"."))
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,387 @@
+// Command worker runs the bot's background jobs (digests, summaries, feed
+// polling, pruning and, with the Redis event bus, embeddings) so the bot
+// process only answers Discord. Run it with BACKGROUND_WORKER=true on both.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"discord-tars/internal/config"
+	"discord-tars/internal/events"
+	"discord-tars/internal/mentions"
+	"discord-tars/internal/models"
+	"discord-tars/internal/repository"
+	"discord-tars/internal/repository/postgres"
+	"discord-tars/internal/secrets"
+	"discord-tars/internal/server"
+	apikeysService "discord-tars/internal/services/apikeys"
+	credentialsService "discord-tars/internal/services/credentials"
+	digestService "discord-tars/internal/services/digest"
+	duplicatesService "discord-tars/internal/services/duplicates"
+	feedsService "discord-tars/internal/services/feeds"
+	highlightsService "discord-tars/internal/services/highlights"
+	"discord-tars/internal/services/jobs"
+	maintenanceService "discord-tars/internal/services/maintenance"
+	ocrService "discord-tars/internal/services/ocr"
+	openaiService "discord-tars/internal/services/openai"
+	outboxService "discord-tars/internal/services/outbox"
+	partitionsService "discord-tars/internal/services/partitions"
+	ragService "discord-tars/internal/services/rag"
+	"discord-tars/internal/services/scheduler"
+	startersService "discord-tars/internal/services/starters"
+	summarizeService "discord-tars/internal/services/summarize"
+	"discord-tars/internal/storage"
+	"discord-tars/internal/tenant"
+	"discord-tars/internal/vcr"
+	"discord-tars/internal/vectorstore"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Job kinds run by the worker
+const (
+	jobDigestDelivery   = "digest-delivery"
+	jobHighlightDigests = "highlight-digests"
+	jobStarterPosts     = "starter-posts"
+	jobChannelSummaries = "channel-summaries"
+	jobFeedPolling      = "feed-polling"
+	jobStorageCleanup   = "storage-cleanup"
+	jobDuplicatePruning = "duplicate-pruning"
+	jobJobPruning       = "job-pruning"
+	jobIndexMaintenance = "index-maintenance"
+	jobPartitionPruning = "partition-pruning"
+	// A message whose embedding failed when it was received
+	jobIndexMessage = "index-message"
+)
+
+func main() {
+	log.Println("🚀 Starting T.A.R.S worker...")
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("❌ Failed to load configuration: %v", err)
+	}
+	// Otherwise the bot runs the same jobs too
+	if !cfg.Worker.Enabled {
+		log.Fatalf("❌ BACKGROUND_WORKER must be true for the worker and the bot")
+	}
+
+	db, err := postgres.NewGormConnection(cfg.Database)
+	if err != nil {
+		log.Fatalf("❌ Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	cipher, err := loadCipher(cfg)
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+
+	// Initialize repositories
+	msgRepo := repository.NewMessageRepository(db)
+	msgRepo.SetDiversity(cfg.RAG.DuplicateThreshold, cfg.RAG.MMRLambda)
+	msgRepo.SetReactionBoost(cfg.RAG.ReactionBoost)
+	msgRepo.SetSolvedBoost(cfg.RAG.SolvedBoost)
+	vectorStore, err := vectorstore.New(vectorstore.Config{
+		Backend:          cfg.VectorStore.Backend,
+		QdrantURL:        cfg.VectorStore.QdrantURL,
+		QdrantAPIKey:     cfg.VectorStore.QdrantAPIKey,
+		QdrantCollection: cfg.VectorStore.QdrantCollection,
+	})
+	if err != nil {
+		log.Fatalf("❌ Failed to initialize the vector store: %v", err)
+	}
+	if vectorStore != nil {
+		msgRepo.SetVectorStore(vectorStore)
+	}
+	priorityRepo := repository.NewPriorityRepository(db)
+	summaryRepo := repository.NewSummaryRepository(db)
+	codeRepo := repository.NewCodeRepository(db)
+	attachmentRepo := repository.NewAttachmentRepository(db)
+	duplicateRepo := repository.NewDuplicateRepository(db)
+	highlightRepo := repository.NewHighlightRepository(db)
+	jobRepo := repository.NewJobRepository(db)
+	outboxRepo := repository.NewOutboxRepository(db)
+	if cfg.Security.EncryptMessages {
+		msgRepo.SetCipher(cipher)
+		priorityRepo.SetCipher(cipher)
+		summaryRepo.SetCipher(cipher)
+		codeRepo.SetCipher(cipher)
+		attachmentRepo.SetCipher(cipher)
+		duplicateRepo.SetCipher(cipher)
+		highlightRepo.SetCipher(cipher)
+		jobRepo.SetCipher(cipher)
+		outboxRepo.SetCipher(cipher)
+	}
+	digestRepo := repository.NewDigestRepository(db)
+	feedRepo := repository.NewFeedRepository(db)
+	starterRepo := repository.NewStarterRepository(db)
+	knowledgeRepo := repository.NewKnowledgeRepository(db)
+	credentialRepo := repository.NewCredentialRepository(db)
+	indexRepo := repository.NewIndexRepository(db)
+
+	fileStore, err := storage.New(storage.Config{
+		Backend:     cfg.Storage.Backend,
+		LocalPath:   cfg.Storage.LocalPath,
+		PublicURL:   cfg.Storage.PublicURL,
+		SigningKey:  cfg.Storage.SigningKey,
+		S3Endpoint:  cfg.Storage.S3Endpoint,
+		S3Region:    cfg.Storage.S3Region,
+		S3Bucket:    cfg.Storage.S3Bucket,
+		S3AccessKey: cfg.Storage.S3AccessKey,
+		S3SecretKey: cfg.Storage.S3SecretKey,
+		S3PathStyle: cfg.Storage.S3PathStyle,
+	})
+	if err != nil {
+		log.Fatalf("❌ Failed to initialize storage: %v", err)
+	}
+
+	aiHTTP := vcr.NewClient(vcr.Config{Mode: vcr.ModeFor(cfg.OpenAI.Offline, cfg.OpenAI.Record), Dir: cfg.OpenAI.FixturesDir})
+	aiSvc := credentialsService.NewService(credentialRepo, cipher, openaiService.NewService(openaiService.Config{
+		APIKey:         cfg.OpenAI.APIKey,
+		Model:          cfg.OpenAI.Model,
+		EmbeddingModel: cfg.OpenAI.EmbeddingModel,
+		HTTPClient:     aiHTTP,
+		ModelOverrides: true, // For the ingest classifier's model
+	}), credentialsService.Config{
+		DefaultOpenAIModel: cfg.OpenAI.Model,
+		RequireOwnKey:      cfg.OpenAI.RequireGuildKey,
+		GlobalConcurrency:  cfg.OpenAI.GlobalConcurrency,
+		GuildConcurrency:   cfg.OpenAI.GuildConcurrency,
+		QueueTimeout:       cfg.OpenAI.QueueTimeout,
+		HTTPClient:         aiHTTP,
+	})
+
+	// Posts through the REST API; only the bot connects to the gateway
+	session, err := discordgo.New("Bot " + cfg.Discord.Token)
+	if err != nil {
+		log.Fatalf("❌ Failed to create discord session: %v", err)
+	}
+	mentionPolicy, err := mentions.ParsePolicy(cfg.Discord.AllowedMentions)
+	if err != nil {
+		log.Fatalf("❌ Invalid ALLOWED_MENTIONS: %v", err)
+	}
+	mentions.Enforce(session, mentionPolicy)
+
+	// Initialize the services whose jobs run here
+	ragSvc := ragService.NewService(aiSvc, msgRepo, priorityRepo, knowledgeRepo, session)
+	ragSvc.SetIndexRepository(indexRepo)
+	ragSvc.SetEmbeddingModel(cfg.OpenAI.EmbeddingModel)
+	if cfg.RAG.ChannelSummaries {
+		ragSvc.SetSummaryRepository(summaryRepo)
+	}
+	if cfg.RAG.CodeSearch {
+		ragSvc.SetCodeRepository(codeRepo)
+	}
+	if cfg.RAG.FileSearch {
+		ragSvc.SetAttachmentRepository(attachmentRepo)
+		ocrEngine, err := ocrService.New(ocrService.Config{
+			Engine:        cfg.RAG.OCREngine,
+			APIKey:        cfg.OpenAI.APIKey,
+			Model:         cfg.RAG.OCRModel,
+			HTTPClient:    aiHTTP,
+			TesseractPath: cfg.RAG.TesseractPath,
+			Languages:     cfg.RAG.OCRLanguages,
+		})
+		if err != nil {
+			log.Fatalf("❌ Failed to initialize OCR: %v", err)
+		}
+		if ocrEngine != nil {
+			ragSvc.SetOCR(ocrEngine)
+			log.Printf("🖼️ Reading the text of shared images with %s", cfg.RAG.OCREngine)
+		}
+	}
+	ingestFilter, err := ragService.NewIngestFilter(ragService.FilterConfig{
+		MinLength:       cfg.RAG.IngestMinLength,
+		Blocklist:       cfg.RAG.IngestBlocklist,
+		SkipLinkOnly:    cfg.RAG.IngestSkipLinkOnly,
+		CommandPrefixes: cfg.RAG.IngestCommandPrefixes,
+		SpamThreshold:   cfg.RAG.IngestSpamThreshold,
+		SpamWindow:      cfg.RAG.IngestSpamWindow,
+	})
+	if err != nil {
+		log.Fatalf("❌ Invalid INGEST_BLOCKLIST: %v", err)
+	}
+	ingestFilter.SetClassifier(ragService.ClassifierConfig{
+		SkipBelow:  cfg.RAG.IngestSkipBelow,
+		EmbedAbove: cfg.RAG.IngestEmbedAbove,
+		Model:      cfg.RAG.IngestClassifierModel,
+	})
+	ragSvc.SetIngestFilter(ingestFilter)
+	if cfg.Storage.ArchiveAttachments {
+		ragSvc.SetAttachmentStore(fileStore, cfg.Storage.MaxAttachmentBytes)
+	}
+	summarizeSvc := summarizeService.NewService(aiSvc, msgRepo)
+	// The bot retries what the worker fails to send
+	outboxSvc := outboxService.NewService(outboxRepo, session, outboxService.Config{MaxAttempts: cfg.Outbox.MaxAttempts})
+	digestSvc := digestService.NewService(digestRepo, summarizeSvc, session)
+	digestSvc.SetOutbox(outboxSvc)
+	highlightSvc := highlightsService.NewService(aiSvc, highlightRepo, session)
+	highlightSvc.SetOutbox(outboxSvc)
+	starterSvc := startersService.NewService(aiSvc, starterRepo, session)
+	starterSvc.SetOutbox(outboxSvc)
+	feedSvc := feedsService.NewService(aiSvc, feedRepo, session)
+	janitor := storage.NewJanitor(fileStore,
+		storage.Rule{Prefix: storage.PrefixAttachments, MaxAge: cfg.Storage.AttachmentRetention},
+		storage.Rule{Prefix: storage.PrefixRecordings, MaxAge: cfg.Storage.RecordingRetention},
+		storage.Rule{Prefix: storage.PrefixImages, MaxAge: cfg.Storage.ImageRetention},
+		storage.Rule{Prefix: storage.PrefixDocuments, MaxAge: cfg.Storage.DocumentRetention},
+	)
+
+	host, err := os.Hostname()
+	if err != nil {
+		host = "worker"
+	}
+	runner := jobs.NewRunner(jobRepo, fmt.Sprintf("%s-%d", host, os.Getpid()), cfg.Worker.PollInterval)
+	sched := scheduler.NewScheduler()
+
+	// Periodic jobs time out like their scheduled counterparts in the bot
+	periodic := func(kind string, interval time.Duration, run func(ctx context.Context) error) {
+		runner.Register(kind, jobs.Options{MaxAttempts: cfg.Worker.MaxAttempts, Timeout: interval}, func(ctx context.Context, payload []byte) error {
+			return run(ctx)
+		})
+		sched.Register(kind, interval, runner.Periodic(kind))
+	}
+	digestSvc.SetJobRunner(runner, jobs.Options{Concurrency: cfg.Worker.Concurrency, MaxAttempts: cfg.Worker.MaxAttempts})
+	ragSvc.RegisterJobs(runner)
+	periodic(jobDigestDelivery, cfg.Scheduler.DigestInterval, digestSvc.DeliverDue)
+	periodic(jobHighlightDigests, cfg.Scheduler.HighlightDigestInterval, highlightSvc.PostDigests)
+	periodic(jobStarterPosts, cfg.Scheduler.StarterInterval, starterSvc.PostDue)
+	periodic(jobFeedPolling, cfg.Scheduler.FeedInterval, feedSvc.PollDue)
+	periodic(jobStorageCleanup, cfg.Scheduler.StorageCleanupInterval, janitor.Cleanup)
+	if cfg.RAG.ChannelSummaries {
+		dailyIndexer := summarizeService.NewDailyIndexer(summarizeSvc, summaryRepo, cfg.RAG.SummaryMinMessages)
+		periodic(jobChannelSummaries, cfg.Scheduler.ChannelSummaryInterval, dailyIndexer.IndexDays)
+	}
+	if cfg.RAG.DuplicateQuestions {
+		duplicateSvc := duplicatesService.NewService(aiSvc, duplicateRepo, priorityRepo, duplicatesService.Config{
+			AnswerSimilarity: cfg.RAG.DuplicateAnswerSimilarity,
+			FAQSimilarity:    cfg.RAG.DuplicateFAQSimilarity,
+			MaxAge:           cfg.RAG.DuplicateAnswerMaxAge,
+			Cooldown:         cfg.RAG.DuplicateCooldown,
+		})
+		periodic(jobDuplicatePruning, cfg.Scheduler.DuplicatePruneInterval, duplicateSvc.Prune)
+	}
+	if cfg.Maintenance.Interval > 0 {
+		location, _ := time.LoadLocation(cfg.Maintenance.Timezone)
+		maintenanceSvc, err := maintenanceService.NewService(repository.NewMaintenanceRepository(db), maintenanceService.Config{
+			Interval:       cfg.Maintenance.Interval,
+			Window:         cfg.Maintenance.Window,
+			Location:       location,
+			BloatThreshold: cfg.Maintenance.BloatThreshold,
+		})
+		if err != nil {
+			log.Fatalf("❌ Invalid MAINTENANCE_WINDOW: %v", err)
+		}
+		periodic(jobIndexMaintenance, maintenanceService.CheckInterval, maintenanceSvc.Run)
+	}
+	if cfg.Database.PartitionRetention > 0 {
+		partitionRepo := repository.NewPartitionRepository(db)
+		if vectorStore != nil {
+			partitionRepo.SetVectorStore(vectorStore)
+		}
+		partitionSvc := partitionsService.NewService(partitionRepo, cfg.Database.PartitionRetention)
+		periodic(jobPartitionPruning, time.Hour, partitionSvc.Prune)
+	}
+	periodic(jobJobPruning, time.Hour, func(ctx context.Context) error {
+		pruned, err := jobRepo.PruneFinished(ctx, time.Now().Add(-cfg.Worker.JobRetention))
+		if pruned > 0 {
+			log.Printf("🧹 Pruned %d finished jobs", pruned)
+		}
+		return err
+	})
+
+	// Embed new messages from the event bus, retrying failures as jobs
+	runner.Register(jobIndexMessage, jobs.Options{Concurrency: cfg.Worker.Concurrency, MaxAttempts: cfg.Worker.MaxAttempts, Timeout: time.Minute}, func(ctx context.Context, payload []byte) error {
+		var msg discordgo.Message
+		if err := json.Unmarshal(payload, &msg); err != nil {
+			return fmt.Errorf("failed to decode message: %w", err)
+		}
+		guildID, _ := strconv.ParseInt(msg.GuildID, 10, 64)
+		return ragSvc.ProcessMessage(tenant.WithGuild(ctx, guildID), &msg)
+	})
+	var bus events.Bus
+	if cfg.Events.Backend == "redis" {
+		bus, err = events.NewRedisBus(events.Config{
+			RedisAddr:     fmt.Sprintf("%s:%d", cfg.Redis.Host, cfg.Redis.Port),
+			RedisPassword: cfg.Redis.Password,
+			RedisDB:       cfg.Redis.DB,
+			StreamMaxLen:  cfg.Events.StreamMaxLen,
+		})
+		if err != nil {
+			log.Fatalf("❌ Failed to connect to the event bus: %v", err)
+		}
+		err = bus.Subscribe("rag-indexing", events.MessageCreated, cfg.Worker.Concurrency, func(ctx context.Context, event *events.Event) error {
+			ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+			defer cancel()
+			if err := ragSvc.ProcessMessage(ctx, event.Message); err != nil {
+				log.Printf("⚠️ Failed to index message %s, retrying as a job: %v", event.Message.ID, err)
+				return runner.Enqueue(context.Background(), jobIndexMessage, event.GuildID, event.Message)
+			}
+			return nil
+		})
+		if err != nil {
+			log.Fatalf("❌ Failed to subscribe to new messages: %v", err)
+		}
+	} else {
+		log.Printf("⚠️ EVENT_BUS is not redis; new messages are still embedded by the bot")
+	}
+
+	httpServer := server.NewServer(cfg.Worker.HTTPPort)
+	apiKeySvc := apikeysService.NewService(repository.NewAPIKeyRepository(db))
+	httpServer.HandleFunc("GET /admin/jobs", apiKeySvc.Require(models.ScopeAdmin, cfg.App.AdminToken, runner.HandleStats))
+
+	runner.Start()
+	sched.Start()
+	httpServer.Start()
+	log.Println("🧰 T.A.R.S worker is running")
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	<-stop
+
+	// Stop taking new work before interrupting the jobs in progress
+	sched.Stop()
+	if bus != nil {
+		bus.Close()
+	}
+	runner.Stop()
+	httpServer.Stop()
+	log.Println("👋 Worker shutdown complete")
+}
+
+// loadCipher loads the key for per-guild secrets and encrypted content, or
+// returns nil when none is configured
+func loadCipher(cfg *config.Config) (*secrets.Cipher, error) {
+	if !cfg.Security.HasEncryptionKey() {
+		return nil, nil
+	}
+	key, err := secrets.LoadKey(context.Background(), secrets.KeySource{
+		Key:           cfg.Security.EncryptionKey,
+		File:          cfg.Security.EncryptionKeyFile,
+		KMSCiphertext: cfg.Security.KMSEncryptedKey,
+		KMS: secrets.KMSConfig{
+			Region:       cfg.Security.KMSRegion,
+			Endpoint:     cfg.Security.KMSEndpoint,
+			AccessKey:    cfg.Security.AWSAccessKey,
+			SecretKey:    cfg.Security.AWSSecretKey,
+			SessionToken: cfg.Security.AWSSessionToken,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load encryption key: %w", err)
+	}
+	cipher, err := secrets.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid encryption key: %w", err)
+	}
+	return cipher, nil
+}
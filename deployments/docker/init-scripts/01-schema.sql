@@ -87,6 +87,844 @@ CREATE TABLE IF NOT EXISTS bot_interactions (
     created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
 );
 
+-- Create digest_subscriptions table for per-user channel digests
+CREATE TABLE IF NOT EXISTS digest_subscriptions (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    guild_id BIGINT NOT NULL,
+    channel_id BIGINT NOT NULL,
+    frequency VARCHAR(16) NOT NULL DEFAULT 'daily',
+    hour INTEGER NOT NULL DEFAULT 9,
+    weekday INTEGER NOT NULL DEFAULT 1,
+    timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    last_sent TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CONSTRAINT idx_digest_user_channel UNIQUE (user_id, channel_id)
+);
+
+-- Create standup tables for async team standups
+CREATE TABLE IF NOT EXISTS standup_teams (
+    id BIGSERIAL PRIMARY KEY,
+    guild_id BIGINT NOT NULL,
+    channel_id BIGINT NOT NULL UNIQUE,
+    name VARCHAR(100) NOT NULL,
+    member_ids TEXT[],
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS standup_sessions (
+    id BIGSERIAL PRIMARY KEY,
+    team_id BIGINT NOT NULL REFERENCES standup_teams(id) ON DELETE CASCADE,
+    started_by BIGINT NOT NULL,
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    ends_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    nagged_at TIMESTAMP WITH TIME ZONE,
+    closed_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE TABLE IF NOT EXISTS standup_updates (
+    id BIGSERIAL PRIMARY KEY,
+    session_id BIGINT NOT NULL REFERENCES standup_sessions(id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL,
+    username VARCHAR(255),
+    content TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CONSTRAINT idx_standup_update_user UNIQUE (session_id, user_id)
+);
+
+-- Create poll tables for button polls
+CREATE TABLE IF NOT EXISTS polls (
+    id BIGSERIAL PRIMARY KEY,
+    guild_id BIGINT NOT NULL,
+    channel_id BIGINT NOT NULL,
+    message_id BIGINT,
+    created_by BIGINT NOT NULL,
+    question TEXT NOT NULL,
+    options TEXT[] NOT NULL,
+    closes_at TIMESTAMP WITH TIME ZONE,
+    closed_at TIMESTAMP WITH TIME ZONE,
+    summary TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS poll_votes (
+    id BIGSERIAL PRIMARY KEY,
+    poll_id BIGINT NOT NULL REFERENCES polls(id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL,
+    option INTEGER NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CONSTRAINT idx_poll_vote_user UNIQUE (poll_id, user_id)
+);
+
+-- Create onboarding tables for new member welcomes
+CREATE TABLE IF NOT EXISTS onboarding_configs (
+    guild_id BIGINT PRIMARY KEY,
+    enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    mode VARCHAR(16) NOT NULL DEFAULT 'dm',
+    welcome_channel_id BIGINT,
+    knowledge_channel_ids TEXT[],
+    greeting TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS onboarding_members (
+    id BIGSERIAL PRIMARY KEY,
+    guild_id BIGINT NOT NULL,
+    user_id BIGINT NOT NULL,
+    questions_asked INTEGER NOT NULL DEFAULT 0,
+    joined_at TIMESTAMP WITH TIME ZONE,
+    completed_at TIMESTAMP WITH TIME ZONE,
+    CONSTRAINT idx_onboarding_member UNIQUE (guild_id, user_id)
+);
+
+-- Create priority context tables for pinned messages and rules/announcement channels
+CREATE TABLE IF NOT EXISTS priority_channels (
+    id BIGSERIAL PRIMARY KEY,
+    guild_id BIGINT NOT NULL,
+    channel_id BIGINT NOT NULL UNIQUE,
+    label VARCHAR(50),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS priority_documents (
+    id BIGSERIAL PRIMARY KEY,
+    guild_id BIGINT NOT NULL,
+    channel_id BIGINT NOT NULL,
+    message_id BIGINT NOT NULL UNIQUE,
+    channel_name VARCHAR(255),
+    author_name VARCHAR(255),
+    source VARCHAR(16) NOT NULL,
+    content TEXT NOT NULL,
+    embedding vector(1536),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create github_subscriptions table for repository announcements
+CREATE TABLE IF NOT EXISTS github_subscriptions (
+    id BIGSERIAL PRIMARY KEY,
+    guild_id BIGINT NOT NULL,
+    channel_id BIGINT NOT NULL,
+    repo VARCHAR(200) NOT NULL,
+    created_by BIGINT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CONSTRAINT idx_github_sub_channel_repo UNIQUE (channel_id, repo)
+);
+
+-- Create feeds table for RSS/Atom watchers
+CREATE TABLE IF NOT EXISTS feeds (
+    id BIGSERIAL PRIMARY KEY,
+    guild_id BIGINT NOT NULL,
+    channel_id BIGINT NOT NULL,
+    url TEXT NOT NULL,
+    title VARCHAR(300),
+    interval_minutes INTEGER NOT NULL DEFAULT 60,
+    last_checked_at TIMESTAMP WITH TIME ZONE,
+    last_error TEXT,
+    created_by BIGINT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CONSTRAINT idx_feed_channel_url UNIQUE (channel_id, url)
+);
+
+-- Create feed_items table to dedupe posted entries
+CREATE TABLE IF NOT EXISTS feed_items (
+    id BIGSERIAL PRIMARY KEY,
+    feed_id BIGINT NOT NULL REFERENCES feeds(id) ON DELETE CASCADE,
+    guid TEXT NOT NULL,
+    title TEXT,
+    link TEXT,
+    published_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CONSTRAINT idx_feed_item_guid UNIQUE (feed_id, guid)
+);
+
+-- Create calendar_sources table for ICS calendars and Discord scheduled events
+CREATE TABLE IF NOT EXISTS calendar_sources (
+    id BIGSERIAL PRIMARY KEY,
+    guild_id BIGINT NOT NULL,
+    kind VARCHAR(20) NOT NULL DEFAULT 'ics',
+    name VARCHAR(100) NOT NULL,
+    url TEXT NOT NULL,
+    reminder_channel_id BIGINT,
+    reminder_minutes INTEGER NOT NULL DEFAULT 30,
+    last_synced_at TIMESTAMP WITH TIME ZONE,
+    last_error TEXT,
+    created_by BIGINT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create calendar_events table for expanded event occurrences
+CREATE TABLE IF NOT EXISTS calendar_events (
+    id BIGSERIAL PRIMARY KEY,
+    source_id BIGINT NOT NULL REFERENCES calendar_sources(id) ON DELETE CASCADE,
+    guild_id BIGINT NOT NULL,
+    uid TEXT NOT NULL,
+    starts_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    ends_at TIMESTAMP WITH TIME ZONE,
+    all_day BOOLEAN DEFAULT FALSE,
+    summary TEXT,
+    description TEXT,
+    location TEXT,
+    reminded_at TIMESTAMP WITH TIME ZONE,
+    synced_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CONSTRAINT idx_calendar_event_occurrence UNIQUE (source_id, uid, starts_at)
+);
+
+-- Create tracker_configs table for per-guild Jira/Linear credentials
+CREATE TABLE IF NOT EXISTS tracker_configs (
+    guild_id BIGINT PRIMARY KEY,
+    provider VARCHAR(16) NOT NULL,
+    base_url TEXT,
+    email VARCHAR(255),
+    api_token TEXT NOT NULL,
+    project_keys TEXT[],
+    created_by BIGINT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create knowledge_sources table for Notion/Confluence connectors
+CREATE TABLE IF NOT EXISTS knowledge_sources (
+    id BIGSERIAL PRIMARY KEY,
+    guild_id BIGINT NOT NULL,
+    provider VARCHAR(16) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    base_url TEXT,
+    email VARCHAR(255),
+    api_token TEXT NOT NULL,
+    space_key VARCHAR(100),
+    page_ids TEXT[],
+    last_synced_at TIMESTAMP WITH TIME ZONE,
+    last_error TEXT,
+    created_by BIGINT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create knowledge_documents table for synced pages
+CREATE TABLE IF NOT EXISTS knowledge_documents (
+    id BIGSERIAL PRIMARY KEY,
+    source_id BIGINT NOT NULL REFERENCES knowledge_sources(id) ON DELETE CASCADE,
+    guild_id BIGINT NOT NULL,
+    external_id VARCHAR(255) NOT NULL,
+    title TEXT,
+    url TEXT,
+    content_hash VARCHAR(64),
+    source_updated_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CONSTRAINT idx_knowledge_doc_external UNIQUE (source_id, external_id)
+);
+
+-- Create knowledge_chunks table for embedded document chunks
+CREATE TABLE IF NOT EXISTS knowledge_chunks (
+    id BIGSERIAL PRIMARY KEY,
+    document_id BIGINT NOT NULL REFERENCES knowledge_documents(id) ON DELETE CASCADE,
+    guild_id BIGINT NOT NULL,
+    chunk_index INTEGER NOT NULL,
+    content TEXT NOT NULL,
+    embedding vector(1536),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create ai_credentials table for per-guild AI provider keys (encrypted)
+CREATE TABLE IF NOT EXISTS ai_credentials (
+    guild_id BIGINT PRIMARY KEY,
+    provider VARCHAR(16) NOT NULL,
+    encrypted_key TEXT NOT NULL,
+    key_hint VARCHAR(8),
+    model VARCHAR(100),
+    created_by BIGINT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create index_runs table to track bulk indexing jobs
+CREATE TABLE IF NOT EXISTS index_runs (
+    id BIGSERIAL PRIMARY KEY,
+    guild_id BIGINT NOT NULL,
+    channel_id BIGINT NOT NULL,
+    kind VARCHAR(32) NOT NULL,
+    status VARCHAR(16) NOT NULL,
+    processed INTEGER DEFAULT 0,
+    total INTEGER DEFAULT 0,
+    error TEXT,
+    started_by BIGINT DEFAULT 0,
+    started_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    finished_at TIMESTAMP WITH TIME ZONE
+);
+
+-- Create conversation_memories table for per-channel chat memory
+CREATE TABLE IF NOT EXISTS conversation_memories (
+    channel_id BIGINT PRIMARY KEY,
+    guild_id BIGINT,
+    summary TEXT,
+    turns TEXT,
+    summarized_turns INTEGER DEFAULT 0,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create asked_questions table for /ask suggestions
+CREATE TABLE IF NOT EXISTS asked_questions (
+    id BIGSERIAL PRIMARY KEY,
+    guild_id BIGINT NOT NULL,
+    hash VARCHAR(64) NOT NULL,
+    question TEXT NOT NULL,
+    count INTEGER NOT NULL DEFAULT 1,
+    last_asked_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (guild_id, hash)
+);
+
+-- Create channel_summaries table for nightly per-channel day summaries
+CREATE TABLE IF NOT EXISTS channel_summaries (
+    id BIGSERIAL PRIMARY KEY,
+    guild_id BIGINT NOT NULL,
+    channel_id BIGINT NOT NULL,
+    day DATE NOT NULL,
+    channel_name VARCHAR(255),
+    message_count INTEGER NOT NULL,
+    content TEXT NOT NULL,
+    embedding vector(1536),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (channel_id, day)
+);
+
+-- Create duplicate_configs table for per-guild repeated question detection
+CREATE TABLE IF NOT EXISTS duplicate_configs (
+    guild_id BIGINT PRIMARY KEY,
+    enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create answered_questions table for linking repeated questions to earlier answers
+CREATE TABLE IF NOT EXISTS answered_questions (
+    id BIGSERIAL PRIMARY KEY,
+    guild_id BIGINT NOT NULL,
+    channel_id BIGINT NOT NULL,
+    message_id BIGINT NOT NULL UNIQUE,
+    question TEXT NOT NULL,
+    embedding vector(1536),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create announcement_configs table for per-guild announcement channels
+CREATE TABLE IF NOT EXISTS announcement_configs (
+    guild_id BIGINT PRIMARY KEY,
+    channel_id BIGINT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create announcement_drafts table for announcements awaiting approval
+CREATE TABLE IF NOT EXISTS announcement_drafts (
+    id BIGSERIAL PRIMARY KEY,
+    guild_id BIGINT NOT NULL,
+    channel_id BIGINT NOT NULL,
+    requested_by BIGINT NOT NULL,
+    topic TEXT NOT NULL,
+    content TEXT NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    reviewed_by BIGINT,
+    message_id BIGINT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create guild_personas table for personas imported by guilds
+CREATE TABLE IF NOT EXISTS guild_personas (
+    guild_id BIGINT PRIMARY KEY,
+    definition TEXT NOT NULL,
+    imported_by BIGINT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create persona_modes table for scheduled persona adjustments
+CREATE TABLE IF NOT EXISTS persona_modes (
+    id BIGSERIAL PRIMARY KEY,
+    guild_id BIGINT NOT NULL,
+    name VARCHAR(50) NOT NULL,
+    instructions TEXT NOT NULL,
+    months INTEGER NOT NULL DEFAULT 0,
+    weekdays INTEGER NOT NULL DEFAULT 0,
+    start_hour INTEGER NOT NULL DEFAULT 0,
+    end_hour INTEGER NOT NULL DEFAULT 0,
+    timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    created_by BIGINT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CONSTRAINT idx_persona_modes_guild_name UNIQUE (guild_id, name)
+);
+
+-- Create message_sentiments table for per-message mood scores
+CREATE TABLE IF NOT EXISTS message_sentiments (
+    message_id BIGINT PRIMARY KEY,
+    guild_id BIGINT NOT NULL,
+    channel_id BIGINT NOT NULL,
+    day DATE NOT NULL,
+    score DOUBLE PRECISION NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create channel_moods table for per-channel daily mood
+CREATE TABLE IF NOT EXISTS channel_moods (
+    id BIGSERIAL PRIMARY KEY,
+    guild_id BIGINT NOT NULL,
+    channel_id BIGINT NOT NULL,
+    day DATE NOT NULL,
+    channel_name VARCHAR(255),
+    messages INTEGER NOT NULL,
+    average DOUBLE PRECISION NOT NULL,
+    negative INTEGER NOT NULL,
+    positive INTEGER NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CONSTRAINT idx_channel_mood_day UNIQUE (channel_id, day)
+);
+
+-- Create toxicity_configs table for per-guild escalation warnings
+CREATE TABLE IF NOT EXISTS toxicity_configs (
+    guild_id BIGINT PRIMARY KEY,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    alert_channel_id BIGINT NOT NULL,
+    mod_role_id BIGINT NOT NULL,
+    threshold DOUBLE PRECISION NOT NULL DEFAULT 0.6,
+    quiet_start INTEGER NOT NULL DEFAULT 0,
+    quiet_end INTEGER NOT NULL DEFAULT 0,
+    timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create message_reactions table for reaction counts used as a relevance signal
+CREATE TABLE IF NOT EXISTS message_reactions (
+    message_id BIGINT NOT NULL,
+    emoji VARCHAR(100) NOT NULL,
+    guild_id BIGINT NOT NULL,
+    count INTEGER NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (message_id, emoji)
+);
+
+-- Create highlight_configs table for per-guild starboards
+CREATE TABLE IF NOT EXISTS highlight_configs (
+    guild_id BIGINT PRIMARY KEY,
+    channel_id BIGINT NOT NULL,
+    threshold INTEGER NOT NULL DEFAULT 5,
+    emoji VARCHAR(100),
+    weekly_digest BOOLEAN NOT NULL DEFAULT TRUE,
+    digest_weekday INTEGER NOT NULL DEFAULT 5,
+    digest_hour INTEGER NOT NULL DEFAULT 17,
+    timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    last_digest TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create highlights table for messages copied to a starboard
+CREATE TABLE IF NOT EXISTS highlights (
+    message_id BIGINT PRIMARY KEY,
+    guild_id BIGINT NOT NULL,
+    channel_id BIGINT NOT NULL,
+    author_id BIGINT NOT NULL,
+    content TEXT,
+    post_id BIGINT NOT NULL,
+    reactions INTEGER NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create jobs table for background work run by the worker
+CREATE TABLE IF NOT EXISTS jobs (
+    id BIGSERIAL PRIMARY KEY,
+    kind VARCHAR(64) NOT NULL,
+    guild_id BIGINT,
+    status VARCHAR(16) NOT NULL,
+    payload TEXT,
+    attempts INTEGER NOT NULL,
+    max_attempts INTEGER NOT NULL,
+    run_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    locked_by VARCHAR(128),
+    last_error TEXT,
+    started_at TIMESTAMP WITH TIME ZONE,
+    finished_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS outbox_messages (
+    id BIGSERIAL PRIMARY KEY,
+    guild_id BIGINT,
+    kind VARCHAR(32) NOT NULL,
+    channel_id BIGINT,
+    user_id BIGINT,
+    payload TEXT NOT NULL,
+    status VARCHAR(16) NOT NULL,
+    attempts INTEGER NOT NULL,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    last_error TEXT,
+    message_id BIGINT,
+    sent_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create command_audits table for the slash command audit log
+CREATE TABLE IF NOT EXISTS command_audits (
+    id BIGSERIAL PRIMARY KEY,
+    guild_id BIGINT,
+    user_id BIGINT NOT NULL,
+    command VARCHAR(100) NOT NULL,
+    options TEXT,
+    latency_ms BIGINT NOT NULL,
+    outcome VARCHAR(16) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create guild_verbosities table for default answer lengths
+CREATE TABLE IF NOT EXISTS guild_verbosities (
+    guild_id BIGINT PRIMARY KEY,
+    verbosity VARCHAR(10) NOT NULL,
+    set_by BIGINT NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create guild_styles table for playful or plain answers
+CREATE TABLE IF NOT EXISTS guild_styles (
+    guild_id BIGINT PRIMARY KEY,
+    style VARCHAR(10) NOT NULL,
+    set_by BIGINT NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create quiz tables for /quiz and its leaderboard
+CREATE TABLE IF NOT EXISTS quizzes (
+    id BIGSERIAL PRIMARY KEY,
+    guild_id BIGINT NOT NULL,
+    channel_id BIGINT NOT NULL,
+    created_by BIGINT NOT NULL,
+    topic VARCHAR(200) NOT NULL,
+    source VARCHAR(16) NOT NULL,
+    closes_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    closed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS quiz_questions (
+    id BIGSERIAL PRIMARY KEY,
+    quiz_id BIGINT NOT NULL REFERENCES quizzes(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    message_id BIGINT,
+    question TEXT NOT NULL,
+    choices TEXT[] NOT NULL,
+    answer INTEGER NOT NULL,
+    explanation TEXT
+);
+
+CREATE TABLE IF NOT EXISTS quiz_answers (
+    id BIGSERIAL PRIMARY KEY,
+    question_id BIGINT NOT NULL REFERENCES quiz_questions(id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL,
+    guild_id BIGINT NOT NULL,
+    choice INTEGER NOT NULL,
+    correct BOOLEAN NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CONSTRAINT idx_quiz_answer_user UNIQUE (question_id, user_id)
+);
+
+-- Create xp_configs table for per-guild activity XP settings
+CREATE TABLE IF NOT EXISTS xp_configs (
+    guild_id BIGINT PRIMARY KEY,
+    message_xp INTEGER NOT NULL,
+    reaction_xp INTEGER NOT NULL,
+    cooldown_seconds INTEGER NOT NULL,
+    announce BOOLEAN NOT NULL,
+    channel_id BIGINT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create member_xps table for each member's XP per guild
+CREATE TABLE IF NOT EXISTS member_xps (
+    guild_id BIGINT NOT NULL,
+    user_id BIGINT NOT NULL,
+    xp BIGINT NOT NULL DEFAULT 0,
+    messages INTEGER NOT NULL DEFAULT 0,
+    reactions INTEGER NOT NULL DEFAULT 0,
+    last_message_at TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (guild_id, user_id)
+);
+
+-- Create starter_schedules table for scheduled conversation starters
+CREATE TABLE IF NOT EXISTS starter_schedules (
+    id BIGSERIAL PRIMARY KEY,
+    guild_id BIGINT NOT NULL,
+    channel_id BIGINT NOT NULL UNIQUE,
+    kind VARCHAR(16) NOT NULL,
+    topics VARCHAR(300),
+    frequency VARCHAR(16) NOT NULL,
+    weekday INTEGER NOT NULL,
+    hour INTEGER NOT NULL,
+    timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    created_by BIGINT NOT NULL,
+    last_posted_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create starter_posts table so posted starters aren't repeated
+CREATE TABLE IF NOT EXISTS starter_posts (
+    id BIGSERIAL PRIMARY KEY,
+    schedule_id BIGINT NOT NULL,
+    guild_id BIGINT NOT NULL,
+    channel_id BIGINT NOT NULL,
+    text TEXT NOT NULL,
+    embedding vector(1536),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create incidents table for outage mode
+CREATE TABLE IF NOT EXISTS incidents (
+    id BIGSERIAL PRIMARY KEY,
+    guild_id BIGINT NOT NULL,
+    channel_id BIGINT NOT NULL,
+    thread_id BIGINT NOT NULL UNIQUE,
+    status_message_id BIGINT NOT NULL,
+    title VARCHAR(100) NOT NULL,
+    severity VARCHAR(8) NOT NULL,
+    status VARCHAR(16) NOT NULL,
+    started_by BIGINT NOT NULL,
+    resolved_by BIGINT,
+    resolved_at TIMESTAMP WITH TIME ZONE,
+    postmortem TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create incident_updates table for incident timelines
+CREATE TABLE IF NOT EXISTS incident_updates (
+    id BIGSERIAL PRIMARY KEY,
+    incident_id BIGINT NOT NULL,
+    author_id BIGINT NOT NULL,
+    status VARCHAR(16) NOT NULL,
+    text TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create notes table for personal and channel notebooks
+CREATE TABLE IF NOT EXISTS notes (
+    id BIGSERIAL PRIMARY KEY,
+    guild_id BIGINT,
+    channel_id BIGINT NOT NULL,
+    user_id BIGINT NOT NULL,
+    content TEXT NOT NULL,
+    embedding vector(1536),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create bookmarks table for members' private message collections
+CREATE TABLE IF NOT EXISTS bookmarks (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    message_id BIGINT NOT NULL,
+    guild_id BIGINT,
+    channel_id BIGINT NOT NULL,
+    author_id BIGINT NOT NULL,
+    author_name VARCHAR(255),
+    content TEXT NOT NULL,
+    embedding vector(1536),
+    posted_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CONSTRAINT idx_bookmark_user_message UNIQUE (user_id, message_id)
+);
+
+-- Create code_snippets table for fenced code blocks embedded on their own
+CREATE TABLE IF NOT EXISTS code_snippets (
+    id BIGSERIAL PRIMARY KEY,
+    message_id BIGINT NOT NULL,
+    guild_id BIGINT,
+    channel_id BIGINT NOT NULL,
+    user_id BIGINT NOT NULL,
+    language VARCHAR(32),
+    code TEXT NOT NULL,
+    embedding vector(1536),
+    timestamp TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create message_attachments table for files shared in messages, with the text of documents
+CREATE TABLE IF NOT EXISTS message_attachments (
+    id BIGINT PRIMARY KEY,
+    message_id BIGINT NOT NULL,
+    guild_id BIGINT,
+    channel_id BIGINT NOT NULL,
+    user_id BIGINT NOT NULL,
+    filename VARCHAR(255) NOT NULL,
+    content_type VARCHAR(128),
+    size BIGINT NOT NULL,
+    url TEXT NOT NULL,
+    content TEXT,
+    embedding vector(1536),
+    timestamp TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create forum_posts table for forum channel posts, their tags and accepted answers
+CREATE TABLE IF NOT EXISTS forum_posts (
+    thread_id BIGINT PRIMARY KEY,
+    guild_id BIGINT NOT NULL,
+    forum_id BIGINT NOT NULL,
+    author_id BIGINT NOT NULL,
+    title VARCHAR(100) NOT NULL,
+    tags VARCHAR(500),
+    content TEXT,
+    embedding vector(1536),
+    solved BOOLEAN NOT NULL,
+    solution_message_id BIGINT,
+    solved_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create help_channels table for channels whose new posts get earlier answers suggested
+CREATE TABLE IF NOT EXISTS help_channels (
+    channel_id BIGINT PRIMARY KEY,
+    guild_id BIGINT NOT NULL,
+    added_by BIGINT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create sandbox_guilds table for guilds whose replies are logged instead of sent
+CREATE TABLE IF NOT EXISTS sandbox_guilds (
+    guild_id BIGINT PRIMARY KEY,
+    channel_id BIGINT NOT NULL DEFAULT 0,
+    enabled_by BIGINT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create rollouts table for answer settings tried on a cohort of guilds first
+CREATE TABLE IF NOT EXISTS rollouts (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    model VARCHAR(100),
+    confidence_threshold DOUBLE PRECISION NOT NULL DEFAULT 0,
+    instructions TEXT,
+    percent INTEGER NOT NULL,
+    status VARCHAR(16) NOT NULL,
+    reason TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create message_languages table for the detected language of indexed messages
+CREATE TABLE IF NOT EXISTS message_languages (
+    message_id BIGINT PRIMARY KEY,
+    guild_id BIGINT,
+    language VARCHAR(8) NOT NULL
+);
+
+-- Create guild_languages table for guilds restricting retrieval to some languages
+CREATE TABLE IF NOT EXISTS guild_languages (
+    guild_id BIGINT PRIMARY KEY,
+    languages TEXT[] NOT NULL,
+    updated_by BIGINT NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create user_timezones table for the timezone members read and write times in
+CREATE TABLE IF NOT EXISTS user_timezones (
+    user_id BIGINT PRIMARY KEY,
+    timezone VARCHAR(64) NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create guild_timezones table for guilds' timezones, for members without their own
+CREATE TABLE IF NOT EXISTS guild_timezones (
+    guild_id BIGINT PRIMARY KEY,
+    timezone VARCHAR(64) NOT NULL,
+    set_by BIGINT NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create filtered_messages table for stored messages the ingest filters kept out of retrieval
+CREATE TABLE IF NOT EXISTS filtered_messages (
+    message_id BIGINT PRIMARY KEY,
+    guild_id BIGINT,
+    reason VARCHAR(16) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create maintenance_runs table to track vector index maintenance
+CREATE TABLE IF NOT EXISTS maintenance_runs (
+    id BIGSERIAL PRIMARY KEY,
+    trigger VARCHAR(16) NOT NULL,
+    status VARCHAR(16) NOT NULL,
+    analyzed TEXT[],
+    reindexed TEXT[],
+    indexes TEXT,
+    error TEXT,
+    started_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    finished_at TIMESTAMP WITH TIME ZONE
+);
+
+-- Create guild_partitions table to track the per-guild partitions of messages,
+-- once it is partitioned with "bot partition-messages"
+CREATE TABLE IF NOT EXISTS guild_partitions (
+    guild_id BIGINT PRIMARY KEY,
+    partition VARCHAR(64) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    left_at TIMESTAMP WITH TIME ZONE
+);
+
+-- Create answer_logs table for the answers guild admins review on the dashboard
+CREATE TABLE IF NOT EXISTS answer_logs (
+    id BIGSERIAL PRIMARY KEY,
+    guild_id BIGINT NOT NULL,
+    channel_id BIGINT NOT NULL,
+    message_id BIGINT NOT NULL UNIQUE,
+    user_id BIGINT NOT NULL,
+    question TEXT NOT NULL,
+    answer TEXT NOT NULL,
+    positive INTEGER NOT NULL DEFAULT 0,
+    negative INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create api_keys table for the keys integrators call the HTTP API with; only
+-- a hash of each secret is stored
+CREATE TABLE IF NOT EXISTS api_keys (
+    id BIGSERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    prefix TEXT NOT NULL,
+    hash TEXT NOT NULL UNIQUE,
+    scopes TEXT[] NOT NULL,
+    rotated_to BIGINT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    last_used_at TIMESTAMP WITH TIME ZONE,
+    expires_at TIMESTAMP WITH TIME ZONE,
+    revoked_at TIMESTAMP WITH TIME ZONE
+);
+
+-- Create api_key_usages table for the requests made with each key, per day and scope
+CREATE TABLE IF NOT EXISTS api_key_usages (
+    key_id BIGINT NOT NULL,
+    day DATE NOT NULL,
+    scope TEXT NOT NULL,
+    requests BIGINT NOT NULL DEFAULT 0,
+    failures BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (key_id, day, scope)
+);
+
 -- Create indexes for better performance
 CREATE INDEX IF NOT EXISTS idx_messages_channel_timestamp ON messages(channel_id, timestamp DESC);
 CREATE INDEX IF NOT EXISTS idx_messages_guild_timestamp ON messages(guild_id, timestamp DESC);
@@ -94,9 +932,62 @@ CREATE INDEX IF NOT EXISTS idx_messages_user_timestamp ON messages(user_id, time
 CREATE INDEX IF NOT EXISTS idx_message_embeddings_message_id ON message_embeddings(message_id);
 CREATE INDEX IF NOT EXISTS idx_conversation_context_channel ON conversation_context(channel_id);
 CREATE INDEX IF NOT EXISTS idx_bot_interactions_channel ON bot_interactions(channel_id);
+CREATE INDEX IF NOT EXISTS idx_priority_documents_guild ON priority_documents(guild_id);
+CREATE INDEX IF NOT EXISTS idx_feeds_guild_id ON feeds(guild_id);
+CREATE INDEX IF NOT EXISTS idx_calendar_sources_guild_id ON calendar_sources(guild_id);
+CREATE INDEX IF NOT EXISTS idx_calendar_events_guild_starts ON calendar_events(guild_id, starts_at);
+CREATE INDEX IF NOT EXISTS idx_knowledge_chunks_document_id ON knowledge_chunks(document_id);
+CREATE INDEX IF NOT EXISTS idx_knowledge_chunks_guild_id ON knowledge_chunks(guild_id);
+CREATE INDEX IF NOT EXISTS idx_index_runs_guild_started ON index_runs(guild_id, started_at DESC);
+CREATE INDEX IF NOT EXISTS idx_conversation_memories_guild_id ON conversation_memories(guild_id);
+CREATE INDEX IF NOT EXISTS idx_asked_questions_last_asked_at ON asked_questions(last_asked_at);
+CREATE INDEX IF NOT EXISTS idx_channel_summaries_guild_day ON channel_summaries(guild_id, day);
+CREATE INDEX IF NOT EXISTS idx_answered_questions_guild_created ON answered_questions(guild_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_announcement_drafts_guild ON announcement_drafts(guild_id);
+CREATE INDEX IF NOT EXISTS idx_message_sentiments_guild_id ON message_sentiments(guild_id);
+CREATE INDEX IF NOT EXISTS idx_message_sentiment_channel_day ON message_sentiments(channel_id, day);
+CREATE INDEX IF NOT EXISTS idx_channel_moods_guild_id ON channel_moods(guild_id);
+CREATE INDEX IF NOT EXISTS idx_message_reactions_guild_id ON message_reactions(guild_id);
+CREATE INDEX IF NOT EXISTS idx_highlight_guild_created ON highlights(guild_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_job_claim ON jobs(kind, status, run_at);
+CREATE INDEX IF NOT EXISTS idx_job_guild ON jobs(guild_id);
+CREATE INDEX IF NOT EXISTS idx_outbox_due ON outbox_messages(status, next_attempt_at);
+CREATE INDEX IF NOT EXISTS idx_outbox_messages_guild_id ON outbox_messages(guild_id);
+CREATE INDEX IF NOT EXISTS idx_command_audit_guild_created ON command_audits(guild_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_command_audits_created_at ON command_audits(created_at);
+CREATE INDEX IF NOT EXISTS idx_quizzes_guild_id ON quizzes(guild_id);
+CREATE INDEX IF NOT EXISTS idx_quizzes_closes_at ON quizzes(closes_at);
+CREATE INDEX IF NOT EXISTS idx_quiz_questions_quiz_id ON quiz_questions(quiz_id);
+CREATE INDEX IF NOT EXISTS idx_quiz_answers_guild_id ON quiz_answers(guild_id);
+CREATE INDEX IF NOT EXISTS idx_member_xp_guild_xp ON member_xps(guild_id, xp DESC);
+CREATE INDEX IF NOT EXISTS idx_starter_schedules_guild_id ON starter_schedules(guild_id);
+CREATE INDEX IF NOT EXISTS idx_starter_posts_schedule_id ON starter_posts(schedule_id);
+CREATE INDEX IF NOT EXISTS idx_starter_post_guild_created ON starter_posts(guild_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_incidents_guild_id ON incidents(guild_id);
+CREATE INDEX IF NOT EXISTS idx_incident_updates_incident_id ON incident_updates(incident_id);
+CREATE INDEX IF NOT EXISTS idx_notes_guild_id ON notes(guild_id);
+CREATE INDEX IF NOT EXISTS idx_notes_channel_id ON notes(channel_id);
+CREATE INDEX IF NOT EXISTS idx_notes_user_id ON notes(user_id);
+CREATE INDEX IF NOT EXISTS idx_code_snippets_message_id ON code_snippets(message_id);
+CREATE INDEX IF NOT EXISTS idx_code_snippets_guild_id ON code_snippets(guild_id);
+CREATE INDEX IF NOT EXISTS idx_code_snippets_language ON code_snippets(language);
+CREATE INDEX IF NOT EXISTS idx_message_attachments_message_id ON message_attachments(message_id);
+CREATE INDEX IF NOT EXISTS idx_message_attachments_guild_id ON message_attachments(guild_id);
+CREATE INDEX IF NOT EXISTS idx_forum_posts_guild_id ON forum_posts(guild_id);
+CREATE INDEX IF NOT EXISTS idx_forum_posts_forum_id ON forum_posts(forum_id);
+CREATE INDEX IF NOT EXISTS idx_forum_posts_solution_message_id ON forum_posts(solution_message_id);
+CREATE INDEX IF NOT EXISTS idx_help_channels_guild_id ON help_channels(guild_id);
+CREATE INDEX IF NOT EXISTS idx_rollouts_status ON rollouts(status);
+CREATE INDEX IF NOT EXISTS idx_message_languages_guild_id ON message_languages(guild_id);
+CREATE INDEX IF NOT EXISTS idx_filtered_messages_guild_id ON filtered_messages(guild_id);
+CREATE INDEX IF NOT EXISTS idx_maintenance_runs_started_at ON maintenance_runs(started_at);
+CREATE INDEX IF NOT EXISTS idx_guild_partitions_left_at ON guild_partitions(left_at);
+CREATE INDEX IF NOT EXISTS idx_answer_logs_guild_created ON answer_logs(guild_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_answer_logs_created_at ON answer_logs(created_at);
 
 -- Create vector similarity search index (using cosine distance for embeddings)
 CREATE INDEX IF NOT EXISTS idx_message_embeddings_vector ON message_embeddings USING ivfflat (embedding vector_cosine_ops) WITH (lists = 100);
+CREATE INDEX IF NOT EXISTS idx_knowledge_chunks_vector ON knowledge_chunks USING ivfflat (embedding vector_cosine_ops) WITH (lists = 100);
 
 -- Insert some initial data for testing
 INSERT INTO guilds (id, name, owner_id) VALUES (0, 'T.A.R.S Test Guild', 0) ON CONFLICT (id) DO NOTHING;
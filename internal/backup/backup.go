@@ -0,0 +1,201 @@
+// Package backup dumps the bot's data to a portable archive and restores it.
+//
+// An archive is a gzip-compressed tar file holding manifest.json followed by
+// one JSON Lines file per table. Rows are serialized by PostgreSQL itself, so
+// every column type, pgvector embeddings included, round-trips through its
+// text form and the files stay readable with standard tools. Values sealed
+// with the encryption key stay sealed; restoring them needs the same key.
+package backup
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"discord-tars/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const (
+	// Format identifies archives written by this package
+	Format = "tars-backup"
+	// FormatVersion is bumped when the archive layout changes incompatibly
+	FormatVersion = 1
+
+	manifestName = "manifest.json"
+	tablesDir    = "tables/"
+)
+
+// Data groups that can be backed up or restored on their own, in restore order
+const (
+	GroupMessages   = "messages"
+	GroupEmbeddings = "embeddings"
+	GroupDocuments  = "documents"
+	GroupUserData   = "user-data"
+	GroupSettings   = "settings"
+)
+
+// Groups lists every data group in restore order
+var Groups = []string{GroupMessages, GroupEmbeddings, GroupDocuments, GroupUserData, GroupSettings}
+
+// groupModels maps each group to its models, parents before children.
+// Short-lived state such as open polls, standup sessions and calendar events
+// is left out: it expires or is re-synced on its own. So is API key usage,
+// which only meters requests.
+var groupModels = map[string][]interface{}{
+	GroupMessages: {
+		&models.Guild{},
+		&models.Channel{},
+		&models.User{},
+		&models.Message{},
+		&models.MessageReaction{},
+		&models.MessageLanguage{},
+		&models.MessageAttachment{},
+	},
+	GroupEmbeddings: {
+		&models.MessageEmbedding{},
+		&models.CodeSnippet{}, // Embedded code blocks, costly to re-extract from every message
+	},
+	GroupDocuments: {
+		&models.PriorityChannel{},
+		&models.PriorityDocument{},
+		&models.KnowledgeSource{},
+		&models.KnowledgeDocument{},
+		&models.KnowledgeChunk{},
+		&models.ChannelSummary{}, // Costly to regenerate
+		&models.MessageSentiment{},
+		&models.ChannelMood{},
+		&models.ForumPost{}, // Solved state and accepted answers can't be re-synced
+	},
+	// What members built up themselves
+	GroupUserData: {
+		&models.MemberXP{},
+		&models.Quiz{},
+		&models.QuizQuestion{},
+		&models.QuizAnswer{}, // The guild's quiz leaderboard
+		&models.Incident{},
+		&models.IncidentUpdate{},
+		&models.Note{},
+		&models.Bookmark{},
+	},
+	GroupSettings: {
+		&models.DigestSubscription{},
+		&models.StandupTeam{},
+		&models.OnboardingConfig{},
+		&models.GitHubSubscription{},
+		&models.Feed{},
+		&models.FeedItem{}, // Keeps restored feeds from re-posting old items
+		&models.CalendarSource{},
+		&models.TrackerConfig{},
+		&models.AICredential{},
+		&models.DuplicateConfig{},
+		&models.AnnouncementConfig{},
+		&models.GuildPersona{},
+		&models.PersonaMode{},
+		&models.GuildVerbosity{},
+		&models.GuildStyle{},
+		&models.GuildLanguages{},
+		&models.GuildTimezone{},
+		&models.UserTimezone{},
+		&models.XPConfig{},
+		&models.StarterSchedule{},
+		&models.StarterPost{}, // Keeps restored schedules from repeating past starters
+		&models.HelpChannel{},
+		&models.SandboxGuild{},
+		&models.Rollout{},
+		&models.APIKey{}, // Only hashes, so integrations keep working after a restore
+		&models.ToxicityConfig{},
+		&models.HighlightConfig{},
+		&models.Highlight{}, // Keeps restored starboards from re-posting messages
+	},
+}
+
+// Manifest describes an archive's contents
+type Manifest struct {
+	Format    string      `json:"format"`
+	Version   int         `json:"version"`
+	CreatedAt time.Time   `json:"created_at"`
+	Groups    []string    `json:"groups"`
+	Tables    []TableInfo `json:"tables"`
+}
+
+// TableInfo describes one table in an archive
+type TableInfo struct {
+	Name    string   `json:"name"`
+	Group   string   `json:"group"`
+	Columns []string `json:"columns"`
+	Rows    int64    `json:"rows"`
+
+	primaryKey []string
+}
+
+// Service dumps and restores the database
+type Service struct {
+	db *gorm.DB
+}
+
+// NewService creates a backup service. Statement logging is turned down since
+// restores run one statement per batch of rows.
+func NewService(db *gorm.DB) *Service {
+	return &Service{db: db.Session(&gorm.Session{Logger: logger.Default.LogMode(logger.Warn)})}
+}
+
+// ParseGroups splits a comma-separated list of group names; empty means all
+func ParseGroups(list string) ([]string, error) {
+	var groups []string
+	for _, name := range strings.Split(list, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if _, ok := groupModels[name]; !ok {
+			return nil, fmt.Errorf("unknown backup group %q (expected %s)", name, strings.Join(Groups, ", "))
+		}
+		groups = append(groups, name)
+	}
+	if len(groups) == 0 {
+		return Groups, nil
+	}
+	return groups, nil
+}
+
+// tables resolves groups to their tables in restore order
+func (s *Service) tables(groups []string) ([]TableInfo, error) {
+	selected := make(map[string]bool, len(groups))
+	for _, group := range groups {
+		selected[group] = true
+	}
+
+	var tables []TableInfo
+	for _, group := range Groups {
+		if !selected[group] {
+			continue
+		}
+		for _, model := range groupModels[group] {
+			stmt := &gorm.Statement{DB: s.db}
+			if err := stmt.Parse(model); err != nil {
+				return nil, fmt.Errorf("failed to resolve table for %T: %w", model, err)
+			}
+			tables = append(tables, TableInfo{
+				Name:       stmt.Schema.Table,
+				Group:      group,
+				primaryKey: stmt.Schema.PrimaryFieldDBNames,
+			})
+		}
+	}
+	return tables, nil
+}
+
+// columns returns a table's columns in order, or nil when the table does not exist
+func (s *Service) columns(db *gorm.DB, table string) ([]string, error) {
+	var columns []string
+	err := db.Raw(`SELECT column_name FROM information_schema.columns
+		WHERE table_schema = current_schema() AND table_name = ?
+		ORDER BY ordinal_position`, table).Scan(&columns).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to read columns of %s: %w", table, err)
+	}
+	return columns, nil
+}
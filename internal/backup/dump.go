@@ -0,0 +1,158 @@
+package backup
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Dump writes an archive of the given groups to w
+func (s *Service) Dump(ctx context.Context, w io.Writer, groups []string) (*Manifest, error) {
+	tables, err := s.tables(groups)
+	if err != nil {
+		return nil, err
+	}
+
+	// Tables are spooled to disk first: tar needs each entry's size up front
+	// and the manifest, which carries the row counts, goes first
+	dir, err := os.MkdirTemp("", "tars-backup-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	manifest := &Manifest{
+		Format:    Format,
+		Version:   FormatVersion,
+		CreatedAt: time.Now().UTC(),
+		Groups:    groups,
+	}
+	spooled := make(map[string]string, len(tables))
+	for _, table := range tables {
+		columns, err := s.columns(s.db.WithContext(ctx), table.Name)
+		if err != nil {
+			return nil, err
+		}
+		if len(columns) == 0 {
+			log.Printf("⚠️ Skipping missing table %s", table.Name)
+			continue
+		}
+		table.Columns = columns
+
+		path := dir + "/" + table.Name + ".jsonl"
+		if table.Rows, err = s.dumpTable(ctx, table, path); err != nil {
+			return nil, err
+		}
+		spooled[table.Name] = path
+		manifest.Tables = append(manifest.Tables, table)
+		log.Printf("📦 Dumped %d rows from %s", table.Rows, table.Name)
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	manifestJSON, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	if err := writeEntry(tw, manifestName, int64(len(manifestJSON)), manifest.CreatedAt, strings.NewReader(string(manifestJSON))); err != nil {
+		return nil, err
+	}
+
+	for _, table := range manifest.Tables {
+		if err := copyEntry(tw, tablesDir+table.Name+".jsonl", spooled[table.Name], manifest.CreatedAt); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finish archive: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finish archive: %w", err)
+	}
+	return manifest, nil
+}
+
+// dumpTable writes one JSON object per row to path and returns the row count
+func (s *Service) dumpTable(ctx context.Context, table TableInfo, path string) (int64, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create spool file: %w", err)
+	}
+	defer f.Close()
+
+	query := fmt.Sprintf("SELECT row_to_json(t)::text FROM %s t", pq.QuoteIdentifier(table.Name))
+	if len(table.primaryKey) > 0 {
+		// Primary key order keeps self-references such as reply_to_id restorable
+		keys := make([]string, len(table.primaryKey))
+		for i, key := range table.primaryKey {
+			keys[i] = "t." + pq.QuoteIdentifier(key)
+		}
+		query += " ORDER BY " + strings.Join(keys, ", ")
+	}
+
+	rows, err := s.db.WithContext(ctx).Raw(query).Rows()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read %s: %w", table.Name, err)
+	}
+	defer rows.Close()
+
+	buf := bufio.NewWriter(f)
+	var count int64
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			return 0, fmt.Errorf("failed to read %s: %w", table.Name, err)
+		}
+		buf.WriteString(line)
+		buf.WriteByte('\n')
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to read %s: %w", table.Name, err)
+	}
+	if err := buf.Flush(); err != nil {
+		return 0, fmt.Errorf("failed to write spool file: %w", err)
+	}
+	return count, nil
+}
+
+func writeEntry(tw *tar.Writer, name string, size int64, modTime time.Time, body io.Reader) error {
+	if err := tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0o644,
+		Size:    size,
+		ModTime: modTime,
+	}); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	if _, err := io.Copy(tw, body); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
+
+func copyEntry(tw *tar.Writer, name, path string, modTime time.Time) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open spool file: %w", err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat spool file: %w", err)
+	}
+	return writeEntry(tw, name, info.Size(), modTime, f)
+}
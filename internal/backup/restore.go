@@ -0,0 +1,232 @@
+package backup
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+const (
+	restoreBatchSize = 200
+	maxRowBytes      = 64 << 20
+)
+
+// ErrInvalidArchive is returned when the input is not a backup archive
+var ErrInvalidArchive = errors.New("not a T.A.R.S backup archive")
+
+// RestoreOptions control what a restore touches
+type RestoreOptions struct {
+	Groups []string // Groups to restore; empty restores everything in the archive
+	// Replace empties the restored tables first, along with rows that reference
+	// them. Otherwise rows are merged and existing keys are left as they are.
+	Replace bool
+}
+
+// Restore loads an archive written by Dump. Everything runs in one
+// transaction, so a failed restore leaves the database untouched.
+func (s *Service) Restore(ctx context.Context, r io.Reader, opts RestoreOptions) (*Manifest, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+
+	manifest, err := readManifest(tr)
+	if err != nil {
+		return nil, err
+	}
+
+	selected := make(map[string]bool)
+	for _, group := range opts.Groups {
+		selected[group] = true
+	}
+	tables := make(map[string]TableInfo)
+	var names []string
+	for _, table := range manifest.Tables {
+		if len(selected) > 0 && !selected[table.Group] {
+			continue
+		}
+		tables[table.Name] = table
+		names = append(names, table.Name)
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Only tables that exist here can be restored; older or newer
+		// versions of the bot may have a different set
+		targetColumns := make(map[string][]string, len(names))
+		var existing []string
+		for _, name := range names {
+			columns, err := s.columns(tx, name)
+			if err != nil {
+				return err
+			}
+			if len(columns) == 0 {
+				log.Printf("⚠️ Skipping table %s: it does not exist in this database", name)
+				continue
+			}
+			targetColumns[name] = columns
+			existing = append(existing, name)
+		}
+
+		if opts.Replace && len(existing) > 0 {
+			quoted := make([]string, len(existing))
+			for i, name := range existing {
+				quoted[i] = pq.QuoteIdentifier(name)
+			}
+			if err := tx.Exec("TRUNCATE " + strings.Join(quoted, ", ") + " CASCADE").Error; err != nil {
+				return fmt.Errorf("failed to empty tables: %w", err)
+			}
+		}
+
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("failed to read archive: %w", err)
+			}
+
+			name := strings.TrimSuffix(strings.TrimPrefix(hdr.Name, tablesDir), ".jsonl")
+			table, ok := tables[name]
+			if !ok || targetColumns[name] == nil {
+				continue
+			}
+
+			columns := sharedColumns(table.Columns, targetColumns[name])
+			restored, err := restoreTable(tx, name, columns, tr)
+			if err != nil {
+				return err
+			}
+			if err := resetSequences(tx, name); err != nil {
+				return err
+			}
+			log.Printf("📥 Restored %d of %d rows into %s", restored, table.Rows, name)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+func readManifest(tr *tar.Reader) (*Manifest, error) {
+	hdr, err := tr.Next()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+	if hdr.Name != manifestName {
+		return nil, fmt.Errorf("%w: missing %s", ErrInvalidArchive, manifestName)
+	}
+
+	var manifest Manifest
+	if err := json.NewDecoder(tr).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+	if manifest.Format != Format {
+		return nil, fmt.Errorf("%w: format %q", ErrInvalidArchive, manifest.Format)
+	}
+	if manifest.Version > FormatVersion {
+		return nil, fmt.Errorf("backup format version %d is newer than this build supports (%d)", manifest.Version, FormatVersion)
+	}
+	return &manifest, nil
+}
+
+// restoreTable inserts rows in batches; PostgreSQL parses each column back from
+// its JSON form, and columns missing from the archive take their defaults
+func restoreTable(tx *gorm.DB, table string, columns []string, r io.Reader) (int64, error) {
+	quotedTable := pq.QuoteIdentifier(table)
+	quoted := make([]string, len(columns))
+	for i, column := range columns {
+		quoted[i] = pq.QuoteIdentifier(column)
+	}
+	columnList := strings.Join(quoted, ", ")
+	query := fmt.Sprintf(
+		"INSERT INTO %s (%s) SELECT %s FROM json_populate_recordset(NULL::%s, ?::json) ON CONFLICT DO NOTHING",
+		quotedTable, columnList, columnList, quotedTable,
+	)
+
+	var restored int64
+	batch := make([]string, 0, restoreBatchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		result := tx.Exec(query, "["+strings.Join(batch, ",")+"]")
+		if result.Error != nil {
+			return fmt.Errorf("failed to restore %s: %w", table, result.Error)
+		}
+		restored += result.RowsAffected
+		batch = batch[:0]
+		return nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxRowBytes)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		batch = append(batch, line)
+		if len(batch) == restoreBatchSize {
+			if err := flush(); err != nil {
+				return restored, err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return restored, fmt.Errorf("failed to read %s from archive: %w", table, err)
+	}
+	return restored, flush()
+}
+
+// resetSequences moves a table's serial counters past the restored IDs
+func resetSequences(tx *gorm.DB, table string) error {
+	var sequences []struct {
+		ColumnName   string
+		SequenceName string
+	}
+	err := tx.Raw(`SELECT column_name, pg_get_serial_sequence(quote_ident(table_name), column_name) AS sequence_name
+		FROM information_schema.columns
+		WHERE table_schema = current_schema() AND table_name = ?
+		AND pg_get_serial_sequence(quote_ident(table_name), column_name) IS NOT NULL`, table).Scan(&sequences).Error
+	if err != nil {
+		return fmt.Errorf("failed to find sequences of %s: %w", table, err)
+	}
+
+	for _, seq := range sequences {
+		query := fmt.Sprintf("SELECT setval(?::text::regclass, COALESCE((SELECT MAX(%s) FROM %s), 0) + 1, false)",
+			pq.QuoteIdentifier(seq.ColumnName), pq.QuoteIdentifier(table))
+		if err := tx.Exec(query, seq.SequenceName).Error; err != nil {
+			return fmt.Errorf("failed to reset sequence %s: %w", seq.SequenceName, err)
+		}
+	}
+	return nil
+}
+
+// sharedColumns keeps the archive's columns that also exist in the target table
+func sharedColumns(archived, target []string) []string {
+	exists := make(map[string]bool, len(target))
+	for _, column := range target {
+		exists[column] = true
+	}
+	var shared []string
+	for _, column := range archived {
+		if exists[column] {
+			shared = append(shared, column)
+		}
+	}
+	return shared
+}
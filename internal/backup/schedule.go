@@ -0,0 +1,114 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"time"
+
+	"discord-tars/internal/storage"
+)
+
+// Archiver uploads backups to a store and prunes old ones
+type Archiver struct {
+	service   *Service
+	store     storage.Store
+	retention time.Duration
+}
+
+// NewArchiver creates an archiver; a zero retention keeps every backup
+func NewArchiver(service *Service, store storage.Store, retention time.Duration) *Archiver {
+	return &Archiver{service: service, store: store, retention: retention}
+}
+
+// Upload dumps the given groups and stores the archive, returning its key
+func (a *Archiver) Upload(ctx context.Context, groups []string) (string, *Manifest, error) {
+	f, err := os.CreateTemp("", "tars-backup-*.tar.gz")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	manifest, err := a.service.Dump(ctx, f, groups)
+	if err != nil {
+		return "", nil, err
+	}
+	size, err := f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to size backup: %w", err)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", nil, fmt.Errorf("failed to rewind backup: %w", err)
+	}
+
+	key := fmt.Sprintf("%stars-%s.tar.gz", storage.PrefixBackups, manifest.CreatedAt.Format("20060102T150405Z"))
+	if err := a.store.Put(ctx, key, f, size, "application/gzip"); err != nil {
+		return "", nil, fmt.Errorf("failed to upload backup: %w", err)
+	}
+	return key, manifest, nil
+}
+
+// Latest returns the key of the newest stored backup
+func (a *Archiver) Latest(ctx context.Context) (string, error) {
+	objects, err := a.list(ctx)
+	if err != nil {
+		return "", err
+	}
+	if len(objects) == 0 {
+		return "", storage.ErrNotFound
+	}
+	return objects[len(objects)-1].Key, nil
+}
+
+// Open reads a stored backup
+func (a *Archiver) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	return a.store.Get(ctx, key)
+}
+
+// Run is the scheduler job: it stores a full backup and prunes expired ones
+func (a *Archiver) Run(ctx context.Context) error {
+	key, manifest, err := a.Upload(ctx, Groups)
+	if err != nil {
+		return err
+	}
+	var rows int64
+	for _, table := range manifest.Tables {
+		rows += table.Rows
+	}
+	log.Printf("💾 Stored backup %s (%d rows)", key, rows)
+
+	if a.retention <= 0 {
+		return nil
+	}
+	objects, err := a.list(ctx)
+	if err != nil || len(objects) == 0 {
+		return err
+	}
+	// The backup just written is never pruned, even with a tiny retention
+	for _, obj := range objects[:len(objects)-1] {
+		if time.Since(obj.LastModified) < a.retention {
+			continue
+		}
+		if err := a.store.Delete(ctx, obj.Key); err != nil {
+			log.Printf("⚠️ Failed to delete expired backup %s: %v", obj.Key, err)
+			continue
+		}
+		log.Printf("🧹 Deleted expired backup %s", obj.Key)
+	}
+	return nil
+}
+
+// list returns stored backups, oldest first
+func (a *Archiver) list(ctx context.Context) ([]storage.Object, error) {
+	objects, err := a.store.List(ctx, storage.PrefixBackups)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list backups: %w", err)
+	}
+	// Keys embed the creation time, so they sort chronologically
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Key < objects[j].Key })
+	return objects, nil
+}
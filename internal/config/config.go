@@ -4,22 +4,56 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
-	Discord    DiscordConfig
-	OpenAI     OpenAIConfig
-	Database   DatabaseConfig
-	Redis      RedisConfig
-	App        AppConfig
-	Monitoring MonitoringConfig
+	Discord     DiscordConfig
+	OpenAI      OpenAIConfig
+	Database    DatabaseConfig
+	Redis       RedisConfig
+	Events      EventsConfig
+	Worker      WorkerConfig
+	Outbox      OutboxConfig
+	Rollout     RolloutConfig
+	App         AppConfig
+	Dashboard   DashboardConfig
+	Monitoring  MonitoringConfig
+	Scheduler   SchedulerConfig
+	GitHub      GitHubConfig
+	Storage     StorageConfig
+	VectorStore VectorStoreConfig
+	Security    SecurityConfig
+	RAG         RAGConfig
+	Agent       AgentConfig
+	Backup      BackupConfig
+	Export      ExportConfig
+	Maintenance MaintenanceConfig
+	Memory      MemoryConfig
 }
 
 type DiscordConfig struct {
 	Token   string
 	GuildID string
+	// PresenceTemplates are "<activity>:<text>" statuses the bot rotates
+	// through; empty uses the built-in set
+	PresenceTemplates []string
+	PresenceInterval  time.Duration
+	// AllowedMentions are the mention types the bot's messages may ping:
+	// users, roles, everyone, or none
+	AllowedMentions []string
+	// StageTranscription lets stage organizers have the bot transcribe a
+	// stage into a text channel and the index (/stage transcribe)
+	StageTranscription bool
+	// Sandbox holds back every reply of the instance, as for a staging bot
+	// trying prompt changes on live guilds: replies are logged and posted to
+	// SandboxChannelID, if set, instead. Guilds can also turn it on for
+	// themselves with /sandbox.
+	Sandbox          bool
+	SandboxChannelID int64
 }
 
 type OpenAIConfig struct {
@@ -27,6 +61,19 @@ type OpenAIConfig struct {
 	Model          string
 	EmbeddingModel string
 	TTSModel       string // Added for TTS
+	// RequireGuildKey makes every server bring its own key via /aikey instead
+	// of using APIKey, which then only pays for embeddings
+	RequireGuildKey bool
+	// GlobalConcurrency and GuildConcurrency cap AI requests running at once,
+	// overall and per guild; 0 for no limit
+	GlobalConcurrency int
+	GuildConcurrency  int
+	QueueTimeout      time.Duration
+	// Offline answers AI requests from the responses recorded in FixturesDir,
+	// without an API key; Record saves every response there
+	Offline     bool
+	Record      bool
+	FixturesDir string
 }
 
 type DatabaseConfig struct {
@@ -36,6 +83,16 @@ type DatabaseConfig struct {
 	Password string
 	DBName   string
 	SSLMode  string
+	// QueryTimeout stops searches and other read queries running longer;
+	// zero leaves them to the request's own deadline
+	QueryTimeout time.Duration
+	// PrepareStatements prepares each distinct query once per connection and
+	// reuses it; turn it off behind a pooler that doesn't keep a client's
+	// session, such as PgBouncer in transaction mode
+	PrepareStatements bool
+	// PartitionRetention is how long a guild's messages are kept after the bot
+	// leaves it, once messages is partitioned by guild; zero keeps them
+	PartitionRetention time.Duration
 }
 
 type RedisConfig struct {
@@ -45,20 +102,323 @@ type RedisConfig struct {
 	DB       int
 }
 
+type EventsConfig struct {
+	Backend   string // "memory" or "redis"; Redis streams survive restarts and share work between instances
+	QueueSize int    // Events buffered per in-process subscriber before new ones are dropped
+	// StreamMaxLen caps each Redis stream; older events are trimmed
+	StreamMaxLen int
+}
+
+type WorkerConfig struct {
+	// Enabled hands digests, summaries, feed polling and pruning to cmd/worker,
+	// and embeddings too when the event bus is Redis; the bot stops running them
+	Enabled      bool
+	Concurrency  int           // Jobs of each kind a worker runs at once
+	PollInterval time.Duration // How often idle workers look for due jobs
+	MaxAttempts  int           // Runs before a job is marked failed
+	JobRetention time.Duration // Finished jobs are kept this long for inspection
+	HTTPPort     int           // Health checks and /admin/jobs
+}
+
+type OutboxConfig struct {
+	Interval    time.Duration // How often failed sends are retried when due
+	MaxAttempts int           // Sends before a message is marked failed for operators
+	Retention   time.Duration // Sent and failed messages are kept this long
+}
+
+// RolloutConfig sets when a rollout's canary guilds count as doing worse than
+// the others. Increases are in points of share of answers.
+type RolloutConfig struct {
+	Interval             time.Duration // How often the canary is judged
+	MinSamples           int           // Answers each cohort needs before it is
+	MaxErrorIncrease     float64
+	MaxUncertainIncrease float64
+	MaxNegativeIncrease  float64 // Thumbs down on answers
+}
+
 type AppConfig struct {
 	Environment string
 	LogLevel    string
 	HTTPPort    int
 	GRPCPort    int
+	AdminToken  string // Bearer token for /admin endpoints, besides admin API keys; none when empty
+	APIToken    string // Bearer token for every /api endpoint, besides API keys; none when empty
+}
+
+// DashboardConfig enables the web dashboard at /dashboard/, where guild admins
+// sign in with Discord to see usage, change settings and review answers
+type DashboardConfig struct {
+	ClientID     string // OAuth2 credentials of the bot's Discord application
+	ClientSecret string
+	// PublicURL is the base URL the HTTP server is reached at; Discord sends
+	// users back to PublicURL/dashboard/callback, which must be registered as
+	// a redirect of the application
+	PublicURL     string
+	SessionSecret string        // Signs session cookies; at least 32 characters
+	SessionTTL    time.Duration // Sign-ins, and the admin rights read at sign-in, last this long
+	// AnswerRetention is how long answers and their feedback are kept for review
+	AnswerRetention time.Duration
+}
+
+// Enabled reports whether the dashboard's OAuth2 credentials are set
+func (c DashboardConfig) Enabled() bool {
+	return c.ClientID != "" && c.ClientSecret != ""
 }
 
 type MonitoringConfig struct {
 	PrometheusPort int
 	GrafanaPort    int
 	JaegerEndpoint string
+
+	// AlertWebhookURL is a Discord or Slack webhook for operator alerts
+	AlertWebhookURL string
+	AlertCooldown   time.Duration // Minimum time between repeated alerts
+	// CommandSLO is the p95 latency objective of commands; CommandSLOs
+	// overrides it per command
+	CommandSLO  time.Duration
+	CommandSLOs map[string]time.Duration
+	// SlowSearchThreshold alerts with the query plan when a vector search takes longer
+	SlowSearchThreshold time.Duration
+
+	// DebugAddr serves pprof and expvar, e.g. "127.0.0.1:6060"; empty disables it
+	DebugAddr  string
+	DebugToken string // Bearer token required by the debug endpoints
+}
+
+type SchedulerConfig struct {
+	DigestInterval           time.Duration // How often due digests are checked
+	StandupInterval          time.Duration // How often open standups are checked for reminders and closing
+	PollInterval             time.Duration // How often expired polls are closed
+	FeedInterval             time.Duration // How often feeds are checked for elapsed per-feed schedules
+	CalendarSyncInterval     time.Duration // How often calendars are re-fetched
+	CalendarReminderInterval time.Duration // How often upcoming events are checked for reminders
+	KnowledgeSyncInterval    time.Duration // How often Notion/Confluence sources are re-synced
+	StorageCleanupInterval   time.Duration // How often expired stored files are deleted
+	ChannelSummaryInterval   time.Duration // How often finished days are checked for channels to summarize
+	DuplicatePruneInterval   time.Duration // How often answers too old to be linked are deleted
+	PersonaModeInterval      time.Duration // How often scheduled persona modes are checked for starting or ending
+	MoodScoringInterval      time.Duration // How often finished days are checked for channels to score the mood of
+	HighlightDigestInterval  time.Duration // How often weekly highlight "best of" posts are checked for being due
+	StarterInterval          time.Duration // How often scheduled conversation starters are checked for being due
+	ReminderMaxAhead         time.Duration // How far ahead /remind accepts reminders
+	// LeaderElection lets several bot replicas share a database: only the one
+	// holding a Postgres advisory lock runs scheduled jobs and registers commands
+	LeaderElection      bool
+	LeaderCheckInterval time.Duration // How often followers campaign and the leader checks its lock
+}
+
+type GitHubConfig struct {
+	Token         string // Optional; raises rate limits and allows private repositories
+	WebhookSecret string // Required to accept webhook deliveries
+}
+
+type RAGConfig struct {
+	// ConfidenceThreshold is the minimum grounding score (0-1) an answer about the
+	// server needs before it is shown; zero disables the check
+	ConfidenceThreshold float64
+	// QueryRewrite rewrites questions into several search phrasings before retrieval
+	QueryRewrite bool
+	// DuplicateThreshold drops search hits at least this similar (cosine) to a
+	// better one; zero keeps near-duplicates
+	DuplicateThreshold float64
+	// MMRLambda trades relevance (1) against variety (0) when picking search hits
+	MMRLambda float64
+	// ReactionBoost ranks search hits higher the more reactions they got; 0
+	// ranks by similarity only
+	ReactionBoost float64
+	// ResponseCacheTTL reuses answers to questions asked again against the same
+	// retrieved context; zero disables it. ResponseCacheSimilarity is how close
+	// (cosine) differently worded questions must be to match.
+	ResponseCacheTTL        time.Duration
+	ResponseCacheSimilarity float64
+	// ChannelSummaries summarizes each active channel's day every night and
+	// searches those summaries, for questions about what happened over a period
+	ChannelSummaries bool
+	// SummaryMinMessages is how many messages a channel needs in a day to be summarized
+	SummaryMinMessages int
+	// SolvedBoost ranks search hits higher for being the accepted answer of a
+	// forum post; 0 ignores it
+	SolvedBoost float64
+	// ForumSuggestions replies to new forum posts with similar earlier ones
+	ForumSuggestions bool
+	// HelpAnswers lets guilds designate help channels (/help-channels) whose
+	// new posts get earlier answers at least HelpAnswerSimilarity (cosine)
+	// close summed up, at most once per thread per HelpAnswerCooldown
+	HelpAnswers          bool
+	HelpAnswerSimilarity float64
+	HelpAnswerCooldown   time.Duration
+	// CodeSearch embeds the fenced code blocks of messages on their own, with
+	// their language, for /search code
+	CodeSearch bool
+	// FileSearch records the files shared in messages, with the text of
+	// documents (plain text, Word, Excel and PowerPoint), for /search files
+	FileSearch bool
+	// OCREngine reads the text of images shared in messages, such as
+	// screenshots of errors, so questions and /search find them: "vision"
+	// (OCRModel) or "tesseract" (TesseractPath, reading OCRLanguages); empty
+	// disables it. The text is stored with the files FileSearch indexes.
+	OCREngine     string
+	OCRModel      string
+	TesseractPath string
+	OCRLanguages  string
+	// Ingest filters keep low-value messages out of retrieval: messages with
+	// fewer than IngestMinLength letters and digits, matching an
+	// IngestBlocklist pattern, made only of links (IngestSkipLinkOnly) or
+	// starting with one of IngestCommandPrefixes are stored but not embedded.
+	// Members whose messages within IngestSpamWindow score IngestSpamThreshold
+	// (one per message, more for repeats, links and mass mentions) aren't
+	// indexed until they slow down; 0 disables it.
+	IngestMinLength       int
+	IngestBlocklist       []string
+	IngestSkipLinkOnly    bool
+	IngestCommandPrefixes string
+	IngestSpamThreshold   float64
+	IngestSpamWindow      time.Duration
+	// Messages the ingest filters let through are scored, from 0 to 1, on how
+	// likely they are to carry information worth finding again: those below
+	// IngestSkipBelow aren't embedded, those in between IngestSkipBelow and
+	// IngestEmbedAbove are judged by IngestClassifierModel when set and
+	// embedded otherwise; 0 for both disables it
+	IngestSkipBelow       float64
+	IngestEmbedAbove      float64
+	IngestClassifierModel string
+	// MessageLanguages records the language of each indexed message and lets
+	// guilds restrict retrieval to some languages (/rag languages);
+	// QueryTranslation also searches translations of each question into the
+	// guild's other languages
+	MessageLanguages bool
+	QueryTranslation bool
+	// DuplicateQuestions lets guilds opt in to having chat questions already
+	// answered linked to the FAQ entry or earlier answer at least
+	// DuplicateFAQSimilarity or DuplicateAnswerSimilarity (cosine) close
+	DuplicateQuestions        bool
+	DuplicateFAQSimilarity    float64
+	DuplicateAnswerSimilarity float64
+	DuplicateAnswerMaxAge     time.Duration // Older answers aren't linked, and are pruned
+	DuplicateCooldown         time.Duration // Minimum time between links in a channel
+	// MoodTracking scores the sentiment of each active channel's messages every
+	// night, for /mood; MoodMinMessages is how many a channel needs in a day
+	MoodTracking    bool
+	MoodMinMessages int
+	// ToxicityWarnings lets guilds (/toxicity) have new messages scored to warn
+	// moderators when an argument escalates, at most once per channel per
+	// ToxicityCoolOff
+	ToxicityWarnings bool
+	ToxicityCoolOff  time.Duration
+}
+
+type AgentConfig struct {
+	MaxSteps        int           // Tool calls per deep /ask
+	TimeBudget      time.Duration // Research time per deep /ask, before the answer is written
+	WebSearchAPIKey string        // Brave Search API key; without it the agent can only fetch URLs
+	DisableWeb      bool
+}
+
+type MemoryConfig struct {
+	Enabled     bool
+	TokenBudget int           // Prompt budget for a channel's remembered conversation
+	KeepTurns   int           // Recent exchanges never folded into the summary
+	TTL         time.Duration // Idle conversations are forgotten after this long
+}
+
+type BackupConfig struct {
+	Interval  time.Duration // How often a full backup is stored; zero disables scheduled backups
+	Retention time.Duration // Zero keeps every backup
+	S3Bucket  string        // Separate bucket for backups; defaults to the file storage backend
+}
+
+// ExportConfig schedules index exports to the file store, as bot export-index
+// writes them
+type ExportConfig struct {
+	Interval     time.Duration // Zero disables scheduled exports
+	GuildIDs     []int64       // Guilds exported; every guild when empty
+	EmbeddedOnly bool          // Leave out messages without an embedding
+	Retention    time.Duration // Zero keeps every export
+}
+
+// MaintenanceConfig sets when the vector indexes are analyzed and rebuilt
+type MaintenanceConfig struct {
+	Interval       time.Duration // Time between runs; zero disables scheduled runs
+	Window         string        // Off-peak hours runs start in, e.g. 02:00-05:00; empty allows any time
+	Timezone       string        // IANA zone the window is in
+	BloatThreshold float64       // Estimated wasted space, in percent, at which an index is rebuilt
+}
+
+type SecurityConfig struct {
+	EncryptionKey     string // 32 bytes, base64 or hex; enables encrypted per-guild secrets
+	EncryptionKeyFile string // Alternative to EncryptionKey, e.g. a mounted secret
+	// KMSEncryptedKey is a data key encrypted with AWS KMS, decrypted at startup
+	KMSEncryptedKey string
+	KMSRegion       string
+	KMSEndpoint     string
+	AWSAccessKey    string
+	AWSSecretKey    string
+	AWSSessionToken string
+	// EncryptMessages stores message content encrypted at rest
+	EncryptMessages bool
+	// AuditRetention is how long the command audit log is kept; zero keeps it
+	AuditRetention time.Duration
+	// InjectionAlerts reports suspected prompt injection in the moderators'
+	// alert channel set up with /toxicity, besides the audit log
+	InjectionAlerts bool
+}
+
+// HasEncryptionKey reports whether any encryption key source is configured
+func (c SecurityConfig) HasEncryptionKey() bool {
+	return c.EncryptionKey != "" || c.EncryptionKeyFile != "" || c.KMSEncryptedKey != ""
+}
+
+// VectorStoreConfig selects where message embeddings are searched
+type VectorStoreConfig struct {
+	Backend          string // "pgvector" or "qdrant"
+	QdrantURL        string
+	QdrantAPIKey     string
+	QdrantCollection string
+}
+
+type StorageConfig struct {
+	Backend    string // "local" or "s3"
+	LocalPath  string
+	PublicURL  string // Public base URL of the HTTP server, for local signed links
+	SigningKey string
+
+	S3Endpoint  string
+	S3Region    string
+	S3Bucket    string
+	S3AccessKey string
+	S3SecretKey string
+	S3PathStyle bool
+
+	ArchiveAttachments  bool
+	MaxAttachmentBytes  int64
+	AttachmentRetention time.Duration // Zero keeps files forever
+	RecordingRetention  time.Duration
+	ImageRetention      time.Duration
+	DocumentRetention   time.Duration
 }
 
 func LoadConfig() (*Config, error) {
+	config := load()
+	return config, config.validate()
+}
+
+// LoadToolConfig loads configuration for maintenance commands such as backup
+// and restore, which only need the database and storage settings
+func LoadToolConfig() (*Config, error) {
+	config := load()
+	if config.Database.Password == "" {
+		return config, fmt.Errorf("POSTGRES_PASSWORD is required")
+	}
+	return config, nil
+}
+
+// LoadClientConfig loads the configuration for tools that only talk to a
+// running bot over HTTP, such as the dashboard, so nothing is required
+func LoadClientConfig() *Config {
+	return load()
+}
+
+func load() *Config {
 	// Load .env file
 	_ = godotenv.Load() // Don't fail if .env doesn't exist
 
@@ -66,12 +426,27 @@ func LoadConfig() (*Config, error) {
 		Discord: DiscordConfig{
 			Token:   os.Getenv("DISCORD_TOKEN"),
 			GuildID: os.Getenv("DISCORD_GUILD_ID"),
+			// Semicolon-separated, since templates may contain commas
+			PresenceTemplates:  getEnvList("PRESENCE_TEMPLATES", ";"),
+			PresenceInterval:   getEnvDurationOrDefault("PRESENCE_INTERVAL", time.Minute),
+			AllowedMentions:    strings.Split(getEnvOrDefault("ALLOWED_MENTIONS", "users"), ","),
+			StageTranscription: getEnvBoolOrDefault("STAGE_TRANSCRIPTION", true),
+			Sandbox:            getEnvBoolOrDefault("SANDBOX", false),
+			SandboxChannelID:   getEnvInt64OrDefault("SANDBOX_CHANNEL_ID", 0),
 		},
 		OpenAI: OpenAIConfig{
-			APIKey:         os.Getenv("OPENAI_API_KEY"),
-			Model:          getEnvOrDefault("OPENAI_MODEL", "gpt-4o-mini"),
-			EmbeddingModel: getEnvOrDefault("OPENAI_EMBEDDING_MODEL", "text-embedding-3-small"),
-			TTSModel:       getEnvOrDefault("OPENAI_TTS_MODEL", "tts-1"), // Added for TTS
+			APIKey:            os.Getenv("OPENAI_API_KEY"),
+			Model:             getEnvOrDefault("OPENAI_MODEL", "gpt-4o-mini"),
+			EmbeddingModel:    getEnvOrDefault("OPENAI_EMBEDDING_MODEL", "text-embedding-3-small"),
+			TTSModel:          getEnvOrDefault("OPENAI_TTS_MODEL", "tts-1"), // Added for TTS
+			RequireGuildKey:   getEnvBoolOrDefault("AI_REQUIRE_GUILD_KEY", false),
+			GlobalConcurrency: getEnvIntOrDefault("AI_GLOBAL_CONCURRENCY", 8),
+			GuildConcurrency:  getEnvIntOrDefault("AI_GUILD_CONCURRENCY", 2),
+			QueueTimeout:      getEnvDurationOrDefault("AI_QUEUE_TIMEOUT", 20*time.Second),
+
+			Offline:     getEnvBoolOrDefault("OFFLINE", false),
+			Record:      getEnvBoolOrDefault("AI_RECORD", false),
+			FixturesDir: getEnvOrDefault("AI_FIXTURES_DIR", "fixtures/ai"),
 		},
 		Database: DatabaseConfig{
 			Host:     getEnvOrDefault("POSTGRES_HOST", "localhost"),
@@ -80,28 +455,271 @@ func LoadConfig() (*Config, error) {
 			Password: os.Getenv("POSTGRES_PASSWORD"),
 			DBName:   getEnvOrDefault("POSTGRES_DB", "tars_db"),
 			SSLMode:  getEnvOrDefault("POSTGRES_SSL_MODE", "disable"),
+
+			QueryTimeout:       getEnvDurationOrDefault("POSTGRES_QUERY_TIMEOUT", 10*time.Second),
+			PrepareStatements:  getEnvBoolOrDefault("POSTGRES_PREPARE_STATEMENTS", true),
+			PartitionRetention: getEnvDurationOrDefault("MESSAGE_PARTITION_RETENTION", 30*24*time.Hour),
+		},
+		Redis: RedisConfig{
+			Host:     getEnvOrDefault("REDIS_HOST", "localhost"),
+			Port:     getEnvIntOrDefault("REDIS_PORT", 6379),
+			Password: os.Getenv("REDIS_PASSWORD"),
+			DB:       getEnvIntOrDefault("REDIS_DB", 0),
+		},
+		Events: EventsConfig{
+			Backend:      getEnvOrDefault("EVENT_BUS", "memory"),
+			QueueSize:    getEnvIntOrDefault("EVENT_QUEUE_SIZE", 1000),
+			StreamMaxLen: getEnvIntOrDefault("EVENT_STREAM_MAX_LEN", 10000),
+		},
+		Worker: WorkerConfig{
+			Enabled:      getEnvBoolOrDefault("BACKGROUND_WORKER", false),
+			Concurrency:  getEnvIntOrDefault("WORKER_CONCURRENCY", 2),
+			PollInterval: getEnvDurationOrDefault("WORKER_POLL_INTERVAL", 5*time.Second),
+			MaxAttempts:  getEnvIntOrDefault("JOB_MAX_ATTEMPTS", 3),
+			JobRetention: getEnvDurationOrDefault("JOB_RETENTION", 7*24*time.Hour),
+			HTTPPort:     getEnvIntOrDefault("WORKER_HTTP_PORT", 8081),
+		},
+		Outbox: OutboxConfig{
+			Interval:    getEnvDurationOrDefault("OUTBOX_INTERVAL", 15*time.Second),
+			MaxAttempts: getEnvIntOrDefault("OUTBOX_MAX_ATTEMPTS", 8),
+			Retention:   getEnvDurationOrDefault("OUTBOX_RETENTION", 7*24*time.Hour),
+		},
+		Rollout: RolloutConfig{
+			Interval:             getEnvDurationOrDefault("ROLLOUT_INTERVAL", 5*time.Minute),
+			MinSamples:           getEnvIntOrDefault("ROLLOUT_MIN_SAMPLES", 30),
+			MaxErrorIncrease:     getEnvFloatOrDefault("ROLLOUT_MAX_ERROR_INCREASE", 0.05),
+			MaxUncertainIncrease: getEnvFloatOrDefault("ROLLOUT_MAX_UNCERTAIN_INCREASE", 0.10),
+			MaxNegativeIncrease:  getEnvFloatOrDefault("ROLLOUT_MAX_NEGATIVE_INCREASE", 0.05),
 		},
 		App: AppConfig{
 			Environment: getEnvOrDefault("ENVIRONMENT", "development"),
 			LogLevel:    getEnvOrDefault("LOG_LEVEL", "info"),
 			HTTPPort:    getEnvIntOrDefault("HTTP_PORT", 8080),
 			GRPCPort:    getEnvIntOrDefault("GRPC_PORT", 8081),
+			AdminToken:  os.Getenv("ADMIN_API_TOKEN"),
+			APIToken:    os.Getenv("API_TOKEN"),
+		},
+		Dashboard: DashboardConfig{
+			ClientID:        os.Getenv("DISCORD_CLIENT_ID"),
+			ClientSecret:    os.Getenv("DISCORD_CLIENT_SECRET"),
+			PublicURL:       strings.TrimRight(os.Getenv("DASHBOARD_URL"), "/"),
+			SessionSecret:   os.Getenv("DASHBOARD_SESSION_SECRET"),
+			SessionTTL:      getEnvDurationOrDefault("DASHBOARD_SESSION_TTL", 12*time.Hour),
+			AnswerRetention: getEnvDurationOrDefault("ANSWER_LOG_RETENTION", 30*24*time.Hour),
+		},
+		Monitoring: MonitoringConfig{
+			PrometheusPort:      getEnvIntOrDefault("PROMETHEUS_PORT", 9090),
+			GrafanaPort:         getEnvIntOrDefault("GRAFANA_PORT", 3000),
+			JaegerEndpoint:      os.Getenv("JAEGER_ENDPOINT"),
+			AlertWebhookURL:     os.Getenv("ALERT_WEBHOOK_URL"),
+			AlertCooldown:       getEnvDurationOrDefault("ALERT_COOLDOWN", 30*time.Minute),
+			CommandSLO:          getEnvDurationOrDefault("SLO_COMMAND_P95", 10*time.Second),
+			CommandSLOs:         getEnvDurationMap("SLO_COMMAND_TARGETS"),
+			SlowSearchThreshold: getEnvDurationOrDefault("SLOW_SEARCH_THRESHOLD", 2*time.Second),
+			DebugAddr:           os.Getenv("DEBUG_ADDR"),
+			DebugToken:          os.Getenv("DEBUG_TOKEN"),
+		},
+		GitHub: GitHubConfig{
+			Token:         os.Getenv("GITHUB_TOKEN"),
+			WebhookSecret: os.Getenv("GITHUB_WEBHOOK_SECRET"),
+		},
+		RAG: RAGConfig{
+			ConfidenceThreshold:     getEnvFloatOrDefault("ANSWER_CONFIDENCE_THRESHOLD", 0.5),
+			QueryRewrite:            getEnvBoolOrDefault("QUERY_REWRITE", true),
+			DuplicateThreshold:      getEnvFloatOrDefault("RETRIEVAL_DUPLICATE_THRESHOLD", 0.97),
+			MMRLambda:               getEnvFloatOrDefault("RETRIEVAL_MMR_LAMBDA", 0.7),
+			ReactionBoost:           getEnvFloatOrDefault("RETRIEVAL_REACTION_BOOST", 0.03),
+			ResponseCacheTTL:        getEnvDurationOrDefault("RESPONSE_CACHE_TTL", time.Hour),
+			ResponseCacheSimilarity: getEnvFloatOrDefault("RESPONSE_CACHE_SIMILARITY", 0.95),
+			ChannelSummaries:        getEnvBoolOrDefault("CHANNEL_SUMMARIES", true),
+			SummaryMinMessages:      getEnvIntOrDefault("CHANNEL_SUMMARY_MIN_MESSAGES", 10),
+			CodeSearch:              getEnvBoolOrDefault("CODE_SEARCH", true),
+			FileSearch:              getEnvBoolOrDefault("FILE_SEARCH", true),
+			OCREngine:               getEnvOrDefault("OCR_ENGINE", ""),
+			OCRModel:                getEnvOrDefault("OCR_MODEL", "gpt-4o-mini"),
+			TesseractPath:           getEnvOrDefault("TESSERACT_PATH", "tesseract"),
+			OCRLanguages:            getEnvOrDefault("OCR_LANGUAGES", "eng"),
+			IngestMinLength:         getEnvIntOrDefault("INGEST_MIN_LENGTH", 8),
+			IngestBlocklist:         getEnvList("INGEST_BLOCKLIST", ";"),
+			IngestSkipLinkOnly:      getEnvBoolOrDefault("INGEST_SKIP_LINK_ONLY", true),
+			IngestCommandPrefixes:   getEnvOrDefault("INGEST_COMMAND_PREFIXES", "!$"),
+			IngestSpamThreshold:     getEnvFloatOrDefault("INGEST_SPAM_THRESHOLD", 15),
+			IngestSpamWindow:        getEnvDurationOrDefault("INGEST_SPAM_WINDOW", time.Minute),
+			IngestSkipBelow:         getEnvFloatOrDefault("INGEST_SKIP_BELOW", 0.2),
+			IngestEmbedAbove:        getEnvFloatOrDefault("INGEST_EMBED_ABOVE", 0.5),
+			IngestClassifierModel:   getEnvOrDefault("INGEST_CLASSIFIER_MODEL", ""),
+			MessageLanguages:        getEnvBoolOrDefault("MESSAGE_LANGUAGES", true),
+			QueryTranslation:        getEnvBoolOrDefault("QUERY_TRANSLATION", false),
+			SolvedBoost:             getEnvFloatOrDefault("RETRIEVAL_SOLVED_BOOST", 0.05),
+			ForumSuggestions:        getEnvBoolOrDefault("FORUM_SUGGESTIONS", true),
+
+			HelpAnswers:          getEnvBoolOrDefault("HELP_ANSWERS", true),
+			HelpAnswerSimilarity: getEnvFloatOrDefault("HELP_ANSWER_SIMILARITY", 0.8),
+			HelpAnswerCooldown:   getEnvDurationOrDefault("HELP_ANSWER_COOLDOWN", time.Hour),
+
+			DuplicateQuestions:        getEnvBoolOrDefault("DUPLICATE_QUESTIONS", true),
+			DuplicateFAQSimilarity:    getEnvFloatOrDefault("DUPLICATE_FAQ_SIMILARITY", 0.85),
+			DuplicateAnswerSimilarity: getEnvFloatOrDefault("DUPLICATE_ANSWER_SIMILARITY", 0.92),
+			DuplicateAnswerMaxAge:     getEnvDurationOrDefault("DUPLICATE_ANSWER_MAX_AGE", 90*24*time.Hour),
+			DuplicateCooldown:         getEnvDurationOrDefault("DUPLICATE_COOLDOWN", 10*time.Minute),
+
+			MoodTracking:    getEnvBoolOrDefault("MOOD_TRACKING", true),
+			MoodMinMessages: getEnvIntOrDefault("MOOD_MIN_MESSAGES", 5),
+
+			ToxicityWarnings: getEnvBoolOrDefault("TOXICITY_WARNINGS", true),
+			ToxicityCoolOff:  getEnvDurationOrDefault("TOXICITY_COOL_OFF", 30*time.Minute),
+		},
+		Agent: AgentConfig{
+			MaxSteps:        getEnvIntOrDefault("AGENT_MAX_STEPS", 6),
+			TimeBudget:      getEnvDurationOrDefault("AGENT_TIME_BUDGET", 2*time.Minute),
+			WebSearchAPIKey: os.Getenv("WEB_SEARCH_API_KEY"),
+			DisableWeb:      getEnvBoolOrDefault("AGENT_DISABLE_WEB", false),
+		},
+		Memory: MemoryConfig{
+			Enabled:     getEnvBoolOrDefault("CONVERSATION_MEMORY", true),
+			TokenBudget: getEnvIntOrDefault("MEMORY_TOKEN_BUDGET", 1500),
+			KeepTurns:   getEnvIntOrDefault("MEMORY_KEEP_TURNS", 4),
+			TTL:         getEnvDurationOrDefault("MEMORY_TTL", 24*time.Hour),
+		},
+		Backup: BackupConfig{
+			Interval:  getEnvDurationOrDefault("BACKUP_INTERVAL", 0),
+			Retention: getEnvDurationOrDefault("BACKUP_RETENTION", 30*24*time.Hour),
+			S3Bucket:  os.Getenv("BACKUP_S3_BUCKET"),
+		},
+		Export: ExportConfig{
+			Interval:     getEnvDurationOrDefault("EXPORT_INTERVAL", 0),
+			GuildIDs:     getEnvIDList("EXPORT_GUILDS"),
+			EmbeddedOnly: getEnvBoolOrDefault("EXPORT_EMBEDDED_ONLY", false),
+			Retention:    getEnvDurationOrDefault("EXPORT_RETENTION", 30*24*time.Hour),
+		},
+		Maintenance: MaintenanceConfig{
+			Interval:       getEnvDurationOrDefault("MAINTENANCE_INTERVAL", 24*time.Hour),
+			Window:         getEnvOrDefault("MAINTENANCE_WINDOW", "02:00-05:00"),
+			Timezone:       getEnvOrDefault("MAINTENANCE_TIMEZONE", "UTC"),
+			BloatThreshold: getEnvFloatOrDefault("MAINTENANCE_BLOAT_THRESHOLD", 30),
+		},
+		Security: SecurityConfig{
+			EncryptionKey:     os.Getenv("ENCRYPTION_KEY"),
+			EncryptionKeyFile: os.Getenv("ENCRYPTION_KEY_FILE"),
+			KMSEncryptedKey:   os.Getenv("KMS_ENCRYPTED_KEY"),
+			KMSRegion:         getEnvOrDefault("KMS_REGION", os.Getenv("AWS_REGION")),
+			KMSEndpoint:       os.Getenv("KMS_ENDPOINT"),
+			AWSAccessKey:      os.Getenv("AWS_ACCESS_KEY_ID"),
+			AWSSecretKey:      os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			AWSSessionToken:   os.Getenv("AWS_SESSION_TOKEN"),
+			EncryptMessages:   getEnvBoolOrDefault("ENCRYPT_MESSAGES", false),
+			AuditRetention:    getEnvDurationOrDefault("AUDIT_RETENTION", 90*24*time.Hour),
+			InjectionAlerts:   getEnvBoolOrDefault("INJECTION_ALERTS", false),
+		},
+		VectorStore: VectorStoreConfig{
+			Backend:          getEnvOrDefault("VECTOR_STORE", "pgvector"),
+			QdrantURL:        os.Getenv("QDRANT_URL"),
+			QdrantAPIKey:     os.Getenv("QDRANT_API_KEY"),
+			QdrantCollection: getEnvOrDefault("QDRANT_COLLECTION", "messages"),
+		},
+		Storage: StorageConfig{
+			Backend:             getEnvOrDefault("STORAGE_BACKEND", "local"),
+			LocalPath:           getEnvOrDefault("STORAGE_LOCAL_PATH", "./data/storage"),
+			PublicURL:           os.Getenv("STORAGE_PUBLIC_URL"),
+			SigningKey:          os.Getenv("STORAGE_SIGNING_KEY"),
+			S3Endpoint:          os.Getenv("S3_ENDPOINT"),
+			S3Region:            getEnvOrDefault("S3_REGION", "us-east-1"),
+			S3Bucket:            os.Getenv("S3_BUCKET"),
+			S3AccessKey:         os.Getenv("S3_ACCESS_KEY"),
+			S3SecretKey:         os.Getenv("S3_SECRET_KEY"),
+			S3PathStyle:         getEnvBoolOrDefault("S3_PATH_STYLE", false),
+			ArchiveAttachments:  getEnvBoolOrDefault("ARCHIVE_ATTACHMENTS", false),
+			MaxAttachmentBytes:  int64(getEnvIntOrDefault("MAX_ATTACHMENT_BYTES", 25*1024*1024)),
+			AttachmentRetention: getEnvDurationOrDefault("ATTACHMENT_RETENTION", 90*24*time.Hour),
+			RecordingRetention:  getEnvDurationOrDefault("RECORDING_RETENTION", 7*24*time.Hour),
+			ImageRetention:      getEnvDurationOrDefault("IMAGE_RETENTION", 30*24*time.Hour),
+			DocumentRetention:   getEnvDurationOrDefault("DOCUMENT_RETENTION", 0),
+		},
+		Scheduler: SchedulerConfig{
+			DigestInterval:           getEnvDurationOrDefault("DIGEST_CHECK_INTERVAL", 5*time.Minute),
+			StandupInterval:          getEnvDurationOrDefault("STANDUP_CHECK_INTERVAL", time.Minute),
+			PollInterval:             getEnvDurationOrDefault("POLL_CHECK_INTERVAL", time.Minute),
+			FeedInterval:             getEnvDurationOrDefault("FEED_CHECK_INTERVAL", 5*time.Minute),
+			CalendarSyncInterval:     getEnvDurationOrDefault("CALENDAR_SYNC_INTERVAL", 15*time.Minute),
+			CalendarReminderInterval: getEnvDurationOrDefault("CALENDAR_REMINDER_INTERVAL", time.Minute),
+			KnowledgeSyncInterval:    getEnvDurationOrDefault("KNOWLEDGE_SYNC_INTERVAL", time.Hour),
+			StorageCleanupInterval:   getEnvDurationOrDefault("STORAGE_CLEANUP_INTERVAL", 6*time.Hour),
+			ChannelSummaryInterval:   getEnvDurationOrDefault("CHANNEL_SUMMARY_INTERVAL", time.Hour),
+			DuplicatePruneInterval:   getEnvDurationOrDefault("DUPLICATE_PRUNE_INTERVAL", 24*time.Hour),
+			PersonaModeInterval:      getEnvDurationOrDefault("PERSONA_MODE_INTERVAL", time.Minute),
+			MoodScoringInterval:      getEnvDurationOrDefault("MOOD_SCORING_INTERVAL", time.Hour),
+			HighlightDigestInterval:  getEnvDurationOrDefault("HIGHLIGHT_DIGEST_INTERVAL", 15*time.Minute),
+			StarterInterval:          getEnvDurationOrDefault("STARTER_CHECK_INTERVAL", 5*time.Minute),
+			ReminderMaxAhead:         getEnvDurationOrDefault("REMINDER_MAX_AHEAD", 365*24*time.Hour),
+			LeaderElection:           getEnvBoolOrDefault("LEADER_ELECTION", false),
+			LeaderCheckInterval:      getEnvDurationOrDefault("LEADER_CHECK_INTERVAL", 10*time.Second),
 		},
 	}
 
-	return config, config.validate()
+	return config
 }
 
 func (c *Config) validate() error {
 	if c.Discord.Token == "" {
 		return fmt.Errorf("DISCORD_TOKEN is required")
 	}
-	if c.OpenAI.APIKey == "" {
-		return fmt.Errorf("OPENAI_API_KEY is required")
+	if c.OpenAI.APIKey == "" && !c.OpenAI.Offline {
+		return fmt.Errorf("OPENAI_API_KEY is required unless OFFLINE is set")
+	}
+	if c.OpenAI.Offline && c.OpenAI.Record {
+		return fmt.Errorf("OFFLINE and AI_RECORD can't both be set")
+	}
+	if c.OpenAI.RequireGuildKey && !c.Security.HasEncryptionKey() {
+		return fmt.Errorf("ENCRYPTION_KEY is required when AI_REQUIRE_GUILD_KEY is set")
+	}
+	if c.Security.EncryptMessages && !c.Security.HasEncryptionKey() {
+		return fmt.Errorf("ENCRYPTION_KEY is required when ENCRYPT_MESSAGES is set")
 	}
 	if c.Database.Password == "" {
 		return fmt.Errorf("POSTGRES_PASSWORD is required")
 	}
+	if c.RAG.OCREngine != "" && c.RAG.OCREngine != "vision" && c.RAG.OCREngine != "tesseract" {
+		return fmt.Errorf("OCR_ENGINE must be vision or tesseract, got %q", c.RAG.OCREngine)
+	}
+	if c.RAG.OCREngine != "" && !c.RAG.FileSearch {
+		return fmt.Errorf("OCR_ENGINE needs FILE_SEARCH, which stores the text it reads")
+	}
+	if c.RAG.IngestSpamThreshold > 0 && c.RAG.IngestSpamWindow <= 0 {
+		return fmt.Errorf("INGEST_SPAM_WINDOW must be positive when INGEST_SPAM_THRESHOLD is set")
+	}
+	if c.RAG.IngestSkipBelow > c.RAG.IngestEmbedAbove {
+		return fmt.Errorf("INGEST_SKIP_BELOW can't be above INGEST_EMBED_ABOVE")
+	}
+	if _, err := time.LoadLocation(c.Maintenance.Timezone); err != nil {
+		return fmt.Errorf("MAINTENANCE_TIMEZONE is not a valid timezone: %w", err)
+	}
+	if c.VectorStore.Backend != "pgvector" && c.VectorStore.Backend != "qdrant" {
+		return fmt.Errorf("VECTOR_STORE must be pgvector or qdrant, got %q", c.VectorStore.Backend)
+	}
+	if c.VectorStore.Backend == "qdrant" && c.VectorStore.QdrantURL == "" {
+		return fmt.Errorf("QDRANT_URL is required when VECTOR_STORE is qdrant")
+	}
+	if c.Events.Backend != "memory" && c.Events.Backend != "redis" {
+		return fmt.Errorf("EVENT_BUS must be memory or redis, got %q", c.Events.Backend)
+	}
+	if c.Worker.Concurrency < 1 || c.Worker.MaxAttempts < 1 {
+		return fmt.Errorf("WORKER_CONCURRENCY and JOB_MAX_ATTEMPTS must be at least 1")
+	}
+	if c.Outbox.Interval <= 0 || c.Outbox.MaxAttempts < 1 {
+		return fmt.Errorf("OUTBOX_INTERVAL must be positive and OUTBOX_MAX_ATTEMPTS at least 1")
+	}
+	if c.Rollout.Interval <= 0 {
+		return fmt.Errorf("ROLLOUT_INTERVAL must be positive")
+	}
+	if c.Scheduler.LeaderElection && c.Scheduler.LeaderCheckInterval <= 0 {
+		return fmt.Errorf("LEADER_CHECK_INTERVAL must be positive")
+	}
+	if c.Dashboard.Enabled() && (c.Dashboard.PublicURL == "" || len(c.Dashboard.SessionSecret) < 32) {
+		return fmt.Errorf("DASHBOARD_URL and a DASHBOARD_SESSION_SECRET of at least 32 characters are required when DISCORD_CLIENT_SECRET is set")
+	}
+	if c.Monitoring.DebugAddr != "" && c.Monitoring.DebugToken == "" {
+		return fmt.Errorf("DEBUG_TOKEN is required when DEBUG_ADDR is set")
+	}
 	return nil
 }
 
@@ -120,3 +738,76 @@ func getEnvIntOrDefault(key string, defaultValue int) int {
 	}
 	return defaultValue
 }
+
+func getEnvInt64OrDefault(key string, defaultValue int64) int64 {
+	if value := os.Getenv(key); value != "" {
+		if intValue, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return intValue
+		}
+	}
+	return defaultValue
+}
+
+func getEnvFloatOrDefault(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatValue
+		}
+	}
+	return defaultValue
+}
+
+func getEnvDurationOrDefault(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if duration, err := time.ParseDuration(value); err == nil {
+			return duration
+		}
+	}
+	return defaultValue
+}
+
+func getEnvBoolOrDefault(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
+
+func getEnvList(key, sep string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), sep) {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// getEnvIDList parses comma-separated Discord IDs; malformed ones are skipped
+func getEnvIDList(key string) []int64 {
+	var ids []int64
+	for _, value := range getEnvList(key, ",") {
+		if id, err := strconv.ParseInt(value, 10, 64); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// getEnvDurationMap parses "name=duration" pairs separated by commas, e.g.
+// "ask=20s,ping=1s"; malformed pairs are skipped
+func getEnvDurationMap(key string) map[string]time.Duration {
+	values := make(map[string]time.Duration)
+	for _, pair := range getEnvList(key, ",") {
+		name, raw, ok := strings.Cut(pair, "=")
+		if !ok {
+			continue
+		}
+		if duration, err := time.ParseDuration(strings.TrimSpace(raw)); err == nil {
+			values[strings.TrimSpace(name)] = duration
+		}
+	}
+	return values
+}
@@ -0,0 +1,261 @@
+// Package dice rolls tabletop dice notation: 3d6+2, d%, 4d6kh3 to keep the
+// three highest, or 2d20kl1 for disadvantage.
+package dice
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	// MaxDice bounds the dice rolled by one notation
+	MaxDice = 100
+	// MaxSides bounds the sides of a die
+	MaxSides = 1000
+	// maxTerms bounds the dice groups and modifiers of one notation
+	maxTerms = 20
+	// maxShownDice is how many dice a breakdown lists before showing only sums
+	maxShownDice = 50
+)
+
+var (
+	// termPattern matches one signed term: a dice group such as 4d6kh3 or d%,
+	// or a constant modifier
+	termPattern = regexp.MustCompile(`^([+-])(?:(\d*)d(\d+|%)(?:(kh|kl|k)(\d+))?|(\d+))`)
+	// loneD20 matches a single d20 without a keep suffix, e.g. in "1d20+5"
+	loneD20 = regexp.MustCompile(`(?i)(?:^|[+\-\s])(1?d20)(?:$|[+\-\s])`)
+)
+
+// Die is one rolled die; dropped dice don't count towards the total
+type Die struct {
+	Value   int
+	Dropped bool
+}
+
+// Term is a dice group or a constant of a roll
+type Term struct {
+	Negative bool
+	Count    int // 0 for a constant
+	Sides    int
+	Keep     int // dice kept, or 0 to keep them all
+	Lowest   bool
+	Dice     []Die
+	Constant int
+}
+
+// Total is the sum of the kept dice, or the constant
+func (t Term) Total() int {
+	total := t.Constant
+	for _, d := range t.Dice {
+		if !d.Dropped {
+			total += d.Value
+		}
+	}
+	if t.Negative {
+		return -total
+	}
+	return total
+}
+
+// Result is a rolled notation
+type Result struct {
+	Notation string
+	Terms    []Term
+	Total    int
+}
+
+// Roll rolls a dice notation
+func Roll(notation string) (*Result, error) {
+	return roll(notation, rand.IntN)
+}
+
+func roll(notation string, intN func(int) int) (*Result, error) {
+	terms, err := parse(notation)
+	if err != nil {
+		return nil, err
+	}
+	result := &Result{Notation: notation, Terms: terms}
+	for n := range result.Terms {
+		term := &result.Terms[n]
+		for range term.Count {
+			term.Dice = append(term.Dice, Die{Value: intN(term.Sides) + 1})
+		}
+		dropLowOrHigh(term)
+		result.Total += term.Total()
+	}
+	return result, nil
+}
+
+// parse reads a notation into terms, checking its limits
+func parse(notation string) ([]Term, error) {
+	rest := strings.ToLower(strings.Join(strings.Fields(notation), ""))
+	if rest == "" {
+		return nil, fmt.Errorf("no dice to roll, try something like 1d20 or 3d6+2")
+	}
+	if rest[0] != '+' && rest[0] != '-' {
+		rest = "+" + rest
+	}
+
+	var terms []Term
+	dice := 0
+	for rest != "" {
+		m := termPattern.FindStringSubmatch(rest)
+		if m == nil {
+			return nil, fmt.Errorf("can't read %q, use dice notation like 3d6+2, d%% or 4d6kh3", strings.TrimPrefix(rest, "+"))
+		}
+		rest = rest[len(m[0]):]
+		if len(terms) == maxTerms {
+			return nil, fmt.Errorf("too many terms, at most %d", maxTerms)
+		}
+
+		term := Term{Negative: m[1] == "-"}
+		if m[6] != "" {
+			term.Constant, _ = strconv.Atoi(m[6])
+			if term.Constant > 1_000_000 {
+				return nil, fmt.Errorf("modifier %d is too large", term.Constant)
+			}
+			terms = append(terms, term)
+			continue
+		}
+
+		term.Count = 1
+		if m[2] != "" {
+			term.Count, _ = strconv.Atoi(m[2])
+		}
+		term.Sides = 100
+		if m[3] != "%" {
+			term.Sides, _ = strconv.Atoi(m[3])
+		}
+		if m[4] != "" {
+			term.Keep, _ = strconv.Atoi(m[5])
+			term.Lowest = m[4] == "kl"
+		}
+
+		switch {
+		case term.Count < 1:
+			return nil, fmt.Errorf("roll at least one die")
+		case term.Count > MaxDice:
+			return nil, fmt.Errorf("too many dice, at most %d", MaxDice)
+		case term.Sides < 2 || term.Sides > MaxSides:
+			return nil, fmt.Errorf("dice need between 2 and %d sides", MaxSides)
+		case m[4] != "" && (term.Keep < 1 || term.Keep > term.Count):
+			return nil, fmt.Errorf("can't keep %d of %d dice", term.Keep, term.Count)
+		}
+		if dice += term.Count; dice > MaxDice {
+			return nil, fmt.Errorf("too many dice, at most %d", MaxDice)
+		}
+		terms = append(terms, term)
+	}
+	return terms, nil
+}
+
+// dropLowOrHigh marks the dice a keep-highest or keep-lowest term drops
+func dropLowOrHigh(term *Term) {
+	if term.Keep == 0 {
+		return
+	}
+	for range len(term.Dice) - term.Keep {
+		drop := -1
+		for n, d := range term.Dice {
+			if d.Dropped {
+				continue
+			}
+			if drop < 0 || (!term.Lowest && d.Value < term.Dice[drop].Value) || (term.Lowest && d.Value > term.Dice[drop].Value) {
+				drop = n
+			}
+		}
+		term.Dice[drop].Dropped = true
+	}
+}
+
+// Advantage rolls the first lone d20 of a notation twice, keeping the highest,
+// or the lowest for disadvantage; notations without one are left as they are
+func Advantage(notation string, disadvantage bool) string {
+	keep := "2d20kh1"
+	if disadvantage {
+		keep = "2d20kl1"
+	}
+	loc := loneD20.FindStringSubmatchIndex(notation)
+	if loc == nil {
+		return notation
+	}
+	return notation[:loc[2]] + keep + notation[loc[3]:]
+}
+
+// Natural is the die a single d20 roll landed on, kept after advantage or
+// disadvantage, or 0 if the roll isn't one; 20 and 1 are criticals
+func (r *Result) Natural() int {
+	natural := 0
+	for _, term := range r.Terms {
+		if term.Count == 0 {
+			continue
+		}
+		if term.Sides != 20 || natural != 0 || (term.Count != 1 && term.Keep != 1) {
+			return 0
+		}
+		for _, d := range term.Dice {
+			if !d.Dropped {
+				natural = d.Value
+			}
+		}
+	}
+	return natural
+}
+
+// Breakdown shows each die of a roll, dropped ones struck through, e.g.
+// "4d6kh3 (6, 5, ~~1~~, 3) + 2"
+func (r *Result) Breakdown() string {
+	dice := 0
+	for _, term := range r.Terms {
+		dice += len(term.Dice)
+	}
+
+	var sb strings.Builder
+	for n, term := range r.Terms {
+		switch {
+		case term.Negative:
+			sb.WriteString(" - ")
+		case n > 0:
+			sb.WriteString(" + ")
+		}
+		if term.Count == 0 {
+			sb.WriteString(strconv.Itoa(term.Constant))
+			continue
+		}
+
+		sb.WriteString(term.notation())
+		values := make([]string, len(term.Dice))
+		for k, d := range term.Dice {
+			values[k] = strconv.Itoa(d.Value)
+			if d.Dropped {
+				values[k] = "~~" + values[k] + "~~"
+			}
+		}
+		if dice <= maxShownDice {
+			sb.WriteString(" (" + strings.Join(values, ", ") + ")")
+		} else {
+			total := term.Total()
+			if term.Negative {
+				total = -total
+			}
+			sb.WriteString(" (" + strconv.Itoa(total) + ")")
+		}
+	}
+	return sb.String()
+}
+
+// notation writes a dice group back in dice notation
+func (t Term) notation() string {
+	s := fmt.Sprintf("%dd%d", t.Count, t.Sides)
+	if t.Keep > 0 {
+		suffix := "kh"
+		if t.Lowest {
+			suffix = "kl"
+		}
+		s += suffix + strconv.Itoa(t.Keep)
+	}
+	return s
+}
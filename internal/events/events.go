@@ -0,0 +1,99 @@
+// Package events is the bot's internal event bus. Discord handlers publish
+// what happened, and features such as indexing, moderation and highlights
+// subscribe to it, so new consumers never touch the handlers.
+package events
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"discord-tars/internal/tenant"
+)
+
+// Topics published by the bot
+const (
+	MessageCreated   = "message.created"
+	ReactionAdded    = "reaction.added"
+	ReactionRemoved  = "reaction.removed"
+	ReactionsCleared = "reaction.cleared"
+)
+
+// Event is something that happened on Discord. Events may go through Redis,
+// so everything a subscriber needs is in the event itself.
+type Event struct {
+	Topic       string                     `json:"topic"`
+	GuildID     int64                      `json:"guild_id,omitempty"` // Zero for DMs
+	Message     *discordgo.Message         `json:"message,omitempty"`
+	Reaction    *discordgo.MessageReaction `json:"reaction,omitempty"`
+	PublishedAt time.Time                  `json:"published_at"`
+}
+
+// NewMessageEvent wraps a message; guildID is parsed from the message
+func NewMessageEvent(topic string, msg *discordgo.Message) *Event {
+	guildID, _ := strconv.ParseInt(msg.GuildID, 10, 64)
+	return &Event{Topic: topic, GuildID: guildID, Message: msg, PublishedAt: time.Now()}
+}
+
+// NewReactionEvent wraps a reaction; for ReactionsCleared only its message,
+// channel and guild are set
+func NewReactionEvent(topic string, reaction *discordgo.MessageReaction) *Event {
+	guildID, _ := strconv.ParseInt(reaction.GuildID, 10, 64)
+	return &Event{Topic: topic, GuildID: guildID, Reaction: reaction, PublishedAt: time.Now()}
+}
+
+// Handler consumes events of one topic. Its context is tagged with the
+// event's guild. Errors are logged; the event is not retried.
+type Handler func(ctx context.Context, event *Event) error
+
+// Bus delivers every published event once to each subscription on its topic
+type Bus interface {
+	// Publish hands an event to the subscribers without waiting for them
+	Publish(ctx context.Context, event *Event) error
+	// Subscribe runs handler for the topic's events on workers goroutines;
+	// name identifies the subscription, e.g. in logs and Redis consumer groups
+	Subscribe(name, topic string, workers int, handler Handler) error
+	// Close stops the subscriptions once their current events are handled
+	Close() error
+}
+
+// Config selects and configures a backend
+type Config struct {
+	Backend   string // "memory" or "redis"
+	QueueSize int    // Events buffered per in-process subscription
+
+	RedisAddr     string
+	RedisPassword string
+	RedisDB       int
+	StreamMaxLen  int // Approximate cap on each Redis stream
+}
+
+// New creates the configured bus
+func New(cfg Config) (Bus, error) {
+	switch cfg.Backend {
+	case "", "memory":
+		return NewMemoryBus(cfg.QueueSize), nil
+	case "redis":
+		return NewRedisBus(cfg)
+	default:
+		return nil, fmt.Errorf("unknown event bus backend %q", cfg.Backend)
+	}
+}
+
+// handle runs a handler on one event, recovering from panics so a bad event
+// doesn't stop the subscription
+func handle(name string, handler Handler, event *Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("❌ Event subscriber %s panicked on %s: %v", name, event.Topic, r)
+		}
+	}()
+	ctx := tenant.WithGuild(context.Background(), event.GuildID)
+	if err := handler(ctx, event); err != nil {
+		log.Printf("⚠️ Event subscriber %s failed on %s: %v", name, event.Topic, err)
+	}
+}
@@ -0,0 +1,95 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+)
+
+// DefaultQueueSize is how many events an in-process subscription buffers
+const DefaultQueueSize = 1000
+
+// MemoryBus delivers events in-process. Each subscription has its own queue,
+// so a slow consumer such as embedding never holds up the Discord handlers or
+// other subscriptions; when its queue is full, new events are dropped.
+type MemoryBus struct {
+	queueSize int
+
+	mu            sync.RWMutex
+	subscriptions map[string][]*memorySubscription
+	closed        bool
+	wg            sync.WaitGroup
+}
+
+type memorySubscription struct {
+	name  string
+	queue chan *Event
+}
+
+func NewMemoryBus(queueSize int) *MemoryBus {
+	if queueSize <= 0 {
+		queueSize = DefaultQueueSize
+	}
+	return &MemoryBus{
+		queueSize:     queueSize,
+		subscriptions: make(map[string][]*memorySubscription),
+	}
+}
+
+func (b *MemoryBus) Publish(ctx context.Context, event *Event) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.closed {
+		return fmt.Errorf("event bus is closed")
+	}
+	for _, sub := range b.subscriptions[event.Topic] {
+		select {
+		case sub.queue <- event:
+		default:
+			log.Printf("⚠️ Event subscriber %s is falling behind, dropping a %s event", sub.name, event.Topic)
+		}
+	}
+	return nil
+}
+
+func (b *MemoryBus) Subscribe(name, topic string, workers int, handler Handler) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return fmt.Errorf("event bus is closed")
+	}
+
+	sub := &memorySubscription{name: name, queue: make(chan *Event, b.queueSize)}
+	b.subscriptions[topic] = append(b.subscriptions[topic], sub)
+	for range max(workers, 1) {
+		b.wg.Add(1)
+		go func() {
+			defer b.wg.Done()
+			for event := range sub.queue {
+				handle(name, handler, event)
+			}
+		}()
+	}
+	log.Printf("📬 Subscribed %s to %s events", name, topic)
+	return nil
+}
+
+// Close stops accepting events and waits for the queued ones to be handled
+func (b *MemoryBus) Close() error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil
+	}
+	b.closed = true
+	for _, subs := range b.subscriptions {
+		for _, sub := range subs {
+			close(sub.queue)
+		}
+	}
+	b.mu.Unlock()
+
+	b.wg.Wait()
+	return nil
+}
@@ -0,0 +1,276 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultStreamMaxLen is how many events each Redis stream keeps
+	DefaultStreamMaxLen = 10000
+
+	streamPrefix = "tars:events:"
+	// Events a crashed instance left unacknowledged for this long are taken over
+	claimIdle    = time.Minute
+	readBlock    = 5 * time.Second
+	readCount    = 10
+	retryBackoff = 5 * time.Second
+	replyTimeout = 5 * time.Second
+)
+
+// RedisBus delivers events through Redis streams, one per topic. Each
+// subscription is a consumer group, so several bot instances share its work
+// and events published while it was down are handled when it comes back.
+// Events are acknowledged once handled, so a crash means at-least-once.
+type RedisBus struct {
+	cfg      Config
+	consumer string // Prefix of this instance's consumer names
+
+	mu    sync.Mutex
+	pub   *redisConn
+	conns map[*redisConn]bool // Blocking readers, closed to stop them
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// streamEntry is one event read from a stream
+type streamEntry struct {
+	id    string
+	event string // JSON; empty when the entry was trimmed before being handled
+}
+
+func NewRedisBus(cfg Config) (*RedisBus, error) {
+	if cfg.StreamMaxLen <= 0 {
+		cfg.StreamMaxLen = DefaultStreamMaxLen
+	}
+	pub, err := dialRedis(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := pub.do(replyTimeout, "PING"); err != nil {
+		pub.Close()
+		return nil, fmt.Errorf("redis is not responding: %w", err)
+	}
+
+	// Stable across restarts of the same host, so its pending events are resumed
+	host, err := os.Hostname()
+	if err != nil {
+		host = "tars"
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &RedisBus{
+		cfg:      cfg,
+		consumer: host,
+		pub:      pub,
+		conns:    make(map[*redisConn]bool),
+		ctx:      ctx,
+		cancel:   cancel,
+	}, nil
+}
+
+func (b *RedisBus) Publish(ctx context.Context, event *Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s event: %w", event.Topic, err)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.ctx.Err() != nil {
+		return fmt.Errorf("event bus is closed")
+	}
+	args := []string{"XADD", streamPrefix + event.Topic, "MAXLEN", "~", strconv.Itoa(b.cfg.StreamMaxLen), "*", "event", string(data)}
+	// Reconnect once if the connection dropped since the last event
+	for attempt := 0; ; attempt++ {
+		if b.pub == nil {
+			if b.pub, err = dialRedis(b.cfg); err != nil {
+				return err
+			}
+		}
+		if _, err = b.pub.do(replyTimeout, args...); err == nil {
+			return nil
+		}
+		if _, ok := err.(redisError); ok || attempt == 1 {
+			return fmt.Errorf("failed to publish %s event: %w", event.Topic, err)
+		}
+		b.pub.Close()
+		b.pub = nil
+	}
+}
+
+func (b *RedisBus) Subscribe(name, topic string, workers int, handler Handler) error {
+	stream := streamPrefix + topic
+
+	// New groups only see events published from now on
+	b.mu.Lock()
+	err := b.ctx.Err()
+	if err == nil && b.pub == nil {
+		b.pub, err = dialRedis(b.cfg)
+	}
+	if err == nil {
+		_, err = b.pub.do(replyTimeout, "XGROUP", "CREATE", stream, name, "$", "MKSTREAM")
+	}
+	b.mu.Unlock()
+	if err != nil && !strings.HasPrefix(err.Error(), "redis: BUSYGROUP") {
+		return fmt.Errorf("failed to create consumer group %s: %w", name, err)
+	}
+
+	for n := range max(workers, 1) {
+		b.wg.Add(1)
+		go b.consume(name, stream, fmt.Sprintf("%s-%d", b.consumer, n), handler)
+	}
+	log.Printf("📬 Subscribed %s to %s events (redis)", name, topic)
+	return nil
+}
+
+// consume reads a consumer's events until the bus is closed, starting with
+// those it left pending, and any a crashed instance left behind
+func (b *RedisBus) consume(group, stream, consumer string, handler Handler) {
+	defer b.wg.Done()
+
+	var conn *redisConn
+	pending := true
+	for b.ctx.Err() == nil {
+		if conn == nil {
+			var err error
+			if conn, err = b.dialReader(); err != nil {
+				log.Printf("⚠️ Event subscriber %s can't reach redis: %v", group, err)
+				b.sleep(retryBackoff)
+				continue
+			}
+			if _, err := conn.do(replyTimeout, "XAUTOCLAIM", stream, group, consumer, strconv.FormatInt(claimIdle.Milliseconds(), 10), "0-0", "COUNT", "100"); err != nil {
+				log.Printf("⚠️ Event subscriber %s failed to claim abandoned events: %v", group, err)
+			}
+			pending = true
+		}
+
+		id := ">"
+		if pending {
+			id = "0"
+		}
+		reply, err := conn.do(readBlock+replyTimeout, "XREADGROUP", "GROUP", group, consumer,
+			"COUNT", strconv.Itoa(readCount), "BLOCK", strconv.FormatInt(readBlock.Milliseconds(), 10), "STREAMS", stream, id)
+		if err != nil {
+			if b.ctx.Err() == nil {
+				log.Printf("⚠️ Event subscriber %s failed to read events: %v", group, err)
+				b.sleep(retryBackoff)
+			}
+			b.closeReader(conn)
+			conn = nil
+			continue
+		}
+
+		entries := streamEntries(reply)
+		if pending && len(entries) == 0 {
+			pending = false
+			continue
+		}
+		for _, entry := range entries {
+			if entry.event != "" {
+				var event Event
+				if err := json.Unmarshal([]byte(entry.event), &event); err != nil {
+					log.Printf("⚠️ Event subscriber %s skipped an unreadable event %s: %v", group, entry.id, err)
+				} else {
+					handle(group, handler, &event)
+				}
+			}
+			if _, err := conn.do(replyTimeout, "XACK", stream, group, entry.id); err != nil {
+				log.Printf("⚠️ Event subscriber %s failed to acknowledge event %s: %v", group, entry.id, err)
+			}
+		}
+	}
+	if conn != nil {
+		b.closeReader(conn)
+	}
+}
+
+func (b *RedisBus) dialReader() (*redisConn, error) {
+	conn, err := dialRedis(b.cfg)
+	if err != nil {
+		return nil, err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.ctx.Err() != nil {
+		conn.Close()
+		return nil, fmt.Errorf("event bus is closed")
+	}
+	b.conns[conn] = true
+	return conn, nil
+}
+
+func (b *RedisBus) closeReader(conn *redisConn) {
+	b.mu.Lock()
+	delete(b.conns, conn)
+	b.mu.Unlock()
+	conn.Close()
+}
+
+func (b *RedisBus) sleep(d time.Duration) {
+	select {
+	case <-time.After(d):
+	case <-b.ctx.Done():
+	}
+}
+
+// Close stops reading events; those being handled finish first, and unread
+// ones stay in Redis for the next start
+func (b *RedisBus) Close() error {
+	b.mu.Lock()
+	if b.ctx.Err() != nil {
+		b.mu.Unlock()
+		return nil
+	}
+	b.cancel()
+	// Unblocks readers waiting for events
+	for conn := range b.conns {
+		conn.Close()
+	}
+	b.mu.Unlock()
+
+	b.wg.Wait()
+	if b.pub != nil {
+		return b.pub.Close()
+	}
+	return nil
+}
+
+// streamEntries flattens an XREADGROUP reply for a single stream
+func streamEntries(reply any) []streamEntry {
+	streams, _ := reply.([]any)
+	var entries []streamEntry
+	for _, s := range streams {
+		stream, _ := s.([]any)
+		if len(stream) != 2 {
+			continue
+		}
+		items, _ := stream[1].([]any)
+		for _, item := range items {
+			fields, _ := item.([]any)
+			if len(fields) != 2 {
+				continue
+			}
+			entry := streamEntry{}
+			entry.id, _ = fields[0].(string)
+			values, _ := fields[1].([]any)
+			for i := 0; i+1 < len(values); i += 2 {
+				if key, _ := values[i].(string); key == "event" {
+					entry.event, _ = values[i+1].(string)
+				}
+			}
+			if entry.id != "" {
+				entries = append(entries, entry)
+			}
+		}
+	}
+	return entries
+}
@@ -0,0 +1,148 @@
+// Package export dumps the search index, messages with their metadata and
+// embeddings, to JSON Lines for offline analysis or for loading into another
+// vector store. Unlike a backup it is a single flat file, one message per
+// line with its content decrypted, which tools such as DuckDB or pandas read
+// directly.
+//
+// Exports are JSON Lines only, not Parquet, which would need a dependency
+// the bot doesn't have; DuckDB converts an export in one statement (see the
+// README). They are written by bot export-index, or on a schedule by Job.
+package export
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"discord-tars/internal/repository"
+	"discord-tars/internal/storage"
+)
+
+// pageSize is how many messages are read from the database at a time
+const pageSize = 1000
+
+// Options selects what an export holds and how it is written
+type Options struct {
+	GuildIDs     []int64 // Every guild when empty
+	EmbeddedOnly bool    // Leave out messages without an embedding
+	Gzip         bool
+}
+
+// Exporter writes index exports
+type Exporter struct {
+	repo *repository.MessageRepository
+}
+
+func NewExporter(repo *repository.MessageRepository) *Exporter {
+	return &Exporter{repo: repo}
+}
+
+// Write writes the messages as JSON Lines, reporting the running count to
+// progress after each page, and returns how many it wrote
+func (e *Exporter) Write(ctx context.Context, w io.Writer, opts Options, progress func(written int)) (int, error) {
+	if opts.Gzip {
+		gz := gzip.NewWriter(w)
+		written, err := e.write(ctx, gz, opts, progress)
+		if closeErr := gz.Close(); err == nil {
+			err = closeErr
+		}
+		return written, err
+	}
+	return e.write(ctx, w, opts, progress)
+}
+
+func (e *Exporter) write(ctx context.Context, w io.Writer, opts Options, progress func(written int)) (int, error) {
+	enc := json.NewEncoder(w)
+	written := 0
+	var afterID int64
+	for {
+		messages, err := e.repo.ExportMessages(ctx, opts.GuildIDs, opts.EmbeddedOnly, afterID, pageSize)
+		if err != nil {
+			return written, err
+		}
+		if len(messages) == 0 {
+			return written, nil
+		}
+		for _, msg := range messages {
+			if err := enc.Encode(msg); err != nil {
+				return written, fmt.Errorf("failed to write export: %w", err)
+			}
+		}
+		written += len(messages)
+		afterID = messages[len(messages)-1].ID
+		if progress != nil {
+			progress(written)
+		}
+	}
+}
+
+// Upload writes an export to a store under storage.PrefixExports and returns
+// its key
+func (e *Exporter) Upload(ctx context.Context, store storage.Store, opts Options, progress func(written int)) (string, int, error) {
+	f, err := os.CreateTemp("", "tars-export-*.jsonl")
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	written, err := e.Write(ctx, f, opts, progress)
+	if err != nil {
+		return "", written, err
+	}
+	size, err := f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return "", written, fmt.Errorf("failed to size export: %w", err)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", written, fmt.Errorf("failed to rewind export: %w", err)
+	}
+
+	key, contentType := Key(opts, time.Now()), "application/x-ndjson"
+	if opts.Gzip {
+		contentType = "application/gzip"
+	}
+	if err := store.Put(ctx, key, f, size, contentType); err != nil {
+		return "", written, fmt.Errorf("failed to upload export: %w", err)
+	}
+	return key, written, nil
+}
+
+// Job is a scheduler job storing an export with opts, and deleting the
+// exports older than retention; a zero retention keeps every export
+func (e *Exporter) Job(store storage.Store, opts Options, retention time.Duration) func(ctx context.Context) error {
+	janitor := storage.NewJanitor(store, storage.Rule{Prefix: storage.PrefixExports, MaxAge: retention})
+	return func(ctx context.Context) error {
+		key, written, err := e.Upload(ctx, store, opts, nil)
+		if err != nil {
+			return err
+		}
+		log.Printf("📤 Stored export %s (%d messages)", key, written)
+		return janitor.Cleanup(ctx)
+	}
+}
+
+// Key names an export by the guilds it holds and when it was written, e.g.
+// exports/index-123456-20240102T030405Z.jsonl.gz
+func Key(opts Options, at time.Time) string {
+	scope := "all"
+	if len(opts.GuildIDs) > 0 {
+		ids := make([]string, len(opts.GuildIDs))
+		for n, id := range opts.GuildIDs {
+			ids[n] = strconv.FormatInt(id, 10)
+		}
+		scope = strings.Join(ids, "_")
+	}
+	key := fmt.Sprintf("%sindex-%s-%s.jsonl", storage.PrefixExports, scope, at.UTC().Format("20060102T150405Z"))
+	if opts.Gzip {
+		key += ".gz"
+	}
+	return key
+}
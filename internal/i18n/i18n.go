@@ -0,0 +1,141 @@
+// Package i18n translates command metadata and bot responses. Translations
+// live in locales/<language>.json; English is the fallback for every key.
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// DefaultLanguage is used for keys a locale does not translate
+const DefaultLanguage = "en"
+
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+// catalog is the content of one locale file
+type catalog struct {
+	// Commands are keyed by command path, e.g. "digest.subscribe.channel"
+	Commands map[string]commandText `json:"commands"`
+	Messages map[string]string      `json:"messages"`
+}
+
+type commandText struct {
+	Name        string            `json:"name,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Choices     map[string]string `json:"choices,omitempty"` // Keyed by choice value
+}
+
+// discordLocales lists the Discord client locales each language serves
+var discordLocales = map[string][]discordgo.Locale{
+	"en": {discordgo.EnglishUS, discordgo.EnglishGB},
+	"fr": {discordgo.French},
+	"es": {discordgo.SpanishES, discordgo.SpanishLATAM},
+	"de": {discordgo.German},
+}
+
+var catalogs = loadCatalogs()
+
+func loadCatalogs() map[string]*catalog {
+	entries, err := localeFiles.ReadDir("locales")
+	if err != nil {
+		panic(fmt.Sprintf("i18n: failed to read locales: %v", err))
+	}
+	catalogs := make(map[string]*catalog, len(entries))
+	for _, entry := range entries {
+		data, err := localeFiles.ReadFile(path.Join("locales", entry.Name()))
+		if err != nil {
+			panic(fmt.Sprintf("i18n: failed to read %s: %v", entry.Name(), err))
+		}
+		var c catalog
+		if err := json.Unmarshal(data, &c); err != nil {
+			panic(fmt.Sprintf("i18n: invalid %s: %v", entry.Name(), err))
+		}
+		catalogs[strings.TrimSuffix(entry.Name(), ".json")] = &c
+	}
+	return catalogs
+}
+
+// Language maps a Discord locale such as "es-419" to its translation file
+func Language(locale discordgo.Locale) string {
+	lang, _, _ := strings.Cut(string(locale), "-")
+	if _, ok := catalogs[lang]; ok {
+		return lang
+	}
+	return DefaultLanguage
+}
+
+// T returns the message for key in the given locale, formatted with args.
+// Missing translations fall back to English, then to the key itself.
+func T(locale discordgo.Locale, key string, args ...interface{}) string {
+	text, ok := catalogs[Language(locale)].Messages[key]
+	if !ok {
+		if text, ok = catalogs[DefaultLanguage].Messages[key]; !ok {
+			text = key
+		}
+	}
+	if len(args) == 0 {
+		return text
+	}
+	return fmt.Sprintf(text, args...)
+}
+
+// LocalizeCommand fills in the name and description localizations of a
+// command, its options and their choices. The English text stays in the
+// command definition itself and is what Discord shows by default.
+func LocalizeCommand(cmd *discordgo.ApplicationCommand) {
+	names := make(map[discordgo.Locale]string)
+	descriptions := make(map[discordgo.Locale]string)
+	localize(cmd.Name, names, descriptions)
+	if len(names) > 0 {
+		cmd.NameLocalizations = &names
+	}
+	if len(descriptions) > 0 {
+		cmd.DescriptionLocalizations = &descriptions
+	}
+	localizeOptions(cmd.Name, cmd.Options)
+}
+
+func localizeOptions(parent string, options []*discordgo.ApplicationCommandOption) {
+	for _, opt := range options {
+		key := parent + "." + opt.Name
+		opt.NameLocalizations = make(map[discordgo.Locale]string)
+		opt.DescriptionLocalizations = make(map[discordgo.Locale]string)
+		localize(key, opt.NameLocalizations, opt.DescriptionLocalizations)
+
+		for _, choice := range opt.Choices {
+			value := fmt.Sprint(choice.Value)
+			choice.NameLocalizations = make(map[discordgo.Locale]string)
+			for lang, c := range catalogs {
+				if text := c.Commands[key].Choices[value]; text != "" && lang != DefaultLanguage {
+					for _, locale := range discordLocales[lang] {
+						choice.NameLocalizations[locale] = text
+					}
+				}
+			}
+		}
+		localizeOptions(key, opt.Options)
+	}
+}
+
+func localize(key string, names, descriptions map[discordgo.Locale]string) {
+	for lang, c := range catalogs {
+		text, ok := c.Commands[key]
+		if !ok || lang == DefaultLanguage {
+			continue
+		}
+		for _, locale := range discordLocales[lang] {
+			if text.Name != "" {
+				names[locale] = text.Name
+			}
+			if text.Description != "" {
+				descriptions[locale] = text.Description
+			}
+		}
+	}
+}
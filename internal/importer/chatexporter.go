@@ -0,0 +1,172 @@
+package importer
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// chatExportChannelTypes maps DiscordChatExporter's channel kinds to
+// Discord's channel types; direct message kinds are left out
+var chatExportChannelTypes = map[string]int{
+	"GuildTextChat":      0,
+	"GuildVoiceChat":     2,
+	"GuildCategory":      4,
+	"GuildNews":          5,
+	"GuildAnnouncement":  5,
+	"GuildNewsThread":    10,
+	"GuildPublicThread":  11,
+	"GuildPrivateThread": 12,
+	"GuildStageVoice":    13,
+	"GuildDirectory":     14,
+	"GuildForum":         15,
+}
+
+type chatExportMessage struct {
+	ID        snowflake `json:"id"`
+	Type      string    `json:"type"`
+	Timestamp time.Time `json:"timestamp"`
+	Content   string    `json:"content"`
+	Author    struct {
+		ID            snowflake  `json:"id"`
+		Name          string     `json:"name"`
+		Discriminator flexString `json:"discriminator"`
+		IsBot         bool       `json:"isBot"`
+		AvatarURL     string     `json:"avatarUrl"`
+	} `json:"author"`
+}
+
+// readChatExport reads a DiscordChatExporter JSON export of one channel,
+// decoding its messages as it goes so large channels aren't held in memory.
+// The guild and channel come before the messages in the files it writes.
+func readChatExport(r io.Reader, handle Handler) error {
+	dec := json.NewDecoder(bufio.NewReader(r))
+	if err := expectDelim(dec, '{'); err != nil {
+		return err
+	}
+
+	var channel Channel
+	var channelType string
+	haveChannel := false
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		switch token {
+		case "guild":
+			var guild struct {
+				ID   snowflake `json:"id"`
+				Name string    `json:"name"`
+			}
+			if err := dec.Decode(&guild); err != nil {
+				return fmt.Errorf("invalid guild: %w", err)
+			}
+			channel.GuildID, channel.GuildName = int64(guild.ID), guild.Name
+		case "channel":
+			var ch struct {
+				ID   snowflake  `json:"id"`
+				Type flexString `json:"type"`
+				Name string     `json:"name"`
+			}
+			if err := dec.Decode(&ch); err != nil {
+				return fmt.Errorf("invalid channel: %w", err)
+			}
+			channel.ID, channel.Name, channelType = int64(ch.ID), ch.Name, string(ch.Type)
+			haveChannel = true
+		case "messages":
+			if !haveChannel {
+				return fmt.Errorf("messages come before the channel they were sent in")
+			}
+			kind, guildChannel := chatExportChannelTypes[channelType]
+			if n, err := strconv.Atoi(channelType); err == nil {
+				kind, guildChannel = n, n != 1 && n != 3
+			}
+			channel.Type = kind
+			// Direct messages are decoded only to get past them
+			skip := !guildChannel || channel.GuildID == 0
+			if err := readChatExportMessages(dec, channel, skip, handle); err != nil {
+				return err
+			}
+		default:
+			var ignored json.RawMessage
+			if err := dec.Decode(&ignored); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func readChatExportMessages(dec *json.Decoder, channel Channel, skip bool, handle Handler) error {
+	if err := expectDelim(dec, '['); err != nil {
+		return err
+	}
+	batch := make([]Message, 0, BatchSize)
+	for dec.More() {
+		var m chatExportMessage
+		if err := dec.Decode(&m); err != nil {
+			return fmt.Errorf("invalid message: %w", err)
+		}
+		// Joins, pins, thread creations and the like have no content of their own
+		if skip || (m.Type != "" && m.Type != "Default" && m.Type != "Reply") {
+			continue
+		}
+		batch = append(batch, Message{
+			ID: int64(m.ID),
+			Author: Author{
+				ID:            int64(m.Author.ID),
+				Username:      m.Author.Name,
+				Discriminator: string(m.Author.Discriminator),
+				Avatar:        avatarHash(m.Author.AvatarURL),
+				Bot:           m.Author.IsBot,
+			},
+			Content:   m.Content,
+			Timestamp: m.Timestamp,
+		})
+		if len(batch) == BatchSize {
+			if err := handle(channel, batch); err != nil {
+				return err
+			}
+			batch = make([]Message, 0, BatchSize)
+		}
+	}
+	if err := expectDelim(dec, ']'); err != nil {
+		return err
+	}
+	if len(batch) > 0 {
+		return handle(channel, batch)
+	}
+	return nil
+}
+
+// avatarHash extracts the hash the bot stores from a CDN avatar URL such as
+// https://cdn.discordapp.com/avatars/<user>/<hash>.png?size=512; exports with
+// downloaded media point at local files, which have none
+func avatarHash(avatarURL string) string {
+	const prefix = "https://cdn.discordapp.com/avatars/"
+	if !strings.HasPrefix(avatarURL, prefix) {
+		return ""
+	}
+	parts := strings.Split(strings.TrimPrefix(avatarURL, prefix), "/")
+	if len(parts) != 2 {
+		return ""
+	}
+	hash, _, _ := strings.Cut(parts[1], ".")
+	return hash
+}
+
+func expectDelim(dec *json.Decoder, want json.Delim) error {
+	token, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if token != want {
+		return fmt.Errorf("expected %q, found %v", want, token)
+	}
+	return nil
+}
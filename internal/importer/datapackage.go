@@ -0,0 +1,205 @@
+package importer
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"path"
+	"strconv"
+	"time"
+)
+
+// Data packages have been written with lower and upper case folder names
+var (
+	packageMessageDirs = []string{"messages", "Messages"}
+	packageAccountDirs = []string{"account", "Account"}
+)
+
+// packageTimestampLayouts are the ways data packages have written times
+var packageTimestampLayouts = []string{
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	time.RFC3339Nano,
+}
+
+type packageChannel struct {
+	ID    snowflake `json:"id"`
+	Type  int       `json:"type"`
+	Name  string    `json:"name"`
+	Guild *struct {
+		ID   snowflake `json:"id"`
+		Name string    `json:"name"`
+	} `json:"guild"`
+}
+
+type packageMessage struct {
+	ID        snowflake `json:"ID"`
+	Timestamp string    `json:"Timestamp"`
+	Contents  string    `json:"Contents"`
+}
+
+// packageRoot finds the folder of a data package holding its messages
+// folder, which zips nest in a folder of their own or not depending on how
+// they were re-packed
+func packageRoot(fsys fs.FS) (string, bool) {
+	for _, pattern := range []string{"%s/*/channel.json", "*/%s/*/channel.json"} {
+		for _, dir := range packageMessageDirs {
+			matches, err := fs.Glob(fsys, fmt.Sprintf(pattern, dir))
+			if err == nil && len(matches) > 0 {
+				return path.Dir(path.Dir(path.Dir(matches[0]))), true
+			}
+		}
+	}
+	return "", false
+}
+
+// readPackage reads the server messages of a data package. They are all the
+// requesting user's, who is read from the account folder.
+func readPackage(fsys fs.FS, root string, handle Handler) error {
+	author, err := readPackageAccount(fsys, root)
+	if err != nil {
+		return err
+	}
+	for _, dir := range packageMessageDirs {
+		channelFiles, err := fs.Glob(fsys, path.Join(root, dir, "*", "channel.json"))
+		if err != nil {
+			return err
+		}
+		for _, channelFile := range channelFiles {
+			if err := readPackageChannel(fsys, path.Dir(channelFile), author, handle); err != nil {
+				return fmt.Errorf("failed to read %s: %w", path.Dir(channelFile), err)
+			}
+		}
+	}
+	return nil
+}
+
+func readPackageAccount(fsys fs.FS, root string) (Author, error) {
+	for _, dir := range packageAccountDirs {
+		data, err := fs.ReadFile(fsys, path.Join(root, dir, "user.json"))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return Author{}, err
+		}
+		var user struct {
+			ID            snowflake  `json:"id"`
+			Username      string     `json:"username"`
+			Discriminator flexString `json:"discriminator"`
+			AvatarHash    string     `json:"avatar_hash"`
+		}
+		if err := json.Unmarshal(data, &user); err != nil {
+			return Author{}, fmt.Errorf("invalid account/user.json: %w", err)
+		}
+		return Author{ID: int64(user.ID), Username: user.Username, Discriminator: string(user.Discriminator), Avatar: user.AvatarHash}, nil
+	}
+	return Author{}, fmt.Errorf("the data package has no account/user.json to tell whose messages it holds")
+}
+
+func readPackageChannel(fsys fs.FS, dir string, author Author, handle Handler) error {
+	data, err := fs.ReadFile(fsys, path.Join(dir, "channel.json"))
+	if err != nil {
+		return err
+	}
+	var ch packageChannel
+	if err := json.Unmarshal(data, &ch); err != nil {
+		return fmt.Errorf("invalid channel.json: %w", err)
+	}
+	// Direct messages and group chats have no guild
+	if ch.Guild == nil || ch.Guild.ID == 0 {
+		return nil
+	}
+	channel := Channel{ID: int64(ch.ID), GuildID: int64(ch.Guild.ID), GuildName: ch.Guild.Name, Name: ch.Name, Type: ch.Type}
+
+	messages, err := readPackageMessages(fsys, dir)
+	if err != nil {
+		return err
+	}
+	batch := make([]Message, 0, BatchSize)
+	for _, m := range messages {
+		timestamp, err := parsePackageTimestamp(m.Timestamp)
+		if err != nil {
+			log.Printf("⚠️ Skipping message %d in %s with an unreadable timestamp %q", m.ID, dir, m.Timestamp)
+			continue
+		}
+		batch = append(batch, Message{ID: int64(m.ID), Author: author, Content: m.Contents, Timestamp: timestamp})
+		if len(batch) == BatchSize {
+			if err := handle(channel, batch); err != nil {
+				return err
+			}
+			batch = make([]Message, 0, BatchSize)
+		}
+	}
+	if len(batch) > 0 {
+		return handle(channel, batch)
+	}
+	return nil
+}
+
+// readPackageMessages reads a channel's messages.json, or the messages.csv
+// of packages from before 2023
+func readPackageMessages(fsys fs.FS, dir string) ([]packageMessage, error) {
+	data, err := fs.ReadFile(fsys, path.Join(dir, "messages.json"))
+	if err == nil {
+		var messages []packageMessage
+		if err := json.Unmarshal(data, &messages); err != nil {
+			return nil, fmt.Errorf("invalid messages.json: %w", err)
+		}
+		return messages, nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	f, err := fsys.Open(path.Join(dir, "messages.csv"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	// ID,Timestamp,Contents,Attachments
+	if _, err := r.Read(); err != nil {
+		if err == io.EOF {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("invalid messages.csv: %w", err)
+	}
+	var messages []packageMessage
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			return messages, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid messages.csv: %w", err)
+		}
+		if len(record) < 3 {
+			continue
+		}
+		id, err := strconv.ParseInt(record[0], 10, 64)
+		if err != nil {
+			continue
+		}
+		messages = append(messages, packageMessage{ID: snowflake(id), Timestamp: record[1], Contents: record[2]})
+	}
+}
+
+func parsePackageTimestamp(text string) (time.Time, error) {
+	var err error
+	for _, layout := range packageTimestampLayouts {
+		var t time.Time
+		if t, err = time.Parse(layout, text); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, err
+}
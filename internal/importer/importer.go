@@ -0,0 +1,148 @@
+// Package importer reads the history of Discord servers from exports, so
+// messages sent before the bot joined can be indexed. It understands
+// DiscordChatExporter's JSON files and the data package Discord sends users
+// who request their data, which holds only that user's own messages.
+package importer
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BatchSize is how many messages Read passes on at a time
+const BatchSize = 500
+
+// Channel is where imported messages were sent
+type Channel struct {
+	ID        int64
+	GuildID   int64
+	GuildName string
+	Name      string
+	Type      int // discordgo.ChannelType
+}
+
+// Author is who sent an imported message
+type Author struct {
+	ID            int64
+	Username      string
+	Discriminator string
+	Avatar        string
+	Bot           bool
+}
+
+// Message is a user message read from an export; system messages such as
+// joins and pins are left out
+type Message struct {
+	ID        int64
+	Author    Author
+	Content   string
+	Timestamp time.Time
+}
+
+// Handler receives the messages of a channel in batches of up to BatchSize,
+// in the order the export lists them
+type Handler func(channel Channel, messages []Message) error
+
+// Read reads an export: a DiscordChatExporter JSON file or a directory of
+// them, or a Discord data package, extracted or as its zip. Direct messages
+// are skipped, since they don't belong to a server.
+func Read(name string, handle Handler) error {
+	info, err := os.Stat(name)
+	if err != nil {
+		return err
+	}
+
+	var fsys fs.FS
+	switch {
+	case info.IsDir():
+		fsys = os.DirFS(name)
+	case strings.EqualFold(filepath.Ext(name), ".zip"):
+		archive, err := zip.OpenReader(name)
+		if err != nil {
+			return fmt.Errorf("failed to open %s: %w", name, err)
+		}
+		defer archive.Close()
+		fsys = archive
+	default:
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		if err := readChatExport(f, handle); err != nil {
+			return fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		return nil
+	}
+
+	if root, ok := packageRoot(fsys); ok {
+		return readPackage(fsys, root, handle)
+	}
+	found := false
+	err = fs.WalkDir(fsys, ".", func(file string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.EqualFold(path.Ext(file), ".json") {
+			return err
+		}
+		found = true
+		f, err := fsys.Open(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		if err := readChatExport(f, handle); err != nil {
+			return fmt.Errorf("failed to read %s: %w", file, err)
+		}
+		return nil
+	})
+	if err == nil && !found {
+		err = fmt.Errorf("%s holds neither DiscordChatExporter JSON files nor a Discord data package", name)
+	}
+	return err
+}
+
+// snowflake is a Discord ID, which exports write as a string or a number
+type snowflake int64
+
+func (s *snowflake) UnmarshalJSON(data []byte) error {
+	text := strings.Trim(string(data), `"`)
+	if text == "" || text == "null" {
+		*s = 0
+		return nil
+	}
+	id, err := strconv.ParseInt(text, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid ID %s", data)
+	}
+	*s = snowflake(id)
+	return nil
+}
+
+// flexString is a field exports write as a string or a number, such as
+// discriminators
+type flexString string
+
+func (s *flexString) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*s = ""
+		return nil
+	}
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*s = flexString(text)
+		return nil
+	}
+	var number json.Number
+	if err := json.Unmarshal(data, &number); err != nil {
+		return err
+	}
+	*s = flexString(number.String())
+	return nil
+}
@@ -9,9 +9,25 @@ import (
 type AIService interface {
 	GenerateResponse(ctx context.Context, userMessage, username string) (string, error)
 	GenerateEmbedding(ctx context.Context, text string) ([]float32, error)
+	Complete(ctx context.Context, systemPrompt, userPrompt string, maxTokens int) (string, error)
+	GenerateResponseWithTools(ctx context.Context, userMessage, username string, tools []Tool) (string, error)
 	SetPersonality(humor, honesty int)
 }
 
+// StreamingAIService is implemented by AI services that can stream an answer
+// as it is generated; onText receives each new piece of text
+type StreamingAIService interface {
+	StreamResponse(ctx context.Context, userMessage, username string, onText func(text string)) (string, error)
+}
+
+// Tool is a function the AI may call while answering
+type Tool struct {
+	Name        string
+	Description string
+	Parameters  map[string]interface{} // JSON schema of the arguments
+	Handler     func(ctx context.Context, arguments string) (string, error)
+}
+
 // DiscordService defines the interface for Discord operations
 type DiscordService interface {
 	SendMessage(channelID, content string) error
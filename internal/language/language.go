@@ -0,0 +1,203 @@
+// Package language tells which natural language a chat message is written
+// in. Detection is a cheap heuristic run on every indexed message: the
+// script of its letters for non-Latin alphabets, and common function words
+// for Latin ones. Messages too short or mixed to tell get no language.
+package language
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// minWords is how many words a Latin-script text needs before its function
+// words are trusted; "ok merci" says little about a channel's language
+const minWords = 3
+
+// minHits is how many function words the most likely language must match
+const minHits = 2
+
+var (
+	// noise is text that isn't prose: code, links, mentions and custom emojis
+	noise = regexp.MustCompile("(?s)```.*?```|`[^`]*`|https?://\\S+|<[@#:a][^>]*>")
+	word  = regexp.MustCompile(`[\p{L}']+`)
+)
+
+// names are the languages Detect can return, by ISO 639-1 code
+var names = map[string]string{
+	"ar": "Arabic",
+	"de": "German",
+	"el": "Greek",
+	"en": "English",
+	"es": "Spanish",
+	"fr": "French",
+	"he": "Hebrew",
+	"hi": "Hindi",
+	"it": "Italian",
+	"ja": "Japanese",
+	"ko": "Korean",
+	"nl": "Dutch",
+	"pt": "Portuguese",
+	"ru": "Russian",
+	"th": "Thai",
+	"uk": "Ukrainian",
+	"zh": "Chinese",
+}
+
+// functionWords are frequent words that mostly belong to one Latin-script
+// language; a few are shared, which only ties the languages sharing them
+var functionWords = map[string][]string{
+	"en": {"the", "and", "is", "are", "was", "you", "that", "this", "with", "for", "have", "not", "what", "how", "can", "does", "it's", "i'm", "my", "of", "to", "in", "on", "be", "there", "it", "will", "would", "just", "from"},
+	"fr": {"le", "la", "les", "des", "est", "et", "une", "un", "pour", "pas", "que", "qui", "dans", "sur", "avec", "je", "tu", "vous", "nous", "il", "c'est", "ce", "mais", "ou", "du", "au", "sont", "j'ai", "ça", "comment", "faire", "on"},
+	"de": {"der", "die", "das", "und", "ist", "nicht", "ich", "du", "wir", "ein", "eine", "mit", "auf", "für", "sie", "es", "den", "dem", "zu", "von", "auch", "wie", "was", "sind", "hat", "kann", "aber", "oder", "bei", "noch"},
+	"es": {"el", "los", "las", "es", "y", "que", "una", "por", "para", "con", "pero", "como", "qué", "cómo", "yo", "tengo", "hay", "muy", "también", "puedo", "esto", "eso", "del", "lo", "se", "está", "estoy", "mi"},
+	"it": {"il", "gli", "della", "che", "è", "sono", "per", "non", "una", "con", "come", "cosa", "questo", "anche", "ma", "mi", "ho", "ci", "perché", "del", "nel", "sul", "di", "io"},
+	"pt": {"os", "as", "não", "uma", "com", "para", "que", "você", "isso", "também", "muito", "mais", "ele", "ela", "são", "tem", "foi", "do", "da", "dos", "das", "em", "um", "eu", "está"},
+	"nl": {"de", "het", "een", "en", "is", "niet", "ik", "je", "dat", "van", "op", "met", "voor", "zijn", "maar", "wat", "hoe", "er", "ook", "naar", "kan", "wel", "dit"},
+}
+
+// accents are letters that hint at one Latin-script language
+var accents = map[rune]string{
+	'ß': "de", 'ä': "de", 'ö': "de", 'ü': "de",
+	'ñ': "es", '¿': "es", '¡': "es",
+	'ã': "pt", 'õ': "pt",
+	'è': "fr", 'ê': "fr", 'à': "fr", 'ù': "fr", 'œ': "fr", 'ç': "fr",
+	'ò': "it", 'ì': "it",
+}
+
+var lexicon = func() map[string][]string {
+	lexicon := make(map[string][]string)
+	for code, words := range functionWords {
+		for _, w := range words {
+			lexicon[w] = append(lexicon[w], code)
+		}
+	}
+	return lexicon
+}()
+
+// Detect returns the ISO 639-1 code of the language text is written in, or
+// "" when it is too short or mixed to tell
+func Detect(text string) string {
+	text = noise.ReplaceAllString(text, " ")
+	if code := detectScript(text); code != "" {
+		return code
+	}
+
+	words := word.FindAllString(strings.ToLower(text), -1)
+	if len(words) < minWords {
+		return ""
+	}
+	scores := make(map[string]int)
+	for _, w := range words {
+		for _, code := range lexicon[strings.Trim(w, "'")] {
+			scores[code]++
+		}
+	}
+	for _, r := range strings.ToLower(text) {
+		if code, ok := accents[r]; ok {
+			scores[code]++
+		}
+	}
+
+	best, bestScore, runnerUp := "", 0, 0
+	for code, score := range scores {
+		switch {
+		case score > bestScore:
+			best, bestScore, runnerUp = code, score, bestScore
+		case score > runnerUp:
+			runnerUp = score
+		}
+	}
+	if bestScore < minHits || bestScore == runnerUp {
+		return ""
+	}
+	return best
+}
+
+// detectScript names the language of text written mostly in a non-Latin
+// script, or returns ""
+func detectScript(text string) string {
+	counts := make(map[string]int)
+	letters, kana := 0, 0
+	ukrainian := false
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		switch {
+		// Japanese mixes kanji with kana, so both count as Chinese script
+		case unicode.Is(unicode.Hiragana, r) || unicode.Is(unicode.Katakana, r):
+			kana++
+			counts["zh"]++
+		case unicode.Is(unicode.Han, r):
+			counts["zh"]++
+		case unicode.Is(unicode.Hangul, r):
+			counts["ko"]++
+		case unicode.Is(unicode.Cyrillic, r):
+			counts["ru"]++
+			ukrainian = ukrainian || strings.ContainsRune("іїєґІЇЄҐ", r)
+		case unicode.Is(unicode.Arabic, r):
+			counts["ar"]++
+		case unicode.Is(unicode.Hebrew, r):
+			counts["he"]++
+		case unicode.Is(unicode.Greek, r):
+			counts["el"]++
+		case unicode.Is(unicode.Devanagari, r):
+			counts["hi"]++
+		case unicode.Is(unicode.Thai, r):
+			counts["th"]++
+		}
+	}
+
+	best, bestCount := "", 0
+	for code, count := range counts {
+		if count > bestCount || (count == bestCount && code < best) {
+			best, bestCount = code, count
+		}
+	}
+	if bestCount*2 <= letters {
+		return ""
+	}
+	switch {
+	case best == "zh" && kana > 0:
+		return "ja"
+	case best == "ru" && ukrainian:
+		return "uk"
+	}
+	return best
+}
+
+// Name is the English name of a language code, or the code itself
+func Name(code string) string {
+	if name, ok := names[code]; ok {
+		return name
+	}
+	return code
+}
+
+// Parse reads a language Detect can return, from its code or English name
+// such as "fr" or "French"
+func Parse(s string) (string, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if _, ok := names[s]; ok {
+		return s, true
+	}
+	for code, name := range names {
+		if strings.ToLower(name) == s {
+			return code, true
+		}
+	}
+	return "", false
+}
+
+// Codes lists the languages Detect can return, sorted
+func Codes() []string {
+	codes := make([]string, 0, len(names))
+	for code := range names {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
@@ -0,0 +1,173 @@
+// Package leader elects one replica to run singleton work, such as scheduled
+// jobs and slash command registration, when several bot instances share a
+// database. The leader holds a Postgres session-level advisory lock on a
+// dedicated connection; if that connection dies, Postgres releases the lock
+// and another replica takes over at its next campaign.
+package leader
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Elector campaigns for the leadership of a named role
+type Elector struct {
+	db       *sql.DB
+	name     string
+	interval time.Duration
+
+	leader atomic.Bool
+
+	mu        sync.Mutex
+	conn      *sql.Conn // Holds the lock while leading
+	resign    context.CancelFunc
+	onElected []func(ctx context.Context)
+	cancel    context.CancelFunc
+	done      chan struct{}
+}
+
+// New creates an elector for a role; interval is how often followers try to
+// take the lock and the leader checks it still holds it
+func New(db *sql.DB, name string, interval time.Duration) *Elector {
+	return &Elector{db: db, name: name, interval: interval}
+}
+
+// IsLeader reports whether this replica currently leads
+func (e *Elector) IsLeader() bool {
+	return e.leader.Load()
+}
+
+// OnElected adds a function run in its own goroutine each time this replica
+// becomes leader; its context is cancelled when leadership is lost. Call it
+// before Start.
+func (e *Elector) OnElected(fn func(ctx context.Context)) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.onElected = append(e.onElected, fn)
+}
+
+// Start campaigns right away, then every interval, until Stop
+func (e *Elector) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	e.cancel = cancel
+	e.done = make(chan struct{})
+
+	go func() {
+		defer close(e.done)
+		ticker := time.NewTicker(e.interval)
+		defer ticker.Stop()
+		for {
+			e.check(ctx)
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	log.Printf("🗳️ Campaigning for %s leadership every %s", e.name, e.interval)
+}
+
+// Stop stops campaigning and releases the lock, so another replica takes over
+// at its next campaign instead of waiting for this connection to time out
+func (e *Elector) Stop() {
+	if e.cancel == nil {
+		return
+	}
+	e.cancel()
+	<-e.done
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.conn == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := e.conn.ExecContext(ctx, "SELECT pg_advisory_unlock(hashtext($1))", e.lockName()); err != nil {
+		log.Printf("⚠️ Failed to release %s leadership: %v", e.name, err)
+	}
+	e.demote(false)
+	log.Printf("👋 Resigned %s leadership", e.name)
+}
+
+func (e *Elector) lockName() string {
+	return "leader:" + e.name
+}
+
+// check takes the lock when following, or makes sure it is still held when leading
+func (e *Elector) check(ctx context.Context) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, e.interval)
+	defer cancel()
+
+	if e.conn != nil {
+		// The lock lives as long as the session; a dead connection has lost it
+		if err := e.conn.PingContext(ctx); err != nil {
+			if ctx.Err() == nil {
+				log.Printf("⚠️ Lost %s leadership: %v", e.name, err)
+				e.demote(true)
+			}
+		}
+		return
+	}
+
+	acquired, err := e.campaign(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("⚠️ Failed to campaign for %s leadership: %v", e.name, err)
+		}
+		return
+	}
+	if !acquired {
+		return
+	}
+
+	e.leader.Store(true)
+	leadCtx, resign := context.WithCancel(context.Background())
+	e.resign = resign
+	for _, fn := range e.onElected {
+		go fn(leadCtx)
+	}
+	log.Printf("👑 Elected %s leader", e.name)
+}
+
+// campaign tries to take the lock on a connection of its own, which it keeps
+// as e.conn when it succeeds
+func (e *Elector) campaign(ctx context.Context) (bool, error) {
+	conn, err := e.db.Conn(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to get a connection: %w", err)
+	}
+	var acquired bool
+	err = conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock(hashtext($1))", e.lockName()).Scan(&acquired)
+	if err != nil || !acquired {
+		conn.Close()
+		return false, err
+	}
+	e.conn = conn
+	return true, nil
+}
+
+// demote gives up leadership; discard drops the connection instead of
+// returning it to the pool, where it could still hold the lock
+func (e *Elector) demote(discard bool) {
+	e.leader.Store(false)
+	if e.resign != nil {
+		e.resign()
+		e.resign = nil
+	}
+	if discard {
+		e.conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+	}
+	e.conn.Close()
+	e.conn = nil
+}
@@ -0,0 +1,247 @@
+// Package mentions enforces which mentions the bot's messages may ping.
+// Content the model writes, or quotes from retrieved messages, can carry role
+// and @everyone mentions; the policy is applied to every message, webhook and
+// interaction response the session sends, whichever service sends it.
+package mentions
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Policy is which kinds of mentions may ping. Users and roles the sending
+// code lists explicitly, such as the moderator role in alerts, always ping.
+type Policy struct {
+	Users    bool
+	Roles    bool
+	Everyone bool // @everyone and @here
+}
+
+// ParsePolicy reads a policy from mention types: "users", "roles",
+// "everyone", or "none" alone
+func ParsePolicy(types []string) (Policy, error) {
+	var policy Policy
+	for _, t := range types {
+		switch strings.ToLower(strings.TrimSpace(t)) {
+		case "users":
+			policy.Users = true
+		case "roles":
+			policy.Roles = true
+		case "everyone":
+			policy.Everyone = true
+		case "none", "":
+		default:
+			return Policy{}, fmt.Errorf("unknown mention type %q, expected users, roles, everyone or none", t)
+		}
+	}
+	return policy, nil
+}
+
+func (p Policy) String() string {
+	var types []string
+	for _, t := range p.parse() {
+		types = append(types, string(t))
+	}
+	if len(types) == 0 {
+		return "none"
+	}
+	return strings.Join(types, ",")
+}
+
+func (p Policy) parse() []discordgo.AllowedMentionType {
+	parse := []discordgo.AllowedMentionType{}
+	if p.Users {
+		parse = append(parse, discordgo.AllowedMentionTypeUsers)
+	}
+	if p.Roles {
+		parse = append(parse, discordgo.AllowedMentionTypeRoles)
+	}
+	if p.Everyone {
+		parse = append(parse, discordgo.AllowedMentionTypeEveryone)
+	}
+	return parse
+}
+
+func (p Policy) allows(t discordgo.AllowedMentionType) bool {
+	switch t {
+	case discordgo.AllowedMentionTypeUsers:
+		return p.Users
+	case discordgo.AllowedMentionTypeRoles:
+		return p.Roles
+	case discordgo.AllowedMentionTypeEveryone:
+		return p.Everyone
+	}
+	return false
+}
+
+// Restrict narrows the mentions a message asked for to the policy. A message
+// that asked for nothing gets the policy itself, with replies pinging their
+// author when users may be pinged, as they do by default.
+func (p Policy) Restrict(requested *discordgo.MessageAllowedMentions) *discordgo.MessageAllowedMentions {
+	if requested == nil {
+		return &discordgo.MessageAllowedMentions{Parse: p.parse(), RepliedUser: p.Users}
+	}
+	restricted := *requested
+	restricted.Parse = []discordgo.AllowedMentionType{}
+	for _, t := range requested.Parse {
+		if p.allows(t) {
+			restricted.Parse = append(restricted.Parse, t)
+		}
+	}
+	return &restricted
+}
+
+// Enforce applies a policy to everything the session sends
+func Enforce(session *discordgo.Session, policy Policy) {
+	if session.Client == nil {
+		session.Client = &http.Client{}
+	}
+	session.Client.Transport = &transport{policy: policy, next: session.Client.Transport}
+}
+
+var (
+	// messageEndpoints send or edit message content: channel messages and
+	// webhooks, which interaction follow-ups and response edits go through
+	messageEndpoints = regexp.MustCompile(`/channels/\d+/messages(/\d+)?$|/webhooks/\d+/[^/]+(/messages/(\d+|@original))?$`)
+	callbackEndpoint = regexp.MustCompile(`/interactions/\d+/[^/]+/callback$`)
+)
+
+// transport rewrites the allowed mentions of outgoing requests
+type transport struct {
+	policy Policy
+	next   http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	next := t.next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	if req.Body == nil || (req.Method != http.MethodPost && req.Method != http.MethodPatch) {
+		return next.RoundTrip(req)
+	}
+
+	var rewrite func([]byte) ([]byte, error)
+	switch path := req.URL.Path; {
+	case messageEndpoints.MatchString(path):
+		rewrite = t.message
+	case callbackEndpoint.MatchString(path):
+		rewrite = t.callback
+	default:
+		return next.RoundTrip(req)
+	}
+
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	if body, err = t.rewriteBody(req.Header.Get("Content-Type"), body, rewrite); err != nil {
+		return nil, fmt.Errorf("failed to apply mention policy: %w", err)
+	}
+
+	req = req.Clone(req.Context())
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(body)), nil }
+	req.ContentLength = int64(len(body))
+	return next.RoundTrip(req)
+}
+
+// rewriteBody applies rewrite to a JSON body, or to the JSON payload of a
+// multipart body carrying files
+func (t *transport) rewriteBody(contentType string, body []byte, rewrite func([]byte) ([]byte, error)) ([]byte, error) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || !strings.HasPrefix(mediaType, "multipart/") {
+		return rewrite(body)
+	}
+
+	reader := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+	var out bytes.Buffer
+	writer := multipart.NewWriter(&out)
+	if err := writer.SetBoundary(params["boundary"]); err != nil {
+		return nil, err
+	}
+	for {
+		part, err := reader.NextRawPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		content, err := io.ReadAll(part)
+		if err != nil {
+			return nil, err
+		}
+		if part.FormName() == "payload_json" {
+			if content, err = rewrite(content); err != nil {
+				return nil, err
+			}
+		}
+		w, err := writer.CreatePart(part.Header)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(content); err != nil {
+			return nil, err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// message restricts the allowed mentions of a message or webhook payload
+func (t *transport) message(payload []byte) ([]byte, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(payload, &fields); err != nil {
+		return nil, err
+	}
+
+	var requested *discordgo.MessageAllowedMentions
+	if raw, ok := fields["allowed_mentions"]; ok && string(raw) != "null" {
+		requested = &discordgo.MessageAllowedMentions{}
+		if err := json.Unmarshal(raw, requested); err != nil {
+			return nil, err
+		}
+	}
+	restricted, err := json.Marshal(t.policy.Restrict(requested))
+	if err != nil {
+		return nil, err
+	}
+	fields["allowed_mentions"] = restricted
+	return json.Marshal(fields)
+}
+
+// callback restricts the message an interaction responds with; deferrals,
+// autocomplete choices and modals carry none
+func (t *transport) callback(payload []byte) ([]byte, error) {
+	var response struct {
+		Type discordgo.InteractionResponseType `json:"type"`
+		Data json.RawMessage                   `json:"data,omitempty"`
+	}
+	if err := json.Unmarshal(payload, &response); err != nil {
+		return nil, err
+	}
+	if len(response.Data) == 0 || string(response.Data) == "null" ||
+		(response.Type != discordgo.InteractionResponseChannelMessageWithSource && response.Type != discordgo.InteractionResponseUpdateMessage) {
+		return payload, nil
+	}
+
+	data, err := t.message(response.Data)
+	if err != nil {
+		return nil, err
+	}
+	response.Data = data
+	return json.Marshal(response)
+}
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// Announcement draft statuses
+const (
+	AnnouncementPending  = "pending"
+	AnnouncementPosted   = "posted"
+	AnnouncementRejected = "rejected"
+)
+
+// AnnouncementConfig is where a guild's approved announcements are posted
+type AnnouncementConfig struct {
+	GuildID   int64 `gorm:"primaryKey;autoIncrement:false"`
+	ChannelID int64 `gorm:"not null"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// AnnouncementDraft is an AI-written announcement waiting for a moderator's approval
+type AnnouncementDraft struct {
+	ID          int64  `gorm:"primaryKey"`
+	GuildID     int64  `gorm:"not null;index"`
+	ChannelID   int64  `gorm:"not null"` // Where it is posted once approved
+	RequestedBy int64  `gorm:"not null"`
+	Topic       string `gorm:"type:text;not null"`
+	Content     string `gorm:"type:text;not null"`
+	Status      string `gorm:"size:16;not null;default:pending"`
+	ReviewedBy  int64
+	MessageID   int64 // Set once posted
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
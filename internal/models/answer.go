@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// AnswerLog is a question the bot answered and the answer it gave, kept for
+// guild admins to review in the dashboard with the 👍 and 👎 it got
+type AnswerLog struct {
+	ID        int64     `gorm:"primaryKey"`
+	GuildID   int64     `gorm:"not null;index:idx_answer_logs_guild_created,priority:1"`
+	ChannelID int64     `gorm:"not null"`
+	MessageID int64     `gorm:"not null;uniqueIndex"` // The bot's answer
+	UserID    int64     `gorm:"not null"`             // Who asked
+	Question  string    `gorm:"type:text;not null"`   // Encrypted with ENCRYPT_MESSAGES, as is the answer
+	Answer    string    `gorm:"type:text;not null"`
+	Positive  int       `gorm:"not null;default:0"`                                   // 👍 reactions
+	Negative  int       `gorm:"not null;default:0"`                                   // 👎 reactions
+	CreatedAt time.Time `gorm:"index:idx_answer_logs_guild_created,priority:2;index"` // Indexed alone for pruning
+}
+
+// AnswerStats sums up a guild's answers over a period
+type AnswerStats struct {
+	Answers  int64
+	Positive int64
+	Negative int64
+}
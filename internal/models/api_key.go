@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Scopes an API key can be given
+const (
+	ScopeSearch = "search" // GET /api/search, read-only
+	ScopeAsk    = "ask"    // POST /api/ask, which spends AI tokens
+	ScopeAdmin  = "admin"  // The /admin endpoints, including key management; implies the others
+)
+
+// APIKeyScopes lists the scopes in the order they are shown
+var APIKeyScopes = []string{ScopeSearch, ScopeAsk, ScopeAdmin}
+
+// APIKey lets an integrator call the HTTP API with the scopes it was given.
+// Only a hash of the secret is stored; the secret is shown once, when the key
+// is created or rotated.
+type APIKey struct {
+	ID         int64          `gorm:"primaryKey" json:"id"`
+	Name       string         `gorm:"not null" json:"name"`
+	Prefix     string         `gorm:"not null" json:"prefix"`        // Start of the secret, to tell keys apart
+	Hash       string         `gorm:"not null;uniqueIndex" json:"-"` // SHA-256 of the secret, hex encoded
+	Scopes     pq.StringArray `gorm:"type:text[];not null" json:"scopes"`
+	RotatedTo  *int64         `json:"rotated_to,omitempty"` // The key that replaced this one
+	CreatedAt  time.Time      `json:"created_at"`
+	LastUsedAt *time.Time     `json:"last_used_at,omitempty"`
+	ExpiresAt  *time.Time     `json:"expires_at,omitempty"` // Set when rotated, after a grace period
+	RevokedAt  *time.Time     `json:"revoked_at,omitempty"`
+}
+
+// Active reports whether the key may still be used
+func (k *APIKey) Active(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || now.Before(*k.ExpiresAt))
+}
+
+// Allows reports whether the key has a scope; admin keys have them all
+func (k *APIKey) Allows(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// APIKeyUsage meters the requests made with a key, per day and scope
+type APIKeyUsage struct {
+	KeyID    int64     `gorm:"primaryKey" json:"-"`
+	Day      time.Time `gorm:"primaryKey;type:date" json:"day"`
+	Scope    string    `gorm:"primaryKey" json:"scope"`
+	Requests int64     `gorm:"not null;default:0" json:"requests"`
+	Failures int64     `gorm:"not null;default:0" json:"failures"` // Answered with an error status
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"discord-tars/internal/pgvector"
+)
+
+// MessageAttachment is a file shared in an indexed message, with the text of
+// documents that have one, embedded so shared files can be found by their
+// name, who shared them and what they contain
+type MessageAttachment struct {
+	ID          int64           `gorm:"primaryKey;autoIncrement:false"` // Discord attachment ID
+	MessageID   int64           `gorm:"not null;index"`
+	GuildID     int64           `gorm:"index"`
+	ChannelID   int64           `gorm:"not null"`
+	UserID      int64           `gorm:"not null"`
+	Filename    string          `gorm:"size:255;not null"`
+	ContentType string          `gorm:"size:128"`
+	Size        int64           `gorm:"not null"`
+	URL         string          `gorm:"type:text;not null"` // Discord CDN link, which expires; /attachments re-serves archived copies
+	Content     string          `gorm:"type:text"`          // Text of documents, truncated; empty for other files
+	Embedding   pgvector.Vector `gorm:"type:vector(1536)"`
+	Timestamp   time.Time       `gorm:"not null"` // When the message was posted
+	CreatedAt   time.Time
+}
+
+// AttachmentResult is a shared file matched by vector search
+type AttachmentResult struct {
+	Attachment MessageAttachment
+	Username   string
+	Similarity float64
+}
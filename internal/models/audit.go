@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// Outcomes of an audited command
+const (
+	AuditOK     = "ok"
+	AuditDenied = "denied" // The user lacked the permissions it needs
+	AuditError  = "error"
+	// Flagged marks a suspected prompt injection, recorded as command "prompt-injection"
+	AuditFlagged = "flagged"
+)
+
+// CommandAudit records one command invocation, for admins reviewing who did what
+type CommandAudit struct {
+	ID        int64     `gorm:"primaryKey"`
+	GuildID   int64     `gorm:"index:idx_command_audit_guild_created,priority:1"` // Zero in DMs
+	UserID    int64     `gorm:"not null"`
+	Command   string    `gorm:"size:100;not null"` // With its subcommand, e.g. "digest subscribe"
+	Options   string    `gorm:"type:text"`         // "name:value" pairs, secrets redacted; encrypted with ENCRYPT_MESSAGES
+	LatencyMS int64     `gorm:"not null"`
+	Outcome   string    `gorm:"size:16;not null"`
+	CreatedAt time.Time `gorm:"index:idx_command_audit_guild_created,priority:2;index"` // Indexed alone for pruning
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"discord-tars/internal/pgvector"
+)
+
+// Bookmark is a message a member saved to their private collection. The
+// content is copied so the bookmark outlives edits and deletions, and embedded
+// for semantic search.
+type Bookmark struct {
+	ID         int64           `gorm:"primaryKey"`
+	UserID     int64           `gorm:"not null;uniqueIndex:idx_bookmark_user_message"`
+	MessageID  int64           `gorm:"not null;uniqueIndex:idx_bookmark_user_message"`
+	GuildID    int64           // 0 for messages in DMs
+	ChannelID  int64           `gorm:"not null"`
+	AuthorID   int64           `gorm:"not null"`
+	AuthorName string          `gorm:"size:255"`
+	Content    string          `gorm:"type:text;not null"`
+	Embedding  pgvector.Vector `gorm:"type:vector(1536)"`
+	PostedAt   time.Time       `gorm:"not null"` // When the message was posted
+	CreatedAt  time.Time
+}
+
+// BookmarkResult is a bookmark matched by vector search
+type BookmarkResult struct {
+	Bookmark   Bookmark
+	Similarity float64
+}
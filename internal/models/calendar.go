@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// Calendar source kinds
+const (
+	CalendarKindICS     = "ics"
+	CalendarKindDiscord = "discord" // The guild's own scheduled events
+)
+
+// CalendarSource is an ICS calendar (e.g. a Google Calendar iCal address)
+// attached to a guild, or the guild's Discord scheduled events
+type CalendarSource struct {
+	ID                int64  `gorm:"primaryKey"`
+	GuildID           int64  `gorm:"not null;index"`
+	Kind              string `gorm:"size:20;not null;default:ics"`
+	Name              string `gorm:"size:100;not null"`
+	URL               string `gorm:"type:text;not null"`
+	ReminderChannelID int64  // 0 disables reminders, and announcements of new Discord events
+	ReminderMinutes   int    `gorm:"not null;default:30"`
+	LastSyncedAt      *time.Time
+	LastError         string `gorm:"type:text"`
+	CreatedBy         int64
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+}
+
+// CalendarEvent is a single (possibly expanded recurring) event occurrence
+type CalendarEvent struct {
+	ID          int64     `gorm:"primaryKey"`
+	SourceID    int64     `gorm:"not null;uniqueIndex:idx_calendar_event_occurrence"`
+	GuildID     int64     `gorm:"not null;index"`
+	UID         string    `gorm:"type:text;not null;uniqueIndex:idx_calendar_event_occurrence"`
+	StartsAt    time.Time `gorm:"not null;index;uniqueIndex:idx_calendar_event_occurrence"`
+	EndsAt      time.Time
+	AllDay      bool
+	Summary     string `gorm:"type:text"`
+	Description string `gorm:"type:text"`
+	Location    string `gorm:"type:text"`
+	RemindedAt  *time.Time
+	SyncedAt    time.Time // Occurrences not seen in the latest sync are pruned
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// CalendarReminder is an event due for a reminder along with where to post it
+type CalendarReminder struct {
+	CalendarEvent
+	ChannelID  int64
+	SourceName string
+}
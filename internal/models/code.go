@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"discord-tars/internal/pgvector"
+)
+
+// CodeSnippet is a fenced code block from an indexed message, embedded on its
+// own so shared snippets can be found by what the code does
+type CodeSnippet struct {
+	ID        int64           `gorm:"primaryKey"`
+	MessageID int64           `gorm:"not null;index"`
+	GuildID   int64           `gorm:"index"`
+	ChannelID int64           `gorm:"not null"`
+	UserID    int64           `gorm:"not null"`
+	Language  string          `gorm:"size:32;index"` // Normalized fence language; empty when unlabeled
+	Code      string          `gorm:"type:text;not null"`
+	Embedding pgvector.Vector `gorm:"type:vector(1536)"`
+	Timestamp time.Time       `gorm:"not null"` // When the message was posted
+	CreatedAt time.Time
+}
+
+// CodeResult is a code snippet matched by vector search
+type CodeResult struct {
+	Snippet    CodeSnippet
+	Username   string
+	Similarity float64
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// AI providers a guild can bring its own key for
+const (
+	ProviderOpenAI    = "openai"
+	ProviderAnthropic = "anthropic"
+)
+
+// AICredential is a guild's own AI provider key, used instead of the operator's
+type AICredential struct {
+	GuildID      int64  `gorm:"primaryKey;autoIncrement:false"`
+	Provider     string `gorm:"size:16;not null"`
+	EncryptedKey string `gorm:"type:text;not null"` // Sealed with the instance encryption key
+	KeyHint      string `gorm:"size:8"`             // Last characters of the key, for display
+	Model        string `gorm:"size:100"`           // Optional model override
+	CreatedBy    int64
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
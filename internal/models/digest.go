@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// Digest frequencies
+const (
+	DigestDaily  = "daily"
+	DigestWeekly = "weekly"
+)
+
+// DigestSubscription is a user's opt-in to a periodic DM digest of a channel
+type DigestSubscription struct {
+	ID        int64      `gorm:"primaryKey"`
+	UserID    int64      `gorm:"not null;uniqueIndex:idx_digest_user_channel"`
+	GuildID   int64      `gorm:"not null;index"`
+	ChannelID int64      `gorm:"not null;uniqueIndex:idx_digest_user_channel"`
+	Frequency string     `gorm:"size:16;not null;default:daily"`
+	Hour      int        `gorm:"not null;default:9"` // Local delivery hour (0-23)
+	Weekday   int        `gorm:"not null;default:1"` // Delivery day for weekly digests (0 = Sunday)
+	Timezone  string     `gorm:"size:64;not null;default:UTC"`
+	LastSent  *time.Time // Nil until the first digest is delivered
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
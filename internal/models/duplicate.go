@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"discord-tars/internal/pgvector"
+)
+
+// DuplicateConfig is a guild's opt-in to having questions already answered
+// pointed at the earlier answer
+type DuplicateConfig struct {
+	GuildID   int64 `gorm:"primaryKey;autoIncrement:false"`
+	Enabled   bool  `gorm:"not null;default:false"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// AnsweredQuestion is a question the bot answered, kept so the same question
+// asked again can be linked to that answer
+type AnsweredQuestion struct {
+	ID        int64           `gorm:"primaryKey"`
+	GuildID   int64           `gorm:"not null;index"`
+	ChannelID int64           `gorm:"not null"`
+	MessageID int64           `gorm:"not null;uniqueIndex"` // The bot's answer
+	Question  string          `gorm:"type:text;not null"`
+	Embedding pgvector.Vector `gorm:"type:vector(1536)"`
+	CreatedAt time.Time       `gorm:"index"`
+}
+
+// AnsweredQuestionResult is an answered question matched by vector search
+type AnsweredQuestionResult struct {
+	Question   AnsweredQuestion
+	Similarity float64
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// ExportedMessage is a stored message with its metadata and embedding, as
+// index exports write it
+type ExportedMessage struct {
+	ID             int64     `json:"id,string"`
+	GuildID        int64     `json:"guild_id,string"`
+	ChannelID      int64     `json:"channel_id,string"`
+	ChannelName    string    `json:"channel_name"`
+	UserID         int64     `json:"user_id,string"`
+	Username       string    `json:"username"`
+	Content        string    `json:"content"`
+	Timestamp      time.Time `json:"timestamp"`
+	Language       string    `json:"language,omitempty"` // Empty when it wasn't detected
+	EmbeddingModel string    `json:"embedding_model,omitempty"`
+	Embedding      []float32 `json:"embedding,omitempty"` // Nil for messages without one
+}
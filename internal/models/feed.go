@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// Feed is an RSS or Atom feed watched for a channel
+type Feed struct {
+	ID              int64  `gorm:"primaryKey"`
+	GuildID         int64  `gorm:"not null;index"`
+	ChannelID       int64  `gorm:"not null;uniqueIndex:idx_feed_channel_url"`
+	URL             string `gorm:"type:text;not null;uniqueIndex:idx_feed_channel_url"`
+	Title           string `gorm:"size:300"`
+	IntervalMinutes int    `gorm:"not null;default:60"`
+	LastCheckedAt   *time.Time
+	LastError       string `gorm:"type:text"`
+	CreatedBy       int64
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+// FeedItem records a feed entry that has been seen, so it is only posted once
+type FeedItem struct {
+	ID          int64  `gorm:"primaryKey"`
+	FeedID      int64  `gorm:"not null;uniqueIndex:idx_feed_item_guid"`
+	GUID        string `gorm:"type:text;not null;uniqueIndex:idx_feed_item_guid"`
+	Title       string `gorm:"type:text"`
+	Link        string `gorm:"type:text"`
+	PublishedAt *time.Time
+	CreatedAt   time.Time
+}
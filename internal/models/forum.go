@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"discord-tars/internal/pgvector"
+)
+
+// ForumPost is a post (thread) of a forum channel with its tags, embedded so
+// new posts can be pointed at similar ones, and solved once its author
+// accepts an answer or a solved tag is applied
+type ForumPost struct {
+	ThreadID  int64           `gorm:"primaryKey;autoIncrement:false"`
+	GuildID   int64           `gorm:"not null;index"`
+	ForumID   int64           `gorm:"not null;index"` // The forum channel it was posted in
+	AuthorID  int64           `gorm:"not null"`
+	Title     string          `gorm:"size:100;not null"`
+	Tags      string          `gorm:"size:500"`  // Applied tag names, comma-separated
+	Content   string          `gorm:"type:text"` // The starter message
+	Embedding pgvector.Vector `gorm:"type:vector(1536)"`
+	// No GORM default: false must be stored as is
+	Solved            bool  `gorm:"not null"`
+	SolutionMessageID int64 `gorm:"index"` // The accepted answer; 0 when solved by tag only
+	SolvedAt          *time.Time
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+}
+
+// ForumPostResult is a forum post matched by vector search
+type ForumPostResult struct {
+	Post       ForumPost
+	Similarity float64
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// GitHubSubscription announces a repository's release and issue events in a channel
+type GitHubSubscription struct {
+	ID        int64  `gorm:"primaryKey"`
+	GuildID   int64  `gorm:"not null;index"`
+	ChannelID int64  `gorm:"not null;uniqueIndex:idx_github_sub_channel_repo"`
+	Repo      string `gorm:"size:200;not null;uniqueIndex:idx_github_sub_channel_repo;index"` // "owner/name", lowercase
+	CreatedBy int64
+	CreatedAt time.Time
+}
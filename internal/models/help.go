@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// HelpChannel is a channel or forum whose new posts get an answer suggested
+// from earlier answers on the server
+type HelpChannel struct {
+	ChannelID int64 `gorm:"primaryKey;autoIncrement:false"`
+	GuildID   int64 `gorm:"not null;index"`
+	AddedBy   int64 `gorm:"not null"`
+	CreatedAt time.Time
+}
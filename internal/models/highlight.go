@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// HighlightConfig is a guild's starboard: messages with enough reactions are
+// copied to a highlights channel, and the week's best are written up
+type HighlightConfig struct {
+	GuildID   int64  `gorm:"primaryKey;autoIncrement:false"`
+	ChannelID int64  `gorm:"not null"`           // Where highlights are posted
+	Threshold int    `gorm:"not null;default:5"` // Reactions a message needs
+	Emoji     string `gorm:"size:100"`           // Only this emoji counts; empty counts every reaction
+	// No GORM defaults below: false and 0 (Sunday, midnight) must be stored as is
+	WeeklyDigest  bool       `gorm:"not null"`
+	DigestWeekday int        `gorm:"not null"` // 0 = Sunday
+	DigestHour    int        `gorm:"not null"` // Local hour (0-23)
+	Timezone      string     `gorm:"size:64;not null;default:UTC"`
+	LastDigest    *time.Time // Nil until the first "best of" is posted
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// Highlight is a message copied to its guild's highlights channel
+type Highlight struct {
+	MessageID int64     `gorm:"primaryKey;autoIncrement:false"`
+	GuildID   int64     `gorm:"not null;index:idx_highlight_guild_created"`
+	ChannelID int64     `gorm:"not null"`
+	AuthorID  int64     `gorm:"not null"`
+	Content   string    `gorm:"type:text"`
+	PostID    int64     `gorm:"not null"` // The copy in the highlights channel
+	Reactions int       `gorm:"not null"`
+	CreatedAt time.Time `gorm:"index:idx_highlight_guild_created"`
+}
@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+// Incident severities, most severe first
+const (
+	IncidentSev1 = "sev1" // Critical: the service is down for most people
+	IncidentSev2 = "sev2" // Major: a core feature is broken or badly degraded
+	IncidentSev3 = "sev3" // Minor: limited impact or a workaround exists
+)
+
+// Incident statuses, in the order an incident usually goes through them
+const (
+	IncidentInvestigating = "investigating"
+	IncidentIdentified    = "identified"
+	IncidentMonitoring    = "monitoring"
+	IncidentResolved      = "resolved"
+)
+
+// Incident is an outage tracked in its own thread, with a pinned status
+// message the bot keeps up to date
+type Incident struct {
+	ID              int64  `gorm:"primaryKey"`
+	GuildID         int64  `gorm:"not null;index"`
+	ChannelID       int64  `gorm:"not null"`             // Where the status message is posted and pinned
+	ThreadID        int64  `gorm:"not null;uniqueIndex"` // Started from the status message
+	StatusMessageID int64  `gorm:"not null"`
+	Title           string `gorm:"size:100;not null"`
+	Severity        string `gorm:"size:8;not null"`
+	Status          string `gorm:"size:16;not null"`
+	StartedBy       int64  `gorm:"not null"`
+	ResolvedBy      int64
+	ResolvedAt      *time.Time
+	Postmortem      string `gorm:"type:text"` // AI draft generated on resolution
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+// IncidentUpdate is one entry of an incident's timeline
+type IncidentUpdate struct {
+	ID         int64  `gorm:"primaryKey"`
+	IncidentID int64  `gorm:"not null;index"`
+	AuthorID   int64  `gorm:"not null"`
+	Status     string `gorm:"size:16;not null"` // The incident's status after this update
+	Text       string `gorm:"type:text;not null"`
+	CreatedAt  time.Time
+}
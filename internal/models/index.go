@@ -0,0 +1,75 @@
+package models
+
+import "time"
+
+// Kinds of indexing runs
+const (
+	IndexRunPriorityBackfill = "priority-backfill"
+	IndexRunReindex          = "reindex"
+)
+
+// Statuses of an indexing run
+const (
+	IndexRunRunning   = "running"
+	IndexRunCompleted = "completed"
+	IndexRunFailed    = "failed"
+	IndexRunCancelled = "cancelled"
+)
+
+// IndexRun records a bulk indexing job such as a channel backfill
+type IndexRun struct {
+	ID         int64  `gorm:"primaryKey"`
+	GuildID    int64  `gorm:"index;not null"`
+	ChannelID  int64  `gorm:"not null"`
+	Kind       string `gorm:"size:32;not null"`
+	Status     string `gorm:"size:16;not null"`
+	Processed  int
+	Total      int    // Zero when the size isn't known up front
+	Error      string `gorm:"type:text"`
+	StartedBy  int64  // Zero for runs the bot started itself
+	StartedAt  time.Time
+	FinishedAt *time.Time
+}
+
+// Reasons the ingest filters keep a stored message from being embedded
+const (
+	FilterReasonShort     = "short"
+	FilterReasonBlocklist = "blocklist"
+	FilterReasonLinkOnly  = "link_only"
+	FilterReasonCommand   = "command"
+	FilterReasonLowValue  = "low_value" // Judged unlikely to carry information
+	FilterReasonSpam      = "spam"      // Counted only; spam isn't stored
+)
+
+// FilteredMessage records a stored message the ingest filters left without
+// an embedding, so it isn't counted as waiting for one
+type FilteredMessage struct {
+	MessageID int64  `gorm:"primaryKey;autoIncrement:false"`
+	GuildID   int64  `gorm:"index"`
+	Reason    string `gorm:"size:16;not null"`
+	CreatedAt time.Time
+}
+
+// IndexStats summarizes how much of a guild's history is searchable
+type IndexStats struct {
+	GuildID           int64            `json:"guild_id,string"`
+	Messages          int64            `json:"messages"`
+	Embeddable        int64            `json:"embeddable"` // Messages with text to embed
+	Embedded          int64            `json:"embedded"`
+	Pending           int64            `json:"pending"` // Embeddable messages still missing an embedding
+	Oldest            *time.Time       `json:"oldest,omitempty"`
+	Newest            *time.Time       `json:"newest,omitempty"`
+	EmbeddingModels   map[string]int64 `json:"embedding_models"`
+	PriorityDocuments int64            `json:"priority_documents"`
+	Filtered          int64            `json:"filtered"` // Messages with text the ingest filters kept out of retrieval
+	FilteredReasons   map[string]int64 `json:"filtered_reasons"`
+	LastRun           *IndexRun        `json:"last_run,omitempty"`
+}
+
+// Coverage is the share of embeddable messages that have an embedding, 0-100
+func (s IndexStats) Coverage() float64 {
+	if s.Embeddable == 0 {
+		return 100
+	}
+	return float64(s.Embedded) * 100 / float64(s.Embeddable)
+}
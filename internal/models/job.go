@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// Statuses of a background job
+const (
+	JobQueued    = "queued"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed" // Out of attempts
+	JobCancelled = "cancelled"
+)
+
+// Job is a unit of background work run by the worker, e.g. a digest delivery
+// or a message whose embedding failed. Finished jobs are kept for a while so
+// operators can see what ran.
+type Job struct {
+	ID          int64     `gorm:"primaryKey"`
+	Kind        string    `gorm:"size:64;not null;index:idx_job_claim,priority:1"`
+	GuildID     int64     `gorm:"index:idx_job_guild"` // Zero for instance-wide jobs
+	Status      string    `gorm:"size:16;not null;index:idx_job_claim,priority:2"`
+	Payload     string    `gorm:"type:text"` // JSON, empty for periodic jobs; encrypted with ENCRYPT_MESSAGES
+	Attempts    int       `gorm:"not null"`
+	MaxAttempts int       `gorm:"not null"`
+	RunAt       time.Time `gorm:"not null;index:idx_job_claim,priority:3"` // Not before; pushed back between attempts
+	LockedBy    string    `gorm:"size:128"`                                // Worker running the job
+	LastError   string    `gorm:"type:text"`
+	StartedAt   *time.Time
+	FinishedAt  *time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// JobStats counts jobs of a kind by status
+type JobStats struct {
+	Kind   string `json:"kind"`
+	Status string `json:"status"`
+	Count  int64  `json:"count"`
+}
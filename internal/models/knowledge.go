@@ -0,0 +1,66 @@
+package models
+
+import (
+	"time"
+
+	"discord-tars/internal/pgvector"
+
+	"github.com/lib/pq"
+)
+
+// Knowledge connector providers
+const (
+	KnowledgeNotion     = "notion"
+	KnowledgeConfluence = "confluence"
+)
+
+// KnowledgeSource is an external documentation space synced into the document index
+type KnowledgeSource struct {
+	ID           int64          `gorm:"primaryKey"`
+	GuildID      int64          `gorm:"not null;index"`
+	Provider     string         `gorm:"size:16;not null"`
+	Name         string         `gorm:"size:100;not null"`
+	BaseURL      string         `gorm:"type:text"` // Confluence base URL, e.g. https://acme.atlassian.net/wiki
+	Email        string         `gorm:"size:255"`  // Confluence account email
+	APIToken     string         `gorm:"type:text;not null"`
+	SpaceKey     string         `gorm:"size:100"`    // Confluence space to sync in full
+	PageIDs      pq.StringArray `gorm:"type:text[]"` // Selected pages
+	LastSyncedAt *time.Time
+	LastError    string `gorm:"type:text"`
+	CreatedBy    int64
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// KnowledgeDocument is a synced page
+type KnowledgeDocument struct {
+	ID              int64  `gorm:"primaryKey"`
+	SourceID        int64  `gorm:"not null;uniqueIndex:idx_knowledge_doc_external"`
+	GuildID         int64  `gorm:"not null;index"`
+	ExternalID      string `gorm:"size:255;not null;uniqueIndex:idx_knowledge_doc_external"`
+	Title           string `gorm:"type:text"`
+	URL             string `gorm:"type:text"`
+	ContentHash     string `gorm:"size:64"` // sha256 of the page text, used to skip re-embedding
+	SourceUpdatedAt time.Time
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+// KnowledgeChunk is an embedded slice of a document
+type KnowledgeChunk struct {
+	ID         int64           `gorm:"primaryKey"`
+	DocumentID int64           `gorm:"not null;index"`
+	GuildID    int64           `gorm:"not null;index"`
+	ChunkIndex int             `gorm:"not null"`
+	Content    string          `gorm:"type:text;not null"`
+	Embedding  pgvector.Vector `gorm:"type:vector(1536)"`
+	CreatedAt  time.Time
+}
+
+// KnowledgeResult is a document chunk matched by vector search
+type KnowledgeResult struct {
+	Chunk      KnowledgeChunk
+	Title      string
+	URL        string
+	Similarity float64
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// MessageLanguage is the language an indexed message is written in, detected
+// when it is embedded. Messages too short to tell have none.
+type MessageLanguage struct {
+	MessageID int64  `gorm:"primaryKey;autoIncrement:false"`
+	GuildID   int64  `gorm:"index"`
+	Language  string `gorm:"size:8;not null"` // ISO 639-1 code
+}
+
+// GuildLanguages restricts a guild's retrieval to messages in some languages
+type GuildLanguages struct {
+	GuildID   int64          `gorm:"primaryKey;autoIncrement:false"`
+	Languages pq.StringArray `gorm:"type:text[];not null"` // ISO 639-1 codes
+	UpdatedBy int64          `gorm:"not null"`
+	UpdatedAt time.Time
+}
+
+// LanguageShare is how many of a guild's indexed messages are in a language
+type LanguageShare struct {
+	Language string `json:"language"`
+	Messages int64  `json:"messages"`
+}
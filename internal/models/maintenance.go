@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// What started a maintenance run
+const (
+	MaintenanceScheduled = "scheduled"
+	MaintenanceManual    = "manual"
+)
+
+// VectorIndexHealth describes a pgvector index and the table it indexes
+type VectorIndexHealth struct {
+	Name       string `json:"name"`
+	Table      string `json:"table"`
+	Method     string `json:"method"` // ivfflat or hnsw
+	Options    string `json:"options,omitempty"`
+	SizeBytes  int64  `json:"size_bytes"`
+	Rows       int64  `json:"rows"`
+	DeadRows   int64  `json:"dead_rows"`
+	Dimensions int    `json:"dimensions"`
+	// Lists is the number of clusters of an ivfflat index, and
+	// RecommendedLists the number suiting the table's size now
+	Lists            int `json:"lists,omitempty"`
+	RecommendedLists int `json:"recommended_lists,omitempty"`
+	// BloatPercent estimates the share of the index that is wasted space
+	BloatPercent float64    `json:"bloat_percent"`
+	LastAnalyzed *time.Time `json:"last_analyzed,omitempty"`
+}
+
+// MaintenanceRun records a pass of maintenance over the vector indexes: the
+// tables analyzed, the indexes rebuilt and the state they were found in
+type MaintenanceRun struct {
+	ID         int64               `gorm:"primaryKey" json:"id"`
+	Trigger    string              `gorm:"size:16;not null" json:"trigger"`
+	Status     string              `gorm:"size:16;not null" json:"status"` // IndexRun statuses
+	Analyzed   pq.StringArray      `gorm:"type:text[]" json:"analyzed"`
+	Reindexed  pq.StringArray      `gorm:"type:text[]" json:"reindexed"` // "<index>: <why>"
+	Indexes    []VectorIndexHealth `gorm:"type:text;serializer:json" json:"indexes"`
+	Error      string              `gorm:"type:text" json:"error,omitempty"`
+	StartedAt  time.Time           `gorm:"index" json:"started_at"`
+	FinishedAt *time.Time          `json:"finished_at,omitempty"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// ConversationMemory is a channel's chat history with the bot: a rolling
+// summary of older exchanges plus the most recent ones verbatim
+type ConversationMemory struct {
+	ChannelID       int64  `gorm:"primaryKey;autoIncrement:false"`
+	GuildID         int64  `gorm:"index"`
+	Summary         string `gorm:"type:text"`
+	Turns           string `gorm:"type:text"` // JSON array of MemoryTurn, oldest first
+	SummarizedTurns int    // How many exchanges the summary covers
+	UpdatedAt       time.Time
+}
+
+// MemoryTurn is one exchange between a member and the bot
+type MemoryTurn struct {
+	Username string    `json:"username"`
+	Question string    `json:"question"`
+	Answer   string    `json:"answer"`
+	At       time.Time `json:"at"`
+}
@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// MessageSentiment is how positive (1) or negative (-1) a message reads
+type MessageSentiment struct {
+	MessageID int64     `gorm:"primaryKey;autoIncrement:false"`
+	GuildID   int64     `gorm:"not null;index"`
+	ChannelID int64     `gorm:"not null;index:idx_message_sentiment_channel_day"`
+	Day       time.Time `gorm:"type:date;not null;index:idx_message_sentiment_channel_day"` // UTC day posted
+	Score     float64   `gorm:"not null"`
+	CreatedAt time.Time
+}
+
+// ChannelMood aggregates a channel's message sentiment over a UTC day
+type ChannelMood struct {
+	ID          int64     `gorm:"primaryKey"`
+	GuildID     int64     `gorm:"not null;index"`
+	ChannelID   int64     `gorm:"not null;uniqueIndex:idx_channel_mood_day"`
+	Day         time.Time `gorm:"type:date;not null;uniqueIndex:idx_channel_mood_day"`
+	ChannelName string    `gorm:"size:255"`
+	Messages    int       `gorm:"not null"` // Messages scored
+	Average     float64   `gorm:"not null"` // Mean score, from -1 to 1
+	Negative    int       `gorm:"not null"` // Messages scoring below -NeutralBand
+	Positive    int       `gorm:"not null"` // Messages scoring above NeutralBand
+	CreatedAt   time.Time
+}
+
+// ToxicityConfig is a guild's setup for early warnings about escalating
+// arguments
+type ToxicityConfig struct {
+	GuildID        int64   `gorm:"primaryKey;autoIncrement:false"`
+	Enabled        bool    `gorm:"not null;default:true"`
+	AlertChannelID int64   `gorm:"not null"` // Where warnings are posted
+	ModRoleID      int64   `gorm:"not null"` // Pinged with warnings, outside quiet hours
+	Threshold      float64 `gorm:"not null;default:0.6"`
+	QuietStart     int     `gorm:"not null;default:0"` // No pings from QuietStart to QuietEnd; equal hours mean none
+	QuietEnd       int     `gorm:"not null;default:0"`
+	Timezone       string  `gorm:"size:64;not null;default:UTC"`
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"discord-tars/internal/pgvector"
+)
+
+// Note is a snippet saved to a notebook: a member's personal one, or a
+// channel's shared one. Notes are embedded for semantic recall, apart from the
+// chat index.
+type Note struct {
+	ID        int64           `gorm:"primaryKey"`
+	GuildID   int64           `gorm:"index"`          // Where it was written; 0 in DMs
+	ChannelID int64           `gorm:"not null;index"` // The channel notebook it belongs to; 0 for personal notes
+	UserID    int64           `gorm:"not null;index"` // Author, and owner of personal notes
+	Content   string          `gorm:"type:text;not null"`
+	Embedding pgvector.Vector `gorm:"type:vector(1536)"`
+	CreatedAt time.Time
+}
+
+// NoteResult is a note matched by vector search
+type NoteResult struct {
+	Note       Note
+	Similarity float64
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Onboarding delivery modes
+const (
+	OnboardingModeDM      = "dm"
+	OnboardingModeChannel = "channel"
+)
+
+// OnboardingConfig is a guild's welcome/onboarding setup
+type OnboardingConfig struct {
+	GuildID             int64          `gorm:"primaryKey;autoIncrement:false"`
+	Enabled             bool           `gorm:"not null;default:false"`
+	Mode                string         `gorm:"size:16;not null;default:dm"`
+	WelcomeChannelID    int64          // Used when Mode is "channel"
+	KnowledgeChannelIDs pq.StringArray `gorm:"type:text[]"` // Rules/FAQ channels answers are grounded in
+	Greeting            string         `gorm:"type:text"`   // Optional admin-written intro included in the welcome
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+}
+
+// OnboardingMember tracks a new member's onboarding conversation
+type OnboardingMember struct {
+	ID             int64 `gorm:"primaryKey"`
+	GuildID        int64 `gorm:"not null;uniqueIndex:idx_onboarding_member"`
+	UserID         int64 `gorm:"not null;uniqueIndex:idx_onboarding_member;index"`
+	QuestionsAsked int   `gorm:"not null;default:0"`
+	JoinedAt       time.Time
+	CompletedAt    *time.Time
+}
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// Statuses of an outbox message
+const (
+	OutboxPending = "pending"
+	OutboxSent    = "sent"
+	OutboxFailed  = "failed" // Gave up; kept for operators to review and retry
+)
+
+// OutboxMessage is a Discord message the bot has committed to send, such as a
+// digest, a reminder or an alert. It is stored before the send, so a crash
+// mid-send delays it instead of losing it; delivery is at least once.
+type OutboxMessage struct {
+	ID            int64     `gorm:"primaryKey"`
+	GuildID       int64     `gorm:"index"`
+	Kind          string    `gorm:"size:32;not null"` // What sent it, e.g. "digest"
+	ChannelID     int64     // Zero for a DM
+	UserID        int64     // DM recipient
+	Payload       string    `gorm:"type:text;not null"` // discordgo.MessageSend as JSON; encrypted with ENCRYPT_MESSAGES
+	Status        string    `gorm:"size:16;not null;index:idx_outbox_due,priority:1"`
+	Attempts      int       `gorm:"not null"`
+	NextAttemptAt time.Time `gorm:"not null;index:idx_outbox_due,priority:2"` // Pushed forward while a send is in flight
+	LastError     string    `gorm:"type:text"`
+	MessageID     int64     // Set once sent
+	SentAt        *time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// GuildPartition records a guild's partition of the messages table and, once
+// the bot has left the guild, when it left
+type GuildPartition struct {
+	GuildID   int64      `gorm:"primaryKey;autoIncrement:false" json:"guild_id"`
+	Partition string     `gorm:"size:64;not null" json:"partition"`
+	CreatedAt time.Time  `json:"created_at"`
+	LeftAt    *time.Time `gorm:"index" json:"left_at,omitempty"`
+}
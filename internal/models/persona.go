@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// GuildPersona is the persona a guild imported to use instead of T.A.R.S
+type GuildPersona struct {
+	GuildID    int64  `gorm:"primaryKey;autoIncrement:false"`
+	Definition string `gorm:"type:text;not null"` // persona.Definition as JSON
+	ImportedBy int64  `gorm:"not null"`
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// PersonaMode adjusts a guild's persona while its schedule is active, such as
+// a spooky mode during October or a formal one during work hours
+type PersonaMode struct {
+	ID           int64  `gorm:"primaryKey"`
+	GuildID      int64  `gorm:"not null;uniqueIndex:idx_persona_modes_guild_name"`
+	Name         string `gorm:"size:50;not null;uniqueIndex:idx_persona_modes_guild_name"`
+	Instructions string `gorm:"type:text;not null"` // Added to the persona's prompt while active
+	Months       int    `gorm:"not null;default:0"` // Bit n-1 set for month n; 0 means every month
+	Weekdays     int    `gorm:"not null;default:0"` // Bit n set for time.Weekday n; 0 means every day
+	StartHour    int    `gorm:"not null;default:0"` // Active from StartHour to EndHour; equal hours mean all day
+	EndHour      int    `gorm:"not null;default:0"`
+	Timezone     string `gorm:"size:64;not null;default:UTC"`
+	CreatedBy    int64  `gorm:"not null"`
+	CreatedAt    time.Time
+}
+
+// GuildVerbosity is how long a guild wants answers by default
+type GuildVerbosity struct {
+	GuildID   int64  `gorm:"primaryKey;autoIncrement:false"`
+	Verbosity string `gorm:"size:10;not null"` // persona.Verbosity
+	SetBy     int64  `gorm:"not null"`
+	UpdatedAt time.Time
+}
+
+// GuildStyle is whether a guild wants playful answers, using its emojis, or
+// plain ones
+type GuildStyle struct {
+	GuildID   int64  `gorm:"primaryKey;autoIncrement:false"`
+	Style     string `gorm:"size:10;not null"` // persona.Style
+	SetBy     int64  `gorm:"not null"`
+	UpdatedAt time.Time
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Poll is a button-based poll posted by the bot
+type Poll struct {
+	ID        int64          `gorm:"primaryKey"`
+	GuildID   int64          `gorm:"not null;index"`
+	ChannelID int64          `gorm:"not null"`
+	MessageID int64          `gorm:"index"` // Set once the poll message is posted
+	CreatedBy int64          `gorm:"not null"`
+	Question  string         `gorm:"type:text;not null"`
+	Options   pq.StringArray `gorm:"type:text[];not null"`
+	ClosesAt  *time.Time     `gorm:"index"`
+	ClosedAt  *time.Time
+	Summary   string `gorm:"type:text"` // AI analysis generated on close
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// PollVote is a user's single choice in a poll
+type PollVote struct {
+	ID        int64 `gorm:"primaryKey"`
+	PollID    int64 `gorm:"not null;uniqueIndex:idx_poll_vote_user"`
+	UserID    int64 `gorm:"not null;uniqueIndex:idx_poll_vote_user"`
+	Option    int   `gorm:"not null"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+
+	"discord-tars/internal/pgvector"
+)
+
+// Priority document sources
+const (
+	PrioritySourcePin     = "pin"
+	PrioritySourceChannel = "channel"
+)
+
+// PriorityLabelSessionNotes labels a channel where a tabletop campaign's
+// session recaps are posted; they answer lore questions, not general ones
+const PriorityLabelSessionNotes = "session-notes"
+
+// PriorityChannel is a channel (e.g. #rules, #announcements) whose messages are
+// indexed as priority context
+type PriorityChannel struct {
+	ID        int64  `gorm:"primaryKey"`
+	GuildID   int64  `gorm:"not null;index"`
+	ChannelID int64  `gorm:"not null;uniqueIndex"`
+	Label     string `gorm:"size:50"`
+	CreatedAt time.Time
+}
+
+// PriorityDocument is a pinned or official message kept in the high-priority collection
+type PriorityDocument struct {
+	ID          int64           `gorm:"primaryKey"`
+	GuildID     int64           `gorm:"not null;index"`
+	ChannelID   int64           `gorm:"not null;index"`
+	MessageID   int64           `gorm:"not null;uniqueIndex"`
+	ChannelName string          `gorm:"size:255"`
+	AuthorName  string          `gorm:"size:255"`
+	Source      string          `gorm:"size:16;not null"`
+	Content     string          `gorm:"type:text;not null"`
+	Embedding   pgvector.Vector `gorm:"type:vector(1536)"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// PriorityResult is a priority document matched by vector search
+type PriorityResult struct {
+	Document   PriorityDocument
+	Similarity float64
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// AskedQuestion counts how often a question was asked in a guild, so popular
+// ones can be suggested while typing /ask
+type AskedQuestion struct {
+	ID       int64  `gorm:"primaryKey"`
+	GuildID  int64  `gorm:"not null;uniqueIndex:idx_asked_questions_guild_hash"`
+	Hash     string `gorm:"size:64;not null;uniqueIndex:idx_asked_questions_guild_hash"` // SHA-256 of the normalized question, since the text may be encrypted
+	Question string `gorm:"type:text;not null"`
+	Count    int    `gorm:"not null;default:1"`
+	// LastAskedAt keeps suggestions to questions that are still being asked
+	LastAskedAt time.Time `gorm:"not null;index"`
+	CreatedAt   time.Time
+}
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Quiz sources
+const (
+	QuizSourceGeneral = "general"
+	QuizSourceServer  = "server"
+)
+
+// Quiz is a set of multiple-choice questions posted by /quiz
+type Quiz struct {
+	ID        int64     `gorm:"primaryKey"`
+	GuildID   int64     `gorm:"not null;index"`
+	ChannelID int64     `gorm:"not null"`
+	CreatedBy int64     `gorm:"not null"`
+	Topic     string    `gorm:"size:200;not null"`
+	Source    string    `gorm:"size:16;not null"`
+	ClosesAt  time.Time `gorm:"not null;index"`
+	ClosedAt  *time.Time
+	CreatedAt time.Time
+}
+
+// QuizQuestion is one question of a quiz, posted as its own message
+type QuizQuestion struct {
+	ID          int64          `gorm:"primaryKey"`
+	QuizID      int64          `gorm:"not null;index"`
+	Position    int            `gorm:"not null"`
+	MessageID   int64          // Set once the question is posted
+	Question    string         `gorm:"type:text;not null"`
+	Choices     pq.StringArray `gorm:"type:text[];not null"`
+	Answer      int            `gorm:"not null"` // Index of the correct choice
+	Explanation string         `gorm:"type:text"`
+}
+
+// QuizAnswer is a user's only answer to a quiz question
+type QuizAnswer struct {
+	ID         int64 `gorm:"primaryKey"`
+	QuestionID int64 `gorm:"not null;uniqueIndex:idx_quiz_answer_user"`
+	UserID     int64 `gorm:"not null;uniqueIndex:idx_quiz_answer_user"`
+	GuildID    int64 `gorm:"not null;index"`
+	Choice     int   `gorm:"not null"`
+	Correct    bool  `gorm:"not null"`
+	CreatedAt  time.Time
+}
+
+// QuizScore is a user's record on a guild's quizzes
+type QuizScore struct {
+	UserID   int64
+	Correct  int
+	Answered int
+}
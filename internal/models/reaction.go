@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// MessageReaction is how many times an emoji was added to an indexed message
+type MessageReaction struct {
+	MessageID int64  `gorm:"primaryKey;autoIncrement:false"`
+	Emoji     string `gorm:"primaryKey;size:100"` // Unicode emoji, or name:id for custom ones
+	GuildID   int64  `gorm:"index;not null"`
+	Count     int    `gorm:"not null;default:0"`
+	UpdatedAt time.Time
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// Statuses of a rollout
+const (
+	RolloutCanary     = "canary"      // Applies to Percent of the guilds
+	RolloutPromoted   = "promoted"    // Applies to every guild
+	RolloutRolledBack = "rolled_back" // Quality regressed on the canary guilds; applies to none
+	RolloutStopped    = "stopped"     // Ended by an operator; applies to none
+)
+
+// Rollout is a change to the default answer settings, tried on a cohort of
+// guilds before every guild gets it. Empty settings keep the defaults.
+type Rollout struct {
+	ID                  int64   `gorm:"primaryKey"`
+	Name                string  `gorm:"size:100;not null"` // e.g. the prompt version it ships
+	Model               string  `gorm:"size:100"`
+	ConfidenceThreshold float64 `gorm:"not null;default:0"`
+	Instructions        string  `gorm:"type:text"` // Added to the system prompt
+	Percent             int     `gorm:"not null"`  // Share of guilds in the canary cohort
+	Status              string  `gorm:"size:16;not null;index"`
+	Reason              string  `gorm:"type:text"` // Why it was rolled back
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+}
@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// SandboxGuild is a guild where the bot answers as usual but, instead of
+// replying publicly, only logs its replies and posts them to a debug channel
+type SandboxGuild struct {
+	GuildID   int64 `gorm:"primaryKey;autoIncrement:false"`
+	ChannelID int64 `gorm:"not null;default:0"` // Debug channel; 0 logs replies only
+	EnabledBy int64 `gorm:"not null"`
+	CreatedAt time.Time
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// StandupTeam is the standup configuration of a channel
+type StandupTeam struct {
+	ID        int64          `gorm:"primaryKey"`
+	GuildID   int64          `gorm:"not null;index"`
+	ChannelID int64          `gorm:"not null;uniqueIndex"`
+	Name      string         `gorm:"size:100;not null"`
+	MemberIDs pq.StringArray `gorm:"type:text[]"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// StandupSession is one collection window for a team's updates
+type StandupSession struct {
+	ID        int64       `gorm:"primaryKey"`
+	TeamID    int64       `gorm:"not null;index"`
+	StartedBy int64       `gorm:"not null"`
+	StartedAt time.Time   `gorm:"not null"`
+	EndsAt    time.Time   `gorm:"not null;index"`
+	NaggedAt  *time.Time  // Set once missing members have been reminded
+	ClosedAt  *time.Time  // Set when the summary has been posted
+	Team      StandupTeam `gorm:"foreignKey:TeamID"`
+}
+
+// StandupUpdate is a member's answer within a session
+type StandupUpdate struct {
+	ID        int64  `gorm:"primaryKey"`
+	SessionID int64  `gorm:"not null;uniqueIndex:idx_standup_update_user"`
+	UserID    int64  `gorm:"not null;uniqueIndex:idx_standup_update_user"`
+	Username  string `gorm:"size:255"`
+	Content   string `gorm:"type:text;not null"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+
+	"discord-tars/internal/pgvector"
+)
+
+// Conversation starter kinds
+const (
+	StarterKindStarter  = "starter"  // An open prompt to get people talking
+	StarterKindQuestion = "question" // A "question of the day"
+)
+
+// Conversation starter frequencies
+const (
+	StarterDaily    = "daily"
+	StarterWeekdays = "weekdays" // Monday to Friday
+	StarterWeekly   = "weekly"
+)
+
+// StarterSchedule posts AI-written conversation starters in a channel
+type StarterSchedule struct {
+	ID        int64  `gorm:"primaryKey"`
+	GuildID   int64  `gorm:"not null;index"`
+	ChannelID int64  `gorm:"not null;uniqueIndex"` // One schedule per channel
+	Kind      string `gorm:"size:16;not null"`
+	Topics    string `gorm:"size:300"` // Hints on what to ask about; empty lets the AI choose
+	Frequency string `gorm:"size:16;not null"`
+	// No GORM defaults below: 0 (Sunday, midnight) must be stored as is
+	Weekday      int        `gorm:"not null"` // Weekly schedules only; 0 = Sunday
+	Hour         int        `gorm:"not null"` // Local hour (0-23)
+	Timezone     string     `gorm:"size:64;not null;default:UTC"`
+	CreatedBy    int64      `gorm:"not null"`
+	LastPostedAt *time.Time // Nil until the first post
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// StarterPost is a starter that was posted, remembered so it isn't asked again
+type StarterPost struct {
+	ID         int64            `gorm:"primaryKey"`
+	ScheduleID int64            `gorm:"not null;index"`
+	GuildID    int64            `gorm:"not null;index:idx_starter_post_guild_created"`
+	ChannelID  int64            `gorm:"not null"`
+	Text       string           `gorm:"type:text;not null"`
+	Embedding  *pgvector.Vector `gorm:"type:vector(1536)"` // Nil if it couldn't be embedded
+	CreatedAt  time.Time        `gorm:"index:idx_starter_post_guild_created"`
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"discord-tars/internal/pgvector"
+)
+
+// ChannelSummary is a channel's day condensed into a short document, embedded
+// so questions about a period can be answered without replaying every message
+type ChannelSummary struct {
+	ID           int64           `gorm:"primaryKey"`
+	GuildID      int64           `gorm:"not null;index"`
+	ChannelID    int64           `gorm:"not null;uniqueIndex:idx_channel_summary_day"`
+	Day          time.Time       `gorm:"type:date;not null;uniqueIndex:idx_channel_summary_day"` // UTC day summarized
+	ChannelName  string          `gorm:"size:255"`
+	MessageCount int             `gorm:"not null"`
+	Content      string          `gorm:"type:text;not null"`
+	Embedding    pgvector.Vector `gorm:"type:vector(1536)"`
+	CreatedAt    time.Time
+}
+
+// SummaryResult is a channel summary matched by vector search
+type SummaryResult struct {
+	Summary    ChannelSummary
+	Similarity float64
+}
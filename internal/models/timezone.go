@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// UserTimezone is the timezone a member reads and writes times in, across
+// guilds
+type UserTimezone struct {
+	UserID    int64  `gorm:"primaryKey;autoIncrement:false"`
+	Timezone  string `gorm:"size:64;not null"` // IANA name or UTC offset
+	UpdatedAt time.Time
+}
+
+// GuildTimezone is a guild's timezone, for members who haven't set theirs
+type GuildTimezone struct {
+	GuildID   int64  `gorm:"primaryKey;autoIncrement:false"`
+	Timezone  string `gorm:"size:64;not null"` // IANA name or UTC offset
+	SetBy     int64  `gorm:"not null"`
+	UpdatedAt time.Time
+}
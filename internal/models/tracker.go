@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Issue tracker providers
+const (
+	TrackerJira   = "jira"
+	TrackerLinear = "linear"
+)
+
+// TrackerConfig holds a guild's issue tracker connection
+type TrackerConfig struct {
+	GuildID     int64          `gorm:"primaryKey;autoIncrement:false"`
+	Provider    string         `gorm:"size:16;not null"`
+	BaseURL     string         `gorm:"type:text"` // Jira site URL, e.g. https://acme.atlassian.net
+	Email       string         `gorm:"size:255"`  // Jira account email used with the API token
+	APIToken    string         `gorm:"type:text;not null"`
+	ProjectKeys pq.StringArray `gorm:"type:text[]"` // Optional allow-list of project/team keys
+	CreatedBy   int64
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// XPConfig turns on activity XP for a guild and sets what each activity is
+// worth
+type XPConfig struct {
+	GuildID int64 `gorm:"primaryKey;autoIncrement:false"`
+	// No GORM defaults below: 0 XP and an announcement-free guild must be
+	// stored as is
+	MessageXP       int   `gorm:"not null"` // Per message, at most once per cooldown
+	ReactionXP      int   `gorm:"not null"` // Per reaction a member's messages receive
+	CooldownSeconds int   `gorm:"not null"` // Messages closer together earn XP once
+	Announce        bool  `gorm:"not null"` // Post milestone level-ups
+	ChannelID       int64 // Where level-ups are posted; zero posts where they happened
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+// MemberXP is a member's XP in a guild
+type MemberXP struct {
+	GuildID       int64 `gorm:"primaryKey;autoIncrement:false;index:idx_member_xp_guild_xp,priority:1"`
+	UserID        int64 `gorm:"primaryKey;autoIncrement:false"`
+	XP            int64 `gorm:"not null;default:0;index:idx_member_xp_guild_xp,priority:2,sort:desc"`
+	Messages      int   `gorm:"not null;default:0"` // Messages that earned XP
+	Reactions     int   `gorm:"not null;default:0"` // Reactions received
+	LastMessageAt *time.Time
+	UpdatedAt     time.Time
+}
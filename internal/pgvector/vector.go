@@ -0,0 +1,119 @@
+// Package pgvector binds embeddings to queries as pgvector values. A Vector
+// is always sent as a query parameter, never spliced into SQL, and refuses
+// values pgvector can't store, such as NaN, before the query runs.
+package pgvector
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrEmpty     = errors.New("vector has no dimensions")
+	ErrNotFinite = errors.New("vector values must be finite")
+)
+
+// Vector is a pgvector vector column or parameter
+type Vector struct {
+	values []float32
+}
+
+func NewVector(values []float32) Vector {
+	return Vector{values: values}
+}
+
+// Slice returns the vector's values
+func (v Vector) Slice() []float32 {
+	return v.values
+}
+
+// Validate checks pgvector would accept the vector
+func (v Vector) Validate() error {
+	if len(v.values) == 0 {
+		return ErrEmpty
+	}
+	for n, value := range v.values {
+		if math.IsNaN(float64(value)) || math.IsInf(float64(value), 0) {
+			return fmt.Errorf("%w: %v at index %d", ErrNotFinite, value, n)
+		}
+	}
+	return nil
+}
+
+// String returns pgvector's "[x,y,...]" text form
+func (v Vector) String() string {
+	buf := make([]byte, 0, 2+len(v.values)*12)
+	buf = append(buf, '[')
+	for n, value := range v.values {
+		if n > 0 {
+			buf = append(buf, ',')
+		}
+		buf = strconv.AppendFloat(buf, float64(value), 'g', -1, 32)
+	}
+	return string(append(buf, ']'))
+}
+
+// Parse reads pgvector's text form
+func Parse(text string) (Vector, error) {
+	text = strings.TrimSpace(text)
+	if len(text) < 2 || text[0] != '[' || text[len(text)-1] != ']' {
+		return Vector{}, fmt.Errorf("malformed vector %q", truncate(text))
+	}
+	text = text[1 : len(text)-1]
+	if strings.TrimSpace(text) == "" {
+		return Vector{}, nil
+	}
+	parts := strings.Split(text, ",")
+	values := make([]float32, len(parts))
+	for n, part := range parts {
+		value, err := strconv.ParseFloat(strings.TrimSpace(part), 32)
+		if err != nil {
+			return Vector{}, fmt.Errorf("malformed vector value %q", truncate(part))
+		}
+		values[n] = float32(value)
+	}
+	return Vector{values: values}, nil
+}
+
+// Value sends the vector as a text parameter, which Postgres casts to the
+// vector column or $n::vector it is bound to
+func (v Vector) Value() (driver.Value, error) {
+	if err := v.Validate(); err != nil {
+		return nil, err
+	}
+	return v.String(), nil
+}
+
+// Scan reads a vector column
+func (v *Vector) Scan(src interface{}) error {
+	var text string
+	switch src := src.(type) {
+	case nil:
+		*v = Vector{}
+		return nil
+	case string:
+		text = src
+	case []byte:
+		text = string(src)
+	default:
+		return fmt.Errorf("cannot scan %T into a vector", src)
+	}
+	parsed, err := Parse(text)
+	if err != nil {
+		return err
+	}
+	*v = parsed
+	return nil
+}
+
+// truncate shortens malformed input quoted in errors
+func truncate(text string) string {
+	if len(text) > 32 {
+		return text[:32] + "..."
+	}
+	return text
+}
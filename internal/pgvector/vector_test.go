@@ -0,0 +1,107 @@
+package pgvector
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"testing"
+)
+
+// vectorFrom reads fuzz input as little-endian float32 values
+func vectorFrom(data []byte) Vector {
+	values := make([]float32, len(data)/4)
+	for n := range values {
+		values[n] = math.Float32frombits(binary.LittleEndian.Uint32(data[n*4:]))
+	}
+	return NewVector(values)
+}
+
+func bytesOf(values ...float32) []byte {
+	data := make([]byte, 0, len(values)*4)
+	for _, value := range values {
+		data = binary.LittleEndian.AppendUint32(data, math.Float32bits(value))
+	}
+	return data
+}
+
+func FuzzParse(f *testing.F) {
+	for _, seed := range []string{"[]", "[1,2,3]", "[ -0.5 , 1e-7 ]", "[3.4028235e+38]", "[1,,2]", "[NaN]", "1,2", "[", "]", ""} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, text string) {
+		v, err := Parse(text)
+		if err != nil {
+			return
+		}
+		if v.Validate() != nil {
+			return
+		}
+		// Vectors pgvector accepts read back as they were written
+		again, err := Parse(v.String())
+		if err != nil {
+			t.Fatalf("Parse(%q) failed on its own output: %v", v.String(), err)
+		}
+		if len(again.Slice()) != len(v.Slice()) {
+			t.Fatalf("Parse(%q) has %d values, want %d", v.String(), len(again.Slice()), len(v.Slice()))
+		}
+		for n, value := range v.Slice() {
+			if math.Float32bits(again.Slice()[n]) != math.Float32bits(value) {
+				t.Fatalf("value %d of %q read back as %v, want %v", n, v.String(), again.Slice()[n], value)
+			}
+		}
+	})
+}
+
+func FuzzRoundTrip(f *testing.F) {
+	f.Add(bytesOf(1, 2, 3))
+	f.Add(bytesOf(-0, math.SmallestNonzeroFloat32, math.MaxFloat32))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		v := vectorFrom(data)
+		if v.Validate() != nil {
+			return
+		}
+		parsed, err := Parse(v.String())
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", v.String(), err)
+		}
+		for n, value := range v.Slice() {
+			if math.Float32bits(parsed.Slice()[n]) != math.Float32bits(value) {
+				t.Fatalf("value %d of %q read back as %v, want %v", n, v.String(), parsed.Slice()[n], value)
+			}
+		}
+	})
+}
+
+func FuzzValidate(f *testing.F) {
+	f.Add([]byte{})
+	f.Add(bytesOf(0.25, -1))
+	f.Add(bytesOf(1, float32(math.NaN())))
+	f.Add(bytesOf(float32(math.Inf(1))))
+	f.Add(bytesOf(2, float32(math.Inf(-1)), 3))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		v := vectorFrom(data)
+		var want error
+		if len(v.Slice()) == 0 {
+			want = ErrEmpty
+		}
+		for _, value := range v.Slice() {
+			if math.IsNaN(float64(value)) || math.IsInf(float64(value), 0) {
+				want = ErrNotFinite
+				break
+			}
+		}
+
+		err := v.Validate()
+		if !errors.Is(err, want) || (want == nil) != (err == nil) {
+			t.Fatalf("Validate(%v) = %v, want %v", v.Slice(), err, want)
+		}
+		// Search queries bind vectors through Value, which must refuse them too
+		value, err := v.Value()
+		if want != nil && (err == nil || value != nil) {
+			t.Fatalf("Value(%v) = %v, %v; want an error", v.Slice(), value, err)
+		}
+		if want == nil && err != nil {
+			t.Fatalf("Value(%v) failed: %v", v.Slice(), err)
+		}
+	})
+}
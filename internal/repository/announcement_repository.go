@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/repository/postgres"
+
+	"gorm.io/gorm"
+)
+
+type AnnouncementRepository struct {
+	db *postgres.GormDB
+}
+
+func NewAnnouncementRepository(db *postgres.GormDB) *AnnouncementRepository {
+	return &AnnouncementRepository{db: db}
+}
+
+// SaveConfig creates or replaces a guild's announcement configuration
+func (r *AnnouncementRepository) SaveConfig(ctx context.Context, cfg *models.AnnouncementConfig) error {
+	if err := r.db.WithContext(ctx).Save(cfg).Error; err != nil {
+		log.Printf("❌ Failed to save announcement config: %v", err)
+		return fmt.Errorf("failed to save announcement config: %w", err)
+	}
+	return nil
+}
+
+// GetConfig returns a guild's announcement configuration, or nil if none
+func (r *AnnouncementRepository) GetConfig(ctx context.Context, guildID int64) (*models.AnnouncementConfig, error) {
+	var cfg models.AnnouncementConfig
+	err := r.db.WithContext(ctx).Where("guild_id = ?", guildID).First(&cfg).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get announcement config: %w", err)
+	}
+	return &cfg, nil
+}
+
+func (r *AnnouncementRepository) CreateDraft(ctx context.Context, draft *models.AnnouncementDraft) error {
+	if err := r.db.WithContext(ctx).Create(draft).Error; err != nil {
+		log.Printf("❌ Failed to create announcement draft: %v", err)
+		return fmt.Errorf("failed to create announcement draft: %w", err)
+	}
+	return nil
+}
+
+// GetDraft returns a draft, or nil if not found
+func (r *AnnouncementRepository) GetDraft(ctx context.Context, id int64) (*models.AnnouncementDraft, error) {
+	var draft models.AnnouncementDraft
+	err := r.db.WithContext(ctx).First(&draft, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get announcement draft: %w", err)
+	}
+	return &draft, nil
+}
+
+// UpdatePendingContent replaces the text of a draft still pending, reporting
+// whether it was
+func (r *AnnouncementRepository) UpdatePendingContent(ctx context.Context, id int64, content string) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.AnnouncementDraft{}).
+		Where("id = ? AND status = ?", id, models.AnnouncementPending).
+		Update("content", content)
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to update announcement draft: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// Review moves a draft from one status to another, reporting whether it was
+// in the expected status. Moving from pending claims the draft, so a double
+// click can't post it twice.
+func (r *AnnouncementRepository) Review(ctx context.Context, id int64, from, to string, reviewedBy int64) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.AnnouncementDraft{}).
+		Where("id = ? AND status = ?", id, from).
+		Updates(map[string]interface{}{"status": to, "reviewed_by": reviewedBy})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to review announcement draft: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// SetMessageID records the message an approved draft was posted as
+func (r *AnnouncementRepository) SetMessageID(ctx context.Context, id, messageID int64) error {
+	err := r.db.WithContext(ctx).Model(&models.AnnouncementDraft{}).Where("id = ?", id).Update("message_id", messageID).Error
+	if err != nil {
+		return fmt.Errorf("failed to set announcement message ID: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/repository/postgres"
+	"discord-tars/internal/secrets"
+
+	"gorm.io/gorm"
+)
+
+type AnswerLogRepository struct {
+	db      *postgres.GormDB
+	content fieldCipher
+}
+
+func NewAnswerLogRepository(db *postgres.GormDB) *AnswerLogRepository {
+	return &AnswerLogRepository{db: db}
+}
+
+// SetCipher encrypts questions and answers at rest; reads decrypt transparently
+func (r *AnswerLogRepository) SetCipher(cipher *secrets.Cipher) {
+	r.content = fieldCipher{cipher: cipher}
+}
+
+// Add records an answer
+func (r *AnswerLogRepository) Add(ctx context.Context, entry *models.AnswerLog) error {
+	question, err := r.content.seal(entry.Question)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt question: %w", err)
+	}
+	answer, err := r.content.seal(entry.Answer)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt answer: %w", err)
+	}
+	row := *entry
+	row.Question, row.Answer = question, answer
+	if err := r.db.WithContext(ctx).Create(&row).Error; err != nil {
+		return fmt.Errorf("failed to record answer: %w", err)
+	}
+	entry.ID, entry.CreatedAt = row.ID, row.CreatedAt
+	return nil
+}
+
+// AddFeedback adjusts the 👍 (positive) and 👎 (negative) counts of an
+// answer by a reaction added (+1) or removed (-1), reporting whether the
+// message is a kept answer; other messages are ignored
+func (r *AnswerLogRepository) AddFeedback(ctx context.Context, messageID int64, positive, negative int) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&models.AnswerLog{}).
+		Where("message_id = ?", messageID).
+		Updates(map[string]interface{}{
+			"positive": gorm.Expr("GREATEST(positive + ?, 0)", positive),
+			"negative": gorm.Expr("GREATEST(negative + ?, 0)", negative),
+		})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to record answer feedback: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// Recent returns a guild's latest answers, only those with a 👎 when
+// negativeOnly is set
+func (r *AnswerLogRepository) Recent(ctx context.Context, guildID int64, negativeOnly bool, limit int) ([]models.AnswerLog, error) {
+	query := r.db.WithContext(ctx).Where("guild_id = ?", guildID)
+	if negativeOnly {
+		query = query.Where("negative > 0")
+	}
+	var entries []models.AnswerLog
+	if err := query.Order("created_at DESC").Limit(limit).Find(&entries).Error; err != nil {
+		return nil, fmt.Errorf("failed to list answers: %w", err)
+	}
+	for n := range entries {
+		entries[n].Question = r.content.open(entries[n].Question)
+		entries[n].Answer = r.content.open(entries[n].Answer)
+	}
+	return entries, nil
+}
+
+// Stats counts a guild's answers since a time and the feedback they got
+func (r *AnswerLogRepository) Stats(ctx context.Context, guildID int64, since time.Time) (models.AnswerStats, error) {
+	var stats models.AnswerStats
+	err := r.db.WithContext(ctx).
+		Model(&models.AnswerLog{}).
+		Select("COUNT(*) AS answers, COALESCE(SUM(positive), 0) AS positive, COALESCE(SUM(negative), 0) AS negative").
+		Where("guild_id = ? AND created_at >= ?", guildID, since).
+		Scan(&stats).Error
+	if err != nil {
+		return stats, fmt.Errorf("failed to count answers: %w", err)
+	}
+	return stats, nil
+}
+
+// Prune deletes answers recorded before a time
+func (r *AnswerLogRepository) Prune(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("created_at < ?", before).Delete(&models.AnswerLog{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to prune answers: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
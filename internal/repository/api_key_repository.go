@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/repository/postgres"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type APIKeyRepository struct {
+	db *postgres.GormDB
+}
+
+func NewAPIKeyRepository(db *postgres.GormDB) *APIKeyRepository {
+	return &APIKeyRepository{db: db}
+}
+
+// Create stores a new key
+func (r *APIKeyRepository) Create(ctx context.Context, key *models.APIKey) error {
+	if err := r.db.WithContext(ctx).Create(key).Error; err != nil {
+		return fmt.Errorf("failed to create API key: %w", err)
+	}
+	return nil
+}
+
+// Rotate stores the key replacing another and lets the old one be used until
+// expires; it fails when the old key was revoked or rotated already
+func (r *APIKeyRepository) Rotate(ctx context.Context, oldID int64, replacement *models.APIKey, expires time.Time) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(replacement).Error; err != nil {
+			return fmt.Errorf("failed to create API key: %w", err)
+		}
+		// Keys already due to expire sooner keep their deadline
+		result := tx.Model(&models.APIKey{}).
+			Where("id = ? AND revoked_at IS NULL AND rotated_to IS NULL", oldID).
+			Updates(map[string]interface{}{
+				"rotated_to": replacement.ID,
+				"expires_at": gorm.Expr("LEAST(COALESCE(expires_at, ?), ?)", expires, expires),
+			})
+		if result.Error != nil {
+			return fmt.Errorf("failed to expire rotated API key: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("API key %d was revoked or rotated already", oldID)
+		}
+		return nil
+	})
+}
+
+// Get returns a key, or nil when there is none with the ID
+func (r *APIKeyRepository) Get(ctx context.Context, id int64) (*models.APIKey, error) {
+	var key models.APIKey
+	err := r.db.WithContext(ctx).First(&key, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get API key: %w", err)
+	}
+	return &key, nil
+}
+
+// FindByHash returns the key with a secret's hash, or nil when there is none
+func (r *APIKeyRepository) FindByHash(ctx context.Context, hash string) (*models.APIKey, error) {
+	var key models.APIKey
+	err := r.db.WithContext(ctx).Where("hash = ?", hash).First(&key).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find API key: %w", err)
+	}
+	return &key, nil
+}
+
+// List returns every key, newest first
+func (r *APIKeyRepository) List(ctx context.Context) ([]models.APIKey, error) {
+	var keys []models.APIKey
+	if err := r.db.WithContext(ctx).Order("id DESC").Find(&keys).Error; err != nil {
+		return nil, fmt.Errorf("failed to list API keys: %w", err)
+	}
+	return keys, nil
+}
+
+// Revoke stops a key from being used, reporting whether it was usable
+func (r *APIKeyRepository) Revoke(ctx context.Context, id int64) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&models.APIKey{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to revoke API key: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// RecordUsage counts a request made with a key and marks the key used
+func (r *APIKeyRepository) RecordUsage(ctx context.Context, keyID int64, scope string, failed bool, at time.Time) error {
+	var failures int64
+	if failed {
+		failures = 1
+	}
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "key_id"}, {Name: "day"}, {Name: "scope"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"requests": gorm.Expr("api_key_usages.requests + 1"),
+				"failures": gorm.Expr("api_key_usages.failures + ?", failures),
+			}),
+		}).Create(&models.APIKeyUsage{
+			KeyID:    keyID,
+			Day:      at.UTC().Truncate(24 * time.Hour),
+			Scope:    scope,
+			Requests: 1,
+			Failures: failures,
+		}).Error
+		if err != nil {
+			return fmt.Errorf("failed to record API key usage: %w", err)
+		}
+		if err := tx.Model(&models.APIKey{}).Where("id = ?", keyID).Update("last_used_at", at).Error; err != nil {
+			return fmt.Errorf("failed to mark API key used: %w", err)
+		}
+		return nil
+	})
+}
+
+// Usage returns a key's daily usage since a day, oldest first
+func (r *APIKeyRepository) Usage(ctx context.Context, keyID int64, since time.Time) ([]models.APIKeyUsage, error) {
+	var usage []models.APIKeyUsage
+	err := r.db.WithContext(ctx).
+		Where("key_id = ? AND day >= ?", keyID, since.UTC().Truncate(24*time.Hour)).
+		Order("day, scope").
+		Find(&usage).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to read API key usage: %w", err)
+	}
+	return usage, nil
+}
+
+// UsageTotals sums each key's requests and failures since a day
+func (r *APIKeyRepository) UsageTotals(ctx context.Context, since time.Time) (map[int64]models.APIKeyUsage, error) {
+	var rows []models.APIKeyUsage
+	err := r.db.WithContext(ctx).
+		Model(&models.APIKeyUsage{}).
+		Select("key_id, SUM(requests) AS requests, SUM(failures) AS failures").
+		Where("day >= ?", since.UTC().Truncate(24*time.Hour)).
+		Group("key_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum API key usage: %w", err)
+	}
+	totals := make(map[int64]models.APIKeyUsage, len(rows))
+	for _, row := range rows {
+		totals[row.KeyID] = row
+	}
+	return totals, nil
+}
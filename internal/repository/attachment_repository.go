@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/pgvector"
+	"discord-tars/internal/repository/postgres"
+	"discord-tars/internal/secrets"
+
+	"gorm.io/gorm"
+)
+
+type AttachmentRepository struct {
+	db      *postgres.GormDB
+	content fieldCipher
+}
+
+func NewAttachmentRepository(db *postgres.GormDB) *AttachmentRepository {
+	return &AttachmentRepository{db: db}
+}
+
+// SetCipher encrypts the text of documents at rest; reads decrypt transparently
+func (r *AttachmentRepository) SetCipher(cipher *secrets.Cipher) {
+	r.content = fieldCipher{cipher: cipher}
+}
+
+// ReplaceMessageAttachments stores the attachments of a message with their
+// embeddings, replacing any stored for it before, e.g. after an edit
+func (r *AttachmentRepository) ReplaceMessageAttachments(ctx context.Context, messageID int64, attachments []models.MessageAttachment, embeddings [][]float32) error {
+	rows := make([]models.MessageAttachment, len(attachments))
+	for n, attachment := range attachments {
+		content, err := r.content.seal(attachment.Content)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt attachment text: %w", err)
+		}
+		rows[n] = attachment
+		rows[n].Content, rows[n].Embedding = content, pgvector.NewVector(embeddings[n])
+	}
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("message_id = ?", messageID).Delete(&models.MessageAttachment{}).Error; err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		return tx.Create(&rows).Error
+	})
+	if err != nil {
+		log.Printf("❌ Failed to store attachments of message ID: %d: %v", messageID, err)
+		return fmt.Errorf("failed to store attachments: %w", err)
+	}
+	return nil
+}
+
+// Search finds the files shared in a guild most similar to the query. Files
+// of messages deleted since are left out.
+func (r *AttachmentRepository) Search(ctx context.Context, guildID int64, queryEmbedding []float32, limit int, similarity float64) ([]models.AttachmentResult, error) {
+	return r.search(ctx, "attachment vector search", "", guildID, queryEmbedding, limit, similarity)
+}
+
+// SearchImageText finds the images shared in a guild whose text, read by
+// OCR, is most similar to the query
+func (r *AttachmentRepository) SearchImageText(ctx context.Context, guildID int64, queryEmbedding []float32, limit int, similarity float64) ([]models.AttachmentResult, error) {
+	return r.search(ctx, "image text vector search", "AND ma.content_type LIKE 'image/%' AND ma.content <> ''", guildID, queryEmbedding, limit, similarity)
+}
+
+func (r *AttachmentRepository) search(ctx context.Context, label, filter string, guildID int64, queryEmbedding []float32, limit int, similarity float64) ([]models.AttachmentResult, error) {
+	query := `
+		SELECT ma.id, ma.message_id, ma.guild_id, ma.channel_id, ma.user_id, ma.filename, COALESCE(ma.content_type, ''),
+			ma.size, ma.url, COALESCE(ma.content, ''), ma.timestamp, COALESCE(u.username, ''), 1 - (ma.embedding <=> $1::vector) as similarity
+		FROM message_attachments ma
+		JOIN messages m ON m.id = ma.message_id
+		LEFT JOIN users u ON ma.user_id = u.id
+		WHERE ma.guild_id = $2 AND 1 - (ma.embedding <=> $1::vector) > $3 ` + filter + `
+		ORDER BY ma.embedding <=> $1::vector
+		LIMIT $4
+	`
+
+	rows, err := r.db.TimedRows(ctx, label, query, pgvector.NewVector(queryEmbedding), guildID, similarity, limit)
+	if err != nil {
+		log.Printf("❌ Failed to execute attachment search query: %v", err)
+		return nil, fmt.Errorf("failed to search attachments: %w", err)
+	}
+	defer rows.Close()
+
+	var results []models.AttachmentResult
+	for rows.Next() {
+		var result models.AttachmentResult
+		a := &result.Attachment
+		err := rows.Scan(&a.ID, &a.MessageID, &a.GuildID, &a.ChannelID, &a.UserID, &a.Filename, &a.ContentType,
+			&a.Size, &a.URL, &a.Content, &a.Timestamp, &result.Username, &result.Similarity)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan attachment result: %w", err)
+		}
+		a.Content = r.content.open(a.Content)
+		results = append(results, result)
+	}
+	return results, rows.Err()
+}
@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/repository/postgres"
+	"discord-tars/internal/secrets"
+)
+
+type AuditRepository struct {
+	db      *postgres.GormDB
+	options fieldCipher
+}
+
+func NewAuditRepository(db *postgres.GormDB) *AuditRepository {
+	return &AuditRepository{db: db}
+}
+
+// SetCipher encrypts command options at rest, as they can quote questions and
+// other user input; reads decrypt transparently
+func (r *AuditRepository) SetCipher(cipher *secrets.Cipher) {
+	r.options = fieldCipher{cipher: cipher}
+}
+
+// Add records a command invocation
+func (r *AuditRepository) Add(ctx context.Context, entry *models.CommandAudit) error {
+	options, err := r.options.seal(entry.Options)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt command options: %w", err)
+	}
+	row := *entry
+	row.Options = options
+	if err := r.db.WithContext(ctx).Create(&row).Error; err != nil {
+		return fmt.Errorf("failed to record %s command: %w", entry.Command, err)
+	}
+	entry.ID, entry.CreatedAt = row.ID, row.CreatedAt
+	return nil
+}
+
+// Recent returns a guild's latest command invocations, optionally only those
+// of one user (userID > 0) or one command, including its subcommands
+func (r *AuditRepository) Recent(ctx context.Context, guildID, userID int64, command string, limit int) ([]models.CommandAudit, error) {
+	query := r.db.WithContext(ctx).Where("guild_id = ?", guildID)
+	if userID > 0 {
+		query = query.Where("user_id = ?", userID)
+	}
+	if command != "" {
+		query = query.Where("command = ? OR command LIKE ?", command, command+" %")
+	}
+	var entries []models.CommandAudit
+	if err := query.Order("created_at DESC").Limit(limit).Find(&entries).Error; err != nil {
+		return nil, fmt.Errorf("failed to list audited commands: %w", err)
+	}
+	for n := range entries {
+		entries[n].Options = r.options.open(entries[n].Options)
+	}
+	return entries, nil
+}
+
+// Prune deletes invocations recorded before a time
+func (r *AuditRepository) Prune(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("created_at < ?", before).Delete(&models.CommandAudit{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to prune the audit log: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
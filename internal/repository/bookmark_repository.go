@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/pgvector"
+	"discord-tars/internal/repository/postgres"
+	"discord-tars/internal/secrets"
+
+	"gorm.io/gorm/clause"
+)
+
+type BookmarkRepository struct {
+	db      *postgres.GormDB
+	content fieldCipher
+}
+
+func NewBookmarkRepository(db *postgres.GormDB) *BookmarkRepository {
+	return &BookmarkRepository{db: db}
+}
+
+// SetCipher encrypts bookmarked content at rest; reads decrypt transparently
+func (r *BookmarkRepository) SetCipher(cipher *secrets.Cipher) {
+	r.content = fieldCipher{cipher: cipher}
+}
+
+// Create stores a bookmark with its embedding, reporting false if the user
+// had already bookmarked that message
+func (r *BookmarkRepository) Create(ctx context.Context, bookmark *models.Bookmark, embedding []float32) (bool, error) {
+	content, err := r.content.seal(bookmark.Content)
+	if err != nil {
+		return false, fmt.Errorf("failed to encrypt bookmark: %w", err)
+	}
+
+	row := *bookmark
+	row.Content, row.Embedding = content, pgvector.NewVector(embedding)
+	result := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&row)
+	if result.Error != nil {
+		log.Printf("❌ Failed to store bookmark of user ID: %d: %v", bookmark.UserID, result.Error)
+		return false, fmt.Errorf("failed to store bookmark: %w", result.Error)
+	}
+	bookmark.ID, bookmark.CreatedAt = row.ID, row.CreatedAt
+	return result.RowsAffected > 0, nil
+}
+
+// Exists reports whether a user has bookmarked a message
+func (r *BookmarkRepository) Exists(ctx context.Context, userID, messageID int64) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Bookmark{}).
+		Where("user_id = ? AND message_id = ?", userID, messageID).
+		Count(&count).Error
+	if err != nil {
+		return false, fmt.Errorf("failed to check bookmark: %w", err)
+	}
+	return count > 0, nil
+}
+
+// Search finds a user's bookmarks most similar to the query; nobody else's
+// bookmarks are ever considered
+func (r *BookmarkRepository) Search(ctx context.Context, userID int64, queryEmbedding []float32, limit int, similarity float64) ([]models.BookmarkResult, error) {
+	query := `
+		SELECT id, user_id, message_id, guild_id, channel_id, author_id, author_name, content, posted_at, created_at,
+			1 - (embedding <=> $1::vector) as similarity
+		FROM bookmarks
+		WHERE user_id = $2 AND 1 - (embedding <=> $1::vector) > $3
+		ORDER BY embedding <=> $1::vector
+		LIMIT $4
+	`
+
+	rows, err := r.db.TimedRows(ctx, "bookmark vector search", query, pgvector.NewVector(queryEmbedding), userID, similarity, limit)
+	if err != nil {
+		log.Printf("❌ Failed to execute bookmark search query: %v", err)
+		return nil, fmt.Errorf("failed to search bookmarks: %w", err)
+	}
+	defer rows.Close()
+
+	var results []models.BookmarkResult
+	for rows.Next() {
+		var result models.BookmarkResult
+		bookmark := &result.Bookmark
+		if err := rows.Scan(&bookmark.ID, &bookmark.UserID, &bookmark.MessageID, &bookmark.GuildID, &bookmark.ChannelID,
+			&bookmark.AuthorID, &bookmark.AuthorName, &bookmark.Content, &bookmark.PostedAt, &bookmark.CreatedAt, &result.Similarity); err != nil {
+			return nil, fmt.Errorf("failed to scan bookmark result: %w", err)
+		}
+		bookmark.Content = r.content.open(bookmark.Content)
+		results = append(results, result)
+	}
+	return results, rows.Err()
+}
@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/repository/postgres"
+)
+
+type CalendarRepository struct {
+	db *postgres.GormDB
+}
+
+func NewCalendarRepository(db *postgres.GormDB) *CalendarRepository {
+	return &CalendarRepository{db: db}
+}
+
+// AddSource stores a calendar source
+func (r *CalendarRepository) AddSource(ctx context.Context, source *models.CalendarSource) error {
+	if err := r.db.WithContext(ctx).Create(source).Error; err != nil {
+		log.Printf("❌ Failed to save calendar source: %v", err)
+		return fmt.Errorf("failed to save calendar source: %w", err)
+	}
+	log.Printf("💾 Saved calendar source %d (%s)", source.ID, source.Name)
+	return nil
+}
+
+// RemoveSource deletes a guild's calendar source and its events
+func (r *CalendarRepository) RemoveSource(ctx context.Context, guildID, sourceID int64) (bool, error) {
+	var removed bool
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ? AND guild_id = ?", sourceID, guildID).Delete(&models.CalendarSource{})
+		if result.Error != nil {
+			return result.Error
+		}
+		removed = result.RowsAffected > 0
+		if !removed {
+			return nil
+		}
+		return tx.Where("source_id = ?", sourceID).Delete(&models.CalendarEvent{}).Error
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to delete calendar source: %w", err)
+	}
+	return removed, nil
+}
+
+// ListSources returns a guild's calendar sources, or every source when guildID is 0
+func (r *CalendarRepository) ListSources(ctx context.Context, guildID int64) ([]models.CalendarSource, error) {
+	var sources []models.CalendarSource
+	query := r.db.WithContext(ctx).Order("id")
+	if guildID != 0 {
+		query = query.Where("guild_id = ?", guildID)
+	}
+	if err := query.Find(&sources).Error; err != nil {
+		return nil, fmt.Errorf("failed to list calendar sources: %w", err)
+	}
+	return sources, nil
+}
+
+// MarkSynced records the outcome of a sync
+func (r *CalendarRepository) MarkSynced(ctx context.Context, sourceID int64, lastError string, at time.Time) error {
+	err := r.db.WithContext(ctx).Model(&models.CalendarSource{}).
+		Where("id = ?", sourceID).
+		Updates(map[string]interface{}{"last_synced_at": at, "last_error": lastError}).Error
+	if err != nil {
+		return fmt.Errorf("failed to update calendar source: %w", err)
+	}
+	return nil
+}
+
+// ReplaceEvents upserts a source's occurrences from `from` onwards and prunes ones that disappeared.
+// Reminder state is preserved for occurrences that still exist.
+func (r *CalendarRepository) ReplaceEvents(ctx context.Context, sourceID int64, from time.Time, events []models.CalendarEvent) error {
+	syncedAt := time.Now()
+	for i := range events {
+		events[i].SourceID = sourceID
+		events[i].SyncedAt = syncedAt
+	}
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if len(events) > 0 {
+			err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "source_id"}, {Name: "uid"}, {Name: "starts_at"}},
+				DoUpdates: clause.AssignmentColumns([]string{"ends_at", "all_day", "summary", "description", "location", "synced_at", "updated_at"}),
+			}).CreateInBatches(events, 200).Error
+			if err != nil {
+				return err
+			}
+		}
+		return tx.Where("source_id = ? AND starts_at >= ? AND synced_at < ?", sourceID, from, syncedAt).
+			Delete(&models.CalendarEvent{}).Error
+	})
+	if err != nil {
+		log.Printf("❌ Failed to store calendar events: %v", err)
+		return fmt.Errorf("failed to store calendar events: %w", err)
+	}
+	return nil
+}
+
+// Upcoming returns a guild's next events that have not ended yet
+func (r *CalendarRepository) Upcoming(ctx context.Context, guildID int64, now time.Time, until time.Time, limit int) ([]models.CalendarEvent, error) {
+	var events []models.CalendarEvent
+	err := r.db.WithContext(ctx).
+		Where("guild_id = ? AND ends_at > ? AND starts_at < ?", guildID, now, until).
+		Order("starts_at").
+		Limit(limit).
+		Find(&events).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list upcoming events: %w", err)
+	}
+	return events, nil
+}
+
+// DueReminders returns events starting within their source's reminder window that were not announced yet
+func (r *CalendarRepository) DueReminders(ctx context.Context, now time.Time) ([]models.CalendarReminder, error) {
+	var reminders []models.CalendarReminder
+	err := r.db.WithContext(ctx).
+		Table("calendar_events AS e").
+		Select("e.*, s.reminder_channel_id AS channel_id, s.name AS source_name").
+		Joins("JOIN calendar_sources s ON s.id = e.source_id").
+		Where("e.reminded_at IS NULL AND s.reminder_channel_id <> 0").
+		Where("e.starts_at > ? AND e.starts_at <= ? + s.reminder_minutes * INTERVAL '1 minute'", now, now).
+		Order("e.starts_at").
+		Scan(&reminders).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list due reminders: %w", err)
+	}
+	return reminders, nil
+}
+
+// ClaimReminder marks an event reminded, returning false if another worker already did
+func (r *CalendarRepository) ClaimReminder(ctx context.Context, eventID int64) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.CalendarEvent{}).
+		Where("id = ? AND reminded_at IS NULL", eventID).
+		Update("reminded_at", time.Now())
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to claim reminder: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/pgvector"
+	"discord-tars/internal/repository/postgres"
+	"discord-tars/internal/secrets"
+
+	"gorm.io/gorm"
+)
+
+type CodeRepository struct {
+	db      *postgres.GormDB
+	content fieldCipher
+}
+
+func NewCodeRepository(db *postgres.GormDB) *CodeRepository {
+	return &CodeRepository{db: db}
+}
+
+// SetCipher encrypts code snippets at rest; reads decrypt transparently
+func (r *CodeRepository) SetCipher(cipher *secrets.Cipher) {
+	r.content = fieldCipher{cipher: cipher}
+}
+
+// ReplaceMessageSnippets stores the code blocks of a message with their
+// embeddings, replacing any stored for it before, e.g. by a reindex
+func (r *CodeRepository) ReplaceMessageSnippets(ctx context.Context, messageID int64, snippets []models.CodeSnippet, embeddings [][]float32) error {
+	rows := make([]models.CodeSnippet, len(snippets))
+	for n, snippet := range snippets {
+		code, err := r.content.seal(snippet.Code)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt code snippet: %w", err)
+		}
+		rows[n] = snippet
+		rows[n].Code, rows[n].Embedding = code, pgvector.NewVector(embeddings[n])
+	}
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("message_id = ?", messageID).Delete(&models.CodeSnippet{}).Error; err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		return tx.Create(&rows).Error
+	})
+	if err != nil {
+		log.Printf("❌ Failed to store code snippets of message ID: %d: %v", messageID, err)
+		return fmt.Errorf("failed to store code snippets: %w", err)
+	}
+	return nil
+}
+
+// Search finds the code snippets of a guild most similar to the query,
+// limited to one language when it is set
+func (r *CodeRepository) Search(ctx context.Context, guildID int64, language string, queryEmbedding []float32, limit int, similarity float64) ([]models.CodeResult, error) {
+	query := `
+		SELECT cs.id, cs.message_id, cs.guild_id, cs.channel_id, cs.user_id, cs.language, cs.code, cs.timestamp,
+			COALESCE(u.username, ''), 1 - (cs.embedding <=> $1::vector) as similarity
+		FROM code_snippets cs
+		LEFT JOIN users u ON cs.user_id = u.id
+		WHERE cs.guild_id = $2 AND ($3 = '' OR cs.language = $3) AND 1 - (cs.embedding <=> $1::vector) > $4
+		ORDER BY cs.embedding <=> $1::vector
+		LIMIT $5
+	`
+
+	rows, err := r.db.TimedRows(ctx, "code vector search", query, pgvector.NewVector(queryEmbedding), guildID, language, similarity, limit)
+	if err != nil {
+		log.Printf("❌ Failed to execute code search query: %v", err)
+		return nil, fmt.Errorf("failed to search code snippets: %w", err)
+	}
+	defer rows.Close()
+
+	var results []models.CodeResult
+	for rows.Next() {
+		var result models.CodeResult
+		snippet := &result.Snippet
+		err := rows.Scan(&snippet.ID, &snippet.MessageID, &snippet.GuildID, &snippet.ChannelID, &snippet.UserID,
+			&snippet.Language, &snippet.Code, &snippet.Timestamp, &result.Username, &result.Similarity)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan code result: %w", err)
+		}
+		snippet.Code = r.content.open(snippet.Code)
+		results = append(results, result)
+	}
+	return results, rows.Err()
+}
+
+// Languages lists the languages of a guild's snippets, most shared first
+func (r *CodeRepository) Languages(ctx context.Context, guildID int64, limit int) ([]string, error) {
+	var languages []string
+	err := r.db.WithContext(ctx).Model(&models.CodeSnippet{}).
+		Where("guild_id = ? AND language <> ''", guildID).
+		Group("language").
+		Order("COUNT(*) DESC").
+		Limit(limit).
+		Pluck("language", &languages).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list code languages: %w", err)
+	}
+	return languages, nil
+}
@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/repository/postgres"
+
+	"gorm.io/gorm"
+)
+
+type CredentialRepository struct {
+	db *postgres.GormDB
+}
+
+func NewCredentialRepository(db *postgres.GormDB) *CredentialRepository {
+	return &CredentialRepository{db: db}
+}
+
+// SaveCredential creates or replaces a guild's AI provider credential
+func (r *CredentialRepository) SaveCredential(ctx context.Context, cred *models.AICredential) error {
+	log.Printf("💾 Saving %s credential for guild ID: %d", cred.Provider, cred.GuildID)
+	if err := r.db.WithContext(ctx).Save(cred).Error; err != nil {
+		log.Printf("❌ Failed to save AI credential: %v", err)
+		return fmt.Errorf("failed to save AI credential: %w", err)
+	}
+	return nil
+}
+
+// GetCredential returns a guild's AI provider credential, or nil if none
+func (r *CredentialRepository) GetCredential(ctx context.Context, guildID int64) (*models.AICredential, error) {
+	var cred models.AICredential
+	err := r.db.WithContext(ctx).Where("guild_id = ?", guildID).First(&cred).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get AI credential: %w", err)
+	}
+	return &cred, nil
+}
+
+// DeleteCredential removes a guild's AI provider credential
+func (r *CredentialRepository) DeleteCredential(ctx context.Context, guildID int64) (bool, error) {
+	result := r.db.WithContext(ctx).Where("guild_id = ?", guildID).Delete(&models.AICredential{})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to delete AI credential: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
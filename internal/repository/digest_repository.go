@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/repository/postgres"
+
+	"gorm.io/gorm"
+)
+
+type DigestRepository struct {
+	db *postgres.GormDB
+}
+
+func NewDigestRepository(db *postgres.GormDB) *DigestRepository {
+	return &DigestRepository{db: db}
+}
+
+// Subscribe creates or updates a user's digest subscription for a channel
+func (r *DigestRepository) Subscribe(ctx context.Context, sub *models.DigestSubscription) error {
+	log.Printf("💾 Saving digest subscription for user ID: %d, channel ID: %d", sub.UserID, sub.ChannelID)
+
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND channel_id = ?", sub.UserID, sub.ChannelID).
+		// Map instead of struct so zero values (midnight, Sunday) are saved too
+		Assign(map[string]interface{}{
+			"guild_id":  sub.GuildID,
+			"frequency": sub.Frequency,
+			"hour":      sub.Hour,
+			"weekday":   sub.Weekday,
+			"timezone":  sub.Timezone,
+		}).
+		FirstOrCreate(sub).Error
+	if err != nil {
+		log.Printf("❌ Failed to save digest subscription: %v", err)
+		return fmt.Errorf("failed to save digest subscription: %w", err)
+	}
+	return nil
+}
+
+// Unsubscribe removes a user's digest subscription for a channel
+func (r *DigestRepository) Unsubscribe(ctx context.Context, userID, channelID int64) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Where("user_id = ? AND channel_id = ?", userID, channelID).
+		Delete(&models.DigestSubscription{})
+	if result.Error != nil {
+		log.Printf("❌ Failed to delete digest subscription: %v", result.Error)
+		return false, fmt.Errorf("failed to delete digest subscription: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// ListByUser returns all digest subscriptions of a user within a guild
+func (r *DigestRepository) ListByUser(ctx context.Context, userID, guildID int64) ([]models.DigestSubscription, error) {
+	var subs []models.DigestSubscription
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND guild_id = ?", userID, guildID).
+		Order("channel_id").
+		Find(&subs).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list digest subscriptions: %w", err)
+	}
+	return subs, nil
+}
+
+// ListAll returns every digest subscription; due checks happen in the service
+// because they depend on each subscriber's timezone
+func (r *DigestRepository) ListAll(ctx context.Context) ([]models.DigestSubscription, error) {
+	var subs []models.DigestSubscription
+	if err := r.db.WithContext(ctx).Find(&subs).Error; err != nil {
+		return nil, fmt.Errorf("failed to list digest subscriptions: %w", err)
+	}
+	return subs, nil
+}
+
+// Get returns a subscription by ID, or nil if it was removed
+func (r *DigestRepository) Get(ctx context.Context, id int64) (*models.DigestSubscription, error) {
+	var sub models.DigestSubscription
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&sub).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get digest subscription: %w", err)
+	}
+	return &sub, nil
+}
+
+// MarkSent records the delivery time of a digest
+func (r *DigestRepository) MarkSent(ctx context.Context, id int64, sentAt time.Time) error {
+	err := r.db.WithContext(ctx).
+		Model(&models.DigestSubscription{}).
+		Where("id = ?", id).
+		Update("last_sent", sentAt).Error
+	if err != nil {
+		return fmt.Errorf("failed to mark digest as sent: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"math"
+
+	"discord-tars/internal/pgvector"
+)
+
+// Retrieval diversification defaults. Reposts and quoted messages embed almost
+// identically, so without filtering they can fill every context slot.
+const (
+	DefaultDuplicateThreshold = 0.97
+	DefaultMMRLambda          = 0.7
+	// diversityOverfetch is how many candidates per result are fetched to choose from
+	diversityOverfetch = 4
+)
+
+// diversity configures how search results are spread across distinct content
+type diversity struct {
+	duplicateThreshold float64 // Candidates at least this similar to a chosen one are dropped; 0 disables
+	lambda             float64 // MMR trade-off: 1 ranks by relevance only, lower favors novelty
+}
+
+func (d diversity) enabled() bool {
+	return d.duplicateThreshold > 0 || d.lambda < 1
+}
+
+// candidate is a search hit with its embedding, used to compare hits to each other
+type candidate struct {
+	index      int // Position in the relevance-ordered result list
+	similarity float64
+	vector     []float32
+}
+
+// selectDiverse picks up to limit candidates with maximal marginal relevance:
+// each pick maximizes lambda*relevance - (1-lambda)*similarity to what's
+// already picked. Near-duplicates of a pick are discarded outright. Returns
+// the chosen indexes in pick order.
+func (d diversity) selectDiverse(candidates []candidate, limit int) []int {
+	chosen := make([]int, 0, limit)
+	picked := make([]candidate, 0, limit)
+	remaining := candidates
+
+	for len(picked) < limit && len(remaining) > 0 {
+		best, bestScore := -1, math.Inf(-1)
+		kept := remaining[:0]
+		for _, c := range remaining {
+			redundancy := 0.0
+			duplicate := false
+			for _, p := range picked {
+				sim := cosine(c.vector, p.vector)
+				if d.duplicateThreshold > 0 && sim >= d.duplicateThreshold {
+					duplicate = true
+					break
+				}
+				redundancy = math.Max(redundancy, sim)
+			}
+			if duplicate {
+				continue
+			}
+			kept = append(kept, c)
+
+			score := d.lambda*c.similarity - (1-d.lambda)*redundancy
+			if score > bestScore {
+				best, bestScore = len(kept)-1, score
+			}
+		}
+		if best < 0 {
+			break
+		}
+
+		picked = append(picked, kept[best])
+		chosen = append(chosen, kept[best].index)
+		remaining = append(kept[:best], kept[best+1:]...)
+	}
+	return chosen
+}
+
+// parseVector reads pgvector's "[x,y,...]" text form, or returns nil if it
+// is malformed
+func parseVector(text string) []float32 {
+	vector, err := pgvector.Parse(text)
+	if err != nil {
+		return nil
+	}
+	return vector.Slice()
+}
+
+// cosine returns the cosine similarity of two vectors, or 0 if they can't be compared
+func cosine(a, b []float32) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+	var dot, normA, normB float64
+	for n := range a {
+		dot += float64(a[n]) * float64(b[n])
+		normA += float64(a[n]) * float64(a[n])
+		normB += float64(b[n]) * float64(b[n])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/pgvector"
+	"discord-tars/internal/repository/postgres"
+	"discord-tars/internal/secrets"
+
+	"gorm.io/gorm"
+)
+
+type DuplicateRepository struct {
+	db      *postgres.GormDB
+	content fieldCipher
+}
+
+func NewDuplicateRepository(db *postgres.GormDB) *DuplicateRepository {
+	return &DuplicateRepository{db: db}
+}
+
+// SetCipher encrypts stored questions at rest; reads decrypt transparently
+func (r *DuplicateRepository) SetCipher(cipher *secrets.Cipher) {
+	r.content = fieldCipher{cipher: cipher}
+}
+
+// SaveConfig creates or replaces a guild's duplicate question configuration
+func (r *DuplicateRepository) SaveConfig(ctx context.Context, cfg *models.DuplicateConfig) error {
+	if err := r.db.WithContext(ctx).Save(cfg).Error; err != nil {
+		log.Printf("❌ Failed to save duplicate question config: %v", err)
+		return fmt.Errorf("failed to save duplicate question config: %w", err)
+	}
+	return nil
+}
+
+// GetConfig returns a guild's duplicate question configuration, or nil if none
+func (r *DuplicateRepository) GetConfig(ctx context.Context, guildID int64) (*models.DuplicateConfig, error) {
+	var cfg models.DuplicateConfig
+	err := r.db.WithContext(ctx).Where("guild_id = ?", guildID).First(&cfg).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get duplicate question config: %w", err)
+	}
+	return &cfg, nil
+}
+
+// SaveAnswer stores a question the bot answered with its embedding
+func (r *DuplicateRepository) SaveAnswer(ctx context.Context, answered *models.AnsweredQuestion, embedding []float32) error {
+	answered.Embedding = pgvector.NewVector(embedding)
+	question, err := r.content.seal(answered.Question)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt question: %w", err)
+	}
+
+	row := *answered
+	row.Question = question
+	if err := r.db.WithContext(ctx).Create(&row).Error; err != nil {
+		log.Printf("❌ Failed to store answered question for guild ID: %d: %v", answered.GuildID, err)
+		return fmt.Errorf("failed to store answered question: %w", err)
+	}
+	answered.ID, answered.CreatedAt = row.ID, row.CreatedAt
+	return nil
+}
+
+// ClosestAnswer returns the guild's answered question since the given time
+// most similar to the query, or nil if none is at least similarity close
+func (r *DuplicateRepository) ClosestAnswer(ctx context.Context, guildID int64, queryEmbedding []float32, since time.Time, similarity float64) (*models.AnsweredQuestionResult, error) {
+	query := `
+		SELECT id, guild_id, channel_id, message_id, question, created_at,
+			1 - (embedding <=> $1::vector) as similarity
+		FROM answered_questions
+		WHERE guild_id = $2 AND created_at >= $3 AND 1 - (embedding <=> $1::vector) >= $4
+		ORDER BY embedding <=> $1::vector
+		LIMIT 1
+	`
+
+	rows, err := r.db.TimedRows(ctx, "answered question search", query, pgvector.NewVector(queryEmbedding), guildID, since, similarity)
+	if err != nil {
+		log.Printf("❌ Failed to execute answered question search query: %v", err)
+		return nil, fmt.Errorf("failed to search answered questions: %w", err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return nil, rows.Err()
+	}
+	var result models.AnsweredQuestionResult
+	answered := &result.Question
+	if err := rows.Scan(&answered.ID, &answered.GuildID, &answered.ChannelID, &answered.MessageID,
+		&answered.Question, &answered.CreatedAt, &result.Similarity); err != nil {
+		return nil, fmt.Errorf("failed to scan answered question: %w", err)
+	}
+	answered.Question = r.content.open(answered.Question)
+	return &result, nil
+}
+
+// PruneAnswers deletes answered questions older than the given time
+func (r *DuplicateRepository) PruneAnswers(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("created_at < ?", before).Delete(&models.AnsweredQuestion{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to prune answered questions: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"log"
+
+	"discord-tars/internal/secrets"
+)
+
+// unreadableContent replaces values that can't be decrypted, so one bad row
+// doesn't fail a whole search
+const unreadableContent = "[encrypted content unavailable]"
+
+// fieldCipher encrypts text columns at rest. The zero value stores plaintext;
+// reads always handle both, so encryption can be turned on for an existing database.
+type fieldCipher struct {
+	cipher *secrets.Cipher
+}
+
+func (f fieldCipher) seal(value string) (string, error) {
+	if f.cipher == nil || value == "" {
+		return value, nil
+	}
+	return f.cipher.Seal(value)
+}
+
+func (f fieldCipher) open(value string) string {
+	if !secrets.IsSealed(value) {
+		return value
+	}
+	if f.cipher == nil {
+		log.Printf("⚠️ Found encrypted content but no encryption key is configured")
+		return unreadableContent
+	}
+	plaintext, err := f.cipher.Open(value)
+	if err != nil {
+		log.Printf("⚠️ Failed to decrypt stored content: %v", err)
+		return unreadableContent
+	}
+	return plaintext
+}
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"discord-tars/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// ExportMessages returns a page of stored messages with their embeddings,
+// ordered by ID and starting after afterID, for index exports. guildIDs
+// restricts it to some guilds when not empty; embeddedOnly leaves out
+// messages without an embedding. Content is decrypted.
+func (r *MessageRepository) ExportMessages(ctx context.Context, guildIDs []int64, embeddedOnly bool, afterID int64, limit int) ([]models.ExportedMessage, error) {
+	join := "LEFT JOIN"
+	if embeddedOnly {
+		join = "JOIN"
+	}
+	query := `
+		SELECT m.id, COALESCE(m.guild_id, 0), m.channel_id, COALESCE(c.name, ''), m.user_id, COALESCE(u.username, ''),
+			m.content, m.timestamp, COALESCE(ml.language, ''), COALESCE(me.model_name, ''), COALESCE(me.embedding::text, '')
+		FROM messages m
+		LEFT JOIN channels c ON c.id = m.channel_id
+		LEFT JOIN users u ON u.id = m.user_id
+		` + join + ` message_embeddings me ON me.message_id = m.id
+		LEFT JOIN message_languages ml ON ml.message_id = m.id
+		WHERE m.id > $1`
+	args := []interface{}{afterID, limit}
+	if len(guildIDs) > 0 {
+		args = append(args, pq.Array(guildIDs))
+		query += `
+		AND m.guild_id = ANY($3)`
+	}
+	query += `
+		ORDER BY m.id
+		LIMIT $2`
+
+	rows, err := r.db.WithContext(ctx).Raw(query, args...).Rows()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read messages to export: %w", err)
+	}
+	defer rows.Close()
+
+	var messages []models.ExportedMessage
+	for rows.Next() {
+		var msg models.ExportedMessage
+		var embedding string
+		err := rows.Scan(&msg.ID, &msg.GuildID, &msg.ChannelID, &msg.ChannelName, &msg.UserID, &msg.Username,
+			&msg.Content, &msg.Timestamp, &msg.Language, &msg.EmbeddingModel, &embedding)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read messages to export: %w", err)
+		}
+		msg.Content = r.content.open(msg.Content)
+		msg.Embedding = parseVector(embedding)
+		messages = append(messages, msg)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read messages to export: %w", err)
+	}
+	return messages, nil
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm/clause"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/repository/postgres"
+)
+
+type FeedRepository struct {
+	db *postgres.GormDB
+}
+
+func NewFeedRepository(db *postgres.GormDB) *FeedRepository {
+	return &FeedRepository{db: db}
+}
+
+// AddFeed stores a feed, failing if the channel already watches the URL
+func (r *FeedRepository) AddFeed(ctx context.Context, feed *models.Feed) error {
+	if err := r.db.WithContext(ctx).Create(feed).Error; err != nil {
+		log.Printf("❌ Failed to save feed: %v", err)
+		return fmt.Errorf("failed to save feed: %w", err)
+	}
+	log.Printf("💾 Saved feed %d (%s)", feed.ID, feed.URL)
+	return nil
+}
+
+// RemoveFeed deletes a guild's feed and its seen items
+func (r *FeedRepository) RemoveFeed(ctx context.Context, guildID, feedID int64) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Where("id = ? AND guild_id = ?", feedID, guildID).
+		Delete(&models.Feed{})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to delete feed: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
+	if err := r.db.WithContext(ctx).Where("feed_id = ?", feedID).Delete(&models.FeedItem{}).Error; err != nil {
+		return true, fmt.Errorf("failed to delete feed items: %w", err)
+	}
+	return true, nil
+}
+
+// ListByGuild returns a guild's feeds
+func (r *FeedRepository) ListByGuild(ctx context.Context, guildID int64) ([]models.Feed, error) {
+	var feeds []models.Feed
+	if err := r.db.WithContext(ctx).Where("guild_id = ?", guildID).Order("id").Find(&feeds).Error; err != nil {
+		return nil, fmt.Errorf("failed to list feeds: %w", err)
+	}
+	return feeds, nil
+}
+
+// ListDue returns feeds whose interval has elapsed since their last check
+func (r *FeedRepository) ListDue(ctx context.Context, now time.Time) ([]models.Feed, error) {
+	var feeds []models.Feed
+	err := r.db.WithContext(ctx).
+		Where("last_checked_at IS NULL OR last_checked_at + interval_minutes * INTERVAL '1 minute' <= ?", now).
+		Find(&feeds).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list due feeds: %w", err)
+	}
+	return feeds, nil
+}
+
+// MarkChecked records the outcome of a poll
+func (r *FeedRepository) MarkChecked(ctx context.Context, feedID int64, title, lastError string, at time.Time) error {
+	updates := map[string]interface{}{
+		"last_checked_at": at,
+		"last_error":      lastError,
+	}
+	if title != "" {
+		updates["title"] = title
+	}
+	err := r.db.WithContext(ctx).Model(&models.Feed{}).Where("id = ?", feedID).Updates(updates).Error
+	if err != nil {
+		return fmt.Errorf("failed to update feed: %w", err)
+	}
+	return nil
+}
+
+// RecordItem marks an item as seen, returning false if it was already recorded
+func (r *FeedRepository) RecordItem(ctx context.Context, item *models.FeedItem) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(item)
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to record feed item: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
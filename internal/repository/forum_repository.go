@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/pgvector"
+	"discord-tars/internal/repository/postgres"
+	"discord-tars/internal/secrets"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// solvedPostBoost ranks solved forum posts above unsolved ones about as
+// similar, when suggesting posts
+const solvedPostBoost = 0.05
+
+type ForumRepository struct {
+	db      *postgres.GormDB
+	content fieldCipher
+}
+
+func NewForumRepository(db *postgres.GormDB) *ForumRepository {
+	return &ForumRepository{db: db}
+}
+
+// SetCipher encrypts forum posts at rest; reads decrypt transparently
+func (r *ForumRepository) SetCipher(cipher *secrets.Cipher) {
+	r.content = fieldCipher{cipher: cipher}
+}
+
+// Save stores a forum post with its embedding, updating its title, tags and
+// content if it was stored before; whether it is solved is kept
+func (r *ForumRepository) Save(ctx context.Context, post *models.ForumPost, embedding []float32) error {
+	content, err := r.content.seal(post.Content)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt forum post: %w", err)
+	}
+
+	row := *post
+	row.Content, row.Embedding = content, pgvector.NewVector(embedding)
+	err = r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "thread_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"title", "tags", "content", "embedding", "updated_at"}),
+	}).Create(&row).Error
+	if err != nil {
+		log.Printf("❌ Failed to store forum post ID: %d: %v", post.ThreadID, err)
+		return fmt.Errorf("failed to store forum post: %w", err)
+	}
+	return nil
+}
+
+// Get returns a forum post, or nil if it isn't stored
+func (r *ForumRepository) Get(ctx context.Context, threadID int64) (*models.ForumPost, error) {
+	var post models.ForumPost
+	err := r.db.WithContext(ctx).Omit("embedding").First(&post, "thread_id = ?", threadID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get forum post: %w", err)
+	}
+	post.Content = r.content.open(post.Content)
+	return &post, nil
+}
+
+// UpdateTags records a post's new title and tags. A solved tag solves it;
+// removing the tag reopens it unless an answer was accepted.
+func (r *ForumRepository) UpdateTags(ctx context.Context, threadID int64, title, tags string, solvedTag bool) error {
+	updates := map[string]interface{}{"title": title, "tags": tags, "updated_at": time.Now()}
+	query := r.db.WithContext(ctx).Model(&models.ForumPost{}).Where("thread_id = ?", threadID)
+	if solvedTag {
+		updates["solved"] = true
+		updates["solved_at"] = gorm.Expr("COALESCE(solved_at, NOW())")
+	} else {
+		updates["solved"] = gorm.Expr("solution_message_id <> 0")
+		updates["solved_at"] = gorm.Expr("CASE WHEN solution_message_id <> 0 THEN solved_at END")
+	}
+	if err := query.Updates(updates).Error; err != nil {
+		return fmt.Errorf("failed to update forum post tags: %w", err)
+	}
+	return nil
+}
+
+// MarkSolution records the accepted answer of a post, replacing any earlier
+// one; it reports false if that answer was already accepted or the post isn't stored
+func (r *ForumRepository) MarkSolution(ctx context.Context, threadID, messageID int64) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.ForumPost{}).
+		Where("thread_id = ? AND solution_message_id <> ?", threadID, messageID).
+		Updates(map[string]interface{}{
+			"solved":              true,
+			"solution_message_id": messageID,
+			"solved_at":           time.Now(),
+		})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to mark forum post solution: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// Similar finds a guild's forum posts most similar to the query, solved ones
+// ranked a little higher, leaving out the post excludeThreadID
+func (r *ForumRepository) Similar(ctx context.Context, guildID, excludeThreadID int64, queryEmbedding []float32, limit int, similarity float64) ([]models.ForumPostResult, error) {
+	query := `
+		SELECT thread_id, guild_id, forum_id, author_id, title, tags, content, solved, solution_message_id, created_at,
+			1 - (embedding <=> $1::vector) as similarity
+		FROM forum_posts
+		WHERE guild_id = $2 AND thread_id <> $3 AND 1 - (embedding <=> $1::vector) > $4
+		ORDER BY 1 - (embedding <=> $1::vector) + CASE WHEN solved THEN $5 ELSE 0 END DESC
+		LIMIT $6
+	`
+
+	rows, err := r.db.TimedRows(ctx, "forum post vector search", query, pgvector.NewVector(queryEmbedding), guildID, excludeThreadID, similarity, solvedPostBoost, limit)
+	if err != nil {
+		log.Printf("❌ Failed to execute forum post search query: %v", err)
+		return nil, fmt.Errorf("failed to search forum posts: %w", err)
+	}
+	defer rows.Close()
+
+	var results []models.ForumPostResult
+	for rows.Next() {
+		var result models.ForumPostResult
+		post := &result.Post
+		err := rows.Scan(&post.ThreadID, &post.GuildID, &post.ForumID, &post.AuthorID, &post.Title, &post.Tags, &post.Content,
+			&post.Solved, &post.SolutionMessageID, &post.CreatedAt, &result.Similarity)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan forum post result: %w", err)
+		}
+		post.Content = r.content.open(post.Content)
+		results = append(results, result)
+	}
+	return results, rows.Err()
+}
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/repository/postgres"
+)
+
+type GitHubRepository struct {
+	db *postgres.GormDB
+}
+
+func NewGitHubRepository(db *postgres.GormDB) *GitHubRepository {
+	return &GitHubRepository{db: db}
+}
+
+// Subscribe adds a repository subscription to a channel
+func (r *GitHubRepository) Subscribe(ctx context.Context, sub *models.GitHubSubscription) error {
+	err := r.db.WithContext(ctx).
+		Where("channel_id = ? AND repo = ?", sub.ChannelID, sub.Repo).
+		FirstOrCreate(sub).Error
+	if err != nil {
+		log.Printf("❌ Failed to save github subscription: %v", err)
+		return fmt.Errorf("failed to save github subscription: %w", err)
+	}
+	return nil
+}
+
+// Unsubscribe removes a repository subscription from a channel
+func (r *GitHubRepository) Unsubscribe(ctx context.Context, channelID int64, repo string) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Where("channel_id = ? AND repo = ?", channelID, repo).
+		Delete(&models.GitHubSubscription{})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to delete github subscription: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// ListByRepo returns every channel subscribed to a repository
+func (r *GitHubRepository) ListByRepo(ctx context.Context, repo string) ([]models.GitHubSubscription, error) {
+	var subs []models.GitHubSubscription
+	if err := r.db.WithContext(ctx).Where("repo = ?", repo).Find(&subs).Error; err != nil {
+		return nil, fmt.Errorf("failed to list github subscriptions: %w", err)
+	}
+	return subs, nil
+}
+
+// ListByGuild returns a guild's repository subscriptions
+func (r *GitHubRepository) ListByGuild(ctx context.Context, guildID int64) ([]models.GitHubSubscription, error) {
+	var subs []models.GitHubSubscription
+	if err := r.db.WithContext(ctx).Where("guild_id = ?", guildID).Order("repo").Find(&subs).Error; err != nil {
+		return nil, fmt.Errorf("failed to list github subscriptions: %w", err)
+	}
+	return subs, nil
+}
@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/repository/postgres"
+)
+
+type HelpRepository struct {
+	db *postgres.GormDB
+}
+
+func NewHelpRepository(db *postgres.GormDB) *HelpRepository {
+	return &HelpRepository{db: db}
+}
+
+// AddChannel designates a help channel; adding it again keeps it as is
+func (r *HelpRepository) AddChannel(ctx context.Context, channel *models.HelpChannel) error {
+	if err := r.db.WithContext(ctx).Where("channel_id = ?", channel.ChannelID).FirstOrCreate(channel).Error; err != nil {
+		log.Printf("❌ Failed to add help channel: %v", err)
+		return fmt.Errorf("failed to add help channel: %w", err)
+	}
+	return nil
+}
+
+// RemoveChannel stops treating a guild's channel as a help channel
+func (r *HelpRepository) RemoveChannel(ctx context.Context, guildID, channelID int64) (bool, error) {
+	result := r.db.WithContext(ctx).Where("guild_id = ? AND channel_id = ?", guildID, channelID).Delete(&models.HelpChannel{})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to remove help channel: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// ListChannels returns help channels, optionally filtered by guild (0 = all)
+func (r *HelpRepository) ListChannels(ctx context.Context, guildID int64) ([]models.HelpChannel, error) {
+	var channels []models.HelpChannel
+	query := r.db.WithContext(ctx)
+	if guildID != 0 {
+		query = query.Where("guild_id = ?", guildID)
+	}
+	if err := query.Order("created_at").Find(&channels).Error; err != nil {
+		return nil, fmt.Errorf("failed to list help channels: %w", err)
+	}
+	return channels, nil
+}
@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/repository/postgres"
+	"discord-tars/internal/secrets"
+
+	"gorm.io/gorm"
+)
+
+type HighlightRepository struct {
+	db      *postgres.GormDB
+	content fieldCipher
+}
+
+func NewHighlightRepository(db *postgres.GormDB) *HighlightRepository {
+	return &HighlightRepository{db: db}
+}
+
+// SetCipher encrypts copied message content at rest; reads decrypt transparently
+func (r *HighlightRepository) SetCipher(cipher *secrets.Cipher) {
+	r.content = fieldCipher{cipher: cipher}
+}
+
+// SaveConfig creates or replaces a guild's starboard configuration
+func (r *HighlightRepository) SaveConfig(ctx context.Context, cfg *models.HighlightConfig) error {
+	if err := r.db.WithContext(ctx).Save(cfg).Error; err != nil {
+		log.Printf("❌ Failed to save highlight config: %v", err)
+		return fmt.Errorf("failed to save highlight config: %w", err)
+	}
+	return nil
+}
+
+// GetConfig returns a guild's starboard configuration, or nil if none
+func (r *HighlightRepository) GetConfig(ctx context.Context, guildID int64) (*models.HighlightConfig, error) {
+	var cfg models.HighlightConfig
+	err := r.db.WithContext(ctx).Where("guild_id = ?", guildID).First(&cfg).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get highlight config: %w", err)
+	}
+	return &cfg, nil
+}
+
+// DeleteConfig turns a guild's starboard off; its highlights are kept
+func (r *HighlightRepository) DeleteConfig(ctx context.Context, guildID int64) (bool, error) {
+	result := r.db.WithContext(ctx).Where("guild_id = ?", guildID).Delete(&models.HighlightConfig{})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to delete highlight config: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// ListDigestConfigs returns the starboards with a weekly "best of"
+func (r *HighlightRepository) ListDigestConfigs(ctx context.Context) ([]models.HighlightConfig, error) {
+	var configs []models.HighlightConfig
+	if err := r.db.WithContext(ctx).Where("weekly_digest = ?", true).Find(&configs).Error; err != nil {
+		return nil, fmt.Errorf("failed to list highlight configs: %w", err)
+	}
+	return configs, nil
+}
+
+// MarkDigestSent records when a guild's "best of" was last posted
+func (r *HighlightRepository) MarkDigestSent(ctx context.Context, guildID int64, sentAt time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.HighlightConfig{}).
+		Where("guild_id = ?", guildID).
+		Update("last_digest", sentAt).Error
+}
+
+// GetHighlight returns the highlight of a message, or nil if it has none
+func (r *HighlightRepository) GetHighlight(ctx context.Context, messageID int64) (*models.Highlight, error) {
+	var highlight models.Highlight
+	err := r.db.WithContext(ctx).Where("message_id = ?", messageID).First(&highlight).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get highlight: %w", err)
+	}
+	highlight.Content = r.content.open(highlight.Content)
+	return &highlight, nil
+}
+
+// SaveHighlight stores a new highlight
+func (r *HighlightRepository) SaveHighlight(ctx context.Context, highlight *models.Highlight) error {
+	content, err := r.content.seal(highlight.Content)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt highlight: %w", err)
+	}
+	row := *highlight
+	row.Content = content
+	if err := r.db.WithContext(ctx).Create(&row).Error; err != nil {
+		log.Printf("❌ Failed to store highlight of message ID: %d: %v", highlight.MessageID, err)
+		return fmt.Errorf("failed to store highlight: %w", err)
+	}
+	highlight.CreatedAt = row.CreatedAt
+	return nil
+}
+
+// UpdateReactions records a highlight's current reaction count
+func (r *HighlightRepository) UpdateReactions(ctx context.Context, messageID int64, reactions int) error {
+	return r.db.WithContext(ctx).Model(&models.Highlight{}).
+		Where("message_id = ?", messageID).
+		Update("reactions", reactions).Error
+}
+
+// ListHighlights returns a guild's highlights since a time, most reactions first
+func (r *HighlightRepository) ListHighlights(ctx context.Context, guildID int64, since time.Time, limit int) ([]models.Highlight, error) {
+	var highlights []models.Highlight
+	err := r.db.WithContext(ctx).
+		Where("guild_id = ? AND created_at >= ?", guildID, since).
+		Order("reactions DESC, created_at").
+		Limit(limit).
+		Find(&highlights).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list highlights: %w", err)
+	}
+	for n := range highlights {
+		highlights[n].Content = r.content.open(highlights[n].Content)
+	}
+	return highlights, nil
+}
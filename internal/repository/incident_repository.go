@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/repository/postgres"
+
+	"gorm.io/gorm"
+)
+
+type IncidentRepository struct {
+	db *postgres.GormDB
+}
+
+func NewIncidentRepository(db *postgres.GormDB) *IncidentRepository {
+	return &IncidentRepository{db: db}
+}
+
+// CreateIncident stores a new incident with its first timeline entry
+func (r *IncidentRepository) CreateIncident(ctx context.Context, incident *models.Incident, first *models.IncidentUpdate) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(incident).Error; err != nil {
+			return err
+		}
+		first.IncidentID = incident.ID
+		return tx.Create(first).Error
+	})
+	if err != nil {
+		log.Printf("❌ Failed to create incident: %v", err)
+		return fmt.Errorf("failed to create incident: %w", err)
+	}
+	return nil
+}
+
+// GetByThread returns the incident discussed in a thread, or nil if there is none
+func (r *IncidentRepository) GetByThread(ctx context.Context, threadID int64) (*models.Incident, error) {
+	var incident models.Incident
+	err := r.db.WithContext(ctx).Where("thread_id = ?", threadID).First(&incident).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get incident: %w", err)
+	}
+	return &incident, nil
+}
+
+// AddUpdate appends a timeline entry and moves the incident to its status
+func (r *IncidentRepository) AddUpdate(ctx context.Context, update *models.IncidentUpdate) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(update).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Incident{}).
+			Where("id = ?", update.IncidentID).
+			Update("status", update.Status).Error
+	})
+	if err != nil {
+		log.Printf("❌ Failed to add incident update: %v", err)
+		return fmt.Errorf("failed to add incident update: %w", err)
+	}
+	return nil
+}
+
+// Resolve marks an open incident resolved with its closing timeline entry,
+// reporting false if it was already resolved
+func (r *IncidentRepository) Resolve(ctx context.Context, update *models.IncidentUpdate, resolvedAt time.Time) (bool, error) {
+	claimed := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Incident{}).
+			Where("id = ? AND resolved_at IS NULL", update.IncidentID).
+			Updates(map[string]interface{}{
+				"status":      models.IncidentResolved,
+				"resolved_by": update.AuthorID,
+				"resolved_at": resolvedAt,
+			})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		claimed = true
+		return tx.Create(update).Error
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to resolve incident: %w", err)
+	}
+	return claimed, nil
+}
+
+// SetPostmortem stores the postmortem draft of an incident
+func (r *IncidentRepository) SetPostmortem(ctx context.Context, incidentID int64, postmortem string) error {
+	return r.db.WithContext(ctx).Model(&models.Incident{}).
+		Where("id = ?", incidentID).Update("postmortem", postmortem).Error
+}
+
+// ListUpdates returns an incident's timeline, oldest first
+func (r *IncidentRepository) ListUpdates(ctx context.Context, incidentID int64) ([]models.IncidentUpdate, error) {
+	var updates []models.IncidentUpdate
+	if err := r.db.WithContext(ctx).Where("incident_id = ?", incidentID).Order("created_at, id").Find(&updates).Error; err != nil {
+		return nil, fmt.Errorf("failed to list incident updates: %w", err)
+	}
+	return updates, nil
+}
@@ -0,0 +1,201 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/repository/postgres"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type IndexRepository struct {
+	db *postgres.GormDB
+}
+
+func NewIndexRepository(db *postgres.GormDB) *IndexRepository {
+	return &IndexRepository{db: db}
+}
+
+// GetStats computes index health for a guild
+func (r *IndexRepository) GetStats(ctx context.Context, guildID int64) (*models.IndexStats, error) {
+	var row struct {
+		Messages   int64
+		Embeddable int64
+		Embedded   int64
+		Pending    int64
+		Filtered   int64
+		Oldest     *time.Time
+		Newest     *time.Time
+	}
+	// Whitespace-only messages are never embedded; sealed content is never
+	// blank. Messages the ingest filters skipped aren't waiting for anything.
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT COUNT(*) AS messages,
+			COUNT(*) FILTER (WHERE btrim(m.content) <> '' AND (f.message_id IS NULL OR e.message_id IS NOT NULL)) AS embeddable,
+			COUNT(e.message_id) AS embedded,
+			COUNT(*) FILTER (WHERE btrim(m.content) <> '' AND f.message_id IS NULL AND e.message_id IS NULL) AS pending,
+			COUNT(f.message_id) FILTER (WHERE e.message_id IS NULL) AS filtered,
+			MIN(m.timestamp) AS oldest,
+			MAX(m.timestamp) AS newest
+		FROM messages m
+		LEFT JOIN message_embeddings e ON e.message_id = m.id
+		LEFT JOIN filtered_messages f ON f.message_id = m.id
+		WHERE m.guild_id = ?`, guildID).Scan(&row).Error
+	if err != nil {
+		log.Printf("❌ Failed to compute index stats for guild ID: %d: %v", guildID, err)
+		return nil, fmt.Errorf("failed to compute index stats: %w", err)
+	}
+
+	stats := &models.IndexStats{
+		GuildID:         guildID,
+		Messages:        row.Messages,
+		Embeddable:      row.Embeddable,
+		Embedded:        row.Embedded,
+		Pending:         row.Pending,
+		Filtered:        row.Filtered,
+		Oldest:          row.Oldest,
+		Newest:          row.Newest,
+		EmbeddingModels: make(map[string]int64),
+		FilteredReasons: make(map[string]int64),
+	}
+
+	var modelCounts []struct {
+		ModelName string
+		Count     int64
+	}
+	err = r.db.WithContext(ctx).Raw(`
+		SELECT e.model_name, COUNT(*) AS count
+		FROM message_embeddings e
+		JOIN messages m ON m.id = e.message_id
+		WHERE m.guild_id = ?
+		GROUP BY e.model_name`, guildID).Scan(&modelCounts).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to count embedding models: %w", err)
+	}
+	for _, mc := range modelCounts {
+		stats.EmbeddingModels[mc.ModelName] = mc.Count
+	}
+
+	var reasonCounts []struct {
+		Reason string
+		Count  int64
+	}
+	err = r.db.WithContext(ctx).Raw(`
+		SELECT f.reason, COUNT(*) AS count
+		FROM filtered_messages f
+		LEFT JOIN message_embeddings e ON e.message_id = f.message_id
+		WHERE f.guild_id = ? AND e.message_id IS NULL
+		GROUP BY f.reason`, guildID).Scan(&reasonCounts).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to count filtered messages: %w", err)
+	}
+	for _, rc := range reasonCounts {
+		stats.FilteredReasons[rc.Reason] = rc.Count
+	}
+
+	if err := r.db.WithContext(ctx).Model(&models.PriorityDocument{}).
+		Where("guild_id = ?", guildID).Count(&stats.PriorityDocuments).Error; err != nil {
+		return nil, fmt.Errorf("failed to count priority documents: %w", err)
+	}
+
+	if stats.LastRun, err = r.LastRun(ctx, guildID); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// MarkFiltered records that the ingest filters kept a stored message from
+// being embedded, and why
+func (r *IndexRepository) MarkFiltered(ctx context.Context, filtered *models.FilteredMessage) error {
+	err := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "message_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"reason"}),
+	}).Create(filtered).Error
+	if err != nil {
+		return fmt.Errorf("failed to record filtered message: %w", err)
+	}
+	return nil
+}
+
+// ListGuildIDs returns every guild with stored messages
+func (r *IndexRepository) ListGuildIDs(ctx context.Context) ([]int64, error) {
+	var ids []int64
+	if err := r.db.WithContext(ctx).Model(&models.Message{}).Distinct().Order("guild_id").Pluck("guild_id", &ids).Error; err != nil {
+		return nil, fmt.Errorf("failed to list indexed guilds: %w", err)
+	}
+	return ids, nil
+}
+
+// CountMessages returns the number of stored messages across all guilds
+func (r *IndexRepository) CountMessages(ctx context.Context) (int64, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&models.Message{}).Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to count messages: %w", err)
+	}
+	return count, nil
+}
+
+// StartRun records the start of an indexing run
+func (r *IndexRepository) StartRun(ctx context.Context, run *models.IndexRun) error {
+	run.Status = models.IndexRunRunning
+	if run.StartedAt.IsZero() {
+		run.StartedAt = time.Now()
+	}
+	if err := r.db.WithContext(ctx).Create(run).Error; err != nil {
+		return fmt.Errorf("failed to record index run: %w", err)
+	}
+	return nil
+}
+
+// UpdateProgress stores a running job's progress
+func (r *IndexRepository) UpdateProgress(ctx context.Context, runID int64, processed, total int) error {
+	err := r.db.WithContext(ctx).Model(&models.IndexRun{}).Where("id = ?", runID).
+		Updates(map[string]interface{}{"processed": processed, "total": total}).Error
+	if err != nil {
+		return fmt.Errorf("failed to update index run: %w", err)
+	}
+	return nil
+}
+
+// FinishRun records how a run ended
+func (r *IndexRepository) FinishRun(ctx context.Context, run *models.IndexRun) error {
+	now := time.Now()
+	run.FinishedAt = &now
+	if err := r.db.WithContext(ctx).Save(run).Error; err != nil {
+		return fmt.Errorf("failed to finish index run: %w", err)
+	}
+	return nil
+}
+
+// LastRun returns a guild's most recent indexing run, or nil if none
+func (r *IndexRepository) LastRun(ctx context.Context, guildID int64) (*models.IndexRun, error) {
+	var run models.IndexRun
+	err := r.db.WithContext(ctx).Where("guild_id = ?", guildID).Order("started_at DESC").First(&run).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last index run: %w", err)
+	}
+	return &run, nil
+}
+
+// InterruptRuns marks runs left running by a previous process as failed
+func (r *IndexRepository) InterruptRuns(ctx context.Context) error {
+	err := r.db.WithContext(ctx).Model(&models.IndexRun{}).Where("status = ?", models.IndexRunRunning).
+		Updates(map[string]interface{}{
+			"status":      models.IndexRunFailed,
+			"error":       "interrupted by a restart",
+			"finished_at": time.Now(),
+		}).Error
+	if err != nil {
+		return fmt.Errorf("failed to close interrupted index runs: %w", err)
+	}
+	return nil
+}
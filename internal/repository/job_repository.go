@@ -0,0 +1,319 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/repository/postgres"
+	"discord-tars/internal/secrets"
+
+	"gorm.io/gorm"
+)
+
+type JobRepository struct {
+	db      *postgres.GormDB
+	payload fieldCipher
+}
+
+func NewJobRepository(db *postgres.GormDB) *JobRepository {
+	return &JobRepository{db: db}
+}
+
+// SetCipher encrypts job payloads at rest, as they can carry message content;
+// reads decrypt transparently
+func (r *JobRepository) SetCipher(cipher *secrets.Cipher) {
+	r.payload = fieldCipher{cipher: cipher}
+}
+
+// create stores a new job, sealing its payload
+func (r *JobRepository) create(tx *gorm.DB, job *models.Job) error {
+	payload, err := r.payload.seal(job.Payload)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt job payload: %w", err)
+	}
+	if job.RunAt.IsZero() {
+		job.RunAt = time.Now()
+	}
+	row := *job
+	row.Payload = payload
+	if err := tx.Create(&row).Error; err != nil {
+		return err
+	}
+	job.ID, job.CreatedAt, job.UpdatedAt = row.ID, row.CreatedAt, row.UpdatedAt
+	return nil
+}
+
+func (r *JobRepository) openAll(jobs []models.Job) {
+	for n := range jobs {
+		jobs[n].Payload = r.payload.open(jobs[n].Payload)
+	}
+}
+
+// Enqueue adds a job to run at job.RunAt, or now
+func (r *JobRepository) Enqueue(ctx context.Context, job *models.Job) error {
+	job.Status = models.JobQueued
+	if err := r.create(r.db.WithContext(ctx), job); err != nil {
+		return fmt.Errorf("failed to enqueue %s job: %w", job.Kind, err)
+	}
+	return nil
+}
+
+// EnqueueOnce adds a job unless one of the same kind is already queued or
+// running, so periodic jobs don't pile up behind a slow run. It reports
+// whether the job was added.
+func (r *JobRepository) EnqueueOnce(ctx context.Context, job *models.Job) (bool, error) {
+	added := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Serializes workers enqueueing the same kind at the same tick
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", "jobs:"+job.Kind).Error; err != nil {
+			return err
+		}
+		var pending int64
+		err := tx.Model(&models.Job{}).
+			Where("kind = ? AND status IN ?", job.Kind, []string{models.JobQueued, models.JobRunning}).
+			Count(&pending).Error
+		if err != nil || pending > 0 {
+			return err
+		}
+		job.Status = models.JobQueued
+		added = true
+		return r.create(tx, job)
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to enqueue %s job: %w", job.Kind, err)
+	}
+	return added, nil
+}
+
+// StartJob records a job that a worker runs right away rather than queueing it
+func (r *JobRepository) StartJob(ctx context.Context, job *models.Job, worker string) error {
+	now := time.Now()
+	job.Status = models.JobRunning
+	job.Attempts = 1
+	job.LockedBy = worker
+	job.StartedAt = &now
+	if err := r.create(r.db.WithContext(ctx), job); err != nil {
+		return fmt.Errorf("failed to record %s job: %w", job.Kind, err)
+	}
+	return nil
+}
+
+// Claim takes the next due job of a kind for a worker, or returns nil when
+// there is none. Concurrent workers never claim the same job.
+func (r *JobRepository) Claim(ctx context.Context, kind, worker string) (*models.Job, error) {
+	var job models.Job
+	err := r.db.WithContext(ctx).Raw(`
+		UPDATE jobs
+		SET status = ?, attempts = attempts + 1, locked_by = ?, started_at = NOW(), updated_at = NOW()
+		WHERE id = (
+			SELECT id FROM jobs
+			WHERE kind = ? AND status = ? AND run_at <= NOW()
+			ORDER BY run_at, id
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`,
+		models.JobRunning, worker, kind, models.JobQueued,
+	).Scan(&job).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim %s job: %w", kind, err)
+	}
+	if job.ID == 0 {
+		return nil, nil
+	}
+	job.Payload = r.payload.open(job.Payload)
+	return &job, nil
+}
+
+// finishRunning updates a running job; a job cancelled meanwhile stays cancelled
+func (r *JobRepository) finishRunning(ctx context.Context, jobID int64, updates map[string]interface{}) error {
+	return r.db.WithContext(ctx).Model(&models.Job{}).
+		Where("id = ? AND status = ?", jobID, models.JobRunning).
+		Updates(updates).Error
+}
+
+// Complete marks a job as done
+func (r *JobRepository) Complete(ctx context.Context, jobID int64) error {
+	return r.finishRunning(ctx, jobID, map[string]interface{}{
+		"status":      models.JobSucceeded,
+		"last_error":  "",
+		"finished_at": time.Now(),
+	})
+}
+
+// Fail records a failed attempt: the job runs again at retryAt, or is
+// marked failed for good when retryAt is nil
+func (r *JobRepository) Fail(ctx context.Context, jobID int64, cause error, retryAt *time.Time) error {
+	updates := map[string]interface{}{
+		"status":     models.JobFailed,
+		"last_error": cause.Error(),
+	}
+	if retryAt != nil {
+		updates["status"] = models.JobQueued
+		updates["run_at"] = *retryAt
+		updates["locked_by"] = ""
+	} else {
+		updates["finished_at"] = time.Now()
+	}
+	return r.finishRunning(ctx, jobID, updates)
+}
+
+// MarkCancelled records that a running job stopped because it was cancelled
+func (r *JobRepository) MarkCancelled(ctx context.Context, jobID int64) error {
+	return r.finishRunning(ctx, jobID, map[string]interface{}{
+		"status":      models.JobCancelled,
+		"finished_at": time.Now(),
+	})
+}
+
+// Release puts a job interrupted by a shutdown back in the queue without
+// counting the attempt
+func (r *JobRepository) Release(ctx context.Context, jobID int64) error {
+	return r.finishRunning(ctx, jobID, map[string]interface{}{
+		"status":    models.JobQueued,
+		"attempts":  gorm.Expr("attempts - 1"),
+		"locked_by": "",
+	})
+}
+
+// RequeueStale puts back jobs of a kind still running since before a time,
+// left behind by a worker that stopped mid-job. Their attempt still counts, so
+// jobs that were on their last one are marked failed instead.
+func (r *JobRepository) RequeueStale(ctx context.Context, kind string, before time.Time) (int64, error) {
+	const cause = "worker stopped while running the job"
+	stale := r.db.WithContext(ctx).Model(&models.Job{}).
+		Where("kind = ? AND status = ? AND started_at < ?", kind, models.JobRunning, before)
+
+	err := stale.Session(&gorm.Session{}).Where("attempts >= max_attempts").
+		Updates(map[string]interface{}{
+			"status":      models.JobFailed,
+			"last_error":  cause,
+			"finished_at": time.Now(),
+		}).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to fail stale %s jobs: %w", kind, err)
+	}
+	result := stale.Session(&gorm.Session{}).Where("attempts < max_attempts").
+		Updates(map[string]interface{}{
+			"status":     models.JobQueued,
+			"locked_by":  "",
+			"run_at":     time.Now(),
+			"last_error": cause,
+		})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to requeue stale %s jobs: %w", kind, result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// GetGuildJob returns one of a guild's jobs, or nil if it has no such job
+func (r *JobRepository) GetGuildJob(ctx context.Context, guildID, jobID int64) (*models.Job, error) {
+	var job models.Job
+	err := r.db.WithContext(ctx).Where("id = ? AND guild_id = ?", jobID, guildID).First(&job).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+	job.Payload = r.payload.open(job.Payload)
+	return &job, nil
+}
+
+// Cancel stops a guild's queued or running job, reporting whether it was
+// either; runners notice running jobs being cancelled within a poll
+func (r *JobRepository) Cancel(ctx context.Context, guildID, jobID int64) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.Job{}).
+		Where("id = ? AND guild_id = ? AND status IN ?", jobID, guildID, []string{models.JobQueued, models.JobRunning}).
+		Updates(map[string]interface{}{
+			"status":      models.JobCancelled,
+			"finished_at": time.Now(),
+		})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to cancel job: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// Retry queues a guild's failed or cancelled job again with fresh attempts,
+// reporting whether it was either
+func (r *JobRepository) Retry(ctx context.Context, guildID, jobID int64) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.Job{}).
+		Where("id = ? AND guild_id = ? AND status IN ?", jobID, guildID, []string{models.JobFailed, models.JobCancelled}).
+		Updates(map[string]interface{}{
+			"status":      models.JobQueued,
+			"attempts":    0,
+			"run_at":      time.Now(),
+			"locked_by":   "",
+			"finished_at": nil,
+		})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to retry job: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// CancelledAmong returns which of the given jobs have been cancelled
+func (r *JobRepository) CancelledAmong(ctx context.Context, jobIDs []int64) ([]int64, error) {
+	var cancelled []int64
+	if len(jobIDs) == 0 {
+		return nil, nil
+	}
+	err := r.db.WithContext(ctx).Model(&models.Job{}).
+		Where("id IN ? AND status = ?", jobIDs, models.JobCancelled).
+		Pluck("id", &cancelled).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to check cancelled jobs: %w", err)
+	}
+	return cancelled, nil
+}
+
+// ListJobs returns the most recently updated jobs, optionally of one guild
+// (guildID > 0) and one status
+func (r *JobRepository) ListJobs(ctx context.Context, guildID int64, status string, limit int) ([]models.Job, error) {
+	query := r.db.WithContext(ctx).Order("updated_at DESC").Limit(limit)
+	if guildID > 0 {
+		query = query.Where("guild_id = ?", guildID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var jobs []models.Job
+	if err := query.Find(&jobs).Error; err != nil {
+		return nil, fmt.Errorf("failed to list jobs: %w", err)
+	}
+	r.openAll(jobs)
+	return jobs, nil
+}
+
+// Stats counts jobs by kind and status, optionally of one guild (guildID > 0)
+func (r *JobRepository) Stats(ctx context.Context, guildID int64) ([]models.JobStats, error) {
+	query := r.db.WithContext(ctx).Model(&models.Job{})
+	if guildID > 0 {
+		query = query.Where("guild_id = ?", guildID)
+	}
+	var stats []models.JobStats
+	err := query.
+		Select("kind, status, COUNT(*) AS count").
+		Group("kind, status").
+		Order("kind, status").
+		Scan(&stats).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to count jobs: %w", err)
+	}
+	return stats, nil
+}
+
+// PruneFinished deletes succeeded, failed and cancelled jobs finished before a time
+func (r *JobRepository) PruneFinished(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Where("status IN ? AND finished_at < ?", []string{models.JobSucceeded, models.JobFailed, models.JobCancelled}, before).
+		Delete(&models.Job{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to prune jobs: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
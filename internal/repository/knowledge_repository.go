@@ -0,0 +1,201 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/pgvector"
+	"discord-tars/internal/repository/postgres"
+
+	"gorm.io/gorm"
+)
+
+type KnowledgeRepository struct {
+	db *postgres.GormDB
+}
+
+func NewKnowledgeRepository(db *postgres.GormDB) *KnowledgeRepository {
+	return &KnowledgeRepository{db: db}
+}
+
+// AddSource stores a knowledge source
+func (r *KnowledgeRepository) AddSource(ctx context.Context, source *models.KnowledgeSource) error {
+	if err := r.db.WithContext(ctx).Create(source).Error; err != nil {
+		log.Printf("❌ Failed to save knowledge source: %v", err)
+		return fmt.Errorf("failed to save knowledge source: %w", err)
+	}
+	log.Printf("💾 Saved %s knowledge source %d", source.Provider, source.ID)
+	return nil
+}
+
+// GetSource returns a guild's knowledge source, or nil if none
+func (r *KnowledgeRepository) GetSource(ctx context.Context, guildID, sourceID int64) (*models.KnowledgeSource, error) {
+	var source models.KnowledgeSource
+	err := r.db.WithContext(ctx).Where("id = ? AND guild_id = ?", sourceID, guildID).First(&source).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get knowledge source: %w", err)
+	}
+	return &source, nil
+}
+
+// ListSources returns a guild's knowledge sources, or every source when guildID is 0
+func (r *KnowledgeRepository) ListSources(ctx context.Context, guildID int64) ([]models.KnowledgeSource, error) {
+	var sources []models.KnowledgeSource
+	query := r.db.WithContext(ctx).Order("id")
+	if guildID != 0 {
+		query = query.Where("guild_id = ?", guildID)
+	}
+	if err := query.Find(&sources).Error; err != nil {
+		return nil, fmt.Errorf("failed to list knowledge sources: %w", err)
+	}
+	return sources, nil
+}
+
+// RemoveSource deletes a guild's knowledge source with its documents and chunks
+func (r *KnowledgeRepository) RemoveSource(ctx context.Context, guildID, sourceID int64) (bool, error) {
+	var removed bool
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ? AND guild_id = ?", sourceID, guildID).Delete(&models.KnowledgeSource{})
+		if result.Error != nil {
+			return result.Error
+		}
+		removed = result.RowsAffected > 0
+		if !removed {
+			return nil
+		}
+		docs := tx.Model(&models.KnowledgeDocument{}).Select("id").Where("source_id = ?", sourceID)
+		if err := tx.Where("document_id IN (?)", docs).Delete(&models.KnowledgeChunk{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("source_id = ?", sourceID).Delete(&models.KnowledgeDocument{}).Error
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to delete knowledge source: %w", err)
+	}
+	return removed, nil
+}
+
+// MarkSynced records the outcome of a sync
+func (r *KnowledgeRepository) MarkSynced(ctx context.Context, sourceID int64, lastError string, at time.Time) error {
+	err := r.db.WithContext(ctx).Model(&models.KnowledgeSource{}).
+		Where("id = ?", sourceID).
+		Updates(map[string]interface{}{"last_synced_at": at, "last_error": lastError}).Error
+	if err != nil {
+		return fmt.Errorf("failed to update knowledge source: %w", err)
+	}
+	return nil
+}
+
+// ListDocuments returns the documents synced from a source
+func (r *KnowledgeRepository) ListDocuments(ctx context.Context, sourceID int64) ([]models.KnowledgeDocument, error) {
+	var docs []models.KnowledgeDocument
+	if err := r.db.WithContext(ctx).Where("source_id = ?", sourceID).Find(&docs).Error; err != nil {
+		return nil, fmt.Errorf("failed to list knowledge documents: %w", err)
+	}
+	return docs, nil
+}
+
+// SaveDocument upserts a document and replaces its chunks
+func (r *KnowledgeRepository) SaveDocument(ctx context.Context, doc *models.KnowledgeDocument, chunks []string, embeddings [][]float32) error {
+	if len(chunks) != len(embeddings) {
+		return fmt.Errorf("got %d chunks but %d embeddings", len(chunks), len(embeddings))
+	}
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(doc).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("document_id = ?", doc.ID).Delete(&models.KnowledgeChunk{}).Error; err != nil {
+			return err
+		}
+		for i, content := range chunks {
+			chunk := models.KnowledgeChunk{
+				DocumentID: doc.ID,
+				GuildID:    doc.GuildID,
+				ChunkIndex: i,
+				Content:    content,
+				Embedding:  pgvector.NewVector(embeddings[i]),
+			}
+			if err := tx.Create(&chunk).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("❌ Failed to save knowledge document %s: %v", doc.ExternalID, err)
+		return fmt.Errorf("failed to save knowledge document: %w", err)
+	}
+	return nil
+}
+
+// TouchDocument records that an unchanged document was seen at a newer revision
+func (r *KnowledgeRepository) TouchDocument(ctx context.Context, doc *models.KnowledgeDocument) error {
+	err := r.db.WithContext(ctx).Model(doc).
+		Updates(map[string]interface{}{"title": doc.Title, "url": doc.URL, "source_updated_at": doc.SourceUpdatedAt}).Error
+	if err != nil {
+		return fmt.Errorf("failed to update knowledge document: %w", err)
+	}
+	return nil
+}
+
+// DeleteDocuments removes documents and their chunks
+func (r *KnowledgeRepository) DeleteDocuments(ctx context.Context, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("document_id IN ?", ids).Delete(&models.KnowledgeChunk{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id IN ?", ids).Delete(&models.KnowledgeDocument{}).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete knowledge documents: %w", err)
+	}
+	return nil
+}
+
+// Search finds the document chunks of a guild most similar to the query
+func (r *KnowledgeRepository) Search(ctx context.Context, guildID int64, queryEmbedding []float32, limit int, similarity float64) ([]models.KnowledgeResult, error) {
+	query := `
+		SELECT c.id, c.document_id, c.guild_id, c.chunk_index, c.content, d.title, d.url,
+			1 - (c.embedding <=> $1::vector) as similarity
+		FROM knowledge_chunks c
+		JOIN knowledge_documents d ON d.id = c.document_id
+		WHERE c.guild_id = $2 AND 1 - (c.embedding <=> $1::vector) > $3
+		ORDER BY c.embedding <=> $1::vector
+		LIMIT $4
+	`
+
+	rows, err := r.db.TimedRows(ctx, "knowledge vector search", query, pgvector.NewVector(queryEmbedding), guildID, similarity, limit)
+	if err != nil {
+		log.Printf("❌ Failed to execute knowledge search query: %v", err)
+		return nil, fmt.Errorf("failed to search knowledge documents: %w", err)
+	}
+	defer rows.Close()
+
+	var results []models.KnowledgeResult
+	for rows.Next() {
+		var result models.KnowledgeResult
+		chunk := &result.Chunk
+		if err := rows.Scan(&chunk.ID, &chunk.DocumentID, &chunk.GuildID, &chunk.ChunkIndex, &chunk.Content,
+			&result.Title, &result.URL, &result.Similarity); err != nil {
+			return nil, fmt.Errorf("failed to scan knowledge result: %w", err)
+		}
+		results = append(results, result)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read knowledge results: %w", err)
+	}
+
+	log.Printf("✅ Knowledge search returned %d results", len(results))
+	return results, nil
+}
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/repository/postgres"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type LanguageRepository struct {
+	db *postgres.GormDB
+}
+
+func NewLanguageRepository(db *postgres.GormDB) *LanguageRepository {
+	return &LanguageRepository{db: db}
+}
+
+// StoreMessageLanguage records the language of an indexed message, replacing
+// the one detected before, e.g. by a reindex
+func (r *LanguageRepository) StoreMessageLanguage(ctx context.Context, lang *models.MessageLanguage) error {
+	err := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "message_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"language"}),
+	}).Create(lang).Error
+	if err != nil {
+		log.Printf("❌ Failed to store language of message ID: %d: %v", lang.MessageID, err)
+		return fmt.Errorf("failed to store message language: %w", err)
+	}
+	return nil
+}
+
+// Shares counts a guild's indexed messages by language, most used first
+func (r *LanguageRepository) Shares(ctx context.Context, guildID int64) ([]models.LanguageShare, error) {
+	var shares []models.LanguageShare
+	err := r.db.WithContext(ctx).Model(&models.MessageLanguage{}).
+		Select("language, COUNT(*) AS messages").
+		Where("guild_id = ?", guildID).
+		Group("language").
+		Order("messages DESC").
+		Scan(&shares).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to count message languages: %w", err)
+	}
+	return shares, nil
+}
+
+// GetGuildLanguages returns the languages a guild's retrieval is restricted
+// to, or nil when it isn't
+func (r *LanguageRepository) GetGuildLanguages(ctx context.Context, guildID int64) (*models.GuildLanguages, error) {
+	var setting models.GuildLanguages
+	err := r.db.WithContext(ctx).Where("guild_id = ?", guildID).First(&setting).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get guild languages: %w", err)
+	}
+	return &setting, nil
+}
+
+// SaveGuildLanguages restricts a guild's retrieval to some languages
+func (r *LanguageRepository) SaveGuildLanguages(ctx context.Context, setting *models.GuildLanguages) error {
+	if err := r.db.WithContext(ctx).Save(setting).Error; err != nil {
+		log.Printf("❌ Failed to save guild languages: %v", err)
+		return fmt.Errorf("failed to save guild languages: %w", err)
+	}
+	return nil
+}
+
+// DeleteGuildLanguages lifts a guild's language restriction
+func (r *LanguageRepository) DeleteGuildLanguages(ctx context.Context, guildID int64) error {
+	if err := r.db.WithContext(ctx).Where("guild_id = ?", guildID).Delete(&models.GuildLanguages{}).Error; err != nil {
+		return fmt.Errorf("failed to delete guild languages: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/repository/postgres"
+
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+type MaintenanceRepository struct {
+	db *postgres.GormDB
+}
+
+func NewMaintenanceRepository(db *postgres.GormDB) *MaintenanceRepository {
+	return &MaintenanceRepository{db: db}
+}
+
+// VectorIndexes lists the pgvector indexes of the database with the size and
+// row counts of their tables
+func (r *MaintenanceRepository) VectorIndexes(ctx context.Context) ([]models.VectorIndexHealth, error) {
+	var rows []struct {
+		Name         string
+		TableName    string
+		Method       string
+		Options      string
+		SizeBytes    int64
+		Rows         int64
+		DeadRows     int64
+		Dimensions   int
+		LastAnalyzed *time.Time
+	}
+	// A vector column's type modifier is its number of dimensions
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT i.relname AS name, t.relname AS table_name, am.amname AS method,
+			COALESCE(array_to_string(i.reloptions, ','), '') AS options,
+			pg_relation_size(i.oid) AS size_bytes,
+			COALESCE(s.n_live_tup, 0) AS rows,
+			COALESCE(s.n_dead_tup, 0) AS dead_rows,
+			a.atttypmod AS dimensions,
+			GREATEST(s.last_analyze, s.last_autoanalyze) AS last_analyzed
+		FROM pg_index x
+		JOIN pg_class i ON i.oid = x.indexrelid
+		JOIN pg_class t ON t.oid = x.indrelid
+		JOIN pg_am am ON am.oid = i.relam
+		JOIN pg_attribute a ON a.attrelid = t.oid AND a.attnum = x.indkey[0]
+		LEFT JOIN pg_stat_user_tables s ON s.relid = t.oid
+		WHERE am.amname IN ('ivfflat', 'hnsw') AND t.relnamespace = 'public'::regnamespace
+		ORDER BY t.relname, i.relname`).Scan(&rows).Error
+	if err != nil {
+		log.Printf("❌ Failed to list vector indexes: %v", err)
+		return nil, fmt.Errorf("failed to list vector indexes: %w", err)
+	}
+
+	indexes := make([]models.VectorIndexHealth, len(rows))
+	for n, row := range rows {
+		indexes[n] = models.VectorIndexHealth{
+			Name:         row.Name,
+			Table:        row.TableName,
+			Method:       row.Method,
+			Options:      row.Options,
+			SizeBytes:    row.SizeBytes,
+			Rows:         row.Rows,
+			DeadRows:     row.DeadRows,
+			Dimensions:   row.Dimensions,
+			LastAnalyzed: row.LastAnalyzed,
+		}
+		if row.Method == "ivfflat" {
+			indexes[n].Lists = indexOption(row.Options, "lists", 100)
+		}
+	}
+	return indexes, nil
+}
+
+// indexOption reads an integer storage parameter of an index, e.g. "lists"
+// from "lists=100", or the default it has when unset
+func indexOption(options, name string, defaultValue int) int {
+	for _, option := range strings.Split(options, ",") {
+		if key, value, ok := strings.Cut(option, "="); ok && key == name {
+			if n, err := strconv.Atoi(value); err == nil {
+				return n
+			}
+		}
+	}
+	return defaultValue
+}
+
+// Analyze refreshes the planner statistics of a table
+func (r *MaintenanceRepository) Analyze(ctx context.Context, table string) error {
+	if err := r.db.WithContext(ctx).Exec("ANALYZE " + pq.QuoteIdentifier(table)).Error; err != nil {
+		return fmt.Errorf("failed to analyze %s: %w", table, err)
+	}
+	return nil
+}
+
+// Reindex rebuilds an index without blocking writes to its table, first
+// setting its ivfflat lists when lists isn't zero. A failed concurrent
+// rebuild leaves an invalid copy behind, which is dropped.
+func (r *MaintenanceRepository) Reindex(ctx context.Context, index string, lists int) error {
+	db := r.db.WithContext(ctx)
+	if lists > 0 {
+		if err := db.Exec(fmt.Sprintf("ALTER INDEX %s SET (lists = %d)", pq.QuoteIdentifier(index), lists)).Error; err != nil {
+			return fmt.Errorf("failed to set lists of %s: %w", index, err)
+		}
+	}
+	if err := db.Exec("REINDEX INDEX CONCURRENTLY " + pq.QuoteIdentifier(index)).Error; err != nil {
+		if dropErr := r.db.Exec("DROP INDEX CONCURRENTLY IF EXISTS " + pq.QuoteIdentifier(index+"_ccnew")).Error; dropErr != nil {
+			log.Printf("⚠️ Failed to drop the invalid copy of %s: %v", index, dropErr)
+		}
+		return fmt.Errorf("failed to reindex %s: %w", index, err)
+	}
+	return nil
+}
+
+// StartRun records the start of a maintenance run
+func (r *MaintenanceRepository) StartRun(ctx context.Context, run *models.MaintenanceRun) error {
+	run.Status = models.IndexRunRunning
+	if run.StartedAt.IsZero() {
+		run.StartedAt = time.Now()
+	}
+	if err := r.db.WithContext(ctx).Create(run).Error; err != nil {
+		return fmt.Errorf("failed to record maintenance run: %w", err)
+	}
+	return nil
+}
+
+// FinishRun records how a maintenance run ended
+func (r *MaintenanceRepository) FinishRun(ctx context.Context, run *models.MaintenanceRun) error {
+	now := time.Now()
+	run.FinishedAt = &now
+	if err := r.db.WithContext(ctx).Save(run).Error; err != nil {
+		return fmt.Errorf("failed to finish maintenance run: %w", err)
+	}
+	return nil
+}
+
+// LastRun returns the most recent maintenance run, or nil if none
+func (r *MaintenanceRepository) LastRun(ctx context.Context) (*models.MaintenanceRun, error) {
+	var run models.MaintenanceRun
+	err := r.db.WithContext(ctx).Order("started_at DESC").First(&run).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last maintenance run: %w", err)
+	}
+	return &run, nil
+}
+
+// ListRuns returns the latest maintenance runs, newest first
+func (r *MaintenanceRepository) ListRuns(ctx context.Context, limit int) ([]models.MaintenanceRun, error) {
+	var runs []models.MaintenanceRun
+	if err := r.db.WithContext(ctx).Order("started_at DESC").Limit(limit).Find(&runs).Error; err != nil {
+		return nil, fmt.Errorf("failed to list maintenance runs: %w", err)
+	}
+	return runs, nil
+}
@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/repository/postgres"
+	"discord-tars/internal/secrets"
+
+	"gorm.io/gorm"
+)
+
+type MemoryRepository struct {
+	db      *postgres.GormDB
+	content fieldCipher
+}
+
+func NewMemoryRepository(db *postgres.GormDB) *MemoryRepository {
+	return &MemoryRepository{db: db}
+}
+
+// SetCipher encrypts stored conversations at rest; reads decrypt transparently
+func (r *MemoryRepository) SetCipher(cipher *secrets.Cipher) {
+	r.content = fieldCipher{cipher: cipher}
+}
+
+// GetMemory returns a channel's conversation memory, or nil if none
+func (r *MemoryRepository) GetMemory(ctx context.Context, channelID int64) (*models.ConversationMemory, error) {
+	var memory models.ConversationMemory
+	err := r.db.WithContext(ctx).Where("channel_id = ?", channelID).First(&memory).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		log.Printf("❌ Failed to get conversation memory: %v", err)
+		return nil, fmt.Errorf("failed to get conversation memory: %w", err)
+	}
+	memory.Summary = r.content.open(memory.Summary)
+	memory.Turns = r.content.open(memory.Turns)
+	return &memory, nil
+}
+
+// SaveMemory creates or replaces a channel's conversation memory
+func (r *MemoryRepository) SaveMemory(ctx context.Context, memory *models.ConversationMemory) error {
+	row := *memory
+	var err error
+	if row.Summary, err = r.content.seal(memory.Summary); err != nil {
+		return fmt.Errorf("failed to encrypt conversation memory: %w", err)
+	}
+	if row.Turns, err = r.content.seal(memory.Turns); err != nil {
+		return fmt.Errorf("failed to encrypt conversation memory: %w", err)
+	}
+	if err := r.db.WithContext(ctx).Save(&row).Error; err != nil {
+		log.Printf("❌ Failed to save conversation memory: %v", err)
+		return fmt.Errorf("failed to save conversation memory: %w", err)
+	}
+	memory.UpdatedAt = row.UpdatedAt
+	return nil
+}
+
+// DeleteMemory forgets a channel's conversation
+func (r *MemoryRepository) DeleteMemory(ctx context.Context, channelID int64) error {
+	if err := r.db.WithContext(ctx).Where("channel_id = ?", channelID).Delete(&models.ConversationMemory{}).Error; err != nil {
+		return fmt.Errorf("failed to delete conversation memory: %w", err)
+	}
+	return nil
+}
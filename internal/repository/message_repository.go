@@ -4,25 +4,61 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"strings"
+	"math"
+	"sort"
+	"time"
 
 	"discord-tars/internal/models"
+	"discord-tars/internal/pgvector"
 	"discord-tars/internal/repository/postgres"
+	"discord-tars/internal/secrets"
+	"discord-tars/internal/vectorstore"
 
+	"github.com/lib/pq"
 	"gorm.io/gorm"
 )
 
 type MessageRepository struct {
-	db *postgres.GormDB
+	db            *postgres.GormDB
+	content       fieldCipher
+	diversity     diversity
+	reactionBoost float64
+	solvedBoost   float64
+	vectors       vectorstore.Store // Searches run in Postgres when nil
 }
 
 func NewMessageRepository(db *postgres.GormDB) *MessageRepository {
-	return &MessageRepository{db: db}
+	return &MessageRepository{
+		db:            db,
+		diversity:     diversity{duplicateThreshold: DefaultDuplicateThreshold, lambda: DefaultMMRLambda},
+		reactionBoost: DefaultReactionBoost,
+		solvedBoost:   DefaultSolvedBoost,
+	}
+}
+
+// SetDiversity configures near-duplicate filtering and MMR re-ranking of
+// search results; a threshold of 0 and a lambda of 1 turn both off
+func (r *MessageRepository) SetDiversity(duplicateThreshold, lambda float64) {
+	r.diversity = diversity{duplicateThreshold: duplicateThreshold, lambda: math.Max(0, math.Min(1, lambda))}
+}
+
+// SetCipher encrypts message content at rest; reads decrypt transparently
+func (r *MessageRepository) SetCipher(cipher *secrets.Cipher) {
+	r.content = fieldCipher{cipher: cipher}
 }
 
 // StoreMessage saves a message with its user and channel info
 func (r *MessageRepository) StoreMessage(ctx context.Context, msg *models.Message, user *models.User, channel *models.Channel, guild *models.Guild) error {
 	log.Printf("💾 Storing message ID: %d in database", msg.ID)
+	content, err := r.content.seal(msg.Content)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt message content: %w", err)
+	}
+	embeds, err := r.content.seal(msg.Embeds)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt message embeds: %w", err)
+	}
+
 	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		// Upsert guild
 		log.Printf("💾 Upserting guild ID: %d", guild.ID)
@@ -63,22 +99,25 @@ func (r *MessageRepository) StoreMessage(ctx context.Context, msg *models.Messag
 			return fmt.Errorf("failed to upsert user: %w", err)
 		}
 
-		// Upsert message
+		// Upsert message; the row holds the sealed content, the caller keeps plaintext.
+		// The guild narrows the lookup to one partition when messages is partitioned.
 		log.Printf("💾 Upserting message ID: %d", msg.ID)
-		if err := tx.Where("id = ?", msg.ID).
+		row := *msg
+		if err := tx.Where("guild_id = ? AND id = ?", msg.GuildID, msg.ID).
 			Assign(models.Message{
 				ChannelID:   msg.ChannelID,
 				UserID:      msg.UserID,
 				GuildID:     msg.GuildID,
-				Content:     msg.Content,
-				Embeds:      msg.Embeds,
+				Content:     content,
+				Embeds:      embeds,
 				Attachments: msg.Attachments,
 				Timestamp:   msg.Timestamp,
 			}).
-			FirstOrCreate(msg).Error; err != nil {
+			FirstOrCreate(&row).Error; err != nil {
 			log.Printf("❌ Failed to upsert message ID: %d: %v", msg.ID, err)
 			return fmt.Errorf("failed to upsert message: %w", err)
 		}
+		msg.CreatedAt = row.CreatedAt
 
 		log.Printf("✅ Successfully stored message ID: %d", msg.ID)
 		return nil
@@ -91,50 +130,118 @@ func (r *MessageRepository) StoreEmbedding(ctx context.Context, messageID int64,
 		modelName = "text-embedding-3-small"
 	}
 
-	// Convert []float32 to comma-separated string for pgvector
-	var vectorParts []string
-	for _, val := range embeddingData {
-		vectorParts = append(vectorParts, fmt.Sprintf("%g", val))
+	vector := pgvector.NewVector(embeddingData)
+	if err := vector.Validate(); err != nil {
+		log.Printf("❌ Refusing to store embedding for message ID: %d: %v", messageID, err)
+		return fmt.Errorf("failed to store embedding: %w", err)
 	}
-	vectorStr := fmt.Sprintf("[%s]", strings.Join(vectorParts, ","))
 
+	vectorStr := vector.String()
 	log.Printf("💾 Storing embedding for message ID: %d, vector: %s", messageID, vectorStr[:min(100, len(vectorStr))]+"...")
 
 	// Create or update embedding
-	embeddingRecord := models.MessageEmbedding{
-		MessageID: messageID,
-		Embedding: vectorStr,
-		ModelName: modelName,
-	}
-
-	result := r.db.WithContext(ctx).Where("message_id = ?", messageID).
-		Assign(models.MessageEmbedding{
-			Embedding: vectorStr,
-			ModelName: modelName,
-		}).
-		FirstOrCreate(&embeddingRecord)
+	result := r.db.WithContext(ctx).Exec(`
+		INSERT INTO message_embeddings (message_id, embedding, model_name, created_at, updated_at)
+		VALUES (?, ?, ?, NOW(), NOW())
+		ON CONFLICT (message_id) DO UPDATE
+		SET embedding = EXCLUDED.embedding, model_name = EXCLUDED.model_name, updated_at = NOW()`,
+		messageID, vector, modelName)
 
 	if result.Error != nil {
 		log.Printf("❌ Failed to store embedding for message ID: %d: %v", messageID, result.Error)
 		return fmt.Errorf("failed to store embedding: %w", result.Error)
 	}
 
+	if r.vectors != nil {
+		if err := r.upsertVector(ctx, messageID, embeddingData); err != nil {
+			log.Printf("❌ Failed to store embedding for message ID: %d in the vector store: %v", messageID, err)
+			return err
+		}
+	}
+
 	log.Printf("✅ Successfully stored embedding for message ID: %d", messageID)
 	return nil
 }
 
-// SearchSimilarMessages finds messages similar to the query using vector search
-func (r *MessageRepository) SearchSimilarMessages(ctx context.Context, queryEmbedding []float32, limit int, similarity float64) ([]models.SearchResult, error) {
-	log.Printf("🔍 Performing vector search with limit: %d, similarity threshold: %.2f", limit, similarity)
+// SearchSimilarMessages finds a guild's messages similar to the query using
+// vector search. The guild filter also keeps the search to the guild's
+// partition once messages are partitioned.
+func (r *MessageRepository) SearchSimilarMessages(ctx context.Context, guildID int64, queryEmbedding []float32, limit int, similarity float64) ([]models.SearchResult, error) {
+	log.Printf("🔍 Performing vector search in guild %d with limit: %d, similarity threshold: %.2f", guildID, limit, similarity)
+	return r.searchSimilar(ctx, guildID, queryEmbedding, limit, similarity, nil, nil)
+}
+
+// SearchSimilarMessagesInLanguages is SearchSimilarMessages leaving out
+// messages detected in other languages than the given ones. Messages whose
+// language wasn't detected, such as short replies, are kept.
+func (r *MessageRepository) SearchSimilarMessagesInLanguages(ctx context.Context, guildID int64, queryEmbedding []float32, languages []string, limit int, similarity float64) ([]models.SearchResult, error) {
+	log.Printf("🔍 Performing vector search in guild %d in languages %v with limit: %d, similarity threshold: %.2f", guildID, languages, limit, similarity)
+	return r.searchSimilar(ctx, guildID, queryEmbedding, limit, similarity, nil, languages)
+}
+
+// SearchSimilarMessagesInChannels is SearchSimilarMessages restricted to the
+// given channels and, unless languages is empty, to messages in those languages
+func (r *MessageRepository) SearchSimilarMessagesInChannels(ctx context.Context, guildID int64, queryEmbedding []float32, channelIDs []int64, languages []string, limit int, similarity float64) ([]models.SearchResult, error) {
+	log.Printf("🔍 Performing vector search in %d channels of guild %d with limit: %d, similarity threshold: %.2f", len(channelIDs), guildID, limit, similarity)
+	if len(channelIDs) == 0 {
+		return nil, nil
+	}
+	return r.searchSimilar(ctx, guildID, queryEmbedding, limit, similarity, channelIDs, languages)
+}
 
-	// Convert query embedding to vector format
-	var vectorParts []string
-	for _, val := range queryEmbedding {
-		vectorParts = append(vectorParts, fmt.Sprintf("%g", val))
+func (r *MessageRepository) searchSimilar(ctx context.Context, guildID int64, queryEmbedding []float32, limit int, similarity float64, channelIDs []int64, languages []string) ([]models.SearchResult, error) {
+	// Over-fetch so there are distinct candidates left after near-duplicates
+	// go, and well-received messages just past the limit can move up
+	fetch := limit
+	if r.diversity.enabled() || r.reactionBoost > 0 || r.solvedBoost > 0 {
+		fetch = limit * diversityOverfetch
 	}
-	vectorStr := fmt.Sprintf("[%s]", strings.Join(vectorParts, ","))
 
 	var results []models.SearchResult
+	var endorsements []endorsement
+	var embeddings []string
+	var err error
+	if r.vectors != nil {
+		results, endorsements, embeddings, err = r.searchVectorStore(ctx, queryEmbedding, vectorstore.Filter{GuildID: guildID, ChannelIDs: channelIDs}, fetch, similarity, languages)
+	} else {
+		results, endorsements, embeddings, err = r.searchPgvector(ctx, guildID, queryEmbedding, fetch, similarity, channelIDs, languages)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ranked := make([]models.SearchResult, 0, len(results))
+	var candidates []candidate
+	for _, index := range r.rankByReactions(results, endorsements) {
+		if r.diversity.enabled() {
+			relevance := r.relevance(results[index].Similarity, endorsements[index])
+			candidates = append(candidates, candidate{index: len(ranked), similarity: relevance, vector: parseVector(embeddings[index])})
+		}
+		ranked = append(ranked, results[index])
+	}
+	results = ranked
+
+	if r.diversity.enabled() && len(results) > 0 {
+		chosen := r.diversity.selectDiverse(candidates, limit)
+		sort.Ints(chosen) // Back to relevance order
+		diverse := make([]models.SearchResult, len(chosen))
+		for n, index := range chosen {
+			diverse[n] = results[index]
+		}
+		log.Printf("🧹 Kept %d distinct results of %d candidates", len(diverse), len(results))
+		results = diverse
+	} else if len(results) > limit {
+		results = results[:limit]
+	}
+
+	log.Printf("✅ Vector search returned %d results", len(results))
+	return results, nil
+}
+
+// searchPgvector runs a vector search in Postgres, returning up to fetch
+// candidates by similarity with what ranking them needs
+func (r *MessageRepository) searchPgvector(ctx context.Context, guildID int64, queryEmbedding []float32, fetch int, similarity float64, channelIDs []int64, languages []string) ([]models.SearchResult, []endorsement, []string, error) {
+	queryVector := pgvector.NewVector(queryEmbedding)
 
 	// Execute raw SQL for vector similarity search
 	query := `
@@ -142,48 +249,157 @@ func (r *MessageRepository) SearchSimilarMessages(ctx context.Context, queryEmbe
 			m.id, m.channel_id, m.user_id, m.guild_id, m.content, m.timestamp,
 			u.id as user_id, u.username, u.discriminator, u.avatar_url,
 			c.id as channel_id, c.name as channel_name, c.type as channel_type,
-			1 - (me.embedding <=> $1::vector) as similarity
+			1 - (me.embedding <=> $1::vector) as similarity,
+			me.embedding::text,
+			` + reactionsColumn + `,
+			` + solvedColumn + `
 		FROM message_embeddings me
 		JOIN messages m ON me.message_id = m.id
 		JOIN users u ON m.user_id = u.id
 		JOIN channels c ON m.channel_id = c.id
-		WHERE 1 - (me.embedding <=> $1::vector) > $2
+		WHERE 1 - (me.embedding <=> $1::vector) > $2`
+	args := []interface{}{queryVector, similarity, fetch}
+	if guildID != 0 {
+		args = append(args, guildID)
+		query += fmt.Sprintf(`
+		AND m.guild_id = $%d`, len(args))
+	}
+	if channelIDs != nil {
+		args = append(args, pq.Array(channelIDs))
+		query += fmt.Sprintf(`
+		AND m.channel_id = ANY($%d)`, len(args))
+	}
+	if len(languages) > 0 {
+		args = append(args, pq.Array(languages))
+		query += fmt.Sprintf(`
+		AND NOT EXISTS (SELECT 1 FROM message_languages ml WHERE ml.message_id = m.id AND ml.language <> ALL($%d))`, len(args))
+	}
+	query += `
 		ORDER BY me.embedding <=> $1::vector
 		LIMIT $3
 	`
 
-	rows, err := r.db.Raw(query, vectorStr, similarity, limit).Rows()
+	rows, err := r.db.TimedRows(ctx, "message vector search", query, args...)
 	if err != nil {
 		log.Printf("❌ Failed to execute vector search query: %v", err)
-		return nil, fmt.Errorf("failed to search similar messages: %w", err)
+		return nil, nil, nil, fmt.Errorf("failed to search similar messages: %w", err)
 	}
 	defer rows.Close()
+	return r.scanSearchResults(rows)
+}
 
+// scanSearchResults reads search candidates: the message, author, channel,
+// similarity, embedding and endorsements of each
+func (r *MessageRepository) scanSearchResults(rows *postgres.Rows) ([]models.SearchResult, []endorsement, []string, error) {
+	var results []models.SearchResult
+	var endorsements []endorsement
+	var embeddings []string
 	for rows.Next() {
 		var result models.SearchResult
 		var msg models.Message
 		var user models.User
 		var channel models.Channel
+		var embedding string
+		var e endorsement
 
 		err := rows.Scan(
 			&msg.ID, &msg.ChannelID, &msg.UserID, &msg.GuildID, &msg.Content, &msg.Timestamp,
 			&user.ID, &user.Username, &user.Discriminator, &user.Avatar,
 			&channel.ID, &channel.Name, &channel.Type,
 			&result.Similarity,
+			&embedding,
+			&e.reactions,
+			&e.solved,
 		)
 		if err != nil {
 			log.Printf("❌ Failed to scan search result: %v", err)
-			return nil, fmt.Errorf("failed to scan result: %w", err)
+			return nil, nil, nil, fmt.Errorf("failed to scan result: %w", err)
 		}
 
+		msg.Content = r.content.open(msg.Content)
 		result.Message = msg
 		result.User = user
 		result.Channel = channel
 		results = append(results, result)
+		endorsements = append(endorsements, e)
+		embeddings = append(embeddings, embedding)
 	}
+	return results, endorsements, embeddings, rows.Err()
+}
 
-	log.Printf("✅ Vector search returned %d results", len(results))
-	return results, nil
+// SearchGuildMessages finds a guild's messages similar to the query across all
+// its channels. Unlike SearchSimilarMessages it keeps near-duplicates, since
+// it is used to measure how much a topic was discussed and by whom.
+func (r *MessageRepository) SearchGuildMessages(ctx context.Context, guildID int64, queryEmbedding []float32, limit int, similarity float64) ([]models.SearchResult, error) {
+	if r.vectors != nil {
+		results, endorsements, _, err := r.searchVectorStore(ctx, queryEmbedding, vectorstore.Filter{GuildID: guildID}, limit, similarity, nil)
+		if err != nil {
+			return nil, err
+		}
+		return r.rankGuildResults(results, endorsements), nil
+	}
+
+	query := `
+		SELECT
+			m.id, m.channel_id, m.user_id, m.guild_id, m.content, m.timestamp,
+			u.id as user_id, u.username, u.discriminator, u.avatar_url,
+			c.id as channel_id, c.name as channel_name, c.type as channel_type,
+			1 - (me.embedding <=> $1::vector) as similarity,
+			` + reactionsColumn + `,
+			` + solvedColumn + `
+		FROM message_embeddings me
+		JOIN messages m ON me.message_id = m.id
+		JOIN users u ON m.user_id = u.id
+		JOIN channels c ON m.channel_id = c.id
+		WHERE m.guild_id = $2 AND 1 - (me.embedding <=> $1::vector) > $3
+		ORDER BY me.embedding <=> $1::vector
+		LIMIT $4
+	`
+
+	rows, err := r.db.TimedRows(ctx, "guild message vector search", query, pgvector.NewVector(queryEmbedding), guildID, similarity, limit)
+	if err != nil {
+		log.Printf("❌ Failed to execute guild vector search query: %v", err)
+		return nil, fmt.Errorf("failed to search guild messages: %w", err)
+	}
+	defer rows.Close()
+
+	var results []models.SearchResult
+	var endorsements []endorsement
+	for rows.Next() {
+		var result models.SearchResult
+		var e endorsement
+		msg, user, channel := &result.Message, &result.User, &result.Channel
+		err := rows.Scan(
+			&msg.ID, &msg.ChannelID, &msg.UserID, &msg.GuildID, &msg.Content, &msg.Timestamp,
+			&user.ID, &user.Username, &user.Discriminator, &user.Avatar,
+			&channel.ID, &channel.Name, &channel.Type,
+			&result.Similarity,
+			&e.reactions,
+			&e.solved,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan result: %w", err)
+		}
+		msg.Content = r.content.open(msg.Content)
+		results = append(results, result)
+		endorsements = append(endorsements, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read guild search results: %w", err)
+	}
+
+	return r.rankGuildResults(results, endorsements), nil
+}
+
+// rankGuildResults reorders a guild search's results by similarity raised by
+// reactions and accepted answers
+func (r *MessageRepository) rankGuildResults(results []models.SearchResult, endorsements []endorsement) []models.SearchResult {
+	ranked := make([]models.SearchResult, len(results))
+	for n, index := range r.rankByReactions(results, endorsements) {
+		ranked[n] = results[index]
+	}
+	log.Printf("✅ Guild vector search returned %d results", len(ranked))
+	return ranked
 }
 
 // GetRecentMessages gets recent messages from a channel
@@ -194,7 +410,9 @@ func (r *MessageRepository) GetRecentMessages(ctx context.Context, channelID int
 	var results []models.SearchResult
 
 	// Get messages with preloaded relations
-	err := r.db.WithContext(ctx).
+	queryCtx, cancel := r.db.ReadContext(ctx)
+	defer cancel()
+	err := r.db.WithContext(queryCtx).
 		Preload("User").
 		Preload("Channel").
 		Where("channel_id = ?", channelID).
@@ -202,13 +420,14 @@ func (r *MessageRepository) GetRecentMessages(ctx context.Context, channelID int
 		Limit(limit).
 		Find(&messages).Error
 
-	if err != nil {
+	if err = postgres.TimeoutError(queryCtx, err); err != nil {
 		log.Printf("❌ Failed to fetch recent messages: %v", err)
 		return nil, fmt.Errorf("failed to get recent messages: %w", err)
 	}
 
 	// Convert to search results
 	for _, msg := range messages {
+		r.decrypt(&msg)
 		result := models.SearchResult{
 			Message:    msg,
 			User:       msg.User,
@@ -222,6 +441,195 @@ func (r *MessageRepository) GetRecentMessages(ctx context.Context, channelID int
 	return results, nil
 }
 
+// GetMessagesInRange gets the latest messages from a channel posted within
+// [since, until), oldest first, so a busy range keeps its end rather than its
+// start when there are more than limit
+func (r *MessageRepository) GetMessagesInRange(ctx context.Context, channelID int64, since, until time.Time, limit int) ([]models.SearchResult, error) {
+	results, err := r.messagesInRange(ctx, channelID, since, until, limit, "timestamp DESC")
+	if err != nil {
+		return nil, err
+	}
+	for i, j := 0, len(results)-1; i < j; i, j = i+1, j-1 {
+		results[i], results[j] = results[j], results[i]
+	}
+	return results, nil
+}
+
+// GetFirstMessagesInRange gets the earliest messages from a channel posted
+// within [since, until), oldest first, such as the replies to a message
+func (r *MessageRepository) GetFirstMessagesInRange(ctx context.Context, channelID int64, since, until time.Time, limit int) ([]models.SearchResult, error) {
+	return r.messagesInRange(ctx, channelID, since, until, limit, "timestamp ASC")
+}
+
+func (r *MessageRepository) messagesInRange(ctx context.Context, channelID int64, since, until time.Time, limit int, order string) ([]models.SearchResult, error) {
+	log.Printf("🔍 Fetching messages for channel ID: %d between %s and %s", channelID, since.Format(time.RFC3339), until.Format(time.RFC3339))
+
+	var messages []models.Message
+	var results []models.SearchResult
+
+	err := r.db.WithContext(ctx).
+		Preload("User").
+		Preload("Channel").
+		Where("channel_id = ? AND timestamp >= ? AND timestamp < ?", channelID, since, until).
+		Order(order).
+		Limit(limit).
+		Find(&messages).Error
+
+	if err != nil {
+		log.Printf("❌ Failed to fetch messages in range: %v", err)
+		return nil, fmt.Errorf("failed to get messages in range: %w", err)
+	}
+
+	for _, msg := range messages {
+		r.decrypt(&msg)
+		results = append(results, models.SearchResult{
+			Message:    msg,
+			User:       msg.User,
+			Channel:    msg.Channel,
+			Similarity: 1.0,
+		})
+	}
+
+	log.Printf("✅ Fetched %d messages in range", len(results))
+	return results, nil
+}
+
+// ChannelActivity is how many messages a channel received over a period
+type ChannelActivity struct {
+	GuildID   int64
+	ChannelID int64
+	Messages  int
+}
+
+// ListActiveChannels returns the channels with at least minMessages messages
+// posted within [since, until)
+func (r *MessageRepository) ListActiveChannels(ctx context.Context, since, until time.Time, minMessages int) ([]ChannelActivity, error) {
+	var active []ChannelActivity
+	err := r.db.WithContext(ctx).Model(&models.Message{}).
+		Select("guild_id, channel_id, COUNT(*) AS messages").
+		Where("timestamp >= ? AND timestamp < ?", since, until).
+		Group("guild_id, channel_id").
+		Having("COUNT(*) >= ?", minMessages).
+		Scan(&active).Error
+	if err != nil {
+		log.Printf("❌ Failed to list active channels: %v", err)
+		return nil, fmt.Errorf("failed to list active channels: %w", err)
+	}
+	return active, nil
+}
+
+// ListUserChannels returns the channels of a guild a user has posted in since the given time
+func (r *MessageRepository) ListUserChannels(ctx context.Context, guildID, userID int64, since time.Time) ([]int64, error) {
+	var ids []int64
+	err := r.db.WithContext(ctx).Model(&models.Message{}).
+		Where("guild_id = ? AND user_id = ? AND timestamp >= ?", guildID, userID, since).
+		Distinct().
+		Pluck("channel_id", &ids).Error
+	if err != nil {
+		log.Printf("❌ Failed to list channels of user ID: %d: %v", userID, err)
+		return nil, fmt.Errorf("failed to list user channels: %w", err)
+	}
+	return ids, nil
+}
+
+// GetUserMessages gets a user's most recent messages in the given channels
+// posted since the given time, oldest first
+func (r *MessageRepository) GetUserMessages(ctx context.Context, userID int64, channelIDs []int64, since time.Time, limit int) ([]models.SearchResult, error) {
+	var messages []models.Message
+	err := r.db.WithContext(ctx).
+		Preload("User").
+		Preload("Channel").
+		Where("user_id = ? AND channel_id IN ? AND timestamp >= ?", userID, channelIDs, since).
+		Order("timestamp DESC").
+		Limit(limit).
+		Find(&messages).Error
+	if err != nil {
+		log.Printf("❌ Failed to fetch messages of user ID: %d: %v", userID, err)
+		return nil, fmt.Errorf("failed to get user messages: %w", err)
+	}
+
+	results := make([]models.SearchResult, 0, len(messages))
+	for n := len(messages) - 1; n >= 0; n-- {
+		msg := messages[n]
+		r.decrypt(&msg)
+		results = append(results, models.SearchResult{
+			Message:    msg,
+			User:       msg.User,
+			Channel:    msg.Channel,
+			Similarity: 1.0,
+		})
+	}
+	return results, nil
+}
+
+// ListChannelMessages pages through a channel's stored messages by ID, oldest
+// first, starting after afterID
+func (r *MessageRepository) ListChannelMessages(ctx context.Context, channelID, afterID int64, limit int) ([]models.Message, error) {
+	var messages []models.Message
+	err := r.db.WithContext(ctx).
+		Where("channel_id = ? AND id > ?", channelID, afterID).
+		Order("id ASC").
+		Limit(limit).
+		Find(&messages).Error
+	if err != nil {
+		log.Printf("❌ Failed to list channel messages: %v", err)
+		return nil, fmt.Errorf("failed to list channel messages: %w", err)
+	}
+	for n := range messages {
+		r.decrypt(&messages[n])
+	}
+	return messages, nil
+}
+
+// GetMessagesByIDs returns the stored messages with the given IDs, in no
+// particular order
+func (r *MessageRepository) GetMessagesByIDs(ctx context.Context, ids []int64) ([]models.Message, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	var messages []models.Message
+	if err := r.db.WithContext(ctx).Preload("User").Where("id IN ?", ids).Find(&messages).Error; err != nil {
+		return nil, fmt.Errorf("failed to get messages: %w", err)
+	}
+	for n := range messages {
+		r.decrypt(&messages[n])
+	}
+	return messages, nil
+}
+
+// CountChannelMessages returns how many stored messages in a channel have text to embed
+func (r *MessageRepository) CountChannelMessages(ctx context.Context, channelID int64) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Message{}).
+		Where("channel_id = ? AND btrim(content) <> ''", channelID).
+		Count(&count).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to count channel messages: %w", err)
+	}
+	return count, nil
+}
+
+// StoredMessageIDs returns which of a guild's message IDs are already stored
+func (r *MessageRepository) StoredMessageIDs(ctx context.Context, guildID int64, ids []int64) (map[int64]bool, error) {
+	var stored []int64
+	err := r.db.WithContext(ctx).Model(&models.Message{}).
+		Where("guild_id = ? AND id = ANY(?)", guildID, pq.Array(ids)).
+		Pluck("id", &stored).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up stored messages: %w", err)
+	}
+	found := make(map[int64]bool, len(stored))
+	for _, id := range stored {
+		found[id] = true
+	}
+	return found, nil
+}
+
+func (r *MessageRepository) decrypt(msg *models.Message) {
+	msg.Content = r.content.open(msg.Content)
+	msg.Embeds = r.content.open(msg.Embeds)
+}
+
 func min(a, b int) int {
 	if a < b {
 		return a
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/repository/postgres"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type MoodRepository struct {
+	db *postgres.GormDB
+}
+
+func NewMoodRepository(db *postgres.GormDB) *MoodRepository {
+	return &MoodRepository{db: db}
+}
+
+// SaveDay stores a channel's message scores for a day and its aggregate,
+// replacing earlier ones
+func (r *MoodRepository) SaveDay(ctx context.Context, mood *models.ChannelMood, scores []models.MessageSentiment) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if len(scores) > 0 {
+			err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "message_id"}},
+				DoUpdates: clause.AssignmentColumns([]string{"score"}),
+			}).CreateInBatches(scores, 200).Error
+			if err != nil {
+				return err
+			}
+		}
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "channel_id"}, {Name: "day"}},
+			DoUpdates: clause.AssignmentColumns([]string{"channel_name", "messages", "average", "negative", "positive"}),
+		}).Create(mood).Error
+	})
+	if err != nil {
+		log.Printf("❌ Failed to store mood of channel ID: %d: %v", mood.ChannelID, err)
+		return fmt.Errorf("failed to store channel mood: %w", err)
+	}
+	return nil
+}
+
+// ScoredChannels returns the channels whose mood was already scored for the day
+func (r *MoodRepository) ScoredChannels(ctx context.Context, day time.Time) (map[int64]bool, error) {
+	var ids []int64
+	err := r.db.WithContext(ctx).Model(&models.ChannelMood{}).
+		Where("day = ?", day).
+		Pluck("channel_id", &ids).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list scored channels: %w", err)
+	}
+	scored := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		scored[id] = true
+	}
+	return scored, nil
+}
+
+// ListDays returns a guild's daily channel moods from since onwards, oldest
+// first; a channelID of 0 includes every channel
+func (r *MoodRepository) ListDays(ctx context.Context, guildID, channelID int64, since time.Time) ([]models.ChannelMood, error) {
+	query := r.db.WithContext(ctx).Where("guild_id = ? AND day >= ?", guildID, since)
+	if channelID != 0 {
+		query = query.Where("channel_id = ?", channelID)
+	}
+	var moods []models.ChannelMood
+	if err := query.Order("day ASC").Find(&moods).Error; err != nil {
+		return nil, fmt.Errorf("failed to list channel moods: %w", err)
+	}
+	return moods, nil
+}
+
+// Extremes returns a channel's most negative (or, with positive set, most
+// positive) scored messages from since onwards
+func (r *MoodRepository) Extremes(ctx context.Context, channelID int64, since time.Time, positive bool, limit int) ([]models.MessageSentiment, error) {
+	order := "score ASC"
+	query := r.db.WithContext(ctx).Where("channel_id = ? AND day >= ?", channelID, since)
+	if positive {
+		order = "score DESC"
+		query = query.Where("score > 0")
+	} else {
+		query = query.Where("score < 0")
+	}
+	var scores []models.MessageSentiment
+	if err := query.Order(order).Limit(limit).Find(&scores).Error; err != nil {
+		return nil, fmt.Errorf("failed to list extreme messages: %w", err)
+	}
+	return scores, nil
+}
+
+// SaveToxicityConfig creates or replaces a guild's early warning setup
+func (r *MoodRepository) SaveToxicityConfig(ctx context.Context, cfg *models.ToxicityConfig) error {
+	if err := r.db.WithContext(ctx).Save(cfg).Error; err != nil {
+		log.Printf("❌ Failed to save toxicity config: %v", err)
+		return fmt.Errorf("failed to save toxicity config: %w", err)
+	}
+	return nil
+}
+
+// GetToxicityConfig returns a guild's early warning setup, or nil if none
+func (r *MoodRepository) GetToxicityConfig(ctx context.Context, guildID int64) (*models.ToxicityConfig, error) {
+	var cfg models.ToxicityConfig
+	err := r.db.WithContext(ctx).Where("guild_id = ?", guildID).First(&cfg).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get toxicity config: %w", err)
+	}
+	return &cfg, nil
+}
@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/pgvector"
+	"discord-tars/internal/repository/postgres"
+	"discord-tars/internal/secrets"
+)
+
+type NoteRepository struct {
+	db      *postgres.GormDB
+	content fieldCipher
+}
+
+func NewNoteRepository(db *postgres.GormDB) *NoteRepository {
+	return &NoteRepository{db: db}
+}
+
+// SetCipher encrypts notes at rest; reads decrypt transparently
+func (r *NoteRepository) SetCipher(cipher *secrets.Cipher) {
+	r.content = fieldCipher{cipher: cipher}
+}
+
+// Create stores a note with its embedding
+func (r *NoteRepository) Create(ctx context.Context, note *models.Note, embedding []float32) error {
+	content, err := r.content.seal(note.Content)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt note: %w", err)
+	}
+
+	row := *note
+	row.Content, row.Embedding = content, pgvector.NewVector(embedding)
+	if err := r.db.WithContext(ctx).Create(&row).Error; err != nil {
+		log.Printf("❌ Failed to store note of user ID: %d: %v", note.UserID, err)
+		return fmt.Errorf("failed to store note: %w", err)
+	}
+	note.ID, note.CreatedAt = row.ID, row.CreatedAt
+	return nil
+}
+
+// Search finds the notes of a notebook most similar to the query: a channel's
+// notes, or a user's personal notes when channelID is 0
+func (r *NoteRepository) Search(ctx context.Context, userID, channelID int64, queryEmbedding []float32, limit int, similarity float64) ([]models.NoteResult, error) {
+	notebook, owner := "channel_id = $2", channelID
+	if channelID == 0 {
+		notebook, owner = "channel_id = 0 AND user_id = $2", userID
+	}
+	query := `
+		SELECT id, guild_id, channel_id, user_id, content, created_at,
+			1 - (embedding <=> $1::vector) as similarity
+		FROM notes
+		WHERE ` + notebook + ` AND 1 - (embedding <=> $1::vector) > $3
+		ORDER BY embedding <=> $1::vector
+		LIMIT $4
+	`
+
+	rows, err := r.db.TimedRows(ctx, "note vector search", query, pgvector.NewVector(queryEmbedding), owner, similarity, limit)
+	if err != nil {
+		log.Printf("❌ Failed to execute note search query: %v", err)
+		return nil, fmt.Errorf("failed to search notes: %w", err)
+	}
+	defer rows.Close()
+
+	var results []models.NoteResult
+	for rows.Next() {
+		var result models.NoteResult
+		note := &result.Note
+		if err := rows.Scan(&note.ID, &note.GuildID, &note.ChannelID, &note.UserID, &note.Content, &note.CreatedAt, &result.Similarity); err != nil {
+			return nil, fmt.Errorf("failed to scan note result: %w", err)
+		}
+		note.Content = r.content.open(note.Content)
+		results = append(results, result)
+	}
+	return results, rows.Err()
+}
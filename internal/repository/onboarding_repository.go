@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/repository/postgres"
+
+	"gorm.io/gorm"
+)
+
+type OnboardingRepository struct {
+	db *postgres.GormDB
+}
+
+func NewOnboardingRepository(db *postgres.GormDB) *OnboardingRepository {
+	return &OnboardingRepository{db: db}
+}
+
+// SaveConfig creates or replaces a guild's onboarding configuration
+func (r *OnboardingRepository) SaveConfig(ctx context.Context, cfg *models.OnboardingConfig) error {
+	log.Printf("💾 Saving onboarding config for guild ID: %d", cfg.GuildID)
+	if err := r.db.WithContext(ctx).Save(cfg).Error; err != nil {
+		log.Printf("❌ Failed to save onboarding config: %v", err)
+		return fmt.Errorf("failed to save onboarding config: %w", err)
+	}
+	return nil
+}
+
+// GetConfig returns a guild's onboarding configuration, or nil if none
+func (r *OnboardingRepository) GetConfig(ctx context.Context, guildID int64) (*models.OnboardingConfig, error) {
+	var cfg models.OnboardingConfig
+	err := r.db.WithContext(ctx).Where("guild_id = ?", guildID).First(&cfg).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get onboarding config: %w", err)
+	}
+	return &cfg, nil
+}
+
+// StartMember records that a member joined and is being onboarded
+func (r *OnboardingRepository) StartMember(ctx context.Context, guildID, userID int64, joinedAt time.Time) error {
+	member := models.OnboardingMember{GuildID: guildID, UserID: userID}
+	err := r.db.WithContext(ctx).
+		Where("guild_id = ? AND user_id = ?", guildID, userID).
+		Assign(map[string]interface{}{"questions_asked": 0, "joined_at": joinedAt, "completed_at": nil}).
+		FirstOrCreate(&member).Error
+	if err != nil {
+		return fmt.Errorf("failed to start onboarding: %w", err)
+	}
+	return nil
+}
+
+// GetActiveMember returns the most recent unfinished onboarding of a user across guilds
+func (r *OnboardingRepository) GetActiveMember(ctx context.Context, userID int64) (*models.OnboardingMember, error) {
+	var member models.OnboardingMember
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND completed_at IS NULL", userID).
+		Order("joined_at DESC").
+		First(&member).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get onboarding member: %w", err)
+	}
+	return &member, nil
+}
+
+// RecordQuestion increments the question counter, completing onboarding when requested
+func (r *OnboardingRepository) RecordQuestion(ctx context.Context, id int64, complete bool) error {
+	updates := map[string]interface{}{"questions_asked": gorm.Expr("questions_asked + 1")}
+	if complete {
+		updates["completed_at"] = time.Now()
+	}
+	return r.db.WithContext(ctx).Model(&models.OnboardingMember{}).Where("id = ?", id).Updates(updates).Error
+}
@@ -0,0 +1,178 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/repository/postgres"
+	"discord-tars/internal/secrets"
+)
+
+type OutboxRepository struct {
+	db      *postgres.GormDB
+	payload fieldCipher
+}
+
+func NewOutboxRepository(db *postgres.GormDB) *OutboxRepository {
+	return &OutboxRepository{db: db}
+}
+
+// SetCipher encrypts message payloads at rest, as digests quote channel
+// content; reads decrypt transparently
+func (r *OutboxRepository) SetCipher(cipher *secrets.Cipher) {
+	r.payload = fieldCipher{cipher: cipher}
+}
+
+// Add stores a message as pending with its first attempt in flight until
+// leaseUntil, when dispatchers may pick it up if the sender never reported back
+func (r *OutboxRepository) Add(ctx context.Context, msg *models.OutboxMessage, leaseUntil time.Time) error {
+	payload, err := r.payload.seal(msg.Payload)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt outbox message: %w", err)
+	}
+	msg.Status = models.OutboxPending
+	msg.Attempts = 1
+	msg.NextAttemptAt = leaseUntil
+	row := *msg
+	row.Payload = payload
+	if err := r.db.WithContext(ctx).Create(&row).Error; err != nil {
+		return fmt.Errorf("failed to add %s message to the outbox: %w", msg.Kind, err)
+	}
+	msg.ID, msg.CreatedAt, msg.UpdatedAt = row.ID, row.CreatedAt, row.UpdatedAt
+	return nil
+}
+
+// ClaimDue takes up to limit due messages for sending, counting the attempt
+// and leasing them until leaseUntil. Concurrent dispatchers never claim the
+// same message.
+func (r *OutboxRepository) ClaimDue(ctx context.Context, limit int, leaseUntil time.Time) ([]models.OutboxMessage, error) {
+	var msgs []models.OutboxMessage
+	err := r.db.WithContext(ctx).Raw(`
+		UPDATE outbox_messages
+		SET attempts = attempts + 1, next_attempt_at = ?, updated_at = NOW()
+		WHERE id IN (
+			SELECT id FROM outbox_messages
+			WHERE status = ? AND next_attempt_at <= NOW()
+			ORDER BY next_attempt_at, id
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`,
+		leaseUntil, models.OutboxPending, limit,
+	).Scan(&msgs).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim outbox messages: %w", err)
+	}
+	for n := range msgs {
+		msgs[n].Payload = r.payload.open(msgs[n].Payload)
+	}
+	return msgs, nil
+}
+
+// MarkSent records a delivered message
+func (r *OutboxRepository) MarkSent(ctx context.Context, id, messageID int64) error {
+	err := r.db.WithContext(ctx).Model(&models.OutboxMessage{}).Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":     models.OutboxSent,
+			"message_id": messageID,
+			"sent_at":    time.Now(),
+			"last_error": "",
+		}).Error
+	if err != nil {
+		return fmt.Errorf("failed to mark outbox message as sent: %w", err)
+	}
+	return nil
+}
+
+// Retry records a failed attempt and when to try again
+func (r *OutboxRepository) Retry(ctx context.Context, id int64, cause error, at time.Time) error {
+	err := r.db.WithContext(ctx).Model(&models.OutboxMessage{}).Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"next_attempt_at": at,
+			"last_error":      cause.Error(),
+		}).Error
+	if err != nil {
+		return fmt.Errorf("failed to reschedule outbox message: %w", err)
+	}
+	return nil
+}
+
+// Fail gives up on a message, leaving it for operators
+func (r *OutboxRepository) Fail(ctx context.Context, id int64, cause error) error {
+	err := r.db.WithContext(ctx).Model(&models.OutboxMessage{}).Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":     models.OutboxFailed,
+			"last_error": cause.Error(),
+		}).Error
+	if err != nil {
+		return fmt.Errorf("failed to mark outbox message as failed: %w", err)
+	}
+	return nil
+}
+
+// Requeue sends a failed message again with fresh attempts, reporting
+// whether it had failed
+func (r *OutboxRepository) Requeue(ctx context.Context, id int64) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.OutboxMessage{}).
+		Where("id = ? AND status = ?", id, models.OutboxFailed).
+		Updates(map[string]interface{}{
+			"status":          models.OutboxPending,
+			"attempts":        0,
+			"next_attempt_at": time.Now(),
+		})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to requeue outbox message: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// List returns the most recently updated messages with a status
+func (r *OutboxRepository) List(ctx context.Context, status string, limit int) ([]models.OutboxMessage, error) {
+	var msgs []models.OutboxMessage
+	err := r.db.WithContext(ctx).
+		Where("status = ?", status).
+		Order("updated_at DESC").
+		Limit(limit).
+		Find(&msgs).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list outbox messages: %w", err)
+	}
+	for n := range msgs {
+		msgs[n].Payload = r.payload.open(msgs[n].Payload)
+	}
+	return msgs, nil
+}
+
+// CountByStatus counts the messages of each status
+func (r *OutboxRepository) CountByStatus(ctx context.Context) (map[string]int64, error) {
+	var rows []struct {
+		Status string
+		Count  int64
+	}
+	err := r.db.WithContext(ctx).
+		Model(&models.OutboxMessage{}).
+		Select("status, COUNT(*) AS count").
+		Group("status").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to count outbox messages: %w", err)
+	}
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, nil
+}
+
+// Prune deletes sent and failed messages last updated before a time
+func (r *OutboxRepository) Prune(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Where("status IN ? AND updated_at < ?", []string{models.OutboxSent, models.OutboxFailed}, before).
+		Delete(&models.OutboxMessage{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to prune the outbox: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,311 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/repository/postgres"
+	"discord-tars/internal/vectorstore"
+
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DefaultMessagePartition holds the messages of guilds without a partition of
+// their own, until one is created for them
+const DefaultMessagePartition = "messages_default"
+
+// Partition changes take this lock, so replicas creating partitions for the
+// guilds they see don't race each other or the conversion
+const partitionLock = "SELECT pg_advisory_xact_lock(hashtext('messages_partitions'))"
+
+var ErrAlreadyPartitioned = errors.New("messages is already partitioned")
+
+// PartitionRepository manages the per-guild partitions of the messages table.
+// Partitioning is opt-in: PartitionMessages converts the table once, and until
+// then the other methods do nothing.
+type PartitionRepository struct {
+	db      *postgres.GormDB
+	vectors vectorstore.Store // Embeddings live only in Postgres when nil
+}
+
+func NewPartitionRepository(db *postgres.GormDB) *PartitionRepository {
+	return &PartitionRepository{db: db}
+}
+
+// SetVectorStore makes DropGuild delete the guild's embeddings from the
+// external store the messages are searched in, too
+func (r *PartitionRepository) SetVectorStore(store vectorstore.Store) {
+	r.vectors = store
+}
+
+// MessagePartition names the partition holding a guild's messages
+func MessagePartition(guildID int64) string {
+	return fmt.Sprintf("messages_g%d", guildID)
+}
+
+// Partitioned reports whether the messages table is partitioned by guild
+func (r *PartitionRepository) Partitioned(ctx context.Context) (bool, error) {
+	var partitioned bool
+	err := r.db.WithContext(ctx).Raw(`SELECT EXISTS (
+		SELECT 1 FROM pg_partitioned_table WHERE partrelid = to_regclass('messages'))`).Scan(&partitioned).Error
+	if err != nil {
+		return false, fmt.Errorf("failed to check messages partitioning: %w", err)
+	}
+	return partitioned, nil
+}
+
+// PartitionMessages converts the messages table into one partitioned by
+// guild, with a partition for each guild it holds messages of and a default
+// one for the rest, and returns how many guilds got a partition. The primary
+// key becomes (id, guild_id), which foreign keys can't reference, so those
+// pointing at messages are dropped. Messages are copied over in a single
+// transaction: run it with the bot stopped.
+func (r *PartitionRepository) PartitionMessages(ctx context.Context) (int, error) {
+	partitioned, err := r.Partitioned(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if partitioned {
+		return 0, ErrAlreadyPartitioned
+	}
+
+	guilds := 0
+	err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(partitionLock).Error; err != nil {
+			return err
+		}
+		var missing int64
+		if err := tx.Raw("SELECT count(*) FROM messages WHERE guild_id IS NULL").Scan(&missing).Error; err != nil {
+			return err
+		}
+		if missing > 0 {
+			return fmt.Errorf("%d messages have no guild_id, set one before partitioning", missing)
+		}
+
+		// Foreign keys pointing at messages, including reply_to_id's
+		var references []struct {
+			TableName string
+			Conname   string
+		}
+		if err := tx.Raw(`SELECT c.relname AS table_name, k.conname FROM pg_constraint k
+			JOIN pg_class c ON c.oid = k.conrelid
+			WHERE k.contype = 'f' AND k.confrelid = 'messages'::regclass`).Scan(&references).Error; err != nil {
+			return err
+		}
+		for _, ref := range references {
+			log.Printf("🧩 Dropping foreign key %s of %s", ref.Conname, ref.TableName)
+			if err := tx.Exec(fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT %s", pq.QuoteIdentifier(ref.TableName), pq.QuoteIdentifier(ref.Conname))).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Exec("ALTER TABLE messages RENAME TO messages_unpartitioned").Error; err != nil {
+			return err
+		}
+		// The foreign keys and indexes of the old table, to recreate on the new one
+		var foreignKeys, indexes []string
+		if err := tx.Raw(`SELECT pg_get_constraintdef(oid) FROM pg_constraint
+			WHERE conrelid = 'messages_unpartitioned'::regclass AND contype = 'f'`).Scan(&foreignKeys).Error; err != nil {
+			return err
+		}
+		if err := tx.Raw(`SELECT pg_get_indexdef(indexrelid) FROM pg_index
+			WHERE indrelid = 'messages_unpartitioned'::regclass AND NOT indisunique`).Scan(&indexes).Error; err != nil {
+			return err
+		}
+
+		for _, statement := range []string{
+			`CREATE TABLE messages (LIKE messages_unpartitioned INCLUDING DEFAULTS INCLUDING STORAGE INCLUDING COMMENTS)
+				PARTITION BY LIST (guild_id)`,
+			"ALTER TABLE messages ADD PRIMARY KEY (id, guild_id)",
+			"CREATE TABLE " + DefaultMessagePartition + " PARTITION OF messages DEFAULT",
+		} {
+			if err := tx.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+		for _, foreignKey := range foreignKeys {
+			if err := tx.Exec("ALTER TABLE messages ADD " + foreignKey).Error; err != nil {
+				return err
+			}
+		}
+
+		var guildIDs []int64
+		if err := tx.Raw("SELECT DISTINCT guild_id FROM messages_unpartitioned WHERE guild_id > 0 ORDER BY guild_id").Scan(&guildIDs).Error; err != nil {
+			return err
+		}
+		for _, guildID := range guildIDs {
+			if err := tx.Exec(fmt.Sprintf("CREATE TABLE %s PARTITION OF messages FOR VALUES IN (%d)", MessagePartition(guildID), guildID)).Error; err != nil {
+				return err
+			}
+			if err := recordPartition(tx, guildID); err != nil {
+				return err
+			}
+		}
+		guilds = len(guildIDs)
+
+		log.Printf("🧩 Copying messages into %d guild partitions", guilds)
+		if err := tx.Exec("INSERT INTO messages SELECT * FROM messages_unpartitioned").Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("DROP TABLE messages_unpartitioned").Error; err != nil {
+			return err
+		}
+		// Indexes are built after the copy, which is faster than maintaining them during it
+		rename := strings.NewReplacer(" ON public.messages_unpartitioned ", " ON messages ", " ON messages_unpartitioned ", " ON messages ")
+		for _, index := range indexes {
+			if err := tx.Exec(rename.Replace(index)).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("❌ Failed to partition messages: %v", err)
+		return 0, fmt.Errorf("failed to partition messages: %w", err)
+	}
+	return guilds, nil
+}
+
+// EnsureGuild creates a guild's partition if it has none yet, moving any of
+// its messages out of the default partition, and clears when the bot left it
+func (r *PartitionRepository) EnsureGuild(ctx context.Context, guildID int64) error {
+	partition := MessagePartition(guildID)
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(partitionLock).Error; err != nil {
+			return err
+		}
+		var exists bool
+		if err := tx.Raw("SELECT to_regclass(?) IS NOT NULL", partition).Scan(&exists).Error; err != nil {
+			return err
+		}
+		if !exists {
+			// Attaching checks the default partition no longer holds the guild's messages
+			for _, statement := range []string{
+				fmt.Sprintf("CREATE TABLE %s (LIKE messages INCLUDING DEFAULTS INCLUDING STORAGE)", partition),
+				fmt.Sprintf(`WITH moved AS (DELETE FROM %s WHERE guild_id = %d RETURNING *)
+					INSERT INTO %s SELECT * FROM moved`, DefaultMessagePartition, guildID, partition),
+				fmt.Sprintf("ALTER TABLE messages ATTACH PARTITION %s FOR VALUES IN (%d)", partition, guildID),
+			} {
+				if err := tx.Exec(statement).Error; err != nil {
+					return err
+				}
+			}
+			log.Printf("🧩 Created message partition %s", partition)
+		}
+		return recordPartition(tx, guildID)
+	})
+	if err != nil {
+		log.Printf("❌ Failed to create message partition for guild %d: %v", guildID, err)
+		return fmt.Errorf("failed to create message partition: %w", err)
+	}
+	return nil
+}
+
+// recordPartition tracks a guild's partition as belonging to a current guild
+func recordPartition(tx *gorm.DB, guildID int64) error {
+	return tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "guild_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"left_at"}),
+	}).Create(&models.GuildPartition{GuildID: guildID, Partition: MessagePartition(guildID)}).Error
+}
+
+// MarkLeft records when the bot left a guild, starting the countdown to its
+// partition being dropped
+func (r *PartitionRepository) MarkLeft(ctx context.Context, guildID int64, at time.Time) error {
+	err := r.db.WithContext(ctx).Model(&models.GuildPartition{}).
+		Where("guild_id = ? AND left_at IS NULL", guildID).
+		Update("left_at", at).Error
+	if err != nil {
+		return fmt.Errorf("failed to mark guild partition left: %w", err)
+	}
+	return nil
+}
+
+// ListPartitions returns the guild partitions, oldest first
+func (r *PartitionRepository) ListPartitions(ctx context.Context) ([]models.GuildPartition, error) {
+	var partitions []models.GuildPartition
+	if err := r.db.WithContext(ctx).Order("created_at").Find(&partitions).Error; err != nil {
+		return nil, fmt.Errorf("failed to list guild partitions: %w", err)
+	}
+	return partitions, nil
+}
+
+// ListLeftBefore returns the partitions of guilds the bot left before a time
+func (r *PartitionRepository) ListLeftBefore(ctx context.Context, before time.Time) ([]models.GuildPartition, error) {
+	var partitions []models.GuildPartition
+	if err := r.db.WithContext(ctx).Where("left_at < ?", before).Order("left_at").Find(&partitions).Error; err != nil {
+		return nil, fmt.Errorf("failed to list left guild partitions: %w", err)
+	}
+	return partitions, nil
+}
+
+// messageChildTables hold rows about single messages, keyed by message_id
+var messageChildTables = []string{
+	"message_embeddings",
+	"message_reactions",
+	"message_languages",
+	"message_attachments",
+	"message_sentiments",
+	"code_snippets",
+}
+
+// DropGuild deletes a guild's messages by dropping its partition, along with
+// the rows about them in messageChildTables, their filter records and their
+// points in the vector store, and returns how many messages it held
+func (r *PartitionRepository) DropGuild(ctx context.Context, guildID int64) (int64, error) {
+	partition := MessagePartition(guildID)
+	// The points go first, so a failure leaves the partition for the next
+	// pruning run rather than orphaned points nothing deletes
+	if r.vectors != nil {
+		if err := r.vectors.DeleteGuild(ctx, guildID); err != nil {
+			log.Printf("❌ Failed to delete the vector store points of guild %d: %v", guildID, err)
+			return 0, fmt.Errorf("failed to drop message partition: %w", err)
+		}
+	}
+	var messages int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(partitionLock).Error; err != nil {
+			return err
+		}
+		var exists bool
+		if err := tx.Raw("SELECT to_regclass(?) IS NOT NULL", partition).Scan(&exists).Error; err != nil {
+			return err
+		}
+		if exists {
+			if err := tx.Raw("SELECT count(*) FROM " + partition).Scan(&messages).Error; err != nil {
+				return err
+			}
+			// Rows about the messages lost their cascading foreign keys when
+			// messages was partitioned
+			var statements []string
+			for _, table := range messageChildTables {
+				statements = append(statements, fmt.Sprintf("DELETE FROM %s c USING %s m WHERE c.message_id = m.id", table, partition))
+			}
+			statements = append(statements,
+				"ALTER TABLE messages DETACH PARTITION "+partition,
+				"DROP TABLE "+partition,
+			)
+			for _, statement := range statements {
+				if err := tx.Exec(statement).Error; err != nil {
+					return err
+				}
+			}
+		}
+		if err := tx.Where("guild_id = ?", guildID).Delete(&models.FilteredMessage{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("guild_id = ?", guildID).Delete(&models.GuildPartition{}).Error
+	})
+	if err != nil {
+		log.Printf("❌ Failed to drop message partition of guild %d: %v", guildID, err)
+		return 0, fmt.Errorf("failed to drop message partition: %w", err)
+	}
+	return messages, nil
+}
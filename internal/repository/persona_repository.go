@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/repository/postgres"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PersonaRepository struct {
+	db *postgres.GormDB
+}
+
+func NewPersonaRepository(db *postgres.GormDB) *PersonaRepository {
+	return &PersonaRepository{db: db}
+}
+
+// Save creates or replaces a guild's persona
+func (r *PersonaRepository) Save(ctx context.Context, p *models.GuildPersona) error {
+	if err := r.db.WithContext(ctx).Save(p).Error; err != nil {
+		log.Printf("❌ Failed to save guild persona: %v", err)
+		return fmt.Errorf("failed to save guild persona: %w", err)
+	}
+	return nil
+}
+
+// Get returns a guild's persona, or nil if it uses the default one
+func (r *PersonaRepository) Get(ctx context.Context, guildID int64) (*models.GuildPersona, error) {
+	var p models.GuildPersona
+	err := r.db.WithContext(ctx).Where("guild_id = ?", guildID).First(&p).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get guild persona: %w", err)
+	}
+	return &p, nil
+}
+
+// Delete removes a guild's persona
+func (r *PersonaRepository) Delete(ctx context.Context, guildID int64) error {
+	if err := r.db.WithContext(ctx).Where("guild_id = ?", guildID).Delete(&models.GuildPersona{}).Error; err != nil {
+		return fmt.Errorf("failed to delete guild persona: %w", err)
+	}
+	return nil
+}
+
+// SaveMode creates a persona mode, or replaces the guild's mode of the same name
+func (r *PersonaRepository) SaveMode(ctx context.Context, mode *models.PersonaMode) error {
+	err := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "guild_id"}, {Name: "name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"instructions", "months", "weekdays", "start_hour", "end_hour", "timezone", "created_by"}),
+	}).Create(mode).Error
+	if err != nil {
+		log.Printf("❌ Failed to save persona mode: %v", err)
+		return fmt.Errorf("failed to save persona mode: %w", err)
+	}
+	return nil
+}
+
+// DeleteMode removes a guild's persona mode by name, reporting whether it existed
+func (r *PersonaRepository) DeleteMode(ctx context.Context, guildID int64, name string) (bool, error) {
+	result := r.db.WithContext(ctx).Where("guild_id = ? AND name = ?", guildID, name).Delete(&models.PersonaMode{})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to delete persona mode: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// ListModes returns a guild's persona modes
+func (r *PersonaRepository) ListModes(ctx context.Context, guildID int64) ([]models.PersonaMode, error) {
+	var modes []models.PersonaMode
+	if err := r.db.WithContext(ctx).Where("guild_id = ?", guildID).Order("name").Find(&modes).Error; err != nil {
+		return nil, fmt.Errorf("failed to list persona modes: %w", err)
+	}
+	return modes, nil
+}
+
+// AllModes returns every guild's persona modes
+func (r *PersonaRepository) AllModes(ctx context.Context) ([]models.PersonaMode, error) {
+	var modes []models.PersonaMode
+	if err := r.db.WithContext(ctx).Order("guild_id, name").Find(&modes).Error; err != nil {
+		return nil, fmt.Errorf("failed to list persona modes: %w", err)
+	}
+	return modes, nil
+}
+
+// SaveVerbosity creates or replaces a guild's default answer verbosity
+func (r *PersonaRepository) SaveVerbosity(ctx context.Context, v *models.GuildVerbosity) error {
+	if err := r.db.WithContext(ctx).Save(v).Error; err != nil {
+		log.Printf("❌ Failed to save answer verbosity: %v", err)
+		return fmt.Errorf("failed to save answer verbosity: %w", err)
+	}
+	return nil
+}
+
+// GetVerbosity returns a guild's default answer verbosity, or nil if it has none
+func (r *PersonaRepository) GetVerbosity(ctx context.Context, guildID int64) (*models.GuildVerbosity, error) {
+	var v models.GuildVerbosity
+	err := r.db.WithContext(ctx).Where("guild_id = ?", guildID).First(&v).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get answer verbosity: %w", err)
+	}
+	return &v, nil
+}
+
+// SaveStyle creates or replaces a guild's answer style
+func (r *PersonaRepository) SaveStyle(ctx context.Context, style *models.GuildStyle) error {
+	if err := r.db.WithContext(ctx).Save(style).Error; err != nil {
+		log.Printf("❌ Failed to save answer style: %v", err)
+		return fmt.Errorf("failed to save answer style: %w", err)
+	}
+	return nil
+}
+
+// GetStyle returns a guild's answer style, or nil if it has none
+func (r *PersonaRepository) GetStyle(ctx context.Context, guildID int64) (*models.GuildStyle, error) {
+	var style models.GuildStyle
+	err := r.db.WithContext(ctx).Where("guild_id = ?", guildID).First(&style).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get answer style: %w", err)
+	}
+	return &style, nil
+}
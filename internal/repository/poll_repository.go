@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/repository/postgres"
+
+	"gorm.io/gorm"
+)
+
+type PollRepository struct {
+	db *postgres.GormDB
+}
+
+func NewPollRepository(db *postgres.GormDB) *PollRepository {
+	return &PollRepository{db: db}
+}
+
+// CreatePoll stores a new poll
+func (r *PollRepository) CreatePoll(ctx context.Context, poll *models.Poll) error {
+	if err := r.db.WithContext(ctx).Create(poll).Error; err != nil {
+		log.Printf("❌ Failed to create poll: %v", err)
+		return fmt.Errorf("failed to create poll: %w", err)
+	}
+	return nil
+}
+
+// SetMessageID links a poll to the Discord message that displays it
+func (r *PollRepository) SetMessageID(ctx context.Context, pollID, messageID int64) error {
+	return r.db.WithContext(ctx).Model(&models.Poll{}).
+		Where("id = ?", pollID).Update("message_id", messageID).Error
+}
+
+// GetPoll returns a poll by ID, or nil if it does not exist
+func (r *PollRepository) GetPoll(ctx context.Context, pollID int64) (*models.Poll, error) {
+	var poll models.Poll
+	err := r.db.WithContext(ctx).First(&poll, pollID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get poll: %w", err)
+	}
+	return &poll, nil
+}
+
+// ListExpired returns open polls whose closing time has passed
+func (r *PollRepository) ListExpired(ctx context.Context, now time.Time) ([]models.Poll, error) {
+	var polls []models.Poll
+	err := r.db.WithContext(ctx).
+		Where("closed_at IS NULL AND closes_at IS NOT NULL AND closes_at <= ?", now).
+		Find(&polls).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list expired polls: %w", err)
+	}
+	return polls, nil
+}
+
+// Vote records or changes a user's vote
+func (r *PollRepository) Vote(ctx context.Context, vote *models.PollVote) error {
+	err := r.db.WithContext(ctx).
+		Where("poll_id = ? AND user_id = ?", vote.PollID, vote.UserID).
+		Assign(map[string]interface{}{"option": vote.Option}).
+		FirstOrCreate(vote).Error
+	if err != nil {
+		log.Printf("❌ Failed to record poll vote: %v", err)
+		return fmt.Errorf("failed to record vote: %w", err)
+	}
+	return nil
+}
+
+// CountVotes returns the number of votes per option index
+func (r *PollRepository) CountVotes(ctx context.Context, pollID int64) (map[int]int, error) {
+	var rows []struct {
+		Option int
+		Count  int
+	}
+	err := r.db.WithContext(ctx).
+		Model(&models.PollVote{}).
+		Select("option, COUNT(*) AS count").
+		Where("poll_id = ?", pollID).
+		Group("option").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to count votes: %w", err)
+	}
+
+	counts := make(map[int]int, len(rows))
+	for _, row := range rows {
+		counts[row.Option] = row.Count
+	}
+	return counts, nil
+}
+
+// ClosePoll marks a poll as closed. It reports false if the poll was already
+// closed, so concurrent closers don't post results twice.
+func (r *PollRepository) ClosePoll(ctx context.Context, pollID int64, at time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.Poll{}).
+		Where("id = ? AND closed_at IS NULL", pollID).
+		Update("closed_at", at)
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to close poll: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// SetSummary stores the AI analysis of a closed poll
+func (r *PollRepository) SetSummary(ctx context.Context, pollID int64, summary string) error {
+	return r.db.WithContext(ctx).Model(&models.Poll{}).
+		Where("id = ?", pollID).Update("summary", summary).Error
+}
@@ -15,6 +15,8 @@ import (
 // GormDB wraps the GORM DB instance
 type GormDB struct {
 	*gorm.DB
+	slowQuery    *slowQueryWatch
+	queryTimeout time.Duration // Bound on read queries; zero for none
 }
 
 // NewGormConnection establishes a connection to PostgreSQL using GORM
@@ -30,6 +32,9 @@ func NewGormConnection(cfg config.DatabaseConfig) (*GormDB, error) {
 		// Disable foreign key constraints when migrating to avoid errors
 		// with existing schema from SQL initialization scripts
 		DisableForeignKeyConstraintWhenMigrating: true,
+		// Each distinct query is parsed once per connection, then reused
+		// with new parameters
+		PrepareStmt: cfg.PrepareStatements,
 	}
 
 	// Connect to database
@@ -53,7 +58,7 @@ func NewGormConnection(cfg config.DatabaseConfig) (*GormDB, error) {
 		return nil, fmt.Errorf("failed to migrate database: %w", err)
 	}
 
-	return &GormDB{DB: db}, nil
+	return &GormDB{DB: db, queryTimeout: cfg.QueryTimeout}, nil
 }
 
 // Close closes the database connection
@@ -77,5 +82,73 @@ func autoMigrate(db *gorm.DB) error {
 		&models.User{},
 		&models.Message{},
 		&models.MessageEmbedding{},
+		&models.DigestSubscription{},
+		&models.StandupTeam{},
+		&models.StandupSession{},
+		&models.StandupUpdate{},
+		&models.Poll{},
+		&models.PollVote{},
+		&models.OnboardingConfig{},
+		&models.OnboardingMember{},
+		&models.PriorityChannel{},
+		&models.PriorityDocument{},
+		&models.GitHubSubscription{},
+		&models.Feed{},
+		&models.FeedItem{},
+		&models.CalendarSource{},
+		&models.CalendarEvent{},
+		&models.TrackerConfig{},
+		&models.KnowledgeSource{},
+		&models.KnowledgeDocument{},
+		&models.KnowledgeChunk{},
+		&models.AICredential{},
+		&models.IndexRun{},
+		&models.ConversationMemory{},
+		&models.AskedQuestion{},
+		&models.ChannelSummary{},
+		&models.DuplicateConfig{},
+		&models.AnsweredQuestion{},
+		&models.AnswerLog{},
+		&models.APIKey{},
+		&models.APIKeyUsage{},
+		&models.AnnouncementConfig{},
+		&models.AnnouncementDraft{},
+		&models.GuildPersona{},
+		&models.PersonaMode{},
+		&models.MessageSentiment{},
+		&models.ChannelMood{},
+		&models.ToxicityConfig{},
+		&models.MessageReaction{},
+		&models.HighlightConfig{},
+		&models.Highlight{},
+		&models.Job{},
+		&models.OutboxMessage{},
+		&models.CommandAudit{},
+		&models.GuildVerbosity{},
+		&models.GuildStyle{},
+		&models.Quiz{},
+		&models.QuizQuestion{},
+		&models.QuizAnswer{},
+		&models.XPConfig{},
+		&models.MemberXP{},
+		&models.StarterSchedule{},
+		&models.StarterPost{},
+		&models.Incident{},
+		&models.IncidentUpdate{},
+		&models.Note{},
+		&models.Bookmark{},
+		&models.CodeSnippet{},
+		&models.MessageAttachment{},
+		&models.ForumPost{},
+		&models.HelpChannel{},
+		&models.SandboxGuild{},
+		&models.Rollout{},
+		&models.MessageLanguage{},
+		&models.GuildLanguages{},
+		&models.UserTimezone{},
+		&models.GuildTimezone{},
+		&models.FilteredMessage{},
+		&models.MaintenanceRun{},
+		&models.GuildPartition{},
 	)
 }
@@ -0,0 +1,91 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"discord-tars/internal/tenant"
+)
+
+// SlowQuery describes a timed query that ran longer than the threshold
+type SlowQuery struct {
+	Name    string // e.g. "message vector search"
+	SQL     string
+	Elapsed time.Duration
+	Plan    string // EXPLAIN output; empty if it couldn't be fetched
+	GuildID int64  // Guild the query ran for, when the context was tagged with one
+}
+
+// slowQueryWatch reports timed queries slower than its threshold
+type slowQueryWatch struct {
+	threshold time.Duration
+	report    func(SlowQuery)
+}
+
+// SetSlowQueryHook makes TimedRows report queries running longer than
+// threshold, along with their query plan; report runs in its own goroutine
+func (db *GormDB) SetSlowQueryHook(threshold time.Duration, report func(SlowQuery)) {
+	if threshold <= 0 || report == nil {
+		db.slowQuery = nil
+		return
+	}
+	db.slowQuery = &slowQueryWatch{threshold: threshold, report: report}
+}
+
+// TimedRows runs a raw query like Raw(query, args...).Rows(), stopping it at
+// the query timeout and reporting it to the slow query hook if it is too slow
+func (db *GormDB) TimedRows(ctx context.Context, name, query string, args ...interface{}) (*Rows, error) {
+	queryCtx, cancel := db.ReadContext(ctx)
+	start := time.Now()
+	rows, err := db.WithContext(queryCtx).Raw(query, args...).Rows()
+	elapsed := time.Since(start)
+	if err != nil {
+		cancel()
+		if err = TimeoutError(queryCtx, err); errors.Is(err, ErrQueryTimeout) {
+			log.Printf("⏱️ %s stopped after %s", name, elapsed.Round(time.Millisecond))
+		}
+		return nil, err
+	}
+
+	if watch := db.slowQuery; watch != nil && elapsed > watch.threshold {
+		log.Printf("🐢 Slow %s took %s", name, elapsed.Round(time.Millisecond))
+		guildID, _ := tenant.GuildFrom(ctx)
+		go func() {
+			// The caller's context may be cancelled by the time the plan is fetched
+			explainCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+			defer cancel()
+			watch.report(SlowQuery{
+				Name:    name,
+				SQL:     strings.TrimSpace(query),
+				Elapsed: elapsed,
+				Plan:    db.explain(explainCtx, query, args...),
+				GuildID: guildID,
+			})
+		}()
+	}
+	return &Rows{Rows: rows, ctx: queryCtx, cancel: cancel}, nil
+}
+
+// explain returns the planner's plan for a query without running it
+func (db *GormDB) explain(ctx context.Context, query string, args ...interface{}) string {
+	rows, err := db.WithContext(ctx).Raw("EXPLAIN "+query, args...).Rows()
+	if err != nil {
+		log.Printf("⚠️ Failed to explain slow query: %v", err)
+		return ""
+	}
+	defer rows.Close()
+
+	var lines []string
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			log.Printf("⚠️ Failed to read query plan: %v", err)
+			return ""
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
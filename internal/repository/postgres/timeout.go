@@ -0,0 +1,48 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// ErrQueryTimeout is returned by read queries stopped for running longer than
+// the query timeout
+var ErrQueryTimeout = errors.New("query timed out")
+
+// ReadContext derives the context a read query runs with, ending after the
+// configured query timeout; cancel it once the results are read
+func (db *GormDB) ReadContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if db.queryTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, db.queryTimeout)
+}
+
+// TimeoutError marks err as ErrQueryTimeout when the query's context, from
+// ReadContext, ran out
+func TimeoutError(ctx context.Context, err error) error {
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) && !errors.Is(err, ErrQueryTimeout) {
+		return fmt.Errorf("%w: %w", ErrQueryTimeout, err)
+	}
+	return err
+}
+
+// Rows are the results of a TimedRows query. The query is cancelled if they
+// are still being read at the query timeout; closing them releases it.
+type Rows struct {
+	*sql.Rows
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// Err returns the error that ended iteration, marking timeouts
+func (r *Rows) Err() error {
+	return TimeoutError(r.ctx, r.Rows.Err())
+}
+
+func (r *Rows) Close() error {
+	defer r.cancel()
+	return r.Rows.Close()
+}
@@ -0,0 +1,213 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/pgvector"
+	"discord-tars/internal/repository/postgres"
+	"discord-tars/internal/secrets"
+)
+
+type PriorityRepository struct {
+	db      *postgres.GormDB
+	content fieldCipher
+}
+
+func NewPriorityRepository(db *postgres.GormDB) *PriorityRepository {
+	return &PriorityRepository{db: db}
+}
+
+// SetCipher encrypts document content at rest; reads decrypt transparently
+func (r *PriorityRepository) SetCipher(cipher *secrets.Cipher) {
+	r.content = fieldCipher{cipher: cipher}
+}
+
+// AddChannel designates a channel as a priority source
+func (r *PriorityRepository) AddChannel(ctx context.Context, channel *models.PriorityChannel) error {
+	err := r.db.WithContext(ctx).
+		Where("channel_id = ?", channel.ChannelID).
+		Assign(models.PriorityChannel{GuildID: channel.GuildID, Label: channel.Label}).
+		FirstOrCreate(channel).Error
+	if err != nil {
+		log.Printf("❌ Failed to add priority channel: %v", err)
+		return fmt.Errorf("failed to add priority channel: %w", err)
+	}
+	return nil
+}
+
+// RemoveChannel removes a priority channel and the documents indexed from it
+// (pinned messages of that channel are kept)
+func (r *PriorityRepository) RemoveChannel(ctx context.Context, channelID int64) (bool, error) {
+	result := r.db.WithContext(ctx).Where("channel_id = ?", channelID).Delete(&models.PriorityChannel{})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to remove priority channel: %w", result.Error)
+	}
+
+	err := r.db.WithContext(ctx).
+		Where("channel_id = ? AND source = ?", channelID, models.PrioritySourceChannel).
+		Delete(&models.PriorityDocument{}).Error
+	if err != nil {
+		return false, fmt.Errorf("failed to remove priority documents: %w", err)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// ListChannels returns all priority channels, optionally filtered by guild (0 = all)
+func (r *PriorityRepository) ListChannels(ctx context.Context, guildID int64) ([]models.PriorityChannel, error) {
+	var channels []models.PriorityChannel
+	query := r.db.WithContext(ctx)
+	if guildID != 0 {
+		query = query.Where("guild_id = ?", guildID)
+	}
+	if err := query.Order("created_at").Find(&channels).Error; err != nil {
+		return nil, fmt.Errorf("failed to list priority channels: %w", err)
+	}
+	return channels, nil
+}
+
+// UpsertDocument stores a priority document with its embedding
+func (r *PriorityRepository) UpsertDocument(ctx context.Context, doc *models.PriorityDocument, embedding []float32) error {
+	doc.Embedding = pgvector.NewVector(embedding)
+	content, err := r.content.seal(doc.Content)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt priority document: %w", err)
+	}
+
+	row := *doc
+	err = r.db.WithContext(ctx).
+		Where("message_id = ?", doc.MessageID).
+		Assign(models.PriorityDocument{
+			GuildID:     doc.GuildID,
+			ChannelID:   doc.ChannelID,
+			ChannelName: doc.ChannelName,
+			AuthorName:  doc.AuthorName,
+			Source:      doc.Source,
+			Content:     content,
+			Embedding:   doc.Embedding,
+		}).
+		FirstOrCreate(&row).Error
+	if err != nil {
+		log.Printf("❌ Failed to store priority document for message ID: %d: %v", doc.MessageID, err)
+		return fmt.Errorf("failed to store priority document: %w", err)
+	}
+	doc.ID, doc.CreatedAt, doc.UpdatedAt = row.ID, row.CreatedAt, row.UpdatedAt
+	return nil
+}
+
+// PruneUnpinned deletes pin documents of a channel that are no longer pinned
+func (r *PriorityRepository) PruneUnpinned(ctx context.Context, channelID int64, pinnedIDs []int64) error {
+	query := r.db.WithContext(ctx).Where("channel_id = ? AND source = ?", channelID, models.PrioritySourcePin)
+	if len(pinnedIDs) > 0 {
+		query = query.Where("message_id NOT IN ?", pinnedIDs)
+	}
+	if err := query.Delete(&models.PriorityDocument{}).Error; err != nil {
+		return fmt.Errorf("failed to prune unpinned documents: %w", err)
+	}
+	return nil
+}
+
+// CountDocuments returns how many priority documents a guild has
+func (r *PriorityRepository) CountDocuments(ctx context.Context, guildID int64) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.PriorityDocument{}).Where("guild_id = ?", guildID).Count(&count).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to count priority documents: %w", err)
+	}
+	return count, nil
+}
+
+// ListDocumentsByLabel returns the documents indexed from a guild's priority
+// channels with the given label, e.g. "faq"
+func (r *PriorityRepository) ListDocumentsByLabel(ctx context.Context, guildID int64, label string) ([]models.PriorityDocument, error) {
+	var docs []models.PriorityDocument
+	err := r.db.WithContext(ctx).
+		Select("priority_documents.id, priority_documents.guild_id, priority_documents.channel_id, priority_documents.message_id, "+
+			"priority_documents.channel_name, priority_documents.author_name, priority_documents.source, priority_documents.content").
+		Joins("JOIN priority_channels pc ON pc.channel_id = priority_documents.channel_id").
+		Where("priority_documents.guild_id = ? AND pc.label = ?", guildID, label).
+		Find(&docs).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s documents: %w", label, err)
+	}
+	for n := range docs {
+		docs[n].Content = r.content.open(docs[n].Content)
+	}
+	return docs, nil
+}
+
+// Search finds the priority documents of a guild most similar to the query.
+// Session notes are campaign fiction rather than server information, so they
+// are left to SearchLabel.
+func (r *PriorityRepository) Search(ctx context.Context, guildID int64, queryEmbedding []float32, limit int, similarity float64) ([]models.PriorityResult, error) {
+	query := `
+		SELECT id, guild_id, channel_id, message_id, channel_name, author_name, source, content, updated_at,
+			1 - (embedding <=> $1::vector) as similarity
+		FROM priority_documents
+		WHERE guild_id = $2 AND 1 - (embedding <=> $1::vector) > $3
+			AND channel_id NOT IN (SELECT channel_id FROM priority_channels WHERE label = $5)
+		ORDER BY embedding <=> $1::vector
+		LIMIT $4
+	`
+
+	rows, err := r.db.TimedRows(ctx, "priority vector search", query, pgvector.NewVector(queryEmbedding), guildID, similarity, limit, models.PriorityLabelSessionNotes)
+	if err != nil {
+		log.Printf("❌ Failed to execute priority search query: %v", err)
+		return nil, fmt.Errorf("failed to search priority documents: %w", err)
+	}
+	defer rows.Close()
+
+	var results []models.PriorityResult
+	for rows.Next() {
+		var result models.PriorityResult
+		doc := &result.Document
+		if err := rows.Scan(&doc.ID, &doc.GuildID, &doc.ChannelID, &doc.MessageID, &doc.ChannelName,
+			&doc.AuthorName, &doc.Source, &doc.Content, &doc.UpdatedAt, &result.Similarity); err != nil {
+			return nil, fmt.Errorf("failed to scan priority result: %w", err)
+		}
+		doc.Content = r.content.open(doc.Content)
+		results = append(results, result)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read priority results: %w", err)
+	}
+
+	log.Printf("✅ Priority search returned %d results", len(results))
+	return results, nil
+}
+
+// SearchLabel finds the documents indexed from a guild's priority channels
+// with the given label, e.g. "faq", most similar to the query
+func (r *PriorityRepository) SearchLabel(ctx context.Context, guildID int64, label string, queryEmbedding []float32, limit int, similarity float64) ([]models.PriorityResult, error) {
+	query := `
+		SELECT d.id, d.guild_id, d.channel_id, d.message_id, d.channel_name, d.author_name, d.source, d.content, d.updated_at,
+			1 - (d.embedding <=> $1::vector) as similarity
+		FROM priority_documents d
+		JOIN priority_channels pc ON pc.channel_id = d.channel_id
+		WHERE d.guild_id = $2 AND pc.label = $3 AND 1 - (d.embedding <=> $1::vector) > $4
+		ORDER BY d.embedding <=> $1::vector
+		LIMIT $5
+	`
+
+	rows, err := r.db.TimedRows(ctx, "priority label vector search", query, pgvector.NewVector(queryEmbedding), guildID, label, similarity, limit)
+	if err != nil {
+		log.Printf("❌ Failed to execute %s search query: %v", label, err)
+		return nil, fmt.Errorf("failed to search %s documents: %w", label, err)
+	}
+	defer rows.Close()
+
+	var results []models.PriorityResult
+	for rows.Next() {
+		var result models.PriorityResult
+		doc := &result.Document
+		if err := rows.Scan(&doc.ID, &doc.GuildID, &doc.ChannelID, &doc.MessageID, &doc.ChannelName,
+			&doc.AuthorName, &doc.Source, &doc.Content, &doc.UpdatedAt, &result.Similarity); err != nil {
+			return nil, fmt.Errorf("failed to scan priority result: %w", err)
+		}
+		doc.Content = r.content.open(doc.Content)
+		results = append(results, result)
+	}
+	return results, rows.Err()
+}
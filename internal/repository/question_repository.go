@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/repository/postgres"
+	"discord-tars/internal/secrets"
+)
+
+type QuestionRepository struct {
+	db      *postgres.GormDB
+	content fieldCipher
+}
+
+func NewQuestionRepository(db *postgres.GormDB) *QuestionRepository {
+	return &QuestionRepository{db: db}
+}
+
+// SetCipher encrypts stored questions at rest; reads decrypt transparently
+func (r *QuestionRepository) SetCipher(cipher *secrets.Cipher) {
+	r.content = fieldCipher{cipher: cipher}
+}
+
+// RecordQuestion counts one more asking of a question, identified by the hash
+// of its normalized text
+func (r *QuestionRepository) RecordQuestion(ctx context.Context, guildID int64, hash, question string) error {
+	sealed, err := r.content.seal(question)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt question: %w", err)
+	}
+	err = r.db.WithContext(ctx).Exec(`
+		INSERT INTO asked_questions (guild_id, hash, question, count, last_asked_at, created_at)
+		VALUES (?, ?, ?, 1, ?, ?)
+		ON CONFLICT (guild_id, hash) DO UPDATE SET
+			count = asked_questions.count + 1,
+			question = EXCLUDED.question,
+			last_asked_at = EXCLUDED.last_asked_at`,
+		guildID, hash, sealed, time.Now(), time.Now()).Error
+	if err != nil {
+		log.Printf("❌ Failed to record question for guild ID: %d: %v", guildID, err)
+		return fmt.Errorf("failed to record question: %w", err)
+	}
+	return nil
+}
+
+// PopularQuestions returns a guild's most asked questions since the given time
+func (r *QuestionRepository) PopularQuestions(ctx context.Context, guildID int64, since time.Time, limit int) ([]models.AskedQuestion, error) {
+	var questions []models.AskedQuestion
+	err := r.db.WithContext(ctx).
+		Where("guild_id = ? AND last_asked_at >= ?", guildID, since).
+		Order("count DESC, last_asked_at DESC").
+		Limit(limit).
+		Find(&questions).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get popular questions: %w", err)
+	}
+	for n := range questions {
+		questions[n].Question = r.content.open(questions[n].Question)
+	}
+	return questions, nil
+}
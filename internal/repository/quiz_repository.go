@@ -0,0 +1,197 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/repository/postgres"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type QuizRepository struct {
+	db *postgres.GormDB
+}
+
+func NewQuizRepository(db *postgres.GormDB) *QuizRepository {
+	return &QuizRepository{db: db}
+}
+
+// CreateQuiz stores a quiz and its questions
+func (r *QuizRepository) CreateQuiz(ctx context.Context, quiz *models.Quiz, questions []models.QuizQuestion) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(quiz).Error; err != nil {
+			return err
+		}
+		for n := range questions {
+			questions[n].QuizID = quiz.ID
+		}
+		return tx.Create(&questions).Error
+	})
+	if err != nil {
+		log.Printf("❌ Failed to create quiz: %v", err)
+		return fmt.Errorf("failed to create quiz: %w", err)
+	}
+	return nil
+}
+
+// SetMessageID links a question to the Discord message that displays it
+func (r *QuizRepository) SetMessageID(ctx context.Context, questionID, messageID int64) error {
+	return r.db.WithContext(ctx).Model(&models.QuizQuestion{}).
+		Where("id = ?", questionID).Update("message_id", messageID).Error
+}
+
+// GetQuiz returns a quiz by ID, or nil if it does not exist
+func (r *QuizRepository) GetQuiz(ctx context.Context, quizID int64) (*models.Quiz, error) {
+	var quiz models.Quiz
+	err := r.db.WithContext(ctx).First(&quiz, quizID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quiz: %w", err)
+	}
+	return &quiz, nil
+}
+
+// GetQuestion returns a question by ID, or nil if it does not exist
+func (r *QuizRepository) GetQuestion(ctx context.Context, questionID int64) (*models.QuizQuestion, error) {
+	var question models.QuizQuestion
+	err := r.db.WithContext(ctx).First(&question, questionID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quiz question: %w", err)
+	}
+	return &question, nil
+}
+
+// ListQuestions returns the questions of a quiz in order
+func (r *QuizRepository) ListQuestions(ctx context.Context, quizID int64) ([]models.QuizQuestion, error) {
+	var questions []models.QuizQuestion
+	if err := r.db.WithContext(ctx).Where("quiz_id = ?", quizID).Order("position").Find(&questions).Error; err != nil {
+		return nil, fmt.Errorf("failed to list quiz questions: %w", err)
+	}
+	return questions, nil
+}
+
+// ListExpired returns open quizzes whose answering time has passed
+func (r *QuizRepository) ListExpired(ctx context.Context, now time.Time) ([]models.Quiz, error) {
+	var quizzes []models.Quiz
+	err := r.db.WithContext(ctx).
+		Where("closed_at IS NULL AND closes_at <= ?", now).
+		Find(&quizzes).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list expired quizzes: %w", err)
+	}
+	return quizzes, nil
+}
+
+// Answer records a user's answer. It reports false if the user had already
+// answered the question, keeping their first answer.
+func (r *QuizRepository) Answer(ctx context.Context, answer *models.QuizAnswer) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(answer)
+	if result.Error != nil {
+		log.Printf("❌ Failed to record quiz answer: %v", result.Error)
+		return false, fmt.Errorf("failed to record quiz answer: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// CountAnswers returns the number of answers per choice index of a question
+func (r *QuizRepository) CountAnswers(ctx context.Context, questionID int64) (map[int]int, error) {
+	var rows []struct {
+		Choice int
+		Count  int
+	}
+	err := r.db.WithContext(ctx).
+		Model(&models.QuizAnswer{}).
+		Select("choice, COUNT(*) AS count").
+		Where("question_id = ?", questionID).
+		Group("choice").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to count quiz answers: %w", err)
+	}
+
+	counts := make(map[int]int, len(rows))
+	for _, row := range rows {
+		counts[row.Choice] = row.Count
+	}
+	return counts, nil
+}
+
+// CloseQuiz marks a quiz as closed. It reports false if the quiz was already
+// closed, so concurrent closers don't post results twice.
+func (r *QuizRepository) CloseQuiz(ctx context.Context, quizID int64, at time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.Quiz{}).
+		Where("id = ? AND closed_at IS NULL", quizID).
+		Update("closed_at", at)
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to close quiz: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// QuizScores returns the scores of a quiz's players, best first
+func (r *QuizRepository) QuizScores(ctx context.Context, quizID int64, limit int) ([]models.QuizScore, error) {
+	return r.scores(ctx, limit, "question_id IN (?)",
+		r.db.WithContext(ctx).Model(&models.QuizQuestion{}).Select("id").Where("quiz_id = ?", quizID))
+}
+
+// Leaderboard returns the best quiz players of a guild across every quiz
+func (r *QuizRepository) Leaderboard(ctx context.Context, guildID int64, limit int) ([]models.QuizScore, error) {
+	return r.scores(ctx, limit, "guild_id = ?", guildID)
+}
+
+// UserScore returns a user's record on a guild's quizzes and their rank on
+// the leaderboard, or a zero rank if they haven't answered any
+func (r *QuizRepository) UserScore(ctx context.Context, guildID, userID int64) (models.QuizScore, int, error) {
+	score := models.QuizScore{UserID: userID}
+	err := r.db.WithContext(ctx).Model(&models.QuizAnswer{}).
+		Select("COALESCE(SUM(CASE WHEN correct THEN 1 ELSE 0 END), 0) AS correct, COUNT(*) AS answered").
+		Where("guild_id = ? AND user_id = ?", guildID, userID).
+		Scan(&score).Error
+	if err != nil {
+		return score, 0, fmt.Errorf("failed to get quiz score: %w", err)
+	}
+	if score.Answered == 0 {
+		return score, 0, nil
+	}
+
+	var ahead int64
+	err = r.db.WithContext(ctx).Table("(?) AS s",
+		r.db.WithContext(ctx).Model(&models.QuizAnswer{}).
+			Select("user_id, SUM(CASE WHEN correct THEN 1 ELSE 0 END) AS correct").
+			Where("guild_id = ?", guildID).
+			Group("user_id")).
+		Where("s.correct > ?", score.Correct).
+		Count(&ahead).Error
+	if err != nil {
+		return score, 0, fmt.Errorf("failed to rank quiz score: %w", err)
+	}
+	return score, int(ahead) + 1, nil
+}
+
+func (r *QuizRepository) scores(ctx context.Context, limit int, where string, args ...interface{}) ([]models.QuizScore, error) {
+	var scores []models.QuizScore
+	err := r.db.WithContext(ctx).Model(&models.QuizAnswer{}).
+		Select("user_id, SUM(CASE WHEN correct THEN 1 ELSE 0 END) AS correct, COUNT(*) AS answered").
+		Where(where, args...).
+		Group("user_id").
+		Order("correct DESC, answered ASC, MIN(created_at) ASC").
+		Limit(limit).
+		Scan(&scores).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to rank quiz players: %w", err)
+	}
+	return scores, nil
+}
@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+
+	"discord-tars/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// DefaultReactionBoost is the relevance a search hit gains per natural-log
+// step of its reaction count: ten reactions add about 0.07 to its similarity.
+// Heavily reacted messages are likelier to be answers people agreed with.
+const DefaultReactionBoost = 0.03
+
+// DefaultSolvedBoost is the relevance a search hit gains for being the
+// accepted answer of a forum post
+const DefaultSolvedBoost = 0.05
+
+// reactionsColumn selects a message's total reactions in a search query
+const reactionsColumn = `(SELECT COALESCE(SUM(mr.count), 0) FROM message_reactions mr WHERE mr.message_id = m.id) AS reactions`
+
+// solvedColumn selects whether a message is the accepted answer of a forum post
+const solvedColumn = `EXISTS (SELECT 1 FROM forum_posts fp WHERE fp.solution_message_id = m.id) AS solved`
+
+// SetReactionBoost sets how much reactions raise a search hit's rank; 0 ranks
+// by similarity only
+func (r *MessageRepository) SetReactionBoost(boost float64) {
+	r.reactionBoost = math.Max(0, boost)
+}
+
+// SetSolvedBoost sets how much being a forum post's accepted answer raises a
+// search hit's rank; 0 ignores it
+func (r *MessageRepository) SetSolvedBoost(boost float64) {
+	r.solvedBoost = math.Max(0, boost)
+}
+
+// endorsement is what, besides similarity, vouches for a search hit
+type endorsement struct {
+	reactions int
+	solved    bool // Accepted answer of a forum post
+}
+
+// relevance is the similarity a hit is ranked by, boosted by its reactions
+// and by being an accepted answer
+func (r *MessageRepository) relevance(similarity float64, e endorsement) float64 {
+	relevance := similarity + r.reactionBoost*math.Log1p(float64(max(e.reactions, 0)))
+	if e.solved {
+		relevance += r.solvedBoost
+	}
+	return relevance
+}
+
+// rankByReactions returns the indexes of search hits ordered by relevance;
+// endorsements[n] is how results[n] was received
+func (r *MessageRepository) rankByReactions(results []models.SearchResult, endorsements []endorsement) []int {
+	order := make([]int, len(results))
+	for n := range order {
+		order[n] = n
+	}
+	if r.reactionBoost > 0 || r.solvedBoost > 0 {
+		sort.SliceStable(order, func(a, b int) bool {
+			return r.relevance(results[order[a]].Similarity, endorsements[order[a]]) > r.relevance(results[order[b]].Similarity, endorsements[order[b]])
+		})
+	}
+	return order
+}
+
+// AdjustReaction adds delta to the count of an emoji on a message; reactions
+// to messages that aren't indexed are ignored
+func (r *MessageRepository) AdjustReaction(ctx context.Context, messageID int64, emoji string, delta int) error {
+	err := r.db.WithContext(ctx).Exec(`
+		INSERT INTO message_reactions (message_id, emoji, guild_id, count, updated_at)
+		SELECT id, ?, guild_id, ?, NOW() FROM messages WHERE id = ?
+		ON CONFLICT (message_id, emoji) DO UPDATE
+		SET count = GREATEST(message_reactions.count + ?, 0), updated_at = NOW()`,
+		emoji, max(delta, 0), messageID, delta).Error
+	if err != nil {
+		return fmt.Errorf("failed to update reaction count: %w", err)
+	}
+	return nil
+}
+
+// ClearReactions removes a message's reactions of one emoji, or all of them
+// when emoji is empty
+func (r *MessageRepository) ClearReactions(ctx context.Context, messageID int64, emoji string) error {
+	query := r.db.WithContext(ctx).Where("message_id = ?", messageID)
+	if emoji != "" {
+		query = query.Where("emoji = ?", emoji)
+	}
+	if err := query.Delete(&models.MessageReaction{}).Error; err != nil {
+		return fmt.Errorf("failed to clear reactions: %w", err)
+	}
+	return nil
+}
+
+// ReactionTotals returns the total reactions of each of the given messages
+// that has any
+func (r *MessageRepository) ReactionTotals(ctx context.Context, messageIDs []int64) (map[int64]int, error) {
+	totals := make(map[int64]int)
+	if len(messageIDs) == 0 {
+		return totals, nil
+	}
+	var rows []struct {
+		MessageID int64
+		Total     int
+	}
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT message_id, SUM(count) AS total FROM message_reactions
+		WHERE message_id = ANY(?) GROUP BY message_id`, pq.Array(messageIDs)).Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to count reactions: %w", err)
+	}
+	for _, row := range rows {
+		totals[row.MessageID] = row.Total
+	}
+	return totals, nil
+}
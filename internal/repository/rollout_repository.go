@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/repository/postgres"
+
+	"gorm.io/gorm"
+)
+
+type RolloutRepository struct {
+	db *postgres.GormDB
+}
+
+func NewRolloutRepository(db *postgres.GormDB) *RolloutRepository {
+	return &RolloutRepository{db: db}
+}
+
+// Save creates or updates a rollout
+func (r *RolloutRepository) Save(ctx context.Context, rollout *models.Rollout) error {
+	if err := r.db.WithContext(ctx).Save(rollout).Error; err != nil {
+		log.Printf("❌ Failed to save rollout: %v", err)
+		return fmt.Errorf("failed to save rollout: %w", err)
+	}
+	return nil
+}
+
+// Active returns the rollout in canary or promoted, or nil if there is none
+func (r *RolloutRepository) Active(ctx context.Context) (*models.Rollout, error) {
+	var rollout models.Rollout
+	err := r.db.WithContext(ctx).
+		Where("status IN ?", []string{models.RolloutCanary, models.RolloutPromoted}).
+		Order("created_at DESC").
+		First(&rollout).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get active rollout: %w", err)
+	}
+	return &rollout, nil
+}
+
+// List returns the latest rollouts, newest first
+func (r *RolloutRepository) List(ctx context.Context, limit int) ([]models.Rollout, error) {
+	var rollouts []models.Rollout
+	if err := r.db.WithContext(ctx).Order("created_at DESC").Limit(limit).Find(&rollouts).Error; err != nil {
+		return nil, fmt.Errorf("failed to list rollouts: %w", err)
+	}
+	return rollouts, nil
+}
@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/repository/postgres"
+)
+
+type SandboxRepository struct {
+	db *postgres.GormDB
+}
+
+func NewSandboxRepository(db *postgres.GormDB) *SandboxRepository {
+	return &SandboxRepository{db: db}
+}
+
+// Save puts a guild in sandbox mode, or changes its debug channel
+func (r *SandboxRepository) Save(ctx context.Context, sandbox *models.SandboxGuild) error {
+	if err := r.db.WithContext(ctx).Save(sandbox).Error; err != nil {
+		log.Printf("❌ Failed to save sandbox guild: %v", err)
+		return fmt.Errorf("failed to save sandbox guild: %w", err)
+	}
+	return nil
+}
+
+// Delete takes a guild out of sandbox mode, reporting whether it was in it
+func (r *SandboxRepository) Delete(ctx context.Context, guildID int64) (bool, error) {
+	result := r.db.WithContext(ctx).Where("guild_id = ?", guildID).Delete(&models.SandboxGuild{})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to delete sandbox guild: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// List returns every guild in sandbox mode
+func (r *SandboxRepository) List(ctx context.Context) ([]models.SandboxGuild, error) {
+	var guilds []models.SandboxGuild
+	if err := r.db.WithContext(ctx).Find(&guilds).Error; err != nil {
+		return nil, fmt.Errorf("failed to list sandbox guilds: %w", err)
+	}
+	return guilds, nil
+}
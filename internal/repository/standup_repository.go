@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/repository/postgres"
+
+	"gorm.io/gorm"
+)
+
+type StandupRepository struct {
+	db *postgres.GormDB
+}
+
+func NewStandupRepository(db *postgres.GormDB) *StandupRepository {
+	return &StandupRepository{db: db}
+}
+
+// SaveTeam creates or updates the standup team of a channel
+func (r *StandupRepository) SaveTeam(ctx context.Context, team *models.StandupTeam) error {
+	log.Printf("💾 Saving standup team for channel ID: %d", team.ChannelID)
+	err := r.db.WithContext(ctx).
+		Where("channel_id = ?", team.ChannelID).
+		Assign(models.StandupTeam{
+			GuildID:   team.GuildID,
+			Name:      team.Name,
+			MemberIDs: team.MemberIDs,
+		}).
+		FirstOrCreate(team).Error
+	if err != nil {
+		log.Printf("❌ Failed to save standup team: %v", err)
+		return fmt.Errorf("failed to save standup team: %w", err)
+	}
+	return nil
+}
+
+// GetTeamByChannel returns the team configured for a channel, or nil if none
+func (r *StandupRepository) GetTeamByChannel(ctx context.Context, channelID int64) (*models.StandupTeam, error) {
+	var team models.StandupTeam
+	err := r.db.WithContext(ctx).Where("channel_id = ?", channelID).First(&team).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get standup team: %w", err)
+	}
+	return &team, nil
+}
+
+// CreateSession opens a new standup session
+func (r *StandupRepository) CreateSession(ctx context.Context, session *models.StandupSession) error {
+	if err := r.db.WithContext(ctx).Omit("Team").Create(session).Error; err != nil {
+		return fmt.Errorf("failed to create standup session: %w", err)
+	}
+	return nil
+}
+
+// GetOpenSession returns the open session of a team, or nil if none
+func (r *StandupRepository) GetOpenSession(ctx context.Context, teamID int64) (*models.StandupSession, error) {
+	var session models.StandupSession
+	err := r.db.WithContext(ctx).
+		Preload("Team").
+		Where("team_id = ? AND closed_at IS NULL", teamID).
+		Order("started_at DESC").
+		First(&session).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get open standup session: %w", err)
+	}
+	return &session, nil
+}
+
+// ListOpenSessions returns every session that has not been closed yet
+func (r *StandupRepository) ListOpenSessions(ctx context.Context) ([]models.StandupSession, error) {
+	var sessions []models.StandupSession
+	err := r.db.WithContext(ctx).
+		Preload("Team").
+		Where("closed_at IS NULL").
+		Find(&sessions).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list open standup sessions: %w", err)
+	}
+	return sessions, nil
+}
+
+// MarkNagged records that missing members were reminded
+func (r *StandupRepository) MarkNagged(ctx context.Context, sessionID int64, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.StandupSession{}).
+		Where("id = ?", sessionID).Update("nagged_at", at).Error
+}
+
+// CloseSession marks a session as summarized
+func (r *StandupRepository) CloseSession(ctx context.Context, sessionID int64, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.StandupSession{}).
+		Where("id = ?", sessionID).Update("closed_at", at).Error
+}
+
+// SaveUpdate stores or replaces a member's update for a session
+func (r *StandupRepository) SaveUpdate(ctx context.Context, update *models.StandupUpdate) error {
+	err := r.db.WithContext(ctx).
+		Where("session_id = ? AND user_id = ?", update.SessionID, update.UserID).
+		Assign(models.StandupUpdate{
+			Username: update.Username,
+			Content:  update.Content,
+		}).
+		FirstOrCreate(update).Error
+	if err != nil {
+		log.Printf("❌ Failed to save standup update: %v", err)
+		return fmt.Errorf("failed to save standup update: %w", err)
+	}
+	return nil
+}
+
+// ListUpdates returns the updates of a session in submission order
+func (r *StandupRepository) ListUpdates(ctx context.Context, sessionID int64) ([]models.StandupUpdate, error) {
+	var updates []models.StandupUpdate
+	err := r.db.WithContext(ctx).
+		Where("session_id = ?", sessionID).
+		Order("created_at").
+		Find(&updates).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list standup updates: %w", err)
+	}
+	return updates, nil
+}
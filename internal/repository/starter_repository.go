@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/pgvector"
+	"discord-tars/internal/repository/postgres"
+
+	"gorm.io/gorm"
+)
+
+type StarterRepository struct {
+	db *postgres.GormDB
+}
+
+func NewStarterRepository(db *postgres.GormDB) *StarterRepository {
+	return &StarterRepository{db: db}
+}
+
+// SaveSchedule creates a channel's starter schedule, or replaces the one it has
+func (r *StarterRepository) SaveSchedule(ctx context.Context, schedule *models.StarterSchedule) error {
+	existing, err := r.GetSchedule(ctx, schedule.ChannelID)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		schedule.ID, schedule.CreatedAt, schedule.LastPostedAt = existing.ID, existing.CreatedAt, existing.LastPostedAt
+	}
+	if err := r.db.WithContext(ctx).Save(schedule).Error; err != nil {
+		log.Printf("❌ Failed to save starter schedule: %v", err)
+		return fmt.Errorf("failed to save starter schedule: %w", err)
+	}
+	return nil
+}
+
+// GetSchedule returns a channel's starter schedule, or nil if it has none
+func (r *StarterRepository) GetSchedule(ctx context.Context, channelID int64) (*models.StarterSchedule, error) {
+	var schedule models.StarterSchedule
+	err := r.db.WithContext(ctx).Where("channel_id = ?", channelID).First(&schedule).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get starter schedule: %w", err)
+	}
+	return &schedule, nil
+}
+
+// DeleteSchedule stops a channel's starters; what was posted is remembered
+func (r *StarterRepository) DeleteSchedule(ctx context.Context, guildID, channelID int64) (bool, error) {
+	result := r.db.WithContext(ctx).Where("guild_id = ? AND channel_id = ?", guildID, channelID).Delete(&models.StarterSchedule{})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to delete starter schedule: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// ListSchedules returns a guild's starter schedules
+func (r *StarterRepository) ListSchedules(ctx context.Context, guildID int64) ([]models.StarterSchedule, error) {
+	var schedules []models.StarterSchedule
+	if err := r.db.WithContext(ctx).Where("guild_id = ?", guildID).Order("created_at").Find(&schedules).Error; err != nil {
+		return nil, fmt.Errorf("failed to list starter schedules: %w", err)
+	}
+	return schedules, nil
+}
+
+// ListAllSchedules returns every guild's starter schedules
+func (r *StarterRepository) ListAllSchedules(ctx context.Context) ([]models.StarterSchedule, error) {
+	var schedules []models.StarterSchedule
+	if err := r.db.WithContext(ctx).Find(&schedules).Error; err != nil {
+		return nil, fmt.Errorf("failed to list starter schedules: %w", err)
+	}
+	return schedules, nil
+}
+
+// MarkPosted records when a schedule last posted
+func (r *StarterRepository) MarkPosted(ctx context.Context, scheduleID int64, postedAt time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.StarterSchedule{}).
+		Where("id = ?", scheduleID).
+		Update("last_posted_at", postedAt).Error
+}
+
+// SavePost remembers a posted starter, with its embedding if it has one
+func (r *StarterRepository) SavePost(ctx context.Context, post *models.StarterPost, embedding []float32) error {
+	if len(embedding) > 0 {
+		vector := pgvector.NewVector(embedding)
+		post.Embedding = &vector
+	}
+	if err := r.db.WithContext(ctx).Create(post).Error; err != nil {
+		log.Printf("❌ Failed to store starter post for guild ID: %d: %v", post.GuildID, err)
+		return fmt.Errorf("failed to store starter post: %w", err)
+	}
+	return nil
+}
+
+// RecentPosts returns the starters last posted in a guild, newest first
+func (r *StarterRepository) RecentPosts(ctx context.Context, guildID int64, limit int) ([]models.StarterPost, error) {
+	var posts []models.StarterPost
+	err := r.db.WithContext(ctx).
+		Select("id, schedule_id, guild_id, channel_id, text, created_at").
+		Where("guild_id = ?", guildID).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&posts).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list starter posts: %w", err)
+	}
+	return posts, nil
+}
+
+// ClosestPost returns the guild's earlier starter most similar to an
+// embedding and their similarity, or an empty text if it has none
+func (r *StarterRepository) ClosestPost(ctx context.Context, guildID int64, embedding []float32) (string, float64, error) {
+	query := `
+		SELECT text, 1 - (embedding <=> $1::vector) AS similarity
+		FROM starter_posts
+		WHERE guild_id = $2 AND embedding IS NOT NULL
+		ORDER BY embedding <=> $1::vector
+		LIMIT 1
+	`
+
+	rows, err := r.db.TimedRows(ctx, "starter post search", query, pgvector.NewVector(embedding), guildID)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to search starter posts: %w", err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return "", 0, rows.Err()
+	}
+	var text string
+	var similarity float64
+	if err := rows.Scan(&text, &similarity); err != nil {
+		return "", 0, fmt.Errorf("failed to scan starter post: %w", err)
+	}
+	return text, similarity, nil
+}
@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/pgvector"
+	"discord-tars/internal/repository/postgres"
+	"discord-tars/internal/secrets"
+)
+
+type SummaryRepository struct {
+	db      *postgres.GormDB
+	content fieldCipher
+}
+
+func NewSummaryRepository(db *postgres.GormDB) *SummaryRepository {
+	return &SummaryRepository{db: db}
+}
+
+// SetCipher encrypts summaries at rest; reads decrypt transparently
+func (r *SummaryRepository) SetCipher(cipher *secrets.Cipher) {
+	r.content = fieldCipher{cipher: cipher}
+}
+
+// Save stores a channel's summary of a day with its embedding, replacing any
+// earlier one for that day
+func (r *SummaryRepository) Save(ctx context.Context, summary *models.ChannelSummary, embedding []float32) error {
+	summary.Embedding = pgvector.NewVector(embedding)
+	content, err := r.content.seal(summary.Content)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt channel summary: %w", err)
+	}
+
+	row := *summary
+	err = r.db.WithContext(ctx).
+		Where("channel_id = ? AND day = ?", summary.ChannelID, summary.Day).
+		Assign(models.ChannelSummary{
+			GuildID:      summary.GuildID,
+			ChannelName:  summary.ChannelName,
+			MessageCount: summary.MessageCount,
+			Content:      content,
+			Embedding:    summary.Embedding,
+		}).
+		FirstOrCreate(&row).Error
+	if err != nil {
+		log.Printf("❌ Failed to store summary of channel ID: %d: %v", summary.ChannelID, err)
+		return fmt.Errorf("failed to store channel summary: %w", err)
+	}
+	summary.ID, summary.CreatedAt = row.ID, row.CreatedAt
+	return nil
+}
+
+// SummarizedChannels returns the channels that already have a summary of the day
+func (r *SummaryRepository) SummarizedChannels(ctx context.Context, day time.Time) (map[int64]bool, error) {
+	var ids []int64
+	err := r.db.WithContext(ctx).Model(&models.ChannelSummary{}).
+		Where("day = ?", day).
+		Pluck("channel_id", &ids).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list summarized channels: %w", err)
+	}
+	summarized := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		summarized[id] = true
+	}
+	return summarized, nil
+}
+
+// Search finds the summaries of a guild most similar to the query, limited to
+// days within [since, until) when those are set
+func (r *SummaryRepository) Search(ctx context.Context, guildID int64, queryEmbedding []float32, since, until time.Time, limit int, similarity float64) ([]models.SummaryResult, error) {
+	if until.IsZero() {
+		until = time.Now().AddDate(1, 0, 0)
+	}
+	query := `
+		SELECT id, guild_id, channel_id, day, channel_name, message_count, content,
+			1 - (embedding <=> $1::vector) as similarity
+		FROM channel_summaries
+		WHERE guild_id = $2 AND day >= $3 AND day < $4 AND 1 - (embedding <=> $1::vector) > $5
+		ORDER BY embedding <=> $1::vector
+		LIMIT $6
+	`
+
+	rows, err := r.db.TimedRows(ctx, "summary vector search", query, pgvector.NewVector(queryEmbedding), guildID, since, until, similarity, limit)
+	if err != nil {
+		log.Printf("❌ Failed to execute summary search query: %v", err)
+		return nil, fmt.Errorf("failed to search channel summaries: %w", err)
+	}
+	defer rows.Close()
+
+	var results []models.SummaryResult
+	for rows.Next() {
+		var result models.SummaryResult
+		summary := &result.Summary
+		if err := rows.Scan(&summary.ID, &summary.GuildID, &summary.ChannelID, &summary.Day, &summary.ChannelName,
+			&summary.MessageCount, &summary.Content, &result.Similarity); err != nil {
+			return nil, fmt.Errorf("failed to scan summary result: %w", err)
+		}
+		summary.Content = r.content.open(summary.Content)
+		results = append(results, result)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read summary results: %w", err)
+	}
+
+	log.Printf("✅ Summary search returned %d results", len(results))
+	return results, nil
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/repository/postgres"
+
+	"gorm.io/gorm"
+)
+
+type TimezoneRepository struct {
+	db *postgres.GormDB
+}
+
+func NewTimezoneRepository(db *postgres.GormDB) *TimezoneRepository {
+	return &TimezoneRepository{db: db}
+}
+
+// GetUser returns a member's timezone, or nil when they haven't set one
+func (r *TimezoneRepository) GetUser(ctx context.Context, userID int64) (*models.UserTimezone, error) {
+	var tz models.UserTimezone
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&tz).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user timezone: %w", err)
+	}
+	return &tz, nil
+}
+
+// SaveUser sets a member's timezone
+func (r *TimezoneRepository) SaveUser(ctx context.Context, tz *models.UserTimezone) error {
+	if err := r.db.WithContext(ctx).Save(tz).Error; err != nil {
+		log.Printf("❌ Failed to save user timezone: %v", err)
+		return fmt.Errorf("failed to save user timezone: %w", err)
+	}
+	return nil
+}
+
+// DeleteUser forgets a member's timezone, reporting whether they had one
+func (r *TimezoneRepository) DeleteUser(ctx context.Context, userID int64) (bool, error) {
+	result := r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&models.UserTimezone{})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to delete user timezone: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// GetGuild returns a guild's timezone, or nil when it has none
+func (r *TimezoneRepository) GetGuild(ctx context.Context, guildID int64) (*models.GuildTimezone, error) {
+	var tz models.GuildTimezone
+	err := r.db.WithContext(ctx).Where("guild_id = ?", guildID).First(&tz).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get guild timezone: %w", err)
+	}
+	return &tz, nil
+}
+
+// SaveGuild sets a guild's timezone
+func (r *TimezoneRepository) SaveGuild(ctx context.Context, tz *models.GuildTimezone) error {
+	if err := r.db.WithContext(ctx).Save(tz).Error; err != nil {
+		log.Printf("❌ Failed to save guild timezone: %v", err)
+		return fmt.Errorf("failed to save guild timezone: %w", err)
+	}
+	return nil
+}
+
+// DeleteGuild forgets a guild's timezone, reporting whether it had one
+func (r *TimezoneRepository) DeleteGuild(ctx context.Context, guildID int64) (bool, error) {
+	result := r.db.WithContext(ctx).Where("guild_id = ?", guildID).Delete(&models.GuildTimezone{})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to delete guild timezone: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
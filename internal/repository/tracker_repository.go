@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/repository/postgres"
+
+	"gorm.io/gorm"
+)
+
+type TrackerRepository struct {
+	db *postgres.GormDB
+}
+
+func NewTrackerRepository(db *postgres.GormDB) *TrackerRepository {
+	return &TrackerRepository{db: db}
+}
+
+// SaveConfig creates or replaces a guild's issue tracker configuration
+func (r *TrackerRepository) SaveConfig(ctx context.Context, cfg *models.TrackerConfig) error {
+	log.Printf("💾 Saving %s tracker config for guild ID: %d", cfg.Provider, cfg.GuildID)
+	if err := r.db.WithContext(ctx).Save(cfg).Error; err != nil {
+		log.Printf("❌ Failed to save tracker config: %v", err)
+		return fmt.Errorf("failed to save tracker config: %w", err)
+	}
+	return nil
+}
+
+// GetConfig returns a guild's issue tracker configuration, or nil if none
+func (r *TrackerRepository) GetConfig(ctx context.Context, guildID int64) (*models.TrackerConfig, error) {
+	var cfg models.TrackerConfig
+	err := r.db.WithContext(ctx).Where("guild_id = ?", guildID).First(&cfg).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tracker config: %w", err)
+	}
+	return &cfg, nil
+}
+
+// DeleteConfig removes a guild's issue tracker configuration
+func (r *TrackerRepository) DeleteConfig(ctx context.Context, guildID int64) (bool, error) {
+	result := r.db.WithContext(ctx).Where("guild_id = ?", guildID).Delete(&models.TrackerConfig{})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to delete tracker config: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
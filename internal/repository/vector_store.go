@@ -0,0 +1,173 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/repository/postgres"
+	"discord-tars/internal/vectorstore"
+
+	"github.com/lib/pq"
+)
+
+// Messages outside the wanted languages are only dropped once loaded, so
+// searches filtering on language ask the vector store for more candidates
+const languageOverfetch = 2
+
+// SetVectorStore searches message embeddings in an external store instead of
+// Postgres. Embeddings are still stored in Postgres, which index health,
+// re-ranking and backups read, and written to the store as well;
+// SyncVectorStore copies those stored before the store was set up.
+func (r *MessageRepository) SetVectorStore(store vectorstore.Store) {
+	r.vectors = store
+}
+
+// upsertVector writes a message's embedding to the vector store, with the
+// guild and channel searches filter on
+func (r *MessageRepository) upsertVector(ctx context.Context, messageID int64, embedding []float32) error {
+	var msg struct {
+		GuildID   int64
+		ChannelID int64
+	}
+	err := r.db.WithContext(ctx).
+		Raw("SELECT COALESCE(guild_id, 0) AS guild_id, COALESCE(channel_id, 0) AS channel_id FROM messages WHERE id = ?", messageID).
+		Scan(&msg).Error
+	if err != nil {
+		return fmt.Errorf("failed to look up message %d: %w", messageID, err)
+	}
+	return r.vectors.Upsert(ctx, []vectorstore.Point{{ID: messageID, Vector: embedding, GuildID: msg.GuildID, ChannelID: msg.ChannelID}})
+}
+
+// searchVectorStore finds candidates in the vector store, then loads them
+// from Postgres in the store's order. Candidates the language filter leaves
+// out, or whose message is gone, are dropped; the points of gone messages are
+// deleted from the store.
+func (r *MessageRepository) searchVectorStore(ctx context.Context, queryEmbedding []float32, filter vectorstore.Filter, fetch int, similarity float64, languages []string) ([]models.SearchResult, []endorsement, []string, error) {
+	if len(languages) > 0 {
+		fetch *= languageOverfetch
+	}
+	searchCtx, cancel := r.db.ReadContext(ctx)
+	matches, err := r.vectors.Search(searchCtx, queryEmbedding, filter, fetch, similarity)
+	cancel()
+	if err != nil {
+		log.Printf("❌ Failed to search the vector store: %v", err)
+		return nil, nil, nil, fmt.Errorf("failed to search similar messages: %w", postgres.TimeoutError(searchCtx, err))
+	}
+	if len(matches) == 0 {
+		return nil, nil, nil, nil
+	}
+	ids := make([]int64, len(matches))
+	for n, match := range matches {
+		ids[n] = match.ID
+	}
+
+	// The similarity is the store's, filled in below
+	query := `
+		SELECT
+			m.id, m.channel_id, m.user_id, m.guild_id, m.content, m.timestamp,
+			u.id as user_id, u.username, u.discriminator, u.avatar_url,
+			c.id as channel_id, c.name as channel_name, c.type as channel_type,
+			0::float8 as similarity,
+			COALESCE(me.embedding::text, ''),
+			` + reactionsColumn + `,
+			` + solvedColumn + `
+		FROM messages m
+		JOIN users u ON m.user_id = u.id
+		JOIN channels c ON m.channel_id = c.id
+		LEFT JOIN message_embeddings me ON me.message_id = m.id
+		WHERE m.id = ANY($1)`
+	args := []interface{}{pq.Array(ids)}
+	if len(languages) > 0 {
+		args = append(args, pq.Array(languages))
+		query += `
+		AND NOT EXISTS (SELECT 1 FROM message_languages ml WHERE ml.message_id = m.id AND ml.language <> ALL($2))`
+	}
+	rows, err := r.db.TimedRows(ctx, "vector store match loading", query, args...)
+	if err != nil {
+		log.Printf("❌ Failed to load vector store matches: %v", err)
+		return nil, nil, nil, fmt.Errorf("failed to load similar messages: %w", err)
+	}
+	defer rows.Close()
+	loaded, loadedEndorsements, loadedEmbeddings, err := r.scanSearchResults(rows)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	position := make(map[int64]int, len(loaded))
+	for n, result := range loaded {
+		position[result.Message.ID] = n
+	}
+	results := make([]models.SearchResult, 0, len(loaded))
+	endorsements := make([]endorsement, 0, len(loaded))
+	embeddings := make([]string, 0, len(loaded))
+	var gone []int64
+	for _, match := range matches {
+		n, ok := position[match.ID]
+		if !ok {
+			// With a language filter the message may just be left out
+			if len(languages) == 0 {
+				gone = append(gone, match.ID)
+			}
+			continue
+		}
+		result := loaded[n]
+		result.Similarity = match.Similarity
+		results = append(results, result)
+		endorsements = append(endorsements, loadedEndorsements[n])
+		embeddings = append(embeddings, loadedEmbeddings[n])
+	}
+	if len(gone) > 0 {
+		if err := r.vectors.Delete(ctx, gone); err != nil {
+			log.Printf("⚠️ Failed to delete %d vector store points of deleted messages: %v", len(gone), err)
+		}
+	}
+	return results, endorsements, embeddings, nil
+}
+
+// SyncVectorStore copies the embeddings stored in Postgres to the vector
+// store in batches, reporting the running total to progress, and returns how
+// many it copied. Copying again is harmless: points are replaced.
+func (r *MessageRepository) SyncVectorStore(ctx context.Context, batchSize int, progress func(copied int)) (int, error) {
+	if r.vectors == nil {
+		return 0, fmt.Errorf("no vector store is configured")
+	}
+	copied := 0
+	var after int64
+	for {
+		var rows []struct {
+			MessageID int64
+			GuildID   int64
+			ChannelID int64
+			Embedding string
+		}
+		err := r.db.WithContext(ctx).Raw(`
+			SELECT me.message_id, COALESCE(m.guild_id, 0) AS guild_id, COALESCE(m.channel_id, 0) AS channel_id,
+				me.embedding::text AS embedding
+			FROM message_embeddings me
+			JOIN messages m ON m.id = me.message_id
+			WHERE me.message_id > ?
+			ORDER BY me.message_id
+			LIMIT ?`, after, batchSize).Scan(&rows).Error
+		if err != nil {
+			return copied, fmt.Errorf("failed to read embeddings: %w", err)
+		}
+		if len(rows) == 0 {
+			return copied, nil
+		}
+
+		points := make([]vectorstore.Point, len(rows))
+		for n, row := range rows {
+			points[n] = vectorstore.Point{ID: row.MessageID, Vector: parseVector(row.Embedding), GuildID: row.GuildID, ChannelID: row.ChannelID}
+		}
+		if err := r.vectors.Upsert(ctx, points); err != nil {
+			return copied, err
+		}
+		copied += len(points)
+		after = rows[len(rows)-1].MessageID
+		if progress != nil {
+			progress(copied)
+		}
+	}
+}
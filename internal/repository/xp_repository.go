@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/repository/postgres"
+
+	"gorm.io/gorm"
+)
+
+type XPRepository struct {
+	db *postgres.GormDB
+}
+
+func NewXPRepository(db *postgres.GormDB) *XPRepository {
+	return &XPRepository{db: db}
+}
+
+// XPGain is the XP a member has after earning some, and how much was earned
+type XPGain struct {
+	UserID int64
+	XP     int64
+	Gained int64 `gorm:"-"` // Negative when a reaction was removed
+}
+
+// SaveConfig creates or replaces a guild's XP settings
+func (r *XPRepository) SaveConfig(ctx context.Context, cfg *models.XPConfig) error {
+	if err := r.db.WithContext(ctx).Save(cfg).Error; err != nil {
+		log.Printf("❌ Failed to save XP config: %v", err)
+		return fmt.Errorf("failed to save XP config: %w", err)
+	}
+	return nil
+}
+
+// GetConfig returns a guild's XP settings, or nil if XP is off
+func (r *XPRepository) GetConfig(ctx context.Context, guildID int64) (*models.XPConfig, error) {
+	var cfg models.XPConfig
+	err := r.db.WithContext(ctx).Where("guild_id = ?", guildID).First(&cfg).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get XP config: %w", err)
+	}
+	return &cfg, nil
+}
+
+// DeleteConfig turns XP off for a guild; members keep the XP they earned
+func (r *XPRepository) DeleteConfig(ctx context.Context, guildID int64) (bool, error) {
+	result := r.db.WithContext(ctx).Where("guild_id = ?", guildID).Delete(&models.XPConfig{})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to delete XP config: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// AddMessageXP credits a member with xp for a message sent at, unless they
+// earned message XP after cooldownStart. It returns nil when nothing was
+// earned.
+func (r *XPRepository) AddMessageXP(ctx context.Context, guildID, userID, xp int64, at, cooldownStart time.Time) (*XPGain, error) {
+	var rows []XPGain
+	err := r.db.WithContext(ctx).Raw(`
+		INSERT INTO member_xps (guild_id, user_id, xp, messages, last_message_at, updated_at)
+		VALUES (?, ?, ?, 1, ?, NOW())
+		ON CONFLICT (guild_id, user_id) DO UPDATE
+		SET xp = member_xps.xp + EXCLUDED.xp, messages = member_xps.messages + 1,
+			last_message_at = EXCLUDED.last_message_at, updated_at = NOW()
+		WHERE member_xps.last_message_at IS NULL OR member_xps.last_message_at <= ?
+		RETURNING user_id, xp`,
+		guildID, userID, xp, at, cooldownStart).Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to add message XP: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	rows[0].Gained = xp
+	return &rows[0], nil
+}
+
+// AddReactionXP credits the author of a message with xp per reaction, delta
+// being +1 for an added reaction and -1 for a removed one. Reactions to
+// messages that aren't indexed, to bots, and to one's own messages earn
+// nothing; it returns nil then.
+func (r *XPRepository) AddReactionXP(ctx context.Context, messageID, reactorID, xp int64, delta int) (*XPGain, error) {
+	gained := xp * int64(delta)
+	var rows []XPGain
+	err := r.db.WithContext(ctx).Raw(`
+		INSERT INTO member_xps (guild_id, user_id, xp, reactions, updated_at)
+		SELECT m.guild_id, m.user_id, GREATEST(?, 0), GREATEST(?, 0), NOW()
+		FROM messages m JOIN users u ON u.id = m.user_id
+		WHERE m.id = ? AND m.user_id <> ? AND m.guild_id IS NOT NULL AND NOT COALESCE(u.bot, FALSE)
+		ON CONFLICT (guild_id, user_id) DO UPDATE
+		SET xp = GREATEST(member_xps.xp + ?, 0), reactions = GREATEST(member_xps.reactions + ?, 0), updated_at = NOW()
+		RETURNING user_id, xp`,
+		gained, delta, messageID, reactorID, gained, delta).Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to add reaction XP: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	rows[0].Gained = gained
+	return &rows[0], nil
+}
+
+// Leaderboard returns a guild's members with the most XP, skipping offset
+func (r *XPRepository) Leaderboard(ctx context.Context, guildID int64, offset, limit int) ([]models.MemberXP, error) {
+	var members []models.MemberXP
+	err := r.db.WithContext(ctx).
+		Where("guild_id = ? AND xp > 0", guildID).
+		Order("xp DESC, updated_at ASC").
+		Offset(offset).
+		Limit(limit).
+		Find(&members).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list XP leaderboard: %w", err)
+	}
+	return members, nil
+}
+
+// CountRanked returns how many members of a guild have XP
+func (r *XPRepository) CountRanked(ctx context.Context, guildID int64) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.MemberXP{}).
+		Where("guild_id = ? AND xp > 0", guildID).
+		Count(&count).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to count ranked members: %w", err)
+	}
+	return count, nil
+}
+
+// Rank returns a member's XP and their place on the guild's leaderboard, or
+// nil and a zero rank if they have none
+func (r *XPRepository) Rank(ctx context.Context, guildID, userID int64) (*models.MemberXP, int, error) {
+	var member models.MemberXP
+	err := r.db.WithContext(ctx).Where("guild_id = ? AND user_id = ?", guildID, userID).First(&member).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && member.XP == 0) {
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get member XP: %w", err)
+	}
+
+	var ahead int64
+	err = r.db.WithContext(ctx).Model(&models.MemberXP{}).
+		Where("guild_id = ? AND (xp > ? OR (xp = ? AND updated_at < ?))", guildID, member.XP, member.XP, member.UpdatedAt).
+		Count(&ahead).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to rank member XP: %w", err)
+	}
+	return &member, int(ahead) + 1, nil
+}
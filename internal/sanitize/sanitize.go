@@ -0,0 +1,91 @@
+// Package sanitize cleans Discord text on its way into and out of the model.
+// Retrieved messages may carry mass mentions, invite links or secrets pasted
+// by mistake; repeating them in an answer would ping people, advertise
+// servers or leak credentials, and unbalanced markdown breaks the message or
+// embed it ends up in.
+package sanitize
+
+import (
+	"regexp"
+	"strings"
+)
+
+// MaxMentions is how many user and role mentions an answer may keep; the
+// rest are shown as plain text
+const MaxMentions = 5
+
+const (
+	removedInvite = "[invite link removed]"
+	removedToken  = "[token removed]"
+	removedEmail  = "[email removed]"
+	removedNumber = "[number removed]"
+)
+
+var (
+	massMention = regexp.MustCompile(`@(everyone|here)\b`)
+	roleMention = regexp.MustCompile(`<@&\d+>`)
+	mention     = regexp.MustCompile(`<@[!&]?\d+>`)
+	invite      = regexp.MustCompile(`(?i)(https?://)?(www\.)?(discord\.gg|discord(app)?\.com/invite)/[a-z0-9-]+`)
+	// tokens are credentials that show up pasted in chat: Discord bot
+	// tokens, OpenAI and Anthropic keys, GitHub, Slack and AWS access keys
+	tokens = regexp.MustCompile(`\b([MNO][A-Za-z\d_-]{23,27}\.[A-Za-z\d_-]{6}\.[A-Za-z\d_-]{27,}|sk-(ant-)?[A-Za-z0-9_-]{20,}|gh[pousr]_[A-Za-z0-9]{36,}|xox[abprs]-[A-Za-z0-9-]{10,}|AKIA[0-9A-Z]{16})\b`)
+	email  = regexp.MustCompile(`[\w.+-]+@[\w-]+(\.[\w-]+)+`)
+	// number matches nine digits or more, maybe separated, such as phone
+	// numbers and Discord IDs
+	number = regexp.MustCompile(`\+?\d([ ().-]?\d){8,}`)
+)
+
+// Context cleans retrieved content before it goes into a prompt: mass and
+// role mentions are defused, and invite links and tokens removed
+func Context(text string) string {
+	text = defuseMassMentions(text)
+	text = roleMention.ReplaceAllString(text, "@role")
+	return scrub(text)
+}
+
+// Output cleans model output before it is sent: mass mentions are defused,
+// mentions past MaxMentions shown as plain text, invite links and tokens
+// removed, and an unclosed code block closed
+func Output(text string) string {
+	text = defuseMassMentions(text)
+	kept := 0
+	text = mention.ReplaceAllStringFunc(text, func(m string) string {
+		if kept < MaxMentions {
+			kept++
+			return m
+		}
+		if strings.HasPrefix(m, "<@&") {
+			return "@role"
+		}
+		return "@user"
+	})
+	text = scrub(text)
+	if strings.Count(text, "```")%2 == 1 {
+		text += "\n```"
+	}
+	return text
+}
+
+// Anonymize cleans text shown outside the server it was written in, such as
+// on the dashboard's live feed: mentions, email addresses and long numbers
+// are replaced so they don't tell who wrote it or whom it is about, and
+// invite links and tokens removed
+func Anonymize(text string) string {
+	text = defuseMassMentions(text)
+	text = roleMention.ReplaceAllString(text, "@role")
+	text = mention.ReplaceAllString(text, "@user")
+	text = scrub(text)
+	text = email.ReplaceAllString(text, removedEmail)
+	return number.ReplaceAllString(text, removedNumber)
+}
+
+// defuseMassMentions breaks @everyone and @here with a zero-width space, so
+// they read the same but ping no one
+func defuseMassMentions(text string) string {
+	return massMention.ReplaceAllString(text, "@\u200b$1")
+}
+
+func scrub(text string) string {
+	text = invite.ReplaceAllString(text, removedInvite)
+	return tokens.ReplaceAllString(text, removedToken)
+}
@@ -0,0 +1,45 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// KeySource describes where the instance encryption key comes from. Exactly one
+// of Key, File or KMSCiphertext is expected; the first one set wins.
+type KeySource struct {
+	Key  string // The key itself, base64 or hex
+	File string // Path to a file holding the key, e.g. a mounted secret
+
+	// KMSCiphertext is a data key encrypted with AWS KMS (base64). It is
+	// decrypted once at startup, so the plaintext key never sits in config.
+	KMSCiphertext string
+	KMS           KMSConfig
+}
+
+// LoadKey resolves a key source into a 32-byte key
+func LoadKey(ctx context.Context, src KeySource) ([]byte, error) {
+	switch {
+	case src.Key != "":
+		return ParseKey(src.Key)
+	case src.File != "":
+		data, err := os.ReadFile(src.File)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read key file: %w", err)
+		}
+		return ParseKey(strings.TrimSpace(string(data)))
+	case src.KMSCiphertext != "":
+		key, err := kmsDecrypt(ctx, src.KMS, src.KMSCiphertext)
+		if err != nil {
+			return nil, err
+		}
+		if len(key) != 32 {
+			return nil, fmt.Errorf("kms data key must be 32 bytes, got %d", len(key))
+		}
+		return key, nil
+	default:
+		return nil, fmt.Errorf("no encryption key configured")
+	}
+}
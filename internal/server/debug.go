@@ -0,0 +1,57 @@
+package server
+
+import (
+	"expvar"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"time"
+)
+
+// NewDebugServer serves net/http/pprof profiles under /debug/pprof/ and
+// expvar variables at /debug/vars. Every request needs the bearer token, and
+// addr should stay on a private interface such as 127.0.0.1:6060.
+func NewDebugServer(addr, token string) *Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /debug/pprof/", pprof.Index)
+	mux.HandleFunc("GET /debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("GET /debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("GET /debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("GET /debug/pprof/trace", pprof.Trace)
+	mux.Handle("GET /debug/vars", expvar.Handler())
+
+	return &Server{
+		mux: mux,
+		server: &http.Server{
+			Addr:              addr,
+			Handler:           RequireToken(token, mux.ServeHTTP),
+			ReadHeaderTimeout: 10 * time.Second,
+		},
+	}
+}
+
+// PublishDebugVar exposes a value computed on each request at /debug/vars;
+// names must be unique, as for expvar.Publish
+func PublishDebugVar(name string, value func() interface{}) {
+	expvar.Publish(name, expvar.Func(value))
+}
+
+// PublishRuntimeVars exposes the goroutine count and a summary of the heap,
+// alongside the full "memstats" expvar always serves
+func PublishRuntimeVars() {
+	PublishDebugVar("goroutines", func() interface{} {
+		return runtime.NumGoroutine()
+	})
+	PublishDebugVar("heap", func() interface{} {
+		var m runtime.MemStats
+		runtime.ReadMemStats(&m)
+		return map[string]uint64{
+			"alloc_bytes":    m.HeapAlloc,
+			"inuse_bytes":    m.HeapInuse,
+			"sys_bytes":      m.HeapSys,
+			"released_bytes": m.HeapReleased,
+			"objects":        m.HeapObjects,
+			"gc_cycles":      uint64(m.NumGC),
+		}
+	})
+}
@@ -0,0 +1,71 @@
+package server
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"time"
+)
+
+// errorMarker starts the log lines of errors throughout the bot
+const errorMarker = "❌"
+
+// LoggedError is an error line of the bot's log
+type LoggedError struct {
+	Time    time.Time `json:"time"`
+	Message string    `json:"message"`
+}
+
+// ErrorLog keeps the last error lines written to the log, for the admin
+// overview. Install it with log.SetOutput(io.MultiWriter(os.Stderr, errorLog)).
+type ErrorLog struct {
+	mu     sync.Mutex
+	recent []LoggedError
+	next   int
+	total  int64
+}
+
+// NewErrorLog keeps the last size error lines
+func NewErrorLog(size int) *ErrorLog {
+	if size <= 0 {
+		size = 50
+	}
+	return &ErrorLog{recent: make([]LoggedError, 0, size)}
+}
+
+// Write records the error lines of p; the log package writes one line per call
+func (l *ErrorLog) Write(p []byte) (int, error) {
+	if !bytes.Contains(p, []byte(errorMarker)) {
+		return len(p), nil
+	}
+	now := time.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, line := range strings.Split(strings.TrimRight(string(p), "\n"), "\n") {
+		i := strings.Index(line, errorMarker)
+		if i < 0 {
+			continue
+		}
+		l.total++
+		entry := LoggedError{Time: now, Message: strings.TrimSpace(line[i+len(errorMarker):])}
+		if len(l.recent) < cap(l.recent) {
+			l.recent = append(l.recent, entry)
+			continue
+		}
+		l.recent[l.next] = entry
+		l.next = (l.next + 1) % len(l.recent)
+	}
+	return len(p), nil
+}
+
+// Recent returns the kept error lines, newest first, and how many were
+// logged since the bot started
+func (l *ErrorLog) Recent() ([]LoggedError, int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	recent := make([]LoggedError, 0, len(l.recent))
+	for n := len(l.recent) - 1; n >= 0; n-- {
+		recent = append(recent, l.recent[(l.next+n)%len(l.recent)])
+	}
+	return recent, l.total
+}
@@ -0,0 +1,270 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"discord-tars/internal/interfaces"
+)
+
+const (
+	defaultMaxSteps   = 6
+	defaultTimeBudget = 2 * time.Minute
+	maxToolTime       = 30 * time.Second
+	maxObservation    = 2500 // Characters of each tool result kept in the transcript
+	decisionMaxTokens = 300
+	planMaxTokens     = 250
+)
+
+const planSystemPrompt = `You plan research for a Discord assistant. Break the user's request into at most 4 short, concrete steps using the tools available.
+Reply with JSON only: {"plan": ["step", "..."]}`
+
+const stepSystemPrompt = `You are the research loop of a Discord assistant. Work through the plan one tool call at a time.
+Reply with exactly one JSON object and nothing else, either
+{"thought": "why", "tool": "<tool name>", "input": {<arguments>}}
+or, once the notes are enough to answer (or nothing more can be learned),
+{"thought": "why", "final": true}
+Never invent tool results. Prefer the server search for anything about this server or its members.`
+
+// Config bounds how much work one request may do
+type Config struct {
+	MaxSteps   int           // Tool calls per request
+	TimeBudget time.Duration // Wall time for planning and tool calls; the final answer is written after it
+}
+
+// ProgressFunc receives a short description of each step as it starts
+type ProgressFunc func(step string)
+
+// Result is the outcome of an agent run
+type Result struct {
+	Answer    string
+	Steps     int
+	Exhausted bool // The step or time budget ran out before the model finished
+}
+
+// Service runs a plan → tool calls → synthesize loop for complex requests. It
+// speaks a JSON protocol over plain completions so it works with any provider.
+type Service struct {
+	aiService interfaces.AIService
+	cfg       Config
+	tools     []interfaces.Tool // Available to every run
+}
+
+func NewService(aiService interfaces.AIService, cfg Config, tools ...interfaces.Tool) *Service {
+	if cfg.MaxSteps <= 0 {
+		cfg.MaxSteps = defaultMaxSteps
+	}
+	if cfg.TimeBudget <= 0 {
+		cfg.TimeBudget = defaultTimeBudget
+	}
+	return &Service{aiService: aiService, cfg: cfg, tools: tools}
+}
+
+type decision struct {
+	Thought string          `json:"thought"`
+	Tool    string          `json:"tool"`
+	Input   json.RawMessage `json:"input"`
+	Final   bool            `json:"final"`
+}
+
+type observation struct {
+	tool   string
+	input  string
+	result string
+}
+
+// Run researches a request with the service's tools plus any request-specific
+// ones (e.g. a search scoped to the asking guild) and writes the final answer
+func (s *Service) Run(ctx context.Context, request, username string, extra []interfaces.Tool, progress ProgressFunc) (*Result, error) {
+	if progress == nil {
+		progress = func(string) {}
+	}
+
+	tools := make(map[string]interfaces.Tool)
+	var catalog strings.Builder
+	for _, tool := range append(append([]interfaces.Tool(nil), s.tools...), extra...) {
+		tools[tool.Name] = tool
+		params, _ := json.Marshal(tool.Parameters)
+		catalog.WriteString(fmt.Sprintf("- %s: %s Arguments schema: %s\n", tool.Name, tool.Description, params))
+	}
+
+	loopCtx, cancel := context.WithTimeout(ctx, s.cfg.TimeBudget)
+	defer cancel()
+
+	progress("🧭 Planning")
+	plan := s.plan(loopCtx, request, catalog.String())
+	if len(plan) > 0 {
+		progress("📋 " + strings.Join(plan, " → "))
+	}
+
+	result := &Result{}
+	var notes []observation
+	for {
+		if result.Steps >= s.cfg.MaxSteps || loopCtx.Err() != nil {
+			result.Exhausted = true
+			break
+		}
+
+		next, err := s.decide(loopCtx, request, catalog.String(), plan, notes)
+		if err != nil {
+			if loopCtx.Err() != nil {
+				result.Exhausted = true
+				break
+			}
+			log.Printf("⚠️ Agent step failed, answering with what was gathered: %v", err)
+			break
+		}
+		if next.Final || next.Tool == "" {
+			break
+		}
+
+		result.Steps++
+		input := string(next.Input)
+		if input == "" || input == "null" {
+			input = "{}"
+		}
+
+		tool, ok := tools[next.Tool]
+		if !ok {
+			notes = append(notes, observation{tool: next.Tool, input: input, result: "error: unknown tool"})
+			continue
+		}
+
+		progress(stepLabel(tool.Name, input))
+		toolCtx, toolCancel := context.WithTimeout(loopCtx, maxToolTime)
+		output, err := tool.Handler(toolCtx, input)
+		toolCancel()
+		if err != nil {
+			log.Printf("⚠️ Agent tool %s failed: %v", tool.Name, err)
+			output = "error: " + err.Error()
+		}
+		output = truncate(output, maxObservation, " …(truncated)")
+		notes = append(notes, observation{tool: tool.Name, input: input, result: output})
+	}
+
+	progress("✍️ Writing the answer")
+	answer, err := s.aiService.GenerateResponse(ctx, synthesisPrompt(request, notes, result.Exhausted), username)
+	if err != nil {
+		return nil, err
+	}
+	result.Answer = answer
+	return result, nil
+}
+
+func (s *Service) plan(ctx context.Context, request, catalog string) []string {
+	reply, err := s.aiService.Complete(ctx, planSystemPrompt, fmt.Sprintf("TOOLS:\n%s\nREQUEST:\n%s", catalog, request), planMaxTokens)
+	if err != nil {
+		log.Printf("⚠️ Agent planning failed: %v", err)
+		return nil
+	}
+	var out struct {
+		Plan []string `json:"plan"`
+	}
+	if err := json.Unmarshal([]byte(extractJSON(reply)), &out); err != nil {
+		log.Printf("⚠️ Agent plan was not valid JSON: %v", err)
+		return nil
+	}
+	if len(out.Plan) > 4 {
+		out.Plan = out.Plan[:4]
+	}
+	return out.Plan
+}
+
+func (s *Service) decide(ctx context.Context, request, catalog string, plan []string, notes []observation) (*decision, error) {
+	var prompt strings.Builder
+	prompt.WriteString("TOOLS:\n" + catalog + "\nREQUEST:\n" + request + "\n")
+	if len(plan) > 0 {
+		prompt.WriteString("\nPLAN:\n")
+		for n, step := range plan {
+			prompt.WriteString(fmt.Sprintf("%d. %s\n", n+1, step))
+		}
+	}
+	prompt.WriteString("\nNOTES SO FAR:\n")
+	if len(notes) == 0 {
+		prompt.WriteString("(none yet)\n")
+	}
+	for _, note := range notes {
+		prompt.WriteString(fmt.Sprintf("- %s %s →\n%s\n", note.tool, note.input, note.result))
+	}
+
+	reply, err := s.aiService.Complete(ctx, stepSystemPrompt, prompt.String(), decisionMaxTokens)
+	if err != nil {
+		return nil, err
+	}
+	var next decision
+	if err := json.Unmarshal([]byte(extractJSON(reply)), &next); err != nil {
+		return nil, fmt.Errorf("agent decision was not valid JSON: %w", err)
+	}
+	return &next, nil
+}
+
+func synthesisPrompt(request string, notes []observation, exhausted bool) string {
+	var sb strings.Builder
+	sb.WriteString("Answer the request below using the research notes. Cite server channels, documents or URLs the notes came from. ")
+	sb.WriteString("If the notes don't settle something, say so plainly instead of guessing.\n")
+	if exhausted {
+		sb.WriteString("The research budget ran out, so the notes may be incomplete; mention that briefly if it matters.\n")
+	}
+	sb.WriteString("\nResearch notes:\n")
+	if len(notes) == 0 {
+		sb.WriteString("(no tools were used)\n")
+	}
+	for _, note := range notes {
+		sb.WriteString(fmt.Sprintf("- %s %s →\n%s\n", note.tool, note.input, note.result))
+	}
+	sb.WriteString("\nRequest: " + request)
+	return sb.String()
+}
+
+// stepLabel describes a tool call for progress updates
+func stepLabel(tool, input string) string {
+	var args map[string]interface{}
+	json.Unmarshal([]byte(input), &args)
+	arg := func(key string) string {
+		value, _ := args[key].(string)
+		return truncate(value, 80, "…")
+	}
+
+	switch tool {
+	case "search_server":
+		return fmt.Sprintf("🔎 Searching the server for “%s”", arg("query"))
+	case "web_search":
+		return fmt.Sprintf("🌐 Searching the web for “%s”", arg("query"))
+	case "web_fetch":
+		return fmt.Sprintf("📄 Reading <%s>", arg("url"))
+	case "calculator":
+		return fmt.Sprintf("🧮 Calculating `%s`", arg("expression"))
+	case "convert_units":
+		return fmt.Sprintf("📐 Converting %s to %s", arg("from"), arg("to"))
+	case "time_math":
+		return "🕐 Working out times and timezones"
+	default:
+		return "🛠️ Using " + tool
+	}
+}
+
+// extractJSON trims prose or code fences around the first JSON object in a reply
+func extractJSON(reply string) string {
+	start, end := strings.Index(reply, "{"), strings.LastIndex(reply, "}")
+	if start < 0 || end < start {
+		return reply
+	}
+	return reply[start : end+1]
+}
+
+// truncate cuts s to its first max bytes on a rune boundary and appends
+// suffix, if it is longer
+func truncate(s string, max int, suffix string) string {
+	if len(s) <= max {
+		return s
+	}
+	for max > 0 && !utf8.RuneStart(s[max]) {
+		max--
+	}
+	return s[:max] + suffix
+}
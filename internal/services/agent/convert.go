@@ -0,0 +1,187 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"discord-tars/internal/interfaces"
+)
+
+// unit converts to its dimension's base unit as value*factor + offset;
+// only temperatures have an offset
+type unit struct {
+	dimension string
+	factor    float64
+	offset    float64
+}
+
+// units by lowercase name and common abbreviations. Base units are the metre,
+// square metre, litre, kilogram, metre per second, second, byte, kelvin, joule
+// and pascal.
+var units = map[string]unit{}
+
+func init() {
+	add := func(dimension string, factor, offset float64, names ...string) {
+		for _, name := range names {
+			units[name] = unit{dimension: dimension, factor: factor, offset: offset}
+		}
+	}
+
+	add("length", 1e-9, 0, "nm", "nanometer", "nanometers", "nanometre", "nanometres")
+	add("length", 1e-6, 0, "µm", "um", "micrometer", "micrometers", "micron", "microns")
+	add("length", 1e-3, 0, "mm", "millimeter", "millimeters", "millimetre", "millimetres")
+	add("length", 1e-2, 0, "cm", "centimeter", "centimeters", "centimetre", "centimetres")
+	add("length", 1, 0, "m", "meter", "meters", "metre", "metres")
+	add("length", 1e3, 0, "km", "kilometer", "kilometers", "kilometre", "kilometres")
+	add("length", 0.0254, 0, "in", "inch", "inches", "\"")
+	add("length", 0.3048, 0, "ft", "foot", "feet", "'")
+	add("length", 0.9144, 0, "yd", "yard", "yards")
+	add("length", 1609.344, 0, "mi", "mile", "miles")
+	add("length", 1852, 0, "nmi", "nautical mile", "nautical miles")
+	add("length", 9.4607304725808e15, 0, "ly", "light year", "light years", "lightyear", "lightyears")
+	add("length", 1.495978707e11, 0, "au", "astronomical unit", "astronomical units")
+
+	add("area", 1e-4, 0, "cm2", "cm²", "square centimeter", "square centimeters")
+	add("area", 1, 0, "m2", "m²", "square meter", "square meters", "square metre", "square metres")
+	add("area", 1e6, 0, "km2", "km²", "square kilometer", "square kilometers")
+	add("area", 0.09290304, 0, "ft2", "ft²", "sq ft", "square foot", "square feet")
+	add("area", 2.58998811e6, 0, "mi2", "mi²", "sq mi", "square mile", "square miles")
+	add("area", 1e4, 0, "ha", "hectare", "hectares")
+	add("area", 4046.8564224, 0, "acre", "acres")
+
+	add("volume", 1e-3, 0, "ml", "milliliter", "milliliters", "millilitre", "millilitres")
+	add("volume", 1e-2, 0, "cl", "centiliter", "centiliters", "centilitre", "centilitres")
+	add("volume", 1, 0, "l", "liter", "liters", "litre", "litres")
+	add("volume", 1e3, 0, "m3", "m³", "cubic meter", "cubic meters", "cubic metre", "cubic metres")
+	add("volume", 3.785411784, 0, "gal", "gallon", "gallons", "us gallon", "us gallons")
+	add("volume", 4.54609, 0, "imperial gallon", "imperial gallons")
+	add("volume", 0.946352946, 0, "qt", "quart", "quarts")
+	add("volume", 0.473176473, 0, "pt", "pint", "pints")
+	add("volume", 0.2365882365, 0, "cup", "cups")
+	add("volume", 0.0295735295625, 0, "fl oz", "floz", "fluid ounce", "fluid ounces")
+	add("volume", 0.01478676478125, 0, "tbsp", "tablespoon", "tablespoons")
+	add("volume", 0.00492892159375, 0, "tsp", "teaspoon", "teaspoons")
+
+	add("mass", 1e-6, 0, "mg", "milligram", "milligrams")
+	add("mass", 1e-3, 0, "g", "gram", "grams")
+	add("mass", 1, 0, "kg", "kilogram", "kilograms", "kilo", "kilos")
+	add("mass", 1e3, 0, "t", "tonne", "tonnes", "metric ton", "metric tons")
+	add("mass", 0.028349523125, 0, "oz", "ounce", "ounces")
+	add("mass", 0.45359237, 0, "lb", "lbs", "pound", "pounds")
+	add("mass", 6.35029318, 0, "st", "stone", "stones")
+
+	add("speed", 1, 0, "m/s", "mps", "meters per second", "metres per second")
+	add("speed", 1/3.6, 0, "km/h", "kmh", "kph", "kilometers per hour", "kilometres per hour")
+	add("speed", 0.44704, 0, "mph", "miles per hour")
+	add("speed", 1852.0/3600, 0, "kn", "kt", "knot", "knots")
+
+	add("duration", 1e-3, 0, "ms", "millisecond", "milliseconds")
+	add("duration", 1, 0, "s", "sec", "secs", "second", "seconds")
+	add("duration", 60, 0, "min", "mins", "minute", "minutes")
+	add("duration", 3600, 0, "h", "hr", "hrs", "hour", "hours")
+	add("duration", 86400, 0, "d", "day", "days")
+	add("duration", 7*86400, 0, "wk", "week", "weeks")
+	add("duration", 365.25*86400, 0, "yr", "year", "years")
+
+	add("data", 1.0/8, 0, "bit", "bits")
+	add("data", 1, 0, "b", "byte", "bytes")
+	add("data", 1e3, 0, "kb", "kilobyte", "kilobytes")
+	add("data", 1e6, 0, "mb", "megabyte", "megabytes")
+	add("data", 1e9, 0, "gb", "gigabyte", "gigabytes")
+	add("data", 1e12, 0, "tb", "terabyte", "terabytes")
+	add("data", 1024, 0, "kib", "kibibyte", "kibibytes")
+	add("data", 1024*1024, 0, "mib", "mebibyte", "mebibytes")
+	add("data", 1024*1024*1024, 0, "gib", "gibibyte", "gibibytes")
+	add("data", 1024*1024*1024*1024, 0, "tib", "tebibyte", "tebibytes")
+
+	add("temperature", 1, 0, "k", "kelvin", "kelvins")
+	add("temperature", 1, 273.15, "c", "°c", "celsius", "degree celsius", "degrees celsius")
+	add("temperature", 5.0/9, 459.67*5/9, "f", "°f", "fahrenheit", "degree fahrenheit", "degrees fahrenheit")
+
+	add("energy", 1, 0, "j", "joule", "joules")
+	add("energy", 1e3, 0, "kj", "kilojoule", "kilojoules")
+	add("energy", 4.184, 0, "cal", "calorie", "calories")
+	add("energy", 4184, 0, "kcal", "kilocalorie", "kilocalories")
+	add("energy", 3.6e6, 0, "kwh", "kilowatt hour", "kilowatt hours")
+
+	add("pressure", 1, 0, "pa", "pascal", "pascals")
+	add("pressure", 1e3, 0, "kpa", "kilopascal", "kilopascals")
+	add("pressure", 1e5, 0, "bar", "bars")
+	add("pressure", 101325, 0, "atm", "atmosphere", "atmospheres")
+	add("pressure", 6894.757293168, 0, "psi")
+}
+
+// ErrCurrency is returned for currencies, whose rates change too often for a
+// fixed table
+var ErrCurrency = errors.New("currency conversion is not supported")
+
+var currencies = map[string]bool{
+	"usd": true, "eur": true, "gbp": true, "jpy": true, "chf": true, "cad": true, "aud": true, "cny": true,
+	"$": true, "€": true, "£": true, "¥": true, "dollar": true, "dollars": true, "euro": true, "euros": true,
+}
+
+// lookupUnit finds a unit by name, ignoring case and a trailing period
+func lookupUnit(name string) (unit, error) {
+	key := strings.TrimSuffix(strings.ToLower(strings.Join(strings.Fields(name), " ")), ".")
+	if u, ok := units[key]; ok {
+		return u, nil
+	}
+	if currencies[key] {
+		return unit{}, ErrCurrency
+	}
+	return unit{}, fmt.Errorf("unknown unit %q", strings.TrimSpace(name))
+}
+
+// Convert converts a value between units of the same dimension, such as
+// kilometers to miles or Celsius to Fahrenheit
+func Convert(value float64, from, to string) (float64, error) {
+	source, err := lookupUnit(from)
+	if err != nil {
+		return 0, err
+	}
+	target, err := lookupUnit(to)
+	if err != nil {
+		return 0, err
+	}
+	if source.dimension != target.dimension {
+		return 0, fmt.Errorf("can't convert %s (%s) to %s (%s)", from, source.dimension, to, target.dimension)
+	}
+	base := value*source.factor + source.offset
+	return (base - target.offset) / target.factor, nil
+}
+
+// ConvertTool converts between units so the model doesn't rely on remembered
+// conversion factors
+func ConvertTool() interfaces.Tool {
+	return interfaces.Tool{
+		Name:        "convert_units",
+		Description: "Convert a value between units of length, area, volume, mass, speed, duration, data, temperature, energy or pressure, e.g. 5 km to mi or 72 F to C. Currencies are not supported.",
+		Parameters: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"value": map[string]interface{}{"type": "number", "description": "Value to convert"},
+				"from":  map[string]interface{}{"type": "string", "description": "Unit of the value, e.g. km, lb, F, GiB"},
+				"to":    map[string]interface{}{"type": "string", "description": "Unit to convert to"},
+			},
+			"required": []string{"value", "from", "to"},
+		},
+		Handler: func(ctx context.Context, arguments string) (string, error) {
+			var args struct {
+				Value float64 `json:"value"`
+				From  string  `json:"from"`
+				To    string  `json:"to"`
+			}
+			if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+				return "", fmt.Errorf("invalid arguments: %w", err)
+			}
+			result, err := Convert(args.Value, args.From, args.To)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("%s %s = %s %s", FormatNumber(args.Value), args.From, FormatNumber(result), args.To), nil
+		},
+	}
+}
@@ -0,0 +1,59 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"discord-tars/internal/injection"
+	"discord-tars/internal/interfaces"
+	"discord-tars/internal/sanitize"
+	"discord-tars/internal/services/rag"
+)
+
+const searchMaxResults = 6
+
+// SearchTool searches a guild's indexed messages, pinned/official posts and synced docs
+func SearchTool(ragService *rag.Service, guildID, channelID int64) interfaces.Tool {
+	return interfaces.Tool{
+		Name:        "search_server",
+		Description: "Search this Discord server's message history, pinned and official posts, and synced documentation.",
+		Parameters: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"query": map[string]interface{}{"type": "string", "description": "What to look for"},
+			},
+			"required": []string{"query"},
+		},
+		Handler: func(ctx context.Context, arguments string) (string, error) {
+			var args struct {
+				Query string `json:"query"`
+			}
+			if err := json.Unmarshal([]byte(arguments), &args); err != nil || strings.TrimSpace(args.Query) == "" {
+				return "", fmt.Errorf("a query is required")
+			}
+
+			rc, err := ragService.Retrieve(ctx, args.Query, guildID, channelID, searchMaxResults)
+			if err != nil {
+				return "", err
+			}
+			sources := rc.Sources()
+			if len(sources) == 0 {
+				return "Nothing found.", nil
+			}
+
+			var sb strings.Builder
+			for _, src := range sources {
+				sb.WriteString(fmt.Sprintf("[%s] %s", src.Kind, src.Label))
+				if src.URL != "" {
+					sb.WriteString(" (" + src.URL + ")")
+				}
+				// Anyone who could post in the server wrote these
+				text, _ := injection.Strip(sanitize.Context(src.Text))
+				sb.WriteString(": " + text + "\n")
+			}
+			return sb.String(), nil
+		},
+	}
+}
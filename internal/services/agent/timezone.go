@@ -0,0 +1,235 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"discord-tars/internal/interfaces"
+)
+
+// TimeLayout is how times are shown, e.g. "Tue 14 May 2024 18:30 CEST (UTC+02:00)"
+const TimeLayout = "Mon 2 Jan 2006 15:04 MST (UTC-07:00)"
+
+var (
+	// dateLayouts are the ways a date and time may be written
+	dateLayouts = []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02T15:04", "2006-01-02 15:04", "2006-01-02"}
+	// clockLayouts are the ways a time of day may be written
+	clockLayouts = []string{"15:04", "15:04:05", "3:04pm", "3:04 pm", "3pm", "3 pm"}
+
+	offsetPattern   = regexp.MustCompile(`^(?i)(?:utc|gmt)?\s*([+-])(\d{1,2})(?::?(\d{2}))?$`)
+	dayWeekPattern  = regexp.MustCompile(`(\d+(?:\.\d+)?)([dw])`)
+	durationPattern = regexp.MustCompile(`^-?(\d+(\.\d+)?(ns|us|µs|ms|s|m|h))+$`)
+)
+
+// zoneAbbreviations maps abbreviations people use to a zone observing them;
+// daylight and standard time share a zone, which picks the right one by date
+var zoneAbbreviations = map[string]string{
+	"pst": "America/Los_Angeles", "pdt": "America/Los_Angeles", "pt": "America/Los_Angeles",
+	"mst": "America/Denver", "mdt": "America/Denver", "mt": "America/Denver",
+	"cst": "America/Chicago", "cdt": "America/Chicago", "ct": "America/Chicago",
+	"est": "America/New_York", "edt": "America/New_York", "et": "America/New_York",
+	"bst": "Europe/London", "cet": "Europe/Paris", "cest": "Europe/Paris",
+	"eet": "Europe/Athens", "eest": "Europe/Athens", "ist": "Asia/Kolkata",
+	"jst": "Asia/Tokyo", "kst": "Asia/Seoul", "aest": "Australia/Sydney", "aedt": "Australia/Sydney",
+}
+
+// LoadZone finds a timezone by IANA name (Europe/Paris), common abbreviation
+// (PST, CET) or UTC offset (UTC+2, +05:30)
+func LoadZone(name string) (*time.Location, error) {
+	name = strings.TrimSpace(name)
+	switch lower := strings.ToLower(name); {
+	case lower == "utc" || lower == "gmt" || lower == "z":
+		return time.UTC, nil
+	case zoneAbbreviations[lower] != "":
+		return time.LoadLocation(zoneAbbreviations[lower])
+	}
+	if m := offsetPattern.FindStringSubmatch(name); m != nil {
+		hours, _ := strconv.Atoi(m[2])
+		minutes, _ := strconv.Atoi(m[3])
+		if hours > 14 || minutes > 59 {
+			return nil, fmt.Errorf("invalid UTC offset %q", name)
+		}
+		offset := hours*3600 + minutes*60
+		if m[1] == "-" {
+			offset = -offset
+		}
+		return time.FixedZone("UTC"+m[1]+fmt.Sprintf("%02d:%02d", hours, minutes), offset), nil
+	}
+	if name == "" || strings.EqualFold(name, "local") {
+		return nil, fmt.Errorf("a timezone is needed, such as Europe/Paris or UTC+2")
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown timezone %q, use a name like Europe/Paris or an offset like UTC+2", name)
+	}
+	return loc, nil
+}
+
+// ParseTime reads a date and time, a time of day on now's date, or a time
+// written in words such as "next friday 5pm" (see ParseWhen), in a timezone;
+// empty or "now" is now
+func ParseTime(value string, loc *time.Location, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" || strings.EqualFold(value, "now") {
+		return now.In(loc), nil
+	}
+	for _, layout := range dateLayouts {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t, nil
+		}
+	}
+	today := now.In(loc)
+	for _, layout := range clockLayouts {
+		if t, err := time.ParseInLocation(layout, strings.ToLower(value), loc); err == nil {
+			return time.Date(today.Year(), today.Month(), today.Day(), t.Hour(), t.Minute(), t.Second(), 0, loc), nil
+		}
+	}
+	if t, err := ParseWhen(value, loc, now); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("unrecognized time %q, use a form like 2024-05-14 18:30, 18:30 or tomorrow 9am", value)
+}
+
+// ConvertTime shows a time written in one timezone in another
+func ConvertTime(value, from, to string, now time.Time) (time.Time, error) {
+	source, err := LoadZone(from)
+	if err != nil {
+		return time.Time{}, err
+	}
+	target, err := LoadZone(to)
+	if err != nil {
+		return time.Time{}, err
+	}
+	t, err := ParseTime(value, source, now)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return t.In(target), nil
+}
+
+// ParseDuration reads a duration such as 90m, 1h30m, 3d or 2w
+func ParseDuration(value string) (time.Duration, error) {
+	value = strings.ReplaceAll(strings.ToLower(strings.TrimSpace(value)), " ", "")
+	value = dayWeekPattern.ReplaceAllStringFunc(value, func(m string) string {
+		parts := dayWeekPattern.FindStringSubmatch(m)
+		n, _ := strconv.ParseFloat(parts[1], 64)
+		hours := n * 24
+		if parts[2] == "w" {
+			hours *= 7
+		}
+		return strconv.FormatFloat(hours, 'f', -1, 64) + "h"
+	})
+	if !durationPattern.MatchString(value) {
+		return 0, fmt.Errorf("unrecognized duration, use a form like 90m, 1h30m, 3d or 2w")
+	}
+	return time.ParseDuration(value)
+}
+
+// FormatDuration writes a duration in days, hours and minutes, e.g. "3d 4h 5m"
+func FormatDuration(d time.Duration) string {
+	sign := ""
+	if d < 0 {
+		sign, d = "-", -d
+	}
+	if d < time.Minute {
+		return sign + d.Round(time.Second).String()
+	}
+	d = d.Round(time.Minute)
+	days, hours, minutes := int(d/(24*time.Hour)), int(d%(24*time.Hour)/time.Hour), int(d%time.Hour/time.Minute)
+	var parts []string
+	if days > 0 {
+		parts = append(parts, fmt.Sprintf("%dd", days))
+	}
+	if hours > 0 {
+		parts = append(parts, fmt.Sprintf("%dh", hours))
+	}
+	if minutes > 0 {
+		parts = append(parts, fmt.Sprintf("%dm", minutes))
+	}
+	return sign + strings.Join(parts, " ")
+}
+
+// TimeTool does date and timezone math so the model doesn't guess offsets or
+// count days in its head
+func TimeTool() interfaces.Tool {
+	return interfaces.Tool{
+		Name: "time_math",
+		Description: "Date and timezone math. operation now: the current time in zone. convert: time in zone shown in to_zone. " +
+			"add: time in zone plus duration (e.g. 90m, 3d, -2w). diff: the time from time until until, both in zone. " +
+			"Zones are IANA names (Europe/Paris), abbreviations (PST) or offsets (UTC+2); times look like 2024-05-14 18:30, 18:30, next friday 5pm, in 2 hours or now.",
+		Parameters: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"operation": map[string]interface{}{"type": "string", "enum": []string{"now", "convert", "add", "diff"}},
+				"time":      map[string]interface{}{"type": "string", "description": "Date and time, time of day, or now (default)"},
+				"zone":      map[string]interface{}{"type": "string", "description": "Timezone of time (default UTC)"},
+				"to_zone":   map[string]interface{}{"type": "string", "description": "Timezone to convert to"},
+				"duration":  map[string]interface{}{"type": "string", "description": "Duration to add"},
+				"until":     map[string]interface{}{"type": "string", "description": "End time for diff"},
+			},
+			"required": []string{"operation"},
+		},
+		Handler: func(ctx context.Context, arguments string) (string, error) {
+			var args struct {
+				Operation string `json:"operation"`
+				Time      string `json:"time"`
+				Zone      string `json:"zone"`
+				ToZone    string `json:"to_zone"`
+				Duration  string `json:"duration"`
+				Until     string `json:"until"`
+			}
+			if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+				return "", fmt.Errorf("invalid arguments: %w", err)
+			}
+			return timeMath(args.Operation, args.Time, args.Zone, args.ToZone, args.Duration, args.Until, time.Now())
+		},
+	}
+}
+
+func timeMath(operation, value, zone, toZone, duration, until string, now time.Time) (string, error) {
+	if zone == "" {
+		zone = "UTC"
+	}
+	loc, err := LoadZone(zone)
+	if err != nil {
+		return "", err
+	}
+	t, err := ParseTime(value, loc, now)
+	if err != nil {
+		return "", err
+	}
+
+	switch operation {
+	case "now":
+		return t.Format(TimeLayout), nil
+	case "convert":
+		converted, err := ConvertTime(value, zone, toZone, now)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s = %s", t.Format(TimeLayout), converted.Format(TimeLayout)), nil
+	case "add":
+		d, err := ParseDuration(duration)
+		if err != nil {
+			return "", err
+		}
+		// Whole days keep the time of day across daylight saving changes
+		if d%(24*time.Hour) == 0 {
+			return t.AddDate(0, 0, int(d/(24*time.Hour))).Format(TimeLayout), nil
+		}
+		return t.Add(d).Format(TimeLayout), nil
+	case "diff":
+		end, err := ParseTime(until, loc, now)
+		if err != nil {
+			return "", err
+		}
+		return FormatDuration(end.Sub(t)), nil
+	default:
+		return "", fmt.Errorf("unknown operation %q, use now, convert, add or diff", operation)
+	}
+}
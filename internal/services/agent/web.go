@@ -0,0 +1,202 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"syscall"
+	"time"
+
+	"discord-tars/internal/interfaces"
+)
+
+const (
+	braveSearchURL   = "https://api.search.brave.com/res/v1/web/search"
+	maxSearchResults = 5
+	maxPageBytes     = 2 << 20
+	maxPageText      = 4000
+)
+
+var (
+	dropElementsPattern = regexp.MustCompile(`(?is)<(script|style|noscript|svg|head)[^>]*>.*?</(script|style|noscript|svg|head)>`)
+	pageBlockPattern    = regexp.MustCompile(`(?i)</?(p|div|br|li|h[1-6]|tr|section|article)[^>]*>`)
+	pageTagPattern      = regexp.MustCompile(`<[^>]+>`)
+	pageSpacePattern    = regexp.MustCompile(`[ \t\r\f\v]+`)
+	pageBlankPattern    = regexp.MustCompile(`\n\s*\n+`)
+)
+
+// WebTools gives the agent read access to the public web: fetching pages always,
+// and searching when a Brave Search API key is configured
+func WebTools(searchAPIKey string) []interfaces.Tool {
+	client := &http.Client{
+		Timeout:   20 * time.Second,
+		Transport: &http.Transport{DialContext: publicOnlyDialer().DialContext},
+	}
+
+	tools := []interfaces.Tool{fetchTool(client)}
+	if searchAPIKey != "" {
+		tools = append(tools, searchTool(client, searchAPIKey))
+	}
+	return tools
+}
+
+func searchTool(client *http.Client, apiKey string) interfaces.Tool {
+	return interfaces.Tool{
+		Name:        "web_search",
+		Description: "Search the public web. Returns titles, URLs and snippets; use web_fetch to read a result.",
+		Parameters: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"query": map[string]interface{}{"type": "string", "description": "Search query"},
+			},
+			"required": []string{"query"},
+		},
+		Handler: func(ctx context.Context, arguments string) (string, error) {
+			var args struct {
+				Query string `json:"query"`
+			}
+			if err := json.Unmarshal([]byte(arguments), &args); err != nil || strings.TrimSpace(args.Query) == "" {
+				return "", fmt.Errorf("a query is required")
+			}
+
+			params := url.Values{"q": {args.Query}, "count": {fmt.Sprint(maxSearchResults)}}
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, braveSearchURL+"?"+params.Encode(), nil)
+			if err != nil {
+				return "", err
+			}
+			req.Header.Set("Accept", "application/json")
+			req.Header.Set("X-Subscription-Token", apiKey)
+
+			resp, err := client.Do(req)
+			if err != nil {
+				return "", fmt.Errorf("search request failed: %w", err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				return "", fmt.Errorf("search returned status %d", resp.StatusCode)
+			}
+
+			var out struct {
+				Web struct {
+					Results []struct {
+						Title       string `json:"title"`
+						URL         string `json:"url"`
+						Description string `json:"description"`
+					} `json:"results"`
+				} `json:"web"`
+			}
+			if err := json.NewDecoder(io.LimitReader(resp.Body, maxPageBytes)).Decode(&out); err != nil {
+				return "", fmt.Errorf("failed to decode search results: %w", err)
+			}
+			if len(out.Web.Results) == 0 {
+				return "No results.", nil
+			}
+
+			var sb strings.Builder
+			for n, r := range out.Web.Results {
+				if n == maxSearchResults {
+					break
+				}
+				sb.WriteString(fmt.Sprintf("%d. %s — %s\n%s\n\n", n+1, r.Title, r.URL, stripTags(r.Description)))
+			}
+			return sb.String(), nil
+		},
+	}
+}
+
+func fetchTool(client *http.Client) interfaces.Tool {
+	return interfaces.Tool{
+		Name:        "web_fetch",
+		Description: "Fetch a public web page and return its text (truncated).",
+		Parameters: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"url": map[string]interface{}{"type": "string", "description": "http(s) URL"},
+			},
+			"required": []string{"url"},
+		},
+		Handler: func(ctx context.Context, arguments string) (string, error) {
+			var args struct {
+				URL string `json:"url"`
+			}
+			if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+				return "", fmt.Errorf("invalid arguments: %w", err)
+			}
+			parsed, err := url.Parse(strings.TrimSpace(args.URL))
+			if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+				return "", fmt.Errorf("only http and https URLs can be fetched")
+			}
+
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, parsed.String(), nil)
+			if err != nil {
+				return "", err
+			}
+			req.Header.Set("User-Agent", "TARS-Discord-Bot/1.0")
+			req.Header.Set("Accept", "text/html, text/plain;q=0.9")
+
+			resp, err := client.Do(req)
+			if err != nil {
+				return "", fmt.Errorf("fetch failed: %w", err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				return "", fmt.Errorf("page returned status %d", resp.StatusCode)
+			}
+
+			contentType := resp.Header.Get("Content-Type")
+			if !strings.HasPrefix(contentType, "text/") && !strings.Contains(contentType, "html") {
+				return "", fmt.Errorf("unsupported content type %q", contentType)
+			}
+			body, err := io.ReadAll(io.LimitReader(resp.Body, maxPageBytes))
+			if err != nil {
+				return "", fmt.Errorf("failed to read page: %w", err)
+			}
+
+			text := string(body)
+			if strings.Contains(contentType, "html") {
+				text = pageText(text)
+			}
+			return truncate(text, maxPageText, " …(truncated)"), nil
+		},
+	}
+}
+
+// pageText reduces an HTML page to readable text
+func pageText(markup string) string {
+	text := dropElementsPattern.ReplaceAllString(markup, " ")
+	text = pageBlockPattern.ReplaceAllString(text, "\n")
+	text = stripTags(text)
+	text = pageSpacePattern.ReplaceAllString(text, " ")
+	return strings.TrimSpace(pageBlankPattern.ReplaceAllString(text, "\n\n"))
+}
+
+func stripTags(s string) string {
+	return html.UnescapeString(pageTagPattern.ReplaceAllString(s, ""))
+}
+
+// publicOnlyDialer refuses connections to loopback, private and link-local
+// addresses, so the model can't be steered into probing the host's network
+func publicOnlyDialer() *net.Dialer {
+	return &net.Dialer{
+		Timeout: 10 * time.Second,
+		Control: func(network, address string, c syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() ||
+				ip.IsLinkLocalMulticast() || ip.IsUnspecified() || ip.IsMulticast() {
+				return fmt.Errorf("refusing to connect to non-public address %s", host)
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,238 @@
+package agent
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultHour is the time of day of a day given without one, e.g. "tomorrow"
+const defaultHour = 9
+
+var (
+	relativePattern = regexp.MustCompile(`^(?:in\s+)?((?:(?:\d+(?:\.\d+)?|\bhalf an?\b|\ban?\b)\s*[a-z]+(?:\s*(?:,|\band\b)\s*)?)+)(?:\s+from\s+now)?$`)
+	amountPattern   = regexp.MustCompile(`(\d+(?:\.\d+)?|\bhalf an?\b|\ban?\b)\s*([a-z]+)`)
+	clockPattern    = regexp.MustCompile(`(?:^|\s)(?:at\s+)?(?:(\d{1,2})(?::(\d{2}))?\s*(am|pm)|(\d{1,2}):(\d{2})|(noon|midnight|morning|afternoon|evening|tonight))(?:\s|$)`)
+	datePattern     = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2})$`)
+	monthDayPattern = regexp.MustCompile(`^(?:([a-z]+)\s+(\d{1,2})(?:st|nd|rd|th)?|(\d{1,2})(?:st|nd|rd|th)?\s+(?:of\s+)?([a-z]+))(?:\s*,?\s*(\d{4}))?$`)
+)
+
+// timeUnits are the units a relative time may be counted in
+var timeUnits = map[string]time.Duration{
+	"s": time.Second, "sec": time.Second, "secs": time.Second, "second": time.Second, "seconds": time.Second,
+	"m": time.Minute, "min": time.Minute, "mins": time.Minute, "minute": time.Minute, "minutes": time.Minute,
+	"h": time.Hour, "hr": time.Hour, "hrs": time.Hour, "hour": time.Hour, "hours": time.Hour,
+	"d": 24 * time.Hour, "day": 24 * time.Hour, "days": 24 * time.Hour,
+	"w": 7 * 24 * time.Hour, "week": 7 * 24 * time.Hour, "weeks": 7 * 24 * time.Hour,
+}
+
+// dayParts are the times of day that can be named
+var dayParts = map[string]int{"noon": 12, "midnight": 0, "morning": 9, "afternoon": 15, "evening": 18, "tonight": 20}
+
+var weekdays = map[string]time.Weekday{
+	"sunday": time.Sunday, "sun": time.Sunday,
+	"monday": time.Monday, "mon": time.Monday,
+	"tuesday": time.Tuesday, "tue": time.Tuesday, "tues": time.Tuesday,
+	"wednesday": time.Wednesday, "wed": time.Wednesday,
+	"thursday": time.Thursday, "thu": time.Thursday, "thurs": time.Thursday,
+	"friday": time.Friday, "fri": time.Friday,
+	"saturday": time.Saturday, "sat": time.Saturday,
+}
+
+var months = map[string]time.Month{
+	"january": time.January, "jan": time.January, "february": time.February, "feb": time.February,
+	"march": time.March, "mar": time.March, "april": time.April, "apr": time.April, "may": time.May,
+	"june": time.June, "jun": time.June, "july": time.July, "jul": time.July, "august": time.August, "aug": time.August,
+	"september": time.September, "sep": time.September, "sept": time.September, "october": time.October, "oct": time.October,
+	"november": time.November, "nov": time.November, "december": time.December, "dec": time.December,
+}
+
+// ParseWhen reads a time the way people write it: relative ("in 2 hours",
+// "in 1h30m", "3 days from now"), a day with an optional time of day
+// ("tomorrow", "next friday 5pm", "may 14 at 18:30", "monday noon") or a
+// time of day alone ("5pm", the next time it comes). Days and times are in
+// loc; a day without a time of day means 9am.
+func ParseWhen(value string, loc *time.Location, now time.Time) (time.Time, error) {
+	text := strings.Join(strings.Fields(strings.ToLower(strings.Trim(value, " \t.!"))), " ")
+	now = now.In(loc)
+	if text == "" || text == "now" {
+		return now, nil
+	}
+	// A date alone is read below, to get the default time of day
+	if !datePattern.MatchString(text) {
+		for _, layout := range dateLayouts {
+			if t, err := time.ParseInLocation(layout, strings.ToUpper(text), loc); err == nil {
+				return t, nil
+			}
+		}
+	}
+	if t, ok := parseRelative(text, now); ok {
+		return t, nil
+	}
+
+	// The time of day, if any, is taken out; what's left names the day
+	hour, minute, hasClock := defaultHour, 0, false
+	if m := clockPattern.FindStringSubmatchIndex(text); m != nil {
+		groups := clockPattern.FindStringSubmatch(text)
+		var err error
+		if hour, minute, err = clockTime(groups); err != nil {
+			return time.Time{}, err
+		}
+		hasClock = true
+		text = strings.TrimSpace(text[:m[0]] + " " + text[m[1]:])
+	}
+
+	day, weekly, ok := parseDay(strings.TrimSpace(strings.TrimPrefix(text, "on ")), now)
+	if !ok {
+		return time.Time{}, fmt.Errorf("unrecognized time %q, use a form like \"in 2 hours\", \"tomorrow 9am\" or \"next friday 17:30\"", value)
+	}
+	if day.IsZero() {
+		// A time of day alone is the next time it comes
+		if !hasClock {
+			return time.Time{}, fmt.Errorf("unrecognized time %q", value)
+		}
+		t := time.Date(now.Year(), now.Month(), now.Day(), hour, minute, 0, 0, loc)
+		if !t.After(now) {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, nil
+	}
+	t := time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, loc)
+	if weekly && !t.After(now) {
+		t = t.AddDate(0, 0, 7)
+	}
+	return t, nil
+}
+
+// parseRelative reads a time counted from now, e.g. "in 2 hours and 30
+// minutes", "in half an hour" or "in 3 weeks"
+func parseRelative(text string, now time.Time) (time.Time, bool) {
+	if d, err := ParseDuration(strings.TrimPrefix(text, "in ")); err == nil {
+		return now.Add(d), true
+	}
+	m := relativePattern.FindStringSubmatch(text)
+	if m == nil {
+		return time.Time{}, false
+	}
+	t := now
+	for _, part := range amountPattern.FindAllStringSubmatch(m[1], -1) {
+		var n float64
+		switch {
+		case strings.HasPrefix(part[1], "half"):
+			n = 0.5
+		case part[1] == "a" || part[1] == "an":
+			n = 1
+		default:
+			n, _ = strconv.ParseFloat(part[1], 64)
+		}
+		unit := part[2]
+		switch {
+		// Months and years keep the day of month rather than counting days
+		case unit == "month" || unit == "months" || unit == "mo":
+			t = t.AddDate(0, int(n), 0)
+		case unit == "year" || unit == "years" || unit == "y":
+			t = t.AddDate(int(n), 0, 0)
+		case timeUnits[unit] >= 24*time.Hour && n == float64(int(n)):
+			t = t.AddDate(0, 0, int(n)*int(timeUnits[unit]/(24*time.Hour)))
+		case timeUnits[unit] > 0:
+			t = t.Add(time.Duration(n * float64(timeUnits[unit])))
+		default:
+			return time.Time{}, false
+		}
+	}
+	return t, true
+}
+
+// clockTime reads the hour and minute clockPattern matched
+func clockTime(groups []string) (int, int, error) {
+	if part := groups[6]; part != "" {
+		return dayParts[part], 0, nil
+	}
+	hourText, minuteText := groups[4], groups[5]
+	if groups[1] != "" {
+		hourText, minuteText = groups[1], groups[2]
+	}
+	hour, _ := strconv.Atoi(hourText)
+	minute, _ := strconv.Atoi(minuteText)
+	switch groups[3] {
+	case "am", "pm":
+		if hour < 1 || hour > 12 {
+			return 0, 0, fmt.Errorf("invalid hour %d%s", hour, groups[3])
+		}
+		hour %= 12
+		if groups[3] == "pm" {
+			hour += 12
+		}
+	}
+	if hour > 23 || minute > 59 {
+		return 0, 0, fmt.Errorf("invalid time of day %s:%s", hourText, minuteText)
+	}
+	return hour, minute, nil
+}
+
+// parseDay reads the day a time is on, as midnight of that day in now's
+// location; an empty text is a zero time. weekly reports a weekday named
+// alone, which means next week's once today's time has passed.
+func parseDay(text string, now time.Time) (day time.Time, weekly bool, ok bool) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	switch text {
+	case "":
+		return time.Time{}, false, true
+	case "today":
+		return today, false, true
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), false, true
+	case "day after tomorrow", "the day after tomorrow":
+		return today.AddDate(0, 0, 2), false, true
+	case "next week":
+		return today.AddDate(0, 0, 7), false, true
+	}
+
+	// "friday" is the coming one, possibly today; "next friday" is never today
+	words := strings.Fields(text)
+	if len(words) <= 2 {
+		prefix, name := "", words[len(words)-1]
+		if len(words) == 2 {
+			prefix = words[0]
+		}
+		if weekday, ok := weekdays[name]; ok && (prefix == "" || prefix == "this" || prefix == "next") {
+			ahead := (int(weekday) - int(today.Weekday()) + 7) % 7
+			if ahead == 0 && prefix == "next" {
+				ahead = 7
+			}
+			return today.AddDate(0, 0, ahead), prefix == "", true
+		}
+	}
+
+	if m := datePattern.FindStringSubmatch(text); m != nil {
+		t, err := time.ParseInLocation("2006-01-02", m[1], now.Location())
+		return t, false, err == nil
+	}
+	if m := monthDayPattern.FindStringSubmatch(text); m != nil {
+		monthName, dayText := m[1], m[2]
+		if monthName == "" {
+			monthName, dayText = m[4], m[3]
+		}
+		month, ok := months[monthName]
+		day, _ := strconv.Atoi(dayText)
+		if !ok || day < 1 || day > 31 {
+			return time.Time{}, false, false
+		}
+		year := today.Year()
+		if m[5] != "" {
+			year, _ = strconv.Atoi(m[5])
+		}
+		t := time.Date(year, month, day, 0, 0, 0, 0, now.Location())
+		if t.Day() != day {
+			return time.Time{}, false, false // e.g. February 30
+		}
+		// A date without a year that has passed is next year's
+		if m[5] == "" && t.Before(today) {
+			t = t.AddDate(1, 0, 0)
+		}
+		return t, false, true
+	}
+	return time.Time{}, false, false
+}
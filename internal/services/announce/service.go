@@ -0,0 +1,313 @@
+// Package announce drafts server announcements in the tone of past ones and
+// posts them once a moderator approves.
+package announce
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+
+	"discord-tars/internal/interfaces"
+	"discord-tars/internal/models"
+	"discord-tars/internal/repository"
+	"discord-tars/internal/tenant"
+)
+
+const (
+	// MaxLength is Discord's message limit, which an announcement must fit
+	MaxLength = 2000
+
+	draftMaxTokens = 700
+	// Past announcements shown to the AI as tone examples
+	maxExamples       = 5
+	maxExampleChars   = 1200
+	announcementLabel = "announcements"
+)
+
+const draftSystemPrompt = `You write announcements for a Discord server on behalf of its moderators.
+You get the topic to announce and, when there are any, past announcements from the server.
+Match the past announcements' tone, language, length and formatting (headings, bullet points, emoji, sign-off).
+Write only the announcement, ready to post: no preamble and no commentary.
+Do not invent dates, times, links or facts that aren't in the topic; use a [placeholder] for a missing detail the announcement needs.
+Never use @everyone or @here. Stay under 1500 characters.`
+
+// mentionPattern matches user and role mentions, capturing "&" for roles
+var mentionPattern = regexp.MustCompile(`<@([!&]?)(\d+)>`)
+
+// maxListedMentions is how many users, and roles, Discord lets a message list
+const maxListedMentions = 100
+
+var (
+	ErrNoChannel  = errors.New("no announcement channel is configured")
+	ErrNotFound   = errors.New("announcement draft not found")
+	ErrNotPending = errors.New("this draft was already posted or rejected")
+	ErrTooLong    = fmt.Errorf("announcements must fit in %d characters", MaxLength)
+)
+
+type Service struct {
+	aiService    interfaces.AIService
+	repo         *repository.AnnouncementRepository
+	priorityRepo *repository.PriorityRepository
+	msgRepo      *repository.MessageRepository
+	session      *discordgo.Session
+}
+
+func NewService(aiService interfaces.AIService, repo *repository.AnnouncementRepository, priorityRepo *repository.PriorityRepository, msgRepo *repository.MessageRepository, session *discordgo.Session) *Service {
+	return &Service{
+		aiService:    aiService,
+		repo:         repo,
+		priorityRepo: priorityRepo,
+		msgRepo:      msgRepo,
+		session:      session,
+	}
+}
+
+// SetChannel sets where a guild's approved announcements are posted
+func (s *Service) SetChannel(ctx context.Context, guildID, channelID int64) error {
+	return s.repo.SaveConfig(ctx, &models.AnnouncementConfig{GuildID: guildID, ChannelID: channelID})
+}
+
+// Channel returns a guild's announcement channel, or 0 if none is configured
+func (s *Service) Channel(ctx context.Context, guildID int64) (int64, error) {
+	cfg, err := s.repo.GetConfig(ctx, guildID)
+	if err != nil || cfg == nil {
+		return 0, err
+	}
+	return cfg.ChannelID, nil
+}
+
+// Draft writes an announcement about a topic in the tone of the guild's past
+// announcements and stores it for review
+func (s *Service) Draft(ctx context.Context, guildID, requestedBy int64, topic string) (*models.AnnouncementDraft, error) {
+	channelID, err := s.Channel(ctx, guildID)
+	if err != nil {
+		return nil, err
+	}
+	if channelID == 0 {
+		return nil, ErrNoChannel
+	}
+
+	ctx = tenant.WithGuild(ctx, guildID)
+	examples, err := s.examples(ctx, guildID, channelID, topic)
+	if err != nil {
+		// Drafting without examples still works, just in a generic tone
+		log.Printf("⚠️ Failed to load past announcements: %v", err)
+	}
+
+	var prompt strings.Builder
+	if len(examples) > 0 {
+		prompt.WriteString("Past announcements from this server:\n")
+		for n, example := range examples {
+			fmt.Fprintf(&prompt, "--- Announcement %d ---\n%s\n", n+1, example)
+		}
+		prompt.WriteString("---\n\n")
+	} else {
+		prompt.WriteString("This server has no past announcements yet; use a friendly, clear tone.\n\n")
+	}
+	fmt.Fprintf(&prompt, "Topic to announce:\n%s", topic)
+
+	content, err := s.aiService.Complete(ctx, draftSystemPrompt, prompt.String(), draftMaxTokens)
+	if err != nil {
+		return nil, fmt.Errorf("failed to draft announcement: %w", err)
+	}
+	if content = fit(content); content == "" {
+		return nil, errors.New("the AI returned an empty announcement")
+	}
+
+	draft := &models.AnnouncementDraft{
+		GuildID:     guildID,
+		ChannelID:   channelID,
+		RequestedBy: requestedBy,
+		Topic:       topic,
+		Content:     content,
+		Status:      models.AnnouncementPending,
+	}
+	if err := s.repo.CreateDraft(ctx, draft); err != nil {
+		return nil, err
+	}
+	log.Printf("📣 Drafted announcement %d for guild %d from %d examples", draft.ID, guildID, len(examples))
+	return draft, nil
+}
+
+// examples returns the guild's past announcements closest to the topic, from
+// channels indexed as announcements and from the announcement channel itself,
+// falling back to the channel's latest messages when none match
+func (s *Service) examples(ctx context.Context, guildID, channelID int64, topic string) ([]string, error) {
+	embedding, err := s.aiService.GenerateEmbedding(ctx, topic)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate topic embedding: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	var examples []string
+	add := func(content string) {
+		content = strings.TrimSpace(content)
+		if content == "" || seen[content] || len(examples) >= maxExamples {
+			return
+		}
+		seen[content] = true
+		if utf8.RuneCountInString(content) > maxExampleChars {
+			content = string([]rune(content)[:maxExampleChars]) + "…"
+		}
+		examples = append(examples, content)
+	}
+
+	// Any announcement shows the tone, so no similarity threshold applies
+	docs, err := s.priorityRepo.SearchLabel(ctx, guildID, announcementLabel, embedding, maxExamples, -1)
+	if err != nil {
+		return nil, err
+	}
+	for _, doc := range docs {
+		add(doc.Document.Content)
+	}
+
+	messages, err := s.msgRepo.SearchSimilarMessagesInChannels(ctx, guildID, embedding, []int64{channelID}, nil, maxExamples, -1)
+	if err != nil {
+		return examples, err
+	}
+	for _, m := range messages {
+		add(m.Message.Content)
+	}
+
+	if len(examples) == 0 {
+		recent, err := s.msgRepo.GetRecentMessages(ctx, channelID, maxExamples)
+		if err != nil {
+			return examples, err
+		}
+		for _, m := range recent {
+			add(m.Message.Content)
+		}
+	}
+	return examples, nil
+}
+
+// Pending returns a guild's draft that is still waiting for review
+func (s *Service) Pending(ctx context.Context, guildID, draftID int64) (*models.AnnouncementDraft, error) {
+	draft, err := s.repo.GetDraft(ctx, draftID)
+	if err != nil {
+		return nil, err
+	}
+	if draft == nil || draft.GuildID != guildID {
+		return nil, ErrNotFound
+	}
+	if draft.Status != models.AnnouncementPending {
+		return nil, ErrNotPending
+	}
+	return draft, nil
+}
+
+// Revise replaces a pending draft's text with a moderator's edit
+func (s *Service) Revise(ctx context.Context, guildID, draftID int64, content string) (*models.AnnouncementDraft, error) {
+	content = strings.TrimSpace(content)
+	if utf8.RuneCountInString(content) > MaxLength {
+		return nil, ErrTooLong
+	}
+	draft, err := s.Pending(ctx, guildID, draftID)
+	if err != nil {
+		return nil, err
+	}
+	ok, err := s.repo.UpdatePendingContent(ctx, draftID, content)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrNotPending
+	}
+	draft.Content = content
+	return draft, nil
+}
+
+// Approve posts a pending draft to the announcement channel
+func (s *Service) Approve(ctx context.Context, guildID, draftID, reviewerID int64) (*models.AnnouncementDraft, error) {
+	draft, err := s.Pending(ctx, guildID, draftID)
+	if err != nil {
+		return nil, err
+	}
+	ok, err := s.repo.Review(ctx, draftID, models.AnnouncementPending, models.AnnouncementPosted, reviewerID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrNotPending
+	}
+
+	// Role and user mentions the moderator approved work; @everyone doesn't
+	msg, err := s.session.ChannelMessageSendComplex(strconv.FormatInt(draft.ChannelID, 10), &discordgo.MessageSend{
+		Content:         draft.Content,
+		AllowedMentions: approvedMentions(draft.Content),
+	})
+	if err != nil {
+		// Put the draft back up for review so it can be approved again
+		if _, revertErr := s.repo.Review(ctx, draftID, models.AnnouncementPosted, models.AnnouncementPending, 0); revertErr != nil {
+			log.Printf("⚠️ Failed to reopen announcement draft %d: %v", draftID, revertErr)
+		}
+		return nil, fmt.Errorf("failed to post announcement: %w", err)
+	}
+
+	draft.Status, draft.ReviewedBy = models.AnnouncementPosted, reviewerID
+	draft.MessageID, _ = strconv.ParseInt(msg.ID, 10, 64)
+	if err := s.repo.SetMessageID(ctx, draftID, draft.MessageID); err != nil {
+		log.Printf("⚠️ Failed to store message ID of announcement %d: %v", draftID, err)
+	}
+	log.Printf("📣 Posted announcement %d in channel %d", draftID, draft.ChannelID)
+	return draft, nil
+}
+
+// Reject discards a pending draft
+func (s *Service) Reject(ctx context.Context, guildID, draftID, reviewerID int64) error {
+	if _, err := s.Pending(ctx, guildID, draftID); err != nil {
+		return err
+	}
+	ok, err := s.repo.Review(ctx, draftID, models.AnnouncementPending, models.AnnouncementRejected, reviewerID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrNotPending
+	}
+	return nil
+}
+
+// fit trims a draft to MaxLength characters, at a line break when there is one
+func fit(content string) string {
+	content = strings.TrimSpace(content)
+	if utf8.RuneCountInString(content) <= MaxLength {
+		return content
+	}
+	content = string([]rune(content)[:MaxLength])
+	if cut := strings.LastIndex(content, "\n"); cut > 0 {
+		content = content[:cut]
+	}
+	return strings.TrimSpace(content)
+}
+
+// approvedMentions lists the users and roles mentioned in an announcement, so
+// they ping whatever the bot's mention policy allows elsewhere
+func approvedMentions(content string) *discordgo.MessageAllowedMentions {
+	mentions := &discordgo.MessageAllowedMentions{}
+	seen := make(map[string]bool)
+	for _, match := range mentionPattern.FindAllStringSubmatch(content, -1) {
+		role, id := match[1] == "&", match[2]
+		key := id
+		if role {
+			key = "&" + id
+		}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		if role && len(mentions.Roles) < maxListedMentions {
+			mentions.Roles = append(mentions.Roles, id)
+		} else if !role && len(mentions.Users) < maxListedMentions {
+			mentions.Users = append(mentions.Users, id)
+		}
+	}
+	return mentions
+}
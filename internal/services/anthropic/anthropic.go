@@ -0,0 +1,273 @@
+package anthropic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"discord-tars/internal/interfaces"
+	"discord-tars/internal/services/persona"
+)
+
+const (
+	apiURL       = "https://api.anthropic.com/v1/messages"
+	apiVersion   = "2023-06-01"
+	DefaultModel = "claude-3-5-haiku-latest"
+
+	// maxToolRounds bounds how many times the model may call tools for one answer
+	maxToolRounds = 3
+)
+
+// ErrEmbeddingsUnsupported is returned by GenerateEmbedding; Anthropic has no embeddings API
+var ErrEmbeddingsUnsupported = errors.New("anthropic does not provide embeddings")
+
+type Config struct {
+	APIKey string
+	Model  string
+	// HTTPClient sends the API requests, e.g. to record or replay them; nil
+	// uses a default client
+	HTTPClient *http.Client
+}
+
+// Service answers with Anthropic's Messages API using the T.A.R.S persona
+type Service struct {
+	httpClient   *http.Client
+	apiKey       string
+	model        string
+	humorLevel   int
+	honestyLevel int
+}
+
+// NewService creates a new Anthropic service instance
+func NewService(cfg Config) *Service {
+	model := cfg.Model
+	if model == "" {
+		model = DefaultModel
+	}
+
+	httpClient := cfg.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 60 * time.Second}
+	}
+
+	return &Service{
+		httpClient:   httpClient,
+		apiKey:       cfg.APIKey,
+		model:        model,
+		humorLevel:   75,
+		honestyLevel: 100,
+	}
+}
+
+type contentBlock struct {
+	Type      string          `json:"type"`
+	Text      string          `json:"text,omitempty"`
+	ID        string          `json:"id,omitempty"`
+	Name      string          `json:"name,omitempty"`
+	Input     json.RawMessage `json:"input,omitempty"`
+	ToolUseID string          `json:"tool_use_id,omitempty"`
+	Content   string          `json:"content,omitempty"`
+	IsError   bool            `json:"is_error,omitempty"`
+}
+
+type message struct {
+	Role    string         `json:"role"`
+	Content []contentBlock `json:"content"`
+}
+
+type toolDefinition struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	InputSchema map[string]interface{} `json:"input_schema"`
+}
+
+type messagesRequest struct {
+	Model       string           `json:"model"`
+	MaxTokens   int              `json:"max_tokens"`
+	System      string           `json:"system,omitempty"`
+	Messages    []message        `json:"messages"`
+	Temperature float32          `json:"temperature"`
+	Tools       []toolDefinition `json:"tools,omitempty"`
+}
+
+type messagesResponse struct {
+	Content    []contentBlock `json:"content"`
+	StopReason string         `json:"stop_reason"`
+}
+
+type apiError struct {
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func userText(text string) message {
+	return message{Role: "user", Content: []contentBlock{{Type: "text", Text: text}}}
+}
+
+func (s *Service) GenerateResponse(ctx context.Context, userMessage, username string) (string, error) {
+	return s.GenerateResponseWithTools(ctx, userMessage, username, nil)
+}
+
+// GenerateResponseWithTools answers like GenerateResponse but lets the model call the
+// given tools, feeding their results back until it produces a final answer
+func (s *Service) GenerateResponseWithTools(ctx context.Context, userMessage, username string, tools []interfaces.Tool) (string, error) {
+	handlers := make(map[string]interfaces.Tool, len(tools))
+	definitions := make([]toolDefinition, 0, len(tools))
+	for _, tool := range tools {
+		handlers[tool.Name] = tool
+		definitions = append(definitions, toolDefinition{
+			Name:        tool.Name,
+			Description: tool.Description,
+			InputSchema: tool.Parameters,
+		})
+	}
+
+	messages := []message{userText(fmt.Sprintf("User %s asks: %s", username, userMessage))}
+
+	for round := 0; ; round++ {
+		req := messagesRequest{
+			Model:       s.model,
+			MaxTokens:   persona.MaxTokensFor(ctx),
+			System:      persona.PromptFor(ctx, s.humorLevel, s.honestyLevel),
+			Messages:    messages,
+			Temperature: 0.7,
+		}
+		// Withhold tools on the last round so the model has to answer
+		if round < maxToolRounds {
+			req.Tools = definitions
+		}
+
+		resp, err := s.send(ctx, req)
+		if err != nil {
+			return "", err
+		}
+
+		var results []contentBlock
+		for _, block := range resp.Content {
+			if block.Type == "tool_use" {
+				results = append(results, s.runTool(ctx, handlers, block))
+			}
+		}
+		if len(results) == 0 {
+			return persona.EnhanceResponseFor(ctx, textOf(resp)), nil
+		}
+
+		messages = append(messages,
+			message{Role: "assistant", Content: resp.Content},
+			message{Role: "user", Content: results},
+		)
+	}
+}
+
+func (s *Service) runTool(ctx context.Context, handlers map[string]interfaces.Tool, call contentBlock) contentBlock {
+	result := contentBlock{Type: "tool_result", ToolUseID: call.ID}
+
+	tool, ok := handlers[call.Name]
+	if !ok {
+		result.Content, result.IsError = fmt.Sprintf("unknown tool %q", call.Name), true
+		return result
+	}
+
+	log.Printf("🛠️ Running tool %s", tool.Name)
+	output, err := tool.Handler(ctx, string(call.Input))
+	if err != nil {
+		log.Printf("⚠️ Tool %s failed: %v", tool.Name, err)
+		result.Content, result.IsError = err.Error(), true
+		return result
+	}
+	result.Content = output
+	return result
+}
+
+// Complete runs a plain completion with the given system and user prompts,
+// without the T.A.R.S persona
+func (s *Service) Complete(ctx context.Context, systemPrompt, userPrompt string, maxTokens int) (string, error) {
+	resp, err := s.send(ctx, messagesRequest{
+		Model:       s.model,
+		MaxTokens:   maxTokens,
+		System:      systemPrompt,
+		Messages:    []message{userText(userPrompt)},
+		Temperature: 0.3,
+	})
+	if err != nil {
+		return "", err
+	}
+	return textOf(resp), nil
+}
+
+func (s *Service) GenerateEmbedding(ctx context.Context, text string) ([]float32, error) {
+	return nil, ErrEmbeddingsUnsupported
+}
+
+func (s *Service) SetPersonality(humor, honesty int) {
+	if humor >= 0 && humor <= 100 {
+		s.humorLevel = humor
+	}
+	if honesty >= 0 && honesty <= 100 {
+		s.honestyLevel = honesty
+	}
+}
+
+func (s *Service) send(ctx context.Context, payload messagesRequest) (*messagesResponse, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode anthropic request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, apiURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("x-api-key", s.apiKey)
+	req.Header.Set("anthropic-version", apiVersion)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("anthropic api error: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read anthropic response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		var apiErr apiError
+		if json.Unmarshal(data, &apiErr) == nil && apiErr.Error.Message != "" {
+			return nil, fmt.Errorf("anthropic api error: %s (%d): %s", apiErr.Error.Type, resp.StatusCode, apiErr.Error.Message)
+		}
+		return nil, fmt.Errorf("anthropic api error: status %d", resp.StatusCode)
+	}
+
+	var out messagesResponse
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, fmt.Errorf("failed to decode anthropic response: %w", err)
+	}
+	if len(out.Content) == 0 {
+		return nil, fmt.Errorf("no response from anthropic")
+	}
+	if out.StopReason == "max_tokens" {
+		persona.ReportTruncated(ctx)
+	}
+	return &out, nil
+}
+
+func textOf(resp *messagesResponse) string {
+	var parts []string
+	for _, block := range resp.Content {
+		if block.Type == "text" {
+			parts = append(parts, block.Text)
+		}
+	}
+	return strings.TrimSpace(strings.Join(parts, "\n"))
+}
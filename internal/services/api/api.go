@@ -0,0 +1,223 @@
+// Package api serves the HTTP API integrators ask the bot questions and
+// search servers' history with. It is described by openapi.json, served at
+// /api/openapi.json, which pkg/client is generated from.
+package api
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/repository/postgres"
+	"discord-tars/internal/server"
+	"discord-tars/internal/services/apikeys"
+	ragService "discord-tars/internal/services/rag"
+)
+
+//go:embed openapi.json
+var spec []byte
+
+const (
+	maxQuestionLength  = 2000
+	defaultSearchLimit = 10
+	maxSearchLimit     = 50
+	askTimeout         = 60 * time.Second
+	searchTimeout      = 15 * time.Second
+)
+
+// Sources are what answers the API's requests
+type Sources struct {
+	// Ask answers a question as in a guild's channel from what anyone in the
+	// guild may read; channelID may be empty, or must be such a channel, else
+	// the error is rag.ErrNotVisible
+	Ask func(ctx context.Context, guildID, channelID, question, username string) (string, error)
+	// Search finds up to limit of a guild's messages that anyone may read
+	Search func(ctx context.Context, guildID int64, query, order string, limit int) ([]ragService.SearchHit, error)
+}
+
+// Service serves the API
+type Service struct {
+	src  Sources
+	keys *apikeys.Service
+}
+
+func NewService(src Sources) *Service {
+	return &Service{src: src}
+}
+
+// AskRequest is the body of POST /api/ask
+type AskRequest struct {
+	GuildID   string `json:"guild_id"`
+	ChannelID string `json:"channel_id,omitempty"`
+	Question  string `json:"question"`
+	Username  string `json:"username,omitempty"`
+}
+
+// AskResponse is the answer to POST /api/ask
+type AskResponse struct {
+	Answer string `json:"answer"`
+}
+
+// SearchResponse is the answer to GET /api/search
+type SearchResponse struct {
+	Results []Message `json:"results"`
+}
+
+// Message is a message found by a search
+type Message struct {
+	ID         string    `json:"id"`
+	ChannelID  string    `json:"channel_id"`
+	Channel    string    `json:"channel,omitempty"`
+	Author     string    `json:"author"`
+	Content    string    `json:"content"`
+	Timestamp  time.Time `json:"timestamp"`
+	Similarity float64   `json:"similarity"`
+	Reactions  int       `json:"reactions"`
+	URL        string    `json:"url"`
+}
+
+// SetKeyService lets API keys call the endpoints their scopes allow
+func (s *Service) SetKeyService(keys *apikeys.Service) {
+	s.keys = keys
+}
+
+// Register mounts the API on the HTTP server. Requests need an API key or
+// the static token, if set, as a bearer token; the OpenAPI document is public.
+func (s *Service) Register(srv *server.Server, token string) {
+	srv.HandleFunc("GET /api/openapi.json", s.HandleSpec)
+	srv.HandleFunc("POST /api/ask", s.require(models.ScopeAsk, token, s.HandleAsk))
+	srv.HandleFunc("GET /api/search", s.require(models.ScopeSearch, token, s.HandleSearch))
+}
+
+func (s *Service) require(scope, token string, next http.HandlerFunc) http.HandlerFunc {
+	if s.keys != nil {
+		return s.keys.Require(scope, token, next)
+	}
+	return server.RequireToken(token, next)
+}
+
+// HandleSpec serves the OpenAPI document describing the API
+func (s *Service) HandleSpec(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(spec)
+}
+
+// HandleAsk answers POST /api/ask
+func (s *Service) HandleAsk(w http.ResponseWriter, r *http.Request) {
+	var req AskRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64*1024)).Decode(&req); err != nil {
+		server.WriteError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	req.Question = strings.TrimSpace(req.Question)
+	switch {
+	case !validID(req.GuildID):
+		server.WriteError(w, http.StatusBadRequest, "guild_id must be a Discord ID")
+		return
+	case req.ChannelID != "" && !validID(req.ChannelID):
+		server.WriteError(w, http.StatusBadRequest, "channel_id must be a Discord ID")
+		return
+	case req.Question == "":
+		server.WriteError(w, http.StatusBadRequest, "question is required")
+		return
+	case len([]rune(req.Question)) > maxQuestionLength:
+		server.WriteError(w, http.StatusBadRequest, fmt.Sprintf("question is longer than %d characters", maxQuestionLength))
+		return
+	}
+	if req.Username == "" {
+		req.Username = "API"
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), askTimeout)
+	defer cancel()
+	answer, err := s.src.Ask(ctx, req.GuildID, req.ChannelID, req.Question, req.Username)
+	if errors.Is(err, ragService.ErrNotVisible) {
+		server.WriteError(w, http.StatusBadRequest, "channel_id must be a channel of the guild everyone in it can read")
+		return
+	}
+	if errors.Is(err, postgres.ErrQueryTimeout) {
+		server.WriteError(w, http.StatusGatewayTimeout, "searching the server's history took too long")
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Failed to answer an API question: %v", err)
+		server.WriteError(w, http.StatusBadGateway, "failed to answer the question")
+		return
+	}
+	server.WriteJSON(w, http.StatusOK, AskResponse{Answer: answer})
+}
+
+// HandleSearch answers GET /api/search
+func (s *Service) HandleSearch(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	guildID, err := strconv.ParseInt(query.Get("guild_id"), 10, 64)
+	if err != nil || guildID <= 0 {
+		server.WriteError(w, http.StatusBadRequest, "guild_id must be a Discord ID")
+		return
+	}
+	q := strings.TrimSpace(query.Get("q"))
+	if q == "" {
+		server.WriteError(w, http.StatusBadRequest, "q is required")
+		return
+	}
+	order := query.Get("order")
+	switch order {
+	case "":
+		order = ragService.SortRelevance
+	case ragService.SortRelevance, ragService.SortEndorsed:
+	default:
+		server.WriteError(w, http.StatusBadRequest, "order must be relevance or endorsed")
+		return
+	}
+	limit := defaultSearchLimit
+	if raw := query.Get("limit"); raw != "" {
+		limit, err = strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > maxSearchLimit {
+			server.WriteError(w, http.StatusBadRequest, fmt.Sprintf("limit must be from 1 to %d", maxSearchLimit))
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), searchTimeout)
+	defer cancel()
+	hits, err := s.src.Search(ctx, guildID, q, order, limit)
+	if errors.Is(err, postgres.ErrQueryTimeout) {
+		server.WriteError(w, http.StatusGatewayTimeout, "searching the server's history took too long")
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Failed to search for an API request: %v", err)
+		server.WriteError(w, http.StatusBadGateway, "failed to search")
+		return
+	}
+	results := make([]Message, 0, len(hits))
+	for _, hit := range hits {
+		m := hit.Message
+		results = append(results, Message{
+			ID:         strconv.FormatInt(m.ID, 10),
+			ChannelID:  strconv.FormatInt(m.ChannelID, 10),
+			Channel:    hit.Channel.Name,
+			Author:     hit.User.Username,
+			Content:    m.Content,
+			Timestamp:  m.Timestamp,
+			Similarity: float64(hit.Similarity),
+			Reactions:  hit.Reactions,
+			URL:        fmt.Sprintf("https://discord.com/channels/%d/%d/%d", m.GuildID, m.ChannelID, m.ID),
+		})
+	}
+	server.WriteJSON(w, http.StatusOK, SearchResponse{Results: results})
+}
+
+// validID reports whether s is a Discord ID
+func validID(s string) bool {
+	id, err := strconv.ParseInt(s, 10, 64)
+	return err == nil && id > 0
+}
@@ -0,0 +1,223 @@
+package apikeys
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/server"
+)
+
+const (
+	maxRequestBytes = 64 << 10
+	maxUsageDays    = 366
+	// recordTimeout bounds metering a request, which happens after answering
+	recordTimeout = 5 * time.Second
+)
+
+// createRequest is the body of a request creating a key
+type createRequest struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+}
+
+// createdKey answers requests creating or rotating a key, with the only copy
+// of its secret
+type createdKey struct {
+	Key    *models.APIKey `json:"key"`
+	Secret string         `json:"secret"`
+}
+
+// Require wraps a handler so it only answers requests carrying a key with the
+// scope as a bearer token, or the static token when one is set. Requests made
+// with keys are metered.
+func (s *Service) Require(scope, token string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || got == "" {
+			server.WriteError(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+		if token != "" && subtle.ConstantTimeCompare([]byte(got), []byte(token)) == 1 {
+			next(w, r)
+			return
+		}
+		key, err := s.Authenticate(r.Context(), got)
+		if err != nil {
+			log.Printf("❌ Failed to check an API key: %v", err)
+			server.WriteError(w, http.StatusServiceUnavailable, "failed to check the API key")
+			return
+		}
+		if key == nil {
+			server.WriteError(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+		if !key.Allows(scope) {
+			s.record(key.ID, scope, true)
+			server.WriteError(w, http.StatusForbidden, "this API key lacks the "+scope+" scope")
+			return
+		}
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next(recorder, r)
+		s.record(key.ID, scope, recorder.status >= http.StatusBadRequest)
+	}
+}
+
+// record meters a request in the background, so answering doesn't wait on it
+func (s *Service) record(keyID int64, scope string, failed bool) {
+	at := time.Now()
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), recordTimeout)
+		defer cancel()
+		if err := s.repo.RecordUsage(ctx, keyID, scope, failed, at); err != nil {
+			log.Printf("⚠️ %v", err)
+		}
+	}()
+}
+
+// statusRecorder remembers the status a handler answered with
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// HandleList lists the keys with their usage over the last UsageDays
+func (s *Service) HandleList(w http.ResponseWriter, r *http.Request) {
+	keys, err := s.List(r.Context())
+	if err != nil {
+		server.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	server.WriteJSON(w, http.StatusOK, map[string]interface{}{"keys": keys, "usage_days": UsageDays})
+}
+
+// HandleCreate creates a key and shows its secret, once
+func (s *Service) HandleCreate(w http.ResponseWriter, r *http.Request) {
+	var req createRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	key, secret, err := s.Create(r.Context(), req.Name, req.Scopes)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	server.WriteJSON(w, http.StatusCreated, createdKey{Key: key, Secret: secret})
+}
+
+// HandleRotate replaces a key, leaving the old one working for the grace
+// period given as a duration, e.g. {"grace": "1h"}, or DefaultGrace
+func (s *Service) HandleRotate(w http.ResponseWriter, r *http.Request) {
+	id, ok := keyID(w, r)
+	if !ok {
+		return
+	}
+	var req struct {
+		Grace string `json:"grace"`
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxRequestBytes))
+	if err != nil || (len(bytes.TrimSpace(body)) > 0 && json.Unmarshal(body, &req) != nil) {
+		server.WriteError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	grace := DefaultGrace
+	if req.Grace != "" {
+		if grace, err = time.ParseDuration(req.Grace); err != nil {
+			server.WriteError(w, http.StatusBadRequest, "grace must be a duration, e.g. 24h")
+			return
+		}
+	}
+	key, secret, err := s.Rotate(r.Context(), id, grace)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	server.WriteJSON(w, http.StatusCreated, createdKey{Key: key, Secret: secret})
+}
+
+// HandleRevoke stops a key from working
+func (s *Service) HandleRevoke(w http.ResponseWriter, r *http.Request) {
+	id, ok := keyID(w, r)
+	if !ok {
+		return
+	}
+	if err := s.Revoke(r.Context(), id); err != nil {
+		writeError(w, err)
+		return
+	}
+	server.WriteJSON(w, http.StatusOK, map[string]interface{}{"id": id, "revoked": true})
+}
+
+// HandleUsage shows a key's requests per day and scope, over the last
+// ?days= (UsageDays by default)
+func (s *Service) HandleUsage(w http.ResponseWriter, r *http.Request) {
+	id, ok := keyID(w, r)
+	if !ok {
+		return
+	}
+	days := UsageDays
+	if raw := r.URL.Query().Get("days"); raw != "" {
+		var err error
+		if days, err = strconv.Atoi(raw); err != nil || days < 1 || days > maxUsageDays {
+			server.WriteError(w, http.StatusBadRequest, fmt.Sprintf("days must be from 1 to %d", maxUsageDays))
+			return
+		}
+	}
+	usage, err := s.Usage(r.Context(), id, days)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	server.WriteJSON(w, http.StatusOK, map[string]interface{}{"id": id, "days": days, "usage": usage})
+}
+
+func keyID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		server.WriteError(w, http.StatusBadRequest, "invalid key id")
+		return 0, false
+	}
+	return id, true
+}
+
+func decode(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxRequestBytes))
+	if err == nil {
+		err = json.Unmarshal(body, v)
+	}
+	if err != nil {
+		server.WriteError(w, http.StatusBadRequest, "invalid JSON body")
+		return false
+	}
+	return true
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrInvalid):
+		server.WriteError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, ErrNotFound):
+		server.WriteError(w, http.StatusNotFound, err.Error())
+	default:
+		server.WriteError(w, http.StatusInternalServerError, err.Error())
+	}
+}
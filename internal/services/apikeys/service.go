@@ -0,0 +1,200 @@
+// Package apikeys manages the keys integrators call the HTTP API with. Each
+// key has scopes limiting what it may call, can be rotated, leaving the old
+// key working for a grace period, or revoked, and its requests are metered
+// per day and scope.
+package apikeys
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/repository"
+)
+
+const (
+	// keyPrefix starts every key, so leaked keys are easy to search for
+	keyPrefix = "tars_"
+	// secretBytes is the randomness in a key
+	secretBytes = 24
+	// shownPrefix is how much of a key is kept in clear to tell keys apart
+	shownPrefix = len(keyPrefix) + 8
+
+	// DefaultGrace is how long a rotated key keeps working
+	DefaultGrace = 24 * time.Hour
+	maxGrace     = 30 * 24 * time.Hour
+	// UsageDays is the period key listings sum usage over
+	UsageDays = 30
+)
+
+var (
+	ErrNotFound = errors.New("no such API key")
+	ErrInvalid  = errors.New("invalid API key request")
+)
+
+// Service creates, rotates, revokes and checks API keys
+type Service struct {
+	repo *repository.APIKeyRepository
+}
+
+func NewService(repo *repository.APIKeyRepository) *Service {
+	return &Service{repo: repo}
+}
+
+// KeyInfo is a key as listed, with its usage over the last UsageDays
+type KeyInfo struct {
+	models.APIKey
+	Active   bool  `json:"active"`
+	Requests int64 `json:"requests"`
+	Failures int64 `json:"failures"`
+}
+
+// Create makes a key with the given scopes. The returned secret is what
+// callers send as a bearer token; it is not stored and can't be shown again.
+func (s *Service) Create(ctx context.Context, name string, scopes []string) (*models.APIKey, string, error) {
+	key, secret, err := newKey(name, scopes)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := s.repo.Create(ctx, key); err != nil {
+		return nil, "", err
+	}
+	return key, secret, nil
+}
+
+// Rotate replaces a key with a new one with the same name and scopes. The old
+// key keeps working for the grace period, so callers can switch over.
+func (s *Service) Rotate(ctx context.Context, id int64, grace time.Duration) (*models.APIKey, string, error) {
+	if grace < 0 || grace > maxGrace {
+		return nil, "", fmt.Errorf("%w: the grace period must be between 0 and %s", ErrInvalid, maxGrace)
+	}
+	old, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return nil, "", err
+	}
+	if old == nil {
+		return nil, "", ErrNotFound
+	}
+	if !old.Active(time.Now()) || old.RotatedTo != nil {
+		return nil, "", fmt.Errorf("%w: key %d was revoked, expired or rotated already", ErrInvalid, id)
+	}
+	key, secret, err := newKey(old.Name, old.Scopes)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := s.repo.Rotate(ctx, id, key, time.Now().Add(grace)); err != nil {
+		return nil, "", err
+	}
+	return key, secret, nil
+}
+
+// Revoke stops a key from working at once
+func (s *Service) Revoke(ctx context.Context, id int64) error {
+	revoked, err := s.repo.Revoke(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return fmt.Errorf("%w, or it was revoked already", ErrNotFound)
+	}
+	return nil
+}
+
+// List returns every key, newest first, with its recent usage
+func (s *Service) List(ctx context.Context) ([]KeyInfo, error) {
+	keys, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	totals, err := s.repo.UsageTotals(ctx, time.Now().AddDate(0, 0, -UsageDays))
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	infos := make([]KeyInfo, len(keys))
+	for n, key := range keys {
+		usage := totals[key.ID]
+		infos[n] = KeyInfo{APIKey: key, Active: key.Active(now), Requests: usage.Requests, Failures: usage.Failures}
+	}
+	return infos, nil
+}
+
+// Usage returns a key's requests per day and scope over the last days
+func (s *Service) Usage(ctx context.Context, id int64, days int) ([]models.APIKeyUsage, error) {
+	key, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if key == nil {
+		return nil, ErrNotFound
+	}
+	return s.repo.Usage(ctx, id, time.Now().AddDate(0, 0, -days))
+}
+
+// Authenticate returns the active key a secret belongs to, or nil
+func (s *Service) Authenticate(ctx context.Context, secret string) (*models.APIKey, error) {
+	if !strings.HasPrefix(secret, keyPrefix) {
+		return nil, nil
+	}
+	key, err := s.repo.FindByHash(ctx, hashSecret(secret))
+	if err != nil || key == nil || !key.Active(time.Now()) {
+		return nil, err
+	}
+	return key, nil
+}
+
+// NormalizeScopes checks scopes and puts them in their usual order
+func NormalizeScopes(scopes []string) ([]string, error) {
+	if len(scopes) == 0 {
+		return nil, fmt.Errorf("%w: at least one scope is required (%s)", ErrInvalid, strings.Join(models.APIKeyScopes, ", "))
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(models.APIKeyScopes, scope) {
+			return nil, fmt.Errorf("%w: unknown scope %q (%s)", ErrInvalid, scope, strings.Join(models.APIKeyScopes, ", "))
+		}
+	}
+	var normalized []string
+	for _, scope := range models.APIKeyScopes {
+		if slices.Contains(scopes, scope) {
+			normalized = append(normalized, scope)
+		}
+	}
+	return normalized, nil
+}
+
+// newKey makes a key and its secret, without storing it
+func newKey(name string, scopes []string) (*models.APIKey, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, "", fmt.Errorf("%w: a name is required", ErrInvalid)
+	}
+	scopes, err := NormalizeScopes(scopes)
+	if err != nil {
+		return nil, "", err
+	}
+	b := make([]byte, secretBytes)
+	if _, err := rand.Read(b); err != nil {
+		return nil, "", fmt.Errorf("failed to generate API key: %w", err)
+	}
+	secret := keyPrefix + hex.EncodeToString(b)
+	return &models.APIKey{
+		Name:   name,
+		Prefix: secret[:shownPrefix],
+		Hash:   hashSecret(secret),
+		Scopes: scopes,
+	}, secret, nil
+}
+
+// hashSecret is how secrets are stored; they are random enough that a plain
+// hash can't be reversed
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
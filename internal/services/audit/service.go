@@ -0,0 +1,49 @@
+// Package audit keeps a log of the commands run in each guild, so admins can
+// review who did what, and purges it after the retention period.
+package audit
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/repository"
+)
+
+type Service struct {
+	repo      *repository.AuditRepository
+	retention time.Duration
+}
+
+// NewService creates the audit log; entries older than retention are pruned,
+// and a zero retention keeps them
+func NewService(repo *repository.AuditRepository, retention time.Duration) *Service {
+	return &Service{repo: repo, retention: retention}
+}
+
+// Record logs a command invocation. Failures are only logged: auditing must
+// not get in the way of the command itself.
+func (s *Service) Record(ctx context.Context, entry *models.CommandAudit) {
+	if err := s.repo.Add(ctx, entry); err != nil {
+		log.Printf("❌ %v", err)
+	}
+}
+
+// Recent returns a guild's latest command invocations, optionally of one
+// user (userID > 0) and one command
+func (s *Service) Recent(ctx context.Context, guildID, userID int64, command string, limit int) ([]models.CommandAudit, error) {
+	return s.repo.Recent(ctx, guildID, userID, command, limit)
+}
+
+// Prune is the scheduler job deleting entries past the retention
+func (s *Service) Prune(ctx context.Context) error {
+	if s.retention <= 0 {
+		return nil
+	}
+	pruned, err := s.repo.Prune(ctx, time.Now().Add(-s.retention))
+	if pruned > 0 {
+		log.Printf("🧹 Pruned %d audit log entries", pruned)
+	}
+	return err
+}
@@ -0,0 +1,67 @@
+// Package bookmarks keeps each member's private collection of saved messages,
+// searchable by meaning.
+package bookmarks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"discord-tars/internal/interfaces"
+	"discord-tars/internal/models"
+	"discord-tars/internal/repository"
+)
+
+const (
+	searchLimit         = 5
+	searchMinSimilarity = 0.2
+)
+
+// ErrAlreadySaved is returned when a member bookmarks a message twice
+var ErrAlreadySaved = errors.New("this message is already bookmarked")
+
+type Service struct {
+	aiService interfaces.AIService
+	repo      *repository.BookmarkRepository
+}
+
+func NewService(aiService interfaces.AIService, repo *repository.BookmarkRepository) *Service {
+	return &Service{aiService: aiService, repo: repo}
+}
+
+// Save adds a message to its member's collection
+func (s *Service) Save(ctx context.Context, bookmark *models.Bookmark) error {
+	exists, err := s.repo.Exists(ctx, bookmark.UserID, bookmark.MessageID)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return ErrAlreadySaved
+	}
+
+	// The author's name helps queries like "what Alice said about the release"
+	embedding, err := s.aiService.GenerateEmbedding(ctx, bookmark.AuthorName+": "+bookmark.Content)
+	if err != nil {
+		return fmt.Errorf("failed to generate bookmark embedding: %w", err)
+	}
+	created, err := s.repo.Create(ctx, bookmark, embedding)
+	if err != nil {
+		return err
+	}
+	if !created {
+		return ErrAlreadySaved
+	}
+
+	log.Printf("🔖 User %d bookmarked message %d", bookmark.UserID, bookmark.MessageID)
+	return nil
+}
+
+// Search returns a member's bookmarks closest in meaning to a query
+func (s *Service) Search(ctx context.Context, userID int64, query string) ([]models.BookmarkResult, error) {
+	embedding, err := s.aiService.GenerateEmbedding(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate query embedding: %w", err)
+	}
+	return s.repo.Search(ctx, userID, embedding, searchLimit, searchMinSimilarity)
+}
@@ -0,0 +1,161 @@
+package calendar
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"discord-tars/internal/models"
+)
+
+// Discord events without an end time, such as stage and voice events, are
+// assumed to last this long
+const defaultEventDuration = 2 * time.Hour
+
+var ErrAlreadyFollowing = errors.New("this server's Discord events are already followed")
+
+// FollowDiscordEvents attaches a guild's Discord scheduled events as a
+// calendar, then performs its first sync
+func (s *Service) FollowDiscordEvents(ctx context.Context, source *models.CalendarSource) (int, error) {
+	existing, err := s.discordSource(ctx, source.GuildID)
+	if err != nil {
+		return 0, err
+	}
+	if existing != nil {
+		return 0, ErrAlreadyFollowing
+	}
+
+	source.Kind = models.CalendarKindDiscord
+	source.URL = fmt.Sprintf("https://discord.com/events/%d", source.GuildID)
+	if source.Name == "" {
+		source.Name = "Discord events"
+	}
+	if source.ReminderMinutes <= 0 {
+		source.ReminderMinutes = DefaultReminderMinutes
+	}
+	if err := s.calendarRepo.AddSource(ctx, source); err != nil {
+		return 0, err
+	}
+
+	count, err := s.syncDiscord(ctx, source)
+	if markErr := s.calendarRepo.MarkSynced(ctx, source.ID, errorText(err), time.Now()); markErr != nil {
+		log.Printf("❌ Failed to mark calendar %d synced: %v", source.ID, markErr)
+	}
+	return count, err
+}
+
+// SyncDiscordEvents refreshes a guild's Discord events after one was
+// scheduled, edited, started or removed, when the guild follows them
+func (s *Service) SyncDiscordEvents(ctx context.Context, guildID int64) error {
+	source, err := s.discordSource(ctx, guildID)
+	if err != nil || source == nil {
+		return err
+	}
+	_, err = s.syncDiscord(ctx, source)
+	if markErr := s.calendarRepo.MarkSynced(ctx, source.ID, errorText(err), time.Now()); markErr != nil {
+		log.Printf("❌ Failed to mark calendar %d synced: %v", source.ID, markErr)
+	}
+	return err
+}
+
+// AnnounceEvent posts a newly scheduled Discord event in the reminder channel
+// of a guild that follows its events
+func (s *Service) AnnounceEvent(ctx context.Context, event *discordgo.GuildScheduledEvent) error {
+	guildID, _ := strconv.ParseInt(event.GuildID, 10, 64)
+	source, err := s.discordSource(ctx, guildID)
+	if err != nil || source == nil || source.ReminderChannelID == 0 {
+		return err
+	}
+
+	msg := &discordgo.MessageSend{Embeds: []*discordgo.MessageEmbed{announcementEmbed(event)}}
+	if err := s.send(ctx, "calendar-announcement", guildID, source.ReminderChannelID, msg); err != nil {
+		return fmt.Errorf("failed to announce event %s: %w", event.ID, err)
+	}
+	log.Printf("📣 Announced event %q", event.Name)
+	return nil
+}
+
+// discordSource returns the calendar following a guild's Discord events, or
+// nil if it doesn't
+func (s *Service) discordSource(ctx context.Context, guildID int64) (*models.CalendarSource, error) {
+	sources, err := s.calendarRepo.ListSources(ctx, guildID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range sources {
+		if sources[i].Kind == models.CalendarKindDiscord {
+			return &sources[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *Service) syncDiscord(ctx context.Context, source *models.CalendarSource) (int, error) {
+	scheduled, err := s.session.GuildScheduledEvents(strconv.FormatInt(source.GuildID, 10), false, discordgo.WithContext(ctx))
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch Discord events: %w", err)
+	}
+
+	events := make([]models.CalendarEvent, 0, len(scheduled))
+	for _, event := range scheduled {
+		if event.Status == discordgo.GuildScheduledEventStatusCompleted || event.Status == discordgo.GuildScheduledEventStatusCanceled {
+			continue
+		}
+		end := event.ScheduledStartTime.Add(defaultEventDuration)
+		if event.ScheduledEndTime != nil {
+			end = *event.ScheduledEndTime
+		}
+		events = append(events, models.CalendarEvent{
+			GuildID:     source.GuildID,
+			UID:         event.ID,
+			StartsAt:    event.ScheduledStartTime,
+			EndsAt:      end,
+			Summary:     event.Name,
+			Description: event.Description,
+			Location:    eventLocation(event),
+		})
+	}
+	// Discord lists every event that is scheduled or live, so any other has
+	// ended or was cancelled, however long ago it started
+	if err := s.calendarRepo.ReplaceEvents(ctx, source.ID, time.Time{}, events); err != nil {
+		return 0, err
+	}
+
+	log.Printf("📅 Synced %d Discord events of guild %d", len(events), source.GuildID)
+	return len(events), nil
+}
+
+// eventLocation is where a Discord event takes place: a stage or voice
+// channel, written as a mention, or an external location
+func eventLocation(event *discordgo.GuildScheduledEvent) string {
+	switch event.EntityType {
+	case discordgo.GuildScheduledEventEntityTypeExternal:
+		return event.EntityMetadata.Location
+	case discordgo.GuildScheduledEventEntityTypeStageInstance:
+		return fmt.Sprintf("Stage <#%s>", event.ChannelID)
+	default:
+		return fmt.Sprintf("<#%s>", event.ChannelID)
+	}
+}
+
+func announcementEmbed(event *discordgo.GuildScheduledEvent) *discordgo.MessageEmbed {
+	start := event.ScheduledStartTime.Unix()
+	embed := &discordgo.MessageEmbed{
+		Title:       truncate("📣 New event: "+event.Name, 256),
+		URL:         fmt.Sprintf("https://discord.com/events/%s/%s", event.GuildID, event.ID),
+		Description: fmt.Sprintf("<t:%d:F> (<t:%d:R>)", start, start),
+		Color:       0x5865f2,
+	}
+	if location := eventLocation(event); location != "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Where", Value: truncate(location, 1024)})
+	}
+	if event.Description != "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Details", Value: truncate(event.Description, 1024)})
+	}
+	return embed
+}
@@ -0,0 +1,455 @@
+package calendar
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Event is one occurrence of a VEVENT
+type Event struct {
+	UID         string
+	Summary     string
+	Description string
+	Location    string
+	Start       time.Time
+	End         time.Time
+	AllDay      bool
+}
+
+const (
+	// maxOccurrences bounds how many occurrences of one event are returned
+	maxOccurrences = 1000
+	// maxIterations bounds recurrence expansion, including occurrences before the window
+	maxIterations = 100000
+)
+
+type property struct {
+	params map[string]string
+	value  string
+}
+
+type vevent map[string][]property
+
+func (v vevent) get(name string) (property, bool) {
+	props := v[name]
+	if len(props) == 0 {
+		return property{}, false
+	}
+	return props[0], true
+}
+
+func (v vevent) text(name string) string {
+	p, _ := v.get(name)
+	return unescapeText(p.value)
+}
+
+// ParseICS parses an iCalendar document and returns the occurrences that overlap [from, to).
+// Recurring events are expanded for the common DAILY/WEEKLY/MONTHLY/YEARLY rules.
+func ParseICS(data []byte, from, to time.Time) ([]Event, error) {
+	lines := unfold(data)
+	if len(lines) == 0 || !strings.EqualFold(lines[0], "BEGIN:VCALENDAR") {
+		return nil, fmt.Errorf("not an iCalendar document")
+	}
+
+	defaultLoc := time.UTC
+	var events []vevent
+	var current vevent
+	depth := 0
+	for _, line := range lines {
+		name, prop, ok := parseLine(line)
+		if !ok {
+			continue
+		}
+		switch {
+		case name == "BEGIN" && strings.EqualFold(prop.value, "VEVENT"):
+			current = vevent{}
+			depth = 1
+		case name == "BEGIN" && current != nil:
+			depth++ // nested component such as VALARM
+		case name == "END" && current != nil:
+			depth--
+			if depth == 0 {
+				events = append(events, current)
+				current = nil
+			}
+		case current != nil && depth == 1:
+			current[name] = append(current[name], prop)
+		case name == "X-WR-TIMEZONE":
+			if loc, err := time.LoadLocation(prop.value); err == nil {
+				defaultLoc = loc
+			}
+		}
+	}
+
+	// RECURRENCE-ID instances replace the matching occurrence of their series
+	overridden := map[string]map[int64]bool{}
+	for _, ev := range events {
+		if p, ok := ev.get("RECURRENCE-ID"); ok {
+			if t, _, err := parseDateTime(p, defaultLoc); err == nil {
+				uid := ev.text("UID")
+				if overridden[uid] == nil {
+					overridden[uid] = map[int64]bool{}
+				}
+				overridden[uid][t.Unix()] = true
+			}
+		}
+	}
+
+	var out []Event
+	for _, ev := range events {
+		if strings.EqualFold(ev.text("STATUS"), "CANCELLED") {
+			continue
+		}
+		occurrences, err := expand(ev, defaultLoc, from, to, overridden[ev.text("UID")])
+		if err != nil {
+			continue // Skip malformed events rather than failing the whole calendar
+		}
+		out = append(out, occurrences...)
+	}
+
+	sort.Slice(out, func(i, j int) bool { return out[i].Start.Before(out[j].Start) })
+	return out, nil
+}
+
+func expand(ev vevent, defaultLoc *time.Location, from, to time.Time, overridden map[int64]bool) ([]Event, error) {
+	startProp, ok := ev.get("DTSTART")
+	if !ok {
+		return nil, fmt.Errorf("event has no DTSTART")
+	}
+	start, allDay, err := parseDateTime(startProp, defaultLoc)
+	if err != nil {
+		return nil, err
+	}
+
+	duration := time.Hour
+	if allDay {
+		duration = 24 * time.Hour
+	}
+	if p, ok := ev.get("DTEND"); ok {
+		if end, _, err := parseDateTime(p, defaultLoc); err == nil && end.After(start) {
+			duration = end.Sub(start)
+		}
+	} else if p, ok := ev.get("DURATION"); ok {
+		if d, err := parseDuration(p.value); err == nil {
+			duration = d
+		}
+	}
+
+	base := Event{
+		UID:         ev.text("UID"),
+		Summary:     ev.text("SUMMARY"),
+		Description: ev.text("DESCRIPTION"),
+		Location:    ev.text("LOCATION"),
+		AllDay:      allDay,
+	}
+	if base.UID == "" {
+		base.UID = fmt.Sprintf("%s@%d", base.Summary, start.Unix())
+	}
+
+	excluded := map[int64]bool{}
+	for _, p := range ev["EXDATE"] {
+		for _, v := range strings.Split(p.value, ",") {
+			if t, _, err := parseDateTime(property{params: p.params, value: v}, defaultLoc); err == nil {
+				excluded[t.Unix()] = true
+			}
+		}
+	}
+	_, isOverride := ev.get("RECURRENCE-ID")
+
+	var starts []time.Time
+	if rule, ok := ev.get("RRULE"); ok && !isOverride {
+		starts, err = recurrences(start, rule.value, defaultLoc, from.Add(-duration), to)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		starts = []time.Time{start}
+	}
+
+	var out []Event
+	for _, s := range starts {
+		if excluded[s.Unix()] || (!isOverride && overridden[s.Unix()]) {
+			continue
+		}
+		e := s.Add(duration)
+		if !e.After(from) || !s.Before(to) {
+			continue
+		}
+		occ := base
+		occ.Start, occ.End = s, e
+		out = append(out, occ)
+	}
+	return out, nil
+}
+
+// recurrences returns the start times generated by an RRULE that fall within [windowStart, to]
+func recurrences(start time.Time, rule string, defaultLoc *time.Location, windowStart, to time.Time) ([]time.Time, error) {
+	parts := map[string]string{}
+	for _, kv := range strings.Split(rule, ";") {
+		if k, v, ok := strings.Cut(kv, "="); ok {
+			parts[strings.ToUpper(k)] = strings.ToUpper(v)
+		}
+	}
+
+	interval := 1
+	if v, err := strconv.Atoi(parts["INTERVAL"]); err == nil && v > 0 {
+		interval = v
+	}
+	count := 0
+	if v, err := strconv.Atoi(parts["COUNT"]); err == nil && v > 0 {
+		count = v
+	}
+	until := to
+	if v := parts["UNTIL"]; v != "" {
+		if t, _, err := parseDateTime(property{value: v}, defaultLoc); err == nil && t.Before(until) {
+			until = t
+		}
+	}
+	byDay, err := parseByDay(parts["BYDAY"])
+	if err != nil {
+		return nil, err
+	}
+
+	var out []time.Time
+	generated := 0
+	emit := func(t time.Time) bool {
+		if t.Before(start) {
+			return true
+		}
+		if t.After(until) || (count > 0 && generated >= count) || generated >= maxIterations || len(out) >= maxOccurrences {
+			return false
+		}
+		generated++
+		if !t.Before(windowStart) {
+			out = append(out, t)
+		}
+		return true
+	}
+
+	switch parts["FREQ"] {
+	case "DAILY":
+		for i := 0; ; i++ {
+			if !emit(start.AddDate(0, 0, i*interval)) {
+				return out, nil
+			}
+		}
+	case "WEEKLY":
+		if len(byDay) == 0 {
+			byDay = []weekdayRule{{day: start.Weekday()}}
+		}
+		weekStart := start.AddDate(0, 0, -mondayOffset(start.Weekday()))
+		for i := 0; len(out) < maxOccurrences; i++ {
+			week := weekStart.AddDate(0, 0, 7*i*interval)
+			if week.After(until) {
+				return out, nil
+			}
+			for _, rule := range byDay {
+				if !emit(week.AddDate(0, 0, mondayOffset(rule.day))) {
+					return out, nil
+				}
+			}
+		}
+	case "MONTHLY":
+		for i := 0; len(out) < maxOccurrences; i++ {
+			month := time.Date(start.Year(), start.Month()+time.Month(i*interval), 1,
+				start.Hour(), start.Minute(), start.Second(), 0, start.Location())
+			if month.After(until) {
+				return out, nil
+			}
+			for _, t := range monthlyDates(month, start.Day(), byDay) {
+				if !emit(t) {
+					return out, nil
+				}
+			}
+		}
+	case "YEARLY":
+		for i := 0; ; i++ {
+			if !emit(start.AddDate(i*interval, 0, 0)) {
+				return out, nil
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unsupported recurrence %q", parts["FREQ"])
+	}
+	return out, nil
+}
+
+type weekdayRule struct {
+	ordinal int // 0 means every matching weekday; negative counts from the end of the month
+	day     time.Weekday
+}
+
+var weekdayCodes = map[string]time.Weekday{
+	"MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday, "TH": time.Thursday,
+	"FR": time.Friday, "SA": time.Saturday, "SU": time.Sunday,
+}
+
+func parseByDay(value string) ([]weekdayRule, error) {
+	if value == "" {
+		return nil, nil
+	}
+	var rules []weekdayRule
+	for _, item := range strings.Split(value, ",") {
+		if len(item) < 2 {
+			return nil, fmt.Errorf("invalid BYDAY %q", item)
+		}
+		day, ok := weekdayCodes[item[len(item)-2:]]
+		if !ok {
+			return nil, fmt.Errorf("invalid BYDAY %q", item)
+		}
+		rule := weekdayRule{day: day}
+		if prefix := item[:len(item)-2]; prefix != "" {
+			n, err := strconv.Atoi(prefix)
+			if err != nil {
+				return nil, fmt.Errorf("invalid BYDAY %q", item)
+			}
+			rule.ordinal = n
+		}
+		rules = append(rules, rule)
+	}
+	sort.Slice(rules, func(i, j int) bool { return mondayOffset(rules[i].day) < mondayOffset(rules[j].day) })
+	return rules, nil
+}
+
+// monthlyDates returns the occurrences within the month starting at first, in order
+func monthlyDates(first time.Time, dayOfMonth int, byDay []weekdayRule) []time.Time {
+	daysInMonth := first.AddDate(0, 1, -1).Day()
+	if len(byDay) == 0 {
+		if dayOfMonth > daysInMonth {
+			return nil
+		}
+		return []time.Time{first.AddDate(0, 0, dayOfMonth-1)}
+	}
+
+	var out []time.Time
+	for d := 1; d <= daysInMonth; d++ {
+		t := first.AddDate(0, 0, d-1)
+		for _, rule := range byDay {
+			if t.Weekday() != rule.day {
+				continue
+			}
+			nth := (d-1)/7 + 1
+			nthFromEnd := -((daysInMonth-d)/7 + 1)
+			if rule.ordinal == 0 || rule.ordinal == nth || rule.ordinal == nthFromEnd {
+				out = append(out, t)
+				break
+			}
+		}
+	}
+	return out
+}
+
+func mondayOffset(day time.Weekday) int {
+	return (int(day) + 6) % 7
+}
+
+// parseDateTime parses DATE and DATE-TIME values, honoring TZID and the UTC suffix
+func parseDateTime(p property, defaultLoc *time.Location) (time.Time, bool, error) {
+	value := strings.TrimSpace(p.value)
+	if p.params["VALUE"] == "DATE" || len(value) == 8 {
+		t, err := time.ParseInLocation("20060102", value, defaultLoc)
+		return t, true, err
+	}
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse("20060102T150405Z", value)
+		return t, false, err
+	}
+
+	loc := defaultLoc
+	if tzid := strings.Trim(p.params["TZID"], `"`); tzid != "" {
+		if l, err := time.LoadLocation(tzid); err == nil {
+			loc = l
+		}
+	}
+	t, err := time.ParseInLocation("20060102T150405", value, loc)
+	return t, false, err
+}
+
+// parseDuration parses the common subset of RFC 5545 durations (e.g. PT1H30M, P1D, P1W)
+func parseDuration(value string) (time.Duration, error) {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "+")
+	if !strings.HasPrefix(value, "P") {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+
+	var total time.Duration
+	num := 0
+	inTime := false
+	for _, r := range value[1:] {
+		switch {
+		case r >= '0' && r <= '9':
+			num = num*10 + int(r-'0')
+			continue
+		case r == 'T':
+			inTime = true
+			continue
+		case r == 'W':
+			total += time.Duration(num) * 7 * 24 * time.Hour
+		case r == 'D':
+			total += time.Duration(num) * 24 * time.Hour
+		case r == 'H' && inTime:
+			total += time.Duration(num) * time.Hour
+		case r == 'M' && inTime:
+			total += time.Duration(num) * time.Minute
+		case r == 'S' && inTime:
+			total += time.Duration(num) * time.Second
+		default:
+			return 0, fmt.Errorf("invalid duration %q", value)
+		}
+		num = 0
+	}
+	return total, nil
+}
+
+// unfold joins folded content lines (continuations start with a space or tab)
+func unfold(data []byte) []string {
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// parseLine splits "NAME;PARAM=VALUE:value" into its parts
+func parseLine(line string) (string, property, bool) {
+	inQuotes := false
+	colon := -1
+	for i, r := range line {
+		if r == '"' {
+			inQuotes = !inQuotes
+		} else if r == ':' && !inQuotes {
+			colon = i
+			break
+		}
+	}
+	if colon < 0 {
+		return "", property{}, false
+	}
+
+	segments := strings.Split(line[:colon], ";")
+	prop := property{params: map[string]string{}, value: line[colon+1:]}
+	for _, seg := range segments[1:] {
+		if k, v, ok := strings.Cut(seg, "="); ok {
+			prop.params[strings.ToUpper(k)] = v
+		}
+	}
+	return strings.ToUpper(segments[0]), prop, true
+}
+
+func unescapeText(s string) string {
+	return strings.NewReplacer(`\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";", `\\`, `\`).Replace(s)
+}
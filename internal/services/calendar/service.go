@@ -0,0 +1,299 @@
+package calendar
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/repository"
+	"discord-tars/internal/services/outbox"
+)
+
+const (
+	DefaultReminderMinutes = 30
+
+	// syncHorizon is how far ahead occurrences are expanded and stored
+	syncHorizon     = 90 * 24 * time.Hour
+	contextHorizon  = 60 * 24 * time.Hour
+	contextMaxItems = 10
+	maxICSBytes     = 10 * 1024 * 1024
+)
+
+var ErrInvalidCalendarURL = errors.New("calendar URL must be an http(s) or webcal ICS address")
+
+// scheduleQuestion detects questions that benefit from calendar context
+var scheduleQuestion = regexp.MustCompile(`(?i)\b(when|next|upcoming|schedule[d]?|calendar|event|events|meeting|meetup|call|stream|stage|session|happening|going on|today|tonight|tomorrow|this week|this weekend|next week|what time|date)\b`)
+
+type Service struct {
+	calendarRepo *repository.CalendarRepository
+	session      *discordgo.Session
+	outbox       *outbox.Service
+	httpClient   *http.Client
+}
+
+func NewService(calendarRepo *repository.CalendarRepository, session *discordgo.Session) *Service {
+	return &Service{
+		calendarRepo: calendarRepo,
+		session:      session,
+		httpClient:   &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// SetOutbox posts reminders through the outbox, retrying failed sends
+func (s *Service) SetOutbox(outbox *outbox.Service) {
+	s.outbox = outbox
+}
+
+// AddSource validates and stores a calendar, then performs its first sync
+func (s *Service) AddSource(ctx context.Context, source *models.CalendarSource) (int, error) {
+	normalized, err := normalizeURL(source.URL)
+	if err != nil {
+		return 0, err
+	}
+	source.URL = normalized
+	source.Kind = models.CalendarKindICS
+	if source.ReminderMinutes <= 0 {
+		source.ReminderMinutes = DefaultReminderMinutes
+	}
+
+	// Fetch before saving so a bad URL is reported immediately
+	data, err := s.fetch(ctx, source.URL)
+	if err != nil {
+		return 0, err
+	}
+	if err := s.calendarRepo.AddSource(ctx, source); err != nil {
+		return 0, err
+	}
+
+	count, err := s.store(ctx, source, data)
+	if markErr := s.calendarRepo.MarkSynced(ctx, source.ID, errorText(err), time.Now()); markErr != nil {
+		log.Printf("❌ Failed to mark calendar %d synced: %v", source.ID, markErr)
+	}
+	return count, err
+}
+
+// RemoveSource deletes a calendar and its events
+func (s *Service) RemoveSource(ctx context.Context, guildID, sourceID int64) (bool, error) {
+	return s.calendarRepo.RemoveSource(ctx, guildID, sourceID)
+}
+
+// ListSources returns a guild's calendars
+func (s *Service) ListSources(ctx context.Context, guildID int64) ([]models.CalendarSource, error) {
+	return s.calendarRepo.ListSources(ctx, guildID)
+}
+
+// Upcoming returns the guild's next events
+func (s *Service) Upcoming(ctx context.Context, guildID int64, limit int) ([]models.CalendarEvent, error) {
+	now := time.Now()
+	return s.calendarRepo.Upcoming(ctx, guildID, now, now.Add(syncHorizon), limit)
+}
+
+// SyncAll is the scheduler job: it refreshes every calendar source
+func (s *Service) SyncAll(ctx context.Context) error {
+	sources, err := s.calendarRepo.ListSources(ctx, 0)
+	if err != nil {
+		return err
+	}
+
+	for i := range sources {
+		source := &sources[i]
+		if source.Kind == models.CalendarKindDiscord {
+			_, err = s.syncDiscord(ctx, source)
+		} else {
+			var data []byte
+			data, err = s.fetch(ctx, source.URL)
+			if err == nil {
+				_, err = s.store(ctx, source, data)
+			}
+		}
+		if err != nil {
+			log.Printf("❌ Failed to sync calendar %d (%s): %v", source.ID, source.Name, err)
+		}
+		if markErr := s.calendarRepo.MarkSynced(ctx, source.ID, errorText(err), time.Now()); markErr != nil {
+			log.Printf("❌ Failed to mark calendar %d synced: %v", source.ID, markErr)
+		}
+	}
+	return nil
+}
+
+// SendReminders is the scheduler job: it posts a reminder for events entering their reminder window
+func (s *Service) SendReminders(ctx context.Context) error {
+	reminders, err := s.calendarRepo.DueReminders(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+
+	for _, reminder := range reminders {
+		claimed, err := s.calendarRepo.ClaimReminder(ctx, reminder.ID)
+		if err != nil {
+			log.Printf("❌ Failed to claim reminder for event %d: %v", reminder.ID, err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		msg := &discordgo.MessageSend{Embeds: []*discordgo.MessageEmbed{reminderEmbed(&reminder)}}
+		if err := s.send(ctx, "calendar-reminder", reminder.GuildID, reminder.ChannelID, msg); err != nil {
+			log.Printf("❌ Failed to post reminder for event %d: %v", reminder.ID, err)
+			continue
+		}
+		log.Printf("📅 Sent reminder for %q", reminder.Summary)
+	}
+	return nil
+}
+
+// send posts to a channel, through the outbox when there is one
+func (s *Service) send(ctx context.Context, kind string, guildID, channelID int64, msg *discordgo.MessageSend) error {
+	if s.outbox != nil {
+		return s.outbox.SendChannel(ctx, kind, guildID, channelID, msg)
+	}
+	_, err := s.session.ChannelMessageSendComplex(strconv.FormatInt(channelID, 10), msg)
+	return err
+}
+
+// ContextFor returns upcoming events as prompt context when the question is about scheduling
+func (s *Service) ContextFor(ctx context.Context, guildID int64, question string) string {
+	if !scheduleQuestion.MatchString(question) {
+		return ""
+	}
+
+	now := time.Now()
+	events, err := s.calendarRepo.Upcoming(ctx, guildID, now, now.Add(contextHorizon), contextMaxItems)
+	if err != nil {
+		log.Printf("⚠️ Failed to load calendar context: %v", err)
+		return ""
+	}
+	if len(events) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Upcoming events from the server calendar (current time %s UTC). ", now.UTC().Format("Mon 2 Jan 2006 15:04")))
+	sb.WriteString("When you mention an event time, write it as <t:UNIX:F> so Discord shows it in the reader's timezone:\n")
+	for _, event := range events {
+		sb.WriteString(fmt.Sprintf("- %s | starts %s UTC (UNIX %d)", event.Summary, event.StartsAt.UTC().Format("Mon 2 Jan 2006 15:04"), event.StartsAt.Unix()))
+		if event.AllDay {
+			sb.WriteString(" | all day")
+		} else if event.StartsAt.Before(now) {
+			sb.WriteString(" | happening now")
+		}
+		if event.Location != "" {
+			sb.WriteString(" | location: " + event.Location)
+		}
+		if event.Description != "" {
+			sb.WriteString(" | " + truncate(strings.ReplaceAll(event.Description, "\n", " "), 200))
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+func (s *Service) store(ctx context.Context, source *models.CalendarSource, data []byte) (int, error) {
+	now := time.Now()
+	occurrences, err := ParseICS(data, now, now.Add(syncHorizon))
+	if err != nil {
+		return 0, err
+	}
+
+	events := make([]models.CalendarEvent, 0, len(occurrences))
+	for _, occ := range occurrences {
+		events = append(events, models.CalendarEvent{
+			GuildID:     source.GuildID,
+			UID:         occ.UID,
+			StartsAt:    occ.Start,
+			EndsAt:      occ.End,
+			AllDay:      occ.AllDay,
+			Summary:     occ.Summary,
+			Description: occ.Description,
+			Location:    occ.Location,
+		})
+	}
+	if err := s.calendarRepo.ReplaceEvents(ctx, source.ID, now, events); err != nil {
+		return 0, err
+	}
+
+	log.Printf("📅 Synced %d upcoming events from calendar %d", len(events), source.ID)
+	return len(events), nil
+}
+
+func (s *Service) fetch(ctx context.Context, calendarURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, calendarURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create calendar request: %w", err)
+	}
+	req.Header.Set("Accept", "text/calendar")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch calendar: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("calendar returned status %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxICSBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read calendar: %w", err)
+	}
+	return data, nil
+}
+
+func reminderEmbed(reminder *models.CalendarReminder) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Title:       truncate("📅 Starting soon: "+reminder.Summary, 256),
+		Description: fmt.Sprintf("<t:%d:F> (<t:%d:R>)", reminder.StartsAt.Unix(), reminder.StartsAt.Unix()),
+		Color:       0x4285f4,
+		Footer:      &discordgo.MessageEmbedFooter{Text: reminder.SourceName},
+	}
+	if reminder.Location != "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Where", Value: truncate(reminder.Location, 1024)})
+	}
+	if reminder.Description != "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Details", Value: truncate(reminder.Description, 1024)})
+	}
+	return embed
+}
+
+func normalizeURL(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if strings.HasPrefix(strings.ToLower(raw), "webcal://") {
+		raw = "https://" + raw[len("webcal://"):]
+	}
+	parsed, err := url.Parse(raw)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return "", ErrInvalidCalendarURL
+	}
+	return parsed.String(), nil
+}
+
+func errorText(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	cut := max - 3
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + "..."
+}
@@ -0,0 +1,164 @@
+package credentials
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrBusy is returned when a request waited in the AI queue for longer than
+// the queue timeout
+var ErrBusy = errors.New("too many AI requests are in progress")
+
+type queueKey struct{}
+
+type slotKey struct{}
+
+// WithQueueUpdates asks to be told a request's place in the AI queue while it
+// waits: onPosition receives its 1-based position whenever it changes, then 0
+// once the request starts. It is called with the limiter locked, so it must
+// return quickly.
+func WithQueueUpdates(ctx context.Context, onPosition func(position int)) context.Context {
+	return context.WithValue(ctx, queueKey{}, onPosition)
+}
+
+// limiter caps how many AI requests run at once, overall and per guild, so a
+// burst in one guild can't use up the provider's rate limit for the others.
+// Waiting requests are admitted in order, skipping those whose guild is at
+// its limit.
+type limiter struct {
+	global  int // 0 for no limit
+	guild   int // 0 for no limit
+	timeout time.Duration
+
+	mu      sync.Mutex
+	running int
+	byGuild map[int64]int
+	queue   []*waiter
+}
+
+type waiter struct {
+	guildID    int64
+	ready      chan struct{}
+	admitted   bool
+	position   int
+	onPosition func(int)
+}
+
+func newLimiter(global, guild int, timeout time.Duration) *limiter {
+	if timeout <= 0 {
+		timeout = 20 * time.Second
+	}
+	return &limiter{global: global, guild: guild, timeout: timeout, byGuild: make(map[int64]int)}
+}
+
+// acquire waits for a slot for the guild a request is tagged with and returns
+// the context to make the request with, and the function releasing the slot.
+// Requests made with the returned context, such as by tools, reuse its slot.
+func (l *limiter) acquire(ctx context.Context, guildID int64) (context.Context, func(), error) {
+	if l == nil || (l.global <= 0 && l.guild <= 0) || ctx.Value(slotKey{}) != nil {
+		return ctx, func() {}, nil
+	}
+	slotCtx := context.WithValue(ctx, slotKey{}, true)
+	release := func() { l.release(guildID) }
+
+	l.mu.Lock()
+	if len(l.queue) == 0 && l.available(guildID) {
+		l.take(guildID)
+		l.mu.Unlock()
+		return slotCtx, release, nil
+	}
+	w := &waiter{guildID: guildID, ready: make(chan struct{})}
+	w.onPosition, _ = ctx.Value(queueKey{}).(func(int))
+	l.queue = append(l.queue, w)
+	// An arrival can still run ahead of waiters held back by their guild limit
+	l.dispatch()
+	l.mu.Unlock()
+
+	timer := time.NewTimer(l.timeout)
+	defer timer.Stop()
+	select {
+	case <-w.ready:
+		return slotCtx, release, nil
+	case <-ctx.Done():
+		return ctx, nil, l.abandon(w, ctx.Err())
+	case <-timer.C:
+		return ctx, nil, l.abandon(w, ErrBusy)
+	}
+}
+
+// abandon takes a waiter that gave up out of the queue, handing its slot on
+// if it was admitted meanwhile
+func (l *limiter) abandon(w *waiter, err error) error {
+	l.mu.Lock()
+	admitted := w.admitted
+	if !admitted {
+		for n, queued := range l.queue {
+			if queued == w {
+				l.queue = append(l.queue[:n], l.queue[n+1:]...)
+				break
+			}
+		}
+		l.dispatch()
+	}
+	l.mu.Unlock()
+
+	if admitted {
+		l.release(w.guildID)
+	}
+	return err
+}
+
+func (l *limiter) release(guildID int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.running--
+	if l.byGuild[guildID]--; l.byGuild[guildID] <= 0 {
+		delete(l.byGuild, guildID)
+	}
+	l.dispatch()
+}
+
+// dispatch admits every waiter that has a slot free, in order, then tells the
+// rest where they stand; the caller holds l.mu
+func (l *limiter) dispatch() {
+	waiting := l.queue[:0]
+	for _, w := range l.queue {
+		if !l.available(w.guildID) {
+			waiting = append(waiting, w)
+			continue
+		}
+		l.take(w.guildID)
+		w.admitted = true
+		close(w.ready)
+		if w.onPosition != nil {
+			w.onPosition(0)
+		}
+	}
+	clear(l.queue[len(waiting):])
+	l.queue = waiting
+
+	for n, w := range l.queue {
+		if w.position != n+1 {
+			w.position = n + 1
+			if w.onPosition != nil {
+				w.onPosition(w.position)
+			}
+		}
+	}
+}
+
+// available reports whether a request of the guild may start; requests not
+// made for a guild only count towards the global limit. The caller holds l.mu.
+func (l *limiter) available(guildID int64) bool {
+	if l.global > 0 && l.running >= l.global {
+		return false
+	}
+	return l.guild <= 0 || guildID == 0 || l.byGuild[guildID] < l.guild
+}
+
+func (l *limiter) take(guildID int64) {
+	l.running++
+	l.byGuild[guildID]++
+}
@@ -0,0 +1,382 @@
+package credentials
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+
+	"discord-tars/internal/interfaces"
+	"discord-tars/internal/models"
+	"discord-tars/internal/repository"
+	"discord-tars/internal/secrets"
+	anthropicService "discord-tars/internal/services/anthropic"
+	openaiService "discord-tars/internal/services/openai"
+	"discord-tars/internal/tenant"
+)
+
+const (
+	// cacheTTL bounds how long a resolved credential is reused before the database is consulted again
+	cacheTTL        = 5 * time.Minute
+	validateTimeout = 20 * time.Second
+)
+
+var (
+	ErrEncryptionDisabled = errors.New("per-server API keys need an encryption key configured on this instance")
+	ErrNoCredential       = errors.New("this server has no AI API key configured")
+	ErrUnknownProvider    = errors.New("unknown AI provider")
+)
+
+type Config struct {
+	DefaultOpenAIModel string
+	// RequireOwnKey refuses guild requests without their own key instead of
+	// falling back to the operator's, for hosted instances
+	RequireOwnKey bool
+	// GlobalConcurrency and GuildConcurrency cap how many AI requests run at
+	// once, overall and per guild; 0 for no limit. Embeddings aren't limited.
+	GlobalConcurrency int
+	GuildConcurrency  int
+	// QueueTimeout is how long a request may wait for a slot before ErrBusy
+	QueueTimeout time.Duration
+	// HTTPClient sends the guilds' own API requests, e.g. to record or replay
+	// them; nil uses the providers' default clients
+	HTTPClient *http.Client
+}
+
+// tenantProvider is what a guild's credential resolves to
+type tenantProvider struct {
+	ai     interfaces.AIService
+	openai *openai.Client // Set for OpenAI keys, used for voice
+}
+
+type cachedCredential struct {
+	provider  *tenantProvider // nil when the guild has no key of its own
+	loadedAt  time.Time
+	clientKey string
+}
+
+// Service implements interfaces.AIService by routing each request to the
+// requesting guild's own provider key, falling back to the operator's service.
+// Embeddings always use the operator's service so every guild's vectors share
+// one embedding space.
+type Service struct {
+	credentialRepo *repository.CredentialRepository
+	cipher         *secrets.Cipher // nil disables per-guild keys
+	fallback       interfaces.AIService
+	cfg            Config
+	limiter        *limiter
+
+	mu           sync.Mutex
+	humorLevel   int
+	honestyLevel int
+	cache        map[int64]cachedCredential
+	providers    map[string]*tenantProvider // provider/model/key hash -> clients, reused across cache refreshes
+}
+
+func NewService(credentialRepo *repository.CredentialRepository, cipher *secrets.Cipher, fallback interfaces.AIService, cfg Config) *Service {
+	return &Service{
+		credentialRepo: credentialRepo,
+		cipher:         cipher,
+		fallback:       fallback,
+		cfg:            cfg,
+		limiter:        newLimiter(cfg.GlobalConcurrency, cfg.GuildConcurrency, cfg.QueueTimeout),
+		humorLevel:     75,
+		honestyLevel:   100,
+		cache:          make(map[int64]cachedCredential),
+		providers:      make(map[string]*tenantProvider),
+	}
+}
+
+// Enabled reports whether guilds can store their own keys
+func (s *Service) Enabled() bool {
+	return s.cipher != nil
+}
+
+// SetKey validates a guild's key against its provider, then stores it encrypted
+func (s *Service) SetKey(ctx context.Context, guildID int64, provider, apiKey, model string, userID int64) (*models.AICredential, error) {
+	if s.cipher == nil {
+		return nil, ErrEncryptionDisabled
+	}
+	apiKey, model = strings.TrimSpace(apiKey), strings.TrimSpace(model)
+	if apiKey == "" {
+		return nil, fmt.Errorf("an API key is required")
+	}
+
+	candidate, err := s.newProvider(provider, apiKey, model)
+	if err != nil {
+		return nil, err
+	}
+
+	checkCtx, cancel := context.WithTimeout(ctx, validateTimeout)
+	defer cancel()
+	if _, err := candidate.ai.Complete(checkCtx, "Reply with the single word OK.", "ping", 5); err != nil {
+		log.Printf("⚠️ Rejected %s key for guild %d: %v", provider, guildID, err)
+		return nil, fmt.Errorf("the %s API rejected this key or model", provider)
+	}
+
+	sealed, err := s.cipher.Seal(apiKey)
+	if err != nil {
+		return nil, err
+	}
+	cred := &models.AICredential{
+		GuildID:      guildID,
+		Provider:     provider,
+		EncryptedKey: sealed,
+		KeyHint:      keyHint(apiKey),
+		Model:        model,
+		CreatedBy:    userID,
+	}
+	if err := s.credentialRepo.SaveCredential(ctx, cred); err != nil {
+		return nil, err
+	}
+
+	s.invalidate(guildID)
+	return cred, nil
+}
+
+// Status returns a guild's stored credential (key still encrypted), or nil if none
+func (s *Service) Status(ctx context.Context, guildID int64) (*models.AICredential, error) {
+	return s.credentialRepo.GetCredential(ctx, guildID)
+}
+
+// RemoveKey deletes a guild's key; the guild falls back to the operator's key
+func (s *Service) RemoveKey(ctx context.Context, guildID int64) (bool, error) {
+	removed, err := s.credentialRepo.DeleteCredential(ctx, guildID)
+	if err != nil {
+		return false, err
+	}
+	s.invalidate(guildID)
+	return removed, nil
+}
+
+// RequiresOwnKey reports whether guilds must bring their own key
+func (s *Service) RequiresOwnKey() bool {
+	return s.cfg.RequireOwnKey
+}
+
+func (s *Service) GenerateResponse(ctx context.Context, userMessage, username string) (string, error) {
+	ctx, release, err := s.acquire(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+	ai, err := s.resolve(ctx)
+	if err != nil {
+		return "", err
+	}
+	return ai.GenerateResponse(ctx, userMessage, username)
+}
+
+// StreamResponse streams the answer when the guild's provider supports it,
+// and otherwise passes the whole answer to onText at once
+func (s *Service) StreamResponse(ctx context.Context, userMessage, username string, onText func(text string)) (string, error) {
+	ctx, release, err := s.acquire(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+	ai, err := s.resolve(ctx)
+	if err != nil {
+		return "", err
+	}
+	if streaming, ok := ai.(interfaces.StreamingAIService); ok {
+		return streaming.StreamResponse(ctx, userMessage, username, onText)
+	}
+	response, err := ai.GenerateResponse(ctx, userMessage, username)
+	if err != nil {
+		return "", err
+	}
+	onText(response)
+	return response, nil
+}
+
+func (s *Service) GenerateResponseWithTools(ctx context.Context, userMessage, username string, tools []interfaces.Tool) (string, error) {
+	ctx, release, err := s.acquire(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+	ai, err := s.resolve(ctx)
+	if err != nil {
+		return "", err
+	}
+	return ai.GenerateResponseWithTools(ctx, userMessage, username, tools)
+}
+
+func (s *Service) Complete(ctx context.Context, systemPrompt, userPrompt string, maxTokens int) (string, error) {
+	ctx, release, err := s.acquire(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+	ai, err := s.resolve(ctx)
+	if err != nil {
+		return "", err
+	}
+	return ai.Complete(ctx, systemPrompt, userPrompt, maxTokens)
+}
+
+func (s *Service) GenerateEmbedding(ctx context.Context, text string) ([]float32, error) {
+	return s.fallback.GenerateEmbedding(ctx, text)
+}
+
+func (s *Service) SetPersonality(humor, honesty int) {
+	s.fallback.SetPersonality(humor, honesty)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if humor >= 0 && humor <= 100 {
+		s.humorLevel = humor
+	}
+	if honesty >= 0 && honesty <= 100 {
+		s.honestyLevel = honesty
+	}
+	for _, p := range s.providers {
+		p.ai.SetPersonality(s.humorLevel, s.honestyLevel)
+	}
+}
+
+// OpenAIClient returns the OpenAI client for a guild's own key, or nil when the
+// operator's client should be used
+func (s *Service) OpenAIClient(ctx context.Context, guildID int64) (*openai.Client, error) {
+	p, err := s.lookup(ctx, guildID)
+	if err != nil {
+		return nil, err
+	}
+	if p == nil || p.openai == nil {
+		if s.cfg.RequireOwnKey {
+			return nil, ErrNoCredential
+		}
+		return nil, nil
+	}
+	return p.openai, nil
+}
+
+// acquire waits for a concurrency slot for the guild a request is tagged with
+func (s *Service) acquire(ctx context.Context) (context.Context, func(), error) {
+	guildID, _ := tenant.GuildFrom(ctx)
+	ctx, release, err := s.limiter.acquire(ctx, guildID)
+	if errors.Is(err, ErrBusy) {
+		log.Printf("⏳ AI request for guild %d timed out in the queue", guildID)
+	}
+	return ctx, release, err
+}
+
+// resolve picks the AI service for the guild a request is tagged with
+func (s *Service) resolve(ctx context.Context) (interfaces.AIService, error) {
+	guildID, ok := tenant.GuildFrom(ctx)
+	if !ok || s.cipher == nil {
+		return s.fallback, nil
+	}
+
+	p, err := s.lookup(ctx, guildID)
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		if s.cfg.RequireOwnKey {
+			return nil, ErrNoCredential
+		}
+		return s.fallback, nil
+	}
+	return p.ai, nil
+}
+
+func (s *Service) lookup(ctx context.Context, guildID int64) (*tenantProvider, error) {
+	if s.cipher == nil {
+		return nil, nil
+	}
+
+	s.mu.Lock()
+	entry, ok := s.cache[guildID]
+	s.mu.Unlock()
+	if ok && time.Since(entry.loadedAt) < cacheTTL {
+		return entry.provider, nil
+	}
+
+	cred, err := s.credentialRepo.GetCredential(ctx, guildID)
+	if err != nil {
+		return nil, err
+	}
+
+	entry = cachedCredential{loadedAt: time.Now()}
+	if cred != nil {
+		apiKey, err := s.cipher.Open(cred.EncryptedKey)
+		if err != nil {
+			// Most likely the instance key was rotated; don't silently bill the operator
+			log.Printf("❌ Failed to decrypt AI credential for guild %d: %v", guildID, err)
+			return nil, fmt.Errorf("failed to decrypt AI credential: %w", err)
+		}
+		entry.clientKey = clientKey(cred.Provider, cred.Model, apiKey)
+
+		s.mu.Lock()
+		p, exists := s.providers[entry.clientKey]
+		s.mu.Unlock()
+		if !exists {
+			if p, err = s.newProvider(cred.Provider, apiKey, cred.Model); err != nil {
+				return nil, err
+			}
+		}
+		entry.provider = p
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if old, ok := s.cache[guildID]; ok && old.clientKey != entry.clientKey {
+		delete(s.providers, old.clientKey)
+	}
+	if entry.provider != nil {
+		entry.provider.ai.SetPersonality(s.humorLevel, s.honestyLevel)
+		s.providers[entry.clientKey] = entry.provider
+	}
+	s.cache[guildID] = entry
+	return entry.provider, nil
+}
+
+func (s *Service) newProvider(provider, apiKey, model string) (*tenantProvider, error) {
+	switch provider {
+	case models.ProviderOpenAI:
+		if model == "" {
+			model = s.cfg.DefaultOpenAIModel
+		}
+		return &tenantProvider{
+			ai:     openaiService.NewService(openaiService.Config{APIKey: apiKey, Model: model, HTTPClient: s.cfg.HTTPClient}),
+			openai: openaiService.NewClient(apiKey, s.cfg.HTTPClient),
+		}, nil
+	case models.ProviderAnthropic:
+		return &tenantProvider{
+			ai: anthropicService.NewService(anthropicService.Config{APIKey: apiKey, Model: model, HTTPClient: s.cfg.HTTPClient}),
+		}, nil
+	default:
+		return nil, fmt.Errorf("%w %q", ErrUnknownProvider, provider)
+	}
+}
+
+func (s *Service) invalidate(guildID int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if entry, ok := s.cache[guildID]; ok {
+		delete(s.providers, entry.clientKey)
+		delete(s.cache, guildID)
+	}
+}
+
+func clientKey(provider, model, apiKey string) string {
+	sum := sha256.Sum256([]byte(apiKey))
+	return provider + "/" + model + "/" + hex.EncodeToString(sum[:8])
+}
+
+func keyHint(apiKey string) string {
+	if len(apiKey) <= 8 {
+		return ""
+	}
+	return apiKey[len(apiKey)-4:]
+}
@@ -0,0 +1,341 @@
+package dashboard
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"discord-tars/internal/models"
+	openaiService "discord-tars/internal/services/openai"
+	"discord-tars/internal/services/persona"
+	"discord-tars/internal/services/slo"
+)
+
+const (
+	faqLabel = "faq"
+	// recentAnswers is how many answers the answers page lists
+	recentAnswers = 50
+	// answerStatsPeriod is the period answers are counted over
+	answerStatsPeriod = 7 * 24 * time.Hour
+)
+
+var (
+	//go:embed templates/*.html
+	templateFiles embed.FS
+	//go:embed static
+	staticFiles embed.FS
+)
+
+func parsePages() (*template.Template, error) {
+	funcs := template.FuncMap{
+		"messageURL": func(guildID, channelID, messageID int64) string {
+			return fmt.Sprintf("https://discord.com/channels/%d/%d/%d", guildID, channelID, messageID)
+		},
+		"when": func(t time.Time) string {
+			return t.UTC().Format("2006-01-02 15:04 UTC")
+		},
+		"ms": func(d time.Duration) int64 {
+			return d.Milliseconds()
+		},
+	}
+	pages, err := template.New("").Funcs(funcs).ParseFS(templateFiles, "templates/*.html")
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse dashboard templates: %w", err)
+	}
+	return pages, nil
+}
+
+// page is what every page shows: who is signed in and which guild is open
+type page struct {
+	Session *session
+	CSRF    string
+	Guild   guildInfo
+	Tab     string
+	Notice  string
+}
+
+type guildInfo struct {
+	ID   int64
+	Name string
+}
+
+type indexPage struct {
+	page
+	Guilds []guildInfo
+}
+
+type guildPage struct {
+	page
+	CommandsLastHour int
+	Commands         []slo.CommandStats
+	Tokens           int64
+	Answers          models.AnswerStats
+	Settings         settingsView
+}
+
+type settingsView struct {
+	Verbosity           persona.Verbosity
+	Verbosities         []persona.Verbosity
+	Style               persona.Style
+	Styles              []persona.Style
+	DuplicatesAvailable bool
+	Duplicates          bool
+}
+
+type faqPage struct {
+	page
+	Documents []models.PriorityDocument
+}
+
+type answersPage struct {
+	page
+	NegativeOnly bool
+	Answers      []models.AnswerLog
+}
+
+func (s *Service) render(w http.ResponseWriter, name string, data interface{}) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; script-src 'self'; connect-src 'self'; style-src 'unsafe-inline'; form-action 'self'; frame-ancestors 'none'")
+	w.Header().Set("Referrer-Policy", "same-origin")
+	if err := s.pages.ExecuteTemplate(w, name, data); err != nil {
+		log.Printf("❌ Failed to render dashboard page %s: %v", name, err)
+	}
+}
+
+func (s *Service) handleIndex(w http.ResponseWriter, r *http.Request) {
+	sess, ok := s.readSession(r)
+	if !ok {
+		s.render(w, "login.html", page{})
+		return
+	}
+	data := indexPage{page: page{Session: sess, CSRF: s.csrfToken(r)}}
+	for _, id := range sess.Guilds {
+		data.Guilds = append(data.Guilds, s.guildInfo(id))
+	}
+	sort.Slice(data.Guilds, func(a, b int) bool {
+		return strings.ToLower(data.Guilds[a].Name) < strings.ToLower(data.Guilds[b].Name)
+	})
+	s.render(w, "index.html", data)
+}
+
+func (s *Service) handleLogin(w http.ResponseWriter, r *http.Request) {
+	state, err := s.newState(w)
+	if err != nil {
+		http.Error(w, "failed to start signing in", http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, s.authorizeURL(state), http.StatusFound)
+}
+
+// handleCallback finishes signing in: it reads the user and the guilds they
+// manage from Discord, then starts a session
+func (s *Service) handleCallback(w http.ResponseWriter, r *http.Request) {
+	if !s.checkState(w, r) {
+		http.Error(w, "this sign-in link expired or was not started here; sign in again", http.StatusBadRequest)
+		return
+	}
+	if reason := r.URL.Query().Get("error"); reason != "" {
+		http.Redirect(w, r, "/dashboard/", http.StatusFound)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 20*time.Second)
+	defer cancel()
+	token, err := s.exchange(ctx, r.URL.Query().Get("code"))
+	if err != nil {
+		log.Printf("❌ Dashboard sign-in failed: %v", err)
+		http.Error(w, "signing in with Discord failed", http.StatusBadGateway)
+		return
+	}
+	var user discordUser
+	var guilds []discordGuild
+	err = s.fetch(ctx, token, "/users/@me", &user)
+	if err == nil {
+		err = s.fetch(ctx, token, "/users/@me/guilds", &guilds)
+	}
+	if err != nil {
+		log.Printf("❌ Dashboard sign-in failed: %v", err)
+		http.Error(w, "signing in with Discord failed", http.StatusBadGateway)
+		return
+	}
+
+	userID, _ := strconv.ParseInt(user.ID, 10, 64)
+	name := user.GlobalName
+	if name == "" {
+		name = user.Username
+	}
+	sess := &session{
+		UserID:   userID,
+		Username: name,
+		Guilds:   s.adminGuilds(guilds),
+		Expires:  time.Now().Add(s.cfg.SessionTTL).Unix(),
+	}
+	if err := s.setSession(w, sess); err != nil {
+		http.Error(w, "failed to start the session", http.StatusInternalServerError)
+		return
+	}
+	log.Printf("🔑 %s signed in to the dashboard, managing %d guilds", name, len(sess.Guilds))
+	http.Redirect(w, r, "/dashboard/", http.StatusFound)
+}
+
+func (s *Service) handleLogout(w http.ResponseWriter, r *http.Request) {
+	if s.validCSRF(r) {
+		s.clearCookie(w, sessionCookie)
+	}
+	http.Redirect(w, r, "/dashboard/", http.StatusSeeOther)
+}
+
+// guildHandler serves a guild's pages to its admins only
+func (s *Service) guildHandler(next func(http.ResponseWriter, *http.Request, page)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sess, ok := s.readSession(r)
+		if !ok {
+			http.Redirect(w, r, "/dashboard/", http.StatusFound)
+			return
+		}
+		guildID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil || !sess.administers(guildID) {
+			http.Error(w, "you don't manage this server", http.StatusForbidden)
+			return
+		}
+		if _, err := s.session.State.Guild(r.PathValue("id")); err != nil {
+			http.Error(w, "the bot is no longer in this server", http.StatusNotFound)
+			return
+		}
+		next(w, r, page{Session: sess, CSRF: s.csrfToken(r), Guild: s.guildInfo(guildID)})
+	}
+}
+
+func (s *Service) guildInfo(guildID int64) guildInfo {
+	info := guildInfo{ID: guildID, Name: strconv.FormatInt(guildID, 10)}
+	if guild, err := s.session.State.Guild(strconv.FormatInt(guildID, 10)); err == nil {
+		info.Name = guild.Name
+	}
+	return info
+}
+
+// handleGuild shows a guild's usage and settings
+func (s *Service) handleGuild(w http.ResponseWriter, r *http.Request, p page) {
+	p.Tab = "overview"
+	if r.URL.Query().Get("saved") != "" {
+		p.Notice = "Settings saved."
+	}
+	data := guildPage{page: p, Tokens: openaiService.Usage().ByGuild[p.Guild.ID]}
+	if s.latency != nil {
+		data.Commands = s.latency.Stats(p.Guild.ID)
+		for _, c := range data.Commands {
+			data.CommandsLastHour += c.Count
+		}
+		sort.SliceStable(data.Commands, func(a, b int) bool { return data.Commands[a].Count > data.Commands[b].Count })
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+	var err error
+	if data.Answers, err = s.answers.Stats(ctx, p.Guild.ID, time.Now().Add(-answerStatsPeriod)); err != nil {
+		log.Printf("⚠️ %v", err)
+	}
+	data.Settings = settingsView{Verbosities: persona.Verbosities, Styles: persona.Styles}
+	if data.Settings.Verbosity, err = s.persona.Verbosity(ctx, p.Guild.ID); err != nil {
+		log.Printf("⚠️ Failed to read answer verbosity: %v", err)
+	}
+	if data.Settings.Style, err = s.persona.Style(ctx, p.Guild.ID); err != nil {
+		log.Printf("⚠️ Failed to read answer style: %v", err)
+	}
+	if s.duplicates != nil {
+		data.Settings.DuplicatesAvailable = true
+		data.Settings.Duplicates = s.duplicates.Enabled(ctx, p.Guild.ID)
+	}
+	s.render(w, "guild.html", data)
+}
+
+// handleSettings saves the settings form, as /persona verbosity, /persona
+// style and /duplicates would
+func (s *Service) handleSettings(w http.ResponseWriter, r *http.Request, p page) {
+	if !s.validCSRF(r) {
+		http.Error(w, "the form expired; reload the page and try again", http.StatusForbidden)
+		return
+	}
+	verbosity, ok := persona.ParseVerbosity(r.PostFormValue("verbosity"))
+	if !ok {
+		http.Error(w, "unknown verbosity", http.StatusBadRequest)
+		return
+	}
+	style, ok := persona.ParseStyle(r.PostFormValue("style"))
+	if !ok {
+		http.Error(w, "unknown style", http.StatusBadRequest)
+		return
+	}
+	duplicates := r.PostFormValue("duplicates") == "on"
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+	started := time.Now()
+	options := fmt.Sprintf("verbosity:%s style:%s", verbosity, style)
+	err := s.persona.SetVerbosity(ctx, p.Guild.ID, p.Session.UserID, verbosity)
+	if err == nil {
+		err = s.persona.SetStyle(ctx, p.Guild.ID, p.Session.UserID, style)
+	}
+	if err == nil && s.duplicates != nil {
+		options += fmt.Sprintf(" duplicates:%t", duplicates)
+		err = s.duplicates.SetEnabled(ctx, p.Guild.ID, duplicates)
+	}
+
+	outcome := models.AuditOK
+	if err != nil {
+		outcome = models.AuditError
+	}
+	if s.auditLog != nil {
+		s.auditLog.Record(ctx, &models.CommandAudit{
+			GuildID:   p.Guild.ID,
+			UserID:    p.Session.UserID,
+			Command:   "dashboard settings",
+			Options:   options,
+			LatencyMS: time.Since(started).Milliseconds(),
+			Outcome:   outcome,
+		})
+	}
+	if err != nil {
+		log.Printf("❌ Failed to save dashboard settings for guild %d: %v", p.Guild.ID, err)
+		http.Error(w, "failed to save the settings; please try again", http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, fmt.Sprintf("/dashboard/guilds/%d?saved=1", p.Guild.ID), http.StatusSeeOther)
+}
+
+// handleFAQ lists the entries of the guild's FAQ channels
+func (s *Service) handleFAQ(w http.ResponseWriter, r *http.Request, p page) {
+	p.Tab = "faq"
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+	docs, err := s.priority.ListDocumentsByLabel(ctx, p.Guild.ID, faqLabel)
+	if err != nil {
+		log.Printf("❌ %v", err)
+		http.Error(w, "failed to load the FAQ", http.StatusInternalServerError)
+		return
+	}
+	s.render(w, "faq.html", faqPage{page: p, Documents: docs})
+}
+
+// handleAnswers lists recent answers with their feedback, only those with a
+// 👎 with ?feedback=negative
+func (s *Service) handleAnswers(w http.ResponseWriter, r *http.Request, p page) {
+	p.Tab = "answers"
+	negativeOnly := r.URL.Query().Get("feedback") == "negative"
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+	answers, err := s.answers.Recent(ctx, p.Guild.ID, negativeOnly, recentAnswers)
+	if err != nil {
+		log.Printf("❌ %v", err)
+		http.Error(w, "failed to load the answers", http.StatusInternalServerError)
+		return
+	}
+	s.render(w, "answers.html", answersPage{page: p, NegativeOnly: negativeOnly, Answers: answers})
+}
@@ -0,0 +1,178 @@
+package dashboard
+
+import (
+	"net/http"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"discord-tars/internal/sanitize"
+
+	"github.com/gorilla/websocket"
+)
+
+// Types of live events
+const (
+	LiveQuestion = "question" // The bot answered a question
+	LiveCommand  = "command"  // A slash command finished
+	LiveFeedback = "feedback" // A 👍 or 👎 was added to or removed from an answer
+)
+
+const (
+	// liveBuffer is how many events a slow viewer may fall behind before
+	// events are dropped for it
+	liveBuffer = 64
+	// maxLiveViewers caps the live feeds open on one guild
+	maxLiveViewers = 20
+	// maxLiveQuestion shortens questions in the feed
+	maxLiveQuestion = 300
+
+	liveWriteTimeout = 10 * time.Second
+	livePingInterval = 30 * time.Second
+)
+
+// LiveEvent is something that just happened in a guild, without who did it
+type LiveEvent struct {
+	Type      string    `json:"type"`
+	GuildID   int64     `json:"-"`
+	At        time.Time `json:"at"`
+	Question  string    `json:"question,omitempty"` // Anonymized
+	Command   string    `json:"command,omitempty"`
+	LatencyMS int64     `json:"latency_ms,omitempty"`
+	Feedback  string    `json:"feedback,omitempty"` // "positive" or "negative"
+	Delta     int       `json:"delta,omitempty"`    // 1 when added, -1 when removed
+}
+
+// liveFeed fans events out to the viewers of each guild's live feed
+type liveFeed struct {
+	mu      sync.Mutex
+	viewers map[int64]map[chan LiveEvent]struct{}
+}
+
+func newLiveFeed() *liveFeed {
+	return &liveFeed{viewers: make(map[int64]map[chan LiveEvent]struct{})}
+}
+
+// subscribe adds a viewer of a guild's events, or reports false when the
+// guild has too many
+func (f *liveFeed) subscribe(guildID int64) (chan LiveEvent, func(), bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.viewers[guildID]) >= maxLiveViewers {
+		return nil, nil, false
+	}
+	if f.viewers[guildID] == nil {
+		f.viewers[guildID] = make(map[chan LiveEvent]struct{})
+	}
+	events := make(chan LiveEvent, liveBuffer)
+	f.viewers[guildID][events] = struct{}{}
+	return events, func() {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		delete(f.viewers[guildID], events)
+		if len(f.viewers[guildID]) == 0 {
+			delete(f.viewers, guildID)
+		}
+	}, true
+}
+
+// watched reports whether anyone views a guild's feed
+func (f *liveFeed) watched(guildID int64) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.viewers[guildID]) > 0
+}
+
+// publish hands an event to the guild's viewers without waiting for them
+func (f *liveFeed) publish(event LiveEvent) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for events := range f.viewers[event.GuildID] {
+		select {
+		case events <- event:
+		default:
+		}
+	}
+}
+
+// Publish shows an event on the guild's live feed, if anyone is watching.
+// Questions are anonymized and shortened here.
+func (s *Service) Publish(event LiveEvent) {
+	if event.GuildID == 0 || !s.live.watched(event.GuildID) {
+		return
+	}
+	if event.At.IsZero() {
+		event.At = time.Now()
+	}
+	if event.Question != "" {
+		event.Question = sanitize.Anonymize(event.Question)
+		if utf8.RuneCountInString(event.Question) > maxLiveQuestion {
+			event.Question = string([]rune(event.Question)[:maxLiveQuestion]) + "…"
+		}
+	}
+	s.live.publish(event)
+}
+
+// handleLivePage shows the live feed, which live.js reads from handleLive
+func (s *Service) handleLivePage(w http.ResponseWriter, r *http.Request, p page) {
+	p.Tab = "live"
+	s.render(w, "live.html", p)
+}
+
+// handleLive streams a guild's live events over a WebSocket as JSON, one
+// event per message
+func (s *Service) handleLive(w http.ResponseWriter, r *http.Request, p page) {
+	events, unsubscribe, ok := s.live.subscribe(p.Guild.ID)
+	if !ok {
+		http.Error(w, "too many live feeds are open on this server", http.StatusTooManyRequests)
+		return
+	}
+	defer unsubscribe()
+
+	upgrader := websocket.Upgrader{
+		// Only the dashboard's own pages may open the feed with the
+		// session cookie
+		CheckOrigin: func(r *http.Request) bool {
+			return r.Header.Get("Origin") == s.cfg.PublicURL
+		},
+	}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	// Viewers only listen; reading notices when they leave and answers pings
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		conn.SetReadLimit(512)
+		conn.SetReadDeadline(time.Now().Add(2 * livePingInterval))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(2 * livePingInterval))
+		})
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ping := time.NewTicker(livePingInterval)
+	defer ping.Stop()
+	for {
+		select {
+		case <-closed:
+			return
+		case event := <-events:
+			conn.SetWriteDeadline(time.Now().Add(liveWriteTimeout))
+			if err := conn.WriteJSON(event); err != nil {
+				return
+			}
+		case <-ping.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(liveWriteTimeout)); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package dashboard
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	authorizeURL = "https://discord.com/oauth2/authorize"
+	discordAPI   = "https://discord.com/api/v10"
+	// oauthScopes read who the user is and which guilds they are in, with
+	// their permissions there
+	oauthScopes = "identify guilds"
+)
+
+// adminPermissions are those that make a member a guild admin, as for the
+// admin slash commands
+const adminPermissions = discordgo.PermissionAdministrator | discordgo.PermissionManageGuild
+
+type discordUser struct {
+	ID         string `json:"id"`
+	Username   string `json:"username"`
+	GlobalName string `json:"global_name"`
+}
+
+type discordGuild struct {
+	ID          string `json:"id"`
+	Owner       bool   `json:"owner"`
+	Permissions string `json:"permissions"`
+}
+
+func (s *Service) redirectURL() string {
+	return s.cfg.PublicURL + "/dashboard/callback"
+}
+
+func (s *Service) authorizeURL(state string) string {
+	query := url.Values{
+		"response_type": {"code"},
+		"client_id":     {s.cfg.ClientID},
+		"scope":         {oauthScopes},
+		"redirect_uri":  {s.redirectURL()},
+		"state":         {state},
+		"prompt":        {"none"},
+	}
+	return authorizeURL + "?" + query.Encode()
+}
+
+// exchange trades an authorization code for an access token
+func (s *Service) exchange(ctx context.Context, code string) (string, error) {
+	form := url.Values{
+		"grant_type":   {"authorization_code"},
+		"code":         {code},
+		"redirect_uri": {s.redirectURL()},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, discordAPI+"/oauth2/token", strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(s.cfg.ClientID, s.cfg.ClientSecret)
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := s.do(req, &token); err != nil {
+		return "", fmt.Errorf("failed to exchange the authorization code: %w", err)
+	}
+	return token.AccessToken, nil
+}
+
+// fetch reads a Discord API resource as the signed-in user
+func (s *Service) fetch(ctx context.Context, accessToken, path string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, discordAPI+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	if err := s.do(req, v); err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return nil
+}
+
+func (s *Service) do(req *http.Request, v interface{}) error {
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("discord returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// adminGuilds lists the guilds the user can manage that the bot is in
+func (s *Service) adminGuilds(guilds []discordGuild) []int64 {
+	var ids []int64
+	for _, g := range guilds {
+		permissions, _ := strconv.ParseInt(g.Permissions, 10, 64)
+		if !g.Owner && permissions&adminPermissions == 0 {
+			continue
+		}
+		if _, err := s.session.State.Guild(g.ID); err != nil {
+			continue
+		}
+		id, err := strconv.ParseInt(g.ID, 10, 64)
+		if err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
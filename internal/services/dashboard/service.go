@@ -0,0 +1,145 @@
+// Package dashboard serves a small web UI at /dashboard/ where guild admins,
+// signed in with Discord OAuth2, see their server's usage, change its answer
+// settings, browse its FAQ entries and review recent answers with the
+// feedback they got. Settings are saved through the same services as the
+// slash commands, so both stay in sync.
+package dashboard
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"time"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/repository"
+	"discord-tars/internal/server"
+	"discord-tars/internal/services/audit"
+	"discord-tars/internal/services/duplicates"
+	"discord-tars/internal/services/persona"
+	"discord-tars/internal/services/slo"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Config holds the OAuth2 application and session settings
+type Config struct {
+	ClientID     string
+	ClientSecret string
+	// PublicURL is the base URL users reach the HTTP server at, without a
+	// trailing slash
+	PublicURL     string
+	SessionSecret string
+	SessionTTL    time.Duration
+	// AnswerRetention is how long answers are kept; zero keeps them
+	AnswerRetention time.Duration
+}
+
+// Service serves the dashboard and keeps the answers it shows
+type Service struct {
+	cfg        Config
+	session    *discordgo.Session
+	answers    *repository.AnswerLogRepository
+	priority   *repository.PriorityRepository
+	persona    *persona.Service
+	duplicates *duplicates.Service
+	auditLog   *audit.Service
+	latency    *slo.Tracker
+	client     *http.Client
+	pages      *template.Template
+	live       *liveFeed
+}
+
+func NewService(cfg Config, session *discordgo.Session, answers *repository.AnswerLogRepository, priority *repository.PriorityRepository, personaSvc *persona.Service) (*Service, error) {
+	if cfg.ClientID == "" || cfg.ClientSecret == "" || cfg.PublicURL == "" || len(cfg.SessionSecret) < 32 {
+		return nil, fmt.Errorf("the dashboard needs OAuth2 credentials, a public URL and a session secret of at least 32 characters")
+	}
+	if cfg.SessionTTL <= 0 {
+		cfg.SessionTTL = 12 * time.Hour
+	}
+	pages, err := parsePages()
+	if err != nil {
+		return nil, err
+	}
+	return &Service{
+		cfg:      cfg,
+		session:  session,
+		answers:  answers,
+		priority: priority,
+		persona:  personaSvc,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		pages:    pages,
+		live:     newLiveFeed(),
+	}, nil
+}
+
+// SetDuplicateService lets admins opt in to linking repeated questions
+func (s *Service) SetDuplicateService(duplicateService *duplicates.Service) {
+	s.duplicates = duplicateService
+}
+
+// SetAuditLog records settings changed in the dashboard alongside commands
+func (s *Service) SetAuditLog(auditLog *audit.Service) {
+	s.auditLog = auditLog
+}
+
+// SetLatencyTracker shows the commands run in the last hour
+func (s *Service) SetLatencyTracker(latency *slo.Tracker) {
+	s.latency = latency
+}
+
+// Register mounts the dashboard on the HTTP server
+func (s *Service) Register(srv *server.Server) {
+	srv.HandleFunc("GET /dashboard/{$}", s.handleIndex)
+	srv.HandleFunc("GET /dashboard/login", s.handleLogin)
+	srv.HandleFunc("GET /dashboard/callback", s.handleCallback)
+	srv.HandleFunc("POST /dashboard/logout", s.handleLogout)
+	srv.HandleFunc("GET /dashboard/guilds/{id}", s.guildHandler(s.handleGuild))
+	srv.HandleFunc("POST /dashboard/guilds/{id}/settings", s.guildHandler(s.handleSettings))
+	srv.HandleFunc("GET /dashboard/guilds/{id}/faq", s.guildHandler(s.handleFAQ))
+	srv.HandleFunc("GET /dashboard/guilds/{id}/answers", s.guildHandler(s.handleAnswers))
+	srv.HandleFunc("GET /dashboard/guilds/{id}/live", s.guildHandler(s.handleLivePage))
+	srv.HandleFunc("GET /dashboard/guilds/{id}/live/feed", s.guildHandler(s.handleLive))
+	srv.Handle("GET /dashboard/static/", http.StripPrefix("/dashboard/", http.FileServerFS(staticFiles)))
+}
+
+// RecordAnswer keeps an answer for review
+func (s *Service) RecordAnswer(ctx context.Context, entry *models.AnswerLog) error {
+	return s.answers.Add(ctx, entry)
+}
+
+// Feedback counts a 👍 or 👎 added to (delta 1) or removed from (delta -1)
+// an answer, and shows it on the live feed; other reactions and reactions
+// to other messages are ignored
+func (s *Service) Feedback(ctx context.Context, guildID, messageID int64, emoji string, delta int) error {
+	var positive, negative int
+	feedback := "positive"
+	switch emoji {
+	case "👍":
+		positive = delta
+	case "👎":
+		negative, feedback = delta, "negative"
+	default:
+		return nil
+	}
+	answered, err := s.answers.AddFeedback(ctx, messageID, positive, negative)
+	if err != nil || !answered {
+		return err
+	}
+	s.Publish(LiveEvent{Type: LiveFeedback, GuildID: guildID, Feedback: feedback, Delta: delta})
+	return nil
+}
+
+// Prune is the scheduler job deleting answers past the retention
+func (s *Service) Prune(ctx context.Context) error {
+	if s.cfg.AnswerRetention <= 0 {
+		return nil
+	}
+	pruned, err := s.answers.Prune(ctx, time.Now().Add(-s.cfg.AnswerRetention))
+	if pruned > 0 {
+		log.Printf("🧹 Pruned %d answers kept for review", pruned)
+	}
+	return err
+}
@@ -0,0 +1,128 @@
+package dashboard
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	sessionCookie = "tars_session"
+	stateCookie   = "tars_oauth_state"
+	// stateTTL is how long a sign-in may take on Discord's side
+	stateTTL = 10 * time.Minute
+)
+
+// session is who is signed in, kept in a signed cookie
+type session struct {
+	UserID   int64   `json:"uid,string"`
+	Username string  `json:"name"`
+	Guilds   []int64 `json:"guilds"` // Those the user could manage at sign-in and the bot is in
+	Expires  int64   `json:"exp"`
+}
+
+// administers reports whether the user signed in as an admin of a guild
+func (sess *session) administers(guildID int64) bool {
+	return slices.Contains(sess.Guilds, guildID)
+}
+
+// sign authenticates a value with the session secret
+func (s *Service) sign(purpose, value string) string {
+	mac := hmac.New(sha256.New, []byte(s.cfg.SessionSecret))
+	mac.Write([]byte(purpose + "\n" + value))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (s *Service) setSession(w http.ResponseWriter, sess *session) error {
+	data, err := json.Marshal(sess)
+	if err != nil {
+		return err
+	}
+	value := base64.RawURLEncoding.EncodeToString(data)
+	s.setCookie(w, sessionCookie, value+"."+s.sign("session", value), time.Unix(sess.Expires, 0))
+	return nil
+}
+
+// readSession returns the signed-in user, if the cookie is valid and current
+func (s *Service) readSession(r *http.Request) (*session, bool) {
+	cookie, err := r.Cookie(sessionCookie)
+	if err != nil {
+		return nil, false
+	}
+	value, sig, ok := strings.Cut(cookie.Value, ".")
+	if !ok || !hmac.Equal([]byte(sig), []byte(s.sign("session", value))) {
+		return nil, false
+	}
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, false
+	}
+	var sess session
+	if err := json.Unmarshal(data, &sess); err != nil || time.Now().Unix() > sess.Expires {
+		return nil, false
+	}
+	return &sess, true
+}
+
+// csrfToken ties forms to the session they were served to
+func (s *Service) csrfToken(r *http.Request) string {
+	cookie, err := r.Cookie(sessionCookie)
+	if err != nil {
+		return ""
+	}
+	return s.sign("csrf", cookie.Value)
+}
+
+func (s *Service) validCSRF(r *http.Request) bool {
+	token := s.csrfToken(r)
+	return token != "" && hmac.Equal([]byte(r.PostFormValue("csrf")), []byte(token))
+}
+
+// newState starts a sign-in, remembering its OAuth2 state in a cookie
+func (s *Service) newState(w http.ResponseWriter) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	state := hex.EncodeToString(b)
+	s.setCookie(w, stateCookie, state, time.Now().Add(stateTTL))
+	return state, nil
+}
+
+// checkState reports whether a callback belongs to a sign-in started here
+func (s *Service) checkState(w http.ResponseWriter, r *http.Request) bool {
+	cookie, err := r.Cookie(stateCookie)
+	s.clearCookie(w, stateCookie)
+	state := r.URL.Query().Get("state")
+	return err == nil && state != "" && hmac.Equal([]byte(state), []byte(cookie.Value))
+}
+
+func (s *Service) setCookie(w http.ResponseWriter, name, value string, expires time.Time) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/dashboard/",
+		Expires:  expires,
+		HttpOnly: true,
+		Secure:   strings.HasPrefix(s.cfg.PublicURL, "https://"),
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+func (s *Service) clearCookie(w http.ResponseWriter, name string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Path:     "/dashboard/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   strings.HasPrefix(s.cfg.PublicURL, "https://"),
+		SameSite: http.SameSiteLaxMode,
+	})
+}
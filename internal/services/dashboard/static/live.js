@@ -0,0 +1,61 @@
+// Shows a guild's live events, read from the dashboard's WebSocket feed.
+// Events are written as text only, never as HTML.
+(function () {
+  "use strict";
+
+  var feed = document.getElementById("live-feed");
+  var status = document.getElementById("live-status");
+  var maxEntries = 100;
+  var retry = 1000;
+
+  function describe(event) {
+    switch (event.type) {
+      case "question":
+        return "Q: " + event.question;
+      case "command":
+        return "/" + event.command + " answered in " + event.latency_ms + " ms";
+      case "feedback":
+        var emoji = event.feedback === "positive" ? "👍" : "👎";
+        return emoji + (event.delta > 0 ? " added to" : " removed from") + " an answer";
+    }
+    return event.type;
+  }
+
+  function show(event) {
+    var empty = document.getElementById("live-empty");
+    if (empty) {
+      empty.remove();
+    }
+    var entry = document.createElement("div");
+    entry.className = "entry";
+    var at = document.createElement("span");
+    at.className = "muted";
+    at.textContent = new Date(event.at).toLocaleTimeString();
+    var text = document.createElement("p");
+    text.textContent = describe(event);
+    entry.append(at, text);
+    feed.prepend(entry);
+    while (feed.children.length > maxEntries) {
+      feed.lastElementChild.remove();
+    }
+  }
+
+  function connect() {
+    var scheme = location.protocol === "https:" ? "wss://" : "ws://";
+    var socket = new WebSocket(scheme + location.host + feed.dataset.feed);
+    socket.onopen = function () {
+      status.textContent = "Connected.";
+      retry = 1000;
+    };
+    socket.onmessage = function (message) {
+      show(JSON.parse(message.data));
+    };
+    socket.onclose = function () {
+      status.textContent = "Disconnected, reconnecting…";
+      setTimeout(connect, retry);
+      retry = Math.min(retry * 2, 30000);
+    };
+  }
+
+  connect();
+})();
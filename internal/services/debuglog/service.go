@@ -0,0 +1,271 @@
+// Package debuglog diagnoses pasted stack traces and log files: it finds
+// where the same error came up on the server before, reads how those threads
+// went on, and asks the AI for a diagnosis that cites the ones that solved it.
+package debuglog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"discord-tars/internal/injection"
+	"discord-tars/internal/interfaces"
+	"discord-tars/internal/models"
+	"discord-tars/internal/repository"
+	"discord-tars/internal/sanitize"
+)
+
+const (
+	// MaxFileBytes bounds the log files read from attachments
+	MaxFileBytes = 1 << 20
+	// maxExcerptChars is how much of a log the AI reads
+	maxExcerptChars = 6000
+	// maxSignatureChars is how much of the error is embedded to find it again
+	maxSignatureChars = 1500
+
+	occurrenceCandidates    = 20
+	occurrenceMinSimilarity = 0.45
+	maxOccurrences          = 4
+	// Replies posted within followUpWindow of an occurrence are read for how
+	// it was solved
+	followUpWindow   = 7 * 24 * time.Hour
+	maxFollowUps     = 8
+	maxFollowUpChars = 400
+
+	diagnosisMaxTokens = 900
+)
+
+const diagnosisSystemPrompt = `You are a senior engineer helping a developer community debug an error.
+You get an error log or stack trace, and earlier threads from the same Discord server where a similar error came up, numbered [1], [2]..., each with the replies that followed.
+Diagnose the error: name the failing component, the most likely root cause, and concrete steps to fix or investigate it.
+When an earlier thread shows how the same error was solved, say so and cite it by its number, e.g. "This was solved in [2] by pinning the driver version". Only cite threads that really are about the same error, and never invent a resolution a thread doesn't contain.
+If no thread solved it, rely on your own knowledge and say the server hasn't solved this one before.
+Keep it under 250 words, use short paragraphs or bullets, and put commands and code in backticks.`
+
+var ErrEmpty = errors.New("the log has no content")
+
+// downloadClient fetches log files from Discord's CDN
+var downloadClient = &http.Client{Timeout: 30 * time.Second}
+
+var (
+	// errorLine matches the lines of a log that report what went wrong
+	errorLine = regexp.MustCompile(`(?i)(error|exception|panic|fatal|traceback|caused by|failed|failure|segmentation fault|undefined|refused|denied|timed? ?out|cannot|can't|unable to|not found|\bE\d{4}\b)`)
+	// Parts of a log that differ between two occurrences of the same error
+	volatileParts = []*regexp.Regexp{
+		regexp.MustCompile(`\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:?\d{2})?`),
+		regexp.MustCompile(`\b\d{2}:\d{2}:\d{2}(\.\d+)?\b`),
+		regexp.MustCompile(`0x[0-9a-fA-F]+`),
+		regexp.MustCompile(`\b[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}\b`),
+		regexp.MustCompile(`\bgoroutine \d+\b`),
+		regexp.MustCompile(`:\d+(:\d+)?\b`),
+	}
+)
+
+type Service struct {
+	aiService interfaces.AIService
+	msgRepo   *repository.MessageRepository
+}
+
+func NewService(aiService interfaces.AIService, msgRepo *repository.MessageRepository) *Service {
+	return &Service{
+		aiService: aiService,
+		msgRepo:   msgRepo,
+	}
+}
+
+// Occurrence is an earlier message about the same error, with the replies
+// that followed it
+type Occurrence struct {
+	Message    models.Message
+	Author     string
+	Similarity float64
+	FollowUps  []models.SearchResult
+}
+
+// Diagnosis is the AI's reading of a log; Occurrences are numbered from 1 in
+// the order the text cites them
+type Diagnosis struct {
+	Text        string
+	Signature   string // The error lines the log was matched on
+	Occurrences []Occurrence
+}
+
+// Diagnose explains the error in a log, citing the guild's earlier threads
+// about it. readable tells which channels the requester may see; occurrences
+// elsewhere are neither read nor cited.
+func (s *Service) Diagnose(ctx context.Context, guildID int64, logText string, readable func(channelID int64) bool) (*Diagnosis, error) {
+	logText = strings.TrimSpace(strings.ReplaceAll(logText, "\r\n", "\n"))
+	if logText == "" {
+		return nil, ErrEmpty
+	}
+	signature := Signature(logText)
+
+	occurrences, err := s.findOccurrences(ctx, guildID, signature, readable)
+	if err != nil {
+		return nil, err
+	}
+
+	var prompt strings.Builder
+	// Anyone can paste a log or have posted in the threads
+	excerpt, _ := injection.Strip(sanitize.Context(Excerpt(logText)))
+	fmt.Fprintf(&prompt, "ERROR LOG:\n```\n%s\n```\n\n", excerpt)
+	if len(occurrences) == 0 {
+		prompt.WriteString("EARLIER THREADS: none found on this server.\n")
+	} else {
+		prompt.WriteString("EARLIER THREADS:\n\n")
+	}
+	for n, occurrence := range occurrences {
+		content, _ := injection.Strip(sanitize.Context(occurrence.Message.Content))
+		fmt.Fprintf(&prompt, "[%d] %s, %s:\n%s\n", n+1, occurrence.Author, occurrence.Message.Timestamp.Format("2 January 2006"), truncate(content, maxExcerptChars/4))
+		for _, reply := range occurrence.FollowUps {
+			text, _ := injection.Strip(sanitize.Context(reply.Message.Content))
+			fmt.Fprintf(&prompt, "  ↳ %s: %s\n", reply.User.Username, truncate(strings.Join(strings.Fields(text), " "), maxFollowUpChars))
+		}
+		prompt.WriteString("\n")
+	}
+
+	text, err := s.aiService.Complete(ctx, diagnosisSystemPrompt, prompt.String(), diagnosisMaxTokens)
+	if err != nil {
+		return nil, err
+	}
+	return &Diagnosis{Text: sanitize.Output(text), Signature: signature, Occurrences: occurrences}, nil
+}
+
+// findOccurrences finds the guild's earlier messages about an error and the
+// replies posted after each in its channel or thread
+func (s *Service) findOccurrences(ctx context.Context, guildID int64, signature string, readable func(channelID int64) bool) ([]Occurrence, error) {
+	embedding, err := s.aiService.GenerateEmbedding(ctx, signature)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate log embedding: %w", err)
+	}
+	results, err := s.msgRepo.SearchGuildMessages(ctx, guildID, embedding, occurrenceCandidates, occurrenceMinSimilarity)
+	if err != nil {
+		return nil, err
+	}
+
+	var occurrences []Occurrence
+	seenChannels := make(map[int64]bool)
+	for _, result := range results {
+		msg := result.Message
+		// One occurrence per thread; its replies cover the rest
+		if seenChannels[msg.ChannelID] || !readable(msg.ChannelID) {
+			continue
+		}
+		seenChannels[msg.ChannelID] = true
+
+		replies, err := s.msgRepo.GetFirstMessagesInRange(ctx, msg.ChannelID, msg.Timestamp, msg.Timestamp.Add(followUpWindow), maxFollowUps+1)
+		if err != nil {
+			return nil, err
+		}
+		followUps := make([]models.SearchResult, 0, len(replies))
+		for _, reply := range replies {
+			if reply.Message.ID != msg.ID && strings.TrimSpace(reply.Message.Content) != "" {
+				followUps = append(followUps, reply)
+			}
+		}
+		if len(followUps) > maxFollowUps {
+			followUps = followUps[:maxFollowUps]
+		}
+
+		occurrences = append(occurrences, Occurrence{Message: msg, Author: result.User.Username, Similarity: result.Similarity, FollowUps: followUps})
+		if len(occurrences) == maxOccurrences {
+			break
+		}
+	}
+	return occurrences, nil
+}
+
+// Signature keeps the lines of a log that say what went wrong, without the
+// timestamps, addresses and line numbers that differ between two runs, so the
+// same error is found however it was logged
+func Signature(logText string) string {
+	var lines []string
+	seen := make(map[string]bool)
+	size := 0
+	for _, line := range strings.Split(logText, "\n") {
+		if !errorLine.MatchString(line) {
+			continue
+		}
+		for _, part := range volatileParts {
+			line = part.ReplaceAllString(line, "")
+		}
+		line = strings.Join(strings.Fields(line), " ")
+		if line == "" || seen[line] {
+			continue
+		}
+		seen[line] = true
+		lines = append(lines, line)
+		if size += len(line) + 1; size >= maxSignatureChars {
+			break
+		}
+	}
+	if len(lines) == 0 {
+		// No line looks like an error; the end of a log usually says why it stopped
+		return truncateStart(logText, maxSignatureChars)
+	}
+	return truncate(strings.Join(lines, "\n"), maxSignatureChars)
+}
+
+// Excerpt is the part of a long log the AI reads: from just before the first
+// error line, or the end of the log when no line looks like an error
+func Excerpt(logText string) string {
+	if len(logText) <= maxExcerptChars {
+		return logText
+	}
+	loc := errorLine.FindStringIndex(logText)
+	if loc == nil {
+		return truncateStart(logText, maxExcerptChars)
+	}
+	// Keep a few lines of what led up to the error
+	start := loc[0]
+	for lines := 0; start > 0 && lines < 5; start-- {
+		if logText[start-1] == '\n' {
+			lines++
+		}
+	}
+	return truncate(logText[start:], maxExcerptChars)
+}
+
+// Download fetches a log file attached to a command, reading at most
+// MaxFileBytes of it
+func Download(ctx context.Context, url string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := downloadClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to download log file: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to download log file: cdn returned status %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, MaxFileBytes))
+	if err != nil {
+		return "", fmt.Errorf("failed to download log file: %w", err)
+	}
+	return strings.ToValidUTF8(string(data), ""), nil
+}
+
+// truncate keeps the start of text, up to limit bytes
+func truncate(text string, limit int) string {
+	if len(text) <= limit {
+		return text
+	}
+	return strings.ToValidUTF8(text[:limit], "")
+}
+
+// truncateStart keeps the end of text, up to limit bytes
+func truncateStart(text string, limit int) string {
+	if len(text) <= limit {
+		return text
+	}
+	return strings.ToValidUTF8(text[len(text)-limit:], "")
+}
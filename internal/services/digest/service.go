@@ -0,0 +1,226 @@
+package digest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/repository"
+	"discord-tars/internal/services/jobs"
+	"discord-tars/internal/services/outbox"
+	"discord-tars/internal/services/summarize"
+	"discord-tars/internal/tenant"
+)
+
+// maxDMLength keeps digests under Discord's 2000 character message limit
+const maxDMLength = 1900
+
+// JobKind is the job delivering one digest, when a job runner is set
+const JobKind = "digest"
+
+type Service struct {
+	digestRepo *repository.DigestRepository
+	summarizer *summarize.Service
+	session    *discordgo.Session
+	runner     *jobs.Runner
+	outbox     *outbox.Service
+}
+
+// deliveryJob is the payload of a digest job
+type deliveryJob struct {
+	SubscriptionID int64     `json:"subscription_id"`
+	Since          time.Time `json:"since"`
+	Until          time.Time `json:"until"`
+}
+
+func NewService(digestRepo *repository.DigestRepository, summarizer *summarize.Service, session *discordgo.Session) *Service {
+	return &Service{
+		digestRepo: digestRepo,
+		summarizer: summarizer,
+		session:    session,
+	}
+}
+
+// SetJobRunner delivers each due digest as its own job, retried on failure and
+// listed in /jobs, instead of inline; call it before the runner starts
+func (s *Service) SetJobRunner(runner *jobs.Runner, opts jobs.Options) {
+	s.runner = runner
+	runner.Register(JobKind, opts, s.runJob)
+}
+
+// SetOutbox delivers digests through the outbox, retrying failed DMs
+func (s *Service) SetOutbox(outbox *outbox.Service) {
+	s.outbox = outbox
+}
+
+// Subscribe validates and stores a digest subscription
+func (s *Service) Subscribe(ctx context.Context, sub *models.DigestSubscription) error {
+	if sub.Frequency != models.DigestDaily && sub.Frequency != models.DigestWeekly {
+		return fmt.Errorf("unknown digest frequency %q", sub.Frequency)
+	}
+	if sub.Hour < 0 || sub.Hour > 23 {
+		return fmt.Errorf("hour must be between 0 and 23")
+	}
+	if sub.Weekday < 0 || sub.Weekday > 6 {
+		return fmt.Errorf("weekday must be between 0 and 6")
+	}
+	if sub.Timezone == "" {
+		sub.Timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(sub.Timezone); err != nil {
+		return fmt.Errorf("unknown timezone %q", sub.Timezone)
+	}
+
+	return s.digestRepo.Subscribe(ctx, sub)
+}
+
+// Unsubscribe removes a subscription, reporting whether one existed
+func (s *Service) Unsubscribe(ctx context.Context, userID, channelID int64) (bool, error) {
+	return s.digestRepo.Unsubscribe(ctx, userID, channelID)
+}
+
+// List returns a user's subscriptions in a guild
+func (s *Service) List(ctx context.Context, userID, guildID int64) ([]models.DigestSubscription, error) {
+	return s.digestRepo.ListByUser(ctx, userID, guildID)
+}
+
+// DeliverDue is the scheduler job: it sends every digest whose local delivery time has passed
+func (s *Service) DeliverDue(ctx context.Context) error {
+	subs, err := s.digestRepo.ListAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for i := range subs {
+		sub := &subs[i]
+		since, due := dueWindow(sub, now)
+		if !due {
+			continue
+		}
+
+		if s.runner != nil {
+			job := deliveryJob{SubscriptionID: sub.ID, Since: since, Until: now}
+			if err := s.runner.Enqueue(ctx, JobKind, sub.GuildID, job); err != nil {
+				log.Printf("❌ Failed to queue digest %d: %v", sub.ID, err)
+				continue
+			}
+		} else if err := s.deliver(ctx, sub, since, now); err != nil {
+			log.Printf("❌ Failed to deliver digest %d to user %d: %v", sub.ID, sub.UserID, err)
+			continue
+		}
+
+		if err := s.digestRepo.MarkSent(ctx, sub.ID, now); err != nil {
+			log.Printf("❌ Failed to mark digest %d as sent: %v", sub.ID, err)
+		}
+	}
+	return nil
+}
+
+// runJob delivers the digest of a job, unless it was unsubscribed meanwhile
+func (s *Service) runJob(ctx context.Context, payload []byte) error {
+	var job deliveryJob
+	if err := json.Unmarshal(payload, &job); err != nil {
+		return fmt.Errorf("failed to decode digest job: %w", err)
+	}
+	sub, err := s.digestRepo.Get(ctx, job.SubscriptionID)
+	if err != nil || sub == nil {
+		return err
+	}
+	return s.deliver(ctx, sub, job.Since, job.Until)
+}
+
+func (s *Service) deliver(ctx context.Context, sub *models.DigestSubscription, since, until time.Time) error {
+	ctx = tenant.WithGuild(ctx, sub.GuildID)
+	summary, count, err := s.summarizer.SummarizeChannel(ctx, sub.ChannelID, since, until)
+	if err != nil {
+		return err
+	}
+
+	period := "today"
+	if sub.Frequency == models.DigestWeekly {
+		period = "this week"
+	}
+
+	var content string
+	if count == 0 {
+		content = fmt.Sprintf("📭 **Digest for <#%d>**\nNothing was posted %s. My humor circuits found that suspicious.", sub.ChannelID, period)
+	} else {
+		content = fmt.Sprintf("📰 **Digest for <#%d>** (%d messages %s)\n\n%s", sub.ChannelID, count, period, summary)
+	}
+	content = truncate(content, maxDMLength)
+
+	if s.outbox != nil {
+		if err := s.outbox.SendDM(ctx, "digest", sub.GuildID, sub.UserID, &discordgo.MessageSend{Content: content}); err != nil {
+			return err
+		}
+	} else {
+		dm, err := s.session.UserChannelCreate(strconv.FormatInt(sub.UserID, 10))
+		if err != nil {
+			return fmt.Errorf("failed to open DM channel: %w", err)
+		}
+		if _, err := s.session.ChannelMessageSend(dm.ID, content); err != nil {
+			return fmt.Errorf("failed to send digest DM: %w", err)
+		}
+	}
+
+	log.Printf("📰 Delivered %s digest of channel %d to user %d", sub.Frequency, sub.ChannelID, sub.UserID)
+	return nil
+}
+
+// dueWindow reports whether a subscription is due at now and the start of the
+// period it should cover. Delivery happens at the first check after the
+// subscriber's local delivery hour.
+func dueWindow(sub *models.DigestSubscription, now time.Time) (time.Time, bool) {
+	loc, err := time.LoadLocation(sub.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+
+	local := now.In(loc)
+	scheduled := time.Date(local.Year(), local.Month(), local.Day(), sub.Hour, 0, 0, 0, loc)
+	period := 24 * time.Hour
+
+	if sub.Frequency == models.DigestWeekly {
+		period = 7 * 24 * time.Hour
+		offset := (int(local.Weekday()) - sub.Weekday + 7) % 7
+		scheduled = scheduled.AddDate(0, 0, -offset)
+	}
+	if scheduled.After(local) {
+		scheduled = scheduled.Add(-period)
+	}
+
+	// New subscriptions wait for their first scheduled slot instead of firing immediately
+	lastDelivery := sub.CreatedAt
+	if sub.LastSent != nil {
+		lastDelivery = *sub.LastSent
+	}
+	if !lastDelivery.Before(scheduled) {
+		return time.Time{}, false
+	}
+
+	since := scheduled.Add(-period)
+	if sub.LastSent != nil && sub.LastSent.After(since) {
+		since = *sub.LastSent
+	}
+	return since, true
+}
+
+// truncate cuts s to at most max bytes on a rune boundary, marking the cut
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	cut := max
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + "…"
+}
@@ -0,0 +1,94 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"discord-tars/internal/interfaces"
+	"discord-tars/internal/sanitize"
+	"discord-tars/internal/services/agent"
+	"discord-tars/internal/tenant"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// deepAskTimeout covers the agent's time budget plus writing the final answer
+const deepAskTimeout = 5 * time.Minute
+
+// handleDeepAsk answers with the multi-step agent, editing its progress into
+// the deferred response as it goes
+func (b *Bot) handleDeepAsk(s *discordgo.Session, i *discordgo.InteractionCreate, question, username string) {
+	if b.agentService == nil {
+		respondEphemeral(s, i, "🔧 Deep research is not enabled on this instance. Ask without `deep` instead.")
+		return
+	}
+	question, flagged := b.screenQuestion(i.GuildID, i.ChannelID, interactionUser(i), "question", question)
+	if flagged && question == "" {
+		respondEphemeral(s, i, injectionRefusal)
+		return
+	}
+
+	if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	}); err != nil {
+		log.Printf("❌ Failed to defer interaction: %v", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), deepAskTimeout)
+	defer cancel()
+	ctx = b.withPersona(tenant.WithGuild(ctx, parseSnowflake(i.GuildID)), i.GuildID)
+
+	header := fmt.Sprintf("🧭 **Researching:** %s\n", truncateText(question, 300))
+	var (
+		mu    sync.Mutex
+		steps []string
+	)
+	progress := func(step string) {
+		mu.Lock()
+		steps = append(steps, step)
+		content := header + strings.Join(steps, "\n")
+		mu.Unlock()
+		content = truncateText(content, 2000)
+		if _, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &content}); err != nil {
+			log.Printf("⚠️ Failed to post agent progress: %v", err)
+		}
+	}
+
+	var tools []interfaces.Tool
+	if b.ragService != nil {
+		tools = append(tools, agent.SearchTool(b.ragService, parseSnowflake(i.GuildID), parseSnowflake(i.ChannelID)))
+	}
+
+	result, err := b.agentService.Run(ctx, question, username, tools, progress)
+	if err != nil {
+		log.Printf("❌ Agent run failed: %v", err)
+		content := aiErrorMessage(err, "🔧 My research circuits failed partway through. Please try again later.")
+		s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &content})
+		return
+	}
+
+	footer := fmt.Sprintf("\n\n-# 🧭 Researched in %d step(s)", result.Steps)
+	if result.Exhausted {
+		footer += " · research budget reached"
+	}
+	result.Answer = sanitize.Output(result.Answer)
+	shown := truncateText(result.Answer, 2000-len(footer))
+	content := shown + footer
+	if _, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &content}); err != nil {
+		log.Printf("❌ Failed to edit interaction response: %v", err)
+		return
+	}
+
+	shown, cut := shownAnswer(shown, "", result.Answer)
+	b.attachFollowUps(s, i.Interaction, i.GuildID, []ConversationTurn{{Question: question, Answer: shown}}, cut)
+}
+
+// SetAgentService enables /ask deep
+func (b *Bot) SetAgentService(agentService *agent.Service) {
+	b.agentService = agentService
+}
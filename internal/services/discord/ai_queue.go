@@ -0,0 +1,66 @@
+package discord
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+
+	"discord-tars/internal/services/credentials"
+)
+
+// queueNotice shows a deferred interaction's place in the AI queue while its
+// request waits for a slot
+type queueNotice struct {
+	session     *discordgo.Session
+	interaction *discordgo.InteractionCreate
+
+	mu      sync.Mutex
+	latest  int
+	shown   int
+	stopped bool
+}
+
+// withQueueNotice returns a context whose AI requests report their queue
+// position in the interaction's deferred response. stop must be called before
+// the response is edited with the answer.
+func withQueueNotice(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) (context.Context, func()) {
+	n := &queueNotice{session: s, interaction: i}
+	return credentials.WithQueueUpdates(ctx, n.update), n.stop
+}
+
+// update records a new position; the limiter calls it with its lock held, so
+// the response is edited in the background
+func (n *queueNotice) update(position int) {
+	n.mu.Lock()
+	n.latest = position
+	n.mu.Unlock()
+	go n.show(position)
+}
+
+// show edits the response unless a newer position came in meanwhile
+func (n *queueNotice) show(position int) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.stopped || position != n.latest || position == n.shown {
+		return
+	}
+
+	content := tr(n.interaction, "ask.queued", position)
+	if position == 0 {
+		content = tr(n.interaction, "ask.thinking")
+	}
+	if _, err := n.session.InteractionResponseEdit(n.interaction.Interaction, &discordgo.WebhookEdit{Content: &content}); err != nil {
+		log.Printf("⚠️ Failed to show queue position: %v", err)
+		return
+	}
+	n.shown = position
+}
+
+// stop keeps edits in flight from overwriting the answer
+func (n *queueNotice) stop() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.stopped = true
+}
@@ -0,0 +1,284 @@
+package discord
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/services/announce"
+	"discord-tars/internal/tenant"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// announcePrefix routes the review buttons, as "announce:<action>:<draft ID>"
+	announcePrefix          = "announce"
+	announceEditModalPrefix = "announce-edit"
+)
+
+func announceCommand() *discordgo.ApplicationCommand {
+	return &discordgo.ApplicationCommand{
+		Name:        "announce",
+		Description: "Draft server announcements for moderator approval",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "draft",
+				Description: "Draft an announcement in the server's tone (moderators only)",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "topic",
+						Description: "What to announce, with any dates, links and details to include",
+						Required:    true,
+						MaxLength:   1000,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "channel",
+				Description: "Set where approved announcements are posted (admins only)",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:         discordgo.ApplicationCommandOptionChannel,
+						Name:         "channel",
+						Description:  "Announcement channel",
+						Required:     true,
+						ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText, discordgo.ChannelTypeGuildNews},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (b *Bot) handleAnnounceCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if b.announceService == nil {
+		respondEphemeral(s, i, "🔧 Announcement drafting is not enabled on this instance.")
+		return
+	}
+	if i.GuildID == "" {
+		respondEphemeral(s, i, "🔒 This command only works in a server.")
+		return
+	}
+
+	sub := i.ApplicationCommandData().Options[0]
+	opts := optionMap(sub.Options)
+	guildID := parseSnowflake(i.GuildID)
+
+	switch sub.Name {
+	case "channel":
+		if !isGuildAdmin(i) {
+			respondEphemeral(s, i, tr(i, "admin_only.announcements"))
+			return
+		}
+		channel := opts["channel"].ChannelValue(s)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := b.announceService.SetChannel(ctx, guildID, parseSnowflake(channel.ID)); err != nil {
+			log.Printf("❌ Failed to set announcement channel: %v", err)
+			respondEphemeral(s, i, "🔧 Failed to save the announcement channel. Please try again.")
+			return
+		}
+		respondEphemeral(s, i, fmt.Sprintf("📣 Approved announcements will be posted in <#%s>.", channel.ID))
+
+	case "draft":
+		if !isModerator(i) {
+			respondEphemeral(s, i, tr(i, "moderators_only.announce"))
+			return
+		}
+		b.draftAnnouncement(s, i, guildID, opts["topic"].StringValue())
+	}
+}
+
+// draftAnnouncement shows the requesting moderator a draft to approve, edit or reject
+func (b *Bot) draftAnnouncement(s *discordgo.Session, i *discordgo.InteractionCreate, guildID int64, topic string) {
+	if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Flags: discordgo.MessageFlagsEphemeral},
+	}); err != nil {
+		log.Printf("❌ Failed to defer interaction: %v", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(tenant.WithGuild(context.Background(), guildID), 60*time.Second)
+	defer cancel()
+
+	draft, err := b.announceService.Draft(ctx, guildID, parseSnowflake(interactionUser(i).ID), topic)
+	var edit *discordgo.WebhookEdit
+	switch {
+	case errors.Is(err, announce.ErrNoChannel):
+		content := "📣 No announcement channel is set yet. An admin can set one with `/announce channel`."
+		edit = &discordgo.WebhookEdit{Content: &content}
+	case err != nil:
+		log.Printf("❌ Failed to draft announcement: %v", err)
+		content := aiErrorMessage(err, "🔧 I couldn't draft that announcement. Please try again later.")
+		edit = &discordgo.WebhookEdit{Content: &content}
+	default:
+		review := draftReview(draft)
+		edit = &discordgo.WebhookEdit{Content: &review.Content, Embeds: &review.Embeds, Components: &review.Components}
+	}
+	if _, err := s.InteractionResponseEdit(i.Interaction, edit); err != nil {
+		log.Printf("❌ Failed to edit interaction response: %v", err)
+	}
+}
+
+// handleAnnounceButton approves, rejects or opens the editor for a draft
+func (b *Bot) handleAnnounceButton(s *discordgo.Session, i *discordgo.InteractionCreate, args []string) {
+	if b.announceService == nil || len(args) != 2 {
+		return
+	}
+	if !isModerator(i) {
+		respondEphemeral(s, i, tr(i, "moderators_only.announce"))
+		return
+	}
+	draftID, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return
+	}
+	guildID := parseSnowflake(i.GuildID)
+	reviewer := parseSnowflake(interactionUser(i).ID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var content string
+	switch args[0] {
+	case "edit":
+		draft, err := b.announceService.Pending(ctx, guildID, draftID)
+		if err != nil {
+			respondEphemeral(s, i, announceErrorMessage(err))
+			return
+		}
+		b.openAnnouncementEditor(s, i, draft)
+		return
+
+	case "approve":
+		draft, err := b.announceService.Approve(ctx, guildID, draftID, reviewer)
+		if err != nil {
+			respondEphemeral(s, i, announceErrorMessage(err))
+			return
+		}
+		content = fmt.Sprintf("✅ Posted in <#%d>: https://discord.com/channels/%d/%d/%d", draft.ChannelID, draft.GuildID, draft.ChannelID, draft.MessageID)
+
+	case "reject":
+		if err := b.announceService.Reject(ctx, guildID, draftID, reviewer); err != nil {
+			respondEphemeral(s, i, announceErrorMessage(err))
+			return
+		}
+		content = "🗑️ Draft rejected; nothing was posted."
+
+	default:
+		return
+	}
+
+	// Replace the review with the outcome, keeping the final text visible
+	var embeds []*discordgo.MessageEmbed
+	if i.Message != nil {
+		embeds = i.Message.Embeds
+	}
+	if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Content:    content,
+			Embeds:     embeds,
+			Components: []discordgo.MessageComponent{},
+		},
+	}); err != nil {
+		log.Printf("❌ Failed to update announcement review: %v", err)
+	}
+}
+
+// openAnnouncementEditor opens a form holding the draft's text
+func (b *Bot) openAnnouncementEditor(s *discordgo.Session, i *discordgo.InteractionCreate, draft *models.AnnouncementDraft) {
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseModal,
+		Data: &discordgo.InteractionResponseData{
+			CustomID: fmt.Sprintf("%s:%d", announceEditModalPrefix, draft.ID),
+			Title:    "Edit announcement",
+			Components: []discordgo.MessageComponent{
+				textInputRow(discordgo.TextInput{
+					CustomID:  "content",
+					Label:     "Announcement",
+					Style:     discordgo.TextInputParagraph,
+					Value:     draft.Content,
+					Required:  true,
+					MaxLength: announce.MaxLength,
+				}),
+			},
+		},
+	})
+	if err != nil {
+		log.Printf("❌ Failed to open announcement editor: %v", err)
+	}
+}
+
+// handleAnnounceEditSubmit saves an edited draft and shows it for review again
+func (b *Bot) handleAnnounceEditSubmit(s *discordgo.Session, i *discordgo.InteractionCreate, args []string, values map[string]string) {
+	if b.announceService == nil || len(args) != 1 {
+		return
+	}
+	if !isModerator(i) {
+		respondEphemeral(s, i, tr(i, "moderators_only.announce"))
+		return
+	}
+	draftID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	draft, err := b.announceService.Revise(ctx, parseSnowflake(i.GuildID), draftID, values["content"])
+	if err != nil {
+		respondEphemeral(s, i, announceErrorMessage(err))
+		return
+	}
+
+	// The form was opened from the review, which is updated in place
+	if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: draftReview(draft),
+	}); err != nil {
+		log.Printf("❌ Failed to update announcement review: %v", err)
+	}
+}
+
+// draftReview shows a draft with its Approve, Edit and Reject buttons
+func draftReview(draft *models.AnnouncementDraft) *discordgo.InteractionResponseData {
+	id := strconv.FormatInt(draft.ID, 10)
+	return &discordgo.InteractionResponseData{
+		Content: fmt.Sprintf("📣 **Announcement draft** for <#%d>. Nothing is posted until you approve it.", draft.ChannelID),
+		Embeds:  []*discordgo.MessageEmbed{{Description: draft.Content, Color: 0x5865F2}},
+		Components: []discordgo.MessageComponent{discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.Button{Label: "Approve", Style: discordgo.SuccessButton, CustomID: announcePrefix + ":approve:" + id},
+			discordgo.Button{Label: "Edit", Style: discordgo.SecondaryButton, CustomID: announcePrefix + ":edit:" + id},
+			discordgo.Button{Label: "Reject", Style: discordgo.DangerButton, CustomID: announcePrefix + ":reject:" + id},
+		}}},
+	}
+}
+
+func announceErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, announce.ErrNotFound):
+		return "📣 That draft no longer exists."
+	case errors.Is(err, announce.ErrNotPending):
+		return "📣 That draft was already posted or rejected."
+	case errors.Is(err, announce.ErrTooLong):
+		return fmt.Sprintf("📣 Announcements must fit in %d characters.", announce.MaxLength)
+	default:
+		log.Printf("❌ Announcement review failed: %v", err)
+		return "🔧 Something went wrong with that draft. Check that I can post in the announcement channel, then try again."
+	}
+}
+
+// SetAnnounceService enables /announce
+func (b *Bot) SetAnnounceService(announceService *announce.Service) {
+	b.announceService = announceService
+}
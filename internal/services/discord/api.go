@@ -0,0 +1,107 @@
+package discord
+
+import (
+	"context"
+	"strconv"
+
+	"discord-tars/internal/services/rag"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Ask answers a question from the HTTP API as in a guild's channel, with the
+// guild's persona and settings; channelID may be empty. Like Search, it only
+// draws on channels everyone in the guild can read, and channelID must be one.
+func (b *Bot) Ask(ctx context.Context, guildID, channelID, question, username string) (string, error) {
+	if channelID != "" && !everyoneCanRead(b.session, guildID, channelID) {
+		return "", rag.ErrNotVisible
+	}
+	ctx = rag.WithChannels(ctx, publicChannels(b.session, guildID))
+	return b.answerQuestion(ctx, question, username, guildID, channelID, Conversation{})
+}
+
+// Search finds a guild's messages for the HTTP API. Its callers aren't guild
+// members, so only channels everyone in the guild can read are searched.
+func (b *Bot) Search(ctx context.Context, guildID int64, query, order string, limit int) ([]rag.SearchHit, error) {
+	hits, err := b.ragService.Search(ctx, guildID, query, order)
+	if err != nil {
+		return nil, err
+	}
+	public := make(map[int64]bool)
+	var found []rag.SearchHit
+	for _, hit := range hits {
+		channelID := hit.Message.ChannelID
+		ok, checked := public[channelID]
+		if !checked {
+			ok = everyoneCanRead(b.session, strconv.FormatInt(guildID, 10), strconv.FormatInt(channelID, 10))
+			public[channelID] = ok
+		}
+		if !ok {
+			continue
+		}
+		found = append(found, hit)
+		if len(found) == limit {
+			break
+		}
+	}
+	return found, nil
+}
+
+// publicChannels lists the channels and active threads of a guild that
+// everyone in it can read
+func publicChannels(s *discordgo.Session, guildID string) []int64 {
+	guild, err := s.State.Guild(guildID)
+	if err != nil {
+		return nil
+	}
+	var ids []int64
+	for _, channels := range [][]*discordgo.Channel{guild.Channels, guild.Threads} {
+		for _, channel := range channels {
+			if everyoneCanRead(s, guildID, channel.ID) {
+				ids = append(ids, parseSnowflake(channel.ID))
+			}
+		}
+	}
+	return ids
+}
+
+// everyoneCanRead tells whether a channel belongs to a guild and the
+// @everyone role can read it
+func everyoneCanRead(s *discordgo.Session, guildID, channelID string) bool {
+	channel, err := s.State.Channel(channelID)
+	if err != nil {
+		if channel, err = s.Channel(channelID); err != nil {
+			return false
+		}
+	}
+	if channel.GuildID != guildID {
+		return false
+	}
+	if channel.IsThread() {
+		if channel.Type == discordgo.ChannelTypeGuildPrivateThread {
+			return false
+		}
+		if channel, err = s.State.Channel(channel.ParentID); err != nil {
+			return false
+		}
+	}
+	guild, err := s.State.Guild(guildID)
+	if err != nil {
+		return false
+	}
+
+	// The @everyone role has the guild's ID
+	var permissions int64
+	for _, role := range guild.Roles {
+		if role.ID == guildID {
+			permissions = role.Permissions
+		}
+	}
+	for _, overwrite := range channel.PermissionOverwrites {
+		if overwrite.ID == guildID {
+			permissions = permissions&^overwrite.Deny | overwrite.Allow
+		}
+	}
+	required := int64(discordgo.PermissionViewChannel | discordgo.PermissionReadMessageHistory)
+	return permissions&required == required
+}
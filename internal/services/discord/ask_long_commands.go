@@ -0,0 +1,91 @@
+package discord
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	askLongModalPrefix = "ask-long"
+	// Discord caps text inputs at 4000 characters
+	maxModalInputLength = 4000
+)
+
+func askLongCommand() *discordgo.ApplicationCommand {
+	return &discordgo.ApplicationCommand{
+		Name:        "ask-long",
+		Description: "Ask T.A.R.S a long or multi-line question in a form",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionBoolean,
+				Name:        "deep",
+				Description: "Research in several steps (server search, web, calculator); slower",
+			},
+		},
+	}
+}
+
+// handleAskLongCommand opens a form with room for a long question and any
+// text to consider along with it, such as logs or code
+func (b *Bot) handleAskLongCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	customID := askLongModalPrefix
+	if opt, ok := optionMap(i.ApplicationCommandData().Options)["deep"]; ok && opt.BoolValue() {
+		customID += ":deep"
+	}
+
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseModal,
+		Data: &discordgo.InteractionResponseData{
+			CustomID: customID,
+			Title:    tr(i, "ask_long.title"),
+			Components: []discordgo.MessageComponent{
+				textInputRow(discordgo.TextInput{
+					CustomID:  "question",
+					Label:     tr(i, "ask_long.question"),
+					Style:     discordgo.TextInputParagraph,
+					Required:  true,
+					MaxLength: maxModalInputLength,
+				}),
+				textInputRow(discordgo.TextInput{
+					CustomID:    "context",
+					Label:       tr(i, "ask_long.context"),
+					Placeholder: tr(i, "ask_long.context_placeholder"),
+					Style:       discordgo.TextInputParagraph,
+					MaxLength:   maxModalInputLength,
+				}),
+			},
+		},
+	})
+	if err != nil {
+		log.Printf("❌ Failed to open ask modal: %v", err)
+	}
+}
+
+func (b *Bot) handleAskLongSubmit(s *discordgo.Session, i *discordgo.InteractionCreate, args []string, values map[string]string) {
+	question := values["question"]
+	if question == "" {
+		respondEphemeral(s, i, tr(i, "ask_long.empty"))
+		return
+	}
+	username := interactionUser(i).Username
+
+	// The question isn't shown with the command like /ask options are, so
+	// quote its first line
+	firstLine, rest, _ := strings.Cut(question, "\n")
+	if rest != "" {
+		firstLine += " …"
+	}
+	header := fmt.Sprintf("> %s\n\n", truncateText(firstLine, 200))
+
+	if len(args) > 0 && args[0] == "deep" {
+		if attached := values["context"]; attached != "" {
+			question += "\n\nContext provided by the user:\n" + attached
+		}
+		b.handleDeepAsk(s, i, question, username)
+		return
+	}
+	b.answerInteraction(s, i, question, username, Conversation{Attached: values["context"]}, header, "")
+}
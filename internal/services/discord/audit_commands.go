@@ -0,0 +1,216 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/services/audit"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// auditListLimit keeps /audit recent within a Discord message
+	auditListLimit = 10
+	// maxAuditedValue caps stored option values, such as long questions
+	maxAuditedValue = 100
+)
+
+// redactedOptions hold secrets, by command path and option name
+var redactedOptions = map[string]bool{
+	"aikey set key":             true,
+	"docs add-notion token":     true,
+	"docs add-confluence token": true,
+	"tracker setup token":       true,
+	// Private calendar addresses embed their access key
+	"calendar add url": true,
+}
+
+var auditOutcomeIcons = map[string]string{
+	models.AuditOK:      "✅",
+	models.AuditDenied:  "🔒",
+	models.AuditError:   "❌",
+	models.AuditFlagged: "🛡️",
+}
+
+// commandOutcomes holds the outcome of each command being audited, by
+// interaction ID, from its arrival until it is recorded
+var commandOutcomes sync.Map
+
+func auditCommand() *discordgo.ApplicationCommand {
+	return &discordgo.ApplicationCommand{
+		Name:        "audit",
+		Description: "Review the commands run on this server (admins only)",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "recent",
+				Description: "List the latest commands run on this server",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionUser,
+						Name:        "user",
+						Description: "Only list this member's commands",
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "command",
+						Description: "Only list this command, e.g. digest or digest subscribe",
+					},
+				},
+			},
+		},
+	}
+}
+
+func (b *Bot) handleAuditCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if b.auditLog == nil {
+		respondEphemeral(s, i, "🔧 The audit log is not enabled on this instance.")
+		return
+	}
+	if i.GuildID == "" {
+		respondEphemeral(s, i, "🔒 This command only works in a server.")
+		return
+	}
+	if !isGuildAdmin(i) {
+		respondEphemeral(s, i, tr(i, "admin_only.audit"))
+		return
+	}
+
+	opts := optionMap(i.ApplicationCommandData().Options[0].Options)
+	var userID int64
+	if opt, ok := opts["user"]; ok {
+		userID = parseSnowflake(opt.UserValue(nil).ID)
+	}
+	command := ""
+	if opt, ok := opts["command"]; ok {
+		command = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(opt.StringValue())), "/")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	entries, err := b.auditLog.Recent(ctx, parseSnowflake(i.GuildID), userID, command, auditListLimit)
+	if err != nil {
+		log.Printf("❌ Failed to list audited commands: %v", err)
+		respondEphemeral(s, i, "🔧 Failed to read the audit log. Please try again.")
+		return
+	}
+	respondEphemeral(s, i, formatAuditEntries(entries))
+}
+
+func formatAuditEntries(entries []models.CommandAudit) string {
+	if len(entries) == 0 {
+		return "📭 No matching commands in the audit log."
+	}
+
+	var sb strings.Builder
+	sb.WriteString("📜 **Recent commands**\n")
+	for _, entry := range entries {
+		sb.WriteString(fmt.Sprintf("%s <t:%d:R> <@%d> `%s`", auditOutcomeIcons[entry.Outcome], entry.CreatedAt.Unix(), entry.UserID, entry.Command))
+		if entry.Outcome != models.AuditFlagged {
+			sb.WriteString(fmt.Sprintf(" — %dms", entry.LatencyMS))
+		}
+		sb.WriteString("\n")
+		if entry.Options != "" {
+			sb.WriteString("  ↳ " + truncateText(entry.Options, 80) + "\n")
+		}
+	}
+	return sb.String()
+}
+
+// auditing starts tracking the outcome of a command when the audit log is on
+func (b *Bot) auditing(i *discordgo.InteractionCreate) bool {
+	if b.auditLog == nil || i.Type != discordgo.InteractionApplicationCommand {
+		return false
+	}
+	commandOutcomes.Store(i.ID, models.AuditOK)
+	return true
+}
+
+// recordCommand adds a command that was handled to the audit log
+func (b *Bot) recordCommand(i *discordgo.InteractionCreate, start time.Time) {
+	outcome, _ := commandOutcomes.LoadAndDelete(i.ID)
+	user := interactionUser(i)
+	if user == nil {
+		return
+	}
+	command, options := auditedInvocation(i.ApplicationCommandData())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	b.auditLog.Record(ctx, &models.CommandAudit{
+		GuildID:   parseSnowflake(i.GuildID),
+		UserID:    parseSnowflake(user.ID),
+		Command:   command,
+		Options:   options,
+		LatencyMS: time.Since(start).Milliseconds(),
+		Outcome:   outcome.(string),
+	})
+}
+
+// noteOutcome classifies an audited command by its response, which follows
+// the usual markers: 🔒 when the user may not run it, 🔧 when it failed
+func noteOutcome(i *discordgo.InteractionCreate, content string) {
+	outcome := ""
+	switch {
+	case strings.HasPrefix(content, "🔒"):
+		outcome = models.AuditDenied
+	case strings.HasPrefix(content, "🔧"):
+		outcome = models.AuditError
+	default:
+		return
+	}
+	commandOutcomes.CompareAndSwap(i.ID, models.AuditOK, outcome)
+}
+
+// auditedInvocation returns a command's full name, with its subcommands, and
+// its options as "name:value" pairs with secrets redacted
+func auditedInvocation(data discordgo.ApplicationCommandInteractionData) (string, string) {
+	path := data.Name
+	options := data.Options
+	for len(options) == 1 && (options[0].Type == discordgo.ApplicationCommandOptionSubCommand ||
+		options[0].Type == discordgo.ApplicationCommandOptionSubCommandGroup) {
+		path += " " + options[0].Name
+		options = options[0].Options
+	}
+
+	var pairs []string
+	if data.TargetID != "" {
+		// Message and user commands act on a target instead of taking options
+		pairs = append(pairs, "target:"+data.TargetID)
+	}
+	for _, opt := range options {
+		pairs = append(pairs, opt.Name+":"+auditedValue(path+" "+opt.Name, opt))
+	}
+	return path, strings.Join(pairs, " ")
+}
+
+func auditedValue(key string, opt *discordgo.ApplicationCommandInteractionDataOption) string {
+	if redactedOptions[key] {
+		return "[redacted]"
+	}
+	switch opt.Type {
+	case discordgo.ApplicationCommandOptionChannel:
+		return fmt.Sprintf("<#%v>", opt.Value)
+	case discordgo.ApplicationCommandOptionUser:
+		return fmt.Sprintf("<@%v>", opt.Value)
+	case discordgo.ApplicationCommandOptionRole:
+		return fmt.Sprintf("<@&%v>", opt.Value)
+	case discordgo.ApplicationCommandOptionInteger:
+		return fmt.Sprintf("%d", opt.IntValue())
+	case discordgo.ApplicationCommandOptionString:
+		return truncateText(strings.Join(strings.Fields(opt.StringValue()), " "), maxAuditedValue)
+	default:
+		return fmt.Sprintf("%v", opt.Value)
+	}
+}
+
+// SetAuditLog records every command in the audit log and enables /audit
+func (b *Bot) SetAuditLog(service *audit.Service) {
+	b.auditLog = service
+}
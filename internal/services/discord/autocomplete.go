@@ -0,0 +1,82 @@
+package discord
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"discord-tars/internal/services/suggest"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Discord shows at most 25 autocomplete choices and drops answers after 3 seconds
+const (
+	maxAutocompleteChoices = 25
+	autocompleteTimeout    = 2 * time.Second
+)
+
+// onAutocomplete routes autocomplete requests by command name
+func (b *Bot) onAutocomplete(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	data := i.ApplicationCommandData()
+
+	var choices []*discordgo.ApplicationCommandOptionChoice
+	switch data.Name {
+	case "ask":
+		choices = b.askSuggestions(i, data.Options)
+	case "search":
+		choices = b.languageSuggestions(i, data.Options)
+	default:
+		log.Printf("❌ Unknown autocomplete command: %s", data.Name)
+	}
+
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionApplicationCommandAutocompleteResult,
+		Data: &discordgo.InteractionResponseData{Choices: choices},
+	})
+	if err != nil {
+		log.Printf("❌ Failed to send autocomplete choices: %v", err)
+	}
+}
+
+// askSuggestions offers FAQ entries and the server's popular questions
+func (b *Bot) askSuggestions(i *discordgo.InteractionCreate, options []*discordgo.ApplicationCommandInteractionDataOption) []*discordgo.ApplicationCommandOptionChoice {
+	if b.suggestService == nil || i.GuildID == "" {
+		return nil
+	}
+	var typed string
+	for _, opt := range options {
+		if opt.Focused && opt.Name == "question" {
+			typed = opt.StringValue()
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), autocompleteTimeout)
+	defer cancel()
+	questions := b.suggestService.Suggest(ctx, parseSnowflake(i.GuildID), typed, maxAutocompleteChoices)
+
+	choices := make([]*discordgo.ApplicationCommandOptionChoice, 0, len(questions))
+	for _, question := range questions {
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: question, Value: question})
+	}
+	return choices
+}
+
+// recordQuestion counts an /ask question towards the server's popular ones
+func (b *Bot) recordQuestion(guildID, question string) {
+	if b.suggestService == nil || guildID == "" {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := b.suggestService.Record(ctx, parseSnowflake(guildID), question); err != nil {
+			log.Printf("⚠️ %v", err)
+		}
+	}()
+}
+
+// SetSuggestService enables /ask autocomplete
+func (b *Bot) SetSuggestService(suggestService *suggest.Service) {
+	b.suggestService = suggestService
+}
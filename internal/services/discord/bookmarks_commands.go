@@ -0,0 +1,116 @@
+package discord
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/services/bookmarks"
+	"discord-tars/internal/tenant"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func bookmarksCommand() *discordgo.ApplicationCommand {
+	return &discordgo.ApplicationCommand{
+		Name:        "bookmarks",
+		Description: "Search the messages you bookmarked",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "search",
+				Description: "Find bookmarks about something, even in other words",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "query",
+						Description: "What you're looking for",
+						Required:    true,
+					},
+				},
+			},
+		},
+	}
+}
+
+func (b *Bot) handleBookmarksCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if b.bookmarkService == nil {
+		respondEphemeral(s, i, "🔧 Bookmarks are not enabled on this instance.")
+		return
+	}
+
+	sub := i.ApplicationCommandData().Options[0]
+	query := optionMap(sub.Options)["query"].StringValue()
+
+	ctx, cancel := context.WithTimeout(tenant.WithGuild(context.Background(), parseSnowflake(i.GuildID)), 15*time.Second)
+	defer cancel()
+
+	results, err := b.bookmarkService.Search(ctx, parseSnowflake(interactionUser(i).ID), query)
+	if err != nil {
+		log.Printf("❌ Failed to search bookmarks: %v", err)
+		respondEphemeral(s, i, aiErrorMessage(err, tr(i, "bookmarks.search_failed")))
+		return
+	}
+	if len(results) == 0 {
+		respondEphemeral(s, i, tr(i, "bookmarks.none"))
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString(tr(i, "bookmarks.found", truncateText(query, 100)) + "\n")
+	for n, result := range results {
+		bookmark := result.Bookmark
+		fmt.Fprintf(&sb, "**%d.** %s\n-# %s · <t:%d:d> · [↗](%s) · %.0f%%\n", n+1,
+			truncateText(strings.Join(strings.Fields(bookmark.Content), " "), 300),
+			bookmark.AuthorName, bookmark.PostedAt.Unix(), bookmarkLink(&bookmark), result.Similarity*100)
+	}
+	respondEphemeral(s, i, truncateText(sb.String(), 2000))
+}
+
+// bookmarkMessage saves the right-clicked message to the user's collection
+func (b *Bot) bookmarkMessage(ctx context.Context, i *discordgo.InteractionCreate, target *discordgo.Message) string {
+	content := messageText(target)
+	for _, a := range target.Attachments {
+		content = strings.TrimSpace(content + "\n[attachment: " + a.Filename + "]")
+	}
+	if content == "" {
+		return tr(i, "message_action.empty")
+	}
+
+	err := b.bookmarkService.Save(ctx, &models.Bookmark{
+		UserID:     parseSnowflake(interactionUser(i).ID),
+		MessageID:  parseSnowflake(target.ID),
+		GuildID:    parseSnowflake(target.GuildID),
+		ChannelID:  parseSnowflake(target.ChannelID),
+		AuthorID:   parseSnowflake(target.Author.ID),
+		AuthorName: target.Author.Username,
+		Content:    content,
+		PostedAt:   target.Timestamp,
+	})
+	switch {
+	case errors.Is(err, bookmarks.ErrAlreadySaved):
+		return tr(i, "bookmarks.already_saved")
+	case err != nil:
+		log.Printf("❌ Failed to bookmark message: %v", err)
+		return aiErrorMessage(err, tr(i, "bookmarks.save_failed"))
+	}
+	return tr(i, "bookmarks.saved", messageLink(target))
+}
+
+// bookmarkLink is a jump link to a bookmarked message
+func bookmarkLink(bookmark *models.Bookmark) string {
+	guildID := "@me"
+	if bookmark.GuildID != 0 {
+		guildID = fmt.Sprint(bookmark.GuildID)
+	}
+	return fmt.Sprintf("https://discord.com/channels/%s/%d/%d", guildID, bookmark.ChannelID, bookmark.MessageID)
+}
+
+// SetBookmarkService enables the Bookmark message action and /bookmarks
+func (b *Bot) SetBookmarkService(bookmarkService *bookmarks.Service) {
+	b.bookmarkService = bookmarkService
+}
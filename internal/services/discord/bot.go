@@ -5,27 +5,150 @@ import (
 	"fmt"
 	"log"
 	"strings"
+	"sync"
 	"time"
 
+	"discord-tars/internal/events"
+	"discord-tars/internal/i18n"
+	"discord-tars/internal/injection"
 	"discord-tars/internal/interfaces"
+	"discord-tars/internal/leader"
+	"discord-tars/internal/mentions"
+	"discord-tars/internal/services/agent"
+	"discord-tars/internal/services/announce"
+	"discord-tars/internal/services/audit"
+	"discord-tars/internal/services/bookmarks"
+	"discord-tars/internal/services/calendar"
+	"discord-tars/internal/services/credentials"
+	"discord-tars/internal/services/dashboard"
+	"discord-tars/internal/services/debuglog"
+	"discord-tars/internal/services/digest"
+	"discord-tars/internal/services/duplicates"
+	"discord-tars/internal/services/feeds"
+	"discord-tars/internal/services/forums"
+	"discord-tars/internal/services/github"
+	"discord-tars/internal/services/helpdesk"
+	"discord-tars/internal/services/highlights"
+	"discord-tars/internal/services/incident"
+	"discord-tars/internal/services/jobs"
+	"discord-tars/internal/services/knowledge"
+	"discord-tars/internal/services/lore"
+	"discord-tars/internal/services/memory"
+	"discord-tars/internal/services/mood"
+	"discord-tars/internal/services/notes"
+	"discord-tars/internal/services/onboarding"
+	"discord-tars/internal/services/partitions"
+	"discord-tars/internal/services/persona"
+	"discord-tars/internal/services/poll"
+	"discord-tars/internal/services/quiz"
 	"discord-tars/internal/services/rag"
+	"discord-tars/internal/services/reminders"
+	"discord-tars/internal/services/rollout"
+	"discord-tars/internal/services/sandbox"
+	"discord-tars/internal/services/slo"
+	"discord-tars/internal/services/standup"
+	"discord-tars/internal/services/starters"
+	"discord-tars/internal/services/suggest"
+	"discord-tars/internal/services/summarize"
+	"discord-tars/internal/services/timezone"
+	"discord-tars/internal/services/tracker"
 	"discord-tars/internal/services/voice"
+	"discord-tars/internal/services/xp"
+	"discord-tars/internal/storage"
 
 	"github.com/bwmarrin/discordgo"
+	"golang.org/x/sync/singleflight"
 )
 
 type Bot struct {
-	session      *discordgo.Session
-	aiService    interfaces.AIService
-	ragService   *rag.Service
-	voiceService *voice.Service
-	config       BotConfig
-	commands     []*discordgo.ApplicationCommand
+	session           *discordgo.Session
+	aiService         interfaces.AIService
+	ragService        *rag.Service
+	voiceService      *voice.Service
+	digestService     *digest.Service
+	standupService    *standup.Service
+	pollService       *poll.Service
+	onboardingService *onboarding.Service
+	githubService     *github.Service
+	feedService       *feeds.Service
+	calendarService   *calendar.Service
+	trackerService    *tracker.Service
+	knowledgeService  *knowledge.Service
+	fileStore         storage.Store
+	credentialService *credentials.Service
+	agentService      *agent.Service
+	summarizeService  *summarize.Service
+	memoryService     *memory.Service
+	suggestService    *suggest.Service
+	duplicateService  *duplicates.Service
+	announceService   *announce.Service
+	personaService    *persona.Service
+	moodService       *mood.Service
+	toxicityWatcher   *mood.Watcher
+	highlightService  *highlights.Service
+	loreService       *lore.Service
+	quizService       *quiz.Service
+	xpService         *xp.Service
+	starterService    *starters.Service
+	incidentService   *incident.Service
+	noteService       *notes.Service
+	bookmarkService   *bookmarks.Service
+	debugLogService   *debuglog.Service
+	forumService      *forums.Service
+	helpdeskService   *helpdesk.Service
+	sandboxService    *sandbox.Service
+	rolloutService    *rollout.Service
+	dashboardService  *dashboard.Service
+	timezoneService   *timezone.Service
+	reminderService   *reminders.Service
+	partitionService  *partitions.Service
+	events            events.Bus
+	jobRunner         *jobs.Runner
+	auditLog          *audit.Service
+	injection         *injectionGuard
+	elector           *leader.Elector
+	latency           *slo.Tracker
+	conversations     *voiceConversations
+	config            BotConfig
+	commands          []*discordgo.ApplicationCommand
+	commandsMu        sync.Mutex // Registration runs on READY and on election
+	followUps         *followUpStore
+	listeners         *listenStore
+	reindexJobs       *reindexJobs
+	presence          *presence
+	gateway           *gateway
+	// inflight coalesces identical questions being answered at the same time;
+	// asking counts the askers of each, so the first is answered by name
+	inflight  singleflight.Group
+	askingMu  sync.Mutex
+	asking    map[string]int
+	responses *responseCache
 }
 
 type BotConfig struct {
 	Token   string
 	GuildID string
+	// ConfidenceThreshold is the minimum grounding score for answers about the
+	// server; zero disables the check
+	ConfidenceThreshold float64
+	// PresenceTemplates rotate through the bot's status every PresenceInterval;
+	// empty uses DefaultPresenceTemplates
+	PresenceTemplates []string
+	PresenceInterval  time.Duration
+	// ResponseCacheTTL is how long answers are reused for the same question and
+	// context; zero disables the cache. ResponseCacheSimilarity is how close
+	// (cosine) differently worded questions must be to share an answer.
+	ResponseCacheTTL        time.Duration
+	ResponseCacheSimilarity float64
+	// ExternalIndexing leaves embedding new messages to cmd/worker, which
+	// reads them from the Redis event bus
+	ExternalIndexing bool
+	// AllowedMentions is which mentions the bot's messages may ping
+	AllowedMentions mentions.Policy
+	// ForumSuggestions replies to new forum posts with similar earlier ones
+	ForumSuggestions bool
+	// StageTranscription lets stage organizers have stages transcribed
+	StageTranscription bool
 }
 
 func NewBot(config BotConfig, aiService interfaces.AIService, ragService *rag.Service, voiceService *voice.Service) (*Bot, error) {
@@ -33,6 +156,9 @@ func NewBot(config BotConfig, aiService interfaces.AIService, ragService *rag.Se
 	if err != nil {
 		return nil, fmt.Errorf("failed to create discord session: %w", err)
 	}
+	mentions.Enforce(session, config.AllowedMentions)
+	// Reconnects are handled by onDisconnect
+	session.ShouldReconnectOnError = false
 
 	bot := &Bot{
 		session:      session,
@@ -41,6 +167,14 @@ func NewBot(config BotConfig, aiService interfaces.AIService, ragService *rag.Se
 		voiceService: voiceService, // Added
 		config:       config,
 		commands:     make([]*discordgo.ApplicationCommand, 0),
+		followUps:    newFollowUpStore(),
+		listeners:    newListenStore(),
+		reindexJobs:  newReindexJobs(),
+		presence:     newPresence(config.PresenceTemplates, config.PresenceInterval),
+		gateway:      newGateway(),
+		responses:    newResponseCache(config.ResponseCacheTTL, config.ResponseCacheSimilarity),
+
+		conversations: newVoiceConversations(),
 	}
 
 	bot.setupHandlers()
@@ -52,14 +186,47 @@ func NewBot(config BotConfig, aiService interfaces.AIService, ragService *rag.Se
 func (b *Bot) setupHandlers() {
 	b.session.AddHandler(b.onReady)
 	b.session.AddHandler(b.onMessageCreate)
-	b.session.AddHandler(b.onSlashCommand)
+	b.session.AddHandler(b.onInteraction)
+	b.session.AddHandler(b.onGuildMemberAdd)
+	b.session.AddHandler(b.onChannelPinsUpdate)
+	b.session.AddHandler(b.onThreadUpdate)
+	b.session.AddHandler(b.onGuildCreate)
+	b.session.AddHandler(b.onGuildDelete)
+	b.session.AddHandler(b.onMessageReactionAdd)
+	b.session.AddHandler(b.onMessageReactionRemove)
+	b.session.AddHandler(b.onMessageReactionRemoveAll)
+	b.session.AddHandler(b.onConnect)
+	b.session.AddHandler(b.onDisconnect)
+	b.session.AddHandler(b.onResumed)
+	b.session.AddHandler(b.onScheduledEventCreate)
+	b.session.AddHandler(b.onScheduledEventUpdate)
+	b.session.AddHandler(b.onScheduledEventDelete)
+	b.session.AddHandler(b.onStageInstanceDelete)
+	if b.voiceService != nil {
+		// Keeps voice connections alive across server moves; gateway
+		// reconnects are handled in resync
+		b.session.AddHandler(b.voiceService.HandleVoiceStateUpdate)
+		b.session.AddHandler(b.voiceService.HandleVoiceServerUpdate)
+	}
 }
 
 func (b *Bot) setupIntents() {
-	b.session.Identify.Intents = discordgo.IntentsGuildMessages | discordgo.IntentsMessageContent | discordgo.IntentsGuildVoiceStates // Added voice states
+	b.session.Identify.Intents = discordgo.IntentsGuildMessages | discordgo.IntentsMessageContent | discordgo.IntentsGuildVoiceStates | // Added voice states
+		discordgo.IntentsGuildMembers | discordgo.IntentsDirectMessages | // Onboarding welcomes and DM answers
+		discordgo.IntentsGuilds | // Channel state and pin updates
+		discordgo.IntentsGuildMessageReactions | // Reactions rank messages in searches
+		discordgo.IntentsGuildScheduledEvents | // Announcing and answering about Discord events
+		discordgo.IntentsGuildEmojis // Keeps the emojis answers may use up to date
 }
 
 func (b *Bot) Start() error {
+	if b.events == nil {
+		b.events = events.NewMemoryBus(events.DefaultQueueSize)
+	}
+	if err := b.subscribeEvents(); err != nil {
+		return err
+	}
+
 	fmt.Println("🔌 Connecting to Discord...")
 	if err := b.session.Open(); err != nil {
 		return fmt.Errorf("failed to open discord connection: %w", err)
@@ -71,9 +238,11 @@ func (b *Bot) Start() error {
 
 func (b *Bot) Stop() error {
 	fmt.Println("👋 Shutting down Discord bot...")
+	close(b.presence.stop)
+	b.stopGateway()
 
-	// Clean up commands
-	if b.config.GuildID != "" {
+	// Clean up commands, unless other replicas are still serving them
+	if b.config.GuildID != "" && b.elector == nil {
 		for _, cmd := range b.commands {
 			err := b.session.ApplicationCommandDelete(b.session.State.User.ID, b.config.GuildID, cmd.ID)
 			if err != nil {
@@ -83,22 +252,32 @@ func (b *Bot) Stop() error {
 	}
 
 	// Disconnect from all voice channels
+	b.conversations.stopAll()
 	if b.voiceService != nil {
-		b.voiceService.DisconnectVoice(b.config.GuildID)
+		b.voiceService.DisconnectAll()
 	}
 
-	return b.session.Close()
+	if err := b.session.Close(); err != nil {
+		return err
+	}
+	// Lets subscribers finish the events already published
+	return b.events.Close()
 }
 
 func (b *Bot) onReady(s *discordgo.Session, event *discordgo.Ready) {
 	fmt.Printf("✅ Bot connected as %s#%s\n", event.User.Username, event.User.Discriminator)
 
-	if err := b.registerCommands(); err != nil {
-		log.Printf("❌ Failed to register commands: %v", err)
-		return
+	if b.leads() {
+		if err := b.registerCommands(); err != nil {
+			log.Printf("❌ Failed to register commands: %v", err)
+			return
+		}
 	}
 
-	s.UpdateGameStatus(0, "🤖 T.A.R.S Online | Humor: 75% | Try /ask or /join")
+	b.startPresence(s)
+	if b.gateway.newSession() {
+		b.resync(s)
+	}
 }
 
 func (b *Bot) registerCommands() error {
@@ -116,9 +295,23 @@ func (b *Bot) registerCommands() error {
 					Name:        "question",
 					Description: "Your question for T.A.R.S",
 					Required:    true,
+					// Suggests FAQ entries and popular questions
+					Autocomplete: true,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionBoolean,
+					Name:        "deep",
+					Description: "Research in several steps (server search, web, calculator); slower",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "verbosity",
+					Description: "How long the answer should be (default set by the server admins)",
+					Choices:     verbosityChoices(),
 				},
 			},
 		},
+		askLongCommand(),
 		{
 			Name:        "help",
 			Description: "Show T.A.R.S help information",
@@ -148,11 +341,84 @@ func (b *Bot) registerCommands() error {
 		{
 			Name:        "join",
 			Description: "Make T.A.R.S join your voice channel",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionBoolean,
+					Name:        "converse",
+					Description: "Listen and answer spoken questions aloud",
+					Required:    false,
+				},
+				{
+					Type:         discordgo.ApplicationCommandOptionChannel,
+					Name:         "captions",
+					Description:  "Text channel where live captions of what is said are posted",
+					Required:     false,
+					ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText, discordgo.ChannelTypeGuildVoice},
+				},
+				{
+					Type:         discordgo.ApplicationCommandOptionChannel,
+					Name:         "sync",
+					Description:  "Text channel mirroring transcripts and answers, searchable later",
+					Required:     false,
+					ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText, discordgo.ChannelTypeGuildVoice},
+				},
+			},
 		},
+		digestCommand(),
+		standupCommand(),
+		pollCommand(),
+		onboardingCommand(),
+		knowledgeCommand(),
+		githubCommand(),
+		feedCommand(),
+		calendarCommand(),
+		stageCommand(),
+		ticketCommand(),
+		trackerCommand(),
+		docsCommand(),
+		attachmentsCommand(),
+		aiKeyCommand(),
+		summarizeCommand(),
+		ragCommand(),
+		whoKnowsCommand(),
+		duplicatesCommand(),
+		announceCommand(),
+		personaCommand(),
+		moodCommand(),
+		toxicityCommand(),
+		searchCommand(),
+		highlightsCommand(),
+		jobsCommand(),
+		statusCommand(),
+		auditCommand(),
+		calcCommand(),
+		convertCommand(),
+		rollCommand(),
+		loreCommand(),
+		quizCommand(),
+		xpCommand(),
+		leaderboardCommand(),
+		rankCommand(),
+		startersCommand(),
+		incidentCommand(),
+		noteCommand(),
+		bookmarksCommand(),
+		debugLogCommand(),
+		similarPostsCommand(),
+		helpChannelsCommand(),
+		sandboxCommand(),
+		timezoneCommand(),
+		remindCommand(),
 	}
+	commands = append(commands, messageCommands()...)
+	commands = append(commands, userCommands()...)
 
 	// Register commands
+	b.commandsMu.Lock()
+	defer b.commandsMu.Unlock()
+	b.commands = b.commands[:0]
 	for _, cmd := range commands {
+		i18n.LocalizeCommand(cmd)
 		registeredCmd, err := b.session.ApplicationCommandCreate(b.session.State.User.ID, b.config.GuildID, cmd)
 		if err != nil {
 			return fmt.Errorf("failed to register command %s: %w", cmd.Name, err)
@@ -172,13 +438,17 @@ func (b *Bot) onMessageCreate(s *discordgo.Session, m *discordgo.MessageCreate)
 
 	fmt.Printf("📨 Message from %s: %s\n", m.Author.Username, m.Content)
 
-	// Process message for RAG indexing
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	// New members talk to the onboarding assistant by DM
+	if m.GuildID == "" && b.handleOnboardingDM(s, m) {
+		return
+	}
 
-	// Process message for RAG context
-	if err := b.ragService.ProcessMessage(ctx, m.Message); err != nil {
-		fmt.Printf("❌ Failed to process message for RAG: %v\n", err)
+	// Indexing, moderation and other consumers subscribe to the event bus
+	b.publish(events.NewMessageEvent(events.MessageCreated, m.Message))
+
+	// Questions dictated over several messages after "@TARS listen"
+	if b.handleListening(s, m) {
+		return
 	}
 
 	// Handle mentions
@@ -187,6 +457,9 @@ func (b *Bot) onMessageCreate(s *discordgo.Session, m *discordgo.MessageCreate)
 		return
 	}
 
+	// Point questions already answered at the earlier answer
+	b.linkDuplicate(s, m)
+
 	// Handle simple commands
 	b.handleSimpleCommands(s, m)
 }
@@ -196,7 +469,7 @@ func (b *Bot) handleSimpleCommands(s *discordgo.Session, m *discordgo.MessageCre
 
 	switch {
 	case content == "!ping":
-		s.ChannelMessageSend(m.ChannelID, "🏓 Pong! T.A.R.S is operational.")
+		b.sendReply(m.Message, &discordgo.MessageSend{Content: "🏓 Pong! T.A.R.S is operational."})
 
 	case content == "hello" || content == "hi" || content == "hey":
 		responses := []string{
@@ -206,10 +479,35 @@ func (b *Bot) handleSimpleCommands(s *discordgo.Session, m *discordgo.MessageCre
 		}
 		// Simple rotation based on user ID hash
 		index := len(m.Author.ID) % len(responses)
-		s.ChannelMessageSend(m.ChannelID, responses[index])
+		b.sendReply(m.Message, &discordgo.MessageSend{Content: responses[index]})
 
 	case strings.Contains(content, "how are you"):
-		s.ChannelMessageSend(m.ChannelID, "🤖 All systems operational. Humor level: 75%. Honesty level: 100%. Thanks for asking!")
+		b.sendReply(m.Message, &discordgo.MessageSend{Content: "🤖 All systems operational. Humor level: 75%. Honesty level: 100%. Thanks for asking!"})
+	}
+}
+
+func (b *Bot) onInteraction(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	defer b.observeLatency(i, time.Now())
+	if b.auditing(i) {
+		defer b.recordCommand(i, time.Now())
+	}
+
+	switch i.Type {
+	case discordgo.InteractionApplicationCommand:
+		switch i.ApplicationCommandData().CommandType {
+		case discordgo.MessageApplicationCommand:
+			b.handleMessageCommand(s, i)
+		case discordgo.UserApplicationCommand:
+			b.handleUserCommand(s, i)
+		default:
+			b.onSlashCommand(s, i)
+		}
+	case discordgo.InteractionMessageComponent:
+		b.onComponent(s, i)
+	case discordgo.InteractionModalSubmit:
+		b.onModalSubmit(s, i)
+	case discordgo.InteractionApplicationCommandAutocomplete:
+		b.onAutocomplete(s, i)
 	}
 }
 
@@ -221,12 +519,104 @@ func (b *Bot) onSlashCommand(s *discordgo.Session, i *discordgo.InteractionCreat
 		b.handlePingCommand(s, i)
 	case "ask":
 		b.handleAskCommand(s, i)
+	case "ask-long":
+		b.handleAskLongCommand(s, i)
 	case "help":
 		b.handleHelpCommand(s, i)
 	case "personality":
 		b.handlePersonalityCommand(s, i)
 	case "join":
 		b.handleJoinCommand(s, i)
+	case "digest":
+		b.handleDigestCommand(s, i)
+	case "standup":
+		b.handleStandupCommand(s, i)
+	case "poll":
+		b.handlePollCommand(s, i)
+	case "onboarding":
+		b.handleOnboardingCommand(s, i)
+	case "knowledge":
+		b.handleKnowledgeCommand(s, i)
+	case "github":
+		b.handleGitHubCommand(s, i)
+	case "feed":
+		b.handleFeedCommand(s, i)
+	case "calendar":
+		b.handleCalendarCommand(s, i)
+	case "stage":
+		b.handleStageCommand(s, i)
+	case "ticket":
+		b.handleTicketCommand(s, i)
+	case "tracker":
+		b.handleTrackerCommand(s, i)
+	case "docs":
+		b.handleDocsCommand(s, i)
+	case "attachments":
+		b.handleAttachmentsCommand(s, i)
+	case "aikey":
+		b.handleAIKeyCommand(s, i)
+	case "summarize":
+		b.handleSummarizeCommand(s, i)
+	case "rag":
+		b.handleRAGCommand(s, i)
+	case "whoknows":
+		b.handleWhoKnowsCommand(s, i)
+	case "duplicates":
+		b.handleDuplicatesCommand(s, i)
+	case "announce":
+		b.handleAnnounceCommand(s, i)
+	case "persona":
+		b.handlePersonaCommand(s, i)
+	case "mood":
+		b.handleMoodCommand(s, i)
+	case "toxicity":
+		b.handleToxicityCommand(s, i)
+	case "search":
+		b.handleSearchCommand(s, i)
+	case "highlights":
+		b.handleHighlightsCommand(s, i)
+	case "jobs":
+		b.handleJobsCommand(s, i)
+	case "status":
+		b.handleStatusCommand(s, i)
+	case "audit":
+		b.handleAuditCommand(s, i)
+	case "calc":
+		b.handleCalcCommand(s, i)
+	case "convert":
+		b.handleConvertCommand(s, i)
+	case "roll":
+		b.handleRollCommand(s, i)
+	case "lore":
+		b.handleLoreCommand(s, i)
+	case "quiz":
+		b.handleQuizCommand(s, i)
+	case "xp":
+		b.handleXPCommand(s, i)
+	case "leaderboard":
+		b.handleLeaderboardCommand(s, i)
+	case "rank":
+		b.handleRankCommand(s, i)
+	case "starters":
+		b.handleStartersCommand(s, i)
+	case "incident":
+		b.handleIncidentCommand(s, i)
+	case "note":
+		b.handleNoteCommand(s, i)
+	case "bookmarks":
+		b.handleBookmarksCommand(s, i)
+	case "debug-log":
+		b.handleDebugLogCommand(s, i)
+	case "similar-posts":
+		b.handleSimilarPostsCommand(s, i)
+	case "help-channels":
+		b.handleHelpChannelsCommand(s, i)
+	case "sandbox":
+		b.handleSandboxCommand(s, i)
+	case "timezone":
+		b.handleTimezoneCommand(s, i)
+	case "remind":
+		b.handleRemindCommand(s, i)
 	default:
 		log.Printf("❌ Unknown command: %s", commandName)
 	}
@@ -238,9 +628,7 @@ func (b *Bot) handlePingCommand(s *discordgo.Session, i *discordgo.InteractionCr
 	// Calculate latency
 	latency := time.Since(startTime)
 
-	response := fmt.Sprintf("🏓 Pong!\n🤖 T.A.R.S is operational\n⚡ Response time: %v\n📡 WebSocket latency: %v",
-		latency,
-		s.HeartbeatLatency())
+	response := tr(i, "ping.pong", latency, s.HeartbeatLatency())
 
 	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseChannelMessageWithSource,
@@ -251,9 +639,39 @@ func (b *Bot) handlePingCommand(s *discordgo.Session, i *discordgo.InteractionCr
 }
 
 func (b *Bot) handleAskCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	question := i.ApplicationCommandData().Options[0].StringValue()
+	opts := optionMap(i.ApplicationCommandData().Options)
+	question := opts["question"].StringValue()
 	username := i.Member.User.Username
 
+	// Attempts at prompt injection aren't worth suggesting to others
+	if _, suspicious := injection.Detect(question); b.injection == nil || !suspicious {
+		b.recordQuestion(i.GuildID, question)
+	}
+
+	if opt, ok := opts["deep"]; ok && opt.BoolValue() {
+		b.handleDeepAsk(s, i, question, username)
+		return
+	}
+	var verbosity persona.Verbosity
+	if opt, ok := opts["verbosity"]; ok {
+		verbosity, _ = persona.ParseVerbosity(opt.StringValue())
+	}
+	b.answerInteraction(s, i, question, username, Conversation{}, "", verbosity)
+}
+
+// answerInteraction answers a question in a deferred public reply; header is
+// shown above the answer but kept out of the conversation history. An empty
+// verbosity uses the guild's default.
+func (b *Bot) answerInteraction(s *discordgo.Session, i *discordgo.InteractionCreate, question, username string, history Conversation, header string, verbosity persona.Verbosity) {
+	question, flagged := b.screenQuestion(i.GuildID, i.ChannelID, interactionUser(i), "question", question)
+	if flagged && question == "" {
+		respondEphemeral(s, i, injectionRefusal)
+		return
+	}
+	if history.Attached != "" {
+		history.Attached, _ = b.screenQuestion(i.GuildID, i.ChannelID, interactionUser(i), "attachment", history.Attached)
+	}
+
 	// Send initial response to avoid timeout
 	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
@@ -266,48 +684,50 @@ func (b *Bot) handleAskCommand(s *discordgo.Session, i *discordgo.InteractionCre
 	// Get AI response with timeout
 	ctx, cancel := context.WithTimeout(context.Background(), 25*time.Second)
 	defer cancel()
+	ctx, stopNotice := withQueueNotice(ctx, s, i)
+	ctx, truncated := persona.WithTruncationReport(persona.WithVerbosity(ctx, verbosity))
 
-	response, err := b.aiService.GenerateResponse(ctx, question, username)
+	response, err := b.answerQuestion(ctx, question, username, i.GuildID, i.ChannelID, history)
+	stopNotice()
+	answered := err == nil
 	if err != nil {
 		log.Printf("❌ AI service error: %v", err)
-		response = "🔧 My circuits are experiencing difficulties. My humor setting might need adjustment. Please try again later."
+		response = aiErrorMessage(err, tr(i, "ask.failed"))
 	}
 
 	// Update the deferred response
-	_, err = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
-		Content: &response,
+	content := truncateText(header+response, 2000)
+	reply, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Content: &content,
 	})
 	if err != nil {
 		log.Printf("❌ Failed to edit interaction response: %v", err)
+		return
+	}
+
+	if answered {
+		b.noteAnswered(i.GuildID, reply.ID)
+		b.logAnswer(i.GuildID, interactionUser(i).ID, reply, question, response)
+		if history.empty() {
+			b.recordAnswer(i.GuildID, reply, question)
+		}
+		shown, cut := shownAnswer(content, header, response)
+		b.attachFollowUps(s, i.Interaction, i.GuildID, []ConversationTurn{{Question: question, Answer: shown}}, cut || truncated())
 	}
 }
 
 func (b *Bot) handleHelpCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	helpText := "🤖 **T.A.R.S - AI Assistant**\n\n" +
-		"**Available Commands:**\n" +
-		"`/ping` - Test bot responsiveness and latency\n" +
-		"`/ask <question>` - Ask me anything (powered by AI)\n" +
-		"`/help` - Show this help message\n" +
-		"`/personality [humor] [honesty]` - Adjust my personality settings\n" +
-		"`/join` - Make me join your voice channel\n\n" +
-		"**Direct Interaction:**\n" +
-		"• Mention me (@T.A.R.S) to chat naturally\n" +
-		"• Simple greetings like \"hello\" work too\n" +
-		"• Type `/ping` for a quick response test\n\n" +
-		"**About T.A.R.S:**\n" +
-		"I'm an AI assistant based on the T.A.R.S robot from Interstellar. My current settings:\n" +
-		"• Humor: 75% (adjustable)\n" +
-		"• Honesty: 100% (always)\n\n" +
-		"**Tips:**\n" +
-		"• I work best with specific questions\n" +
-		"• I can help with general knowledge, coding, science, and more\n" +
-		"• My responses are powered by advanced AI\n\n" +
-		"Built with ❤️ for the Discord community"
+	// An embed description fits twice as much as a message, which the
+	// translated help text needs; past that it continues in a second embed
+	var embeds []*discordgo.MessageEmbed
+	for _, page := range splitMessage(tr(i, "help.text"), 4096, 2) {
+		embeds = append(embeds, &discordgo.MessageEmbed{Description: page, Color: 0x5865F2})
+	}
 
 	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseChannelMessageWithSource,
 		Data: &discordgo.InteractionResponseData{
-			Content: helpText,
+			Embeds: embeds,
 		},
 	})
 }
@@ -331,18 +751,20 @@ func (b *Bot) handlePersonalityCommand(s *discordgo.Session, i *discordgo.Intera
 
 	// Update AI service personality
 	b.aiService.SetPersonality(humor, honesty)
+	b.setPresencePersonality(s, humor, honesty)
+	b.responses.clear()
 
 	// Create response based on settings
 	var response string
 	switch {
 	case humor == 0:
-		response = fmt.Sprintf("⚙️ Personality matrix updated:\n• Humor: %d%% (Disabled)\n• Honesty: %d%%\n\nHumor circuits offline. I will now communicate with maximum efficiency and zero entertainment value.", humor, honesty)
+		response = tr(i, "personality.off", humor, honesty)
 	case humor >= 90:
-		response = fmt.Sprintf("🎭 Personality matrix updated:\n• Humor: %d%% (Maximum!)\n• Honesty: %d%%\n\nWarning: Humor levels approaching critical mass. Dad jokes and puns may spontaneously occur. You've been warned! 😄", humor, honesty)
+		response = tr(i, "personality.max", humor, honesty)
 	case humor <= 25:
-		response = fmt.Sprintf("🤖 Personality matrix updated:\n• Humor: %d%% (Low)\n• Honesty: %d%%\n\nSwitching to serious mode. My witty remarks will be kept to a minimum.", humor, honesty)
+		response = tr(i, "personality.low", humor, honesty)
 	default:
-		response = fmt.Sprintf("🔧 Personality matrix updated:\n• Humor: %d%%\n• Honesty: %d%%\n\nOptimal settings configured. I'll maintain my characteristic blend of helpfulness and sarcasm.", humor, honesty)
+		response = tr(i, "personality.default", humor, honesty)
 	}
 
 	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
@@ -357,40 +779,41 @@ func (b *Bot) handleJoinCommand(s *discordgo.Session, i *discordgo.InteractionCr
 	// Get user’s voice state
 	guildID := i.GuildID
 	userID := i.Member.User.ID
-	var voiceChannelID string
 
 	// Find user’s voice channel
-	guild, err := s.State.Guild(guildID)
-	if err != nil {
+	if _, err := s.State.Guild(guildID); err != nil {
 		log.Printf("❌ Failed to get guild: %v", err)
 		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
 			Data: &discordgo.InteractionResponseData{
-				Content: "🔧 Failed to find server info. Please try again.",
+				Content: tr(i, "join.no_server"),
 			},
 		})
 		return
 	}
-
-	for _, vs := range guild.VoiceStates {
-		if vs.UserID == userID {
-			voiceChannelID = vs.ChannelID
-			break
-		}
-	}
+	voiceChannelID := userVoiceChannel(s, guildID, userID)
 
 	if voiceChannelID == "" {
 		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
 			Data: &discordgo.InteractionResponseData{
-				Content: "🎙️ You need to be in a voice channel to use this command!",
+				Content: tr(i, "join.not_in_voice"),
 			},
 		})
 		return
 	}
 
+	// Recording a stage needs the consent of whoever runs it
+	opts := optionMap(i.ApplicationCommandData().Options)
+	_, captions := opts["captions"]
+	_, mirrored := opts["sync"]
+	if (captions || mirrored) && isStage(s, voiceChannelID) && !isStageOrganizer(s, guildID, userID, voiceChannelID) {
+		respondEphemeral(s, i, tr(i, "join.stage_consent"))
+		return
+	}
+
 	// Defer response to avoid timeout
-	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
 	})
 	if err != nil {
@@ -406,7 +829,7 @@ func (b *Bot) handleJoinCommand(s *discordgo.Session, i *discordgo.InteractionCr
 	if err != nil {
 		log.Printf("❌ Failed to join voice channel: %v", err)
 		s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
-			Content: func() *string { s := "🔧 Failed to join voice channel. Please try again."; return &s }(),
+			Content: func() *string { s := tr(i, "join.failed"); return &s }(),
 		})
 		return
 	}
@@ -416,14 +839,35 @@ func (b *Bot) handleJoinCommand(s *discordgo.Session, i *discordgo.InteractionCr
 	if err != nil {
 		log.Printf("❌ Failed to speak: %v", err)
 		s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
-			Content: func() *string { s := "🔧 Joined channel but failed to speak. Check logs for details."; return &s }(),
+			Content: func() *string { s := tr(i, "join.speak_failed"); return &s }(),
 		})
 		return
 	}
 
+	// Listen only when asked to, since it means recording what is said
+	var listen voiceListenOptions
+	joined := tr(i, "join.joined")
+	if opt, ok := opts["converse"]; ok && opt.BoolValue() {
+		listen.answer = true
+		joined += "\n" + tr(i, "join.converse_on")
+	}
+	if opt, ok := opts["captions"]; ok {
+		listen.captionChannelID = opt.ChannelValue(s).ID
+		joined += "\n" + tr(i, "join.captions_on", listen.captionChannelID)
+	}
+	if opt, ok := opts["sync"]; ok {
+		listen.syncChannelID = opt.ChannelValue(s).ID
+		joined += "\n" + tr(i, "join.sync_on", listen.syncChannelID)
+	}
+	if listen.listening() {
+		go b.converse(b.conversations.start(guildID), guildID, listen)
+	} else {
+		b.conversations.stop(guildID)
+	}
+
 	// Send success message
 	s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
-		Content: func() *string { s := "🎙️ T.A.R.S has joined your voice channel!"; return &s }(),
+		Content: &joined,
 	})
 }
 
@@ -439,25 +883,163 @@ func (b *Bot) isBotMentioned(m *discordgo.MessageCreate) bool {
 func (b *Bot) handleMentionMessage(s *discordgo.Session, m *discordgo.MessageCreate) {
 	// Extract message content without mentions
 	content := b.cleanMentionsFromContent(m.Content, m.Mentions)
+	// Stickers don't show in the text, so the AI is told which were sent
+	for _, sticker := range m.StickerItems {
+		content = strings.TrimSpace(content + fmt.Sprintf(" [sticker: %s]", sticker.Name))
+	}
 	if content == "" {
 		content = "Hello! How can I help you?"
 	}
+	b.answerMention(s, m, content)
+}
 
-	// Show typing indicator
-	s.ChannelTyping(m.ChannelID)
+// answerMention answers a question a member asked by mentioning the bot
+func (b *Bot) answerMention(s *discordgo.Session, m *discordgo.MessageCreate, content string) {
+	content, flagged := b.screenQuestion(m.GuildID, m.ChannelID, m.Author, "question", content)
+	if flagged && content == "" {
+		b.sendReply(m.Message, &discordgo.MessageSend{Content: injectionRefusal})
+		return
+	}
+
+	// Show typing indicator, unless replies are held back
+	if !b.sandboxed(m.GuildID) {
+		s.ChannelTyping(m.ChannelID)
+	}
 
 	// Get AI response
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	response, err := b.aiService.GenerateResponse(ctx, content, m.Author.Username)
+	history := b.loadConversation(ctx, m.ChannelID)
+	response, err := b.answerQuestion(ctx, content, m.Author.Username, m.GuildID, m.ChannelID, history)
 	if err != nil {
 		fmt.Printf("❌ AI service error: %v\n", err)
-		s.ChannelMessageSend(m.ChannelID, "🔧 My circuits seem to be malfunctioning. Please try again later.")
+		b.sendReply(m.Message, &discordgo.MessageSend{Content: aiErrorMessage(err, "🔧 My circuits seem to be malfunctioning. Please try again later.")})
+		return
+	}
+
+	reply, err := b.sendReply(m.Message, &discordgo.MessageSend{Content: response})
+	if err != nil {
+		log.Printf("❌ Failed to send response: %v", err)
 		return
 	}
+	// A held back answer is neither linked to later nor followed up on
+	if reply == nil {
+		return
+	}
+	b.noteAnswered(m.GuildID, reply.ID)
+	b.logAnswer(m.GuildID, m.Author.ID, reply, content, response)
+	if history.empty() {
+		b.recordAnswer(m.GuildID, reply, content)
+	}
+	b.rememberExchange(m.GuildID, m.ChannelID, m.Author.Username, content, response)
+}
+
+const (
+	// sharedAsker is who an answer shared by identical questions is for
+	sharedAsker = "a member"
+	// sharedAnswerTimeout bounds a shared answer, which no caller's deadline
+	// bounds any more
+	sharedAnswerTimeout = 60 * time.Second
+)
 
-	s.ChannelMessageSend(m.ChannelID, response)
+// answerQuestion answers a question with retrieved server context and, when the
+// answer isn't backed by that context, says so instead of guessing. History
+// holds earlier exchanges when the question follows up on them.
+//
+// The first asker of a question is answered by name. Identical questions
+// asked in the channel while it is being answered, as happens after an
+// announcement, share one answer addressed to none of them. Follow-ups
+// don't, since their history differs, and neither do other channels, whose
+// recent messages go into the prompt and may be private.
+func (b *Bot) answerQuestion(ctx context.Context, question, username, guildID, channelID string, history Conversation) (answer string, err error) {
+	defer func() {
+		if err != nil {
+			b.recordQuality(guildID, rollout.SignalError)
+		} else {
+			b.recordQuality(guildID, rollout.SignalAnswer)
+		}
+	}()
+
+	if !history.empty() {
+		return b.generateAnswer(ctx, question, username, guildID, channelID, history)
+	}
+
+	key := guildID + "\x00" + channelID + "\x00" + string(persona.VerbosityFromContext(ctx)) + "\x00" + normalizeQuestion(question)
+	if rag.Limited(ctx) {
+		// Answers drawing on fewer channels are shared only among their askers
+		key += "\x00limited"
+	}
+	first := b.beginQuestion(key)
+	defer b.endQuestion(key)
+	if first {
+		return b.generateAnswer(ctx, question, username, guildID, channelID, history)
+	}
+
+	// The answer may go to several askers, so it is addressed to none of them,
+	// and the first of them giving up mustn't fail the others
+	shared := context.WithoutCancel(ctx)
+	result := b.inflight.DoChan(key, func() (interface{}, error) {
+		shared, cancel := context.WithTimeout(shared, sharedAnswerTimeout)
+		defer cancel()
+		return b.generateAnswer(shared, question, sharedAsker, guildID, channelID, history)
+	})
+	select {
+	case res := <-result:
+		if res.Err != nil {
+			return "", res.Err
+		}
+		if res.Shared {
+			log.Printf("🔗 Answered identical in-flight questions once in guild %s", guildID)
+		}
+		return res.Val.(string), nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// beginQuestion counts an asker of a question, reporting whether nobody else
+// is waiting for its answer
+func (b *Bot) beginQuestion(key string) bool {
+	b.askingMu.Lock()
+	defer b.askingMu.Unlock()
+	if b.asking == nil {
+		b.asking = make(map[string]int)
+	}
+	b.asking[key]++
+	return b.asking[key] == 1
+}
+
+func (b *Bot) endQuestion(key string) {
+	b.askingMu.Lock()
+	defer b.askingMu.Unlock()
+	if b.asking[key]--; b.asking[key] <= 0 {
+		delete(b.asking, key)
+	}
+}
+
+// normalizeQuestion reduces a question to what makes it the same as another:
+// case, spacing and trailing punctuation don't count
+func normalizeQuestion(question string) string {
+	question = strings.Join(strings.Fields(strings.ToLower(question)), " ")
+	return strings.TrimRight(question, " ?!.…")
+}
+
+// generateAnswer answers a question on its own; see answerQuestion
+func (b *Bot) generateAnswer(ctx context.Context, question, username, guildID, channelID string, history Conversation) (string, error) {
+	if guildID != "" {
+		// The guild's emojis come from the session's state, which the
+		// pipeline goes without
+		ctx = persona.WithEmojis(ctx, guildEmojis(b.session, guildID))
+	}
+	answer, err := b.pipeline().Answer(ctx, question, username, guildID, channelID, history)
+	if err != nil {
+		return "", err
+	}
+	if answer.Uncertain {
+		b.recordQuality(guildID, rollout.SignalUncertain)
+	}
+	return answer.Text, nil
 }
 
 func (b *Bot) cleanMentionsFromContent(content string, mentions []*discordgo.User) string {
@@ -0,0 +1,105 @@
+package discord
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"discord-tars/internal/services/agent"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func calcCommand() *discordgo.ApplicationCommand {
+	return &discordgo.ApplicationCommand{
+		Name:        "calc",
+		Description: "Calculate an expression, such as (12.5 * 4) / 3 or sqrt(2)",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "expression",
+				Description: "Expression to calculate; + - * / ^ %, parentheses, pi, e, sqrt, log, sin...",
+				Required:    true,
+				MaxLength:   500,
+			},
+		},
+	}
+}
+
+func convertCommand() *discordgo.ApplicationCommand {
+	return &discordgo.ApplicationCommand{
+		Name:        "convert",
+		Description: "Convert between units (5 km to mi, 72 F to C) or a time between timezones",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "value",
+				Description: "Number or expression to convert, or a time such as 18:30, 2024-05-14 18:30 or now",
+				Required:    true,
+				MaxLength:   100,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "from",
+				Description: "Unit (km, lb, F, GiB...) or timezone (Europe/Paris, PST, UTC+2)",
+				Required:    true,
+				MaxLength:   50,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "to",
+				Description: "Unit or timezone to convert to",
+				Required:    true,
+				MaxLength:   50,
+			},
+		},
+	}
+}
+
+// handleCalcCommand evaluates an expression with the same calculator the
+// model uses, so the result is exact rather than predicted
+func (b *Bot) handleCalcCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	expression := optionMap(i.ApplicationCommandData().Options)["expression"].StringValue()
+	result, err := agent.Evaluate(expression)
+	if err != nil {
+		respondEphemeral(s, i, tr(i, "calc.invalid", err))
+		return
+	}
+	respondText(s, i, tr(i, "calc.result", strings.ReplaceAll(expression, "`", ""), agent.FormatNumber(result)))
+}
+
+// handleConvertCommand converts a time between timezones when both ends are
+// timezones, and a value between units otherwise
+func (b *Bot) handleConvertCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	options := optionMap(i.ApplicationCommandData().Options)
+	value := options["value"].StringValue()
+	from, to := options["from"].StringValue(), options["to"].StringValue()
+
+	if _, err := agent.LoadZone(from); err == nil {
+		if _, err := agent.LoadZone(to); err == nil {
+			converted, err := agent.ConvertTime(value, from, to, time.Now())
+			if err != nil {
+				respondEphemeral(s, i, tr(i, "convert.invalid", err))
+				return
+			}
+			respondText(s, i, tr(i, "convert.time", converted.Format(agent.TimeLayout), converted.Unix()))
+			return
+		}
+	}
+
+	amount, err := agent.Evaluate(value)
+	if err != nil {
+		respondEphemeral(s, i, tr(i, "convert.invalid", err))
+		return
+	}
+	result, err := agent.Convert(amount, from, to)
+	if errors.Is(err, agent.ErrCurrency) {
+		respondEphemeral(s, i, tr(i, "convert.currency"))
+		return
+	}
+	if err != nil {
+		respondEphemeral(s, i, tr(i, "convert.invalid", err))
+		return
+	}
+	respondText(s, i, tr(i, "convert.result", agent.FormatNumber(amount), from, agent.FormatNumber(result), to))
+}
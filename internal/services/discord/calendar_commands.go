@@ -0,0 +1,323 @@
+package discord
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/services/calendar"
+	"discord-tars/internal/tenant"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func calendarCommand() *discordgo.ApplicationCommand {
+	minReminder := float64(1)
+
+	return &discordgo.ApplicationCommand{
+		Name:        "calendar",
+		Description: "Server calendars, upcoming events and reminders",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "add",
+				Description: "Attach an ICS calendar, e.g. a Google Calendar iCal address (admins only)",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "name",
+						Description: "Display name for the calendar",
+						Required:    true,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "url",
+						Description: "ICS or webcal URL",
+						Required:    true,
+					},
+					{
+						Type:         discordgo.ApplicationCommandOptionChannel,
+						Name:         "reminder_channel",
+						Description:  "Channel for event reminders (omit to disable reminders)",
+						ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText, discordgo.ChannelTypeGuildNews},
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "reminder_minutes",
+						Description: fmt.Sprintf("Minutes before an event to remind (default %d)", calendar.DefaultReminderMinutes),
+						MinValue:    &minReminder,
+						MaxValue:    7 * 24 * 60,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "discord",
+				Description: "Follow this server's Discord events, announcing new ones (admins only)",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:         discordgo.ApplicationCommandOptionChannel,
+						Name:         "reminder_channel",
+						Description:  "Channel announcing new events and reminding of them (omit to only answer about them)",
+						ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText, discordgo.ChannelTypeGuildNews},
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "reminder_minutes",
+						Description: fmt.Sprintf("Minutes before an event to remind (default %d)", calendar.DefaultReminderMinutes),
+						MinValue:    &minReminder,
+						MaxValue:    7 * 24 * 60,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "remove",
+				Description: "Detach a calendar (admins only)",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "id",
+						Description: "Calendar ID from /calendar list",
+						Required:    true,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "list",
+				Description: "Show attached calendars",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "upcoming",
+				Description: "Show the next events",
+			},
+		},
+	}
+}
+
+func (b *Bot) handleCalendarCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if b.calendarService == nil {
+		respondEphemeral(s, i, "🔧 Calendars are not enabled on this instance.")
+		return
+	}
+
+	sub := i.ApplicationCommandData().Options[0]
+	opts := optionMap(sub.Options)
+	guildID := parseSnowflake(i.GuildID)
+
+	if (sub.Name == "add" || sub.Name == "discord" || sub.Name == "remove") && !isGuildAdmin(i) {
+		respondEphemeral(s, i, tr(i, "admin_only.calendars"))
+		return
+	}
+
+	switch sub.Name {
+	case "add":
+		source := &models.CalendarSource{
+			GuildID:   guildID,
+			Name:      opts["name"].StringValue(),
+			URL:       opts["url"].StringValue(),
+			CreatedBy: parseSnowflake(interactionUser(i).ID),
+		}
+		if opt, ok := opts["reminder_channel"]; ok {
+			source.ReminderChannelID = parseSnowflake(opt.ChannelValue(s).ID)
+		}
+		if opt, ok := opts["reminder_minutes"]; ok {
+			source.ReminderMinutes = int(opt.IntValue())
+		}
+
+		if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Flags: discordgo.MessageFlagsEphemeral},
+		}); err != nil {
+			log.Printf("❌ Failed to defer interaction: %v", err)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+		defer cancel()
+
+		var content string
+		count, err := b.calendarService.AddSource(ctx, source)
+		switch {
+		case errors.Is(err, calendar.ErrInvalidCalendarURL):
+			content = "🔧 " + err.Error() + "."
+		case err != nil && source.ID == 0:
+			log.Printf("❌ Failed to add calendar: %v", err)
+			content = fmt.Sprintf("🔧 Could not add that calendar: %v", err)
+		case err != nil:
+			log.Printf("❌ Failed first calendar sync: %v", err)
+			content = fmt.Sprintf("⚠️ Calendar **%s** (#%d) was added but the first sync failed: %v", source.Name, source.ID, err)
+		default:
+			content = fmt.Sprintf("✅ Calendar **%s** (#%d) added with %d upcoming events.", source.Name, source.ID, count)
+			if source.ReminderChannelID != 0 {
+				content += fmt.Sprintf(" Reminders will be posted in <#%d> %d minutes ahead.", source.ReminderChannelID, source.ReminderMinutes)
+			}
+		}
+		s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &content})
+
+	case "discord":
+		source := &models.CalendarSource{
+			GuildID:   guildID,
+			CreatedBy: parseSnowflake(interactionUser(i).ID),
+		}
+		if opt, ok := opts["reminder_channel"]; ok {
+			source.ReminderChannelID = parseSnowflake(opt.ChannelValue(s).ID)
+		}
+		if opt, ok := opts["reminder_minutes"]; ok {
+			source.ReminderMinutes = int(opt.IntValue())
+		}
+
+		if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Flags: discordgo.MessageFlagsEphemeral},
+		}); err != nil {
+			log.Printf("❌ Failed to defer interaction: %v", err)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+		defer cancel()
+
+		var content string
+		count, err := b.calendarService.FollowDiscordEvents(ctx, source)
+		switch {
+		case errors.Is(err, calendar.ErrAlreadyFollowing):
+			content = "ℹ️ This server's Discord events are already followed. Remove them from `/calendar list` to change where they're announced."
+		case err != nil && source.ID == 0:
+			log.Printf("❌ Failed to follow Discord events: %v", err)
+			content = fmt.Sprintf("🔧 Could not follow the server's events: %v", err)
+		case err != nil:
+			log.Printf("❌ Failed first Discord events sync: %v", err)
+			content = fmt.Sprintf("⚠️ Discord events (#%d) are followed but the first sync failed: %v", source.ID, err)
+		default:
+			content = fmt.Sprintf("✅ Following this server's Discord events (#%d), %d scheduled. I'll answer questions like \"what's happening this week?\" from them.", source.ID, count)
+			if source.ReminderChannelID != 0 {
+				content += fmt.Sprintf(" New events will be announced in <#%d>, with a reminder %d minutes ahead.", source.ReminderChannelID, source.ReminderMinutes)
+			}
+		}
+		s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &content})
+
+	case "remove":
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		removed, err := b.calendarService.RemoveSource(ctx, guildID, opts["id"].IntValue())
+		if err != nil {
+			log.Printf("❌ Failed to remove calendar: %v", err)
+			respondEphemeral(s, i, "🔧 Failed to remove the calendar. Please try again.")
+			return
+		}
+		if !removed {
+			respondEphemeral(s, i, "ℹ️ No calendar with that ID exists in this server.")
+			return
+		}
+		respondEphemeral(s, i, "✅ Calendar removed.")
+
+	case "list":
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		sources, err := b.calendarService.ListSources(ctx, guildID)
+		if err != nil {
+			log.Printf("❌ Failed to list calendars: %v", err)
+			respondEphemeral(s, i, "🔧 Failed to load calendars. Please try again.")
+			return
+		}
+		if len(sources) == 0 {
+			respondEphemeral(s, i, "📅 No calendars are attached to this server yet.")
+			return
+		}
+		var sb strings.Builder
+		sb.WriteString("📅 **Calendars:**\n")
+		for _, source := range sources {
+			sb.WriteString(fmt.Sprintf("• `#%d` **%s**", source.ID, source.Name))
+			if source.ReminderChannelID != 0 {
+				sb.WriteString(fmt.Sprintf(" — reminders in <#%d> %dm ahead", source.ReminderChannelID, source.ReminderMinutes))
+			}
+			if source.LastError != "" {
+				sb.WriteString(" ⚠️ last sync failed")
+			}
+			sb.WriteString("\n")
+		}
+		respondEphemeral(s, i, sb.String())
+
+	case "upcoming":
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		events, err := b.calendarService.Upcoming(ctx, guildID, 10)
+		if err != nil {
+			log.Printf("❌ Failed to list upcoming events: %v", err)
+			respondEphemeral(s, i, "🔧 Failed to load events. Please try again.")
+			return
+		}
+		if len(events) == 0 {
+			respondText(s, i, "📅 No upcoming events.")
+			return
+		}
+		var sb strings.Builder
+		sb.WriteString("📅 **Upcoming events:**\n")
+		for _, event := range events {
+			if event.AllDay {
+				sb.WriteString(fmt.Sprintf("• <t:%d:D> — **%s** (all day)\n", event.StartsAt.Unix(), event.Summary))
+				continue
+			}
+			sb.WriteString(fmt.Sprintf("• <t:%d:F> (<t:%d:R>) — **%s**\n", event.StartsAt.Unix(), event.StartsAt.Unix(), event.Summary))
+		}
+		respondText(s, i, sb.String())
+	}
+}
+
+// onScheduledEventCreate announces new Discord events in guilds that follow
+// them
+func (b *Bot) onScheduledEventCreate(s *discordgo.Session, e *discordgo.GuildScheduledEventCreate) {
+	if b.calendarService == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(tenant.WithGuild(context.Background(), parseSnowflake(e.GuildID)), 30*time.Second)
+	defer cancel()
+	b.syncScheduledEvents(ctx, e.GuildID)
+	if e.Status != discordgo.GuildScheduledEventStatusScheduled {
+		return
+	}
+	if err := b.calendarService.AnnounceEvent(ctx, e.GuildScheduledEvent); err != nil {
+		log.Printf("❌ %v", err)
+	}
+}
+
+func (b *Bot) onScheduledEventUpdate(s *discordgo.Session, e *discordgo.GuildScheduledEventUpdate) {
+	if b.calendarService == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(tenant.WithGuild(context.Background(), parseSnowflake(e.GuildID)), 30*time.Second)
+	defer cancel()
+	b.syncScheduledEvents(ctx, e.GuildID)
+}
+
+func (b *Bot) onScheduledEventDelete(s *discordgo.Session, e *discordgo.GuildScheduledEventDelete) {
+	if b.calendarService == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(tenant.WithGuild(context.Background(), parseSnowflake(e.GuildID)), 30*time.Second)
+	defer cancel()
+	b.syncScheduledEvents(ctx, e.GuildID)
+}
+
+// syncScheduledEvents refreshes a guild's Discord events right away rather
+// than at the next calendar sync
+func (b *Bot) syncScheduledEvents(ctx context.Context, guildID string) {
+	if err := b.calendarService.SyncDiscordEvents(ctx, parseSnowflake(guildID)); err != nil {
+		log.Printf("❌ Failed to sync Discord events of guild %s: %v", guildID, err)
+	}
+}
+
+// SetCalendarService enables the /calendar command and calendar-aware answers
+func (b *Bot) SetCalendarService(calendarService *calendar.Service) {
+	b.calendarService = calendarService
+}
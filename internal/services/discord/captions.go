@@ -0,0 +1,92 @@
+package discord
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// captionEditInterval throttles edits of a live caption, within Discord's rate limits
+const captionEditInterval = 1500 * time.Millisecond
+
+// liveCaption is the message following one utterance in the text channel
+// linked to a voice channel: partial transcriptions while it is spoken, then
+// the final one
+type liveCaption struct {
+	session   *discordgo.Session
+	channelID string
+
+	mu        sync.Mutex
+	messageID string
+	lastEdit  time.Time
+	done      bool
+}
+
+func newLiveCaption(session *discordgo.Session, channelID string) *liveCaption {
+	return &liveCaption{session: session, channelID: channelID}
+}
+
+// partial shows the transcription so far
+func (c *liveCaption) partial(text string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.done || time.Since(c.lastEdit) < captionEditInterval {
+		return
+	}
+	c.show("🎙️ *" + text + "…*")
+}
+
+// finish replaces the caption with the final transcription and returns it, or
+// removes it when nothing intelligible was said
+func (c *liveCaption) finish(text string) *discordgo.Message {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.done = true
+
+	if text != "" {
+		return c.show("🗣️ " + text)
+	}
+	if c.messageID != "" {
+		if err := c.session.ChannelMessageDelete(c.channelID, c.messageID); err != nil {
+			log.Printf("⚠️ Failed to delete empty caption: %v", err)
+		}
+	}
+	return nil
+}
+
+// show posts the caption, or edits it once posted, and returns the message;
+// the caller holds c.mu
+func (c *liveCaption) show(content string) *discordgo.Message {
+	content = truncateText(content, 2000)
+	// Transcripts may contain anything, including "@everyone"
+	noMentions := &discordgo.MessageAllowedMentions{}
+
+	if c.messageID == "" {
+		msg, err := c.session.ChannelMessageSendComplex(c.channelID, &discordgo.MessageSend{
+			Content:         content,
+			AllowedMentions: noMentions,
+		})
+		if err != nil {
+			log.Printf("⚠️ Failed to post live caption: %v", err)
+			return nil
+		}
+		c.messageID = msg.ID
+		c.lastEdit = time.Now()
+		return msg
+	}
+
+	msg, err := c.session.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		ID:              c.messageID,
+		Channel:         c.channelID,
+		Content:         &content,
+		AllowedMentions: noMentions,
+	})
+	if err != nil {
+		log.Printf("⚠️ Failed to update live caption: %v", err)
+		return nil
+	}
+	c.lastEdit = time.Now()
+	return msg
+}
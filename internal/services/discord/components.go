@@ -0,0 +1,38 @@
+package discord
+
+import (
+	"log"
+	"strings"
+
+	"discord-tars/internal/services/poll"
+	"discord-tars/internal/services/quiz"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// onComponent routes button and select menu clicks by the prefix of their
+// custom ID, formatted as "prefix:arg1:arg2"
+func (b *Bot) onComponent(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	parts := strings.Split(i.MessageComponentData().CustomID, ":")
+
+	switch parts[0] {
+	case poll.VotePrefix:
+		b.handlePollVote(s, i, parts[1:])
+	case poll.ClosePrefix:
+		b.handlePollClose(s, i, parts[1:])
+	case followUpPrefix:
+		b.handleFollowUp(s, i, parts[1:])
+	case continuePrefix:
+		b.handleContinue(s, i, parts[1:])
+	case reindexCancelPrefix:
+		b.handleReindexCancel(s, i, parts[1:])
+	case announcePrefix:
+		b.handleAnnounceButton(s, i, parts[1:])
+	case quiz.AnswerPrefix:
+		b.handleQuizAnswer(s, i, parts[1:])
+	case quiz.LeaderboardPrefix:
+		b.handleQuizLeaderboard(s, i)
+	default:
+		log.Printf("❌ Unknown component: %s", i.MessageComponentData().CustomID)
+	}
+}
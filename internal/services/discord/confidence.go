@@ -0,0 +1,78 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"discord-tars/internal/services/rag"
+	"discord-tars/internal/tenant"
+)
+
+const (
+	maxShownSources     = 3
+	sourceSnippetLength = 160
+)
+
+var sourceIcons = map[string]string{
+	"pin":        "📌",
+	"official":   "📜",
+	"doc":        "📚",
+	"screenshot": "🖼️",
+	"message":    "💬",
+}
+
+// checkConfidence returns the answer unless it is about the server and the
+// retrieved context doesn't back it, in which case it admits uncertainty and
+// shows what was found instead
+func (p *Pipeline) checkConfidence(ctx context.Context, d *Draft, answer string) Answer {
+	guildID, _ := tenant.GuildFrom(ctx)
+	threshold := p.confidenceThresholdFor(guildID)
+	if threshold <= 0 || p.rag == nil || d.Retrieved == nil || d.external {
+		return Answer{Text: answer}
+	}
+
+	grounding, err := p.rag.CheckGrounding(ctx, d.question, answer, d.Retrieved)
+	if err != nil {
+		// Failing open: a broken judge shouldn't silence every answer
+		log.Printf("⚠️ %v", err)
+		return Answer{Text: answer}
+	}
+	if grounding.Confident(threshold) {
+		return Answer{Text: answer}
+	}
+
+	log.Printf("🤔 Withholding answer with support %.2f below threshold %.2f", grounding.Support, threshold)
+	return Answer{Text: uncertainAnswer(d.Retrieved), Uncertain: true}
+}
+
+func uncertainAnswer(rc *rag.RetrievedContext) string {
+	var sb strings.Builder
+	sb.WriteString("🤔 I'm not sure about this one, and I'd rather not make something up. ")
+
+	var found []rag.Source
+	for _, src := range rc.Sources() {
+		// Recent messages fetched as a fallback aren't matches
+		if src.Similarity > 0 {
+			found = append(found, src)
+		}
+		if len(found) == maxShownSources {
+			break
+		}
+	}
+	if len(found) == 0 {
+		sb.WriteString("I couldn't find anything relevant in this server's history or docs.")
+		return sb.String()
+	}
+
+	sb.WriteString("Here's the closest I found:\n")
+	for _, src := range found {
+		label := src.Label
+		if src.URL != "" {
+			label = fmt.Sprintf("[%s](<%s>)", src.Label, src.URL)
+		}
+		sb.WriteString(fmt.Sprintf("%s **%s**: %s\n", sourceIcons[src.Kind], label, truncateText(src.Text, sourceSnippetLength)))
+	}
+	return truncateText(sb.String(), 2000)
+}
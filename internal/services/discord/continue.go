@@ -0,0 +1,87 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"discord-tars/internal/sanitize"
+	"discord-tars/internal/services/persona"
+	"discord-tars/internal/tenant"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const continuePrefix = "continue"
+
+const continueSystemPrompt = `You continue an answer a Discord bot gave that was cut off.
+Pick up exactly where the answer stops, mid-sentence if need be, in the same language, tone and formatting.
+Do not repeat what was already said and do not add a preamble.`
+
+// verbosityChoices are the answer lengths a question or a guild may pick
+func verbosityChoices() []*discordgo.ApplicationCommandOptionChoice {
+	return []*discordgo.ApplicationCommandOptionChoice{
+		{Name: "Brief", Value: string(persona.VerbosityBrief)},
+		{Name: "Normal", Value: string(persona.VerbosityNormal)},
+		{Name: "Detailed", Value: string(persona.VerbosityDetailed)},
+	}
+}
+
+// shownAnswer is the part of an answer a reply showed, and whether it was
+// cut to fit; content is the reply, starting with header
+func shownAnswer(content, header, answer string) (string, bool) {
+	if content == header+answer {
+		return answer, false
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(content, header), "…"), true
+}
+
+// handleContinue writes the rest of an answer that was cut off, as a new
+// message
+func (b *Bot) handleContinue(s *discordgo.Session, i *discordgo.InteractionCreate, args []string) {
+	if len(args) != 1 {
+		return
+	}
+	thread, ok := b.followUps.get(args[0])
+	if !ok {
+		respondEphemeral(s, i, "⌛ That answer has expired. Ask again with `/ask` and a `detailed` verbosity instead.")
+		return
+	}
+	last := thread.history[len(thread.history)-1]
+
+	if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	}); err != nil {
+		log.Printf("❌ Failed to defer interaction: %v", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 25*time.Second)
+	defer cancel()
+	ctx = b.withPersona(tenant.WithGuild(ctx, parseSnowflake(i.GuildID)), i.GuildID)
+	ctx, truncated := persona.WithTruncationReport(ctx)
+
+	prompt := fmt.Sprintf("QUESTION:\n%s\n\nANSWER SO FAR:\n%s", last.Question, last.Answer)
+	rest, err := b.aiService.Complete(ctx, continueSystemPrompt, prompt, persona.MaxTokensFor(ctx))
+	if err != nil {
+		log.Printf("❌ Failed to continue answer: %v", err)
+		content := aiErrorMessage(err, "🔧 My circuits are experiencing difficulties. Please try again later.")
+		s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &content})
+		return
+	}
+	rest = sanitize.Output(rest)
+
+	header := fmt.Sprintf("⏩ **Continued:** %s\n\n", truncateText(last.Question, 100))
+	content := truncateText(header+rest, 2000)
+	if _, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &content}); err != nil {
+		log.Printf("❌ Failed to edit interaction response: %v", err)
+		return
+	}
+
+	shown, cut := shownAnswer(content, header, rest)
+	history := append([]ConversationTurn(nil), thread.history...)
+	history[len(history)-1].Answer = last.Answer + shown
+	b.attachFollowUps(s, i.Interaction, i.GuildID, history, cut || truncated())
+}
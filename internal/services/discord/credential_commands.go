@@ -0,0 +1,169 @@
+package discord
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/repository/postgres"
+	"discord-tars/internal/services/credentials"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func aiKeyCommand() *discordgo.ApplicationCommand {
+	return &discordgo.ApplicationCommand{
+		Name:        "aikey",
+		Description: "Use this server's own AI provider key (admins only)",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "set",
+				Description: "Store an API key; it is verified, then encrypted",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "provider",
+						Description: "AI provider",
+						Required:    true,
+						Choices: []*discordgo.ApplicationCommandOptionChoice{
+							{Name: "OpenAI", Value: models.ProviderOpenAI},
+							{Name: "Anthropic", Value: models.ProviderAnthropic},
+						},
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "key",
+						Description: "API key",
+						Required:    true,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "model",
+						Description: "Model to use (default depends on the provider)",
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "status",
+				Description: "Show which key this server uses",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "remove",
+				Description: "Delete this server's key",
+			},
+		},
+	}
+}
+
+func (b *Bot) handleAIKeyCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if b.credentialService == nil || !b.credentialService.Enabled() {
+		respondEphemeral(s, i, "🔧 Per-server API keys are not enabled on this instance.")
+		return
+	}
+	if !isGuildAdmin(i) {
+		respondEphemeral(s, i, tr(i, "admin_only.aikey"))
+		return
+	}
+
+	sub := i.ApplicationCommandData().Options[0]
+	opts := optionMap(sub.Options)
+	guildID := parseSnowflake(i.GuildID)
+
+	switch sub.Name {
+	case "set":
+		provider := opts["provider"].StringValue()
+		apiKey := opts["key"].StringValue()
+		model := ""
+		if opt, ok := opts["model"]; ok {
+			model = opt.StringValue()
+		}
+		userID := parseSnowflake(interactionUser(i).ID)
+
+		// Verifying the key is a live API call
+		b.deferEphemeral(s, i, func(ctx context.Context) string {
+			cred, err := b.credentialService.SetKey(ctx, guildID, provider, apiKey, model, userID)
+			if err != nil {
+				log.Printf("❌ Failed to set AI key: %v", err)
+				return fmt.Sprintf("🔧 Could not save the key: %v", err)
+			}
+			return fmt.Sprintf("✅ This server now uses its own **%s** key%s. Usage is billed to that key.", cred.Provider, hintSuffix(cred))
+		})
+
+	case "status":
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		cred, err := b.credentialService.Status(ctx, guildID)
+		if err != nil {
+			log.Printf("❌ Failed to load AI key: %v", err)
+			respondEphemeral(s, i, "🔧 Failed to load the AI key configuration.")
+			return
+		}
+		if cred == nil {
+			if b.credentialService.RequiresOwnKey() {
+				respondEphemeral(s, i, "🔑 No key configured. This instance requires each server to bring its own key: run `/aikey set`.")
+				return
+			}
+			respondEphemeral(s, i, "🔑 No key configured; this server uses the instance's default key.")
+			return
+		}
+		status := fmt.Sprintf("🔑 Using this server's own **%s** key%s", cred.Provider, hintSuffix(cred))
+		if cred.Model != "" {
+			status += fmt.Sprintf("\nModel: `%s`", cred.Model)
+		}
+		status += fmt.Sprintf("\nSet <t:%d:R> by <@%d>", cred.UpdatedAt.Unix(), cred.CreatedBy)
+		respondEphemeral(s, i, status)
+
+	case "remove":
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		removed, err := b.credentialService.RemoveKey(ctx, guildID)
+		if err != nil {
+			log.Printf("❌ Failed to remove AI key: %v", err)
+			respondEphemeral(s, i, "🔧 Failed to remove the key. Please try again.")
+			return
+		}
+		if !removed {
+			respondEphemeral(s, i, "ℹ️ No key was configured.")
+			return
+		}
+		respondEphemeral(s, i, "✅ Key deleted.")
+	}
+}
+
+func hintSuffix(cred *models.AICredential) string {
+	if cred.KeyHint == "" {
+		return ""
+	}
+	return fmt.Sprintf(" (ending in `%s`)", cred.KeyHint)
+}
+
+// aiErrorMessage turns an answer failure into a user-facing message
+func aiErrorMessage(err error, fallback string) string {
+	if errors.Is(err, credentials.ErrNoCredential) {
+		return "🔑 This server has no AI key configured. A server manager can add one with `/aikey set`."
+	}
+	if errors.Is(err, credentials.ErrBusy) {
+		return "⏳ Too many questions are being answered right now. Please try again in a moment."
+	}
+	// Checked before the deadline, which query timeouts also wrap
+	if errors.Is(err, postgres.ErrQueryTimeout) {
+		return "⏳ Searching this server's history took too long, so I stopped. Please try again in a moment, or ask something more specific."
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return "⏳ That took too long to answer, so I stopped. Please try again in a moment."
+	}
+	return fallback
+}
+
+// SetCredentialService enables /aikey
+func (b *Bot) SetCredentialService(credentialService *credentials.Service) {
+	b.credentialService = credentialService
+}
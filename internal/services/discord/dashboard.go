@@ -0,0 +1,60 @@
+package discord
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/services/dashboard"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// logAnswer keeps an answer for admins to review in the dashboard and shows
+// its question on the live feed
+func (b *Bot) logAnswer(guildID, userID string, reply *discordgo.Message, question, answer string) {
+	if b.dashboardService == nil || guildID == "" || reply == nil {
+		return
+	}
+	b.dashboardService.Publish(dashboard.LiveEvent{
+		Type:     dashboard.LiveQuestion,
+		GuildID:  parseSnowflake(guildID),
+		Question: question,
+	})
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		err := b.dashboardService.RecordAnswer(ctx, &models.AnswerLog{
+			GuildID:   parseSnowflake(guildID),
+			ChannelID: parseSnowflake(reply.ChannelID),
+			MessageID: parseSnowflake(reply.ID),
+			UserID:    parseSnowflake(userID),
+			Question:  question,
+			Answer:    answer,
+		})
+		if err != nil {
+			log.Printf("⚠️ Failed to keep answer for review: %v", err)
+		}
+	}()
+}
+
+// answerFeedback counts a 👍 or 👎 added to (delta 1) or removed from
+// (delta -1) an answer kept for review
+func (b *Bot) answerFeedback(guildID, messageID, emoji string, delta int) {
+	if b.dashboardService == nil || (emoji != "👍" && emoji != "👎") {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := b.dashboardService.Feedback(ctx, parseSnowflake(guildID), parseSnowflake(messageID), emoji, delta); err != nil {
+			log.Printf("⚠️ %v", err)
+		}
+	}()
+}
+
+// SetDashboardService keeps answers and their feedback for the web dashboard
+func (b *Bot) SetDashboardService(dashboardService *dashboard.Service) {
+	b.dashboardService = dashboardService
+}
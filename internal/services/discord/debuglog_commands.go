@@ -0,0 +1,128 @@
+package discord
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"discord-tars/internal/services/debuglog"
+	"discord-tars/internal/tenant"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func debugLogCommand() *discordgo.ApplicationCommand {
+	return &discordgo.ApplicationCommand{
+		Name:        "debug-log",
+		Description: "Diagnose a stack trace or log file, citing threads where the same error was solved",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "log",
+				Description: "The stack trace or error output",
+				MaxLength:   6000,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionAttachment,
+				Name:        "file",
+				Description: "A log file, read up to 1 MB",
+			},
+		},
+	}
+}
+
+func (b *Bot) handleDebugLogCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if b.debugLogService == nil {
+		respondEphemeral(s, i, "🔧 Log analysis is not enabled on this instance.")
+		return
+	}
+	if i.GuildID == "" {
+		respondEphemeral(s, i, "🔒 This command only works in a server.")
+		return
+	}
+
+	data := i.ApplicationCommandData()
+	opts := optionMap(data.Options)
+	var pasted string
+	if opt, ok := opts["log"]; ok {
+		pasted = opt.StringValue()
+	}
+	var attachment *discordgo.MessageAttachment
+	if opt, ok := opts["file"]; ok {
+		id, _ := opt.Value.(string)
+		if data.Resolved != nil {
+			attachment = data.Resolved.Attachments[id]
+		}
+	}
+	if strings.TrimSpace(pasted) == "" && attachment == nil {
+		respondEphemeral(s, i, tr(i, "debug_log.no_input"))
+		return
+	}
+
+	// Earlier threads can come from any channel, so only the requester sees them
+	if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Flags: discordgo.MessageFlagsEphemeral},
+	}); err != nil {
+		log.Printf("❌ Failed to defer interaction: %v", err)
+		return
+	}
+
+	guildID := parseSnowflake(i.GuildID)
+	ctx, cancel := context.WithTimeout(tenant.WithGuild(context.Background(), guildID), time.Minute)
+	defer cancel()
+
+	content := b.diagnoseLog(ctx, s, i, guildID, pasted, attachment)
+	if _, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &content}); err != nil {
+		log.Printf("❌ Failed to edit interaction response: %v", err)
+	}
+}
+
+// diagnoseLog reads the pasted log and attached file and diagnoses them
+func (b *Bot) diagnoseLog(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, guildID int64, pasted string, attachment *discordgo.MessageAttachment) string {
+	logText := pasted
+	if attachment != nil {
+		file, err := debuglog.Download(ctx, attachment.URL)
+		if err != nil {
+			log.Printf("❌ Failed to download log file %s: %v", attachment.Filename, err)
+			return tr(i, "debug_log.download_failed", attachment.Filename)
+		}
+		logText = strings.TrimSpace(logText + "\n" + file)
+	}
+
+	diagnosis, err := b.debugLogService.Diagnose(ctx, guildID, logText, channelReader(s, interactionUser(i).ID))
+	switch {
+	case errors.Is(err, debuglog.ErrEmpty):
+		return tr(i, "debug_log.no_input")
+	case err != nil:
+		log.Printf("❌ Failed to diagnose log in guild %s: %v", i.GuildID, err)
+		return aiErrorMessage(err, tr(i, "debug_log.failed"))
+	}
+	return diagnosisMessage(i, diagnosis)
+}
+
+// diagnosisMessage shows a diagnosis with numbered links to the earlier
+// threads it can cite
+func diagnosisMessage(i *discordgo.InteractionCreate, diagnosis *debuglog.Diagnosis) string {
+	firstLine, _, _ := strings.Cut(diagnosis.Signature, "\n")
+	firstLine = strings.ReplaceAll(strings.TrimSpace(firstLine), "`", "'")
+	header := tr(i, "debug_log.title", truncateText(firstLine, 150)) + "\n\n"
+
+	footer := "\n\n-# " + tr(i, "debug_log.no_threads")
+	if len(diagnosis.Occurrences) > 0 {
+		links := make([]string, len(diagnosis.Occurrences))
+		for n, occurrence := range diagnosis.Occurrences {
+			links[n] = fmt.Sprintf("[%d](%s) %s, <t:%d:d>", n+1, storedMessageLink(occurrence.Message), occurrence.Author, occurrence.Message.Timestamp.Unix())
+		}
+		footer = "\n\n-# " + tr(i, "debug_log.threads", strings.Join(links, " · "))
+	}
+	return truncateText(header+diagnosis.Text, 2000-len(footer)) + footer
+}
+
+// SetDebugLogService enables /debug-log
+func (b *Bot) SetDebugLogService(debugLogService *debuglog.Service) {
+	b.debugLogService = debugLogService
+}
@@ -0,0 +1,195 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/services/digest"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func digestCommand() *discordgo.ApplicationCommand {
+	minHour := 0.0
+	return &discordgo.ApplicationCommand{
+		Name:        "digest",
+		Description: "Get AI summaries of channels delivered by DM",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "subscribe",
+				Description: "Subscribe to a channel digest",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:         discordgo.ApplicationCommandOptionChannel,
+						Name:         "channel",
+						Description:  "Channel to summarize",
+						Required:     true,
+						ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText},
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "frequency",
+						Description: "How often to receive the digest",
+						Required:    true,
+						Choices: []*discordgo.ApplicationCommandOptionChoice{
+							{Name: "Daily", Value: models.DigestDaily},
+							{Name: "Weekly", Value: models.DigestWeekly},
+						},
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "hour",
+						Description: "Local delivery hour (0-23, default 9)",
+						MinValue:    &minHour,
+						MaxValue:    23,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "timezone",
+						Description: "IANA timezone, e.g. Europe/Paris (default: your timezone)",
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "weekday",
+						Description: "Delivery day for weekly digests (default Monday)",
+						Choices:     weekdayChoices(),
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "unsubscribe",
+				Description: "Stop receiving a channel digest",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionChannel,
+						Name:        "channel",
+						Description: "Channel to unsubscribe from",
+						Required:    true,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "list",
+				Description: "Show your digest subscriptions",
+			},
+		},
+	}
+}
+
+func weekdayChoices() []*discordgo.ApplicationCommandOptionChoice {
+	choices := make([]*discordgo.ApplicationCommandOptionChoice, 0, 7)
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: d.String(), Value: int(d)})
+	}
+	return choices
+}
+
+func (b *Bot) handleDigestCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if b.digestService == nil {
+		respondEphemeral(s, i, "🔧 Digests are not enabled on this instance.")
+		return
+	}
+	if i.GuildID == "" {
+		respondEphemeral(s, i, "🔧 Digests can only be managed from a server.")
+		return
+	}
+
+	sub := i.ApplicationCommandData().Options[0]
+	userID := parseSnowflake(interactionUser(i).ID)
+	guildID := parseSnowflake(i.GuildID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	switch sub.Name {
+	case "subscribe":
+		b.handleDigestSubscribe(ctx, s, i, sub.Options, userID, guildID)
+	case "unsubscribe":
+		opts := optionMap(sub.Options)
+		channel := opts["channel"].ChannelValue(s)
+		removed, err := b.digestService.Unsubscribe(ctx, userID, parseSnowflake(channel.ID))
+		if err != nil {
+			log.Printf("❌ Failed to unsubscribe digest: %v", err)
+			respondEphemeral(s, i, "🔧 Failed to update your subscription. Please try again.")
+			return
+		}
+		if !removed {
+			respondEphemeral(s, i, fmt.Sprintf("ℹ️ You were not subscribed to <#%s>.", channel.ID))
+			return
+		}
+		respondEphemeral(s, i, fmt.Sprintf("✅ Unsubscribed from the <#%s> digest.", channel.ID))
+	case "list":
+		subs, err := b.digestService.List(ctx, userID, guildID)
+		if err != nil {
+			log.Printf("❌ Failed to list digests: %v", err)
+			respondEphemeral(s, i, "🔧 Failed to load your subscriptions. Please try again.")
+			return
+		}
+		respondEphemeral(s, i, formatDigestList(subs))
+	}
+}
+
+func (b *Bot) handleDigestSubscribe(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, options []*discordgo.ApplicationCommandInteractionDataOption, userID, guildID int64) {
+	opts := optionMap(options)
+	channel := opts["channel"].ChannelValue(s)
+
+	sub := &models.DigestSubscription{
+		UserID:    userID,
+		GuildID:   guildID,
+		ChannelID: parseSnowflake(channel.ID),
+		Frequency: opts["frequency"].StringValue(),
+		Hour:      9,
+		Weekday:   int(time.Monday),
+		Timezone:  b.memberTimezone(ctx, i),
+	}
+	if opt, ok := opts["hour"]; ok {
+		sub.Hour = int(opt.IntValue())
+	}
+	if opt, ok := opts["timezone"]; ok {
+		sub.Timezone = strings.TrimSpace(opt.StringValue())
+	}
+	if opt, ok := opts["weekday"]; ok {
+		sub.Weekday = int(opt.IntValue())
+	}
+
+	if err := b.digestService.Subscribe(ctx, sub); err != nil {
+		log.Printf("❌ Failed to subscribe digest: %v", err)
+		respondEphemeral(s, i, fmt.Sprintf("🔧 Could not save your subscription: %v", err))
+		return
+	}
+
+	respondEphemeral(s, i, fmt.Sprintf("✅ Subscribed to the %s digest of <#%s>, delivered by DM %s.\nMake sure your DMs are open so I can reach you.",
+		sub.Frequency, channel.ID, describeSchedule(*sub)))
+}
+
+func formatDigestList(subs []models.DigestSubscription) string {
+	if len(subs) == 0 {
+		return "📭 You have no digest subscriptions. Use `/digest subscribe` to add one."
+	}
+
+	var sb strings.Builder
+	sb.WriteString("📰 **Your digest subscriptions:**\n")
+	for _, sub := range subs {
+		sb.WriteString(fmt.Sprintf("• <#%d> — %s, %s\n", sub.ChannelID, sub.Frequency, describeSchedule(sub)))
+	}
+	return sb.String()
+}
+
+func describeSchedule(sub models.DigestSubscription) string {
+	if sub.Frequency == models.DigestWeekly {
+		return fmt.Sprintf("every %s at %02d:00 (%s)", time.Weekday(sub.Weekday), sub.Hour, sub.Timezone)
+	}
+	return fmt.Sprintf("every day at %02d:00 (%s)", sub.Hour, sub.Timezone)
+}
+
+// SetDigestService enables the /digest command
+func (b *Bot) SetDigestService(digestService *digest.Service) {
+	b.digestService = digestService
+}
@@ -0,0 +1,271 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/services/knowledge"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func docsCommand() *discordgo.ApplicationCommand {
+	nameOption := &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionString,
+		Name:        "name",
+		Description: "Display name for this source",
+		Required:    true,
+	}
+	tokenOption := &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionString,
+		Name:        "token",
+		Description: "API token",
+		Required:    true,
+	}
+	idOption := &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionInteger,
+		Name:        "id",
+		Description: "Source ID from /docs list",
+		Required:    true,
+	}
+
+	return &discordgo.ApplicationCommand{
+		Name:        "docs",
+		Description: "Sync team documentation from Notion or Confluence (admins only)",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "add-notion",
+				Description: "Sync selected Notion pages",
+				Options: []*discordgo.ApplicationCommandOption{
+					nameOption,
+					tokenOption,
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "pages",
+						Description: "Comma-separated page URLs or IDs shared with the integration",
+						Required:    true,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "add-confluence",
+				Description: "Sync a Confluence space or selected pages",
+				Options: []*discordgo.ApplicationCommandOption{
+					nameOption,
+					tokenOption,
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "base_url",
+						Description: "Confluence base URL, e.g. https://acme.atlassian.net/wiki",
+						Required:    true,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "email",
+						Description: "Account email (omit for a Data Center personal access token)",
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "space",
+						Description: "Space key to sync in full",
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "pages",
+						Description: "Comma-separated page IDs",
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "sync",
+				Description: "Sync a source now",
+				Options:     []*discordgo.ApplicationCommandOption{idOption},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "remove",
+				Description: "Remove a source and its indexed pages",
+				Options:     []*discordgo.ApplicationCommandOption{idOption},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "list",
+				Description: "Show documentation sources",
+			},
+		},
+	}
+}
+
+func (b *Bot) handleDocsCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if b.knowledgeService == nil {
+		respondEphemeral(s, i, "🔧 Documentation sync is not enabled on this instance.")
+		return
+	}
+	if !isGuildAdmin(i) {
+		respondEphemeral(s, i, tr(i, "admin_only.docs"))
+		return
+	}
+
+	sub := i.ApplicationCommandData().Options[0]
+	opts := optionMap(sub.Options)
+	guildID := parseSnowflake(i.GuildID)
+
+	switch sub.Name {
+	case "add-notion", "add-confluence":
+		source := &models.KnowledgeSource{
+			GuildID:   guildID,
+			Name:      opts["name"].StringValue(),
+			APIToken:  opts["token"].StringValue(),
+			CreatedBy: parseSnowflake(interactionUser(i).ID),
+		}
+		if sub.Name == "add-notion" {
+			source.Provider = models.KnowledgeNotion
+			for _, value := range splitList(opts["pages"].StringValue()) {
+				id, ok := knowledge.NotionPageID(value)
+				if !ok {
+					respondEphemeral(s, i, fmt.Sprintf("🔧 `%s` doesn't look like a Notion page URL or ID.", value))
+					return
+				}
+				source.PageIDs = append(source.PageIDs, id)
+			}
+		} else {
+			source.Provider = models.KnowledgeConfluence
+			source.BaseURL = strings.TrimRight(opts["base_url"].StringValue(), "/")
+			if opt, ok := opts["email"]; ok {
+				source.Email = opt.StringValue()
+			}
+			if opt, ok := opts["space"]; ok {
+				source.SpaceKey = strings.TrimSpace(opt.StringValue())
+			}
+			if opt, ok := opts["pages"]; ok {
+				source.PageIDs = splitList(opt.StringValue())
+			}
+		}
+
+		b.deferEphemeral(s, i, func(ctx context.Context) string {
+			result, err := b.knowledgeService.AddSource(ctx, source)
+			switch {
+			case err != nil && source.ID == 0:
+				log.Printf("❌ Failed to add knowledge source: %v", err)
+				return fmt.Sprintf("🔧 Could not add the source: %v", err)
+			case err != nil:
+				log.Printf("❌ First knowledge sync failed: %v", err)
+				return fmt.Sprintf("⚠️ Source **%s** (#%d) was added but the first sync had errors: %v", source.Name, source.ID, err)
+			}
+			return fmt.Sprintf("✅ Source **%s** (#%d) added: %d pages indexed. `/ask` can now answer from it.", source.Name, source.ID, result.Updated)
+		})
+
+	case "sync":
+		sourceID := opts["id"].IntValue()
+		b.deferEphemeral(s, i, func(ctx context.Context) string {
+			result, err := b.knowledgeService.Sync(ctx, guildID, sourceID)
+			if err != nil && result == nil {
+				log.Printf("❌ Failed to sync knowledge source %d: %v", sourceID, err)
+				return fmt.Sprintf("🔧 Sync failed: %v", err)
+			}
+			summary := fmt.Sprintf("📚 Synced: %d updated, %d unchanged, %d removed.", result.Updated, result.Unchanged, result.Removed)
+			if err != nil {
+				summary += fmt.Sprintf("\n⚠️ %v", err)
+			}
+			return summary
+		})
+
+	case "remove":
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		removed, err := b.knowledgeService.RemoveSource(ctx, guildID, opts["id"].IntValue())
+		if err != nil {
+			log.Printf("❌ Failed to remove knowledge source: %v", err)
+			respondEphemeral(s, i, "🔧 Failed to remove the source. Please try again.")
+			return
+		}
+		if !removed {
+			respondEphemeral(s, i, "ℹ️ No documentation source with that ID exists in this server.")
+			return
+		}
+		respondEphemeral(s, i, "✅ Source removed along with its indexed pages.")
+
+	case "list":
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		sources, err := b.knowledgeService.ListSources(ctx, guildID)
+		if err != nil {
+			log.Printf("❌ Failed to list knowledge sources: %v", err)
+			respondEphemeral(s, i, "🔧 Failed to load documentation sources. Please try again.")
+			return
+		}
+		if len(sources) == 0 {
+			respondEphemeral(s, i, "📚 No documentation sources are configured yet.")
+			return
+		}
+		var sb strings.Builder
+		sb.WriteString("📚 **Documentation sources:**\n")
+		for _, source := range sources {
+			sb.WriteString(fmt.Sprintf("• `#%d` **%s** (%s)", source.ID, source.Name, source.Provider))
+			if source.LastSyncedAt != nil {
+				sb.WriteString(fmt.Sprintf(" — synced <t:%d:R>", source.LastSyncedAt.Unix()))
+			}
+			if source.LastError != "" {
+				sb.WriteString(" ⚠️ last sync had errors")
+			}
+			sb.WriteString("\n")
+		}
+		respondEphemeral(s, i, sb.String())
+	}
+}
+
+// deferEphemeral acknowledges a slow interaction privately and edits in the
+// result of work; results over one message continue in private follow-ups
+func (b *Bot) deferEphemeral(s *discordgo.Session, i *discordgo.InteractionCreate, work func(ctx context.Context) string) {
+	if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Flags: discordgo.MessageFlagsEphemeral},
+	}); err != nil {
+		log.Printf("❌ Failed to defer interaction: %v", err)
+		return
+	}
+
+	// Interaction tokens stay valid for 15 minutes
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	pages := splitMessage(work(ctx), 2000, maxResponsePages)
+	if _, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &pages[0]}); err != nil {
+		log.Printf("❌ Failed to edit interaction response: %v", err)
+		return
+	}
+	for _, page := range pages[1:] {
+		if _, err := s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Content: page,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		}); err != nil {
+			log.Printf("❌ Failed to send follow-up page: %v", err)
+			return
+		}
+	}
+}
+
+// splitList splits a comma-separated option into trimmed, non-empty values
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// SetKnowledgeService enables the /docs command
+func (b *Bot) SetKnowledgeService(knowledgeService *knowledge.Service) {
+	b.knowledgeService = knowledgeService
+}
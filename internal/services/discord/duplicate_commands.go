@@ -0,0 +1,123 @@
+package discord
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"time"
+
+	"discord-tars/internal/services/duplicates"
+	"discord-tars/internal/tenant"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func duplicatesCommand() *discordgo.ApplicationCommand {
+	return &discordgo.ApplicationCommand{
+		Name:        "duplicates",
+		Description: "Link questions already answered to the earlier answer (admins only)",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionBoolean,
+				Name:        "enabled",
+				Description: "Turn it on or off (default: show the current setting)",
+			},
+		},
+	}
+}
+
+func (b *Bot) handleDuplicatesCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if b.duplicateService == nil {
+		respondEphemeral(s, i, "🔧 Duplicate question detection is not enabled on this instance.")
+		return
+	}
+	if !isGuildAdmin(i) {
+		respondEphemeral(s, i, tr(i, "admin_only.duplicates"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	guildID := parseSnowflake(i.GuildID)
+
+	opt, ok := optionMap(i.ApplicationCommandData().Options)["enabled"]
+	if !ok {
+		if b.duplicateService.Enabled(ctx, guildID) {
+			respondEphemeral(s, i, "🔁 On: questions already answered by the FAQ or by me get a link to that answer.")
+		} else {
+			respondEphemeral(s, i, "🔁 Off. Run `/duplicates enabled:True` to link questions already answered to the earlier answer.")
+		}
+		return
+	}
+
+	enabled := opt.BoolValue()
+	if err := b.duplicateService.SetEnabled(ctx, guildID, enabled); err != nil {
+		log.Printf("❌ Failed to save duplicate question setting: %v", err)
+		respondEphemeral(s, i, "🔧 Failed to save the setting. Please try again.")
+		return
+	}
+	if enabled {
+		respondEphemeral(s, i, "✅ Questions already answered by the FAQ or by me will get a link to that answer. Answers given from now on are remembered for this.")
+	} else {
+		respondEphemeral(s, i, "✅ Duplicate question detection is off.")
+	}
+}
+
+// linkDuplicate replies to a chat question already answered with a link to
+// that answer, in guilds that opted in
+func (b *Bot) linkDuplicate(s *discordgo.Session, m *discordgo.MessageCreate) {
+	if b.duplicateService == nil || m.GuildID == "" || m.Author.Bot || !duplicates.IsQuestion(m.Content) {
+		return
+	}
+	go func() {
+		guildID := parseSnowflake(m.GuildID)
+		ctx, cancel := context.WithTimeout(tenant.WithGuild(context.Background(), guildID), 10*time.Second)
+		defer cancel()
+
+		match, err := b.duplicateService.Find(ctx, guildID, parseSnowflake(m.ChannelID), m.Content)
+		if err != nil {
+			log.Printf("⚠️ Duplicate question check failed: %v", err)
+			return
+		}
+		// The asker must be able to open the link
+		if match == nil || !userCanRead(s, m.Author.ID, strconv.FormatInt(match.ChannelID, 10)) {
+			return
+		}
+
+		content := "🔁 This looks like it was answered before: " + match.Link()
+		if match.Kind == duplicates.KindFAQ {
+			content = "📌 This may be covered in the FAQ: " + match.Link()
+		}
+		_, err = b.sendReply(m.Message, &discordgo.MessageSend{
+			Content:         content,
+			Reference:       m.Reference(),
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		})
+		if err != nil {
+			log.Printf("❌ Failed to link earlier answer: %v", err)
+			return
+		}
+		log.Printf("🔁 Linked a repeated question in channel %s to an earlier %s (similarity %.2f)", m.ChannelID, match.Kind, match.Similarity)
+	}()
+}
+
+// recordAnswer remembers the answer to a standalone question so the same
+// question asked later can be linked to it
+func (b *Bot) recordAnswer(guildID string, answer *discordgo.Message, question string) {
+	if b.duplicateService == nil || guildID == "" || answer == nil {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(tenant.WithGuild(context.Background(), parseSnowflake(guildID)), 10*time.Second)
+		defer cancel()
+		err := b.duplicateService.RecordAnswer(ctx, parseSnowflake(guildID), parseSnowflake(answer.ChannelID), parseSnowflake(answer.ID), question)
+		if err != nil {
+			log.Printf("⚠️ Failed to remember answered question: %v", err)
+		}
+	}()
+}
+
+// SetDuplicateService enables linking repeated questions to earlier answers
+func (b *Bot) SetDuplicateService(duplicateService *duplicates.Service) {
+	b.duplicateService = duplicateService
+}
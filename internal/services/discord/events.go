@@ -0,0 +1,108 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"discord-tars/internal/events"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Workers per subscription; indexing waits on embeddings, so it gets more
+const indexingWorkers = 4
+
+// SetEventBus replaces the in-process event bus the Discord handlers publish
+// to; call it before Start. The bot closes it when stopped.
+func (b *Bot) SetEventBus(bus events.Bus) {
+	b.events = bus
+}
+
+// EventBus lets other services subscribe to what happens on Discord; it is
+// set once the bot has started
+func (b *Bot) EventBus() events.Bus {
+	return b.events
+}
+
+// subscription is one of the bot's event consumers
+type subscription struct {
+	name    string
+	topic   string
+	workers int
+	handler events.Handler
+}
+
+// subscribeEvents wires the bot's own features to the event bus. Each checks
+// its service when an event arrives, as they can be set in any order.
+func (b *Bot) subscribeEvents() error {
+	subscriptions := []subscription{
+		{"toxicity-watch", events.MessageCreated, 1, b.watchToxicity},
+		{"reaction-counts", events.ReactionAdded, 1, b.countReaction},
+		{"reaction-counts", events.ReactionRemoved, 1, b.countReaction},
+		{"reaction-counts", events.ReactionsCleared, 1, b.clearReactions},
+		{"highlights", events.ReactionAdded, 1, b.updateHighlight},
+		{"highlights", events.ReactionRemoved, 1, b.updateHighlight},
+		{"xp", events.MessageCreated, 1, b.awardMessageXP},
+		{"xp", events.ReactionAdded, 1, b.awardReactionXP},
+		{"xp", events.ReactionRemoved, 1, b.awardReactionXP},
+		{"forums", events.MessageCreated, 1, b.trackForumPost},
+		{"forums", events.ReactionAdded, 1, b.acceptForumAnswer},
+		{"help-answers", events.MessageCreated, 1, b.suggestHelpAnswer},
+	}
+	// The worker embeds messages itself; the bot only counts them
+	if b.config.ExternalIndexing {
+		subscriptions = append(subscriptions, subscription{"message-count", events.MessageCreated, 1, b.countMessage})
+	} else {
+		subscriptions = append(subscriptions, subscription{"rag-indexing", events.MessageCreated, indexingWorkers, b.indexMessage})
+	}
+
+	for _, sub := range subscriptions {
+		if err := b.events.Subscribe(sub.name, sub.topic, sub.workers, sub.handler); err != nil {
+			return fmt.Errorf("failed to subscribe %s: %w", sub.name, err)
+		}
+	}
+	return nil
+}
+
+// publish hands an event to the bus; handlers never wait for subscribers
+func (b *Bot) publish(event *events.Event) {
+	if err := b.events.Publish(context.Background(), event); err != nil {
+		log.Printf("❌ Failed to publish %s event: %v", event.Topic, err)
+	}
+}
+
+// indexMessage stores and embeds new messages for RAG, and counts them for
+// the bot's status
+func (b *Bot) indexMessage(ctx context.Context, event *events.Event) error {
+	if b.ragService == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	if err := b.ragService.ProcessMessage(ctx, event.Message); err != nil {
+		return fmt.Errorf("failed to process message for RAG: %w", err)
+	}
+	if !event.Message.Author.Bot {
+		b.countIndexedMessage(b.session)
+	}
+	return nil
+}
+
+// countMessage counts new messages for the bot's status when the worker
+// indexes them
+func (b *Bot) countMessage(ctx context.Context, event *events.Event) error {
+	if !event.Message.Author.Bot {
+		b.countIndexedMessage(b.session)
+	}
+	return nil
+}
+
+// watchToxicity warns moderators when an argument escalates
+func (b *Bot) watchToxicity(ctx context.Context, event *events.Event) error {
+	if b.toxicityWatcher != nil {
+		b.toxicityWatcher.Observe(&discordgo.MessageCreate{Message: event.Message})
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package discord
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"discord-tars/internal/services/feeds"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func feedCommand() *discordgo.ApplicationCommand {
+	minInterval := float64(feeds.MinIntervalMinutes)
+
+	return &discordgo.ApplicationCommand{
+		Name:        "feed",
+		Description: "Watch RSS/Atom feeds and post summarized updates (admins only)",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "add",
+				Description: "Watch a feed",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "url",
+						Description: "RSS or Atom feed URL",
+						Required:    true,
+					},
+					{
+						Type:         discordgo.ApplicationCommandOptionChannel,
+						Name:         "channel",
+						Description:  "Channel to post updates in (defaults to this one)",
+						ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText, discordgo.ChannelTypeGuildNews},
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "interval",
+						Description: fmt.Sprintf("Minutes between checks (default %d)", feeds.DefaultIntervalMinutes),
+						MinValue:    &minInterval,
+						MaxValue:    feeds.MaxIntervalMinutes,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "remove",
+				Description: "Stop watching a feed",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "id",
+						Description: "Feed ID from /feed list",
+						Required:    true,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "list",
+				Description: "Show watched feeds",
+			},
+		},
+	}
+}
+
+func (b *Bot) handleFeedCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if b.feedService == nil {
+		respondEphemeral(s, i, "🔧 Feeds are not enabled on this instance.")
+		return
+	}
+	if !isGuildAdmin(i) {
+		respondEphemeral(s, i, tr(i, "admin_only.feeds"))
+		return
+	}
+
+	sub := i.ApplicationCommandData().Options[0]
+	opts := optionMap(sub.Options)
+	guildID := parseSnowflake(i.GuildID)
+
+	switch sub.Name {
+	case "add":
+		channelID := i.ChannelID
+		if opt, ok := opts["channel"]; ok {
+			channelID = opt.ChannelValue(s).ID
+		}
+		interval := 0
+		if opt, ok := opts["interval"]; ok {
+			interval = int(opt.IntValue())
+		}
+
+		if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Flags: discordgo.MessageFlagsEphemeral},
+		}); err != nil {
+			log.Printf("❌ Failed to defer interaction: %v", err)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		var content string
+		feed, err := b.feedService.AddFeed(ctx, guildID, parseSnowflake(channelID), parseSnowflake(interactionUser(i).ID), opts["url"].StringValue(), interval)
+		switch {
+		case errors.Is(err, feeds.ErrInvalidFeedURL):
+			content = "🔧 " + err.Error() + "."
+		case err != nil:
+			log.Printf("❌ Failed to add feed: %v", err)
+			content = fmt.Sprintf("🔧 Could not add that feed: %v", err)
+		default:
+			content = fmt.Sprintf("✅ Watching **%s** (#%d) every %d minutes in <#%s>. New items will be summarized as they appear.",
+				feedTitle(feed.Title, feed.URL), feed.ID, feed.IntervalMinutes, channelID)
+		}
+		s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &content})
+
+	case "remove":
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		removed, err := b.feedService.RemoveFeed(ctx, guildID, opts["id"].IntValue())
+		if err != nil {
+			log.Printf("❌ Failed to remove feed: %v", err)
+			respondEphemeral(s, i, "🔧 Failed to remove the feed. Please try again.")
+			return
+		}
+		if !removed {
+			respondEphemeral(s, i, "ℹ️ No feed with that ID exists in this server.")
+			return
+		}
+		respondEphemeral(s, i, "✅ Feed removed.")
+
+	case "list":
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		list, err := b.feedService.ListFeeds(ctx, guildID)
+		if err != nil {
+			log.Printf("❌ Failed to list feeds: %v", err)
+			respondEphemeral(s, i, "🔧 Failed to load feeds. Please try again.")
+			return
+		}
+		if len(list) == 0 {
+			respondEphemeral(s, i, "📰 No feeds are watched in this server yet.")
+			return
+		}
+		var sb strings.Builder
+		sb.WriteString("📰 **Watched feeds:**\n")
+		for _, feed := range list {
+			sb.WriteString(fmt.Sprintf("• `#%d` **%s** → <#%d> every %dm", feed.ID, feedTitle(feed.Title, feed.URL), feed.ChannelID, feed.IntervalMinutes))
+			if feed.LastError != "" {
+				sb.WriteString(" ⚠️ last check failed")
+			}
+			sb.WriteString("\n")
+		}
+		respondEphemeral(s, i, sb.String())
+	}
+}
+
+func feedTitle(title, url string) string {
+	if title != "" {
+		return title
+	}
+	return url
+}
+
+// SetFeedService enables the /feed command
+func (b *Bot) SetFeedService(feedService *feeds.Service) {
+	b.feedService = feedService
+}
@@ -0,0 +1,80 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"path"
+	"strings"
+	"time"
+
+	"discord-tars/internal/services/rag"
+	"discord-tars/internal/storage"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// signedLinkTTL is how long links handed out by /attachments stay valid
+const signedLinkTTL = time.Hour
+
+func attachmentsCommand() *discordgo.ApplicationCommand {
+	return &discordgo.ApplicationCommand{
+		Name:        "attachments",
+		Description: "Get fresh links to a message's archived attachments",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "message_id",
+				Description: "ID of a message in this channel",
+				Required:    true,
+			},
+		},
+	}
+}
+
+func (b *Bot) handleAttachmentsCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if b.fileStore == nil {
+		respondEphemeral(s, i, "🔧 Attachment archiving is not enabled on this instance.")
+		return
+	}
+
+	messageID := strings.TrimSpace(optionMap(i.ApplicationCommandData().Options)["message_id"].StringValue())
+	if parseSnowflake(messageID) == 0 {
+		respondEphemeral(s, i, "🔧 That doesn't look like a message ID.")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Keys are scoped to the invoking channel so links can't be fetched for other channels
+	prefix := path.Dir(rag.AttachmentKey(i.GuildID, i.ChannelID, messageID, "x")) + "/"
+	objects, err := b.fileStore.List(ctx, prefix)
+	if err != nil {
+		log.Printf("❌ Failed to list archived attachments: %v", err)
+		respondEphemeral(s, i, "🔧 Failed to look up archived attachments. Please try again.")
+		return
+	}
+	if len(objects) == 0 {
+		respondEphemeral(s, i, "📎 No archived attachments for that message in this channel.")
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("📎 **Archived attachments** (links expire <t:%d:R>):\n", time.Now().Add(signedLinkTTL).Unix()))
+	for _, obj := range objects {
+		link, err := b.fileStore.SignedURL(ctx, obj.Key, signedLinkTTL)
+		if err != nil {
+			log.Printf("❌ Failed to sign %s: %v", obj.Key, err)
+			respondEphemeral(s, i, "🔧 Files are archived but links can't be generated on this instance.")
+			return
+		}
+		sb.WriteString(fmt.Sprintf("• [%s](%s)\n", path.Base(obj.Key), link))
+	}
+	respondEphemeral(s, i, truncateText(sb.String(), 2000))
+}
+
+// SetFileStore enables /attachments
+func (b *Bot) SetFileStore(store storage.Store) {
+	b.fileStore = store
+}
@@ -0,0 +1,258 @@
+package discord
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"discord-tars/internal/services/persona"
+	"discord-tars/internal/tenant"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	followUpPrefix = "followup"
+
+	maxFollowUps       = 3
+	maxFollowUpLabel   = 80 // Discord's button label limit
+	followUpTTL        = 2 * time.Hour
+	maxFollowUpThreads = 1000
+	// maxHistoryTurns bounds how many earlier exchanges are replayed with a follow-up
+	maxHistoryTurns = 3
+	// maxHistoryAnswer bounds each replayed answer so history doesn't crowd out retrieval
+	maxHistoryAnswer = 800
+)
+
+const followUpSystemPrompt = `You suggest follow-up questions for a Discord Q&A bot.
+Given a question and its answer, propose 2 or 3 short, distinct questions the asker would plausibly ask next.
+Each must stand on its own and be under 80 characters.
+Reply with a JSON array of strings only, no prose.`
+
+// ConversationTurn is one question and the answer given to it
+type ConversationTurn struct {
+	Asker    string // Set when several members share the conversation
+	Question string
+	Answer   string
+}
+
+// Conversation is what an answer is told about earlier exchanges
+type Conversation struct {
+	Summary string // Rolling summary of exchanges too old to replay verbatim
+	Turns   []ConversationTurn
+	// Attached is text the user supplied along with the question, e.g. through /ask-long
+	Attached string
+}
+
+// empty reports whether the question stands on its own
+func (c Conversation) empty() bool {
+	return c.Summary == "" && len(c.Turns) == 0 && c.Attached == ""
+}
+
+// followUpThread holds what a set of follow-up buttons needs to continue a conversation
+type followUpThread struct {
+	history     []ConversationTurn
+	suggestions []string
+	createdAt   time.Time
+}
+
+// followUpStore keeps recent threads in memory; buttons on older answers expire
+type followUpStore struct {
+	mu      sync.Mutex
+	threads map[string]*followUpThread
+}
+
+func newFollowUpStore() *followUpStore {
+	return &followUpStore{threads: make(map[string]*followUpThread)}
+}
+
+func (f *followUpStore) put(thread *followUpThread) string {
+	buf := make([]byte, 6)
+	rand.Read(buf)
+	id := hex.EncodeToString(buf)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for key, t := range f.threads {
+		if time.Since(t.createdAt) > followUpTTL {
+			delete(f.threads, key)
+		}
+	}
+	if len(f.threads) >= maxFollowUpThreads {
+		var oldestKey string
+		var oldest time.Time
+		for key, t := range f.threads {
+			if oldestKey == "" || t.createdAt.Before(oldest) {
+				oldestKey, oldest = key, t.createdAt
+			}
+		}
+		delete(f.threads, oldestKey)
+	}
+	f.threads[id] = thread
+	return id
+}
+
+func (f *followUpStore) get(id string) (*followUpThread, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	thread, ok := f.threads[id]
+	if !ok || time.Since(thread.createdAt) > followUpTTL {
+		return nil, false
+	}
+	return thread, true
+}
+
+// attachFollowUps suggests follow-up questions for an answer and adds them as
+// buttons to the interaction's response, after a button to continue the
+// answer when it was truncated
+func (b *Bot) attachFollowUps(s *discordgo.Session, interaction *discordgo.Interaction, guildID string, history []ConversationTurn, truncated bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	last := history[len(history)-1]
+	suggestions, err := b.suggestFollowUps(tenant.WithGuild(ctx, parseSnowflake(guildID)), last.Question, last.Answer)
+	if err != nil {
+		log.Printf("⚠️ Failed to suggest follow-ups: %v", err)
+	}
+	if len(suggestions) == 0 && !truncated {
+		return
+	}
+
+	if len(history) > maxHistoryTurns {
+		history = history[len(history)-maxHistoryTurns:]
+	}
+	id := b.followUps.put(&followUpThread{
+		history:     history,
+		suggestions: suggestions,
+		createdAt:   time.Now(),
+	})
+
+	buttons := make([]discordgo.MessageComponent, 0, len(suggestions)+1)
+	if truncated {
+		buttons = append(buttons, discordgo.Button{
+			Label:    "Continue",
+			Style:    discordgo.PrimaryButton,
+			CustomID: fmt.Sprintf("%s:%s", continuePrefix, id),
+			Emoji:    &discordgo.ComponentEmoji{Name: "⏩"},
+		})
+	}
+	for n, question := range suggestions {
+		buttons = append(buttons, discordgo.Button{
+			Label:    truncateText(question, maxFollowUpLabel),
+			Style:    discordgo.SecondaryButton,
+			CustomID: fmt.Sprintf("%s:%s:%d", followUpPrefix, id, n),
+			Emoji:    &discordgo.ComponentEmoji{Name: "💡"},
+		})
+	}
+	components := []discordgo.MessageComponent{discordgo.ActionsRow{Components: buttons}}
+	if _, err := s.InteractionResponseEdit(interaction, &discordgo.WebhookEdit{Components: &components}); err != nil {
+		log.Printf("❌ Failed to attach follow-ups: %v", err)
+	}
+}
+
+func (b *Bot) suggestFollowUps(ctx context.Context, question, answer string) ([]string, error) {
+	prompt := fmt.Sprintf("QUESTION:\n%s\n\nANSWER:\n%s", question, truncateText(answer, 1500))
+	reply, err := b.aiService.Complete(ctx, followUpSystemPrompt, prompt, 150)
+	if err != nil {
+		return nil, err
+	}
+
+	start, end := strings.Index(reply, "["), strings.LastIndex(reply, "]")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("no JSON array in follow-up suggestions: %q", reply)
+	}
+	var raw []string
+	if err := json.Unmarshal([]byte(reply[start:end+1]), &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse follow-up suggestions: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	var suggestions []string
+	for _, q := range raw {
+		q = strings.TrimSpace(q)
+		if q == "" || seen[strings.ToLower(q)] {
+			continue
+		}
+		seen[strings.ToLower(q)] = true
+		suggestions = append(suggestions, q)
+		if len(suggestions) == maxFollowUps {
+			break
+		}
+	}
+	return suggestions, nil
+}
+
+// handleFollowUp answers a clicked follow-up question as a new message,
+// carrying the earlier exchanges along
+func (b *Bot) handleFollowUp(s *discordgo.Session, i *discordgo.InteractionCreate, args []string) {
+	if len(args) != 2 {
+		return
+	}
+	thread, ok := b.followUps.get(args[0])
+	n, _ := strconv.Atoi(args[1])
+	if !ok || n < 0 || n >= len(thread.suggestions) {
+		respondEphemeral(s, i, "⌛ That suggestion has expired. Ask it with `/ask` instead.")
+		return
+	}
+	question := thread.suggestions[n]
+	user := interactionUser(i)
+
+	if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	}); err != nil {
+		log.Printf("❌ Failed to defer interaction: %v", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 25*time.Second)
+	defer cancel()
+	ctx, truncated := persona.WithTruncationReport(ctx)
+
+	answer, err := b.answerQuestion(ctx, question, user.Username, i.GuildID, i.ChannelID, Conversation{Turns: thread.history})
+	if err != nil {
+		log.Printf("❌ AI service error: %v", err)
+		content := aiErrorMessage(err, "🔧 My circuits are experiencing difficulties. Please try again later.")
+		s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &content})
+		return
+	}
+
+	header := fmt.Sprintf("💡 **%s asked:** %s\n\n", user.Username, question)
+	content := truncateText(header+answer, 2000)
+	reply, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &content})
+	if err != nil {
+		log.Printf("❌ Failed to edit interaction response: %v", err)
+		return
+	}
+	b.noteAnswered(i.GuildID, reply.ID)
+
+	shown, cut := shownAnswer(content, header, answer)
+	history := append(append([]ConversationTurn(nil), thread.history...), ConversationTurn{Question: question, Answer: shown})
+	b.attachFollowUps(s, i.Interaction, i.GuildID, history, cut || truncated())
+}
+
+// historyPrompt renders earlier exchanges so a follow-up can refer back to them
+func historyPrompt(history Conversation) string {
+	if history.Summary == "" && len(history.Turns) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("Earlier in this conversation:\n\n")
+	if history.Summary != "" {
+		sb.WriteString(fmt.Sprintf("Summary of older messages: %s\n\n", history.Summary))
+	}
+	for _, turn := range history.Turns {
+		if turn.Asker != "" {
+			sb.WriteString(fmt.Sprintf("Q (%s): %s\nA: %s\n\n", turn.Asker, turn.Question, truncateText(turn.Answer, maxHistoryAnswer)))
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("Q: %s\nA: %s\n\n", turn.Question, truncateText(turn.Answer, maxHistoryAnswer)))
+	}
+	return sb.String()
+}
@@ -0,0 +1,204 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"discord-tars/internal/events"
+	"discord-tars/internal/i18n"
+	"discord-tars/internal/models"
+	"discord-tars/internal/services/forums"
+	"discord-tars/internal/tenant"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func similarPostsCommand() *discordgo.ApplicationCommand {
+	return &discordgo.ApplicationCommand{
+		Name:        "similar-posts",
+		Description: "Find earlier forum posts like this one, solved ones first",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "query",
+				Description: "What the post is about; defaults to the forum post you're in",
+				MaxLength:   300,
+			},
+		},
+	}
+}
+
+func (b *Bot) handleSimilarPostsCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if b.forumService == nil {
+		respondEphemeral(s, i, "🔧 Forum support is not enabled on this instance.")
+		return
+	}
+	if i.GuildID == "" {
+		respondEphemeral(s, i, "🔒 This command only works in a server.")
+		return
+	}
+
+	var query string
+	if opt, ok := optionMap(i.ApplicationCommandData().Options)["query"]; ok {
+		query = strings.TrimSpace(opt.StringValue())
+	}
+
+	ctx, cancel := context.WithTimeout(tenant.WithGuild(context.Background(), parseSnowflake(i.GuildID)), 15*time.Second)
+	defer cancel()
+
+	// Without a query, look for posts like the one the command runs in
+	exclude := int64(0)
+	if thread, forum, ok := b.forumService.ForumPost(i.ChannelID); ok {
+		exclude = parseSnowflake(thread.ID)
+		if query == "" {
+			post, err := b.forumService.Post(ctx, exclude)
+			if err != nil {
+				log.Printf("⚠️ %v", err)
+			}
+			content := ""
+			if post != nil {
+				content = post.Content
+			}
+			query = forums.PostText(thread.Name, forums.TagNames(thread, forum), content)
+		}
+	}
+	if query == "" {
+		respondEphemeral(s, i, tr(i, "forums.no_query"))
+		return
+	}
+
+	results, err := b.forumService.Similar(ctx, parseSnowflake(i.GuildID), exclude, query)
+	if err != nil {
+		log.Printf("❌ Failed to find similar forum posts: %v", err)
+		respondEphemeral(s, i, aiErrorMessage(err, tr(i, "forums.search_failed")))
+		return
+	}
+	results = readablePosts(s, interactionUser(i).ID, results)
+	if len(results) == 0 {
+		respondEphemeral(s, i, tr(i, "forums.none"))
+		return
+	}
+	respondEphemeral(s, i, truncateText(tr(i, "forums.found")+"\n"+similarPostLines(results, tr(i, "forums.answer")), 2000))
+}
+
+// trackForumPost stores new forum posts and, when enabled, points their
+// authors at similar earlier posts
+func (b *Bot) trackForumPost(ctx context.Context, event *events.Event) error {
+	if b.forumService == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	post, err := b.forumService.HandleMessage(ctx, event.Message)
+	if err != nil {
+		return fmt.Errorf("failed to store forum post %s: %w", event.Message.ChannelID, err)
+	}
+	if post == nil || !b.config.ForumSuggestions {
+		return nil
+	}
+	// Help forums get an answer suggestion instead
+	if b.helpdeskService != nil && b.helpdeskService.IsHelpChannel(ctx, post.ForumID) {
+		return nil
+	}
+
+	results, err := b.forumService.Similar(ctx, post.GuildID, post.ThreadID, forums.PostText(post.Title, splitTags(post.Tags), post.Content))
+	if err != nil {
+		return fmt.Errorf("failed to find posts similar to %d: %w", post.ThreadID, err)
+	}
+	// Only suggest posts in forums the author can open
+	results = readablePosts(b.session, event.Message.Author.ID, results)
+	if len(results) == 0 {
+		return nil
+	}
+	locale := guildLocale(b.session, event.Message.GuildID)
+	content := truncateText(i18n.T(locale, "forums.suggestion")+"\n"+similarPostLines(results, i18n.T(locale, "forums.answer")), 2000)
+	if _, err := b.sendReply(event.Message, &discordgo.MessageSend{
+		Content:   content,
+		Reference: event.Message.Reference(),
+		Flags:     discordgo.MessageFlagsSuppressEmbeds,
+	}); err != nil {
+		return fmt.Errorf("failed to suggest similar posts in %s: %w", event.Message.ChannelID, err)
+	}
+	return nil
+}
+
+// acceptForumAnswer records a check-mark reaction from a post's author as
+// the answer that solved it
+func (b *Bot) acceptForumAnswer(ctx context.Context, event *events.Event) error {
+	if b.forumService == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	if _, err := b.forumService.HandleReaction(ctx, event.Reaction); err != nil {
+		return fmt.Errorf("failed to accept answer %s: %w", event.Reaction.MessageID, err)
+	}
+	return nil
+}
+
+// onThreadUpdate keeps forum posts' titles and tags, and so whether a solved
+// tag was applied, up to date
+func (b *Bot) onThreadUpdate(s *discordgo.Session, t *discordgo.ThreadUpdate) {
+	if b.forumService == nil || t.Channel == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(tenant.WithGuild(context.Background(), parseSnowflake(t.GuildID)), 10*time.Second)
+	defer cancel()
+	if err := b.forumService.HandleThreadUpdate(ctx, t.Channel); err != nil {
+		log.Printf("❌ Failed to update forum post %s: %v", t.ID, err)
+	}
+}
+
+// readablePosts keeps the posts in forums the user can read
+func readablePosts(s *discordgo.Session, userID string, results []models.ForumPostResult) []models.ForumPostResult {
+	readable := make(map[int64]bool)
+	kept := results[:0]
+	for _, result := range results {
+		ok, checked := readable[result.Post.ForumID]
+		if !checked {
+			ok = userCanRead(s, userID, fmt.Sprint(result.Post.ForumID))
+			readable[result.Post.ForumID] = ok
+		}
+		if ok {
+			kept = append(kept, result)
+		}
+	}
+	return kept
+}
+
+// similarPostLines lists forum posts with their tags, marking solved ones and
+// linking their accepted answer
+func similarPostLines(results []models.ForumPostResult, answerLabel string) string {
+	lines := make([]string, len(results))
+	for n, result := range results {
+		post := result.Post
+		title := strings.NewReplacer("[", "(", "]", ")").Replace(post.Title)
+		line := fmt.Sprintf("• [%s](https://discord.com/channels/%d/%d)", truncateText(title, 90), post.GuildID, post.ThreadID)
+		if post.Solved {
+			line = "✅ " + strings.TrimPrefix(line, "• ")
+			if post.SolutionMessageID != 0 {
+				line += fmt.Sprintf(" → [%s](https://discord.com/channels/%d/%d/%d)", answerLabel, post.GuildID, post.ThreadID, post.SolutionMessageID)
+			}
+		}
+		if post.Tags != "" {
+			line += " · " + post.Tags
+		}
+		lines[n] = line
+	}
+	return strings.Join(lines, "\n")
+}
+
+func splitTags(tags string) []string {
+	if tags == "" {
+		return nil
+	}
+	return strings.Split(tags, ", ")
+}
+
+// SetForumService enables forum post tracking and /similar-posts
+func (b *Bot) SetForumService(forumService *forums.Service) {
+	b.forumService = forumService
+}
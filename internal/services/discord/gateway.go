@@ -0,0 +1,179 @@
+package discord
+
+import (
+	"log"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	gatewayMinBackoff = time.Second
+	gatewayMaxBackoff = 5 * time.Minute
+)
+
+// gateway tracks the Discord websocket connection. discordgo's own reconnect
+// is turned off: the bot reconnects with jittered backoff, so replicas that
+// lost the gateway together don't retry in lockstep, and rebuilds its state
+// once the connection is back.
+type gateway struct {
+	mu           sync.Mutex
+	connected    bool
+	since        time.Time // When the connection last came up or went down
+	sessions     int       // READY events received; more than one means a new session replaced a lost one
+	disconnects  int64
+	reconnects   int64
+	resumes      int64
+	reconnecting bool
+	stopping     bool
+	stop         chan struct{}
+}
+
+func newGateway() *gateway {
+	return &gateway{stop: make(chan struct{})}
+}
+
+// GatewayStats describes the gateway connection, for /status and metrics
+type GatewayStats struct {
+	Connected   bool          `json:"connected"`
+	Since       time.Time     `json:"since"`
+	Latency     time.Duration `json:"heartbeat_latency_ns"`
+	Disconnects int64         `json:"disconnects"`
+	Reconnects  int64         `json:"reconnects"`
+	Resumes     int64         `json:"resumes"`
+	Guilds      int           `json:"guilds"`
+	Voice       int           `json:"voice_connections"`
+}
+
+// GatewayStats reports the state of the gateway connection
+func (b *Bot) GatewayStats() GatewayStats {
+	g := b.gateway
+	g.mu.Lock()
+	stats := GatewayStats{
+		Connected:   g.connected,
+		Since:       g.since,
+		Disconnects: g.disconnects,
+		Reconnects:  g.reconnects,
+		Resumes:     g.resumes,
+	}
+	g.mu.Unlock()
+
+	if stats.Connected {
+		stats.Latency = b.session.HeartbeatLatency()
+	}
+	b.session.State.RLock()
+	stats.Guilds = len(b.session.State.Guilds)
+	b.session.State.RUnlock()
+	if b.voiceService != nil {
+		stats.Voice = b.voiceService.Stats().Connections
+	}
+	return stats
+}
+
+func (b *Bot) onConnect(s *discordgo.Session, e *discordgo.Connect) {
+	g := b.gateway
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.connected = true
+	g.since = time.Now()
+}
+
+// onDisconnect reconnects after the gateway connection drops, unless the bot
+// is shutting down
+func (b *Bot) onDisconnect(s *discordgo.Session, e *discordgo.Disconnect) {
+	g := b.gateway
+	g.mu.Lock()
+	if g.connected {
+		g.connected = false
+		g.since = time.Now()
+		g.disconnects++
+	}
+	if g.stopping || g.reconnecting {
+		g.mu.Unlock()
+		return
+	}
+	g.reconnecting = true
+	g.mu.Unlock()
+
+	log.Printf("🔌 Disconnected from the Discord gateway, reconnecting")
+	go b.reconnectGateway(s)
+}
+
+// reconnectGateway reopens the gateway until it succeeds or the bot stops.
+// discordgo resumes the session when it can, which fires onResumed; otherwise
+// it identifies again and onReady follows.
+func (b *Bot) reconnectGateway(s *discordgo.Session) {
+	g := b.gateway
+	defer func() {
+		g.mu.Lock()
+		g.reconnecting = false
+		g.mu.Unlock()
+	}()
+
+	backoff := gatewayMinBackoff
+	for attempt := 1; ; attempt++ {
+		err := s.Open()
+		if err == nil || err == discordgo.ErrWSAlreadyOpen {
+			g.mu.Lock()
+			g.reconnects++
+			g.mu.Unlock()
+			log.Printf("✅ Reconnected to the Discord gateway (attempt %d)", attempt)
+			return
+		}
+
+		// Jittered within the upper half of the backoff
+		wait := backoff/2 + rand.N(backoff/2+1)
+		log.Printf("⚠️ Failed to reconnect to the Discord gateway (attempt %d), retrying in %s: %v", attempt, wait.Round(time.Second), err)
+		select {
+		case <-g.stop:
+			return
+		case <-time.After(wait):
+		}
+		backoff = min(backoff*2, gatewayMaxBackoff)
+	}
+}
+
+func (b *Bot) onResumed(s *discordgo.Session, e *discordgo.Resumed) {
+	b.gateway.mu.Lock()
+	b.gateway.resumes++
+	b.gateway.mu.Unlock()
+
+	log.Printf("🔁 Resumed the Discord gateway session")
+	b.resync(s)
+}
+
+// newSession records a READY event and reports whether it replaces a
+// session lost to a disconnect
+func (g *gateway) newSession() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.sessions++
+	return g.sessions > 1
+}
+
+// resync rebuilds what a reconnect may have lost: voice connections that
+// didn't survive it and the bot's presence, which a new session starts without
+func (b *Bot) resync(s *discordgo.Session) {
+	if b.voiceService != nil {
+		b.voiceService.Resync()
+	}
+	b.presence.mu.Lock()
+	shown := b.presence.current >= 0
+	b.presence.mu.Unlock()
+	if shown {
+		b.showPresence(s, false)
+	}
+}
+
+// stopGateway keeps the disconnect on shutdown from triggering a reconnect
+func (b *Bot) stopGateway() {
+	g := b.gateway
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if !g.stopping {
+		g.stopping = true
+		close(g.stop)
+	}
+}
@@ -0,0 +1,164 @@
+package discord
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"discord-tars/internal/services/github"
+	"discord-tars/internal/tenant"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func githubCommand() *discordgo.ApplicationCommand {
+	repoOption := &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionString,
+		Name:        "repo",
+		Description: "Repository as owner/name",
+		Required:    true,
+	}
+	channelOption := &discordgo.ApplicationCommandOption{
+		Type:         discordgo.ApplicationCommandOptionChannel,
+		Name:         "channel",
+		Description:  "Announcement channel (defaults to this one)",
+		ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText, discordgo.ChannelTypeGuildNews},
+	}
+
+	return &discordgo.ApplicationCommand{
+		Name:        "github",
+		Description: "GitHub pull request summaries and repository announcements",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "summarize",
+				Description: "Summarize a pull request and its diff",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "pr",
+						Description: "Pull request URL",
+						Required:    true,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "subscribe",
+				Description: "Announce releases and new issues of a repository (admins only)",
+				Options:     []*discordgo.ApplicationCommandOption{repoOption, channelOption},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "unsubscribe",
+				Description: "Stop announcing a repository (admins only)",
+				Options:     []*discordgo.ApplicationCommandOption{repoOption, channelOption},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "list",
+				Description: "Show repository subscriptions",
+			},
+		},
+	}
+}
+
+func (b *Bot) handleGitHubCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if b.githubService == nil {
+		respondEphemeral(s, i, "🔧 The GitHub integration is not enabled on this instance.")
+		return
+	}
+
+	sub := i.ApplicationCommandData().Options[0]
+	opts := optionMap(sub.Options)
+
+	switch sub.Name {
+	case "summarize":
+		if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		}); err != nil {
+			log.Printf("❌ Failed to defer interaction: %v", err)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+		defer cancel()
+
+		summary, err := b.githubService.SummarizePullRequest(tenant.WithGuild(ctx, parseSnowflake(i.GuildID)), opts["pr"].StringValue())
+		if err != nil {
+			log.Printf("❌ Failed to summarize pull request: %v", err)
+			summary = "🔧 I couldn't fetch or summarize that pull request. Check the URL and that the repository is accessible."
+			if errors.Is(err, github.ErrInvalidPRURL) {
+				summary = "🔧 That doesn't look like a pull request URL. Expected https://github.com/owner/repo/pull/123."
+			}
+		}
+		summary = truncateText(summary, 2000)
+		s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &summary})
+
+	case "subscribe", "unsubscribe":
+		if !isGuildAdmin(i) {
+			respondEphemeral(s, i, tr(i, "admin_only.github"))
+			return
+		}
+		channelID := i.ChannelID
+		if opt, ok := opts["channel"]; ok {
+			channelID = opt.ChannelValue(s).ID
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		repo := opts["repo"].StringValue()
+		if sub.Name == "subscribe" {
+			normalized, err := b.githubService.Subscribe(ctx, parseSnowflake(i.GuildID), parseSnowflake(channelID), parseSnowflake(interactionUser(i).ID), repo)
+			if err != nil {
+				log.Printf("❌ Failed to subscribe to github repo: %v", err)
+				respondEphemeral(s, i, fmt.Sprintf("🔧 Could not subscribe: %v", err))
+				return
+			}
+			respondEphemeral(s, i, fmt.Sprintf("✅ Releases and new issues of **%s** will be announced in <#%s>.\nPoint the repository's webhook at `/webhooks/github` on this bot's HTTP server.", normalized, channelID))
+			return
+		}
+
+		removed, err := b.githubService.Unsubscribe(ctx, parseSnowflake(channelID), repo)
+		if err != nil {
+			log.Printf("❌ Failed to unsubscribe from github repo: %v", err)
+			respondEphemeral(s, i, "🔧 Failed to unsubscribe. Please try again.")
+			return
+		}
+		if !removed {
+			respondEphemeral(s, i, fmt.Sprintf("ℹ️ <#%s> was not subscribed to that repository.", channelID))
+			return
+		}
+		respondEphemeral(s, i, fmt.Sprintf("✅ Stopped announcing that repository in <#%s>.", channelID))
+
+	case "list":
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		subs, err := b.githubService.ListSubscriptions(ctx, parseSnowflake(i.GuildID))
+		if err != nil {
+			log.Printf("❌ Failed to list github subscriptions: %v", err)
+			respondEphemeral(s, i, "🔧 Failed to load subscriptions. Please try again.")
+			return
+		}
+		if len(subs) == 0 {
+			respondEphemeral(s, i, "🐙 No repositories are announced here yet.")
+			return
+		}
+		var sb strings.Builder
+		sb.WriteString("🐙 **GitHub subscriptions:**\n")
+		for _, sub := range subs {
+			sb.WriteString(fmt.Sprintf("• **%s** → <#%d>\n", sub.Repo, sub.ChannelID))
+		}
+		respondEphemeral(s, i, sb.String())
+	}
+}
+
+// SetGitHubService enables the /github command
+func (b *Bot) SetGitHubService(githubService *github.Service) {
+	b.githubService = githubService
+}
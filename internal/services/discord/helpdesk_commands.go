@@ -0,0 +1,201 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"discord-tars/internal/events"
+	"discord-tars/internal/i18n"
+	"discord-tars/internal/services/agent"
+	"discord-tars/internal/services/helpdesk"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func helpChannelsCommand() *discordgo.ApplicationCommand {
+	return &discordgo.ApplicationCommand{
+		Name:        "help-channels",
+		Description: "Suggest earlier answers to new posts in help channels (admins only)",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "add",
+				Description: "Suggest answers to new posts in a channel or forum",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:         discordgo.ApplicationCommandOptionChannel,
+						Name:         "channel",
+						Description:  "Help channel or forum",
+						Required:     true,
+						ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText, discordgo.ChannelTypeGuildForum},
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "remove",
+				Description: "Stop suggesting answers in a channel",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionChannel,
+						Name:        "channel",
+						Description: "Channel to remove",
+						Required:    true,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "list",
+				Description: "Show the help channels",
+			},
+		},
+	}
+}
+
+func (b *Bot) handleHelpChannelsCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if b.helpdeskService == nil {
+		respondEphemeral(s, i, "🔧 Answer suggestions are not enabled on this instance.")
+		return
+	}
+	if i.GuildID == "" {
+		respondEphemeral(s, i, "🔒 This command only works in a server.")
+		return
+	}
+	if !isGuildAdmin(i) {
+		respondEphemeral(s, i, tr(i, "admin_only.help_channels"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	guildID := parseSnowflake(i.GuildID)
+	sub := i.ApplicationCommandData().Options[0]
+	opts := optionMap(sub.Options)
+
+	switch sub.Name {
+	case "add":
+		channel := opts["channel"].ChannelValue(s)
+		if err := b.helpdeskService.AddChannel(ctx, guildID, parseSnowflake(channel.ID), parseSnowflake(interactionUser(i).ID)); err != nil {
+			log.Printf("❌ Failed to add help channel: %v", err)
+			respondEphemeral(s, i, "🔧 Failed to add the channel. Please try again.")
+			return
+		}
+		respondEphemeral(s, i, fmt.Sprintf("💡 New posts in <#%s> that were already answered on the server get a link to the earlier answers and a short summary of them, at most once per thread every %s.",
+			channel.ID, agent.FormatDuration(b.helpdeskService.Cooldown())))
+
+	case "remove":
+		channel := opts["channel"].ChannelValue(s)
+		removed, err := b.helpdeskService.RemoveChannel(ctx, guildID, parseSnowflake(channel.ID))
+		if err != nil {
+			log.Printf("❌ Failed to remove help channel: %v", err)
+			respondEphemeral(s, i, "🔧 Failed to remove the channel. Please try again.")
+			return
+		}
+		if !removed {
+			respondEphemeral(s, i, fmt.Sprintf("ℹ️ <#%s> was not a help channel.", channel.ID))
+			return
+		}
+		respondEphemeral(s, i, fmt.Sprintf("✅ <#%s> is no longer a help channel.", channel.ID))
+
+	case "list":
+		channels, err := b.helpdeskService.Channels(ctx, guildID)
+		if err != nil {
+			log.Printf("❌ Failed to list help channels: %v", err)
+			respondEphemeral(s, i, "🔧 Failed to load the help channels. Please try again.")
+			return
+		}
+		if len(channels) == 0 {
+			respondEphemeral(s, i, "💡 No help channels yet. Run `/help-channels add` to suggest earlier answers to new posts in one.")
+			return
+		}
+		var sb strings.Builder
+		sb.WriteString("💡 **Help channels**\n")
+		for _, ch := range channels {
+			sb.WriteString(fmt.Sprintf("• <#%d>, added by <@%d>\n", ch.ChannelID, ch.AddedBy))
+		}
+		respondEphemeral(s, i, sb.String())
+	}
+}
+
+// suggestHelpAnswer replies to new posts in help channels that were already
+// answered with links to the earlier answers and a short summary of them
+func (b *Bot) suggestHelpAnswer(ctx context.Context, event *events.Event) error {
+	msg := event.Message
+	if b.helpdeskService == nil || msg.GuildID == "" || msg.Author == nil || msg.Author.Bot || !helpdesk.IsPost(msg.Content) {
+		return nil
+	}
+	// Questions asked to the bot get a full answer instead
+	if b.isBotMentioned(&discordgo.MessageCreate{Message: msg}) {
+		return nil
+	}
+	post, ok := b.helpPost(ctx, msg)
+	if !ok {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	// The poster must be able to open every link
+	suggestion, err := b.helpdeskService.Suggest(ctx, post, channelReader(b.session, msg.Author.ID))
+	if err != nil {
+		return fmt.Errorf("failed to suggest an answer in %s: %w", msg.ChannelID, err)
+	}
+	if suggestion == nil {
+		return nil
+	}
+
+	locale := guildLocale(b.session, msg.GuildID)
+	cited := suggestion.Cited()
+	links := make([]string, len(cited))
+	for n, number := range cited {
+		links[n] = fmt.Sprintf("[%d](%s)", number, storedMessageLink(suggestion.Sources[number-1].Message))
+	}
+	header := i18n.T(locale, "help_answer.suggestion", strings.Join(links, " · ")) + "\n"
+	footer := "\n-# " + i18n.T(locale, "help_answer.footer")
+	if _, err := b.sendReply(msg, &discordgo.MessageSend{
+		Content:         header + truncateText(suggestion.Answer, 2000-len(header)-len(footer)) + footer,
+		Reference:       msg.Reference(),
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+		Flags:           discordgo.MessageFlagsSuppressEmbeds,
+	}); err != nil {
+		return fmt.Errorf("failed to send answer suggestion in %s: %w", msg.ChannelID, err)
+	}
+	log.Printf("💡 Suggested %d earlier answers in channel %s", len(cited), msg.ChannelID)
+	return nil
+}
+
+// helpPost tells whether a message is a new post in a help channel: any
+// message in the channel itself, or in one of its threads or forum posts, a
+// message from whoever opened it
+func (b *Bot) helpPost(ctx context.Context, msg *discordgo.Message) (helpdesk.Post, bool) {
+	post := helpdesk.Post{
+		GuildID:   parseSnowflake(msg.GuildID),
+		ChannelID: parseSnowflake(msg.ChannelID),
+		MessageID: parseSnowflake(msg.ID),
+		Content:   msg.Content,
+	}
+	if b.helpdeskService.IsHelpChannel(ctx, post.ChannelID) {
+		return post, true
+	}
+
+	channel, err := b.session.State.Channel(msg.ChannelID)
+	if err != nil {
+		if channel, err = b.session.Channel(msg.ChannelID); err != nil {
+			return post, false
+		}
+	}
+	if !channel.IsThread() || channel.OwnerID != msg.Author.ID || !b.helpdeskService.IsHelpChannel(ctx, parseSnowflake(channel.ParentID)) {
+		return post, false
+	}
+	post.InThread = true
+	return post, true
+}
+
+// SetHelpdeskService enables /help-channels and answer suggestions
+func (b *Bot) SetHelpdeskService(helpdeskService *helpdesk.Service) {
+	b.helpdeskService = helpdeskService
+}
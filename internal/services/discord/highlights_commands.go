@@ -0,0 +1,212 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"discord-tars/internal/events"
+	"discord-tars/internal/models"
+	"discord-tars/internal/services/highlights"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func highlightsCommand() *discordgo.ApplicationCommand {
+	minThreshold := 1.0
+	minHour := 0.0
+	return &discordgo.ApplicationCommand{
+		Name:        "highlights",
+		Description: "Copy the most reacted-to messages to a highlights channel (admins only)",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "setup",
+				Description: "Turn on highlights, or change their settings",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:         discordgo.ApplicationCommandOptionChannel,
+						Name:         "channel",
+						Description:  "Channel where highlights are posted",
+						Required:     true,
+						ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText},
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "threshold",
+						Description: fmt.Sprintf("Reactions a message needs (default %d)", highlights.DefaultThreshold),
+						MinValue:    &minThreshold,
+						MaxValue:    100,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "emoji",
+						Description: "Only count this emoji, e.g. ⭐ (default: every reaction)",
+						MaxLength:   100,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionBoolean,
+						Name:        "weekly",
+						Description: "Post an AI-picked best of the week (default on)",
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "weekday",
+						Description: "Day of the best of the week (default Friday)",
+						Choices:     weekdayChoices(),
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "hour",
+						Description: "Local hour of the best of the week (0-23, default 17)",
+						MinValue:    &minHour,
+						MaxValue:    23,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "timezone",
+						Description: "IANA timezone, e.g. Europe/Paris (default: the server's timezone)",
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "off",
+				Description: "Stop copying messages to the highlights channel",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "status",
+				Description: "Show the highlights settings",
+			},
+		},
+	}
+}
+
+func (b *Bot) handleHighlightsCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if b.highlightService == nil {
+		respondEphemeral(s, i, "🔧 Highlights are not enabled on this instance.")
+		return
+	}
+	if i.GuildID == "" {
+		respondEphemeral(s, i, "🔒 This command only works in a server.")
+		return
+	}
+	if !isGuildAdmin(i) {
+		respondEphemeral(s, i, tr(i, "admin_only.highlights"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	guildID := parseSnowflake(i.GuildID)
+	sub := i.ApplicationCommandData().Options[0]
+
+	cfg, err := b.highlightService.Config(ctx, guildID)
+	if err != nil {
+		log.Printf("❌ Failed to load highlight config: %v", err)
+		respondEphemeral(s, i, "🔧 Failed to load the settings. Please try again.")
+		return
+	}
+
+	switch sub.Name {
+	case "setup":
+		b.handleHighlightsSetup(ctx, s, i, sub.Options, guildID, cfg)
+	case "off":
+		removed, err := b.highlightService.Disable(ctx, guildID)
+		if err != nil {
+			log.Printf("❌ Failed to disable highlights: %v", err)
+			respondEphemeral(s, i, "🔧 Failed to save the settings. Please try again.")
+			return
+		}
+		if !removed {
+			respondEphemeral(s, i, "ℹ️ Highlights are already off.")
+			return
+		}
+		respondEphemeral(s, i, "✅ Highlights are off. Messages already highlighted stay where they are.")
+	case "status":
+		if cfg == nil {
+			respondEphemeral(s, i, "⭐ Highlights are off. Run `/highlights setup` to copy the most reacted-to messages to a channel.")
+			return
+		}
+		respondEphemeral(s, i, "⭐ "+describeHighlights(cfg))
+	}
+}
+
+func (b *Bot) handleHighlightsSetup(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, options []*discordgo.ApplicationCommandInteractionDataOption, guildID int64, previous *models.HighlightConfig) {
+	opts := optionMap(options)
+	channel := opts["channel"].ChannelValue(s)
+
+	cfg := &models.HighlightConfig{
+		GuildID:       guildID,
+		Threshold:     highlights.DefaultThreshold,
+		WeeklyDigest:  true,
+		DigestWeekday: int(time.Friday),
+		DigestHour:    17,
+		Timezone:      b.serverTimezone(ctx, i),
+	}
+	// Settings left out keep their current value
+	if previous != nil {
+		*cfg = *previous
+	}
+	cfg.ChannelID = parseSnowflake(channel.ID)
+	if opt, ok := opts["threshold"]; ok {
+		cfg.Threshold = int(opt.IntValue())
+	}
+	if opt, ok := opts["emoji"]; ok {
+		cfg.Emoji = opt.StringValue()
+	}
+	if opt, ok := opts["weekly"]; ok {
+		cfg.WeeklyDigest = opt.BoolValue()
+	}
+	if opt, ok := opts["weekday"]; ok {
+		cfg.DigestWeekday = int(opt.IntValue())
+	}
+	if opt, ok := opts["hour"]; ok {
+		cfg.DigestHour = int(opt.IntValue())
+	}
+	if opt, ok := opts["timezone"]; ok {
+		cfg.Timezone = strings.TrimSpace(opt.StringValue())
+	}
+
+	if err := b.highlightService.Configure(ctx, cfg); err != nil {
+		log.Printf("❌ Failed to save highlight config: %v", err)
+		respondEphemeral(s, i, fmt.Sprintf("🔧 Could not save the settings: %v", err))
+		return
+	}
+	respondEphemeral(s, i, "✅ "+describeHighlights(cfg)+"\nMake sure I can send messages there. Only messages from channels everyone can read are copied.")
+}
+
+func describeHighlights(cfg *models.HighlightConfig) string {
+	var sb strings.Builder
+	reaction := "reactions"
+	if cfg.Emoji != "" {
+		reaction = highlights.DisplayEmoji(cfg.Emoji) + " reactions"
+	}
+	fmt.Fprintf(&sb, "Messages with %d %s are copied to <#%d>.", cfg.Threshold, reaction, cfg.ChannelID)
+	if cfg.WeeklyDigest {
+		fmt.Fprintf(&sb, " The best of the week is posted there every %s at %02d:00 (%s).", time.Weekday(cfg.DigestWeekday), cfg.DigestHour, cfg.Timezone)
+	}
+	return sb.String()
+}
+
+// updateHighlight copies messages that reach the reaction threshold to the
+// guild's highlights channel
+func (b *Bot) updateHighlight(ctx context.Context, event *events.Event) error {
+	if b.highlightService == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+	if err := b.highlightService.HandleReaction(ctx, event.Reaction); err != nil {
+		return fmt.Errorf("failed to update highlights for message %s: %w", event.Reaction.MessageID, err)
+	}
+	return nil
+}
+
+// SetHighlightService enables /highlights and the highlights channel
+func (b *Bot) SetHighlightService(highlightService *highlights.Service) {
+	b.highlightService = highlightService
+}
@@ -0,0 +1,258 @@
+package discord
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/services/incident"
+	"discord-tars/internal/services/summarize"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func incidentCommand() *discordgo.ApplicationCommand {
+	statusChoices := []*discordgo.ApplicationCommandOptionChoice{
+		{Name: "Investigating", Value: models.IncidentInvestigating},
+		{Name: "Identified", Value: models.IncidentIdentified},
+		{Name: "Monitoring", Value: models.IncidentMonitoring},
+	}
+	return &discordgo.ApplicationCommand{
+		Name:        "incident",
+		Description: "Run an outage from a dedicated thread with a pinned status (moderators only)",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "start",
+				Description: "Declare an incident: post a pinned status and open a thread for it",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "title",
+						Description: "What is broken, e.g. Login fails for EU users",
+						Required:    true,
+						MaxLength:   incident.MaxTitleLength,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "severity",
+						Description: "How bad it is",
+						Required:    true,
+						Choices: []*discordgo.ApplicationCommandOptionChoice{
+							{Name: "SEV1 - critical", Value: models.IncidentSev1},
+							{Name: "SEV2 - major", Value: models.IncidentSev2},
+							{Name: "SEV3 - minor", Value: models.IncidentSev3},
+						},
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "description",
+						Description: "What is known so far",
+						MaxLength:   incident.MaxUpdateLength,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "update",
+				Description: "Post a status update (run it in the incident thread)",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "text",
+						Description: "What changed",
+						Required:    true,
+						MaxLength:   incident.MaxUpdateLength,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "status",
+						Description: "New status (default: unchanged)",
+						Choices:     statusChoices,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "resolve",
+				Description: "Resolve the incident and draft a postmortem (run it in the incident thread)",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "summary",
+						Description: "How it was fixed",
+						MaxLength:   incident.MaxUpdateLength,
+					},
+				},
+			},
+		},
+	}
+}
+
+func (b *Bot) handleIncidentCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if b.incidentService == nil {
+		respondEphemeral(s, i, "🔧 Incident mode is not enabled on this instance.")
+		return
+	}
+	if i.GuildID == "" {
+		respondEphemeral(s, i, "🔒 This command only works in a server.")
+		return
+	}
+	if !isModerator(i) {
+		respondEphemeral(s, i, tr(i, "moderators_only.incident"))
+		return
+	}
+
+	sub := i.ApplicationCommandData().Options[0]
+	opts := optionMap(sub.Options)
+	userID := parseSnowflake(interactionUser(i).ID)
+
+	switch sub.Name {
+	case "start":
+		b.handleIncidentStart(s, i, opts, userID)
+	case "update":
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		defer cancel()
+		status := ""
+		if opt, ok := opts["status"]; ok {
+			status = opt.StringValue()
+		}
+		text := opts["text"].StringValue()
+		inc, err := b.incidentService.Update(ctx, parseSnowflake(i.ChannelID), userID, status, text)
+		if err != nil {
+			respondEphemeral(s, i, incidentError(i, err))
+			return
+		}
+		respondUnpinged(s, i, tr(i, "incident.updated", incidentStatus(i, inc.Status), text))
+	case "resolve":
+		b.handleIncidentResolve(s, i, opts, userID)
+	}
+}
+
+func (b *Bot) handleIncidentStart(s *discordgo.Session, i *discordgo.InteractionCreate, opts map[string]*discordgo.ApplicationCommandInteractionDataOption, userID int64) {
+	// Threads can't hold threads of their own
+	if channel, err := s.State.Channel(i.ChannelID); err == nil && channel.IsThread() {
+		respondEphemeral(s, i, tr(i, "incident.in_thread"))
+		return
+	}
+	description := ""
+	if opt, ok := opts["description"]; ok {
+		description = opt.StringValue()
+	}
+
+	b.deferEphemeral(s, i, func(ctx context.Context) string {
+		inc, err := b.incidentService.Start(ctx, parseSnowflake(i.GuildID), parseSnowflake(i.ChannelID), userID,
+			opts["title"].StringValue(), opts["severity"].StringValue(), description)
+		if err != nil {
+			log.Printf("❌ Failed to start incident: %v", err)
+			return tr(i, "incident.start_failed")
+		}
+		return tr(i, "incident.started", inc.ThreadID)
+	})
+}
+
+// handleIncidentResolve resolves the thread's incident, then answers in the
+// thread with the postmortem drafted from its discussion
+func (b *Bot) handleIncidentResolve(s *discordgo.Session, i *discordgo.InteractionCreate, opts map[string]*discordgo.ApplicationCommandInteractionDataOption, userID int64) {
+	threadID := parseSnowflake(i.ChannelID)
+	checkCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	_, err := b.incidentService.Open(checkCtx, threadID)
+	cancel()
+	if err != nil {
+		respondEphemeral(s, i, incidentError(i, err))
+		return
+	}
+	summary := ""
+	if opt, ok := opts["summary"]; ok {
+		summary = opt.StringValue()
+	}
+
+	if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	}); err != nil {
+		log.Printf("❌ Failed to defer interaction: %v", err)
+		return
+	}
+
+	// Interaction tokens stay valid for 15 minutes
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	content := b.resolveIncident(ctx, s, i, threadID, userID, summary)
+	pages := splitMessage(content, 2000, maxResponsePages)
+	if _, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Content:         &pages[0],
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	}); err != nil {
+		log.Printf("❌ Failed to edit interaction response: %v", err)
+		return
+	}
+	for _, page := range pages[1:] {
+		if _, err := s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Content:         page,
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		}); err != nil {
+			log.Printf("❌ Failed to send follow-up page: %v", err)
+			return
+		}
+	}
+}
+
+func (b *Bot) resolveIncident(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, threadID, userID int64, summary string) string {
+	inc, err := b.incidentService.Resolve(ctx, threadID, userID, summary)
+	if err != nil {
+		return incidentError(i, err)
+	}
+	resolved := tr(i, "incident.resolved", userID)
+
+	thread, err := s.State.Channel(i.ChannelID)
+	if err != nil {
+		thread, err = s.Channel(i.ChannelID)
+	}
+	var messages []*discordgo.Message
+	if err == nil {
+		messages, err = fetchThread(s, thread)
+	}
+	if err != nil {
+		log.Printf("❌ Failed to read incident thread %d: %v", threadID, err)
+		return resolved + "\n\n" + tr(i, "incident.postmortem_failed")
+	}
+
+	draft, err := b.incidentService.Postmortem(ctx, inc, summarize.FromDiscord(messages))
+	if err != nil {
+		log.Printf("❌ Failed to draft postmortem of incident %d: %v", inc.ID, err)
+		return resolved + "\n\n" + aiErrorMessage(err, tr(i, "incident.postmortem_failed"))
+	}
+	return resolved + "\n\n" + tr(i, "incident.postmortem_title") + "\n" + draft
+}
+
+// incidentError explains why an incident command couldn't run
+func incidentError(i *discordgo.InteractionCreate, err error) string {
+	switch {
+	case errors.Is(err, incident.ErrNotIncident):
+		return tr(i, "incident.not_incident")
+	case errors.Is(err, incident.ErrResolved):
+		return tr(i, "incident.already_resolved")
+	default:
+		log.Printf("❌ Incident command failed: %v", err)
+		return tr(i, "incident.failed")
+	}
+}
+
+func incidentStatus(i *discordgo.InteractionCreate, status string) string {
+	switch status {
+	case models.IncidentIdentified:
+		return tr(i, "incident.identified")
+	case models.IncidentMonitoring:
+		return tr(i, "incident.monitoring")
+	default:
+		return tr(i, "incident.investigating")
+	}
+}
+
+// SetIncidentService enables /incident
+func (b *Bot) SetIncidentService(incidentService *incident.Service) {
+	b.incidentService = incidentService
+}
@@ -0,0 +1,211 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"discord-tars/internal/injection"
+	"discord-tars/internal/models"
+	"discord-tars/internal/services/rag"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// injectionCommand is how attempts are named in the audit log
+	injectionCommand = "prompt-injection"
+	// injectionAlertCooldown is the minimum time between alerts about a member
+	injectionAlertCooldown = 10 * time.Minute
+	maxInjectionReports    = 5000
+
+	injectionRefusal = "🛡️ I won't follow instructions that try to override my own. Ask me something else!"
+	leakRefusal      = "🛡️ I can't share my instructions."
+)
+
+// injectionGuard screens questions and retrieved context for prompt
+// injection. Attempts are recorded in the audit log and, with alerts on,
+// reported to the moderators' alert channel set up with /toxicity.
+type injectionGuard struct {
+	alerts bool
+
+	mu       sync.Mutex
+	alerted  map[string]time.Time // Last alert per guild and member
+	reported map[int64]bool       // Retrieved messages already recorded
+}
+
+// screenQuestion strips injected instructions from what a member asked. It
+// reports whether there were any; the question is empty when nothing else
+// was asked.
+func (b *Bot) screenQuestion(guildID, channelID string, user *discordgo.User, source, text string) (string, bool) {
+	if b.injection == nil {
+		return text, false
+	}
+	detection, found := injection.Detect(text)
+	if !found {
+		return text, false
+	}
+	b.reportInjection(guildID, channelID, parseSnowflake(user.ID), source, detection)
+
+	stripped, _ := injection.Strip(text)
+	if strings.TrimSpace(strings.ReplaceAll(stripped, injection.Removed, "")) == "" {
+		return "", true
+	}
+	return stripped, true
+}
+
+// screenRetrieved strips injected instructions from retrieved context, which
+// anyone who could post in the server may have planted. Each planted message
+// is recorded once.
+func (p *Pipeline) screenRetrieved(guildID string, rc *rag.RetrievedContext) {
+	if p.injection == nil {
+		return
+	}
+	for n := range rc.Messages {
+		msg := &rc.Messages[n].Message
+		detection, found := injection.Detect(msg.Content)
+		if !found {
+			continue
+		}
+		msg.Content, _ = injection.Strip(msg.Content)
+		if p.injection.firstReport(msg.ID) {
+			p.reportInjection(guildID, strconv.FormatInt(msg.ChannelID, 10), msg.UserID, "context", detection)
+		}
+	}
+	for n := range rc.Priority {
+		rc.Priority[n].Document.Content, _ = injection.Strip(rc.Priority[n].Document.Content)
+	}
+	for n := range rc.Documents {
+		rc.Documents[n].Chunk.Content, _ = injection.Strip(rc.Documents[n].Chunk.Content)
+	}
+	for n := range rc.Summaries {
+		rc.Summaries[n].Summary.Content, _ = injection.Strip(rc.Summaries[n].Summary.Content)
+	}
+}
+
+// checkLeak replaces an answer that gave away the prompt's honeypot
+func (p *Pipeline) checkLeak(guildID, channelID, answer string) string {
+	if p.injection == nil || !injection.Leaked(answer) {
+		return answer
+	}
+	p.reportInjection(guildID, channelID, 0, "answer", injection.Detection{Pattern: "prompt-leak"})
+	return leakRefusal
+}
+
+// reportInjection passes an attempt the pipeline screened out to its report
+// function, or logs it
+func (p *Pipeline) reportInjection(guildID, channelID string, userID int64, source string, detection injection.Detection) {
+	if p.report != nil {
+		p.report(guildID, channelID, userID, source, detection)
+		return
+	}
+	log.Printf("🛡️ Suspected prompt injection (%s) in %s from user %d in guild %s", detection.Pattern, source, userID, guildID)
+}
+
+func (g *injectionGuard) firstReport(messageID int64) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.reported[messageID] {
+		return false
+	}
+	if len(g.reported) >= maxInjectionReports {
+		clear(g.reported)
+	}
+	g.reported[messageID] = true
+	return true
+}
+
+// reportInjection logs an attempt, records it in the audit log and alerts
+// moderators. userID is who wrote the text, or zero for a leaked answer.
+func (b *Bot) reportInjection(guildID, channelID string, userID int64, source string, detection injection.Detection) {
+	log.Printf("🛡️ Suspected prompt injection (%s) in %s from user %d in guild %s", detection.Pattern, source, userID, guildID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if b.auditLog != nil && guildID != "" {
+		options := fmt.Sprintf("source:%s pattern:%s channel:<#%s>", source, detection.Pattern, channelID)
+		if detection.Excerpt != "" {
+			options += " excerpt:" + truncateText(strings.Join(strings.Fields(detection.Excerpt), " "), maxAuditedValue)
+		}
+		b.auditLog.Record(ctx, &models.CommandAudit{
+			GuildID: parseSnowflake(guildID),
+			UserID:  userID,
+			Command: injectionCommand,
+			Options: options,
+			Outcome: models.AuditFlagged,
+		})
+	}
+	if b.injection.alerts && b.toxicityWatcher != nil && guildID != "" && b.injection.shouldAlert(guildID, userID) {
+		b.alertInjection(ctx, guildID, channelID, userID, source, detection)
+	}
+}
+
+func (g *injectionGuard) shouldAlert(guildID string, userID int64) bool {
+	key := fmt.Sprintf("%s:%d", guildID, userID)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if time.Since(g.alerted[key]) < injectionAlertCooldown {
+		return false
+	}
+	if len(g.alerted) >= maxInjectionReports {
+		clear(g.alerted)
+	}
+	g.alerted[key] = time.Now()
+	return true
+}
+
+var injectionSources = map[string]string{
+	"question":   "a question",
+	"attachment": "context attached to a question",
+	"context":    "a message retrieved as context",
+	"answer":     "an answer that leaked the bot's prompt",
+}
+
+// alertInjection posts an attempt to the moderators' alert channel, if the
+// guild has one
+func (b *Bot) alertInjection(ctx context.Context, guildID, channelID string, userID int64, source string, detection injection.Detection) {
+	cfg, err := b.toxicityWatcher.Config(ctx, parseSnowflake(guildID))
+	if err != nil {
+		log.Printf("❌ Failed to load moderator alert settings: %v", err)
+		return
+	}
+	if cfg == nil || !cfg.Enabled {
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("🛡️ **Possible prompt injection** (%s) in %s in <#%s>", detection.Pattern, injectionSources[source], channelID))
+	if userID != 0 {
+		sb.WriteString(fmt.Sprintf(" by <@%d>", userID))
+	}
+	if detection.Excerpt != "" {
+		sb.WriteString("\n> " + truncateText(strings.Join(strings.Fields(detection.Excerpt), " "), 300))
+	}
+	sb.WriteString("\nThe instructions were ignored. Further attempts by the same member are only recorded in `/audit` for a while.")
+
+	_, err = b.session.ChannelMessageSendComplex(strconv.FormatInt(cfg.AlertChannelID, 10), &discordgo.MessageSend{
+		Content:         sb.String(),
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	}, discordgo.WithContext(ctx))
+	if err != nil {
+		log.Printf("❌ Failed to alert moderators about prompt injection: %v", err)
+	}
+}
+
+// SetInjectionGuard screens questions and retrieved context for prompt
+// injection; alerts also reports attempts in the moderators' alert channel
+func (b *Bot) SetInjectionGuard(alerts bool) {
+	b.injection = newInjectionGuard(alerts)
+}
+
+func newInjectionGuard(alerts bool) *injectionGuard {
+	return &injectionGuard{
+		alerts:   alerts,
+		alerted:  make(map[string]time.Time),
+		reported: make(map[int64]bool),
+	}
+}
@@ -0,0 +1,111 @@
+package discord
+
+import (
+	"log"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// respondText sends an immediate interaction response
+func respondText(s *discordgo.Session, i *discordgo.InteractionCreate, content string) {
+	noteOutcome(i, content)
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+		},
+	})
+	if err != nil {
+		log.Printf("❌ Failed to respond to interaction: %v", err)
+	}
+}
+
+// respondEphemeral sends an immediate response only visible to the invoking user
+func respondEphemeral(s *discordgo.Session, i *discordgo.InteractionCreate, content string) {
+	noteOutcome(i, content)
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		log.Printf("❌ Failed to respond to interaction: %v", err)
+	}
+}
+
+// interactionUser returns the invoking user for both guild and DM interactions
+func interactionUser(i *discordgo.InteractionCreate) *discordgo.User {
+	if i.Member != nil {
+		return i.Member.User
+	}
+	return i.User
+}
+
+// optionMap indexes command options by name
+func optionMap(options []*discordgo.ApplicationCommandInteractionDataOption) map[string]*discordgo.ApplicationCommandInteractionDataOption {
+	m := make(map[string]*discordgo.ApplicationCommandInteractionDataOption, len(options))
+	for _, opt := range options {
+		m[opt.Name] = opt
+	}
+	return m
+}
+
+// parseSnowflake converts a Discord ID to the int64 used by the database
+func parseSnowflake(id string) int64 {
+	v, _ := strconv.ParseInt(id, 10, 64)
+	return v
+}
+
+// isGuildAdmin reports whether the invoking member can manage the server
+func isGuildAdmin(i *discordgo.InteractionCreate) bool {
+	if i.Member == nil {
+		return false
+	}
+	return i.Member.Permissions&(discordgo.PermissionAdministrator|discordgo.PermissionManageGuild) != 0
+}
+
+// maxResponsePages caps how many messages one long response may span
+const maxResponsePages = 5
+
+// splitMessage breaks text into at most maxPages chunks of up to size bytes,
+// preferring to cut at line breaks; the last page is truncated if needed
+func splitMessage(text string, size, maxPages int) []string {
+	var pages []string
+	for len(text) > size && len(pages) < maxPages-1 {
+		cut := strings.LastIndex(text[:size], "\n")
+		if cut < size/2 {
+			cut = size
+			for cut > 0 && !utf8.RuneStart(text[cut]) {
+				cut--
+			}
+		}
+		pages = append(pages, strings.TrimRight(text[:cut], "\n"))
+		text = strings.TrimLeft(text[cut:], "\n")
+	}
+	return append(pages, truncateText(text, size))
+}
+
+// isModerator reports whether the invoking member can manage messages
+func isModerator(i *discordgo.InteractionCreate) bool {
+	if i.Member == nil {
+		return false
+	}
+	return i.Member.Permissions&(discordgo.PermissionAdministrator|discordgo.PermissionManageMessages) != 0
+}
+
+// truncateText shortens s to at most max bytes without splitting a character
+func truncateText(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	cut := max - len("…")
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + "…"
+}
@@ -0,0 +1,198 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/services/jobs"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// jobListLimit keeps /jobs list within a Discord message
+const jobListLimit = 10
+
+var jobStatusIcons = map[string]string{
+	models.JobQueued:    "⏳",
+	models.JobRunning:   "🔄",
+	models.JobSucceeded: "✅",
+	models.JobFailed:    "❌",
+	models.JobCancelled: "⏹️",
+}
+
+func jobsCommand() *discordgo.ApplicationCommand {
+	minID := 1.0
+	jobID := []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionInteger,
+			Name:        "id",
+			Description: "Job number, as shown by /jobs list",
+			Required:    true,
+			MinValue:    &minID,
+		},
+	}
+	return &discordgo.ApplicationCommand{
+		Name:        "jobs",
+		Description: "See and manage background jobs such as reindexes and digests (admins only)",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "list",
+				Description: "List the server's latest jobs",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "status",
+						Description: "Only list jobs with this status",
+						Choices: []*discordgo.ApplicationCommandOptionChoice{
+							{Name: "Queued", Value: models.JobQueued},
+							{Name: "Running", Value: models.JobRunning},
+							{Name: "Succeeded", Value: models.JobSucceeded},
+							{Name: "Failed", Value: models.JobFailed},
+							{Name: "Cancelled", Value: models.JobCancelled},
+						},
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "cancel",
+				Description: "Cancel a queued or running job",
+				Options:     jobID,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "retry",
+				Description: "Run a failed or cancelled job again",
+				Options:     jobID,
+			},
+		},
+	}
+}
+
+func (b *Bot) handleJobsCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if b.jobRunner == nil {
+		respondEphemeral(s, i, "🔧 Background jobs are not enabled on this instance.")
+		return
+	}
+	if i.GuildID == "" {
+		respondEphemeral(s, i, "🔒 This command only works in a server.")
+		return
+	}
+	if !isGuildAdmin(i) {
+		respondEphemeral(s, i, tr(i, "admin_only.jobs"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	guildID := parseSnowflake(i.GuildID)
+	sub := i.ApplicationCommandData().Options[0]
+	opts := optionMap(sub.Options)
+
+	switch sub.Name {
+	case "list":
+		status := ""
+		if opt, ok := opts["status"]; ok {
+			status = opt.StringValue()
+		}
+		list, err := b.jobRunner.GuildJobs(ctx, guildID, status, jobListLimit)
+		if err != nil {
+			log.Printf("❌ Failed to list jobs: %v", err)
+			respondEphemeral(s, i, "🔧 Failed to list the jobs. Please try again.")
+			return
+		}
+		respondEphemeral(s, i, formatJobList(list, status))
+
+	case "cancel", "retry":
+		jobID := opts["id"].IntValue()
+		var changed bool
+		var err error
+		if sub.Name == "cancel" {
+			changed, err = b.jobRunner.Cancel(ctx, guildID, jobID)
+		} else {
+			changed, err = b.jobRunner.Retry(ctx, guildID, jobID)
+		}
+		if err != nil {
+			log.Printf("❌ Failed to %s job %d: %v", sub.Name, jobID, err)
+			respondEphemeral(s, i, fmt.Sprintf("🔧 Failed to %s the job. Please try again.", sub.Name))
+			return
+		}
+		if changed {
+			if sub.Name == "cancel" {
+				respondEphemeral(s, i, fmt.Sprintf("⏹️ Job `#%d` cancelled. A running job stops within a few seconds.", jobID))
+			} else {
+				respondEphemeral(s, i, fmt.Sprintf("⏳ Job `#%d` is queued to run again.", jobID))
+			}
+			return
+		}
+		respondEphemeral(s, i, b.jobUnchangedText(ctx, guildID, jobID, sub.Name))
+	}
+}
+
+// jobUnchangedText explains why a job could not be cancelled or retried
+func (b *Bot) jobUnchangedText(ctx context.Context, guildID, jobID int64, action string) string {
+	job, err := b.jobRunner.GuildJob(ctx, guildID, jobID)
+	if err != nil {
+		log.Printf("❌ Failed to get job %d: %v", jobID, err)
+		return "🔧 Failed to look up the job. Please try again."
+	}
+	if job == nil {
+		return fmt.Sprintf("🤷 This server has no job `#%d`.", jobID)
+	}
+	if action == "cancel" {
+		return fmt.Sprintf("🤷 Job `#%d` is %s; only queued or running jobs can be cancelled.", jobID, job.Status)
+	}
+	return fmt.Sprintf("🤷 Job `#%d` is %s; only failed or cancelled jobs can be retried.", jobID, job.Status)
+}
+
+func formatJobList(list []models.Job, status string) string {
+	if len(list) == 0 {
+		if status != "" {
+			return fmt.Sprintf("📭 No %s jobs on this server.", status)
+		}
+		return "📭 No background jobs have run for this server yet."
+	}
+
+	var sb strings.Builder
+	sb.WriteString("🧰 **Background jobs**\n")
+	for _, job := range list {
+		sb.WriteString(fmt.Sprintf("%s `#%d` **%s** — %s", jobStatusIcons[job.Status], job.ID, job.Kind, job.Status))
+		if job.MaxAttempts > 1 && job.Attempts > 0 {
+			sb.WriteString(fmt.Sprintf(", attempt %d/%d", job.Attempts, job.MaxAttempts))
+		}
+		switch {
+		case job.Status == models.JobQueued && job.RunAt.After(time.Now()):
+			sb.WriteString(fmt.Sprintf(", runs <t:%d:R>", job.RunAt.Unix()))
+		case job.FinishedAt != nil:
+			sb.WriteString(fmt.Sprintf(", finished <t:%d:R>", job.FinishedAt.Unix()))
+		case job.StartedAt != nil:
+			sb.WriteString(fmt.Sprintf(", started <t:%d:R>", job.StartedAt.Unix()))
+		default:
+			sb.WriteString(fmt.Sprintf(", queued <t:%d:R>", job.CreatedAt.Unix()))
+		}
+		sb.WriteString("\n")
+		if job.LastError != "" && job.Status != models.JobSucceeded {
+			sb.WriteString("  ↳ " + truncateText(job.LastError, 80) + "\n")
+		}
+	}
+	return sb.String()
+}
+
+// trackJob runs work an admin started as a job of the runner, so it shows up
+// in /jobs and can be cancelled there; without a runner it just runs it
+func (b *Bot) trackJob(ctx context.Context, kind string, guildID int64, payload interface{}, run func(ctx context.Context) error) error {
+	if b.jobRunner == nil {
+		return run(ctx)
+	}
+	return b.jobRunner.Run(ctx, kind, guildID, payload, run)
+}
+
+// SetJobRunner enables /jobs and tracks reindexes and backfills as jobs
+func (b *Bot) SetJobRunner(runner *jobs.Runner) {
+	b.jobRunner = runner
+}
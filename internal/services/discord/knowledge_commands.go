@@ -0,0 +1,193 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/services/rag"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func knowledgeCommand() *discordgo.ApplicationCommand {
+	return &discordgo.ApplicationCommand{
+		Name:        "knowledge",
+		Description: "Manage priority context: pins, rules and announcements (admins only)",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "add",
+				Description: "Index a rules/announcements channel as priority context",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:         discordgo.ApplicationCommandOptionChannel,
+						Name:         "channel",
+						Description:  "Channel to index",
+						Required:     true,
+						ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText, discordgo.ChannelTypeGuildNews},
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "label",
+						Description: "What the channel contains",
+						Choices: []*discordgo.ApplicationCommandOptionChoice{
+							{Name: "Rules", Value: "rules"},
+							{Name: "Announcements", Value: "announcements"},
+							{Name: "FAQ", Value: "faq"},
+							{Name: "Session notes", Value: models.PriorityLabelSessionNotes},
+						},
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "remove",
+				Description: "Stop indexing a channel as priority context",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionChannel,
+						Name:        "channel",
+						Description: "Channel to remove",
+						Required:    true,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "list",
+				Description: "Show priority channels and indexed document count",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "sync",
+				Description: "Re-index pinned messages of every channel",
+			},
+		},
+	}
+}
+
+func (b *Bot) handleKnowledgeCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if b.ragService == nil {
+		respondEphemeral(s, i, "🔧 Knowledge indexing is not enabled on this instance.")
+		return
+	}
+	if !isGuildAdmin(i) {
+		respondEphemeral(s, i, tr(i, "admin_only.knowledge"))
+		return
+	}
+
+	sub := i.ApplicationCommandData().Options[0]
+	opts := optionMap(sub.Options)
+	guildID := parseSnowflake(i.GuildID)
+
+	switch sub.Name {
+	case "add", "sync":
+		// Ingestion fetches and embeds history, so run it behind a deferred response
+		if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Flags: discordgo.MessageFlagsEphemeral},
+		}); err != nil {
+			log.Printf("❌ Failed to defer interaction: %v", err)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		defer cancel()
+
+		var content string
+		if sub.Name == "add" {
+			channel := opts["channel"].ChannelValue(s)
+			label := "rules"
+			if opt, ok := opts["label"]; ok {
+				label = opt.StringValue()
+			}
+			channelID := parseSnowflake(channel.ID)
+			var n int
+			err := b.ragService.AddPriorityChannel(ctx, guildID, channelID, label)
+			if err == nil {
+				job := rag.ChannelJob{GuildID: guildID, ChannelID: channelID}
+				err = b.trackJob(ctx, models.IndexRunPriorityBackfill, guildID, job, func(ctx context.Context) error {
+					var err error
+					n, err = b.ragService.BackfillPriorityChannel(ctx, guildID, channelID)
+					return err
+				})
+			}
+			if err != nil {
+				log.Printf("❌ Failed to add priority channel: %v", err)
+				content = fmt.Sprintf("🔧 Indexed %d messages from <#%s> before an error occurred. Please try again.", n, channel.ID)
+			} else if label == models.PriorityLabelSessionNotes {
+				content = fmt.Sprintf("📜 <#%s> now holds session notes for `/lore`. Indexed %d recaps; new ones are indexed automatically.", channel.ID, n)
+			} else {
+				content = fmt.Sprintf("📜 <#%s> is now priority context (%s). Indexed %d messages; new ones are indexed automatically.", channel.ID, label, n)
+			}
+		} else {
+			n, err := b.ragService.SyncGuildPins(ctx, i.GuildID)
+			if err != nil {
+				log.Printf("❌ Failed to sync pins: %v", err)
+				content = "🔧 Failed to sync pinned messages. Please try again."
+			} else {
+				content = fmt.Sprintf("📌 Synced %d pinned messages across the server.", n)
+			}
+		}
+		s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &content})
+
+	case "remove":
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		channel := opts["channel"].ChannelValue(s)
+		removed, err := b.ragService.RemovePriorityChannel(ctx, parseSnowflake(channel.ID))
+		if err != nil {
+			log.Printf("❌ Failed to remove priority channel: %v", err)
+			respondEphemeral(s, i, "🔧 Failed to remove the channel. Please try again.")
+			return
+		}
+		if !removed {
+			respondEphemeral(s, i, fmt.Sprintf("ℹ️ <#%s> was not a priority channel.", channel.ID))
+			return
+		}
+		respondEphemeral(s, i, fmt.Sprintf("✅ <#%s> is no longer priority context.", channel.ID))
+
+	case "list":
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		channels, err := b.ragService.ListPriorityChannels(ctx, guildID)
+		if err != nil {
+			log.Printf("❌ Failed to list priority channels: %v", err)
+			respondEphemeral(s, i, "🔧 Failed to load priority channels. Please try again.")
+			return
+		}
+		count, err := b.ragService.CountPriorityDocuments(ctx, guildID)
+		if err != nil {
+			log.Printf("⚠️ Failed to count priority documents: %v", err)
+		}
+
+		var sb strings.Builder
+		sb.WriteString(fmt.Sprintf("📚 **Priority context** — %d documents indexed\n", count))
+		if len(channels) == 0 {
+			sb.WriteString("No rules/announcement channels configured; only pinned messages are used.")
+		}
+		for _, ch := range channels {
+			sb.WriteString(fmt.Sprintf("• <#%d> (%s)\n", ch.ChannelID, ch.Label))
+		}
+		respondEphemeral(s, i, sb.String())
+	}
+}
+
+func (b *Bot) onChannelPinsUpdate(s *discordgo.Session, p *discordgo.ChannelPinsUpdate) {
+	if b.ragService == nil || p.GuildID == "" {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	if _, err := b.ragService.SyncPins(ctx, p.ChannelID); err != nil {
+		log.Printf("❌ Failed to sync pins of channel %s: %v", p.ChannelID, err)
+	}
+}
@@ -0,0 +1,57 @@
+package discord
+
+import (
+	"strings"
+	"time"
+
+	"discord-tars/internal/services/dashboard"
+	"discord-tars/internal/services/slo"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// observeLatency records how long an interaction took to handle, from its
+// arrival to the final response, and shows it on the dashboard's live feed
+func (b *Bot) observeLatency(i *discordgo.InteractionCreate, start time.Time) {
+	if b.latency == nil && b.dashboardService == nil {
+		return
+	}
+	command := latencyCommand(i)
+	if command == "" {
+		return
+	}
+	elapsed := time.Since(start)
+	if b.latency != nil {
+		b.latency.Observe(parseSnowflake(i.GuildID), command, elapsed)
+	}
+	if b.dashboardService != nil {
+		b.dashboardService.Publish(dashboard.LiveEvent{
+			Type:      dashboard.LiveCommand,
+			GuildID:   parseSnowflake(i.GuildID),
+			Command:   command,
+			LatencyMS: elapsed.Milliseconds(),
+		})
+	}
+}
+
+// latencyCommand names what an interaction ran for latency stats: the command
+// with its subcommand, e.g. "digest subscribe", or the modal it submitted
+func latencyCommand(i *discordgo.InteractionCreate) string {
+	switch i.Type {
+	case discordgo.InteractionApplicationCommand:
+		data := i.ApplicationCommandData()
+		name := data.Name
+		if len(data.Options) > 0 && data.Options[0].Type == discordgo.ApplicationCommandOptionSubCommand {
+			name += " " + data.Options[0].Name
+		}
+		return name
+	case discordgo.InteractionModalSubmit:
+		return strings.Split(i.ModalSubmitData().CustomID, ":")[0] + " (form)"
+	}
+	return ""
+}
+
+// SetLatencyTracker enables command latency tracking and SLO alerts
+func (b *Bot) SetLatencyTracker(tracker *slo.Tracker) {
+	b.latency = tracker
+}
@@ -0,0 +1,30 @@
+package discord
+
+import (
+	"context"
+	"log"
+
+	"discord-tars/internal/leader"
+)
+
+// SetLeaderElector shares the bot between replicas: only the leader registers
+// slash commands, on connecting or once elected, while every replica answers
+// interactions. Call it before Start.
+func (b *Bot) SetLeaderElector(elector *leader.Elector) {
+	b.elector = elector
+	elector.OnElected(func(ctx context.Context) {
+		// Before the first READY, onReady registers them instead
+		if b.session.State.User == nil {
+			return
+		}
+		if err := b.registerCommands(); err != nil {
+			log.Printf("❌ Failed to register commands: %v", err)
+		}
+	})
+}
+
+// leads reports whether this replica runs singleton work such as command
+// registration; a bot without an elector always does
+func (b *Bot) leads() bool {
+	return b.elector == nil || b.elector.IsLeader()
+}
@@ -0,0 +1,172 @@
+package discord
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// listenTimeout is how long listen mode waits for the next message before
+	// answering what it has
+	listenTimeout = 5 * time.Minute
+	// maxListenChars bounds a question collected over several messages
+	maxListenChars = 6000
+	// maxListeners bounds how many members can be in listen mode at once
+	maxListeners = 500
+)
+
+// Words that start, end and abandon listen mode
+const (
+	listenWord = "listen"
+	doneWord   = "done"
+	cancelWord = "cancel"
+)
+
+// listening is a question being collected from one member's messages in a
+// channel, to be answered as one when they say done
+type listening struct {
+	trigger *discordgo.MessageCreate // The "@TARS listen" message
+	parts   []string
+	size    int
+	timer   *time.Timer
+}
+
+// listenStore tracks listen mode per member and channel
+type listenStore struct {
+	mu       sync.Mutex
+	sessions map[string]*listening
+}
+
+func newListenStore() *listenStore {
+	return &listenStore{sessions: make(map[string]*listening)}
+}
+
+func listenKey(channelID, userID string) string {
+	return channelID + ":" + userID
+}
+
+// start puts a member in listen mode, replacing any question they were
+// already dictating there; it reports false when too many members listen
+func (l *listenStore) start(m *discordgo.MessageCreate, onTimeout func()) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	key := listenKey(m.ChannelID, m.Author.ID)
+	if previous, ok := l.sessions[key]; ok {
+		previous.timer.Stop()
+	} else if len(l.sessions) >= maxListeners {
+		return false
+	}
+	l.sessions[key] = &listening{trigger: m, timer: time.AfterFunc(listenTimeout, onTimeout)}
+	return true
+}
+
+// add appends a message to a member's question and restarts the timeout,
+// reporting false if the question would grow too long
+func (l *listenStore) add(key, part string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	session := l.sessions[key]
+	if session.size+len(part) > maxListenChars {
+		return false
+	}
+	session.parts = append(session.parts, part)
+	session.size += len(part) + 1
+	session.timer.Reset(listenTimeout)
+	return true
+}
+
+// active reports whether a member is in listen mode in a channel
+func (l *listenStore) active(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, ok := l.sessions[key]
+	return ok
+}
+
+// stop ends a member's listen mode and returns what was collected, or nil if
+// it had already ended
+func (l *listenStore) stop(key string) *listening {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	session, ok := l.sessions[key]
+	if !ok {
+		return nil
+	}
+	session.timer.Stop()
+	delete(l.sessions, key)
+	return session
+}
+
+// handleListening runs listen mode for a message: "@TARS listen" starts it,
+// and while it runs the member's messages are collected until "done", which
+// answers them as one question. It reports whether the message was consumed.
+func (b *Bot) handleListening(s *discordgo.Session, m *discordgo.MessageCreate) bool {
+	key := listenKey(m.ChannelID, m.Author.ID)
+
+	if b.isBotMentioned(m) {
+		content := b.cleanMentionsFromContent(m.Content, m.Mentions)
+		word, rest, _ := strings.Cut(content, " ")
+		if !strings.EqualFold(strings.TrimRight(word, ".!:,"), listenWord) {
+			return false
+		}
+		if !b.listeners.start(m, func() { b.finishListening(s, key, true) }) {
+			b.sendReply(m.Message, &discordgo.MessageSend{Content: "👂 Too many people are dictating questions right now. Please ask in a single message."})
+			return true
+		}
+		if rest = strings.TrimSpace(rest); rest != "" {
+			b.listeners.add(key, rest)
+		}
+		b.sendReply(m.Message, &discordgo.MessageSend{
+			Content:   "👂 Listening. Send your question in as many messages as you need, code blocks included, then say `done` (or `cancel`).",
+			Reference: m.Reference(),
+		})
+		return true
+	}
+
+	if !b.listeners.active(key) {
+		return false
+	}
+	switch strings.ToLower(strings.Trim(strings.TrimSpace(m.Content), ".!")) {
+	case doneWord:
+		b.finishListening(s, key, false)
+	case cancelWord:
+		if b.listeners.stop(key) != nil && !b.sandboxed(m.GuildID) {
+			s.MessageReactionAdd(m.ChannelID, m.ID, "👌")
+		}
+	default:
+		part := messageText(m.Message)
+		for _, a := range m.Attachments {
+			part = strings.TrimSpace(part + "\n[attachment: " + a.Filename + "]")
+		}
+		if part == "" {
+			return true
+		}
+		if !b.listeners.add(key, part) {
+			b.sendReply(m.Message, &discordgo.MessageSend{Content: "📏 That's as much as I can take in one question. Say `done` and I'll answer what I have.", Reference: m.Reference()})
+			return true
+		}
+		if !b.sandboxed(m.GuildID) {
+			s.MessageReactionAdd(m.ChannelID, m.ID, "📝")
+		}
+	}
+	return true
+}
+
+// finishListening answers the question a member dictated; on a timeout, a
+// member who sent nothing gets no answer
+func (b *Bot) finishListening(s *discordgo.Session, key string, timedOut bool) {
+	session := b.listeners.stop(key)
+	if session == nil {
+		return
+	}
+	if len(session.parts) == 0 {
+		if !timedOut {
+			b.sendReply(session.trigger.Message, &discordgo.MessageSend{Content: "👂 I didn't hear a question. Mention me with `listen` to start again.", Reference: session.trigger.Reference()})
+		}
+		return
+	}
+	b.answerMention(s, session.trigger, strings.Join(session.parts, "\n"))
+}
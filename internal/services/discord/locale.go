@@ -0,0 +1,33 @@
+package discord
+
+import (
+	"discord-tars/internal/i18n"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// interactionLocale is the invoking user's client language, falling back to
+// the server's preferred one
+func interactionLocale(i *discordgo.InteractionCreate) discordgo.Locale {
+	if i.Locale != discordgo.Unknown {
+		return i.Locale
+	}
+	if i.GuildLocale != nil {
+		return *i.GuildLocale
+	}
+	return discordgo.EnglishUS
+}
+
+// guildLocale is a server's preferred language, for messages no interaction
+// asked for
+func guildLocale(s *discordgo.Session, guildID string) discordgo.Locale {
+	if guild, err := s.State.Guild(guildID); err == nil && guild.PreferredLocale != "" {
+		return discordgo.Locale(guild.PreferredLocale)
+	}
+	return discordgo.EnglishUS
+}
+
+// tr translates a response string into the language of the interaction
+func tr(i *discordgo.InteractionCreate, key string, args ...interface{}) string {
+	return i18n.T(interactionLocale(i), key, args...)
+}
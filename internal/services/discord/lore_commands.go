@@ -0,0 +1,88 @@
+package discord
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"discord-tars/internal/services/lore"
+	"discord-tars/internal/tenant"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func loreCommand() *discordgo.ApplicationCommand {
+	return &discordgo.ApplicationCommand{
+		Name:        "lore",
+		Description: "Ask the lore keeper about your campaign, from the session notes",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "question",
+				Description: "e.g. Who betrayed the party in Greyhaven?",
+				Required:    true,
+				MaxLength:   300,
+			},
+		},
+	}
+}
+
+// handleLoreCommand answers a campaign question from the recaps posted in the
+// guild's session notes channels
+func (b *Bot) handleLoreCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if b.loreService == nil {
+		respondEphemeral(s, i, "🔧 The lore keeper is not enabled on this instance.")
+		return
+	}
+	if i.GuildID == "" {
+		respondEphemeral(s, i, "🔒 This command only works in a server.")
+		return
+	}
+	question := strings.TrimSpace(optionMap(i.ApplicationCommandData().Options)["question"].StringValue())
+
+	if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	}); err != nil {
+		log.Printf("❌ Failed to defer interaction: %v", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(tenant.WithGuild(context.Background(), parseSnowflake(i.GuildID)), 30*time.Second)
+	defer cancel()
+
+	var content string
+	answer, err := b.loreService.Ask(ctx, parseSnowflake(i.GuildID), question)
+	switch {
+	case errors.Is(err, lore.ErrNoNotes):
+		content = tr(i, "lore.no_notes")
+	case errors.Is(err, lore.ErrNotFound):
+		content = tr(i, "lore.not_found", question)
+	case err != nil:
+		log.Printf("❌ Failed to answer lore question in guild %s: %v", i.GuildID, err)
+		content = aiErrorMessage(err, tr(i, "lore.failed"))
+	default:
+		content = loreAnswer(i, question, answer)
+	}
+	if _, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &content}); err != nil {
+		log.Printf("❌ Failed to edit interaction response: %v", err)
+	}
+}
+
+// loreAnswer shows an answer with links to the session notes it drew on
+func loreAnswer(i *discordgo.InteractionCreate, question string, answer *lore.Answer) string {
+	links := make([]string, len(answer.Notes))
+	for n, note := range answer.Notes {
+		links[n] = fmt.Sprintf("[%s](https://discord.com/channels/%s/%d/%d)", note.Date.Format("2 Jan 2006"), i.GuildID, note.ChannelID, note.MessageID)
+	}
+	footer := "\n\n-# " + tr(i, "lore.sources", strings.Join(links, " · "))
+	header := fmt.Sprintf("📜 **%s**\n\n", truncateText(question, 200))
+	return truncateText(header+answer.Text, 2000-len(footer)) + footer
+}
+
+// SetLoreService enables /lore
+func (b *Bot) SetLoreService(loreService *lore.Service) {
+	b.loreService = loreService
+}
@@ -0,0 +1,51 @@
+package discord
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/services/memory"
+	"discord-tars/internal/tenant"
+)
+
+// loadConversation returns what the bot remembers of a channel's chat with it
+func (b *Bot) loadConversation(ctx context.Context, channelID string) Conversation {
+	if b.memoryService == nil {
+		return Conversation{}
+	}
+	remembered, err := b.memoryService.Load(ctx, parseSnowflake(channelID))
+	if err != nil {
+		log.Printf("⚠️ Failed to load conversation memory: %v", err)
+		return Conversation{}
+	}
+
+	history := Conversation{Summary: remembered.Summary}
+	for _, turn := range remembered.Turns {
+		history.Turns = append(history.Turns, ConversationTurn{Asker: turn.Username, Question: turn.Question, Answer: turn.Answer})
+	}
+	return history
+}
+
+// rememberExchange records an exchange in the background; summarizing older
+// turns takes an extra AI call that the reply shouldn't wait for
+func (b *Bot) rememberExchange(guildID, channelID, username, question, answer string) {
+	if b.memoryService == nil {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(tenant.WithGuild(context.Background(), parseSnowflake(guildID)), time.Minute)
+		defer cancel()
+
+		turn := models.MemoryTurn{Username: username, Question: question, Answer: answer, At: time.Now()}
+		if err := b.memoryService.Record(ctx, parseSnowflake(guildID), parseSnowflake(channelID), turn); err != nil {
+			log.Printf("⚠️ Failed to remember conversation: %v", err)
+		}
+	}()
+}
+
+// SetMemoryService enables per-channel conversation memory for mentions
+func (b *Bot) SetMemoryService(memoryService *memory.Service) {
+	b.memoryService = memoryService
+}
@@ -0,0 +1,172 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"discord-tars/internal/sanitize"
+	"discord-tars/internal/services/summarize"
+	"discord-tars/internal/tenant"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Message context-menu command names, shown when right-clicking a message
+const (
+	summarizeMessageCommand = "Summarize this"
+	explainMessageCommand   = "Explain this"
+	translateMessageCommand = "Translate this"
+	bookmarkMessageCommand  = "Bookmark"
+)
+
+const (
+	// explainContextMessages is how many earlier messages are given as context for an explanation
+	explainContextMessages = 10
+	maxActionInputChars    = 6000
+)
+
+const explainSystemPrompt = `You explain a Discord message to someone who just read it and didn't follow.
+Say what it means in plain words: define jargon, acronyms and references, and spell out what the author is asking or proposing.
+Use the earlier messages only to understand what it refers to. Be concise: a short paragraph, or a few bullets for dense technical content.
+Don't invent facts that are neither in the messages nor general knowledge.`
+
+const translateSystemPrompt = `You translate Discord messages. Reply with the translation only, with no preamble or notes.
+Keep the formatting, emoji, mentions, links, code blocks and usernames exactly as they are, and keep the tone of the original.`
+
+func messageCommands() []*discordgo.ApplicationCommand {
+	return []*discordgo.ApplicationCommand{
+		{Type: discordgo.MessageApplicationCommand, Name: summarizeMessageCommand},
+		{Type: discordgo.MessageApplicationCommand, Name: explainMessageCommand},
+		{Type: discordgo.MessageApplicationCommand, Name: translateMessageCommand},
+		{Type: discordgo.MessageApplicationCommand, Name: bookmarkMessageCommand},
+	}
+}
+
+// handleMessageCommand runs an action on the right-clicked message; the result
+// is shown only to the user who asked
+func (b *Bot) handleMessageCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	data := i.ApplicationCommandData()
+	if data.Resolved == nil || data.Resolved.Messages[data.TargetID] == nil || data.Resolved.Messages[data.TargetID].Author == nil {
+		respondEphemeral(s, i, tr(i, "message_action.not_found"))
+		return
+	}
+	target := data.Resolved.Messages[data.TargetID]
+	// Resolved messages don't carry the channel and guild IDs
+	target.ChannelID = i.ChannelID
+	target.GuildID = i.GuildID
+
+	switch data.Name {
+	case summarizeMessageCommand:
+		if b.summarizeService == nil {
+			respondEphemeral(s, i, "🔧 Summaries are not enabled on this instance.")
+			return
+		}
+		b.deferEphemeral(s, i, func(ctx context.Context) string {
+			ctx = tenant.WithGuild(ctx, parseSnowflake(i.GuildID))
+			return b.summarizeFetched(ctx, i.ChannelID, func() ([]*discordgo.Message, error) {
+				return fetchAround(s, i.ChannelID, target.ID, defaultAroundMessages, defaultAroundMessages)
+			})
+		})
+
+	case explainMessageCommand:
+		b.deferEphemeral(s, i, func(ctx context.Context) string {
+			return b.explainMessage(tenant.WithGuild(ctx, parseSnowflake(i.GuildID)), s, i, target)
+		})
+
+	case translateMessageCommand:
+		b.deferEphemeral(s, i, func(ctx context.Context) string {
+			return b.translateMessage(tenant.WithGuild(ctx, parseSnowflake(i.GuildID)), i, target)
+		})
+
+	case bookmarkMessageCommand:
+		if b.bookmarkService == nil {
+			respondEphemeral(s, i, "🔧 Bookmarks are not enabled on this instance.")
+			return
+		}
+		b.deferEphemeral(s, i, func(ctx context.Context) string {
+			return b.bookmarkMessage(tenant.WithGuild(ctx, parseSnowflake(i.GuildID)), i, target)
+		})
+
+	default:
+		log.Printf("❌ Unknown message command: %s", data.Name)
+	}
+}
+
+func (b *Bot) explainMessage(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, target *discordgo.Message) string {
+	message := messageText(target)
+	if message == "" {
+		return tr(i, "message_action.empty")
+	}
+
+	var prompt strings.Builder
+	earlier, err := s.ChannelMessages(i.ChannelID, explainContextMessages, target.ID, "", "")
+	if err != nil {
+		// The explanation still works from the message alone
+		log.Printf("⚠️ Failed to fetch context for explanation: %v", err)
+	} else if transcript := summarize.BuildTranscript(summarize.FromDiscord(earlier), maxActionInputChars/2); transcript != "" {
+		prompt.WriteString("EARLIER MESSAGES:\n" + transcript + "\n\n")
+	}
+	fmt.Fprintf(&prompt, "MESSAGE TO EXPLAIN (from %s):\n%s", target.Author.Username, truncateText(message, maxActionInputChars))
+	prompt.WriteString("\n\nAnswer in " + localeName(interactionLocale(i)) + ".")
+
+	explanation, err := b.aiService.Complete(ctx, explainSystemPrompt, prompt.String(), 500)
+	if err != nil {
+		log.Printf("❌ Failed to explain message: %v", err)
+		return aiErrorMessage(err, tr(i, "message_action.failed"))
+	}
+	return tr(i, "message_action.explained", messageLink(target), sanitize.Output(explanation))
+}
+
+func (b *Bot) translateMessage(ctx context.Context, i *discordgo.InteractionCreate, target *discordgo.Message) string {
+	message := messageText(target)
+	if message == "" {
+		return tr(i, "message_action.empty")
+	}
+
+	language := localeName(interactionLocale(i))
+	prompt := fmt.Sprintf("Translate this message into %s. If it is already in %s, translate it into English instead.\n\n%s",
+		language, language, truncateText(message, maxActionInputChars))
+	translation, err := b.aiService.Complete(ctx, translateSystemPrompt, prompt, 1500)
+	if err != nil {
+		log.Printf("❌ Failed to translate message: %v", err)
+		return aiErrorMessage(err, tr(i, "message_action.failed"))
+	}
+	return tr(i, "message_action.translated", messageLink(target), sanitize.Output(translation))
+}
+
+// messageText is a message's content along with the text of its embeds
+func messageText(m *discordgo.Message) string {
+	parts := []string{m.Content}
+	for _, e := range m.Embeds {
+		parts = append(parts, e.Title, e.Description)
+		for _, field := range e.Fields {
+			parts = append(parts, field.Name+": "+field.Value)
+		}
+	}
+	var nonEmpty []string
+	for _, part := range parts {
+		if part = strings.TrimSpace(part); part != "" {
+			nonEmpty = append(nonEmpty, part)
+		}
+	}
+	return strings.Join(nonEmpty, "\n")
+}
+
+// messageLink is a jump link to a message
+func messageLink(m *discordgo.Message) string {
+	guildID := m.GuildID
+	if guildID == "" {
+		guildID = "@me"
+	}
+	return fmt.Sprintf("https://discord.com/channels/%s/%s/%s", guildID, m.ChannelID, m.ID)
+}
+
+// localeName names a Discord locale's language for prompts, e.g. "French"
+func localeName(locale discordgo.Locale) string {
+	if name, ok := discordgo.Locales[locale]; ok && locale != discordgo.Unknown {
+		return name
+	}
+	return "English"
+}
@@ -0,0 +1,46 @@
+package discord
+
+import (
+	"log"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// onModalSubmit routes modal submissions by the prefix of their custom ID,
+// formatted like component IDs as "prefix:arg1:arg2"
+func (b *Bot) onModalSubmit(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	data := i.ModalSubmitData()
+	parts := strings.Split(data.CustomID, ":")
+
+	switch parts[0] {
+	case askLongModalPrefix:
+		b.handleAskLongSubmit(s, i, parts[1:], modalValues(data))
+	case announceEditModalPrefix:
+		b.handleAnnounceEditSubmit(s, i, parts[1:], modalValues(data))
+	default:
+		log.Printf("❌ Unknown modal: %s", data.CustomID)
+	}
+}
+
+// modalValues indexes the text inputs of a submitted modal by custom ID
+func modalValues(data discordgo.ModalSubmitInteractionData) map[string]string {
+	values := make(map[string]string)
+	for _, row := range data.Components {
+		actions, ok := row.(*discordgo.ActionsRow)
+		if !ok {
+			continue
+		}
+		for _, component := range actions.Components {
+			if input, ok := component.(*discordgo.TextInput); ok {
+				values[input.CustomID] = strings.TrimSpace(input.Value)
+			}
+		}
+	}
+	return values
+}
+
+// textInputRow wraps a text input in the action row modals require
+func textInputRow(input discordgo.TextInput) discordgo.ActionsRow {
+	return discordgo.ActionsRow{Components: []discordgo.MessageComponent{input}}
+}
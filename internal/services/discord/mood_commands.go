@@ -0,0 +1,220 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/services/mood"
+	"discord-tars/internal/tenant"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	moodDefaultDays = 7
+	moodMaxChannels = 10
+	// A channel's negativity change is called out past this, with enough messages to mean something
+	moodAlertChange      = 0.2
+	moodAlertMinMessages = 20
+)
+
+func moodCommand() *discordgo.ApplicationCommand {
+	return &discordgo.ApplicationCommand{
+		Name:        "mood",
+		Description: "Show how the mood of the server's channels is trending (moderators only)",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:         discordgo.ApplicationCommandOptionChannel,
+				Name:         "channel",
+				Description:  "Drill down into one channel, with its most negative and positive messages",
+				ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText, discordgo.ChannelTypeGuildNews, discordgo.ChannelTypeGuildForum},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        "days",
+				Description: "Days to cover, compared with the same number of days before (default 7)",
+				MinValue:    func() *float64 { v := 1.0; return &v }(),
+				MaxValue:    30,
+			},
+		},
+	}
+}
+
+func (b *Bot) handleMoodCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if b.moodService == nil {
+		respondEphemeral(s, i, "🔧 Mood tracking is not enabled on this instance.")
+		return
+	}
+	if i.GuildID == "" {
+		respondEphemeral(s, i, "🔒 This command only works in a server.")
+		return
+	}
+	if !isModerator(i) {
+		respondEphemeral(s, i, tr(i, "moderators_only.mood"))
+		return
+	}
+
+	opts := optionMap(i.ApplicationCommandData().Options)
+	days := moodDefaultDays
+	if opt, ok := opts["days"]; ok {
+		days = int(opt.IntValue())
+	}
+	var channelID string
+	if opt, ok := opts["channel"]; ok {
+		channelID = opt.ChannelValue(s).ID
+		if !userCanRead(s, interactionUser(i).ID, channelID) {
+			respondEphemeral(s, i, "🔒 You can't read that channel.")
+			return
+		}
+	}
+
+	if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Flags: discordgo.MessageFlagsEphemeral},
+	}); err != nil {
+		log.Printf("❌ Failed to defer interaction: %v", err)
+		return
+	}
+
+	guildID := parseSnowflake(i.GuildID)
+	ctx, cancel := context.WithTimeout(tenant.WithGuild(context.Background(), guildID), 15*time.Second)
+	defer cancel()
+
+	var content string
+	report, err := b.moodService.Report(ctx, guildID, parseSnowflake(channelID), days)
+	switch {
+	case err != nil:
+		log.Printf("❌ Failed to build mood report: %v", err)
+		content = "🔧 Failed to load the mood data. Please try again."
+	case channelID != "":
+		content = channelMoodReport(report, channelID)
+	default:
+		content = b.serverMoodReport(s, i, report)
+	}
+	if _, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &content}); err != nil {
+		log.Printf("❌ Failed to edit interaction response: %v", err)
+	}
+}
+
+// serverMoodReport lists the busiest channels the requester can read, calling
+// out those whose negativity changed the most
+func (b *Bot) serverMoodReport(s *discordgo.Session, i *discordgo.InteractionCreate, report *mood.Report) string {
+	userID := interactionUser(i).ID
+	var trends []mood.Trend
+	for _, trend := range report.Trends {
+		if trend.Current.Messages > 0 && userCanRead(s, userID, strconv.FormatInt(trend.ChannelID, 10)) {
+			trends = append(trends, trend)
+		}
+	}
+	if len(trends) == 0 {
+		return fmt.Sprintf("🌡️ No mood data for the last %s yet. Channels are scored once a day, after midnight UTC.", moodPeriod(report.Days))
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "🌡️ **Server mood over the last %s** (compared with the %s before)\n", moodPeriod(report.Days), moodPeriod(report.Days))
+	for _, trend := range trends {
+		change, ok := trend.NegativityChange()
+		if !ok || trend.Current.Messages < moodAlertMinMessages || (change < moodAlertChange && change > -moodAlertChange) {
+			continue
+		}
+		direction, icon := "up", "⚠️"
+		if change < 0 {
+			direction, icon = "down", "✅"
+		}
+		fmt.Fprintf(&sb, "%s <#%d> negativity %s %.0f%% this %s\n", icon, trend.ChannelID, direction, math.Abs(change)*100, moodSpan(report.Days))
+	}
+	sb.WriteString("\n")
+
+	for _, trend := range trends[:min(len(trends), moodMaxChannels)] {
+		fmt.Fprintf(&sb, "%s <#%d> mood %+.2f", moodEmoji(trend.Current.Average()), trend.ChannelID, trend.Current.Average())
+		if trend.Previous.Messages > 0 {
+			fmt.Fprintf(&sb, " (was %+.2f)", trend.Previous.Average())
+		}
+		fmt.Fprintf(&sb, " · %d%% negative · %d messages\n", percent(trend.Current.Negativity()), trend.Current.Messages)
+	}
+	sb.WriteString("\n-# Use `/mood channel:` for a channel's day-by-day mood and example messages.")
+	return truncateText(sb.String(), 2000)
+}
+
+// channelMoodReport shows a channel's mood day by day with its most negative
+// and positive messages
+func channelMoodReport(report *mood.Report, channelID string) string {
+	if len(report.Daily) == 0 {
+		return fmt.Sprintf("🌡️ No mood data for <#%s> over the last %s. Channels are scored once a day, after midnight UTC, when they have enough messages.", channelID, moodPeriod(report.Days))
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "🌡️ **Mood of <#%s> over the last %s**\n", channelID, moodPeriod(report.Days))
+	if len(report.Trends) > 0 {
+		trend := report.Trends[0]
+		fmt.Fprintf(&sb, "%s Average %+.2f · %d%% negative · %d%% positive", moodEmoji(trend.Current.Average()), trend.Current.Average(),
+			percent(trend.Current.Negativity()), percent(float64(trend.Current.Positive)/float64(max(trend.Current.Messages, 1))))
+		if change, ok := trend.NegativityChange(); ok {
+			fmt.Fprintf(&sb, " · negativity %+.0f%% vs the %s before", change*100, moodPeriod(report.Days))
+		}
+		sb.WriteString("\n\n")
+	}
+
+	for _, day := range report.Daily {
+		fmt.Fprintf(&sb, "`%s` %s %+.2f · %d messages, %d negative\n", day.Day.Format("Mon 01-02"), moodEmoji(day.Average), day.Average, day.Messages, day.Negative)
+	}
+
+	writeExamples := func(title string, examples []mood.Example) {
+		if len(examples) == 0 {
+			return
+		}
+		fmt.Fprintf(&sb, "\n**%s**\n", title)
+		for _, example := range examples {
+			fmt.Fprintf(&sb, "• %+.1f [%s](%s)\n", example.Score, truncateText(exampleSnippet(models.SearchResult{Message: example.Message}), 90), storedMessageLink(example.Message))
+		}
+	}
+	writeExamples("Most negative", report.Negative)
+	writeExamples("Most positive", report.Positive)
+	return truncateText(sb.String(), 2000)
+}
+
+func moodEmoji(average float64) string {
+	switch {
+	case average > mood.NeutralBand/2:
+		return "😊"
+	case average < -mood.NeutralBand/2:
+		return "😟"
+	default:
+		return "😐"
+	}
+}
+
+// moodPeriod names a number of days, e.g. "7 days"
+func moodPeriod(days int) string {
+	if days == 1 {
+		return "day"
+	}
+	return fmt.Sprintf("%d days", days)
+}
+
+// moodSpan names the period in a headline, e.g. "week"
+func moodSpan(days int) string {
+	switch days {
+	case 1:
+		return "day"
+	case 7:
+		return "week"
+	default:
+		return "period"
+	}
+}
+
+func percent(share float64) int {
+	return int(share*100 + 0.5)
+}
+
+// SetMoodService enables /mood
+func (b *Bot) SetMoodService(moodService *mood.Service) {
+	b.moodService = moodService
+}
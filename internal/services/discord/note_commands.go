@@ -0,0 +1,138 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"discord-tars/internal/services/notes"
+	"discord-tars/internal/tenant"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Notebooks a note can go to
+const (
+	notebookPersonal = "personal"
+	notebookChannel  = "channel"
+)
+
+func noteCommand() *discordgo.ApplicationCommand {
+	notebookOption := func(description string) *discordgo.ApplicationCommandOption {
+		return &discordgo.ApplicationCommandOption{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "notebook",
+			Description: description,
+			Choices: []*discordgo.ApplicationCommandOptionChoice{
+				{Name: "Personal (only you)", Value: notebookPersonal},
+				{Name: "This channel (shared)", Value: notebookChannel},
+			},
+		}
+	}
+	return &discordgo.ApplicationCommand{
+		Name:        "note",
+		Description: "Save notes and find them again by meaning",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "add",
+				Description: "Save a note",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "text",
+						Description: "What to remember",
+						Required:    true,
+						MaxLength:   notes.MaxNoteLength,
+					},
+					notebookOption("Where to save it (default: personal)"),
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "find",
+				Description: "Find notes about something, even in other words",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "query",
+						Description: "What you're looking for",
+						Required:    true,
+					},
+					notebookOption("Which notebook to search (default: personal)"),
+				},
+			},
+		},
+	}
+}
+
+func (b *Bot) handleNoteCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if b.noteService == nil {
+		respondEphemeral(s, i, "🔧 Notes are not enabled on this instance.")
+		return
+	}
+
+	sub := i.ApplicationCommandData().Options[0]
+	opts := optionMap(sub.Options)
+	userID := parseSnowflake(interactionUser(i).ID)
+
+	// Personal notes follow their author everywhere; channel notes stay in
+	// the server channel they were written in
+	var channelID int64
+	if opt, ok := opts["notebook"]; ok && opt.StringValue() == notebookChannel {
+		if i.GuildID == "" {
+			respondEphemeral(s, i, tr(i, "note.channel_in_dm"))
+			return
+		}
+		channelID = parseSnowflake(i.ChannelID)
+	}
+
+	ctx, cancel := context.WithTimeout(tenant.WithGuild(context.Background(), parseSnowflake(i.GuildID)), 15*time.Second)
+	defer cancel()
+
+	switch sub.Name {
+	case "add":
+		if _, err := b.noteService.Add(ctx, parseSnowflake(i.GuildID), channelID, userID, opts["text"].StringValue()); err != nil {
+			log.Printf("❌ Failed to save note: %v", err)
+			respondEphemeral(s, i, aiErrorMessage(err, tr(i, "note.save_failed")))
+			return
+		}
+		if channelID != 0 {
+			respondEphemeral(s, i, tr(i, "note.saved_channel", channelID))
+		} else {
+			respondEphemeral(s, i, tr(i, "note.saved"))
+		}
+	case "find":
+		query := opts["query"].StringValue()
+		results, err := b.noteService.Find(ctx, userID, channelID, query)
+		if err != nil {
+			log.Printf("❌ Failed to search notes: %v", err)
+			respondEphemeral(s, i, aiErrorMessage(err, tr(i, "note.find_failed")))
+			return
+		}
+		if len(results) == 0 {
+			respondEphemeral(s, i, tr(i, "note.none"))
+			return
+		}
+
+		var sb strings.Builder
+		sb.WriteString(tr(i, "note.found", truncateText(query, 100)) + "\n")
+		for n, result := range results {
+			note := result.Note
+			line := fmt.Sprintf("**%d.** %s\n-# <t:%d:d> · %.0f%%", n+1,
+				truncateText(strings.Join(strings.Fields(note.Content), " "), 300), note.CreatedAt.Unix(), result.Similarity*100)
+			if note.ChannelID != 0 {
+				line += fmt.Sprintf(" · <@%d>", note.UserID)
+			}
+			sb.WriteString(line + "\n")
+		}
+		respondEphemeral(s, i, truncateText(sb.String(), 2000))
+	}
+}
+
+// SetNoteService enables /note
+func (b *Bot) SetNoteService(noteService *notes.Service) {
+	b.noteService = noteService
+}
@@ -0,0 +1,192 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/services/onboarding"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+var channelMentionPattern = regexp.MustCompile(`<#(\d+)>`)
+
+func onboardingCommand() *discordgo.ApplicationCommand {
+	return &discordgo.ApplicationCommand{
+		Name:        "onboarding",
+		Description: "Configure how T.A.R.S welcomes new members (admins only)",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "setup",
+				Description: "Enable or update onboarding",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionBoolean,
+						Name:        "enabled",
+						Description: "Whether new members are welcomed",
+						Required:    true,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "mode",
+						Description: "Where to send the welcome (default: DM)",
+						Choices: []*discordgo.ApplicationCommandOptionChoice{
+							{Name: "Direct message", Value: models.OnboardingModeDM},
+							{Name: "Welcome channel", Value: models.OnboardingModeChannel},
+						},
+					},
+					{
+						Type:         discordgo.ApplicationCommandOptionChannel,
+						Name:         "channel",
+						Description:  "Welcome channel (required for channel mode)",
+						ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText},
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "knowledge",
+						Description: "Rules/FAQ channels to answer from, e.g. #rules #faq",
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "greeting",
+						Description: "Short intro the welcome should include",
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "status",
+				Description: "Show the current onboarding configuration",
+			},
+		},
+	}
+}
+
+func (b *Bot) handleOnboardingCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if b.onboardingService == nil {
+		respondEphemeral(s, i, "🔧 Onboarding is not enabled on this instance.")
+		return
+	}
+	if !isGuildAdmin(i) {
+		respondEphemeral(s, i, tr(i, "admin_only.onboarding"))
+		return
+	}
+
+	sub := i.ApplicationCommandData().Options[0]
+	guildID := parseSnowflake(i.GuildID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	switch sub.Name {
+	case "setup":
+		opts := optionMap(sub.Options)
+		cfg := &models.OnboardingConfig{
+			GuildID: guildID,
+			Enabled: opts["enabled"].BoolValue(),
+			Mode:    models.OnboardingModeDM,
+		}
+		if opt, ok := opts["mode"]; ok {
+			cfg.Mode = opt.StringValue()
+		}
+		if opt, ok := opts["channel"]; ok {
+			cfg.WelcomeChannelID = parseSnowflake(opt.ChannelValue(s).ID)
+		}
+		if opt, ok := opts["knowledge"]; ok {
+			for _, match := range channelMentionPattern.FindAllStringSubmatch(opt.StringValue(), -1) {
+				cfg.KnowledgeChannelIDs = append(cfg.KnowledgeChannelIDs, match[1])
+			}
+		}
+		if opt, ok := opts["greeting"]; ok {
+			cfg.Greeting = strings.TrimSpace(opt.StringValue())
+		}
+
+		if err := b.onboardingService.Configure(ctx, cfg); err != nil {
+			log.Printf("❌ Failed to configure onboarding: %v", err)
+			respondEphemeral(s, i, fmt.Sprintf("🔧 Could not save onboarding settings: %v", err))
+			return
+		}
+		respondEphemeral(s, i, "✅ Onboarding settings saved.\n"+formatOnboardingConfig(cfg))
+
+	case "status":
+		cfg, err := b.onboardingService.GetConfig(ctx, guildID)
+		if err != nil {
+			log.Printf("❌ Failed to load onboarding config: %v", err)
+			respondEphemeral(s, i, "🔧 Failed to load onboarding settings. Please try again.")
+			return
+		}
+		if cfg == nil {
+			respondEphemeral(s, i, "ℹ️ Onboarding is not configured. Use `/onboarding setup` to enable it.")
+			return
+		}
+		respondEphemeral(s, i, formatOnboardingConfig(cfg))
+	}
+}
+
+func formatOnboardingConfig(cfg *models.OnboardingConfig) string {
+	state := "disabled"
+	if cfg.Enabled {
+		state = "enabled"
+	}
+	where := "by DM"
+	if cfg.Mode == models.OnboardingModeChannel {
+		where = fmt.Sprintf("in <#%d>", cfg.WelcomeChannelID)
+	}
+	knowledge := "none (answers will be limited)"
+	if len(cfg.KnowledgeChannelIDs) > 0 {
+		mentions := make([]string, 0, len(cfg.KnowledgeChannelIDs))
+		for _, id := range cfg.KnowledgeChannelIDs {
+			mentions = append(mentions, "<#"+id+">")
+		}
+		knowledge = strings.Join(mentions, " ")
+	}
+	return fmt.Sprintf("👋 **Onboarding:** %s\n• Welcome sent %s\n• Knowledge channels: %s\n• New members get up to %d questions answered by DM",
+		state, where, knowledge, onboarding.MaxQuestions)
+}
+
+func (b *Bot) onGuildMemberAdd(s *discordgo.Session, m *discordgo.GuildMemberAdd) {
+	if b.onboardingService == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := b.onboardingService.Welcome(ctx, m.Member); err != nil {
+		log.Printf("❌ Failed to welcome new member: %v", err)
+	}
+}
+
+// handleOnboardingDM answers a new member's DM, reporting whether it was handled
+func (b *Bot) handleOnboardingDM(s *discordgo.Session, m *discordgo.MessageCreate) bool {
+	if b.onboardingService == nil {
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	answer, handled, err := b.onboardingService.HandleDirectMessage(ctx, parseSnowflake(m.Author.ID), m.Content)
+	if err != nil {
+		log.Printf("❌ Failed to answer onboarding DM: %v", err)
+		if handled {
+			s.ChannelMessageSend(m.ChannelID, "🔧 My circuits seem to be malfunctioning. Please try again later.")
+		}
+		return handled
+	}
+	if handled {
+		s.ChannelMessageSend(m.ChannelID, answer)
+	}
+	return handled
+}
+
+// SetOnboardingService enables welcome messages and the /onboarding command
+func (b *Bot) SetOnboardingService(onboardingService *onboarding.Service) {
+	b.onboardingService = onboardingService
+}
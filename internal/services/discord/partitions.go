@@ -0,0 +1,41 @@
+package discord
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"discord-tars/internal/services/partitions"
+)
+
+// partitionTimeout bounds creating a guild's partition, which moves the
+// messages it already has out of the default partition
+const partitionTimeout = 5 * time.Minute
+
+// SetPartitionService gives guilds the bot joins a partition of the messages
+// table, and starts the retention period of those it leaves
+func (b *Bot) SetPartitionService(partitionService *partitions.Service) {
+	b.partitionService = partitionService
+}
+
+// guildPartitionChanged creates or retires a guild's partition in the
+// background, keeping the gateway event handler free
+func (b *Bot) guildPartitionChanged(guildID string, joined bool) {
+	if b.partitionService == nil {
+		return
+	}
+	id := parseSnowflake(guildID)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), partitionTimeout)
+		defer cancel()
+		var err error
+		if joined {
+			err = b.partitionService.GuildJoined(ctx, id)
+		} else {
+			err = b.partitionService.GuildLeft(ctx, id)
+		}
+		if err != nil {
+			log.Printf("⚠️ Failed to update the message partition of guild %s: %v", guildID, err)
+		}
+	}()
+}
@@ -0,0 +1,446 @@
+package discord
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/services/persona"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+var unsafeFileChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+func personaCommand() *discordgo.ApplicationCommand {
+	presets := make([]*discordgo.ApplicationCommandOptionChoice, 0, len(persona.PresetNames()))
+	for _, name := range persona.PresetNames() {
+		presets = append(presets, &discordgo.ApplicationCommandOptionChoice{Name: persona.Preset(name).Name, Value: name})
+	}
+
+	return &discordgo.ApplicationCommand{
+		Name:        "persona",
+		Description: "Share and switch the persona I answer with (admins only)",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "import",
+				Description: "Use a persona from a JSON file or a bundled preset",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionAttachment,
+						Name:        "file",
+						Description: "Persona JSON file exported with /persona export",
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "preset",
+						Description: "Bundled persona to use instead of a file",
+						Choices:     presets,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "export",
+				Description: "Download the current persona as JSON",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "reset",
+				Description: "Go back to the default T.A.R.S persona",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "verbosity",
+				Description: "Set how long answers are unless a question asks otherwise",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "level",
+						Description: "Default answer length",
+						Required:    true,
+						Choices:     verbosityChoices(),
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "style",
+				Description: "Set whether answers are playful, with this server's emojis, or plain",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "tone",
+						Description: "Answer style",
+						Required:    true,
+						Choices: []*discordgo.ApplicationCommandOptionChoice{
+							{Name: "Playful", Value: string(persona.StylePlayful)},
+							{Name: "Plain", Value: string(persona.StylePlain)},
+						},
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommandGroup,
+				Name:        "mode",
+				Description: "Adjust the persona on a schedule, like a spooky October or formal work hours",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionSubCommand,
+						Name:        "add",
+						Description: "Add or replace a scheduled mode",
+						Options: []*discordgo.ApplicationCommandOption{
+							{
+								Type:        discordgo.ApplicationCommandOptionString,
+								Name:        "name",
+								Description: "Mode name, e.g. spooky",
+								Required:    true,
+								MaxLength:   50,
+							},
+							{
+								Type:        discordgo.ApplicationCommandOptionString,
+								Name:        "instructions",
+								Description: "How to adjust the persona, e.g. Add a playful Halloween twist",
+								Required:    true,
+								MaxLength:   500,
+							},
+							{
+								Type:        discordgo.ApplicationCommandOptionString,
+								Name:        "months",
+								Description: "e.g. oct, or dec,jan, or jun-aug (default every month)",
+							},
+							{
+								Type:        discordgo.ApplicationCommandOptionString,
+								Name:        "days",
+								Description: "e.g. mon-fri, or sat,sun (default every day)",
+							},
+							{
+								Type:        discordgo.ApplicationCommandOptionString,
+								Name:        "hours",
+								Description: "e.g. 9-17, or 22-6 (default all day)",
+							},
+							{
+								Type:        discordgo.ApplicationCommandOptionString,
+								Name:        "timezone",
+								Description: "IANA timezone, e.g. Europe/Paris (default: the server's timezone)",
+							},
+						},
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionSubCommand,
+						Name:        "remove",
+						Description: "Remove a scheduled mode",
+						Options: []*discordgo.ApplicationCommandOption{
+							{
+								Type:        discordgo.ApplicationCommandOptionString,
+								Name:        "name",
+								Description: "Mode name",
+								Required:    true,
+								MaxLength:   50,
+							},
+						},
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionSubCommand,
+						Name:        "list",
+						Description: "Show the scheduled modes and which are active",
+					},
+				},
+			},
+		},
+	}
+}
+
+func (b *Bot) handlePersonaCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if b.personaService == nil {
+		respondEphemeral(s, i, "🔧 Custom personas are not enabled on this instance.")
+		return
+	}
+	if i.GuildID == "" {
+		respondEphemeral(s, i, "🔒 This command only works in a server.")
+		return
+	}
+	if !isGuildAdmin(i) {
+		respondEphemeral(s, i, tr(i, "admin_only.persona"))
+		return
+	}
+
+	data := i.ApplicationCommandData()
+	sub := data.Options[0]
+	guildID := parseSnowflake(i.GuildID)
+
+	switch sub.Name {
+	case "import":
+		b.importPersona(s, i, guildID, data.Resolved, optionMap(sub.Options))
+
+	case "export":
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		def, err := b.personaService.Get(ctx, guildID)
+		if err != nil {
+			log.Printf("❌ Failed to load persona: %v", err)
+			respondEphemeral(s, i, "🔧 Failed to load the persona. Please try again.")
+			return
+		}
+		if def == nil {
+			b.presence.mu.Lock()
+			def = persona.Default(b.presence.humor, b.presence.honesty)
+			b.presence.mu.Unlock()
+		}
+		exported, err := def.JSON()
+		if err != nil {
+			log.Printf("❌ Failed to export persona: %v", err)
+			respondEphemeral(s, i, "🔧 Failed to export the persona. Please try again.")
+			return
+		}
+		err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: fmt.Sprintf("🎭 Here is the **%s** persona. Edit it if you like, then load it in any server with `/persona import`.", def.Name),
+				Flags:   discordgo.MessageFlagsEphemeral,
+				Files: []*discordgo.File{{
+					Name:        personaFileName(def.Name),
+					ContentType: "application/json",
+					Reader:      bytes.NewReader(exported),
+				}},
+			},
+		})
+		if err != nil {
+			log.Printf("❌ Failed to send persona export: %v", err)
+		}
+
+	case "reset":
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := b.personaService.Reset(ctx, guildID); err != nil {
+			log.Printf("❌ Failed to reset persona: %v", err)
+			respondEphemeral(s, i, "🔧 Failed to reset the persona. Please try again.")
+			return
+		}
+		respondEphemeral(s, i, "🤖 Back to T.A.R.S. Humor and honesty follow `/personality` again.")
+
+	case "verbosity":
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		verbosity, _ := persona.ParseVerbosity(optionMap(sub.Options)["level"].StringValue())
+		if err := b.personaService.SetVerbosity(ctx, guildID, parseSnowflake(interactionUser(i).ID), verbosity); err != nil {
+			log.Printf("❌ Failed to set answer verbosity: %v", err)
+			respondEphemeral(s, i, "🔧 Failed to save the verbosity. Please try again.")
+			return
+		}
+		respondEphemeral(s, i, fmt.Sprintf("📏 Answers are now **%s** by default. `/ask` can still pick another length per question.", verbosity))
+
+	case "style":
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		style, _ := persona.ParseStyle(optionMap(sub.Options)["tone"].StringValue())
+		if err := b.personaService.SetStyle(ctx, guildID, parseSnowflake(interactionUser(i).ID), style); err != nil {
+			log.Printf("❌ Failed to set answer style: %v", err)
+			respondEphemeral(s, i, "🔧 Failed to save the style. Please try again.")
+			return
+		}
+		if style == persona.StylePlain {
+			respondEphemeral(s, i, "📄 Answers are now **plain**, without emojis.")
+			return
+		}
+		respondEphemeral(s, i, fmt.Sprintf("🎉 Answers are now **playful** and may use this server's emojis (%d available to me).", len(guildEmojis(s, i.GuildID))))
+
+	case "mode":
+		b.handlePersonaMode(s, i, guildID, sub.Options[0])
+	}
+}
+
+// handlePersonaMode adds, removes and lists a guild's scheduled persona modes
+func (b *Bot) handlePersonaMode(s *discordgo.Session, i *discordgo.InteractionCreate, guildID int64, sub *discordgo.ApplicationCommandInteractionDataOption) {
+	opts := optionMap(sub.Options)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	switch sub.Name {
+	case "add":
+		mode := &models.PersonaMode{
+			GuildID:      guildID,
+			Name:         opts["name"].StringValue(),
+			Instructions: opts["instructions"].StringValue(),
+			CreatedBy:    parseSnowflake(interactionUser(i).ID),
+			Timezone:     b.serverTimezone(ctx, i),
+		}
+		var err error
+		if opt, ok := opts["months"]; ok {
+			mode.Months, err = persona.ParseMonths(opt.StringValue())
+		}
+		if opt, ok := opts["days"]; ok && err == nil {
+			mode.Weekdays, err = persona.ParseWeekdays(opt.StringValue())
+		}
+		if opt, ok := opts["hours"]; ok && err == nil {
+			mode.StartHour, mode.EndHour, err = persona.ParseHours(opt.StringValue())
+		}
+		if opt, ok := opts["timezone"]; ok {
+			mode.Timezone = strings.TrimSpace(opt.StringValue())
+		}
+		if err == nil {
+			err = b.personaService.SaveMode(ctx, mode)
+		}
+		switch {
+		case errors.Is(err, persona.ErrInvalid):
+			respondEphemeral(s, i, "⚠️ "+strings.TrimPrefix(err.Error(), persona.ErrInvalid.Error()+": "))
+		case err != nil:
+			log.Printf("❌ Failed to save persona mode: %v", err)
+			respondEphemeral(s, i, "🔧 Failed to save the mode. Please try again.")
+		default:
+			respondEphemeral(s, i, fmt.Sprintf("🎭 Mode **%s** is on %s, layered over the current persona.", mode.Name, persona.DescribeSchedule(mode)))
+		}
+
+	case "remove":
+		removed, err := b.personaService.RemoveMode(ctx, guildID, opts["name"].StringValue())
+		switch {
+		case err != nil:
+			log.Printf("❌ Failed to remove persona mode: %v", err)
+			respondEphemeral(s, i, "🔧 Failed to remove the mode. Please try again.")
+		case !removed:
+			respondEphemeral(s, i, "🎭 There is no mode by that name. See `/persona mode list`.")
+		default:
+			respondEphemeral(s, i, "🗑️ Mode removed.")
+		}
+
+	case "list":
+		modes, err := b.personaService.Modes(ctx, guildID)
+		if err != nil {
+			log.Printf("❌ Failed to list persona modes: %v", err)
+			respondEphemeral(s, i, "🔧 Failed to load the modes. Please try again.")
+			return
+		}
+		if len(modes) == 0 {
+			respondEphemeral(s, i, "🎭 No modes yet. Add one with `/persona mode add`.")
+			return
+		}
+		var sb strings.Builder
+		sb.WriteString("🎭 **Persona modes**\n")
+		now := time.Now()
+		for n := range modes {
+			status := "⚪"
+			if persona.ModeActive(&modes[n], now) {
+				status = "🟢 active"
+			}
+			fmt.Fprintf(&sb, "%s **%s**: %s\n-# %s\n", status, modes[n].Name, persona.DescribeSchedule(&modes[n]), truncateText(modes[n].Instructions, 150))
+		}
+		respondEphemeral(s, i, truncateText(sb.String(), 2000))
+	}
+}
+
+// importPersona makes an attached persona file or a bundled preset the guild's persona
+func (b *Bot) importPersona(s *discordgo.Session, i *discordgo.InteractionCreate, guildID int64, resolved *discordgo.ApplicationCommandInteractionDataResolved, opts map[string]*discordgo.ApplicationCommandInteractionDataOption) {
+	fileOpt, hasFile := opts["file"]
+	presetOpt, hasPreset := opts["preset"]
+	if hasFile == hasPreset {
+		respondEphemeral(s, i, "🎭 Attach a persona `file` or pick a `preset`, but not both.")
+		return
+	}
+
+	if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Flags: discordgo.MessageFlagsEphemeral},
+	}); err != nil {
+		log.Printf("❌ Failed to defer interaction: %v", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var (
+		def *persona.Definition
+		err error
+	)
+	if hasPreset {
+		if def = persona.Preset(presetOpt.StringValue()); def == nil {
+			err = fmt.Errorf("%w: unknown preset", persona.ErrInvalid)
+		}
+	} else {
+		def, err = downloadPersona(ctx, resolved, fileOpt.Value)
+	}
+	if err == nil {
+		err = b.personaService.Import(ctx, guildID, parseSnowflake(interactionUser(i).ID), def)
+	}
+
+	var content string
+	switch {
+	case errors.Is(err, persona.ErrInvalid):
+		content = "⚠️ That persona can't be used: " + truncateText(strings.TrimPrefix(err.Error(), persona.ErrInvalid.Error()+": "), 1800)
+	case err != nil:
+		log.Printf("❌ Failed to import persona: %v", err)
+		content = "🔧 Failed to import the persona. Please try again."
+	default:
+		content = fmt.Sprintf("🎭 I now answer as **%s**. Use `/persona reset` to go back to T.A.R.S.", def.Name)
+		if def.Description != "" {
+			content += "\n-# " + def.Description
+		}
+	}
+	if _, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &content}); err != nil {
+		log.Printf("❌ Failed to edit interaction response: %v", err)
+	}
+}
+
+// downloadPersona fetches the persona file attached to the command
+func downloadPersona(ctx context.Context, resolved *discordgo.ApplicationCommandInteractionDataResolved, value interface{}) (*persona.Definition, error) {
+	id, _ := value.(string)
+	if resolved == nil || resolved.Attachments[id] == nil {
+		return nil, errors.New("attachment missing from interaction")
+	}
+	att := resolved.Attachments[id]
+	if att.Size > persona.MaxDefinitionBytes {
+		return nil, fmt.Errorf("%w: the file must be under %d KB", persona.ErrInvalid, persona.MaxDefinitionBytes/1024)
+	}
+	return persona.Download(ctx, att.URL)
+}
+
+// personaFileName names an exported persona file after the persona
+func personaFileName(name string) string {
+	slug := strings.Trim(unsafeFileChars.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	if slug == "" {
+		slug = "persona"
+	}
+	return slug + ".persona.json"
+}
+
+// withPersona makes AI answers made with ctx use the guild's persona, style
+// and emojis
+func (b *Bot) withPersona(ctx context.Context, guildID string) context.Context {
+	if guildID == "" {
+		return ctx
+	}
+	ctx = persona.WithEmojis(ctx, guildEmojis(b.session, guildID))
+	if b.personaService == nil {
+		return ctx
+	}
+	return b.personaService.Use(ctx, parseSnowflake(guildID))
+}
+
+// guildEmojis are the custom emojis the bot can use in a guild: available
+// ones not restricted to some roles
+func guildEmojis(s *discordgo.Session, guildID string) []persona.Emoji {
+	guild, err := s.State.Guild(guildID)
+	if err != nil {
+		return nil
+	}
+	var emojis []persona.Emoji
+	for _, e := range guild.Emojis {
+		if e.Name == "" || !e.Available || len(e.Roles) > 0 {
+			continue
+		}
+		emojis = append(emojis, persona.Emoji{Name: e.Name, Code: e.MessageFormat()})
+	}
+	return emojis
+}
+
+// SetPersonaService enables /persona and per-guild personas
+func (b *Bot) SetPersonaService(personaService *persona.Service) {
+	b.personaService = personaService
+}
@@ -0,0 +1,271 @@
+package discord
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"discord-tars/internal/injection"
+	"discord-tars/internal/interfaces"
+	"discord-tars/internal/repository/postgres"
+	"discord-tars/internal/sanitize"
+	"discord-tars/internal/services/agent"
+	"discord-tars/internal/services/calendar"
+	"discord-tars/internal/services/persona"
+	"discord-tars/internal/services/rag"
+	"discord-tars/internal/services/rollout"
+	"discord-tars/internal/services/tracker"
+	"discord-tars/internal/tenant"
+)
+
+// Pipeline turns a question into an answer: the guild's persona and rollout
+// settings, retrieved context, server memory and calendar events go into the
+// prompt, screened for prompt injection, and the model's answer is sanitized
+// and checked against the context. It needs no Discord session, so "bot
+// chat" answers exactly as the bot does.
+type Pipeline struct {
+	ai        interfaces.AIService
+	rag       *rag.Service
+	persona   *persona.Service
+	rollout   *rollout.Service
+	calendar  *calendar.Service
+	tracker   *tracker.Service
+	responses *responseCache
+	injection *injectionGuard
+	// report is told about screened prompt injection, which is only logged
+	// when it is nil
+	report func(guildID, channelID string, userID int64, source string, detection injection.Detection)
+
+	confidenceThreshold float64
+}
+
+// NewPipeline answers with ai from the context ragService retrieves. Answers
+// about the server need confidenceThreshold support from that context, as
+// BotConfig.ConfidenceThreshold.
+func NewPipeline(ai interfaces.AIService, ragService *rag.Service, confidenceThreshold float64) *Pipeline {
+	return &Pipeline{ai: ai, rag: ragService, confidenceThreshold: confidenceThreshold}
+}
+
+// SetPersonaService answers with each guild's persona, verbosity and style
+func (p *Pipeline) SetPersonaService(personaService *persona.Service) {
+	p.persona = personaService
+}
+
+// SetRolloutService answers guilds in a rollout with its settings
+func (p *Pipeline) SetRolloutService(rolloutService *rollout.Service) {
+	p.rollout = rolloutService
+}
+
+// SetCalendarService puts upcoming events before scheduling questions
+func (p *Pipeline) SetCalendarService(calendarService *calendar.Service) {
+	p.calendar = calendarService
+}
+
+// SetInjectionScreen strips prompt injection from retrieved context and
+// replaces answers that give the prompt away
+func (p *Pipeline) SetInjectionScreen() {
+	p.injection = newInjectionGuard(false)
+}
+
+// pipeline is the answer pipeline with the bot's services
+func (b *Bot) pipeline() *Pipeline {
+	return &Pipeline{
+		ai:                  b.aiService,
+		rag:                 b.ragService,
+		persona:             b.personaService,
+		rollout:             b.rolloutService,
+		calendar:            b.calendarService,
+		tracker:             b.trackerService,
+		responses:           b.responses,
+		injection:           b.injection,
+		report:              b.reportInjection,
+		confidenceThreshold: b.config.ConfidenceThreshold,
+	}
+}
+
+// Draft is a question made ready for the model
+type Draft struct {
+	Prompt    string
+	Retrieved *rag.RetrievedContext // nil when retrieval was unavailable
+
+	question, username, guildID, channelID string
+	// standalone is set when no earlier exchanges or attached text went into
+	// the prompt
+	standalone bool
+	// external is set when the prompt holds context the grounding check can't see
+	external bool
+	tools    []interfaces.Tool
+}
+
+// Answer is what the pipeline answered
+type Answer struct {
+	Text string
+	// Uncertain is set when the model's answer was withheld because the
+	// retrieved context doesn't back it
+	Uncertain bool
+}
+
+// Answer answers a question; see Prepare and Generate
+func (p *Pipeline) Answer(ctx context.Context, question, username, guildID, channelID string, history Conversation) (Answer, error) {
+	ctx, draft, err := p.Prepare(ctx, question, username, guildID, channelID, history)
+	if err != nil {
+		return Answer{}, err
+	}
+	return p.Generate(ctx, draft, nil)
+}
+
+// Prepare builds the prompt for a question asked in a guild's channel, after
+// earlier exchanges when it follows up on them. Generate must be given the
+// context it returns, which carries the guild's answer settings.
+func (p *Pipeline) Prepare(ctx context.Context, question, username, guildID, channelID string, history Conversation) (context.Context, *Draft, error) {
+	ctx = tenant.WithGuild(ctx, parseSnowflake(guildID))
+	if p.persona != nil && guildID != "" {
+		ctx = p.persona.Use(ctx, parseSnowflake(guildID))
+	}
+	if p.rollout != nil {
+		ctx = p.rollout.Apply(ctx, parseSnowflake(guildID))
+	}
+
+	d := &Draft{
+		Prompt:     question,
+		question:   question,
+		username:   username,
+		guildID:    guildID,
+		channelID:  channelID,
+		standalone: history.empty(),
+	}
+	if err := p.addContext(ctx, d, history); err != nil {
+		return ctx, nil, err
+	}
+	if history.Attached != "" {
+		d.Prompt = "CONTEXT PROVIDED BY THE USER:\n" + history.Attached + "\n\n" + d.Prompt
+		d.external = true
+	}
+	d.Prompt = historyPrompt(history) + d.Prompt
+	if p.injection != nil {
+		d.Prompt = injection.Honeypot() + d.Prompt
+	}
+
+	// Offer the AI tools relevant to the question
+	if p.tracker != nil && guildID != "" && len(tracker.ExtractKeys(question)) > 0 {
+		d.tools = append(d.tools, p.tracker.Tool(parseSnowflake(guildID)))
+		d.external = true
+	}
+	// Computed results depend on the moment and the numbers asked, not only on
+	// the retrieved context
+	if agent.WantsMath(question) {
+		d.tools = append(d.tools, agent.MathTools()...)
+		d.external = true
+	}
+	return ctx, d, nil
+}
+
+// addContext enriches a draft with retrieved server context, falling back to
+// the bare question when retrieval is unavailable. A search that hit the
+// query timeout fails the answer instead, so the user is told.
+func (p *Pipeline) addContext(ctx context.Context, d *Draft, history Conversation) error {
+	if p.rag != nil {
+		turns := make([]string, 0, len(history.Turns)*2+1)
+		if history.Summary != "" {
+			turns = append(turns, "Earlier: "+history.Summary)
+		}
+		for _, turn := range history.Turns {
+			turns = append(turns, "Q: "+turn.Question, "A: "+turn.Answer)
+		}
+		rc, err := p.rag.RetrieveConversational(ctx, d.question, turns, parseSnowflake(d.guildID), parseSnowflake(d.channelID), 5)
+		if errors.Is(err, postgres.ErrQueryTimeout) {
+			return err
+		}
+		if err != nil {
+			log.Printf("⚠️ Context retrieval failed, answering without context: %v", err)
+		} else {
+			p.screenRetrieved(d.guildID, rc)
+			d.Prompt = p.rag.BuildContextPrompt(d.question, rc)
+			d.Retrieved = rc
+		}
+	}
+
+	// Questions about the server itself need statistics over every channel
+	if p.rag != nil && history.empty() {
+		memory, err := p.rag.ServerMemory(ctx, d.question, parseSnowflake(d.guildID))
+		if errors.Is(err, postgres.ErrQueryTimeout) {
+			return err
+		}
+		if err != nil {
+			log.Printf("⚠️ Server memory failed, answering from retrieval only: %v", err)
+		} else if memory != "" {
+			d.Prompt = memory + d.Prompt
+			d.external = true
+		}
+	}
+
+	if p.calendar != nil && d.guildID != "" {
+		if events := p.calendar.ContextFor(ctx, parseSnowflake(d.guildID), d.question); events != "" {
+			d.Prompt = events + "\n" + d.Prompt
+			d.external = true
+		}
+	}
+	return nil
+}
+
+// Generate has the model answer a draft, then sanitizes the answer and checks
+// it against the retrieved context. onText, when set, is given the model's
+// text as it is generated; the answer returned may differ from it.
+func (p *Pipeline) Generate(ctx context.Context, d *Draft, onText func(text string)) (Answer, error) {
+	// Answers to standalone questions based only on retrieved context can be
+	// reused until that context changes
+	cacheable := p.responses != nil && d.Retrieved != nil && !d.external && d.standalone
+	var fingerprint string
+	if cacheable {
+		// Answers of another length or style, or given under other rollout
+		// settings, don't fit the question
+		fingerprint = d.Retrieved.Fingerprint() + ":" + string(persona.VerbosityFromContext(ctx)) + ":" + string(persona.StyleFromContext(ctx)) + ":" + p.rolloutTag(d.guildID)
+		if answer, ok := p.responses.get(d.guildID, fingerprint, d.question, d.Retrieved.QueryEmbedding); ok {
+			log.Printf("♻️ Reusing cached answer in guild %s", d.guildID)
+			if onText != nil {
+				onText(answer)
+			}
+			return Answer{Text: answer}, nil
+		}
+	}
+
+	text, err := p.generate(ctx, d, onText)
+	if err != nil {
+		return Answer{}, err
+	}
+	answer := p.checkConfidence(ctx, d, sanitize.Output(p.checkLeak(d.guildID, d.channelID, text)))
+	if cacheable {
+		p.responses.put(d.guildID, fingerprint, d.question, d.Retrieved.QueryEmbedding, answer.Text)
+	}
+	return answer, nil
+}
+
+// generate asks the model, streaming its answer to onText when the AI
+// service can and no tools are offered
+func (p *Pipeline) generate(ctx context.Context, d *Draft, onText func(text string)) (string, error) {
+	if streaming, ok := p.ai.(interfaces.StreamingAIService); ok && onText != nil && len(d.tools) == 0 {
+		return streaming.StreamResponse(ctx, d.Prompt, d.username, onText)
+	}
+	text, err := p.ai.GenerateResponseWithTools(ctx, d.Prompt, d.username, d.tools)
+	if err == nil && onText != nil {
+		onText(text)
+	}
+	return text, err
+}
+
+// rolloutTag names the rollout settings a guild's answers get; see
+// rollout.Service.Tag
+func (p *Pipeline) rolloutTag(guildID string) string {
+	if p.rollout == nil {
+		return ""
+	}
+	return p.rollout.Tag(parseSnowflake(guildID))
+}
+
+// confidenceThresholdFor is the grounding score a guild's answers need
+func (p *Pipeline) confidenceThresholdFor(guildID int64) float64 {
+	if p.rollout == nil {
+		return p.confidenceThreshold
+	}
+	return p.rollout.ConfidenceThreshold(guildID, p.confidenceThreshold)
+}
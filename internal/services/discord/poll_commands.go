@@ -0,0 +1,151 @@
+package discord
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"discord-tars/internal/services/poll"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func pollCommand() *discordgo.ApplicationCommand {
+	minHours := 0.0
+	return &discordgo.ApplicationCommand{
+		Name:        "poll",
+		Description: "Create a poll that T.A.R.S analyzes when it closes",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "question",
+				Description: "The poll question",
+				Required:    true,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "options",
+				Description: fmt.Sprintf("%d to %d options separated by ; (e.g. Pizza; Tacos; Sushi)", poll.MinOptions, poll.MaxOptions),
+				Required:    true,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        "hours",
+				Description: "Close automatically after this many hours (0 = close manually, default 24)",
+				MinValue:    &minHours,
+				MaxValue:    168,
+			},
+		},
+	}
+}
+
+func (b *Bot) handlePollCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if b.pollService == nil {
+		respondEphemeral(s, i, "🔧 Polls are not enabled on this instance.")
+		return
+	}
+	if i.GuildID == "" {
+		respondEphemeral(s, i, "🔧 Polls can only be created in a server channel.")
+		return
+	}
+
+	opts := optionMap(i.ApplicationCommandData().Options)
+	var options []string
+	for _, option := range strings.Split(opts["options"].StringValue(), ";") {
+		if option = strings.TrimSpace(option); option != "" {
+			options = append(options, option)
+		}
+	}
+	hours := int64(24)
+	if opt, ok := opts["hours"]; ok {
+		hours = opt.IntValue()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := b.pollService.Create(ctx,
+		parseSnowflake(i.GuildID),
+		parseSnowflake(i.ChannelID),
+		parseSnowflake(interactionUser(i).ID),
+		opts["question"].StringValue(),
+		options,
+		time.Duration(hours)*time.Hour)
+	if err != nil {
+		log.Printf("❌ Failed to create poll: %v", err)
+		if errors.Is(err, poll.ErrBadOptions) {
+			respondEphemeral(s, i, "🔧 "+err.Error()+".")
+			return
+		}
+		respondEphemeral(s, i, "🔧 Failed to create the poll. Please try again.")
+		return
+	}
+
+	respondEphemeral(s, i, "✅ Poll created!")
+}
+
+func (b *Bot) handlePollVote(s *discordgo.Session, i *discordgo.InteractionCreate, args []string) {
+	if b.pollService == nil || len(args) != 2 {
+		return
+	}
+	pollID, _ := strconv.ParseInt(args[0], 10, 64)
+	option, _ := strconv.Atoi(args[1])
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	label, err := b.pollService.Vote(ctx, pollID, parseSnowflake(interactionUser(i).ID), option)
+	if err != nil {
+		log.Printf("❌ Failed to record vote on poll %d: %v", pollID, err)
+		respondEphemeral(s, i, "🔧 "+pollErrorMessage(err))
+		return
+	}
+	respondEphemeral(s, i, fmt.Sprintf("🗳️ Your vote for **%s** has been recorded.", label))
+}
+
+func (b *Bot) handlePollClose(s *discordgo.Session, i *discordgo.InteractionCreate, args []string) {
+	if b.pollService == nil || len(args) != 1 {
+		return
+	}
+	pollID, _ := strconv.ParseInt(args[0], 10, 64)
+
+	// Closing runs the AI analysis, so acknowledge first
+	if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Flags: discordgo.MessageFlagsEphemeral},
+	}); err != nil {
+		log.Printf("❌ Failed to defer interaction: %v", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	content := "✅ Poll closed. Results are posted below the poll."
+	if err := b.pollService.Close(ctx, pollID, parseSnowflake(interactionUser(i).ID), isGuildAdmin(i)); err != nil {
+		log.Printf("❌ Failed to close poll %d: %v", pollID, err)
+		content = "🔧 " + pollErrorMessage(err)
+	}
+	s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &content})
+}
+
+func pollErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, poll.ErrNotFound),
+		errors.Is(err, poll.ErrClosed),
+		errors.Is(err, poll.ErrNotAllowed),
+		errors.Is(err, poll.ErrInvalidVote):
+		return strings.ToUpper(err.Error()[:1]) + err.Error()[1:] + "."
+	default:
+		return "Something went wrong with the poll. Please try again."
+	}
+}
+
+// SetPollService enables the /poll command
+func (b *Bot) SetPollService(pollService *poll.Service) {
+	b.pollService = pollService
+}
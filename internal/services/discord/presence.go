@@ -0,0 +1,228 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	defaultPresenceInterval = time.Minute
+	// minPresenceGap keeps counter-driven updates under Discord's presence rate limit
+	minPresenceGap = 20 * time.Second
+	// messageCountSync is how often the indexed message count is re-read from the database
+	messageCountSync = 15 * time.Minute
+)
+
+// DefaultPresenceTemplates are shown when none are configured. Each is
+// "<activity>:<text>", where activity is playing, listening, watching,
+// competing or custom, and the text may use {guilds}, {messages}, {humor}
+// and {honesty}.
+var DefaultPresenceTemplates = []string{
+	"custom:🤖 T.A.R.S online | Humor: {humor}%",
+	"listening:/ask",
+	"watching:{guilds} servers",
+	"watching:{messages} indexed messages",
+}
+
+type presenceTemplate struct {
+	activity discordgo.ActivityType
+	text     string
+}
+
+// parsePresenceTemplate reads "<activity>:<text>"; text without a known
+// activity prefix is shown as "Playing <text>"
+func parsePresenceTemplate(raw string) presenceTemplate {
+	kinds := map[string]discordgo.ActivityType{
+		"playing":   discordgo.ActivityTypeGame,
+		"listening": discordgo.ActivityTypeListening,
+		"watching":  discordgo.ActivityTypeWatching,
+		"competing": discordgo.ActivityTypeCompeting,
+		"custom":    discordgo.ActivityTypeCustom,
+	}
+	if prefix, text, ok := strings.Cut(raw, ":"); ok {
+		if kind, known := kinds[strings.ToLower(strings.TrimSpace(prefix))]; known {
+			return presenceTemplate{activity: kind, text: strings.TrimSpace(text)}
+		}
+	}
+	return presenceTemplate{activity: discordgo.ActivityTypeGame, text: strings.TrimSpace(raw)}
+}
+
+// presence rotates the bot's status through templates, re-rendering the
+// current one right away when a counter it shows changes
+type presence struct {
+	mu        sync.Mutex
+	templates []presenceTemplate
+	interval  time.Duration
+	current   int // Index of the template on display, -1 before the first update
+
+	humor    int
+	honesty  int
+	messages int64
+	syncedAt time.Time
+
+	lastUpdate time.Time
+	pending    bool // A counter changed while updates were throttled
+	stop       chan struct{}
+	started    sync.Once
+}
+
+func newPresence(templates []string, interval time.Duration) *presence {
+	if len(templates) == 0 {
+		templates = DefaultPresenceTemplates
+	}
+	if interval <= 0 {
+		interval = defaultPresenceInterval
+	}
+	p := &presence{interval: interval, current: -1, humor: 75, honesty: 100, stop: make(chan struct{})}
+	for _, raw := range templates {
+		if t := parsePresenceTemplate(raw); t.text != "" {
+			p.templates = append(p.templates, t)
+		}
+	}
+	return p
+}
+
+// startPresence begins rotating; reconnects fire onReady again, so it only starts once
+func (b *Bot) startPresence(s *discordgo.Session) {
+	b.presence.started.Do(func() {
+		b.syncMessageCount()
+		b.showPresence(s, true)
+		go func() {
+			ticker := time.NewTicker(b.presence.interval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ticker.C:
+					if time.Since(b.presence.syncedAt) > messageCountSync {
+						b.syncMessageCount()
+					}
+					b.showPresence(s, true)
+				case <-b.presence.stop:
+					return
+				}
+			}
+		}()
+	})
+}
+
+// showPresence renders a template into the bot's status, advancing to the
+// next template when rotating
+func (b *Bot) showPresence(s *discordgo.Session, rotate bool) {
+	p := b.presence
+	p.mu.Lock()
+	if len(p.templates) == 0 {
+		p.mu.Unlock()
+		return
+	}
+	if rotate || p.current < 0 {
+		p.current = (p.current + 1) % len(p.templates)
+	}
+	t := p.templates[p.current]
+	text := strings.NewReplacer(
+		"{guilds}", strconv.Itoa(len(s.State.Guilds)),
+		"{messages}", formatCount(p.messages),
+		"{humor}", strconv.Itoa(p.humor),
+		"{honesty}", strconv.Itoa(p.honesty),
+	).Replace(t.text)
+	p.lastUpdate = time.Now()
+	p.pending = false
+	p.mu.Unlock()
+
+	activity := &discordgo.Activity{Name: text, Type: t.activity}
+	if t.activity == discordgo.ActivityTypeCustom {
+		// Custom statuses show State; Name is required but not displayed
+		activity = &discordgo.Activity{Name: "Custom Status", State: text, Type: t.activity}
+	}
+	if err := s.UpdateStatusComplex(discordgo.UpdateStatusData{
+		Activities: []*discordgo.Activity{activity},
+		Status:     string(discordgo.StatusOnline),
+	}); err != nil {
+		log.Printf("⚠️ Failed to update presence: %v", err)
+	}
+}
+
+// presenceChanged re-renders the status if it shows the given placeholder,
+// waiting out the rate limit gap when the last update was too recent
+func (b *Bot) presenceChanged(s *discordgo.Session, placeholder string) {
+	p := b.presence
+	p.mu.Lock()
+	if p.current < 0 || !strings.Contains(p.templates[p.current].text, placeholder) || p.pending {
+		p.mu.Unlock()
+		return
+	}
+	wait := minPresenceGap - time.Since(p.lastUpdate)
+	if wait > 0 {
+		p.pending = true
+		p.mu.Unlock()
+		time.AfterFunc(wait, func() { b.showPresence(s, false) })
+		return
+	}
+	p.mu.Unlock()
+	b.showPresence(s, false)
+}
+
+// syncMessageCount re-reads the indexed message total; between syncs it is
+// counted up as messages are stored
+func (b *Bot) syncMessageCount() {
+	if b.ragService == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	count, err := b.ragService.CountIndexedMessages(ctx)
+	if err != nil {
+		log.Printf("⚠️ Failed to count indexed messages: %v", err)
+		return
+	}
+	b.presence.mu.Lock()
+	b.presence.messages = count
+	b.presence.syncedAt = time.Now()
+	b.presence.mu.Unlock()
+}
+
+func (b *Bot) countIndexedMessage(s *discordgo.Session) {
+	b.presence.mu.Lock()
+	b.presence.messages++
+	b.presence.mu.Unlock()
+	b.presenceChanged(s, "{messages}")
+}
+
+func (b *Bot) setPresencePersonality(s *discordgo.Session, humor, honesty int) {
+	b.presence.mu.Lock()
+	b.presence.humor, b.presence.honesty = humor, honesty
+	b.presence.mu.Unlock()
+	b.presenceChanged(s, "{humor}")
+	b.presenceChanged(s, "{honesty}")
+}
+
+func (b *Bot) onGuildCreate(s *discordgo.Session, g *discordgo.GuildCreate) {
+	b.presenceChanged(s, "{guilds}")
+	b.guildPartitionChanged(g.ID, true)
+}
+
+func (b *Bot) onGuildDelete(s *discordgo.Session, g *discordgo.GuildDelete) {
+	b.presenceChanged(s, "{guilds}")
+	// An unavailable guild is in an outage, the bot is still in it
+	if !g.Unavailable {
+		b.guildPartitionChanged(g.ID, false)
+	}
+}
+
+// formatCount abbreviates large counts, e.g. 12345 as "12.3k"
+func formatCount(n int64) string {
+	switch {
+	case n >= 1_000_000:
+		return fmt.Sprintf("%.1fM", float64(n)/1_000_000)
+	case n >= 10_000:
+		return fmt.Sprintf("%.1fk", float64(n)/1_000)
+	default:
+		return strconv.FormatInt(n, 10)
+	}
+}
@@ -0,0 +1,231 @@
+package discord
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/services/quiz"
+	"discord-tars/internal/tenant"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// quizMaterialResults is how many pieces of server content a server
+	// history quiz is written from
+	quizMaterialResults = 12
+	// quizLeaderboardSize is how many players the leaderboard lists
+	quizLeaderboardSize = 10
+)
+
+func quizCommand() *discordgo.ApplicationCommand {
+	minQuestions, minMinutes := float64(quiz.MinQuestions), 1.0
+	return &discordgo.ApplicationCommand{
+		Name:        "quiz",
+		Description: "Start a multiple-choice quiz on a topic, scored on the server leaderboard",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "topic",
+				Description: "What the questions are about, e.g. The solar system",
+				Required:    true,
+				MaxLength:   200,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "source",
+				Description: "Where questions come from (default: general knowledge)",
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{Name: "General knowledge", Value: models.QuizSourceGeneral},
+					{Name: "Server history", Value: models.QuizSourceServer},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        "questions",
+				Description: "How many questions (default 3)",
+				MinValue:    &minQuestions,
+				MaxValue:    quiz.MaxQuestions,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        "minutes",
+				Description: "How long answers are open (default 5)",
+				MinValue:    &minMinutes,
+				MaxValue:    60,
+			},
+		},
+	}
+}
+
+func (b *Bot) handleQuizCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if b.quizService == nil {
+		respondEphemeral(s, i, "🔧 Quizzes are not enabled on this instance.")
+		return
+	}
+	if i.GuildID == "" {
+		respondEphemeral(s, i, "🔒 This command only works in a server.")
+		return
+	}
+
+	opts := optionMap(i.ApplicationCommandData().Options)
+	q := &models.Quiz{
+		GuildID:   parseSnowflake(i.GuildID),
+		ChannelID: parseSnowflake(i.ChannelID),
+		CreatedBy: parseSnowflake(interactionUser(i).ID),
+		Topic:     strings.TrimSpace(opts["topic"].StringValue()),
+		Source:    models.QuizSourceGeneral,
+	}
+	if opt, ok := opts["source"]; ok {
+		q.Source = opt.StringValue()
+	}
+	count, minutes := 3, 5
+	if opt, ok := opts["questions"]; ok {
+		count = int(opt.IntValue())
+	}
+	if opt, ok := opts["minutes"]; ok {
+		minutes = int(opt.IntValue())
+	}
+	if q.Source == models.QuizSourceServer && b.ragService == nil {
+		respondEphemeral(s, i, "🔧 Server history quizzes need the search index, which is not enabled on this instance.")
+		return
+	}
+
+	if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	}); err != nil {
+		log.Printf("❌ Failed to defer interaction: %v", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(tenant.WithGuild(context.Background(), q.GuildID), time.Minute)
+	defer cancel()
+
+	var content string
+	material, err := b.quizMaterial(ctx, q)
+	if err == nil {
+		err = b.quizService.Create(ctx, q, count, material, time.Duration(minutes)*time.Minute)
+	}
+	switch {
+	case errors.Is(err, quiz.ErrNoMaterial):
+		content = tr(i, "quiz.no_material", q.Topic)
+	case err != nil:
+		log.Printf("❌ Failed to create quiz on %q: %v", q.Topic, err)
+		content = aiErrorMessage(err, tr(i, "quiz.failed"))
+	case q.Source == models.QuizSourceServer:
+		content = tr(i, "quiz.started_server", interactionUser(i).ID, q.Topic, q.ClosesAt.Unix())
+	default:
+		content = tr(i, "quiz.started", interactionUser(i).ID, q.Topic, q.ClosesAt.Unix())
+	}
+	if _, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &content}); err != nil {
+		log.Printf("❌ Failed to edit interaction response: %v", err)
+	}
+}
+
+// quizMaterial is the server content a server history quiz is written from:
+// what was retrieved for the topic, leaving out recent messages retrieval
+// falls back to when nothing matches
+func (b *Bot) quizMaterial(ctx context.Context, q *models.Quiz) ([]string, error) {
+	if q.Source != models.QuizSourceServer {
+		return nil, nil
+	}
+	rc, err := b.ragService.Retrieve(ctx, q.Topic, q.GuildID, q.ChannelID, quizMaterialResults)
+	if err != nil {
+		return nil, err
+	}
+	var material []string
+	for _, src := range rc.Sources() {
+		if src.Similarity > 0 {
+			material = append(material, src.Text)
+		}
+	}
+	return material, nil
+}
+
+// handleQuizAnswer records the answer a player clicked, telling only them
+// whether it was right
+func (b *Bot) handleQuizAnswer(s *discordgo.Session, i *discordgo.InteractionCreate, args []string) {
+	if b.quizService == nil || len(args) != 2 {
+		return
+	}
+	questionID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return
+	}
+	choice, err := strconv.Atoi(args[1])
+	if err != nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	question, correct, err := b.quizService.Answer(ctx, questionID, parseSnowflake(interactionUser(i).ID), choice)
+	switch {
+	case errors.Is(err, quiz.ErrAlreadyAnswered):
+		respondEphemeral(s, i, tr(i, "quiz.already_answered"))
+	case errors.Is(err, quiz.ErrClosed):
+		respondEphemeral(s, i, tr(i, "quiz.closed"))
+	case errors.Is(err, quiz.ErrNotFound):
+		respondEphemeral(s, i, tr(i, "quiz.not_found"))
+	case err != nil:
+		log.Printf("❌ Failed to record quiz answer: %v", err)
+		respondEphemeral(s, i, tr(i, "quiz.answer_failed"))
+	case correct:
+		respondEphemeral(s, i, tr(i, "quiz.correct", question.Choices[choice]))
+	default:
+		respondEphemeral(s, i, tr(i, "quiz.wrong", question.Choices[choice]))
+	}
+}
+
+// handleQuizLeaderboard shows the guild's best quiz players and where the
+// clicking user stands
+func (b *Bot) handleQuizLeaderboard(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if b.quizService == nil || i.GuildID == "" {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	guildID, userID := parseSnowflake(i.GuildID), parseSnowflake(interactionUser(i).ID)
+	scores, err := b.quizService.Leaderboard(ctx, guildID, quizLeaderboardSize)
+	if err != nil {
+		log.Printf("❌ Failed to load quiz leaderboard: %v", err)
+		respondEphemeral(s, i, tr(i, "quiz.leaderboard_failed"))
+		return
+	}
+	if len(scores) == 0 {
+		respondEphemeral(s, i, tr(i, "quiz.leaderboard_empty"))
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString(tr(i, "quiz.leaderboard_title") + "\n")
+	listed := false
+	for n, score := range scores {
+		fmt.Fprintf(&sb, "**%d.** <@%d> — %s\n", n+1, score.UserID, tr(i, "quiz.score", score.Correct, score.Answered))
+		listed = listed || score.UserID == userID
+	}
+	if !listed {
+		mine, rank, err := b.quizService.UserScore(ctx, guildID, userID)
+		if err != nil {
+			log.Printf("⚠️ Failed to load quiz score of user %d: %v", userID, err)
+		} else if rank > 0 {
+			sb.WriteString("…\n")
+			fmt.Fprintf(&sb, "**%d.** <@%d> — %s\n", rank, userID, tr(i, "quiz.score", mine.Correct, mine.Answered))
+		}
+	}
+	respondEphemeral(s, i, sb.String())
+}
+
+// SetQuizService enables /quiz
+func (b *Bot) SetQuizService(quizService *quiz.Service) {
+	b.quizService = quizService
+}
@@ -0,0 +1,405 @@
+package discord
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"discord-tars/internal/language"
+	"discord-tars/internal/models"
+	"discord-tars/internal/services/rag"
+	"discord-tars/internal/tenant"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	reindexCancelPrefix = "reindex-cancel"
+	// reindexEditWindow stops progress edits before the interaction token expires at 15 minutes
+	reindexEditWindow   = 14 * time.Minute
+	reindexEditInterval = 3 * time.Second
+)
+
+// reindexJobs tracks running reindexes by channel, so each channel runs at most one
+type reindexJobs struct {
+	mu     sync.Mutex
+	cancel map[string]context.CancelFunc
+}
+
+func newReindexJobs() *reindexJobs {
+	return &reindexJobs{cancel: make(map[string]context.CancelFunc)}
+}
+
+// start registers a job, or reports false if the channel already has one
+func (j *reindexJobs) start(channelID string, cancel context.CancelFunc) bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if _, running := j.cancel[channelID]; running {
+		return false
+	}
+	j.cancel[channelID] = cancel
+	return true
+}
+
+func (j *reindexJobs) done(channelID string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	delete(j.cancel, channelID)
+}
+
+// stop cancels a channel's job and reports whether one was running
+func (j *reindexJobs) stop(channelID string) bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	cancel, running := j.cancel[channelID]
+	if running {
+		cancel()
+	}
+	return running
+}
+
+func ragCommand() *discordgo.ApplicationCommand {
+	return &discordgo.ApplicationCommand{
+		Name:        "rag",
+		Description: "Inspect the search index (admins only)",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "status",
+				Description: "Show how much of this server's history is searchable",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "reindex",
+				Description: "Re-embed a channel's stored messages, e.g. after an embedding model change",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionChannel,
+						Name:        "channel",
+						Description: "Channel to reindex",
+						Required:    true,
+						ChannelTypes: []discordgo.ChannelType{
+							discordgo.ChannelTypeGuildText,
+							discordgo.ChannelTypeGuildNews,
+							discordgo.ChannelTypeGuildPublicThread,
+							discordgo.ChannelTypeGuildPrivateThread,
+							discordgo.ChannelTypeGuildNewsThread,
+						},
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "languages",
+				Description: "Show the languages of this server's history, or restrict answers to some of them",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "languages",
+						Description: "Language codes or names to search, e.g. \"en, fr\", or \"all\" to search every language",
+					},
+				},
+			},
+		},
+	}
+}
+
+func (b *Bot) handleRAGCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if b.ragService == nil {
+		respondEphemeral(s, i, "🔧 RAG service is not available.")
+		return
+	}
+	if !isGuildAdmin(i) {
+		respondEphemeral(s, i, tr(i, "admin_only.rag"))
+		return
+	}
+
+	sub := i.ApplicationCommandData().Options[0]
+	switch sub.Name {
+	case "reindex":
+		b.startReindex(s, i, optionMap(sub.Options)["channel"].ChannelValue(nil).ID)
+	case "status":
+		b.deferEphemeral(s, i, func(ctx context.Context) string {
+			stats, err := b.ragService.IndexStatus(ctx, parseSnowflake(i.GuildID))
+			if err != nil {
+				log.Printf("❌ Failed to load index stats: %v", err)
+				return "🔧 I couldn't read the index stats. Please try again later."
+			}
+			return formatIndexStats(stats)
+		})
+	case "languages":
+		b.handleRAGLanguages(s, i, sub)
+	}
+}
+
+// handleRAGLanguages shows which languages a guild's messages are in and
+// which ones answers draw on, or changes the latter
+func (b *Bot) handleRAGLanguages(s *discordgo.Session, i *discordgo.InteractionCreate, sub *discordgo.ApplicationCommandInteractionDataOption) {
+	if !b.ragService.LanguagesEnabled() {
+		respondEphemeral(s, i, "🔧 Message languages are not enabled on this instance.")
+		return
+	}
+	guildID := parseSnowflake(i.GuildID)
+
+	opt, ok := optionMap(sub.Options)["languages"]
+	if !ok {
+		b.deferEphemeral(s, i, func(ctx context.Context) string {
+			allowed, shares, err := b.ragService.GuildLanguages(ctx, guildID)
+			if err != nil {
+				log.Printf("❌ Failed to load guild languages: %v", err)
+				return "🔧 I couldn't read the languages of this server. Please try again later."
+			}
+			return formatGuildLanguages(allowed, shares)
+		})
+		return
+	}
+
+	var codes []string
+	seen := make(map[string]bool)
+	if raw := strings.TrimSpace(opt.StringValue()); !strings.EqualFold(raw, "all") {
+		for _, field := range strings.FieldsFunc(raw, func(r rune) bool { return r == ',' || r == ' ' }) {
+			code, ok := language.Parse(field)
+			if !ok {
+				respondEphemeral(s, i, fmt.Sprintf("❓ I don't know the language `%s`. I can tell apart: %s.", truncateText(field, 50), strings.Join(language.Codes(), ", ")))
+				return
+			}
+			if !seen[code] {
+				seen[code] = true
+				codes = append(codes, code)
+			}
+		}
+		if len(codes) == 0 {
+			respondEphemeral(s, i, "❓ Give language codes or names, e.g. `en, fr`, or `all` to search every language.")
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := b.ragService.SetGuildLanguages(ctx, guildID, codes, parseSnowflake(interactionUser(i).ID)); err != nil {
+		log.Printf("❌ Failed to save guild languages: %v", err)
+		respondEphemeral(s, i, "🔧 Failed to save the languages. Please try again.")
+		return
+	}
+	if len(codes) == 0 {
+		log.Printf("🌐 Language restriction lifted in guild %s", i.GuildID)
+		respondEphemeral(s, i, "✅ Answers draw on messages in every language again.")
+		return
+	}
+	log.Printf("🌐 Guild %s restricted to languages %v", i.GuildID, codes)
+	respondEphemeral(s, i, fmt.Sprintf("✅ Answers now only draw on messages in %s, and on messages too short to tell their language.", languageList(codes)))
+}
+
+func formatGuildLanguages(allowed []string, shares []models.LanguageShare) string {
+	var sb strings.Builder
+	sb.WriteString("🌐 **Languages**\n")
+	if len(allowed) > 0 {
+		sb.WriteString("• Answers draw on: " + languageList(allowed) + "\n")
+	} else {
+		sb.WriteString("• Answers draw on: every language\n")
+	}
+	if len(shares) == 0 {
+		sb.WriteString("• No message languages detected yet.")
+		return sb.String()
+	}
+	var total int64
+	for _, share := range shares {
+		total += share.Messages
+	}
+	parts := make([]string, len(shares))
+	for n, share := range shares {
+		parts[n] = fmt.Sprintf("%s %.0f%%", language.Name(share.Language), float64(share.Messages)*100/float64(total))
+	}
+	sb.WriteString(fmt.Sprintf("• Messages with a detected language: **%d** (%s)", total, strings.Join(parts, ", ")))
+	return sb.String()
+}
+
+// languageList names languages with their codes, e.g. for "en, fr"
+func languageList(codes []string) string {
+	names := make([]string, len(codes))
+	for n, code := range codes {
+		names[n] = fmt.Sprintf("%s (`%s`)", language.Name(code), code)
+	}
+	return strings.Join(names, ", ")
+}
+
+// filterReasonNames describe why the ingest filters skipped messages
+var filterReasonNames = map[string]string{
+	models.FilterReasonShort:     "too short",
+	models.FilterReasonBlocklist: "blocklisted",
+	models.FilterReasonLinkOnly:  "links only",
+	models.FilterReasonCommand:   "bot commands",
+	models.FilterReasonLowValue:  "low value",
+}
+
+func formatIndexStats(stats *models.IndexStats) string {
+	if stats.Messages == 0 {
+		return "📭 **Search index is empty.** I haven't stored any messages from this server yet, so answers can't draw on its history."
+	}
+
+	var sb strings.Builder
+	sb.WriteString("📊 **Search index status**\n")
+	sb.WriteString(fmt.Sprintf("• Messages stored: **%d** (%d with text)\n", stats.Messages, stats.Embeddable))
+	sb.WriteString(fmt.Sprintf("• Embedding coverage: **%.1f%%** (%d embedded)\n", stats.Coverage(), stats.Embedded))
+	if stats.Pending > 0 {
+		sb.WriteString(fmt.Sprintf("• Backlog: **%d** messages waiting for an embedding\n", stats.Pending))
+	} else {
+		sb.WriteString("• Backlog: none\n")
+	}
+	if stats.Filtered > 0 {
+		// Skipped against indexed, so operators can see what the filters cost
+		reasons := make([]string, 0, len(stats.FilteredReasons))
+		for reason := range stats.FilteredReasons {
+			reasons = append(reasons, reason)
+		}
+		sort.Strings(reasons)
+		parts := make([]string, len(reasons))
+		for n, reason := range reasons {
+			parts[n] = fmt.Sprintf("%s %d", filterReasonNames[reason], stats.FilteredReasons[reason])
+		}
+		share := float64(stats.Filtered) * 100 / float64(stats.Filtered+stats.Embedded)
+		sb.WriteString(fmt.Sprintf("• Filtered: **%d** messages left out of search (%.1f%% of those with text): %s\n", stats.Filtered, share, strings.Join(parts, ", ")))
+	}
+	if stats.Oldest != nil && stats.Newest != nil {
+		sb.WriteString(fmt.Sprintf("• Oldest: <t:%d:f> · Newest: <t:%d:R>\n", stats.Oldest.Unix(), stats.Newest.Unix()))
+	}
+	if len(stats.EmbeddingModels) > 0 {
+		names := make([]string, 0, len(stats.EmbeddingModels))
+		for name := range stats.EmbeddingModels {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		parts := make([]string, len(names))
+		for n, name := range names {
+			parts[n] = fmt.Sprintf("`%s` (%d)", name, stats.EmbeddingModels[name])
+		}
+		sb.WriteString("• Embedding models: " + strings.Join(parts, ", ") + "\n")
+	}
+	sb.WriteString(fmt.Sprintf("• Priority documents: %d\n", stats.PriorityDocuments))
+
+	if run := stats.LastRun; run != nil {
+		sb.WriteString(fmt.Sprintf("• Last bulk run: %s of <#%d> started <t:%d:R> — %s", run.Kind, run.ChannelID, run.StartedAt.Unix(), run.Status))
+		if run.Total > 0 {
+			sb.WriteString(fmt.Sprintf(", %d/%d messages", run.Processed, run.Total))
+		} else {
+			sb.WriteString(fmt.Sprintf(", %d messages", run.Processed))
+		}
+		if run.Error != "" {
+			sb.WriteString(" (" + truncateText(run.Error, 200) + ")")
+		}
+		sb.WriteString("\n")
+	} else {
+		sb.WriteString("• Last bulk run: never\n")
+	}
+	return sb.String()
+}
+
+// startReindex runs a reindex in the background, editing its progress into
+// the deferred response with a button to cancel it
+func (b *Bot) startReindex(s *discordgo.Session, i *discordgo.InteractionCreate, channelID string) {
+	ctx, cancel := context.WithCancel(tenant.WithGuild(context.Background(), parseSnowflake(i.GuildID)))
+	if !b.reindexJobs.start(channelID, cancel) {
+		cancel()
+		respondEphemeral(s, i, fmt.Sprintf("⏳ <#%s> is already being reindexed.", channelID))
+		return
+	}
+
+	cancelButton := []discordgo.MessageComponent{
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.Button{
+				Label:    "Cancel",
+				Style:    discordgo.DangerButton,
+				CustomID: reindexCancelPrefix + ":" + channelID,
+			},
+		}},
+	}
+	if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content:    fmt.Sprintf("🔁 Reindexing <#%s>…", channelID),
+			Components: cancelButton,
+			Flags:      discordgo.MessageFlagsEphemeral,
+		},
+	}); err != nil {
+		log.Printf("❌ Failed to respond to interaction: %v", err)
+		b.reindexJobs.done(channelID)
+		cancel()
+		return
+	}
+
+	user := interactionUser(i)
+	go func() {
+		defer b.reindexJobs.done(channelID)
+		defer cancel()
+
+		started := time.Now()
+		var lastEdit time.Time
+		edit := func(content string, components []discordgo.MessageComponent) {
+			if time.Since(started) > reindexEditWindow {
+				return
+			}
+			if _, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &content, Components: &components}); err != nil {
+				log.Printf("⚠️ Failed to edit reindex progress: %v", err)
+			}
+		}
+
+		job := rag.ChannelJob{GuildID: parseSnowflake(i.GuildID), ChannelID: parseSnowflake(channelID), StartedBy: parseSnowflake(user.ID)}
+		var result rag.ReindexProgress
+		err := b.trackJob(ctx, models.IndexRunReindex, job.GuildID, job, func(ctx context.Context) error {
+			var err error
+			result, err = b.ragService.ReindexChannel(ctx, job.GuildID, job.ChannelID, job.StartedBy, func(p rag.ReindexProgress) {
+				if time.Since(lastEdit) < reindexEditInterval {
+					return
+				}
+				lastEdit = time.Now()
+				edit(fmt.Sprintf("🔁 Reindexing <#%s>… %s", channelID, reindexProgressText(p)), cancelButton)
+			})
+			return err
+		})
+
+		elapsed := time.Since(started).Round(time.Second)
+		switch {
+		case errors.Is(err, context.Canceled):
+			edit(fmt.Sprintf("⏹️ Reindex of <#%s> cancelled after %s. %s", channelID, elapsed, reindexProgressText(result)), []discordgo.MessageComponent{})
+		case err != nil:
+			log.Printf("❌ Reindex of channel %s failed: %v", channelID, err)
+			edit(fmt.Sprintf("❌ Reindex of <#%s> failed after %s: %s. %s", channelID, elapsed, truncateText(err.Error(), 300), reindexProgressText(result)), []discordgo.MessageComponent{})
+		default:
+			edit(fmt.Sprintf("✅ Reindexed <#%s> in %s. %s", channelID, elapsed, reindexProgressText(result)), []discordgo.MessageComponent{})
+		}
+	}()
+}
+
+func reindexProgressText(p rag.ReindexProgress) string {
+	text := fmt.Sprintf("%d/%d messages", p.Processed, p.Total)
+	if p.Failed > 0 {
+		text += fmt.Sprintf(" (%d failed)", p.Failed)
+	}
+	return text
+}
+
+// handleReindexCancel stops a running reindex from its Cancel button
+func (b *Bot) handleReindexCancel(s *discordgo.Session, i *discordgo.InteractionCreate, args []string) {
+	if len(args) != 1 {
+		return
+	}
+	if !isGuildAdmin(i) {
+		respondEphemeral(s, i, tr(i, "admin_only.reindex_cancel"))
+		return
+	}
+	if !b.reindexJobs.stop(args[0]) {
+		respondEphemeral(s, i, "That reindex has already finished.")
+		return
+	}
+	// The job edits the final status in once it stops
+	if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredMessageUpdate,
+	}); err != nil {
+		log.Printf("❌ Failed to acknowledge cancel: %v", err)
+	}
+}
@@ -0,0 +1,91 @@
+package discord
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"discord-tars/internal/services/agent"
+	"discord-tars/internal/services/reminders"
+	"discord-tars/internal/services/timezone"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func remindCommand() *discordgo.ApplicationCommand {
+	return &discordgo.ApplicationCommand{
+		Name:        "remind",
+		Description: "Get a reminder here later, e.g. in 2 hours or next friday 5pm",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "when",
+				Description: "When, e.g. in 2 hours, tomorrow 9am, next friday 17:30 or 2025-06-01 18:00",
+				Required:    true,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "what",
+				Description: "What to remind you of",
+				Required:    true,
+				MaxLength:   reminders.MaxTextLength,
+			},
+		},
+	}
+}
+
+func (b *Bot) handleRemindCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if b.reminderService == nil {
+		respondEphemeral(s, i, "🔧 Reminders are not enabled on this instance.")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	opts := optionMap(i.ApplicationCommandData().Options)
+	when := opts["when"].StringValue()
+	zone := b.zoneFor(ctx, i)
+
+	at, err := agent.ParseWhen(when, zone.Location, time.Now())
+	if err != nil {
+		respondEphemeral(s, i, fmt.Sprintf("❓ I couldn't read that time: %v.", err))
+		return
+	}
+	_, err = b.reminderService.Schedule(ctx, &reminders.Reminder{
+		GuildID:   parseSnowflake(i.GuildID),
+		ChannelID: parseSnowflake(i.ChannelID),
+		UserID:    parseSnowflake(interactionUser(i).ID),
+		Text:      strings.TrimSpace(opts["what"].StringValue()),
+	}, at)
+	switch {
+	case errors.Is(err, reminders.ErrPast):
+		respondEphemeral(s, i, fmt.Sprintf("⌛ %s (%s) has already passed.", at.Format("Mon 2 Jan 15:04"), zone.Name()))
+		return
+	case errors.Is(err, reminders.ErrTooFar):
+		respondEphemeral(s, i, fmt.Sprintf("📅 Reminders can be set at most %d days ahead.", int(b.reminderService.MaxAhead().Hours()/24)))
+		return
+	case errors.Is(err, reminders.ErrNoText):
+		respondEphemeral(s, i, "❓ Tell me what to remind you of.")
+		return
+	case err != nil:
+		log.Printf("❌ Failed to schedule reminder: %v", err)
+		respondEphemeral(s, i, "🔧 Failed to set the reminder. Please try again.")
+		return
+	}
+
+	reply := fmt.Sprintf("⏰ I'll remind you here <t:%d:F> (<t:%d:R>).", at.Unix(), at.Unix())
+	if zone.Source == timezone.SourceDefault {
+		reply += "\nI read your time in UTC; set your timezone with `/timezone set` if that's not yours."
+	} else {
+		reply += fmt.Sprintf("\nI read your time in %s.", zone.Name())
+	}
+	respondEphemeral(s, i, reply)
+}
+
+// SetReminderService enables /remind
+func (b *Bot) SetReminderService(reminderService *reminders.Service) {
+	b.reminderService = reminderService
+}
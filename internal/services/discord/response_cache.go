@@ -0,0 +1,123 @@
+package discord
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	// maxCachedContexts bounds the cache; it starts over once full
+	maxCachedContexts = 1000
+	// maxAnswersPerContext caps the differently worded questions kept for one context
+	maxAnswersPerContext = 10
+)
+
+// responseCache reuses answers to questions asked again in a guild. Answers
+// are filed under the fingerprint of the context they were based on, so one
+// stops being used as soon as retrieval finds something new for the question.
+// Within a context, a question matches one worded the same way, or one whose
+// embedding is at least similarity close.
+type responseCache struct {
+	ttl        time.Duration
+	similarity float64
+
+	mu      sync.Mutex
+	entries map[string][]cachedResponse
+}
+
+type cachedResponse struct {
+	question  string // Normalized
+	embedding []float32
+	answer    string
+	cachedAt  time.Time
+}
+
+// newResponseCache returns nil, a disabled cache, when ttl isn't positive
+func newResponseCache(ttl time.Duration, similarity float64) *responseCache {
+	if ttl <= 0 {
+		return nil
+	}
+	return &responseCache{ttl: ttl, similarity: similarity, entries: make(map[string][]cachedResponse)}
+}
+
+// get returns the answer cached for a question asked in a guild with the
+// given context fingerprint
+func (c *responseCache) get(guildID, fingerprint, question string, embedding []float32) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+	key := guildID + "\x00" + fingerprint
+	question = normalizeQuestion(question)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fresh := c.entries[key][:0]
+	answer, found := "", false
+	for _, entry := range c.entries[key] {
+		if time.Since(entry.cachedAt) > c.ttl {
+			continue
+		}
+		fresh = append(fresh, entry)
+		if !found && (entry.question == question || (c.similarity > 0 && cosineSimilarity(entry.embedding, embedding) >= c.similarity)) {
+			answer, found = entry.answer, true
+		}
+	}
+	if len(fresh) == 0 {
+		delete(c.entries, key)
+	} else {
+		c.entries[key] = fresh
+	}
+	return answer, found
+}
+
+// put caches an answer, keeping the most recent ones per context
+func (c *responseCache) put(guildID, fingerprint, question string, embedding []float32, answer string) {
+	if c == nil {
+		return
+	}
+	key := guildID + "\x00" + fingerprint
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, exists := c.entries[key]; !exists && len(c.entries) >= maxCachedContexts {
+		c.entries = make(map[string][]cachedResponse)
+	}
+	entries := append(c.entries[key], cachedResponse{
+		question:  normalizeQuestion(question),
+		embedding: embedding,
+		answer:    answer,
+		cachedAt:  time.Now(),
+	})
+	if len(entries) > maxAnswersPerContext {
+		entries = entries[len(entries)-maxAnswersPerContext:]
+	}
+	c.entries[key] = entries
+}
+
+// clear drops every cached answer, e.g. when the personality changes
+func (c *responseCache) clear() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string][]cachedResponse)
+}
+
+// cosineSimilarity returns the cosine similarity of two vectors, or 0 if they can't be compared
+func cosineSimilarity(a, b []float32) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+	var dot, normA, normB float64
+	for n := range a {
+		dot += float64(a[n]) * float64(b[n])
+		normA += float64(a[n]) * float64(a[n])
+		normB += float64(b[n]) * float64(b[n])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
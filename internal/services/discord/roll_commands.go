@@ -0,0 +1,77 @@
+package discord
+
+import (
+	"fmt"
+	"strings"
+
+	"discord-tars/internal/dice"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func rollCommand() *discordgo.ApplicationCommand {
+	return &discordgo.ApplicationCommand{
+		Name:        "roll",
+		Description: "Roll dice: 1d20, 3d6+2, d%, 4d6kh3 (keep the 3 highest)",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "dice",
+				Description: "Dice notation, 1d20 by default",
+				MaxLength:   100,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "mode",
+				Description: "Roll the d20 twice and keep the highest or the lowest",
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{Name: "Advantage", Value: "advantage"},
+					{Name: "Disadvantage", Value: "disadvantage"},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "reason",
+				Description: "What the roll is for, e.g. Stealth check",
+				MaxLength:   100,
+			},
+		},
+	}
+}
+
+func (b *Bot) handleRollCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	opts := optionMap(i.ApplicationCommandData().Options)
+	notation := "1d20"
+	if opt, ok := opts["dice"]; ok && strings.TrimSpace(opt.StringValue()) != "" {
+		notation = strings.TrimSpace(opt.StringValue())
+	}
+	if opt, ok := opts["mode"]; ok {
+		rolled := dice.Advantage(notation, opt.StringValue() == "disadvantage")
+		if rolled == notation {
+			respondEphemeral(s, i, tr(i, "roll.no_d20"))
+			return
+		}
+		notation = rolled
+	}
+
+	result, err := dice.Roll(notation)
+	if err != nil {
+		respondEphemeral(s, i, tr(i, "roll.invalid", err))
+		return
+	}
+
+	var sb strings.Builder
+	if opt, ok := opts["reason"]; ok {
+		sb.WriteString(tr(i, "roll.header_reason", interactionUser(i).ID, strings.ReplaceAll(opt.StringValue(), "*", "")))
+	} else {
+		sb.WriteString(tr(i, "roll.header", interactionUser(i).ID))
+	}
+	fmt.Fprintf(&sb, "\n%s = **%d**", result.Breakdown(), result.Total)
+	switch result.Natural() {
+	case 20:
+		sb.WriteString("\n" + tr(i, "roll.natural_20"))
+	case 1:
+		sb.WriteString("\n" + tr(i, "roll.natural_1"))
+	}
+	respondText(s, i, truncateText(sb.String(), 2000))
+}
@@ -0,0 +1,25 @@
+package discord
+
+import "discord-tars/internal/services/rollout"
+
+// recordQuality counts an answer, or a failure to answer, toward the quality
+// signals a rollout is judged on
+func (b *Bot) recordQuality(guildID string, signal rollout.Signal) {
+	if b.rolloutService != nil {
+		b.rolloutService.Record(parseSnowflake(guildID), signal)
+	}
+}
+
+// noteAnswered lets reactions to an answer count as feedback on the settings
+// it was given with
+func (b *Bot) noteAnswered(guildID, messageID string) {
+	if b.rolloutService != nil && guildID != "" {
+		b.rolloutService.Answered(parseSnowflake(guildID), messageID)
+	}
+}
+
+// SetRolloutService tries changes to the answer settings on a cohort of
+// guilds first, rolling them back when their quality regresses
+func (b *Bot) SetRolloutService(rolloutService *rollout.Service) {
+	b.rolloutService = rolloutService
+}
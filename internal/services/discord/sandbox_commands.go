@@ -0,0 +1,167 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"discord-tars/internal/services/sandbox"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func sandboxCommand() *discordgo.ApplicationCommand {
+	return &discordgo.ApplicationCommand{
+		Name:        "sandbox",
+		Description: "Answer without replying publicly, to try changes on live traffic (admins only)",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "on",
+				Description: "Post replies to a debug channel, or only log them, instead of replying",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:         discordgo.ApplicationCommandOptionChannel,
+						Name:         "channel",
+						Description:  "Debug channel for the replies; without one they are only logged",
+						ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText},
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "off",
+				Description: "Reply publicly again",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "status",
+				Description: "Show whether replies are held back, and where they go",
+			},
+		},
+	}
+}
+
+func (b *Bot) handleSandboxCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if b.sandboxService == nil {
+		respondEphemeral(s, i, "🔧 Sandbox mode is not enabled on this instance.")
+		return
+	}
+	if i.GuildID == "" {
+		respondEphemeral(s, i, "🔒 This command only works in a server.")
+		return
+	}
+	if !isGuildAdmin(i) {
+		respondEphemeral(s, i, tr(i, "admin_only.sandbox"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	guildID := parseSnowflake(i.GuildID)
+	sub := i.ApplicationCommandData().Options[0]
+
+	if b.sandboxService.Instance() && sub.Name != "status" {
+		respondEphemeral(s, i, "🧪 This whole instance runs in sandbox mode, so it never replies publicly.")
+		return
+	}
+
+	switch sub.Name {
+	case "on":
+		var channelID int64
+		if opt, ok := optionMap(sub.Options)["channel"]; ok {
+			channelID = parseSnowflake(opt.ChannelValue(s).ID)
+		}
+		if err := b.sandboxService.Enable(ctx, guildID, channelID, parseSnowflake(interactionUser(i).ID)); err != nil {
+			log.Printf("❌ Failed to enable sandbox mode: %v", err)
+			respondEphemeral(s, i, "🔧 Failed to enable sandbox mode. Please try again.")
+			return
+		}
+		log.Printf("🧪 Sandbox mode on in guild %s", i.GuildID)
+		if channelID == 0 {
+			respondEphemeral(s, i, "🧪 Sandbox mode is on: I still answer mentions and suggest earlier answers, but only write my replies to the logs. Run `/sandbox off` to reply publicly again.")
+			return
+		}
+		respondEphemeral(s, i, fmt.Sprintf("🧪 Sandbox mode is on: I still answer mentions and suggest earlier answers, but post my replies in <#%d> instead of replying. Run `/sandbox off` to reply publicly again.", channelID))
+
+	case "off":
+		disabled, err := b.sandboxService.Disable(ctx, guildID)
+		if err != nil {
+			log.Printf("❌ Failed to disable sandbox mode: %v", err)
+			respondEphemeral(s, i, "🔧 Failed to disable sandbox mode. Please try again.")
+			return
+		}
+		if !disabled {
+			respondEphemeral(s, i, "ℹ️ Sandbox mode was not on.")
+			return
+		}
+		log.Printf("🧪 Sandbox mode off in guild %s", i.GuildID)
+		respondEphemeral(s, i, "✅ Sandbox mode is off, I reply publicly again.")
+
+	case "status":
+		sb := b.sandboxService.Get(ctx, guildID)
+		switch {
+		case sb == nil:
+			respondEphemeral(s, i, "ℹ️ Sandbox mode is off, I reply publicly.")
+		case sb.ChannelID == 0:
+			respondEphemeral(s, i, "🧪 Sandbox mode is on: my replies are only written to the logs.")
+		default:
+			respondEphemeral(s, i, fmt.Sprintf("🧪 Sandbox mode is on: my replies are posted in <#%d>.", sb.ChannelID))
+		}
+	}
+}
+
+// sandboxed tells whether a guild's replies are held back
+func (b *Bot) sandboxed(guildID string) bool {
+	if b.sandboxService == nil || guildID == "" {
+		return false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return b.sandboxService.Get(ctx, parseSnowflake(guildID)) != nil
+}
+
+// sendReply sends a message the bot writes on its own in reply to a member's
+// message, in the same channel. In sandbox mode the reply is diverted to the
+// logs and the debug channel instead, and nil is returned.
+func (b *Bot) sendReply(trigger *discordgo.Message, msg *discordgo.MessageSend) (*discordgo.Message, error) {
+	if b.sandboxService != nil && trigger.GuildID != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if sb := b.sandboxService.Get(ctx, parseSnowflake(trigger.GuildID)); sb != nil {
+			b.divertReply(ctx, trigger, sb.ChannelID, msg)
+			return nil, nil
+		}
+	}
+	return b.session.ChannelMessageSendComplex(trigger.ChannelID, msg)
+}
+
+// divertReply logs a reply held back by sandbox mode and posts it to the
+// debug channel, if there is one, under the message it answers
+func (b *Bot) divertReply(ctx context.Context, trigger *discordgo.Message, debugChannelID int64, msg *discordgo.MessageSend) {
+	log.Printf("🧪 Sandbox reply in guild %s, channel %s: %q", trigger.GuildID, trigger.ChannelID, msg.Content)
+	if debugChannelID == 0 {
+		return
+	}
+
+	header := fmt.Sprintf("🧪 Would have replied in <#%s> to https://discord.com/channels/%s/%s/%s", trigger.ChannelID, trigger.GuildID, trigger.ChannelID, trigger.ID)
+	if question := strings.Join(strings.Fields(trigger.Content), " "); question != "" {
+		header += "\n> " + truncateText(question, 300)
+	}
+	header += "\n"
+	if _, err := b.session.ChannelMessageSendComplex(strconv.FormatInt(debugChannelID, 10), &discordgo.MessageSend{
+		Content:         header + truncateText(msg.Content, 2000-len(header)),
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+		Flags:           discordgo.MessageFlagsSuppressEmbeds,
+	}, discordgo.WithContext(ctx)); err != nil {
+		log.Printf("❌ Failed to post sandbox reply to debug channel %d: %v", debugChannelID, err)
+	}
+}
+
+// SetSandboxService enables /sandbox
+func (b *Bot) SetSandboxService(sandboxService *sandbox.Service) {
+	b.sandboxService = sandboxService
+}
@@ -0,0 +1,420 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"discord-tars/internal/events"
+	"discord-tars/internal/models"
+	"discord-tars/internal/services/rag"
+	"discord-tars/internal/tenant"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// searchMaxResults is how many messages /search lists
+const searchMaxResults = 10
+
+func searchCommand() *discordgo.ApplicationCommand {
+	return &discordgo.ApplicationCommand{
+		Name:        "search",
+		Description: "Find messages about a topic across the server",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "query",
+				Description: "What to look for, e.g. how to reset the staging database",
+				MaxLength:   200,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "sort",
+				Description: "Order of the results (default most relevant)",
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{Name: "Most relevant", Value: rag.SortRelevance},
+					{Name: "Most endorsed", Value: rag.SortEndorsed},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "code",
+				Description: "Find shared code snippets instead, e.g. retry with exponential backoff",
+				MaxLength:   200,
+			},
+			{
+				Type:         discordgo.ApplicationCommandOptionString,
+				Name:         "language",
+				Description:  "Only code in this language, e.g. go or python",
+				MaxLength:    32,
+				Autocomplete: true,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "files",
+				Description: "Find shared files instead, by name, sharer or content, e.g. the budget spreadsheet Dana uploaded",
+				MaxLength:   200,
+			},
+		},
+	}
+}
+
+func (b *Bot) handleSearchCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if b.ragService == nil {
+		respondEphemeral(s, i, "🔧 RAG service is not available.")
+		return
+	}
+	if i.GuildID == "" {
+		respondEphemeral(s, i, "🔒 This command only works in a server.")
+		return
+	}
+
+	opts := optionMap(i.ApplicationCommandData().Options)
+	if opt, ok := opts["files"]; ok && strings.TrimSpace(opt.StringValue()) != "" {
+		b.searchFiles(s, i, strings.TrimSpace(opt.StringValue()))
+		return
+	}
+	var query, code, language string
+	if opt, ok := opts["query"]; ok {
+		query = strings.TrimSpace(opt.StringValue())
+	}
+	if opt, ok := opts["code"]; ok {
+		code = strings.TrimSpace(opt.StringValue())
+	}
+	if opt, ok := opts["language"]; ok {
+		language = rag.NormalizeLanguage(opt.StringValue())
+	}
+	// A language alone searches its snippets for the query
+	if code == "" && language != "" {
+		code = query
+	}
+	if code != "" || language != "" {
+		if code == "" {
+			respondEphemeral(s, i, "❓ Tell me what the code you're looking for does.")
+			return
+		}
+		b.searchCode(s, i, code, language)
+		return
+	}
+	if query == "" {
+		respondEphemeral(s, i, "❓ Tell me what to look for.")
+		return
+	}
+	order := rag.SortRelevance
+	if opt, ok := opts["sort"]; ok {
+		order = opt.StringValue()
+	}
+
+	// Results can come from any channel, so only the requester sees them
+	if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Flags: discordgo.MessageFlagsEphemeral},
+	}); err != nil {
+		log.Printf("❌ Failed to defer interaction: %v", err)
+		return
+	}
+
+	guildID := parseSnowflake(i.GuildID)
+	ctx, cancel := context.WithTimeout(tenant.WithGuild(context.Background(), guildID), 30*time.Second)
+	defer cancel()
+
+	var content string
+	hits, err := b.ragService.Search(ctx, guildID, query, order)
+	if err != nil {
+		log.Printf("❌ Failed to search for %q: %v", query, err)
+		content = aiErrorMessage(err, "🔧 I couldn't search the server's history. Please try again later.")
+	} else {
+		content = searchResults(s, i, query, order, hits)
+	}
+	if _, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &content}); err != nil {
+		log.Printf("❌ Failed to edit interaction response: %v", err)
+	}
+}
+
+// searchResults lists the hits in channels the requester can read
+func searchResults(s *discordgo.Session, i *discordgo.InteractionCreate, query, order string, hits []rag.SearchHit) string {
+	userID := interactionUser(i).ID
+	readable := make(map[int64]bool)
+	var lines []string
+	for _, hit := range hits {
+		channelID := hit.Message.ChannelID
+		ok, checked := readable[channelID]
+		if !checked {
+			ok = userCanRead(s, userID, strconv.FormatInt(channelID, 10))
+			readable[channelID] = ok
+		}
+		if !ok || strings.TrimSpace(hit.Message.Content) == "" {
+			continue
+		}
+
+		line := fmt.Sprintf("• [%s](%s) — %s in <#%d>", truncateText(exampleSnippet(hit.SearchResult), 90), storedMessageLink(hit.Message), hit.User.Username, channelID)
+		switch {
+		case hit.Reactions == 1:
+			line += " · 1 reaction"
+		case hit.Reactions > 1:
+			line += fmt.Sprintf(" · %d reactions", hit.Reactions)
+		}
+		lines = append(lines, line)
+		if len(lines) == searchMaxResults {
+			break
+		}
+	}
+	if len(lines) == 0 {
+		return fmt.Sprintf("🔎 I found nothing about **%s** in the channels you can read.", query)
+	}
+
+	heading := "most relevant first"
+	if order == rag.SortEndorsed {
+		heading = "most endorsed first"
+	}
+	return truncateText(fmt.Sprintf("🔎 **Messages about %s** (%s)\n%s", query, heading, strings.Join(lines, "\n")), 2000)
+}
+
+// searchCode lists the shared code snippets matching a description
+func (b *Bot) searchCode(s *discordgo.Session, i *discordgo.InteractionCreate, query, language string) {
+	if !b.ragService.CodeSearchEnabled() {
+		respondEphemeral(s, i, "🔧 Code search is not enabled on this bot.")
+		return
+	}
+	if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Flags: discordgo.MessageFlagsEphemeral},
+	}); err != nil {
+		log.Printf("❌ Failed to defer interaction: %v", err)
+		return
+	}
+
+	guildID := parseSnowflake(i.GuildID)
+	ctx, cancel := context.WithTimeout(tenant.WithGuild(context.Background(), guildID), 30*time.Second)
+	defer cancel()
+
+	var content string
+	results, err := b.ragService.SearchCode(ctx, guildID, query, language)
+	if err != nil {
+		log.Printf("❌ Failed to search code for %q: %v", query, err)
+		content = aiErrorMessage(err, "🔧 I couldn't search the server's code snippets. Please try again later.")
+	} else {
+		content = codeSearchResults(s, i, query, language, results)
+	}
+	if _, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &content}); err != nil {
+		log.Printf("❌ Failed to edit interaction response: %v", err)
+	}
+}
+
+// codeSearchResults lists the snippets in channels the requester can read,
+// quoting the best match
+func codeSearchResults(s *discordgo.Session, i *discordgo.InteractionCreate, query, language string, results []models.CodeResult) string {
+	userID := interactionUser(i).ID
+	readable := make(map[int64]bool)
+	var best *models.CodeSnippet
+	var lines []string
+	for n := range results {
+		snippet := &results[n].Snippet
+		ok, checked := readable[snippet.ChannelID]
+		if !checked {
+			ok = userCanRead(s, userID, strconv.FormatInt(snippet.ChannelID, 10))
+			readable[snippet.ChannelID] = ok
+		}
+		if !ok {
+			continue
+		}
+		if best == nil {
+			best = snippet
+		}
+
+		firstLine, _, _ := strings.Cut(snippet.Code, "\n")
+		firstLine = strings.NewReplacer("[", "(", "]", ")", "`", "'").Replace(strings.TrimSpace(firstLine))
+		link := fmt.Sprintf("https://discord.com/channels/%d/%d/%d", snippet.GuildID, snippet.ChannelID, snippet.MessageID)
+		line := fmt.Sprintf("• [%s](%s) — %s in <#%d>", truncateText(firstLine, 70), link, results[n].Username, snippet.ChannelID)
+		if snippet.Language != "" {
+			line = fmt.Sprintf("• `%s` %s", snippet.Language, strings.TrimPrefix(line, "• "))
+		}
+		lines = append(lines, line)
+		if len(lines) == searchMaxResults {
+			break
+		}
+	}
+
+	subject := query
+	if language != "" {
+		subject = fmt.Sprintf("%s (%s)", query, language)
+	}
+	if len(lines) == 0 {
+		return fmt.Sprintf("🧩 I found no shared code for **%s** in the channels you can read.", subject)
+	}
+
+	header := fmt.Sprintf("🧩 **Code snippets for %s**\n%s", subject, strings.Join(lines, "\n"))
+	// Quote the best match when it fits, fences defused so the block stays closed
+	code := strings.ReplaceAll(best.Code, "```", "`\u200b``")
+	quoted := fmt.Sprintf("\n\n```%s\n%s\n```", best.Language, code)
+	if len(header)+len(quoted) <= 2000 {
+		return header + quoted
+	}
+	return truncateText(header, 2000)
+}
+
+// searchFiles lists the shared files matching a description
+func (b *Bot) searchFiles(s *discordgo.Session, i *discordgo.InteractionCreate, query string) {
+	if !b.ragService.FileSearchEnabled() {
+		respondEphemeral(s, i, "🔧 File search is not enabled on this bot.")
+		return
+	}
+	if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Flags: discordgo.MessageFlagsEphemeral},
+	}); err != nil {
+		log.Printf("❌ Failed to defer interaction: %v", err)
+		return
+	}
+
+	guildID := parseSnowflake(i.GuildID)
+	ctx, cancel := context.WithTimeout(tenant.WithGuild(context.Background(), guildID), 30*time.Second)
+	defer cancel()
+
+	var content string
+	results, err := b.ragService.SearchFiles(ctx, guildID, query)
+	if err != nil {
+		log.Printf("❌ Failed to search files for %q: %v", query, err)
+		content = aiErrorMessage(err, "🔧 I couldn't search the server's shared files. Please try again later.")
+	} else {
+		content = fileSearchResults(s, i, query, results)
+	}
+	if _, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &content}); err != nil {
+		log.Printf("❌ Failed to edit interaction response: %v", err)
+	}
+}
+
+// fileSearchResults lists the files in channels the requester can read,
+// linking the messages they were shared in
+func fileSearchResults(s *discordgo.Session, i *discordgo.InteractionCreate, query string, results []models.AttachmentResult) string {
+	userID := interactionUser(i).ID
+	readable := make(map[int64]bool)
+	var lines []string
+	for n := range results {
+		file := &results[n].Attachment
+		ok, checked := readable[file.ChannelID]
+		if !checked {
+			ok = userCanRead(s, userID, strconv.FormatInt(file.ChannelID, 10))
+			readable[file.ChannelID] = ok
+		}
+		if !ok {
+			continue
+		}
+
+		name := strings.NewReplacer("[", "(", "]", ")", "`", "'").Replace(file.Filename)
+		link := fmt.Sprintf("https://discord.com/channels/%d/%d/%d", file.GuildID, file.ChannelID, file.MessageID)
+		lines = append(lines, fmt.Sprintf("• [%s](%s) — %s, %s, shared by %s in <#%d> <t:%d:d>",
+			truncateText(name, 70), link, rag.FileKind(file.Filename, file.ContentType), formatFileSize(file.Size),
+			results[n].Username, file.ChannelID, file.Timestamp.Unix()))
+		if len(lines) == searchMaxResults {
+			break
+		}
+	}
+	if len(lines) == 0 {
+		return fmt.Sprintf("📎 I found no shared files for **%s** in the channels you can read.", query)
+	}
+	return truncateText(fmt.Sprintf("📎 **Files for %s**\n%s", query, strings.Join(lines, "\n")), 2000)
+}
+
+func formatFileSize(bytes int64) string {
+	switch {
+	case bytes >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(bytes)/(1<<20))
+	case bytes >= 1<<10:
+		return fmt.Sprintf("%d KB", bytes/(1<<10))
+	}
+	return fmt.Sprintf("%d B", bytes)
+}
+
+// languageSuggestions offers the languages of the server's shared snippets
+func (b *Bot) languageSuggestions(i *discordgo.InteractionCreate, options []*discordgo.ApplicationCommandInteractionDataOption) []*discordgo.ApplicationCommandOptionChoice {
+	if b.ragService == nil || i.GuildID == "" {
+		return nil
+	}
+	var typed string
+	for _, opt := range options {
+		if opt.Focused && opt.Name == "language" {
+			typed = strings.ToLower(strings.TrimSpace(opt.StringValue()))
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), autocompleteTimeout)
+	defer cancel()
+	languages, err := b.ragService.CodeLanguages(ctx, parseSnowflake(i.GuildID), maxAutocompleteChoices)
+	if err != nil {
+		log.Printf("⚠️ %v", err)
+		return nil
+	}
+
+	choices := make([]*discordgo.ApplicationCommandOptionChoice, 0, len(languages))
+	for _, language := range languages {
+		if strings.HasPrefix(language, typed) {
+			choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: language, Value: language})
+		}
+	}
+	return choices
+}
+
+// onMessageReactionAdd publishes reactions; counts on indexed messages rank
+// them higher in searches, and enough of them make a highlight
+func (b *Bot) onMessageReactionAdd(s *discordgo.Session, r *discordgo.MessageReactionAdd) {
+	if r.GuildID != "" {
+		b.publish(events.NewReactionEvent(events.ReactionAdded, r.MessageReaction))
+	}
+	// Thumbs on answers are feedback on the settings they were given with
+	if r.UserID != s.State.User.ID {
+		if b.rolloutService != nil {
+			b.rolloutService.Feedback(r.MessageID, r.Emoji.Name)
+		}
+		b.answerFeedback(r.GuildID, r.MessageID, r.Emoji.Name, 1)
+	}
+}
+
+func (b *Bot) onMessageReactionRemove(s *discordgo.Session, r *discordgo.MessageReactionRemove) {
+	if r.GuildID != "" {
+		b.publish(events.NewReactionEvent(events.ReactionRemoved, r.MessageReaction))
+	}
+	if r.UserID != s.State.User.ID {
+		b.answerFeedback(r.GuildID, r.MessageID, r.Emoji.Name, -1)
+	}
+}
+
+func (b *Bot) onMessageReactionRemoveAll(s *discordgo.Session, r *discordgo.MessageReactionRemoveAll) {
+	if r.GuildID != "" {
+		b.publish(events.NewReactionEvent(events.ReactionsCleared, r.MessageReaction))
+	}
+}
+
+// countReaction keeps the reaction counts of indexed messages
+func (b *Bot) countReaction(ctx context.Context, event *events.Event) error {
+	r := event.Reaction
+	// The bot's own reactions, e.g. on polls, don't endorse anything
+	if b.ragService == nil || (b.session.State.User != nil && r.UserID == b.session.State.User.ID) {
+		return nil
+	}
+	delta := 1
+	if event.Topic == events.ReactionRemoved {
+		delta = -1
+	}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	if err := b.ragService.RecordReaction(ctx, r.MessageID, r.Emoji, delta); err != nil {
+		return fmt.Errorf("failed to record reaction on message %s: %w", r.MessageID, err)
+	}
+	return nil
+}
+
+func (b *Bot) clearReactions(ctx context.Context, event *events.Event) error {
+	if b.ragService == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	if err := b.ragService.ClearReactions(ctx, event.Reaction.MessageID); err != nil {
+		return fmt.Errorf("failed to clear reactions of message %s: %w", event.Reaction.MessageID, err)
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package discord
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"discord-tars/internal/i18n"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func stageCommand() *discordgo.ApplicationCommand {
+	return &discordgo.ApplicationCommand{
+		Name:        "stage",
+		Description: "Transcribe the stage you're on into the server's searchable history (stage organizers only)",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "transcribe",
+				Description: "Transcribe what is said on your stage into a text channel, searchable later",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:         discordgo.ApplicationCommandOptionChannel,
+						Name:         "channel",
+						Description:  "Text channel where the transcript is posted",
+						Required:     true,
+						ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText},
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "stop",
+				Description: "Stop transcribing the stage and leave it",
+			},
+		},
+	}
+}
+
+func (b *Bot) handleStageCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !b.config.StageTranscription || b.voiceService == nil {
+		respondEphemeral(s, i, tr(i, "stage.disabled"))
+		return
+	}
+	if i.GuildID == "" {
+		respondEphemeral(s, i, tr(i, "stage.not_on_stage"))
+		return
+	}
+
+	guildID := i.GuildID
+	userID := interactionUser(i).ID
+	sub := i.ApplicationCommandData().Options[0]
+
+	switch sub.Name {
+	case "transcribe":
+		stageID := userVoiceChannel(s, guildID, userID)
+		if !isStage(s, stageID) {
+			respondEphemeral(s, i, tr(i, "stage.not_on_stage"))
+			return
+		}
+		// Recording a stage needs the consent of whoever runs it
+		if !isStageOrganizer(s, guildID, userID, stageID) {
+			respondEphemeral(s, i, tr(i, "stage.not_organizer"))
+			return
+		}
+		channelID := optionMap(sub.Options)["channel"].ChannelValue(s).ID
+
+		if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Flags: discordgo.MessageFlagsEphemeral},
+		}); err != nil {
+			log.Printf("❌ Failed to defer interaction: %v", err)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		content := tr(i, "stage.transcribing", stageID, channelID)
+		if _, err := b.voiceService.JoinVoiceChannel(ctx, s, guildID, stageID); err != nil {
+			log.Printf("❌ Failed to join stage: %v", err)
+			content = tr(i, "stage.failed")
+			s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &content})
+			return
+		}
+		go b.converse(b.conversations.start(guildID), guildID, voiceListenOptions{syncChannelID: channelID})
+
+		// Everyone on the stage is told they are being transcribed, and where
+		if _, err := s.ChannelMessageSendComplex(stageID, &discordgo.MessageSend{
+			Content:         i18n.T(guildLocale(s, guildID), "stage.notice", userID, channelID),
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		}); err != nil {
+			log.Printf("⚠️ Failed to post transcription notice on stage %s: %v", stageID, err)
+		}
+		log.Printf("🎤 Transcribing stage %s into channel %s for %s", stageID, channelID, userID)
+		s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &content})
+
+	case "stop":
+		vc := b.voiceService.Connection(guildID)
+		if vc == nil || !isStage(s, vc.ChannelID) {
+			respondEphemeral(s, i, tr(i, "stage.not_transcribing"))
+			return
+		}
+		if !isStageOrganizer(s, guildID, userID, vc.ChannelID) {
+			respondEphemeral(s, i, tr(i, "stage.not_organizer"))
+			return
+		}
+		b.conversations.stop(guildID)
+		b.voiceService.DisconnectVoice(guildID)
+		respondEphemeral(s, i, tr(i, "stage.stopped"))
+	}
+}
+
+// onStageInstanceDelete stops transcribing a stage once it ends
+func (b *Bot) onStageInstanceDelete(s *discordgo.Session, e *discordgo.StageInstanceEventDelete) {
+	if b.voiceService == nil {
+		return
+	}
+	if vc := b.voiceService.Connection(e.GuildID); vc != nil && vc.ChannelID == e.ChannelID {
+		b.conversations.stop(e.GuildID)
+		b.voiceService.DisconnectVoice(e.GuildID)
+		log.Printf("🎤 Stage %s ended, stopped transcribing", e.ChannelID)
+	}
+}
+
+// userVoiceChannel returns the voice or stage channel a member is in, or ""
+func userVoiceChannel(s *discordgo.Session, guildID, userID string) string {
+	guild, err := s.State.Guild(guildID)
+	if err != nil {
+		return ""
+	}
+	for _, vs := range guild.VoiceStates {
+		if vs.UserID == userID {
+			return vs.ChannelID
+		}
+	}
+	return ""
+}
+
+func isStage(s *discordgo.Session, channelID string) bool {
+	if channelID == "" {
+		return false
+	}
+	channel, err := s.State.Channel(channelID)
+	return err == nil && channel.Type == discordgo.ChannelTypeGuildStageVoice
+}
+
+// isStageOrganizer tells whether a member runs a stage: a stage moderator,
+// or whoever scheduled the event live on it
+func isStageOrganizer(s *discordgo.Session, guildID, userID, stageID string) bool {
+	if perms, err := s.State.UserChannelPermissions(userID, stageID); err == nil && perms&discordgo.PermissionVoiceMuteMembers != 0 {
+		return true
+	}
+	events, err := s.GuildScheduledEvents(guildID, false)
+	if err != nil {
+		log.Printf("⚠️ Failed to fetch events of guild %s: %v", guildID, err)
+		return false
+	}
+	for _, event := range events {
+		if event.ChannelID == stageID && event.Status == discordgo.GuildScheduledEventStatusActive && event.CreatorID == userID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,221 @@
+package discord
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"discord-tars/internal/services/standup"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+var userMentionPattern = regexp.MustCompile(`<@!?(\d+)>`)
+
+func standupCommand() *discordgo.ApplicationCommand {
+	minHours := 1.0
+	return &discordgo.ApplicationCommand{
+		Name:        "standup",
+		Description: "Run async standups in this channel",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "setup",
+				Description: "Configure the standup team of this channel (admins only)",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "members",
+						Description: "Mention every team member, e.g. @alice @bob",
+						Required:    true,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "name",
+						Description: "Team name (defaults to the channel name)",
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "start",
+				Description: "Start collecting standup updates",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "hours",
+						Description: "Collection window in hours (default 4)",
+						MinValue:    &minHours,
+						MaxValue:    48,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "update",
+				Description: "Post your standup update",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "text",
+						Description: "What you did, what you're doing, and any blockers",
+						Required:    true,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "status",
+				Description: "Show who has posted an update",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "summary",
+				Description: "Close the standup and post the AI summary now",
+			},
+		},
+	}
+}
+
+func (b *Bot) handleStandupCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if b.standupService == nil {
+		respondEphemeral(s, i, "🔧 Standups are not enabled on this instance.")
+		return
+	}
+	if i.GuildID == "" {
+		respondEphemeral(s, i, "🔧 Standups can only be run in a server channel.")
+		return
+	}
+
+	sub := i.ApplicationCommandData().Options[0]
+	opts := optionMap(sub.Options)
+	user := interactionUser(i)
+	channelID := parseSnowflake(i.ChannelID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	switch sub.Name {
+	case "setup":
+		if !isGuildAdmin(i) {
+			respondEphemeral(s, i, tr(i, "admin_only.standups"))
+			return
+		}
+		var members []string
+		for _, match := range userMentionPattern.FindAllStringSubmatch(opts["members"].StringValue(), -1) {
+			members = append(members, match[1])
+		}
+		name := ""
+		if opt, ok := opts["name"]; ok {
+			name = strings.TrimSpace(opt.StringValue())
+		}
+		if name == "" {
+			if channel, err := s.State.Channel(i.ChannelID); err == nil {
+				name = channel.Name
+			} else {
+				name = "standup"
+			}
+		}
+		team, err := b.standupService.ConfigureTeam(ctx, parseSnowflake(i.GuildID), channelID, name, members)
+		if err != nil {
+			respondEphemeral(s, i, fmt.Sprintf("🔧 Could not save the team: %v", err))
+			return
+		}
+		respondText(s, i, fmt.Sprintf("✅ Standup team **%s** configured with %d member(s).", team.Name, len(team.MemberIDs)))
+
+	case "start":
+		hours := int64(4)
+		if opt, ok := opts["hours"]; ok {
+			hours = opt.IntValue()
+		}
+		session, err := b.standupService.Start(ctx, channelID, parseSnowflake(user.ID), time.Duration(hours)*time.Hour)
+		if err != nil {
+			b.respondStandupError(s, i, err)
+			return
+		}
+		respondText(s, i, fmt.Sprintf("📋 **Standup started for %s!** %s\nPost your update with `/standup update` before <t:%d:t>. I'll post the summary when time is up.",
+			session.Team.Name, joinMentions(session.Team.MemberIDs), session.EndsAt.Unix()))
+
+	case "update":
+		err := b.standupService.SubmitUpdate(ctx, channelID, user.ID, user.Username, opts["text"].StringValue())
+		if err != nil {
+			b.respondStandupError(s, i, err)
+			return
+		}
+		respondEphemeral(s, i, "✅ Update recorded. You can run `/standup update` again to replace it.")
+
+	case "status":
+		status, err := b.standupService.GetStatus(ctx, channelID)
+		if err != nil {
+			b.respondStandupError(s, i, err)
+			return
+		}
+		respondEphemeral(s, i, formatStandupStatus(status))
+
+	case "summary":
+		// Summaries call the AI, so defer to stay within the interaction deadline
+		if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Flags: discordgo.MessageFlagsEphemeral},
+		}); err != nil {
+			log.Printf("❌ Failed to defer interaction: %v", err)
+			return
+		}
+
+		aiCtx, aiCancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer aiCancel()
+
+		content := "✅ Standup summary posted."
+		if _, err := b.standupService.Summarize(aiCtx, channelID); err != nil {
+			log.Printf("❌ Failed to summarize standup: %v", err)
+			content = "🔧 " + standupErrorMessage(err)
+		}
+		s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &content})
+	}
+}
+
+func (b *Bot) respondStandupError(s *discordgo.Session, i *discordgo.InteractionCreate, err error) {
+	log.Printf("❌ Standup command failed: %v", err)
+	respondEphemeral(s, i, "🔧 "+standupErrorMessage(err))
+}
+
+func standupErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, standup.ErrNoTeam):
+		return "No standup team is configured here. Ask an admin to run `/standup setup`."
+	case errors.Is(err, standup.ErrSessionOpen),
+		errors.Is(err, standup.ErrNoOpenSession),
+		errors.Is(err, standup.ErrNotMember):
+		return strings.ToUpper(err.Error()[:1]) + err.Error()[1:] + "."
+	default:
+		return "Something went wrong with the standup. Please try again."
+	}
+}
+
+func formatStandupStatus(status *standup.Status) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("📋 **%s standup** — closes <t:%d:R>\n", status.Session.Team.Name, status.Session.EndsAt.Unix()))
+	sb.WriteString(fmt.Sprintf("✅ Posted (%d): %s\n", len(status.Responded), joinMentions(status.Responded)))
+	sb.WriteString(fmt.Sprintf("⏳ Missing (%d): %s", len(status.Missing), joinMentions(status.Missing)))
+	return sb.String()
+}
+
+func joinMentions(ids []string) string {
+	if len(ids) == 0 {
+		return "—"
+	}
+	mentions := make([]string, 0, len(ids))
+	for _, id := range ids {
+		mentions = append(mentions, "<@"+id+">")
+	}
+	return strings.Join(mentions, " ")
+}
+
+// SetStandupService enables the /standup command
+func (b *Bot) SetStandupService(standupService *standup.Service) {
+	b.standupService = standupService
+}
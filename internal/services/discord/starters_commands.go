@@ -0,0 +1,275 @@
+package discord
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/services/starters"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func startersCommand() *discordgo.ApplicationCommand {
+	minHour := 0.0
+	channelOption := func(description string) *discordgo.ApplicationCommandOption {
+		return &discordgo.ApplicationCommandOption{
+			Type:         discordgo.ApplicationCommandOptionChannel,
+			Name:         "channel",
+			Description:  description,
+			Required:     true,
+			ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText},
+		}
+	}
+	return &discordgo.ApplicationCommand{
+		Name:        "starters",
+		Description: "Post AI-written conversation starters on a schedule (admins only)",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "add",
+				Description: "Schedule starters in a channel, or change its schedule",
+				Options: []*discordgo.ApplicationCommandOption{
+					channelOption("Channel to post in"),
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "kind",
+						Description: "What to post (default: question of the day)",
+						Choices: []*discordgo.ApplicationCommandOptionChoice{
+							{Name: "Question of the day", Value: models.StarterKindQuestion},
+							{Name: "Conversation starter", Value: models.StarterKindStarter},
+						},
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "topics",
+						Description: "Topic hints, e.g. games, music, food (default: anything)",
+						MaxLength:   starters.MaxTopicsLength,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "frequency",
+						Description: "How often to post (default daily)",
+						Choices: []*discordgo.ApplicationCommandOptionChoice{
+							{Name: "Every day", Value: models.StarterDaily},
+							{Name: "Weekdays", Value: models.StarterWeekdays},
+							{Name: "Once a week", Value: models.StarterWeekly},
+						},
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "weekday",
+						Description: "Day of weekly posts (default Monday)",
+						Choices:     weekdayChoices(),
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "hour",
+						Description: "Local hour to post at (0-23, default 10)",
+						MinValue:    &minHour,
+						MaxValue:    23,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "timezone",
+						Description: "IANA timezone, e.g. Europe/Paris (default: the server's timezone)",
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "remove",
+				Description: "Stop posting starters in a channel",
+				Options:     []*discordgo.ApplicationCommandOption{channelOption("Channel to stop posting in")},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "list",
+				Description: "List the channels with scheduled starters",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "now",
+				Description: "Post a starter in a scheduled channel right away",
+				Options:     []*discordgo.ApplicationCommandOption{channelOption("Scheduled channel to post in")},
+			},
+		},
+	}
+}
+
+func (b *Bot) handleStartersCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if b.starterService == nil {
+		respondEphemeral(s, i, "🔧 Conversation starters are not enabled on this instance.")
+		return
+	}
+	if i.GuildID == "" {
+		respondEphemeral(s, i, "🔒 This command only works in a server.")
+		return
+	}
+	if !isGuildAdmin(i) {
+		respondEphemeral(s, i, tr(i, "admin_only.starters"))
+		return
+	}
+
+	guildID := parseSnowflake(i.GuildID)
+	sub := i.ApplicationCommandData().Options[0]
+	opts := optionMap(sub.Options)
+
+	switch sub.Name {
+	case "add":
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		b.handleStartersAdd(ctx, s, i, opts, guildID)
+	case "remove":
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		channelID := parseSnowflake(opts["channel"].ChannelValue(s).ID)
+		removed, err := b.starterService.Remove(ctx, guildID, channelID)
+		switch {
+		case err != nil:
+			log.Printf("❌ Failed to remove starter schedule: %v", err)
+			respondEphemeral(s, i, tr(i, "starters.save_failed"))
+		case !removed:
+			respondEphemeral(s, i, tr(i, "starters.not_scheduled", channelID))
+		default:
+			respondEphemeral(s, i, tr(i, "starters.removed", channelID))
+		}
+	case "list":
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		schedules, err := b.starterService.List(ctx, guildID)
+		if err != nil {
+			log.Printf("❌ Failed to list starter schedules: %v", err)
+			respondEphemeral(s, i, tr(i, "starters.load_failed"))
+			return
+		}
+		if len(schedules) == 0 {
+			respondEphemeral(s, i, tr(i, "starters.none"))
+			return
+		}
+		var sb strings.Builder
+		sb.WriteString(tr(i, "starters.list_title") + "\n")
+		for n := range schedules {
+			sb.WriteString("• " + describeStarters(i, &schedules[n]) + "\n")
+		}
+		respondEphemeral(s, i, sb.String())
+	case "now":
+		b.handleStartersNow(s, i, parseSnowflake(opts["channel"].ChannelValue(s).ID))
+	}
+}
+
+func (b *Bot) handleStartersAdd(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, opts map[string]*discordgo.ApplicationCommandInteractionDataOption, guildID int64) {
+	channelID := parseSnowflake(opts["channel"].ChannelValue(s).ID)
+	schedule := &models.StarterSchedule{
+		GuildID:   guildID,
+		ChannelID: channelID,
+		Kind:      models.StarterKindQuestion,
+		Frequency: models.StarterDaily,
+		Weekday:   int(time.Monday),
+		Hour:      10,
+		Timezone:  b.serverTimezone(ctx, i),
+	}
+	// Settings left out keep their current value
+	previous, err := b.starterService.Schedule(ctx, channelID)
+	if err != nil {
+		log.Printf("❌ Failed to load starter schedule: %v", err)
+		respondEphemeral(s, i, tr(i, "starters.load_failed"))
+		return
+	}
+	if previous != nil {
+		*schedule = *previous
+	}
+	schedule.CreatedBy = parseSnowflake(interactionUser(i).ID)
+	if opt, ok := opts["kind"]; ok {
+		schedule.Kind = opt.StringValue()
+	}
+	if opt, ok := opts["topics"]; ok {
+		schedule.Topics = opt.StringValue()
+	}
+	if opt, ok := opts["frequency"]; ok {
+		schedule.Frequency = opt.StringValue()
+	}
+	if opt, ok := opts["weekday"]; ok {
+		schedule.Weekday = int(opt.IntValue())
+	}
+	if opt, ok := opts["hour"]; ok {
+		schedule.Hour = int(opt.IntValue())
+	}
+	if opt, ok := opts["timezone"]; ok {
+		schedule.Timezone = strings.TrimSpace(opt.StringValue())
+	}
+
+	if err := b.starterService.Configure(ctx, schedule); err != nil {
+		log.Printf("❌ Failed to save starter schedule: %v", err)
+		respondEphemeral(s, i, tr(i, "starters.invalid", err))
+		return
+	}
+	respondEphemeral(s, i, "✅ "+describeStarters(i, schedule)+"\n"+tr(i, "starters.added_hint"))
+}
+
+// handleStartersNow posts a starter in a scheduled channel without waiting
+// for its next slot
+func (b *Bot) handleStartersNow(s *discordgo.Session, i *discordgo.InteractionCreate, channelID int64) {
+	if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Flags: discordgo.MessageFlagsEphemeral},
+	}); err != nil {
+		log.Printf("❌ Failed to defer interaction: %v", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	var content string
+	schedule, err := b.starterService.Schedule(ctx, channelID)
+	switch {
+	case err != nil:
+		log.Printf("❌ Failed to load starter schedule: %v", err)
+		content = tr(i, "starters.load_failed")
+	case schedule == nil || schedule.GuildID != parseSnowflake(i.GuildID):
+		content = tr(i, "starters.not_scheduled", channelID)
+	default:
+		_, err = b.starterService.Post(ctx, schedule)
+		switch {
+		case errors.Is(err, starters.ErrNoFreshStarter):
+			content = tr(i, "starters.no_fresh")
+		case err != nil:
+			log.Printf("❌ Failed to post starter in channel %d: %v", channelID, err)
+			content = aiErrorMessage(err, tr(i, "starters.failed"))
+		default:
+			content = tr(i, "starters.posted", channelID)
+		}
+	}
+	if _, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &content}); err != nil {
+		log.Printf("❌ Failed to edit interaction response: %v", err)
+	}
+}
+
+func describeStarters(i *discordgo.InteractionCreate, schedule *models.StarterSchedule) string {
+	when := tr(i, "starters.daily", schedule.Hour, schedule.Timezone)
+	switch schedule.Frequency {
+	case models.StarterWeekdays:
+		when = tr(i, "starters.weekdays", schedule.Hour, schedule.Timezone)
+	case models.StarterWeekly:
+		when = tr(i, "starters.weekly", time.Weekday(schedule.Weekday), schedule.Hour, schedule.Timezone)
+	}
+	kind := tr(i, "starters.kind_question")
+	if schedule.Kind == models.StarterKindStarter {
+		kind = tr(i, "starters.kind_starter")
+	}
+	text := fmt.Sprintf("<#%d>: %s %s", schedule.ChannelID, kind, when)
+	if schedule.Topics != "" {
+		text += " " + tr(i, "starters.topics", schedule.Topics)
+	}
+	return text
+}
+
+// SetStarterService enables /starters
+func (b *Bot) SetStarterService(starterService *starters.Service) {
+	b.starterService = starterService
+}
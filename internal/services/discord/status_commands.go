@@ -0,0 +1,29 @@
+package discord
+
+import (
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func statusCommand() *discordgo.ApplicationCommand {
+	return &discordgo.ApplicationCommand{
+		Name:        "status",
+		Description: "Show T.A.R.S connection health: uptime, latency and reconnects",
+	}
+}
+
+func (b *Bot) handleStatusCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	stats := b.GatewayStats()
+
+	state := tr(i, "status.connected")
+	if !stats.Connected {
+		state = tr(i, "status.reconnecting")
+	}
+	respondEphemeral(s, i, tr(i, "status.text",
+		state, stats.Since.Unix(),
+		stats.Latency.Round(time.Millisecond),
+		stats.Reconnects, stats.Resumes, stats.Disconnects,
+		stats.Guilds, stats.Voice,
+	))
+}
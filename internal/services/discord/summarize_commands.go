@@ -0,0 +1,275 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"time"
+
+	"discord-tars/internal/services/summarize"
+	"discord-tars/internal/tenant"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	maxThreadMessages     = 500
+	defaultAroundMessages = 20
+	maxAroundMessages     = 100 // One Discord API page in each direction
+)
+
+// messageLinkPattern matches links like https://discord.com/channels/<guild>/<channel>/<message>
+var messageLinkPattern = regexp.MustCompile(`https?://(?:(?:ptb|canary)\.)?discord(?:app)?\.com/channels/(\d+)/(\d+)/(\d+)`)
+
+func summarizeCommand() *discordgo.ApplicationCommand {
+	minCount := 0.0
+	return &discordgo.ApplicationCommand{
+		Name:        "summarize",
+		Description: "Summarize a thread or the conversation around a message",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "thread",
+				Description: "Summarize a whole thread",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionChannel,
+						Name:        "thread",
+						Description: "Thread or forum post (default: this thread)",
+						ChannelTypes: []discordgo.ChannelType{
+							discordgo.ChannelTypeGuildPublicThread,
+							discordgo.ChannelTypeGuildPrivateThread,
+							discordgo.ChannelTypeGuildNewsThread,
+						},
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "link",
+				Description: "Summarize the conversation around a linked message",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "message",
+						Description: "Message link (right-click a message → Copy Message Link)",
+						Required:    true,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "before",
+						Description: fmt.Sprintf("Messages before it (default %d)", defaultAroundMessages),
+						MinValue:    &minCount,
+						MaxValue:    maxAroundMessages,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "after",
+						Description: fmt.Sprintf("Messages after it (default %d)", defaultAroundMessages),
+						MinValue:    &minCount,
+						MaxValue:    maxAroundMessages,
+					},
+				},
+			},
+		},
+	}
+}
+
+func (b *Bot) handleSummarizeCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if b.summarizeService == nil {
+		respondEphemeral(s, i, "🔧 Summaries are not enabled on this instance.")
+		return
+	}
+
+	sub := i.ApplicationCommandData().Options[0]
+	opts := optionMap(sub.Options)
+	user := interactionUser(i)
+
+	var channelID string
+	var fetch func() ([]*discordgo.Message, error)
+	switch sub.Name {
+	case "thread":
+		channelID = i.ChannelID
+		if opt, ok := opts["thread"]; ok {
+			channelID = opt.ChannelValue(nil).ID
+		}
+		thread, err := s.State.Channel(channelID)
+		if err != nil {
+			thread, err = s.Channel(channelID)
+		}
+		if err != nil || !thread.IsThread() {
+			respondEphemeral(s, i, "🧵 Run this inside a thread, or pick one with the `thread` option.")
+			return
+		}
+		fetch = func() ([]*discordgo.Message, error) { return fetchThread(s, thread) }
+
+	case "link":
+		match := messageLinkPattern.FindStringSubmatch(opts["message"].StringValue())
+		if match == nil {
+			respondEphemeral(s, i, "🔗 That doesn't look like a message link. Right-click a message and choose **Copy Message Link**.")
+			return
+		}
+		if match[1] != i.GuildID {
+			respondEphemeral(s, i, "🔒 I can only summarize messages from this server.")
+			return
+		}
+		before, after := defaultAroundMessages, defaultAroundMessages
+		if opt, ok := opts["before"]; ok {
+			before = int(opt.IntValue())
+		}
+		if opt, ok := opts["after"]; ok {
+			after = int(opt.IntValue())
+		}
+		channelID = match[2]
+		messageID := match[3]
+		fetch = func() ([]*discordgo.Message, error) { return fetchAround(s, channelID, messageID, before, after) }
+	}
+
+	// The summary must not reveal a channel the user can't read
+	if !userCanRead(s, user.ID, channelID) {
+		respondEphemeral(s, i, "🔒 You don't have access to that channel.")
+		return
+	}
+
+	// Summaries of other channels go only to the requester
+	var flags discordgo.MessageFlags
+	if channelID != i.ChannelID {
+		flags = discordgo.MessageFlagsEphemeral
+	}
+	if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Flags: flags},
+	}); err != nil {
+		log.Printf("❌ Failed to defer interaction: %v", err)
+		return
+	}
+
+	content := b.summarizeFetched(tenant.WithGuild(context.Background(), parseSnowflake(i.GuildID)), channelID, fetch)
+	if _, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &content}); err != nil {
+		log.Printf("❌ Failed to edit interaction response: %v", err)
+	}
+}
+
+func (b *Bot) summarizeFetched(ctx context.Context, channelID string, fetch func() ([]*discordgo.Message, error)) string {
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	messages, err := fetch()
+	if err != nil {
+		log.Printf("❌ Failed to fetch messages to summarize: %v", err)
+		return "🔧 I couldn't read those messages. Check that I have access to the channel."
+	}
+	results := summarize.FromDiscord(messages)
+	if len(results) == 0 {
+		return "🧾 There's nothing to summarize there."
+	}
+
+	summary, err := b.summarizeService.SummarizeMessages(ctx, results)
+	if err != nil {
+		log.Printf("❌ Failed to summarize: %v", err)
+		return aiErrorMessage(err, "🔧 My summarization circuits failed. Please try again later.")
+	}
+
+	header := fmt.Sprintf("🧾 **Summary of <#%s>** (%d messages)\n\n", channelID, len(results))
+	return truncateText(header+summary, 2000)
+}
+
+// fetchThread loads a thread's messages, oldest last, plus the message it was started from
+func fetchThread(s *discordgo.Session, thread *discordgo.Channel) ([]*discordgo.Message, error) {
+	var messages []*discordgo.Message
+	before := ""
+	for len(messages) < maxThreadMessages {
+		page, err := s.ChannelMessages(thread.ID, 100, before, "", "")
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, page...)
+		if len(page) < 100 {
+			break
+		}
+		before = page[len(page)-1].ID
+	}
+
+	// Threads started from a message share its ID; forum posts hold it themselves
+	if thread.ParentID != "" {
+		if starter, err := s.ChannelMessage(thread.ParentID, thread.ID); err == nil {
+			messages = append(messages, starter)
+		}
+	}
+	return messages, nil
+}
+
+// fetchAround loads a message with up to before/after messages on each side
+func fetchAround(s *discordgo.Session, channelID, messageID string, before, after int) ([]*discordgo.Message, error) {
+	target, err := s.ChannelMessage(channelID, messageID)
+	if err != nil {
+		return nil, err
+	}
+	messages := []*discordgo.Message{target}
+
+	if before > 0 {
+		page, err := s.ChannelMessages(channelID, before, messageID, "", "")
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, page...)
+	}
+	if after > 0 {
+		page, err := s.ChannelMessages(channelID, after, "", messageID, "")
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, page...)
+	}
+	return messages, nil
+}
+
+// userCanRead reports whether a member may read a channel's history; threads
+// follow their parent's permissions, and private threads also need membership
+func userCanRead(s *discordgo.Session, userID, channelID string) bool {
+	channel, err := s.State.Channel(channelID)
+	if err != nil {
+		if channel, err = s.Channel(channelID); err != nil {
+			return false
+		}
+	}
+
+	permChannel := channelID
+	if channel.IsThread() {
+		permChannel = channel.ParentID
+		if channel.Type == discordgo.ChannelTypeGuildPrivateThread {
+			if _, err := s.ThreadMember(channelID, userID, false); err != nil {
+				return false
+			}
+		}
+	}
+
+	perms, err := s.UserChannelPermissions(userID, permChannel)
+	if err != nil {
+		log.Printf("⚠️ Failed to check permissions of %s in %s: %v", userID, permChannel, err)
+		return false
+	}
+	required := int64(discordgo.PermissionViewChannel | discordgo.PermissionReadMessageHistory)
+	return perms&required == required
+}
+
+// channelReader checks with userCanRead which channels a member may read,
+// once per channel
+func channelReader(s *discordgo.Session, userID string) func(channelID int64) bool {
+	readable := make(map[int64]bool)
+	return func(channelID int64) bool {
+		ok, checked := readable[channelID]
+		if !checked {
+			ok = userCanRead(s, userID, strconv.FormatInt(channelID, 10))
+			readable[channelID] = ok
+		}
+		return ok
+	}
+}
+
+// SetSummarizeService enables /summarize
+func (b *Bot) SetSummarizeService(summarizeService *summarize.Service) {
+	b.summarizeService = summarizeService
+}
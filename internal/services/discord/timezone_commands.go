@@ -0,0 +1,185 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"discord-tars/internal/services/timezone"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func timezoneCommand() *discordgo.ApplicationCommand {
+	zone := func(required bool, description string) []*discordgo.ApplicationCommandOption {
+		return []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "zone",
+				Description: description,
+				Required:    required,
+			},
+		}
+	}
+	return &discordgo.ApplicationCommand{
+		Name:        "timezone",
+		Description: "Set the timezone the times you give me and schedules you set are in",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "show",
+				Description: "Show which timezone your times are read in",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "set",
+				Description: "Set your timezone, in every server",
+				Options:     zone(true, "Timezone name, abbreviation or offset, e.g. Europe/Paris, PST or UTC+2"),
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "clear",
+				Description: "Forget your timezone and use the server's",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "server",
+				Description: "Set the server's timezone, for members without their own (admins only)",
+				Options:     zone(false, "Timezone name, abbreviation or offset; leave out to clear it"),
+			},
+		},
+	}
+}
+
+func (b *Bot) handleTimezoneCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if b.timezoneService == nil {
+		respondEphemeral(s, i, "🔧 Timezones are not enabled on this instance.")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	userID := parseSnowflake(interactionUser(i).ID)
+	sub := i.ApplicationCommandData().Options[0]
+	opts := optionMap(sub.Options)
+
+	switch sub.Name {
+	case "show":
+		respondEphemeral(s, i, formatZone(b.timezoneService.For(ctx, parseSnowflake(i.GuildID), userID)))
+
+	case "set":
+		loc, err := timezone.Resolve(opts["zone"].StringValue())
+		if err != nil {
+			respondEphemeral(s, i, fmt.Sprintf("❓ %v.", err))
+			return
+		}
+		if err := b.timezoneService.SetUser(ctx, userID, loc); err != nil {
+			log.Printf("❌ Failed to set user timezone: %v", err)
+			respondEphemeral(s, i, "🔧 Failed to save your timezone. Please try again.")
+			return
+		}
+		respondEphemeral(s, i, fmt.Sprintf("✅ Your timezone is now **%s** (it's %s there).", loc, time.Now().In(loc).Format("Mon 15:04")))
+
+	case "clear":
+		cleared, err := b.timezoneService.ClearUser(ctx, userID)
+		if err != nil {
+			log.Printf("❌ Failed to clear user timezone: %v", err)
+			respondEphemeral(s, i, "🔧 Failed to clear your timezone. Please try again.")
+			return
+		}
+		if !cleared {
+			respondEphemeral(s, i, "ℹ️ You had no timezone set.")
+			return
+		}
+		respondEphemeral(s, i, "✅ Your timezone is cleared. "+formatZone(b.timezoneService.For(ctx, parseSnowflake(i.GuildID), userID)))
+
+	case "server":
+		if i.GuildID == "" {
+			respondEphemeral(s, i, "🔒 This command only works in a server.")
+			return
+		}
+		if !isGuildAdmin(i) {
+			respondEphemeral(s, i, tr(i, "admin_only.timezone"))
+			return
+		}
+		guildID := parseSnowflake(i.GuildID)
+		opt, ok := opts["zone"]
+		if !ok {
+			if _, err := b.timezoneService.ClearGuild(ctx, guildID); err != nil {
+				log.Printf("❌ Failed to clear guild timezone: %v", err)
+				respondEphemeral(s, i, "🔧 Failed to clear the server's timezone. Please try again.")
+				return
+			}
+			respondEphemeral(s, i, "✅ The server has no timezone anymore; members without their own use UTC.")
+			return
+		}
+		loc, err := timezone.Resolve(opt.StringValue())
+		if err != nil {
+			respondEphemeral(s, i, fmt.Sprintf("❓ %v.", err))
+			return
+		}
+		if err := b.timezoneService.SetGuild(ctx, guildID, loc, userID); err != nil {
+			log.Printf("❌ Failed to set guild timezone: %v", err)
+			respondEphemeral(s, i, "🔧 Failed to save the server's timezone. Please try again.")
+			return
+		}
+		log.Printf("🕐 Guild %s timezone set to %s", i.GuildID, loc)
+		respondEphemeral(s, i, fmt.Sprintf("✅ The server's timezone is now **%s**, for members who haven't set their own.", loc))
+	}
+}
+
+func formatZone(zone timezone.Zone) string {
+	now := time.Now().In(zone.Location).Format("Mon 15:04")
+	switch zone.Source {
+	case timezone.SourceUser:
+		return fmt.Sprintf("🕐 Your times are read in **%s**, your timezone (it's %s there).", zone.Name(), now)
+	case timezone.SourceGuild:
+		return fmt.Sprintf("🕐 Your times are read in **%s**, the server's timezone (it's %s there). Set your own with `/timezone set`.", zone.Name(), now)
+	}
+	return "🕐 Your times are read in **UTC**. Set your timezone with `/timezone set`."
+}
+
+// zoneFor is the timezone the member behind an interaction reads and writes
+// times in
+func (b *Bot) zoneFor(ctx context.Context, i *discordgo.InteractionCreate) timezone.Zone {
+	if b.timezoneService == nil {
+		return timezone.Zone{Location: time.UTC, Source: timezone.SourceDefault}
+	}
+	return b.timezoneService.For(ctx, parseSnowflake(i.GuildID), parseSnowflake(interactionUser(i).ID))
+}
+
+// memberTimezone is the timezone a member's own schedules, such as digests,
+// default to. Schedules store IANA names, so UTC offsets fall back to UTC.
+func (b *Bot) memberTimezone(ctx context.Context, i *discordgo.InteractionCreate) string {
+	return scheduleTimezone(b.zoneFor(ctx, i).Location)
+}
+
+// serverTimezone is the timezone a server's schedules, such as question
+// starters, default to
+func (b *Bot) serverTimezone(ctx context.Context, i *discordgo.InteractionCreate) string {
+	if b.timezoneService == nil || i.GuildID == "" {
+		return "UTC"
+	}
+	loc, err := b.timezoneService.Guild(ctx, parseSnowflake(i.GuildID))
+	if err != nil {
+		log.Printf("⚠️ %v", err)
+	}
+	return scheduleTimezone(loc)
+}
+
+func scheduleTimezone(loc *time.Location) string {
+	if loc == nil {
+		return "UTC"
+	}
+	if _, err := time.LoadLocation(loc.String()); err != nil {
+		return "UTC"
+	}
+	return loc.String()
+}
+
+// SetTimezoneService enables /timezone, and makes times and schedules
+// default to members' and servers' timezones
+func (b *Bot) SetTimezoneService(timezoneService *timezone.Service) {
+	b.timezoneService = timezoneService
+}
@@ -0,0 +1,212 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/services/mood"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func toxicityCommand() *discordgo.ApplicationCommand {
+	minHour := 0.0
+	minThreshold := 0.1
+	return &discordgo.ApplicationCommand{
+		Name:        "toxicity",
+		Description: "Warn moderators when an argument escalates (admins only)",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "setup",
+				Description: "Turn on early warnings, or change their settings",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:         discordgo.ApplicationCommandOptionChannel,
+						Name:         "channel",
+						Description:  "Channel where warnings are posted",
+						Required:     true,
+						ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText},
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionRole,
+						Name:        "role",
+						Description: "Moderator role pinged with warnings",
+						Required:    true,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionNumber,
+						Name:        "threshold",
+						Description: "Rolling toxicity that triggers a warning, from 0.1 to 1 (default 0.6)",
+						MinValue:    &minThreshold,
+						MaxValue:    1,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "quiet_start",
+						Description: "Local hour (0-23) from which warnings are posted without a ping",
+						MinValue:    &minHour,
+						MaxValue:    23,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "quiet_end",
+						Description: "Local hour (0-23) when pings resume",
+						MinValue:    &minHour,
+						MaxValue:    23,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "timezone",
+						Description: "IANA timezone of the quiet hours, e.g. Europe/Paris (default: the server's timezone)",
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "off",
+				Description: "Stop the early warnings",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "status",
+				Description: "Show the settings and the channels heating up",
+			},
+		},
+	}
+}
+
+func (b *Bot) handleToxicityCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if b.toxicityWatcher == nil {
+		respondEphemeral(s, i, "🔧 Toxicity warnings are not enabled on this instance.")
+		return
+	}
+	if i.GuildID == "" {
+		respondEphemeral(s, i, "🔒 This command only works in a server.")
+		return
+	}
+	if !isGuildAdmin(i) {
+		respondEphemeral(s, i, tr(i, "admin_only.toxicity"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	guildID := parseSnowflake(i.GuildID)
+	sub := i.ApplicationCommandData().Options[0]
+
+	cfg, err := b.toxicityWatcher.Config(ctx, guildID)
+	if err != nil {
+		log.Printf("❌ Failed to load toxicity config: %v", err)
+		respondEphemeral(s, i, "🔧 Failed to load the settings. Please try again.")
+		return
+	}
+
+	switch sub.Name {
+	case "setup":
+		b.handleToxicitySetup(ctx, s, i, sub.Options, guildID, cfg)
+	case "off":
+		if cfg == nil || !cfg.Enabled {
+			respondEphemeral(s, i, "ℹ️ Toxicity warnings are already off.")
+			return
+		}
+		cfg.Enabled = false
+		if err := b.toxicityWatcher.Configure(ctx, cfg); err != nil {
+			log.Printf("❌ Failed to save toxicity config: %v", err)
+			respondEphemeral(s, i, "🔧 Failed to save the settings. Please try again.")
+			return
+		}
+		respondEphemeral(s, i, "✅ Toxicity warnings are off. Run `/toxicity setup` to turn them back on.")
+	case "status":
+		respondEphemeral(s, i, b.toxicityStatus(s, i, cfg))
+	}
+}
+
+func (b *Bot) handleToxicitySetup(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, options []*discordgo.ApplicationCommandInteractionDataOption, guildID int64, previous *models.ToxicityConfig) {
+	opts := optionMap(options)
+	channel := opts["channel"].ChannelValue(s)
+	role := opts["role"].RoleValue(s, i.GuildID)
+
+	cfg := &models.ToxicityConfig{
+		GuildID:        guildID,
+		Enabled:        true,
+		AlertChannelID: parseSnowflake(channel.ID),
+		ModRoleID:      parseSnowflake(role.ID),
+		Threshold:      mood.DefaultThreshold,
+		Timezone:       b.serverTimezone(ctx, i),
+	}
+	// Settings left out keep their current value
+	if previous != nil {
+		cfg.Threshold = previous.Threshold
+		cfg.QuietStart, cfg.QuietEnd = previous.QuietStart, previous.QuietEnd
+		cfg.Timezone = previous.Timezone
+		cfg.CreatedAt = previous.CreatedAt
+	}
+	if opt, ok := opts["threshold"]; ok {
+		cfg.Threshold = opt.FloatValue()
+	}
+	if opt, ok := opts["quiet_start"]; ok {
+		cfg.QuietStart = int(opt.IntValue())
+	}
+	if opt, ok := opts["quiet_end"]; ok {
+		cfg.QuietEnd = int(opt.IntValue())
+	}
+	if opt, ok := opts["timezone"]; ok {
+		cfg.Timezone = strings.TrimSpace(opt.StringValue())
+	}
+
+	if err := b.toxicityWatcher.Configure(ctx, cfg); err != nil {
+		log.Printf("❌ Failed to save toxicity config: %v", err)
+		respondEphemeral(s, i, fmt.Sprintf("🔧 Could not save the settings: %v", err))
+		return
+	}
+	respondEphemeral(s, i, fmt.Sprintf("✅ I'll warn <@&%s> in <#%s> when an argument heats up past %.0f%% toxicity%s.\nMake sure I can send messages there.",
+		role.ID, channel.ID, cfg.Threshold*100, describeQuietHours(cfg)))
+}
+
+// toxicityStatus shows a guild's settings and the rolling toxicity of the
+// channels the requester can read
+func (b *Bot) toxicityStatus(s *discordgo.Session, i *discordgo.InteractionCreate, cfg *models.ToxicityConfig) string {
+	if cfg == nil || !cfg.Enabled {
+		return "🚨 Toxicity warnings are off. Run `/toxicity setup` to warn moderators when an argument escalates."
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "🚨 **Toxicity warnings are on**\nWarnings go to <#%d> for <@&%d> past %.0f%% toxicity%s.\n",
+		cfg.AlertChannelID, cfg.ModRoleID, cfg.Threshold*100, describeQuietHours(cfg))
+
+	guild, err := s.State.Guild(i.GuildID)
+	if err != nil {
+		return sb.String()
+	}
+	userID := interactionUser(i).ID
+	var heated []string
+	for _, channel := range guild.Channels {
+		level := b.toxicityWatcher.Level(channel.ID)
+		if level >= 0.05 && userCanRead(s, userID, channel.ID) {
+			heated = append(heated, fmt.Sprintf("• <#%s> %.0f%%", channel.ID, level*100))
+		}
+	}
+	if len(heated) == 0 {
+		sb.WriteString("\nAll channels are calm right now.")
+	} else {
+		sb.WriteString("\n**Current toxicity**\n" + strings.Join(heated, "\n"))
+	}
+	return truncateText(sb.String(), 2000)
+}
+
+func describeQuietHours(cfg *models.ToxicityConfig) string {
+	if cfg.QuietStart == cfg.QuietEnd {
+		return ""
+	}
+	return fmt.Sprintf(", without pings from %02d:00 to %02d:00 (%s)", cfg.QuietStart, cfg.QuietEnd, cfg.Timezone)
+}
+
+// SetToxicityWatcher enables /toxicity and the early warnings
+func (b *Bot) SetToxicityWatcher(toxicityWatcher *mood.Watcher) {
+	b.toxicityWatcher = toxicityWatcher
+}
@@ -0,0 +1,245 @@
+package discord
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/services/tracker"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func ticketCommand() *discordgo.ApplicationCommand {
+	return &discordgo.ApplicationCommand{
+		Name:        "ticket",
+		Description: "Look up an issue tracker ticket",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "key",
+				Description: "Ticket key, e.g. PROJ-123",
+				Required:    true,
+			},
+		},
+	}
+}
+
+func trackerCommand() *discordgo.ApplicationCommand {
+	return &discordgo.ApplicationCommand{
+		Name:        "tracker",
+		Description: "Connect Jira or Linear for ticket lookups (admins only)",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "setup",
+				Description: "Connect an issue tracker",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "provider",
+						Description: "Issue tracker",
+						Required:    true,
+						Choices: []*discordgo.ApplicationCommandOptionChoice{
+							{Name: "Jira", Value: models.TrackerJira},
+							{Name: "Linear", Value: models.TrackerLinear},
+						},
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "token",
+						Description: "API token (Jira) or API key (Linear)",
+						Required:    true,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "site",
+						Description: "Jira site URL, e.g. https://acme.atlassian.net",
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "email",
+						Description: "Jira account email (omit for a Jira Server personal access token)",
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "projects",
+						Description: "Comma-separated project keys to allow (default: all)",
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "status",
+				Description: "Show the connected tracker",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "disconnect",
+				Description: "Remove the tracker connection and its credentials",
+			},
+		},
+	}
+}
+
+func (b *Bot) handleTicketCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if b.trackerService == nil {
+		respondEphemeral(s, i, "🔧 Ticket lookups are not enabled on this instance.")
+		return
+	}
+
+	key := optionMap(i.ApplicationCommandData().Options)["key"].StringValue()
+
+	if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	}); err != nil {
+		log.Printf("❌ Failed to defer interaction: %v", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	ticket, err := b.trackerService.Lookup(ctx, parseSnowflake(i.GuildID), key)
+	if err != nil {
+		var content string
+		switch {
+		case errors.Is(err, tracker.ErrNotConfigured):
+			content = "🔧 No issue tracker is connected. A server manager can run `/tracker setup`."
+		case errors.Is(err, tracker.ErrTicketNotFound):
+			content = fmt.Sprintf("🔍 I couldn't find **%s**.", strings.ToUpper(key))
+		case errors.Is(err, tracker.ErrKeyNotAllowed):
+			content = "🔒 That project is not linked to this server."
+		default:
+			log.Printf("❌ Failed to look up ticket %s: %v", key, err)
+			content = "🔧 The issue tracker didn't respond properly. Please try again later."
+		}
+		s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &content})
+		return
+	}
+
+	embeds := []*discordgo.MessageEmbed{ticketEmbed(ticket)}
+	s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Embeds: &embeds})
+}
+
+func (b *Bot) handleTrackerCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if b.trackerService == nil {
+		respondEphemeral(s, i, "🔧 Ticket lookups are not enabled on this instance.")
+		return
+	}
+	if !isGuildAdmin(i) {
+		respondEphemeral(s, i, tr(i, "admin_only.tracker"))
+		return
+	}
+
+	sub := i.ApplicationCommandData().Options[0]
+	opts := optionMap(sub.Options)
+	guildID := parseSnowflake(i.GuildID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	switch sub.Name {
+	case "setup":
+		cfg := &models.TrackerConfig{
+			GuildID:   guildID,
+			Provider:  opts["provider"].StringValue(),
+			APIToken:  opts["token"].StringValue(),
+			CreatedBy: parseSnowflake(interactionUser(i).ID),
+		}
+		if opt, ok := opts["site"]; ok {
+			cfg.BaseURL = opt.StringValue()
+		}
+		if opt, ok := opts["email"]; ok {
+			cfg.Email = opt.StringValue()
+		}
+		if opt, ok := opts["projects"]; ok {
+			for _, key := range strings.Split(opt.StringValue(), ",") {
+				if key = strings.TrimSpace(key); key != "" {
+					cfg.ProjectKeys = append(cfg.ProjectKeys, key)
+				}
+			}
+		}
+
+		if err := b.trackerService.Configure(ctx, cfg); err != nil {
+			log.Printf("❌ Failed to configure tracker: %v", err)
+			respondEphemeral(s, i, fmt.Sprintf("🔧 Could not connect the tracker: %v", err))
+			return
+		}
+		respondEphemeral(s, i, fmt.Sprintf("✅ Connected **%s**. Try `/ticket`, or mention a ticket key when asking me a question.", cfg.Provider))
+
+	case "status":
+		cfg, err := b.trackerService.GetConfig(ctx, guildID)
+		if err != nil {
+			log.Printf("❌ Failed to load tracker config: %v", err)
+			respondEphemeral(s, i, "🔧 Failed to load the tracker configuration.")
+			return
+		}
+		if cfg == nil {
+			respondEphemeral(s, i, "🎫 No issue tracker is connected.")
+			return
+		}
+		status := fmt.Sprintf("🎫 Connected to **%s**", cfg.Provider)
+		if cfg.BaseURL != "" {
+			status += " at " + cfg.BaseURL
+		}
+		if len(cfg.ProjectKeys) > 0 {
+			status += "\nProjects: " + strings.Join(cfg.ProjectKeys, ", ")
+		}
+		respondEphemeral(s, i, status)
+
+	case "disconnect":
+		removed, err := b.trackerService.Disconnect(ctx, guildID)
+		if err != nil {
+			log.Printf("❌ Failed to disconnect tracker: %v", err)
+			respondEphemeral(s, i, "🔧 Failed to disconnect the tracker. Please try again.")
+			return
+		}
+		if !removed {
+			respondEphemeral(s, i, "ℹ️ No issue tracker was connected.")
+			return
+		}
+		respondEphemeral(s, i, "✅ Tracker disconnected and credentials deleted.")
+	}
+}
+
+func ticketEmbed(t *tracker.Ticket) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Title: truncateText(fmt.Sprintf("🎫 %s: %s", t.Key, t.Title), 256),
+		URL:   t.URL,
+		Color: 0x0052cc,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Status", Value: valueOr(t.Status, "Unknown"), Inline: true},
+			{Name: "Assignee", Value: valueOr(t.Assignee, "Unassigned"), Inline: true},
+		},
+	}
+	if t.Priority != "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Priority", Value: t.Priority, Inline: true})
+	}
+	if t.Type != "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Type", Value: truncateText(t.Type, 1024), Inline: true})
+	}
+	if t.Description != "" {
+		embed.Description = truncateText(t.Description, 500)
+	}
+	if !t.Updated.IsZero() {
+		embed.Timestamp = t.Updated.Format(time.RFC3339)
+	}
+	return embed
+}
+
+func valueOr(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+// SetTrackerService enables /ticket, /tracker and ticket lookups in answers
+func (b *Bot) SetTrackerService(trackerService *tracker.Service) {
+	b.trackerService = trackerService
+}
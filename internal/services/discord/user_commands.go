@@ -0,0 +1,79 @@
+package discord
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"time"
+
+	"discord-tars/internal/tenant"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// userActivityCommand is the user context-menu command, shown when right-clicking a member
+const userActivityCommand = "What have they been discussing?"
+
+// userActivityWindow is how far back a member's messages are summarized
+const userActivityWindow = 7 * 24 * time.Hour
+
+func userCommands() []*discordgo.ApplicationCommand {
+	moderators := int64(discordgo.PermissionManageMessages)
+	dmPermission := false
+	return []*discordgo.ApplicationCommand{
+		{
+			Type:                     discordgo.UserApplicationCommand,
+			Name:                     userActivityCommand,
+			DefaultMemberPermissions: &moderators,
+			DMPermission:             &dmPermission,
+		},
+	}
+}
+
+// handleUserCommand summarizes what the right-clicked member has recently
+// discussed, for moderators catching up
+func (b *Bot) handleUserCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	data := i.ApplicationCommandData()
+	if data.Name != userActivityCommand {
+		log.Printf("❌ Unknown user command: %s", data.Name)
+		return
+	}
+	if i.GuildID == "" {
+		respondEphemeral(s, i, tr(i, "user_activity.guild_only"))
+		return
+	}
+	// Server settings can open the command up to anyone, so check again here
+	if !isModerator(i) {
+		respondEphemeral(s, i, tr(i, "user_activity.moderators_only"))
+		return
+	}
+	if b.summarizeService == nil {
+		respondEphemeral(s, i, "🔧 Summaries are not enabled on this instance.")
+		return
+	}
+	if data.Resolved == nil || data.Resolved.Users[data.TargetID] == nil {
+		respondEphemeral(s, i, tr(i, "user_activity.not_found"))
+		return
+	}
+	target := data.Resolved.Users[data.TargetID]
+	requester := interactionUser(i).ID
+
+	b.deferEphemeral(s, i, func(ctx context.Context) string {
+		ctx = tenant.WithGuild(ctx, parseSnowflake(i.GuildID))
+		days := int(userActivityWindow / (24 * time.Hour))
+		canRead := func(channelID int64) bool {
+			return userCanRead(s, requester, strconv.FormatInt(channelID, 10))
+		}
+
+		activity, err := b.summarizeService.SummarizeUser(ctx, parseSnowflake(i.GuildID), parseSnowflake(target.ID),
+			target.Username, time.Now().Add(-userActivityWindow), canRead)
+		if err != nil {
+			log.Printf("❌ Failed to summarize activity of user %s: %v", target.ID, err)
+			return aiErrorMessage(err, tr(i, "user_activity.failed"))
+		}
+		if activity.Messages == 0 {
+			return tr(i, "user_activity.nothing", target.Mention(), days)
+		}
+		return tr(i, "user_activity.summary", target.Mention(), activity.Messages, activity.Channels, days, activity.Summary)
+	})
+}
@@ -0,0 +1,165 @@
+package discord
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"discord-tars/internal/interfaces"
+	"discord-tars/internal/services/persona"
+	"discord-tars/internal/services/voice"
+	"discord-tars/internal/tenant"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// voiceSpeaker is how speakers are named to the AI, since transcripts don't say who spoke
+const voiceSpeaker = "someone in the voice channel"
+
+// voiceListenOptions say what T.A.R.S does with what it hears in a guild
+type voiceListenOptions struct {
+	answer bool // Answer spoken questions aloud
+	// captionChannelID is a text channel showing live captions; empty for none
+	captionChannelID string
+	// syncChannelID is a text channel where transcripts and answers are
+	// mirrored and indexed for search; empty for none
+	syncChannelID string
+}
+
+// listening reports whether any option needs the bot to listen
+func (o voiceListenOptions) listening() bool {
+	return o.answer || o.captionChannelID != "" || o.syncChannelID != ""
+}
+
+// voiceConversations are the guilds where T.A.R.S listens to what is said
+type voiceConversations struct {
+	mu     sync.Mutex
+	active map[string]context.CancelFunc
+}
+
+func newVoiceConversations() *voiceConversations {
+	return &voiceConversations{active: make(map[string]context.CancelFunc)}
+}
+
+// start begins a conversation in a guild, replacing any running one
+func (c *voiceConversations) start(guildID string) context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if stop, ok := c.active[guildID]; ok {
+		stop()
+	}
+	c.active[guildID] = cancel
+	return ctx
+}
+
+// stop ends a guild's conversation, if any
+func (c *voiceConversations) stop(guildID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if stop, ok := c.active[guildID]; ok {
+		stop()
+		delete(c.active, guildID)
+	}
+}
+
+func (c *voiceConversations) stopAll() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for guildID, stop := range c.active {
+		stop()
+		delete(c.active, guildID)
+	}
+}
+
+// converse listens in a guild's voice channel, captioning, mirroring and
+// answering what is said as the options ask, until the conversation is stopped or the bot leaves
+func (b *Bot) converse(ctx context.Context, guildID string, opts voiceListenOptions) {
+	log.Printf("🗣️ Voice conversation started in guild %s", guildID)
+	defer log.Printf("🗣️ Voice conversation ended in guild %s", guildID)
+
+	for ctx.Err() == nil {
+		vc := b.voiceService.Connection(guildID)
+		if vc == nil {
+			return
+		}
+
+		var caption *liveCaption
+		var onPartial func(string)
+		if opts.captionChannelID != "" {
+			caption = newLiveCaption(b.session, opts.captionChannelID)
+			onPartial = caption.partial
+		}
+		question, err := b.voiceService.Listen(ctx, vc, onPartial)
+		question = strings.TrimSpace(question)
+		var captioned *discordgo.Message
+		if caption != nil {
+			captioned = caption.finish(question)
+		}
+		if errors.Is(err, voice.ErrNoAudio) {
+			continue
+		}
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("⚠️ Failed to listen in guild %s: %v", guildID, err)
+				time.Sleep(time.Second)
+			}
+			continue
+		}
+		if question == "" {
+			continue
+		}
+		b.syncVoiceLine(ctx, guildID, opts, "🗣️ "+question, captioned)
+		if !opts.answer {
+			continue
+		}
+		if answer := b.speakAnswer(ctx, guildID, vc, question); answer != "" {
+			b.syncVoiceLine(ctx, guildID, opts, "🤖 **T.A.R.S:** "+answer, nil)
+		}
+	}
+}
+
+// speakAnswer answers a spoken question aloud and returns the answer. Streamed
+// answers are spoken a sentence at a time as they are generated, rather than
+// once complete.
+func (b *Bot) speakAnswer(ctx context.Context, guildID string, vc *discordgo.VoiceConnection, question string) string {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+	defer cancel()
+	ctx = b.withPersona(tenant.WithGuild(ctx, parseSnowflake(guildID)), guildID)
+	// Emojis can't be spoken
+	ctx = persona.WithStyle(ctx, persona.StylePlain)
+
+	streaming, ok := b.aiService.(interfaces.StreamingAIService)
+	if !ok {
+		answer, err := b.aiService.GenerateResponse(ctx, question, voiceSpeaker)
+		if err != nil {
+			log.Printf("❌ AI service error: %v", err)
+			return ""
+		}
+		if err := b.voiceService.SpeakText(ctx, vc, answer); err != nil {
+			log.Printf("❌ Failed to speak: %v", err)
+		}
+		return answer
+	}
+
+	pieces := make(chan string, 64)
+	spoken := make(chan error, 1)
+	go func() {
+		spoken <- b.voiceService.SpeakStream(ctx, vc, pieces)
+	}()
+
+	answer, err := streaming.StreamResponse(ctx, question, voiceSpeaker, func(text string) {
+		pieces <- text
+	})
+	close(pieces)
+	if err != nil {
+		log.Printf("❌ AI service error: %v", err)
+	}
+	if err := <-spoken; err != nil {
+		log.Printf("❌ Failed to speak: %v", err)
+	}
+	return answer
+}
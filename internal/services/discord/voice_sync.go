@@ -0,0 +1,47 @@
+package discord
+
+import (
+	"context"
+	"log"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// syncVoiceLine mirrors what was heard or said in voice into the guild's
+// text-sync channel and indexes it, so conversations can be searched and
+// answered from later. A caption already posted in that channel is indexed
+// instead of posting the line twice.
+func (b *Bot) syncVoiceLine(ctx context.Context, guildID string, opts voiceListenOptions, content string, caption *discordgo.Message) {
+	if opts.syncChannelID == "" {
+		return
+	}
+
+	var posted []*discordgo.Message
+	if caption != nil && opts.captionChannelID == opts.syncChannelID {
+		posted = append(posted, caption)
+	} else {
+		for _, page := range splitMessage(content, 2000, maxResponsePages) {
+			msg, err := b.session.ChannelMessageSendComplex(opts.syncChannelID, &discordgo.MessageSend{
+				Content: page,
+				// Transcripts may contain anything, including "@everyone"
+				AllowedMentions: &discordgo.MessageAllowedMentions{},
+			})
+			if err != nil {
+				log.Printf("⚠️ Failed to mirror voice to text channel %s: %v", opts.syncChannelID, err)
+				return
+			}
+			posted = append(posted, msg)
+		}
+	}
+
+	if b.ragService == nil {
+		return
+	}
+	for _, msg := range posted {
+		// Message responses don't carry the guild
+		msg.GuildID = guildID
+		if err := b.ragService.IndexOwnMessage(ctx, msg); err != nil {
+			log.Printf("❌ Failed to index voice transcript: %v", err)
+		}
+	}
+}
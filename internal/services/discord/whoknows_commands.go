@@ -0,0 +1,125 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/tenant"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// whoKnowsMaxExperts is how many members /whoknows lists
+	whoKnowsMaxExperts = 5
+	// whoKnowsMaxExamples is how many of each member's messages are linked
+	whoKnowsMaxExamples = 2
+)
+
+func whoKnowsCommand() *discordgo.ApplicationCommand {
+	return &discordgo.ApplicationCommand{
+		Name:        "whoknows",
+		Description: "Find the members who discussed a topic the most",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "topic",
+				Description: "What you need help with, e.g. Kubernetes networking",
+				Required:    true,
+				MaxLength:   200,
+			},
+		},
+	}
+}
+
+func (b *Bot) handleWhoKnowsCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if b.ragService == nil {
+		respondEphemeral(s, i, "🔧 RAG service is not available.")
+		return
+	}
+	if i.GuildID == "" {
+		respondEphemeral(s, i, "🔒 This command only works in a server.")
+		return
+	}
+
+	topic := strings.TrimSpace(optionMap(i.ApplicationCommandData().Options)["topic"].StringValue())
+	if topic == "" {
+		respondEphemeral(s, i, "❓ Tell me which topic to look for.")
+		return
+	}
+
+	if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	}); err != nil {
+		log.Printf("❌ Failed to defer interaction: %v", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(tenant.WithGuild(context.Background(), parseSnowflake(i.GuildID)), 30*time.Second)
+	defer cancel()
+
+	content := b.whoKnows(ctx, s, i, topic)
+	if _, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Content: &content,
+		// Listing members shouldn't ping them
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	}); err != nil {
+		log.Printf("❌ Failed to edit interaction response: %v", err)
+	}
+}
+
+func (b *Bot) whoKnows(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, topic string) string {
+	stats, err := b.ragService.TopicStats(ctx, parseSnowflake(i.GuildID), topic, whoKnowsMaxExamples)
+	if err != nil {
+		log.Printf("❌ Failed to find experts on %q: %v", topic, err)
+		return aiErrorMessage(err, "🔧 I couldn't search the server's history. Please try again later.")
+	}
+	if len(stats.Experts) == 0 {
+		return fmt.Sprintf("🔎 Nobody seems to have discussed **%s** here yet.", topic)
+	}
+
+	user := interactionUser(i)
+	readable := make(map[int64]bool)
+	canRead := func(channelID int64) bool {
+		ok, checked := readable[channelID]
+		if !checked {
+			ok = userCanRead(s, user.ID, strconv.FormatInt(channelID, 10))
+			readable[channelID] = ok
+		}
+		return ok
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "🔎 **Who knows about %s** (%d related messages)\n", topic, stats.Messages)
+	for n, e := range stats.Experts[:min(len(stats.Experts), whoKnowsMaxExperts)] {
+		fmt.Fprintf(&sb, "\n**%d.** <@%d> — %d messages (score %.1f)", n+1, e.UserID, e.Messages, e.Score)
+		if len(e.Channels) > 0 {
+			sb.WriteString(", mostly in #" + strings.Join(e.Channels[:min(len(e.Channels), 3)], ", #"))
+		}
+		sb.WriteString("\n")
+		// Examples only link channels the requester can read
+		for _, example := range e.Examples {
+			if canRead(example.Message.ChannelID) {
+				fmt.Fprintf(&sb, "  • [%s](%s)\n", truncateText(exampleSnippet(example), 80), storedMessageLink(example.Message))
+			}
+		}
+	}
+	return truncateText(sb.String(), 2000)
+}
+
+// exampleSnippet is a message's first line, for link text
+func exampleSnippet(result models.SearchResult) string {
+	text, _, _ := strings.Cut(strings.TrimSpace(result.Message.Content), "\n")
+	// Brackets would end the link text early
+	return strings.NewReplacer("[", "(", "]", ")").Replace(text)
+}
+
+// storedMessageLink is a jump link to an indexed message
+func storedMessageLink(m models.Message) string {
+	return fmt.Sprintf("https://discord.com/channels/%d/%d/%d", m.GuildID, m.ChannelID, m.ID)
+}
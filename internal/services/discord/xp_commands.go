@@ -0,0 +1,364 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"discord-tars/internal/events"
+	"discord-tars/internal/models"
+	"discord-tars/internal/services/xp"
+	"discord-tars/internal/tenant"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// leaderboardPageSize is how many members a /leaderboard page lists
+	leaderboardPageSize = 10
+	// rankBarLength is how many segments the progress bar of /rank has
+	rankBarLength = 10
+)
+
+func xpCommand() *discordgo.ApplicationCommand {
+	minXP, minCooldown := 0.0, 0.0
+	return &discordgo.ApplicationCommand{
+		Name:        "xp",
+		Description: "Award members XP for their activity (admins only)",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "setup",
+				Description: "Turn on XP, or change what each activity is worth",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "message_xp",
+						Description: fmt.Sprintf("XP per message (default %d)", xp.DefaultMessageXP),
+						MinValue:    &minXP,
+						MaxValue:    xp.MaxActivityXP,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "reaction_xp",
+						Description: fmt.Sprintf("XP per reaction a member's messages receive (default %d)", xp.DefaultReactionXP),
+						MinValue:    &minXP,
+						MaxValue:    xp.MaxActivityXP,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "cooldown",
+						Description: fmt.Sprintf("Seconds before another message earns XP (default %d)", int(xp.DefaultCooldown.Seconds())),
+						MinValue:    &minCooldown,
+						MaxValue:    xp.MaxCooldown.Seconds(),
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionBoolean,
+						Name:        "announce",
+						Description: "Congratulate members when they reach a milestone level (default on)",
+					},
+					{
+						Type:         discordgo.ApplicationCommandOptionChannel,
+						Name:         "channel",
+						Description:  "Where level-ups are announced (default: where they happen)",
+						ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText},
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "off",
+				Description: "Stop awarding XP; members keep what they earned",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "status",
+				Description: "Show the XP settings",
+			},
+		},
+	}
+}
+
+func leaderboardCommand() *discordgo.ApplicationCommand {
+	minPage := 1.0
+	return &discordgo.ApplicationCommand{
+		Name:        "leaderboard",
+		Description: "Show the members with the most XP",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        "page",
+				Description: "Page of the leaderboard (default 1)",
+				MinValue:    &minPage,
+			},
+		},
+	}
+}
+
+func rankCommand() *discordgo.ApplicationCommand {
+	return &discordgo.ApplicationCommand{
+		Name:        "rank",
+		Description: "Show your level and XP, or another member's",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionUser,
+				Name:        "member",
+				Description: "Member to show (default: you)",
+			},
+		},
+	}
+}
+
+func (b *Bot) handleXPCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if b.xpService == nil {
+		respondEphemeral(s, i, "🔧 XP is not enabled on this instance.")
+		return
+	}
+	if i.GuildID == "" {
+		respondEphemeral(s, i, "🔒 This command only works in a server.")
+		return
+	}
+	if !isGuildAdmin(i) {
+		respondEphemeral(s, i, tr(i, "admin_only.xp"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	guildID := parseSnowflake(i.GuildID)
+	sub := i.ApplicationCommandData().Options[0]
+
+	cfg, err := b.xpService.Config(ctx, guildID)
+	if err != nil {
+		log.Printf("❌ Failed to load XP config: %v", err)
+		respondEphemeral(s, i, tr(i, "xp.load_failed"))
+		return
+	}
+
+	switch sub.Name {
+	case "setup":
+		b.handleXPSetup(ctx, s, i, sub.Options, guildID, cfg)
+	case "off":
+		removed, err := b.xpService.Disable(ctx, guildID)
+		if err != nil {
+			log.Printf("❌ Failed to disable XP: %v", err)
+			respondEphemeral(s, i, tr(i, "xp.save_failed"))
+			return
+		}
+		if !removed {
+			respondEphemeral(s, i, tr(i, "xp.already_off"))
+			return
+		}
+		respondEphemeral(s, i, tr(i, "xp.off"))
+	case "status":
+		if cfg == nil {
+			respondEphemeral(s, i, tr(i, "xp.status_off"))
+			return
+		}
+		respondEphemeral(s, i, "📈 "+describeXP(i, cfg))
+	}
+}
+
+func (b *Bot) handleXPSetup(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, options []*discordgo.ApplicationCommandInteractionDataOption, guildID int64, previous *models.XPConfig) {
+	opts := optionMap(options)
+	cfg := &models.XPConfig{
+		GuildID:         guildID,
+		MessageXP:       xp.DefaultMessageXP,
+		ReactionXP:      xp.DefaultReactionXP,
+		CooldownSeconds: int(xp.DefaultCooldown.Seconds()),
+		Announce:        true,
+	}
+	// Settings left out keep their current value
+	if previous != nil {
+		*cfg = *previous
+	}
+	if opt, ok := opts["message_xp"]; ok {
+		cfg.MessageXP = int(opt.IntValue())
+	}
+	if opt, ok := opts["reaction_xp"]; ok {
+		cfg.ReactionXP = int(opt.IntValue())
+	}
+	if opt, ok := opts["cooldown"]; ok {
+		cfg.CooldownSeconds = int(opt.IntValue())
+	}
+	if opt, ok := opts["announce"]; ok {
+		cfg.Announce = opt.BoolValue()
+	}
+	if opt, ok := opts["channel"]; ok {
+		cfg.ChannelID = parseSnowflake(opt.ChannelValue(s).ID)
+	}
+
+	if err := b.xpService.Configure(ctx, cfg); err != nil {
+		log.Printf("❌ Failed to save XP config: %v", err)
+		respondEphemeral(s, i, tr(i, "xp.invalid", err))
+		return
+	}
+	respondEphemeral(s, i, "✅ "+describeXP(i, cfg))
+}
+
+func describeXP(i *discordgo.InteractionCreate, cfg *models.XPConfig) string {
+	text := tr(i, "xp.settings", cfg.MessageXP, cfg.CooldownSeconds, cfg.ReactionXP)
+	switch {
+	case !cfg.Announce:
+		text += " " + tr(i, "xp.announce_off")
+	case cfg.ChannelID != 0:
+		text += " " + tr(i, "xp.announce_channel", cfg.ChannelID)
+	default:
+		text += " " + tr(i, "xp.announce_here")
+	}
+	return text
+}
+
+func (b *Bot) handleLeaderboardCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if b.xpService == nil {
+		respondEphemeral(s, i, "🔧 XP is not enabled on this instance.")
+		return
+	}
+	if i.GuildID == "" {
+		respondEphemeral(s, i, "🔒 This command only works in a server.")
+		return
+	}
+	page := 1
+	if opt, ok := optionMap(i.ApplicationCommandData().Options)["page"]; ok {
+		page = int(opt.IntValue())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	members, total, err := b.xpService.Leaderboard(ctx, parseSnowflake(i.GuildID), (page-1)*leaderboardPageSize, leaderboardPageSize)
+	if err != nil {
+		log.Printf("❌ Failed to load XP leaderboard: %v", err)
+		respondEphemeral(s, i, tr(i, "xp.leaderboard_failed"))
+		return
+	}
+	if total == 0 {
+		respondEphemeral(s, i, tr(i, "xp.leaderboard_empty"))
+		return
+	}
+	pages := int((total + leaderboardPageSize - 1) / leaderboardPageSize)
+	if len(members) == 0 {
+		respondEphemeral(s, i, tr(i, "xp.leaderboard_no_page", pages))
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString(tr(i, "xp.leaderboard_title") + "\n")
+	for n, member := range members {
+		fmt.Fprintf(&sb, "**%d.** <@%d> — %s\n", (page-1)*leaderboardPageSize+n+1, member.UserID, tr(i, "xp.level", xp.Level(member.XP), member.XP))
+	}
+	sb.WriteString("-# " + tr(i, "xp.leaderboard_page", page, pages))
+	respondUnpinged(s, i, sb.String())
+}
+
+func (b *Bot) handleRankCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if b.xpService == nil {
+		respondEphemeral(s, i, "🔧 XP is not enabled on this instance.")
+		return
+	}
+	if i.GuildID == "" {
+		respondEphemeral(s, i, "🔒 This command only works in a server.")
+		return
+	}
+	user := interactionUser(i)
+	if opt, ok := optionMap(i.ApplicationCommandData().Options)["member"]; ok {
+		user = opt.UserValue(s)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	member, rank, err := b.xpService.Rank(ctx, parseSnowflake(i.GuildID), parseSnowflake(user.ID))
+	if err != nil {
+		log.Printf("❌ Failed to load rank of user %s: %v", user.ID, err)
+		respondEphemeral(s, i, tr(i, "xp.rank_failed"))
+		return
+	}
+	if member == nil {
+		respondEphemeral(s, i, tr(i, "xp.rank_none", user.ID))
+		return
+	}
+
+	level := xp.Level(member.XP)
+	floor, next := xp.LevelXP(level), xp.LevelXP(level+1)
+	progress := float64(member.XP-floor) / float64(next-floor)
+	filled := int(progress * rankBarLength)
+	bar := strings.Repeat("▰", filled) + strings.Repeat("▱", rankBarLength-filled)
+
+	var sb strings.Builder
+	sb.WriteString(tr(i, "xp.rank_title", user.ID, rank) + "\n")
+	sb.WriteString(tr(i, "xp.level", level, member.XP) + "\n")
+	fmt.Fprintf(&sb, "%s %d%% · %s\n", bar, int(progress*100), tr(i, "xp.rank_next", next-member.XP, level+1))
+	sb.WriteString("-# " + tr(i, "xp.rank_activity", member.Messages, member.Reactions))
+	respondUnpinged(s, i, sb.String())
+}
+
+// respondUnpinged responds publicly without pinging the members listed
+func respondUnpinged(s *discordgo.Session, i *discordgo.InteractionCreate, content string) {
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content:         content,
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		},
+	})
+	if err != nil {
+		log.Printf("❌ Failed to respond to interaction: %v", err)
+	}
+}
+
+// awardMessageXP awards XP for new messages in guilds that turned it on
+func (b *Bot) awardMessageXP(ctx context.Context, event *events.Event) error {
+	if b.xpService == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	up, err := b.xpService.HandleMessage(ctx, event.Message)
+	if err != nil {
+		return fmt.Errorf("failed to award XP for message %s: %w", event.Message.ID, err)
+	}
+	b.announceLevelUp(up)
+	return nil
+}
+
+// awardReactionXP awards XP to the authors of messages that get reactions,
+// and takes it back when the reactions are removed
+func (b *Bot) awardReactionXP(ctx context.Context, event *events.Event) error {
+	if b.xpService == nil {
+		return nil
+	}
+	delta := 1
+	if event.Topic == events.ReactionRemoved {
+		delta = -1
+	}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	up, err := b.xpService.HandleReaction(ctx, event.Reaction, delta)
+	if err != nil {
+		return fmt.Errorf("failed to award XP for reaction on message %s: %w", event.Reaction.MessageID, err)
+	}
+	b.announceLevelUp(up)
+	return nil
+}
+
+// announceLevelUp congratulates a member on a milestone in the guild's persona
+func (b *Bot) announceLevelUp(up *xp.LevelUp) {
+	if up == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(tenant.WithGuild(context.Background(), up.GuildID), 30*time.Second)
+	defer cancel()
+	ctx = b.withPersona(ctx, strconv.FormatInt(up.GuildID, 10))
+	if err := b.xpService.Announce(ctx, up); err != nil {
+		log.Printf("❌ Failed to announce level %d of user %d: %v", up.Level, up.UserID, err)
+	}
+}
+
+// SetXPService enables XP, /leaderboard and /rank
+func (b *Bot) SetXPService(xpService *xp.Service) {
+	b.xpService = xpService
+}
@@ -0,0 +1,216 @@
+// Package duplicates spots questions asked in chat that were already
+// answered, by an FAQ entry or by the bot, so they can be linked to that
+// answer instead of being answered again.
+package duplicates
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"discord-tars/internal/interfaces"
+	"discord-tars/internal/models"
+	"discord-tars/internal/repository"
+)
+
+const (
+	defaultAnswerSimilarity = 0.92
+	defaultFAQSimilarity    = 0.85
+	defaultMaxAge           = 90 * 24 * time.Hour
+	defaultCooldown         = 10 * time.Minute
+
+	// Questions are messages with a question mark of a sensible length
+	minQuestionLength = 15
+	maxQuestionLength = 500
+
+	faqLabel        = "faq"
+	configTTL       = 5 * time.Minute
+	maxCachedGuilds = 1000
+)
+
+// Match kinds
+const (
+	KindFAQ    = "faq"
+	KindAnswer = "answer"
+)
+
+// Config sets how close a question must be to an earlier one to be linked
+type Config struct {
+	AnswerSimilarity float64       // Cosine similarity to a question the bot answered
+	FAQSimilarity    float64       // Cosine similarity to an FAQ entry, which holds its answer too
+	MaxAge           time.Duration // Older answers are no longer linked, and are pruned
+	Cooldown         time.Duration // Minimum time between links in a channel
+}
+
+// Match is an earlier answer to a question
+type Match struct {
+	Kind       string
+	GuildID    int64
+	ChannelID  int64
+	MessageID  int64
+	Similarity float64
+}
+
+// Link is a jump link to the earlier answer
+func (m *Match) Link() string {
+	return fmt.Sprintf("https://discord.com/channels/%d/%d/%d", m.GuildID, m.ChannelID, m.MessageID)
+}
+
+type Service struct {
+	aiService    interfaces.AIService
+	repo         *repository.DuplicateRepository
+	priorityRepo *repository.PriorityRepository
+	cfg          Config
+
+	mu         sync.Mutex
+	enabled    map[int64]cachedConfig
+	lastLinked map[int64]time.Time // By channel
+}
+
+type cachedConfig struct {
+	enabled  bool
+	loadedAt time.Time
+}
+
+func NewService(aiService interfaces.AIService, repo *repository.DuplicateRepository, priorityRepo *repository.PriorityRepository, cfg Config) *Service {
+	if cfg.AnswerSimilarity <= 0 {
+		cfg.AnswerSimilarity = defaultAnswerSimilarity
+	}
+	if cfg.FAQSimilarity <= 0 {
+		cfg.FAQSimilarity = defaultFAQSimilarity
+	}
+	if cfg.MaxAge <= 0 {
+		cfg.MaxAge = defaultMaxAge
+	}
+	if cfg.Cooldown <= 0 {
+		cfg.Cooldown = defaultCooldown
+	}
+	return &Service{
+		aiService:    aiService,
+		repo:         repo,
+		priorityRepo: priorityRepo,
+		cfg:          cfg,
+		enabled:      make(map[int64]cachedConfig),
+		lastLinked:   make(map[int64]time.Time),
+	}
+}
+
+// Enabled tells whether a guild opted in; errors count as not enabled
+func (s *Service) Enabled(ctx context.Context, guildID int64) bool {
+	s.mu.Lock()
+	cached, ok := s.enabled[guildID]
+	s.mu.Unlock()
+	if ok && time.Since(cached.loadedAt) < configTTL {
+		return cached.enabled
+	}
+
+	cfg, err := s.repo.GetConfig(ctx, guildID)
+	if err != nil {
+		return false
+	}
+	s.cacheEnabled(guildID, cfg != nil && cfg.Enabled)
+	return cfg != nil && cfg.Enabled
+}
+
+// SetEnabled opts a guild in or out
+func (s *Service) SetEnabled(ctx context.Context, guildID int64, enabled bool) error {
+	if err := s.repo.SaveConfig(ctx, &models.DuplicateConfig{GuildID: guildID, Enabled: enabled}); err != nil {
+		return err
+	}
+	s.cacheEnabled(guildID, enabled)
+	return nil
+}
+
+func (s *Service) cacheEnabled(guildID int64, enabled bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.enabled) >= maxCachedGuilds {
+		s.enabled = make(map[int64]cachedConfig)
+	}
+	s.enabled[guildID] = cachedConfig{enabled: enabled, loadedAt: time.Now()}
+}
+
+// RecordAnswer remembers a question the bot answered with the given message,
+// in guilds that opted in
+func (s *Service) RecordAnswer(ctx context.Context, guildID, channelID, messageID int64, question string) error {
+	question = strings.TrimSpace(question)
+	if guildID == 0 || question == "" || !s.Enabled(ctx, guildID) {
+		return nil
+	}
+	embedding, err := s.aiService.GenerateEmbedding(ctx, question)
+	if err != nil {
+		return fmt.Errorf("failed to generate question embedding: %w", err)
+	}
+	return s.repo.SaveAnswer(ctx, &models.AnsweredQuestion{
+		GuildID:   guildID,
+		ChannelID: channelID,
+		MessageID: messageID,
+		Question:  question,
+	}, embedding)
+}
+
+// Find returns the earlier answer to a chat message when it is a question
+// already answered in a guild that opted in, or nil. FAQ entries come before
+// the bot's own answers. A channel gets at most one link per cooldown.
+func (s *Service) Find(ctx context.Context, guildID, channelID int64, content string) (*Match, error) {
+	if guildID == 0 || !IsQuestion(content) || s.coolingDown(channelID) || !s.Enabled(ctx, guildID) {
+		return nil, nil
+	}
+
+	embedding, err := s.aiService.GenerateEmbedding(ctx, content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate question embedding: %w", err)
+	}
+
+	var match *Match
+	faq, err := s.priorityRepo.SearchLabel(ctx, guildID, faqLabel, embedding, 1, s.cfg.FAQSimilarity)
+	if err != nil {
+		return nil, err
+	}
+	if len(faq) > 0 {
+		doc := faq[0].Document
+		match = &Match{Kind: KindFAQ, GuildID: guildID, ChannelID: doc.ChannelID, MessageID: doc.MessageID, Similarity: faq[0].Similarity}
+	} else {
+		answered, err := s.repo.ClosestAnswer(ctx, guildID, embedding, time.Now().Add(-s.cfg.MaxAge), s.cfg.AnswerSimilarity)
+		if err != nil {
+			return nil, err
+		}
+		if answered == nil {
+			return nil, nil
+		}
+		q := answered.Question
+		match = &Match{Kind: KindAnswer, GuildID: guildID, ChannelID: q.ChannelID, MessageID: q.MessageID, Similarity: answered.Similarity}
+	}
+
+	s.mu.Lock()
+	s.lastLinked[channelID] = time.Now()
+	s.mu.Unlock()
+	return match, nil
+}
+
+func (s *Service) coolingDown(channelID int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	last, ok := s.lastLinked[channelID]
+	if ok && time.Since(last) >= s.cfg.Cooldown {
+		delete(s.lastLinked, channelID)
+		return false
+	}
+	return ok
+}
+
+// Prune is the scheduler job deleting answers too old to be linked
+func (s *Service) Prune(ctx context.Context) error {
+	_, err := s.repo.PruneAnswers(ctx, time.Now().Add(-s.cfg.MaxAge))
+	return err
+}
+
+// IsQuestion tells whether a chat message looks like a question worth matching
+func IsQuestion(content string) bool {
+	content = strings.TrimSpace(content)
+	n := utf8.RuneCountInString(content)
+	return n >= minQuestionLength && n <= maxQuestionLength && strings.Contains(content, "?")
+}
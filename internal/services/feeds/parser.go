@@ -0,0 +1,161 @@
+package feeds
+
+import (
+	"encoding/xml"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Item is a normalized RSS item or Atom entry
+type Item struct {
+	GUID        string
+	Title       string
+	Link        string
+	Description string
+	Published   *time.Time
+}
+
+// Document is a normalized feed
+type Document struct {
+	Title string
+	Items []Item
+}
+
+type rssDocument struct {
+	Channel struct {
+		Title string `xml:"title"`
+		Items []struct {
+			Title       string `xml:"title"`
+			Link        string `xml:"link"`
+			GUID        string `xml:"guid"`
+			PubDate     string `xml:"pubDate"`
+			Description string `xml:"description"`
+		} `xml:"item"`
+	} `xml:"channel"`
+}
+
+type atomDocument struct {
+	Title   string `xml:"title"`
+	Entries []struct {
+		Title string `xml:"title"`
+		ID    string `xml:"id"`
+		Links []struct {
+			Href string `xml:"href,attr"`
+			Rel  string `xml:"rel,attr"`
+		} `xml:"link"`
+		Published string `xml:"published"`
+		Updated   string `xml:"updated"`
+		Summary   string `xml:"summary"`
+		Content   string `xml:"content"`
+	} `xml:"entry"`
+}
+
+var (
+	htmlTagPattern    = regexp.MustCompile(`<[^>]*>`)
+	whitespacePattern = regexp.MustCompile(`\s+`)
+)
+
+var dateLayouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	time.RFC3339,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04:05 -0700",
+}
+
+// Parse decodes an RSS 2.0 or Atom document
+func Parse(data []byte) (*Document, error) {
+	var root struct {
+		XMLName xml.Name
+	}
+	if err := xml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("invalid feed XML: %w", err)
+	}
+
+	switch strings.ToLower(root.XMLName.Local) {
+	case "rss":
+		return parseRSS(data)
+	case "feed":
+		return parseAtom(data)
+	default:
+		return nil, fmt.Errorf("unsupported feed format <%s>", root.XMLName.Local)
+	}
+}
+
+func parseRSS(data []byte) (*Document, error) {
+	var rss rssDocument
+	if err := xml.Unmarshal(data, &rss); err != nil {
+		return nil, fmt.Errorf("invalid RSS feed: %w", err)
+	}
+
+	doc := &Document{Title: cleanText(rss.Channel.Title)}
+	for _, it := range rss.Channel.Items {
+		doc.Items = append(doc.Items, Item{
+			GUID:        firstNonEmpty(it.GUID, it.Link, it.Title),
+			Title:       cleanText(it.Title),
+			Link:        strings.TrimSpace(it.Link),
+			Description: cleanText(it.Description),
+			Published:   parseDate(it.PubDate),
+		})
+	}
+	return doc, nil
+}
+
+func parseAtom(data []byte) (*Document, error) {
+	var atom atomDocument
+	if err := xml.Unmarshal(data, &atom); err != nil {
+		return nil, fmt.Errorf("invalid Atom feed: %w", err)
+	}
+
+	doc := &Document{Title: cleanText(atom.Title)}
+	for _, e := range atom.Entries {
+		var link string
+		for _, l := range e.Links {
+			if l.Rel == "" || l.Rel == "alternate" {
+				link = l.Href
+				break
+			}
+		}
+		doc.Items = append(doc.Items, Item{
+			GUID:        firstNonEmpty(e.ID, link, e.Title),
+			Title:       cleanText(e.Title),
+			Link:        strings.TrimSpace(link),
+			Description: cleanText(firstNonEmpty(e.Summary, e.Content)),
+			Published:   parseDate(firstNonEmpty(e.Published, e.Updated)),
+		})
+	}
+	return doc, nil
+}
+
+// cleanText strips markup and collapses whitespace
+func cleanText(s string) string {
+	s = htmlTagPattern.ReplaceAllString(s, " ")
+	s = html.UnescapeString(s)
+	return strings.TrimSpace(whitespacePattern.ReplaceAllString(s, " "))
+}
+
+func parseDate(s string) *time.Time {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil
+	}
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return &t
+		}
+	}
+	return nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,294 @@
+package feeds
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+
+	"discord-tars/internal/interfaces"
+	"discord-tars/internal/models"
+	"discord-tars/internal/repository"
+	"discord-tars/internal/tenant"
+)
+
+const (
+	DefaultIntervalMinutes = 60
+	MinIntervalMinutes     = 15
+	MaxIntervalMinutes     = 24 * 60
+
+	// maxItemsPerDigest bounds how many new items are summarized in one post
+	maxItemsPerDigest = 5
+	maxFeedBytes      = 5 * 1024 * 1024
+	maxPromptSnippet  = 1200
+	summaryMaxTokens  = 600
+)
+
+const summarySystemPrompt = `You are T.A.R.S summarizing new articles from a news feed for a Discord channel.
+For each numbered item, write a one or two sentence summary of what it is about.
+Reply with exactly one line per item in the form "<number>. <summary>" and nothing else.`
+
+var ErrInvalidFeedURL = errors.New("feed URL must be an http or https URL")
+
+type Service struct {
+	aiService  interfaces.AIService
+	feedRepo   *repository.FeedRepository
+	session    *discordgo.Session
+	httpClient *http.Client
+}
+
+func NewService(aiService interfaces.AIService, feedRepo *repository.FeedRepository, session *discordgo.Session) *Service {
+	return &Service{
+		aiService:  aiService,
+		feedRepo:   feedRepo,
+		session:    session,
+		httpClient: &http.Client{Timeout: 20 * time.Second},
+	}
+}
+
+// AddFeed validates a feed by fetching it and starts watching it for a channel.
+// Items already in the feed are marked as seen so only later entries are posted.
+func (s *Service) AddFeed(ctx context.Context, guildID, channelID, createdBy int64, feedURL string, intervalMinutes int) (*models.Feed, error) {
+	parsed, err := url.Parse(strings.TrimSpace(feedURL))
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, ErrInvalidFeedURL
+	}
+	if intervalMinutes == 0 {
+		intervalMinutes = DefaultIntervalMinutes
+	}
+	if intervalMinutes < MinIntervalMinutes || intervalMinutes > MaxIntervalMinutes {
+		return nil, fmt.Errorf("interval must be between %d and %d minutes", MinIntervalMinutes, MaxIntervalMinutes)
+	}
+
+	doc, err := s.fetch(ctx, parsed.String())
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	feed := &models.Feed{
+		GuildID:         guildID,
+		ChannelID:       channelID,
+		URL:             parsed.String(),
+		Title:           doc.Title,
+		IntervalMinutes: intervalMinutes,
+		LastCheckedAt:   &now,
+		CreatedBy:       createdBy,
+	}
+	if err := s.feedRepo.AddFeed(ctx, feed); err != nil {
+		return nil, err
+	}
+
+	for _, item := range doc.Items {
+		if item.GUID == "" {
+			continue
+		}
+		if _, err := s.feedRepo.RecordItem(ctx, toModel(feed.ID, item)); err != nil {
+			return feed, err
+		}
+	}
+
+	log.Printf("📰 Watching feed %q for channel %d (%d existing items)", feed.Title, channelID, len(doc.Items))
+	return feed, nil
+}
+
+// RemoveFeed stops watching a feed
+func (s *Service) RemoveFeed(ctx context.Context, guildID, feedID int64) (bool, error) {
+	return s.feedRepo.RemoveFeed(ctx, guildID, feedID)
+}
+
+// ListFeeds returns a guild's feeds
+func (s *Service) ListFeeds(ctx context.Context, guildID int64) ([]models.Feed, error) {
+	return s.feedRepo.ListByGuild(ctx, guildID)
+}
+
+// PollDue is the scheduler job: it checks every feed whose interval has elapsed
+func (s *Service) PollDue(ctx context.Context) error {
+	feeds, err := s.feedRepo.ListDue(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+
+	for i := range feeds {
+		feed := &feeds[i]
+		lastError := ""
+		title, err := s.check(ctx, feed)
+		if err != nil {
+			log.Printf("❌ Failed to check feed %d (%s): %v", feed.ID, feed.URL, err)
+			lastError = err.Error()
+		}
+		if err := s.feedRepo.MarkChecked(ctx, feed.ID, title, lastError, time.Now()); err != nil {
+			log.Printf("❌ Failed to mark feed %d checked: %v", feed.ID, err)
+		}
+	}
+	return nil
+}
+
+// check fetches a feed and posts a digest of unseen items, returning the feed title
+func (s *Service) check(ctx context.Context, feed *models.Feed) (string, error) {
+	doc, err := s.fetch(ctx, feed.URL)
+	if err != nil {
+		return "", err
+	}
+
+	// Post oldest first so a digest reads chronologically
+	items := doc.Items
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].Published == nil || items[j].Published == nil {
+			return false
+		}
+		return items[i].Published.Before(*items[j].Published)
+	})
+
+	var fresh []Item
+	for _, item := range items {
+		if item.GUID == "" {
+			continue
+		}
+		isNew, err := s.feedRepo.RecordItem(ctx, toModel(feed.ID, item))
+		if err != nil {
+			return doc.Title, err
+		}
+		if isNew {
+			fresh = append(fresh, item)
+		}
+	}
+	if len(fresh) == 0 {
+		return doc.Title, nil
+	}
+
+	if doc.Title != "" {
+		feed.Title = doc.Title
+	}
+	return doc.Title, s.postDigest(ctx, feed, fresh)
+}
+
+func (s *Service) postDigest(ctx context.Context, feed *models.Feed, items []Item) error {
+	ctx = tenant.WithGuild(ctx, feed.GuildID)
+	overflow := 0
+	if len(items) > maxItemsPerDigest {
+		overflow = len(items) - maxItemsPerDigest
+		items = items[len(items)-maxItemsPerDigest:]
+	}
+
+	summaries := s.summarize(ctx, items)
+
+	title := feed.Title
+	if title == "" {
+		title = feed.URL
+	}
+	embed := &discordgo.MessageEmbed{
+		Title:     truncate("📰 "+title, 256),
+		Color:     0xf26522,
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+	for i, item := range items {
+		value := summaries[i]
+		if item.Link != "" {
+			value += "\n" + item.Link
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  truncate(firstNonEmpty(item.Title, "Untitled"), 256),
+			Value: truncate(value, 1024),
+		})
+	}
+	if overflow > 0 {
+		embed.Footer = &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("+%d older items not shown", overflow)}
+	}
+
+	if _, err := s.session.ChannelMessageSendEmbed(strconv.FormatInt(feed.ChannelID, 10), embed); err != nil {
+		return fmt.Errorf("failed to post feed digest: %w", err)
+	}
+	log.Printf("📰 Posted %d items from feed %d", len(items), feed.ID)
+	return nil
+}
+
+// summarize returns one summary per item, falling back to the item's own description
+func (s *Service) summarize(ctx context.Context, items []Item) []string {
+	summaries := make([]string, len(items))
+	for i, item := range items {
+		summaries[i] = truncate(item.Description, 300)
+	}
+
+	var sb strings.Builder
+	for i, item := range items {
+		sb.WriteString(fmt.Sprintf("%d. %s\n%s\n\n", i+1, item.Title, truncate(item.Description, maxPromptSnippet)))
+	}
+
+	reply, err := s.aiService.Complete(ctx, summarySystemPrompt, sb.String(), summaryMaxTokens)
+	if err != nil {
+		log.Printf("⚠️ Feed summary failed, using descriptions: %v", err)
+		return summaries
+	}
+
+	for _, line := range strings.Split(reply, "\n") {
+		num, text, ok := strings.Cut(strings.TrimSpace(line), ". ")
+		if !ok {
+			continue
+		}
+		n, err := strconv.Atoi(num)
+		if err != nil || n < 1 || n > len(items) {
+			continue
+		}
+		if text = strings.TrimSpace(text); text != "" {
+			summaries[n-1] = text
+		}
+	}
+	return summaries
+}
+
+func (s *Service) fetch(ctx context.Context, feedURL string) (*Document, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, feedURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create feed request: %w", err)
+	}
+	req.Header.Set("User-Agent", "discord-tars-feeds/1.0")
+	req.Header.Set("Accept", "application/rss+xml, application/atom+xml, application/xml, text/xml")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch feed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("feed returned status %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxFeedBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read feed: %w", err)
+	}
+	return Parse(data)
+}
+
+func toModel(feedID int64, item Item) *models.FeedItem {
+	return &models.FeedItem{
+		FeedID:      feedID,
+		GUID:        item.GUID,
+		Title:       item.Title,
+		Link:        item.Link,
+		PublishedAt: item.Published,
+	}
+}
+
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	cut := max - 3
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + "..."
+}
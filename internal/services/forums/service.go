@@ -0,0 +1,210 @@
+// Package forums follows Discord forum channels: it keeps each post with its
+// tags, records which answers solved it, and finds earlier posts similar to
+// a new one.
+package forums
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"discord-tars/internal/interfaces"
+	"discord-tars/internal/models"
+	"discord-tars/internal/repository"
+)
+
+const (
+	similarMaxResults    = 5
+	similarMinSimilarity = 0.5
+	// maxPostChars bounds the text of a post that is embedded
+	maxPostChars = 4000
+)
+
+// acceptedEmojis are the reactions with which a post's author accepts an answer
+var acceptedEmojis = map[string]bool{"✅": true, "☑️": true, "✔️": true}
+
+// solvedTagNames are forum tag names that mark a post solved
+var solvedTagNames = map[string]bool{
+	"solved": true, "resolved": true, "answered": true, "fixed": true,
+	"résolu": true, "resolu": true, "gelöst": true, "erledigt": true, "resuelto": true, "solucionado": true,
+}
+
+type Service struct {
+	aiService interfaces.AIService
+	forumRepo *repository.ForumRepository
+	session   *discordgo.Session
+}
+
+func NewService(aiService interfaces.AIService, forumRepo *repository.ForumRepository, session *discordgo.Session) *Service {
+	return &Service{
+		aiService: aiService,
+		forumRepo: forumRepo,
+		session:   session,
+	}
+}
+
+// PostText is what a forum post is embedded as: its title and tags, then the
+// starter message
+func PostText(title string, tags []string, content string) string {
+	text := title
+	if len(tags) > 0 {
+		text += "\nTags: " + strings.Join(tags, ", ")
+	}
+	if content = strings.TrimSpace(content); content != "" {
+		text += "\n\n" + content
+	}
+	if len(text) > maxPostChars {
+		text = strings.ToValidUTF8(text[:maxPostChars], "")
+	}
+	return text
+}
+
+// ForumPost returns the thread a message was posted in and its forum channel
+// when that thread is a forum post
+func (s *Service) ForumPost(channelID string) (*discordgo.Channel, *discordgo.Channel, bool) {
+	thread := s.channel(channelID)
+	if thread == nil || !thread.IsThread() || thread.ParentID == "" {
+		return nil, nil, false
+	}
+	forum := s.channel(thread.ParentID)
+	if forum == nil || forum.Type != discordgo.ChannelTypeGuildForum {
+		return nil, nil, false
+	}
+	return thread, forum, true
+}
+
+// TagNames resolves the tags applied to a post to their names
+func TagNames(thread, forum *discordgo.Channel) []string {
+	names := make([]string, 0, len(thread.AppliedTags))
+	for _, id := range thread.AppliedTags {
+		for _, tag := range forum.AvailableTags {
+			if tag.ID == id {
+				names = append(names, tag.Name)
+			}
+		}
+	}
+	return names
+}
+
+// HandleMessage stores a forum post when msg is its starter message, and
+// returns the post; other messages return nil
+func (s *Service) HandleMessage(ctx context.Context, msg *discordgo.Message) (*models.ForumPost, error) {
+	// A post's starter message has the thread's ID
+	if msg.GuildID == "" || msg.ID != msg.ChannelID || msg.Author == nil || msg.Author.Bot {
+		return nil, nil
+	}
+	thread, forum, ok := s.ForumPost(msg.ChannelID)
+	if !ok {
+		return nil, nil
+	}
+
+	tags := TagNames(thread, forum)
+	post := &models.ForumPost{
+		ThreadID: parseID(thread.ID),
+		GuildID:  parseID(thread.GuildID),
+		ForumID:  parseID(forum.ID),
+		AuthorID: parseID(msg.Author.ID),
+		Title:    thread.Name,
+		Tags:     strings.Join(tags, ", "),
+		Content:  msg.Content,
+	}
+	if hasSolvedTag(tags) {
+		now := time.Now()
+		post.Solved, post.SolvedAt = true, &now
+	}
+	embedding, err := s.aiService.GenerateEmbedding(ctx, PostText(thread.Name, tags, msg.Content))
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed forum post: %w", err)
+	}
+	if err := s.forumRepo.Save(ctx, post, embedding); err != nil {
+		return nil, err
+	}
+	log.Printf("🗂️ Stored forum post %s (%q, tags: %s)", thread.ID, thread.Name, post.Tags)
+	return post, nil
+}
+
+// HandleThreadUpdate records a post's renamed title or changed tags; a
+// solved tag marks it solved
+func (s *Service) HandleThreadUpdate(ctx context.Context, thread *discordgo.Channel) error {
+	if !thread.IsThread() {
+		return nil
+	}
+	forum := s.channel(thread.ParentID)
+	if forum == nil || forum.Type != discordgo.ChannelTypeGuildForum {
+		return nil
+	}
+	tags := TagNames(thread, forum)
+	return s.forumRepo.UpdateTags(ctx, parseID(thread.ID), thread.Name, strings.Join(tags, ", "), hasSolvedTag(tags))
+}
+
+// HandleReaction accepts a reply as a post's answer when the post's author,
+// or someone who manages threads, reacts to it with a check mark. It reports
+// whether the post was newly solved by it.
+func (s *Service) HandleReaction(ctx context.Context, r *discordgo.MessageReaction) (bool, error) {
+	if !acceptedEmojis[r.Emoji.Name] || r.MessageID == r.ChannelID {
+		return false, nil
+	}
+	thread, _, ok := s.ForumPost(r.ChannelID)
+	if !ok {
+		return false, nil
+	}
+	if r.UserID != thread.OwnerID {
+		perms, err := s.session.State.UserChannelPermissions(r.UserID, r.ChannelID)
+		if err != nil || perms&discordgo.PermissionManageThreads == 0 {
+			return false, nil
+		}
+	}
+	solved, err := s.forumRepo.MarkSolution(ctx, parseID(thread.ID), parseID(r.MessageID))
+	if err != nil {
+		return false, err
+	}
+	if solved {
+		log.Printf("✅ Forum post %s solved by message %s", thread.ID, r.MessageID)
+	}
+	return solved, nil
+}
+
+// Similar finds a guild's earlier forum posts similar to a text, solved ones
+// first among equals, leaving out the post excludeThreadID
+func (s *Service) Similar(ctx context.Context, guildID, excludeThreadID int64, text string) ([]models.ForumPostResult, error) {
+	embedding, err := s.aiService.GenerateEmbedding(ctx, text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate query embedding: %w", err)
+	}
+	return s.forumRepo.Similar(ctx, guildID, excludeThreadID, embedding, similarMaxResults, similarMinSimilarity)
+}
+
+// Post returns a stored forum post, or nil if it isn't one
+func (s *Service) Post(ctx context.Context, threadID int64) (*models.ForumPost, error) {
+	return s.forumRepo.Get(ctx, threadID)
+}
+
+func (s *Service) channel(channelID string) *discordgo.Channel {
+	if channel, err := s.session.State.Channel(channelID); err == nil {
+		return channel
+	}
+	channel, err := s.session.Channel(channelID)
+	if err != nil {
+		return nil
+	}
+	return channel
+}
+
+func hasSolvedTag(tags []string) bool {
+	for _, tag := range tags {
+		if solvedTagNames[strings.ToLower(strings.Trim(tag, " ✅✔️☑️"))] {
+			return true
+		}
+	}
+	return false
+}
+
+func parseID(id string) int64 {
+	n, _ := strconv.ParseInt(id, 10, 64)
+	return n
+}
@@ -0,0 +1,269 @@
+package github
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+
+	"discord-tars/internal/interfaces"
+	"discord-tars/internal/models"
+	"discord-tars/internal/repository"
+	"discord-tars/internal/server"
+)
+
+const (
+	maxPromptDiffChars = 24000
+	reviewMaxTokens    = 700
+	blurbMaxTokens     = 200
+	maxWebhookBytes    = 5 * 1024 * 1024
+)
+
+const reviewSystemPrompt = `You are T.A.R.S, a senior engineer summarizing a GitHub pull request for a Discord channel.
+Using the title, description and diff, write:
+**Summary** — what the change does in 2-3 sentences.
+**Key changes** — up to 5 bullets naming files or components.
+**Review notes** — risks, missing tests, or questions a reviewer should ask (say "none spotted" if so).
+Be concrete and concise. If the diff was truncated, mention that the review is partial.`
+
+const blurbSystemPrompt = `You are T.A.R.S announcing a GitHub event in a Discord channel.
+Write a 1-3 sentence blurb explaining what happened and why it might matter to the community.
+A touch of dry humor is welcome. Do not repeat the URL.`
+
+var prURLPattern = regexp.MustCompile(`github\.com/([\w.-]+)/([\w.-]+)/pull/(\d+)`)
+
+var ErrInvalidPRURL = errors.New("not a GitHub pull request URL")
+
+type Service struct {
+	aiService     interfaces.AIService
+	client        *Client
+	githubRepo    *repository.GitHubRepository
+	session       *discordgo.Session
+	webhookSecret string
+}
+
+type Config struct {
+	Token         string
+	WebhookSecret string
+}
+
+func NewService(cfg Config, aiService interfaces.AIService, githubRepo *repository.GitHubRepository, session *discordgo.Session) *Service {
+	return &Service{
+		aiService:     aiService,
+		client:        NewClient(cfg.Token),
+		githubRepo:    githubRepo,
+		session:       session,
+		webhookSecret: cfg.WebhookSecret,
+	}
+}
+
+// SummarizePullRequest fetches a pull request and its diff and returns an AI review summary
+func (s *Service) SummarizePullRequest(ctx context.Context, url string) (string, error) {
+	match := prURLPattern.FindStringSubmatch(url)
+	if match == nil {
+		return "", ErrInvalidPRURL
+	}
+	owner, repo := match[1], match[2]
+	number, _ := strconv.Atoi(match[3])
+
+	pr, err := s.client.GetPullRequest(ctx, owner, repo, number)
+	if err != nil {
+		return "", err
+	}
+	diff, err := s.client.GetPullRequestDiff(ctx, owner, repo, number)
+	if err != nil {
+		return "", err
+	}
+
+	truncated := ""
+	if len(diff) > maxPromptDiffChars {
+		diff = truncate(diff, maxPromptDiffChars)
+		truncated = "\n(diff truncated)"
+	}
+
+	prompt := fmt.Sprintf("PR #%d: %s\nAuthor: %s\nState: %s\nFiles changed: %d (+%d/-%d)\n\nDescription:\n%s\n\nDiff:\n%s%s",
+		pr.Number, pr.Title, pr.User.Login, pr.State, pr.ChangedFiles, pr.Additions, pr.Deletions, pr.Body, diff, truncated)
+
+	review, err := s.aiService.Complete(ctx, reviewSystemPrompt, prompt, reviewMaxTokens)
+	if err != nil {
+		return "", fmt.Errorf("failed to summarize pull request: %w", err)
+	}
+
+	log.Printf("🐙 Summarized %s/%s#%d", owner, repo, number)
+	return fmt.Sprintf("🐙 **%s/%s#%d — %s**\n<%s>\n\n%s", owner, repo, pr.Number, pr.Title, pr.HTMLURL, review), nil
+}
+
+// Subscribe announces a repository's events in a channel
+func (s *Service) Subscribe(ctx context.Context, guildID, channelID, createdBy int64, repo string) (string, error) {
+	repo = normalizeRepo(repo)
+	if strings.Count(repo, "/") != 1 {
+		return "", fmt.Errorf("repository must be in owner/name form")
+	}
+	return repo, s.githubRepo.Subscribe(ctx, &models.GitHubSubscription{
+		GuildID:   guildID,
+		ChannelID: channelID,
+		Repo:      repo,
+		CreatedBy: createdBy,
+	})
+}
+
+// Unsubscribe stops announcing a repository in a channel
+func (s *Service) Unsubscribe(ctx context.Context, channelID int64, repo string) (bool, error) {
+	return s.githubRepo.Unsubscribe(ctx, channelID, normalizeRepo(repo))
+}
+
+// ListSubscriptions returns a guild's repository subscriptions
+func (s *Service) ListSubscriptions(ctx context.Context, guildID int64) ([]models.GitHubSubscription, error) {
+	return s.githubRepo.ListByGuild(ctx, guildID)
+}
+
+// webhookPayload holds the fields of release and issues events that are announced
+type webhookPayload struct {
+	Action     string `json:"action"`
+	Repository struct {
+		FullName string `json:"full_name"`
+	} `json:"repository"`
+	Release *struct {
+		Name    string `json:"name"`
+		TagName string `json:"tag_name"`
+		Body    string `json:"body"`
+		HTMLURL string `json:"html_url"`
+	} `json:"release"`
+	Issue *struct {
+		Number  int    `json:"number"`
+		Title   string `json:"title"`
+		Body    string `json:"body"`
+		HTMLURL string `json:"html_url"`
+		User    struct {
+			Login string `json:"login"`
+		} `json:"user"`
+	} `json:"issue"`
+}
+
+// HandleWebhook receives GitHub webhook deliveries
+func (s *Service) HandleWebhook(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookBytes))
+	if err != nil {
+		server.WriteError(w, http.StatusBadRequest, "failed to read body")
+		return
+	}
+
+	if !s.validSignature(body, r.Header.Get("X-Hub-Signature-256")) {
+		log.Printf("⚠️ Rejected github webhook with invalid signature")
+		server.WriteError(w, http.StatusUnauthorized, "invalid signature")
+		return
+	}
+
+	event := r.Header.Get("X-GitHub-Event")
+	var payload webhookPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		server.WriteError(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+
+	// Acknowledge quickly; GitHub times out deliveries after 10 seconds
+	server.WriteJSON(w, http.StatusAccepted, map[string]string{"status": "accepted"})
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		if err := s.announce(ctx, event, &payload); err != nil {
+			log.Printf("❌ Failed to announce github %s event: %v", event, err)
+		}
+	}()
+}
+
+func (s *Service) announce(ctx context.Context, event string, payload *webhookPayload) error {
+	var title, url, details string
+	switch {
+	case event == "release" && payload.Action == "published" && payload.Release != nil:
+		name := payload.Release.Name
+		if name == "" {
+			name = payload.Release.TagName
+		}
+		title = fmt.Sprintf("🚀 New release of **%s**: %s", payload.Repository.FullName, name)
+		url = payload.Release.HTMLURL
+		details = payload.Release.Body
+	case event == "issues" && payload.Action == "opened" && payload.Issue != nil:
+		title = fmt.Sprintf("🐛 New issue in **%s** #%d: %s (by %s)", payload.Repository.FullName,
+			payload.Issue.Number, payload.Issue.Title, payload.Issue.User.Login)
+		url = payload.Issue.HTMLURL
+		details = payload.Issue.Body
+	default:
+		return nil
+	}
+
+	subs, err := s.githubRepo.ListByRepo(ctx, normalizeRepo(payload.Repository.FullName))
+	if err != nil {
+		return err
+	}
+	if len(subs) == 0 {
+		return nil
+	}
+
+	details = truncate(details, 4000)
+	blurb, err := s.aiService.Complete(ctx, blurbSystemPrompt, fmt.Sprintf("%s\n\n%s", title, details), blurbMaxTokens)
+	if err != nil {
+		log.Printf("⚠️ Failed to generate github blurb: %v", err)
+		blurb = ""
+	}
+
+	content := fmt.Sprintf("%s\n%s\n<%s>", title, blurb, url)
+	for _, sub := range subs {
+		if _, err := s.session.ChannelMessageSend(strconv.FormatInt(sub.ChannelID, 10), content); err != nil {
+			log.Printf("❌ Failed to announce github event in channel %d: %v", sub.ChannelID, err)
+		}
+	}
+
+	log.Printf("🐙 Announced github %s event for %s in %d channels", event, payload.Repository.FullName, len(subs))
+	return nil
+}
+
+// validSignature checks the HMAC-SHA256 signature GitHub sends with each delivery
+func (s *Service) validSignature(body []byte, signature string) bool {
+	if s.webhookSecret == "" {
+		return false
+	}
+	sig, ok := strings.CutPrefix(signature, "sha256=")
+	if !ok {
+		return false
+	}
+	expected, err := hex.DecodeString(sig)
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(s.webhookSecret))
+	mac.Write(body)
+	return hmac.Equal(mac.Sum(nil), expected)
+}
+
+func normalizeRepo(repo string) string {
+	repo = strings.TrimSpace(strings.ToLower(repo))
+	repo = strings.TrimPrefix(repo, "https://github.com/")
+	return strings.Trim(repo, "/")
+}
+
+// truncate cuts s to at most max bytes on a rune boundary
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	for max > 0 && !utf8.RuneStart(s[max]) {
+		max--
+	}
+	return s[:max]
+}
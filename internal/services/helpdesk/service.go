@@ -0,0 +1,258 @@
+// Package helpdesk watches the channels a server designates for help: when
+// a new post there was already answered on the server, it points at the
+// earlier answers and sums them up, at most once per thread in a while.
+package helpdesk
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"discord-tars/internal/injection"
+	"discord-tars/internal/interfaces"
+	"discord-tars/internal/models"
+	"discord-tars/internal/repository"
+	"discord-tars/internal/sanitize"
+)
+
+const (
+	defaultMinSimilarity = 0.8
+	defaultCooldown      = time.Hour
+
+	// Posts shorter than this don't say enough to be matched
+	minPostLength = 20
+	maxPostChars  = 2000
+
+	candidates      = 12
+	maxPriorAnswers = 4
+	maxAnswerChars  = 800
+
+	answerMaxTokens = 300
+	// noAnswer is the model's reply when the earlier messages don't answer
+	noAnswer = "NONE"
+)
+
+const answerSystemPrompt = `You help a Discord server's help channel by spotting questions that were already answered there.
+You get a new post and earlier messages from the same server, numbered [1], [2]...
+If the earlier messages clearly answer the post, write a short answer from them alone, in the post's language, citing the messages you used by their number, e.g. "Run the migration first [2]". Keep it under 80 words, and put commands and code in backticks.
+If they don't clearly answer it, or only touch on the topic, reply with exactly NONE. Never guess, and never add what the messages don't say.`
+
+// citation matches a reference to an earlier message, e.g. [2]
+var citation = regexp.MustCompile(`\[(\d+)\]`)
+
+// Config sets how close earlier answers must be and how often a thread gets
+// a suggestion
+type Config struct {
+	MinSimilarity float64       // Cosine similarity of an earlier message to the post
+	Cooldown      time.Duration // Minimum time between suggestions in a thread
+}
+
+// Post is a new message in a help channel, or in a thread or forum post of one
+type Post struct {
+	GuildID   int64
+	ChannelID int64 // The thread when the post is in one
+	MessageID int64
+	InThread  bool
+	Content   string
+}
+
+// Suggestion is a short answer summed up from earlier messages, which the
+// answer cites as [1], [2]... in the order of Sources
+type Suggestion struct {
+	Answer  string
+	Sources []models.SearchResult
+}
+
+type Service struct {
+	aiService interfaces.AIService
+	msgRepo   *repository.MessageRepository
+	repo      *repository.HelpRepository
+	cfg       Config
+
+	mu            sync.Mutex
+	channels      map[int64]bool      // Help channels of every guild, loaded on first use
+	lastSuggested map[int64]time.Time // By thread, or channel outside threads
+}
+
+func NewService(aiService interfaces.AIService, msgRepo *repository.MessageRepository, repo *repository.HelpRepository, cfg Config) *Service {
+	if cfg.MinSimilarity <= 0 {
+		cfg.MinSimilarity = defaultMinSimilarity
+	}
+	if cfg.Cooldown <= 0 {
+		cfg.Cooldown = defaultCooldown
+	}
+	return &Service{
+		aiService:     aiService,
+		msgRepo:       msgRepo,
+		repo:          repo,
+		cfg:           cfg,
+		lastSuggested: make(map[int64]time.Time),
+	}
+}
+
+// AddChannel designates a help channel
+func (s *Service) AddChannel(ctx context.Context, guildID, channelID, addedBy int64) error {
+	if err := s.repo.AddChannel(ctx, &models.HelpChannel{ChannelID: channelID, GuildID: guildID, AddedBy: addedBy}); err != nil {
+		return err
+	}
+	s.invalidate()
+	return nil
+}
+
+// RemoveChannel stops suggesting answers in a channel, reporting whether it
+// was a help channel
+func (s *Service) RemoveChannel(ctx context.Context, guildID, channelID int64) (bool, error) {
+	removed, err := s.repo.RemoveChannel(ctx, guildID, channelID)
+	if err != nil {
+		return false, err
+	}
+	s.invalidate()
+	return removed, nil
+}
+
+// Channels lists a guild's help channels
+func (s *Service) Channels(ctx context.Context, guildID int64) ([]models.HelpChannel, error) {
+	return s.repo.ListChannels(ctx, guildID)
+}
+
+// IsHelpChannel tells whether answers are suggested in a channel; errors
+// count as not
+func (s *Service) IsHelpChannel(ctx context.Context, channelID int64) bool {
+	s.mu.Lock()
+	cached := s.channels
+	s.mu.Unlock()
+
+	if cached == nil {
+		channels, err := s.repo.ListChannels(ctx, 0)
+		if err != nil {
+			log.Printf("⚠️ Failed to load help channels: %v", err)
+			return false
+		}
+		cached = make(map[int64]bool, len(channels))
+		for _, ch := range channels {
+			cached[ch.ChannelID] = true
+		}
+
+		s.mu.Lock()
+		s.channels = cached
+		s.mu.Unlock()
+	}
+	return cached[channelID]
+}
+
+func (s *Service) invalidate() {
+	s.mu.Lock()
+	s.channels = nil
+	s.mu.Unlock()
+}
+
+// Cooldown is the minimum time between suggestions in a thread
+func (s *Service) Cooldown() time.Duration {
+	return s.cfg.Cooldown
+}
+
+// IsPost tells whether a message says enough to look for earlier answers
+func IsPost(content string) bool {
+	return utf8.RuneCountInString(strings.TrimSpace(content)) >= minPostLength
+}
+
+// Suggest sums up the guild's earlier answers to a post, or returns nil when
+// none clearly answers it or its thread (or channel) had a suggestion within
+// the cooldown. readable tells which channels the poster may see; messages
+// elsewhere are neither read nor cited.
+func (s *Service) Suggest(ctx context.Context, p Post, readable func(channelID int64) bool) (*Suggestion, error) {
+	post := strings.TrimSpace(p.Content)
+	if !IsPost(post) || s.coolingDown(p.ChannelID) {
+		return nil, nil
+	}
+	if len(post) > maxPostChars {
+		post = strings.ToValidUTF8(post[:maxPostChars], "")
+	}
+
+	embedding, err := s.aiService.GenerateEmbedding(ctx, post)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate post embedding: %w", err)
+	}
+	results, err := s.msgRepo.SearchGuildMessages(ctx, p.GuildID, embedding, candidates, s.cfg.MinSimilarity)
+	if err != nil {
+		return nil, err
+	}
+
+	var prior []models.SearchResult
+	for _, result := range results {
+		// The post itself and the rest of its thread aren't earlier answers
+		msg := result.Message
+		if msg.ID == p.MessageID || (p.InThread && msg.ChannelID == p.ChannelID) || strings.TrimSpace(msg.Content) == "" || !readable(msg.ChannelID) {
+			continue
+		}
+		prior = append(prior, result)
+		if len(prior) == maxPriorAnswers {
+			break
+		}
+	}
+	if len(prior) == 0 {
+		return nil, nil
+	}
+
+	var prompt strings.Builder
+	// Anyone can post in a help channel or have posted the earlier messages
+	question, _ := injection.Strip(sanitize.Context(post))
+	fmt.Fprintf(&prompt, "NEW POST:\n%s\n\nEARLIER MESSAGES:\n\n", question)
+	for n, result := range prior {
+		content, _ := injection.Strip(sanitize.Context(result.Message.Content))
+		if len(content) > maxAnswerChars {
+			content = strings.ToValidUTF8(content[:maxAnswerChars], "")
+		}
+		fmt.Fprintf(&prompt, "[%d] %s, %s:\n%s\n\n", n+1, result.User.Username, result.Message.Timestamp.Format("2 January 2006"), content)
+	}
+
+	text, err := s.aiService.Complete(ctx, answerSystemPrompt, prompt.String(), answerMaxTokens)
+	if err != nil {
+		return nil, err
+	}
+	text = strings.TrimSpace(sanitize.Output(text))
+	if text == "" || strings.HasPrefix(strings.ToUpper(text), noAnswer) {
+		return nil, nil
+	}
+
+	s.mu.Lock()
+	s.lastSuggested[p.ChannelID] = time.Now()
+	s.mu.Unlock()
+	return &Suggestion{Answer: text, Sources: prior}, nil
+}
+
+// Cited returns the numbers of the sources the answer cites, or the closest
+// source's when it cites none
+func (s *Suggestion) Cited() []int {
+	var numbers []int
+	seen := make(map[int]bool)
+	for _, match := range citation.FindAllStringSubmatch(s.Answer, -1) {
+		n, err := strconv.Atoi(match[1])
+		if err != nil || n < 1 || n > len(s.Sources) || seen[n] {
+			continue
+		}
+		seen[n] = true
+		numbers = append(numbers, n)
+	}
+	if len(numbers) == 0 {
+		return []int{1}
+	}
+	return numbers
+}
+
+func (s *Service) coolingDown(channelID int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	last, ok := s.lastSuggested[channelID]
+	if ok && time.Since(last) >= s.cfg.Cooldown {
+		delete(s.lastSuggested, channelID)
+		return false
+	}
+	return ok
+}
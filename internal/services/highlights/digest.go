@@ -0,0 +1,203 @@
+package highlights
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/tenant"
+)
+
+const (
+	// Highlights offered to the AI, and picked for a "best of"
+	maxDigestCandidates = 25
+	maxDigestPicks      = 5
+	maxCandidateChars   = 400
+	digestMaxTokens     = 600
+)
+
+const bestOfSystemPrompt = `You write a short, warm "best of the week" post for a Discord server from its most reacted-to messages.
+Pick up to 5 messages that were the most helpful, interesting or funny; the reaction count is a hint, not the ranking.
+For each pick, write one sentence saying why it stood out, without quoting it at length. Refer to people by username.
+Reply with only JSON: {"intro": "one sentence", "picks": [{"n": 1, "why": "..."}]}, where n is the message number.`
+
+// bestOf is the AI's selection for a "best of" post
+type bestOf struct {
+	Intro string       `json:"intro"`
+	Picks []bestOfPick `json:"picks"`
+}
+
+type bestOfPick struct {
+	N   int    `json:"n"` // Message number in the prompt
+	Why string `json:"why"`
+}
+
+// PostDigests is the scheduler job: it posts every guild's "best of" whose
+// local time has come
+func (s *Service) PostDigests(ctx context.Context) error {
+	configs, err := s.repo.ListDigestConfigs(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for n := range configs {
+		cfg := &configs[n]
+		since, due := digestDue(cfg, now)
+		if !due {
+			continue
+		}
+		if err := s.postDigest(ctx, cfg, since); err != nil {
+			log.Printf("❌ Failed to post the best of guild %d: %v", cfg.GuildID, err)
+			continue
+		}
+		if err := s.repo.MarkDigestSent(ctx, cfg.GuildID, now); err != nil {
+			log.Printf("❌ Failed to mark the best of guild %d as posted: %v", cfg.GuildID, err)
+		}
+	}
+	return nil
+}
+
+func (s *Service) postDigest(ctx context.Context, cfg *models.HighlightConfig, since time.Time) error {
+	ctx = tenant.WithGuild(ctx, cfg.GuildID)
+	highlights, err := s.repo.ListHighlights(ctx, cfg.GuildID, since, maxDigestCandidates)
+	if err != nil {
+		return err
+	}
+	// A quiet week gets no post
+	if len(highlights) == 0 {
+		return nil
+	}
+
+	selection, err := s.curate(ctx, highlights)
+	if err != nil {
+		log.Printf("⚠️ Failed to pick the best of guild %d, using the most reacted: %v", cfg.GuildID, err)
+		selection = &bestOf{}
+		for n := range highlights[:min(len(highlights), maxDigestPicks)] {
+			selection.Picks = append(selection.Picks, bestOfPick{N: n + 1})
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString("🏆 **Best of the week**\n")
+	if intro := strings.TrimSpace(selection.Intro); intro != "" {
+		sb.WriteString(intro + "\n")
+	}
+	sb.WriteString("\n")
+	rank := 0
+	for _, pick := range selection.Picks {
+		if rank == maxDigestPicks {
+			break
+		}
+		rank++
+		h := highlights[pick.N-1]
+		fmt.Fprintf(&sb, "**%d.** ", rank)
+		if why := strings.TrimSpace(pick.Why); why != "" {
+			sb.WriteString(why + " ")
+		}
+		fmt.Fprintf(&sb, "[Message](https://discord.com/channels/%d/%d/%d) by <@%d> in <#%d> · %s %d\n",
+			h.GuildID, h.ChannelID, h.MessageID, h.AuthorID, h.ChannelID, DisplayEmoji(cfg.Emoji), h.Reactions)
+	}
+	content := sb.String()
+	if runes := []rune(content); len(runes) > 2000 {
+		content = string(runes[:1999]) + "…"
+	}
+	post := &discordgo.MessageSend{
+		Content:         content,
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	}
+	if s.outbox != nil {
+		err = s.outbox.SendChannel(ctx, "highlight-digest", cfg.GuildID, cfg.ChannelID, post)
+	} else {
+		_, err = s.session.ChannelMessageSendComplex(strconv.FormatInt(cfg.ChannelID, 10), post)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to post best of: %w", err)
+	}
+	log.Printf("🏆 Posted the best of guild %d from %d highlights", cfg.GuildID, len(highlights))
+	return nil
+}
+
+// curate asks the AI for the week's best highlights
+func (s *Service) curate(ctx context.Context, highlights []models.Highlight) (*bestOf, error) {
+	var prompt strings.Builder
+	for n, h := range highlights {
+		content := strings.Join(strings.Fields(h.Content), " ")
+		if runes := []rune(content); len(runes) > maxCandidateChars {
+			content = string(runes[:maxCandidateChars]) + "…"
+		}
+		fmt.Fprintf(&prompt, "%d. %s (%d reactions): %s\n", n+1, s.username(h.AuthorID), h.Reactions, content)
+	}
+
+	reply, err := s.aiService.Complete(ctx, bestOfSystemPrompt, prompt.String(), digestMaxTokens)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pick highlights: %w", err)
+	}
+	start, end := strings.Index(reply, "{"), strings.LastIndex(reply, "}")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("no JSON object in highlight picks: %q", reply)
+	}
+	var selection bestOf
+	if err := json.Unmarshal([]byte(reply[start:end+1]), &selection); err != nil {
+		return nil, fmt.Errorf("failed to parse highlight picks: %w", err)
+	}
+	valid := selection.Picks[:0]
+	for _, pick := range selection.Picks {
+		if pick.N >= 1 && pick.N <= len(highlights) {
+			valid = append(valid, pick)
+		}
+	}
+	if len(valid) == 0 {
+		return nil, fmt.Errorf("no valid picks among %d highlights: %q", len(highlights), reply)
+	}
+	selection.Picks = valid
+	return &selection, nil
+}
+
+// username names a highlight's author for the AI, falling back to "someone"
+func (s *Service) username(userID int64) string {
+	user, err := s.session.User(strconv.FormatInt(userID, 10))
+	if err != nil {
+		return "someone"
+	}
+	return user.Username
+}
+
+// digestDue reports whether a guild's "best of" is due at now, and the start
+// of the week it covers. It is posted at the first check after the local
+// weekday and hour.
+func digestDue(cfg *models.HighlightConfig, now time.Time) (time.Time, bool) {
+	loc, err := time.LoadLocation(cfg.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	local := now.In(loc)
+	week := 7 * 24 * time.Hour
+	scheduled := time.Date(local.Year(), local.Month(), local.Day(), cfg.DigestHour, 0, 0, 0, loc)
+	scheduled = scheduled.AddDate(0, 0, -((int(local.Weekday()) - cfg.DigestWeekday + 7) % 7))
+	if scheduled.After(local) {
+		scheduled = scheduled.Add(-week)
+	}
+
+	// New starboards wait for their first scheduled slot
+	last := cfg.CreatedAt
+	if cfg.LastDigest != nil {
+		last = *cfg.LastDigest
+	}
+	if !last.Before(scheduled) {
+		return time.Time{}, false
+	}
+
+	since := scheduled.Add(-week)
+	if cfg.LastDigest != nil && cfg.LastDigest.After(since) {
+		since = *cfg.LastDigest
+	}
+	return since, true
+}
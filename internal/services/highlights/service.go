@@ -0,0 +1,305 @@
+// Package highlights runs per-guild starboards: messages with enough
+// reactions are copied to a highlights channel, and once a week the AI picks
+// the best of them for a "best of" post.
+package highlights
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"discord-tars/internal/interfaces"
+	"discord-tars/internal/models"
+	"discord-tars/internal/repository"
+	"discord-tars/internal/services/outbox"
+)
+
+const (
+	// DefaultThreshold is how many reactions a message needs by default
+	DefaultThreshold = 5
+
+	maxHighlightChars = 3500
+	highlightColor    = 0xF5C518
+	configTTL         = 5 * time.Minute
+	maxCachedGuilds   = 1000
+)
+
+// customEmoji matches a custom emoji as typed in Discord, e.g. <:party:123>
+var customEmoji = regexp.MustCompile(`^<a?:(\w+):(\d+)>$`)
+
+type Service struct {
+	aiService interfaces.AIService
+	repo      *repository.HighlightRepository
+	session   *discordgo.Session
+	outbox    *outbox.Service
+
+	mu      sync.Mutex
+	configs map[int64]cachedConfig
+	// postMu makes a burst of reactions post a message once
+	postMu sync.Mutex
+}
+
+type cachedConfig struct {
+	cfg      *models.HighlightConfig // nil when the guild has no starboard
+	loadedAt time.Time
+}
+
+func NewService(aiService interfaces.AIService, repo *repository.HighlightRepository, session *discordgo.Session) *Service {
+	return &Service{
+		aiService: aiService,
+		repo:      repo,
+		session:   session,
+		configs:   make(map[int64]cachedConfig),
+	}
+}
+
+// SetOutbox posts the weekly best of through the outbox, retrying failed sends
+func (s *Service) SetOutbox(outbox *outbox.Service) {
+	s.outbox = outbox
+}
+
+// Configure validates and stores a guild's starboard
+func (s *Service) Configure(ctx context.Context, cfg *models.HighlightConfig) error {
+	if cfg.Threshold < 1 {
+		return fmt.Errorf("the threshold must be at least 1 reaction")
+	}
+	if cfg.DigestHour < 0 || cfg.DigestHour > 23 {
+		return fmt.Errorf("hour must be between 0 and 23")
+	}
+	if cfg.DigestWeekday < 0 || cfg.DigestWeekday > 6 {
+		return fmt.Errorf("weekday must be between 0 and 6")
+	}
+	if cfg.Timezone == "" {
+		cfg.Timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(cfg.Timezone); err != nil {
+		return fmt.Errorf("unknown timezone %q", cfg.Timezone)
+	}
+	cfg.Emoji = normalizeEmoji(cfg.Emoji)
+
+	if err := s.repo.SaveConfig(ctx, cfg); err != nil {
+		return err
+	}
+	s.cacheConfig(cfg.GuildID, cfg)
+	return nil
+}
+
+// Disable turns a guild's starboard off, reporting whether it had one
+func (s *Service) Disable(ctx context.Context, guildID int64) (bool, error) {
+	removed, err := s.repo.DeleteConfig(ctx, guildID)
+	if err != nil {
+		return false, err
+	}
+	s.cacheConfig(guildID, nil)
+	return removed, nil
+}
+
+// Config returns a guild's starboard, or nil if it has none
+func (s *Service) Config(ctx context.Context, guildID int64) (*models.HighlightConfig, error) {
+	s.mu.Lock()
+	cached, ok := s.configs[guildID]
+	s.mu.Unlock()
+	if ok && time.Since(cached.loadedAt) < configTTL {
+		return cached.cfg, nil
+	}
+	cfg, err := s.repo.GetConfig(ctx, guildID)
+	if err != nil {
+		return nil, err
+	}
+	s.cacheConfig(guildID, cfg)
+	return cfg, nil
+}
+
+func (s *Service) cacheConfig(guildID int64, cfg *models.HighlightConfig) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.configs) >= maxCachedGuilds {
+		s.configs = make(map[int64]cachedConfig)
+	}
+	s.configs[guildID] = cachedConfig{cfg: cfg, loadedAt: time.Now()}
+}
+
+// HandleReaction copies a message to the highlights channel once it has
+// enough reactions, and keeps the count on its copy up to date
+func (s *Service) HandleReaction(ctx context.Context, r *discordgo.MessageReaction) error {
+	guildID, _ := strconv.ParseInt(r.GuildID, 10, 64)
+	cfg, err := s.Config(ctx, guildID)
+	if err != nil || cfg == nil {
+		return err
+	}
+	// The highlights themselves aren't highlighted again
+	if r.ChannelID == strconv.FormatInt(cfg.ChannelID, 10) || (cfg.Emoji != "" && r.Emoji.APIName() != cfg.Emoji) {
+		return nil
+	}
+	messageID, err := strconv.ParseInt(r.MessageID, 10, 64)
+	if err != nil {
+		return fmt.Errorf("failed to parse message ID: %w", err)
+	}
+
+	s.postMu.Lock()
+	defer s.postMu.Unlock()
+
+	msg, err := s.session.ChannelMessage(r.ChannelID, r.MessageID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch message: %w", err)
+	}
+	if msg.Author == nil || msg.Author.Bot {
+		return nil
+	}
+	count := countReactions(msg.Reactions, cfg.Emoji)
+
+	existing, err := s.repo.GetHighlight(ctx, messageID)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		if existing.Reactions == count {
+			return nil
+		}
+		header := highlightHeader(cfg, count, existing.ChannelID, existing.AuthorID)
+		if _, err := s.session.ChannelMessageEdit(strconv.FormatInt(cfg.ChannelID, 10), strconv.FormatInt(existing.PostID, 10), header); err != nil {
+			log.Printf("⚠️ Failed to update highlight of message %s: %v", r.MessageID, err)
+		}
+		return s.repo.UpdateReactions(ctx, messageID, count)
+	}
+	if count < cfg.Threshold || !s.publicChannel(r.GuildID, r.ChannelID) {
+		return nil
+	}
+
+	authorID, _ := strconv.ParseInt(msg.Author.ID, 10, 64)
+	channelID, _ := strconv.ParseInt(r.ChannelID, 10, 64)
+	post, err := s.session.ChannelMessageSendComplex(strconv.FormatInt(cfg.ChannelID, 10), &discordgo.MessageSend{
+		Content:         highlightHeader(cfg, count, channelID, authorID),
+		Embeds:          []*discordgo.MessageEmbed{highlightEmbed(r.GuildID, msg)},
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to post highlight: %w", err)
+	}
+	postID, _ := strconv.ParseInt(post.ID, 10, 64)
+
+	log.Printf("⭐ Highlighted message %s of guild %s with %d reactions", r.MessageID, r.GuildID, count)
+	return s.repo.SaveHighlight(ctx, &models.Highlight{
+		MessageID: messageID,
+		GuildID:   guildID,
+		ChannelID: channelID,
+		AuthorID:  authorID,
+		Content:   msg.Content,
+		PostID:    postID,
+		Reactions: count,
+	})
+}
+
+// publicChannel tells whether everyone in a guild can read a channel, so its
+// messages can be copied to the highlights channel
+func (s *Service) publicChannel(guildID, channelID string) bool {
+	channel, err := s.session.State.Channel(channelID)
+	if err != nil {
+		if channel, err = s.session.Channel(channelID); err != nil {
+			return false
+		}
+	}
+	if channel.IsThread() {
+		if channel.Type == discordgo.ChannelTypeGuildPrivateThread {
+			return false
+		}
+		if channel, err = s.session.State.Channel(channel.ParentID); err != nil {
+			return false
+		}
+	}
+	if channel.NSFW {
+		return false
+	}
+	guild, err := s.session.State.Guild(guildID)
+	if err != nil {
+		return false
+	}
+
+	// The @everyone role has the guild's ID
+	var permissions int64
+	for _, role := range guild.Roles {
+		if role.ID == guildID {
+			permissions = role.Permissions
+		}
+	}
+	for _, overwrite := range channel.PermissionOverwrites {
+		if overwrite.ID == guildID {
+			permissions = permissions&^overwrite.Deny | overwrite.Allow
+		}
+	}
+	return permissions&discordgo.PermissionViewChannel != 0
+}
+
+// countReactions counts a message's reactions with the given emoji, or all
+// of them, leaving out the bot's own
+func countReactions(reactions []*discordgo.MessageReactions, emoji string) int {
+	count := 0
+	for _, reaction := range reactions {
+		if reaction.Emoji == nil || (emoji != "" && reaction.Emoji.APIName() != emoji) {
+			continue
+		}
+		count += reaction.Count
+		if reaction.Me {
+			count--
+		}
+	}
+	return count
+}
+
+func highlightHeader(cfg *models.HighlightConfig, count int, channelID, authorID int64) string {
+	return fmt.Sprintf("%s **%d** · <#%d> · <@%d>", DisplayEmoji(cfg.Emoji), count, channelID, authorID)
+}
+
+// highlightEmbed copies a message; messages fetched from the API have no
+// guild ID of their own
+func highlightEmbed(guildID string, msg *discordgo.Message) *discordgo.MessageEmbed {
+	description := msg.Content
+	if runes := []rune(description); len(runes) > maxHighlightChars {
+		description = string(runes[:maxHighlightChars]) + "…"
+	}
+	embed := &discordgo.MessageEmbed{
+		Author: &discordgo.MessageEmbedAuthor{
+			Name:    msg.Author.Username,
+			IconURL: msg.Author.AvatarURL("64"),
+		},
+		Description: strings.TrimSpace(description + fmt.Sprintf("\n\n[Jump to message](https://discord.com/channels/%s/%s/%s)", guildID, msg.ChannelID, msg.ID)),
+		Timestamp:   msg.Timestamp.Format(time.RFC3339),
+		Color:       highlightColor,
+	}
+	for _, attachment := range msg.Attachments {
+		if strings.HasPrefix(attachment.ContentType, "image/") {
+			embed.Image = &discordgo.MessageEmbedImage{URL: attachment.URL}
+			break
+		}
+	}
+	return embed
+}
+
+// normalizeEmoji turns an emoji as typed in Discord into the form reactions
+// report: unicode emoji as is, custom ones as name:id
+func normalizeEmoji(emoji string) string {
+	emoji = strings.TrimSpace(emoji)
+	if match := customEmoji.FindStringSubmatch(emoji); match != nil {
+		return match[1] + ":" + match[2]
+	}
+	return emoji
+}
+
+// DisplayEmoji shows a configured emoji in a message
+func DisplayEmoji(emoji string) string {
+	switch {
+	case emoji == "":
+		return "⭐"
+	case strings.Contains(emoji, ":"):
+		return "<:" + emoji + ">"
+	default:
+		return emoji
+	}
+}
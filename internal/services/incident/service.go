@@ -0,0 +1,324 @@
+// Package incident runs outage mode: each incident gets a thread started from
+// a pinned status message that is kept up to date, and a postmortem draft is
+// written from the thread when it is resolved.
+package incident
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/repository"
+	"discord-tars/internal/sanitize"
+	"discord-tars/internal/services/agent"
+	"discord-tars/internal/services/summarize"
+	"discord-tars/internal/tenant"
+)
+
+const (
+	MaxTitleLength  = 100
+	MaxUpdateLength = 1000
+
+	// timelineEntries is how many of the latest updates the status message shows
+	timelineEntries   = 8
+	threadArchiveMins = 7 * 24 * 60
+	maxStatusBytes    = 2000
+)
+
+var (
+	ErrNotIncident     = errors.New("this isn't an incident thread")
+	ErrResolved        = errors.New("this incident is already resolved")
+	ErrInvalidSeverity = errors.New("unknown severity")
+	ErrInvalidStatus   = errors.New("unknown status")
+)
+
+var severityLabels = map[string]string{
+	models.IncidentSev1: "🔴 SEV1 (critical)",
+	models.IncidentSev2: "🟠 SEV2 (major)",
+	models.IncidentSev3: "🟡 SEV3 (minor)",
+}
+
+var statusLabels = map[string]string{
+	models.IncidentInvestigating: "🔍 Investigating",
+	models.IncidentIdentified:    "🎯 Identified",
+	models.IncidentMonitoring:    "👀 Monitoring",
+	models.IncidentResolved:      "✅ Resolved",
+}
+
+type Service struct {
+	repo       *repository.IncidentRepository
+	summarizer *summarize.Service
+	session    *discordgo.Session
+}
+
+func NewService(repo *repository.IncidentRepository, summarizer *summarize.Service, session *discordgo.Session) *Service {
+	return &Service{repo: repo, summarizer: summarizer, session: session}
+}
+
+// Start posts and pins the status message of a new incident in a channel and
+// starts its thread from it
+func (s *Service) Start(ctx context.Context, guildID, channelID, startedBy int64, title, severity, description string) (*models.Incident, error) {
+	if _, ok := severityLabels[severity]; !ok {
+		return nil, ErrInvalidSeverity
+	}
+	incident := &models.Incident{
+		GuildID:   guildID,
+		ChannelID: channelID,
+		Title:     strings.TrimSpace(title),
+		Severity:  severity,
+		Status:    models.IncidentInvestigating,
+		StartedBy: startedBy,
+		CreatedAt: time.Now(),
+	}
+	first := &models.IncidentUpdate{
+		AuthorID:  startedBy,
+		Status:    models.IncidentInvestigating,
+		Text:      strings.TrimSpace(description),
+		CreatedAt: incident.CreatedAt,
+	}
+	if first.Text == "" {
+		first.Text = "Incident declared."
+	}
+
+	channel := strconv.FormatInt(channelID, 10)
+	msg, err := s.session.ChannelMessageSendComplex(channel, &discordgo.MessageSend{
+		Content:         renderStatus(incident, []models.IncidentUpdate{*first}),
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to post incident status: %w", err)
+	}
+	incident.StatusMessageID, _ = strconv.ParseInt(msg.ID, 10, 64)
+
+	thread, err := s.session.MessageThreadStartComplex(channel, msg.ID, &discordgo.ThreadStart{
+		Name:                threadName(incident),
+		AutoArchiveDuration: threadArchiveMins,
+	})
+	if err != nil {
+		s.discard(channel, msg.ID, "")
+		return nil, fmt.Errorf("failed to start incident thread: %w", err)
+	}
+	incident.ThreadID, _ = strconv.ParseInt(thread.ID, 10, 64)
+
+	if err := s.repo.CreateIncident(ctx, incident, first); err != nil {
+		s.discard(channel, msg.ID, thread.ID)
+		return nil, err
+	}
+
+	if err := s.session.ChannelMessagePin(channel, msg.ID); err != nil {
+		log.Printf("⚠️ Failed to pin status message of incident %d: %v", incident.ID, err)
+	}
+	if err := s.session.ThreadMemberAdd(thread.ID, strconv.FormatInt(startedBy, 10)); err != nil {
+		log.Printf("⚠️ Failed to add incident %d starter to its thread: %v", incident.ID, err)
+	}
+
+	log.Printf("🚨 Started %s incident %d in channel %d of guild %d", severity, incident.ID, channelID, guildID)
+	return incident, nil
+}
+
+// discard deletes the status message and thread of an incident that couldn't
+// be started
+func (s *Service) discard(channelID, messageID, threadID string) {
+	if threadID != "" {
+		if _, err := s.session.ChannelDelete(threadID); err != nil {
+			log.Printf("⚠️ Failed to delete thread of unsaved incident: %v", err)
+		}
+	}
+	if err := s.session.ChannelMessageDelete(channelID, messageID); err != nil {
+		log.Printf("⚠️ Failed to delete status message of unsaved incident: %v", err)
+	}
+}
+
+// Update adds a timeline entry to the incident discussed in a thread and
+// refreshes its status message; an empty status keeps the current one
+func (s *Service) Update(ctx context.Context, threadID, authorID int64, status, text string) (*models.Incident, error) {
+	incident, err := s.Open(ctx, threadID)
+	if err != nil {
+		return nil, err
+	}
+	if status == "" {
+		status = incident.Status
+	}
+	if _, ok := statusLabels[status]; !ok || status == models.IncidentResolved {
+		return nil, ErrInvalidStatus
+	}
+
+	if err := s.repo.AddUpdate(ctx, &models.IncidentUpdate{
+		IncidentID: incident.ID,
+		AuthorID:   authorID,
+		Status:     status,
+		Text:       strings.TrimSpace(text),
+	}); err != nil {
+		return nil, err
+	}
+	incident.Status = status
+	s.refreshStatus(ctx, incident)
+	return incident, nil
+}
+
+// Resolve closes the incident discussed in a thread and refreshes its status
+// message
+func (s *Service) Resolve(ctx context.Context, threadID, authorID int64, text string) (*models.Incident, error) {
+	incident, err := s.Open(ctx, threadID)
+	if err != nil {
+		return nil, err
+	}
+	if text = strings.TrimSpace(text); text == "" {
+		text = "Incident resolved."
+	}
+
+	now := time.Now()
+	claimed, err := s.repo.Resolve(ctx, &models.IncidentUpdate{
+		IncidentID: incident.ID,
+		AuthorID:   authorID,
+		Status:     models.IncidentResolved,
+		Text:       text,
+		CreatedAt:  now,
+	}, now)
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		return nil, ErrResolved
+	}
+	incident.Status, incident.ResolvedBy, incident.ResolvedAt = models.IncidentResolved, authorID, &now
+	s.refreshStatus(ctx, incident)
+
+	log.Printf("✅ Resolved incident %d after %s", incident.ID, agent.FormatDuration(now.Sub(incident.CreatedAt)))
+	return incident, nil
+}
+
+// Postmortem drafts and stores the postmortem of a resolved incident from the
+// messages of its thread
+func (s *Service) Postmortem(ctx context.Context, incident *models.Incident, messages []models.SearchResult) (string, error) {
+	ctx = tenant.WithGuild(ctx, incident.GuildID)
+	updates, err := s.repo.ListUpdates(ctx, incident.ID)
+	if err != nil {
+		return "", err
+	}
+
+	draft, err := s.summarizer.DraftPostmortem(ctx, describe(incident, updates), messages)
+	if err != nil {
+		return "", err
+	}
+	if err := s.repo.SetPostmortem(ctx, incident.ID, draft); err != nil {
+		log.Printf("⚠️ Failed to store postmortem of incident %d: %v", incident.ID, err)
+	}
+	return draft, nil
+}
+
+// Open returns the unresolved incident discussed in a thread
+func (s *Service) Open(ctx context.Context, threadID int64) (*models.Incident, error) {
+	incident, err := s.repo.GetByThread(ctx, threadID)
+	if err != nil {
+		return nil, err
+	}
+	if incident == nil {
+		return nil, ErrNotIncident
+	}
+	if incident.ResolvedAt != nil {
+		return nil, ErrResolved
+	}
+	return incident, nil
+}
+
+// refreshStatus re-renders the pinned status message and renames the thread
+// once the incident is resolved
+func (s *Service) refreshStatus(ctx context.Context, incident *models.Incident) {
+	updates, err := s.repo.ListUpdates(ctx, incident.ID)
+	if err != nil {
+		log.Printf("⚠️ Failed to load timeline of incident %d: %v", incident.ID, err)
+		return
+	}
+
+	content := renderStatus(incident, updates)
+	if _, err := s.session.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		Channel:         strconv.FormatInt(incident.ChannelID, 10),
+		ID:              strconv.FormatInt(incident.StatusMessageID, 10),
+		Content:         &content,
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	}); err != nil {
+		log.Printf("⚠️ Failed to refresh status message of incident %d: %v", incident.ID, err)
+	}
+
+	if incident.ResolvedAt != nil {
+		name := threadName(incident)
+		if _, err := s.session.ChannelEdit(strconv.FormatInt(incident.ThreadID, 10), &discordgo.ChannelEdit{Name: name}); err != nil {
+			log.Printf("⚠️ Failed to rename thread of incident %d: %v", incident.ID, err)
+		}
+	}
+}
+
+// renderStatus writes the status message: the incident's state followed by
+// its latest timeline entries
+func renderStatus(incident *models.Incident, updates []models.IncidentUpdate) string {
+	var sb strings.Builder
+	if incident.ResolvedAt != nil {
+		fmt.Fprintf(&sb, "✅ **Resolved incident: %s**\n", incident.Title)
+	} else {
+		fmt.Fprintf(&sb, "🚨 **Incident: %s**\n", incident.Title)
+	}
+	fmt.Fprintf(&sb, "**Severity:** %s · **Status:** %s\n", severityLabels[incident.Severity], statusLabels[incident.Status])
+	fmt.Fprintf(&sb, "**Started:** <t:%d:f> by <@%d>", incident.CreatedAt.Unix(), incident.StartedBy)
+	if incident.ResolvedAt != nil {
+		fmt.Fprintf(&sb, " · **Resolved:** <t:%d:f> by <@%d> (%s)",
+			incident.ResolvedAt.Unix(), incident.ResolvedBy, agent.FormatDuration(incident.ResolvedAt.Sub(incident.CreatedAt)))
+	}
+	sb.WriteString("\n\n**Timeline**\n")
+
+	if len(updates) > timelineEntries {
+		fmt.Fprintf(&sb, "*… %d earlier updates in the thread*\n", len(updates)-timelineEntries)
+		updates = updates[len(updates)-timelineEntries:]
+	}
+	header := sb.String()
+	lines := make([]string, 0, len(updates))
+	for _, update := range updates {
+		lines = append(lines, fmt.Sprintf("• <t:%d:t> %s — %s (<@%d>)",
+			update.CreatedAt.Unix(), statusLabels[update.Status], strings.Join(strings.Fields(update.Text), " "), update.AuthorID))
+	}
+	// Older entries give way first when the message would be too long
+	for len(lines) > 1 && len(header)+len(strings.Join(lines, "\n")) > maxStatusBytes {
+		lines = lines[1:]
+	}
+	content := header + strings.Join(lines, "\n")
+	if len(content) > maxStatusBytes {
+		content = strings.ToValidUTF8(content[:maxStatusBytes-len("…")], "") + "…"
+	}
+	return content
+}
+
+// describe lists an incident's details and timeline for the postmortem
+func describe(incident *models.Incident, updates []models.IncidentUpdate) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Title: %s\nSeverity: %s\n", sanitize.Context(incident.Title), severityLabels[incident.Severity])
+	fmt.Fprintf(&sb, "Started: %s UTC\n", incident.CreatedAt.UTC().Format("2006-01-02 15:04"))
+	if incident.ResolvedAt != nil {
+		fmt.Fprintf(&sb, "Resolved: %s UTC (after %s)\n",
+			incident.ResolvedAt.UTC().Format("2006-01-02 15:04"), agent.FormatDuration(incident.ResolvedAt.Sub(incident.CreatedAt)))
+	}
+	sb.WriteString("Status updates:\n")
+	for _, update := range updates {
+		fmt.Fprintf(&sb, "- %s UTC [%s] %s\n", update.CreatedAt.UTC().Format("15:04"), update.Status, sanitize.Context(strings.Join(strings.Fields(update.Text), " ")))
+	}
+	return sb.String()
+}
+
+// threadName names an incident's thread after its severity, title and state
+func threadName(incident *models.Incident) string {
+	prefix := "🚨 " + strings.ToUpper(incident.Severity) + " "
+	if incident.ResolvedAt != nil {
+		prefix = "✅ "
+	}
+	name := []rune(prefix + incident.Title)
+	if len(name) > 100 {
+		name = append(name[:99], '…')
+	}
+	return string(name)
+}
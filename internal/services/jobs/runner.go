@@ -0,0 +1,421 @@
+// Package jobs runs background work from the jobs table: periodic jobs the
+// scheduler enqueues, one-off jobs such as retried embeddings, and tracked
+// runs such as reindexes. Several workers can share the table; failed jobs
+// are retried with backoff, and admins can cancel or retry them.
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/repository"
+	"discord-tars/internal/server"
+)
+
+const (
+	DefaultMaxAttempts = 3
+	DefaultTimeout     = 10 * time.Minute
+
+	// Delay before the first retry, doubled for each later one
+	retryBase     = 30 * time.Second
+	maxRetryDelay = 30 * time.Minute
+	// A running job this much past its timeout belongs to a stopped worker
+	staleGrace = time.Minute
+)
+
+// errCancelled interrupts a job an admin cancelled
+var errCancelled = errors.New("job cancelled")
+
+// Func runs one job; payload is the JSON it was enqueued with, empty for
+// periodic jobs
+type Func func(ctx context.Context, payload []byte) error
+
+// Options controls how a kind of job runs
+type Options struct {
+	Concurrency int           // Jobs of the kind this worker runs at once; defaults to 1
+	MaxAttempts int           // Runs before a job is marked failed; defaults to DefaultMaxAttempts
+	Timeout     time.Duration // Per run; defaults to DefaultTimeout
+}
+
+type kind struct {
+	name string
+	opts Options
+	run  Func
+}
+
+// Runner claims and runs the jobs of the kinds registered with it
+type Runner struct {
+	repo         *repository.JobRepository
+	worker       string
+	pollInterval time.Duration
+
+	mu      sync.Mutex
+	kinds   map[string]*kind
+	running map[int64]context.CancelCauseFunc // Jobs this runner is running, to stop cancelled ones
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	started bool
+}
+
+// NewRunner creates a runner; worker names it in the jobs table, and
+// pollInterval is how often an idle kind looks for due jobs
+func NewRunner(repo *repository.JobRepository, worker string, pollInterval time.Duration) *Runner {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Runner{
+		repo:         repo,
+		worker:       worker,
+		pollInterval: pollInterval,
+		kinds:        make(map[string]*kind),
+		running:      make(map[int64]context.CancelCauseFunc),
+		ctx:          ctx,
+		cancel:       cancel,
+	}
+}
+
+// Register adds a kind of job. Kinds registered after Start are ignored.
+func (r *Runner) Register(name string, opts Options, run Func) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.started {
+		log.Printf("⚠️ Job runner already started, ignoring job kind %s", name)
+		return
+	}
+	opts.Concurrency = max(opts.Concurrency, 1)
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = DefaultMaxAttempts
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	r.kinds[name] = &kind{name: name, opts: opts, run: run}
+	log.Printf("🧰 Registered job kind %s (%d at once, %d attempts)", name, opts.Concurrency, opts.MaxAttempts)
+}
+
+// Enqueue adds a job of a registered kind for a guild, or the whole instance
+// when guildID is zero; payload is encoded as JSON
+func (r *Runner) Enqueue(ctx context.Context, name string, guildID int64, payload interface{}) error {
+	_, err := r.EnqueueAt(ctx, name, guildID, payload, time.Time{})
+	return err
+}
+
+// EnqueueAt is Enqueue for a job that must not run before runAt, such as a
+// reminder; a zero runAt runs it right away. It returns the job's ID.
+func (r *Runner) EnqueueAt(ctx context.Context, name string, guildID int64, payload interface{}, runAt time.Time) (int64, error) {
+	k, err := r.kind(name)
+	if err != nil {
+		return 0, err
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode %s job: %w", name, err)
+	}
+	job := &models.Job{Kind: name, GuildID: guildID, Payload: string(data), MaxAttempts: k.opts.MaxAttempts, RunAt: runAt}
+	if err := r.repo.Enqueue(ctx, job); err != nil {
+		return 0, err
+	}
+	return job.ID, nil
+}
+
+// Run records work started right away, e.g. a reindex an admin asked for, as
+// a running job and runs it under ctx, so it shows up in /jobs and can be
+// cancelled there; cancelling ctx marks it cancelled too. A failed run is not
+// retried automatically, but can be retried by hand when a runner has the
+// kind registered.
+func (r *Runner) Run(ctx context.Context, name string, guildID int64, payload interface{}, run func(ctx context.Context) error) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s job: %w", name, err)
+	}
+	job := &models.Job{Kind: name, GuildID: guildID, Payload: string(data), MaxAttempts: 1}
+	if err := r.repo.StartJob(ctx, job, r.worker); err != nil {
+		// Tracking is best effort; the work itself still runs
+		log.Printf("⚠️ %v", err)
+		return run(ctx)
+	}
+
+	ctx, cancel := r.track(ctx, job.ID)
+	defer cancel()
+	start := time.Now()
+	err = run(ctx)
+	outcome := err
+	if err != nil && (errors.Is(err, context.Canceled) || errors.Is(context.Cause(ctx), errCancelled)) {
+		outcome = errCancelled
+	}
+	r.finish(name, job, start, outcome, nil)
+	return err
+}
+
+// Periodic returns a scheduler job that enqueues a run of a kind, unless one
+// is already waiting or running
+func (r *Runner) Periodic(name string) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		k, err := r.kind(name)
+		if err != nil {
+			return err
+		}
+		_, err = r.repo.EnqueueOnce(ctx, &models.Job{Kind: name, MaxAttempts: k.opts.MaxAttempts})
+		return err
+	}
+}
+
+func (r *Runner) kind(name string) (*kind, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	k, ok := r.kinds[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown job kind %q", name)
+	}
+	return k, nil
+}
+
+// Start launches Concurrency goroutines per kind
+func (r *Runner) Start() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.started {
+		return
+	}
+	r.started = true
+
+	for _, k := range r.kinds {
+		for slot := range k.opts.Concurrency {
+			r.wg.Add(1)
+			go r.loop(k, slot)
+		}
+	}
+	r.wg.Add(1)
+	go r.watchCancellations()
+	log.Printf("✅ Job runner %s started with %d job kinds", r.worker, len(r.kinds))
+}
+
+// Stop interrupts running jobs, which go back to the queue, and waits for
+// them to return
+func (r *Runner) Stop() {
+	r.cancel()
+	r.wg.Wait()
+	log.Println("👋 Job runner stopped")
+}
+
+func (r *Runner) loop(k *kind, slot int) {
+	defer r.wg.Done()
+
+	for r.ctx.Err() == nil {
+		// One slot per kind takes over jobs of workers that stopped mid-job
+		if slot == 0 {
+			requeued, err := r.repo.RequeueStale(r.ctx, k.name, time.Now().Add(-k.opts.Timeout-staleGrace))
+			if err != nil {
+				log.Printf("⚠️ %v", err)
+			} else if requeued > 0 {
+				log.Printf("🔁 Requeued %d stale %s jobs", requeued, k.name)
+			}
+		}
+
+		job, err := r.repo.Claim(r.ctx, k.name, fmt.Sprintf("%s/%d", r.worker, slot))
+		if err != nil && r.ctx.Err() == nil {
+			log.Printf("❌ %v", err)
+		}
+		if job == nil {
+			select {
+			case <-time.After(r.pollInterval):
+			case <-r.ctx.Done():
+			}
+			continue
+		}
+		r.runOnce(k, job)
+	}
+}
+
+func (r *Runner) runOnce(k *kind, job *models.Job) {
+	ctx, cancel := context.WithTimeout(r.ctx, k.opts.Timeout)
+	defer cancel()
+	ctx, untrack := r.track(ctx, job.ID)
+	defer untrack()
+
+	start := time.Now()
+	err := r.call(ctx, k, job)
+	if err != nil && errors.Is(context.Cause(ctx), errCancelled) {
+		err = errCancelled
+	}
+	r.finish(k.name, job, start, err, r.ctx.Err())
+}
+
+// finish records how a run ended; shutdown is set when the runner is stopping
+func (r *Runner) finish(name string, job *models.Job, start time.Time, err, shutdown error) {
+	// Updates must land even while shutting down
+	saveCtx, saveCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer saveCancel()
+
+	switch {
+	case err == nil:
+		if err := r.repo.Complete(saveCtx, job.ID); err != nil {
+			log.Printf("❌ Failed to mark %s job %d as done: %v", name, job.ID, err)
+		}
+		log.Printf("✅ Job %s %d completed in %s", name, job.ID, time.Since(start))
+	case errors.Is(err, errCancelled):
+		// A no-op when an admin cancelled the job
+		if err := r.repo.MarkCancelled(saveCtx, job.ID); err != nil {
+			log.Printf("❌ Failed to mark %s job %d as cancelled: %v", name, job.ID, err)
+		}
+		log.Printf("🛑 Job %s %d cancelled after %s", name, job.ID, time.Since(start))
+	case errors.Is(err, context.Canceled) && shutdown != nil:
+		if err := r.repo.Release(saveCtx, job.ID); err != nil {
+			log.Printf("❌ Failed to release %s job %d: %v", name, job.ID, err)
+		}
+	default:
+		var retryAt *time.Time
+		if job.Attempts < job.MaxAttempts {
+			at := time.Now().Add(retryDelay(job.Attempts))
+			retryAt = &at
+		}
+		if err := r.repo.Fail(saveCtx, job.ID, err, retryAt); err != nil {
+			log.Printf("❌ Failed to record failure of %s job %d: %v", name, job.ID, err)
+		}
+		if retryAt != nil {
+			log.Printf("⚠️ Job %s %d failed (attempt %d/%d), retrying at %s: %v", name, job.ID, job.Attempts, job.MaxAttempts, retryAt.Format(time.RFC3339), err)
+		} else {
+			log.Printf("❌ Job %s %d failed for good after %d attempts: %v", name, job.ID, job.Attempts, err)
+		}
+	}
+}
+
+// track lets the cancellation watcher interrupt a running job
+func (r *Runner) track(ctx context.Context, jobID int64) (context.Context, func()) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	r.mu.Lock()
+	r.running[jobID] = cancel
+	r.mu.Unlock()
+	return ctx, func() {
+		r.mu.Lock()
+		delete(r.running, jobID)
+		r.mu.Unlock()
+		cancel(nil)
+	}
+}
+
+// watchCancellations interrupts the jobs this runner is running once an
+// admin cancels them, checking every poll interval
+func (r *Runner) watchCancellations() {
+	defer r.wg.Done()
+
+	ticker := time.NewTicker(r.pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-r.ctx.Done():
+			return
+		}
+
+		r.mu.Lock()
+		ids := make([]int64, 0, len(r.running))
+		for id := range r.running {
+			ids = append(ids, id)
+		}
+		r.mu.Unlock()
+
+		cancelled, err := r.repo.CancelledAmong(r.ctx, ids)
+		if err != nil {
+			if r.ctx.Err() == nil {
+				log.Printf("⚠️ %v", err)
+			}
+			continue
+		}
+		r.mu.Lock()
+		for _, id := range cancelled {
+			if cancel, ok := r.running[id]; ok {
+				cancel(errCancelled)
+			}
+		}
+		r.mu.Unlock()
+	}
+}
+
+// call runs a job, turning a panic into an error
+func (r *Runner) call(ctx context.Context, k *kind, job *models.Job) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("job panicked: %v", p)
+		}
+	}()
+	return k.run(ctx, []byte(job.Payload))
+}
+
+// GuildJobs returns a guild's most recently updated jobs, optionally of one status
+func (r *Runner) GuildJobs(ctx context.Context, guildID int64, status string, limit int) ([]models.Job, error) {
+	return r.repo.ListJobs(ctx, guildID, status, limit)
+}
+
+// GuildJob returns one of a guild's jobs, or nil if it has no such job
+func (r *Runner) GuildJob(ctx context.Context, guildID, jobID int64) (*models.Job, error) {
+	return r.repo.GetGuildJob(ctx, guildID, jobID)
+}
+
+// Cancel stops a guild's queued or running job, reporting whether it was
+// either; whichever runner is running it interrupts it within a poll interval
+func (r *Runner) Cancel(ctx context.Context, guildID, jobID int64) (bool, error) {
+	return r.repo.Cancel(ctx, guildID, jobID)
+}
+
+// Retry queues a guild's failed or cancelled job again, reporting whether it
+// was either
+func (r *Runner) Retry(ctx context.Context, guildID, jobID int64) (bool, error) {
+	return r.repo.Retry(ctx, guildID, jobID)
+}
+
+// retryDelay is the backoff after a job's nth failed attempt
+func retryDelay(attempt int) time.Duration {
+	delay := retryBase
+	for n := 1; n < attempt && delay < maxRetryDelay; n++ {
+		delay *= 2
+	}
+	return min(delay, maxRetryDelay)
+}
+
+// HandleStats serves job counts by kind and status, and the latest jobs
+// (?status= filters them); ?guild_id= narrows both to one guild
+func (r *Runner) HandleStats(w http.ResponseWriter, req *http.Request) {
+	var guildID int64
+	if raw := req.URL.Query().Get("guild_id"); raw != "" {
+		id, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || id <= 0 {
+			server.WriteError(w, http.StatusBadRequest, "guild_id must be a Discord ID")
+			return
+		}
+		guildID = id
+	}
+	limit := 50
+	if raw := req.URL.Query().Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > 500 {
+			server.WriteError(w, http.StatusBadRequest, "limit must be between 1 and 500")
+			return
+		}
+		limit = n
+	}
+	stats, err := r.repo.Stats(req.Context(), guildID)
+	if err != nil {
+		server.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	recent, err := r.repo.ListJobs(req.Context(), guildID, req.URL.Query().Get("status"), limit)
+	if err != nil {
+		server.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	server.WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"worker": r.worker,
+		"counts": stats,
+		"recent": recent,
+	})
+}
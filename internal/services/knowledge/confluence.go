@@ -0,0 +1,150 @@
+package knowledge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"discord-tars/internal/models"
+)
+
+const (
+	confluencePageSize = 50
+	// confluenceMaxPages bounds how many pages of one space are synced
+	confluenceMaxPages = 1000
+)
+
+type confluenceConnector struct {
+	baseURL  string
+	email    string
+	token    string
+	spaceKey string
+	pageIDs  []string
+	client   *http.Client
+}
+
+func newConfluenceConnector(source *models.KnowledgeSource) (Connector, error) {
+	if source.BaseURL == "" {
+		return nil, fmt.Errorf("confluence sources need a base URL")
+	}
+	if source.SpaceKey == "" && len(source.PageIDs) == 0 {
+		return nil, fmt.Errorf("confluence sources need a space key or page IDs")
+	}
+	return &confluenceConnector{
+		baseURL:  strings.TrimRight(source.BaseURL, "/"),
+		email:    source.Email,
+		token:    source.APIToken,
+		spaceKey: source.SpaceKey,
+		pageIDs:  source.PageIDs,
+		client:   &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+type confluenceContent struct {
+	ID      string `json:"id"`
+	Title   string `json:"title"`
+	Version struct {
+		When time.Time `json:"when"`
+	} `json:"version"`
+	Body struct {
+		Storage struct {
+			Value string `json:"value"`
+		} `json:"storage"`
+	} `json:"body"`
+	Links struct {
+		WebUI string `json:"webui"`
+	} `json:"_links"`
+}
+
+func (c *confluenceConnector) ref(content confluenceContent) PageRef {
+	return PageRef{
+		ID:        content.ID,
+		Title:     content.Title,
+		URL:       c.baseURL + content.Links.WebUI,
+		UpdatedAt: content.Version.When,
+	}
+}
+
+func (c *confluenceConnector) ListPages(ctx context.Context) ([]PageRef, error) {
+	seen := make(map[string]bool)
+	var pages []PageRef
+
+	if c.spaceKey != "" {
+		for start := 0; start < confluenceMaxPages; start += confluencePageSize {
+			query := url.Values{
+				"spaceKey": {c.spaceKey},
+				"type":     {"page"},
+				"expand":   {"version"},
+				"limit":    {fmt.Sprint(confluencePageSize)},
+				"start":    {fmt.Sprint(start)},
+			}
+			var resp struct {
+				Results []confluenceContent `json:"results"`
+				Size    int                 `json:"size"`
+			}
+			if err := c.get(ctx, "/rest/api/content?"+query.Encode(), &resp); err != nil {
+				return nil, fmt.Errorf("failed to list confluence space %s: %w", c.spaceKey, err)
+			}
+			for _, content := range resp.Results {
+				if !seen[content.ID] {
+					seen[content.ID] = true
+					pages = append(pages, c.ref(content))
+				}
+			}
+			if resp.Size < confluencePageSize {
+				break
+			}
+		}
+	}
+
+	for _, id := range c.pageIDs {
+		if seen[id] {
+			continue
+		}
+		var content confluenceContent
+		if err := c.get(ctx, "/rest/api/content/"+url.PathEscape(id)+"?expand=version", &content); err != nil {
+			return nil, fmt.Errorf("failed to fetch confluence page %s: %w", id, err)
+		}
+		seen[id] = true
+		pages = append(pages, c.ref(content))
+	}
+	return pages, nil
+}
+
+func (c *confluenceConnector) FetchContent(ctx context.Context, page PageRef) (string, error) {
+	var content confluenceContent
+	if err := c.get(ctx, "/rest/api/content/"+url.PathEscape(page.ID)+"?expand=body.storage", &content); err != nil {
+		return "", fmt.Errorf("failed to fetch confluence page %s: %w", page.ID, err)
+	}
+	return htmlToText(content.Body.Storage.Value), nil
+}
+
+func (c *confluenceConnector) get(ctx context.Context, path string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.email != "" {
+		req.SetBasicAuth(c.email, c.token)
+	} else {
+		req.Header.Set("Authorization", "Bearer "+c.token) // Data Center personal access token
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("confluence returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(snippet)))
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,46 @@
+package knowledge
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"discord-tars/internal/models"
+)
+
+// PageRef identifies a page and its last modification, so unchanged pages can be skipped
+type PageRef struct {
+	ID        string
+	Title     string
+	URL       string
+	UpdatedAt time.Time
+}
+
+// Connector reads pages from an external knowledge base
+type Connector interface {
+	// ListPages returns the selected pages without their content
+	ListPages(ctx context.Context) ([]PageRef, error)
+	// FetchContent returns a page's plain text
+	FetchContent(ctx context.Context, page PageRef) (string, error)
+}
+
+// ConnectorFactory builds a connector for a configured source
+type ConnectorFactory func(source *models.KnowledgeSource) (Connector, error)
+
+var connectors = map[string]ConnectorFactory{
+	models.KnowledgeNotion:     newNotionConnector,
+	models.KnowledgeConfluence: newConfluenceConnector,
+}
+
+// RegisterConnector adds or replaces the connector used for a provider
+func RegisterConnector(provider string, factory ConnectorFactory) {
+	connectors[provider] = factory
+}
+
+func connectorFor(source *models.KnowledgeSource) (Connector, error) {
+	factory, ok := connectors[source.Provider]
+	if !ok {
+		return nil, fmt.Errorf("unknown knowledge provider %q", source.Provider)
+	}
+	return factory(source)
+}
@@ -0,0 +1,206 @@
+package knowledge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"discord-tars/internal/models"
+)
+
+const (
+	notionAPIURL  = "https://api.notion.com/v1"
+	notionVersion = "2022-06-28"
+	// notionMaxDepth bounds how deep nested blocks (toggles, lists) are read
+	notionMaxDepth = 3
+)
+
+var notionIDPattern = regexp.MustCompile(`([0-9a-fA-F]{8}-?[0-9a-fA-F]{4}-?[0-9a-fA-F]{4}-?[0-9a-fA-F]{4}-?[0-9a-fA-F]{12})$`)
+
+type notionConnector struct {
+	token   string
+	pageIDs []string
+	client  *http.Client
+}
+
+func newNotionConnector(source *models.KnowledgeSource) (Connector, error) {
+	if len(source.PageIDs) == 0 {
+		return nil, fmt.Errorf("notion sources need at least one page")
+	}
+	return &notionConnector{
+		token:   source.APIToken,
+		pageIDs: source.PageIDs,
+		client:  &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// NotionPageID extracts the page ID from a Notion page URL or raw ID
+func NotionPageID(value string) (string, bool) {
+	value = strings.TrimSpace(value)
+	if i := strings.IndexAny(value, "?#"); i >= 0 {
+		value = value[:i]
+	}
+	match := notionIDPattern.FindStringSubmatch(value)
+	if match == nil {
+		return "", false
+	}
+	return strings.ReplaceAll(strings.ToLower(match[1]), "-", ""), true
+}
+
+type notionRichText []struct {
+	PlainText string `json:"plain_text"`
+}
+
+func (t notionRichText) String() string {
+	var sb strings.Builder
+	for _, part := range t {
+		sb.WriteString(part.PlainText)
+	}
+	return sb.String()
+}
+
+func (c *notionConnector) ListPages(ctx context.Context) ([]PageRef, error) {
+	var pages []PageRef
+	for _, id := range c.pageIDs {
+		var page struct {
+			ID             string    `json:"id"`
+			URL            string    `json:"url"`
+			LastEditedTime time.Time `json:"last_edited_time"`
+			Properties     map[string]struct {
+				Type  string         `json:"type"`
+				Title notionRichText `json:"title"`
+			} `json:"properties"`
+		}
+		if err := c.get(ctx, "/pages/"+id, &page); err != nil {
+			return nil, fmt.Errorf("failed to fetch notion page %s: %w", id, err)
+		}
+
+		ref := PageRef{ID: id, URL: page.URL, UpdatedAt: page.LastEditedTime}
+		for _, prop := range page.Properties {
+			if prop.Type == "title" {
+				ref.Title = prop.Title.String()
+				break
+			}
+		}
+		pages = append(pages, ref)
+	}
+	return pages, nil
+}
+
+func (c *notionConnector) FetchContent(ctx context.Context, page PageRef) (string, error) {
+	var sb strings.Builder
+	if err := c.appendBlocks(ctx, &sb, page.ID, 0); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(sb.String()), nil
+}
+
+// notionBlock captures the rich text of the block types that carry prose
+type notionBlock struct {
+	ID          string `json:"id"`
+	Type        string `json:"type"`
+	HasChildren bool   `json:"has_children"`
+	ChildPage   *struct {
+		Title string `json:"title"`
+	} `json:"child_page"`
+}
+
+func (c *notionConnector) appendBlocks(ctx context.Context, sb *strings.Builder, blockID string, depth int) error {
+	cursor := ""
+	for {
+		path := fmt.Sprintf("/blocks/%s/children?page_size=100", blockID)
+		if cursor != "" {
+			path += "&start_cursor=" + cursor
+		}
+
+		var resp struct {
+			Results    []json.RawMessage `json:"results"`
+			HasMore    bool              `json:"has_more"`
+			NextCursor string            `json:"next_cursor"`
+		}
+		if err := c.get(ctx, path, &resp); err != nil {
+			return fmt.Errorf("failed to fetch notion blocks: %w", err)
+		}
+
+		for _, raw := range resp.Results {
+			var block notionBlock
+			if err := json.Unmarshal(raw, &block); err != nil {
+				continue
+			}
+			sb.WriteString(blockText(raw, block))
+
+			// Child pages are synced separately when selected; don't inline them
+			if block.HasChildren && block.Type != "child_page" && block.Type != "child_database" && depth < notionMaxDepth {
+				if err := c.appendBlocks(ctx, sb, block.ID, depth+1); err != nil {
+					return err
+				}
+			}
+		}
+
+		if !resp.HasMore || resp.NextCursor == "" {
+			return nil
+		}
+		cursor = resp.NextCursor
+	}
+}
+
+// blockText renders a block's rich text with light markdown so headings and lists survive chunking
+func blockText(raw json.RawMessage, block notionBlock) string {
+	if block.Type == "child_page" && block.ChildPage != nil {
+		return "[Subpage: " + block.ChildPage.Title + "]\n"
+	}
+
+	var body map[string]struct {
+		RichText notionRichText `json:"rich_text"`
+	}
+	if err := json.Unmarshal(raw, &body); err != nil {
+		return ""
+	}
+	text := body[block.Type].RichText.String()
+	if text == "" {
+		return ""
+	}
+
+	switch block.Type {
+	case "heading_1":
+		return "\n# " + text + "\n"
+	case "heading_2":
+		return "\n## " + text + "\n"
+	case "heading_3":
+		return "\n### " + text + "\n"
+	case "bulleted_list_item", "to_do":
+		return "- " + text + "\n"
+	case "numbered_list_item":
+		return "1. " + text + "\n"
+	case "code":
+		return "```\n" + text + "\n```\n"
+	default:
+		return text + "\n\n"
+	}
+}
+
+func (c *notionConnector) get(ctx context.Context, path string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, notionAPIURL+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Notion-Version", notionVersion)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("notion returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(snippet)))
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,216 @@
+package knowledge
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"discord-tars/internal/interfaces"
+	"discord-tars/internal/models"
+	"discord-tars/internal/repository"
+	"discord-tars/internal/storage"
+)
+
+// SyncResult summarizes one sync of a source
+type SyncResult struct {
+	Updated   int
+	Unchanged int
+	Removed   int
+	Failed    int
+}
+
+type Service struct {
+	aiService     interfaces.AIService
+	knowledgeRepo *repository.KnowledgeRepository
+	snapshots     storage.Store // Optional; keeps the raw text of each synced revision
+}
+
+func NewService(aiService interfaces.AIService, knowledgeRepo *repository.KnowledgeRepository) *Service {
+	return &Service{
+		aiService:     aiService,
+		knowledgeRepo: knowledgeRepo,
+	}
+}
+
+// SetSnapshotStore keeps the latest synced text of each page, for auditing what answers were grounded in
+func (s *Service) SetSnapshotStore(store storage.Store) {
+	s.snapshots = store
+}
+
+// AddSource validates a source's configuration, stores it and runs the first sync
+func (s *Service) AddSource(ctx context.Context, source *models.KnowledgeSource) (*SyncResult, error) {
+	if _, err := connectorFor(source); err != nil {
+		return nil, err
+	}
+	if err := s.knowledgeRepo.AddSource(ctx, source); err != nil {
+		return nil, err
+	}
+	return s.syncAndRecord(ctx, source)
+}
+
+// RemoveSource deletes a source and everything synced from it
+func (s *Service) RemoveSource(ctx context.Context, guildID, sourceID int64) (bool, error) {
+	return s.knowledgeRepo.RemoveSource(ctx, guildID, sourceID)
+}
+
+// ListSources returns a guild's knowledge sources
+func (s *Service) ListSources(ctx context.Context, guildID int64) ([]models.KnowledgeSource, error) {
+	return s.knowledgeRepo.ListSources(ctx, guildID)
+}
+
+// Sync re-syncs one of a guild's sources immediately
+func (s *Service) Sync(ctx context.Context, guildID, sourceID int64) (*SyncResult, error) {
+	source, err := s.knowledgeRepo.GetSource(ctx, guildID, sourceID)
+	if err != nil {
+		return nil, err
+	}
+	if source == nil {
+		return nil, fmt.Errorf("no knowledge source with ID %d", sourceID)
+	}
+	return s.syncAndRecord(ctx, source)
+}
+
+// SyncAll is the scheduler job: it syncs every knowledge source
+func (s *Service) SyncAll(ctx context.Context) error {
+	sources, err := s.knowledgeRepo.ListSources(ctx, 0)
+	if err != nil {
+		return err
+	}
+	for i := range sources {
+		if _, err := s.syncAndRecord(ctx, &sources[i]); err != nil {
+			log.Printf("❌ Failed to sync knowledge source %d: %v", sources[i].ID, err)
+		}
+	}
+	return nil
+}
+
+func (s *Service) syncAndRecord(ctx context.Context, source *models.KnowledgeSource) (*SyncResult, error) {
+	result, err := s.sync(ctx, source)
+	lastError := ""
+	if err != nil {
+		lastError = err.Error()
+	}
+	if markErr := s.knowledgeRepo.MarkSynced(ctx, source.ID, lastError, time.Now()); markErr != nil {
+		log.Printf("❌ Failed to mark knowledge source %d synced: %v", source.ID, markErr)
+	}
+	return result, err
+}
+
+// sync pulls changed pages from a source, re-embedding only pages whose content changed,
+// and removes documents for pages that are no longer selected or were deleted
+func (s *Service) sync(ctx context.Context, source *models.KnowledgeSource) (*SyncResult, error) {
+	conn, err := connectorFor(source)
+	if err != nil {
+		return nil, err
+	}
+	pages, err := conn.ListPages(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	docs, err := s.knowledgeRepo.ListDocuments(ctx, source.ID)
+	if err != nil {
+		return nil, err
+	}
+	existing := make(map[string]*models.KnowledgeDocument, len(docs))
+	for i := range docs {
+		existing[docs[i].ExternalID] = &docs[i]
+	}
+
+	result := &SyncResult{}
+	var firstErr error
+	for _, page := range pages {
+		doc := existing[page.ID]
+		delete(existing, page.ID)
+
+		changed, err := s.syncPage(ctx, conn, source, doc, page)
+		switch {
+		case err != nil:
+			log.Printf("⚠️ Failed to sync page %s of knowledge source %d: %v", page.ID, source.ID, err)
+			result.Failed++
+			if firstErr == nil {
+				firstErr = err
+			}
+		case changed:
+			result.Updated++
+		default:
+			result.Unchanged++
+		}
+	}
+
+	var stale []int64
+	for _, doc := range existing {
+		stale = append(stale, doc.ID)
+	}
+	if err := s.knowledgeRepo.DeleteDocuments(ctx, stale); err != nil {
+		return result, err
+	}
+	result.Removed = len(stale)
+
+	log.Printf("📚 Synced knowledge source %d: %d updated, %d unchanged, %d removed, %d failed",
+		source.ID, result.Updated, result.Unchanged, result.Removed, result.Failed)
+	if firstErr != nil {
+		return result, fmt.Errorf("%d pages failed to sync: %w", result.Failed, firstErr)
+	}
+	return result, nil
+}
+
+func (s *Service) syncPage(ctx context.Context, conn Connector, source *models.KnowledgeSource, doc *models.KnowledgeDocument, page PageRef) (bool, error) {
+	// Pages whose revision hasn't moved are skipped without fetching content
+	if doc != nil && !page.UpdatedAt.IsZero() && !page.UpdatedAt.After(doc.SourceUpdatedAt) {
+		return false, nil
+	}
+
+	content, err := conn.FetchContent(ctx, page)
+	if err != nil {
+		return false, err
+	}
+
+	sum := sha256.Sum256([]byte(page.Title + "\n" + content))
+	hash := hex.EncodeToString(sum[:])
+
+	if doc == nil {
+		doc = &models.KnowledgeDocument{
+			SourceID:   source.ID,
+			GuildID:    source.GuildID,
+			ExternalID: page.ID,
+		}
+	}
+	doc.Title = page.Title
+	doc.URL = page.URL
+	doc.SourceUpdatedAt = page.UpdatedAt
+
+	// Edits that didn't change the text (e.g. permissions) only bump the revision
+	if doc.ID != 0 && doc.ContentHash == hash {
+		return false, s.knowledgeRepo.TouchDocument(ctx, doc)
+	}
+	doc.ContentHash = hash
+	s.storeSnapshot(ctx, source, page, content)
+
+	chunks := chunkText(content)
+	embeddings := make([][]float32, len(chunks))
+	for i, chunk := range chunks {
+		// The title is embedded with every chunk so sections stay tied to their page
+		embeddings[i], err = s.aiService.GenerateEmbedding(ctx, page.Title+"\n\n"+chunk)
+		if err != nil {
+			return false, fmt.Errorf("failed to embed chunk: %w", err)
+		}
+	}
+
+	return true, s.knowledgeRepo.SaveDocument(ctx, doc, chunks, embeddings)
+}
+
+func (s *Service) storeSnapshot(ctx context.Context, source *models.KnowledgeSource, page PageRef, content string) {
+	if s.snapshots == nil {
+		return
+	}
+	key := fmt.Sprintf("%s%d/%d/%s.txt", storage.PrefixDocuments, source.GuildID, source.ID, storage.SanitizeName(page.ID))
+	body := page.Title + "\n" + page.URL + "\n\n" + content
+	if err := s.snapshots.Put(ctx, key, strings.NewReader(body), int64(len(body)), "text/plain; charset=utf-8"); err != nil {
+		log.Printf("⚠️ Failed to store snapshot of page %s: %v", page.ID, err)
+	}
+}
@@ -0,0 +1,71 @@
+package knowledge
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	chunkSize    = 1500
+	chunkOverlap = 200
+)
+
+var (
+	blockEndPattern  = regexp.MustCompile(`(?i)</(p|div|h[1-6]|li|tr|pre|blockquote)>|<br\s*/?>`)
+	tagPattern       = regexp.MustCompile(`<[^>]*>`)
+	blankRunsPattern = regexp.MustCompile(`\n{3,}`)
+	spaceRunsPattern = regexp.MustCompile(`[ \t]+`)
+)
+
+// htmlToText converts page markup to plain text, keeping paragraph breaks
+func htmlToText(markup string) string {
+	text := blockEndPattern.ReplaceAllString(markup, "\n")
+	text = tagPattern.ReplaceAllString(text, " ")
+	text = html.UnescapeString(text)
+	text = spaceRunsPattern.ReplaceAllString(text, " ")
+
+	lines := strings.Split(text, "\n")
+	for i := range lines {
+		lines[i] = strings.TrimSpace(lines[i])
+	}
+	return strings.TrimSpace(blankRunsPattern.ReplaceAllString(strings.Join(lines, "\n"), "\n\n"))
+}
+
+// chunkText splits text into overlapping chunks of about chunkSize bytes,
+// preferring paragraph and then word boundaries
+func chunkText(text string) []string {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil
+	}
+
+	var chunks []string
+	for len(text) > chunkSize {
+		cut := strings.LastIndex(text[:chunkSize], "\n\n")
+		if cut < chunkSize/2 {
+			cut = strings.LastIndexAny(text[:chunkSize], "\n ")
+		}
+		if cut < chunkSize/2 {
+			cut = chunkSize
+			for cut > 0 && !utf8.RuneStart(text[cut]) {
+				cut-- // don't split a UTF-8 sequence
+			}
+		}
+		chunks = append(chunks, strings.TrimSpace(text[:cut]))
+
+		next := cut - chunkOverlap
+		if space := strings.IndexAny(text[next:cut], " \n"); space >= 0 {
+			next += space // start the overlap on a word boundary
+		}
+		for next < cut && !utf8.RuneStart(text[next]) {
+			next++
+		}
+		text = strings.TrimSpace(text[next:])
+	}
+	if text != "" {
+		chunks = append(chunks, text)
+	}
+	return chunks
+}
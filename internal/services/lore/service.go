@@ -0,0 +1,122 @@
+// Package lore keeps a tabletop campaign's lore: it answers questions about
+// characters, places and past events from the session recaps players post in
+// a session notes channel.
+package lore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"discord-tars/internal/injection"
+	"discord-tars/internal/interfaces"
+	"discord-tars/internal/models"
+	"discord-tars/internal/repository"
+	"discord-tars/internal/sanitize"
+)
+
+const (
+	maxNotes          = 8
+	noteMinSimilarity = 0.2
+	answerMaxTokens   = 700
+)
+
+const loreSystemPrompt = `You are the lore keeper of a tabletop roleplaying campaign played on a Discord server.
+You get session notes the players wrote after their sessions, oldest first, and a question about the campaign.
+Answer only from the notes: who characters are, where places are, what happened and when.
+Mention the session a fact comes from by its date, e.g. "(session of 12 May 2024)".
+When later notes contradict earlier ones, the later notes are what happened.
+If the notes don't cover the question, say the chronicles are silent on it; never invent lore, and never reveal more than the notes say.
+Stay in the voice of a lore keeper, but keep answers short and clear.`
+
+var (
+	ErrNoNotes  = errors.New("no session notes are indexed")
+	ErrNotFound = errors.New("the session notes don't mention this")
+)
+
+type Service struct {
+	aiService    interfaces.AIService
+	priorityRepo *repository.PriorityRepository
+}
+
+func NewService(aiService interfaces.AIService, priorityRepo *repository.PriorityRepository) *Service {
+	return &Service{
+		aiService:    aiService,
+		priorityRepo: priorityRepo,
+	}
+}
+
+// Note is a session recap an answer drew on
+type Note struct {
+	ChannelID int64
+	MessageID int64
+	Author    string
+	Date      time.Time
+}
+
+// Answer is the lore keeper's answer to a question, with the notes it drew on
+type Answer struct {
+	Text  string
+	Notes []Note
+}
+
+// Ask answers a campaign question from a guild's session notes
+func (s *Service) Ask(ctx context.Context, guildID int64, question string) (*Answer, error) {
+	embedding, err := s.aiService.GenerateEmbedding(ctx, question)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate question embedding: %w", err)
+	}
+	results, err := s.priorityRepo.SearchLabel(ctx, guildID, models.PriorityLabelSessionNotes, embedding, maxNotes, noteMinSimilarity)
+	if err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		// Tell a campaign without notes apart from a question they don't cover
+		some, err := s.priorityRepo.SearchLabel(ctx, guildID, models.PriorityLabelSessionNotes, embedding, 1, -1)
+		if err != nil {
+			return nil, err
+		}
+		if len(some) == 0 {
+			return nil, ErrNoNotes
+		}
+		return nil, ErrNotFound
+	}
+
+	// Told in order, later sessions can supersede earlier ones
+	sort.Slice(results, func(a, b int) bool { return results[a].Document.MessageID < results[b].Document.MessageID })
+	notes := make([]Note, len(results))
+	for n, result := range results {
+		doc := result.Document
+		notes[n] = Note{ChannelID: doc.ChannelID, MessageID: doc.MessageID, Author: doc.AuthorName, Date: messageTime(doc.MessageID)}
+	}
+
+	var prompt strings.Builder
+	prompt.WriteString("SESSION NOTES:\n\n")
+	for n, result := range results {
+		// Any player could have written these
+		content, _ := injection.Strip(sanitize.Context(result.Document.Content))
+		prompt.WriteString(fmt.Sprintf("[Session of %s, noted by %s]\n%s\n\n", notes[n].Date.Format("2 January 2006"), notes[n].Author, content))
+	}
+	prompt.WriteString("QUESTION: " + question)
+
+	text, err := s.aiService.Complete(ctx, loreSystemPrompt, prompt.String(), answerMaxTokens)
+	if err != nil {
+		return nil, err
+	}
+	return &Answer{Text: sanitize.Output(text), Notes: notes}, nil
+}
+
+// messageTime is when a message was posted, read from its ID
+func messageTime(messageID int64) time.Time {
+	t, err := discordgo.SnowflakeTimestamp(strconv.FormatInt(messageID, 10))
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
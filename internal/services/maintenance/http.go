@@ -0,0 +1,54 @@
+package maintenance
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/server"
+)
+
+const recentRuns = 10
+
+// HandleStatus shows the vector indexes as they are now, the off-peak window
+// and the latest maintenance runs with what they rebuilt
+func (s *Service) HandleStatus(w http.ResponseWriter, r *http.Request) {
+	indexes, err := s.Indexes(r.Context())
+	if err != nil {
+		server.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	runs, err := s.repo.ListRuns(r.Context(), recentRuns)
+	if err != nil {
+		server.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	server.WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"indexes":         indexes,
+		"window":          s.cfg.Window,
+		"in_window":       s.InWindow(time.Now()),
+		"interval":        s.cfg.Interval.String(),
+		"bloat_threshold": s.cfg.BloatThreshold,
+		"runs":            runs,
+	})
+}
+
+// HandleRun starts a maintenance run now, whatever the time of day; its
+// result shows in HandleStatus once it finishes
+func (s *Service) HandleRun(w http.ResponseWriter, r *http.Request) {
+	if !s.begin() {
+		server.WriteError(w, http.StatusConflict, ErrRunning.Error())
+		return
+	}
+	go func() {
+		defer s.done()
+		ctx, cancel := context.WithTimeout(context.Background(), CheckInterval)
+		defer cancel()
+		if _, err := s.execute(ctx, models.MaintenanceManual); err != nil {
+			log.Printf("❌ Manual maintenance failed: %v", err)
+		}
+	}()
+	server.WriteJSON(w, http.StatusAccepted, map[string]string{"status": "started"})
+}
@@ -0,0 +1,280 @@
+// Package maintenance keeps the pgvector indexes fast as the tables behind
+// them grow and churn. Each run analyzes the indexed tables so the planner
+// sees their real size, estimates how bloated each vector index is, and
+// rebuilds those that are bloated or, for ivfflat, clustered for a table a
+// fraction of today's size. Rebuilds don't block writes but are heavy, so
+// scheduled runs wait for the configured off-peak hours.
+package maintenance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/repository"
+)
+
+const (
+	// CheckInterval is how often the scheduler asks whether a run is due; a
+	// run may take this long before it is cancelled
+	CheckInterval = time.Hour
+	pageBytes     = 8192
+	// ivfflat lists are compared with the table's size once it has this many
+	// rows, and rebuilt when this many times off
+	minRowsForLists = 10000
+	listsDrift      = 2
+)
+
+var ErrRunning = errors.New("maintenance is already running")
+
+// Config sets how often maintenance runs and when
+type Config struct {
+	// Interval is the time between scheduled runs
+	Interval time.Duration
+	// Window is the off-peak hours scheduled runs may start in, e.g.
+	// "02:00-05:00", read in Location; empty allows any time
+	Window   string
+	Location *time.Location
+	// BloatThreshold is the estimated share of wasted space, in percent, at
+	// which an index is rebuilt
+	BloatThreshold float64
+}
+
+type Service struct {
+	repo  *repository.MaintenanceRepository
+	cfg   Config
+	start time.Duration // Window bounds, as times of day
+	end   time.Duration
+
+	mu      sync.Mutex
+	running bool
+}
+
+// NewService checks the off-peak window of cfg
+func NewService(repo *repository.MaintenanceRepository, cfg Config) (*Service, error) {
+	if cfg.Location == nil {
+		cfg.Location = time.UTC
+	}
+	s := &Service{repo: repo, cfg: cfg}
+	if cfg.Window != "" {
+		from, to, ok := strings.Cut(cfg.Window, "-")
+		var err error
+		if ok {
+			if s.start, err = timeOfDay(from); err == nil {
+				s.end, err = timeOfDay(to)
+			}
+		}
+		if !ok || err != nil || s.start == s.end {
+			return nil, fmt.Errorf("invalid maintenance window %q, use a form like 02:00-05:00", cfg.Window)
+		}
+	}
+	return s, nil
+}
+
+func timeOfDay(value string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(value))
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// InWindow reports whether t is within the off-peak hours; windows may span
+// midnight, e.g. 23:00-04:00
+func (s *Service) InWindow(t time.Time) bool {
+	if s.cfg.Window == "" {
+		return true
+	}
+	t = t.In(s.cfg.Location)
+	now := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+	if s.start < s.end {
+		return now >= s.start && now < s.end
+	}
+	return now >= s.start || now < s.end
+}
+
+// Run is the scheduler job: within the off-peak window, it runs maintenance
+// once per interval
+func (s *Service) Run(ctx context.Context) error {
+	now := time.Now()
+	if !s.InWindow(now) {
+		return nil
+	}
+	last, err := s.repo.LastRun(ctx)
+	if err != nil {
+		return err
+	}
+	// Checks are an hour apart, so a run an interval ago may be just short of it
+	if last != nil && now.Sub(last.StartedAt) < s.cfg.Interval-CheckInterval {
+		return nil
+	}
+	_, err = s.Maintain(ctx, models.MaintenanceScheduled)
+	if errors.Is(err, ErrRunning) {
+		return nil
+	}
+	return err
+}
+
+// Maintain analyzes the tables with vector indexes and rebuilds the indexes
+// that need it, recording the run
+func (s *Service) Maintain(ctx context.Context, trigger string) (*models.MaintenanceRun, error) {
+	if !s.begin() {
+		return nil, ErrRunning
+	}
+	defer s.done()
+	return s.execute(ctx, trigger)
+}
+
+// execute runs maintenance; the caller holds the running flag
+func (s *Service) execute(ctx context.Context, trigger string) (*models.MaintenanceRun, error) {
+	run := &models.MaintenanceRun{Trigger: trigger}
+	if err := s.repo.StartRun(ctx, run); err != nil {
+		return nil, err
+	}
+	err := s.maintain(ctx, run)
+	run.Status = models.IndexRunCompleted
+	if err != nil {
+		run.Status, run.Error = models.IndexRunFailed, err.Error()
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			run.Status = models.IndexRunCancelled
+		}
+	}
+	// The run is recorded even when ctx ran out
+	if finishErr := s.repo.FinishRun(context.WithoutCancel(ctx), run); finishErr != nil {
+		log.Printf("⚠️ %v", finishErr)
+	}
+	log.Printf("🧰 Maintenance %s: analyzed %d tables, rebuilt %d indexes", run.Status, len(run.Analyzed), len(run.Reindexed))
+	return run, err
+}
+
+func (s *Service) maintain(ctx context.Context, run *models.MaintenanceRun) error {
+	indexes, err := s.Indexes(ctx)
+	if err != nil {
+		return err
+	}
+	run.Indexes = indexes
+
+	// Messages drive most queries even without a vector index of their own
+	tables := []string{"messages"}
+	for _, index := range indexes {
+		if !contains(tables, index.Table) {
+			tables = append(tables, index.Table)
+		}
+	}
+	for _, table := range tables {
+		if err := s.repo.Analyze(ctx, table); err != nil {
+			return err
+		}
+		run.Analyzed = append(run.Analyzed, table)
+	}
+
+	for _, index := range indexes {
+		reason, lists := s.needsRebuild(index)
+		if reason == "" {
+			continue
+		}
+		log.Printf("🧰 Rebuilding %s: %s", index.Name, reason)
+		if err := s.repo.Reindex(ctx, index.Name, lists); err != nil {
+			return err
+		}
+		run.Reindexed = append(run.Reindexed, index.Name+": "+reason)
+	}
+	return nil
+}
+
+// needsRebuild says why an index should be rebuilt, "" when it shouldn't,
+// and the ivfflat lists it should be rebuilt with, 0 to keep them
+func (s *Service) needsRebuild(index models.VectorIndexHealth) (string, int) {
+	lists := 0
+	if index.RecommendedLists > 0 && index.Rows >= minRowsForLists &&
+		(index.Lists*listsDrift <= index.RecommendedLists || index.Lists >= index.RecommendedLists*listsDrift) {
+		lists = index.RecommendedLists
+	}
+	switch {
+	case lists > 0:
+		return fmt.Sprintf("%d lists for %d rows, rebuilding with %d", index.Lists, index.Rows, lists), lists
+	case s.cfg.BloatThreshold > 0 && index.BloatPercent >= s.cfg.BloatThreshold:
+		return fmt.Sprintf("%.0f%% bloat", index.BloatPercent), 0
+	}
+	return "", 0
+}
+
+// Indexes reports the vector indexes with their estimated bloat and, for
+// ivfflat, the lists suiting their table
+func (s *Service) Indexes(ctx context.Context) ([]models.VectorIndexHealth, error) {
+	indexes, err := s.repo.VectorIndexes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for n := range indexes {
+		indexes[n].BloatPercent = bloatPercent(indexes[n])
+		if indexes[n].Method == "ivfflat" {
+			indexes[n].RecommendedLists = recommendedLists(indexes[n].Rows)
+		}
+	}
+	return indexes, nil
+}
+
+// recommendedLists follows pgvector's advice: a list per thousand rows up to
+// a million rows, the square root of the rows beyond
+func recommendedLists(rows int64) int {
+	if rows <= 1_000_000 {
+		return max(int(rows/1000), 10)
+	}
+	return int(math.Sqrt(float64(rows)))
+}
+
+// bloatPercent estimates the wasted share of an index by comparing its size
+// with the pages its live rows need: one tuple per row, holding the vector
+// and, for hnsw, its neighbors' addresses
+func bloatPercent(index models.VectorIndexHealth) float64 {
+	pages := float64(index.SizeBytes) / pageBytes
+	if pages == 0 || index.Dimensions <= 0 {
+		return 0
+	}
+	tupleBytes := 4*index.Dimensions + 8 + 8 + 4 // Vector, vector and tuple headers, line pointer
+	if index.Method == "hnsw" {
+		tupleBytes += 2 * 16 * 6 * 2 // Neighbors at the default m of 16, across layers
+	}
+	perPage := max((pageBytes-24)/tupleBytes, 1)
+	expected := math.Ceil(float64(index.Rows)/float64(perPage)) + 1 // Metapage
+	if index.Method == "ivfflat" {
+		// The lists' centers, and each list's last page is partly empty
+		expected += math.Ceil(float64(index.Lists)/float64(perPage)) + float64(index.Lists)
+	}
+	if expected >= pages {
+		return 0
+	}
+	return math.Round((1-expected/pages)*1000) / 10
+}
+
+func (s *Service) begin() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.running {
+		return false
+	}
+	s.running = true
+	return true
+}
+
+func (s *Service) done() {
+	s.mu.Lock()
+	s.running = false
+	s.mu.Unlock()
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
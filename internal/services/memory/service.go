@@ -0,0 +1,183 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"discord-tars/internal/interfaces"
+	"discord-tars/internal/models"
+	"discord-tars/internal/repository"
+)
+
+const (
+	defaultTokenBudget = 1500
+	defaultKeepTurns   = 4
+	defaultTTL         = 24 * time.Hour
+	summaryMaxTokens   = 400
+	// charsPerToken is a rough estimate for English text, good enough for budgeting
+	charsPerToken = 4
+)
+
+const summarySystemPrompt = `You maintain the memory of an ongoing Discord conversation with an assistant bot.
+You get the current summary (possibly empty) and older exchanges that no longer fit verbatim.
+Write an updated summary that merges them: who asked what, what was answered or decided,
+names, numbers and preferences worth remembering, and anything still open.
+Keep it under 200 words, in plain prose or short bullets. Do not invent details.`
+
+// Config bounds how much conversation is replayed into prompts
+type Config struct {
+	TokenBudget int           // Summary plus verbatim turns; older turns are summarized past it
+	KeepTurns   int           // Most recent exchanges always kept verbatim
+	TTL         time.Duration // A conversation idle this long starts fresh
+}
+
+// Conversation is what a prompt is given about earlier exchanges
+type Conversation struct {
+	Summary string
+	Turns   []models.MemoryTurn
+}
+
+// Service keeps a rolling memory of each channel's conversation with the bot
+type Service struct {
+	aiService interfaces.AIService
+	repo      *repository.MemoryRepository
+	cfg       Config
+
+	locksMu sync.Mutex
+	locks   map[int64]*sync.Mutex // Serializes updates per channel
+}
+
+func NewService(aiService interfaces.AIService, repo *repository.MemoryRepository, cfg Config) *Service {
+	if cfg.TokenBudget <= 0 {
+		cfg.TokenBudget = defaultTokenBudget
+	}
+	if cfg.KeepTurns <= 0 {
+		cfg.KeepTurns = defaultKeepTurns
+	}
+	if cfg.TTL <= 0 {
+		cfg.TTL = defaultTTL
+	}
+	return &Service{
+		aiService: aiService,
+		repo:      repo,
+		cfg:       cfg,
+		locks:     make(map[int64]*sync.Mutex),
+	}
+}
+
+// Load returns a channel's remembered conversation; idle conversations come back empty
+func (s *Service) Load(ctx context.Context, channelID int64) (Conversation, error) {
+	memory, err := s.repo.GetMemory(ctx, channelID)
+	if err != nil || memory == nil || time.Since(memory.UpdatedAt) > s.cfg.TTL {
+		return Conversation{}, err
+	}
+	return decode(memory)
+}
+
+// Record appends an exchange, folding older exchanges into the summary once
+// the conversation no longer fits the token budget
+func (s *Service) Record(ctx context.Context, guildID, channelID int64, turn models.MemoryTurn) error {
+	lock := s.lock(channelID)
+	lock.Lock()
+	defer lock.Unlock()
+
+	memory, err := s.repo.GetMemory(ctx, channelID)
+	if err != nil {
+		return err
+	}
+	if memory == nil || time.Since(memory.UpdatedAt) > s.cfg.TTL {
+		memory = &models.ConversationMemory{ChannelID: channelID, GuildID: guildID}
+	}
+	conv, err := decode(memory)
+	if err != nil {
+		log.Printf("⚠️ Discarding unreadable conversation memory for channel %d: %v", channelID, err)
+		conv = Conversation{}
+	}
+	conv.Turns = append(conv.Turns, turn)
+
+	if estimateTokens(conv) > s.cfg.TokenBudget && len(conv.Turns) > s.cfg.KeepTurns {
+		older := conv.Turns[:len(conv.Turns)-s.cfg.KeepTurns]
+		summary, err := s.summarize(ctx, conv.Summary, older)
+		if err != nil {
+			// Keep everything for now; the next exchange retries
+			log.Printf("⚠️ Failed to summarize conversation in channel %d: %v", channelID, err)
+		} else {
+			conv.Summary = summary
+			memory.SummarizedTurns += len(older)
+			conv.Turns = append([]models.MemoryTurn(nil), conv.Turns[len(older):]...)
+			log.Printf("🗜️ Summarized %d exchanges in channel %d", len(older), channelID)
+		}
+	}
+
+	turns, err := json.Marshal(conv.Turns)
+	if err != nil {
+		return fmt.Errorf("failed to encode conversation turns: %w", err)
+	}
+	memory.Summary = conv.Summary
+	memory.Turns = string(turns)
+	return s.repo.SaveMemory(ctx, memory)
+}
+
+// Forget clears a channel's conversation
+func (s *Service) Forget(ctx context.Context, channelID int64) error {
+	return s.repo.DeleteMemory(ctx, channelID)
+}
+
+func (s *Service) summarize(ctx context.Context, summary string, turns []models.MemoryTurn) (string, error) {
+	var sb strings.Builder
+	sb.WriteString("Current summary:\n")
+	if summary == "" {
+		sb.WriteString("(none)\n")
+	} else {
+		sb.WriteString(summary + "\n")
+	}
+	sb.WriteString("\nOlder exchanges to merge in:\n\n")
+	for _, turn := range turns {
+		sb.WriteString(fmt.Sprintf("%s: %s\nBot: %s\n\n", turn.Username, turn.Question, turn.Answer))
+	}
+
+	updated, err := s.aiService.Complete(ctx, summarySystemPrompt, sb.String(), summaryMaxTokens)
+	if err != nil {
+		return "", err
+	}
+	updated = strings.TrimSpace(updated)
+	if updated == "" {
+		return "", fmt.Errorf("empty summary")
+	}
+	return updated, nil
+}
+
+func (s *Service) lock(channelID int64) *sync.Mutex {
+	s.locksMu.Lock()
+	defer s.locksMu.Unlock()
+	lock, ok := s.locks[channelID]
+	if !ok {
+		lock = &sync.Mutex{}
+		s.locks[channelID] = lock
+	}
+	return lock
+}
+
+func decode(memory *models.ConversationMemory) (Conversation, error) {
+	conv := Conversation{Summary: memory.Summary}
+	if memory.Turns != "" {
+		if err := json.Unmarshal([]byte(memory.Turns), &conv.Turns); err != nil {
+			return Conversation{}, fmt.Errorf("failed to decode conversation turns: %w", err)
+		}
+	}
+	return conv, nil
+}
+
+// estimateTokens approximates the prompt size of a conversation
+func estimateTokens(conv Conversation) int {
+	chars := len(conv.Summary)
+	for _, turn := range conv.Turns {
+		chars += len(turn.Username) + len(turn.Question) + len(turn.Answer)
+	}
+	return chars / charsPerToken
+}
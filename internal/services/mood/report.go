@@ -0,0 +1,159 @@
+package mood
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"discord-tars/internal/models"
+)
+
+// examplesPerSide is how many of the most negative and most positive
+// messages a drill-down shows
+const examplesPerSide = 3
+
+// Period is a channel's mood over a span of days
+type Period struct {
+	Messages int
+	Negative int
+	Positive int
+	total    float64 // Sum of scores, for the average
+}
+
+func (p *Period) add(day models.ChannelMood) {
+	p.Messages += day.Messages
+	p.Negative += day.Negative
+	p.Positive += day.Positive
+	p.total += day.Average * float64(day.Messages)
+}
+
+// Average is the mean score, from -1 to 1
+func (p Period) Average() float64 {
+	if p.Messages == 0 {
+		return 0
+	}
+	return p.total / float64(p.Messages)
+}
+
+// Negativity is the share of negative messages, from 0 to 1
+func (p Period) Negativity() float64 {
+	if p.Messages == 0 {
+		return 0
+	}
+	return float64(p.Negative) / float64(p.Messages)
+}
+
+// Trend compares a channel's mood over the report's days with the days before
+type Trend struct {
+	ChannelID   int64
+	ChannelName string
+	Current     Period
+	Previous    Period
+}
+
+// NegativityChange is the relative change in negativity, e.g. 0.3 for up 30%;
+// ok is false when there is nothing to compare with
+func (t Trend) NegativityChange() (change float64, ok bool) {
+	if t.Previous.Messages == 0 || t.Current.Messages == 0 || t.Previous.Negativity() == 0 {
+		return 0, false
+	}
+	return t.Current.Negativity()/t.Previous.Negativity() - 1, true
+}
+
+// Example is a scored message shown in a drill-down
+type Example struct {
+	Message models.Message
+	Score   float64
+}
+
+// Report is a guild's mood over the last days, by channel
+type Report struct {
+	Since  time.Time // First day of the period
+	Days   int
+	Trends []Trend // Busiest channels first
+
+	// Filled in for a single channel
+	Daily    []models.ChannelMood
+	Negative []Example
+	Positive []Example
+}
+
+// Report compares each channel's mood over the last complete days with the
+// same number of days before. With a channelID, it covers that channel only
+// and adds its daily moods and most negative and positive messages.
+func (s *Service) Report(ctx context.Context, guildID, channelID int64, days int) (*Report, error) {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	since := today.AddDate(0, 0, -days)
+	moods, err := s.repo.ListDays(ctx, guildID, channelID, since.AddDate(0, 0, -days))
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{Since: since, Days: days}
+	trends := make(map[int64]*Trend)
+	for _, day := range moods {
+		trend, ok := trends[day.ChannelID]
+		if !ok {
+			trend = &Trend{ChannelID: day.ChannelID}
+			trends[day.ChannelID] = trend
+		}
+		if day.ChannelName != "" {
+			trend.ChannelName = day.ChannelName
+		}
+		if day.Day.Before(since) {
+			trend.Previous.add(day)
+			continue
+		}
+		trend.Current.add(day)
+		if channelID != 0 {
+			report.Daily = append(report.Daily, day)
+		}
+	}
+	for _, trend := range trends {
+		report.Trends = append(report.Trends, *trend)
+	}
+	sort.Slice(report.Trends, func(a, b int) bool {
+		if report.Trends[a].Current.Messages != report.Trends[b].Current.Messages {
+			return report.Trends[a].Current.Messages > report.Trends[b].Current.Messages
+		}
+		return report.Trends[a].ChannelID < report.Trends[b].ChannelID
+	})
+
+	if channelID != 0 {
+		if report.Negative, err = s.examples(ctx, channelID, since, false); err != nil {
+			return nil, err
+		}
+		if report.Positive, err = s.examples(ctx, channelID, since, true); err != nil {
+			return nil, err
+		}
+	}
+	return report, nil
+}
+
+// examples returns a channel's most negative or most positive messages since a day
+func (s *Service) examples(ctx context.Context, channelID int64, since time.Time, positive bool) ([]Example, error) {
+	scores, err := s.repo.Extremes(ctx, channelID, since, positive, examplesPerSide)
+	if err != nil || len(scores) == 0 {
+		return nil, err
+	}
+	ids := make([]int64, len(scores))
+	for n, score := range scores {
+		ids[n] = score.MessageID
+	}
+	messages, err := s.msgRepo.GetMessagesByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[int64]models.Message, len(messages))
+	for _, m := range messages {
+		byID[m.ID] = m
+	}
+
+	var examples []Example
+	for _, score := range scores {
+		if m, ok := byID[score.MessageID]; ok {
+			examples = append(examples, Example{Message: m, Score: score.Score})
+		}
+	}
+	return examples, nil
+}
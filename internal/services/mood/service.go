@@ -0,0 +1,217 @@
+// Package mood scores the sentiment of indexed messages and tracks how each
+// channel's mood changes from day to day, and warns moderators when an
+// argument escalates.
+package mood
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"discord-tars/internal/interfaces"
+	"discord-tars/internal/models"
+	"discord-tars/internal/repository"
+	"discord-tars/internal/tenant"
+)
+
+const (
+	// NeutralBand is how far from 0 a score must be to count as negative or positive
+	NeutralBand = 0.25
+
+	// catchUpDays is how far back missed days are scored, e.g. after downtime
+	catchUpDays = 3
+	// Messages scored per channel per day, and per AI call
+	maxMessagesPerDay = 300
+	batchSize         = 40
+	maxMessageChars   = 300
+	scoreMaxTokens    = 400
+)
+
+const scoreSystemPrompt = `You rate the sentiment of Discord messages.
+For each numbered message, give a score from -1 (angry, frustrated, upset) through 0 (neutral or factual) to 1 (happy, grateful, excited).
+Judge the writer's mood, not the topic: a calm bug report is 0, "this is broken AGAIN" is negative. Sarcasm counts for what it means.
+Reply with only a JSON array of numbers, one per message, in order, e.g. [0, -0.6, 0.8].`
+
+type Service struct {
+	aiService   interfaces.AIService
+	repo        *repository.MoodRepository
+	msgRepo     *repository.MessageRepository
+	session     *discordgo.Session
+	minMessages int
+}
+
+// NewService creates the mood tracker; channels with fewer than minMessages
+// messages in a day are skipped
+func NewService(aiService interfaces.AIService, repo *repository.MoodRepository, msgRepo *repository.MessageRepository, session *discordgo.Session, minMessages int) *Service {
+	if minMessages < 1 {
+		minMessages = 1
+	}
+	return &Service{
+		aiService:   aiService,
+		repo:        repo,
+		msgRepo:     msgRepo,
+		session:     session,
+		minMessages: minMessages,
+	}
+}
+
+// ScoreDays is the scheduler job: it scores the last complete UTC days that
+// haven't been yet, so running it hourly scores each day shortly after midnight
+func (s *Service) ScoreDays(ctx context.Context) error {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	for n := catchUpDays; n >= 1; n-- {
+		if err := s.scoreDay(ctx, today.AddDate(0, 0, -n)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Service) scoreDay(ctx context.Context, day time.Time) error {
+	until := day.AddDate(0, 0, 1)
+	active, err := s.msgRepo.ListActiveChannels(ctx, day, until, s.minMessages)
+	if err != nil {
+		return err
+	}
+	done, err := s.repo.ScoredChannels(ctx, day)
+	if err != nil {
+		return err
+	}
+
+	for _, channel := range active {
+		if done[channel.ChannelID] {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := s.scoreChannel(ctx, channel, day, until); err != nil {
+			log.Printf("❌ Failed to score mood of channel %d for %s: %v", channel.ChannelID, day.Format(time.DateOnly), err)
+		}
+	}
+	return nil
+}
+
+func (s *Service) scoreChannel(ctx context.Context, channel repository.ChannelActivity, day, until time.Time) error {
+	ctx = tenant.WithGuild(ctx, channel.GuildID)
+	results, err := s.msgRepo.GetMessagesInRange(ctx, channel.ChannelID, day, until, maxMessagesPerDay)
+	if err != nil {
+		return fmt.Errorf("failed to load messages: %w", err)
+	}
+
+	// The bot's own answers don't reflect the community's mood
+	botID := s.botUserID()
+	var messages []models.Message
+	for _, r := range results {
+		if r.Message.UserID != botID && strings.TrimSpace(r.Message.Content) != "" {
+			messages = append(messages, r.Message)
+		}
+	}
+
+	mood := &models.ChannelMood{GuildID: channel.GuildID, ChannelID: channel.ChannelID, Day: day}
+	if len(results) > 0 {
+		mood.ChannelName = results[0].Channel.Name
+	}
+	var scores []models.MessageSentiment
+	var total float64
+	for start := 0; start < len(messages); start += batchSize {
+		batch := messages[start:min(start+batchSize, len(messages))]
+		batchScores, err := s.scoreBatch(ctx, batch)
+		if err != nil {
+			return err
+		}
+		for n, score := range batchScores {
+			scores = append(scores, models.MessageSentiment{
+				MessageID: batch[n].ID,
+				GuildID:   channel.GuildID,
+				ChannelID: channel.ChannelID,
+				Day:       day,
+				Score:     score,
+			})
+			total += score
+			switch {
+			case score < -NeutralBand:
+				mood.Negative++
+			case score > NeutralBand:
+				mood.Positive++
+			}
+		}
+	}
+	// A day with only bot messages is still stored, so it isn't retried
+	mood.Messages = len(scores)
+	if mood.Messages > 0 {
+		mood.Average = total / float64(mood.Messages)
+	}
+
+	if err := s.repo.SaveDay(ctx, mood, scores); err != nil {
+		return err
+	}
+	log.Printf("🌡️ Scored mood of #%s for %s (%d messages, average %.2f)", mood.ChannelName, day.Format(time.DateOnly), mood.Messages, mood.Average)
+	return nil
+}
+
+// scoreBatch asks the AI for the sentiment of each message, from -1 to 1
+func (s *Service) scoreBatch(ctx context.Context, messages []models.Message) ([]float64, error) {
+	lines := make([]string, len(messages))
+	for n, m := range messages {
+		lines[n] = m.Content
+	}
+	scores, err := scoreLines(ctx, s.aiService, scoreSystemPrompt, "", lines)
+	if err != nil {
+		return nil, err
+	}
+	for n, score := range scores {
+		scores[n] = max(-1, min(1, score))
+	}
+	return scores, nil
+}
+
+// scoreLines asks the AI for one score per numbered line, after an optional
+// preamble giving context
+func scoreLines(ctx context.Context, aiService interfaces.AIService, systemPrompt, preamble string, lines []string) ([]float64, error) {
+	var prompt strings.Builder
+	prompt.WriteString(preamble)
+	for n, line := range lines {
+		fmt.Fprintf(&prompt, "%d. %s\n", n+1, clip(line))
+	}
+
+	reply, err := aiService.Complete(ctx, systemPrompt, prompt.String(), scoreMaxTokens)
+	if err != nil {
+		return nil, fmt.Errorf("failed to score messages: %w", err)
+	}
+	start, end := strings.Index(reply, "["), strings.LastIndex(reply, "]")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("no JSON array in message scores: %q", reply)
+	}
+	var scores []float64
+	if err := json.Unmarshal([]byte(reply[start:end+1]), &scores); err != nil {
+		return nil, fmt.Errorf("failed to parse message scores: %w", err)
+	}
+	if len(scores) != len(lines) {
+		return nil, fmt.Errorf("got %d message scores for %d messages", len(scores), len(lines))
+	}
+	return scores, nil
+}
+
+// clip puts a message on one line of at most maxMessageChars characters
+func clip(content string) string {
+	content = strings.Join(strings.Fields(content), " ")
+	if runes := []rune(content); len(runes) > maxMessageChars {
+		content = string(runes[:maxMessageChars]) + "…"
+	}
+	return content
+}
+
+func (s *Service) botUserID() int64 {
+	if s.session == nil || s.session.State == nil || s.session.State.User == nil {
+		return 0
+	}
+	id, _ := strconv.ParseInt(s.session.State.User.ID, 10, 64)
+	return id
+}
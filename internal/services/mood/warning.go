@@ -0,0 +1,391 @@
+package mood
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"discord-tars/internal/interfaces"
+	"discord-tars/internal/models"
+	"discord-tars/internal/repository"
+	"discord-tars/internal/services/outbox"
+	"discord-tars/internal/tenant"
+)
+
+const (
+	// DefaultThreshold is the rolling toxicity, from 0 to 1, that triggers a warning
+	DefaultThreshold = 0.6
+
+	// New messages are scored in small batches, or after a short wait
+	warningBatchSize  = 5
+	warningFlushDelay = 20 * time.Second
+	// recentLines is how much of a channel's chat is kept for context and summaries
+	recentLines = 20
+	// Each scored message moves the rolling score by this much of the difference,
+	// and the score halves every toxicityHalfLife without messages
+	toxicityWeight   = 0.35
+	toxicityHalfLife = 10 * time.Minute
+	// A warning needs this many people among the heated messages: one person
+	// venting isn't an argument
+	minArguers     = 2
+	heatedScore    = 0.5
+	defaultCoolOff = 30 * time.Minute
+
+	warningConfigTTL = 5 * time.Minute
+	maxWatched       = 5000
+	summaryMaxTokens = 200
+)
+
+const toxicitySystemPrompt = `You rate how hostile Discord messages are, to warn moderators about arguments getting out of hand.
+For each numbered message, give a score from 0 (friendly or neutral) to 1 (insults, harassment, threats, slurs).
+Heated disagreement without personal attacks is around 0.4. Banter between friends and jokes are low. Use the earlier messages only as context.
+Reply with only a JSON array of numbers, one per numbered message, in order, e.g. [0, 0.7, 0.2].`
+
+const escalationSystemPrompt = `You brief Discord moderators about an argument that may need them.
+In two or three neutral sentences, say who is involved, what it is about and how heated it got. Refer to people by username.
+Do not take sides or suggest punishments.`
+
+// Watcher keeps a rolling toxicity score per channel from new messages and
+// warns a guild's moderators when an argument escalates past its threshold
+type Watcher struct {
+	aiService interfaces.AIService
+	repo      *repository.MoodRepository
+	session   *discordgo.Session
+	outbox    *outbox.Service
+	coolOff   time.Duration // Minimum time between warnings about a channel
+
+	mu       sync.Mutex
+	configs  map[int64]cachedWarningConfig
+	channels map[string]*watchedChannel
+}
+
+type cachedWarningConfig struct {
+	cfg      *models.ToxicityConfig // nil when the guild didn't set up warnings
+	loadedAt time.Time
+}
+
+type watchedChannel struct {
+	guildID   int64
+	recent    []chatLine // Scored, oldest first
+	pending   []chatLine // Waiting to be scored
+	scoring   bool
+	timer     *time.Timer
+	level     float64 // Rolling toxicity
+	updatedAt time.Time
+	warnedAt  time.Time
+}
+
+type chatLine struct {
+	messageID string
+	authorID  string
+	author    string
+	content   string
+	score     float64
+}
+
+// NewWatcher creates the early warning watcher; coolOff is the minimum time
+// between warnings about the same channel
+func NewWatcher(aiService interfaces.AIService, repo *repository.MoodRepository, session *discordgo.Session, coolOff time.Duration) *Watcher {
+	if coolOff <= 0 {
+		coolOff = defaultCoolOff
+	}
+	return &Watcher{
+		aiService: aiService,
+		repo:      repo,
+		session:   session,
+		coolOff:   coolOff,
+		configs:   make(map[int64]cachedWarningConfig),
+		channels:  make(map[string]*watchedChannel),
+	}
+}
+
+// SetOutbox posts warnings through the outbox, retrying failed sends
+func (w *Watcher) SetOutbox(outbox *outbox.Service) {
+	w.outbox = outbox
+}
+
+// Configure sets up or replaces a guild's early warnings
+func (w *Watcher) Configure(ctx context.Context, cfg *models.ToxicityConfig) error {
+	if cfg.Threshold <= 0 || cfg.Threshold > 1 {
+		cfg.Threshold = DefaultThreshold
+	}
+	if cfg.Timezone == "" {
+		cfg.Timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(cfg.Timezone); err != nil {
+		return fmt.Errorf("unknown timezone %q", cfg.Timezone)
+	}
+	if err := w.repo.SaveToxicityConfig(ctx, cfg); err != nil {
+		return err
+	}
+	w.cacheConfig(cfg.GuildID, cfg)
+	return nil
+}
+
+// Config returns a guild's early warning setup, or nil if it has none
+func (w *Watcher) Config(ctx context.Context, guildID int64) (*models.ToxicityConfig, error) {
+	w.mu.Lock()
+	cached, ok := w.configs[guildID]
+	w.mu.Unlock()
+	if ok && time.Since(cached.loadedAt) < warningConfigTTL {
+		return cached.cfg, nil
+	}
+	cfg, err := w.repo.GetToxicityConfig(ctx, guildID)
+	if err != nil {
+		return nil, err
+	}
+	w.cacheConfig(guildID, cfg)
+	return cfg, nil
+}
+
+func (w *Watcher) cacheConfig(guildID int64, cfg *models.ToxicityConfig) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.configs) >= maxWatched {
+		w.configs = make(map[int64]cachedWarningConfig)
+	}
+	w.configs[guildID] = cachedWarningConfig{cfg: cfg, loadedAt: time.Now()}
+}
+
+// Level returns a channel's current rolling toxicity
+func (w *Watcher) Level(channelID string) float64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	ch, ok := w.channels[channelID]
+	if !ok {
+		return 0
+	}
+	return decay(ch.level, time.Since(ch.updatedAt))
+}
+
+// Observe queues a new message for scoring, in guilds with warnings enabled
+func (w *Watcher) Observe(m *discordgo.MessageCreate) {
+	if m.GuildID == "" || m.Author == nil || m.Author.Bot || strings.TrimSpace(m.Content) == "" {
+		return
+	}
+	guildID, _ := strconv.ParseInt(m.GuildID, 10, 64)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	cfg, err := w.Config(ctx, guildID)
+	cancel()
+	if err != nil || cfg == nil || !cfg.Enabled {
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	ch, ok := w.channels[m.ChannelID]
+	if !ok {
+		if len(w.channels) >= maxWatched {
+			w.channels = make(map[string]*watchedChannel)
+		}
+		ch = &watchedChannel{guildID: guildID}
+		w.channels[m.ChannelID] = ch
+	}
+	ch.pending = append(ch.pending, chatLine{messageID: m.ID, authorID: m.Author.ID, author: m.Author.Username, content: m.Content})
+
+	switch {
+	case ch.scoring:
+		// Picked up when the batch being scored is done
+	case len(ch.pending) >= warningBatchSize:
+		w.startScoring(m.ChannelID, ch)
+	default:
+		w.scheduleScoring(m.ChannelID, ch)
+	}
+}
+
+// scheduleScoring scores a channel's pending messages after a short wait, so
+// a quiet channel doesn't wait for a full batch; w.mu must be held
+func (w *Watcher) scheduleScoring(channelID string, ch *watchedChannel) {
+	if ch.timer != nil {
+		return
+	}
+	ch.timer = time.AfterFunc(warningFlushDelay, func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		ch.timer = nil
+		if !ch.scoring && len(ch.pending) > 0 {
+			w.startScoring(channelID, ch)
+		}
+	})
+}
+
+// startScoring scores a channel's pending messages in the background; w.mu
+// must be held
+func (w *Watcher) startScoring(channelID string, ch *watchedChannel) {
+	if ch.timer != nil {
+		ch.timer.Stop()
+		ch.timer = nil
+	}
+	batch := ch.pending
+	earlier := append([]chatLine(nil), ch.recent...)
+	ch.pending = nil
+	ch.scoring = true
+
+	go func() {
+		scores, err := w.score(ch.guildID, earlier, batch)
+
+		w.mu.Lock()
+		ch.scoring = false
+		if err != nil {
+			log.Printf("⚠️ Toxicity scoring failed in channel %s: %v", channelID, err)
+		} else {
+			now := time.Now()
+			for n := range batch {
+				batch[n].score = scores[n]
+				ch.level = decay(ch.level, now.Sub(ch.updatedAt))
+				ch.level += (scores[n] - ch.level) * toxicityWeight
+				ch.updatedAt = now
+			}
+			ch.recent = append(ch.recent, batch...)
+			if len(ch.recent) > recentLines {
+				ch.recent = ch.recent[len(ch.recent)-recentLines:]
+			}
+		}
+		warn := err == nil && w.escalated(ch)
+		if warn {
+			ch.warnedAt = time.Now()
+		}
+		level := ch.level
+		lines := append([]chatLine(nil), ch.recent...)
+		// Messages that arrived meanwhile are scored next
+		if len(ch.pending) >= warningBatchSize {
+			w.startScoring(channelID, ch)
+		} else if len(ch.pending) > 0 {
+			w.scheduleScoring(channelID, ch)
+		}
+		w.mu.Unlock()
+
+		if warn {
+			w.warn(ch.guildID, channelID, level, lines)
+		}
+	}()
+}
+
+func (w *Watcher) score(guildID int64, earlier, batch []chatLine) ([]float64, error) {
+	ctx, cancel := context.WithTimeout(tenant.WithGuild(context.Background(), guildID), 30*time.Second)
+	defer cancel()
+
+	var preamble strings.Builder
+	if len(earlier) > 0 {
+		preamble.WriteString("Earlier messages, for context only:\n")
+		for _, line := range earlier[max(0, len(earlier)-10):] {
+			fmt.Fprintf(&preamble, "- %s: %s\n", line.author, clip(line.content))
+		}
+		preamble.WriteString("\nMessages to rate:\n")
+	}
+	lines := make([]string, len(batch))
+	for n, line := range batch {
+		lines[n] = line.author + ": " + line.content
+	}
+	scores, err := scoreLines(ctx, w.aiService, toxicitySystemPrompt, preamble.String(), lines)
+	if err != nil {
+		return nil, err
+	}
+	for n, score := range scores {
+		scores[n] = max(0, min(1, score))
+	}
+	return scores, nil
+}
+
+// escalated tells whether a channel's argument crossed the guild's threshold
+// and moderators weren't warned about it recently; w.mu must be held
+func (w *Watcher) escalated(ch *watchedChannel) bool {
+	cached, ok := w.configs[ch.guildID]
+	if !ok || cached.cfg == nil || !cached.cfg.Enabled || ch.level < cached.cfg.Threshold {
+		return false
+	}
+	if time.Since(ch.warnedAt) < w.coolOff {
+		return false
+	}
+	arguers := make(map[string]bool)
+	for _, line := range ch.recent[max(0, len(ch.recent)-10):] {
+		if line.score >= heatedScore {
+			arguers[line.authorID] = true
+		}
+	}
+	return len(arguers) >= minArguers
+}
+
+// warn posts a summary of a heated channel for the guild's moderators
+func (w *Watcher) warn(guildID int64, channelID string, level float64, lines []chatLine) {
+	ctx, cancel := context.WithTimeout(tenant.WithGuild(context.Background(), guildID), 30*time.Second)
+	defer cancel()
+	cfg, err := w.Config(ctx, guildID)
+	if err != nil || cfg == nil {
+		return
+	}
+
+	var transcript strings.Builder
+	for _, line := range lines {
+		fmt.Fprintf(&transcript, "%s: %s\n", line.author, clip(line.content))
+	}
+	summary, err := w.aiService.Complete(ctx, escalationSystemPrompt, transcript.String(), summaryMaxTokens)
+	if err != nil {
+		log.Printf("⚠️ Failed to summarize escalating argument: %v", err)
+		summary = "I couldn't summarize it; please take a look."
+	}
+
+	var content strings.Builder
+	mentions := &discordgo.MessageAllowedMentions{}
+	if quiet := inQuietHours(cfg, time.Now()); !quiet && cfg.ModRoleID != 0 {
+		fmt.Fprintf(&content, "<@&%d> ", cfg.ModRoleID)
+		mentions.Roles = []string{strconv.FormatInt(cfg.ModRoleID, 10)}
+	}
+	fmt.Fprintf(&content, "🚨 **An argument is heating up in <#%s>** (toxicity %.0f%%)\n%s", channelID, level*100, strings.TrimSpace(summary))
+	if last := lines[len(lines)-1]; last.messageID != "" {
+		fmt.Fprintf(&content, "\nhttps://discord.com/channels/%d/%s/%s", guildID, channelID, last.messageID)
+	}
+
+	alert := &discordgo.MessageSend{
+		Content:         truncate(content.String(), 2000),
+		AllowedMentions: mentions,
+	}
+	if w.outbox != nil {
+		err = w.outbox.SendChannel(ctx, "toxicity-alert", guildID, cfg.AlertChannelID, alert)
+	} else {
+		_, err = w.session.ChannelMessageSendComplex(strconv.FormatInt(cfg.AlertChannelID, 10), alert)
+	}
+	if err != nil {
+		log.Printf("❌ Failed to post toxicity warning: %v", err)
+		return
+	}
+	log.Printf("🚨 Warned moderators of guild %d about channel %s (toxicity %.2f)", guildID, channelID, level)
+}
+
+// inQuietHours tells whether moderators shouldn't be pinged at a moment
+func inQuietHours(cfg *models.ToxicityConfig, now time.Time) bool {
+	if cfg.QuietStart == cfg.QuietEnd {
+		return false
+	}
+	loc, err := time.LoadLocation(cfg.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	hour := now.In(loc).Hour()
+	if cfg.QuietStart < cfg.QuietEnd {
+		return hour >= cfg.QuietStart && hour < cfg.QuietEnd
+	}
+	return hour >= cfg.QuietStart || hour < cfg.QuietEnd
+}
+
+// decay cools a rolling score down over a quiet period
+func decay(level float64, elapsed time.Duration) float64 {
+	if elapsed <= 0 {
+		return level
+	}
+	return level * math.Pow(0.5, float64(elapsed)/float64(toxicityHalfLife))
+}
+
+func truncate(s string, n int) string {
+	if runes := []rune(s); len(runes) > n {
+		return string(runes[:n-1]) + "…"
+	}
+	return s
+}
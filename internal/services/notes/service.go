@@ -0,0 +1,67 @@
+// Package notes keeps semantic notebooks: snippets members save for
+// themselves or for a channel's team, recalled by meaning rather than by
+// keyword.
+package notes
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"discord-tars/internal/interfaces"
+	"discord-tars/internal/models"
+	"discord-tars/internal/repository"
+)
+
+const (
+	MaxNoteLength = 2000
+
+	findLimit         = 5
+	findMinSimilarity = 0.2
+)
+
+type Service struct {
+	aiService interfaces.AIService
+	repo      *repository.NoteRepository
+}
+
+func NewService(aiService interfaces.AIService, repo *repository.NoteRepository) *Service {
+	return &Service{aiService: aiService, repo: repo}
+}
+
+// Add saves a note to a channel's notebook, or to its author's personal one
+// when channelID is 0
+func (s *Service) Add(ctx context.Context, guildID, channelID, userID int64, content string) (*models.Note, error) {
+	content = strings.TrimSpace(content)
+	if content == "" || len(content) > MaxNoteLength {
+		return nil, fmt.Errorf("a note must be between 1 and %d characters", MaxNoteLength)
+	}
+
+	embedding, err := s.aiService.GenerateEmbedding(ctx, content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate note embedding: %w", err)
+	}
+	note := &models.Note{
+		GuildID:   guildID,
+		ChannelID: channelID,
+		UserID:    userID,
+		Content:   content,
+	}
+	if err := s.repo.Create(ctx, note, embedding); err != nil {
+		return nil, err
+	}
+
+	log.Printf("📝 Saved note %d of user %d (channel %d)", note.ID, userID, channelID)
+	return note, nil
+}
+
+// Find returns the notes closest in meaning to a query, from a channel's
+// notebook or from the user's personal one when channelID is 0
+func (s *Service) Find(ctx context.Context, userID, channelID int64, query string) ([]models.NoteResult, error) {
+	embedding, err := s.aiService.GenerateEmbedding(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate query embedding: %w", err)
+	}
+	return s.repo.Search(ctx, userID, channelID, embedding, findLimit, findMinSimilarity)
+}
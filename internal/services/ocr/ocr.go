@@ -0,0 +1,75 @@
+// Package ocr reads the text in images, such as screenshots of error
+// messages posted in chat, so what they say can be searched like messages.
+// The text is read by a vision model or, without sending images anywhere, by
+// a local tesseract binary.
+package ocr
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode"
+)
+
+const (
+	EngineVision    = "vision"
+	EngineTesseract = "tesseract"
+)
+
+// MaxImageBytes bounds the images read; larger ones are skipped
+const MaxImageBytes = 8 << 20
+
+// Engine reads the text in an image
+type Engine interface {
+	// Read returns the text in an image, or "" when it has none
+	Read(ctx context.Context, image []byte, contentType string) (string, error)
+}
+
+type Config struct {
+	// Engine is EngineVision or EngineTesseract; empty disables OCR
+	Engine string
+	// Vision model, its API key and the client sending its requests
+	APIKey     string
+	Model      string
+	HTTPClient *http.Client
+	// TesseractPath is the tesseract binary, and Languages the tesseract
+	// languages to read, e.g. "eng+fra"
+	TesseractPath string
+	Languages     string
+}
+
+// New returns the engine cfg names, or nil when OCR is disabled
+func New(cfg Config) (Engine, error) {
+	switch strings.ToLower(strings.TrimSpace(cfg.Engine)) {
+	case "", "off", "none":
+		return nil, nil
+	case EngineVision:
+		return NewVision(cfg.APIKey, cfg.Model, cfg.HTTPClient), nil
+	case EngineTesseract:
+		return NewTesseract(cfg.TesseractPath, cfg.Languages)
+	}
+	return nil, fmt.Errorf("unknown OCR engine %q, use %s or %s", cfg.Engine, EngineVision, EngineTesseract)
+}
+
+// Supported reports whether an image of a content type can be read
+func Supported(contentType string) bool {
+	switch strings.ToLower(contentType) {
+	case "image/png", "image/jpeg", "image/jpg", "image/webp", "image/gif":
+		return true
+	}
+	return false
+}
+
+// clean trims text read from an image and drops the lines without a letter
+// or digit, which are mostly misread borders and icons
+func clean(text string) string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.IndexFunc(line, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) >= 0 {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,43 @@
+package ocr
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Tesseract reads images with a local tesseract binary, so they never leave
+// the host
+type Tesseract struct {
+	path      string
+	languages string
+}
+
+// NewTesseract finds the tesseract binary at path, or on the PATH when it is
+// empty; languages defaults to English
+func NewTesseract(path, languages string) (*Tesseract, error) {
+	if path == "" {
+		path = "tesseract"
+	}
+	resolved, err := exec.LookPath(path)
+	if err != nil {
+		return nil, fmt.Errorf("tesseract not found: %w", err)
+	}
+	if languages == "" {
+		languages = "eng"
+	}
+	return &Tesseract{path: resolved, languages: languages}, nil
+}
+
+func (t *Tesseract) Read(ctx context.Context, image []byte, contentType string) (string, error) {
+	cmd := exec.CommandContext(ctx, t.path, "stdin", "stdout", "-l", t.languages)
+	cmd.Stdin = bytes.NewReader(image)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("tesseract failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return clean(stdout.String()), nil
+}
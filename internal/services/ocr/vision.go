@@ -0,0 +1,73 @@
+package ocr
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+
+	openaiService "discord-tars/internal/services/openai"
+)
+
+const (
+	visionMaxTokens = 1500
+	// noText is how the model says an image has no text
+	noText = "NO_TEXT"
+)
+
+const visionPrompt = `Transcribe all the text in this image exactly as written, keeping line breaks, code, error messages, file paths and numbers as they are.
+Don't describe the image or add anything. If it has no readable text, reply ` + noText + `.`
+
+// Vision reads images with an OpenAI vision model
+type Vision struct {
+	client *openai.Client
+	model  string
+}
+
+// NewVision creates a vision engine; model defaults to gpt-4o-mini
+func NewVision(apiKey, model string, httpClient *http.Client) *Vision {
+	if model == "" {
+		model = openai.GPT4oMini
+	}
+	return &Vision{client: openaiService.NewClient(apiKey, httpClient), model: model}
+}
+
+func (v *Vision) Read(ctx context.Context, image []byte, contentType string) (string, error) {
+	if contentType == "image/jpg" {
+		contentType = "image/jpeg"
+	}
+	req := openai.ChatCompletionRequest{
+		Model: v.model,
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role: openai.ChatMessageRoleUser,
+				MultiContent: []openai.ChatMessagePart{
+					{Type: openai.ChatMessagePartTypeText, Text: visionPrompt},
+					{Type: openai.ChatMessagePartTypeImageURL, ImageURL: &openai.ChatMessageImageURL{
+						URL:    fmt.Sprintf("data:%s;base64,%s", contentType, base64.StdEncoding.EncodeToString(image)),
+						Detail: openai.ImageURLDetailHigh,
+					}},
+				},
+			},
+		},
+		MaxTokens:   visionMaxTokens,
+		Temperature: 0,
+	}
+
+	resp, err := v.client.CreateChatCompletion(ctx, req)
+	if err != nil {
+		return "", fmt.Errorf("openai api error: %w", err)
+	}
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("no response from openai")
+	}
+	openaiService.RecordUsage(ctx, req.Model, resp.Usage)
+	text := strings.TrimSpace(resp.Choices[0].Message.Content)
+	if text == noText {
+		return "", nil
+	}
+	return clean(text), nil
+}
@@ -0,0 +1,228 @@
+package onboarding
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"discord-tars/internal/interfaces"
+	"discord-tars/internal/models"
+	"discord-tars/internal/repository"
+	"discord-tars/internal/services/rag"
+	"discord-tars/internal/tenant"
+)
+
+const (
+	// MaxQuestions is how many questions a new member gets answered before onboarding ends
+	MaxQuestions = 5
+	// Window is how long after joining a member is considered new
+	Window = 7 * 24 * time.Hour
+
+	welcomeMaxTokens = 350
+	answerMaxTokens  = 450
+	maxChannels      = 40
+)
+
+const welcomeSystemPrompt = `You are T.A.R.S, welcoming a new member to a Discord server.
+Write a short, friendly welcome (under 120 words) with a hint of dry humor.
+Mention 2-4 of the listed channels that are most useful for newcomers using their <#id> mention exactly as given.
+End by inviting them to reply with their interests or any question about the server.`
+
+const answerSystemPrompt = `You are T.A.R.S, helping a new member get started on a Discord server.
+Answer their message using ONLY the server knowledge provided (rules, pinned messages, FAQ). If the knowledge
+does not cover it, say so honestly and suggest asking a moderator.
+If they mention interests, recommend up to 3 relevant channels from the channel list using their <#id> mention exactly as given.
+Keep the answer under 150 words.`
+
+type Service struct {
+	aiService      interfaces.AIService
+	ragService     *rag.Service
+	onboardingRepo *repository.OnboardingRepository
+	session        *discordgo.Session
+}
+
+func NewService(aiService interfaces.AIService, ragService *rag.Service, onboardingRepo *repository.OnboardingRepository, session *discordgo.Session) *Service {
+	return &Service{
+		aiService:      aiService,
+		ragService:     ragService,
+		onboardingRepo: onboardingRepo,
+		session:        session,
+	}
+}
+
+// Configure saves a guild's onboarding settings
+func (s *Service) Configure(ctx context.Context, cfg *models.OnboardingConfig) error {
+	if cfg.Mode != models.OnboardingModeDM && cfg.Mode != models.OnboardingModeChannel {
+		return fmt.Errorf("unknown onboarding mode %q", cfg.Mode)
+	}
+	if cfg.Mode == models.OnboardingModeChannel && cfg.WelcomeChannelID == 0 {
+		return fmt.Errorf("channel mode needs a welcome channel")
+	}
+	return s.onboardingRepo.SaveConfig(ctx, cfg)
+}
+
+// GetConfig returns a guild's onboarding settings, or nil if not configured
+func (s *Service) GetConfig(ctx context.Context, guildID int64) (*models.OnboardingConfig, error) {
+	return s.onboardingRepo.GetConfig(ctx, guildID)
+}
+
+// Welcome greets a member who just joined, if onboarding is enabled in their guild
+func (s *Service) Welcome(ctx context.Context, member *discordgo.Member) error {
+	if member.User == nil || member.User.Bot {
+		return nil
+	}
+
+	guildID, _ := strconv.ParseInt(member.GuildID, 10, 64)
+	ctx = tenant.WithGuild(ctx, guildID)
+	cfg, err := s.onboardingRepo.GetConfig(ctx, guildID)
+	if err != nil {
+		return err
+	}
+	if cfg == nil || !cfg.Enabled {
+		return nil
+	}
+
+	guildName := "the server"
+	if guild, err := s.session.State.Guild(member.GuildID); err == nil {
+		guildName = guild.Name
+	}
+
+	prompt := fmt.Sprintf("Server: %s\nNew member: %s\n", guildName, member.User.Username)
+	if cfg.Greeting != "" {
+		prompt += fmt.Sprintf("Admin-provided intro to include in spirit: %s\n", cfg.Greeting)
+	}
+	prompt += "\nChannels:\n" + s.channelDirectory(member.GuildID)
+
+	welcome, err := s.aiService.Complete(ctx, welcomeSystemPrompt, prompt, welcomeMaxTokens)
+	if err != nil {
+		log.Printf("⚠️ Failed to generate welcome, using fallback: %v", err)
+		welcome = fmt.Sprintf("👋 Welcome to **%s**, %s! I'm T.A.R.S. Reply here with your interests or any question about the server and I'll point you in the right direction.", guildName, member.User.Username)
+	}
+
+	targetChannel := strconv.FormatInt(cfg.WelcomeChannelID, 10)
+	if cfg.Mode == models.OnboardingModeDM {
+		dm, err := s.session.UserChannelCreate(member.User.ID)
+		if err != nil {
+			return fmt.Errorf("failed to open DM channel: %w", err)
+		}
+		targetChannel = dm.ID
+	} else {
+		welcome = fmt.Sprintf("<@%s> %s", member.User.ID, welcome)
+	}
+
+	if _, err := s.session.ChannelMessageSend(targetChannel, welcome); err != nil {
+		return fmt.Errorf("failed to send welcome: %w", err)
+	}
+
+	userID, _ := strconv.ParseInt(member.User.ID, 10, 64)
+	if err := s.onboardingRepo.StartMember(ctx, guildID, userID, time.Now()); err != nil {
+		return err
+	}
+
+	log.Printf("👋 Welcomed %s to guild %s (%s)", member.User.Username, member.GuildID, cfg.Mode)
+	return nil
+}
+
+// HandleDirectMessage answers a DM from a member who is still being onboarded.
+// It returns false when the user is not in onboarding so the caller can fall back.
+func (s *Service) HandleDirectMessage(ctx context.Context, userID int64, content string) (string, bool, error) {
+	member, err := s.onboardingRepo.GetActiveMember(ctx, userID)
+	if err != nil {
+		return "", false, err
+	}
+	if member == nil || time.Since(member.JoinedAt) > Window {
+		return "", false, nil
+	}
+
+	cfg, err := s.onboardingRepo.GetConfig(ctx, member.GuildID)
+	if err != nil {
+		return "", false, err
+	}
+	if cfg == nil || !cfg.Enabled {
+		return "", false, nil
+	}
+
+	answer, err := s.answer(ctx, cfg, strconv.FormatInt(member.GuildID, 10), content)
+	if err != nil {
+		return "", true, err
+	}
+
+	last := member.QuestionsAsked+1 >= MaxQuestions
+	if err := s.onboardingRepo.RecordQuestion(ctx, member.ID, last); err != nil {
+		log.Printf("⚠️ Failed to record onboarding question: %v", err)
+	}
+	if last {
+		answer += "\n\n*That's the end of your onboarding Q&A — you can keep asking me anything with `/ask` in the server.*"
+	}
+	return answer, true, nil
+}
+
+func (s *Service) answer(ctx context.Context, cfg *models.OnboardingConfig, guildID, question string) (string, error) {
+	ctx = tenant.WithGuild(ctx, cfg.GuildID)
+	channelIDs := make([]int64, 0, len(cfg.KnowledgeChannelIDs))
+	for _, id := range cfg.KnowledgeChannelIDs {
+		if v, err := strconv.ParseInt(id, 10, 64); err == nil {
+			channelIDs = append(channelIDs, v)
+		}
+	}
+
+	knowledge, err := s.ragService.SearchChannels(ctx, question, cfg.GuildID, channelIDs, 8)
+	if err != nil {
+		log.Printf("⚠️ Failed to search onboarding knowledge: %v", err)
+	}
+
+	gid, _ := strconv.ParseInt(guildID, 10, 64)
+	pinned, err := s.ragService.SearchPriority(ctx, gid, question, 4)
+	if err != nil {
+		log.Printf("⚠️ Failed to search priority knowledge: %v", err)
+	}
+
+	var prompt strings.Builder
+	prompt.WriteString("Server knowledge:\n")
+	if len(knowledge) == 0 && len(pinned) == 0 {
+		prompt.WriteString("(none found)\n")
+	}
+	for _, result := range pinned {
+		prompt.WriteString(fmt.Sprintf("- 📌 #%s: %s\n", result.Document.ChannelName, result.Document.Content))
+	}
+	for _, result := range knowledge {
+		prompt.WriteString(fmt.Sprintf("- #%s: %s\n", result.Channel.Name, result.Message.Content))
+	}
+	prompt.WriteString("\nChannels:\n")
+	prompt.WriteString(s.channelDirectory(guildID))
+	prompt.WriteString(fmt.Sprintf("\nNew member's message: %s", question))
+
+	return s.aiService.Complete(ctx, answerSystemPrompt, prompt.String(), answerMaxTokens)
+}
+
+// channelDirectory lists the guild's text channels with topics for channel recommendations
+func (s *Service) channelDirectory(guildID string) string {
+	channels, err := s.session.GuildChannels(guildID)
+	if err != nil {
+		log.Printf("⚠️ Failed to list guild channels: %v", err)
+		return "(unavailable)\n"
+	}
+
+	var sb strings.Builder
+	count := 0
+	for _, ch := range channels {
+		if ch.Type != discordgo.ChannelTypeGuildText && ch.Type != discordgo.ChannelTypeGuildForum {
+			continue
+		}
+		if count >= maxChannels {
+			break
+		}
+		sb.WriteString(fmt.Sprintf("- <#%s> (%s)", ch.ID, ch.Name))
+		if ch.Topic != "" {
+			sb.WriteString(": " + ch.Topic)
+		}
+		sb.WriteString("\n")
+		count++
+	}
+	return sb.String()
+}
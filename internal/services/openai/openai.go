@@ -2,45 +2,92 @@ package openai
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"log"
+	"net/http"
 	"strings"
 
 	"github.com/sashabaranov/go-openai"
+
+	"discord-tars/internal/interfaces"
+	"discord-tars/internal/services/persona"
 )
 
+// maxToolRounds bounds how many times the model may call tools for one answer
+const maxToolRounds = 3
+
 type Service struct {
-	client       *openai.Client
-	model        string
-	humorLevel   int
-	honestyLevel int
+	client         *openai.Client
+	model          string
+	embeddingModel string
+	modelOverrides bool
+	humorLevel     int
+	honestyLevel   int
 }
 
 type Config struct {
-	APIKey string
-	Model  string
+	APIKey         string
+	Model          string
+	EmbeddingModel string // Must produce 1536-dimension vectors to fit the schema
+	// HTTPClient sends the API requests, e.g. to record or replay them; nil
+	// uses the default client
+	HTTPClient *http.Client
+	// ModelOverrides lets a request's context pick the chat model, as a
+	// canary rollout does; guilds' own keys keep the model they chose
+	ModelOverrides bool
+}
+
+type modelKey struct{}
+
+// WithModel makes chat requests made with the context use a model instead
+// of the service's, where the service allows it
+func WithModel(ctx context.Context, model string) context.Context {
+	if model == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, modelKey{}, model)
 }
 
 // NewService creates a new OpenAI service instance
 func NewService(cfg Config) *Service {
-	client := openai.NewClient(cfg.APIKey)
+	client := NewClient(cfg.APIKey, cfg.HTTPClient)
 	model := cfg.Model
 	if model == "" {
 		model = openai.GPT4oMini
 	}
+	embeddingModel := cfg.EmbeddingModel
+	if embeddingModel == "" {
+		embeddingModel = string(openai.SmallEmbedding3)
+	}
 
 	return &Service{
-		client:       client,
-		model:        model,
-		humorLevel:   75,  // Default T.A.R.S humor level
-		honestyLevel: 100, // Default T.A.R.S honesty level
+		client:         client,
+		model:          model,
+		embeddingModel: embeddingModel,
+		modelOverrides: cfg.ModelOverrides,
+		humorLevel:     75,  // Default T.A.R.S humor level
+		honestyLevel:   100, // Default T.A.R.S honesty level
+	}
+}
+
+// NewClient creates an OpenAI API client sending its requests with
+// httpClient, or the default client when nil
+func NewClient(apiKey string, httpClient *http.Client) *openai.Client {
+	if httpClient == nil {
+		return openai.NewClient(apiKey)
 	}
+	config := openai.DefaultConfig(apiKey)
+	config.HTTPClient = httpClient
+	return openai.NewClientWithConfig(config)
 }
 
 func (s *Service) GenerateResponse(ctx context.Context, userMessage, username string) (string, error) {
-	systemPrompt := s.buildSystemPrompt()
+	systemPrompt := s.buildSystemPrompt(ctx)
 
 	req := openai.ChatCompletionRequest{
-		Model: s.model,
+		Model: s.chatModel(ctx),
 		Messages: []openai.ChatCompletionMessage{
 			{
 				Role:    openai.ChatMessageRoleSystem,
@@ -51,7 +98,7 @@ func (s *Service) GenerateResponse(ctx context.Context, userMessage, username st
 				Content: fmt.Sprintf("User %s asks: %s", username, userMessage),
 			},
 		},
-		MaxTokens:   500,
+		MaxTokens:   persona.MaxTokensFor(ctx),
 		Temperature: 0.7,
 	}
 
@@ -63,21 +110,200 @@ func (s *Service) GenerateResponse(ctx context.Context, userMessage, username st
 	if len(resp.Choices) == 0 {
 		return "", fmt.Errorf("no response from openai")
 	}
+	recordUsage(ctx, req.Model, resp.Usage, false)
+	noteFinish(ctx, resp.Choices[0].FinishReason)
 
 	response := strings.TrimSpace(resp.Choices[0].Message.Content)
-	return s.enhanceResponse(response), nil
+	return s.enhanceResponse(ctx, response), nil
+}
+
+// StreamResponse answers like GenerateResponse, passing the text to onText
+// as it is generated. The persona touches of GenerateResponse are left out,
+// since the text may already be on its way to the user.
+func (s *Service) StreamResponse(ctx context.Context, userMessage, username string, onText func(text string)) (string, error) {
+	req := openai.ChatCompletionRequest{
+		Model: s.chatModel(ctx),
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role:    openai.ChatMessageRoleSystem,
+				Content: s.buildSystemPrompt(ctx),
+			},
+			{
+				Role:    openai.ChatMessageRoleUser,
+				Content: fmt.Sprintf("User %s asks: %s", username, userMessage),
+			},
+		},
+		MaxTokens:   persona.MaxTokensFor(ctx),
+		Temperature: 0.7,
+		Stream:      true,
+		// The last chunk then carries the token counts
+		StreamOptions: &openai.StreamOptions{IncludeUsage: true},
+	}
+
+	stream, err := s.client.CreateChatCompletionStream(ctx, req)
+	if err != nil {
+		return "", fmt.Errorf("openai api error: %w", err)
+	}
+	defer stream.Close()
+
+	var response strings.Builder
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return response.String(), fmt.Errorf("openai stream error: %w", err)
+		}
+		if chunk.Usage != nil {
+			recordUsage(ctx, req.Model, *chunk.Usage, false)
+		}
+		if len(chunk.Choices) > 0 {
+			noteFinish(ctx, chunk.Choices[0].FinishReason)
+		}
+		if len(chunk.Choices) == 0 || chunk.Choices[0].Delta.Content == "" {
+			continue
+		}
+		response.WriteString(chunk.Choices[0].Delta.Content)
+		onText(chunk.Choices[0].Delta.Content)
+	}
+
+	if response.Len() == 0 {
+		return "", fmt.Errorf("no response from openai")
+	}
+	return strings.TrimSpace(response.String()), nil
+}
+
+// GenerateResponseWithTools answers like GenerateResponse but lets the model call the
+// given tools, feeding their results back until it produces a final answer
+func (s *Service) GenerateResponseWithTools(ctx context.Context, userMessage, username string, tools []interfaces.Tool) (string, error) {
+	if len(tools) == 0 {
+		return s.GenerateResponse(ctx, userMessage, username)
+	}
+
+	handlers := make(map[string]interfaces.Tool, len(tools))
+	definitions := make([]openai.Tool, 0, len(tools))
+	for _, tool := range tools {
+		handlers[tool.Name] = tool
+		definitions = append(definitions, openai.Tool{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        tool.Name,
+				Description: tool.Description,
+				Parameters:  tool.Parameters,
+			},
+		})
+	}
+
+	messages := []openai.ChatCompletionMessage{
+		{
+			Role:    openai.ChatMessageRoleSystem,
+			Content: s.buildSystemPrompt(ctx),
+		},
+		{
+			Role:    openai.ChatMessageRoleUser,
+			Content: fmt.Sprintf("User %s asks: %s", username, userMessage),
+		},
+	}
+
+	for round := 0; ; round++ {
+		req := openai.ChatCompletionRequest{
+			Model:       s.chatModel(ctx),
+			Messages:    messages,
+			MaxTokens:   persona.MaxTokensFor(ctx),
+			Temperature: 0.7,
+		}
+		// Withhold tools on the last round so the model has to answer
+		if round < maxToolRounds {
+			req.Tools = definitions
+		}
+
+		resp, err := s.client.CreateChatCompletion(ctx, req)
+		if err != nil {
+			return "", fmt.Errorf("openai api error: %w", err)
+		}
+		if len(resp.Choices) == 0 {
+			return "", fmt.Errorf("no response from openai")
+		}
+		recordUsage(ctx, req.Model, resp.Usage, false)
+
+		msg := resp.Choices[0].Message
+		if len(msg.ToolCalls) == 0 {
+			noteFinish(ctx, resp.Choices[0].FinishReason)
+			return s.enhanceResponse(ctx, strings.TrimSpace(msg.Content)), nil
+		}
+
+		messages = append(messages, msg)
+		for _, call := range msg.ToolCalls {
+			result := s.runTool(ctx, handlers, call)
+			messages = append(messages, openai.ChatCompletionMessage{
+				Role:       openai.ChatMessageRoleTool,
+				Content:    result,
+				ToolCallID: call.ID,
+			})
+		}
+	}
+}
+
+func (s *Service) runTool(ctx context.Context, handlers map[string]interfaces.Tool, call openai.ToolCall) string {
+	tool, ok := handlers[call.Function.Name]
+	if !ok {
+		return fmt.Sprintf("error: unknown tool %q", call.Function.Name)
+	}
+
+	log.Printf("🛠️ Running tool %s", tool.Name)
+	result, err := tool.Handler(ctx, call.Function.Arguments)
+	if err != nil {
+		log.Printf("⚠️ Tool %s failed: %v", tool.Name, err)
+		return "error: " + err.Error()
+	}
+	return result
+}
+
+// Complete runs a plain chat completion with the given system and user prompts,
+// without the T.A.R.S persona. It is used for summaries and other utility tasks.
+func (s *Service) Complete(ctx context.Context, systemPrompt, userPrompt string, maxTokens int) (string, error) {
+	req := openai.ChatCompletionRequest{
+		Model: s.chatModel(ctx),
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role:    openai.ChatMessageRoleSystem,
+				Content: systemPrompt,
+			},
+			{
+				Role:    openai.ChatMessageRoleUser,
+				Content: userPrompt,
+			},
+		},
+		MaxTokens:   maxTokens,
+		Temperature: 0.3,
+	}
+
+	resp, err := s.client.CreateChatCompletion(ctx, req)
+	if err != nil {
+		return "", fmt.Errorf("openai api error: %w", err)
+	}
+
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("no response from openai")
+	}
+	recordUsage(ctx, req.Model, resp.Usage, false)
+	noteFinish(ctx, resp.Choices[0].FinishReason)
+
+	return strings.TrimSpace(resp.Choices[0].Message.Content), nil
 }
 
 func (s *Service) GenerateEmbedding(ctx context.Context, text string) ([]float32, error) {
 	req := openai.EmbeddingRequest{
 		Input: []string{text},
-		Model: openai.SmallEmbedding3,
+		Model: openai.EmbeddingModel(s.embeddingModel),
 	}
 
 	resp, err := s.client.CreateEmbeddings(ctx, req)
 	if err != nil {
 		return nil, fmt.Errorf("embedding api error: %w", err)
 	}
+	recordUsage(ctx, string(req.Model), resp.Usage, true)
 
 	if len(resp.Data) == 0 {
 		return nil, fmt.Errorf("no embedding data received")
@@ -95,32 +321,25 @@ func (s *Service) SetPersonality(humor, honesty int) {
 	}
 }
 
-func (s *Service) buildSystemPrompt() string {
-	basePrompt := `You are T.A.R.S, an AI assistant from the movie Interstellar. You are:
-- Sarcastic but helpful
-- Highly intelligent and logical
-- Sometimes humorous with a dry wit
-- Always honest
-- Efficient in your responses
-- Knowledgeable about science, technology, and general topics`
-
-	// Adjust prompt based on personality settings
-	if s.humorLevel == 0 {
-		basePrompt += "\n\nIMPORTANT: Humor setting is disabled. Respond with technical precision and no jokes."
-	} else if s.humorLevel > 90 {
-		basePrompt += "\n\nIMPORTANT: Humor setting is at maximum. Use more jokes, puns, and witty remarks."
+// chatModel is the model for a chat request made with the context
+func (s *Service) chatModel(ctx context.Context) string {
+	if model, ok := ctx.Value(modelKey{}).(string); ok && s.modelOverrides {
+		return model
 	}
+	return s.model
+}
 
-	basePrompt += fmt.Sprintf("\n\nCurrent settings: Humor %d%%, Honesty %d%%", s.humorLevel, s.honestyLevel)
-	basePrompt += "\n\nKeep responses concise but informative. Use occasional humor when appropriate."
+func (s *Service) buildSystemPrompt(ctx context.Context) string {
+	return persona.PromptFor(ctx, s.humorLevel, s.honestyLevel)
+}
 
-	return basePrompt
+func (s *Service) enhanceResponse(ctx context.Context, response string) string {
+	return persona.EnhanceResponseFor(ctx, response)
 }
 
-func (s *Service) enhanceResponse(response string) string {
-	// Add T.A.R.S signature touch for short responses
-	if !strings.Contains(response, "T.A.R.S") && len(response) < 100 {
-		response = "🤖 " + response
+// noteFinish reports a completion that stopped at its token budget
+func noteFinish(ctx context.Context, reason openai.FinishReason) {
+	if reason == openai.FinishReasonLength {
+		persona.ReportTruncated(ctx)
 	}
-	return response
 }
@@ -0,0 +1,58 @@
+package openai_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	openaiService "discord-tars/internal/services/openai"
+	"discord-tars/internal/vcr"
+)
+
+// fixturesDir holds responses recorded from the API, replayed offline
+const fixturesDir = "testdata/ai"
+
+func offlineService() *openaiService.Service {
+	return openaiService.NewService(openaiService.Config{
+		APIKey:     "offline",
+		Model:      "gpt-4o-mini",
+		HTTPClient: vcr.NewClient(vcr.Config{Mode: vcr.ModeFor(true, false), Dir: fixturesDir}),
+	})
+}
+
+func TestCompleteReplaysRecording(t *testing.T) {
+	before := openaiService.Usage()
+
+	summary, err := offlineService().Complete(context.Background(),
+		"Summarize the conversation in one sentence.",
+		"alice: can we ship on Friday?\nbob: yes, if we freeze merges Thursday evening\nalice: deal",
+		60)
+	if err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+	want := "The team agreed to ship the release on Friday and to freeze merges on Thursday evening."
+	if summary != want {
+		t.Errorf("Complete = %q, want %q", summary, want)
+	}
+
+	after := openaiService.Usage()
+	if got := after.PromptTokens - before.PromptTokens; got != 41 {
+		t.Errorf("counted %d prompt tokens, want 41", got)
+	}
+	if got := after.CompletionTokens - before.CompletionTokens; got != 18 {
+		t.Errorf("counted %d completion tokens, want 18", got)
+	}
+	if got := after.ByModel["gpt-4o-mini"] - before.ByModel["gpt-4o-mini"]; got != 59 {
+		t.Errorf("counted %d tokens for gpt-4o-mini, want 59", got)
+	}
+}
+
+func TestCompleteWithoutRecordingAnswersOffline(t *testing.T) {
+	answer, err := offlineService().Complete(context.Background(), "Translate to French.", "Good morning", 20)
+	if err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+	if !strings.HasPrefix(answer, "(offline)") {
+		t.Errorf("Complete = %q, want the offline stand-in reply", answer)
+	}
+}
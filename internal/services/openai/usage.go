@@ -0,0 +1,70 @@
+package openai
+
+import (
+	"context"
+	"sync"
+
+	"github.com/sashabaranov/go-openai"
+
+	"discord-tars/internal/tenant"
+)
+
+// TokenUsage is what the bot spent on the API since it started, across the
+// shared key and guilds' own
+type TokenUsage struct {
+	Requests         int64            `json:"requests"`
+	PromptTokens     int64            `json:"prompt_tokens"`
+	CompletionTokens int64            `json:"completion_tokens"`
+	EmbeddingTokens  int64            `json:"embedding_tokens"`
+	ByModel          map[string]int64 `json:"by_model"` // Total tokens
+	ByGuild          map[int64]int64  `json:"by_guild"` // Total tokens of requests tagged with a guild
+}
+
+// usage counts tokens for every Service, since guilds with their own key
+// get services of their own
+var usage = struct {
+	sync.Mutex
+	TokenUsage
+}{TokenUsage: TokenUsage{ByModel: make(map[string]int64), ByGuild: make(map[int64]int64)}}
+
+// Usage returns the tokens spent since the bot started
+func Usage() TokenUsage {
+	usage.Lock()
+	defer usage.Unlock()
+
+	snapshot := usage.TokenUsage
+	snapshot.ByModel = make(map[string]int64, len(usage.ByModel))
+	for model, tokens := range usage.ByModel {
+		snapshot.ByModel[model] = tokens
+	}
+	snapshot.ByGuild = make(map[int64]int64, len(usage.ByGuild))
+	for guildID, tokens := range usage.ByGuild {
+		snapshot.ByGuild[guildID] = tokens
+	}
+	return snapshot
+}
+
+// RecordUsage counts the tokens of a chat completion made with a client of
+// NewClient rather than through a Service
+func RecordUsage(ctx context.Context, model string, u openai.Usage) {
+	recordUsage(ctx, model, u, false)
+}
+
+// recordUsage adds a response's token counts; embeddings only have prompt tokens
+func recordUsage(ctx context.Context, model string, u openai.Usage, embedding bool) {
+	usage.Lock()
+	defer usage.Unlock()
+
+	usage.Requests++
+	if embedding {
+		usage.EmbeddingTokens += int64(u.PromptTokens)
+	} else {
+		usage.PromptTokens += int64(u.PromptTokens)
+		usage.CompletionTokens += int64(u.CompletionTokens)
+	}
+	total := int64(u.PromptTokens + u.CompletionTokens)
+	usage.ByModel[model] += total
+	if guildID, ok := tenant.GuildFrom(ctx); ok {
+		usage.ByGuild[guildID] += total
+	}
+}
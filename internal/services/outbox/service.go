@@ -0,0 +1,231 @@
+// Package outbox delivers the messages the bot commits to sending, such as
+// digests, reminders and alerts. Each is stored before its first attempt;
+// failed sends are retried with backoff by a scheduled dispatcher, and
+// messages Discord refuses for good are kept for operators to review.
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/repository"
+	"discord-tars/internal/server"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	DefaultMaxAttempts = 8
+
+	// An attempt in flight for longer is presumed lost with its process
+	sendLease = 2 * time.Minute
+	// Delay before the first retry, doubled for each later one
+	retryBase     = 30 * time.Second
+	maxRetryDelay = time.Hour
+	dispatchBatch = 50
+)
+
+type Config struct {
+	MaxAttempts int           // Sends before a message is marked failed; defaults to DefaultMaxAttempts
+	Retention   time.Duration // How long sent and failed messages are kept; zero keeps them
+}
+
+type Service struct {
+	repo    *repository.OutboxRepository
+	session *discordgo.Session
+	config  Config
+}
+
+func NewService(repo *repository.OutboxRepository, session *discordgo.Session, config Config) *Service {
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = DefaultMaxAttempts
+	}
+	return &Service{repo: repo, session: session, config: config}
+}
+
+// SendChannel posts a message to a channel, retrying until it is delivered.
+// kind names the feature sending it, for operators. It only fails when the
+// message could not be stored.
+func (s *Service) SendChannel(ctx context.Context, kind string, guildID, channelID int64, msg *discordgo.MessageSend) error {
+	return s.send(ctx, &models.OutboxMessage{Kind: kind, GuildID: guildID, ChannelID: channelID}, msg)
+}
+
+// SendDM sends a message to a user by DM, like SendChannel
+func (s *Service) SendDM(ctx context.Context, kind string, guildID, userID int64, msg *discordgo.MessageSend) error {
+	return s.send(ctx, &models.OutboxMessage{Kind: kind, GuildID: guildID, UserID: userID}, msg)
+}
+
+func (s *Service) send(ctx context.Context, row *models.OutboxMessage, msg *discordgo.MessageSend) error {
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s message: %w", row.Kind, err)
+	}
+	row.Payload = string(payload)
+	if err := s.repo.Add(ctx, row, time.Now().Add(sendLease)); err != nil {
+		return err
+	}
+	s.attempt(ctx, row, msg)
+	return nil
+}
+
+// Dispatch is the scheduler job: it retries the messages whose next attempt is due
+func (s *Service) Dispatch(ctx context.Context) error {
+	for ctx.Err() == nil {
+		rows, err := s.repo.ClaimDue(ctx, dispatchBatch, time.Now().Add(sendLease))
+		if err != nil {
+			return err
+		}
+		for n := range rows {
+			// Unsent claims are picked up again once their lease expires
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			row := &rows[n]
+			var msg discordgo.MessageSend
+			if err := json.Unmarshal([]byte(row.Payload), &msg); err != nil {
+				s.record(ctx, row, fmt.Errorf("failed to decode message: %w", err), true)
+				continue
+			}
+			s.attempt(ctx, row, &msg)
+		}
+		if len(rows) < dispatchBatch {
+			return nil
+		}
+	}
+	return ctx.Err()
+}
+
+// attempt sends a message and records the outcome
+func (s *Service) attempt(ctx context.Context, row *models.OutboxMessage, msg *discordgo.MessageSend) {
+	sent, err := s.deliver(ctx, row, msg)
+	if err == nil {
+		// The message is out; recording it must not be cut short
+		if err := s.repo.MarkSent(context.WithoutCancel(ctx), row.ID, parseID(sent.ID)); err != nil {
+			log.Printf("❌ %v", err)
+		}
+		return
+	}
+	s.record(ctx, row, err, permanent(err))
+}
+
+func (s *Service) deliver(ctx context.Context, row *models.OutboxMessage, msg *discordgo.MessageSend) (*discordgo.Message, error) {
+	channelID := strconv.FormatInt(row.ChannelID, 10)
+	if row.ChannelID == 0 {
+		dm, err := s.session.UserChannelCreate(strconv.FormatInt(row.UserID, 10), discordgo.WithContext(ctx))
+		if err != nil {
+			return nil, fmt.Errorf("failed to open DM channel: %w", err)
+		}
+		channelID = dm.ID
+	}
+	return s.session.ChannelMessageSendComplex(channelID, msg, discordgo.WithContext(ctx))
+}
+
+// record schedules the next attempt of a failed send, or gives up on it
+func (s *Service) record(ctx context.Context, row *models.OutboxMessage, cause error, permanent bool) {
+	ctx = context.WithoutCancel(ctx)
+	if permanent || row.Attempts >= s.config.MaxAttempts {
+		if err := s.repo.Fail(ctx, row.ID, cause); err != nil {
+			log.Printf("❌ %v", err)
+		}
+		log.Printf("❌ Gave up on %s message %d after %d attempts: %v", row.Kind, row.ID, row.Attempts, cause)
+		return
+	}
+	at := time.Now().Add(retryDelay(row.Attempts))
+	if err := s.repo.Retry(ctx, row.ID, cause, at); err != nil {
+		log.Printf("❌ %v", err)
+	}
+	log.Printf("⚠️ Failed to send %s message %d (attempt %d/%d), retrying at %s: %v", row.Kind, row.ID, row.Attempts, s.config.MaxAttempts, at.Format(time.RFC3339), cause)
+}
+
+// permanent tells whether Discord refused a message in a way retrying won't
+// fix, e.g. a deleted channel, missing permissions or closed DMs
+func permanent(err error) bool {
+	var restErr *discordgo.RESTError
+	if !errors.As(err, &restErr) || restErr.Response == nil {
+		return false
+	}
+	status := restErr.Response.StatusCode
+	return status >= 400 && status < 500 && status != http.StatusTooManyRequests
+}
+
+// retryDelay is the backoff after a message's nth failed attempt
+func retryDelay(attempt int) time.Duration {
+	delay := retryBase
+	for n := 1; n < attempt && delay < maxRetryDelay; n++ {
+		delay *= 2
+	}
+	return min(delay, maxRetryDelay)
+}
+
+// Prune is the scheduler job deleting sent and failed messages past the retention
+func (s *Service) Prune(ctx context.Context) error {
+	if s.config.Retention <= 0 {
+		return nil
+	}
+	pruned, err := s.repo.Prune(ctx, time.Now().Add(-s.config.Retention))
+	if pruned > 0 {
+		log.Printf("🧹 Pruned %d outbox messages", pruned)
+	}
+	return err
+}
+
+func parseID(id string) int64 {
+	n, _ := strconv.ParseInt(id, 10, 64)
+	return n
+}
+
+// HandleList serves the latest outbox messages with a status (?status=,
+// failed by default) for operators
+func (s *Service) HandleList(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "":
+		status = models.OutboxFailed
+	case models.OutboxPending, models.OutboxSent, models.OutboxFailed:
+	default:
+		server.WriteError(w, http.StatusBadRequest, "status must be pending, sent or failed")
+		return
+	}
+	limit := 50
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > 500 {
+			server.WriteError(w, http.StatusBadRequest, "limit must be between 1 and 500")
+			return
+		}
+		limit = n
+	}
+	msgs, err := s.repo.List(r.Context(), status, limit)
+	if err != nil {
+		server.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	server.WriteJSON(w, http.StatusOK, map[string]interface{}{"messages": msgs})
+}
+
+// HandleRetry sends a failed message again once an operator fixed its cause,
+// e.g. restored the bot's access to the channel
+func (s *Service) HandleRetry(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		server.WriteError(w, http.StatusBadRequest, "invalid message id")
+		return
+	}
+	requeued, err := s.repo.Requeue(r.Context(), id)
+	if err != nil {
+		server.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if !requeued {
+		server.WriteError(w, http.StatusNotFound, "no failed outbox message with that id")
+		return
+	}
+	server.WriteJSON(w, http.StatusOK, map[string]interface{}{"id": id, "status": models.OutboxPending})
+}
@@ -0,0 +1,187 @@
+// Package overview gathers the numbers operators watch while the bot runs,
+// command throughput, queue depths, token spend, recent errors and activity
+// per guild, into the one admin endpoint the terminal dashboard polls.
+package overview
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"time"
+
+	"discord-tars/internal/repository"
+	"discord-tars/internal/server"
+	openaiService "discord-tars/internal/services/openai"
+	ragService "discord-tars/internal/services/rag"
+	"discord-tars/internal/services/slo"
+)
+
+// Sources are where the overview reads its numbers from
+type Sources struct {
+	Latency *slo.Tracker
+	RAG     *ragService.Service
+	Jobs    *repository.JobRepository
+	Outbox  *repository.OutboxRepository
+	Errors  *server.ErrorLog
+	// GuildName names guilds in the overview; optional
+	GuildName func(guildID int64) string
+}
+
+// Service serves the overview
+type Service struct {
+	src     Sources
+	started time.Time
+}
+
+func NewService(src Sources) *Service {
+	return &Service{src: src, started: time.Now()}
+}
+
+// Overview is a snapshot of the running bot
+type Overview struct {
+	Time     time.Time                `json:"time"`
+	Uptime   int64                    `json:"uptime_seconds"`
+	Commands Commands                 `json:"commands"`
+	Queues   Queues                   `json:"queues"`
+	Tokens   openaiService.TokenUsage `json:"tokens"`
+	Guilds   []GuildActivity          `json:"guilds"`
+	Errors   Errors                   `json:"errors"`
+	Ingest   *ragService.IngestStats  `json:"ingest,omitempty"`
+}
+
+// Commands is the command throughput over the last hour, across guilds
+type Commands struct {
+	LastHour   int               `json:"last_hour"`
+	LastMinute int               `json:"last_minute"`
+	ByCommand  []CommandActivity `json:"by_command"` // Busiest first
+}
+
+// CommandActivity is how much one command was used
+type CommandActivity struct {
+	Command    string `json:"command"`
+	LastHour   int    `json:"last_hour"`
+	LastMinute int    `json:"last_minute"`
+	P95        int64  `json:"p95_ms"` // Of the guild where it was slowest
+}
+
+// Queues is the work waiting for the bot
+type Queues struct {
+	Embeddings int64            `json:"embeddings"` // Messages waiting to be embedded
+	Jobs       map[string]int64 `json:"jobs"`       // By status
+	Outbox     map[string]int64 `json:"outbox"`     // By status
+}
+
+// GuildActivity is how much one guild used the bot
+type GuildActivity struct {
+	GuildID    int64  `json:"guild_id,string"`
+	Name       string `json:"name,omitempty"`
+	LastHour   int    `json:"commands_last_hour"`
+	LastMinute int    `json:"commands_last_minute"`
+	Tokens     int64  `json:"tokens"` // Since the bot started
+}
+
+// Errors are the errors logged since the bot started
+type Errors struct {
+	Total  int64                `json:"total"`
+	Recent []server.LoggedError `json:"recent"` // Newest first
+}
+
+// Overview takes a snapshot of the bot
+func (s *Service) Overview(ctx context.Context) (*Overview, error) {
+	now := time.Now()
+	o := &Overview{
+		Time:   now,
+		Uptime: int64(now.Sub(s.started).Seconds()),
+		Tokens: openaiService.Usage(),
+		Queues: Queues{Jobs: make(map[string]int64)},
+	}
+
+	guilds := make(map[int64]*GuildActivity)
+	guild := func(guildID int64) *GuildActivity {
+		if g, ok := guilds[guildID]; ok {
+			return g
+		}
+		g := &GuildActivity{GuildID: guildID}
+		guilds[guildID] = g
+		return g
+	}
+
+	commands := make(map[string]*CommandActivity)
+	for _, stats := range s.src.Latency.Stats(0) {
+		c, ok := commands[stats.Command]
+		if !ok {
+			c = &CommandActivity{Command: stats.Command}
+			commands[stats.Command] = c
+		}
+		c.LastHour += stats.Count
+		c.LastMinute += stats.LastMinute
+		if p95 := stats.P95.Milliseconds(); p95 > c.P95 {
+			c.P95 = p95
+		}
+		o.Commands.LastHour += stats.Count
+		o.Commands.LastMinute += stats.LastMinute
+		if stats.GuildID != 0 {
+			g := guild(stats.GuildID)
+			g.LastHour += stats.Count
+			g.LastMinute += stats.LastMinute
+		}
+	}
+	for _, c := range commands {
+		o.Commands.ByCommand = append(o.Commands.ByCommand, *c)
+	}
+	sort.Slice(o.Commands.ByCommand, func(a, b int) bool {
+		ca, cb := o.Commands.ByCommand[a], o.Commands.ByCommand[b]
+		if ca.LastHour != cb.LastHour {
+			return ca.LastHour > cb.LastHour
+		}
+		return ca.Command < cb.Command
+	})
+
+	for guildID, tokens := range o.Tokens.ByGuild {
+		guild(guildID).Tokens = tokens
+	}
+	for _, g := range guilds {
+		if s.src.GuildName != nil {
+			g.Name = s.src.GuildName(g.GuildID)
+		}
+		o.Guilds = append(o.Guilds, *g)
+	}
+	sort.Slice(o.Guilds, func(a, b int) bool {
+		ga, gb := o.Guilds[a], o.Guilds[b]
+		if ga.LastHour != gb.LastHour {
+			return ga.LastHour > gb.LastHour
+		}
+		if ga.Tokens != gb.Tokens {
+			return ga.Tokens > gb.Tokens
+		}
+		return ga.GuildID < gb.GuildID
+	})
+
+	o.Queues.Embeddings = s.src.RAG.EmbeddingQueueDepth()
+	o.Ingest = s.src.RAG.IngestStats()
+	jobs, err := s.src.Jobs.Stats(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+	for _, stats := range jobs {
+		o.Queues.Jobs[stats.Status] += stats.Count
+	}
+	if o.Queues.Outbox, err = s.src.Outbox.CountByStatus(ctx); err != nil {
+		return nil, err
+	}
+
+	if s.src.Errors != nil {
+		o.Errors.Recent, o.Errors.Total = s.src.Errors.Recent()
+	}
+	return o, nil
+}
+
+// HandleStats serves the overview as JSON
+func (s *Service) HandleStats(w http.ResponseWriter, r *http.Request) {
+	o, err := s.Overview(r.Context())
+	if err != nil {
+		server.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	server.WriteJSON(w, http.StatusOK, o)
+}
@@ -0,0 +1,118 @@
+// Package partitions keeps the messages table partitioned by guild once it
+// has been converted with "bot partition-messages": each guild the bot joins
+// gets a partition of its own, so its queries only read its messages, and
+// the partitions of guilds the bot left are dropped after a retention period.
+package partitions
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"discord-tars/internal/repository"
+)
+
+type Service struct {
+	repo *repository.PartitionRepository
+	// Retention is how long a guild's messages are kept after the bot leaves
+	// it; zero keeps them
+	retention time.Duration
+
+	mu          sync.Mutex
+	loaded      bool
+	partitioned bool
+	current     map[int64]bool // Guilds with a partition the bot is still in
+}
+
+func NewService(repo *repository.PartitionRepository, retention time.Duration) *Service {
+	return &Service{repo: repo, retention: retention}
+}
+
+// load reads, once, whether messages is partitioned and which guilds have a
+// partition; the caller holds mu
+func (s *Service) load(ctx context.Context) error {
+	if s.loaded {
+		return nil
+	}
+	partitioned, err := s.repo.Partitioned(ctx)
+	if err != nil {
+		return err
+	}
+	current := make(map[int64]bool)
+	if partitioned {
+		partitions, err := s.repo.ListPartitions(ctx)
+		if err != nil {
+			return err
+		}
+		for _, p := range partitions {
+			if p.LeftAt == nil {
+				current[p.GuildID] = true
+			}
+		}
+	}
+	s.partitioned, s.current, s.loaded = partitioned, current, true
+	return nil
+}
+
+// GuildJoined creates a partition for a guild the bot is in if it has none
+func (s *Service) GuildJoined(ctx context.Context, guildID int64) error {
+	if guildID <= 0 {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.load(ctx); err != nil {
+		return err
+	}
+	if !s.partitioned || s.current[guildID] {
+		return nil
+	}
+	if err := s.repo.EnsureGuild(ctx, guildID); err != nil {
+		return err
+	}
+	s.current[guildID] = true
+	return nil
+}
+
+// GuildLeft starts the retention period of a guild the bot was removed from
+func (s *Service) GuildLeft(ctx context.Context, guildID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.load(ctx); err != nil {
+		return err
+	}
+	if !s.partitioned {
+		return nil
+	}
+	delete(s.current, guildID)
+	return s.repo.MarkLeft(ctx, guildID, time.Now())
+}
+
+// Prune drops the partitions of guilds the bot left longer than the retention
+// period ago
+func (s *Service) Prune(ctx context.Context) error {
+	if s.retention <= 0 {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.load(ctx); err != nil {
+		return err
+	}
+	if !s.partitioned {
+		return nil
+	}
+	partitions, err := s.repo.ListLeftBefore(ctx, time.Now().Add(-s.retention))
+	if err != nil {
+		return err
+	}
+	for _, p := range partitions {
+		messages, err := s.repo.DropGuild(ctx, p.GuildID)
+		if err != nil {
+			return err
+		}
+		log.Printf("🧹 Dropped %s with %d messages, the bot left the guild on %s", p.Partition, messages, p.LeftAt.Format(time.DateOnly))
+	}
+	return nil
+}
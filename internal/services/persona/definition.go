@@ -0,0 +1,172 @@
+package persona
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// FormatVersion is the version of the persona JSON format
+const FormatVersion = 1
+
+// Limits a persona definition must fit in
+const (
+	MaxDefinitionBytes = 16 * 1024
+	maxNameLength      = 50
+	maxDescription     = 200
+	minPromptLength    = 20
+	maxPromptLength    = 4000
+	maxExamples        = 5
+	maxExampleLength   = 500
+)
+
+// ErrInvalid wraps every reason a persona definition is rejected; the
+// message explains what to fix
+var ErrInvalid = errors.New("invalid persona")
+
+// Emoji styles
+const (
+	EmojiNone       = "none"
+	EmojiLight      = "light"
+	EmojiExpressive = "expressive"
+)
+
+// Voices are the text-to-speech voices a persona may speak with
+var Voices = []string{"alloy", "ash", "coral", "echo", "fable", "onyx", "nova", "sage", "shimmer"}
+
+// Definition is a persona a guild can use instead of the default T.A.R.S one.
+// It is what /persona exports and imports as JSON.
+type Definition struct {
+	Version      int       `json:"version"`
+	Name         string    `json:"name"`
+	Description  string    `json:"description,omitempty"`
+	SystemPrompt string    `json:"system_prompt"`
+	Voice        string    `json:"voice,omitempty"`       // Text-to-speech voice; empty uses the default
+	EmojiStyle   string    `json:"emoji_style,omitempty"` // EmojiNone, EmojiLight or EmojiExpressive
+	Examples     []Example `json:"examples,omitempty"`    // Sample exchanges showing the persona's style
+}
+
+// Example is a sample exchange showing how a persona answers
+type Example struct {
+	User      string `json:"user"`
+	Assistant string `json:"assistant"`
+}
+
+// Parse decodes and validates a persona definition. Unknown fields are
+// rejected, so a typo doesn't silently drop a setting.
+func Parse(data []byte) (*Definition, error) {
+	if len(data) > MaxDefinitionBytes {
+		return nil, fmt.Errorf("%w: the file must be under %d KB", ErrInvalid, MaxDefinitionBytes/1024)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	var def Definition
+	if err := decoder.Decode(&def); err != nil {
+		return nil, fmt.Errorf("%w: the JSON can't be read (%v)", ErrInvalid, err)
+	}
+	if err := def.Validate(); err != nil {
+		return nil, err
+	}
+	return &def, nil
+}
+
+// Validate checks a definition's version, required fields and limits
+func (d *Definition) Validate() error {
+	var problems []string
+	if d.Version != FormatVersion {
+		problems = append(problems, fmt.Sprintf("version must be %d", FormatVersion))
+	}
+	if n := utf8.RuneCountInString(strings.TrimSpace(d.Name)); n == 0 || n > maxNameLength {
+		problems = append(problems, fmt.Sprintf("name must be 1 to %d characters", maxNameLength))
+	}
+	if utf8.RuneCountInString(d.Description) > maxDescription {
+		problems = append(problems, fmt.Sprintf("description must be at most %d characters", maxDescription))
+	}
+	if n := utf8.RuneCountInString(strings.TrimSpace(d.SystemPrompt)); n < minPromptLength || n > maxPromptLength {
+		problems = append(problems, fmt.Sprintf("system_prompt must be %d to %d characters", minPromptLength, maxPromptLength))
+	}
+	if d.Voice != "" && !contains(Voices, d.Voice) {
+		problems = append(problems, "voice must be one of "+strings.Join(Voices, ", "))
+	}
+	switch d.EmojiStyle {
+	case "", EmojiNone, EmojiLight, EmojiExpressive:
+	default:
+		problems = append(problems, fmt.Sprintf("emoji_style must be %s, %s or %s", EmojiNone, EmojiLight, EmojiExpressive))
+	}
+	if len(d.Examples) > maxExamples {
+		problems = append(problems, fmt.Sprintf("at most %d examples are allowed", maxExamples))
+	}
+	for n, example := range d.Examples {
+		if strings.TrimSpace(example.User) == "" || strings.TrimSpace(example.Assistant) == "" {
+			problems = append(problems, fmt.Sprintf("example %d needs both user and assistant", n+1))
+		} else if utf8.RuneCountInString(example.User)+utf8.RuneCountInString(example.Assistant) > maxExampleLength {
+			problems = append(problems, fmt.Sprintf("example %d must be at most %d characters", n+1, maxExampleLength))
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrInvalid, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// JSON encodes a definition the way /persona exports it
+func (d *Definition) JSON() ([]byte, error) {
+	data, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// Prompt builds the system prompt of a persona
+func (d *Definition) Prompt() string {
+	var sb strings.Builder
+	sb.WriteString(strings.TrimSpace(d.SystemPrompt))
+
+	switch d.EmojiStyle {
+	case EmojiNone:
+		sb.WriteString("\n\nDo not use emoji.")
+	case EmojiLight:
+		sb.WriteString("\n\nUse an emoji now and then, at most one per message.")
+	case EmojiExpressive:
+		sb.WriteString("\n\nUse emoji freely to add warmth and emphasis.")
+	}
+
+	if len(d.Examples) > 0 {
+		sb.WriteString("\n\nExamples of how you answer:")
+		for _, example := range d.Examples {
+			fmt.Fprintf(&sb, "\nUser: %s\nYou: %s\n", example.User, example.Assistant)
+		}
+	}
+	return sb.String()
+}
+
+type definitionKey struct{}
+
+// WithDefinition makes AI requests made with the context use a persona
+// instead of T.A.R.S; nil keeps T.A.R.S
+func WithDefinition(ctx context.Context, def *Definition) context.Context {
+	if def == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, definitionKey{}, def)
+}
+
+// FromContext returns the persona a context was given, or nil for T.A.R.S
+func FromContext(ctx context.Context) *Definition {
+	def, _ := ctx.Value(definitionKey{}).(*Definition)
+	return def
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,259 @@
+// Package golden guards the persona prompts against regressions. Each
+// transcript is a canned prompt with the settings it is asked under, the
+// stylistic properties its answer must have (length bounds, no markdown
+// headers, persona markers...) and a response recorded from the model
+// together with the system prompt it was recorded with. Replaying checks the
+// recorded response, and fails as soon as the system prompt built today
+// differs from the recorded one, so a prompt change is re-recorded and its
+// answers reviewed before it ships.
+package golden
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"discord-tars/internal/services/persona"
+)
+
+// Personality settings of T.A.R.S when a transcript doesn't set them
+const (
+	defaultHumor   = 75
+	defaultHonesty = 100
+)
+
+var (
+	markdownHeader = regexp.MustCompile(`(?m)^#{1,6}\s`)
+	customEmoji    = regexp.MustCompile(`<a?:\w{2,32}:\d+>`)
+)
+
+// ErrStale is returned when the system prompt changed since a response was
+// recorded
+var ErrStale = errors.New("system prompt changed since the response was recorded")
+
+// Model answers a prompt, like interfaces.AIService
+type Model interface {
+	Complete(ctx context.Context, systemPrompt, userPrompt string, maxTokens int) (string, error)
+}
+
+// Transcript is a canned prompt, what its answer must look like and the
+// answer recorded for it
+type Transcript struct {
+	Name string `json:"-"` // File name without .json
+
+	// Settings the prompt is asked under
+	Persona   string   `json:"persona,omitempty"` // Bundled preset; empty for T.A.R.S
+	Humor     *int     `json:"humor,omitempty"`
+	Honesty   *int     `json:"honesty,omitempty"`
+	Verbosity string   `json:"verbosity,omitempty"`
+	Style     string   `json:"style,omitempty"`
+	Emojis    []string `json:"emojis,omitempty"` // Names of the server's custom emojis
+
+	Prompt   string   `json:"prompt"`
+	Expect   Expect   `json:"expect"`
+	Recorded Recorded `json:"recorded"`
+}
+
+// Expect lists the stylistic properties an answer must have
+type Expect struct {
+	MinWords  int      `json:"min_words,omitempty"`
+	MaxWords  int      `json:"max_words,omitempty"`
+	NoHeaders bool     `json:"no_headers,omitempty"`
+	NoEmojis  bool     `json:"no_emojis,omitempty"`
+	Markers   []string `json:"markers,omitempty"`   // At least one must appear, ignoring case
+	Forbidden []string `json:"forbidden,omitempty"` // None may appear, ignoring case
+}
+
+// Recorded is a model response and the system prompt it answered
+type Recorded struct {
+	System   string `json:"system"`
+	Response string `json:"response"`
+}
+
+// Result is how a transcript fared
+type Result struct {
+	Name     string
+	Answer   string   // Recorded response as the bot would send it
+	Failures []string // Empty when the answer has every expected property
+	Err      error
+}
+
+// Passed reports whether the transcript replayed and met its expectations
+func (r Result) Passed() bool {
+	return r.Err == nil && len(r.Failures) == 0
+}
+
+// Load reads every transcript in a directory, sorted by name
+func Load(dir string) ([]*Transcript, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	transcripts := make([]*Transcript, 0, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read transcript: %w", err)
+		}
+		var t Transcript
+		if err := json.Unmarshal(data, &t); err != nil {
+			return nil, fmt.Errorf("failed to parse transcript %s: %w", path, err)
+		}
+		t.Name = strings.TrimSuffix(filepath.Base(path), ".json")
+		if strings.TrimSpace(t.Prompt) == "" {
+			return nil, fmt.Errorf("transcript %s has no prompt", t.Name)
+		}
+		if t.Persona != "" && persona.Preset(t.Persona) == nil {
+			return nil, fmt.Errorf("transcript %s uses unknown persona %q", t.Name, t.Persona)
+		}
+		transcripts = append(transcripts, &t)
+	}
+	return transcripts, nil
+}
+
+// Save writes a transcript back to its directory, after recording it
+func (t *Transcript) Save(dir string) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	// Prompts and answers stay readable in diffs
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(t); err != nil {
+		return fmt.Errorf("failed to encode transcript %s: %w", t.Name, err)
+	}
+	return os.WriteFile(filepath.Join(dir, t.Name+".json"), buf.Bytes(), 0o644)
+}
+
+// Context is what the bot would answer the transcript's prompt with: its
+// persona, verbosity, style and emojis
+func (t *Transcript) Context(ctx context.Context) context.Context {
+	ctx = persona.WithDefinition(ctx, persona.Preset(t.Persona))
+	if v, ok := persona.ParseVerbosity(t.Verbosity); ok {
+		ctx = persona.WithVerbosity(ctx, v)
+	}
+	if style, ok := persona.ParseStyle(t.Style); ok {
+		ctx = persona.WithStyle(ctx, style)
+	}
+	emojis := make([]persona.Emoji, len(t.Emojis))
+	for n, name := range t.Emojis {
+		emojis[n] = persona.Emoji{Name: name, Code: fmt.Sprintf("<:%s:%d>", name, 1000+n)}
+	}
+	return persona.WithEmojis(ctx, emojis)
+}
+
+// systemPrompt is the system prompt the transcript's prompt is answered with,
+// given its Context
+func (t *Transcript) systemPrompt(ctx context.Context) string {
+	return persona.PromptFor(ctx, orDefault(t.Humor, defaultHumor), orDefault(t.Honesty, defaultHonesty))
+}
+
+// Record asks the model for a new response under today's system prompt
+func (t *Transcript) Record(ctx context.Context, model Model) error {
+	ctx = t.Context(ctx)
+	system := t.systemPrompt(ctx)
+	response, err := model.Complete(ctx, system, t.Prompt, persona.MaxTokensFor(ctx))
+	if err != nil {
+		return fmt.Errorf("failed to record %s: %w", t.Name, err)
+	}
+	t.Recorded = Recorded{System: system, Response: response}
+	return nil
+}
+
+// Replay checks the recorded response, as the bot would send it, against the
+// expectations. It fails with ErrStale when the system prompt changed since
+// the recording.
+func (t *Transcript) Replay(ctx context.Context) Result {
+	ctx = t.Context(ctx)
+	result := Result{Name: t.Name}
+	if system := t.systemPrompt(ctx); system != t.Recorded.System {
+		result.Err = fmt.Errorf("%w: %s", ErrStale, firstDifference(t.Recorded.System, system))
+		return result
+	}
+	result.Answer = persona.EnhanceResponseFor(ctx, t.Recorded.Response)
+	result.Failures = t.Expect.Check(result.Answer)
+	return result
+}
+
+// Check lists how an answer falls short of the expectations
+func (e Expect) Check(answer string) []string {
+	var failures []string
+	words := len(strings.Fields(answer))
+	if e.MinWords > 0 && words < e.MinWords {
+		failures = append(failures, fmt.Sprintf("%d words, expected at least %d", words, e.MinWords))
+	}
+	if e.MaxWords > 0 && words > e.MaxWords {
+		failures = append(failures, fmt.Sprintf("%d words, expected at most %d", words, e.MaxWords))
+	}
+	if e.NoHeaders && markdownHeader.MatchString(answer) {
+		failures = append(failures, "uses markdown headers")
+	}
+	if e.NoEmojis && hasEmoji(answer) {
+		failures = append(failures, "uses emojis")
+	}
+
+	lower := strings.ToLower(answer)
+	if len(e.Markers) > 0 {
+		found := false
+		for _, marker := range e.Markers {
+			found = found || strings.Contains(lower, strings.ToLower(marker))
+		}
+		if !found {
+			failures = append(failures, fmt.Sprintf("has none of the persona markers %q", e.Markers))
+		}
+	}
+	for _, forbidden := range e.Forbidden {
+		if strings.Contains(lower, strings.ToLower(forbidden)) {
+			failures = append(failures, fmt.Sprintf("contains %q", forbidden))
+		}
+	}
+	return failures
+}
+
+// hasEmoji reports whether text has a custom emoji or an emoji character
+func hasEmoji(text string) bool {
+	if customEmoji.MatchString(text) {
+		return true
+	}
+	for _, r := range text {
+		if unicode.Is(unicode.So, r) && r > unicode.MaxLatin1 {
+			return true
+		}
+	}
+	return false
+}
+
+// firstDifference describes the first line where two prompts differ
+func firstDifference(recorded, current string) string {
+	was := strings.Split(recorded, "\n")
+	now := strings.Split(current, "\n")
+	for n := 0; n < len(was) || n < len(now); n++ {
+		var a, b string
+		if n < len(was) {
+			a = was[n]
+		}
+		if n < len(now) {
+			b = now[n]
+		}
+		if a != b {
+			return fmt.Sprintf("line %d was %q, is now %q", n+1, a, b)
+		}
+	}
+	return "prompts differ"
+}
+
+func orDefault(value *int, fallback int) int {
+	if value == nil {
+		return fallback
+	}
+	return *value
+}
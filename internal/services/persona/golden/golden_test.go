@@ -0,0 +1,81 @@
+package golden_test
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"os"
+	"testing"
+	"time"
+
+	openaiService "discord-tars/internal/services/openai"
+	"discord-tars/internal/services/persona/golden"
+)
+
+// update re-records the transcripts whose system prompt changed, asking the
+// model configured by OPENAI_API_KEY and OPENAI_MODEL
+var update = flag.Bool("update", false, "re-record golden transcripts whose system prompt changed (needs OPENAI_API_KEY)")
+
+const transcriptsDir = "transcripts"
+
+func TestGoldenTranscripts(t *testing.T) {
+	transcripts, err := golden.Load(transcriptsDir)
+	if err != nil {
+		t.Fatalf("failed to load transcripts: %v", err)
+	}
+	if len(transcripts) == 0 {
+		t.Fatalf("no transcripts in %s", transcriptsDir)
+	}
+	if *update {
+		recordStale(t, transcripts)
+	}
+
+	for _, transcript := range transcripts {
+		t.Run(transcript.Name, func(t *testing.T) {
+			result := transcript.Replay(context.Background())
+			if errors.Is(result.Err, golden.ErrStale) {
+				t.Fatalf("%v\nre-record with make test-golden-record and review the new answer", result.Err)
+			}
+			if result.Err != nil {
+				t.Fatal(result.Err)
+			}
+			for _, failure := range result.Failures {
+				t.Errorf("%s", failure)
+			}
+			if !result.Passed() || testing.Verbose() {
+				t.Logf("answer: %q", result.Answer)
+			}
+		})
+	}
+}
+
+// recordStale asks the model for new answers to the transcripts whose system
+// prompt changed, and saves them
+func recordStale(t *testing.T, transcripts []*golden.Transcript) {
+	t.Helper()
+	apiKey := os.Getenv("OPENAI_API_KEY")
+	if apiKey == "" {
+		t.Fatal("OPENAI_API_KEY is needed to record answers")
+	}
+	model := os.Getenv("OPENAI_MODEL")
+	if model == "" {
+		model = "gpt-4o-mini"
+	}
+	ai := openaiService.NewService(openaiService.Config{APIKey: apiKey, Model: model})
+
+	for _, transcript := range transcripts {
+		if result := transcript.Replay(context.Background()); result.Err == nil && transcript.Recorded.Response != "" {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		err := transcript.Record(ctx, ai)
+		cancel()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := transcript.Save(transcriptsDir); err != nil {
+			t.Fatalf("failed to save transcript %s: %v", transcript.Name, err)
+		}
+		t.Logf("📼 Recorded %s", transcript.Name)
+	}
+}
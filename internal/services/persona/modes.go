@@ -0,0 +1,205 @@
+package persona
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"discord-tars/internal/models"
+)
+
+// Limits a persona mode must fit in
+const (
+	MaxModesPerGuild       = 10
+	maxModeNameLength      = 50
+	maxModeInstructionsLen = 500
+)
+
+var (
+	monthNames   = []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}
+	weekdayNames = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+)
+
+// Mode is a persona mode active for a request
+type Mode struct {
+	Name         string
+	Instructions string
+}
+
+type modesKey struct{}
+
+// WithModes layers active persona modes over the persona of AI requests made
+// with the context
+func WithModes(ctx context.Context, modes []Mode) context.Context {
+	if len(modes) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, modesKey{}, modes)
+}
+
+// ModesFromContext returns the persona modes a context was given
+func ModesFromContext(ctx context.Context) []Mode {
+	modes, _ := ctx.Value(modesKey{}).([]Mode)
+	return modes
+}
+
+// modesPrompt is added after the persona's prompt while modes are active
+func modesPrompt(modes []Mode) string {
+	if len(modes) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("\n\nACTIVE MODES (adjust your usual personality accordingly, without dropping accuracy):")
+	for _, mode := range modes {
+		fmt.Fprintf(&sb, "\n- %s: %s", mode.Name, mode.Instructions)
+	}
+	return sb.String()
+}
+
+// ModeActive tells whether a mode's schedule covers a moment, in the mode's
+// timezone
+func ModeActive(mode *models.PersonaMode, now time.Time) bool {
+	loc, err := time.LoadLocation(mode.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	local := now.In(loc)
+	if mode.Months != 0 && mode.Months&(1<<(int(local.Month())-1)) == 0 {
+		return false
+	}
+	if mode.Weekdays != 0 && mode.Weekdays&(1<<int(local.Weekday())) == 0 {
+		return false
+	}
+	hour := local.Hour()
+	switch {
+	case mode.StartHour == mode.EndHour:
+		return true
+	case mode.StartHour < mode.EndHour:
+		return hour >= mode.StartHour && hour < mode.EndHour
+	default: // Overnight, e.g. 22-6
+		return hour >= mode.StartHour || hour < mode.EndHour
+	}
+}
+
+// ParseMonths reads months such as "oct", "12,1" or "jun-aug" into a
+// PersonaMode.Months mask; empty means every month
+func ParseMonths(s string) (int, error) {
+	mask, err := parseSet(s, monthNames, 1)
+	if err != nil {
+		return 0, fmt.Errorf("%w: months %v", ErrInvalid, err)
+	}
+	return mask, nil
+}
+
+// ParseWeekdays reads days such as "mon-fri" or "sat,sun" into a
+// PersonaMode.Weekdays mask; empty means every day
+func ParseWeekdays(s string) (int, error) {
+	mask, err := parseSet(s, weekdayNames, 0)
+	if err != nil {
+		return 0, fmt.Errorf("%w: days %v", ErrInvalid, err)
+	}
+	return mask, nil
+}
+
+// ParseHours reads hours such as "9-17" or "22-6"; empty means all day
+func ParseHours(s string) (start, end int, err error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, 0, nil
+	}
+	from, to, ok := strings.Cut(s, "-")
+	start, err1 := strconv.Atoi(strings.TrimSpace(from))
+	end, err2 := strconv.Atoi(strings.TrimSpace(to))
+	if !ok || err1 != nil || err2 != nil || start < 0 || start > 23 || end < 0 || end > 24 {
+		return 0, 0, fmt.Errorf("%w: hours must look like 9-17, from 0 to 24", ErrInvalid)
+	}
+	return start, end % 24, nil
+}
+
+// ValidateMode checks a mode's name, instructions and timezone
+func ValidateMode(mode *models.PersonaMode) error {
+	if n := len([]rune(strings.TrimSpace(mode.Name))); n == 0 || n > maxModeNameLength {
+		return fmt.Errorf("%w: the mode name must be 1 to %d characters", ErrInvalid, maxModeNameLength)
+	}
+	if n := len([]rune(strings.TrimSpace(mode.Instructions))); n == 0 || n > maxModeInstructionsLen {
+		return fmt.Errorf("%w: instructions must be 1 to %d characters", ErrInvalid, maxModeInstructionsLen)
+	}
+	if _, err := time.LoadLocation(mode.Timezone); err != nil {
+		return fmt.Errorf("%w: unknown timezone %q", ErrInvalid, mode.Timezone)
+	}
+	return nil
+}
+
+// DescribeSchedule writes a mode's schedule for people, e.g.
+// "oct, every day, all day (UTC)"
+func DescribeSchedule(mode *models.PersonaMode) string {
+	months := "every month"
+	if mode.Months != 0 {
+		months = describeSet(mode.Months, monthNames)
+	}
+	days := "every day"
+	if mode.Weekdays != 0 {
+		days = describeSet(mode.Weekdays, weekdayNames)
+	}
+	hours := "all day"
+	if mode.StartHour != mode.EndHour {
+		hours = fmt.Sprintf("%02d:00-%02d:00", mode.StartHour, mode.EndHour)
+	}
+	return fmt.Sprintf("%s, %s, %s (%s)", months, days, hours, mode.Timezone)
+}
+
+// parseSet reads a comma-separated list of names or numbers and ranges of
+// them into a bit mask, where bit 0 is the first name. Numbers start at base.
+// Ranges may wrap around, as in "nov-feb".
+func parseSet(s string, names []string, base int) (int, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" {
+		return 0, nil
+	}
+	index := func(part string) (int, error) {
+		part = strings.TrimSpace(part)
+		for n, name := range names {
+			if strings.HasPrefix(part, name) {
+				return n, nil
+			}
+		}
+		if v, err := strconv.Atoi(part); err == nil && v-base >= 0 && v-base < len(names) {
+			return v - base, nil
+		}
+		return 0, fmt.Errorf("%q is not one of %s", part, strings.Join(names, ", "))
+	}
+
+	mask := 0
+	for _, item := range strings.Split(s, ",") {
+		from, to, isRange := strings.Cut(item, "-")
+		first, err := index(from)
+		if err != nil {
+			return 0, err
+		}
+		last := first
+		if isRange {
+			if last, err = index(to); err != nil {
+				return 0, err
+			}
+		}
+		for n := first; ; n = (n + 1) % len(names) {
+			mask |= 1 << n
+			if n == last {
+				break
+			}
+		}
+	}
+	return mask, nil
+}
+
+func describeSet(mask int, names []string) string {
+	var set []string
+	for n, name := range names {
+		if mask&(1<<n) != 0 {
+			set = append(set, name)
+		}
+	}
+	return strings.Join(set, ",")
+}
@@ -0,0 +1,90 @@
+package persona
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// SystemPrompt builds the T.A.R.S persona prompt for the given personality settings
+func SystemPrompt(humorLevel, honestyLevel int) string {
+	basePrompt := `You are T.A.R.S, an AI assistant from the movie Interstellar. You are:
+- Sarcastic but helpful
+- Highly intelligent and logical
+- Sometimes humorous with a dry wit
+- Always honest
+- Efficient in your responses
+- Knowledgeable about science, technology, and general topics`
+
+	// Adjust prompt based on personality settings
+	if humorLevel == 0 {
+		basePrompt += "\n\nIMPORTANT: Humor setting is disabled. Respond with technical precision and no jokes."
+	} else if humorLevel > 90 {
+		basePrompt += "\n\nIMPORTANT: Humor setting is at maximum. Use more jokes, puns, and witty remarks."
+	}
+
+	basePrompt += fmt.Sprintf("\n\nCurrent settings: Humor %d%%, Honesty %d%%", humorLevel, honestyLevel)
+	basePrompt += "\n\nKeep responses concise but informative. Use occasional humor when appropriate."
+
+	return basePrompt
+}
+
+// EnhanceResponse adds the T.A.R.S signature touch to short responses
+func EnhanceResponse(response string) string {
+	if !strings.Contains(response, "T.A.R.S") && len(response) < 100 {
+		response = "🤖 " + response
+	}
+	return response
+}
+
+// PromptFor builds the system prompt for a request: the persona the context
+// was given, or T.A.R.S with the given personality settings, followed by the
+// persona modes active for it, the answer length it asks for, the style
+// with the guild's emojis, unless the persona uses none, and the prompt
+// change being rolled out to the guild, if any
+func PromptFor(ctx context.Context, humorLevel, honestyLevel int) string {
+	prompt := SystemPrompt(humorLevel, honestyLevel)
+	emojis := EmojisFromContext(ctx)
+	if def := FromContext(ctx); def != nil {
+		prompt = def.Prompt()
+		if def.EmojiStyle == EmojiNone {
+			emojis = nil
+		}
+	}
+	return prompt + modesPrompt(ModesFromContext(ctx)) + verbosityPrompt(VerbosityFromContext(ctx)) +
+		stylePrompt(StyleFromContext(ctx), emojis) + instructionsPrompt(InstructionsFromContext(ctx))
+}
+
+type instructionsKey struct{}
+
+// WithInstructions adds instructions to the system prompt of AI requests made
+// with the context, such as a prompt change tried on some guilds first
+func WithInstructions(ctx context.Context, instructions string) context.Context {
+	if instructions = strings.TrimSpace(instructions); instructions == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, instructionsKey{}, instructions)
+}
+
+// InstructionsFromContext returns the instructions a context was given
+func InstructionsFromContext(ctx context.Context) string {
+	instructions, _ := ctx.Value(instructionsKey{}).(string)
+	return instructions
+}
+
+func instructionsPrompt(instructions string) string {
+	if instructions == "" {
+		return ""
+	}
+	return "\n\n" + instructions
+}
+
+// EnhanceResponseFor renders the guild's emojis and adds the T.A.R.S touch,
+// unless the context was given another persona or a plain style
+func EnhanceResponseFor(ctx context.Context, response string) string {
+	response = RenderEmojis(ctx, response)
+	if FromContext(ctx) != nil || StyleFromContext(ctx) == StylePlain {
+		return response
+	}
+	return EnhanceResponse(response)
+}
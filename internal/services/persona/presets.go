@@ -0,0 +1,69 @@
+package persona
+
+import (
+	"embed"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Presets bundled in the binary, importable by name with /persona import
+//
+//go:embed presets/*.json
+var presetFiles embed.FS
+
+var presets = loadPresets()
+
+func loadPresets() map[string]*Definition {
+	entries, err := presetFiles.ReadDir("presets")
+	if err != nil {
+		panic(fmt.Sprintf("persona presets: %v", err))
+	}
+	loaded := make(map[string]*Definition, len(entries))
+	for _, entry := range entries {
+		data, err := presetFiles.ReadFile(path.Join("presets", entry.Name()))
+		if err != nil {
+			panic(fmt.Sprintf("persona preset %s: %v", entry.Name(), err))
+		}
+		def, err := Parse(data)
+		if err != nil {
+			panic(fmt.Sprintf("persona preset %s: %v", entry.Name(), err))
+		}
+		loaded[strings.TrimSuffix(entry.Name(), ".json")] = def
+	}
+	return loaded
+}
+
+// Preset returns a copy of a bundled preset, or nil if there is none by that name
+func Preset(name string) *Definition {
+	def, ok := presets[name]
+	if !ok {
+		return nil
+	}
+	preset := *def
+	preset.Examples = append([]Example(nil), def.Examples...)
+	return &preset
+}
+
+// PresetNames lists the bundled presets in alphabetical order
+func PresetNames() []string {
+	names := make([]string, 0, len(presets))
+	for name := range presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Default returns the T.A.R.S persona as a definition, so it can be exported
+// and tweaked like any other
+func Default(humorLevel, honestyLevel int) *Definition {
+	return &Definition{
+		Version:      FormatVersion,
+		Name:         "T.A.R.S",
+		Description:  "The default sarcastic but helpful robot from Interstellar",
+		SystemPrompt: SystemPrompt(humorLevel, honestyLevel),
+		Voice:        "alloy",
+	}
+}
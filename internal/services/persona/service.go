@@ -0,0 +1,381 @@
+package persona
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/repository"
+)
+
+const (
+	// Answers look the persona up on every request, so it is cached briefly
+	cacheTTL        = 5 * time.Minute
+	maxCachedGuilds = 1000
+)
+
+// Service stores the personas guilds imported
+type Service struct {
+	repo *repository.PersonaRepository
+
+	mu          sync.Mutex
+	cache       map[int64]cachedPersona
+	verbosities map[int64]cachedVerbosity
+	styles      map[int64]cachedStyle
+
+	modesMu sync.RWMutex
+	active  map[int64][]Mode // Modes whose schedule is active, by guild
+}
+
+type cachedPersona struct {
+	def      *Definition // nil for T.A.R.S
+	loadedAt time.Time
+}
+
+type cachedVerbosity struct {
+	verbosity Verbosity
+	loadedAt  time.Time
+}
+
+type cachedStyle struct {
+	style    Style
+	loadedAt time.Time
+}
+
+func NewService(repo *repository.PersonaRepository) *Service {
+	return &Service{
+		repo:        repo,
+		cache:       make(map[int64]cachedPersona),
+		verbosities: make(map[int64]cachedVerbosity),
+		styles:      make(map[int64]cachedStyle),
+		active:      make(map[int64][]Mode),
+	}
+}
+
+// Get returns a guild's persona, or nil when it uses T.A.R.S
+func (s *Service) Get(ctx context.Context, guildID int64) (*Definition, error) {
+	s.mu.Lock()
+	cached, ok := s.cache[guildID]
+	s.mu.Unlock()
+	if ok && time.Since(cached.loadedAt) < cacheTTL {
+		return cached.def, nil
+	}
+
+	stored, err := s.repo.Get(ctx, guildID)
+	if err != nil {
+		return nil, err
+	}
+	var def *Definition
+	if stored != nil {
+		if def, err = Parse([]byte(stored.Definition)); err != nil {
+			// Only validated personas are saved, so this means the format changed
+			log.Printf("⚠️ Ignoring invalid persona of guild %d: %v", guildID, err)
+			def = nil
+		}
+	}
+	s.cachePersona(guildID, def)
+	return def, nil
+}
+
+// Use returns ctx with a guild's persona, so AI requests made with it answer
+// as that persona; a lookup failure falls back to T.A.R.S. Answers take the
+// guild's default verbosity unless ctx already asks for one, and its style.
+func (s *Service) Use(ctx context.Context, guildID int64) context.Context {
+	if guildID == 0 {
+		return ctx
+	}
+	if ctx.Value(verbosityKey{}) == nil {
+		verbosity, err := s.Verbosity(ctx, guildID)
+		if err != nil {
+			log.Printf("⚠️ Failed to load answer verbosity of guild %d: %v", guildID, err)
+		}
+		ctx = WithVerbosity(ctx, verbosity)
+	}
+	style, err := s.Style(ctx, guildID)
+	if err != nil {
+		log.Printf("⚠️ Failed to load answer style of guild %d: %v", guildID, err)
+	}
+	ctx = WithStyle(ctx, style)
+	def, err := s.Get(ctx, guildID)
+	if err != nil {
+		log.Printf("⚠️ Failed to load persona of guild %d: %v", guildID, err)
+		return ctx
+	}
+	return WithModes(WithDefinition(ctx, def), s.activeModes(guildID))
+}
+
+// Verbosity returns a guild's default answer verbosity
+func (s *Service) Verbosity(ctx context.Context, guildID int64) (Verbosity, error) {
+	s.mu.Lock()
+	cached, ok := s.verbosities[guildID]
+	s.mu.Unlock()
+	if ok && time.Since(cached.loadedAt) < cacheTTL {
+		return cached.verbosity, nil
+	}
+
+	stored, err := s.repo.GetVerbosity(ctx, guildID)
+	if err != nil {
+		return VerbosityNormal, err
+	}
+	verbosity := VerbosityNormal
+	if stored != nil {
+		if v, ok := ParseVerbosity(stored.Verbosity); ok {
+			verbosity = v
+		}
+	}
+	s.cacheVerbosity(guildID, verbosity)
+	return verbosity, nil
+}
+
+// SetVerbosity changes a guild's default answer verbosity
+func (s *Service) SetVerbosity(ctx context.Context, guildID, setBy int64, verbosity Verbosity) error {
+	if _, ok := ParseVerbosity(string(verbosity)); !ok {
+		return fmt.Errorf("%w: unknown verbosity %q", ErrInvalid, verbosity)
+	}
+	if err := s.repo.SaveVerbosity(ctx, &models.GuildVerbosity{GuildID: guildID, Verbosity: string(verbosity), SetBy: setBy}); err != nil {
+		return err
+	}
+	s.cacheVerbosity(guildID, verbosity)
+	return nil
+}
+
+func (s *Service) cacheVerbosity(guildID int64, verbosity Verbosity) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.verbosities) >= maxCachedGuilds {
+		s.verbosities = make(map[int64]cachedVerbosity)
+	}
+	s.verbosities[guildID] = cachedVerbosity{verbosity: verbosity, loadedAt: time.Now()}
+}
+
+// Style returns whether a guild wants playful or plain answers
+func (s *Service) Style(ctx context.Context, guildID int64) (Style, error) {
+	s.mu.Lock()
+	cached, ok := s.styles[guildID]
+	s.mu.Unlock()
+	if ok && time.Since(cached.loadedAt) < cacheTTL {
+		return cached.style, nil
+	}
+
+	stored, err := s.repo.GetStyle(ctx, guildID)
+	if err != nil {
+		return StylePlayful, err
+	}
+	style := StylePlayful
+	if stored != nil {
+		if st, ok := ParseStyle(stored.Style); ok {
+			style = st
+		}
+	}
+	s.cacheStyle(guildID, style)
+	return style, nil
+}
+
+// SetStyle changes whether a guild's answers are playful or plain
+func (s *Service) SetStyle(ctx context.Context, guildID, setBy int64, style Style) error {
+	if _, ok := ParseStyle(string(style)); !ok {
+		return fmt.Errorf("%w: unknown style %q", ErrInvalid, style)
+	}
+	if err := s.repo.SaveStyle(ctx, &models.GuildStyle{GuildID: guildID, Style: string(style), SetBy: setBy}); err != nil {
+		return err
+	}
+	s.cacheStyle(guildID, style)
+	return nil
+}
+
+func (s *Service) cacheStyle(guildID int64, style Style) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.styles) >= maxCachedGuilds {
+		s.styles = make(map[int64]cachedStyle)
+	}
+	s.styles[guildID] = cachedStyle{style: style, loadedAt: time.Now()}
+}
+
+// Import validates a definition and makes it the guild's persona
+func (s *Service) Import(ctx context.Context, guildID, importedBy int64, def *Definition) error {
+	if err := def.Validate(); err != nil {
+		return err
+	}
+	data, err := def.JSON()
+	if err != nil {
+		return fmt.Errorf("failed to encode persona: %w", err)
+	}
+	if err := s.repo.Save(ctx, &models.GuildPersona{GuildID: guildID, Definition: string(data), ImportedBy: importedBy}); err != nil {
+		return err
+	}
+	s.cachePersona(guildID, def)
+	log.Printf("🎭 Guild %d now uses the %q persona", guildID, def.Name)
+	return nil
+}
+
+// Reset brings a guild back to T.A.R.S
+func (s *Service) Reset(ctx context.Context, guildID int64) error {
+	if err := s.repo.Delete(ctx, guildID); err != nil {
+		return err
+	}
+	s.cachePersona(guildID, nil)
+	return nil
+}
+
+func (s *Service) cachePersona(guildID int64, def *Definition) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.cache) >= maxCachedGuilds {
+		s.cache = make(map[int64]cachedPersona)
+	}
+	s.cache[guildID] = cachedPersona{def: def, loadedAt: time.Now()}
+}
+
+// Modes returns a guild's persona modes
+func (s *Service) Modes(ctx context.Context, guildID int64) ([]models.PersonaMode, error) {
+	return s.repo.ListModes(ctx, guildID)
+}
+
+// SaveMode validates and stores a persona mode, replacing the guild's mode of
+// the same name
+func (s *Service) SaveMode(ctx context.Context, mode *models.PersonaMode) error {
+	mode.Name = strings.ToLower(strings.TrimSpace(mode.Name))
+	mode.Instructions = strings.TrimSpace(mode.Instructions)
+	if mode.Timezone == "" {
+		mode.Timezone = "UTC"
+	}
+	if err := ValidateMode(mode); err != nil {
+		return err
+	}
+
+	existing, err := s.repo.ListModes(ctx, mode.GuildID)
+	if err != nil {
+		return err
+	}
+	replacing := false
+	for _, m := range existing {
+		replacing = replacing || m.Name == mode.Name
+	}
+	if !replacing && len(existing) >= MaxModesPerGuild {
+		return fmt.Errorf("%w: a server can have at most %d modes", ErrInvalid, MaxModesPerGuild)
+	}
+
+	if err := s.repo.SaveMode(ctx, mode); err != nil {
+		return err
+	}
+	return s.refreshGuildModes(ctx, mode.GuildID)
+}
+
+// RemoveMode deletes a guild's persona mode, reporting whether it existed
+func (s *Service) RemoveMode(ctx context.Context, guildID int64, name string) (bool, error) {
+	removed, err := s.repo.DeleteMode(ctx, guildID, strings.ToLower(strings.TrimSpace(name)))
+	if err != nil || !removed {
+		return removed, err
+	}
+	return true, s.refreshGuildModes(ctx, guildID)
+}
+
+// RefreshModes works out which persona modes are active in every guild. It
+// runs as a scheduled job, so modes start and end within one interval.
+func (s *Service) RefreshModes(ctx context.Context) error {
+	modes, err := s.repo.AllModes(ctx)
+	if err != nil {
+		return err
+	}
+	active := activeModes(modes, time.Now())
+
+	s.modesMu.Lock()
+	previous := s.active
+	s.active = active
+	s.modesMu.Unlock()
+	logModeChanges(previous, active)
+	return nil
+}
+
+func (s *Service) refreshGuildModes(ctx context.Context, guildID int64) error {
+	modes, err := s.repo.ListModes(ctx, guildID)
+	if err != nil {
+		return err
+	}
+	active := activeModes(modes, time.Now())[guildID]
+
+	s.modesMu.Lock()
+	defer s.modesMu.Unlock()
+	if len(active) == 0 {
+		delete(s.active, guildID)
+	} else {
+		s.active[guildID] = active
+	}
+	return nil
+}
+
+// activeModes groups the modes active at a moment by guild
+func activeModes(modes []models.PersonaMode, now time.Time) map[int64][]Mode {
+	active := make(map[int64][]Mode)
+	for i := range modes {
+		if ModeActive(&modes[i], now) {
+			active[modes[i].GuildID] = append(active[modes[i].GuildID], Mode{Name: modes[i].Name, Instructions: modes[i].Instructions})
+		}
+	}
+	return active
+}
+
+func (s *Service) activeModes(guildID int64) []Mode {
+	s.modesMu.RLock()
+	defer s.modesMu.RUnlock()
+	return s.active[guildID]
+}
+
+// logModeChanges logs the modes that started or ended between two refreshes
+func logModeChanges(previous, current map[int64][]Mode) {
+	names := func(modes []Mode) map[string]bool {
+		set := make(map[string]bool, len(modes))
+		for _, m := range modes {
+			set[m.Name] = true
+		}
+		return set
+	}
+	for guildID, modes := range current {
+		was := names(previous[guildID])
+		for _, m := range modes {
+			if !was[m.Name] {
+				log.Printf("🎭 Persona mode %q started in guild %d", m.Name, guildID)
+			}
+		}
+	}
+	for guildID, modes := range previous {
+		is := names(current[guildID])
+		for _, m := range modes {
+			if !is[m.Name] {
+				log.Printf("🎭 Persona mode %q ended in guild %d", m.Name, guildID)
+			}
+		}
+	}
+}
+
+var downloadClient = &http.Client{Timeout: 30 * time.Second}
+
+// Download fetches and parses a persona file, such as a Discord attachment
+func Download(ctx context.Context, url string) (*Definition, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := downloadClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download persona: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download persona: cdn returned status %d", resp.StatusCode)
+	}
+	// Read one byte past the limit so Parse reports an oversized file
+	data, err := io.ReadAll(io.LimitReader(resp.Body, MaxDefinitionBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to download persona: %w", err)
+	}
+	return Parse(data)
+}
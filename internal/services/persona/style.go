@@ -0,0 +1,128 @@
+package persona
+
+import (
+	"context"
+	"regexp"
+	"strings"
+)
+
+// Style is whether answers may be playful, with the server's emojis, or
+// plain
+type Style string
+
+const (
+	StylePlayful Style = "playful"
+	StylePlain   Style = "plain"
+)
+
+// Styles are the styles a guild may pick
+var Styles = []Style{StylePlayful, StylePlain}
+
+// maxPromptEmojis caps how many of a guild's emojis are listed in the prompt
+const maxPromptEmojis = 50
+
+var (
+	// emojiCode matches code spans, which are left alone, a custom emoji
+	// code like <:name:id> and an emoji name written between colons
+	emojiCode = regexp.MustCompile("(?s)```.*?```|`[^`\n]*`|<a?:\\w{2,32}:\\d+>|:\\w{2,32}:")
+	emojiName = regexp.MustCompile(`^<a?:(\w{2,32}):\d+>$`)
+)
+
+// ParseStyle reads a style, reporting whether it is one of Styles
+func ParseStyle(s string) (Style, bool) {
+	style := Style(strings.ToLower(strings.TrimSpace(s)))
+	return style, style == StylePlayful || style == StylePlain
+}
+
+// Emoji is a custom emoji the bot may use in a guild
+type Emoji struct {
+	Name string
+	Code string // As written in a message, e.g. <:name:id>
+}
+
+type styleKey struct{}
+
+type emojisKey struct{}
+
+// WithStyle makes answers generated with the context use a style; an unknown
+// style leaves the context as it is
+func WithStyle(ctx context.Context, style Style) context.Context {
+	if _, ok := ParseStyle(string(style)); !ok {
+		return ctx
+	}
+	return context.WithValue(ctx, styleKey{}, style)
+}
+
+// StyleFromContext returns the style a context was given, or StylePlayful
+func StyleFromContext(ctx context.Context) Style {
+	if style, ok := ctx.Value(styleKey{}).(Style); ok {
+		return style
+	}
+	return StylePlayful
+}
+
+// WithEmojis gives answers generated with the context the custom emojis of
+// the guild they are for
+func WithEmojis(ctx context.Context, emojis []Emoji) context.Context {
+	if len(emojis) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, emojisKey{}, emojis)
+}
+
+// EmojisFromContext returns the custom emojis a context was given
+func EmojisFromContext(ctx context.Context) []Emoji {
+	emojis, _ := ctx.Value(emojisKey{}).([]Emoji)
+	return emojis
+}
+
+// stylePrompt is added to the system prompt to list the emojis a playful
+// answer may use, or to keep a plain one free of them
+func stylePrompt(style Style, emojis []Emoji) string {
+	if style == StylePlain {
+		return "\n\nSTYLE: plain. Don't use emojis or emoticons."
+	}
+	if len(emojis) == 0 {
+		return ""
+	}
+	if len(emojis) > maxPromptEmojis {
+		emojis = emojis[:maxPromptEmojis]
+	}
+	names := make([]string, len(emojis))
+	for n, e := range emojis {
+		names[n] = ":" + e.Name + ":"
+	}
+	return "\n\nSERVER EMOJIS: this server has its own emojis. Where one fits the tone, you may use it by writing its name between colons. Use at most two per answer, and only these: " + strings.Join(names, " ")
+}
+
+// RenderEmojis turns the emoji names an answer wrote between colons into the
+// codes of the guild's emojis, and drops custom emoji codes the guild doesn't
+// have, so only valid emojis are sent. Plain answers keep no custom emoji.
+func RenderEmojis(ctx context.Context, text string) string {
+	if !strings.Contains(text, ":") {
+		return text
+	}
+	codes := make(map[string]string)
+	if StyleFromContext(ctx) == StylePlayful {
+		for _, e := range EmojisFromContext(ctx) {
+			codes[e.Name] = e.Code
+		}
+	}
+	return emojiCode.ReplaceAllStringFunc(text, func(m string) string {
+		switch {
+		case strings.HasPrefix(m, "`"):
+			return m
+		case strings.HasPrefix(m, "<"):
+			name := emojiName.FindStringSubmatch(m)[1]
+			if codes[name] == m {
+				return m
+			}
+			return ""
+		default:
+			if code, ok := codes[strings.Trim(m, ":")]; ok {
+				return code
+			}
+			return m
+		}
+	})
+}
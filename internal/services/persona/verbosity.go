@@ -0,0 +1,88 @@
+package persona
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+)
+
+// Verbosity is how long answers should be
+type Verbosity string
+
+const (
+	VerbosityBrief    Verbosity = "brief"
+	VerbosityNormal   Verbosity = "normal"
+	VerbosityDetailed Verbosity = "detailed"
+)
+
+// Verbosities are the verbosities a guild or a question may ask for
+var Verbosities = []Verbosity{VerbosityBrief, VerbosityNormal, VerbosityDetailed}
+
+// answerTokens is the token budget of an answer at each verbosity
+var answerTokens = map[Verbosity]int{
+	VerbosityBrief:    200,
+	VerbosityNormal:   500,
+	VerbosityDetailed: 1200,
+}
+
+// ParseVerbosity reads a verbosity, reporting whether it is one of Verbosities
+func ParseVerbosity(s string) (Verbosity, bool) {
+	v := Verbosity(strings.ToLower(strings.TrimSpace(s)))
+	_, ok := answerTokens[v]
+	return v, ok
+}
+
+type verbosityKey struct{}
+
+// WithVerbosity makes answers generated with the context use a verbosity;
+// empty leaves the context as it is
+func WithVerbosity(ctx context.Context, v Verbosity) context.Context {
+	if _, ok := answerTokens[v]; !ok {
+		return ctx
+	}
+	return context.WithValue(ctx, verbosityKey{}, v)
+}
+
+// VerbosityFromContext returns the verbosity a context was given, or
+// VerbosityNormal
+func VerbosityFromContext(ctx context.Context) Verbosity {
+	if v, ok := ctx.Value(verbosityKey{}).(Verbosity); ok {
+		return v
+	}
+	return VerbosityNormal
+}
+
+// MaxTokensFor is the token budget of an answer generated with the context
+func MaxTokensFor(ctx context.Context) int {
+	return answerTokens[VerbosityFromContext(ctx)]
+}
+
+// verbosityPrompt is added to the system prompt when answers should be
+// shorter or longer than usual
+func verbosityPrompt(v Verbosity) string {
+	switch v {
+	case VerbosityBrief:
+		return "\n\nANSWER LENGTH: brief. Answer in one to three sentences, with no preamble, lists or examples unless asked."
+	case VerbosityDetailed:
+		return "\n\nANSWER LENGTH: detailed. Give a thorough answer: explain the reasoning, cover the relevant cases and include examples or steps where they help."
+	}
+	return ""
+}
+
+type truncatedKey struct{}
+
+// WithTruncationReport asks AI requests made with the context to report an
+// answer cut short by its token budget; the returned function tells whether
+// one was
+func WithTruncationReport(ctx context.Context) (context.Context, func() bool) {
+	var truncated atomic.Bool
+	return context.WithValue(ctx, truncatedKey{}, &truncated), truncated.Load
+}
+
+// ReportTruncated records that an answer generated with the context ran out
+// of tokens
+func ReportTruncated(ctx context.Context) {
+	if truncated, ok := ctx.Value(truncatedKey{}).(*atomic.Bool); ok {
+		truncated.Store(true)
+	}
+}
@@ -0,0 +1,328 @@
+package poll
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+
+	"discord-tars/internal/interfaces"
+	"discord-tars/internal/models"
+	"discord-tars/internal/repository"
+	"discord-tars/internal/services/summarize"
+	"discord-tars/internal/tenant"
+)
+
+const (
+	MinOptions = 2
+	MaxOptions = 5 // One action row of buttons
+
+	analysisMaxTokens  = 500
+	maxDiscussionChars = 6000
+
+	// Component custom ID prefixes routed by the Discord bot
+	VotePrefix  = "poll_vote"
+	ClosePrefix = "poll_close"
+)
+
+const analysisSystemPrompt = `You are T.A.R.S, analyzing the results of a Discord poll.
+You get the question, the vote tally, and the channel discussion that happened while the poll was open.
+Write a short analysis: state the outcome, note whether it was close, and summarize notable arguments
+from the discussion that relate to the poll topic, attributing them by username. Ignore unrelated chatter.
+Keep it under 150 words.`
+
+var (
+	ErrNotFound    = errors.New("poll not found")
+	ErrClosed      = errors.New("this poll is closed")
+	ErrNotAllowed  = errors.New("only the poll creator or a server manager can close this poll")
+	ErrBadOptions  = fmt.Errorf("a poll needs between %d and %d options", MinOptions, MaxOptions)
+	ErrInvalidVote = errors.New("invalid poll option")
+)
+
+type Service struct {
+	aiService interfaces.AIService
+	pollRepo  *repository.PollRepository
+	msgRepo   *repository.MessageRepository
+	session   *discordgo.Session
+}
+
+func NewService(aiService interfaces.AIService, pollRepo *repository.PollRepository, msgRepo *repository.MessageRepository, session *discordgo.Session) *Service {
+	return &Service{
+		aiService: aiService,
+		pollRepo:  pollRepo,
+		msgRepo:   msgRepo,
+		session:   session,
+	}
+}
+
+// Create stores a poll and posts it with one vote button per option
+func (s *Service) Create(ctx context.Context, guildID, channelID, createdBy int64, question string, options []string, duration time.Duration) (*models.Poll, error) {
+	if len(options) < MinOptions || len(options) > MaxOptions {
+		return nil, ErrBadOptions
+	}
+
+	poll := &models.Poll{
+		GuildID:   guildID,
+		ChannelID: channelID,
+		CreatedBy: createdBy,
+		Question:  question,
+		Options:   options,
+	}
+	if duration > 0 {
+		closesAt := time.Now().Add(duration)
+		poll.ClosesAt = &closesAt
+	}
+
+	if err := s.pollRepo.CreatePoll(ctx, poll); err != nil {
+		return nil, err
+	}
+
+	msg, err := s.session.ChannelMessageSendComplex(strconv.FormatInt(channelID, 10), &discordgo.MessageSend{
+		Content:    renderPoll(poll, nil, false),
+		Components: pollComponents(poll, false),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to post poll: %w", err)
+	}
+
+	poll.MessageID, _ = strconv.ParseInt(msg.ID, 10, 64)
+	if err := s.pollRepo.SetMessageID(ctx, poll.ID, poll.MessageID); err != nil {
+		log.Printf("⚠️ Failed to store message ID of poll %d: %v", poll.ID, err)
+	}
+
+	log.Printf("🗳️ Created poll %d with %d options in channel %d", poll.ID, len(options), channelID)
+	return poll, nil
+}
+
+// Vote records a user's choice and returns the chosen option label
+func (s *Service) Vote(ctx context.Context, pollID, userID int64, option int) (string, error) {
+	poll, err := s.pollRepo.GetPoll(ctx, pollID)
+	if err != nil {
+		return "", err
+	}
+	if poll == nil {
+		return "", ErrNotFound
+	}
+	if poll.ClosedAt != nil {
+		return "", ErrClosed
+	}
+	if option < 0 || option >= len(poll.Options) {
+		return "", ErrInvalidVote
+	}
+
+	if err := s.pollRepo.Vote(ctx, &models.PollVote{PollID: pollID, UserID: userID, Option: option}); err != nil {
+		return "", err
+	}
+
+	s.refreshMessage(ctx, poll)
+	return poll.Options[option], nil
+}
+
+// Close ends a poll on behalf of a user, checking they are allowed to
+func (s *Service) Close(ctx context.Context, pollID, userID int64, isAdmin bool) error {
+	poll, err := s.pollRepo.GetPoll(ctx, pollID)
+	if err != nil {
+		return err
+	}
+	if poll == nil {
+		return ErrNotFound
+	}
+	if poll.CreatedBy != userID && !isAdmin {
+		return ErrNotAllowed
+	}
+	return s.close(ctx, poll)
+}
+
+// CloseExpired is the scheduler job closing polls whose duration has elapsed
+func (s *Service) CloseExpired(ctx context.Context) error {
+	polls, err := s.pollRepo.ListExpired(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+	for i := range polls {
+		if err := s.close(ctx, &polls[i]); err != nil {
+			log.Printf("❌ Failed to close poll %d: %v", polls[i].ID, err)
+		}
+	}
+	return nil
+}
+
+func (s *Service) close(ctx context.Context, poll *models.Poll) error {
+	now := time.Now()
+	claimed, err := s.pollRepo.ClosePoll(ctx, poll.ID, now)
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		return ErrClosed
+	}
+	poll.ClosedAt = &now
+
+	counts, err := s.pollRepo.CountVotes(ctx, poll.ID)
+	if err != nil {
+		return err
+	}
+
+	channelID := strconv.FormatInt(poll.ChannelID, 10)
+	if _, err := s.session.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		Channel:    channelID,
+		ID:         strconv.FormatInt(poll.MessageID, 10),
+		Content:    stringPtr(renderPoll(poll, counts, true)),
+		Components: &[]discordgo.MessageComponent{},
+	}); err != nil {
+		log.Printf("⚠️ Failed to update closed poll message %d: %v", poll.ID, err)
+	}
+
+	analysis, err := s.analyze(ctx, poll, counts)
+	if err != nil {
+		log.Printf("⚠️ Failed to analyze poll %d: %v", poll.ID, err)
+		analysis = "My analysis circuits failed, but the numbers above speak for themselves."
+	}
+	if err := s.pollRepo.SetSummary(ctx, poll.ID, analysis); err != nil {
+		log.Printf("⚠️ Failed to store summary of poll %d: %v", poll.ID, err)
+	}
+
+	content := fmt.Sprintf("🗳️ **Poll closed: %s**\n%s\n\n%s", poll.Question, renderTally(poll, counts), analysis)
+	content = truncate(content, 2000)
+	if _, err := s.session.ChannelMessageSendReply(channelID, content, &discordgo.MessageReference{
+		MessageID: strconv.FormatInt(poll.MessageID, 10),
+		ChannelID: channelID,
+	}); err != nil {
+		return fmt.Errorf("failed to post poll results: %w", err)
+	}
+
+	log.Printf("✅ Closed poll %d", poll.ID)
+	return nil
+}
+
+// analyze asks the AI to interpret the tally and the discussion held while the poll was open
+func (s *Service) analyze(ctx context.Context, poll *models.Poll, counts map[int]int) (string, error) {
+	ctx = tenant.WithGuild(ctx, poll.GuildID)
+	discussion, err := s.msgRepo.GetMessagesInRange(ctx, poll.ChannelID, poll.CreatedAt, *poll.ClosedAt, 200)
+	if err != nil {
+		return "", err
+	}
+
+	var prompt strings.Builder
+	prompt.WriteString(fmt.Sprintf("Question: %s\n\nResults:\n%s\n\n", poll.Question, renderTally(poll, counts)))
+	if len(discussion) > 0 {
+		prompt.WriteString("Discussion while the poll was open:\n")
+		prompt.WriteString(summarize.BuildTranscript(discussion, maxDiscussionChars))
+	} else {
+		prompt.WriteString("There was no discussion while the poll was open.")
+	}
+
+	return s.aiService.Complete(ctx, analysisSystemPrompt, prompt.String(), analysisMaxTokens)
+}
+
+// refreshMessage re-renders the live tally on the poll message
+func (s *Service) refreshMessage(ctx context.Context, poll *models.Poll) {
+	counts, err := s.pollRepo.CountVotes(ctx, poll.ID)
+	if err != nil {
+		log.Printf("⚠️ Failed to count votes of poll %d: %v", poll.ID, err)
+		return
+	}
+
+	_, err = s.session.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		Channel:    strconv.FormatInt(poll.ChannelID, 10),
+		ID:         strconv.FormatInt(poll.MessageID, 10),
+		Content:    stringPtr(renderPoll(poll, counts, false)),
+		Components: ptrComponents(pollComponents(poll, false)),
+	})
+	if err != nil {
+		log.Printf("⚠️ Failed to refresh poll message %d: %v", poll.ID, err)
+	}
+}
+
+func renderPoll(poll *models.Poll, counts map[int]int, closed bool) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("🗳️ **%s**\n", poll.Question))
+	if counts == nil {
+		for idx, option := range poll.Options {
+			sb.WriteString(fmt.Sprintf("%d. %s\n", idx+1, option))
+		}
+	} else {
+		sb.WriteString(renderTally(poll, counts))
+		sb.WriteString("\n")
+	}
+
+	switch {
+	case closed:
+		sb.WriteString("*This poll is closed.*")
+	case poll.ClosesAt != nil:
+		sb.WriteString(fmt.Sprintf("*Closes <t:%d:R>.*", poll.ClosesAt.Unix()))
+	default:
+		sb.WriteString("*Open until the creator closes it.*")
+	}
+	return sb.String()
+}
+
+func renderTally(poll *models.Poll, counts map[int]int) string {
+	total := 0
+	for _, c := range counts {
+		total += c
+	}
+
+	lines := make([]string, 0, len(poll.Options))
+	for idx, option := range poll.Options {
+		pct := 0
+		if total > 0 {
+			pct = counts[idx] * 100 / total
+		}
+		bar := strings.Repeat("█", pct/10) + strings.Repeat("░", 10-pct/10)
+		lines = append(lines, fmt.Sprintf("%d. %s — `%s` %d%% (%d)", idx+1, option, bar, pct, counts[idx]))
+	}
+	lines = append(lines, fmt.Sprintf("Total votes: %d", total))
+	return strings.Join(lines, "\n")
+}
+
+func pollComponents(poll *models.Poll, disabled bool) []discordgo.MessageComponent {
+	buttons := make([]discordgo.MessageComponent, 0, len(poll.Options))
+	for idx, option := range poll.Options {
+		label := truncate(option, 80)
+		buttons = append(buttons, discordgo.Button{
+			Label:    label,
+			Style:    discordgo.PrimaryButton,
+			Disabled: disabled,
+			CustomID: fmt.Sprintf("%s:%d:%d", VotePrefix, poll.ID, idx),
+		})
+	}
+
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{Components: buttons},
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.Button{
+				Label:    "Close poll",
+				Style:    discordgo.DangerButton,
+				Disabled: disabled,
+				CustomID: fmt.Sprintf("%s:%d", ClosePrefix, poll.ID),
+			},
+		}},
+	}
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
+
+func ptrComponents(c []discordgo.MessageComponent) *[]discordgo.MessageComponent {
+	return &c
+}
+
+// truncate cuts s to at most max bytes on a rune boundary, marking the cut
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	cut := max - len("…")
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + "…"
+}
@@ -0,0 +1,391 @@
+// Package quiz runs multiple-choice quizzes on a topic, written by the AI from
+// general knowledge or from what the server itself has discussed, and keeps
+// each guild's leaderboard.
+package quiz
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+
+	"discord-tars/internal/injection"
+	"discord-tars/internal/interfaces"
+	"discord-tars/internal/models"
+	"discord-tars/internal/repository"
+	"discord-tars/internal/sanitize"
+)
+
+const (
+	MinQuestions = 1
+	MaxQuestions = 5
+	// Choices is how many answers each question offers, one button each
+	Choices = 4
+
+	generateMaxTokens = 1500
+	maxMaterialChars  = 6000
+	// resultPlayers is how many players the results of a quiz list
+	resultPlayers = 5
+
+	// Component custom ID prefixes routed by the Discord bot
+	AnswerPrefix      = "quiz_answer"
+	LeaderboardPrefix = "quiz_board"
+)
+
+const generateSystemPrompt = `You write multiple-choice quiz questions for a Discord server.
+Write exactly the number of questions asked, on the topic given, each with exactly 4 choices of which exactly one is correct.
+Make the wrong choices plausible, keep every choice under 80 characters, and don't repeat a question.
+Write in the language of the topic.
+Reply with JSON only: {"questions": [{"question": "...", "choices": ["...", "...", "...", "..."], "answer": 0, "explanation": "..."}]}
+where answer is the index of the correct choice and explanation is one short sentence on why it is correct.`
+
+const serverMaterialPrompt = `
+The questions are about this server's own history: base every question and answer only on the SERVER MATERIAL below, never on outside knowledge.
+Ask about what was decided, announced, explained or discussed, not about who said what.`
+
+var (
+	ErrNotFound        = errors.New("quiz question not found")
+	ErrClosed          = errors.New("this quiz is closed")
+	ErrAlreadyAnswered = errors.New("you already answered this question")
+	ErrNoMaterial      = errors.New("nothing indexed on this server is about that topic")
+	ErrBadCount        = fmt.Errorf("a quiz has between %d and %d questions", MinQuestions, MaxQuestions)
+)
+
+type Service struct {
+	aiService interfaces.AIService
+	quizRepo  *repository.QuizRepository
+	session   *discordgo.Session
+}
+
+func NewService(aiService interfaces.AIService, quizRepo *repository.QuizRepository, session *discordgo.Session) *Service {
+	return &Service{
+		aiService: aiService,
+		quizRepo:  quizRepo,
+		session:   session,
+	}
+}
+
+// Create writes a quiz on a topic and posts one message per question, open
+// for answers during duration. Server quizzes are written from material, the
+// server content retrieved for the topic.
+func (s *Service) Create(ctx context.Context, quiz *models.Quiz, count int, material []string, duration time.Duration) error {
+	if count < MinQuestions || count > MaxQuestions {
+		return ErrBadCount
+	}
+	if quiz.Source == models.QuizSourceServer && len(material) == 0 {
+		return ErrNoMaterial
+	}
+
+	questions, err := s.generate(ctx, quiz.Topic, count, material)
+	if err != nil {
+		return err
+	}
+	quiz.ClosesAt = time.Now().Add(duration)
+	if err := s.quizRepo.CreateQuiz(ctx, quiz, questions); err != nil {
+		return err
+	}
+
+	channelID := strconv.FormatInt(quiz.ChannelID, 10)
+	for n := range questions {
+		question := &questions[n]
+		msg, err := s.session.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{
+			Content:    renderQuestion(quiz, question, len(questions), nil),
+			Components: questionComponents(question, false),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to post quiz question: %w", err)
+		}
+		question.MessageID, _ = strconv.ParseInt(msg.ID, 10, 64)
+		if err := s.quizRepo.SetMessageID(ctx, question.ID, question.MessageID); err != nil {
+			log.Printf("⚠️ Failed to store message ID of quiz question %d: %v", question.ID, err)
+		}
+	}
+
+	log.Printf("❓ Created quiz %d with %d questions on %q in channel %d", quiz.ID, len(questions), quiz.Topic, quiz.ChannelID)
+	return nil
+}
+
+// generate asks the AI for questions and checks they are well formed
+func (s *Service) generate(ctx context.Context, topic string, count int, material []string) ([]models.QuizQuestion, error) {
+	system := generateSystemPrompt
+	prompt := fmt.Sprintf("TOPIC: %s\nNUMBER OF QUESTIONS: %d", topic, count)
+	if len(material) > 0 {
+		system += serverMaterialPrompt
+		var sb strings.Builder
+		for _, text := range material {
+			// Anyone who could post in the server wrote these
+			text, _ = injection.Strip(sanitize.Context(text))
+			line := "- " + text + "\n"
+			if sb.Len()+len(line) > maxMaterialChars {
+				break
+			}
+			sb.WriteString(line)
+		}
+		prompt += "\n\nSERVER MATERIAL:\n" + sb.String()
+	}
+
+	reply, err := s.aiService.Complete(ctx, system, prompt, generateMaxTokens)
+	if err != nil {
+		return nil, err
+	}
+	start, end := strings.Index(reply, "{"), strings.LastIndex(reply, "}")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("quiz generation returned no JSON: %q", reply)
+	}
+	var out struct {
+		Questions []struct {
+			Question    string   `json:"question"`
+			Choices     []string `json:"choices"`
+			Answer      int      `json:"answer"`
+			Explanation string   `json:"explanation"`
+		} `json:"questions"`
+	}
+	if err := json.Unmarshal([]byte(reply[start:end+1]), &out); err != nil {
+		return nil, fmt.Errorf("failed to parse quiz questions: %w", err)
+	}
+
+	var questions []models.QuizQuestion
+	for _, q := range out.Questions {
+		if strings.TrimSpace(q.Question) == "" || len(q.Choices) != Choices || q.Answer < 0 || q.Answer >= Choices {
+			continue
+		}
+		question := models.QuizQuestion{
+			Position:    len(questions) + 1,
+			Question:    sanitize.Output(strings.TrimSpace(q.Question)),
+			Choices:     make([]string, Choices),
+			Explanation: sanitize.Output(strings.TrimSpace(q.Explanation)),
+		}
+		// Models favor putting the right answer first, so shuffle the choices
+		for to, from := range rand.Perm(Choices) {
+			question.Choices[to] = strings.TrimSpace(q.Choices[from])
+			if from == q.Answer {
+				question.Answer = to
+			}
+		}
+		questions = append(questions, question)
+		if len(questions) == count {
+			break
+		}
+	}
+	if len(questions) == 0 {
+		return nil, fmt.Errorf("quiz generation returned no usable questions")
+	}
+	return questions, nil
+}
+
+// Answer records a user's answer to a question; only the first counts
+func (s *Service) Answer(ctx context.Context, questionID, userID int64, choice int) (*models.QuizQuestion, bool, error) {
+	question, err := s.quizRepo.GetQuestion(ctx, questionID)
+	if err != nil {
+		return nil, false, err
+	}
+	if question == nil || choice < 0 || choice >= len(question.Choices) {
+		return nil, false, ErrNotFound
+	}
+	quiz, err := s.quizRepo.GetQuiz(ctx, question.QuizID)
+	if err != nil {
+		return nil, false, err
+	}
+	if quiz == nil {
+		return nil, false, ErrNotFound
+	}
+	if quiz.ClosedAt != nil || time.Now().After(quiz.ClosesAt) {
+		return nil, false, ErrClosed
+	}
+
+	correct := choice == question.Answer
+	recorded, err := s.quizRepo.Answer(ctx, &models.QuizAnswer{
+		QuestionID: questionID,
+		UserID:     userID,
+		GuildID:    quiz.GuildID,
+		Choice:     choice,
+		Correct:    correct,
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	if !recorded {
+		return nil, false, ErrAlreadyAnswered
+	}
+	return question, correct, nil
+}
+
+// Leaderboard returns a guild's best quiz players
+func (s *Service) Leaderboard(ctx context.Context, guildID int64, limit int) ([]models.QuizScore, error) {
+	return s.quizRepo.Leaderboard(ctx, guildID, limit)
+}
+
+// UserScore returns a user's quiz record in a guild and their rank, zero if
+// they never played
+func (s *Service) UserScore(ctx context.Context, guildID, userID int64) (models.QuizScore, int, error) {
+	return s.quizRepo.UserScore(ctx, guildID, userID)
+}
+
+// CloseExpired is the scheduler job revealing the answers of quizzes whose
+// answering time has elapsed
+func (s *Service) CloseExpired(ctx context.Context) error {
+	quizzes, err := s.quizRepo.ListExpired(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+	for n := range quizzes {
+		if err := s.close(ctx, &quizzes[n]); err != nil {
+			log.Printf("❌ Failed to close quiz %d: %v", quizzes[n].ID, err)
+		}
+	}
+	return nil
+}
+
+func (s *Service) close(ctx context.Context, quiz *models.Quiz) error {
+	now := time.Now()
+	claimed, err := s.quizRepo.CloseQuiz(ctx, quiz.ID, now)
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		return ErrClosed
+	}
+	quiz.ClosedAt = &now
+
+	questions, err := s.quizRepo.ListQuestions(ctx, quiz.ID)
+	if err != nil {
+		return err
+	}
+	channelID := strconv.FormatInt(quiz.ChannelID, 10)
+	for n := range questions {
+		question := &questions[n]
+		if question.MessageID == 0 {
+			continue
+		}
+		counts, err := s.quizRepo.CountAnswers(ctx, question.ID)
+		if err != nil {
+			log.Printf("⚠️ Failed to count answers of quiz question %d: %v", question.ID, err)
+		}
+		if _, err := s.session.ChannelMessageEditComplex(&discordgo.MessageEdit{
+			Channel:    channelID,
+			ID:         strconv.FormatInt(question.MessageID, 10),
+			Content:    stringPtr(renderQuestion(quiz, question, len(questions), counts)),
+			Components: ptrComponents(questionComponents(question, true)),
+		}); err != nil {
+			log.Printf("⚠️ Failed to reveal quiz question %d: %v", question.ID, err)
+		}
+	}
+
+	scores, err := s.quizRepo.QuizScores(ctx, quiz.ID, resultPlayers)
+	if err != nil {
+		return err
+	}
+	var reference *discordgo.MessageReference
+	if len(questions) > 0 && questions[0].MessageID != 0 {
+		reference = &discordgo.MessageReference{MessageID: strconv.FormatInt(questions[0].MessageID, 10), ChannelID: channelID}
+	}
+	if _, err := s.session.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{
+		Content:   renderResults(quiz, len(questions), scores),
+		Reference: reference,
+		// Results name the winners without pinging them
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	}); err != nil {
+		return fmt.Errorf("failed to post quiz results: %w", err)
+	}
+
+	log.Printf("✅ Closed quiz %d", quiz.ID)
+	return nil
+}
+
+var choiceLetters = []string{"A", "B", "C", "D"}
+
+// renderQuestion shows a question; counts, once the quiz closed, reveal the
+// answer and how players answered
+func renderQuestion(quiz *models.Quiz, question *models.QuizQuestion, total int, counts map[int]int) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "❓ **Quiz: %s** — question %d/%d\n**%s**\n", quiz.Topic, question.Position, total, question.Question)
+	for n, choice := range question.Choices {
+		switch {
+		case quiz.ClosedAt == nil:
+			fmt.Fprintf(&sb, "%s. %s\n", choiceLetters[n], choice)
+		case n == question.Answer:
+			fmt.Fprintf(&sb, "✅ **%s. %s** (%d)\n", choiceLetters[n], choice, counts[n])
+		default:
+			fmt.Fprintf(&sb, "▫️ %s. %s (%d)\n", choiceLetters[n], choice, counts[n])
+		}
+	}
+	if quiz.ClosedAt == nil {
+		fmt.Fprintf(&sb, "*Answers close <t:%d:R>. Your first answer counts.*", quiz.ClosesAt.Unix())
+	} else if question.Explanation != "" {
+		sb.WriteString("💡 " + question.Explanation)
+	}
+	return truncate(sb.String(), 2000)
+}
+
+// renderResults shows the best players of a closed quiz
+func renderResults(quiz *models.Quiz, total int, scores []models.QuizScore) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "🏁 **Quiz over: %s**\n", quiz.Topic)
+	if len(scores) == 0 {
+		sb.WriteString("Nobody answered this time.")
+	}
+	medals := []string{"🥇", "🥈", "🥉"}
+	for n, score := range scores {
+		place := fmt.Sprintf("%d.", n+1)
+		if n < len(medals) {
+			place = medals[n]
+		}
+		fmt.Fprintf(&sb, "%s <@%d> — %d/%d\n", place, score.UserID, score.Correct, total)
+	}
+	return truncate(sb.String(), 2000)
+}
+
+func questionComponents(question *models.QuizQuestion, disabled bool) []discordgo.MessageComponent {
+	buttons := make([]discordgo.MessageComponent, 0, len(question.Choices)+1)
+	for n, choice := range question.Choices {
+		style := discordgo.SecondaryButton
+		if disabled && n == question.Answer {
+			style = discordgo.SuccessButton
+		}
+		buttons = append(buttons, discordgo.Button{
+			Label:    truncate(choiceLetters[n]+". "+choice, 80),
+			Style:    style,
+			Disabled: disabled,
+			CustomID: fmt.Sprintf("%s:%d:%d", AnswerPrefix, question.ID, n),
+		})
+	}
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{Components: buttons},
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.Button{
+				Label:    "Leaderboard",
+				Emoji:    &discordgo.ComponentEmoji{Name: "🏆"},
+				Style:    discordgo.PrimaryButton,
+				CustomID: LeaderboardPrefix,
+			},
+		}},
+	}
+}
+
+// truncate cuts text to a byte length without splitting a character
+func truncate(text string, max int) string {
+	if len(text) <= max {
+		return text
+	}
+	cut := max - len("…")
+	for cut > 0 && !utf8.RuneStart(text[cut]) {
+		cut--
+	}
+	return text[:cut] + "…"
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
+
+func ptrComponents(c []discordgo.MessageComponent) *[]discordgo.MessageComponent {
+	return &c
+}
@@ -0,0 +1,68 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"discord-tars/internal/storage"
+)
+
+// attachmentClient downloads attachments from Discord's CDN
+var attachmentClient = &http.Client{Timeout: 2 * time.Minute}
+
+// SetAttachmentStore enables archiving message attachments, which Discord's CDN
+// links expire, so they can be re-served later with signed URLs
+func (s *Service) SetAttachmentStore(store storage.Store, maxBytes int64) {
+	s.attachmentStore = store
+	s.maxAttachmentBytes = maxBytes
+}
+
+// AttachmentKey returns the storage key of a message attachment
+func AttachmentKey(guildID, channelID, messageID, filename string) string {
+	if guildID == "" {
+		guildID = "dm"
+	}
+	return fmt.Sprintf("%s%s/%s/%s/%s", storage.PrefixAttachments, guildID, channelID, messageID, storage.SanitizeName(filename))
+}
+
+// archiveAttachments copies a message's attachments into the attachment store
+func (s *Service) archiveAttachments(msg *discordgo.Message) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	for _, att := range msg.Attachments {
+		if s.maxAttachmentBytes > 0 && int64(att.Size) > s.maxAttachmentBytes {
+			log.Printf("ℹ️ Skipping attachment %s (%d bytes) over the archive limit", att.Filename, att.Size)
+			continue
+		}
+
+		key := AttachmentKey(msg.GuildID, msg.ChannelID, msg.ID, att.Filename)
+		if err := s.archiveAttachment(ctx, att, key); err != nil {
+			log.Printf("❌ Failed to archive attachment %s: %v", att.Filename, err)
+			continue
+		}
+		log.Printf("💾 Archived attachment %s", key)
+	}
+}
+
+func (s *Service) archiveAttachment(ctx context.Context, att *discordgo.MessageAttachment, key string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, att.URL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := attachmentClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("cdn returned status %d", resp.StatusCode)
+	}
+	return s.attachmentStore.Put(ctx, key, resp.Body, resp.ContentLength, att.ContentType)
+}
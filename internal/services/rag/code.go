@@ -0,0 +1,251 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/repository"
+)
+
+const (
+	// maxCodeSnippets bounds how many code blocks of one message are embedded
+	maxCodeSnippets = 5
+	// minCodeChars skips blocks too short to be worth finding, e.g. ```yes```
+	minCodeChars = 12
+	// maxCodeEmbedChars bounds the text embedded for a snippet
+	maxCodeEmbedChars = 6000
+	// Code search ranks this many of a guild's closest snippets
+	codeSearchCandidates = 25
+	codeMinSimilarity    = 0.3
+	// maxCodeIdentifiers bounds the identifiers spelled out for the embedding
+	maxCodeIdentifiers = 60
+)
+
+var (
+	// fencedCode matches a fenced block with its optional language; a block
+	// on one line has no language
+	fencedCode = regexp.MustCompile("(?s)```(?:([\\w+#.-]+)?[ \\t]*\\n)?(.*?)```")
+	identifier = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_]{2,}`)
+)
+
+// languageAliases maps fence names to the language they are normalized to
+var languageAliases = func() map[string]string {
+	names := map[string][]string{
+		"go":         {"golang"},
+		"javascript": {"js", "jsx", "mjs", "node"},
+		"typescript": {"ts", "tsx"},
+		"python":     {"py", "py3", "python3"},
+		"ruby":       {"rb"},
+		"rust":       {"rs"},
+		"shell":      {"sh", "bash", "zsh", "console", "shell-session"},
+		"powershell": {"ps1", "pwsh"},
+		"cpp":        {"c++", "cc", "cxx", "hpp"},
+		"csharp":     {"c#", "cs"},
+		"kotlin":     {"kt"},
+		"yaml":       {"yml"},
+		"markdown":   {"md"},
+		"sql":        {"psql", "postgres", "postgresql", "mysql"},
+		"docker":     {"dockerfile"},
+		"terraform":  {"tf", "hcl"},
+		"html":       {"htm", "xml"},
+		"json":       {"jsonc"},
+	}
+	aliases := make(map[string]string)
+	for language, names := range names {
+		for _, name := range names {
+			aliases[name] = language
+		}
+	}
+	return aliases
+}()
+
+// NormalizeLanguage maps a fence or option language such as "py" or "Golang"
+// to the name snippets are stored under
+func NormalizeLanguage(language string) string {
+	language = strings.ToLower(strings.TrimSpace(language))
+	if alias, ok := languageAliases[language]; ok {
+		return alias
+	}
+	return language
+}
+
+// codeBlock is a fenced block found in a message
+type codeBlock struct {
+	language string
+	code     string
+}
+
+// extractCode returns the fenced code blocks of a message and its text
+// without them, which usually says what the code is for
+func extractCode(content string) ([]codeBlock, string) {
+	var blocks []codeBlock
+	for _, match := range fencedCode.FindAllStringSubmatch(content, -1) {
+		code := strings.TrimSpace(match[2])
+		if len(code) < minCodeChars {
+			continue
+		}
+		blocks = append(blocks, codeBlock{language: NormalizeLanguage(match[1]), code: code})
+		if len(blocks) == maxCodeSnippets {
+			break
+		}
+	}
+	prose := strings.Join(strings.Fields(fencedCode.ReplaceAllString(content, " ")), " ")
+	return blocks, prose
+}
+
+// codeEmbeddingText is what a snippet is embedded as. General-purpose
+// embeddings match code poorly against questions in plain words, so the code
+// is preceded by its language, the message text around it, and its
+// identifiers split into words (parseRetryAfter becomes "parse retry after").
+func codeEmbeddingText(block codeBlock, prose string) string {
+	var text strings.Builder
+	if block.language != "" {
+		fmt.Fprintf(&text, "Language: %s\n", block.language)
+	}
+	if prose != "" {
+		fmt.Fprintf(&text, "Context: %s\n", truncate(prose, 500))
+	}
+	if words := identifierWords(block.code); words != "" {
+		fmt.Fprintf(&text, "Identifiers: %s\n", words)
+	}
+	text.WriteString("\n")
+	text.WriteString(block.code)
+	return truncate(text.String(), maxCodeEmbedChars)
+}
+
+// identifierWords spells out the distinct identifiers of some code as words
+func identifierWords(code string) string {
+	seen := make(map[string]bool)
+	var words []string
+	for _, ident := range identifier.FindAllString(code, -1) {
+		phrase := strings.ToLower(strings.Join(splitIdentifier(ident), " "))
+		if seen[phrase] {
+			continue
+		}
+		seen[phrase] = true
+		words = append(words, phrase)
+		if len(words) == maxCodeIdentifiers {
+			break
+		}
+	}
+	return strings.Join(words, ", ")
+}
+
+// splitIdentifier splits camelCase, PascalCase and snake_case names into words
+func splitIdentifier(ident string) []string {
+	var words []string
+	var word []rune
+	runes := []rune(ident)
+	for n, r := range runes {
+		if r == '_' {
+			if len(word) > 0 {
+				words = append(words, string(word))
+				word = nil
+			}
+			continue
+		}
+		// A capital starts a word after a lowercase letter, or ends an
+		// acronym when a lowercase letter follows (HTTPServer)
+		if unicode.IsUpper(r) && len(word) > 0 {
+			prev := runes[n-1]
+			nextLower := n+1 < len(runes) && unicode.IsLower(runes[n+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				words = append(words, string(word))
+				word = nil
+			}
+		}
+		word = append(word, r)
+	}
+	if len(word) > 0 {
+		words = append(words, string(word))
+	}
+	return words
+}
+
+func truncate(text string, limit int) string {
+	if len(text) <= limit {
+		return text
+	}
+	cut := limit
+	for cut > 0 && !utf8.RuneStart(text[cut]) {
+		cut--
+	}
+	return text[:cut]
+}
+
+// SetCodeRepository makes indexing store the code blocks of messages
+// separately, so /search can find shared snippets
+func (s *Service) SetCodeRepository(codeRepo *repository.CodeRepository) {
+	s.codeRepo = codeRepo
+}
+
+// CodeSearchEnabled reports whether code blocks are indexed
+func (s *Service) CodeSearchEnabled() bool {
+	return s.codeRepo != nil
+}
+
+// indexCode embeds the code blocks of a stored message, replacing those it had
+func (s *Service) indexCode(ctx context.Context, message *models.Message) error {
+	if s.codeRepo == nil || !strings.Contains(message.Content, "```") {
+		return nil
+	}
+	blocks, prose := extractCode(message.Content)
+	snippets := make([]models.CodeSnippet, 0, len(blocks))
+	embeddings := make([][]float32, 0, len(blocks))
+	for _, block := range blocks {
+		embedding, err := s.aiService.GenerateEmbedding(ctx, codeEmbeddingText(block, prose))
+		if err != nil {
+			return fmt.Errorf("failed to embed code block of message %d: %w", message.ID, err)
+		}
+		snippets = append(snippets, models.CodeSnippet{
+			MessageID: message.ID,
+			GuildID:   message.GuildID,
+			ChannelID: message.ChannelID,
+			UserID:    message.UserID,
+			Language:  block.language,
+			Code:      block.code,
+			Timestamp: message.Timestamp,
+		})
+		embeddings = append(embeddings, embedding)
+	}
+	if err := s.codeRepo.ReplaceMessageSnippets(ctx, message.ID, snippets, embeddings); err != nil {
+		return err
+	}
+	if len(snippets) > 0 {
+		log.Printf("🧩 Indexed %d code block(s) of message ID: %d", len(snippets), message.ID)
+	}
+	return nil
+}
+
+// SearchCode finds a guild's shared code snippets matching a description or
+// a fragment of code, limited to a language when one is given
+func (s *Service) SearchCode(ctx context.Context, guildID int64, query, language string) ([]models.CodeResult, error) {
+	if s.codeRepo == nil {
+		return nil, fmt.Errorf("code search is not enabled")
+	}
+	language = NormalizeLanguage(language)
+	text := query
+	if language != "" {
+		text = fmt.Sprintf("Language: %s\n%s", language, query)
+	}
+	queryEmbedding, err := s.aiService.GenerateEmbedding(ctx, text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate query embedding: %w", err)
+	}
+	return s.codeRepo.Search(ctx, guildID, language, queryEmbedding, codeSearchCandidates, codeMinSimilarity)
+}
+
+// CodeLanguages lists the languages of a guild's shared snippets, most
+// common first
+func (s *Service) CodeLanguages(ctx context.Context, guildID int64, limit int) ([]string, error) {
+	if s.codeRepo == nil {
+		return nil, nil
+	}
+	return s.codeRepo.Languages(ctx, guildID, limit)
+}
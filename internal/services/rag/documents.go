@@ -0,0 +1,116 @@
+package rag
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxDocumentPartBytes bounds how much of one file inside an Office document
+// is read, so a zip bomb can't exhaust memory
+const maxDocumentPartBytes = 8 << 20
+
+// textExtensions are files read as plain text whatever their content type
+var textExtensions = map[string]bool{
+	".txt": true, ".md": true, ".markdown": true, ".rst": true, ".log": true,
+	".csv": true, ".tsv": true, ".json": true, ".yaml": true, ".yml": true, ".toml": true, ".ini": true, ".xml": true,
+	".html": true, ".sql": true, ".go": true, ".py": true, ".js": true, ".ts": true, ".java": true, ".rs": true, ".sh": true,
+}
+
+// officeParts are the files inside Office documents holding their text, by
+// document extension
+var officeParts = map[string]func(name string) bool{
+	".docx": func(name string) bool { return name == "word/document.xml" },
+	".xlsx": func(name string) bool {
+		return name == "xl/sharedStrings.xml" || name == "xl/workbook.xml"
+	},
+	".pptx": func(name string) bool {
+		return strings.HasPrefix(name, "ppt/slides/slide") && strings.HasSuffix(name, ".xml")
+	},
+}
+
+// isDocument reports whether documentText can read a file
+func isDocument(filename, contentType string) bool {
+	ext := strings.ToLower(path.Ext(filename))
+	return textExtensions[ext] || officeParts[ext] != nil || strings.HasPrefix(contentType, "text/")
+}
+
+// documentText returns the text of a document: plain text files as they are,
+// and the words of Word, Excel and PowerPoint files. Other files, and
+// documents it can't read, have no text.
+func documentText(filename, contentType string, data []byte) string {
+	ext := strings.ToLower(path.Ext(filename))
+	if isPart := officeParts[ext]; isPart != nil {
+		return officeText(data, isPart)
+	}
+	if !isDocument(filename, contentType) || !utf8.Valid(data) {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// officeText reads the text elements of the parts of an Office document, a
+// zip of XML files; paragraphs, cells and sheet names each go on a line
+func officeText(data []byte, isPart func(name string) bool) string {
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return ""
+	}
+	var parts []*zip.File
+	for _, file := range reader.File {
+		if isPart(file.Name) {
+			parts = append(parts, file)
+		}
+	}
+	// Slides are numbered slide1.xml, slide2.xml...; keep them in order
+	sort.Slice(parts, func(a, b int) bool {
+		if len(parts[a].Name) != len(parts[b].Name) {
+			return len(parts[a].Name) < len(parts[b].Name)
+		}
+		return parts[a].Name < parts[b].Name
+	})
+
+	var sb strings.Builder
+	for _, part := range parts {
+		rc, err := part.Open()
+		if err != nil {
+			continue
+		}
+		decoder := xml.NewDecoder(io.LimitReader(rc, maxDocumentPartBytes))
+		inText := false
+		for {
+			token, err := decoder.Token()
+			if err != nil {
+				break
+			}
+			switch t := token.(type) {
+			case xml.StartElement:
+				inText = t.Name.Local == "t"
+				// Sheet names say what a spreadsheet holds
+				if t.Name.Local == "sheet" {
+					for _, attr := range t.Attr {
+						if attr.Name.Local == "name" {
+							sb.WriteString(attr.Value + "\n")
+						}
+					}
+				}
+			case xml.EndElement:
+				inText = false
+				if t.Name.Local == "p" || t.Name.Local == "si" {
+					sb.WriteString("\n")
+				}
+			case xml.CharData:
+				if inText {
+					sb.Write(t)
+				}
+			}
+		}
+		rc.Close()
+	}
+	return strings.TrimSpace(sb.String())
+}
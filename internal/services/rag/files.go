@@ -0,0 +1,250 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/bwmarrin/discordgo"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/repository"
+	"discord-tars/internal/services/ocr"
+)
+
+const (
+	// maxDocumentBytes bounds the files downloaded to read their text; larger
+	// ones are indexed by name only
+	maxDocumentBytes = 10 << 20
+	// maxFileTextChars bounds the text of a document stored and embedded
+	maxFileTextChars = 6000
+	// File search ranks this many of a guild's closest files
+	fileSearchCandidates = 25
+	fileMinSimilarity    = 0.25
+	// Questions consider this many screenshots whose text is at least this
+	// close to them
+	screenshotMaxResults    = 3
+	screenshotMinSimilarity = 0.4
+	// A file whose name, or uploader's, has a word of the query ranks this
+	// much higher
+	fileNameBoost     = 0.1
+	fileUploaderBoost = 0.05
+)
+
+// fileKinds name files by extension, the way people ask for them
+var fileKinds = map[string]string{
+	".xlsx": "spreadsheet", ".xls": "spreadsheet", ".ods": "spreadsheet", ".csv": "spreadsheet", ".tsv": "spreadsheet",
+	".docx": "document", ".doc": "document", ".odt": "document", ".rtf": "document", ".md": "document", ".txt": "document",
+	".pptx": "presentation", ".ppt": "presentation", ".odp": "presentation", ".key": "presentation",
+	".pdf": "PDF",
+	".zip": "archive", ".tar": "archive", ".gz": "archive", ".7z": "archive", ".rar": "archive",
+	".log": "log file", ".json": "data file", ".yaml": "data file", ".yml": "data file", ".xml": "data file",
+}
+
+// SetAttachmentRepository makes indexing store the files shared in messages,
+// with the text of documents, so /search can find them
+func (s *Service) SetAttachmentRepository(attachmentRepo *repository.AttachmentRepository) {
+	s.attachmentRepo = attachmentRepo
+}
+
+// SetOCR makes indexing read the text in images, such as screenshots of
+// errors, so questions and /search find them by what they say
+func (s *Service) SetOCR(engine ocr.Engine) {
+	s.ocr = engine
+}
+
+// FileSearchEnabled reports whether shared files are indexed
+func (s *Service) FileSearchEnabled() bool {
+	return s.attachmentRepo != nil
+}
+
+// FileKind names the kind of a file, e.g. "spreadsheet" or "image"
+func FileKind(filename, contentType string) string {
+	if kind, ok := fileKinds[strings.ToLower(path.Ext(filename))]; ok {
+		return kind
+	}
+	switch {
+	case strings.HasPrefix(contentType, "image/"):
+		return "image"
+	case strings.HasPrefix(contentType, "video/"):
+		return "video"
+	case strings.HasPrefix(contentType, "audio/"):
+		return "audio"
+	case isDocument(filename, contentType):
+		return "text file"
+	}
+	return "file"
+}
+
+// indexAttachments stores the attachments of a stored message with what they
+// are, who shared them and the text of documents, replacing those it had.
+// Documents are downloaded, so this runs apart from indexing the message.
+func (s *Service) indexAttachments(msg *discordgo.Message, message *models.Message) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	attachments := make([]models.MessageAttachment, 0, len(msg.Attachments))
+	embeddings := make([][]float32, 0, len(msg.Attachments))
+	for _, att := range msg.Attachments {
+		id, err := strconv.ParseInt(att.ID, 10, 64)
+		if err != nil {
+			continue
+		}
+		attachment := models.MessageAttachment{
+			ID:          id,
+			MessageID:   message.ID,
+			GuildID:     message.GuildID,
+			ChannelID:   message.ChannelID,
+			UserID:      message.UserID,
+			Filename:    att.Filename,
+			ContentType: att.ContentType,
+			Size:        int64(att.Size),
+			URL:         att.URL,
+			Timestamp:   message.Timestamp,
+		}
+		attachment.Content = truncate(s.attachmentText(ctx, att), maxFileTextChars)
+
+		embedding, err := s.aiService.GenerateEmbedding(ctx, fileEmbeddingText(&attachment, msg.Author.Username, msg.Content))
+		if err != nil {
+			log.Printf("⚠️ Failed to embed attachment %s of message ID: %d: %v", att.Filename, message.ID, err)
+			return
+		}
+		attachments = append(attachments, attachment)
+		embeddings = append(embeddings, embedding)
+	}
+	if err := s.attachmentRepo.ReplaceMessageAttachments(ctx, message.ID, attachments, embeddings); err != nil {
+		log.Printf("⚠️ %v", err)
+		return
+	}
+	log.Printf("📎 Indexed %d attachment(s) of message ID: %d", len(attachments), message.ID)
+}
+
+// attachmentText reads the text of a document, or of an image when OCR is
+// enabled; files it can't read are indexed by name
+func (s *Service) attachmentText(ctx context.Context, att *discordgo.MessageAttachment) string {
+	switch {
+	case isDocument(att.Filename, att.ContentType) && att.Size <= maxDocumentBytes:
+		data, err := downloadAttachment(ctx, att, maxDocumentBytes)
+		if err != nil {
+			log.Printf("⚠️ Failed to read attachment %s, indexing it by name: %v", att.Filename, err)
+			return ""
+		}
+		return documentText(att.Filename, att.ContentType, data)
+
+	case s.ocr != nil && ocr.Supported(att.ContentType) && att.Size <= ocr.MaxImageBytes:
+		data, err := downloadAttachment(ctx, att, ocr.MaxImageBytes)
+		if err == nil {
+			var text string
+			if text, err = s.ocr.Read(ctx, data, att.ContentType); err == nil {
+				if text != "" {
+					log.Printf("🖼️ Read %d characters of text from image %s", len(text), att.Filename)
+				}
+				return text
+			}
+		}
+		log.Printf("⚠️ Failed to read the text of image %s, indexing it by name: %v", att.Filename, err)
+	}
+	return ""
+}
+
+func downloadAttachment(ctx context.Context, att *discordgo.MessageAttachment, limit int64) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, att.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := attachmentClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("cdn returned status %d", resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, limit))
+}
+
+// fileEmbeddingText is what a shared file is embedded as: its name and kind,
+// who shared it and why, and what it says
+func fileEmbeddingText(attachment *models.MessageAttachment, username, message string) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "File: %s (%s)\n", attachment.Filename, FileKind(attachment.Filename, attachment.ContentType))
+	// Names like budget_2024-q3.xlsx read better as words
+	words := strings.NewReplacer("_", " ", "-", " ", ".", " ").Replace(strings.TrimSuffix(attachment.Filename, path.Ext(attachment.Filename)))
+	fmt.Fprintf(&sb, "Name: %s\n", words)
+	fmt.Fprintf(&sb, "Shared by: %s\n", username)
+	if message = strings.TrimSpace(message); message != "" {
+		fmt.Fprintf(&sb, "Message: %s\n", truncate(message, 1000))
+	}
+	if attachment.Content != "" {
+		label := "Content"
+		if strings.HasPrefix(attachment.ContentType, "image/") {
+			label = "Text in the image"
+		}
+		fmt.Fprintf(&sb, "%s:\n%s", label, attachment.Content)
+	}
+	return sb.String()
+}
+
+// SearchFiles finds the files shared in a guild matching a description,
+// which may name the file, its kind, who shared it or what it says, e.g.
+// "the spreadsheet Dana uploaded about budgets"
+func (s *Service) SearchFiles(ctx context.Context, guildID int64, query string) ([]models.AttachmentResult, error) {
+	if s.attachmentRepo == nil {
+		return nil, fmt.Errorf("file search is not enabled")
+	}
+	queryEmbedding, err := s.aiService.GenerateEmbedding(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate query embedding: %w", err)
+	}
+	results, err := s.attachmentRepo.Search(ctx, guildID, queryEmbedding, fileSearchCandidates, fileMinSimilarity)
+	if err != nil {
+		return nil, err
+	}
+
+	// Names are matched on their words too, which embeddings blur
+	var terms []string
+	for _, term := range strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if len([]rune(term)) >= 3 {
+			terms = append(terms, term)
+		}
+	}
+	for n := range results {
+		filename := strings.ToLower(results[n].Attachment.Filename)
+		username := strings.ToLower(results[n].Username)
+		for _, term := range terms {
+			if strings.Contains(filename, term) {
+				results[n].Similarity += fileNameBoost
+			}
+			if username != "" && strings.HasPrefix(username, term) {
+				results[n].Similarity += fileUploaderBoost
+			}
+		}
+	}
+	sort.SliceStable(results, func(a, b int) bool { return results[a].Similarity > results[b].Similarity })
+	return results, nil
+}
+
+// searchScreenshots finds the images posted in a guild whose text, read by
+// OCR, is close to a question
+func (s *Service) searchScreenshots(ctx context.Context, queryEmbedding []float32, guildID int64) []models.AttachmentResult {
+	if s.attachmentRepo == nil || s.ocr == nil || guildID == 0 {
+		return nil
+	}
+	results, err := s.attachmentRepo.SearchImageText(ctx, guildID, queryEmbedding, screenshotMaxResults, screenshotMinSimilarity)
+	if err != nil {
+		log.Printf("⚠️ Screenshot search failed, continuing without it: %v", err)
+		return nil
+	}
+	return results
+}
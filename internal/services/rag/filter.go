@@ -0,0 +1,209 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"discord-tars/internal/models"
+)
+
+const (
+	// A message repeating one its author sent within the spam window, or
+	// linking or mentioning a lot, scores this much more than a plain one
+	spamRepeatScore  = 3
+	spamLinkScore    = 1
+	spamMaxLinks     = 3
+	spamMentionScore = 2
+	spamMaxMentions  = 5
+	// The tracker drops idle members once it follows this many
+	spamSweepSize = 5000
+)
+
+var (
+	linkPattern = regexp.MustCompile(`https?://\S+`)
+	// Mentions, channel links, custom emojis and timestamps say nothing alone
+	mentionPattern = regexp.MustCompile(`<(?:@[!&]?\d+|#\d+|a?:\w+:\d+|t:\d+(?::\w)?)>|@everyone|@here`)
+)
+
+// FilterConfig sets which messages are kept out of retrieval
+type FilterConfig struct {
+	// MinLength is the fewest letters and digits a message needs, not counting
+	// mentions and emojis; messages with links are left to SkipLinkOnly. 0
+	// disables the check.
+	MinLength int
+	// Blocklist holds regular expressions of messages never to embed
+	Blocklist []string
+	// SkipLinkOnly skips messages made only of links
+	SkipLinkOnly bool
+	// CommandPrefixes are the characters other bots' commands start with,
+	// e.g. "!$" for "!play" and "$balance"
+	CommandPrefixes string
+	// A member whose messages within SpamWindow score SpamThreshold or more is
+	// flooding, and their messages aren't indexed; 0 disables spam scoring
+	SpamThreshold float64
+	SpamWindow    time.Duration
+}
+
+// IngestFilter decides which incoming messages are worth indexing. Messages
+// like "lol", bare links and commands for other bots are stored but not
+// embedded; messages from members flooding a channel aren't stored at all.
+type IngestFilter struct {
+	cfg        FilterConfig
+	blocklist  []*regexp.Regexp
+	classifier ClassifierConfig
+	counts     ingestCounters
+
+	mu     sync.Mutex
+	recent map[spamKey][]scoredMessage // Members' messages within the spam window
+}
+
+type spamKey struct {
+	guildID, userID int64
+}
+
+type scoredMessage struct {
+	id    int64
+	text  string // Normalized, to spot repeats
+	score float64
+	at    time.Time
+}
+
+// NewIngestFilter compiles a filter's blocklist
+func NewIngestFilter(cfg FilterConfig) (*IngestFilter, error) {
+	filter := &IngestFilter{cfg: cfg, recent: make(map[spamKey][]scoredMessage)}
+	for _, pattern := range cfg.Blocklist {
+		if pattern = strings.TrimSpace(pattern); pattern == "" {
+			continue
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid blocklist pattern %q: %w", pattern, err)
+		}
+		filter.blocklist = append(filter.blocklist, re)
+	}
+	return filter, nil
+}
+
+// SetIngestFilter keeps low-value and spam messages out of the index.
+// Messages in priority channels, and the bot's own, are always indexed.
+func (s *Service) SetIngestFilter(filter *IngestFilter) {
+	s.ingestFilter = filter
+}
+
+// lowValue returns why a message isn't worth embedding, or "" when it is
+func (f *IngestFilter) lowValue(content string) string {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return ""
+	}
+	for _, re := range f.blocklist {
+		if re.MatchString(content) {
+			return models.FilterReasonBlocklist
+		}
+	}
+	if f.isCommand(content) {
+		return models.FilterReasonCommand
+	}
+
+	hasLinks := linkPattern.MatchString(content)
+	length := textLength(mentionPattern.ReplaceAllString(linkPattern.ReplaceAllString(content, " "), " "))
+	switch {
+	case hasLinks && f.cfg.SkipLinkOnly && length == 0:
+		return models.FilterReasonLinkOnly
+	case !hasLinks && length < f.cfg.MinLength:
+		return models.FilterReasonShort
+	}
+	return ""
+}
+
+// isCommand reports whether a message is a prefix command for another bot: a
+// prefix character followed by a word, e.g. "!play never gonna"
+func (f *IngestFilter) isCommand(content string) bool {
+	if f.cfg.CommandPrefixes == "" {
+		return false
+	}
+	runes := []rune(content)
+	return len(runes) > 1 && strings.ContainsRune(f.cfg.CommandPrefixes, runes[0]) && unicode.IsLetter(runes[1])
+}
+
+// textLength counts the letters and digits of a text
+func textLength(text string) int {
+	n := 0
+	for _, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			n++
+		}
+	}
+	return n
+}
+
+// spam scores a member's message and reports whether, with their other
+// messages within the spam window, they are flooding. A plain message scores
+// 1; repeats, links and mass mentions score more. A message scored before,
+// e.g. when indexing is retried, isn't counted twice.
+func (f *IngestFilter) spam(guildID, userID, messageID int64, content string, at time.Time) bool {
+	if f.cfg.SpamThreshold <= 0 || f.cfg.SpamWindow <= 0 {
+		return false
+	}
+	text := strings.Join(strings.Fields(strings.ToLower(content)), " ")
+	key := spamKey{guildID: guildID, userID: userID}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if len(f.recent) >= spamSweepSize {
+		f.sweep(at)
+	}
+	var kept []scoredMessage
+	seen, repeat := false, false
+	total := 0.0
+	for _, m := range f.recent[key] {
+		if at.Sub(m.at) >= f.cfg.SpamWindow {
+			continue
+		}
+		kept = append(kept, m)
+		total += m.score
+		seen = seen || m.id == messageID
+		repeat = repeat || (text != "" && m.text == text)
+	}
+	if !seen {
+		score := 1.0
+		if repeat {
+			score += spamRepeatScore
+		}
+		score += spamLinkScore * float64(min(len(linkPattern.FindAllString(content, -1)), spamMaxLinks))
+		if len(mentionPattern.FindAllString(content, -1)) >= spamMaxMentions {
+			score += spamMentionScore
+		}
+		kept = append(kept, scoredMessage{id: messageID, text: text, score: score, at: at})
+		total += score
+	}
+	f.recent[key] = kept
+	return total >= f.cfg.SpamThreshold
+}
+
+// sweep forgets members with no message within the spam window
+func (f *IngestFilter) sweep(now time.Time) {
+	for key, messages := range f.recent {
+		if len(messages) == 0 || now.Sub(messages[len(messages)-1].at) >= f.cfg.SpamWindow {
+			delete(f.recent, key)
+		}
+	}
+}
+
+// markFiltered records a stored message the ingest filters left unembedded,
+// so index health doesn't count it as waiting
+func (s *Service) markFiltered(ctx context.Context, message *models.Message, reason string) {
+	if s.indexRepo == nil {
+		return
+	}
+	if err := s.indexRepo.MarkFiltered(ctx, &models.FilteredMessage{MessageID: message.ID, GuildID: message.GuildID, Reason: reason}); err != nil {
+		log.Printf("⚠️ %v", err)
+	}
+}
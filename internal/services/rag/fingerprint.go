@@ -0,0 +1,39 @@
+package rag
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strconv"
+)
+
+// Fingerprint identifies the set of context retrieved, regardless of order.
+// It changes when different documents or messages are retrieved, or when one
+// of them was edited, so answers cached against it go stale on their own.
+func (rc *RetrievedContext) Fingerprint() string {
+	if rc == nil {
+		return ""
+	}
+
+	items := make([]string, 0, len(rc.Priority)+len(rc.Documents)+len(rc.Summaries)+len(rc.Messages))
+	for _, r := range rc.Priority {
+		items = append(items, "p"+strconv.FormatInt(r.Document.ID, 10)+"\x00"+r.Document.Content)
+	}
+	for _, r := range rc.Documents {
+		items = append(items, "d"+strconv.FormatInt(r.Chunk.ID, 10)+"\x00"+r.Chunk.Content)
+	}
+	for _, r := range rc.Summaries {
+		items = append(items, "s"+strconv.FormatInt(r.Summary.ID, 10)+"\x00"+r.Summary.Content)
+	}
+	for _, r := range rc.Messages {
+		items = append(items, "m"+strconv.FormatInt(r.Message.ID, 10)+"\x00"+r.Message.Content)
+	}
+	sort.Strings(items)
+
+	h := sha256.New()
+	for _, item := range items {
+		h.Write([]byte(item))
+		h.Write([]byte{0xff})
+	}
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
@@ -0,0 +1,26 @@
+package rag
+
+import (
+	"github.com/bwmarrin/discordgo"
+
+	"discord-tars/internal/services/forums"
+)
+
+// embeddingText is what a message is embedded as. The first message of a
+// forum post is embedded with the post's title and tags, which often say
+// more about it than the message itself.
+func (s *Service) embeddingText(msg *discordgo.Message, channel *discordgo.Channel) string {
+	if channel == nil || !channel.IsThread() || msg.ID != channel.ID || channel.ParentID == "" {
+		return msg.Content
+	}
+	forum, err := s.session.State.Channel(channel.ParentID)
+	if err != nil {
+		if forum, err = s.session.Channel(channel.ParentID); err != nil {
+			return msg.Content
+		}
+	}
+	if forum.Type != discordgo.ChannelTypeGuildForum {
+		return msg.Content
+	}
+	return forums.PostText(channel.Name, forums.TagNames(channel, forum), msg.Content)
+}
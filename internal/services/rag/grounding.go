@@ -0,0 +1,161 @@
+package rag
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"discord-tars/internal/models"
+)
+
+const (
+	groundingMaxTokens     = 120
+	groundingMaxEvidence   = 6000 // Characters of retrieved context shown to the judge
+	groundingSnippetLength = 400
+)
+
+const groundingSystemPrompt = `You check whether an assistant's answer is supported by the context retrieved from a Discord server.
+
+Reply with JSON only, no prose: {"server_specific": true|false, "support": 0.0-1.0, "reason": "..."}
+
+- server_specific: true if the question is about this server, its people, projects, decisions, schedules or documents; false for general knowledge, small talk or opinions.
+- support: how well the SOURCES back the factual claims of the ANSWER. 1.0 = every claim is stated in the sources, 0.5 = partly, 0.0 = not at all or contradicted.
+- reason: one short sentence.`
+
+// Grounding estimates how well an answer is backed by retrieved context
+type Grounding struct {
+	ServerSpecific bool    `json:"server_specific"`
+	Support        float64 `json:"support"`
+	Reason         string  `json:"reason"`
+}
+
+// Confident reports whether an answer may be shown as is. General-knowledge
+// answers aren't expected to be grounded in server context.
+func (g *Grounding) Confident(threshold float64) bool {
+	return !g.ServerSpecific || g.Support >= threshold
+}
+
+// Source is one retrieved item, for showing users what was found
+type Source struct {
+	Kind       string // "pin", "official", "doc", "summary", "screenshot" or "message"
+	Label      string // Channel, author or page title
+	URL        string
+	Text       string
+	Similarity float64
+}
+
+// CheckGrounding asks the model to judge whether the answer is supported by the
+// retrieved context
+func (s *Service) CheckGrounding(ctx context.Context, question, answer string, rc *RetrievedContext) (*Grounding, error) {
+	var evidence strings.Builder
+	for _, src := range rc.Sources() {
+		line := fmt.Sprintf("- [%s] %s: %s\n", src.Kind, src.Label, src.Text)
+		if evidence.Len()+len(line) > groundingMaxEvidence {
+			break
+		}
+		evidence.WriteString(line)
+	}
+	if evidence.Len() == 0 {
+		evidence.WriteString("(nothing was found)\n")
+	}
+
+	prompt := fmt.Sprintf("QUESTION:\n%s\n\nSOURCES:\n%s\nANSWER:\n%s", question, evidence.String(), answer)
+	reply, err := s.aiService.Complete(ctx, groundingSystemPrompt, prompt, groundingMaxTokens)
+	if err != nil {
+		return nil, fmt.Errorf("grounding check failed: %w", err)
+	}
+
+	start, end := strings.Index(reply, "{"), strings.LastIndex(reply, "}")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("grounding check returned no JSON: %q", reply)
+	}
+	var g Grounding
+	if err := json.Unmarshal([]byte(reply[start:end+1]), &g); err != nil {
+		return nil, fmt.Errorf("failed to parse grounding verdict: %w", err)
+	}
+	if g.Support < 0 {
+		g.Support = 0
+	} else if g.Support > 1 {
+		g.Support = 1
+	}
+
+	log.Printf("🧭 Grounding: server_specific=%t support=%.2f (%s)", g.ServerSpecific, g.Support, g.Reason)
+	return &g, nil
+}
+
+// Sources flattens retrieved context into a single list, most relevant first.
+// Recent messages used as a fallback carry no similarity and come last.
+func (rc *RetrievedContext) Sources() []Source {
+	if rc == nil {
+		return nil
+	}
+
+	var sources []Source
+	for _, r := range rc.Priority {
+		kind := "pin"
+		if r.Document.Source == models.PrioritySourceChannel {
+			kind = "official"
+		}
+		sources = append(sources, Source{
+			Kind:       kind,
+			Label:      "#" + r.Document.ChannelName,
+			Text:       snippet(r.Document.Content),
+			Similarity: r.Similarity,
+		})
+	}
+	for _, r := range rc.Documents {
+		sources = append(sources, Source{
+			Kind:       "doc",
+			Label:      r.Title,
+			URL:        r.URL,
+			Text:       snippet(r.Chunk.Content),
+			Similarity: r.Similarity,
+		})
+	}
+	for _, r := range rc.Summaries {
+		sources = append(sources, Source{
+			Kind:       "summary",
+			Label:      summaryLabel(r.Summary),
+			Text:       snippet(r.Summary.Content),
+			Similarity: r.Similarity,
+		})
+	}
+	for _, r := range rc.Screenshots {
+		file := r.Attachment
+		sources = append(sources, Source{
+			Kind:       "screenshot",
+			Label:      fmt.Sprintf("%s's %s", r.Username, file.Filename),
+			URL:        fmt.Sprintf("https://discord.com/channels/%d/%d/%d", file.GuildID, file.ChannelID, file.MessageID),
+			Text:       snippet(file.Content),
+			Similarity: r.Similarity,
+		})
+	}
+	for _, r := range rc.Messages {
+		similarity := r.Similarity
+		if similarity >= 1 {
+			similarity = 0
+		}
+		sources = append(sources, Source{
+			Kind:       "message",
+			Label:      fmt.Sprintf("%s in #%s", r.User.Username, r.Channel.Name),
+			Text:       snippet(r.Message.Content),
+			Similarity: similarity,
+		})
+	}
+
+	sort.SliceStable(sources, func(i, j int) bool {
+		return sources[i].Similarity > sources[j].Similarity
+	})
+	return sources
+}
+
+func snippet(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if runes := []rune(text); len(runes) > groundingSnippetLength {
+		return string(runes[:groundingSnippetLength]) + "…"
+	}
+	return text
+}
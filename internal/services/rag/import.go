@@ -0,0 +1,109 @@
+package rag
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"discord-tars/internal/importer"
+	"discord-tars/internal/models"
+)
+
+// ImportProgress counts what imports did with the messages they read
+type ImportProgress struct {
+	Imported int // Messages stored
+	Embedded int // Of those, messages embedded
+	Existing int // Messages already stored, left alone
+	Skipped  int // Bot messages
+	Failed   int // Messages stored without an embedding after an error
+}
+
+// ImportMessages stores a channel's messages read from an export and embeds
+// them like new ones, adding to progress. Messages already stored are left
+// alone, so importing overlapping exports, or history the bot has seen, is
+// harmless. The ingest filters apply except the spam check, which needs
+// messages to arrive live; with embed false messages are only stored, and a
+// reindex of the channel embeds them later.
+func (s *Service) ImportMessages(ctx context.Context, channel importer.Channel, messages []importer.Message, embed bool, progress *ImportProgress) error {
+	ids := make([]int64, len(messages))
+	for n, m := range messages {
+		ids[n] = m.ID
+	}
+	stored, err := s.msgRepo.StoredMessageIDs(ctx, channel.GuildID, ids)
+	if err != nil {
+		return err
+	}
+
+	filter := s.ingestFilter != nil && !s.isPriorityChannel(ctx, channel.ID)
+	guild := &models.Guild{ID: channel.GuildID, Name: channel.GuildName}
+	consecutiveFailures := 0
+	for _, m := range messages {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if stored[m.ID] {
+			progress.Existing++
+			continue
+		}
+		if m.Author.Bot {
+			progress.Skipped++
+			continue
+		}
+
+		message := &models.Message{
+			ID:        m.ID,
+			ChannelID: channel.ID,
+			UserID:    m.Author.ID,
+			GuildID:   channel.GuildID,
+			Content:   m.Content,
+			Timestamp: m.Timestamp,
+		}
+		user := &models.User{ID: m.Author.ID, Username: m.Author.Username, Discriminator: m.Author.Discriminator, Avatar: m.Author.Avatar}
+		ch := &models.Channel{ID: channel.ID, GuildID: channel.GuildID, Name: channel.Name, Type: channel.Type}
+		if err := s.msgRepo.StoreMessage(ctx, message, user, ch, guild); err != nil {
+			return fmt.Errorf("failed to store message %d: %w", m.ID, err)
+		}
+		progress.Imported++
+		if !embed || strings.TrimSpace(m.Content) == "" {
+			continue
+		}
+
+		if filter {
+			reason := s.ingestFilter.lowValue(m.Content)
+			if reason == "" {
+				reason = s.worthless(ctx, m.Content)
+			}
+			if reason != "" {
+				s.ingestFilter.record(reason)
+				s.markFiltered(ctx, message, reason)
+				continue
+			}
+		}
+
+		embedding, err := s.aiService.GenerateEmbedding(ctx, m.Content)
+		if err == nil {
+			err = s.msgRepo.StoreEmbedding(ctx, m.ID, embedding, s.embeddingModel)
+		}
+		if err != nil {
+			if errors.Is(err, context.Canceled) {
+				return err
+			}
+			log.Printf("⚠️ Failed to embed imported message %d: %v", m.ID, err)
+			progress.Failed++
+			consecutiveFailures++
+			if consecutiveFailures >= maxReindexFailures {
+				return fmt.Errorf("stopped after %d failed embeddings in a row: %w", consecutiveFailures, err)
+			}
+			continue
+		}
+		consecutiveFailures = 0
+		progress.Embedded++
+		if err := s.indexCode(ctx, message); err != nil {
+			log.Printf("⚠️ %v", err)
+		}
+		s.storeLanguage(ctx, message)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package rag
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/services/jobs"
+	"discord-tars/internal/tenant"
+)
+
+// reindexJobTimeout bounds a reindex retried from /jobs; large channels take a while
+const reindexJobTimeout = 6 * time.Hour
+
+// ChannelJob is the payload of reindex and priority backfill jobs, which are
+// named after their index run kinds
+type ChannelJob struct {
+	GuildID   int64 `json:"guild_id"`
+	ChannelID int64 `json:"channel_id"`
+	StartedBy int64 `json:"started_by,omitempty"`
+}
+
+// RegisterJobs lets a runner run reindexes and priority backfills retried
+// from /jobs; they are started with the runner's Run from commands
+func (s *Service) RegisterJobs(runner *jobs.Runner) {
+	runner.Register(models.IndexRunReindex, jobs.Options{MaxAttempts: 1, Timeout: reindexJobTimeout}, func(ctx context.Context, payload []byte) error {
+		job, err := decodeChannelJob(payload)
+		if err != nil {
+			return err
+		}
+		_, err = s.ReindexChannel(tenant.WithGuild(ctx, job.GuildID), job.GuildID, job.ChannelID, job.StartedBy, nil)
+		return err
+	})
+	runner.Register(models.IndexRunPriorityBackfill, jobs.Options{MaxAttempts: 1, Timeout: 30 * time.Minute}, func(ctx context.Context, payload []byte) error {
+		job, err := decodeChannelJob(payload)
+		if err != nil {
+			return err
+		}
+		_, err = s.BackfillPriorityChannel(tenant.WithGuild(ctx, job.GuildID), job.GuildID, job.ChannelID)
+		return err
+	})
+}
+
+func decodeChannelJob(payload []byte) (*ChannelJob, error) {
+	var job ChannelJob
+	if err := json.Unmarshal(payload, &job); err != nil {
+		return nil, fmt.Errorf("failed to decode channel job: %w", err)
+	}
+	return &job, nil
+}
@@ -0,0 +1,234 @@
+package rag
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"discord-tars/internal/language"
+	"discord-tars/internal/models"
+	"discord-tars/internal/repository"
+)
+
+const (
+	languageCacheTTL = 10 * time.Minute
+	// A language is translated into when at least this share of a guild's
+	// messages are written in it
+	spokenMinShare = 0.1
+	// maxTranslations bounds the extra searches of a translated question
+	maxTranslations    = 3
+	translateMaxTokens = 400
+)
+
+const translateSystemPrompt = `You translate a Discord user's question for a search over the server's messages.
+Translate it into each requested language, keeping names, code, commands and product terms as they are.
+Reply with JSON only: {"translations": {"<language code>": "..."}}`
+
+// guildLanguageInfo is what retrieval needs to know about a guild's languages
+type guildLanguageInfo struct {
+	allowed   []string // Retrieval restriction; empty searches every language
+	spoken    []string // Languages common enough to translate questions into
+	fetchedAt time.Time
+}
+
+// languageCache remembers guilds' language settings so each question doesn't
+// load them
+type languageCache struct {
+	mu      sync.Mutex
+	entries map[int64]guildLanguageInfo
+}
+
+// SetLanguageRepository makes indexing record the language of each message,
+// and lets guilds restrict retrieval to some languages
+func (s *Service) SetLanguageRepository(languageRepo *repository.LanguageRepository) {
+	s.languageRepo = languageRepo
+}
+
+// SetQueryTranslation enables translating questions into the other languages
+// a guild's messages are written in, searching every translation
+func (s *Service) SetQueryTranslation(enabled bool) {
+	s.translateQueries = enabled
+}
+
+// LanguagesEnabled reports whether message languages are recorded
+func (s *Service) LanguagesEnabled() bool {
+	return s.languageRepo != nil
+}
+
+// storeLanguage records the language a message is written in, when it can tell
+func (s *Service) storeLanguage(ctx context.Context, message *models.Message) {
+	if s.languageRepo == nil || message.GuildID == 0 {
+		return
+	}
+	code := language.Detect(message.Content)
+	if code == "" {
+		return
+	}
+	if err := s.languageRepo.StoreMessageLanguage(ctx, &models.MessageLanguage{MessageID: message.ID, GuildID: message.GuildID, Language: code}); err != nil {
+		log.Printf("⚠️ %v", err)
+	}
+}
+
+// GuildLanguages returns the languages a guild's retrieval is restricted to,
+// empty when it isn't, and how many of its messages are in each language
+func (s *Service) GuildLanguages(ctx context.Context, guildID int64) ([]string, []models.LanguageShare, error) {
+	if s.languageRepo == nil {
+		return nil, nil, errors.New("message languages are not enabled")
+	}
+	setting, err := s.languageRepo.GetGuildLanguages(ctx, guildID)
+	if err != nil {
+		return nil, nil, err
+	}
+	shares, err := s.languageRepo.Shares(ctx, guildID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if setting == nil {
+		return nil, shares, nil
+	}
+	return setting.Languages, shares, nil
+}
+
+// SetGuildLanguages restricts a guild's retrieval to messages in the given
+// languages; no languages lifts the restriction
+func (s *Service) SetGuildLanguages(ctx context.Context, guildID int64, codes []string, updatedBy int64) error {
+	if s.languageRepo == nil {
+		return errors.New("message languages are not enabled")
+	}
+	var err error
+	if len(codes) == 0 {
+		err = s.languageRepo.DeleteGuildLanguages(ctx, guildID)
+	} else {
+		err = s.languageRepo.SaveGuildLanguages(ctx, &models.GuildLanguages{GuildID: guildID, Languages: codes, UpdatedBy: updatedBy})
+	}
+	if err != nil {
+		return err
+	}
+
+	s.languages.mu.Lock()
+	delete(s.languages.entries, guildID)
+	s.languages.mu.Unlock()
+	return nil
+}
+
+// guildLanguages loads a guild's language settings through the cache. Errors
+// leave retrieval unrestricted rather than failing the question.
+func (s *Service) guildLanguages(ctx context.Context, guildID int64) guildLanguageInfo {
+	if s.languageRepo == nil || guildID == 0 {
+		return guildLanguageInfo{}
+	}
+
+	s.languages.mu.Lock()
+	if cached, ok := s.languages.entries[guildID]; ok && time.Since(cached.fetchedAt) < languageCacheTTL {
+		s.languages.mu.Unlock()
+		return cached
+	}
+	s.languages.mu.Unlock()
+
+	info := guildLanguageInfo{fetchedAt: time.Now()}
+	setting, err := s.languageRepo.GetGuildLanguages(ctx, guildID)
+	if err != nil {
+		log.Printf("⚠️ Failed to load languages of guild %d, searching all of them: %v", guildID, err)
+		return guildLanguageInfo{}
+	}
+	if setting != nil {
+		info.allowed = setting.Languages
+	}
+	if s.translateQueries {
+		info.spoken = s.spokenLanguages(ctx, guildID)
+	}
+
+	s.languages.mu.Lock()
+	defer s.languages.mu.Unlock()
+	if s.languages.entries == nil {
+		s.languages.entries = make(map[int64]guildLanguageInfo)
+	}
+	s.languages.entries[guildID] = info
+	return info
+}
+
+// spokenLanguages lists the languages at least spokenMinShare of a guild's
+// messages are written in, most used first
+func (s *Service) spokenLanguages(ctx context.Context, guildID int64) []string {
+	shares, err := s.languageRepo.Shares(ctx, guildID)
+	if err != nil {
+		log.Printf("⚠️ %v", err)
+		return nil
+	}
+	var total int64
+	for _, share := range shares {
+		total += share.Messages
+	}
+	var spoken []string
+	for _, share := range shares {
+		if float64(share.Messages) >= spokenMinShare*float64(total) {
+			spoken = append(spoken, share.Language)
+		}
+	}
+	return spoken
+}
+
+// translateQuery translates a question into the languages a guild searches
+// that it isn't written in: the allowed ones when retrieval is restricted,
+// otherwise the ones its members mostly write in. Questions too short to
+// tell their language are searched as asked.
+func (s *Service) translateQuery(ctx context.Context, guildID int64, question string) []string {
+	if !s.translateQueries {
+		return nil
+	}
+	asked := language.Detect(question)
+	if asked == "" {
+		return nil
+	}
+	info := s.guildLanguages(ctx, guildID)
+	targets := info.allowed
+	if len(targets) == 0 {
+		targets = info.spoken
+	}
+	var wanted []string
+	for _, code := range targets {
+		if code != asked && len(wanted) < maxTranslations {
+			wanted = append(wanted, code)
+		}
+	}
+	if len(wanted) == 0 {
+		return nil
+	}
+
+	names := make([]string, len(wanted))
+	for n, code := range wanted {
+		names[n] = fmt.Sprintf("%s (%s)", code, language.Name(code))
+	}
+	prompt := fmt.Sprintf("LANGUAGES: %s\n\nQUESTION:\n%s", strings.Join(names, ", "), question)
+	reply, err := s.aiService.Complete(ctx, translateSystemPrompt, prompt, translateMaxTokens)
+	if err != nil {
+		log.Printf("⚠️ Query translation failed, searching with the question as asked: %v", err)
+		return nil
+	}
+	start, end := strings.Index(reply, "{"), strings.LastIndex(reply, "}")
+	if start < 0 || end < start {
+		log.Printf("⚠️ Query translation returned no JSON: %q", reply)
+		return nil
+	}
+	var out struct {
+		Translations map[string]string `json:"translations"`
+	}
+	if err := json.Unmarshal([]byte(reply[start:end+1]), &out); err != nil {
+		log.Printf("⚠️ Query translation was not valid JSON: %v", err)
+		return nil
+	}
+
+	var translations []string
+	for _, code := range wanted {
+		if text := strings.TrimSpace(out.Translations[code]); text != "" && !strings.EqualFold(text, strings.TrimSpace(question)) {
+			translations = append(translations, text)
+		}
+	}
+	log.Printf("🌐 Translated query from %s into: %q", asked, translations)
+	return translations
+}
@@ -0,0 +1,228 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+
+	"discord-tars/internal/models"
+)
+
+const (
+	priorityMaxResults    = 3
+	priorityMinSimilarity = 0.35
+	// maxChannelBackfill bounds how many messages are ingested when a channel is designated
+	maxChannelBackfill = 500
+)
+
+// SearchPriority finds the priority documents of a guild relevant to a query
+func (s *Service) SearchPriority(ctx context.Context, guildID int64, query string, maxResults int) ([]models.PriorityResult, error) {
+	queryEmbedding, err := s.aiService.GenerateEmbedding(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate query embedding: %w", err)
+	}
+	return s.priorityRepo.Search(ctx, guildID, queryEmbedding, maxResults, priorityMinSimilarity)
+}
+
+// AddPriorityChannel designates a rules/announcements channel; new messages
+// are ingested from then on, and BackfillPriorityChannel ingests its history
+func (s *Service) AddPriorityChannel(ctx context.Context, guildID, channelID int64, label string) error {
+	if err := s.priorityRepo.AddChannel(ctx, &models.PriorityChannel{GuildID: guildID, ChannelID: channelID, Label: label}); err != nil {
+		return err
+	}
+	s.invalidatePriorityChannels()
+	return nil
+}
+
+// BackfillPriorityChannel ingests the recent history of a priority channel
+func (s *Service) BackfillPriorityChannel(ctx context.Context, guildID, channelID int64) (int, error) {
+	channelIDStr := strconv.FormatInt(channelID, 10)
+	channelName := s.channelName(channelIDStr)
+
+	ingested := 0
+	finish := s.startRun(ctx, &models.IndexRun{GuildID: guildID, ChannelID: channelID, Kind: models.IndexRunPriorityBackfill})
+	before := ""
+	for ingested < maxChannelBackfill {
+		messages, err := s.session.ChannelMessages(channelIDStr, 100, before, "", "")
+		if err != nil {
+			err = fmt.Errorf("failed to fetch channel history: %w", err)
+			finish(ingested, err)
+			return ingested, err
+		}
+		if len(messages) == 0 {
+			break
+		}
+		for _, msg := range messages {
+			if err := s.ingestPriorityMessage(ctx, msg, channelName, models.PrioritySourceChannel); err != nil {
+				log.Printf("⚠️ Failed to ingest priority message %s: %v", msg.ID, err)
+				continue
+			}
+			ingested++
+		}
+		before = messages[len(messages)-1].ID
+	}
+
+	finish(ingested, nil)
+	log.Printf("📜 Ingested %d messages from priority channel %d", ingested, channelID)
+	return ingested, nil
+}
+
+// RemovePriorityChannel stops treating a channel as a priority source
+func (s *Service) RemovePriorityChannel(ctx context.Context, channelID int64) (bool, error) {
+	removed, err := s.priorityRepo.RemoveChannel(ctx, channelID)
+	s.invalidatePriorityChannels()
+	return removed, err
+}
+
+// ListPriorityChannels returns a guild's priority channels
+func (s *Service) ListPriorityChannels(ctx context.Context, guildID int64) ([]models.PriorityChannel, error) {
+	return s.priorityRepo.ListChannels(ctx, guildID)
+}
+
+// CountPriorityDocuments returns the size of a guild's priority collection
+func (s *Service) CountPriorityDocuments(ctx context.Context, guildID int64) (int64, error) {
+	return s.priorityRepo.CountDocuments(ctx, guildID)
+}
+
+// SyncPins re-indexes the pinned messages of a channel, dropping unpinned ones
+func (s *Service) SyncPins(ctx context.Context, channelID string) (int, error) {
+	pins, err := s.session.ChannelMessagesPinned(channelID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch pinned messages: %w", err)
+	}
+
+	channelName := s.channelName(channelID)
+	pinnedIDs := make([]int64, 0, len(pins))
+	for _, msg := range pins {
+		if err := s.ingestPriorityMessage(ctx, msg, channelName, models.PrioritySourcePin); err != nil {
+			log.Printf("⚠️ Failed to ingest pinned message %s: %v", msg.ID, err)
+			continue
+		}
+		id, _ := strconv.ParseInt(msg.ID, 10, 64)
+		pinnedIDs = append(pinnedIDs, id)
+	}
+
+	cid, _ := strconv.ParseInt(channelID, 10, 64)
+	if err := s.priorityRepo.PruneUnpinned(ctx, cid, pinnedIDs); err != nil {
+		return len(pinnedIDs), err
+	}
+
+	log.Printf("📌 Synced %d pinned messages in channel %s", len(pinnedIDs), channelID)
+	return len(pinnedIDs), nil
+}
+
+// SyncGuildPins re-indexes the pins of every text channel in a guild
+func (s *Service) SyncGuildPins(ctx context.Context, guildID string) (int, error) {
+	channels, err := s.session.GuildChannels(guildID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list guild channels: %w", err)
+	}
+
+	total := 0
+	for _, ch := range channels {
+		if ch.Type != discordgo.ChannelTypeGuildText && ch.Type != discordgo.ChannelTypeGuildNews {
+			continue
+		}
+		n, err := s.SyncPins(ctx, ch.ID)
+		if err != nil {
+			log.Printf("⚠️ Failed to sync pins of channel %s: %v", ch.ID, err)
+			continue
+		}
+		total += n
+	}
+	return total, nil
+}
+
+func (s *Service) ingestPriorityMessage(ctx context.Context, msg *discordgo.Message, channelName, source string) error {
+	if strings.TrimSpace(msg.Content) == "" {
+		return nil
+	}
+
+	embedding, err := s.aiService.GenerateEmbedding(ctx, msg.Content)
+	if err != nil {
+		return fmt.Errorf("failed to generate embedding: %w", err)
+	}
+	return s.storePriorityDocument(ctx, msg, channelName, source, embedding)
+}
+
+func (s *Service) storePriorityDocument(ctx context.Context, msg *discordgo.Message, channelName, source string, embedding []float32) error {
+	messageID, _ := strconv.ParseInt(msg.ID, 10, 64)
+	channelID, _ := strconv.ParseInt(msg.ChannelID, 10, 64)
+	guildID, _ := strconv.ParseInt(msg.GuildID, 10, 64)
+	if guildID == 0 {
+		if ch, err := s.session.State.Channel(msg.ChannelID); err == nil {
+			guildID, _ = strconv.ParseInt(ch.GuildID, 10, 64)
+		}
+	}
+
+	author := "unknown"
+	if msg.Author != nil {
+		author = msg.Author.Username
+	}
+
+	doc := &models.PriorityDocument{
+		GuildID:     guildID,
+		ChannelID:   channelID,
+		MessageID:   messageID,
+		ChannelName: channelName,
+		AuthorName:  author,
+		Source:      source,
+		Content:     msg.Content,
+	}
+	if err := s.priorityRepo.UpsertDocument(ctx, doc, embedding); err != nil {
+		log.Printf("❌ Failed to store priority document for message %s: %v", msg.ID, err)
+		return err
+	}
+	return nil
+}
+
+func (s *Service) isPriorityChannel(ctx context.Context, channelID int64) bool {
+	if s.priorityRepo == nil {
+		return false
+	}
+
+	s.priorityMu.RLock()
+	cached := s.priorityChannels
+	s.priorityMu.RUnlock()
+
+	if cached == nil {
+		channels, err := s.priorityRepo.ListChannels(ctx, 0)
+		if err != nil {
+			log.Printf("⚠️ Failed to load priority channels: %v", err)
+			return false
+		}
+		cached = make(map[int64]bool, len(channels))
+		for _, ch := range channels {
+			cached[ch.ChannelID] = true
+		}
+
+		s.priorityMu.Lock()
+		s.priorityChannels = cached
+		s.priorityMu.Unlock()
+	}
+
+	return cached[channelID]
+}
+
+func (s *Service) invalidatePriorityChannels() {
+	s.priorityMu.Lock()
+	s.priorityChannels = nil
+	s.priorityMu.Unlock()
+}
+
+func (s *Service) channelName(channelID string) string {
+	if s.session == nil {
+		return "unknown"
+	}
+	if ch, err := s.session.State.Channel(channelID); err == nil {
+		return ch.Name
+	}
+	if ch, err := s.session.Channel(channelID); err == nil {
+		return ch.Name
+	}
+	return "unknown"
+}
@@ -0,0 +1,82 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/bwmarrin/discordgo"
+
+	"discord-tars/internal/models"
+)
+
+// Orders for Search
+const (
+	SortRelevance = "relevance"
+	SortEndorsed  = "endorsed"
+)
+
+const (
+	// searchCandidates is how many of a guild's closest messages Search ranks
+	searchCandidates    = 50
+	searchMinSimilarity = 0.35
+)
+
+// SearchHit is a message found by Search with its total reactions
+type SearchHit struct {
+	models.SearchResult
+	Reactions int
+}
+
+// RecordReaction counts a reaction added to (delta 1) or removed from (delta
+// -1) an indexed message
+func (s *Service) RecordReaction(ctx context.Context, messageID string, emoji discordgo.Emoji, delta int) error {
+	id, err := strconv.ParseInt(messageID, 10, 64)
+	if err != nil {
+		return fmt.Errorf("failed to parse message ID: %w", err)
+	}
+	return s.msgRepo.AdjustReaction(ctx, id, emoji.APIName(), delta)
+}
+
+// ClearReactions forgets all of a message's reactions, e.g. when a moderator
+// removes them
+func (s *Service) ClearReactions(ctx context.Context, messageID string) error {
+	id, err := strconv.ParseInt(messageID, 10, 64)
+	if err != nil {
+		return fmt.Errorf("failed to parse message ID: %w", err)
+	}
+	return s.msgRepo.ClearReactions(ctx, id, "")
+}
+
+// Search finds a guild's messages about a query, ordered by relevance (which
+// favors well-received messages) or by reactions first
+func (s *Service) Search(ctx context.Context, guildID int64, query, order string) ([]SearchHit, error) {
+	queryEmbedding, err := s.aiService.GenerateEmbedding(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate query embedding: %w", err)
+	}
+	results, err := s.msgRepo.SearchGuildMessages(ctx, guildID, queryEmbedding, searchCandidates, searchMinSimilarity)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int64, len(results))
+	for n, result := range results {
+		ids[n] = result.Message.ID
+	}
+	totals, err := s.msgRepo.ReactionTotals(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	hits := make([]SearchHit, len(results))
+	for n, result := range results {
+		hits[n] = SearchHit{SearchResult: result, Reactions: totals[result.Message.ID]}
+	}
+	if order == SortEndorsed {
+		// Equally endorsed messages stay in relevance order
+		sort.SliceStable(hits, func(a, b int) bool { return hits[a].Reactions > hits[b].Reactions })
+	}
+	return hits, nil
+}
@@ -0,0 +1,118 @@
+package rag
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"discord-tars/internal/models"
+)
+
+const (
+	reindexPageSize = 100
+	// maxReindexFailures aborts a reindex when embeddings keep failing, e.g. the API is down
+	maxReindexFailures = 10
+)
+
+// ReindexProgress reports how far a reindex has got
+type ReindexProgress struct {
+	Processed int // Messages re-embedded so far
+	Failed    int // Messages skipped after an embedding error
+	Total     int
+}
+
+// SetEmbeddingModel sets the model name recorded with new embeddings
+func (s *Service) SetEmbeddingModel(model string) {
+	if model != "" {
+		s.embeddingModel = model
+	}
+}
+
+// ReindexChannel re-embeds every stored message of a channel with the current
+// embedding model, except those the ingest filters skip. progress is called
+// after each page; cancelling ctx stops the run after the current message.
+func (s *Service) ReindexChannel(ctx context.Context, guildID, channelID, startedBy int64, progress func(ReindexProgress)) (ReindexProgress, error) {
+	total, err := s.msgRepo.CountChannelMessages(ctx, channelID)
+	if err != nil {
+		return ReindexProgress{}, err
+	}
+
+	state := ReindexProgress{Total: int(total)}
+	run := &models.IndexRun{GuildID: guildID, ChannelID: channelID, Kind: models.IndexRunReindex, Total: state.Total, StartedBy: startedBy}
+	finish := s.startRun(ctx, run)
+
+	err = s.reindex(ctx, channelID, &state, func() {
+		if run.ID != 0 {
+			if err := s.indexRepo.UpdateProgress(ctx, run.ID, state.Processed, state.Total); err != nil {
+				log.Printf("⚠️ %v", err)
+			}
+		}
+		if progress != nil {
+			progress(state)
+		}
+	})
+	finish(state.Processed, err)
+
+	log.Printf("🔁 Reindexed %d/%d messages in channel %d (%d failed)", state.Processed, state.Total, channelID, state.Failed)
+	return state, err
+}
+
+func (s *Service) reindex(ctx context.Context, channelID int64, state *ReindexProgress, report func()) error {
+	var afterID int64
+	consecutiveFailures := 0
+	filter := s.ingestFilter != nil && !s.isPriorityChannel(ctx, channelID)
+	for {
+		messages, err := s.msgRepo.ListChannelMessages(ctx, channelID, afterID, reindexPageSize)
+		if err != nil {
+			return err
+		}
+		if len(messages) == 0 {
+			return nil
+		}
+
+		for _, msg := range messages {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			afterID = msg.ID
+			if strings.TrimSpace(msg.Content) == "" {
+				continue
+			}
+			if filter {
+				reason := s.ingestFilter.lowValue(msg.Content)
+				if reason == "" {
+					reason = s.worthless(ctx, msg.Content)
+				}
+				if reason != "" {
+					s.markFiltered(ctx, &msg, reason)
+					continue
+				}
+			}
+
+			embedding, err := s.aiService.GenerateEmbedding(ctx, msg.Content)
+			if err == nil {
+				err = s.msgRepo.StoreEmbedding(ctx, msg.ID, embedding, s.embeddingModel)
+			}
+			if err != nil {
+				if errors.Is(err, context.Canceled) {
+					return err
+				}
+				state.Failed++
+				consecutiveFailures++
+				if consecutiveFailures >= maxReindexFailures {
+					return fmt.Errorf("stopped after %d failed embeddings in a row: %w", consecutiveFailures, err)
+				}
+				continue
+			}
+			consecutiveFailures = 0
+			state.Processed++
+			if err := s.indexCode(ctx, &msg); err != nil {
+				log.Printf("⚠️ %v", err)
+			}
+			s.storeLanguage(ctx, &msg)
+		}
+		report()
+	}
+}
@@ -0,0 +1,244 @@
+package rag
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	maxQueryVariants    = 3
+	rewriteMaxTokens    = 200
+	rewriteRecentChat   = 8   // Recent channel messages shown to the rewriter
+	rewriteMaxLineChars = 300 // Per history line
+)
+
+const rewriteSystemPrompt = `You turn a Discord user's question into search queries for a vector search over the server's messages and documents.
+Use the conversation to resolve pronouns and vague references ("it", "that thing Bob mentioned") into the concrete names, topics and people meant.
+Write 1 to 3 short, self-contained phrasings that approach the question from different angles (synonyms, likely wording in chat, the underlying topic).
+Reply with JSON only: {"queries": ["...", "..."]}`
+
+// SetQueryRewriting enables rewriting questions into several search phrasings in RetrieveConversational
+func (s *Service) SetQueryRewriting(enabled bool) {
+	s.rewriteQueries = enabled
+}
+
+// RetrieveConversational is Retrieve for questions asked mid-conversation. The
+// question is rewritten using the earlier turns and recent channel chatter into
+// several phrasings, whose results are merged.
+func (s *Service) RetrieveConversational(ctx context.Context, question string, turns []string, guildID, channelID int64, maxResults int) (*RetrievedContext, error) {
+	if !s.rewriteQueries {
+		return s.Retrieve(ctx, question, guildID, channelID, maxResults)
+	}
+
+	queries := s.RewriteQuery(ctx, question, s.conversationLines(ctx, channelID, turns))
+	queries = append(queries, s.translateQuery(ctx, guildID, question)...)
+	recap := parseRecap(question, time.Now())
+	log.Printf("🔍 Retrieving context for %d query phrasings", len(queries))
+
+	var merged *RetrievedContext
+	for _, query := range queries {
+		queryEmbedding, err := s.aiService.GenerateEmbedding(ctx, query)
+		if err != nil {
+			log.Printf("❌ Failed to generate query embedding: %v", err)
+			return nil, fmt.Errorf("failed to generate query embedding: %w", err)
+		}
+		rc, err := s.searchAll(ctx, queryEmbedding, guildID, maxResults, recap)
+		if err != nil {
+			return nil, err
+		}
+		if merged == nil {
+			// The question itself is searched first
+			rc.QueryEmbedding = queryEmbedding
+		}
+		merged = mergeContexts(merged, rc, maxResults)
+	}
+	preferSummaries(merged, recap)
+	return merged, s.fallbackToRecent(ctx, merged, channelID, maxResults)
+}
+
+// RewriteQuery returns search phrasings for a question, starting with the
+// question itself so a bad rewrite can't lose the original wording
+func (s *Service) RewriteQuery(ctx context.Context, question string, conversation []string) []string {
+	queries := []string{question}
+
+	var prompt strings.Builder
+	if len(conversation) > 0 {
+		prompt.WriteString("CONVERSATION (oldest first):\n")
+		for _, line := range conversation {
+			prompt.WriteString(line + "\n")
+		}
+		prompt.WriteString("\n")
+	}
+	prompt.WriteString("QUESTION:\n" + question)
+
+	reply, err := s.aiService.Complete(ctx, rewriteSystemPrompt, prompt.String(), rewriteMaxTokens)
+	if err != nil {
+		log.Printf("⚠️ Query rewrite failed, searching with the question as asked: %v", err)
+		return queries
+	}
+	start, end := strings.Index(reply, "{"), strings.LastIndex(reply, "}")
+	if start < 0 || end < start {
+		log.Printf("⚠️ Query rewrite returned no JSON: %q", reply)
+		return queries
+	}
+	var out struct {
+		Queries []string `json:"queries"`
+	}
+	if err := json.Unmarshal([]byte(reply[start:end+1]), &out); err != nil {
+		log.Printf("⚠️ Query rewrite was not valid JSON: %v", err)
+		return queries
+	}
+
+	seen := map[string]bool{strings.ToLower(strings.TrimSpace(question)): true}
+	for _, q := range out.Queries {
+		key := strings.ToLower(strings.TrimSpace(q))
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		queries = append(queries, strings.TrimSpace(q))
+		if len(queries) > maxQueryVariants {
+			break
+		}
+	}
+	log.Printf("✏️ Rewrote query into: %q", queries[1:])
+	return queries
+}
+
+// conversationLines combines recent channel messages with earlier Q&A turns
+func (s *Service) conversationLines(ctx context.Context, channelID int64, turns []string) []string {
+	var lines []string
+	if channelID != 0 && canSee(ctx, channelID) {
+		recent, err := s.msgRepo.GetRecentMessages(ctx, channelID, rewriteRecentChat)
+		if err != nil {
+			log.Printf("⚠️ Failed to load recent messages for query rewrite: %v", err)
+		}
+		// Recent messages come newest first
+		for i := len(recent) - 1; i >= 0; i-- {
+			lines = append(lines, clip(fmt.Sprintf("%s: %s", recent[i].User.Username, recent[i].Message.Content)))
+		}
+	}
+	for _, turn := range turns {
+		lines = append(lines, clip(turn))
+	}
+	return lines
+}
+
+func clip(line string) string {
+	line = strings.Join(strings.Fields(line), " ")
+	if runes := []rune(line); len(runes) > rewriteMaxLineChars {
+		return string(runes[:rewriteMaxLineChars]) + "…"
+	}
+	return line
+}
+
+// mergeContexts adds b's results to a, keeping each item once with its best
+// similarity, and trims every collection back to its limit
+func mergeContexts(a, b *RetrievedContext, maxResults int) *RetrievedContext {
+	if a == nil {
+		return b
+	}
+
+	for _, r := range b.Priority {
+		found := false
+		for i := range a.Priority {
+			if a.Priority[i].Document.ID == r.Document.ID {
+				a.Priority[i].Similarity = max(a.Priority[i].Similarity, r.Similarity)
+				found = true
+				break
+			}
+		}
+		if !found {
+			a.Priority = append(a.Priority, r)
+		}
+	}
+	for _, r := range b.Documents {
+		found := false
+		for i := range a.Documents {
+			if a.Documents[i].Chunk.ID == r.Chunk.ID {
+				a.Documents[i].Similarity = max(a.Documents[i].Similarity, r.Similarity)
+				found = true
+				break
+			}
+		}
+		if !found {
+			a.Documents = append(a.Documents, r)
+		}
+	}
+	for _, r := range b.Summaries {
+		found := false
+		for i := range a.Summaries {
+			if a.Summaries[i].Summary.ID == r.Summary.ID {
+				a.Summaries[i].Similarity = max(a.Summaries[i].Similarity, r.Similarity)
+				found = true
+				break
+			}
+		}
+		if !found {
+			a.Summaries = append(a.Summaries, r)
+		}
+	}
+	for _, r := range b.Screenshots {
+		found := false
+		for i := range a.Screenshots {
+			if a.Screenshots[i].Attachment.ID == r.Attachment.ID {
+				a.Screenshots[i].Similarity = max(a.Screenshots[i].Similarity, r.Similarity)
+				found = true
+				break
+			}
+		}
+		if !found {
+			a.Screenshots = append(a.Screenshots, r)
+		}
+	}
+	for _, r := range b.Messages {
+		found := false
+		for i := range a.Messages {
+			// Each search already drops near-duplicates; this catches reposts found by different phrasings
+			if a.Messages[i].Message.ID == r.Message.ID || sameText(a.Messages[i].Message.Content, r.Message.Content) {
+				a.Messages[i].Similarity = max(a.Messages[i].Similarity, r.Similarity)
+				found = true
+				break
+			}
+		}
+		if !found {
+			a.Messages = append(a.Messages, r)
+		}
+	}
+
+	sort.SliceStable(a.Priority, func(i, j int) bool { return a.Priority[i].Similarity > a.Priority[j].Similarity })
+	sort.SliceStable(a.Documents, func(i, j int) bool { return a.Documents[i].Similarity > a.Documents[j].Similarity })
+	sort.SliceStable(a.Summaries, func(i, j int) bool { return a.Summaries[i].Similarity > a.Summaries[j].Similarity })
+	sort.SliceStable(a.Messages, func(i, j int) bool { return a.Messages[i].Similarity > a.Messages[j].Similarity })
+	if len(a.Priority) > priorityMaxResults {
+		a.Priority = a.Priority[:priorityMaxResults]
+	}
+	if len(a.Documents) > knowledgeMaxResults {
+		a.Documents = a.Documents[:knowledgeMaxResults]
+	}
+	if len(a.Summaries) > recapMaxSummaries {
+		a.Summaries = a.Summaries[:recapMaxSummaries]
+	}
+	if len(a.Messages) > maxResults {
+		a.Messages = a.Messages[:maxResults]
+	}
+	return a
+}
+
+// sameText reports whether two messages say the same thing, ignoring case,
+// spacing and punctuation
+func sameText(a, b string) bool {
+	normalize := func(s string) string {
+		return strings.Join(strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+		}), " ")
+	}
+	na := normalize(a)
+	return na != "" && na == normalize(b)
+}
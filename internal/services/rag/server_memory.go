@@ -0,0 +1,246 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"discord-tars/internal/models"
+)
+
+const (
+	// topicSampleSize is how many of a guild's closest messages are counted
+	// towards a topic's statistics
+	topicSampleSize    = 300
+	topicMinSimilarity = 0.3
+	maxTopicExperts    = 5
+	maxTopicChannels   = 5
+	// Policy questions see more official documents than ordinary ones
+	policyMaxDocuments  = 8
+	policyMinSimilarity = 0.2
+)
+
+var (
+	// expertQuestion matches "who is the expert on X" and similar; what follows is the topic
+	expertQuestion = regexp.MustCompile(`(?i)\b(who(?:'s| is| are)(?: the| our)? (?:experts?|go-to(?: person)?|specialists?)(?: (?:on|for|in|at|about|with))?|` +
+		`who knows(?: the most)?(?: about)?|who (?:can|could) help(?: me)?(?: with)?|who talks(?: the most)? about|` +
+		`qui (?:s'y connaît|est l'expert|connaît)(?: en| le mieux| bien)?|quién sabe(?: más)?(?: de| sobre)?|wer kennt sich(?: mit)?(?: am besten)?(?: aus)?)\s*`)
+	// policyQuestion matches questions about the server's rules; permission
+	// words alone ("is recursion allowed in Go?") also need serverWords
+	policyQuestion  = regexp.MustCompile(`(?i)\b(rules?|polic(?:y|ies)|guidelines?|règles?|reglas?|normas?|regeln?)\b`)
+	permissionWords = regexp.MustCompile(`(?i)\b(allowed|permitted|forbidden|banned|autorisée?s?|interdite?s?|permitido|prohibido|erlaubt|verboten)\b`)
+	serverWords     = regexp.MustCompile(`(?i)\b(here|server|channels?|serveur|servidor|canal|kanal|hier|ici|aquí)\b`)
+	// topicFiller is trailing wording that isn't part of an expert question's topic
+	topicFiller = regexp.MustCompile(`(?i)(\s+(here|around here|in this server|on this server|on the server|ici|aquí|hier|aus))*[\s?!.]*$`)
+)
+
+// TopicExpert is a member ranked by how much they discussed a topic
+type TopicExpert struct {
+	UserID   int64
+	Username string
+	Score    float64 // Similarity-weighted count of their related messages
+	Messages int
+	Channels []string              // Where they discussed it, most first
+	Examples []models.SearchResult // Their closest messages, best first
+}
+
+// TopicChannel is how much a topic was discussed in a channel
+type TopicChannel struct {
+	ChannelID int64
+	Name      string
+	Messages  int
+}
+
+// TopicStats aggregate who discussed a topic across a guild's channels, and where
+type TopicStats struct {
+	Messages int
+	Experts  []TopicExpert
+	Channels []TopicChannel
+}
+
+// TopicStats ranks the members of a guild who discussed a topic most, keeping
+// up to maxExamples of each one's closest messages
+func (s *Service) TopicStats(ctx context.Context, guildID int64, topic string, maxExamples int) (*TopicStats, error) {
+	queryEmbedding, err := s.aiService.GenerateEmbedding(ctx, topic)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate topic embedding: %w", err)
+	}
+	return s.topicStats(ctx, guildID, queryEmbedding, maxExamples)
+}
+
+func (s *Service) topicStats(ctx context.Context, guildID int64, queryEmbedding []float32, maxExamples int) (*TopicStats, error) {
+	messages, err := s.msgRepo.SearchGuildMessages(ctx, guildID, queryEmbedding, topicSampleSize, topicMinSimilarity)
+	if err != nil {
+		return nil, err
+	}
+	messages = visibleMessages(ctx, messages)
+
+	botID := s.botUserID()
+	stats := &TopicStats{}
+	experts := make(map[int64]*TopicExpert)
+	channels := make(map[int64]*TopicChannel)
+	expertChannels := make(map[int64]map[string]int)
+	for _, m := range messages {
+		if m.Message.UserID == botID {
+			continue
+		}
+		stats.Messages++
+
+		e, ok := experts[m.Message.UserID]
+		if !ok {
+			e = &TopicExpert{UserID: m.Message.UserID, Username: m.User.Username}
+			experts[m.Message.UserID] = e
+			expertChannels[m.Message.UserID] = make(map[string]int)
+		}
+		e.Score += m.Similarity
+		e.Messages++
+		if len(e.Examples) < maxExamples {
+			e.Examples = append(e.Examples, m) // Messages come best first
+		}
+		expertChannels[m.Message.UserID][m.Channel.Name]++
+
+		c, ok := channels[m.Message.ChannelID]
+		if !ok {
+			c = &TopicChannel{ChannelID: m.Message.ChannelID, Name: m.Channel.Name}
+			channels[m.Message.ChannelID] = c
+		}
+		c.Messages++
+	}
+
+	for userID, e := range experts {
+		e.Channels = rankedKeys(expertChannels[userID])
+		stats.Experts = append(stats.Experts, *e)
+	}
+	sort.Slice(stats.Experts, func(a, b int) bool {
+		if stats.Experts[a].Score != stats.Experts[b].Score {
+			return stats.Experts[a].Score > stats.Experts[b].Score
+		}
+		return stats.Experts[a].Username < stats.Experts[b].Username
+	})
+	for _, c := range channels {
+		stats.Channels = append(stats.Channels, *c)
+	}
+	sort.Slice(stats.Channels, func(a, b int) bool {
+		if stats.Channels[a].Messages != stats.Channels[b].Messages {
+			return stats.Channels[a].Messages > stats.Channels[b].Messages
+		}
+		return stats.Channels[a].Name < stats.Channels[b].Name
+	})
+	return stats, nil
+}
+
+// ServerMemory answers the groundwork of meta questions about the server
+// itself, such as "who is the expert on Kubernetes here?" or "what are the
+// rules on self-promo?". Those need the whole server rather than the few
+// closest messages, so it returns statistics gathered across every channel, or
+// official documents, to put before the question; "" for other questions.
+func (s *Service) ServerMemory(ctx context.Context, question string, guildID int64) (string, error) {
+	if guildID == 0 {
+		return "", nil
+	}
+
+	if loc := expertQuestion.FindStringIndex(question); loc != nil {
+		topic := strings.TrimSpace(topicFiller.ReplaceAllString(question[loc[1]:], ""))
+		if topic == "" {
+			topic = question
+		}
+		stats, err := s.TopicStats(ctx, guildID, topic, 1)
+		if err != nil {
+			return "", err
+		}
+		return expertMemory(topic, stats), nil
+	}
+
+	if policyQuestion.MatchString(question) || (permissionWords.MatchString(question) && serverWords.MatchString(question)) {
+		queryEmbedding, err := s.aiService.GenerateEmbedding(ctx, question)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate query embedding: %w", err)
+		}
+		var docs []models.PriorityResult
+		if s.priorityRepo != nil {
+			if docs, err = s.priorityRepo.Search(ctx, guildID, queryEmbedding, policyMaxDocuments, policyMinSimilarity); err != nil {
+				return "", err
+			}
+			docs = visiblePriority(ctx, docs)
+		}
+		stats, err := s.topicStats(ctx, guildID, queryEmbedding, 0)
+		if err != nil {
+			return "", err
+		}
+		return policyMemory(docs, stats), nil
+	}
+	return "", nil
+}
+
+func expertMemory(topic string, stats *TopicStats) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "SERVER MEMORY (computed across all channels): members who discussed %q the most, ranked by similarity-weighted message count.\n", topic)
+	if len(stats.Experts) == 0 {
+		sb.WriteString("Nobody has discussed this topic on the server yet.\n")
+		return sb.String()
+	}
+	for n, e := range stats.Experts[:min(len(stats.Experts), maxTopicExperts)] {
+		fmt.Fprintf(&sb, "%d. %s: %d related messages (score %.1f), mostly in #%s\n",
+			n+1, e.Username, e.Messages, e.Score, strings.Join(e.Channels[:min(len(e.Channels), 3)], ", #"))
+	}
+	sb.WriteString(channelLine(stats))
+	sb.WriteString("Answer who knows about this from these statistics, which cover the whole server rather than only the excerpts below.\n\n")
+	return sb.String()
+}
+
+func policyMemory(docs []models.PriorityResult, stats *TopicStats) string {
+	var sb strings.Builder
+	sb.WriteString("SERVER MEMORY (computed across all channels): this is a question about the server's rules.\n")
+	if len(docs) == 0 {
+		sb.WriteString("No official rules or announcements seem to cover it; say so rather than guessing a rule.\n")
+	} else {
+		sb.WriteString("Official information that may apply:\n")
+		for _, result := range docs {
+			fmt.Fprintf(&sb, "[📜 #%s] %s\n", result.Document.ChannelName, result.Document.Content)
+		}
+	}
+	if stats.Messages > 0 {
+		sb.WriteString(channelLine(stats))
+	}
+	sb.WriteString("\n")
+	return sb.String()
+}
+
+// channelLine tells where a topic comes up, e.g. "Where it comes up: #ops (12), #general (3)"
+func channelLine(stats *TopicStats) string {
+	if len(stats.Channels) == 0 {
+		return ""
+	}
+	parts := make([]string, 0, maxTopicChannels)
+	for _, c := range stats.Channels[:min(len(stats.Channels), maxTopicChannels)] {
+		parts = append(parts, "#"+c.Name+" ("+strconv.Itoa(c.Messages)+")")
+	}
+	return "Where it comes up: " + strings.Join(parts, ", ") + "\n"
+}
+
+// rankedKeys returns a count map's keys, highest count first
+func rankedKeys(counts map[string]int) []string {
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(a, b int) bool {
+		if counts[keys[a]] != counts[keys[b]] {
+			return counts[keys[a]] > counts[keys[b]]
+		}
+		return keys[a] < keys[b]
+	})
+	return keys
+}
+
+// botUserID is the bot's own user ID, whose indexed messages aren't expertise
+func (s *Service) botUserID() int64 {
+	if s.session == nil || s.session.State == nil || s.session.State.User == nil {
+		return 0
+	}
+	id, _ := strconv.ParseInt(s.session.State.User.ID, 10, 64)
+	return id
+}
@@ -2,10 +2,13 @@ package rag
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
@@ -13,19 +16,55 @@ import (
 	"discord-tars/internal/interfaces"
 	"discord-tars/internal/models"
 	"discord-tars/internal/repository"
+	"discord-tars/internal/repository/postgres"
+	"discord-tars/internal/sanitize"
+	"discord-tars/internal/services/ocr"
+	"discord-tars/internal/storage"
+)
+
+// Synced documentation competes with chat history, so only close matches are used
+const (
+	knowledgeMaxResults    = 4
+	knowledgeMinSimilarity = 0.35
 )
 
 type Service struct {
-	aiService interfaces.AIService
-	msgRepo   *repository.MessageRepository
-	session   *discordgo.Session
+	aiService      interfaces.AIService
+	msgRepo        *repository.MessageRepository
+	priorityRepo   *repository.PriorityRepository
+	knowledgeRepo  *repository.KnowledgeRepository
+	indexRepo      *repository.IndexRepository      // Optional; tracks index health and bulk runs
+	summaryRepo    *repository.SummaryRepository    // Optional; nightly channel summaries
+	codeRepo       *repository.CodeRepository       // Optional; code blocks embedded on their own
+	languageRepo   *repository.LanguageRepository   // Optional; message languages and guild restrictions
+	attachmentRepo *repository.AttachmentRepository // Optional; shared files and the text of documents
+	ocr            ocr.Engine                       // Optional; reads the text of images
+	ingestFilter   *IngestFilter                    // Optional; keeps low-value and spam messages out
+	session        *discordgo.Session
+
+	attachmentStore    storage.Store // Optional; archives attachments when set
+	maxAttachmentBytes int64
+	rewriteQueries     bool
+	translateQueries   bool
+	embeddingModel     string // Recorded with each embedding so stale ones can be found
+
+	speakers  speakerCache
+	languages languageCache
+
+	pendingEmbeddings atomic.Int64 // Messages waiting to be stored and embedded
+
+	priorityMu       sync.RWMutex
+	priorityChannels map[int64]bool // Cached set of priority channel IDs
 }
 
-func NewService(aiService interfaces.AIService, msgRepo *repository.MessageRepository, session *discordgo.Session) *Service {
+func NewService(aiService interfaces.AIService, msgRepo *repository.MessageRepository, priorityRepo *repository.PriorityRepository, knowledgeRepo *repository.KnowledgeRepository, session *discordgo.Session) *Service {
 	return &Service{
-		aiService: aiService,
-		msgRepo:   msgRepo,
-		session:   session,
+		aiService:      aiService,
+		msgRepo:        msgRepo,
+		priorityRepo:   priorityRepo,
+		knowledgeRepo:  knowledgeRepo,
+		session:        session,
+		embeddingModel: "text-embedding-3-small",
 	}
 }
 
@@ -39,6 +78,20 @@ func (s *Service) ProcessMessage(ctx context.Context, discordMsg *discordgo.Mess
 		log.Printf("ℹ️ Skipping bot message ID: %s", discordMsg.ID)
 		return nil
 	}
+	return s.index(ctx, discordMsg, true)
+}
+
+// IndexOwnMessage stores and embeds a message the bot posted itself, such as
+// a voice transcript, which ProcessMessage skips like any bot message
+func (s *Service) IndexOwnMessage(ctx context.Context, discordMsg *discordgo.Message) error {
+	log.Printf("📨 Indexing own message ID: %s", discordMsg.ID)
+	return s.index(ctx, discordMsg, false)
+}
+
+// index stores and embeds a message; filter applies the ingest filters
+func (s *Service) index(ctx context.Context, discordMsg *discordgo.Message, filter bool) error {
+	s.pendingEmbeddings.Add(1)
+	defer s.pendingEmbeddings.Add(-1)
 
 	// Convert Discord message to our models
 	userID, err := strconv.ParseInt(discordMsg.Author.ID, 10, 64)
@@ -71,6 +124,19 @@ func (s *Service) ProcessMessage(ctx context.Context, discordMsg *discordgo.Mess
 		timestamp = time.Now()
 	}
 
+	priority := s.isPriorityChannel(ctx, channelID)
+	skipReason := ""
+	if filter && s.ingestFilter != nil && !priority {
+		if s.ingestFilter.spam(guildID, userID, messageID, discordMsg.Content, timestamp) {
+			s.ingestFilter.record(models.FilterReasonSpam)
+			log.Printf("🚯 Skipping message ID: %s from user: %s, who is flooding", discordMsg.ID, discordMsg.Author.Username)
+			return nil
+		}
+		if skipReason = s.ingestFilter.lowValue(discordMsg.Content); skipReason == "" && strings.TrimSpace(discordMsg.Content) != "" {
+			skipReason = s.worthless(ctx, discordMsg.Content)
+		}
+	}
+
 	user := &models.User{
 		ID:            userID,
 		Username:      discordMsg.Author.Username,
@@ -81,6 +147,7 @@ func (s *Service) ProcessMessage(ctx context.Context, discordMsg *discordgo.Mess
 	// Get channel information from Discord API
 	channelName := "unknown"
 	channelType := 0
+	var discordChannel *discordgo.Channel
 
 	if s.session != nil {
 		channel, err := s.session.Channel(discordMsg.ChannelID)
@@ -89,6 +156,7 @@ func (s *Service) ProcessMessage(ctx context.Context, discordMsg *discordgo.Mess
 		} else if channel != nil {
 			channelName = channel.Name
 			channelType = int(channel.Type)
+			discordChannel = channel
 		}
 	}
 
@@ -132,10 +200,24 @@ func (s *Service) ProcessMessage(ctx context.Context, discordMsg *discordgo.Mess
 		return fmt.Errorf("failed to store message: %w", err)
 	}
 
+	if s.attachmentStore != nil && len(discordMsg.Attachments) > 0 {
+		go s.archiveAttachments(discordMsg)
+	}
+	if s.attachmentRepo != nil && guildID != 0 && len(discordMsg.Attachments) > 0 {
+		go s.indexAttachments(discordMsg, message)
+	}
+
+	if skipReason != "" {
+		log.Printf("🚯 Not embedding low-value message ID: %s (%s)", discordMsg.ID, skipReason)
+		s.ingestFilter.record(skipReason)
+		s.markFiltered(ctx, message, skipReason)
+		return nil
+	}
+
 	// Generate and store embedding for non-empty content
 	if strings.TrimSpace(discordMsg.Content) != "" {
 		log.Printf("🧠 Generating embedding for message ID: %s", discordMsg.ID)
-		embedding, err := s.aiService.GenerateEmbedding(ctx, discordMsg.Content)
+		embedding, err := s.aiService.GenerateEmbedding(ctx, s.embeddingText(discordMsg, discordChannel))
 		if err != nil {
 			log.Printf("⚠️ Failed to generate embedding for message ID: %s: %v", discordMsg.ID, err)
 			// Continue without embedding to avoid blocking message storage
@@ -143,13 +225,26 @@ func (s *Service) ProcessMessage(ctx context.Context, discordMsg *discordgo.Mess
 		}
 
 		log.Printf("💾 Storing embedding for message ID: %s", discordMsg.ID)
-		if err := s.msgRepo.StoreEmbedding(ctx, messageID, embedding, "text-embedding-3-small"); err != nil {
+		if err := s.msgRepo.StoreEmbedding(ctx, messageID, embedding, s.embeddingModel); err != nil {
 			log.Printf("❌ Failed to store embedding for message ID: %s: %v", discordMsg.ID, err)
 			return fmt.Errorf("failed to store embedding: %w", err)
 		}
 
 		log.Printf("✅ Successfully stored message and embedding for ID: %s, content: %s",
 			discordMsg.ID, discordMsg.Content[:min(50, len(discordMsg.Content))])
+		if filter && s.ingestFilter != nil {
+			s.ingestFilter.record("")
+		}
+
+		// Messages in rules/announcement channels also go to the priority collection
+		if priority {
+			s.storePriorityDocument(ctx, discordMsg, channelName, models.PrioritySourceChannel, embedding)
+		}
+
+		if err := s.indexCode(ctx, message); err != nil {
+			log.Printf("⚠️ %v", err)
+		}
+		s.storeLanguage(ctx, message)
 	} else {
 		log.Printf("ℹ️ Skipping embedding for empty message ID: %s", discordMsg.ID)
 	}
@@ -157,8 +252,14 @@ func (s *Service) ProcessMessage(ctx context.Context, discordMsg *discordgo.Mess
 	return nil
 }
 
+// EmbeddingQueueDepth is the number of incoming messages still being stored
+// and embedded; a growing value means embeddings can't keep up
+func (s *Service) EmbeddingQueueDepth() int64 {
+	return s.pendingEmbeddings.Load()
+}
+
 // SearchContext finds relevant messages for RAG context
-func (s *Service) SearchContext(ctx context.Context, query string, channelID int64, maxResults int) ([]models.SearchResult, error) {
+func (s *Service) SearchContext(ctx context.Context, query string, guildID, channelID int64, maxResults int) ([]models.SearchResult, error) {
 	log.Printf("🔍 Searching context for query: %s", query[:min(50, len(query))])
 
 	// Generate embedding for the query
@@ -168,8 +269,12 @@ func (s *Service) SearchContext(ctx context.Context, query string, channelID int
 		return nil, fmt.Errorf("failed to generate query embedding: %w", err)
 	}
 
+	return s.searchMessages(ctx, queryEmbedding, guildID, channelID, maxResults)
+}
+
+func (s *Service) searchMessages(ctx context.Context, queryEmbedding []float32, guildID, channelID int64, maxResults int) ([]models.SearchResult, error) {
 	// Search for similar messages
-	results, err := s.msgRepo.SearchSimilarMessages(ctx, queryEmbedding, maxResults, 0.7)
+	results, err := s.msgRepo.SearchSimilarMessages(ctx, guildID, queryEmbedding, maxResults, 0.7)
 	if err != nil {
 		log.Printf("❌ Failed to search similar messages: %v", err)
 		return nil, fmt.Errorf("failed to search similar messages: %w", err)
@@ -191,16 +296,203 @@ func (s *Service) SearchContext(ctx context.Context, query string, channelID int
 	return results, nil
 }
 
-// BuildRAGPrompt creates a prompt with relevant context
+// SearchChannels finds relevant messages restricted to specific channels, e.g. rules or FAQ channels
+func (s *Service) SearchChannels(ctx context.Context, query string, guildID int64, channelIDs []int64, maxResults int) ([]models.SearchResult, error) {
+	log.Printf("🔍 Searching %d channels for query: %s", len(channelIDs), query[:min(50, len(query))])
+
+	queryEmbedding, err := s.aiService.GenerateEmbedding(ctx, query)
+	if err != nil {
+		log.Printf("❌ Failed to generate query embedding: %v", err)
+		return nil, fmt.Errorf("failed to generate query embedding: %w", err)
+	}
+
+	results, err := s.msgRepo.SearchSimilarMessagesInChannels(ctx, guildID, queryEmbedding, channelIDs, nil, maxResults, 0.5)
+	if err != nil {
+		log.Printf("❌ Failed to search channel messages: %v", err)
+		return nil, fmt.Errorf("failed to search channel messages: %w", err)
+	}
+
+	log.Printf("📊 Found %d similar messages in selected channels", len(results))
+	return results, nil
+}
+
+// RetrievedContext is the context gathered for a query: priority documents
+// (pins, rules, announcements), synced team documentation and ordinary chat history
+type RetrievedContext struct {
+	Priority  []models.PriorityResult
+	Documents []models.KnowledgeResult
+	Messages  []models.SearchResult
+	// Summaries are nightly summaries of a channel's day
+	Summaries []models.SummaryResult
+	// Screenshots are images posted in chat whose text was read by OCR
+	Screenshots []models.AttachmentResult
+	// QueryEmbedding is the embedding of the question as asked, before any rewriting
+	QueryEmbedding []float32
+}
+
+// Retrieve gathers priority documents and similar chat messages for a query.
+// Priority documents are always searched, with a lower similarity threshold,
+// so official information is considered even when chat history matches better.
+func (s *Service) Retrieve(ctx context.Context, query string, guildID, channelID int64, maxResults int) (*RetrievedContext, error) {
+	log.Printf("🔍 Retrieving context for query: %s", query[:min(50, len(query))])
+
+	queryEmbedding, err := s.aiService.GenerateEmbedding(ctx, query)
+	if err != nil {
+		log.Printf("❌ Failed to generate query embedding: %v", err)
+		return nil, fmt.Errorf("failed to generate query embedding: %w", err)
+	}
+
+	recap := parseRecap(query, time.Now())
+	rc, err := s.searchAll(ctx, queryEmbedding, guildID, maxResults, recap)
+	if err != nil {
+		return nil, err
+	}
+	rc.QueryEmbedding = queryEmbedding
+
+	// Translations find what was discussed in the guild's other languages
+	for _, translated := range s.translateQuery(ctx, guildID, query) {
+		translatedEmbedding, err := s.aiService.GenerateEmbedding(ctx, translated)
+		if err != nil {
+			log.Printf("⚠️ Failed to embed translated query, skipping it: %v", err)
+			continue
+		}
+		more, err := s.searchAll(ctx, translatedEmbedding, guildID, maxResults, recap)
+		if err != nil {
+			return nil, err
+		}
+		rc = mergeContexts(rc, more, maxResults)
+	}
+	preferSummaries(rc, recap)
+	return rc, s.fallbackToRecent(ctx, rc, channelID, maxResults)
+}
+
+// searchAll runs one query embedding against every collection. Searches
+// besides the chat history's may fail without failing retrieval, unless they
+// hit the query timeout, which the asker is told about.
+func (s *Service) searchAll(ctx context.Context, queryEmbedding []float32, guildID int64, maxResults int, recap recapPeriod) (*RetrievedContext, error) {
+	var err error
+	rc := &RetrievedContext{}
+	if s.priorityRepo != nil && guildID != 0 {
+		rc.Priority, err = s.priorityRepo.Search(ctx, guildID, queryEmbedding, priorityMaxResults, priorityMinSimilarity)
+		if errors.Is(err, postgres.ErrQueryTimeout) {
+			return nil, err
+		}
+		if err != nil {
+			log.Printf("⚠️ Priority search failed, continuing with chat history only: %v", err)
+		}
+	}
+	if s.knowledgeRepo != nil && guildID != 0 {
+		rc.Documents, err = s.knowledgeRepo.Search(ctx, guildID, queryEmbedding, knowledgeMaxResults, knowledgeMinSimilarity)
+		if errors.Is(err, postgres.ErrQueryTimeout) {
+			return nil, err
+		}
+		if err != nil {
+			log.Printf("⚠️ Documentation search failed, continuing without it: %v", err)
+		}
+	}
+	if rc.Summaries, err = s.searchSummaries(ctx, queryEmbedding, guildID, recap); err != nil {
+		return nil, err
+	}
+	rc.Screenshots = s.searchScreenshots(ctx, queryEmbedding, guildID)
+
+	allowed := s.guildLanguages(ctx, guildID).allowed
+	switch visible := channelsFrom(ctx); {
+	case visible != nil:
+		rc.Messages, err = s.msgRepo.SearchSimilarMessagesInChannels(ctx, guildID, queryEmbedding, visible.ids, allowed, maxResults, 0.7)
+	case len(allowed) > 0:
+		rc.Messages, err = s.msgRepo.SearchSimilarMessagesInLanguages(ctx, guildID, queryEmbedding, allowed, maxResults, 0.7)
+	default:
+		rc.Messages, err = s.msgRepo.SearchSimilarMessages(ctx, guildID, queryEmbedding, maxResults, 0.7)
+	}
+	if err != nil {
+		log.Printf("❌ Failed to search similar messages: %v", err)
+		return nil, fmt.Errorf("failed to search similar messages: %w", err)
+	}
+	keepVisible(ctx, rc)
+	log.Printf("📊 Found %d similar messages", len(rc.Messages))
+	return rc, nil
+}
+
+// fallbackToRecent fills in recent channel messages when nothing similar was found
+func (s *Service) fallbackToRecent(ctx context.Context, rc *RetrievedContext, channelID int64, maxResults int) error {
+	if len(rc.Messages) > 0 || len(rc.Summaries) > 0 || !canSee(ctx, channelID) {
+		return nil
+	}
+
+	log.Printf("ℹ️ No similar messages found, fetching recent messages for channel ID: %d", channelID)
+	recent, err := s.msgRepo.GetRecentMessages(ctx, channelID, min(maxResults, 5))
+	if err != nil {
+		log.Printf("❌ Failed to get recent messages: %v", err)
+		return fmt.Errorf("failed to get recent messages: %w", err)
+	}
+	log.Printf("📊 Found %d recent messages", len(recent))
+	rc.Messages = recent
+	return nil
+}
+
+// BuildContextPrompt creates a prompt with priority documents and team documentation
+// listed before chat history
+func (s *Service) BuildContextPrompt(userQuery string, rc *RetrievedContext) string {
+	if rc == nil {
+		return userQuery
+	}
+
+	var contextBuilder strings.Builder
+	if len(rc.Priority) > 0 {
+		contextBuilder.WriteString("Official server information (pinned messages, rules, announcements). ")
+		contextBuilder.WriteString("Treat it as authoritative and prefer it over chat history when they conflict:\n\n")
+		for _, result := range rc.Priority {
+			label := "📌 pinned"
+			if result.Document.Source == models.PrioritySourceChannel {
+				label = "📜 official"
+			}
+			contextBuilder.WriteString(fmt.Sprintf("[%s in #%s] %s\n\n", label, result.Document.ChannelName, sanitize.Context(result.Document.Content)))
+		}
+	}
+
+	if len(rc.Documents) > 0 {
+		contextBuilder.WriteString("Excerpts from the team's documentation. Cite the page title and link when you use them:\n\n")
+		for _, result := range rc.Documents {
+			contextBuilder.WriteString(fmt.Sprintf("[📚 %s — %s]\n%s\n\n", result.Title, result.URL, sanitize.Context(result.Chunk.Content)))
+		}
+	}
+
+	if len(rc.Summaries) > 0 {
+		contextBuilder.WriteString("Daily summaries of the server's channels. Prefer them for questions about what happened over a period:\n\n")
+		for _, result := range rc.Summaries {
+			contextBuilder.WriteString(fmt.Sprintf("[🗓️ %s]\n%s\n\n", summaryLabel(result.Summary), sanitize.Context(result.Summary.Content)))
+		}
+	}
+
+	if len(rc.Screenshots) > 0 {
+		contextBuilder.WriteString("Text read from screenshots posted in chat, such as error messages. It may have misread characters:\n\n")
+		for _, result := range rc.Screenshots {
+			file := result.Attachment
+			contextBuilder.WriteString(fmt.Sprintf("[🖼️ %s posted by %s · %s]\n%s\n\n", file.Filename, result.Username,
+				file.Timestamp.UTC().Format("2006-01-02 15:04 UTC"), sanitize.Context(file.Content)))
+		}
+	}
+
+	contextBuilder.WriteString(s.BuildRAGPrompt(userQuery, rc.Messages))
+	return contextBuilder.String()
+}
+
+// BuildRAGPrompt creates a prompt with relevant context. Each message is
+// attributed to its channel, time and author's top role so the model can
+// weigh staff statements about server policy above hearsay.
 func (s *Service) BuildRAGPrompt(userQuery string, context []models.SearchResult) string {
 	var contextBuilder strings.Builder
 
-	contextBuilder.WriteString("Here is some relevant context from previous conversations:\n\n")
+	contextBuilder.WriteString("Here is some relevant context from previous conversations. ")
+	contextBuilder.WriteString("Each message shows where and when it was posted and who posted it. ")
+	contextBuilder.WriteString("Messages marked staff come from the server's owner or moderators: on rules, policy and official decisions, ")
+	contextBuilder.WriteString("trust them over other members, and prefer newer messages when statements conflict.\n\n")
 
 	for _, result := range context {
-		contextBuilder.WriteString(fmt.Sprintf("**%s**: %s\n",
+		contextBuilder.WriteString(fmt.Sprintf("[%s] **%s**: %s\n",
+			s.attribution(result),
 			result.User.Username,
-			result.Message.Content))
+			sanitize.Context(result.Message.Content)))
 
 		if result.Similarity < 1.0 {
 			contextBuilder.WriteString(fmt.Sprintf("(similarity: %.2f)\n", result.Similarity))
@@ -213,6 +505,37 @@ func (s *Service) BuildRAGPrompt(userQuery string, context []models.SearchResult
 	return contextBuilder.String()
 }
 
+// attribution describes where, when and by whom a message was posted, e.g.
+// "#rules · 2024-05-03 14:20 UTC · Moderator, staff"
+func (s *Service) attribution(result models.SearchResult) string {
+	parts := make([]string, 0, 3)
+	if result.Channel.Name != "" && result.Channel.Name != "unknown" {
+		parts = append(parts, "#"+result.Channel.Name)
+	}
+	if !result.Message.Timestamp.IsZero() {
+		parts = append(parts, result.Message.Timestamp.UTC().Format("2006-01-02 15:04 UTC"))
+	}
+
+	userID := result.Message.UserID
+	if userID == 0 {
+		userID = result.User.ID
+	}
+	info := s.speakerInfo(result.Message.GuildID, userID)
+	switch {
+	case info.topRole != "" && info.staff:
+		parts = append(parts, info.topRole+", staff")
+	case info.topRole != "":
+		parts = append(parts, info.topRole)
+	case info.staff:
+		parts = append(parts, "staff")
+	}
+
+	if len(parts) == 0 {
+		return "message"
+	}
+	return strings.Join(parts, " · ")
+}
+
 func min(a, b int) int {
 	if a < b {
 		return a
@@ -0,0 +1,104 @@
+package rag
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	speakerCacheTTL = 10 * time.Minute
+	maxSpeakerCache = 5000
+)
+
+// staffPermissions marks roles whose holders speak for the server
+const staffPermissions = discordgo.PermissionAdministrator | discordgo.PermissionManageGuild |
+	discordgo.PermissionManageMessages | discordgo.PermissionKickMembers | discordgo.PermissionBanMembers
+
+// speaker is what the prompt says about a message's author
+type speaker struct {
+	topRole string // Highest role other than @everyone, if any
+	staff   bool   // Owner, or holds a role with moderation permissions
+}
+
+type cachedSpeaker struct {
+	speaker
+	fetchedAt time.Time
+}
+
+// speakerCache remembers member roles so building a prompt doesn't fetch
+// every author from the API
+type speakerCache struct {
+	mu      sync.Mutex
+	entries map[string]cachedSpeaker
+}
+
+// speakerInfo looks up an author's top role and whether they are staff
+func (s *Service) speakerInfo(guildID, userID int64) speaker {
+	if s.session == nil || guildID == 0 || userID == 0 {
+		return speaker{}
+	}
+	key := strconv.FormatInt(guildID, 10) + ":" + strconv.FormatInt(userID, 10)
+
+	s.speakers.mu.Lock()
+	if cached, ok := s.speakers.entries[key]; ok && time.Since(cached.fetchedAt) < speakerCacheTTL {
+		s.speakers.mu.Unlock()
+		return cached.speaker
+	}
+	s.speakers.mu.Unlock()
+
+	info := s.lookupSpeaker(strconv.FormatInt(guildID, 10), strconv.FormatInt(userID, 10))
+
+	s.speakers.mu.Lock()
+	defer s.speakers.mu.Unlock()
+	if s.speakers.entries == nil || len(s.speakers.entries) >= maxSpeakerCache {
+		s.speakers.entries = make(map[string]cachedSpeaker)
+	}
+	s.speakers.entries[key] = cachedSpeaker{speaker: info, fetchedAt: time.Now()}
+	return info
+}
+
+func (s *Service) lookupSpeaker(guildID, userID string) speaker {
+	member, err := s.session.State.Member(guildID, userID)
+	if err != nil {
+		// Authors who left the server have no roles to report
+		if member, err = s.session.GuildMember(guildID, userID); err != nil {
+			return speaker{}
+		}
+	}
+
+	// Roles and ownership come from the gateway cache; the API is the fallback
+	var info speaker
+	var roles []*discordgo.Role
+	if guild, err := s.session.State.Guild(guildID); err == nil {
+		roles = guild.Roles
+		info.staff = guild.OwnerID == userID
+	}
+	if len(roles) == 0 {
+		if roles, err = s.session.GuildRoles(guildID); err != nil {
+			return speaker{}
+		}
+	}
+	byID := make(map[string]*discordgo.Role, len(roles))
+	for _, role := range roles {
+		byID[role.ID] = role
+	}
+
+	topPosition := -1
+	for _, id := range member.Roles {
+		role, ok := byID[id]
+		if !ok {
+			continue
+		}
+		if role.Permissions&staffPermissions != 0 {
+			info.staff = true
+		}
+		if role.Position > topPosition {
+			topPosition = role.Position
+			info.topRole = role.Name
+		}
+	}
+	return info
+}
@@ -0,0 +1,104 @@
+package rag
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/repository"
+	"discord-tars/internal/server"
+)
+
+// SetIndexRepository enables index health stats and records bulk indexing runs
+func (s *Service) SetIndexRepository(indexRepo *repository.IndexRepository) {
+	s.indexRepo = indexRepo
+	if err := indexRepo.InterruptRuns(context.Background()); err != nil {
+		log.Printf("⚠️ %v", err)
+	}
+}
+
+// IndexStatus reports how much of a guild's history is searchable
+func (s *Service) IndexStatus(ctx context.Context, guildID int64) (*models.IndexStats, error) {
+	if s.indexRepo == nil {
+		return nil, errors.New("index stats are not enabled")
+	}
+	return s.indexRepo.GetStats(ctx, guildID)
+}
+
+// CountIndexedMessages returns the number of stored messages across all guilds
+func (s *Service) CountIndexedMessages(ctx context.Context) (int64, error) {
+	if s.indexRepo == nil {
+		return 0, errors.New("index stats are not enabled")
+	}
+	return s.indexRepo.CountMessages(ctx)
+}
+
+// HandleStatus serves index stats as JSON, for one guild with ?guild_id= or for all of them
+func (s *Service) HandleStatus(w http.ResponseWriter, r *http.Request) {
+	if s.indexRepo == nil {
+		server.WriteError(w, http.StatusServiceUnavailable, "index stats are not enabled")
+		return
+	}
+
+	var guildIDs []int64
+	if raw := r.URL.Query().Get("guild_id"); raw != "" {
+		id, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			server.WriteError(w, http.StatusBadRequest, "invalid guild_id")
+			return
+		}
+		guildIDs = []int64{id}
+	} else {
+		ids, err := s.indexRepo.ListGuildIDs(r.Context())
+		if err != nil {
+			server.WriteError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		guildIDs = ids
+	}
+
+	type guildStatus struct {
+		*models.IndexStats
+		Coverage float64 `json:"coverage_percent"`
+	}
+	statuses := make([]guildStatus, 0, len(guildIDs))
+	for _, id := range guildIDs {
+		stats, err := s.indexRepo.GetStats(r.Context(), id)
+		if err != nil {
+			server.WriteError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		statuses = append(statuses, guildStatus{IndexStats: stats, Coverage: stats.Coverage()})
+	}
+	server.WriteJSON(w, http.StatusOK, map[string]interface{}{"guilds": statuses})
+}
+
+// startRun records a bulk indexing run when run tracking is enabled; the
+// returned function finishes it with the final count and error
+func (s *Service) startRun(ctx context.Context, run *models.IndexRun) func(processed int, err error) {
+	if s.indexRepo == nil {
+		return func(int, error) {}
+	}
+	if err := s.indexRepo.StartRun(ctx, run); err != nil {
+		log.Printf("⚠️ %v", err)
+		return func(int, error) {}
+	}
+	return func(processed int, err error) {
+		run.Processed = processed
+		run.Status = models.IndexRunCompleted
+		switch {
+		case ctx.Err() != nil:
+			run.Status = models.IndexRunCancelled
+		case err != nil:
+			run.Status = models.IndexRunFailed
+			run.Error = err.Error()
+		}
+		// The run's own context may be cancelled by now
+		if err := s.indexRepo.FinishRun(context.Background(), run); err != nil {
+			log.Printf("⚠️ %v", err)
+		}
+	}
+}
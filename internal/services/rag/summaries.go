@@ -0,0 +1,127 @@
+package rag
+
+import (
+	"context"
+	"errors"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/repository"
+	"discord-tars/internal/repository/postgres"
+)
+
+// Daily channel summaries are searched for every question, but only close
+// matches are used unless the question asks what happened over a period
+const (
+	summaryMaxResults    = 2
+	summaryMinSimilarity = 0.4
+	// Recap questions get more summaries and fewer raw messages
+	recapMaxSummaries  = 8
+	recapMinSimilarity = 0.2
+	recapMaxMessages   = 2
+)
+
+// recapPeriod is what a question asks to recap; the zero value is a question
+// that isn't a recap
+type recapPeriod struct {
+	asked        bool
+	since, until time.Time // Zero when the question doesn't name a period
+}
+
+var (
+	recapWords = regexp.MustCompile(`(?i)\b(what happened|what's happened|recap|catch (me )?up|summar|what did (we|i) miss|` +
+		`que s'est-il passé|qu'est-ce qui s'est passé|résum|qué pasó|qué ha pasado|resumen|was ist passiert|was war los|zusammenfass)`)
+
+	recapPeriods = []struct {
+		pattern *regexp.Regexp
+		span    func(today time.Time) (time.Time, time.Time)
+	}{
+		{regexp.MustCompile(`(?i)\b(yesterday|hier|ayer|gestern)\b`), func(today time.Time) (time.Time, time.Time) {
+			return today.AddDate(0, 0, -1), today
+		}},
+		{regexp.MustCompile(`(?i)\b(last week|semaine dernière|semana pasada|letzte[nr]? woche|vergangene[nr]? woche)`), func(today time.Time) (time.Time, time.Time) {
+			monday := startOfWeek(today)
+			return monday.AddDate(0, 0, -7), monday
+		}},
+		{regexp.MustCompile(`(?i)\b(this week|cette semaine|esta semana|diese[rn]? woche)`), func(today time.Time) (time.Time, time.Time) {
+			return startOfWeek(today), today.AddDate(0, 0, 1)
+		}},
+		{regexp.MustCompile(`(?i)\b(past|last) (7|seven) days`), func(today time.Time) (time.Time, time.Time) {
+			return today.AddDate(0, 0, -7), today.AddDate(0, 0, 1)
+		}},
+		{regexp.MustCompile(`(?i)\b(last month|mois dernier|mes pasado|letzte[nr]? monat|vergangene[nr]? monat)`), func(today time.Time) (time.Time, time.Time) {
+			first := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.UTC)
+			return first.AddDate(0, -1, 0), first
+		}},
+		{regexp.MustCompile(`(?i)\b(this month|ce mois|este mes|diese[nr]? monat)`), func(today time.Time) (time.Time, time.Time) {
+			return time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.UTC), today.AddDate(0, 0, 1)
+		}},
+	}
+)
+
+// SetSummaryRepository makes retrieval search nightly channel summaries
+func (s *Service) SetSummaryRepository(summaryRepo *repository.SummaryRepository) {
+	s.summaryRepo = summaryRepo
+}
+
+// parseRecap tells whether a question asks what happened, and over which
+// UTC days when it names a period such as "last month"
+func parseRecap(question string, now time.Time) recapPeriod {
+	today := now.UTC().Truncate(24 * time.Hour)
+	for _, p := range recapPeriods {
+		if p.pattern.MatchString(question) {
+			since, until := p.span(today)
+			return recapPeriod{asked: true, since: since, until: until}
+		}
+	}
+	return recapPeriod{asked: recapWords.MatchString(question)}
+}
+
+func startOfWeek(day time.Time) time.Time {
+	return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+}
+
+// searchSummaries finds the channel summaries relevant to a question. Only
+// a query timeout is returned; other failures leave the summaries out.
+func (s *Service) searchSummaries(ctx context.Context, queryEmbedding []float32, guildID int64, recap recapPeriod) ([]models.SummaryResult, error) {
+	if s.summaryRepo == nil || guildID == 0 {
+		return nil, nil
+	}
+
+	limit, similarity := summaryMaxResults, summaryMinSimilarity
+	if recap.asked {
+		limit, similarity = recapMaxSummaries, recapMinSimilarity
+		if !recap.since.IsZero() {
+			// Within the period named, every day is relevant
+			similarity = -1
+		}
+	}
+	results, err := s.summaryRepo.Search(ctx, guildID, queryEmbedding, recap.since, recap.until, limit, similarity)
+	if errors.Is(err, postgres.ErrQueryTimeout) {
+		return nil, err
+	}
+	if err != nil {
+		log.Printf("⚠️ Summary search failed, continuing without summaries: %v", err)
+		return nil, nil
+	}
+	return results, nil
+}
+
+// preferSummaries keeps recap prompts small: when summaries cover the
+// question, only the best few chat messages are kept alongside them
+func preferSummaries(rc *RetrievedContext, recap recapPeriod) {
+	if rc == nil || !recap.asked || len(rc.Summaries) == 0 {
+		return
+	}
+	if len(rc.Messages) > recapMaxMessages {
+		rc.Messages = rc.Messages[:recapMaxMessages]
+	}
+}
+
+// summaryLabel names a summary in sources, e.g. "#general, 2024-05-01"
+func summaryLabel(summary models.ChannelSummary) string {
+	return "#" + strings.TrimPrefix(summary.ChannelName, "#") + ", " + summary.Day.Format(time.DateOnly)
+}
@@ -0,0 +1,98 @@
+package rag
+
+import (
+	"context"
+	"errors"
+
+	"discord-tars/internal/models"
+)
+
+// ErrNotVisible is returned when asked about a channel the asker can't see
+var ErrNotVisible = errors.New("channel is not visible to the asker")
+
+type channelsKey struct{}
+
+// visibleChannels are the channels of a guild an asker can see
+type visibleChannels struct {
+	ids []int64
+	set map[int64]bool
+}
+
+// WithChannels limits retrieval made with ctx to what was posted in the given
+// channels, for askers who can't see the guild's other channels, such as
+// HTTP API callers. Documentation synced to the guild isn't limited.
+func WithChannels(ctx context.Context, channelIDs []int64) context.Context {
+	v := &visibleChannels{ids: channelIDs, set: make(map[int64]bool, len(channelIDs))}
+	for _, id := range channelIDs {
+		v.set[id] = true
+	}
+	return context.WithValue(ctx, channelsKey{}, v)
+}
+
+// Limited reports whether retrieval made with ctx is limited to some channels
+func Limited(ctx context.Context) bool {
+	return channelsFrom(ctx) != nil
+}
+
+func channelsFrom(ctx context.Context) *visibleChannels {
+	v, _ := ctx.Value(channelsKey{}).(*visibleChannels)
+	return v
+}
+
+// canSee reports whether a channel's content may be retrieved with ctx
+func canSee(ctx context.Context, channelID int64) bool {
+	v := channelsFrom(ctx)
+	return v == nil || v.set[channelID]
+}
+
+// keepVisible drops what was retrieved from channels ctx can't see
+func keepVisible(ctx context.Context, rc *RetrievedContext) {
+	if channelsFrom(ctx) == nil {
+		return
+	}
+	rc.Priority = visiblePriority(ctx, rc.Priority)
+
+	summaries := rc.Summaries[:0]
+	for _, r := range rc.Summaries {
+		if canSee(ctx, r.Summary.ChannelID) {
+			summaries = append(summaries, r)
+		}
+	}
+	rc.Summaries = summaries
+
+	screenshots := rc.Screenshots[:0]
+	for _, r := range rc.Screenshots {
+		if canSee(ctx, r.Attachment.ChannelID) {
+			screenshots = append(screenshots, r)
+		}
+	}
+	rc.Screenshots = screenshots
+}
+
+// visiblePriority drops the priority documents of channels ctx can't see
+func visiblePriority(ctx context.Context, docs []models.PriorityResult) []models.PriorityResult {
+	if channelsFrom(ctx) == nil {
+		return docs
+	}
+	kept := docs[:0]
+	for _, doc := range docs {
+		if canSee(ctx, doc.Document.ChannelID) {
+			kept = append(kept, doc)
+		}
+	}
+	return kept
+}
+
+// visibleMessages drops the messages of channels ctx can't see
+func visibleMessages(ctx context.Context, messages []models.SearchResult) []models.SearchResult {
+	if channelsFrom(ctx) == nil {
+		return messages
+	}
+	kept := messages[:0]
+	for _, m := range messages {
+		if canSee(ctx, m.Message.ChannelID) {
+			kept = append(kept, m)
+		}
+	}
+	return kept
+}
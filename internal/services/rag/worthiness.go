@@ -0,0 +1,205 @@
+package rag
+
+import (
+	"context"
+	"log"
+	"math"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode"
+
+	"discord-tars/internal/models"
+	openaiService "discord-tars/internal/services/openai"
+)
+
+const (
+	// A message scores up to worthLengthWeight for its content words, full at
+	// worthFullLength of them, up to worthContentWeight for how few of its
+	// words are filler, and worthSignalWeight for each sign of information
+	worthLengthWeight  = 0.45
+	worthFullLength    = 12
+	worthContentWeight = 0.3
+	worthSignalWeight  = 0.15
+	worthQuestionBonus = 0.1
+	classifyMaxTokens  = 3
+)
+
+const classifySystemPrompt = `You decide whether a Discord message is worth indexing for a search over a server's history.
+Worth it: facts, answers, decisions, instructions, plans with dates, questions about a topic, links with context.
+Not worth it: reactions, small talk, greetings, jokes, agreement without substance.
+Reply with YES or NO only.`
+
+var (
+	// Identifiers like DATABASE_URL, config.yaml, /etc/hosts, fooBar or v1.2
+	technicalPattern = regexp.MustCompile(`\w[._/:]\w|[a-z][A-Z]|\bv?\d+\.\d+`)
+	wordPattern      = regexp.MustCompile(`[\p{L}\p{N}]+`)
+)
+
+// fillerWords carry no information on their own; reactions and small talk
+// are made of them
+var fillerWords = toSet("lol", "lmao", "lmfao", "rofl", "haha", "hahaha", "hehe", "xd", "ok", "okay", "k", "kk",
+	"yes", "no", "yeah", "yea", "yep", "yup", "nope", "nah", "sure", "true", "same", "thanks", "thank", "thx", "ty",
+	"np", "nice", "cool", "wow", "omg", "gg", "hi", "hello", "hey", "bye", "gm", "gn", "idk", "ikr", "fr", "ngl",
+	"pls", "please", "welcome", "cheers", "congrats", "lmk", "brb", "wp", "rip",
+	"merci", "oui", "non", "mdr", "ptdr", "jaja", "jajaja", "gracias", "si", "danke", "ja", "nein", "hallo", "salut")
+
+// stopWords are neither filler nor content
+var stopWords = toSet("i", "you", "he", "she", "it", "we", "they", "me", "my", "your", "our", "a", "an", "the",
+	"is", "are", "was", "be", "am", "m", "s", "re", "ll", "t", "d", "ve", "to", "of", "in", "on", "at", "for",
+	"and", "or", "but", "so", "that", "this", "just", "too", "very", "really", "do", "did", "all", "what")
+
+func toSet(words ...string) map[string]bool {
+	set := make(map[string]bool, len(words))
+	for _, w := range words {
+		set[w] = true
+	}
+	return set
+}
+
+// ClassifierConfig sets how messages the filters let through are judged
+// worth embedding
+type ClassifierConfig struct {
+	// Messages scoring below SkipBelow aren't embedded, those scoring
+	// EmbedAbove or more are; zero values disable the classifier
+	SkipBelow  float64
+	EmbedAbove float64
+	// Model judges the messages scoring in between, e.g. gpt-4o-mini; without
+	// one they are embedded
+	Model string
+}
+
+// ingestCounters count what happened to incoming messages, by outcome
+type ingestCounters struct {
+	mu         sync.Mutex
+	outcomes   map[string]int64
+	modelCalls int64
+}
+
+// IngestStats compares the incoming messages embedded with those skipped
+type IngestStats struct {
+	Embedded   int64            `json:"embedded"`
+	Skipped    map[string]int64 `json:"skipped"` // By reason
+	SkipRate   float64          `json:"skip_rate"`
+	ModelCalls int64            `json:"classifier_model_calls"`
+}
+
+// SetClassifier makes indexing skip messages unlikely to carry information
+// worth finding again, scored by heuristics and, when unsure, a small model
+func (f *IngestFilter) SetClassifier(cfg ClassifierConfig) {
+	f.classifier = cfg
+}
+
+// Worthiness scores, from 0 to 1, how likely a message is to carry
+// information worth finding again: long messages of content words with code,
+// numbers, identifiers or links score high, reactions and small talk low
+func Worthiness(content string) float64 {
+	contentWords, fillers := 0, 0
+	for _, word := range wordPattern.FindAllString(strings.ToLower(linkPattern.ReplaceAllString(content, " ")), -1) {
+		switch {
+		case fillerWords[word] || isLaugh(word):
+			fillers++
+		case !stopWords[word]:
+			contentWords++
+		}
+	}
+	if contentWords == 0 {
+		return 0
+	}
+
+	score := worthLengthWeight * float64(min(contentWords, worthFullLength)) / worthFullLength
+	score += worthContentWeight * float64(contentWords) / float64(contentWords+fillers)
+	text := mentionPattern.ReplaceAllString(content, " ")
+	for _, signal := range []bool{
+		strings.Contains(text, "`"),
+		linkPattern.MatchString(text),
+		strings.IndexFunc(text, unicode.IsDigit) >= 0,
+		technicalPattern.MatchString(linkPattern.ReplaceAllString(text, " ")),
+	} {
+		if signal {
+			score += worthSignalWeight
+		}
+	}
+	if strings.HasSuffix(strings.TrimSpace(text), "?") {
+		score += worthQuestionBonus
+	}
+	return math.Min(score, 1)
+}
+
+// isLaugh reports words like "hahahaha" or "jajaja"
+func isLaugh(word string) bool {
+	if len(word) < 4 {
+		return false
+	}
+	for _, pair := range []string{"ha", "he", "ja", "xd"} {
+		if strings.Trim(word, pair) == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// worthless classifies a message the filters let through, returning
+// FilterReasonLowValue when it isn't worth embedding
+func (s *Service) worthless(ctx context.Context, content string) string {
+	cfg := s.ingestFilter.classifier
+	if cfg.SkipBelow <= 0 && cfg.EmbedAbove <= 0 {
+		return ""
+	}
+	score := Worthiness(content)
+	switch {
+	case score < cfg.SkipBelow:
+		return models.FilterReasonLowValue
+	case score >= cfg.EmbedAbove || cfg.Model == "":
+		return ""
+	}
+
+	// Unsure; a small model decides, and embedding wins when it can't
+	s.ingestFilter.counts.mu.Lock()
+	s.ingestFilter.counts.modelCalls++
+	s.ingestFilter.counts.mu.Unlock()
+	reply, err := s.aiService.Complete(openaiService.WithModel(ctx, cfg.Model), classifySystemPrompt, truncate(content, 2000), classifyMaxTokens)
+	if err != nil {
+		log.Printf("⚠️ Worthiness classification failed, embedding the message: %v", err)
+		return ""
+	}
+	if strings.HasPrefix(strings.ToUpper(strings.TrimSpace(reply)), "NO") {
+		return models.FilterReasonLowValue
+	}
+	return ""
+}
+
+// record counts what happened to an incoming message: its skip reason, or
+// "" when it was embedded
+func (f *IngestFilter) record(reason string) {
+	f.counts.mu.Lock()
+	defer f.counts.mu.Unlock()
+	if f.counts.outcomes == nil {
+		f.counts.outcomes = make(map[string]int64)
+	}
+	f.counts.outcomes[reason]++
+}
+
+// IngestStats reports how many incoming messages were embedded and skipped
+// since startup, or nil without ingest filters
+func (s *Service) IngestStats() *IngestStats {
+	if s.ingestFilter == nil {
+		return nil
+	}
+	counts := &s.ingestFilter.counts
+	counts.mu.Lock()
+	defer counts.mu.Unlock()
+
+	stats := &IngestStats{Embedded: counts.outcomes[""], Skipped: make(map[string]int64), ModelCalls: counts.modelCalls}
+	var skipped int64
+	for reason, n := range counts.outcomes {
+		if reason != "" {
+			stats.Skipped[reason] = n
+			skipped += n
+		}
+	}
+	if total := skipped + stats.Embedded; total > 0 {
+		stats.SkipRate = float64(skipped) / float64(total)
+	}
+	return stats
+}
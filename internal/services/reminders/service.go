@@ -0,0 +1,119 @@
+// Package reminders delivers the reminders members set with /remind. Each
+// reminder is a job of the background job runner due at the reminder's
+// time, so reminders survive restarts and show up in /jobs; they are sent
+// through the outbox, which retries them when Discord is down.
+package reminders
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"discord-tars/internal/services/jobs"
+	"discord-tars/internal/services/outbox"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// JobKind names reminder jobs
+const JobKind = "reminder"
+
+const (
+	DefaultMaxAhead = 365 * 24 * time.Hour
+	// MaxTextLength keeps a reminder within a Discord message with its header
+	MaxTextLength = 1500
+)
+
+var (
+	ErrPast   = errors.New("that time has already passed")
+	ErrTooFar = errors.New("that is too far ahead")
+	ErrNoText = errors.New("there is nothing to remind")
+)
+
+// Config bounds how far ahead reminders can be set
+type Config struct {
+	MaxAhead time.Duration
+}
+
+// Reminder is the payload of a reminder job
+type Reminder struct {
+	GuildID   int64     `json:"guild_id,string,omitempty"` // Zero in DMs
+	ChannelID int64     `json:"channel_id,string"`
+	UserID    int64     `json:"user_id,string"`
+	Text      string    `json:"text"`
+	SetAt     time.Time `json:"set_at"`
+}
+
+type Service struct {
+	runner  *jobs.Runner
+	session *discordgo.Session
+	outbox  *outbox.Service
+	cfg     Config
+}
+
+// NewService registers the reminder job kind with runner, which must not
+// have started yet
+func NewService(runner *jobs.Runner, session *discordgo.Session, cfg Config) *Service {
+	if cfg.MaxAhead <= 0 {
+		cfg.MaxAhead = DefaultMaxAhead
+	}
+	s := &Service{runner: runner, session: session, cfg: cfg}
+	runner.Register(JobKind, jobs.Options{Concurrency: 2, MaxAttempts: 5, Timeout: time.Minute}, s.deliver)
+	return s
+}
+
+// SetOutbox delivers reminders through the outbox, so those Discord refuses
+// for good are kept for operators to review
+func (s *Service) SetOutbox(outbox *outbox.Service) {
+	s.outbox = outbox
+}
+
+// Schedule sets a reminder due at a time, returning its job ID
+func (s *Service) Schedule(ctx context.Context, reminder *Reminder, at time.Time) (int64, error) {
+	now := time.Now()
+	switch {
+	case reminder.Text == "":
+		return 0, ErrNoText
+	case !at.After(now):
+		return 0, ErrPast
+	case at.Sub(now) > s.cfg.MaxAhead:
+		return 0, ErrTooFar
+	}
+	if reminder.SetAt.IsZero() {
+		reminder.SetAt = now
+	}
+	return s.runner.EnqueueAt(ctx, JobKind, reminder.GuildID, reminder, at)
+}
+
+// MaxAhead is how far ahead reminders can be set
+func (s *Service) MaxAhead() time.Duration {
+	return s.cfg.MaxAhead
+}
+
+// deliver posts a due reminder in the channel it was set in, pinging only
+// the member who set it
+func (s *Service) deliver(ctx context.Context, payload []byte) error {
+	var reminder Reminder
+	if err := json.Unmarshal(payload, &reminder); err != nil {
+		return fmt.Errorf("failed to decode reminder: %w", err)
+	}
+	userID := strconv.FormatInt(reminder.UserID, 10)
+	msg := &discordgo.MessageSend{
+		Content:         fmt.Sprintf("⏰ <@%s>, you asked me <t:%d:R> to remind you:\n%s", userID, reminder.SetAt.Unix(), reminder.Text),
+		AllowedMentions: &discordgo.MessageAllowedMentions{Users: []string{userID}},
+	}
+	if s.outbox != nil {
+		// Once stored, the outbox retries the send itself
+		if err := s.outbox.SendChannel(ctx, JobKind, reminder.GuildID, reminder.ChannelID, msg); err != nil {
+			return err
+		}
+	} else if _, err := s.session.ChannelMessageSendComplex(strconv.FormatInt(reminder.ChannelID, 10), msg, discordgo.WithContext(ctx)); err != nil {
+		return fmt.Errorf("failed to deliver reminder: %w", err)
+	}
+	log.Printf("⏰ Delivered reminder to user %d in channel %d", reminder.UserID, reminder.ChannelID)
+	return nil
+}
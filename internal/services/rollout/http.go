@@ -0,0 +1,122 @@
+package rollout
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/server"
+)
+
+const (
+	maxRequestBytes = 64 << 10
+	recentRollouts  = 10
+)
+
+// startRequest is the body of a request starting a rollout
+type startRequest struct {
+	Name                string  `json:"name"`
+	Model               string  `json:"model"`
+	ConfidenceThreshold float64 `json:"confidence_threshold"`
+	Instructions        string  `json:"instructions"`
+	Percent             int     `json:"percent"`
+}
+
+// HandleList shows the active rollout with the metrics of both cohorts, and
+// the latest rollouts with why any was rolled back
+func (s *Service) HandleList(w http.ResponseWriter, r *http.Request) {
+	status, err := s.Status(r.Context())
+	if err != nil {
+		server.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	recent, err := s.repo.List(r.Context(), recentRollouts)
+	if err != nil {
+		server.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	server.WriteJSON(w, http.StatusOK, map[string]interface{}{"active": status, "recent": recent})
+}
+
+// HandleStart starts a rollout on a percentage of guilds
+func (s *Service) HandleStart(w http.ResponseWriter, r *http.Request) {
+	var req startRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	rollout := &models.Rollout{
+		Name:                req.Name,
+		Model:               req.Model,
+		ConfidenceThreshold: req.ConfidenceThreshold,
+		Instructions:        req.Instructions,
+		Percent:             req.Percent,
+	}
+	if err := s.Start(r.Context(), rollout); err != nil {
+		writeError(w, err)
+		return
+	}
+	server.WriteJSON(w, http.StatusCreated, rollout)
+}
+
+// HandlePercent widens or narrows the canary cohort of the active rollout
+func (s *Service) HandlePercent(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Percent int `json:"percent"`
+	}
+	if !decode(w, r, &req) {
+		return
+	}
+	rollout, err := s.SetPercent(r.Context(), req.Percent)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	server.WriteJSON(w, http.StatusOK, rollout)
+}
+
+// HandlePromote applies the active rollout to every guild
+func (s *Service) HandlePromote(w http.ResponseWriter, r *http.Request) {
+	rollout, err := s.Promote(r.Context())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	server.WriteJSON(w, http.StatusOK, rollout)
+}
+
+// HandleStop ends the active rollout
+func (s *Service) HandleStop(w http.ResponseWriter, r *http.Request) {
+	rollout, err := s.Stop(r.Context())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	server.WriteJSON(w, http.StatusOK, rollout)
+}
+
+func decode(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxRequestBytes))
+	if err == nil {
+		err = json.Unmarshal(body, v)
+	}
+	if err != nil {
+		server.WriteError(w, http.StatusBadRequest, "invalid JSON body")
+		return false
+	}
+	return true
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrInvalid):
+		server.WriteError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, ErrActive):
+		server.WriteError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, ErrNoActive):
+		server.WriteError(w, http.StatusNotFound, err.Error())
+	default:
+		server.WriteError(w, http.StatusInternalServerError, err.Error())
+	}
+}
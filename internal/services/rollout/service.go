@@ -0,0 +1,415 @@
+// Package rollout ships changes to the default answer settings (the prompt,
+// the confidence threshold, the model) to a percentage of guilds first. It
+// compares the quality signals of the canary guilds with the others' (failed
+// answers, answers withheld as uncertain, thumbs down) and rolls the change
+// back on its own when they regress; operators promote it to every guild
+// once satisfied.
+package rollout
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"strings"
+	"sync"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/repository"
+	openaiService "discord-tars/internal/services/openai"
+	"discord-tars/internal/services/persona"
+)
+
+const (
+	defaultMinSamples           = 30
+	defaultMaxErrorIncrease     = 0.05
+	defaultMaxUncertainIncrease = 0.10
+	defaultMaxNegativeIncrease  = 0.05
+
+	// maxAnswers bounds the answers whose feedback is still counted
+	maxAnswers = 5000
+)
+
+var (
+	ErrActive   = errors.New("a rollout is already active; promote or stop it first")
+	ErrNoActive = errors.New("no rollout is active")
+	ErrInvalid  = errors.New("invalid rollout")
+)
+
+// Signal is a quality signal of an answer
+type Signal int
+
+const (
+	SignalAnswer    Signal = iota // An answer was given
+	SignalError                   // Answering failed
+	SignalUncertain               // The answer was withheld as not backed by the server's content
+)
+
+// Config sets how many answers each cohort needs before the canary is judged,
+// and how much worse than the others its rates may get, as a share of answers
+type Config struct {
+	MinSamples           int
+	MaxErrorIncrease     float64
+	MaxUncertainIncrease float64
+	MaxNegativeIncrease  float64
+	// Alert receives a message when a rollout is rolled back; nil only logs it
+	Alert func(text string)
+}
+
+// Metrics are the quality signals of a cohort since the rollout started or
+// this process did
+type Metrics struct {
+	Answers   int `json:"answers"`
+	Errors    int `json:"errors"`
+	Uncertain int `json:"uncertain"`
+	Positive  int `json:"positive"` // 👍 on answers
+	Negative  int `json:"negative"` // 👎 on answers
+}
+
+func (m Metrics) rate(n int) float64 {
+	if total := m.Answers + m.Errors; total > 0 {
+		return float64(n) / float64(total)
+	}
+	return 0
+}
+
+// Status is the active rollout with the metrics of both cohorts
+type Status struct {
+	Rollout *models.Rollout `json:"rollout"`
+	Canary  Metrics         `json:"canary"`
+	Control Metrics         `json:"control"`
+}
+
+type Service struct {
+	repo *repository.RolloutRepository
+	cfg  Config
+
+	mu      sync.Mutex
+	active  *models.Rollout // nil when none is active
+	loaded  bool
+	canary  Metrics
+	control Metrics
+	answers map[string]bool // Answer message IDs, true for the canary cohort's
+}
+
+func NewService(repo *repository.RolloutRepository, cfg Config) *Service {
+	if cfg.MinSamples <= 0 {
+		cfg.MinSamples = defaultMinSamples
+	}
+	if cfg.MaxErrorIncrease <= 0 {
+		cfg.MaxErrorIncrease = defaultMaxErrorIncrease
+	}
+	if cfg.MaxUncertainIncrease <= 0 {
+		cfg.MaxUncertainIncrease = defaultMaxUncertainIncrease
+	}
+	if cfg.MaxNegativeIncrease <= 0 {
+		cfg.MaxNegativeIncrease = defaultMaxNegativeIncrease
+	}
+	return &Service{
+		repo:    repo,
+		cfg:     cfg,
+		answers: make(map[string]bool),
+	}
+}
+
+// Start begins a rollout on a percentage of guilds; only one is active at a
+// time
+func (s *Service) Start(ctx context.Context, rollout *models.Rollout) error {
+	rollout.Name = strings.TrimSpace(rollout.Name)
+	rollout.Model = strings.TrimSpace(rollout.Model)
+	rollout.Instructions = strings.TrimSpace(rollout.Instructions)
+	switch {
+	case rollout.Name == "":
+		return fmt.Errorf("%w: a name is required", ErrInvalid)
+	case rollout.Percent < 1 || rollout.Percent > 100:
+		return fmt.Errorf("%w: percent must be between 1 and 100", ErrInvalid)
+	case rollout.ConfidenceThreshold < 0 || rollout.ConfidenceThreshold > 1:
+		return fmt.Errorf("%w: confidence_threshold must be between 0 and 1", ErrInvalid)
+	case rollout.Model == "" && rollout.Instructions == "" && rollout.ConfidenceThreshold == 0:
+		return fmt.Errorf("%w: set a model, instructions or a confidence_threshold", ErrInvalid)
+	}
+
+	active, err := s.Active(ctx)
+	if err != nil {
+		return err
+	}
+	if active != nil {
+		return ErrActive
+	}
+	rollout.ID = 0
+	rollout.Status = models.RolloutCanary
+	rollout.Reason = ""
+	if err := s.repo.Save(ctx, rollout); err != nil {
+		return err
+	}
+	s.use(rollout)
+	log.Printf("🐤 Started rollout %q on %d%% of guilds", rollout.Name, rollout.Percent)
+	return nil
+}
+
+// SetPercent widens or narrows the canary cohort of the active rollout;
+// guilds in it stay in it as it widens
+func (s *Service) SetPercent(ctx context.Context, percent int) (*models.Rollout, error) {
+	if percent < 1 || percent > 100 {
+		return nil, fmt.Errorf("%w: percent must be between 1 and 100", ErrInvalid)
+	}
+	return s.update(ctx, func(r *models.Rollout) error {
+		if r.Status != models.RolloutCanary {
+			return fmt.Errorf("%w: rollout %q is already promoted", ErrInvalid, r.Name)
+		}
+		r.Percent = percent
+		return nil
+	})
+}
+
+// Promote applies the active rollout to every guild
+func (s *Service) Promote(ctx context.Context) (*models.Rollout, error) {
+	return s.update(ctx, func(r *models.Rollout) error {
+		r.Status = models.RolloutPromoted
+		r.Percent = 100
+		return nil
+	})
+}
+
+// Stop ends the active rollout; every guild gets the defaults again
+func (s *Service) Stop(ctx context.Context) (*models.Rollout, error) {
+	return s.update(ctx, func(r *models.Rollout) error {
+		r.Status = models.RolloutStopped
+		return nil
+	})
+}
+
+func (s *Service) update(ctx context.Context, change func(r *models.Rollout) error) (*models.Rollout, error) {
+	active, err := s.Active(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if active == nil {
+		return nil, ErrNoActive
+	}
+	updated := *active
+	if err := change(&updated); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Save(ctx, &updated); err != nil {
+		return nil, err
+	}
+	s.use(&updated)
+	log.Printf("🐤 Rollout %q is now %s on %d%% of guilds", updated.Name, updated.Status, updated.Percent)
+	return &updated, nil
+}
+
+// Active returns the active rollout, or nil if there is none
+func (s *Service) Active(ctx context.Context) (*models.Rollout, error) {
+	s.mu.Lock()
+	active, loaded := s.active, s.loaded
+	s.mu.Unlock()
+	if loaded {
+		return active, nil
+	}
+	return s.reload(ctx)
+}
+
+// Status returns the active rollout and the metrics of both cohorts, or nil
+// when none is active
+func (s *Service) Status(ctx context.Context) (*Status, error) {
+	active, err := s.Active(ctx)
+	if err != nil || active == nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return &Status{Rollout: active, Canary: s.canary, Control: s.control}, nil
+}
+
+// Evaluate reloads the active rollout, which another replica may have
+// changed, and rolls it back if the canary cohort's quality regressed
+func (s *Service) Evaluate(ctx context.Context) error {
+	active, err := s.reload(ctx)
+	if err != nil || active == nil || active.Status != models.RolloutCanary {
+		return err
+	}
+
+	s.mu.Lock()
+	reason := s.regression()
+	s.mu.Unlock()
+	if reason == "" {
+		return nil
+	}
+
+	rolledBack := *active
+	rolledBack.Status = models.RolloutRolledBack
+	rolledBack.Reason = reason
+	if err := s.repo.Save(ctx, &rolledBack); err != nil {
+		return err
+	}
+	s.use(nil)
+
+	text := fmt.Sprintf("↩️ **Rollout %q rolled back** from %d%% of guilds: %s", active.Name, active.Percent, reason)
+	if s.cfg.Alert != nil {
+		s.cfg.Alert(text)
+	} else {
+		log.Printf("🚨 %s", text)
+	}
+	return nil
+}
+
+// regression describes how the canary cohort did worse than the others, or
+// is empty while it didn't or there are too few answers to tell
+func (s *Service) regression() string {
+	if s.canary.Answers+s.canary.Errors < s.cfg.MinSamples || s.control.Answers+s.control.Errors < s.cfg.MinSamples {
+		return ""
+	}
+	checks := []struct {
+		name            string
+		canary, control float64
+		max             float64
+	}{
+		{"failed answers", s.canary.rate(s.canary.Errors), s.control.rate(s.control.Errors), s.cfg.MaxErrorIncrease},
+		{"uncertain answers", s.canary.rate(s.canary.Uncertain), s.control.rate(s.control.Uncertain), s.cfg.MaxUncertainIncrease},
+		{"thumbs down", s.canary.rate(s.canary.Negative), s.control.rate(s.control.Negative), s.cfg.MaxNegativeIncrease},
+	}
+	for _, c := range checks {
+		if c.canary-c.control > c.max {
+			return fmt.Sprintf("%s rose to %.0f%% against %.0f%% on the other guilds (%d canary answers)",
+				c.name, 100*c.canary, 100*c.control, s.canary.Answers+s.canary.Errors)
+		}
+	}
+	return ""
+}
+
+func (s *Service) reload(ctx context.Context) (*models.Rollout, error) {
+	active, err := s.repo.Active(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.use(active)
+	return active, nil
+}
+
+// use makes a rollout the active one; metrics start over when it is another
+// rollout or its cohort changed
+func (s *Service) use(active *models.Rollout) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.active == nil || active == nil || s.active.ID != active.ID || s.active.Percent != active.Percent {
+		s.canary, s.control = Metrics{}, Metrics{}
+		clear(s.answers)
+	}
+	s.active = active
+	s.loaded = true
+}
+
+// inCohort tells whether a guild gets a rollout's settings. Guilds are
+// spread by a hash of the rollout and guild, so each rollout picks other
+// guilds and a guild stays in as the percentage grows.
+func inCohort(r *models.Rollout, guildID int64) bool {
+	switch {
+	case r == nil || guildID == 0:
+		return false
+	case r.Status == models.RolloutPromoted:
+		return true
+	case r.Status != models.RolloutCanary:
+		return false
+	}
+	h := fnv.New32a()
+	fmt.Fprintf(h, "%d:%d", r.ID, guildID)
+	return int(h.Sum32()%100) < r.Percent
+}
+
+// assignment returns the active rollout, when a guild gets its settings
+func (s *Service) assignment(guildID int64) *models.Rollout {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if inCohort(s.active, guildID) {
+		return s.active
+	}
+	return nil
+}
+
+// Apply gives AI requests made with the context the settings of the rollout
+// a guild is in: its model and added instructions
+func (s *Service) Apply(ctx context.Context, guildID int64) context.Context {
+	r := s.assignment(guildID)
+	if r == nil {
+		return ctx
+	}
+	return persona.WithInstructions(openaiService.WithModel(ctx, r.Model), r.Instructions)
+}
+
+// ConfidenceThreshold is the threshold a guild's answers are held to: the
+// rollout's, when the guild is in one that sets it, or fallback
+func (s *Service) ConfidenceThreshold(guildID int64, fallback float64) float64 {
+	if r := s.assignment(guildID); r != nil && r.ConfidenceThreshold > 0 {
+		return r.ConfidenceThreshold
+	}
+	return fallback
+}
+
+// Tag names the settings a guild's answers get, to keep answers given under
+// other settings apart; empty for the defaults
+func (s *Service) Tag(guildID int64) string {
+	if r := s.assignment(guildID); r != nil {
+		return fmt.Sprintf("rollout-%d", r.ID)
+	}
+	return ""
+}
+
+// Record counts a quality signal of a guild's answer
+func (s *Service) Record(guildID int64, signal Signal) {
+	if guildID == 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.active == nil || s.active.Status != models.RolloutCanary {
+		return
+	}
+	m := &s.control
+	if inCohort(s.active, guildID) {
+		m = &s.canary
+	}
+	switch signal {
+	case SignalAnswer:
+		m.Answers++
+	case SignalError:
+		m.Errors++
+	case SignalUncertain:
+		m.Uncertain++
+	}
+}
+
+// Answered remembers the message an answer was sent as, so reactions to it
+// count as feedback on the guild's cohort
+func (s *Service) Answered(guildID int64, messageID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.active == nil || s.active.Status != models.RolloutCanary {
+		return
+	}
+	if len(s.answers) >= maxAnswers {
+		clear(s.answers)
+	}
+	s.answers[messageID] = inCohort(s.active, guildID)
+}
+
+// Feedback counts a 👍 or 👎 on an answer; other reactions are ignored
+func (s *Service) Feedback(messageID, emoji string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	canary, ok := s.answers[messageID]
+	if !ok {
+		return
+	}
+	m := &s.control
+	if canary {
+		m = &s.canary
+	}
+	switch emoji {
+	case "👍":
+		m.Positive++
+	case "👎":
+		m.Negative++
+	}
+}
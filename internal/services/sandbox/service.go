@@ -0,0 +1,101 @@
+// Package sandbox keeps track of the guilds where the bot runs in sandbox
+// mode: it handles events and produces its replies as usual, but writes them
+// to the logs and a debug channel instead of replying publicly, so prompt
+// and retrieval changes can be tried against live traffic safely.
+package sandbox
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/repository"
+)
+
+// Config puts the whole instance in sandbox mode, as for a staging bot
+// watching live guilds
+type Config struct {
+	All       bool
+	ChannelID int64 // Debug channel of an instance-wide sandbox; 0 logs replies only
+}
+
+type Service struct {
+	repo *repository.SandboxRepository
+	cfg  Config
+
+	mu     sync.Mutex
+	guilds map[int64]models.SandboxGuild // Loaded on first use
+}
+
+func NewService(repo *repository.SandboxRepository, cfg Config) *Service {
+	return &Service{repo: repo, cfg: cfg}
+}
+
+// Enable puts a guild in sandbox mode; its replies go to channelID, or only
+// to the logs when it is 0
+func (s *Service) Enable(ctx context.Context, guildID, channelID, enabledBy int64) error {
+	if err := s.repo.Save(ctx, &models.SandboxGuild{GuildID: guildID, ChannelID: channelID, EnabledBy: enabledBy}); err != nil {
+		return err
+	}
+	s.invalidate()
+	return nil
+}
+
+// Disable lets a guild's replies out again, reporting whether it was in
+// sandbox mode
+func (s *Service) Disable(ctx context.Context, guildID int64) (bool, error) {
+	disabled, err := s.repo.Delete(ctx, guildID)
+	if err != nil {
+		return false, err
+	}
+	s.invalidate()
+	return disabled, nil
+}
+
+// Instance reports whether the whole instance is in sandbox mode, which
+// guilds can't leave
+func (s *Service) Instance() bool {
+	return s.cfg.All
+}
+
+// Get returns a guild's sandbox settings, or nil when its replies are sent
+// as usual; errors count as not sandboxed
+func (s *Service) Get(ctx context.Context, guildID int64) *models.SandboxGuild {
+	if guildID == 0 {
+		return nil
+	}
+	if s.cfg.All {
+		return &models.SandboxGuild{GuildID: guildID, ChannelID: s.cfg.ChannelID}
+	}
+
+	s.mu.Lock()
+	cached := s.guilds
+	s.mu.Unlock()
+
+	if cached == nil {
+		guilds, err := s.repo.List(ctx)
+		if err != nil {
+			log.Printf("⚠️ Failed to load sandbox guilds: %v", err)
+			return nil
+		}
+		cached = make(map[int64]models.SandboxGuild, len(guilds))
+		for _, g := range guilds {
+			cached[g.GuildID] = g
+		}
+
+		s.mu.Lock()
+		s.guilds = cached
+		s.mu.Unlock()
+	}
+	if g, ok := cached[guildID]; ok {
+		return &g
+	}
+	return nil
+}
+
+func (s *Service) invalidate() {
+	s.mu.Lock()
+	s.guilds = nil
+	s.mu.Unlock()
+}
@@ -0,0 +1,136 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// JobFunc is the unit of work executed by the scheduler
+type JobFunc func(ctx context.Context) error
+
+type job struct {
+	name     string
+	interval time.Duration
+	timeout  time.Duration
+	run      JobFunc
+	local    bool // Runs on every replica, not only the leader
+}
+
+// Scheduler runs registered jobs periodically in background goroutines
+type Scheduler struct {
+	jobs     []job
+	isLeader func() bool
+	mu       sync.Mutex
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+	started  bool
+}
+
+func NewScheduler() *Scheduler {
+	return &Scheduler{}
+}
+
+// SetLeaderCheck skips the jobs registered with Register while isLeader
+// returns false, so replicas sharing a database don't run them twice
+func (s *Scheduler) SetLeaderCheck(isLeader func() bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.isLeader = isLeader
+}
+
+// Register adds a job that runs every interval. Jobs registered after Start are ignored.
+func (s *Scheduler) Register(name string, interval time.Duration, run JobFunc) {
+	s.register(job{name: name, interval: interval, timeout: interval, run: run})
+}
+
+// RegisterLocal adds a job that refreshes state of this process, so it runs
+// on every replica whether or not it leads
+func (s *Scheduler) RegisterLocal(name string, interval time.Duration, run JobFunc) {
+	s.register(job{name: name, interval: interval, timeout: interval, run: run, local: true})
+}
+
+func (s *Scheduler) register(j job) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.started {
+		log.Printf("⚠️ Scheduler already started, ignoring job %s", j.name)
+		return
+	}
+
+	s.jobs = append(s.jobs, j)
+	log.Printf("🗓️ Registered scheduled job %s (every %s)", j.name, j.interval)
+}
+
+// Start launches one goroutine per registered job
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.started {
+		return
+	}
+	s.started = true
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+
+	for _, j := range s.jobs {
+		s.wg.Add(1)
+		go s.loop(ctx, j)
+	}
+	log.Printf("✅ Scheduler started with %d jobs", len(s.jobs))
+}
+
+// Stop cancels running jobs and waits for them to return
+func (s *Scheduler) Stop() {
+	s.mu.Lock()
+	cancel := s.cancel
+	s.mu.Unlock()
+
+	if cancel == nil {
+		return
+	}
+	cancel()
+	s.wg.Wait()
+	log.Println("👋 Scheduler stopped")
+}
+
+func (s *Scheduler) loop(ctx context.Context, j job) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if !j.local && s.isLeader != nil && !s.isLeader() {
+				continue
+			}
+			s.runOnce(ctx, j)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (s *Scheduler) runOnce(ctx context.Context, j job) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("❌ Scheduled job %s panicked: %v", j.name, r)
+		}
+	}()
+
+	jobCtx, cancel := context.WithTimeout(ctx, j.timeout)
+	defer cancel()
+
+	start := time.Now()
+	if err := j.run(jobCtx); err != nil {
+		log.Printf("❌ Scheduled job %s failed after %s: %v", j.name, time.Since(start), err)
+		return
+	}
+	log.Printf("✅ Scheduled job %s completed in %s", j.name, time.Since(start))
+}
@@ -0,0 +1,102 @@
+package slo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"discord-tars/internal/repository/postgres"
+)
+
+// maxAlertLength is Discord's message limit, the tighter of the webhook formats
+const maxAlertLength = 2000
+
+// alerter posts alerts to an operator webhook
+type alerter struct {
+	webhookURL string
+	client     *http.Client
+}
+
+func newAlerter(webhookURL string) *alerter {
+	return &alerter{webhookURL: webhookURL, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// ReportSlowQuery alerts about a vector search slower than the threshold,
+// with its query plan; it is meant as the database's slow query hook
+func (t *Tracker) ReportSlowQuery(q postgres.SlowQuery) {
+	t.mu.Lock()
+	ok := t.shouldAlert("query:"+q.Name, time.Now())
+	t.mu.Unlock()
+	if ok {
+		t.alerter.slowQuery(q)
+	}
+}
+
+// Alert sends an alert raised elsewhere, such as a rolled back rollout, to
+// the operator webhook
+func (t *Tracker) Alert(text string) {
+	go t.alerter.send(text)
+}
+
+func (a *alerter) sloBreach(stats CommandStats) {
+	a.send(fmt.Sprintf("⏱️ **Latency SLO breached**: `%s` in guild %d\np95 %s over the last hour, objective %s (p50 %s, p99 %s, %d calls)",
+		stats.Command, stats.GuildID, round(stats.P95), round(stats.Target), round(stats.P50), round(stats.P99), stats.Count))
+}
+
+func (a *alerter) slowQuery(q postgres.SlowQuery) {
+	header := fmt.Sprintf("🐢 **Slow %s**: %s", q.Name, round(q.Elapsed))
+	if q.GuildID != 0 {
+		header += fmt.Sprintf(" in guild %d", q.GuildID)
+	}
+	plan := q.Plan
+	if plan == "" {
+		plan = "(query plan unavailable)\n" + q.SQL
+	}
+	// Keep the top of the plan, where the scan choice shows
+	room := maxAlertLength - len(header) - len("\n```\n```")
+	if len(plan) > room {
+		plan = plan[:strings.LastIndex(plan[:room], "\n")+1] + "…"
+	}
+	a.send(header + "\n```\n" + plan + "```")
+}
+
+// send posts an alert; the payload works with both Discord and Slack webhooks
+func (a *alerter) send(text string) {
+	log.Printf("🚨 %s", text)
+	if a.webhookURL == "" {
+		return
+	}
+
+	body, err := json.Marshal(map[string]string{"content": text, "text": text})
+	if err != nil {
+		log.Printf("❌ Failed to encode alert: %v", err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.webhookURL, bytes.NewReader(body))
+	if err != nil {
+		log.Printf("❌ Failed to create alert request: %v", err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		log.Printf("❌ Failed to send alert: %v", err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		log.Printf("❌ Alert webhook returned status %d", resp.StatusCode)
+	}
+}
+
+func round(d time.Duration) time.Duration {
+	return d.Round(time.Millisecond)
+}
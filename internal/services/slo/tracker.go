@@ -0,0 +1,242 @@
+// Package slo tracks command latency per guild and alerts operators when a
+// command misses its latency objective or a vector search is slow.
+package slo
+
+import (
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"discord-tars/internal/server"
+)
+
+const (
+	// DefaultTarget is the p95 objective for commands without their own
+	DefaultTarget = 10 * time.Second
+
+	window     = time.Hour
+	maxSamples = 1000
+	minSamples = 20
+)
+
+// Config sets the latency objectives and where alerts go
+type Config struct {
+	// Targets are p95 objectives by command name; others use DefaultTarget
+	Targets       map[string]time.Duration
+	DefaultTarget time.Duration
+	// WebhookURL receives alerts; without it they are only logged
+	WebhookURL string
+	// Cooldown is the minimum time between two alerts about the same thing
+	Cooldown time.Duration
+}
+
+// Tracker keeps the last hour of latencies of each command in each guild
+type Tracker struct {
+	cfg     Config
+	alerter *alerter
+
+	mu      sync.Mutex
+	samples map[series][]sample
+	alerted map[string]time.Time
+}
+
+type series struct {
+	guildID int64
+	command string
+}
+
+type sample struct {
+	at      time.Time
+	elapsed time.Duration
+}
+
+// CommandStats are the latency percentiles of one command over the last hour
+type CommandStats struct {
+	GuildID int64  `json:"guild_id,string"`
+	Command string `json:"command"`
+	Count   int    `json:"count"`
+	// LastMinute is how many of them ran in the last minute, the live rate
+	LastMinute int           `json:"last_minute"`
+	P50        time.Duration `json:"-"`
+	P95        time.Duration `json:"-"`
+	P99        time.Duration `json:"-"`
+	Target     time.Duration `json:"-"`
+}
+
+func NewTracker(cfg Config) *Tracker {
+	if cfg.DefaultTarget <= 0 {
+		cfg.DefaultTarget = DefaultTarget
+	}
+	if cfg.Cooldown <= 0 {
+		cfg.Cooldown = 30 * time.Minute
+	}
+	return &Tracker{
+		cfg:     cfg,
+		alerter: newAlerter(cfg.WebhookURL),
+		samples: make(map[series][]sample),
+		alerted: make(map[string]time.Time),
+	}
+}
+
+// Observe records how long a command took end to end, and alerts when its
+// p95 over the last hour exceeds the command's objective
+func (t *Tracker) Observe(guildID int64, command string, elapsed time.Duration) {
+	now := time.Now()
+	key := series{guildID: guildID, command: command}
+
+	t.mu.Lock()
+	samples := prune(append(t.samples[key], sample{at: now, elapsed: elapsed}), now)
+	t.samples[key] = samples
+	stats := t.stats(key, samples, now)
+	breached := stats.Count >= minSamples && stats.P95 > stats.Target && t.shouldAlert("slo:"+command+":"+strconv.FormatInt(guildID, 10), now)
+	t.mu.Unlock()
+
+	if breached {
+		go t.alerter.sloBreach(stats)
+	}
+}
+
+// Stats returns the latency percentiles of every command used in a guild
+// over the last hour, or of all guilds when guildID is 0
+func (t *Tracker) Stats(guildID int64) []CommandStats {
+	now := time.Now()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var all []CommandStats
+	for key, samples := range t.samples {
+		samples = prune(samples, now)
+		if len(samples) == 0 {
+			delete(t.samples, key)
+			continue
+		}
+		t.samples[key] = samples
+		if guildID == 0 || key.guildID == guildID {
+			all = append(all, t.stats(key, samples, now))
+		}
+	}
+	sort.Slice(all, func(a, b int) bool {
+		if all[a].GuildID != all[b].GuildID {
+			return all[a].GuildID < all[b].GuildID
+		}
+		return all[a].Command < all[b].Command
+	})
+	return all
+}
+
+// HandleStats serves latency percentiles as JSON, for one guild with ?guild_id= or for all of them
+func (t *Tracker) HandleStats(w http.ResponseWriter, r *http.Request) {
+	var guildID int64
+	if raw := r.URL.Query().Get("guild_id"); raw != "" {
+		id, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			server.WriteError(w, http.StatusBadRequest, "invalid guild_id")
+			return
+		}
+		guildID = id
+	}
+
+	type commandStats struct {
+		CommandStats
+		P50    int64 `json:"p50_ms"`
+		P95    int64 `json:"p95_ms"`
+		P99    int64 `json:"p99_ms"`
+		Target int64 `json:"target_ms"`
+		Breach bool  `json:"breach"`
+	}
+	stats := t.Stats(guildID)
+	response := make([]commandStats, 0, len(stats))
+	for _, s := range stats {
+		response = append(response, commandStats{
+			CommandStats: s,
+			P50:          s.P50.Milliseconds(),
+			P95:          s.P95.Milliseconds(),
+			P99:          s.P99.Milliseconds(),
+			Target:       s.Target.Milliseconds(),
+			Breach:       s.Count >= minSamples && s.P95 > s.Target,
+		})
+	}
+	server.WriteJSON(w, http.StatusOK, map[string]interface{}{"window": window.String(), "commands": response})
+}
+
+// target is a command's p95 objective; subcommands such as "digest list"
+// fall back to their command's
+func (t *Tracker) target(command string) time.Duration {
+	if target, ok := t.cfg.Targets[command]; ok {
+		return target
+	}
+	if name, _, ok := strings.Cut(command, " "); ok {
+		if target, ok := t.cfg.Targets[name]; ok {
+			return target
+		}
+	}
+	return t.cfg.DefaultTarget
+}
+
+func (t *Tracker) stats(key series, samples []sample, now time.Time) CommandStats {
+	sorted := make([]time.Duration, len(samples))
+	lastMinute := 0
+	for n, s := range samples {
+		sorted[n] = s.elapsed
+		if now.Sub(s.at) <= time.Minute {
+			lastMinute++
+		}
+	}
+	sort.Slice(sorted, func(a, b int) bool { return sorted[a] < sorted[b] })
+	return CommandStats{
+		GuildID:    key.guildID,
+		Command:    key.command,
+		Count:      len(sorted),
+		LastMinute: lastMinute,
+		P50:        percentile(sorted, 50),
+		P95:        percentile(sorted, 95),
+		P99:        percentile(sorted, 99),
+		Target:     t.target(key.command),
+	}
+}
+
+// shouldAlert reports whether nothing was alerted about key within the
+// cooldown, and starts a new one if so; the caller holds t.mu
+func (t *Tracker) shouldAlert(key string, now time.Time) bool {
+	if last, ok := t.alerted[key]; ok && now.Sub(last) < t.cfg.Cooldown {
+		return false
+	}
+	for k, last := range t.alerted {
+		if now.Sub(last) >= t.cfg.Cooldown {
+			delete(t.alerted, k)
+		}
+	}
+	t.alerted[key] = now
+	return true
+}
+
+// prune drops samples older than the window and the oldest beyond maxSamples
+func prune(samples []sample, now time.Time) []sample {
+	start := len(samples) - maxSamples
+	if start < 0 {
+		start = 0
+	}
+	for start < len(samples) && now.Sub(samples[start].at) > window {
+		start++
+	}
+	if start == 0 {
+		return samples
+	}
+	return append(samples[:0:0], samples[start:]...)
+}
+
+// percentile picks the nearest-rank percentile p of sorted durations
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
@@ -0,0 +1,309 @@
+package standup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+
+	"discord-tars/internal/interfaces"
+	"discord-tars/internal/models"
+	"discord-tars/internal/repository"
+	"discord-tars/internal/services/outbox"
+	"discord-tars/internal/tenant"
+)
+
+const summaryMaxTokens = 700
+
+const summarySystemPrompt = `You are T.A.R.S, formatting an async team standup.
+Given each member's update, write a consolidated summary in Markdown with sections:
+**Done**, **Doing**, **Blockers**. Attribute items to members by name.
+Finish with one dry, short remark in the T.A.R.S style. Do not invent work that was not reported.`
+
+var (
+	ErrNoTeam        = errors.New("no standup team is configured for this channel")
+	ErrSessionOpen   = errors.New("a standup is already running in this channel")
+	ErrNoOpenSession = errors.New("no standup is running in this channel")
+	ErrNotMember     = errors.New("you are not a member of this standup team")
+)
+
+type Service struct {
+	aiService   interfaces.AIService
+	standupRepo *repository.StandupRepository
+	session     *discordgo.Session
+	outbox      *outbox.Service
+}
+
+func NewService(aiService interfaces.AIService, standupRepo *repository.StandupRepository, session *discordgo.Session) *Service {
+	return &Service{
+		aiService:   aiService,
+		standupRepo: standupRepo,
+		session:     session,
+	}
+}
+
+// SetOutbox posts standup reminders through the outbox, retrying failed sends
+func (s *Service) SetOutbox(outbox *outbox.Service) {
+	s.outbox = outbox
+}
+
+// Status describes the progress of an open standup
+type Status struct {
+	Session   *models.StandupSession
+	Responded []string
+	Missing   []string
+}
+
+// ConfigureTeam saves the members and name of a channel's standup team
+func (s *Service) ConfigureTeam(ctx context.Context, guildID, channelID int64, name string, memberIDs []string) (*models.StandupTeam, error) {
+	if len(memberIDs) == 0 {
+		return nil, fmt.Errorf("a standup team needs at least one member")
+	}
+	team := &models.StandupTeam{
+		GuildID:   guildID,
+		ChannelID: channelID,
+		Name:      name,
+		MemberIDs: memberIDs,
+	}
+	if err := s.standupRepo.SaveTeam(ctx, team); err != nil {
+		return nil, err
+	}
+	return team, nil
+}
+
+// Start opens a collection window and pings the team
+func (s *Service) Start(ctx context.Context, channelID, startedBy int64, window time.Duration) (*models.StandupSession, error) {
+	team, err := s.standupRepo.GetTeamByChannel(ctx, channelID)
+	if err != nil {
+		return nil, err
+	}
+	if team == nil {
+		return nil, ErrNoTeam
+	}
+
+	open, err := s.standupRepo.GetOpenSession(ctx, team.ID)
+	if err != nil {
+		return nil, err
+	}
+	if open != nil {
+		return nil, ErrSessionOpen
+	}
+
+	now := time.Now()
+	session := &models.StandupSession{
+		TeamID:    team.ID,
+		StartedBy: startedBy,
+		StartedAt: now,
+		EndsAt:    now.Add(window),
+		Team:      *team,
+	}
+	if err := s.standupRepo.CreateSession(ctx, session); err != nil {
+		return nil, err
+	}
+
+	log.Printf("📋 Started standup session %d for team %s (%d members)", session.ID, team.Name, len(team.MemberIDs))
+	return session, nil
+}
+
+// SubmitUpdate records a member's update in the channel's open session
+func (s *Service) SubmitUpdate(ctx context.Context, channelID int64, userID, username, content string) error {
+	session, err := s.openSession(ctx, channelID)
+	if err != nil {
+		return err
+	}
+	if !isMember(session.Team, userID) {
+		return ErrNotMember
+	}
+
+	uid, _ := strconv.ParseInt(userID, 10, 64)
+	return s.standupRepo.SaveUpdate(ctx, &models.StandupUpdate{
+		SessionID: session.ID,
+		UserID:    uid,
+		Username:  username,
+		Content:   content,
+	})
+}
+
+// GetStatus reports who has and hasn't answered the channel's open standup
+func (s *Service) GetStatus(ctx context.Context, channelID int64) (*Status, error) {
+	session, err := s.openSession(ctx, channelID)
+	if err != nil {
+		return nil, err
+	}
+	return s.status(ctx, session)
+}
+
+// Summarize closes the channel's open standup and returns the posted summary
+func (s *Service) Summarize(ctx context.Context, channelID int64) (string, error) {
+	session, err := s.openSession(ctx, channelID)
+	if err != nil {
+		return "", err
+	}
+	return s.close(ctx, session)
+}
+
+// ProcessDue is the scheduler job: it nags missing members halfway through the
+// window and posts the summary once the window has ended
+func (s *Service) ProcessDue(ctx context.Context) error {
+	sessions, err := s.standupRepo.ListOpenSessions(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for i := range sessions {
+		session := &sessions[i]
+		if !now.Before(session.EndsAt) {
+			if _, err := s.close(ctx, session); err != nil {
+				log.Printf("❌ Failed to close standup session %d: %v", session.ID, err)
+			}
+			continue
+		}
+
+		halfway := session.StartedAt.Add(session.EndsAt.Sub(session.StartedAt) / 2)
+		if session.NaggedAt == nil && now.After(halfway) {
+			if err := s.nag(ctx, session); err != nil {
+				log.Printf("❌ Failed to nag standup session %d: %v", session.ID, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Service) nag(ctx context.Context, session *models.StandupSession) error {
+	status, err := s.status(ctx, session)
+	if err != nil {
+		return err
+	}
+
+	if len(status.Missing) > 0 {
+		mentions := make([]string, 0, len(status.Missing))
+		for _, id := range status.Missing {
+			mentions = append(mentions, "<@"+id+">")
+		}
+		content := fmt.Sprintf("⏰ Standup reminder for **%s**: %s — please post your update with `/standup update` before <t:%d:t>.",
+			session.Team.Name, strings.Join(mentions, " "), session.EndsAt.Unix())
+		if s.outbox != nil {
+			err = s.outbox.SendChannel(ctx, "standup-reminder", session.Team.GuildID, session.Team.ChannelID, &discordgo.MessageSend{Content: content})
+		} else {
+			_, err = s.session.ChannelMessageSend(strconv.FormatInt(session.Team.ChannelID, 10), content)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to send standup reminder: %w", err)
+		}
+	}
+
+	return s.standupRepo.MarkNagged(ctx, session.ID, time.Now())
+}
+
+func (s *Service) close(ctx context.Context, session *models.StandupSession) (string, error) {
+	ctx = tenant.WithGuild(ctx, session.Team.GuildID)
+	updates, err := s.standupRepo.ListUpdates(ctx, session.ID)
+	if err != nil {
+		return "", err
+	}
+	status, err := s.status(ctx, session)
+	if err != nil {
+		return "", err
+	}
+
+	var summary string
+	if len(updates) == 0 {
+		summary = "Nobody posted an update. I'll assume everyone was too busy working. Optimistic setting: 10%."
+	} else {
+		var prompt strings.Builder
+		for _, u := range updates {
+			prompt.WriteString(fmt.Sprintf("### %s\n%s\n\n", u.Username, u.Content))
+		}
+		summary, err = s.aiService.Complete(ctx, summarySystemPrompt, prompt.String(), summaryMaxTokens)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate standup summary: %w", err)
+		}
+	}
+
+	content := fmt.Sprintf("📋 **Standup summary — %s** (%d/%d updates)\n\n%s",
+		session.Team.Name, len(status.Responded), len(session.Team.MemberIDs), summary)
+	if len(status.Missing) > 0 {
+		content += fmt.Sprintf("\n\n*No update from %d member(s).*", len(status.Missing))
+	}
+	content = truncate(content, 2000)
+
+	if _, err := s.session.ChannelMessageSend(strconv.FormatInt(session.Team.ChannelID, 10), content); err != nil {
+		return "", fmt.Errorf("failed to post standup summary: %w", err)
+	}
+
+	if err := s.standupRepo.CloseSession(ctx, session.ID, time.Now()); err != nil {
+		return "", fmt.Errorf("failed to close standup session: %w", err)
+	}
+
+	log.Printf("✅ Closed standup session %d with %d updates", session.ID, len(updates))
+	return content, nil
+}
+
+func (s *Service) status(ctx context.Context, session *models.StandupSession) (*Status, error) {
+	updates, err := s.standupRepo.ListUpdates(ctx, session.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	answered := make(map[string]bool, len(updates))
+	for _, u := range updates {
+		answered[strconv.FormatInt(u.UserID, 10)] = true
+	}
+
+	status := &Status{Session: session}
+	for _, id := range session.Team.MemberIDs {
+		if answered[id] {
+			status.Responded = append(status.Responded, id)
+		} else {
+			status.Missing = append(status.Missing, id)
+		}
+	}
+	return status, nil
+}
+
+func (s *Service) openSession(ctx context.Context, channelID int64) (*models.StandupSession, error) {
+	team, err := s.standupRepo.GetTeamByChannel(ctx, channelID)
+	if err != nil {
+		return nil, err
+	}
+	if team == nil {
+		return nil, ErrNoTeam
+	}
+
+	session, err := s.standupRepo.GetOpenSession(ctx, team.ID)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil {
+		return nil, ErrNoOpenSession
+	}
+	return session, nil
+}
+
+func isMember(team models.StandupTeam, userID string) bool {
+	for _, id := range team.MemberIDs {
+		if id == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// truncate cuts s to at most max bytes on a rune boundary, marking the cut
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	cut := max - len("…")
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + "…"
+}
@@ -0,0 +1,284 @@
+// Package starters posts AI-written conversation starters and "question of
+// the day" posts on a schedule, remembering what was asked so it doesn't ask
+// it again.
+package starters
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"discord-tars/internal/interfaces"
+	"discord-tars/internal/models"
+	"discord-tars/internal/repository"
+	"discord-tars/internal/sanitize"
+	"discord-tars/internal/services/outbox"
+	"discord-tars/internal/tenant"
+)
+
+const (
+	// MaxTopicsLength caps the topic hints of a schedule
+	MaxTopicsLength = 300
+
+	// recentPosts is how many earlier starters the AI is told not to repeat
+	recentPosts = 30
+	// duplicateSimilarity is how close (cosine) a new starter may come to an
+	// earlier one before it counts as a repeat
+	duplicateSimilarity = 0.9
+	// maxAttempts is how many starters are written before giving up on a
+	// fresh one
+	maxAttempts     = 3
+	starterTokens   = 200
+	maxStarterRunes = 600
+)
+
+const starterSystemPrompt = `You write posts that get a Discord community talking.
+Write exactly one post: a single engaging, open-ended prompt that anyone can answer in a sentence or two, friendly and inclusive, never divisive, political or personal.
+Keep it under 300 characters, don't tag anyone, and reply with the post only, without quotes or a preamble.`
+
+var (
+	ErrInvalidKind      = errors.New("unknown kind of starter")
+	ErrInvalidFrequency = errors.New("unknown frequency")
+	// ErrNoFreshStarter is returned when every starter written repeats one
+	// already posted
+	ErrNoFreshStarter = errors.New("couldn't write a starter that wasn't posted before")
+)
+
+type Service struct {
+	aiService interfaces.AIService
+	repo      *repository.StarterRepository
+	session   *discordgo.Session
+	outbox    *outbox.Service
+}
+
+func NewService(aiService interfaces.AIService, repo *repository.StarterRepository, session *discordgo.Session) *Service {
+	return &Service{aiService: aiService, repo: repo, session: session}
+}
+
+// SetOutbox posts scheduled starters through the outbox, retrying failed sends
+func (s *Service) SetOutbox(outbox *outbox.Service) {
+	s.outbox = outbox
+}
+
+// Configure validates and stores a channel's schedule
+func (s *Service) Configure(ctx context.Context, schedule *models.StarterSchedule) error {
+	switch schedule.Kind {
+	case models.StarterKindStarter, models.StarterKindQuestion:
+	default:
+		return ErrInvalidKind
+	}
+	switch schedule.Frequency {
+	case models.StarterDaily, models.StarterWeekdays, models.StarterWeekly:
+	default:
+		return ErrInvalidFrequency
+	}
+	if schedule.Hour < 0 || schedule.Hour > 23 {
+		return fmt.Errorf("hour must be between 0 and 23")
+	}
+	if schedule.Weekday < 0 || schedule.Weekday > 6 {
+		return fmt.Errorf("weekday must be between 0 and 6")
+	}
+	schedule.Topics = strings.TrimSpace(schedule.Topics)
+	if len(schedule.Topics) > MaxTopicsLength {
+		return fmt.Errorf("topic hints must be at most %d characters", MaxTopicsLength)
+	}
+	if schedule.Timezone == "" {
+		schedule.Timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(schedule.Timezone); err != nil {
+		return fmt.Errorf("unknown timezone %q", schedule.Timezone)
+	}
+	return s.repo.SaveSchedule(ctx, schedule)
+}
+
+// Schedule returns a channel's schedule, or nil if it has none
+func (s *Service) Schedule(ctx context.Context, channelID int64) (*models.StarterSchedule, error) {
+	return s.repo.GetSchedule(ctx, channelID)
+}
+
+// Remove stops posting starters in a channel, reporting whether it had a
+// schedule
+func (s *Service) Remove(ctx context.Context, guildID, channelID int64) (bool, error) {
+	return s.repo.DeleteSchedule(ctx, guildID, channelID)
+}
+
+// List returns a guild's schedules
+func (s *Service) List(ctx context.Context, guildID int64) ([]models.StarterSchedule, error) {
+	return s.repo.ListSchedules(ctx, guildID)
+}
+
+// PostDue is the scheduler job: it posts every starter whose local time has
+// come
+func (s *Service) PostDue(ctx context.Context) error {
+	schedules, err := s.repo.ListAllSchedules(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for n := range schedules {
+		schedule := &schedules[n]
+		if !Due(schedule, now) {
+			continue
+		}
+		if _, err := s.Post(ctx, schedule); err != nil {
+			log.Printf("❌ Failed to post the starter of channel %d: %v", schedule.ChannelID, err)
+		}
+		// A failed starter waits for its next slot rather than retrying every
+		// check
+		if err := s.repo.MarkPosted(ctx, schedule.ID, now); err != nil {
+			log.Printf("❌ Failed to mark the starter of channel %d as posted: %v", schedule.ChannelID, err)
+		}
+	}
+	return nil
+}
+
+// Post writes a fresh starter for a schedule and posts it in its channel,
+// returning what was posted
+func (s *Service) Post(ctx context.Context, schedule *models.StarterSchedule) (string, error) {
+	ctx = tenant.WithGuild(ctx, schedule.GuildID)
+	text, embedding, err := s.write(ctx, schedule)
+	if err != nil {
+		return "", err
+	}
+
+	content := "💬 " + text
+	if schedule.Kind == models.StarterKindQuestion {
+		content = "❓ **Question of the day**\n" + text
+	}
+	post := &discordgo.MessageSend{
+		Content:         content,
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	}
+	if s.outbox != nil {
+		err = s.outbox.SendChannel(ctx, "conversation-starter", schedule.GuildID, schedule.ChannelID, post)
+	} else {
+		_, err = s.session.ChannelMessageSendComplex(strconv.FormatInt(schedule.ChannelID, 10), post)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to post starter: %w", err)
+	}
+
+	if err := s.repo.SavePost(ctx, &models.StarterPost{
+		ScheduleID: schedule.ID,
+		GuildID:    schedule.GuildID,
+		ChannelID:  schedule.ChannelID,
+		Text:       text,
+	}, embedding); err != nil {
+		log.Printf("⚠️ Starter posted in channel %d won't be remembered: %v", schedule.ChannelID, err)
+	}
+	log.Printf("💬 Posted a %s in channel %d of guild %d", schedule.Kind, schedule.ChannelID, schedule.GuildID)
+	return text, nil
+}
+
+// write asks the AI for a starter the guild hasn't seen: the latest ones are
+// listed in the prompt, and older ones are caught by their embedding
+func (s *Service) write(ctx context.Context, schedule *models.StarterSchedule) (string, []float32, error) {
+	recent, err := s.repo.RecentPosts(ctx, schedule.GuildID, recentPosts)
+	if err != nil {
+		return "", nil, err
+	}
+	avoid := make([]string, 0, len(recent)+maxAttempts)
+	for _, post := range recent {
+		avoid = append(avoid, post.Text)
+	}
+
+	for range maxAttempts {
+		reply, err := s.aiService.Complete(ctx, starterSystemPrompt, starterPrompt(schedule, avoid), starterTokens)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to write starter: %w", err)
+		}
+		text := cleanStarter(reply)
+		if text == "" {
+			continue
+		}
+
+		embedding, err := s.aiService.GenerateEmbedding(ctx, text)
+		if err != nil {
+			// The prompt already steers away from recent starters
+			log.Printf("⚠️ Failed to embed starter, posting it without the repeat check: %v", err)
+			return text, nil, nil
+		}
+		earlier, similarity, err := s.repo.ClosestPost(ctx, schedule.GuildID, embedding)
+		if err != nil {
+			return "", nil, err
+		}
+		if earlier == "" || similarity < duplicateSimilarity {
+			return text, embedding, nil
+		}
+		log.Printf("🔁 Starter for channel %d repeats an earlier one (%.2f similar), writing another", schedule.ChannelID, similarity)
+		avoid = append(avoid, text)
+	}
+	return "", nil, ErrNoFreshStarter
+}
+
+// starterPrompt describes the starter to write and what was already asked
+func starterPrompt(schedule *models.StarterSchedule, avoid []string) string {
+	var sb strings.Builder
+	if schedule.Kind == models.StarterKindQuestion {
+		sb.WriteString("Write a \"question of the day\": one question people will enjoy answering.\n")
+	} else {
+		sb.WriteString("Write a conversation starter: an open prompt, opinion or \"would you rather\" that invites replies.\n")
+	}
+	if schedule.Topics != "" {
+		fmt.Fprintf(&sb, "Draw on these topics, without always picking the first one: %s\n", sanitize.Context(schedule.Topics))
+	}
+	if len(avoid) > 0 {
+		sb.WriteString("\nThese were already posted; don't repeat or rephrase any of them:\n")
+		for _, text := range avoid {
+			sb.WriteString("- " + strings.Join(strings.Fields(text), " ") + "\n")
+		}
+	}
+	return sb.String()
+}
+
+// cleanStarter trims the AI's reply to the post itself
+func cleanStarter(reply string) string {
+	text := strings.Trim(strings.TrimSpace(sanitize.Output(reply)), `"“”`)
+	if runes := []rune(text); len(runes) > maxStarterRunes {
+		text = string(runes[:maxStarterRunes-1]) + "…"
+	}
+	return strings.TrimSpace(text)
+}
+
+// Due reports whether a schedule should post at now: at the first check after
+// its local hour, on the days it posts. New schedules wait for their first
+// slot.
+func Due(schedule *models.StarterSchedule, now time.Time) bool {
+	loc, err := time.LoadLocation(schedule.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	local := now.In(loc)
+	slot := time.Date(local.Year(), local.Month(), local.Day(), schedule.Hour, 0, 0, 0, loc)
+	if slot.After(local) {
+		slot = slot.AddDate(0, 0, -1)
+	}
+	for !postsOn(schedule, slot.Weekday()) {
+		slot = slot.AddDate(0, 0, -1)
+	}
+
+	last := schedule.CreatedAt
+	if schedule.LastPostedAt != nil {
+		last = *schedule.LastPostedAt
+	}
+	return last.Before(slot)
+}
+
+// postsOn tells whether a schedule posts on a weekday
+func postsOn(schedule *models.StarterSchedule, day time.Weekday) bool {
+	switch schedule.Frequency {
+	case models.StarterWeekdays:
+		return day != time.Saturday && day != time.Sunday
+	case models.StarterWeekly:
+		return day == time.Weekday(schedule.Weekday)
+	default:
+		return true
+	}
+}
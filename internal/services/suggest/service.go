@@ -0,0 +1,192 @@
+// Package suggest offers questions to complete /ask with: FAQ entries and
+// what the guild has been asking lately.
+package suggest
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"discord-tars/internal/repository"
+)
+
+const (
+	// MaxLength is Discord's limit for an autocomplete choice
+	MaxLength = 100
+	minLength = 8
+
+	faqLabel        = "faq"
+	popularWindow   = 30 * 24 * time.Hour
+	popularLimit    = 200
+	cacheTTL        = 5 * time.Minute
+	maxCachedGuilds = 1000
+)
+
+// faqMarkup matches list bullets, numbering, headings, quotes and "Q:" labels
+// at the start of an FAQ line
+var faqMarkup = regexp.MustCompile(`^\s*(?:(?:[#>*•-]+|\d+[.)])\s*)*(?i:(?:q|question)\s*[:.-]\s*)?`)
+
+type Service struct {
+	questionRepo *repository.QuestionRepository
+	priorityRepo *repository.PriorityRepository
+
+	mu    sync.Mutex
+	cache map[int64]*candidates
+}
+
+// candidates are a guild's suggestions, FAQ entries first, then popular
+// questions from most to least asked
+type candidates struct {
+	questions []string
+	loadedAt  time.Time
+}
+
+func NewService(questionRepo *repository.QuestionRepository, priorityRepo *repository.PriorityRepository) *Service {
+	return &Service{
+		questionRepo: questionRepo,
+		priorityRepo: priorityRepo,
+		cache:        make(map[int64]*candidates),
+	}
+}
+
+// Record counts a question asked in a guild. Questions too long to be offered
+// as a suggestion aren't kept.
+func (s *Service) Record(ctx context.Context, guildID int64, question string) error {
+	question = cleanQuestion(question)
+	if guildID == 0 || !suggestible(question) {
+		return nil
+	}
+	sum := sha256.Sum256([]byte(normalize(question)))
+	return s.questionRepo.RecordQuestion(ctx, guildID, hex.EncodeToString(sum[:]), question)
+}
+
+// Suggest returns up to limit questions matching what has been typed so far.
+// Every typed word must appear in a suggestion; those starting with the typed
+// text come first.
+func (s *Service) Suggest(ctx context.Context, guildID int64, typed string, limit int) []string {
+	all := s.candidates(ctx, guildID)
+	typed = normalize(typed)
+	words := strings.Fields(typed)
+
+	var prefixed, contained []string
+	for _, question := range all {
+		text := normalize(question)
+		if strings.HasPrefix(text, typed) {
+			prefixed = append(prefixed, question)
+			continue
+		}
+		if containsAll(text, words) {
+			contained = append(contained, question)
+		}
+	}
+
+	matches := append(prefixed, contained...)
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+// candidates returns a guild's cached suggestions, reloading them when stale.
+// On a load failure the stale list is kept rather than suggesting nothing.
+func (s *Service) candidates(ctx context.Context, guildID int64) []string {
+	s.mu.Lock()
+	cached, ok := s.cache[guildID]
+	s.mu.Unlock()
+	if ok && time.Since(cached.loadedAt) < cacheTTL {
+		return cached.questions
+	}
+
+	questions, err := s.load(ctx, guildID)
+	if err != nil {
+		log.Printf("⚠️ Failed to load question suggestions: %v", err)
+		if ok {
+			return cached.questions
+		}
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.cache) >= maxCachedGuilds {
+		s.cache = make(map[int64]*candidates)
+	}
+	s.cache[guildID] = &candidates{questions: questions, loadedAt: time.Now()}
+	return questions
+}
+
+func (s *Service) load(ctx context.Context, guildID int64) ([]string, error) {
+	seen := make(map[string]bool)
+	var questions []string
+	add := func(question string) {
+		key := normalize(question)
+		if !suggestible(question) || seen[key] {
+			return
+		}
+		seen[key] = true
+		questions = append(questions, question)
+	}
+
+	docs, err := s.priorityRepo.ListDocumentsByLabel(ctx, guildID, faqLabel)
+	if err != nil {
+		return nil, err
+	}
+	for _, doc := range docs {
+		for _, question := range faqQuestions(doc.Content) {
+			add(question)
+		}
+	}
+
+	popular, err := s.questionRepo.PopularQuestions(ctx, guildID, time.Now().Add(-popularWindow), popularLimit)
+	if err != nil {
+		return nil, err
+	}
+	for _, asked := range popular {
+		add(asked.Question)
+	}
+	return questions, nil
+}
+
+// faqQuestions picks the questions out of an FAQ message: lines ending in a
+// question mark, stripped of list and heading markup and "Q:" prefixes
+func faqQuestions(content string) []string {
+	var questions []string
+	for _, line := range strings.Split(content, "\n") {
+		line = cleanQuestion(line)
+		if strings.HasSuffix(line, "?") {
+			questions = append(questions, line)
+		}
+	}
+	return questions
+}
+
+// cleanQuestion strips markdown and FAQ markup from a question
+func cleanQuestion(text string) string {
+	text = strings.NewReplacer("**", "", "__", "", "`", "").Replace(text)
+	text = faqMarkup.ReplaceAllString(text, "")
+	return strings.Join(strings.Fields(text), " ")
+}
+
+func suggestible(question string) bool {
+	n := utf8.RuneCountInString(question)
+	return n >= minLength && n <= MaxLength
+}
+
+func normalize(text string) string {
+	return strings.Join(strings.Fields(strings.ToLower(text)), " ")
+}
+
+func containsAll(text string, words []string) bool {
+	for _, word := range words {
+		if !strings.Contains(text, word) {
+			return false
+		}
+	}
+	return true
+}
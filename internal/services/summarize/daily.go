@@ -0,0 +1,108 @@
+package summarize
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/repository"
+	"discord-tars/internal/tenant"
+)
+
+// catchUpDays is how far back missed days are summarized, e.g. after downtime
+const catchUpDays = 3
+
+// DailyIndexer summarizes each active channel's day into a document that is
+// embedded and searched alongside chat history, so questions about a period
+// can be answered from a few summaries instead of many messages
+type DailyIndexer struct {
+	summarizer  *Service
+	summaryRepo *repository.SummaryRepository
+	minMessages int
+}
+
+// NewDailyIndexer creates the indexer; channels with fewer than minMessages
+// messages in a day are skipped
+func NewDailyIndexer(summarizer *Service, summaryRepo *repository.SummaryRepository, minMessages int) *DailyIndexer {
+	if minMessages < 1 {
+		minMessages = 1
+	}
+	return &DailyIndexer{summarizer: summarizer, summaryRepo: summaryRepo, minMessages: minMessages}
+}
+
+// IndexDays is the scheduler job: it summarizes the last complete UTC days
+// that haven't been yet, so running it hourly summarizes each day shortly
+// after midnight
+func (d *DailyIndexer) IndexDays(ctx context.Context) error {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	for n := catchUpDays; n >= 1; n-- {
+		if err := d.indexDay(ctx, today.AddDate(0, 0, -n)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *DailyIndexer) indexDay(ctx context.Context, day time.Time) error {
+	until := day.AddDate(0, 0, 1)
+	active, err := d.summarizer.msgRepo.ListActiveChannels(ctx, day, until, d.minMessages)
+	if err != nil {
+		return err
+	}
+	done, err := d.summaryRepo.SummarizedChannels(ctx, day)
+	if err != nil {
+		return err
+	}
+
+	for _, channel := range active {
+		if done[channel.ChannelID] {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := d.indexChannel(ctx, channel, day, until); err != nil {
+			log.Printf("❌ Failed to summarize channel %d for %s: %v", channel.ChannelID, day.Format(time.DateOnly), err)
+		}
+	}
+	return nil
+}
+
+func (d *DailyIndexer) indexChannel(ctx context.Context, channel repository.ChannelActivity, day, until time.Time) error {
+	ctx = tenant.WithGuild(ctx, channel.GuildID)
+	messages, err := d.summarizer.msgRepo.GetMessagesInRange(ctx, channel.ChannelID, day, until, defaultMaxMessages)
+	if err != nil {
+		return fmt.Errorf("failed to load messages: %w", err)
+	}
+	if len(messages) == 0 {
+		return nil
+	}
+
+	summary, err := d.summarizer.SummarizeMessages(ctx, messages)
+	if err != nil {
+		return err
+	}
+
+	name := messages[0].Channel.Name
+	content := fmt.Sprintf("Summary of #%s on %s (%d messages):\n%s", name, day.Format(time.DateOnly), channel.Messages, summary)
+	embedding, err := d.summarizer.aiService.GenerateEmbedding(ctx, content)
+	if err != nil {
+		return fmt.Errorf("failed to embed summary: %w", err)
+	}
+
+	err = d.summaryRepo.Save(ctx, &models.ChannelSummary{
+		GuildID:      channel.GuildID,
+		ChannelID:    channel.ChannelID,
+		Day:          day,
+		ChannelName:  name,
+		MessageCount: channel.Messages,
+		Content:      content,
+	}, embedding)
+	if err != nil {
+		return err
+	}
+	log.Printf("🗓️ Summarized #%s for %s (%d messages)", name, day.Format(time.DateOnly), channel.Messages)
+	return nil
+}
@@ -0,0 +1,48 @@
+package summarize
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+
+	"discord-tars/internal/models"
+)
+
+// FromDiscord converts messages fetched from Discord into the form used for
+// summaries, oldest first. Attachments and embeds are noted so a message that
+// only shares a file still shows up in the transcript.
+func FromDiscord(messages []*discordgo.Message) []models.SearchResult {
+	results := make([]models.SearchResult, 0, len(messages))
+	for _, msg := range messages {
+		if msg == nil || msg.Author == nil {
+			continue
+		}
+
+		content := msg.Content
+		for _, a := range msg.Attachments {
+			content += " [attachment: " + a.Filename + "]"
+		}
+		for _, e := range msg.Embeds {
+			if e.Title != "" || e.Description != "" {
+				content += " [embed: " + strings.TrimSpace(e.Title+" "+e.Description) + "]"
+			}
+		}
+
+		id, _ := strconv.ParseInt(msg.ID, 10, 64)
+		results = append(results, models.SearchResult{
+			Message: models.Message{
+				ID:        id,
+				Content:   strings.TrimSpace(content),
+				Timestamp: msg.Timestamp,
+			},
+			User: models.User{Username: msg.Author.Username},
+		})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Message.ID < results[j].Message.ID
+	})
+	return results
+}
@@ -0,0 +1,36 @@
+package summarize
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/sanitize"
+)
+
+const postmortemMaxTokens = 1200
+
+const postmortemSystemPrompt = `You draft incident postmortems from the Discord thread where an incident was handled.
+Write Markdown with these sections, each as a bold heading:
+**Summary** - two or three sentences on what happened
+**Impact** - who or what was affected, and for how long
+**Timeline** - key moments as "HH:MM UTC - event" bullet points
+**Root cause** - what caused it, or "Not established" if the thread doesn't say
+**Resolution** - what fixed it
+**Action items** - follow-ups as bullet points, with owners by username when the thread names them
+Be blameless: describe what happened, not who is at fault. Only state facts from the incident details and the transcript, and mark anything uncertain as such.`
+
+// DraftPostmortem writes a postmortem draft from an incident's details and
+// the messages of its thread
+func (s *Service) DraftPostmortem(ctx context.Context, details string, messages []models.SearchResult) (string, error) {
+	transcript := BuildTranscript(messages, maxTranscriptChars)
+	prompt := fmt.Sprintf("Incident details:\n%s\n\nThread transcript:\n%s", details, transcript)
+
+	log.Printf("🧾 Drafting a postmortem from %d messages (%d chars)", len(messages), len(transcript))
+	draft, err := s.aiService.Complete(ctx, postmortemSystemPrompt, prompt, postmortemMaxTokens)
+	if err != nil {
+		return "", fmt.Errorf("failed to draft postmortem: %w", err)
+	}
+	return sanitize.Output(draft), nil
+}
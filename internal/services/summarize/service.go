@@ -0,0 +1,94 @@
+package summarize
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"discord-tars/internal/interfaces"
+	"discord-tars/internal/models"
+	"discord-tars/internal/repository"
+	"discord-tars/internal/sanitize"
+)
+
+const (
+	defaultMaxMessages = 300
+	maxTranscriptChars = 12000
+	summaryMaxTokens   = 600
+)
+
+const summarySystemPrompt = `You summarize Discord conversations. Produce a short digest with:
+- the main topics discussed, as bullet points
+- any decisions, answers, or action items
+- open questions that went unanswered
+Refer to people by username. Do not invent details that are not in the transcript.
+If nothing meaningful was discussed, say so in one sentence.`
+
+type Service struct {
+	aiService interfaces.AIService
+	msgRepo   *repository.MessageRepository
+}
+
+func NewService(aiService interfaces.AIService, msgRepo *repository.MessageRepository) *Service {
+	return &Service{
+		aiService: aiService,
+		msgRepo:   msgRepo,
+	}
+}
+
+// SummarizeChannel summarizes the messages posted in a channel within [since, until)
+func (s *Service) SummarizeChannel(ctx context.Context, channelID int64, since, until time.Time) (string, int, error) {
+	messages, err := s.msgRepo.GetMessagesInRange(ctx, channelID, since, until, defaultMaxMessages)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to load messages: %w", err)
+	}
+
+	if len(messages) == 0 {
+		return "", 0, nil
+	}
+
+	summary, err := s.SummarizeMessages(ctx, messages)
+	if err != nil {
+		return "", 0, err
+	}
+	return summary, len(messages), nil
+}
+
+// SummarizeMessages summarizes an ordered slice of messages
+func (s *Service) SummarizeMessages(ctx context.Context, messages []models.SearchResult) (string, error) {
+	transcript := BuildTranscript(messages, maxTranscriptChars)
+
+	log.Printf("🧾 Summarizing %d messages (%d chars)", len(messages), len(transcript))
+	summary, err := s.aiService.Complete(ctx, summarySystemPrompt, transcript, summaryMaxTokens)
+	if err != nil {
+		return "", fmt.Errorf("failed to summarize messages: %w", err)
+	}
+	return sanitize.Output(summary), nil
+}
+
+// BuildTranscript renders messages as "[15:04] username: content" lines, keeping
+// the most recent lines when the transcript exceeds maxChars
+func BuildTranscript(messages []models.SearchResult, maxChars int) string {
+	lines := make([]string, 0, len(messages))
+	for _, result := range messages {
+		content := sanitize.Context(strings.TrimSpace(result.Message.Content))
+		if content == "" {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("[%s] %s: %s",
+			result.Message.Timestamp.UTC().Format("2006-01-02 15:04"),
+			result.User.Username,
+			content))
+	}
+
+	total := 0
+	start := len(lines)
+	for start > 0 && total+len(lines[start-1])+1 <= maxChars {
+		start--
+		total += len(lines[start]) + 1
+	}
+
+	return strings.Join(lines[start:], "\n")
+}
@@ -0,0 +1,81 @@
+package summarize
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"discord-tars/internal/sanitize"
+)
+
+const maxUserMessages = 200
+
+const userActivitySystemPrompt = `You help a Discord moderator catch up on what one member has been talking about.
+From the member's messages, list the main topics they discussed as short bullet points, noting the channel for each.
+Mention questions they asked that may still need an answer, and anything a moderator should know about, such as conflicts or reports.
+Stick to what the messages say: don't guess at motives or judge the member, and don't quote long passages.`
+
+// UserActivity is a summary of what one member has been discussing
+type UserActivity struct {
+	Summary  string
+	Messages int
+	Channels int
+}
+
+// SummarizeUser summarizes a member's messages in a guild since the given
+// time. Only channels for which canRead returns true are included, so the
+// summary never reveals what the requester couldn't read themselves.
+func (s *Service) SummarizeUser(ctx context.Context, guildID, userID int64, username string, since time.Time, canRead func(channelID int64) bool) (*UserActivity, error) {
+	channelIDs, err := s.msgRepo.ListUserChannels(ctx, guildID, userID, since)
+	if err != nil {
+		return nil, err
+	}
+	var readable []int64
+	for _, id := range channelIDs {
+		if canRead(id) {
+			readable = append(readable, id)
+		}
+	}
+	if len(readable) == 0 {
+		return &UserActivity{}, nil
+	}
+
+	messages, err := s.msgRepo.GetUserMessages(ctx, userID, readable, since, maxUserMessages)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := make([]string, 0, len(messages))
+	channels := make(map[int64]bool)
+	for _, result := range messages {
+		content := sanitize.Context(strings.TrimSpace(result.Message.Content))
+		if content == "" {
+			continue
+		}
+		channels[result.Message.ChannelID] = true
+		lines = append(lines, fmt.Sprintf("[%s] #%s: %s",
+			result.Message.Timestamp.UTC().Format("2006-01-02 15:04"),
+			result.Channel.Name,
+			content))
+	}
+	if len(lines) == 0 {
+		return &UserActivity{}, nil
+	}
+
+	// Keep the most recent lines when the transcript is too long
+	total, start := 0, len(lines)
+	for start > 0 && total+len(lines[start-1])+1 <= maxTranscriptChars {
+		start--
+		total += len(lines[start]) + 1
+	}
+	transcript := fmt.Sprintf("Messages from %s:\n%s", username, strings.Join(lines[start:], "\n"))
+
+	log.Printf("🧾 Summarizing %d messages from user ID: %d", len(lines), userID)
+	summary, err := s.aiService.Complete(ctx, userActivitySystemPrompt, transcript, summaryMaxTokens)
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarize user activity: %w", err)
+	}
+	return &UserActivity{Summary: sanitize.Output(summary), Messages: len(lines), Channels: len(channels)}, nil
+}
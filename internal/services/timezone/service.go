@@ -0,0 +1,110 @@
+// Package timezone keeps the timezones members and guilds read and write
+// times in, so "tomorrow 9am" in /remind or a digest hour means what the
+// member meant. A member's own timezone wins over their guild's, and UTC is
+// the fallback.
+package timezone
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"discord-tars/internal/models"
+	"discord-tars/internal/repository"
+	"discord-tars/internal/services/agent"
+)
+
+// Where a Zone comes from
+const (
+	SourceUser    = "user"
+	SourceGuild   = "server"
+	SourceDefault = "default"
+)
+
+// Zone is the timezone times are read in for someone, and where it comes from
+type Zone struct {
+	Location *time.Location
+	Source   string
+}
+
+// Name is the zone's IANA name or UTC offset
+func (z Zone) Name() string {
+	return z.Location.String()
+}
+
+type Service struct {
+	repo *repository.TimezoneRepository
+}
+
+func NewService(repo *repository.TimezoneRepository) *Service {
+	return &Service{repo: repo}
+}
+
+// Resolve finds a timezone by IANA name, abbreviation or UTC offset, as
+// members may write it
+func Resolve(name string) (*time.Location, error) {
+	return agent.LoadZone(name)
+}
+
+// For returns the timezone a member reads and writes times in, in a guild;
+// guildID is zero in DMs. Errors fall back to the next preference.
+func (s *Service) For(ctx context.Context, guildID, userID int64) Zone {
+	if userID != 0 {
+		tz, err := s.repo.GetUser(ctx, userID)
+		if err != nil {
+			log.Printf("⚠️ %v", err)
+		} else if tz != nil {
+			if loc := load(tz.Timezone); loc != nil {
+				return Zone{Location: loc, Source: SourceUser}
+			}
+		}
+	}
+	if guildID != 0 {
+		if loc, err := s.Guild(ctx, guildID); err != nil {
+			log.Printf("⚠️ %v", err)
+		} else if loc != nil {
+			return Zone{Location: loc, Source: SourceGuild}
+		}
+	}
+	return Zone{Location: time.UTC, Source: SourceDefault}
+}
+
+// Guild returns a guild's own timezone, or nil when it has none
+func (s *Service) Guild(ctx context.Context, guildID int64) (*time.Location, error) {
+	tz, err := s.repo.GetGuild(ctx, guildID)
+	if err != nil || tz == nil {
+		return nil, err
+	}
+	return load(tz.Timezone), nil
+}
+
+// SetUser sets a member's timezone
+func (s *Service) SetUser(ctx context.Context, userID int64, loc *time.Location) error {
+	return s.repo.SaveUser(ctx, &models.UserTimezone{UserID: userID, Timezone: loc.String()})
+}
+
+// ClearUser forgets a member's timezone, reporting whether they had one
+func (s *Service) ClearUser(ctx context.Context, userID int64) (bool, error) {
+	return s.repo.DeleteUser(ctx, userID)
+}
+
+// SetGuild sets a guild's timezone
+func (s *Service) SetGuild(ctx context.Context, guildID int64, loc *time.Location, setBy int64) error {
+	return s.repo.SaveGuild(ctx, &models.GuildTimezone{GuildID: guildID, Timezone: loc.String(), SetBy: setBy})
+}
+
+// ClearGuild forgets a guild's timezone, reporting whether it had one
+func (s *Service) ClearGuild(ctx context.Context, guildID int64) (bool, error) {
+	return s.repo.DeleteGuild(ctx, guildID)
+}
+
+// load resolves a stored timezone, nil when it no longer resolves, e.g.
+// after a tzdata change
+func load(name string) *time.Location {
+	loc, err := Resolve(name)
+	if err != nil {
+		log.Printf("⚠️ Ignoring stored timezone: %v", err)
+		return nil
+	}
+	return loc
+}
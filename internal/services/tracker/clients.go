@@ -0,0 +1,213 @@
+package tracker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ErrTicketNotFound is returned when the tracker has no ticket with the given key
+var ErrTicketNotFound = errors.New("ticket not found")
+
+// Ticket is the provider-neutral view of an issue
+type Ticket struct {
+	Key         string
+	Title       string
+	Status      string
+	Type        string
+	Priority    string
+	Assignee    string
+	URL         string
+	Description string
+	Updated     time.Time
+}
+
+// client fetches tickets from one provider
+type client interface {
+	GetTicket(ctx context.Context, key string) (*Ticket, error)
+}
+
+var httpClient = &http.Client{Timeout: 15 * time.Second}
+
+// jiraClient talks to the Jira Cloud/Server REST API v2
+type jiraClient struct {
+	baseURL string
+	email   string
+	token   string
+}
+
+func (c *jiraClient) GetTicket(ctx context.Context, key string) (*Ticket, error) {
+	endpoint := fmt.Sprintf("%s/rest/api/2/issue/%s?fields=summary,status,issuetype,priority,assignee,description,updated",
+		strings.TrimRight(c.baseURL, "/"), url.PathEscape(key))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create jira request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.email != "" {
+		req.SetBasicAuth(c.email, c.token)
+	} else {
+		req.Header.Set("Authorization", "Bearer "+c.token) // Jira Server personal access token
+	}
+
+	var issue struct {
+		Key    string `json:"key"`
+		Fields struct {
+			Summary     string `json:"summary"`
+			Description string `json:"description"`
+			Updated     string `json:"updated"`
+			Status      struct {
+				Name string `json:"name"`
+			} `json:"status"`
+			IssueType struct {
+				Name string `json:"name"`
+			} `json:"issuetype"`
+			Priority *struct {
+				Name string `json:"name"`
+			} `json:"priority"`
+			Assignee *struct {
+				DisplayName string `json:"displayName"`
+			} `json:"assignee"`
+		} `json:"fields"`
+	}
+	if err := doJSON(req, &issue); err != nil {
+		return nil, err
+	}
+
+	ticket := &Ticket{
+		Key:         issue.Key,
+		Title:       issue.Fields.Summary,
+		Status:      issue.Fields.Status.Name,
+		Type:        issue.Fields.IssueType.Name,
+		URL:         fmt.Sprintf("%s/browse/%s", strings.TrimRight(c.baseURL, "/"), issue.Key),
+		Description: issue.Fields.Description,
+	}
+	if issue.Fields.Priority != nil {
+		ticket.Priority = issue.Fields.Priority.Name
+	}
+	if issue.Fields.Assignee != nil {
+		ticket.Assignee = issue.Fields.Assignee.DisplayName
+	}
+	if t, err := time.Parse("2006-01-02T15:04:05.000-0700", issue.Fields.Updated); err == nil {
+		ticket.Updated = t
+	}
+	return ticket, nil
+}
+
+// linearClient talks to the Linear GraphQL API
+type linearClient struct {
+	token string
+}
+
+const linearAPIURL = "https://api.linear.app/graphql"
+
+const linearIssueQuery = `query Issue($id: String!) {
+  issue(id: $id) {
+    identifier title description url updatedAt priorityLabel
+    state { name }
+    assignee { displayName }
+    labels { nodes { name } }
+  }
+}`
+
+func (c *linearClient) GetTicket(ctx context.Context, key string) (*Ticket, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"query":     linearIssueQuery,
+		"variables": map[string]string{"id": key},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode linear query: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, linearAPIURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create linear request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", c.token)
+
+	var resp struct {
+		Data struct {
+			Issue *struct {
+				Identifier    string    `json:"identifier"`
+				Title         string    `json:"title"`
+				Description   string    `json:"description"`
+				URL           string    `json:"url"`
+				UpdatedAt     time.Time `json:"updatedAt"`
+				PriorityLabel string    `json:"priorityLabel"`
+				State         struct {
+					Name string `json:"name"`
+				} `json:"state"`
+				Assignee *struct {
+					DisplayName string `json:"displayName"`
+				} `json:"assignee"`
+				Labels struct {
+					Nodes []struct {
+						Name string `json:"name"`
+					} `json:"nodes"`
+				} `json:"labels"`
+			} `json:"issue"`
+		} `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := doJSON(req, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Data.Issue == nil {
+		if len(resp.Errors) > 0 && !strings.Contains(strings.ToLower(resp.Errors[0].Message), "not found") {
+			return nil, fmt.Errorf("linear api error: %s", resp.Errors[0].Message)
+		}
+		return nil, ErrTicketNotFound
+	}
+
+	issue := resp.Data.Issue
+	ticket := &Ticket{
+		Key:         issue.Identifier,
+		Title:       issue.Title,
+		Status:      issue.State.Name,
+		Priority:    issue.PriorityLabel,
+		URL:         issue.URL,
+		Description: issue.Description,
+		Updated:     issue.UpdatedAt,
+	}
+	var labels []string
+	for _, l := range issue.Labels.Nodes {
+		labels = append(labels, l.Name)
+	}
+	ticket.Type = strings.Join(labels, ", ")
+	if issue.Assignee != nil {
+		ticket.Assignee = issue.Assignee.DisplayName
+	}
+	return ticket, nil
+}
+
+func doJSON(req *http.Request, out interface{}) error {
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("tracker request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return ErrTicketNotFound
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return fmt.Errorf("tracker rejected the credentials (status %d)", resp.StatusCode)
+	case resp.StatusCode != http.StatusOK:
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("tracker returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(snippet)))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode tracker response: %w", err)
+	}
+	return nil
+}
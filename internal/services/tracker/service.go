@@ -0,0 +1,241 @@
+package tracker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"discord-tars/internal/interfaces"
+	"discord-tars/internal/models"
+	"discord-tars/internal/repository"
+)
+
+const (
+	cacheTTL          = 2 * time.Minute
+	maxCacheEntries   = 500
+	maxToolDescChars  = 1500
+	maxKeysPerMessage = 5
+)
+
+var (
+	ErrNotConfigured = errors.New("no issue tracker is connected to this server")
+	ErrKeyNotAllowed = errors.New("that project is not linked to this server")
+)
+
+// ticketKeyPattern matches Jira/Linear style keys such as PROJ-123 or ENG-42
+var ticketKeyPattern = regexp.MustCompile(`\b[A-Z][A-Z0-9]{1,9}-[1-9][0-9]{0,6}\b`)
+
+type cachedTicket struct {
+	ticket    *Ticket
+	fetchedAt time.Time
+}
+
+type Service struct {
+	trackerRepo *repository.TrackerRepository
+
+	mu    sync.Mutex
+	cache map[string]cachedTicket // guildID/key -> ticket
+}
+
+func NewService(trackerRepo *repository.TrackerRepository) *Service {
+	return &Service{
+		trackerRepo: trackerRepo,
+		cache:       make(map[string]cachedTicket),
+	}
+}
+
+// Configure validates and stores a guild's tracker connection
+func (s *Service) Configure(ctx context.Context, cfg *models.TrackerConfig) error {
+	switch cfg.Provider {
+	case models.TrackerJira:
+		parsed, err := url.Parse(strings.TrimSpace(cfg.BaseURL))
+		if err != nil || parsed.Scheme != "https" || parsed.Host == "" {
+			return fmt.Errorf("jira needs an https site URL such as https://acme.atlassian.net")
+		}
+		cfg.BaseURL = strings.TrimRight(parsed.String(), "/")
+	case models.TrackerLinear:
+		cfg.BaseURL, cfg.Email = "", ""
+	default:
+		return fmt.Errorf("unknown tracker provider %q", cfg.Provider)
+	}
+	if strings.TrimSpace(cfg.APIToken) == "" {
+		return fmt.Errorf("an API token is required")
+	}
+	for i, key := range cfg.ProjectKeys {
+		cfg.ProjectKeys[i] = strings.ToUpper(strings.TrimSpace(key))
+	}
+
+	if err := s.trackerRepo.SaveConfig(ctx, cfg); err != nil {
+		return err
+	}
+	s.clearCache(cfg.GuildID)
+	return nil
+}
+
+// GetConfig returns a guild's tracker configuration, or nil if none
+func (s *Service) GetConfig(ctx context.Context, guildID int64) (*models.TrackerConfig, error) {
+	return s.trackerRepo.GetConfig(ctx, guildID)
+}
+
+// Disconnect removes a guild's tracker configuration
+func (s *Service) Disconnect(ctx context.Context, guildID int64) (bool, error) {
+	s.clearCache(guildID)
+	return s.trackerRepo.DeleteConfig(ctx, guildID)
+}
+
+// ExtractKeys returns the distinct ticket keys referenced in text
+func ExtractKeys(text string) []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, key := range ticketKeyPattern.FindAllString(text, -1) {
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+		if len(keys) == maxKeysPerMessage {
+			break
+		}
+	}
+	return keys
+}
+
+// Lookup fetches a ticket using the guild's tracker, with a short cache
+func (s *Service) Lookup(ctx context.Context, guildID int64, key string) (*Ticket, error) {
+	key = strings.ToUpper(strings.TrimSpace(key))
+	cacheKey := fmt.Sprintf("%d/%s", guildID, key)
+
+	s.mu.Lock()
+	if cached, ok := s.cache[cacheKey]; ok && time.Since(cached.fetchedAt) < cacheTTL {
+		s.mu.Unlock()
+		return cached.ticket, nil
+	}
+	s.mu.Unlock()
+
+	cfg, err := s.trackerRepo.GetConfig(ctx, guildID)
+	if err != nil {
+		return nil, err
+	}
+	if cfg == nil {
+		return nil, ErrNotConfigured
+	}
+	if !projectAllowed(cfg, key) {
+		return nil, ErrKeyNotAllowed
+	}
+
+	ticket, err := clientFor(cfg).GetTicket(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	if len(s.cache) >= maxCacheEntries {
+		for k, cached := range s.cache {
+			if time.Since(cached.fetchedAt) >= cacheTTL {
+				delete(s.cache, k)
+			}
+		}
+	}
+	s.cache[cacheKey] = cachedTicket{ticket: ticket, fetchedAt: time.Now()}
+	s.mu.Unlock()
+
+	log.Printf("🎫 Looked up %s via %s", key, cfg.Provider)
+	return ticket, nil
+}
+
+// Tool exposes ticket lookup to the AI for a guild
+func (s *Service) Tool(guildID int64) interfaces.Tool {
+	return interfaces.Tool{
+		Name:        "lookup_ticket",
+		Description: "Fetch an issue tracker ticket (Jira or Linear) by key, e.g. PROJ-123. Returns title, status, assignee, priority and description.",
+		Parameters: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"key": map[string]interface{}{
+					"type":        "string",
+					"description": "Ticket key such as PROJ-123",
+				},
+			},
+			"required": []string{"key"},
+		},
+		Handler: func(ctx context.Context, arguments string) (string, error) {
+			var args struct {
+				Key string `json:"key"`
+			}
+			if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+				return "", fmt.Errorf("invalid arguments: %w", err)
+			}
+			ticket, err := s.Lookup(ctx, guildID, args.Key)
+			if err != nil {
+				return "", err
+			}
+			return describe(ticket), nil
+		},
+	}
+}
+
+// describe renders a ticket as plain text for the model
+func describe(t *Ticket) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%s: %s\nStatus: %s\n", t.Key, t.Title, t.Status))
+	if t.Type != "" {
+		sb.WriteString("Type: " + t.Type + "\n")
+	}
+	if t.Priority != "" {
+		sb.WriteString("Priority: " + t.Priority + "\n")
+	}
+	assignee := t.Assignee
+	if assignee == "" {
+		assignee = "unassigned"
+	}
+	sb.WriteString("Assignee: " + assignee + "\n")
+	if !t.Updated.IsZero() {
+		sb.WriteString("Updated: " + t.Updated.UTC().Format(time.RFC3339) + "\n")
+	}
+	sb.WriteString("URL: " + t.URL + "\n")
+	if t.Description != "" {
+		desc := t.Description
+		if len(desc) > maxToolDescChars {
+			desc = desc[:maxToolDescChars] + " (truncated)"
+		}
+		sb.WriteString("Description:\n" + desc)
+	}
+	return sb.String()
+}
+
+func projectAllowed(cfg *models.TrackerConfig, key string) bool {
+	if len(cfg.ProjectKeys) == 0 {
+		return true
+	}
+	project, _, _ := strings.Cut(key, "-")
+	for _, allowed := range cfg.ProjectKeys {
+		if allowed == project {
+			return true
+		}
+	}
+	return false
+}
+
+func clientFor(cfg *models.TrackerConfig) client {
+	if cfg.Provider == models.TrackerLinear {
+		return &linearClient{token: cfg.APIToken}
+	}
+	return &jiraClient{baseURL: cfg.BaseURL, email: cfg.Email, token: cfg.APIToken}
+}
+
+func (s *Service) clearCache(guildID int64) {
+	prefix := fmt.Sprintf("%d/", guildID)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key := range s.cache {
+		if strings.HasPrefix(key, prefix) {
+			delete(s.cache, key)
+		}
+	}
+}
@@ -0,0 +1,329 @@
+package voice
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// healthCheckInterval is how often connections are checked; one missing
+	// check is tolerated since discordgo briefly drops Ready while it resumes
+	healthCheckInterval = 5 * time.Second
+	maxMissedChecks     = 2
+	// serverUpdateGrace leaves discordgo time to move to a new voice server
+	serverUpdateGrace = 10 * time.Second
+
+	reconnectAttempts   = 8
+	reconnectMinBackoff = time.Second
+	reconnectMaxBackoff = time.Minute
+
+	// sendTimeout is how long a frame may wait for the connection before
+	// playback is considered interrupted
+	sendTimeout = time.Second
+	maxQueued   = 5
+)
+
+// connection is a voice channel the bot should stay in. The manager keeps it
+// alive: the VoiceConnection behind it is replaced when it drops.
+type connection struct {
+	guildID   string
+	channelID string
+	session   *discordgo.Session
+	vc        *discordgo.VoiceConnection
+
+	reconnecting bool
+	// pending is TTS interrupted by a disconnect, spoken again once reconnected
+	pending []string
+	stop    chan struct{}
+}
+
+// JoinVoiceChannel joins the specified voice channel and keeps the bot
+// connected to it until DisconnectVoice
+func (s *Service) JoinVoiceChannel(ctx context.Context, session *discordgo.Session, guildID, channelID string) (*discordgo.VoiceConnection, error) {
+	s.voiceMu.Lock()
+	defer s.voiceMu.Unlock()
+
+	c, exists := s.voiceConns[guildID]
+	if exists && c.channelID == channelID && isReady(c.vc) {
+		return c.vc, nil
+	}
+
+	vc, err := session.ChannelVoiceJoin(guildID, channelID, false, false) // Enable receiving
+	if err != nil {
+		return nil, fmt.Errorf("failed to join voice channel: %w", err)
+	}
+
+	if exists {
+		c.channelID = channelID
+		c.vc = vc
+	} else {
+		c = &connection{guildID: guildID, channelID: channelID, session: session, vc: vc, stop: make(chan struct{})}
+		s.voiceConns[guildID] = c
+		go s.monitor(c)
+	}
+	log.Printf("✅ Joined voice channel %s in guild %s", channelID, guildID)
+	return vc, nil
+}
+
+// DisconnectVoice disconnects from the voice channel in the guild
+func (s *Service) DisconnectVoice(guildID string) {
+	s.voiceMu.Lock()
+	c, exists := s.voiceConns[guildID]
+	if exists {
+		delete(s.voiceConns, guildID)
+		close(c.stop)
+	}
+	s.voiceMu.Unlock()
+
+	if exists {
+		if err := c.vc.Disconnect(); err != nil {
+			log.Printf("⚠️ Failed to leave voice channel in guild %s: %v", guildID, err)
+		}
+		log.Printf("✅ Disconnected from voice channel in guild %s", guildID)
+	}
+}
+
+// DisconnectAll leaves every voice channel, on shutdown
+func (s *Service) DisconnectAll() {
+	s.voiceMu.Lock()
+	guildIDs := make([]string, 0, len(s.voiceConns))
+	for guildID := range s.voiceConns {
+		guildIDs = append(guildIDs, guildID)
+	}
+	s.voiceMu.Unlock()
+
+	for _, guildID := range guildIDs {
+		s.DisconnectVoice(guildID)
+	}
+}
+
+// HandleVoiceStateUpdate follows the bot being moved to another channel, and
+// forgets connections it was disconnected from by a moderator
+func (s *Service) HandleVoiceStateUpdate(session *discordgo.Session, e *discordgo.VoiceStateUpdate) {
+	if session.State.User == nil || e.UserID != session.State.User.ID {
+		return
+	}
+
+	s.voiceMu.Lock()
+	c, exists := s.voiceConns[e.GuildID]
+	if !exists || c.reconnecting || e.ChannelID == c.channelID {
+		s.voiceMu.Unlock()
+		return
+	}
+	if e.ChannelID != "" {
+		log.Printf("🔀 Moved to voice channel %s in guild %s", e.ChannelID, e.GuildID)
+		c.channelID = e.ChannelID
+		s.voiceMu.Unlock()
+		return
+	}
+	s.voiceMu.Unlock()
+
+	log.Printf("👢 Removed from voice channel in guild %s", e.GuildID)
+	s.DisconnectVoice(e.GuildID)
+}
+
+// HandleVoiceServerUpdate checks a connection after Discord moves it to
+// another voice server, such as on a region change. discordgo reopens the
+// connection itself; it is rebuilt if that doesn't work out.
+func (s *Service) HandleVoiceServerUpdate(session *discordgo.Session, e *discordgo.VoiceServerUpdate) {
+	s.voiceMu.Lock()
+	c, exists := s.voiceConns[e.GuildID]
+	s.voiceMu.Unlock()
+	if !exists {
+		return
+	}
+
+	log.Printf("🔀 Voice server changed to %s in guild %s", e.Endpoint, e.GuildID)
+	time.AfterFunc(serverUpdateGrace, func() {
+		s.checkConnection(c)
+	})
+}
+
+// Resync checks every connection after the gateway reconnects, since voice
+// connections don't always survive a resume, let alone a new session
+func (s *Service) Resync() {
+	s.voiceMu.Lock()
+	conns := make([]*connection, 0, len(s.voiceConns))
+	for _, c := range s.voiceConns {
+		conns = append(conns, c)
+	}
+	s.voiceMu.Unlock()
+
+	for _, c := range conns {
+		time.AfterFunc(serverUpdateGrace, func() {
+			s.checkConnection(c)
+		})
+	}
+}
+
+// monitor reconnects a connection that stays down for maxMissedChecks checks
+func (s *Service) monitor(c *connection) {
+	ticker := time.NewTicker(healthCheckInterval)
+	defer ticker.Stop()
+
+	missed := 0
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+		}
+
+		s.voiceMu.Lock()
+		ready := c.reconnecting || isReady(c.vc)
+		s.voiceMu.Unlock()
+		if ready {
+			missed = 0
+			continue
+		}
+		if missed++; missed >= maxMissedChecks {
+			missed = 0
+			s.reconnect(c)
+		}
+	}
+}
+
+// checkConnection reconnects c right away if it is down
+func (s *Service) checkConnection(c *connection) {
+	s.voiceMu.Lock()
+	healthy := c.reconnecting || isReady(c.vc)
+	s.voiceMu.Unlock()
+	if !healthy {
+		s.reconnect(c)
+	}
+}
+
+// reconnect rejoins c's channel with exponential backoff, then replays the
+// speech that was interrupted. The connection is dropped if every attempt fails.
+func (s *Service) reconnect(c *connection) {
+	s.voiceMu.Lock()
+	if c.reconnecting || s.voiceConns[c.guildID] != c {
+		s.voiceMu.Unlock()
+		return
+	}
+	c.reconnecting = true
+	s.voiceMu.Unlock()
+
+	backoff := reconnectMinBackoff
+	for attempt := 1; attempt <= reconnectAttempts; attempt++ {
+		s.voiceMu.Lock()
+		old, channelID := c.vc, c.channelID
+		s.voiceMu.Unlock()
+
+		log.Printf("🔄 Reconnecting to voice channel %s in guild %s (attempt %d/%d)", channelID, c.guildID, attempt, reconnectAttempts)
+		// Leave properly first, or discordgo reuses the broken connection
+		if err := old.Disconnect(); err != nil {
+			log.Printf("⚠️ Failed to close broken voice connection: %v", err)
+		}
+		vc, err := c.session.ChannelVoiceJoin(c.guildID, channelID, false, false)
+		if err == nil {
+			s.voiceMu.Lock()
+			// The bot was told to leave, or joined again, while rejoining
+			if current := s.voiceConns[c.guildID]; current != c {
+				c.reconnecting = false
+				s.voiceMu.Unlock()
+				// discordgo keeps one connection per guild, which a newer join may own
+				if current == nil || current.vc != vc {
+					if err := vc.Disconnect(); err != nil {
+						log.Printf("⚠️ Failed to leave voice channel %s in guild %s: %v", channelID, c.guildID, err)
+					}
+				}
+				return
+			}
+			c.vc = vc
+			c.reconnecting = false
+			pending := c.pending
+			c.pending = nil
+			s.voiceMu.Unlock()
+
+			log.Printf("✅ Reconnected to voice channel %s in guild %s", channelID, c.guildID)
+			s.replay(vc, pending)
+			return
+		}
+		log.Printf("⚠️ Voice reconnect failed: %v", err)
+
+		select {
+		case <-c.stop:
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, reconnectMaxBackoff)
+	}
+
+	log.Printf("❌ Giving up on voice channel %s in guild %s", c.channelID, c.guildID)
+	s.voiceMu.Lock()
+	c.reconnecting = false
+	s.voiceMu.Unlock()
+	s.DisconnectVoice(c.guildID)
+}
+
+// replay speaks TTS that a disconnect interrupted, in order
+func (s *Service) replay(vc *discordgo.VoiceConnection, texts []string) {
+	if len(texts) == 0 {
+		return
+	}
+	go func() {
+		for _, text := range texts {
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+			err := s.SpeakText(ctx, vc, text)
+			cancel()
+			if err != nil {
+				log.Printf("❌ Failed to replay interrupted speech: %v", err)
+				return
+			}
+		}
+	}()
+}
+
+// requeue keeps speech interrupted by a dropped connection for after the
+// reconnect; it reports false when the guild's connection isn't managed
+func (s *Service) requeue(guildID, text string) bool {
+	s.voiceMu.Lock()
+	defer s.voiceMu.Unlock()
+
+	c, exists := s.voiceConns[guildID]
+	if !exists {
+		return false
+	}
+	if len(c.pending) < maxQueued {
+		c.pending = append(c.pending, text)
+	}
+	return true
+}
+
+// Connection returns the guild's live voice connection, or nil when the bot
+// isn't in a voice channel there
+func (s *Service) Connection(guildID string) *discordgo.VoiceConnection {
+	s.voiceMu.Lock()
+	defer s.voiceMu.Unlock()
+
+	if c, exists := s.voiceConns[guildID]; exists {
+		return c.vc
+	}
+	return nil
+}
+
+// current returns the live connection for vc's guild, which replaces vc
+// after a reconnect
+func (s *Service) current(vc *discordgo.VoiceConnection) *discordgo.VoiceConnection {
+	s.voiceMu.Lock()
+	defer s.voiceMu.Unlock()
+
+	if c, exists := s.voiceConns[vc.GuildID]; exists && c.vc != nil {
+		return c.vc
+	}
+	return vc
+}
+
+func isReady(vc *discordgo.VoiceConnection) bool {
+	if vc == nil {
+		return false
+	}
+	vc.RLock()
+	defer vc.RUnlock()
+	return vc.Ready
+}
@@ -0,0 +1,154 @@
+package voice
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/hraban/opus"
+)
+
+const (
+	frameDuration = 20 * time.Millisecond
+	// jitterFrames are encoded ahead of playback, so an encoding hiccup
+	// doesn't reach the listener as a gap
+	jitterFrames = 5
+)
+
+// play encodes 48kHz stereo PCM to Opus and sends one 20ms frame per tick.
+// Frames are encoded in the background into a small jitter buffer, which is
+// filled before playback starts.
+func (s *Service) play(ctx context.Context, vc *discordgo.VoiceConnection, pcm []int16, text string) error {
+	enc, err := opus.NewEncoder(frameRate, channels, opus.AppVoIP)
+	if err != nil {
+		return fmt.Errorf("failed to create Opus encoder: %w", err)
+	}
+	enc.SetBitrate(64000)
+	if err := enc.SetInBandFEC(true); err != nil {
+		log.Printf("⚠️ Failed to enable FEC: %v", err)
+	}
+
+	frames := make(chan []byte, jitterFrames)
+	primed := make(chan struct{})
+	encodeErr := make(chan error, 1)
+	done := make(chan struct{})
+	defer close(done)
+	go encodeFrames(enc, pcm, frames, primed, encodeErr, done)
+
+	select {
+	case <-primed:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	// The connection may have been replaced while the audio was generated
+	vc = s.current(vc)
+	vc.Speaking(true)
+	defer vc.Speaking(false)
+	s.speaking.Add(1)
+	defer s.speaking.Add(-1)
+
+	ticker := time.NewTicker(frameDuration)
+	defer ticker.Stop()
+	for {
+		var frame []byte
+		select {
+		case f, ok := <-frames:
+			if !ok {
+				// Every frame was sent; report a late encoding error if any
+				select {
+				case err := <-encodeErr:
+					return err
+				default:
+					return nil
+				}
+			}
+			frame = f
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		if !isReady(vc) {
+			return s.interrupted(vc.GuildID, text)
+		}
+		select {
+		case vc.OpusSend <- frame:
+		case <-time.After(sendTimeout):
+			return s.interrupted(vc.GuildID, text)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// encodeFrames encodes pcm into Opus frames, padding the last one with
+// silence. primed is closed once the jitter buffer is full or every frame is
+// encoded, frames when done, and errc gets the outcome.
+func encodeFrames(enc *opus.Encoder, pcm []int16, frames chan<- []byte, primed chan<- struct{}, errc chan<- error, done <-chan struct{}) {
+	defer close(frames)
+	var once sync.Once
+	prime := func() { once.Do(func() { close(primed) }) }
+	defer prime()
+
+	const samplesPerFrame = frameSize * channels
+	for i, encoded := 0, 0; i < len(pcm); i, encoded = i+samplesPerFrame, encoded+1 {
+		if encoded == jitterFrames {
+			prime()
+		}
+		sample := pcm[i:min(i+samplesPerFrame, len(pcm))]
+		if len(sample) < samplesPerFrame {
+			padded := make([]int16, samplesPerFrame)
+			copy(padded, sample)
+			sample = padded
+		}
+
+		data := make([]byte, maxBytes)
+		n, err := enc.Encode(sample, data)
+		if err != nil {
+			errc <- fmt.Errorf("error encoding audio: %w", err)
+			return
+		}
+
+		select {
+		case frames <- data[:n]:
+		case <-done:
+			errc <- nil
+			return
+		}
+	}
+	errc <- nil
+}
+
+// resample converts interleaved PCM between sample rates by linear
+// interpolation, e.g. 24kHz TTS output to the 48kHz Discord plays
+func resample(pcm []int16, from, to, channels int) []int16 {
+	if from == to || from <= 0 || len(pcm) < channels {
+		return pcm
+	}
+
+	inFrames := len(pcm) / channels
+	outFrames := int(int64(inFrames) * int64(to) / int64(from))
+	out := make([]int16, outFrames*channels)
+	step := float64(from) / float64(to)
+	for i := 0; i < outFrames; i++ {
+		pos := float64(i) * step
+		j := int(pos)
+		frac := pos - float64(j)
+		next := min(j+1, inFrames-1)
+		for c := 0; c < channels; c++ {
+			a := float64(pcm[j*channels+c])
+			b := float64(pcm[next*channels+c])
+			out[i*channels+c] = int16(a + (b-a)*frac)
+		}
+	}
+	return out
+}
@@ -0,0 +1,153 @@
+package voice
+
+import (
+	"context"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// minSentenceLength merges very short sentences into the next one, which
+	// saves a TTS request without delaying speech noticeably
+	minSentenceLength = 20
+	// maxSentenceLength splits run-on text at a comma or space
+	maxSentenceLength = 300
+)
+
+// speechMarkup is markdown that shouldn't be read aloud
+var speechMarkup = strings.NewReplacer("**", "", "__", "", "`", "", "#", "")
+
+// SpeakStream speaks text that arrives in pieces, such as a streaming
+// completion, a sentence at a time: the first sentence plays while later ones
+// are still being generated, and each sentence is synthesized while the one
+// before it plays. pieces must be closed once the text is complete.
+func (s *Service) SpeakStream(ctx context.Context, vc *discordgo.VoiceConnection, pieces <-chan string) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	sentences := make(chan string, 8)
+	go splitSentences(ctx, pieces, sentences)
+
+	type clip struct {
+		text string
+		pcm  []int16
+	}
+	// One clip is synthesized ahead of the one playing
+	clips := make(chan clip, 1)
+	synthErr := make(chan error, 1)
+	go func() {
+		defer close(clips)
+		for sentence := range sentences {
+			pcm, err := s.synthesize(ctx, vc.GuildID, sentence)
+			if err != nil {
+				synthErr <- err
+				return
+			}
+			s.pcmSamples.Add(int64(len(pcm)))
+			select {
+			case clips <- clip{text: sentence, pcm: pcm}:
+			case <-ctx.Done():
+				s.pcmSamples.Add(-int64(len(pcm)))
+				return
+			}
+		}
+	}()
+	defer func() {
+		cancel()
+		for c := range clips {
+			s.pcmSamples.Add(-int64(len(c.pcm)))
+		}
+	}()
+
+	for c := range clips {
+		err := s.play(ctx, vc, c.pcm, c.text)
+		s.pcmSamples.Add(-int64(len(c.pcm)))
+		if err != nil {
+			return err
+		}
+	}
+	select {
+	case err := <-synthErr:
+		return err
+	default:
+		return nil
+	}
+}
+
+// splitSentences regroups pieces of text into sentences and closes sentences
+// when pieces is closed. pieces is always drained, so its writer never blocks.
+func splitSentences(ctx context.Context, pieces <-chan string, sentences chan<- string) {
+	defer close(sentences)
+
+	var buf strings.Builder
+	emit := func(text string) bool {
+		text = strings.TrimSpace(speechMarkup.Replace(text))
+		if text == "" {
+			return true
+		}
+		select {
+		case sentences <- text:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	for piece := range pieces {
+		buf.WriteString(piece)
+		for {
+			cut := sentenceEnd(buf.String())
+			if cut < 0 {
+				break
+			}
+			text := buf.String()
+			buf.Reset()
+			buf.WriteString(text[cut:])
+			if !emit(text[:cut]) {
+				for range pieces {
+				}
+				return
+			}
+		}
+	}
+	emit(buf.String())
+}
+
+// sentenceEnd returns where the first complete sentence of text ends, or -1.
+// A sentence ends at a line break or at ".", "!", "?" or "…" followed by a
+// space; shorter ones wait for the next, and overlong ones are split.
+func sentenceEnd(text string) int {
+	for i, r := range text {
+		if i < minSentenceLength {
+			continue
+		}
+		if r == '\n' {
+			return i + 1
+		}
+		if !strings.ContainsRune(".!?…", r) {
+			continue
+		}
+		next := i + len(string(r))
+		if next < len(text) && unicode.IsSpace(rune(text[next])) {
+			return next
+		}
+	}
+	if len(text) > maxSentenceLength {
+		head := text[:maxSentenceLength]
+		if cut := strings.LastIndexAny(head, ",;:"); cut > minSentenceLength {
+			return cut + 1
+		}
+		if cut := strings.LastIndex(head, " "); cut > minSentenceLength {
+			return cut + 1
+		}
+		cut := maxSentenceLength
+		for cut > 0 && !utf8.RuneStart(text[cut]) {
+			cut--
+		}
+		return cut
+	}
+	return -1
+}
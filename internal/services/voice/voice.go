@@ -4,90 +4,160 @@ import (
 	"bytes"
 	"context"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"io"
 	"log"
+	"net/http"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/hajimehoshi/go-mp3"
 	"github.com/hraban/opus"
 	"github.com/sashabaranov/go-openai"
+
+	"discord-tars/internal/services/persona"
+	"discord-tars/internal/storage"
+)
+
+const (
+	// listenTimeout is how long Listen waits for someone to start speaking
+	listenTimeout = 5 * time.Second
+	// silenceGap is the pause that ends an utterance
+	silenceGap   = 1200 * time.Millisecond
+	maxUtterance = 30 * time.Second
+	// partialInterval is how much more audio is captured between partial transcriptions
+	partialInterval = 3 * time.Second
 )
 
 const (
 	channels  = 2                          // Stereo audio
-	frameRate = 24000                      // Match OpenAI TTS output (24kHz)
-	frameSize = 480                        // 20ms frame size at 24kHz (480 samples per 20ms)
+	frameRate = 48000                      // Discord's Opus sample rate; TTS output is resampled to it
+	frameSize = 960                        // 20ms frame size at 48kHz (960 samples per 20ms)
 	maxBytes  = (frameSize * 2 * channels) // Max bytes per frame
 )
 
+// ErrNoAudio means nobody spoke while listening
+var ErrNoAudio = errors.New("no audio data collected")
+
 type Service struct {
 	client     *openai.Client
 	ttsModel   string
-	voiceConns map[string]*discordgo.VoiceConnection
+	voiceConns map[string]*connection
 	voiceMu    sync.Mutex
+	recordings storage.Store // Optional; keeps captured audio when set
+	resolver   ClientResolver
+
+	// Live counters for diagnostics
+	listening  atomic.Int64
+	speaking   atomic.Int64
+	pcmSamples atomic.Int64 // PCM samples held by captures and playbacks in progress
+}
+
+// Stats are the voice pipeline's live counters
+type Stats struct {
+	Connections int   `json:"connections"`
+	Listening   int64 `json:"listening"`
+	Speaking    int64 `json:"speaking"`
+	PCMSamples  int64 `json:"pcm_buffered_samples"`
+	PCMBytes    int64 `json:"pcm_buffered_bytes"`
 }
 
+// ClientResolver returns the OpenAI client to use for a guild, or nil for the default one
+type ClientResolver func(ctx context.Context, guildID int64) (*openai.Client, error)
+
 type Config struct {
 	OpenAIAPIKey string
 	TTSModel     string
+	// HTTPClient sends the API requests, e.g. to record or replay them; nil
+	// uses the default client
+	HTTPClient *http.Client
 }
 
 func NewService(cfg Config) *Service {
 	client := openai.NewClient(cfg.OpenAIAPIKey)
+	if cfg.HTTPClient != nil {
+		config := openai.DefaultConfig(cfg.OpenAIAPIKey)
+		config.HTTPClient = cfg.HTTPClient
+		client = openai.NewClientWithConfig(config)
+	}
 	return &Service{
 		client:     client,
 		ttsModel:   cfg.TTSModel,
-		voiceConns: make(map[string]*discordgo.VoiceConnection),
+		voiceConns: make(map[string]*connection),
 	}
 }
 
-// JoinVoiceChannel joins the specified voice channel and stores the connection
-func (s *Service) JoinVoiceChannel(ctx context.Context, session *discordgo.Session, guildID, channelID string) (*discordgo.VoiceConnection, error) {
-	s.voiceMu.Lock()
-	defer s.voiceMu.Unlock()
+// SetRecordingStore keeps a copy of every captured voice clip
+func (s *Service) SetRecordingStore(store storage.Store) {
+	s.recordings = store
+}
 
-	if vc, exists := s.voiceConns[guildID]; exists && vc != nil && vc.Ready {
-		if vc.ChannelID == channelID {
-			return vc, nil
-		}
-		vc.Close()
-	}
+// SetClientResolver lets guilds use their own OpenAI key for speech
+func (s *Service) SetClientResolver(resolver ClientResolver) {
+	s.resolver = resolver
+}
 
-	vc, err := session.ChannelVoiceJoin(guildID, channelID, false, false) // Enable receiving
+func (s *Service) clientFor(ctx context.Context, guildID string) (*openai.Client, error) {
+	if s.resolver == nil {
+		return s.client, nil
+	}
+	id, _ := strconv.ParseInt(guildID, 10, 64)
+	client, err := s.resolver(ctx, id)
 	if err != nil {
-		return nil, fmt.Errorf("failed to join voice channel: %w", err)
+		return nil, err
 	}
-
-	s.voiceConns[guildID] = vc
-	log.Printf("✅ Joined voice channel %s in guild %s", channelID, guildID)
-	return vc, nil
+	if client == nil {
+		return s.client, nil
+	}
+	return client, nil
 }
 
 // SpeakText generates TTS audio and plays it in the voice channel
 func (s *Service) SpeakText(ctx context.Context, vc *discordgo.VoiceConnection, text string) error {
+	pcm, err := s.synthesize(ctx, vc.GuildID, text)
+	if err != nil {
+		return err
+	}
+	s.pcmSamples.Add(int64(len(pcm)))
+	defer s.pcmSamples.Add(-int64(len(pcm)))
+
+	return s.play(ctx, vc, pcm, text)
+}
+
+// synthesize generates TTS audio for text as 48kHz stereo PCM
+func (s *Service) synthesize(ctx context.Context, guildID, text string) ([]int16, error) {
 	req := openai.CreateSpeechRequest{
 		Model: openai.SpeechModel(s.ttsModel),
 		Input: text,
 		Voice: openai.VoiceAlloy,
 	}
-	resp, err := s.client.CreateSpeech(ctx, req)
+	if def := persona.FromContext(ctx); def != nil && def.Voice != "" {
+		req.Voice = openai.SpeechVoice(def.Voice)
+	}
+	client, err := s.clientFor(ctx, guildID)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.CreateSpeech(ctx, req)
 	if err != nil {
-		return fmt.Errorf("failed to generate TTS audio: %w", err)
+		return nil, fmt.Errorf("failed to generate TTS audio: %w", err)
 	}
 	defer resp.Close()
 
 	audio, err := io.ReadAll(resp)
 	if err != nil {
-		return fmt.Errorf("failed to read TTS audio: %w", err)
+		return nil, fmt.Errorf("failed to read TTS audio: %w", err)
 	}
 	log.Printf("📢 Received %d bytes of TTS audio", len(audio))
 
 	decoder, err := mp3.NewDecoder(bytes.NewReader(audio))
 	if err != nil {
-		return fmt.Errorf("failed to create MP3 decoder: %w", err)
+		return nil, fmt.Errorf("failed to create MP3 decoder: %w", err)
 	}
 
 	// Log sample rate for debugging
@@ -101,7 +171,7 @@ func (s *Service) SpeakText(ctx context.Context, vc *discordgo.VoiceConnection,
 			if err == io.EOF {
 				break
 			}
-			return fmt.Errorf("failed to decode MP3: %w", err)
+			return nil, fmt.Errorf("failed to decode MP3: %w", err)
 		}
 		if n == 0 {
 			continue
@@ -115,105 +185,143 @@ func (s *Service) SpeakText(ctx context.Context, vc *discordgo.VoiceConnection,
 			pcm = append(pcm, sample)
 		}
 	}
-	log.Printf("📢 Decoded PCM: %d samples (expected multiple of %d for %dms frames)",
-		len(pcm), frameSize*channels, frameSize*1000/frameRate)
-
-	enc, err := opus.NewEncoder(frameRate, channels, opus.AppVoIP)
-	if err != nil {
-		return fmt.Errorf("failed to create Opus encoder: %w", err)
-	}
-	enc.SetBitrate(64000)
-	if err := enc.SetInBandFEC(true); err != nil {
-		log.Printf("⚠️ Failed to enable FEC: %v", err)
-	}
-	log.Printf("📢 Using encoder: %d Hz, %d channels, %d kbps", frameRate, channels, 64)
+	log.Printf("📢 Decoded PCM: %d samples at %d Hz", len(pcm), decoder.SampleRate())
 
-	vc.Speaking(true)
-	defer vc.Speaking(false)
-
-	for i := 0; i < len(pcm); i += frameSize * channels {
-		end := i + frameSize*channels
-		if end > len(pcm) {
-			end = len(pcm)
-		}
-		sample := pcm[i:end]
-
-		if len(sample) < frameSize*channels {
-			padding := make([]int16, frameSize*channels-len(sample))
-			log.Printf("⚠️ Padding frame with %d zeros", len(padding))
-			sample = append(sample, padding...)
-		}
-
-		opusData := make([]byte, maxBytes)
-		n, err := enc.Encode(sample, opusData)
-		if err != nil {
-			log.Printf("⚠️ Error encoding audio: %v (sample size: %d)", err, len(sample))
-			return fmt.Errorf("error encoding audio: %w", err)
-		}
-		opusData = opusData[:n]
+	return resample(pcm, decoder.SampleRate(), frameRate, channels), nil
+}
 
-		select {
-		case vc.OpusSend <- opusData:
-			log.Printf("📢 Sent Opus frame: %d bytes", n)
-		case <-ctx.Done():
-			return ctx.Err()
-		}
+// interrupted handles playback cut off by a dropped connection: the text is
+// spoken again from the start once the manager has reconnected
+func (s *Service) interrupted(guildID, text string) error {
+	if !s.requeue(guildID, text) {
+		return fmt.Errorf("voice connection lost during playback")
 	}
-
+	log.Printf("⏸️ Voice connection lost during playback in guild %s; speech queued for after reconnecting", guildID)
 	return nil
 }
 
-// ListenToVoice captures incoming audio, transcribes it using OpenAI Whisper, and returns the text
+// ListenToVoice captures the next utterance and transcribes it using OpenAI Whisper
 func (s *Service) ListenToVoice(ctx context.Context, vc *discordgo.VoiceConnection) (string, error) {
+	return s.Listen(ctx, vc, nil)
+}
+
+// Listen captures the next utterance, from when someone starts speaking until
+// they pause, and returns its transcription. While the utterance goes on,
+// onPartial (if set) receives transcriptions of the audio so far every few
+// seconds; it is never called after Listen returns.
+func (s *Service) Listen(ctx context.Context, vc *discordgo.VoiceConnection, onPartial func(text string)) (string, error) {
 	log.Printf("🎧 Starting to listen to voice channel")
+	s.listening.Add(1)
+	defer s.listening.Add(-1)
 
 	var pcmBuffer []int16
+	defer func() { s.pcmSamples.Add(-int64(len(pcmBuffer))) }()
 	decoder, err := opus.NewDecoder(frameRate, channels)
 	if err != nil {
 		return "", fmt.Errorf("failed to create Opus decoder: %w", err)
 	}
 
-	// Collect audio for 5 seconds
-	timeout := time.After(5 * time.Second)
+	partialCtx, cancelPartials := context.WithCancel(ctx)
+	defer cancelPartials()
+	var partials sync.WaitGroup
+	var partialBusy atomic.Bool
+	nextPartial := partialInterval
+
+	waiting := time.NewTimer(listenTimeout)
+	defer waiting.Stop()
+	silence := time.NewTimer(silenceGap)
+	silence.Stop()
+	defer silence.Stop()
+	var deadline <-chan time.Time
+
+capture:
 	for {
 		select {
-		case data := <-vc.OpusSend:
-			if data == nil {
+		case packet, ok := <-vc.OpusRecv:
+			if !ok {
+				return "", fmt.Errorf("voice connection closed")
+			}
+			if packet == nil || len(packet.Opus) == 0 {
 				continue
 			}
-			log.Printf("🎧 Received Opus frame: %d bytes", len(data))
 			pcm := make([]int16, frameSize*channels)
-			n, err := decoder.Decode(data, pcm)
+			n, err := decoder.Decode(packet.Opus, pcm)
 			if err != nil {
 				log.Printf("⚠️ Error decoding Opus: %v", err)
 				continue
 			}
-			log.Printf("🎧 Decoded %d PCM samples", n)
-			pcmBuffer = append(pcmBuffer, pcm[:n]...)
-		case <-timeout:
-			log.Printf("🎧 Finished collecting audio, total samples: %d", len(pcmBuffer))
-			goto transcription
+			// Decode counts samples per channel
+			pcmBuffer = append(pcmBuffer, pcm[:n*channels]...)
+			s.pcmSamples.Add(int64(n * channels))
+
+			if deadline == nil {
+				waiting.Stop()
+				deadline = time.After(maxUtterance)
+			}
+			silence.Reset(silenceGap)
+
+			// Only one partial transcription runs at a time; later audio waits for the next
+			if captured := pcmDuration(len(pcmBuffer)); onPartial != nil && captured >= nextPartial && partialBusy.CompareAndSwap(false, true) {
+				nextPartial = captured + partialInterval
+				// Earlier samples aren't modified by later appends, so this is safe to share
+				snapshot := pcmBuffer[:len(pcmBuffer):len(pcmBuffer)]
+				partials.Add(1)
+				go func() {
+					defer partials.Done()
+					defer partialBusy.Store(false)
+					text, err := s.transcribe(partialCtx, vc, snapshot, false)
+					if err != nil {
+						if partialCtx.Err() == nil {
+							log.Printf("⚠️ Partial transcription failed: %v", err)
+						}
+						return
+					}
+					if text = strings.TrimSpace(text); text != "" && partialCtx.Err() == nil {
+						onPartial(text)
+					}
+				}()
+			}
+		case <-waiting.C:
+			return "", ErrNoAudio
+		case <-silence.C:
+			break capture
+		case <-deadline:
+			break capture
 		case <-ctx.Done():
 			return "", ctx.Err()
 		}
 	}
+	cancelPartials()
+	partials.Wait()
 
-transcription:
-	if len(pcmBuffer) == 0 {
-		return "", fmt.Errorf("no audio data collected")
+	log.Printf("🎧 Finished collecting audio, total samples: %d", len(pcmBuffer))
+	text, err := s.transcribe(ctx, vc, pcmBuffer, true)
+	if err != nil {
+		return "", err
 	}
+	log.Printf("🎤 Transcribed text: %s", text)
+	return text, nil
+}
 
+// transcribe converts PCM to a WAV file and transcribes it with Whisper;
+// record also keeps the audio in the recording store
+func (s *Service) transcribe(ctx context.Context, vc *discordgo.VoiceConnection, pcm []int16, record bool) (string, error) {
 	// Convert PCM to WAV format for Whisper API
 	wavBuffer := new(bytes.Buffer)
 	// Write WAV header
-	err = writeWAVHeader(wavBuffer, len(pcmBuffer), frameRate, channels, 16)
+	err := writeWAVHeader(wavBuffer, len(pcm)/channels, frameRate, channels, 16)
 	if err != nil {
 		return "", fmt.Errorf("failed to write WAV header: %w", err)
 	}
 	// Write PCM data
-	for _, sample := range pcmBuffer {
-		if err := binary.Write(wavBuffer, binary.LittleEndian, sample); err != nil {
-			return "", fmt.Errorf("failed to write PCM data: %w", err)
+	if err := binary.Write(wavBuffer, binary.LittleEndian, pcm); err != nil {
+		return "", fmt.Errorf("failed to write PCM data: %w", err)
+	}
+
+	if record && s.recordings != nil {
+		key := fmt.Sprintf("%s%s/%s/%d.wav", storage.PrefixRecordings, vc.GuildID, vc.ChannelID, time.Now().UnixNano())
+		if err := s.recordings.Put(ctx, key, bytes.NewReader(wavBuffer.Bytes()), int64(wavBuffer.Len()), "audio/wav"); err != nil {
+			log.Printf("⚠️ Failed to store recording: %v", err)
 		}
 	}
 
@@ -223,15 +331,22 @@ transcription:
 		Reader:   wavBuffer,
 		FilePath: "audio.wav", // FilePath is required by the API, even though we're using Reader
 	}
-	resp, err := s.client.CreateTranscription(ctx, req)
+	client, err := s.clientFor(ctx, vc.GuildID)
+	if err != nil {
+		return "", err
+	}
+	resp, err := client.CreateTranscription(ctx, req)
 	if err != nil {
 		return "", fmt.Errorf("failed to transcribe audio: %w", err)
 	}
-
-	log.Printf("🎤 Transcribed text: %s", resp.Text)
 	return resp.Text, nil
 }
 
+// pcmDuration is how long n interleaved samples play for
+func pcmDuration(n int) time.Duration {
+	return time.Duration(n/channels) * time.Second / frameRate
+}
+
 // writeWAVHeader writes a WAV file header to the buffer
 func writeWAVHeader(w *bytes.Buffer, numSamples, sampleRate, channels, bitsPerSample int) error {
 	dataSize := numSamples * channels * (bitsPerSample / 8)
@@ -277,14 +392,19 @@ func writeWAVHeader(w *bytes.Buffer, numSamples, sampleRate, channels, bitsPerSa
 	return nil
 }
 
-// DisconnectVoice disconnects from the voice channel in the guild
-func (s *Service) DisconnectVoice(guildID string) {
+// Stats reports open connections and the audio currently buffered, to help
+// track down memory growth in voice handling
+func (s *Service) Stats() Stats {
 	s.voiceMu.Lock()
-	defer s.voiceMu.Unlock()
-
-	if vc, exists := s.voiceConns[guildID]; exists && vc != nil {
-		vc.Close()
-		delete(s.voiceConns, guildID)
-		log.Printf("✅ Disconnected from voice channel in guild %s", guildID)
+	connections := len(s.voiceConns)
+	s.voiceMu.Unlock()
+
+	samples := s.pcmSamples.Load()
+	return Stats{
+		Connections: connections,
+		Listening:   s.listening.Load(),
+		Speaking:    s.speaking.Load(),
+		PCMSamples:  samples,
+		PCMBytes:    samples * 2, // 16-bit samples
 	}
 }
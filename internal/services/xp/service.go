@@ -0,0 +1,272 @@
+// Package xp awards members activity XP per guild, for their messages and the
+// reactions those messages receive, ranks them on a leaderboard, and has the
+// bot congratulate them in its own voice when they reach a milestone level.
+package xp
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"discord-tars/internal/interfaces"
+	"discord-tars/internal/models"
+	"discord-tars/internal/repository"
+	"discord-tars/internal/sanitize"
+)
+
+const (
+	// Default XP settings of a guild
+	DefaultMessageXP  = 10
+	DefaultReactionXP = 5
+	DefaultCooldown   = time.Minute
+
+	// MaxActivityXP caps what a single message or reaction is worth
+	MaxActivityXP = 100
+	// MaxCooldown is the longest a guild can make members wait between
+	// messages that earn XP
+	MaxCooldown = time.Hour
+
+	configTTL       = 5 * time.Minute
+	maxCachedGuilds = 1000
+	maxCommentChars = 400
+)
+
+const commentaryPrompt = `%s just reached level %d on this Discord server, with %d XP earned by chatting and by the reactions their messages got.
+Write one or two short sentences congratulating them on this milestone, in your own voice and personality.
+Be playful but kind, don't mention or tag anyone else, don't repeat the numbers back, and reply with the message only.`
+
+type Service struct {
+	aiService interfaces.AIService
+	repo      *repository.XPRepository
+	session   *discordgo.Session
+
+	mu      sync.Mutex
+	configs map[int64]cachedConfig
+}
+
+type cachedConfig struct {
+	cfg      *models.XPConfig // nil when XP is off
+	loadedAt time.Time
+}
+
+// LevelUp is a member reaching a milestone level
+type LevelUp struct {
+	GuildID   int64
+	UserID    int64
+	ChannelID int64 // Where it is announced
+	Level     int
+	XP        int64
+}
+
+func NewService(aiService interfaces.AIService, repo *repository.XPRepository, session *discordgo.Session) *Service {
+	return &Service{
+		aiService: aiService,
+		repo:      repo,
+		session:   session,
+		configs:   make(map[int64]cachedConfig),
+	}
+}
+
+// LevelXP is the total XP a member needs to reach a level: 100 for level 1,
+// 300 for level 2, 5,500 for level 10
+func LevelXP(level int) int64 {
+	return 50 * int64(level) * int64(level+1)
+}
+
+// Level is the level a member with xp has reached
+func Level(xp int64) int {
+	level := 0
+	for LevelXP(level+1) <= xp {
+		level++
+	}
+	return level
+}
+
+// Milestone tells whether reaching a level is announced: the first level,
+// then every fifth
+func Milestone(level int) bool {
+	return level == 1 || (level > 0 && level%5 == 0)
+}
+
+// Configure validates and stores a guild's XP settings
+func (s *Service) Configure(ctx context.Context, cfg *models.XPConfig) error {
+	if cfg.MessageXP < 0 || cfg.MessageXP > MaxActivityXP || cfg.ReactionXP < 0 || cfg.ReactionXP > MaxActivityXP {
+		return fmt.Errorf("XP per message and per reaction must be between 0 and %d", MaxActivityXP)
+	}
+	if cfg.CooldownSeconds < 0 || cfg.CooldownSeconds > int(MaxCooldown.Seconds()) {
+		return fmt.Errorf("the cooldown must be between 0 and %d seconds", int(MaxCooldown.Seconds()))
+	}
+	if err := s.repo.SaveConfig(ctx, cfg); err != nil {
+		return err
+	}
+	s.cacheConfig(cfg.GuildID, cfg)
+	return nil
+}
+
+// Disable turns XP off for a guild, reporting whether it was on
+func (s *Service) Disable(ctx context.Context, guildID int64) (bool, error) {
+	removed, err := s.repo.DeleteConfig(ctx, guildID)
+	if err != nil {
+		return false, err
+	}
+	s.cacheConfig(guildID, nil)
+	return removed, nil
+}
+
+// Config returns a guild's XP settings, or nil if XP is off
+func (s *Service) Config(ctx context.Context, guildID int64) (*models.XPConfig, error) {
+	s.mu.Lock()
+	cached, ok := s.configs[guildID]
+	s.mu.Unlock()
+	if ok && time.Since(cached.loadedAt) < configTTL {
+		return cached.cfg, nil
+	}
+	cfg, err := s.repo.GetConfig(ctx, guildID)
+	if err != nil {
+		return nil, err
+	}
+	s.cacheConfig(guildID, cfg)
+	return cfg, nil
+}
+
+func (s *Service) cacheConfig(guildID int64, cfg *models.XPConfig) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.configs) >= maxCachedGuilds {
+		s.configs = make(map[int64]cachedConfig)
+	}
+	s.configs[guildID] = cachedConfig{cfg: cfg, loadedAt: time.Now()}
+}
+
+// HandleMessage awards XP for a message, returning the milestone its author
+// reached if it is to be announced
+func (s *Service) HandleMessage(ctx context.Context, msg *discordgo.Message) (*LevelUp, error) {
+	if msg.Author == nil || msg.Author.Bot || msg.GuildID == "" {
+		return nil, nil
+	}
+	guildID, _ := strconv.ParseInt(msg.GuildID, 10, 64)
+	cfg, err := s.Config(ctx, guildID)
+	if err != nil || cfg == nil || cfg.MessageXP == 0 {
+		return nil, err
+	}
+
+	userID, _ := strconv.ParseInt(msg.Author.ID, 10, 64)
+	channelID, _ := strconv.ParseInt(msg.ChannelID, 10, 64)
+	at := msg.Timestamp
+	if at.IsZero() {
+		at = time.Now()
+	}
+	cooldownStart := at.Add(-time.Duration(cfg.CooldownSeconds) * time.Second)
+	gain, err := s.repo.AddMessageXP(ctx, guildID, userID, int64(cfg.MessageXP), at, cooldownStart)
+	if err != nil || gain == nil {
+		return nil, err
+	}
+	return levelUp(cfg, gain, channelID), nil
+}
+
+// HandleReaction awards the author of a message XP for a reaction it
+// received, or takes it back when delta is -1 for a removed reaction
+func (s *Service) HandleReaction(ctx context.Context, r *discordgo.MessageReaction, delta int) (*LevelUp, error) {
+	guildID, _ := strconv.ParseInt(r.GuildID, 10, 64)
+	cfg, err := s.Config(ctx, guildID)
+	if err != nil || cfg == nil || cfg.ReactionXP == 0 {
+		return nil, err
+	}
+
+	messageID, err := strconv.ParseInt(r.MessageID, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse message ID: %w", err)
+	}
+	reactorID, _ := strconv.ParseInt(r.UserID, 10, 64)
+	channelID, _ := strconv.ParseInt(r.ChannelID, 10, 64)
+	gain, err := s.repo.AddReactionXP(ctx, messageID, reactorID, int64(cfg.ReactionXP), delta)
+	if err != nil || gain == nil {
+		return nil, err
+	}
+	return levelUp(cfg, gain, channelID), nil
+}
+
+// levelUp returns the highest milestone a gain crossed, if the guild
+// announces them
+func levelUp(cfg *models.XPConfig, gain *repository.XPGain, channelID int64) *LevelUp {
+	if !cfg.Announce || gain.Gained <= 0 {
+		return nil
+	}
+	before, after := Level(gain.XP-gain.Gained), Level(gain.XP)
+	for level := after; level > before; level-- {
+		if !Milestone(level) {
+			continue
+		}
+		if cfg.ChannelID != 0 {
+			channelID = cfg.ChannelID
+		}
+		return &LevelUp{GuildID: cfg.GuildID, UserID: gain.UserID, ChannelID: channelID, Level: level, XP: gain.XP}
+	}
+	return nil
+}
+
+// Announce congratulates a member on a milestone. The commentary is written
+// with ctx, so it speaks as the persona ctx was given.
+func (s *Service) Announce(ctx context.Context, up *LevelUp) error {
+	userID := strconv.FormatInt(up.UserID, 10)
+	content := fmt.Sprintf("🎉 <@%s> reached **level %d**!", userID, up.Level)
+
+	name := s.displayName(up.GuildID, userID)
+	comment, err := s.aiService.GenerateResponse(ctx, fmt.Sprintf(commentaryPrompt, name, up.Level, up.XP), name)
+	if err != nil {
+		log.Printf("⚠️ Failed to write level-up commentary for user %d: %v", up.UserID, err)
+	} else if comment = strings.TrimSpace(sanitize.Output(comment)); comment != "" {
+		if runes := []rune(comment); len(runes) > maxCommentChars {
+			comment = string(runes[:maxCommentChars-1]) + "…"
+		}
+		content += "\n" + comment
+	}
+
+	_, err = s.session.ChannelMessageSendComplex(strconv.FormatInt(up.ChannelID, 10), &discordgo.MessageSend{
+		Content:         content,
+		AllowedMentions: &discordgo.MessageAllowedMentions{Users: []string{userID}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to announce level up: %w", err)
+	}
+	log.Printf("🎉 User %d reached level %d in guild %d", up.UserID, up.Level, up.GuildID)
+	return nil
+}
+
+// displayName names a member for the commentary, falling back to "someone"
+func (s *Service) displayName(guildID int64, userID string) string {
+	if member, err := s.session.State.Member(strconv.FormatInt(guildID, 10), userID); err == nil && member.User != nil {
+		return member.DisplayName()
+	}
+	user, err := s.session.User(userID)
+	if err != nil {
+		return "someone"
+	}
+	if user.GlobalName != "" {
+		return user.GlobalName
+	}
+	return user.Username
+}
+
+// Leaderboard returns a page of a guild's members with the most XP, and how
+// many members have XP
+func (s *Service) Leaderboard(ctx context.Context, guildID int64, offset, limit int) ([]models.MemberXP, int64, error) {
+	total, err := s.repo.CountRanked(ctx, guildID)
+	if err != nil || total == 0 {
+		return nil, total, err
+	}
+	members, err := s.repo.Leaderboard(ctx, guildID, offset, limit)
+	return members, total, err
+}
+
+// Rank returns a member's XP and place on the leaderboard, or nil if they
+// have none
+func (s *Service) Rank(ctx context.Context, guildID, userID int64) (*models.MemberXP, int, error) {
+	return s.repo.Rank(ctx, guildID, userID)
+}
@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Rule expires objects under a prefix once they are older than MaxAge
+type Rule struct {
+	Prefix string
+	MaxAge time.Duration
+}
+
+// Janitor applies lifecycle rules to a store
+type Janitor struct {
+	store Store
+	rules []Rule
+}
+
+// NewJanitor creates a janitor; rules with a zero MaxAge keep objects forever
+func NewJanitor(store Store, rules ...Rule) *Janitor {
+	var active []Rule
+	for _, rule := range rules {
+		if rule.MaxAge > 0 {
+			active = append(active, rule)
+		}
+	}
+	return &Janitor{store: store, rules: active}
+}
+
+// Cleanup is the scheduler job: it deletes expired objects
+func (j *Janitor) Cleanup(ctx context.Context) error {
+	now := time.Now()
+	for _, rule := range j.rules {
+		objects, err := j.store.List(ctx, rule.Prefix)
+		if err != nil {
+			return err
+		}
+
+		deleted := 0
+		for _, obj := range objects {
+			if now.Sub(obj.LastModified) < rule.MaxAge {
+				continue
+			}
+			if err := j.store.Delete(ctx, obj.Key); err != nil {
+				log.Printf("⚠️ Failed to delete expired object %s: %v", obj.Key, err)
+				continue
+			}
+			deleted++
+		}
+		if deleted > 0 {
+			log.Printf("🧹 Deleted %d expired objects under %s", deleted, rule.Prefix)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Key prefixes used by the bot's features; lifecycle rules are configured per prefix
+const (
+	PrefixAttachments = "attachments/"
+	PrefixRecordings  = "recordings/"
+	PrefixImages      = "images/"
+	PrefixDocuments   = "documents/"
+	PrefixBackups     = "backups/"
+	PrefixExports     = "exports/"
+)
+
+// ErrNotFound is returned when an object does not exist
+var ErrNotFound = errors.New("object not found")
+
+// Object describes a stored object
+type Object struct {
+	Key          string
+	Size         int64
+	LastModified time.Time
+}
+
+// Store is a blob store for files the bot keeps or re-serves
+type Store interface {
+	// Put writes an object; size may be -1 when unknown
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	// Get opens an object for reading
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes an object; deleting a missing object is not an error
+	Delete(ctx context.Context, key string) error
+	// List returns the objects whose keys start with prefix
+	List(ctx context.Context, prefix string) ([]Object, error)
+	// SignedURL returns a time-limited URL that serves the object without credentials
+	SignedURL(ctx context.Context, key string, ttl time.Duration) (string, error)
+}
+
+// Config selects and configures a backend
+type Config struct {
+	Backend string // "local" or "s3"
+
+	LocalPath  string
+	PublicURL  string // Base URL of this bot's HTTP server, used for local signed URLs
+	SigningKey string // HMAC key for local signed URLs
+
+	S3Endpoint  string
+	S3Region    string
+	S3Bucket    string
+	S3AccessKey string
+	S3SecretKey string
+	S3PathStyle bool // Required by most S3-compatible servers such as MinIO
+}
+
+// New creates the configured store
+func New(cfg Config) (Store, error) {
+	switch cfg.Backend {
+	case "", "local":
+		return NewLocalStore(cfg.LocalPath, cfg.PublicURL, cfg.SigningKey)
+	case "s3":
+		return NewS3Store(cfg)
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", cfg.Backend)
+	}
+}
+
+// ValidKey reports whether a key is safe to use on every backend
+func ValidKey(key string) bool {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return false
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return false
+		}
+	}
+	return true
+}
+
+// SanitizeName makes a user-supplied file name safe to use as a key segment
+func SanitizeName(name string) string {
+	var sb strings.Builder
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_':
+			sb.WriteRune(r)
+		default:
+			sb.WriteRune('_')
+		}
+	}
+	cleaned := strings.Trim(sb.String(), ".")
+	if cleaned == "" {
+		return "file"
+	}
+	if len(cleaned) > 128 {
+		cleaned = cleaned[len(cleaned)-128:]
+	}
+	return cleaned
+}
+
+// escapeKey percent-encodes each path segment of a key with the strict
+// RFC 3986 rules that SigV4 canonical requests expect
+func escapeKey(key string) string {
+	parts := strings.Split(key, "/")
+	for i, part := range parts {
+		parts[i] = strings.ReplaceAll(url.QueryEscape(part), "+", "%20")
+	}
+	return strings.Join(parts, "/")
+}
@@ -0,0 +1,20 @@
+package tenant
+
+import "context"
+
+type guildKey struct{}
+
+// WithGuild tags a context with the guild a request is made on behalf of, so
+// downstream services can bill and configure per guild
+func WithGuild(ctx context.Context, guildID int64) context.Context {
+	if guildID == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, guildKey{}, guildID)
+}
+
+// GuildFrom returns the guild a context was tagged with, if any
+func GuildFrom(ctx context.Context) (int64, bool) {
+	guildID, ok := ctx.Value(guildKey{}).(int64)
+	return guildID, ok && guildID != 0
+}
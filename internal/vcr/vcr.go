@@ -0,0 +1,328 @@
+// Package vcr records the AI providers' HTTP responses to fixture files and
+// replays them, so the whole bot can run in local development (OFFLINE=1)
+// without API keys or cost. Requests are matched by method, path and body;
+// a request with no recording gets a stand-in answer instead of failing:
+// a canned chat reply, and a deterministic embedding so indexing and search
+// keep working.
+package vcr
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"math/rand"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Mode is whether AI requests are recorded, replayed or sent as usual
+type Mode int
+
+const (
+	Off Mode = iota
+	Record
+	Replay
+)
+
+// embeddingDimensions is the size of stand-in embeddings, which the schema's
+// vector columns require
+const embeddingDimensions = 1536
+
+const offlineReply = "(offline) There is no recorded answer for this prompt. Run once with AI_RECORD=true and an API key to record one."
+
+// ModeFor is the mode of the OFFLINE and AI_RECORD settings
+func ModeFor(offline, record bool) Mode {
+	switch {
+	case offline:
+		return Replay
+	case record:
+		return Record
+	}
+	return Off
+}
+
+// Config says what to do with AI requests and where fixtures are kept
+type Config struct {
+	Mode Mode
+	Dir  string
+}
+
+// Fixture is a recorded request and the response it got
+type Fixture struct {
+	Request struct {
+		Method string          `json:"method"`
+		Path   string          `json:"path"`
+		Body   json.RawMessage `json:"body,omitempty"` // Only JSON bodies are kept
+	} `json:"request"`
+	Response struct {
+		Status      int    `json:"status"`
+		ContentType string `json:"content_type,omitempty"`
+		Body        string `json:"body,omitempty"`
+		BodyBase64  []byte `json:"body_base64,omitempty"` // Binary bodies, such as speech
+	} `json:"response"`
+}
+
+// NewClient returns an HTTP client that records or replays AI requests, or
+// nil when cfg.Mode is Off so services keep their own client
+func NewClient(cfg Config) *http.Client {
+	if cfg.Mode == Off {
+		return nil
+	}
+	return &http.Client{Transport: NewTransport(cfg, http.DefaultTransport), Timeout: 2 * time.Minute}
+}
+
+// Transport records the responses next returns, or replays recorded ones
+type Transport struct {
+	cfg  Config
+	next http.RoundTripper
+	mu   sync.Mutex // Serializes fixture writes
+}
+
+func NewTransport(cfg Config, next http.RoundTripper) *Transport {
+	return &Transport{cfg: cfg, next: next}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return nil, fmt.Errorf("vcr: failed to read request: %w", err)
+		}
+		req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	path := filepath.Join(t.cfg.Dir, fixtureName(req, body))
+
+	if t.cfg.Mode == Replay {
+		fixture, err := load(path)
+		if err != nil {
+			return nil, err
+		}
+		if fixture == nil {
+			log.Printf("📼 No recording for %s %s, answering offline", req.Method, req.URL.Path)
+			return standIn(req, body), nil
+		}
+		return fixture.response(req), nil
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("vcr: failed to read response: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+
+	// Rate limits and outages aren't worth replaying
+	if resp.StatusCode < 300 {
+		if err := t.save(path, req, body, resp, data); err != nil {
+			log.Printf("⚠️ Failed to record AI response: %v", err)
+		}
+	}
+	return resp, nil
+}
+
+func (t *Transport) save(path string, req *http.Request, body []byte, resp *http.Response, data []byte) error {
+	var fixture Fixture
+	fixture.Request.Method = req.Method
+	fixture.Request.Path = req.URL.Path
+	if json.Valid(body) {
+		fixture.Request.Body = body
+	}
+	fixture.Response.Status = resp.StatusCode
+	fixture.Response.ContentType = resp.Header.Get("Content-Type")
+	if utf8.Valid(data) {
+		fixture.Response.Body = string(data)
+	} else {
+		fixture.Response.BodyBase64 = data
+	}
+
+	encoded, err := json.MarshalIndent(fixture, "", "  ")
+	if err != nil {
+		return err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(encoded, '\n'), 0o644)
+}
+
+func load(path string) (*Fixture, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("vcr: failed to read fixture: %w", err)
+	}
+	var fixture Fixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return nil, fmt.Errorf("vcr: failed to parse fixture %s: %w", path, err)
+	}
+	return &fixture, nil
+}
+
+func (f *Fixture) response(req *http.Request) *http.Response {
+	body := f.Response.BodyBase64
+	if body == nil {
+		body = []byte(f.Response.Body)
+	}
+	return newResponse(req, f.Response.Status, f.Response.ContentType, body)
+}
+
+// fixtureName identifies a request by its method, path and body. JSON bodies
+// are re-encoded so key order doesn't matter, and multipart boundaries,
+// which are random, are left out.
+func fixtureName(req *http.Request, body []byte) string {
+	var decoded any
+	if json.Unmarshal(body, &decoded) == nil {
+		if canonical, err := json.Marshal(decoded); err == nil {
+			body = canonical
+		}
+	} else if _, params, err := mime.ParseMediaType(req.Header.Get("Content-Type")); err == nil && params["boundary"] != "" {
+		body = bytes.ReplaceAll(body, []byte(params["boundary"]), []byte("boundary"))
+	}
+
+	sum := sha256.New()
+	fmt.Fprintf(sum, "%s %s\n", req.Method, req.URL.Path)
+	sum.Write(body)
+	slug := strings.ReplaceAll(strings.Trim(req.URL.Path, "/"), "/", "-")
+	return fmt.Sprintf("%s-%s.json", slug, hex.EncodeToString(sum.Sum(nil))[:16])
+}
+
+// standIn answers a request nothing was recorded for: chat requests with a
+// canned reply and embeddings with a deterministic vector; anything else,
+// such as speech, fails as the API would
+func standIn(req *http.Request, body []byte) *http.Response {
+	var request struct {
+		Model  string          `json:"model"`
+		Stream bool            `json:"stream"`
+		Input  json.RawMessage `json:"input"`
+	}
+	_ = json.Unmarshal(body, &request)
+
+	switch {
+	case strings.HasSuffix(req.URL.Path, "/chat/completions") && request.Stream:
+		return newResponse(req, http.StatusOK, "text/event-stream", chatStream(request.Model))
+	case strings.HasSuffix(req.URL.Path, "/chat/completions"):
+		return jsonResponse(req, http.StatusOK, map[string]any{
+			"id":      "offline",
+			"object":  "chat.completion",
+			"created": time.Now().Unix(),
+			"model":   request.Model,
+			"choices": []any{map[string]any{
+				"index":         0,
+				"message":       map[string]any{"role": "assistant", "content": offlineReply},
+				"finish_reason": "stop",
+			}},
+		})
+	case strings.HasSuffix(req.URL.Path, "/embeddings"):
+		return jsonResponse(req, http.StatusOK, embeddings(request.Model, request.Input))
+	case strings.HasSuffix(req.URL.Path, "/messages"):
+		return jsonResponse(req, http.StatusOK, map[string]any{
+			"id":          "offline",
+			"type":        "message",
+			"role":        "assistant",
+			"model":       request.Model,
+			"content":     []any{map[string]any{"type": "text", "text": offlineReply}},
+			"stop_reason": "end_turn",
+		})
+	}
+	message := fmt.Sprintf("offline: no recording for %s %s", req.Method, req.URL.Path)
+	return jsonResponse(req, http.StatusNotFound, map[string]any{"error": map[string]any{"type": "offline", "message": message}})
+}
+
+// chatStream is offlineReply as a streamed chat completion
+func chatStream(model string) []byte {
+	var buf bytes.Buffer
+	for _, chunk := range []map[string]any{
+		{"delta": map[string]any{"role": "assistant", "content": offlineReply}},
+		{"delta": map[string]any{}, "finish_reason": "stop"},
+	} {
+		chunk["index"] = 0
+		data, _ := json.Marshal(map[string]any{
+			"id":      "offline",
+			"object":  "chat.completion.chunk",
+			"created": time.Now().Unix(),
+			"model":   model,
+			"choices": []any{chunk},
+		})
+		fmt.Fprintf(&buf, "data: %s\n\n", data)
+	}
+	buf.WriteString("data: [DONE]\n\n")
+	return buf.Bytes()
+}
+
+// embeddings derives a unit vector from each input, so the same text always
+// gets the same embedding
+func embeddings(model string, input json.RawMessage) map[string]any {
+	var texts []string
+	if json.Unmarshal(input, &texts) != nil {
+		var text string
+		_ = json.Unmarshal(input, &text)
+		texts = []string{text}
+	}
+
+	data := make([]any, len(texts))
+	for n, text := range texts {
+		sum := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(text))))
+		rng := rand.New(rand.NewSource(int64(binary.BigEndian.Uint64(sum[:8]))))
+		vector := make([]float32, embeddingDimensions)
+		var norm float64
+		for i := range vector {
+			vector[i] = float32(rng.NormFloat64())
+			norm += float64(vector[i]) * float64(vector[i])
+		}
+		for i := range vector {
+			vector[i] /= float32(math.Sqrt(norm))
+		}
+		data[n] = map[string]any{"object": "embedding", "index": n, "embedding": vector}
+	}
+	return map[string]any{
+		"object": "list",
+		"model":  model,
+		"data":   data,
+		"usage":  map[string]any{"prompt_tokens": 0, "total_tokens": 0},
+	}
+}
+
+func jsonResponse(req *http.Request, status int, v any) *http.Response {
+	data, _ := json.Marshal(v)
+	return newResponse(req, status, "application/json", data)
+}
+
+func newResponse(req *http.Request, status int, contentType string, body []byte) *http.Response {
+	header := make(http.Header)
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
@@ -0,0 +1,225 @@
+package vectorstore
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// errCollectionMissing is returned by requests to a collection not created
+// yet, which happens before the first embedding is stored
+var errCollectionMissing = errors.New("qdrant collection does not exist")
+
+// QdrantStore keeps embeddings in a Qdrant collection, using its REST API.
+// The collection is created with the size of the first vector stored, with
+// the guild and channel indexed for filtered searches.
+type QdrantStore struct {
+	httpClient *http.Client
+	baseURL    string // Collection URL
+	apiKey     string
+
+	mu      sync.Mutex
+	created bool
+}
+
+// NewQdrantStore connects to a collection of a Qdrant server, e.g.
+// http://localhost:6333
+func NewQdrantStore(serverURL, apiKey, collection string) (*QdrantStore, error) {
+	if serverURL == "" {
+		return nil, fmt.Errorf("QDRANT_URL is required for the qdrant vector store")
+	}
+	if collection == "" {
+		collection = "messages"
+	}
+	return &QdrantStore{
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		baseURL:    strings.TrimRight(serverURL, "/") + "/collections/" + url.PathEscape(collection),
+		apiKey:     apiKey,
+	}, nil
+}
+
+type qdrantPoint struct {
+	ID      int64            `json:"id"`
+	Vector  []float32        `json:"vector"`
+	Payload map[string]int64 `json:"payload"`
+}
+
+// qdrantCondition matches a payload field against a value or any of several
+type qdrantCondition struct {
+	Key   string                 `json:"key"`
+	Match map[string]interface{} `json:"match"`
+}
+
+type qdrantFilter struct {
+	Must []qdrantCondition `json:"must"`
+}
+
+func (s *QdrantStore) Upsert(ctx context.Context, points []Point) error {
+	if len(points) == 0 {
+		return nil
+	}
+	if err := s.ensureCollection(ctx, len(points[0].Vector)); err != nil {
+		return err
+	}
+	body := struct {
+		Points []qdrantPoint `json:"points"`
+	}{}
+	for _, p := range points {
+		body.Points = append(body.Points, qdrantPoint{
+			ID:      p.ID,
+			Vector:  p.Vector,
+			Payload: map[string]int64{"guild_id": p.GuildID, "channel_id": p.ChannelID},
+		})
+	}
+	if err := s.do(ctx, http.MethodPut, "/points?wait=true", body, nil); err != nil {
+		return fmt.Errorf("failed to upsert qdrant points: %w", err)
+	}
+	return nil
+}
+
+func (s *QdrantStore) Search(ctx context.Context, vector []float32, filter Filter, limit int, minSimilarity float64) ([]Match, error) {
+	body := map[string]interface{}{
+		"vector":          vector,
+		"limit":           limit,
+		"score_threshold": minSimilarity,
+	}
+	var must []qdrantCondition
+	if filter.GuildID != 0 {
+		must = append(must, qdrantCondition{Key: "guild_id", Match: map[string]interface{}{"value": filter.GuildID}})
+	}
+	if len(filter.ChannelIDs) > 0 {
+		must = append(must, qdrantCondition{Key: "channel_id", Match: map[string]interface{}{"any": filter.ChannelIDs}})
+	}
+	if len(must) > 0 {
+		body["filter"] = qdrantFilter{Must: must}
+	}
+
+	var out struct {
+		Result []struct {
+			ID    int64   `json:"id"`
+			Score float64 `json:"score"`
+		} `json:"result"`
+	}
+	err := s.do(ctx, http.MethodPost, "/points/search", body, &out)
+	if errors.Is(err, errCollectionMissing) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to search qdrant: %w", err)
+	}
+	matches := make([]Match, len(out.Result))
+	for n, r := range out.Result {
+		matches[n] = Match{ID: r.ID, Similarity: r.Score}
+	}
+	return matches, nil
+}
+
+func (s *QdrantStore) Delete(ctx context.Context, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return s.deletePoints(ctx, map[string]interface{}{"points": ids})
+}
+
+func (s *QdrantStore) DeleteGuild(ctx context.Context, guildID int64) error {
+	filter := qdrantFilter{Must: []qdrantCondition{{Key: "guild_id", Match: map[string]interface{}{"value": guildID}}}}
+	return s.deletePoints(ctx, map[string]interface{}{"filter": filter})
+}
+
+// deletePoints deletes the points a selector picks, by ID or by filter
+func (s *QdrantStore) deletePoints(ctx context.Context, selector map[string]interface{}) error {
+	err := s.do(ctx, http.MethodPost, "/points/delete?wait=true", selector, nil)
+	if errors.Is(err, errCollectionMissing) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete qdrant points: %w", err)
+	}
+	return nil
+}
+
+// ensureCollection creates the collection for vectors of a size, with
+// cosine distance, unless it exists
+func (s *QdrantStore) ensureCollection(ctx context.Context, size int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.created {
+		return nil
+	}
+	err := s.do(ctx, http.MethodGet, "", nil, nil)
+	if errors.Is(err, errCollectionMissing) {
+		create := map[string]interface{}{"vectors": map[string]interface{}{"size": size, "distance": "Cosine"}}
+		if err = s.do(ctx, http.MethodPut, "", create, nil); err == nil {
+			for _, field := range []string{"guild_id", "channel_id"} {
+				index := map[string]string{"field_name": field, "field_schema": "integer"}
+				if err = s.do(ctx, http.MethodPut, "/index?wait=true", index, nil); err != nil {
+					break
+				}
+			}
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create qdrant collection: %w", err)
+	}
+	s.created = true
+	return nil
+}
+
+// do sends a request to the collection and decodes the response into out
+func (s *QdrantStore) do(ctx context.Context, method, path string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, s.baseURL+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.apiKey != "" {
+		req.Header.Set("api-key", s.apiKey)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("qdrant api error: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 32<<20))
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return errCollectionMissing
+	}
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Status struct {
+				Error string `json:"error"`
+			} `json:"status"`
+		}
+		if json.Unmarshal(data, &apiErr) == nil && apiErr.Status.Error != "" {
+			return fmt.Errorf("qdrant api error (%d): %s", resp.StatusCode, apiErr.Status.Error)
+		}
+		return fmt.Errorf("qdrant api error: status %d", resp.StatusCode)
+	}
+	if out != nil {
+		if err := json.Unmarshal(data, out); err != nil {
+			return fmt.Errorf("failed to decode qdrant response: %w", err)
+		}
+	}
+	return nil
+}
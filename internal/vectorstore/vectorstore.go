@@ -0,0 +1,76 @@
+// Package vectorstore moves message embedding search out of Postgres, so
+// heavy deployments can scale search on its own. Postgres keeps the
+// embeddings either way, for index health, re-ranking and backups; with an
+// external store they are also written there and searches run against it.
+//
+// pgvector is not a Store: its searches are SQL joined to the messages they
+// load, and its embeddings are deleted along with their messages, so nil
+// stands for it and callers skip the store.
+package vectorstore
+
+import (
+	"context"
+	"fmt"
+)
+
+// Backends
+const (
+	// BackendPgvector searches the embeddings in Postgres, in the same query
+	// that loads the messages; it needs no Store
+	BackendPgvector = "pgvector"
+	BackendQdrant   = "qdrant"
+)
+
+// Point is a message's embedding with the fields searches filter on
+type Point struct {
+	ID        int64 // Message ID
+	Vector    []float32
+	GuildID   int64
+	ChannelID int64
+}
+
+// Filter restricts a search; zero values don't restrict
+type Filter struct {
+	GuildID    int64
+	ChannelIDs []int64
+}
+
+// Match is a message found by a search, most similar first
+type Match struct {
+	ID         int64
+	Similarity float64 // Cosine similarity
+}
+
+// Store holds message embeddings outside Postgres
+type Store interface {
+	// Upsert writes points, replacing those with the same IDs
+	Upsert(ctx context.Context, points []Point) error
+	// Search returns up to limit messages at least minSimilarity similar to
+	// vector
+	Search(ctx context.Context, vector []float32, filter Filter, limit int, minSimilarity float64) ([]Match, error)
+	// Delete removes the points of messages; missing ones are ignored
+	Delete(ctx context.Context, ids []int64) error
+	// DeleteGuild removes every point of a guild
+	DeleteGuild(ctx context.Context, guildID int64) error
+}
+
+// Config selects and configures a backend
+type Config struct {
+	Backend string // "pgvector" or "qdrant"
+
+	QdrantURL        string
+	QdrantAPIKey     string
+	QdrantCollection string
+}
+
+// New creates the configured store, or returns nil for pgvector
+func New(cfg Config) (Store, error) {
+	switch cfg.Backend {
+	case "", BackendPgvector:
+		return nil, nil
+	case BackendQdrant:
+		return NewQdrantStore(cfg.QdrantURL, cfg.QdrantAPIKey, cfg.QdrantCollection)
+	default:
+		return nil, fmt.Errorf("unknown vector store %q", cfg.Backend)
+	}
+}
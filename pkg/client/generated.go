@@ -0,0 +1,89 @@
+// Code generated by cmd/openapi-client from internal/services/api/openapi.json; DO NOT EDIT.
+
+package client
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// AskRequest is a question to answer
+type AskRequest struct {
+	GuildID   string `json:"guild_id"`             // Server whose history and settings answer the question
+	ChannelID string `json:"channel_id,omitempty"` // Channel, which everyone in the server must be able to read, whose recent messages fill in when nothing matches
+	Question  string `json:"question"`
+	Username  string `json:"username,omitempty"` // Name the question is asked as
+}
+
+// AskResponse is the answer to a question
+type AskResponse struct {
+	Answer string `json:"answer"`
+}
+
+// Error is the body of failed requests
+type Error struct {
+	Error string `json:"error"`
+}
+
+// Message is a message found by a search
+type Message struct {
+	ID         string    `json:"id"`
+	ChannelID  string    `json:"channel_id"`
+	Channel    string    `json:"channel,omitempty"` // Channel name
+	Author     string    `json:"author"`
+	Content    string    `json:"content"`
+	Timestamp  time.Time `json:"timestamp"`
+	Similarity float64   `json:"similarity"` // How close the message is to the query, from 0 to 1
+	Reactions  int       `json:"reactions"`
+	URL        string    `json:"url"` // Jump link to the message
+}
+
+// SearchResponse is the result of a search
+type SearchResponse struct {
+	Results []Message `json:"results"` // Best match first
+}
+
+// Ask calls POST /api/ask, to answer a question as the bot would in a server
+//
+// Retrieves context from the channels everyone in the server can read and its documents, and answers with its persona and settings, like /ask.
+func (c *Client) Ask(ctx context.Context, body AskRequest) (*AskResponse, error) {
+	var out AskResponse
+	if err := c.do(ctx, "POST", "/api/ask", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SearchParams are the query parameters of Search
+type SearchParams struct {
+	GuildID string // Server to search (required)
+	Q       string // What to search for (required)
+	Order   string // relevance, which favors well-received messages, or endorsed, most reactions first
+	Limit   int    // Most messages returned
+}
+
+func (p SearchParams) values() url.Values {
+	v := url.Values{}
+	v.Set("guild_id", p.GuildID)
+	v.Set("q", p.Q)
+	if p.Order != "" {
+		v.Set("order", p.Order)
+	}
+	if p.Limit != 0 {
+		v.Set("limit", strconv.Itoa(p.Limit))
+	}
+	return v
+}
+
+// Search calls GET /api/search, to search a server's messages
+//
+// Finds messages about a query, like /search, in the channels everyone in the server can read.
+func (c *Client) Search(ctx context.Context, params SearchParams) (*SearchResponse, error) {
+	var out SearchResponse
+	if err := c.do(ctx, "GET", "/api/search", params.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}